@@ -81,8 +81,24 @@ func (app *App) InitRLM(ctx context.Context) error {
 }
 
 // createRLMClient creates the best available LLM client for RLM.
-// Tries OpenRouter first (intelligent routing), then Anthropic (single model).
+// Tries an explicitly configured OpenAI-compatible server first, then
+// OpenRouter (intelligent routing), then Anthropic (single model). With
+// none available, RECURSE_RLM_KEYLESS_FALLBACK=stub runs RLM on an offline
+// stub client.
 func (app *App) createRLMClient() (meta.LLMClient, string, error) {
+	// A local or self-hosted server (vLLM, Ollama, LM Studio)
+	if baseURL := os.Getenv("RECURSE_RLM_OPENAI_BASE_URL"); baseURL != "" {
+		client, err := meta.NewOpenAICompatibleClient(meta.OpenAICompatibleConfig{
+			BaseURL: baseURL,
+			Model:   os.Getenv("RECURSE_RLM_OPENAI_MODEL"),
+		})
+		if err == nil {
+			slog.Info("RLM using OpenAI-compatible server", "base_url", baseURL, "model", client.Model())
+			return client, "openai-compatible", nil
+		}
+		slog.Warn("Failed to create OpenAI-compatible client, trying OpenRouter", "error", err)
+	}
+
 	// Try OpenRouter first (enables intelligent multi-model routing)
 	if apiKey := os.Getenv("OPENROUTER_API_KEY"); apiKey != "" {
 		client, err := meta.NewOpenRouterClient(meta.OpenRouterConfig{
@@ -98,6 +114,11 @@ func (app *App) createRLMClient() (meta.LLMClient, string, error) {
 	// Fall back to Anthropic (single model - Haiku)
 	provider, err := app.findAnthropicProvider()
 	if err != nil {
+		// Offline development: answer with a stub rather than leave RLM off
+		if meta.KeylessFallback(os.Getenv("RECURSE_RLM_KEYLESS_FALLBACK")) == meta.KeylessStub {
+			slog.Warn("No LLM provider available; model routing disabled, RLM answering with stub client")
+			return meta.NewStubClient(meta.StubConfig{}), "stub", nil
+		}
 		return nil, "", fmt.Errorf("no LLM provider available: %w", err)
 	}
 
@@ -3,6 +3,7 @@ package learning
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"strings"
 	"time"
 )
@@ -31,6 +32,13 @@ type ApplierConfig struct {
 
 	// ContextMaxTokens limits total context additions.
 	ContextMaxTokens int
+
+	// MaxNegativeExamples limits correction guards injected per query.
+	MaxNegativeExamples int
+
+	// NegativeExampleSimilarity is the minimum similarity between a query
+	// and a corrected query for the correction's guard to be injected.
+	NegativeExampleSimilarity float64
 }
 
 // NewApplier creates a new knowledge applier.
@@ -51,6 +59,12 @@ func NewApplier(store *Store, cfg ApplierConfig) *Applier {
 	if cfg.ContextMaxTokens == 0 {
 		cfg.ContextMaxTokens = 2000
 	}
+	if cfg.MaxNegativeExamples == 0 {
+		cfg.MaxNegativeExamples = 3
+	}
+	if cfg.NegativeExampleSimilarity == 0 {
+		cfg.NegativeExampleSimilarity = DefaultNegativeExampleSimilarity
+	}
 
 	return &Applier{
 		store: store,
@@ -91,6 +105,13 @@ func (a *Applier) Apply(ctx context.Context, query string, domain string, projec
 	}
 	result.Constraints = constraints
 
+	// Get guards from past corrections of similar queries
+	negatives, err := a.getNegativeExamples(ctx, query, domain)
+	if err != nil {
+		return nil, fmt.Errorf("get negative examples: %w", err)
+	}
+	result.NegativeExamples = negatives
+
 	// Generate context additions
 	result.ContextAdditions = a.generateContextAdditions(result)
 
@@ -250,11 +271,44 @@ func (a *Applier) getConstraints(ctx context.Context, query string, domain strin
 	return relevant, nil
 }
 
+// getNegativeExamples retrieves guards for corrected queries similar to
+// query and records that they were injected.
+func (a *Applier) getNegativeExamples(ctx context.Context, query string, domain string) ([]*NegativeExample, error) {
+	examples, err := a.store.FindSimilarNegativeExamples(ctx, query, domain, a.cfg.NegativeExampleSimilarity, a.cfg.MaxNegativeExamples)
+	if err != nil {
+		return nil, err
+	}
+
+	// Injection counts are what make recurrences measurable, so update
+	// them synchronously rather than fire-and-forget. A failed update
+	// loses a count but not the guard.
+	for _, ex := range examples {
+		ex.Injections++
+		ex.LastInjected = time.Now()
+		if err := a.store.UpdateNegativeExample(ctx, ex); err != nil {
+			slog.Warn("Failed to record negative example injection", "id", ex.ID, "error", err)
+		}
+	}
+
+	return examples, nil
+}
+
 // generateContextAdditions creates text to add to LLM context.
 func (a *Applier) generateContextAdditions(result *ApplyResult) []string {
 	var additions []string
 	tokenCount := 0
 
+	// Add negative examples first - mistakes already corrected on similar tasks
+	for _, n := range result.NegativeExamples {
+		text := formatNegativeExample(n)
+		tokens := estimateTokens(text)
+		if tokenCount+tokens > a.cfg.ContextMaxTokens {
+			break
+		}
+		additions = append(additions, text)
+		tokenCount += tokens
+	}
+
 	// Add constraints (highest priority - things to avoid)
 	for _, c := range result.Constraints {
 		text := formatConstraint(c)
@@ -327,6 +381,10 @@ func (a *Applier) calculateTotalConfidence(result *ApplyResult) float64 {
 		sum += c.Severity
 		count++
 	}
+	for _, n := range result.NegativeExamples {
+		sum += n.Severity
+		count++
+	}
 
 	if count == 0 {
 		return 0
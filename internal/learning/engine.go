@@ -203,6 +203,21 @@ func (e *Engine) Stats(ctx context.Context) (*EngineStats, error) {
 		return nil, fmt.Errorf("consolidator stats: %w", err)
 	}
 
+	negatives, err := e.store.ListNegativeExamples(ctx, "")
+	if err != nil {
+		return nil, fmt.Errorf("list negative examples: %w", err)
+	}
+	guards := GuardStats{Total: len(negatives)}
+	for _, n := range negatives {
+		if n.Injections > 0 {
+			guards.Injected++
+		}
+		if n.Prevented() {
+			guards.Prevented++
+		}
+		guards.Recurrences += n.Recurrences
+	}
+
 	return &EngineStats{
 		TotalKnowledge:   storeStats.TotalNodes,
 		Facts:            storeStats.FactCount,
@@ -210,6 +225,7 @@ func (e *Engine) Stats(ctx context.Context) (*EngineStats, error) {
 		Preferences:      storeStats.PreferenceCount,
 		Constraints:      storeStats.ConstraintCount,
 		Signals:          storeStats.SignalCount,
+		Guards:           guards,
 		ConsolidatorInfo: consolidatorStats,
 	}, nil
 }
@@ -222,9 +238,26 @@ type EngineStats struct {
 	Preferences      int                  `json:"preferences"`
 	Constraints      int                  `json:"constraints"`
 	Signals          int                  `json:"signals"`
+	Guards           GuardStats           `json:"guards"`
 	ConsolidatorInfo *ConsolidationStats  `json:"consolidator"`
 }
 
+// GuardStats summarizes how well correction-derived negative examples
+// prevent repeated mistakes.
+type GuardStats struct {
+	// Total is the number of negative examples recorded.
+	Total int `json:"total"`
+
+	// Injected is the number of guards added to at least one prompt.
+	Injected int `json:"injected"`
+
+	// Prevented is the number of injected guards whose mistake never recurred.
+	Prevented int `json:"prevented"`
+
+	// Recurrences is the number of corrections that repeated despite a guard.
+	Recurrences int `json:"recurrences"`
+}
+
 // Consolidate triggers a manual consolidation pass.
 func (e *Engine) Consolidate(ctx context.Context) error {
 	return e.consolidator.ConsolidateAll(ctx)
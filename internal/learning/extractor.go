@@ -140,7 +140,49 @@ func (e *Extractor) extractFromCorrection(ctx context.Context, signal *LearningS
 		}
 	}
 
-	return e.store.StoreConstraint(ctx, constraint)
+	if err := e.store.StoreConstraint(ctx, constraint); err != nil {
+		return err
+	}
+
+	return e.recordNegativeExample(ctx, signal, details)
+}
+
+// recordNegativeExample turns a correction into a guard for similar queries.
+// If a guard already exists for a similar query and had been injected, the
+// correction counts as a recurrence the guard failed to prevent.
+func (e *Extractor) recordNegativeExample(ctx context.Context, signal *LearningSignal, details *CorrectionDetails) error {
+	if signal.Context.Query == "" {
+		return nil
+	}
+
+	similar, err := e.store.FindSimilarNegativeExamples(ctx, signal.Context.Query, signal.Domain, DefaultNegativeExampleSimilarity, 1)
+	if err != nil {
+		return fmt.Errorf("find negative examples: %w", err)
+	}
+	if len(similar) > 0 {
+		existing := similar[0]
+		if existing.Injections > 0 {
+			existing.Recurrences++
+		}
+		existing.Avoid = truncate(details.OriginalOutput, 500)
+		existing.Correction = truncate(details.CorrectedOutput, 500)
+		if details.Explanation != "" {
+			existing.Reason = details.Explanation
+		}
+		if details.Severity > existing.Severity {
+			existing.Severity = details.Severity
+		}
+		return e.store.UpdateNegativeExample(ctx, existing)
+	}
+
+	return e.store.StoreNegativeExample(ctx, &NegativeExample{
+		Query:      signal.Context.Query,
+		Avoid:      truncate(details.OriginalOutput, 500),
+		Reason:     details.Explanation,
+		Correction: truncate(details.CorrectedOutput, 500),
+		Domain:     signal.Domain,
+		Severity:   details.Severity,
+	})
 }
 
 // extractFromRejection processes a rejection signal.
@@ -282,6 +282,9 @@ type ApplyResult struct {
 	// Constraints are constraints to enforce.
 	Constraints []*LearnedConstraint `json:"constraints,omitempty"`
 
+	// NegativeExamples are guards from corrections of similar queries.
+	NegativeExamples []*NegativeExample `json:"negative_examples,omitempty"`
+
 	// ContextAdditions are text additions to enhance the prompt.
 	ContextAdditions []string `json:"context_additions,omitempty"`
 
@@ -297,7 +300,8 @@ func (r *ApplyResult) IsEmpty() bool {
 	return len(r.RelevantFacts) == 0 &&
 		len(r.ApplicablePatterns) == 0 &&
 		len(r.Preferences) == 0 &&
-		len(r.Constraints) == 0
+		len(r.Constraints) == 0 &&
+		len(r.NegativeExamples) == 0
 }
 
 // ItemCount returns the total number of knowledge items.
@@ -305,5 +309,6 @@ func (r *ApplyResult) ItemCount() int {
 	return len(r.RelevantFacts) +
 		len(r.ApplicablePatterns) +
 		len(r.Preferences) +
-		len(r.Constraints)
+		len(r.Constraints) +
+		len(r.NegativeExamples)
 }
@@ -651,3 +651,69 @@ func TestConsolidator_ProcessConstraint(t *testing.T) {
 	processed = consolidator.processConstraint(ctx, explicitConstraint)
 	assert.False(t, processed, "explicit constraint should not be processed")
 }
+
+func TestEngine_CorrectionInjectsNegativeExample(t *testing.T) {
+	_, graph := newTestStore(t)
+	engine := NewEngine(graph, EngineConfig{})
+	ctx := context.Background()
+
+	// Severity 0.4 keeps the correction signal above the default confidence floor
+	err := engine.LearnCorrection(ctx, "sess-1", "task-1",
+		"Handle the database connection error in the user loader",
+		"panic(err)", "return fmt.Errorf(\"load user: %w\", err)",
+		"code", "panicking crashes the server on transient failures", "go", 0.4)
+	require.NoError(t, err)
+
+	enhanced, err := engine.EnhancePrompt(ctx, "Handle the connection error in the order loader", "go", "")
+	require.NoError(t, err)
+	assert.Contains(t, enhanced, "[NEGATIVE EXAMPLE]")
+	assert.Contains(t, enhanced, `Avoid doing "panic(err)"`)
+	assert.Contains(t, enhanced, "panicking crashes the server on transient failures")
+
+	// An unrelated query does not get the guard
+	unrelated, err := engine.EnhancePrompt(ctx, "Render the settings page template", "go", "")
+	require.NoError(t, err)
+	assert.NotContains(t, unrelated, "[NEGATIVE EXAMPLE]")
+
+	stats, err := engine.Stats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, GuardStats{Total: 1, Injected: 1, Prevented: 1}, stats.Guards)
+}
+
+func TestEngine_NegativeExampleTracksRecurrence(t *testing.T) {
+	store, graph := newTestStore(t)
+	engine := NewEngine(graph, EngineConfig{})
+	ctx := context.Background()
+
+	query := "Parse the config file and validate ports"
+	require.NoError(t, engine.LearnCorrection(ctx, "sess-1", "task-1", query,
+		"strconv.Atoi(port)", "validate range 1-65535", "code", "ports must be range-checked", "go", 0.4))
+
+	// A similar correction before the guard was ever shown is not a recurrence
+	require.NoError(t, engine.LearnCorrection(ctx, "sess-1", "task-2", query,
+		"strconv.Atoi(port)", "validate range 1-65535", "code", "", "go", 0.4))
+	examples, err := store.ListNegativeExamples(ctx, "go")
+	require.NoError(t, err)
+	require.Len(t, examples, 1)
+	assert.Equal(t, 0, examples[0].Recurrences)
+
+	_, err = engine.EnhancePrompt(ctx, "Parse the config file and validate the ports", "go", "")
+	require.NoError(t, err)
+
+	// The same mistake after injection counts against the guard
+	require.NoError(t, engine.LearnCorrection(ctx, "sess-1", "task-3", query,
+		"strconv.Atoi(port)", "validate range 1-65535", "code", "", "go", 0.4))
+	examples, err = store.ListNegativeExamples(ctx, "go")
+	require.NoError(t, err)
+	require.Len(t, examples, 1)
+	assert.Equal(t, 1, examples[0].Injections)
+	assert.Equal(t, 1, examples[0].Recurrences)
+	assert.False(t, examples[0].Prevented())
+	assert.Equal(t, "ports must be range-checked", examples[0].Reason, "explanation is kept when a repeat has none")
+}
+
+func TestNegativeExample_PreventionRate(t *testing.T) {
+	assert.Equal(t, 0.0, (&NegativeExample{}).PreventionRate())
+	assert.Equal(t, 0.75, (&NegativeExample{Injections: 4, Recurrences: 1}).PreventionRate())
+	assert.Equal(t, 0.0, (&NegativeExample{Injections: 1, Recurrences: 3}).PreventionRate())
+}
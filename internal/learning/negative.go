@@ -0,0 +1,247 @@
+package learning
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+	"github.com/rand/recurse/internal/memory/hypergraph"
+)
+
+// SubtypeNegativeExample is the node subtype for correction-derived guards.
+const SubtypeNegativeExample = "negative_example"
+
+// DefaultNegativeExampleSimilarity is the keyword overlap above which a
+// query is considered a recurrence of a corrected one.
+const DefaultNegativeExampleSimilarity = 0.3
+
+// NegativeExample is a guard created from a user correction. When a query
+// similar to the corrected one recurs, the guard is injected into the prompt
+// so the same mistake is not repeated.
+type NegativeExample struct {
+	// ID is a unique identifier for the negative example.
+	ID string `json:"id"`
+
+	// Query is the query that produced the corrected output.
+	Query string `json:"query"`
+
+	// Avoid is the output that was wrong.
+	Avoid string `json:"avoid"`
+
+	// Reason explains why the output was wrong.
+	Reason string `json:"reason,omitempty"`
+
+	// Correction is what the user corrected the output to.
+	Correction string `json:"correction,omitempty"`
+
+	// Domain categorizes the negative example.
+	Domain string `json:"domain,omitempty"`
+
+	// Severity is the severity of the original correction (0.0-1.0).
+	Severity float64 `json:"severity"`
+
+	// Injections counts how many times the guard was added to a prompt.
+	Injections int `json:"injections"`
+
+	// Recurrences counts corrections of a similar query that arrived after
+	// the guard had been injected, i.e. times the guard failed.
+	Recurrences int `json:"recurrences"`
+
+	// CreatedAt is when the correction was recorded.
+	CreatedAt time.Time `json:"created_at"`
+
+	// LastInjected is when the guard was last added to a prompt.
+	LastInjected time.Time `json:"last_injected"`
+}
+
+// Prevented reports whether the guard has been used and the corrected
+// mistake has not recurred since.
+func (n *NegativeExample) Prevented() bool {
+	return n.Injections > 0 && n.Recurrences == 0
+}
+
+// PreventionRate returns the fraction of injections not followed by a
+// repeat correction, or zero if the guard was never injected.
+func (n *NegativeExample) PreventionRate() float64 {
+	if n.Injections == 0 {
+		return 0
+	}
+	rate := 1 - float64(n.Recurrences)/float64(n.Injections)
+	if rate < 0 {
+		return 0
+	}
+	return rate
+}
+
+// StoreNegativeExample persists a negative example.
+func (s *Store) StoreNegativeExample(ctx context.Context, example *NegativeExample) error {
+	if example.ID == "" {
+		example.ID = uuid.New().String()
+	}
+	if example.CreatedAt.IsZero() {
+		example.CreatedAt = time.Now()
+	}
+
+	metadata, err := json.Marshal(example)
+	if err != nil {
+		return fmt.Errorf("marshal negative example: %w", err)
+	}
+
+	node := &hypergraph.Node{
+		ID:         example.ID,
+		Type:       hypergraph.NodeTypeExperience,
+		Subtype:    SubtypeNegativeExample,
+		Content:    example.Query,
+		Tier:       hypergraph.TierLongterm,
+		Confidence: example.Severity,
+		Metadata:   metadata,
+		CreatedAt:  example.CreatedAt,
+		UpdatedAt:  time.Now(),
+	}
+
+	return s.graph.CreateNode(ctx, node)
+}
+
+// ListNegativeExamples lists negative examples, optionally filtered by domain.
+func (s *Store) ListNegativeExamples(ctx context.Context, domain string) ([]*NegativeExample, error) {
+	nodes, err := s.graph.ListNodes(ctx, hypergraph.NodeFilter{
+		Types:    []hypergraph.NodeType{hypergraph.NodeTypeExperience},
+		Subtypes: []string{SubtypeNegativeExample},
+		Limit:    1000,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	examples := make([]*NegativeExample, 0, len(nodes))
+	for _, node := range nodes {
+		example, err := nodeToNegativeExample(node)
+		if err != nil {
+			continue
+		}
+		if domain != "" && example.Domain != "" && example.Domain != domain {
+			continue
+		}
+		examples = append(examples, example)
+	}
+	return examples, nil
+}
+
+// UpdateNegativeExample updates an existing negative example.
+func (s *Store) UpdateNegativeExample(ctx context.Context, example *NegativeExample) error {
+	node, err := s.graph.GetNode(ctx, example.ID)
+	if err != nil {
+		return fmt.Errorf("get negative example for update: %w", err)
+	}
+	if node == nil {
+		return fmt.Errorf("negative example not found: %s", example.ID)
+	}
+
+	metadata, err := json.Marshal(example)
+	if err != nil {
+		return fmt.Errorf("marshal negative example: %w", err)
+	}
+
+	node.Content = example.Query
+	node.Confidence = example.Severity
+	node.Metadata = metadata
+	node.UpdatedAt = time.Now()
+
+	return s.graph.UpdateNode(ctx, node)
+}
+
+// FindSimilarNegativeExamples returns negative examples whose corrected query
+// is at least minSimilarity similar to query, most similar first.
+func (s *Store) FindSimilarNegativeExamples(ctx context.Context, query, domain string, minSimilarity float64, limit int) ([]*NegativeExample, error) {
+	examples, err := s.ListNegativeExamples(ctx, domain)
+	if err != nil {
+		return nil, err
+	}
+
+	type scored struct {
+		example *NegativeExample
+		score   float64
+	}
+	var matches []scored
+	for _, ex := range examples {
+		if score := querySimilarity(query, ex.Query); score >= minSimilarity {
+			matches = append(matches, scored{ex, score})
+		}
+	}
+
+	slices.SortFunc(matches, func(a, b scored) int {
+		return cmp.Compare(b.score, a.score)
+	})
+
+	result := make([]*NegativeExample, 0, len(matches))
+	for _, m := range matches {
+		if limit > 0 && len(result) >= limit {
+			break
+		}
+		result = append(result, m.example)
+	}
+	return result, nil
+}
+
+func nodeToNegativeExample(node *hypergraph.Node) (*NegativeExample, error) {
+	example := &NegativeExample{}
+	if len(node.Metadata) > 0 {
+		if err := json.Unmarshal(node.Metadata, example); err != nil {
+			return nil, fmt.Errorf("unmarshal negative example: %w", err)
+		}
+	}
+	example.ID = node.ID
+	example.Query = node.Content
+	if example.CreatedAt.IsZero() {
+		example.CreatedAt = node.CreatedAt
+	}
+	return example, nil
+}
+
+// querySimilarity returns the Jaccard overlap of the keywords in a and b.
+func querySimilarity(a, b string) float64 {
+	ka, kb := queryKeywords(a), queryKeywords(b)
+	if len(ka) == 0 && len(kb) == 0 {
+		return 0
+	}
+	shared := 0
+	for w := range ka {
+		if kb[w] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(ka)+len(kb)-shared)
+}
+
+// queryKeywords returns the distinct lowercase words of at least three
+// characters in s.
+func queryKeywords(s string) map[string]bool {
+	words := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	})
+	keywords := make(map[string]bool, len(words))
+	for _, w := range words {
+		if len(w) >= 3 {
+			keywords[w] = true
+		}
+	}
+	return keywords
+}
+
+func formatNegativeExample(n *NegativeExample) string {
+	reason := n.Reason
+	if reason == "" && n.Correction != "" {
+		reason = fmt.Sprintf("it was corrected to %q", truncate(n.Correction, 200))
+	}
+	if reason == "" {
+		reason = "the user corrected it"
+	}
+	return fmt.Sprintf("[NEGATIVE EXAMPLE] Avoid doing %q; last time it was wrong because %s",
+		truncate(n.Avoid, 200), reason)
+}
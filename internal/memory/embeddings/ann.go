@@ -0,0 +1,354 @@
+package embeddings
+
+import (
+	"container/heap"
+	"math"
+	"math/rand"
+	"sort"
+	"sync"
+)
+
+// ANNConfig configures the optional in-memory approximate nearest-neighbour
+// index (HNSW) used by SearchByVector. Larger M, EfConstruction and
+// EfSearch raise recall at the cost of memory, build time and search time.
+type ANNConfig struct {
+	// Enabled turns on the ANN index. When false, search is brute force.
+	Enabled bool
+
+	// M is the number of neighbours kept per node on each upper layer;
+	// layer 0 keeps 2*M (default: 16).
+	M int
+
+	// EfConstruction is the candidate list size while inserting
+	// (default: 200).
+	EfConstruction int
+
+	// EfSearch is the candidate list size while searching; it is raised to
+	// the requested limit when smaller (default: 64).
+	EfSearch int
+
+	// MinNodes is the store size below which brute force is used, since a
+	// linear scan is exact and fast enough for small stores (default: 1000).
+	MinNodes int
+}
+
+func (c ANNConfig) withDefaults() ANNConfig {
+	if c.M <= 0 {
+		c.M = 16
+	}
+	if c.EfConstruction <= 0 {
+		c.EfConstruction = 200
+	}
+	if c.EfSearch <= 0 {
+		c.EfSearch = 64
+	}
+	if c.MinNodes <= 0 {
+		c.MinNodes = 1000
+	}
+	return c
+}
+
+// hnsw is a hierarchical navigable small world graph over unit vectors,
+// using cosine distance. Updates and deletes tombstone the old entry, which
+// stays traversable so the graph remains connected.
+type hnsw struct {
+	mu sync.RWMutex
+
+	cfg   ANNConfig
+	mL    float64
+	rng   *rand.Rand
+	nodes []*hnswNode
+	ids   map[string]int // live node ID -> index into nodes
+	entry int
+	top   int // level of the entry point
+}
+
+type hnswNode struct {
+	id      string
+	vec     Vector // normalized
+	deleted bool
+	links   [][]int // per-layer neighbour indices
+}
+
+func newHNSW(cfg ANNConfig) *hnsw {
+	cfg = cfg.withDefaults()
+	return &hnsw{
+		cfg:   cfg,
+		mL:    1 / math.Log(float64(cfg.M)),
+		rng:   rand.New(rand.NewSource(1)),
+		ids:   make(map[string]int),
+		entry: -1,
+	}
+}
+
+// Len returns the number of live vectors.
+func (h *hnsw) Len() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.ids)
+}
+
+// Insert adds or replaces the vector for id.
+func (h *hnsw) Insert(id string, vec Vector) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if old, ok := h.ids[id]; ok {
+		h.nodes[old].deleted = true
+		delete(h.ids, id)
+	}
+	h.insert(id, vec)
+	h.compactIfSparse()
+}
+
+// insert adds vec to the graph; the caller holds the write lock.
+func (h *hnsw) insert(id string, vec Vector) {
+	level := int(math.Floor(-math.Log(1-h.rng.Float64()) * h.mL))
+	n := &hnswNode{id: id, vec: vec.Normalize(), links: make([][]int, level+1)}
+	idx := len(h.nodes)
+	h.nodes = append(h.nodes, n)
+	h.ids[id] = idx
+
+	if h.entry < 0 {
+		h.entry, h.top = idx, level
+		return
+	}
+
+	ep := h.entry
+	for l := h.top; l > level; l-- {
+		ep = h.greedy(n.vec, ep, l)
+	}
+	for l := min(level, h.top); l >= 0; l-- {
+		candidates := h.searchLayer(n.vec, ep, h.cfg.EfConstruction, l)
+		neighbours := h.selectNeighbours(candidates, h.maxLinks(l))
+		n.links[l] = neighbours
+		for _, nb := range neighbours {
+			h.link(nb, idx, l)
+		}
+		ep = candidates[0].node
+	}
+
+	if level > h.top {
+		h.entry, h.top = idx, level
+	}
+}
+
+// Delete tombstones the vector for id.
+func (h *hnsw) Delete(id string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if i, ok := h.ids[id]; ok {
+		h.nodes[i].deleted = true
+		delete(h.ids, id)
+	}
+	h.compactIfSparse()
+}
+
+// compactIfSparse rebuilds the graph from live vectors once tombstones
+// outnumber them, bounding the extra search width they cost.
+func (h *hnsw) compactIfSparse() {
+	if len(h.nodes)-len(h.ids) <= len(h.ids) {
+		return
+	}
+	old := h.nodes
+	h.nodes = nil
+	h.ids = make(map[string]int, len(h.ids))
+	h.entry, h.top = -1, 0
+	for _, n := range old {
+		if !n.deleted {
+			h.insert(n.id, n.vec)
+		}
+	}
+}
+
+// Search returns up to k live vectors closest to query, most similar first.
+func (h *hnsw) Search(query Vector, k int) []SearchResult {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	if h.entry < 0 || k <= 0 {
+		return nil
+	}
+	q := query.Normalize()
+
+	ep := h.entry
+	for l := h.top; l > 0; l-- {
+		ep = h.greedy(q, ep, l)
+	}
+
+	// Widen the beam by the tombstone count so deletions don't cost recall
+	ef := max(h.cfg.EfSearch, k) + len(h.nodes) - len(h.ids)
+	candidates := h.searchLayer(q, ep, ef, 0)
+
+	results := make([]SearchResult, 0, k)
+	for _, c := range candidates {
+		n := h.nodes[c.node]
+		if n.deleted {
+			continue
+		}
+		results = append(results, SearchResult{NodeID: n.id, Similarity: 1 - c.dist})
+		if len(results) == k {
+			break
+		}
+	}
+	return results
+}
+
+func (h *hnsw) maxLinks(level int) int {
+	if level == 0 {
+		return 2 * h.cfg.M
+	}
+	return h.cfg.M
+}
+
+// link adds to as a neighbour of from on level l, re-selecting neighbours
+// when the list overflows.
+func (h *hnsw) link(from, to, l int) {
+	n := h.nodes[from]
+	n.links[l] = append(n.links[l], to)
+	limit := h.maxLinks(l)
+	if len(n.links[l]) <= limit {
+		return
+	}
+	scored := make([]hnswCandidate, len(n.links[l]))
+	for i, nb := range n.links[l] {
+		scored[i] = hnswCandidate{node: nb, dist: cosineDistance(n.vec, h.nodes[nb].vec)}
+	}
+	sort.Slice(scored, func(i, j int) bool { return scored[i].dist < scored[j].dist })
+	n.links[l] = h.selectNeighbours(scored, limit)
+}
+
+// selectNeighbours picks up to n of the sorted candidates, preferring ones
+// closer to the base node than to any already selected. This keeps links
+// pointing in diverse directions, so outlying nodes stay reachable; the
+// remaining slots are filled with the closest skipped candidates.
+func (h *hnsw) selectNeighbours(sorted []hnswCandidate, n int) []int {
+	if len(sorted) <= n {
+		return closest(sorted, n)
+	}
+	selected := make([]int, 0, n)
+	var skipped []int
+	for _, c := range sorted {
+		if len(selected) == n {
+			break
+		}
+		diverse := true
+		for _, s := range selected {
+			if cosineDistance(h.nodes[c.node].vec, h.nodes[s].vec) < c.dist {
+				diverse = false
+				break
+			}
+		}
+		if diverse {
+			selected = append(selected, c.node)
+		} else {
+			skipped = append(skipped, c.node)
+		}
+	}
+	for _, s := range skipped {
+		if len(selected) == n {
+			break
+		}
+		selected = append(selected, s)
+	}
+	return selected
+}
+
+// greedy walks level l from ep towards q and returns the closest node found.
+func (h *hnsw) greedy(q Vector, ep, l int) int {
+	best := cosineDistance(q, h.nodes[ep].vec)
+	for changed := true; changed; {
+		changed = false
+		for _, nb := range h.nodes[ep].links[l] {
+			if d := cosineDistance(q, h.nodes[nb].vec); d < best {
+				best, ep, changed = d, nb, true
+			}
+		}
+	}
+	return ep
+}
+
+// searchLayer runs a best-first beam search of width ef on level l and
+// returns the candidates found, closest first.
+func (h *hnsw) searchLayer(q Vector, ep, ef, l int) []hnswCandidate {
+	visited := map[int]bool{ep: true}
+	start := hnswCandidate{node: ep, dist: cosineDistance(q, h.nodes[ep].vec)}
+	frontier := &candidateHeap{list: []hnswCandidate{start}}
+	found := &candidateHeap{list: []hnswCandidate{start}, farthestFirst: true}
+
+	for frontier.Len() > 0 {
+		c := heap.Pop(frontier).(hnswCandidate)
+		if found.Len() >= ef && c.dist > found.peek().dist {
+			break
+		}
+		for _, nb := range h.nodes[c.node].links[l] {
+			if visited[nb] {
+				continue
+			}
+			visited[nb] = true
+			d := cosineDistance(q, h.nodes[nb].vec)
+			if found.Len() < ef || d < found.peek().dist {
+				heap.Push(frontier, hnswCandidate{node: nb, dist: d})
+				heap.Push(found, hnswCandidate{node: nb, dist: d})
+				if found.Len() > ef {
+					heap.Pop(found)
+				}
+			}
+		}
+	}
+
+	result := append([]hnswCandidate(nil), found.items()...)
+	sort.Slice(result, func(i, j int) bool { return result[i].dist < result[j].dist })
+	return result
+}
+
+func closest(sorted []hnswCandidate, n int) []int {
+	if len(sorted) > n {
+		sorted = sorted[:n]
+	}
+	ids := make([]int, len(sorted))
+	for i, c := range sorted {
+		ids[i] = c.node
+	}
+	return ids
+}
+
+// cosineDistance returns 1 - cos(a, b) for unit vectors a and b.
+func cosineDistance(a, b Vector) float32 {
+	if len(a) != len(b) {
+		return 2
+	}
+	var dot float32
+	for i := range a {
+		dot += a[i] * b[i]
+	}
+	return 1 - dot
+}
+
+type hnswCandidate struct {
+	node int
+	dist float32
+}
+
+// candidateHeap is a min-heap by distance, or a max-heap when farthestFirst.
+type candidateHeap struct {
+	list          []hnswCandidate
+	farthestFirst bool
+}
+
+func (c *candidateHeap) Len() int { return len(c.list) }
+func (c *candidateHeap) Less(i, j int) bool {
+	if c.farthestFirst {
+		return c.list[i].dist > c.list[j].dist
+	}
+	return c.list[i].dist < c.list[j].dist
+}
+func (c *candidateHeap) Swap(i, j int)          { c.list[i], c.list[j] = c.list[j], c.list[i] }
+func (c *candidateHeap) Push(x any)             { c.list = append(c.list, x.(hnswCandidate)) }
+func (c *candidateHeap) peek() hnswCandidate    { return c.list[0] }
+func (c *candidateHeap) items() []hnswCandidate { return c.list }
+func (c *candidateHeap) Pop() any {
+	last := c.list[len(c.list)-1]
+	c.list = c.list[:len(c.list)-1]
+	return last
+}
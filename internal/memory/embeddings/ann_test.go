@@ -0,0 +1,198 @@
+package embeddings
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// clusteredVectors returns n unit vectors drawn around a handful of random
+// centroids, which is closer to real embedding distributions than uniform
+// noise.
+func clusteredVectors(rng *rand.Rand, n, dims, clusters int) []Vector {
+	centroids := make([]Vector, clusters)
+	for i := range centroids {
+		centroids[i] = randomUnitVector(rng, dims)
+	}
+	vecs := make([]Vector, n)
+	for i := range vecs {
+		c := centroids[rng.Intn(clusters)]
+		v := make(Vector, dims)
+		for d := range v {
+			v[d] = c[d] + float32(rng.NormFloat64()*0.15)
+		}
+		vecs[i] = v.Normalize()
+	}
+	return vecs
+}
+
+func bruteForceTopK(vecs []Vector, query Vector, k int) []string {
+	type scored struct {
+		id  string
+		sim float32
+	}
+	all := make([]scored, len(vecs))
+	for i, v := range vecs {
+		all[i] = scored{fmt.Sprintf("node-%d", i), query.Similarity(v)}
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].sim > all[j].sim })
+	ids := make([]string, k)
+	for i := range ids {
+		ids[i] = all[i].id
+	}
+	return ids
+}
+
+func recallAtK(truth []string, got []SearchResult) float64 {
+	want := make(map[string]bool, len(truth))
+	for _, id := range truth {
+		want[id] = true
+	}
+	hits := 0
+	for _, r := range got {
+		if want[r.NodeID] {
+			hits++
+		}
+	}
+	return float64(hits) / float64(len(truth))
+}
+
+func TestHNSW_RecallMatchesBruteForce(t *testing.T) {
+	const (
+		n, dims, k   = 3000, 32, 10
+		queries      = 100
+		targetRecall = 0.95
+	)
+	rng := rand.New(rand.NewSource(7))
+	vecs := clusteredVectors(rng, n, dims, 20)
+
+	ann := newHNSW(ANNConfig{Enabled: true})
+	for i, v := range vecs {
+		ann.Insert(fmt.Sprintf("node-%d", i), v)
+	}
+	require.Equal(t, n, ann.Len())
+
+	var total float64
+	for q := 0; q < queries; q++ {
+		query := clusteredVectors(rng, 1, dims, 20)[0]
+		got := ann.Search(query, k)
+		require.Len(t, got, k)
+		for i := 1; i < len(got); i++ {
+			assert.GreaterOrEqual(t, got[i-1].Similarity, got[i].Similarity, "results sorted by similarity")
+		}
+		total += recallAtK(bruteForceTopK(vecs, query, k), got)
+	}
+
+	recall := total / queries
+	t.Logf("recall@%d = %.3f", k, recall)
+	assert.GreaterOrEqual(t, recall, targetRecall)
+}
+
+func TestHNSW_UpdateAndDelete(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	ann := newHNSW(ANNConfig{Enabled: true, M: 8})
+	for i, v := range clusteredVectors(rng, 200, 16, 4) {
+		ann.Insert(fmt.Sprintf("node-%d", i), v)
+	}
+
+	target := randomUnitVector(rng, 16)
+	ann.Insert("node-5", target)
+	assert.Equal(t, 200, ann.Len(), "re-inserting replaces rather than adds")
+
+	got := ann.Search(target, 1)
+	require.Len(t, got, 1)
+	assert.Equal(t, "node-5", got[0].NodeID)
+	assert.InDelta(t, 1.0, got[0].Similarity, 1e-5)
+
+	ann.Delete("node-5")
+	assert.Equal(t, 199, ann.Len())
+	for _, r := range ann.Search(target, 20) {
+		assert.NotEqual(t, "node-5", r.NodeID)
+	}
+
+	// Deleting most of the graph triggers a rebuild that keeps it searchable
+	for i := 0; i < 150; i++ {
+		ann.Delete(fmt.Sprintf("node-%d", i))
+	}
+	assert.Equal(t, 50, ann.Len())
+	assert.Len(t, ann.Search(target, 100), 50)
+}
+
+func TestIndex_ANNSearch(t *testing.T) {
+	const n, dims, k = 600, 16, 5
+	db := newFileTestDB(t)
+	mock := newMockProvider()
+	mock.dimensions = dims
+
+	annIdx, err := NewIndex(db, IndexConfig{
+		Provider: mock,
+		Workers:  1,
+		ANN:      ANNConfig{Enabled: true, MinNodes: 100},
+	})
+	require.NoError(t, err)
+	defer annIdx.Close()
+	exact, err := NewIndex(db, IndexConfig{Provider: mock, Workers: 1})
+	require.NoError(t, err)
+	defer exact.Close()
+
+	rng := rand.New(rand.NewSource(11))
+	vecs := clusteredVectors(rng, n, dims, 8)
+	for i, v := range vecs {
+		require.NoError(t, annIdx.storeVector(fmt.Sprintf("node-%d", i), v))
+	}
+
+	ctx := context.Background()
+	query := vecs[42]
+	got, err := annIdx.SearchByVector(ctx, query, k)
+	require.NoError(t, err)
+	want, err := exact.SearchByVector(ctx, query, k)
+	require.NoError(t, err)
+	require.NotNil(t, annIdx.ann, "ANN index built on first search")
+
+	wantIDs := make([]string, len(want))
+	for i, r := range want {
+		wantIDs[i] = r.NodeID
+	}
+	assert.GreaterOrEqual(t, recallAtK(wantIDs, got), 0.8)
+	assert.Equal(t, "node-42", got[0].NodeID)
+
+	// Writes after the build are applied incrementally
+	fresh := randomUnitVector(rng, dims)
+	require.NoError(t, annIdx.storeVector("fresh", fresh))
+	got, err = annIdx.SearchByVector(ctx, fresh, 1)
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	assert.Equal(t, "fresh", got[0].NodeID)
+
+	require.NoError(t, annIdx.Delete(ctx, "fresh"))
+	got, err = annIdx.SearchByVector(ctx, fresh, 1)
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	assert.NotEqual(t, "fresh", got[0].NodeID)
+}
+
+func TestIndex_ANNFallsBackBelowMinNodes(t *testing.T) {
+	db := newFileTestDB(t)
+	mock := newMockProvider()
+	idx, err := NewIndex(db, IndexConfig{
+		Provider: mock,
+		Workers:  1,
+		ANN:      ANNConfig{Enabled: true, MinNodes: 100},
+	})
+	require.NoError(t, err)
+	defer idx.Close()
+
+	require.NoError(t, idx.storeVector("a", Vector{1, 0, 0}))
+	require.NoError(t, idx.storeVector("b", Vector{0, 1, 0}))
+
+	got, err := idx.SearchByVector(context.Background(), Vector{1, 0.1, 0}, 2)
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+	assert.Equal(t, "a", got[0].NodeID)
+	assert.Equal(t, 2, idx.ann.Len())
+}
@@ -13,15 +13,21 @@ import (
 
 // Index manages embedding storage and search.
 type Index struct {
-	db         *sql.DB
-	provider   Provider
-	batchSize  int
-	background chan indexRequest
-	done       chan struct{}
-	wg         sync.WaitGroup
-	mu         sync.RWMutex
-	logger     *slog.Logger
-	metrics    *EmbeddingMetrics
+	db           *sql.DB
+	provider     Provider
+	batchSize    int
+	quantization Quantization
+	background   chan indexRequest
+	done         chan struct{}
+	wg           sync.WaitGroup
+	mu           sync.RWMutex
+	logger       *slog.Logger
+	metrics      *EmbeddingMetrics
+
+	// ANN index, built from the table on first search and kept current
+	// on writes after that. Guarded by mu.
+	annCfg ANNConfig
+	ann    *hnsw
 }
 
 // IndexConfig configures the embedding index.
@@ -32,6 +38,15 @@ type IndexConfig struct {
 	QueueSize int      // Background queue depth (default: 1000)
 	Logger    *slog.Logger
 	Metrics   *EmbeddingMetrics // Optional: metrics collector
+
+	// Quantization is the scheme new embeddings are stored with
+	// (default: float32). Existing embeddings keep their scheme until
+	// Compact re-encodes them.
+	Quantization Quantization
+
+	// ANN enables an in-memory approximate nearest-neighbour index for
+	// SearchByVector on large stores. Disabled by default.
+	ANN ANNConfig
 }
 
 type indexRequest struct {
@@ -57,15 +72,20 @@ func NewIndex(db *sql.DB, cfg IndexConfig) (*Index, error) {
 	if cfg.Logger == nil {
 		cfg.Logger = slog.Default()
 	}
+	if !cfg.Quantization.Valid() {
+		return nil, fmt.Errorf("unknown quantization %q", cfg.Quantization)
+	}
 
 	idx := &Index{
-		db:         db,
-		provider:   cfg.Provider,
-		batchSize:  cfg.BatchSize,
-		background: make(chan indexRequest, cfg.QueueSize),
-		done:       make(chan struct{}),
-		logger:     cfg.Logger,
-		metrics:    cfg.Metrics,
+		db:           db,
+		provider:     cfg.Provider,
+		batchSize:    cfg.BatchSize,
+		quantization: cfg.Quantization.orDefault(),
+		background:   make(chan indexRequest, cfg.QueueSize),
+		done:         make(chan struct{}),
+		logger:       cfg.Logger,
+		metrics:      cfg.Metrics,
+		annCfg:       cfg.ANN.withDefaults(),
 	}
 
 	// Initialize schema
@@ -91,6 +111,7 @@ func (idx *Index) initSchema() error {
 			embedding BLOB NOT NULL,
 			model TEXT NOT NULL,
 			dimensions INTEGER NOT NULL,
+			quantization TEXT NOT NULL DEFAULT 'float32',
 			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 		)
@@ -99,6 +120,21 @@ func (idx *Index) initSchema() error {
 		return fmt.Errorf("create table: %w", err)
 	}
 
+	// Tables created before quantization hold float32 embeddings
+	var hasQuantization bool
+	if err := idx.db.QueryRow(`
+		SELECT COUNT(*) > 0 FROM pragma_table_info('node_embeddings') WHERE name = 'quantization'
+	`).Scan(&hasQuantization); err != nil {
+		return fmt.Errorf("inspect table: %w", err)
+	}
+	if !hasQuantization {
+		if _, err := idx.db.Exec(`
+			ALTER TABLE node_embeddings ADD COLUMN quantization TEXT NOT NULL DEFAULT 'float32'
+		`); err != nil {
+			return fmt.Errorf("add quantization column: %w", err)
+		}
+	}
+
 	// Index for faster lookups
 	_, err = idx.db.Exec(`
 		CREATE INDEX IF NOT EXISTS idx_node_embeddings_model
@@ -151,16 +187,21 @@ func (idx *Index) HasEmbedding(nodeID string) bool {
 // GetEmbedding retrieves the embedding for a node.
 func (idx *Index) GetEmbedding(ctx context.Context, nodeID string) (Vector, error) {
 	var blob []byte
+	var q Quantization
 	err := idx.db.QueryRowContext(ctx, `
-		SELECT embedding FROM node_embeddings WHERE node_id = ?
-	`, nodeID).Scan(&blob)
+		SELECT embedding, quantization FROM node_embeddings WHERE node_id = ?
+	`, nodeID).Scan(&blob, &q)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, nil
 		}
 		return nil, fmt.Errorf("get embedding: %w", err)
 	}
-	return VectorFromBytes(blob), nil
+	vec, err := DecodeVector(blob, q)
+	if err != nil {
+		return nil, fmt.Errorf("decode embedding: %w", err)
+	}
+	return vec, nil
 }
 
 // SearchResult represents a search match.
@@ -201,11 +242,21 @@ func (idx *Index) SearchByVector(ctx context.Context, queryVec Vector, limit int
 		limit = 20
 	}
 
+	if idx.annCfg.Enabled {
+		ann, err := idx.loadANN(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if ann.Len() >= idx.annCfg.MinNodes {
+			return ann.Search(queryVec, limit), nil
+		}
+	}
+
 	// Load all embeddings and compute similarity
 	// This is O(n) but works without sqlite-vec
 	// For production scale, use sqlite-vec or pgvector
 	rows, err := idx.db.QueryContext(ctx, `
-		SELECT node_id, embedding FROM node_embeddings
+		SELECT node_id, embedding, quantization FROM node_embeddings
 	`)
 	if err != nil {
 		return nil, fmt.Errorf("query embeddings: %w", err)
@@ -216,11 +267,16 @@ func (idx *Index) SearchByVector(ctx context.Context, queryVec Vector, limit int
 	for rows.Next() {
 		var nodeID string
 		var blob []byte
-		if err := rows.Scan(&nodeID, &blob); err != nil {
+		var q Quantization
+		if err := rows.Scan(&nodeID, &blob, &q); err != nil {
 			continue
 		}
 
-		vec := VectorFromBytes(blob)
+		// Dequantize on the fly; schemes may be mixed mid-migration
+		vec, err := DecodeVector(blob, q)
+		if err != nil {
+			continue
+		}
 		similarity := queryVec.Similarity(vec)
 
 		results = append(results, SearchResult{
@@ -246,11 +302,82 @@ func (idx *Index) SearchByVector(ctx context.Context, queryVec Vector, limit int
 	return results, nil
 }
 
+// loadANN returns the ANN index, building it from stored embeddings on
+// first use.
+func (idx *Index) loadANN(ctx context.Context) (*hnsw, error) {
+	idx.mu.RLock()
+	ann := idx.ann
+	idx.mu.RUnlock()
+	if ann != nil {
+		return ann, nil
+	}
+
+	// Hold the write lock while loading so concurrent writes wait and are
+	// then applied incrementally rather than missed
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	if idx.ann != nil {
+		return idx.ann, nil
+	}
+
+	rows, err := idx.db.QueryContext(ctx, `
+		SELECT node_id, embedding, quantization FROM node_embeddings
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("query embeddings: %w", err)
+	}
+	defer rows.Close()
+
+	ann = newHNSW(idx.annCfg)
+	start := time.Now()
+	for rows.Next() {
+		var nodeID string
+		var blob []byte
+		var q Quantization
+		if err := rows.Scan(&nodeID, &blob, &q); err != nil {
+			continue
+		}
+		vec, err := DecodeVector(blob, q)
+		if err != nil {
+			continue
+		}
+		ann.Insert(nodeID, vec)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("scan rows: %w", err)
+	}
+
+	idx.logger.Debug("built ANN index",
+		"embeddings", ann.Len(),
+		"duration", time.Since(start))
+	idx.ann = ann
+	return ann, nil
+}
+
+// updateANN applies a write to the ANN index if it has been built; a nil
+// vec removes the node.
+func (idx *Index) updateANN(nodeID string, vec Vector) {
+	idx.mu.RLock()
+	ann := idx.ann
+	idx.mu.RUnlock()
+	if ann == nil {
+		return
+	}
+	if vec == nil {
+		ann.Delete(nodeID)
+		return
+	}
+	ann.Insert(nodeID, vec)
+}
+
 // Delete removes an embedding.
 func (idx *Index) Delete(ctx context.Context, nodeID string) error {
 	_, err := idx.db.ExecContext(ctx, `
 		DELETE FROM node_embeddings WHERE node_id = ?
 	`, nodeID)
+	if err == nil {
+		idx.updateANN(nodeID, nil)
+	}
 	return err
 }
 
@@ -263,6 +390,138 @@ func (idx *Index) Count(ctx context.Context) (int, error) {
 	return count, err
 }
 
+// StorageStats describes the space used by stored embeddings.
+type StorageStats struct {
+	// Embeddings is the number of stored embeddings.
+	Embeddings int
+
+	// StoredBytes is the size of the embedding blobs as stored.
+	StoredBytes int64
+
+	// Float32Bytes is their size at full float32 precision.
+	Float32Bytes int64
+
+	// ByQuantization counts embeddings per storage scheme.
+	ByQuantization map[Quantization]int
+}
+
+// Savings returns the fraction of float32 space saved by quantization.
+func (s StorageStats) Savings() float64 {
+	if s.Float32Bytes == 0 {
+		return 0
+	}
+	return 1 - float64(s.StoredBytes)/float64(s.Float32Bytes)
+}
+
+// StorageStats reports the space used by stored embeddings and how much
+// quantization saves.
+func (idx *Index) StorageStats(ctx context.Context) (*StorageStats, error) {
+	rows, err := idx.db.QueryContext(ctx, `
+		SELECT quantization, COUNT(*), COALESCE(SUM(LENGTH(embedding)), 0), COALESCE(SUM(dimensions), 0)
+		FROM node_embeddings
+		GROUP BY quantization
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("query storage stats: %w", err)
+	}
+	defer rows.Close()
+
+	stats := &StorageStats{ByQuantization: make(map[Quantization]int)}
+	for rows.Next() {
+		var q Quantization
+		var count int
+		var stored, dims int64
+		if err := rows.Scan(&q, &count, &stored, &dims); err != nil {
+			return nil, fmt.Errorf("scan storage stats: %w", err)
+		}
+		stats.Embeddings += count
+		stats.StoredBytes += stored
+		stats.Float32Bytes += dims * 4
+		stats.ByQuantization[q] += count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("scan storage stats: %w", err)
+	}
+	return stats, nil
+}
+
+// Compact re-encodes embeddings stored with another scheme into the index's
+// configured quantization and returns the resulting storage stats. Search
+// keeps working throughout, since each embedding records its own scheme.
+func (idx *Index) Compact(ctx context.Context) (*StorageStats, error) {
+	type stored struct {
+		nodeID string
+		vec    Vector
+		q      Quantization
+	}
+
+	rows, err := idx.db.QueryContext(ctx, `
+		SELECT node_id, embedding, quantization FROM node_embeddings
+		WHERE quantization != ?
+	`, idx.quantization)
+	if err != nil {
+		return nil, fmt.Errorf("query embeddings: %w", err)
+	}
+	var pending []stored
+	for rows.Next() {
+		var nodeID string
+		var blob []byte
+		var q Quantization
+		if err := rows.Scan(&nodeID, &blob, &q); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("scan embedding: %w", err)
+		}
+		vec, err := DecodeVector(blob, q)
+		if err != nil {
+			idx.logger.Warn("skipping undecodable embedding", "node_id", nodeID, "error", err)
+			continue
+		}
+		pending = append(pending, stored{nodeID: nodeID, vec: vec, q: q})
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("scan embeddings: %w", err)
+	}
+
+	tx, err := idx.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	for _, p := range pending {
+		blob, err := EncodeVector(p.vec, idx.quantization)
+		if err != nil {
+			return nil, err
+		}
+		// A node re-embedded meanwhile already has the new scheme; skip it
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE node_embeddings SET embedding = ?, quantization = ?, updated_at = CURRENT_TIMESTAMP
+			WHERE node_id = ? AND quantization = ?
+		`, blob, idx.quantization, p.nodeID, p.q); err != nil {
+			return nil, fmt.Errorf("update embedding: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit: %w", err)
+	}
+
+	stats, err := idx.StorageStats(ctx)
+	if err != nil {
+		return nil, err
+	}
+	idx.logger.Info("compacted embeddings",
+		"reencoded", len(pending),
+		"quantization", idx.quantization,
+		"stored_bytes", stats.StoredBytes,
+		"savings", stats.Savings())
+	return stats, nil
+}
+
+// Quantization returns the scheme new embeddings are stored with.
+func (idx *Index) Quantization() Quantization {
+	return idx.quantization
+}
+
 // QueueDepth returns the number of pending embedding requests.
 func (idx *Index) QueueDepth() int {
 	return len(idx.background)
@@ -365,9 +624,16 @@ func (idx *Index) worker() {
 }
 
 func (idx *Index) storeVector(nodeID string, vec Vector) error {
-	_, err := idx.db.Exec(`
-		INSERT OR REPLACE INTO node_embeddings (node_id, embedding, model, dimensions, updated_at)
-		VALUES (?, ?, ?, ?, CURRENT_TIMESTAMP)
-	`, nodeID, vec.ToBytes(), idx.provider.Model(), len(vec))
+	blob, err := EncodeVector(vec, idx.quantization)
+	if err != nil {
+		return err
+	}
+	_, err = idx.db.Exec(`
+		INSERT OR REPLACE INTO node_embeddings (node_id, embedding, model, dimensions, quantization, updated_at)
+		VALUES (?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+	`, nodeID, blob, idx.provider.Model(), len(vec), idx.quantization)
+	if err == nil {
+		idx.updateANN(nodeID, vec)
+	}
 	return err
 }
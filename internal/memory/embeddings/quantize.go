@@ -0,0 +1,162 @@
+package embeddings
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// Quantization is the encoding used to store an embedding. Lower-precision
+// schemes trade a small loss in similarity accuracy for less space;
+// vectors are dequantized when read.
+type Quantization string
+
+const (
+	// QuantizationFloat32 stores full-precision floats (4 bytes/dimension).
+	QuantizationFloat32 Quantization = "float32"
+	// QuantizationFloat16 stores IEEE half-precision floats (2 bytes/dimension).
+	QuantizationFloat16 Quantization = "float16"
+	// QuantizationInt8 stores symmetric per-vector int8 values plus a
+	// float32 scale (1 byte/dimension + 4 bytes).
+	QuantizationInt8 Quantization = "int8"
+)
+
+// Valid reports whether q is a known scheme. The empty scheme means float32.
+func (q Quantization) Valid() bool {
+	switch q {
+	case "", QuantizationFloat32, QuantizationFloat16, QuantizationInt8:
+		return true
+	}
+	return false
+}
+
+func (q Quantization) orDefault() Quantization {
+	if q == "" {
+		return QuantizationFloat32
+	}
+	return q
+}
+
+// EncodeVector serializes v with scheme q.
+func EncodeVector(v Vector, q Quantization) ([]byte, error) {
+	switch q.orDefault() {
+	case QuantizationFloat32:
+		return v.ToBytes(), nil
+	case QuantizationFloat16:
+		buf := make([]byte, len(v)*2)
+		for i, f := range v {
+			binary.LittleEndian.PutUint16(buf[i*2:], float32ToHalf(f))
+		}
+		return buf, nil
+	case QuantizationInt8:
+		var maxAbs float32
+		for _, f := range v {
+			maxAbs = max(maxAbs, float32(math.Abs(float64(f))))
+		}
+		scale := maxAbs / 127
+		buf := make([]byte, 4+len(v))
+		binary.LittleEndian.PutUint32(buf, math.Float32bits(scale))
+		if scale > 0 {
+			for i, f := range v {
+				n := math.Round(float64(f / scale))
+				buf[4+i] = byte(int8(max(-127, min(127, n))))
+			}
+		}
+		return buf, nil
+	default:
+		return nil, fmt.Errorf("unknown quantization %q", q)
+	}
+}
+
+// DecodeVector deserializes b, encoded with scheme q, back to float32.
+func DecodeVector(b []byte, q Quantization) (Vector, error) {
+	switch q.orDefault() {
+	case QuantizationFloat32:
+		if len(b)%4 != 0 {
+			return nil, fmt.Errorf("float32 embedding has %d bytes, not a multiple of 4", len(b))
+		}
+		return VectorFromBytes(b), nil
+	case QuantizationFloat16:
+		if len(b)%2 != 0 {
+			return nil, fmt.Errorf("float16 embedding has %d bytes, not a multiple of 2", len(b))
+		}
+		v := make(Vector, len(b)/2)
+		for i := range v {
+			v[i] = halfToFloat32(binary.LittleEndian.Uint16(b[i*2:]))
+		}
+		return v, nil
+	case QuantizationInt8:
+		if len(b) < 4 {
+			return nil, fmt.Errorf("int8 embedding has %d bytes, missing its scale", len(b))
+		}
+		scale := math.Float32frombits(binary.LittleEndian.Uint32(b))
+		v := make(Vector, len(b)-4)
+		for i := range v {
+			v[i] = float32(int8(b[4+i])) * scale
+		}
+		return v, nil
+	default:
+		return nil, fmt.Errorf("unknown quantization %q", q)
+	}
+}
+
+// float32ToHalf converts f to IEEE 754 half precision, rounding to nearest
+// even. Values too large become infinity, too small zero.
+func float32ToHalf(f float32) uint16 {
+	b := math.Float32bits(f)
+	sign := uint16(b>>16) & 0x8000
+	rawExp := int(b>>23) & 0xff
+	mant := b & 0x7fffff
+
+	if rawExp == 0xff { // Inf or NaN
+		if mant != 0 {
+			return sign | 0x7e00
+		}
+		return sign | 0x7c00
+	}
+
+	exp := rawExp - 127 + 15
+	switch {
+	case exp >= 0x1f:
+		return sign | 0x7c00
+	case exp <= 0:
+		// Subnormal half: value = mant_h * 2^-24
+		if exp < -10 {
+			return sign
+		}
+		mant |= 0x800000
+		shift := uint(14 - exp)
+		half := uint16(mant >> shift)
+		rem, mid := mant&(1<<shift-1), uint32(1)<<(shift-1)
+		if rem > mid || (rem == mid && half&1 == 1) {
+			half++
+		}
+		return sign | half
+	}
+
+	half := sign | uint16(exp)<<10 | uint16(mant>>13)
+	// A carry out of the mantissa correctly bumps the exponent
+	if rem := mant & 0x1fff; rem > 0x1000 || (rem == 0x1000 && half&1 == 1) {
+		half++
+	}
+	return half
+}
+
+// halfToFloat32 converts an IEEE 754 half-precision value to float32.
+func halfToFloat32(h uint16) float32 {
+	sign := uint32(h&0x8000) << 16
+	exp := uint32(h>>10) & 0x1f
+	mant := uint32(h & 0x3ff)
+
+	switch exp {
+	case 0:
+		f := float32(mant) / (1 << 24)
+		if sign != 0 {
+			f = -f
+		}
+		return f
+	case 0x1f:
+		return math.Float32frombits(sign | 0x7f800000 | mant<<13)
+	}
+	return math.Float32frombits(sign | (exp-15+127)<<23 | mant<<13)
+}
@@ -0,0 +1,224 @@
+package embeddings
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"math/rand"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newFileTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "embeddings.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func randomUnitVector(rng *rand.Rand, dims int) Vector {
+	v := make(Vector, dims)
+	for i := range v {
+		v[i] = float32(rng.NormFloat64())
+	}
+	return v.Normalize()
+}
+
+func TestEncodeVector_RoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	v := randomUnitVector(rng, 256)
+
+	tests := []struct {
+		q         Quantization
+		size      int
+		tolerance float64
+	}{
+		{QuantizationFloat32, 4 * 256, 0},
+		{"", 4 * 256, 0},
+		{QuantizationFloat16, 2 * 256, 1e-3},
+		{QuantizationInt8, 4 + 256, 0.01},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.q), func(t *testing.T) {
+			blob, err := EncodeVector(v, tt.q)
+			require.NoError(t, err)
+			assert.Len(t, blob, tt.size)
+
+			got, err := DecodeVector(blob, tt.q)
+			require.NoError(t, err)
+			require.Len(t, got, len(v))
+			for i := range v {
+				assert.InDelta(t, v[i], got[i], tt.tolerance, "dimension %d", i)
+			}
+			assert.InDelta(t, 1.0, v.Similarity(got), 1e-3)
+		})
+	}
+}
+
+func TestFloat16_SpecialValues(t *testing.T) {
+	for _, f := range []float32{0, 1, -2.5, 65504, 1e-5, -6e-8} {
+		got := halfToFloat32(float32ToHalf(f))
+		assert.InEpsilon(t, f+1e-30, got+1e-30, 0.01, "%g", f)
+	}
+	assert.True(t, math.IsInf(float64(halfToFloat32(float32ToHalf(1e6))), 1), "overflow")
+	assert.Zero(t, halfToFloat32(float32ToHalf(1e-10)), "underflow")
+	assert.True(t, math.IsNaN(float64(halfToFloat32(float32ToHalf(float32(math.NaN()))))))
+}
+
+func TestEncodeVector_Invalid(t *testing.T) {
+	_, err := EncodeVector(Vector{1}, "int4")
+	assert.Error(t, err)
+	_, err = DecodeVector([]byte{1, 2, 3}, QuantizationFloat32)
+	assert.Error(t, err)
+	_, err = DecodeVector([]byte{1}, QuantizationFloat16)
+	assert.Error(t, err)
+	_, err = DecodeVector([]byte{1, 2}, QuantizationInt8)
+	assert.Error(t, err)
+
+	_, err = NewIndex(newFileTestDB(t), IndexConfig{Provider: newMockProvider(), Quantization: "int4"})
+	assert.Error(t, err)
+}
+
+func TestIndex_Int8Recall(t *testing.T) {
+	const (
+		docs    = 200
+		queries = 100
+		dims    = 128
+	)
+	rng := rand.New(rand.NewSource(42))
+	mock := newMockProvider()
+	mock.dimensions = dims
+
+	ctx := context.Background()
+	exact, err := NewIndex(newFileTestDB(t), IndexConfig{Provider: mock, BatchSize: 1, Workers: 1})
+	require.NoError(t, err)
+	defer exact.Close()
+	quantized, err := NewIndex(newFileTestDB(t), IndexConfig{Provider: mock, BatchSize: 1, Workers: 1, Quantization: QuantizationInt8})
+	require.NoError(t, err)
+	defer quantized.Close()
+
+	vectors := make([]Vector, docs)
+	for i := range vectors {
+		vectors[i] = randomUnitVector(rng, dims)
+		content := fmt.Sprintf("doc-%d", i)
+		mock.embeddings[content] = vectors[i]
+		require.NoError(t, exact.IndexSync(ctx, content, content))
+		require.NoError(t, quantized.IndexSync(ctx, content, content))
+	}
+
+	// Each query is a noisy copy of a labeled document
+	var hits, agree int
+	for i := 0; i < queries; i++ {
+		label := rng.Intn(docs)
+		query := make(Vector, dims)
+		for d := range query {
+			query[d] = vectors[label][d] + float32(rng.NormFloat64()*0.05)
+		}
+
+		want, err := exact.SearchByVector(ctx, query, 1)
+		require.NoError(t, err)
+		got, err := quantized.SearchByVector(ctx, query, 1)
+		require.NoError(t, err)
+		require.Len(t, got, 1)
+
+		if got[0].NodeID == fmt.Sprintf("doc-%d", label) {
+			hits++
+		}
+		if got[0].NodeID == want[0].NodeID {
+			agree++
+			assert.InDelta(t, want[0].Similarity, got[0].Similarity, 0.01)
+		}
+	}
+	assert.GreaterOrEqual(t, float64(hits)/queries, 0.98, "recall@1 against labels")
+	assert.GreaterOrEqual(t, float64(agree)/queries, 0.98, "agreement with float32 search")
+
+	stats, err := quantized.StorageStats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, docs, stats.Embeddings)
+	assert.Equal(t, int64(docs*(dims+4)), stats.StoredBytes)
+	assert.Equal(t, int64(docs*dims*4), stats.Float32Bytes)
+	assert.InDelta(t, 0.74, stats.Savings(), 0.01)
+}
+
+func TestIndex_MixedSchemesAndCompact(t *testing.T) {
+	db := newFileTestDB(t)
+	mock := newMockProvider()
+	mock.embeddings["a"] = Vector{1, 0, 0}
+	mock.embeddings["b"] = Vector{0, 1, 0}
+	mock.embeddings["c"] = Vector{0.8, 0.6, 0}
+	mock.embeddings["query"] = Vector{0.9, 0.1, 0}
+	ctx := context.Background()
+
+	// Existing full-precision embeddings
+	idx, err := NewIndex(db, IndexConfig{Provider: mock, BatchSize: 1, Workers: 1})
+	require.NoError(t, err)
+	require.NoError(t, idx.IndexSync(ctx, "node-a", "a"))
+	require.NoError(t, idx.IndexSync(ctx, "node-b", "b"))
+	require.NoError(t, idx.Close())
+
+	// Reopened with int8: new embeddings are quantized, old ones untouched
+	idx, err = NewIndex(db, IndexConfig{Provider: mock, BatchSize: 1, Workers: 1, Quantization: QuantizationInt8})
+	require.NoError(t, err)
+	defer idx.Close()
+	require.NoError(t, idx.IndexSync(ctx, "node-c", "c"))
+
+	stats, err := idx.StorageStats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[Quantization]int{QuantizationFloat32: 2, QuantizationInt8: 1}, stats.ByQuantization)
+
+	results, err := idx.Search(ctx, "query", 3)
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+	assert.Equal(t, []string{"node-a", "node-c", "node-b"},
+		[]string{results[0].NodeID, results[1].NodeID, results[2].NodeID})
+
+	stats, err = idx.Compact(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[Quantization]int{QuantizationInt8: 3}, stats.ByQuantization)
+	assert.Equal(t, int64(3*(3+4)), stats.StoredBytes)
+	assert.Positive(t, stats.Savings())
+
+	vec, err := idx.GetEmbedding(ctx, "node-b")
+	require.NoError(t, err)
+	assert.InDeltaSlice(t, []float32{0, 1, 0}, []float32(vec), 0.01)
+
+	results, err = idx.Search(ctx, "query", 1)
+	require.NoError(t, err)
+	assert.Equal(t, "node-a", results[0].NodeID)
+}
+
+func TestIndex_MigratesLegacyTable(t *testing.T) {
+	db := newFileTestDB(t)
+	_, err := db.Exec(`
+		CREATE TABLE node_embeddings (
+			node_id TEXT PRIMARY KEY,
+			embedding BLOB NOT NULL,
+			model TEXT NOT NULL,
+			dimensions INTEGER NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+	require.NoError(t, err)
+	_, err = db.Exec(`INSERT INTO node_embeddings (node_id, embedding, model, dimensions) VALUES (?, ?, ?, ?)`,
+		"old", Vector{0.6, 0.8}.ToBytes(), "mock-model", 2)
+	require.NoError(t, err)
+
+	idx, err := NewIndex(db, IndexConfig{Provider: newMockProvider(), Workers: 1, Quantization: QuantizationFloat16})
+	require.NoError(t, err)
+	defer idx.Close()
+
+	vec, err := idx.GetEmbedding(context.Background(), "old")
+	require.NoError(t, err)
+	assert.Equal(t, Vector{0.6, 0.8}, vec)
+
+	stats, err := idx.StorageStats(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, map[Quantization]int{QuantizationFloat32: 1}, stats.ByQuantization)
+	assert.Zero(t, stats.Savings())
+}
@@ -25,6 +25,8 @@ const (
 	AuditArchive     AuditEventType = "archive"
 	AuditRestore     AuditEventType = "restore"
 	AuditPrune       AuditEventType = "prune"
+	AuditPurge       AuditEventType = "purge"
+	AuditGC          AuditEventType = "gc"
 	AuditAccess      AuditEventType = "access"
 )
 
@@ -206,7 +208,7 @@ func mapEventToOperation(eventType AuditEventType) hypergraph.EvolutionOperation
 		return hypergraph.EvolutionDecay
 	case AuditArchive:
 		return hypergraph.EvolutionArchive
-	case AuditPrune:
+	case AuditPrune, AuditPurge, AuditGC:
 		return hypergraph.EvolutionPrune
 	default:
 		// AuditDemote, AuditRestore, AuditAccess don't have direct mappings
@@ -383,6 +385,50 @@ func (l *AuditLogger) LogPrune(result *DecayResult, err error) error {
 	return l.Log(entry)
 }
 
+// LogPurge logs the permanent removal of expired soft-deleted nodes.
+func (l *AuditLogger) LogPurge(result *DecayResult, err error) error {
+	if result == nil {
+		result = &DecayResult{}
+	}
+	entry := AuditEntry{
+		EventType: AuditPurge,
+		Duration:  result.Duration,
+		Details: map[string]any{
+			"nodes_purged": result.NodesPurged,
+		},
+		Result: &AuditResult{
+			Success:       err == nil,
+			NodesAffected: result.NodesPurged,
+		},
+	}
+	if err != nil {
+		entry.Result.Error = err.Error()
+	}
+	return l.Log(entry)
+}
+
+// LogGC logs a hypergraph garbage collection pass.
+func (l *AuditLogger) LogGC(result *hypergraph.GCResult, err error) error {
+	if result == nil {
+		result = &hypergraph.GCResult{}
+	}
+	entry := AuditEntry{
+		EventType: AuditGC,
+		Duration:  result.Duration,
+		Details: map[string]any{
+			"memberships_pruned": result.MembershipsPruned,
+			"hyperedges_removed": result.HyperedgesRemoved,
+		},
+		Result: &AuditResult{
+			Success: err == nil,
+		},
+	}
+	if err != nil {
+		entry.Result.Error = err.Error()
+	}
+	return l.Log(entry)
+}
+
 // LogAccess logs a node access event.
 func (l *AuditLogger) LogAccess(nodeID string) error {
 	return l.Log(AuditEntry{
@@ -2,6 +2,7 @@ package evolution
 
 import (
 	"context"
+	"errors"
 	"os"
 	"path/filepath"
 	"testing"
@@ -276,6 +277,24 @@ func TestLogPrune(t *testing.T) {
 	assert.Equal(t, 5, entries[0].Result.NodesAffected)
 }
 
+func TestLogPurge(t *testing.T) {
+	logger, err := NewAuditLogger(DefaultAuditConfig())
+	require.NoError(t, err)
+
+	err = logger.LogPurge(&DecayResult{NodesPurged: 3, Duration: time.Millisecond}, nil)
+	require.NoError(t, err)
+
+	err = logger.LogPurge(nil, errors.New("db locked"))
+	require.NoError(t, err)
+
+	entries := logger.GetRecentEntries(2)
+	require.Len(t, entries, 2)
+	assert.Equal(t, AuditPurge, entries[0].EventType)
+	assert.Equal(t, 3, entries[0].Result.NodesAffected)
+	assert.False(t, entries[1].Result.Success)
+	assert.Equal(t, "db locked", entries[1].Result.Error)
+}
+
 func TestLogAccess(t *testing.T) {
 	logger, err := NewAuditLogger(DefaultAuditConfig())
 	require.NoError(t, err)
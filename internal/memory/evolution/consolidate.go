@@ -145,8 +145,17 @@ func (c *Consolidator) deduplicateNodes(ctx context.Context, nodes []*hypergraph
 		key := normalizeContent(node.Content)
 
 		if existing, ok := seen[key]; ok {
-			// Merge into existing node
-			if err := c.mergeNodes(ctx, existing, node); err != nil {
+			// Pinned nodes are never merged away: keep a pinned duplicate
+			// as the target, and leave two pinned duplicates alone
+			target, source := existing, node
+			if source.Pinned {
+				if target.Pinned {
+					continue
+				}
+				target, source = source, target
+				seen[key] = target
+			}
+			if err := c.mergeNodes(ctx, target, source); err != nil {
 				return merged, err
 			}
 			merged++
@@ -171,8 +180,8 @@ func (c *Consolidator) mergeNodes(ctx context.Context, target, source *hypergrap
 		return fmt.Errorf("update target: %w", err)
 	}
 
-	// Delete the source node (edges will cascade)
-	if err := c.store.DeleteNode(ctx, source.ID); err != nil {
+	// Soft-delete the source node so a bad merge can be undone
+	if err := c.store.SoftDeleteNode(ctx, source.ID); err != nil {
 		return fmt.Errorf("delete source: %w", err)
 	}
 
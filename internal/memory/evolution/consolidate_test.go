@@ -108,6 +108,37 @@ func TestConsolidate_WithDuplicates(t *testing.T) {
 	}
 }
 
+func TestConsolidate_KeepsPinnedDuplicates(t *testing.T) {
+	store := createTestStore(t)
+	c := NewConsolidator(store, DefaultConsolidationConfig())
+	ctx := context.Background()
+
+	newNode := func(content string, pinned bool) *hypergraph.Node {
+		node := hypergraph.NewNode(hypergraph.NodeTypeFact, content)
+		node.Tier = hypergraph.TierTask
+		node.Pinned = pinned
+		require.NoError(t, store.CreateNode(ctx, node))
+		return node
+	}
+	plain := newNode("Deploys need approval", false)
+	pinned := newNode("deploys need approval", true)
+	otherPinned := newNode("DEPLOYS NEED APPROVAL", true)
+
+	merged, err := c.deduplicateNodes(ctx, []*hypergraph.Node{plain, pinned, otherPinned})
+	require.NoError(t, err)
+	assert.Equal(t, 1, merged)
+
+	// The unpinned duplicate is merged into the pinned one, and the two
+	// pinned nodes both survive
+	_, err = store.GetNode(ctx, plain.ID)
+	assert.True(t, hypergraph.IsNotFound(err))
+	for _, id := range []string{pinned.ID, otherPinned.ID} {
+		node, err := store.GetNode(ctx, id)
+		require.NoError(t, err)
+		assert.True(t, node.Pinned)
+	}
+}
+
 func TestConsolidate_CreatesSummaries(t *testing.T) {
 	store := createTestStore(t)
 	cfg := DefaultConsolidationConfig()
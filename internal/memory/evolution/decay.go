@@ -21,8 +21,13 @@ type DecayConfig struct {
 	ArchiveThreshold float64
 
 	// PruneThreshold is the confidence below which archived nodes are deleted.
+	// Pruned nodes are soft-deleted and can be restored until purged.
 	PruneThreshold float64
 
+	// DeletedRetention is how long soft-deleted nodes are kept before
+	// PurgeDeleted removes them permanently.
+	DeletedRetention time.Duration
+
 	// MinRetention is the minimum time before a node can be archived.
 	MinRetention time.Duration
 
@@ -37,6 +42,7 @@ func DefaultDecayConfig() DecayConfig {
 		AccessBoost:      0.1,                // 10% boost per access
 		ArchiveThreshold: 0.3,                // Archive below 30%
 		PruneThreshold:   0.1,                // Delete below 10%
+		DeletedRetention: time.Hour * 24 * 7, // Allow undo for 1 week
 		MinRetention:     time.Hour * 24,     // Keep at least 1 day
 		ExcludeTiers:     []hypergraph.Tier{hypergraph.TierTask}, // Don't decay task tier
 	}
@@ -56,6 +62,9 @@ type DecayResult struct {
 	// NodesPruned is the count of nodes deleted.
 	NodesPruned int
 
+	// NodesPurged is the count of soft-deleted nodes permanently removed.
+	NodesPurged int
+
 	// Duration of the decay process.
 	Duration time.Duration
 }
@@ -174,8 +183,11 @@ func (d *Decayer) PruneArchived(ctx context.Context) (*DecayResult, error) {
 	result.NodesProcessed = len(nodes)
 
 	for _, node := range nodes {
+		if node.Pinned {
+			continue
+		}
 		if node.Confidence < d.config.PruneThreshold {
-			if err := d.store.DeleteNode(ctx, node.ID); err != nil {
+			if err := d.store.SoftDeleteNode(ctx, node.ID); err != nil {
 				return nil, fmt.Errorf("delete node %s: %w", node.ID, err)
 			}
 			result.NodesPruned++
@@ -186,6 +198,22 @@ func (d *Decayer) PruneArchived(ctx context.Context) (*DecayResult, error) {
 	return result, nil
 }
 
+// PurgeDeleted permanently removes nodes soft-deleted longer ago than the
+// configured retention window.
+func (d *Decayer) PurgeDeleted(ctx context.Context) (*DecayResult, error) {
+	start := time.Now()
+
+	purged, err := d.store.PurgeDeleted(ctx, d.config.DeletedRetention)
+	if err != nil {
+		return nil, fmt.Errorf("purge deleted nodes: %w", err)
+	}
+
+	return &DecayResult{
+		NodesPurged: int(purged),
+		Duration:    time.Since(start),
+	}, nil
+}
+
 // RunFullCycle runs decay, archive, and prune in sequence.
 func (d *Decayer) RunFullCycle(ctx context.Context) (*DecayResult, error) {
 	start := time.Now()
@@ -326,7 +354,8 @@ func (d *Decayer) calculateAmplification(accessCount int) float64 {
 	return 1.0 + math.Log2(float64(accessCount+1))*d.config.AccessBoost
 }
 
-// getDecayableNodes returns nodes that should have decay applied.
+// getDecayableNodes returns nodes that should have decay applied. Pinned
+// nodes are left out, so they neither decay nor get archived.
 func (d *Decayer) getDecayableNodes(ctx context.Context) ([]*hypergraph.Node, error) {
 	// Build list of tiers to include (all except excluded)
 	allTiers := []hypergraph.Tier{
@@ -350,10 +379,20 @@ func (d *Decayer) getDecayableNodes(ctx context.Context) ([]*hypergraph.Node, er
 		}
 	}
 
-	return d.store.ListNodes(ctx, hypergraph.NodeFilter{
+	nodes, err := d.store.ListNodes(ctx, hypergraph.NodeFilter{
 		Tiers: includeTiers,
 		Limit: 10000,
 	})
+	if err != nil {
+		return nil, err
+	}
+	decayable := nodes[:0]
+	for _, node := range nodes {
+		if !node.Pinned {
+			decayable = append(decayable, node)
+		}
+	}
+	return decayable, nil
 }
 
 // confidenceBucket returns a human-readable bucket for a confidence value.
@@ -19,6 +19,7 @@ func TestDefaultDecayConfig(t *testing.T) {
 	assert.Equal(t, 0.3, cfg.ArchiveThreshold)
 	assert.Equal(t, 0.1, cfg.PruneThreshold)
 	assert.Equal(t, time.Hour*24, cfg.MinRetention)
+	assert.Equal(t, time.Hour*24*7, cfg.DeletedRetention)
 	assert.Contains(t, cfg.ExcludeTiers, hypergraph.TierTask)
 }
 
@@ -250,6 +251,41 @@ func TestPruneArchived(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestPruneArchived_RestorableUntilPurged(t *testing.T) {
+	store := createTestStore(t)
+	cfg := DefaultDecayConfig()
+	cfg.PruneThreshold = 0.2
+	cfg.DeletedRetention = time.Hour
+	d := NewDecayer(store, cfg)
+	ctx := context.Background()
+
+	node := hypergraph.NewNode(hypergraph.NodeTypeFact, "Pruned by mistake")
+	node.Tier = hypergraph.TierArchive
+	node.Confidence = 0.05
+	require.NoError(t, store.CreateNode(ctx, node))
+
+	_, err := d.PruneArchived(ctx)
+	require.NoError(t, err)
+
+	// Within retention, purge keeps the node and it can be restored
+	result, err := d.PurgeDeleted(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, result.NodesPurged)
+
+	require.NoError(t, store.RestoreNode(ctx, node.ID))
+	_, err = store.GetNode(ctx, node.ID)
+	assert.NoError(t, err)
+
+	// With no retention, a pruned node is purged for good
+	d.config.DeletedRetention = 0
+	_, err = d.PruneArchived(ctx)
+	require.NoError(t, err)
+	result, err = d.PurgeDeleted(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.NodesPurged)
+	assert.Error(t, store.RestoreNode(ctx, node.ID))
+}
+
 func TestPruneArchived_KeepsAboveThreshold(t *testing.T) {
 	store := createTestStore(t)
 	cfg := DefaultDecayConfig()
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"slices"
 	"sync"
 	"time"
 
@@ -41,6 +42,10 @@ type SessionSummary struct {
 	UnfinishedWork string   `json:"unfinished_work,omitempty"`
 	NextSteps      []string `json:"next_steps,omitempty"`
 
+	// Progress (set on milestone summaries of a session still in progress)
+	Milestone int `json:"milestone,omitempty"`
+	Tokens    int `json:"tokens,omitempty"`
+
 	// Human-readable summary
 	Summary string `json:"summary"`
 }
@@ -77,6 +82,21 @@ type LifecycleConfig struct {
 
 	// RunPruneOnIdle enables pruning during idle maintenance.
 	RunPruneOnIdle bool
+
+	// RunGCOnIdle enables collecting orphaned hyperedges and memberships
+	// during idle maintenance.
+	RunGCOnIdle bool
+
+	// GC configures garbage collection.
+	GC hypergraph.GCOptions
+
+	// SummaryMilestones are cumulative session token counts at which an
+	// incremental session summary is generated mid-session (empty disables).
+	SummaryMilestones []int
+
+	// CompressOnMilestone archives the task- and session-tier experiences a
+	// milestone summary covers, so they stop occupying context.
+	CompressOnMilestone bool
 }
 
 // DefaultLifecycleConfig returns sensible defaults.
@@ -90,6 +110,8 @@ func DefaultLifecycleConfig() LifecycleConfig {
 		RunDecayOnSessionEnd: true,
 		RunArchiveOnIdle:     true,
 		RunPruneOnIdle:       true,
+		RunGCOnIdle:          true,
+		GC:                   hypergraph.DefaultGCOptions(),
 	}
 }
 
@@ -107,6 +129,12 @@ type LifecycleResult struct {
 	// Decay result if decay ran
 	Decay *DecayResult
 
+	// GC result if garbage collection ran
+	GC *hypergraph.GCResult
+
+	// Milestone result if a summary milestone was reached
+	Milestone *MilestoneResult
+
 	// Duration of the entire operation
 	Duration time.Duration
 
@@ -132,10 +160,16 @@ type LifecycleManager struct {
 	sessionID     string
 	sessionStart  time.Time
 
+	// Milestone summarization
+	sessionTokens int
+	nextMilestone int              // index into config.SummaryMilestones
+	lastMilestone *hypergraph.Node // latest milestone summary this session
+
 	// Callbacks for lifecycle events
 	onTaskComplete  []func(*LifecycleResult)
 	onSessionEnd    []func(*LifecycleResult)
 	onIdleMaintenance []func(*LifecycleResult)
+	onMilestone       []func(*LifecycleResult)
 
 	// Idle maintenance
 	stopIdle chan struct{}
@@ -186,6 +220,13 @@ func (m *LifecycleManager) OnIdleMaintenance(callback func(*LifecycleResult)) {
 	m.onIdleMaintenance = append(m.onIdleMaintenance, callback)
 }
 
+// OnMilestone registers a callback for summary milestone events.
+func (m *LifecycleManager) OnMilestone(callback func(*LifecycleResult)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onMilestone = append(m.onMilestone, callback)
+}
+
 // TaskComplete handles task completion lifecycle.
 // This consolidates the task tier and promotes high-value nodes to session.
 func (m *LifecycleManager) TaskComplete(ctx context.Context) (*LifecycleResult, error) {
@@ -299,6 +340,7 @@ func (m *LifecycleManager) SessionEnd(ctx context.Context) (*LifecycleResult, er
 	// Reset session for next time
 	m.sessionID = fmt.Sprintf("session-%d", time.Now().UnixNano())
 	m.sessionStart = time.Now()
+	m.resetMilestones()
 
 	if len(result.Errors) > 0 {
 		return result, result.Errors[0]
@@ -319,6 +361,16 @@ func (m *LifecycleManager) createSessionSummary(ctx context.Context, duration ti
 		return fmt.Errorf("query experiences: %w", err)
 	}
 
+	// Fold in the latest milestone summary in place of the experiences it
+	// already covers
+	if m.lastMilestone != nil {
+		covered := m.lastMilestone.CreatedAt
+		experiences = slices.DeleteFunc(experiences, func(n *hypergraph.Node) bool {
+			return !n.CreatedAt.After(covered)
+		})
+		experiences = append([]*hypergraph.Node{m.lastMilestone}, experiences...)
+	}
+
 	// Don't create summary if no experiences
 	if len(experiences) == 0 {
 		return nil
@@ -348,11 +400,18 @@ func (m *LifecycleManager) createSessionSummary(ctx context.Context, duration ti
 		return fmt.Errorf("create node: %w", err)
 	}
 
+	// The session summary supersedes the milestone summaries
+	if err := m.retireMilestone(ctx); err != nil {
+		return fmt.Errorf("retire milestone summary: %w", err)
+	}
+
 	return nil
 }
 
 // IdleMaintenance runs background maintenance tasks.
-// This applies decay, archives low-confidence nodes, and prunes old archives.
+// This applies decay, archives low-confidence nodes, prunes old archives,
+// purges soft-deleted nodes past their retention window, and collects the
+// hyperedges and memberships those deletions orphaned.
 func (m *LifecycleManager) IdleMaintenance(ctx context.Context) (*LifecycleResult, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -386,7 +445,7 @@ func (m *LifecycleManager) IdleMaintenance(ctx context.Context) (*LifecycleResul
 		}
 	}
 
-	// Step 3: Prune old archives
+	// Step 3: Prune old archives and purge expired soft-deletes
 	if m.config.RunPruneOnIdle {
 		pruneResult, err := m.decayer.PruneArchived(ctx)
 		if err != nil {
@@ -396,9 +455,29 @@ func (m *LifecycleManager) IdleMaintenance(ctx context.Context) (*LifecycleResul
 			result.Decay.NodesPruned += pruneResult.NodesPruned
 			m.audit.LogPrune(pruneResult, nil)
 		}
+
+		purgeResult, err := m.decayer.PurgeDeleted(ctx)
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Errorf("purge: %w", err))
+			m.audit.LogPurge(nil, err)
+		} else {
+			result.Decay.NodesPurged += purgeResult.NodesPurged
+			m.audit.LogPurge(purgeResult, nil)
+		}
+	}
+
+	// Step 4: Collect hyperedges and memberships orphaned by the deletions
+	if m.config.RunGCOnIdle {
+		gcResult, err := m.store.CollectGarbage(ctx, m.config.GC)
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Errorf("gc: %w", err))
+		} else {
+			result.GC = gcResult
+		}
+		m.audit.LogGC(gcResult, err)
 	}
 
-	// Step 4: Run meta-evolution analysis (if enabled)
+	// Step 5: Run meta-evolution analysis (if enabled)
 	if m.metaEvolution != nil {
 		if _, err := m.metaEvolution.RunAnalysis(ctx); err != nil {
 			result.Errors = append(result.Errors, fmt.Errorf("meta-evolution: %w", err))
@@ -569,4 +648,5 @@ func (m *LifecycleManager) StartSession(sessionID string) {
 	defer m.mu.Unlock()
 	m.sessionID = sessionID
 	m.sessionStart = time.Now()
+	m.resetMilestones()
 }
@@ -178,6 +178,10 @@ func TestIdleMaintenance_Basic(t *testing.T) {
 	assert.Equal(t, "idle", result.Operation)
 	assert.NotNil(t, result.Decay)
 	assert.Greater(t, result.Duration, time.Duration(0))
+
+	// Irreversible purges leave an audit trail like prunes do
+	assert.Len(t, mgr.AuditLogger().GetEntriesByType(AuditPrune, 10), 1)
+	assert.Len(t, mgr.AuditLogger().GetEntriesByType(AuditPurge, 10), 1)
 }
 
 func TestIdleMaintenance_Callback(t *testing.T) {
@@ -201,6 +205,59 @@ func TestIdleMaintenance_Callback(t *testing.T) {
 	assert.True(t, callbackInvoked)
 }
 
+func TestIdleMaintenance_CollectsOrphanedHyperedges(t *testing.T) {
+	store := createTestStoreLifecycle(t)
+	cfg := DefaultLifecycleConfig()
+	cfg.Decay.DeletedRetention = 0
+	cfg.GC.MinEdgeAge = 0
+
+	mgr, err := NewLifecycleManager(store, cfg)
+	require.NoError(t, err)
+	defer mgr.Close()
+
+	ctx := context.Background()
+
+	// A deleted node that was the edge's only member leaves it empty once
+	// the purge removes the node for good.
+	node := hypergraph.NewNode(hypergraph.NodeTypeFact, "stale fact")
+	require.NoError(t, store.CreateNode(ctx, node))
+	edge := hypergraph.NewHyperedge(hypergraph.HyperedgeContext, "about the fact")
+	require.NoError(t, store.CreateHyperedge(ctx, edge))
+	require.NoError(t, store.AddMember(ctx, hypergraph.Membership{
+		HyperedgeID: edge.ID, NodeID: node.ID, Role: hypergraph.RoleContext,
+	}))
+	require.NoError(t, store.SoftDeleteNode(ctx, node.ID))
+
+	result, err := mgr.IdleMaintenance(ctx)
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, result.Decay.NodesPurged)
+	require.NotNil(t, result.GC)
+	assert.Equal(t, int64(1), result.GC.HyperedgesRemoved)
+	_, err = store.GetHyperedge(ctx, edge.ID)
+	assert.True(t, hypergraph.IsNotFound(err))
+
+	entries := mgr.AuditLogger().GetEntriesByType(AuditGC, 10)
+	require.Len(t, entries, 1)
+	assert.True(t, entries[0].Result.Success)
+	assert.Equal(t, int64(1), entries[0].Details["hyperedges_removed"])
+}
+
+func TestIdleMaintenance_NoGC(t *testing.T) {
+	store := createTestStoreLifecycle(t)
+	cfg := DefaultLifecycleConfig()
+	cfg.RunGCOnIdle = false
+
+	mgr, err := NewLifecycleManager(store, cfg)
+	require.NoError(t, err)
+	defer mgr.Close()
+
+	result, err := mgr.IdleMaintenance(context.Background())
+	require.NoError(t, err)
+	assert.Nil(t, result.GC)
+	assert.Empty(t, mgr.AuditLogger().GetEntriesByType(AuditGC, 10))
+}
+
 func TestIdleMaintenance_NoArchive(t *testing.T) {
 	store := createTestStoreLifecycle(t)
 	cfg := DefaultLifecycleConfig()
@@ -219,6 +276,49 @@ func TestIdleMaintenance_NoArchive(t *testing.T) {
 	assert.NotNil(t, result.Decay)
 }
 
+func TestIdleMaintenance_SparesPinnedNodes(t *testing.T) {
+	store := createTestStoreLifecycle(t)
+	cfg := DefaultLifecycleConfig()
+	cfg.Decay.HalfLife = time.Hour
+	cfg.Decay.MinRetention = 0
+	cfg.RunPruneOnIdle = false
+
+	mgr, err := NewLifecycleManager(store, cfg)
+	require.NoError(t, err)
+	defer mgr.Close()
+
+	ctx := context.Background()
+	old := time.Now().Add(-time.Hour * 24 * 30)
+	newFact := func(content string) *hypergraph.Node {
+		node := hypergraph.NewNode(hypergraph.NodeTypeFact, content)
+		node.Tier = hypergraph.TierLongterm
+		node.Confidence = 0.8
+		node.CreatedAt = old
+		require.NoError(t, store.CreateNode(ctx, node))
+		return node
+	}
+	pinned := newFact("the prod database must never be dropped")
+	unpinned := newFact("the staging database was reset last month")
+	require.NoError(t, store.PinNode(ctx, pinned.ID))
+
+	result, err := mgr.IdleMaintenance(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, result.Errors)
+	assert.Equal(t, 1, result.Decay.NodesDecayed)
+	assert.Equal(t, 1, result.Decay.NodesArchived)
+
+	kept, err := store.GetNode(ctx, pinned.ID)
+	require.NoError(t, err)
+	assert.True(t, kept.Pinned)
+	assert.Equal(t, 0.8, kept.Confidence)
+	assert.Equal(t, hypergraph.TierLongterm, kept.Tier)
+
+	decayed, err := store.GetNode(ctx, unpinned.ID)
+	require.NoError(t, err)
+	assert.Less(t, decayed.Confidence, cfg.Decay.ArchiveThreshold)
+	assert.Equal(t, hypergraph.TierArchive, decayed.Tier)
+}
+
 func TestStartStopIdleLoop(t *testing.T) {
 	store := createTestStoreLifecycle(t)
 	cfg := DefaultLifecycleConfig()
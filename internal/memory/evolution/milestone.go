@@ -0,0 +1,189 @@
+package evolution
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/rand/recurse/internal/memory/hypergraph"
+)
+
+// MilestoneSummarySubtype is the node subtype of mid-session summaries.
+// Each one folds in the one before it, so the latest covers the whole
+// session so far.
+const MilestoneSummarySubtype = "session_milestone"
+
+// MilestoneResult describes a summary made at a token milestone.
+type MilestoneResult struct {
+	// Milestone is the highest threshold crossed.
+	Milestone int
+
+	// SessionTokens is the cumulative session token count.
+	SessionTokens int
+
+	// Summary is the incremental summary, nil if there was nothing new to
+	// summarize.
+	Summary *SessionSummary
+
+	// NodesCompressed is how many summarized experiences were archived.
+	NodesCompressed int
+}
+
+// RecordTokens adds tokens to the session's running total. When the total
+// crosses one or more of the configured SummaryMilestones, an incremental
+// summary of the experiences since the last milestone is synthesized and
+// stored, and the result describes it; otherwise the result is nil.
+func (m *LifecycleManager) RecordTokens(ctx context.Context, tokens int) (*LifecycleResult, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if tokens <= 0 {
+		return nil, nil
+	}
+	m.sessionTokens += tokens
+
+	milestones := m.config.SummaryMilestones
+	crossed := 0
+	for m.nextMilestone < len(milestones) && m.sessionTokens >= milestones[m.nextMilestone] {
+		crossed = milestones[m.nextMilestone]
+		m.nextMilestone++
+	}
+	if crossed == 0 {
+		return nil, nil
+	}
+
+	start := time.Now()
+	result := &LifecycleResult{
+		Operation: "milestone",
+		Milestone: &MilestoneResult{
+			Milestone:     crossed,
+			SessionTokens: m.sessionTokens,
+		},
+	}
+
+	if m.synthesizer != nil {
+		if err := m.createMilestoneSummary(ctx, result.Milestone); err != nil {
+			result.Errors = append(result.Errors, fmt.Errorf("create milestone summary: %w", err))
+		}
+	}
+
+	result.Duration = time.Since(start)
+
+	// Invoke callbacks
+	for _, cb := range m.onMilestone {
+		cb(result)
+	}
+
+	if len(result.Errors) > 0 {
+		return result, result.Errors[0]
+	}
+	return result, nil
+}
+
+// SessionTokens returns the tokens recorded in the current session.
+func (m *LifecycleManager) SessionTokens() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.sessionTokens
+}
+
+// createMilestoneSummary synthesizes the experiences made since the last
+// milestone, together with its summary, into a new milestone summary that
+// replaces it.
+func (m *LifecycleManager) createMilestoneSummary(ctx context.Context, milestone *MilestoneResult) error {
+	nodes, err := m.store.ListNodes(ctx, hypergraph.NodeFilter{
+		Types: []hypergraph.NodeType{hypergraph.NodeTypeExperience},
+		Tiers: []hypergraph.Tier{hypergraph.TierTask, hypergraph.TierSession},
+		Limit: 100,
+	})
+	if err != nil {
+		return fmt.Errorf("query experiences: %w", err)
+	}
+
+	var since time.Time
+	if m.lastMilestone != nil {
+		since = m.lastMilestone.CreatedAt
+	}
+	var fresh []*hypergraph.Node
+	for _, node := range nodes {
+		if node.CreatedAt.After(since) {
+			fresh = append(fresh, node)
+		}
+	}
+
+	// Nothing happened since the last milestone, so its summary stands
+	if len(fresh) == 0 {
+		return nil
+	}
+
+	experiences := fresh
+	if m.lastMilestone != nil {
+		experiences = append([]*hypergraph.Node{m.lastMilestone}, fresh...)
+	}
+
+	summary, err := m.synthesizer.Synthesize(ctx, m.sessionID, experiences, time.Since(m.sessionStart))
+	if err != nil {
+		return fmt.Errorf("synthesize: %w", err)
+	}
+	summary.Milestone = milestone.Milestone
+	summary.Tokens = milestone.SessionTokens
+
+	// Stored to TierLongterm like session summaries, so ResumeSession can
+	// find it while the session is still going
+	node := hypergraph.NewNode(hypergraph.NodeTypeExperience, summary.Summary)
+	node.Subtype = MilestoneSummarySubtype
+	node.Tier = hypergraph.TierLongterm
+	node.Confidence = 1.0
+
+	metadataBytes, err := json.Marshal(summary)
+	if err != nil {
+		return fmt.Errorf("marshal summary: %w", err)
+	}
+	node.Metadata = metadataBytes
+
+	if err := m.store.CreateNode(ctx, node); err != nil {
+		return fmt.Errorf("create node: %w", err)
+	}
+	if err := m.retireMilestone(ctx); err != nil {
+		return fmt.Errorf("retire previous milestone summary: %w", err)
+	}
+	m.lastMilestone = node
+	milestone.Summary = summary
+
+	if m.config.CompressOnMilestone {
+		for _, exp := range fresh {
+			if exp.Pinned {
+				continue
+			}
+			exp.Tier = hypergraph.TierArchive
+			if err := m.store.UpdateNode(ctx, exp); err != nil {
+				return fmt.Errorf("archive node %s: %w", exp.ID, err)
+			}
+			milestone.NodesCompressed++
+		}
+	}
+
+	return nil
+}
+
+// retireMilestone archives the session's latest milestone summary once a
+// newer summary covers it.
+func (m *LifecycleManager) retireMilestone(ctx context.Context) error {
+	if m.lastMilestone == nil {
+		return nil
+	}
+	m.lastMilestone.Tier = hypergraph.TierArchive
+	if err := m.store.UpdateNode(ctx, m.lastMilestone); err != nil {
+		return err
+	}
+	m.lastMilestone = nil
+	return nil
+}
+
+// resetMilestones restarts milestone tracking for a new session.
+func (m *LifecycleManager) resetMilestones() {
+	m.sessionTokens = 0
+	m.nextMilestone = 0
+	m.lastMilestone = nil
+}
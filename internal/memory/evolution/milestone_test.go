@@ -0,0 +1,199 @@
+package evolution
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rand/recurse/internal/memory/hypergraph"
+)
+
+// recordingSynthesizer summarizes by listing the experiences it was given.
+type recordingSynthesizer struct {
+	calls [][]*hypergraph.Node
+}
+
+func (s *recordingSynthesizer) Synthesize(ctx context.Context, sessionID string, experiences []*hypergraph.Node, duration time.Duration) (*SessionSummary, error) {
+	s.calls = append(s.calls, experiences)
+	summary := &SessionSummary{SessionID: sessionID, Duration: duration}
+	for _, exp := range experiences {
+		summary.Summary += exp.Content + "; "
+	}
+	return summary, nil
+}
+
+func addExperience(t *testing.T, store *hypergraph.Store, content string) *hypergraph.Node {
+	t.Helper()
+	node := hypergraph.NewNode(hypergraph.NodeTypeExperience, content)
+	node.Tier = hypergraph.TierSession
+	require.NoError(t, store.CreateNode(context.Background(), node))
+	// Keep creation times distinct from the milestone summaries'
+	time.Sleep(2 * time.Millisecond)
+	return node
+}
+
+func milestoneNodes(t *testing.T, store *hypergraph.Store, tiers ...hypergraph.Tier) []*hypergraph.Node {
+	t.Helper()
+	nodes, err := store.ListNodes(context.Background(), hypergraph.NodeFilter{
+		Subtypes: []string{MilestoneSummarySubtype},
+		Tiers:    tiers,
+	})
+	require.NoError(t, err)
+	return nodes
+}
+
+func TestRecordTokens_SummarizesAtEachMilestone(t *testing.T) {
+	store := createTestStoreLifecycle(t)
+	cfg := DefaultLifecycleConfig()
+	cfg.SummaryMilestones = []int{1000, 2000, 4000}
+
+	mgr, err := NewLifecycleManager(store, cfg)
+	require.NoError(t, err)
+	defer mgr.Close()
+
+	synth := &recordingSynthesizer{}
+	mgr.SetSessionSynthesizer(synth)
+	mgr.StartSession("long-session")
+
+	var callbacks int
+	mgr.OnMilestone(func(*LifecycleResult) { callbacks++ })
+
+	ctx := context.Background()
+	addExperience(t, store, "read the parser")
+
+	result, err := mgr.RecordTokens(ctx, 600)
+	require.NoError(t, err)
+	assert.Nil(t, result, "below the first milestone")
+
+	for i, milestone := range cfg.SummaryMilestones {
+		if i > 0 {
+			addExperience(t, store, fmt.Sprintf("step %d", i))
+		}
+		result, err := mgr.RecordTokens(ctx, milestone-mgr.SessionTokens())
+		require.NoError(t, err)
+		require.NotNil(t, result, "milestone %d", milestone)
+
+		assert.Equal(t, "milestone", result.Operation)
+		require.NotNil(t, result.Milestone)
+		assert.Equal(t, milestone, result.Milestone.Milestone)
+		assert.Equal(t, milestone, result.Milestone.SessionTokens)
+		require.NotNil(t, result.Milestone.Summary)
+		assert.Equal(t, "long-session", result.Milestone.Summary.SessionID)
+		assert.Equal(t, milestone, result.Milestone.Summary.Milestone)
+
+		// The latest summary is retrievable and supersedes earlier ones
+		current := milestoneNodes(t, store, hypergraph.TierLongterm)
+		require.Len(t, current, 1)
+		var stored SessionSummary
+		require.NoError(t, json.Unmarshal(current[0].Metadata, &stored))
+		assert.Equal(t, milestone, stored.Milestone)
+		assert.Equal(t, result.Milestone.Summary.Summary, current[0].Content)
+	}
+
+	assert.Equal(t, 3, callbacks)
+	assert.Len(t, milestoneNodes(t, store, hypergraph.TierArchive), 2, "earlier summaries are archived")
+
+	// Each summary is incremental: the previous summary plus what is new
+	require.Len(t, synth.calls, 3)
+	assert.Len(t, synth.calls[0], 1)
+	require.Len(t, synth.calls[2], 2)
+	assert.Equal(t, MilestoneSummarySubtype, synth.calls[2][0].Subtype)
+	assert.Equal(t, "step 2", synth.calls[2][1].Content)
+	assert.Contains(t, synth.calls[2][0].Content, "step 1")
+
+	result, err = mgr.RecordTokens(ctx, 10000)
+	require.NoError(t, err)
+	assert.Nil(t, result, "all milestones passed")
+}
+
+func TestRecordTokens_CrossingSeveralMilestonesSummarizesOnce(t *testing.T) {
+	store := createTestStoreLifecycle(t)
+	cfg := DefaultLifecycleConfig()
+	cfg.SummaryMilestones = []int{100, 200, 300}
+
+	mgr, err := NewLifecycleManager(store, cfg)
+	require.NoError(t, err)
+	defer mgr.Close()
+
+	synth := &recordingSynthesizer{}
+	mgr.SetSessionSynthesizer(synth)
+	addExperience(t, store, "bulk import")
+
+	result, err := mgr.RecordTokens(context.Background(), 250)
+	require.NoError(t, err)
+	require.NotNil(t, result)
+	assert.Equal(t, 200, result.Milestone.Milestone)
+	assert.Len(t, synth.calls, 1)
+
+	// Nothing new by the next milestone: the existing summary stands
+	result, err = mgr.RecordTokens(context.Background(), 50)
+	require.NoError(t, err)
+	require.NotNil(t, result)
+	assert.Nil(t, result.Milestone.Summary)
+	assert.Len(t, synth.calls, 1)
+}
+
+func TestRecordTokens_CompressOnMilestone(t *testing.T) {
+	store := createTestStoreLifecycle(t)
+	cfg := DefaultLifecycleConfig()
+	cfg.SummaryMilestones = []int{100}
+	cfg.CompressOnMilestone = true
+
+	mgr, err := NewLifecycleManager(store, cfg)
+	require.NoError(t, err)
+	defer mgr.Close()
+	mgr.SetSessionSynthesizer(&recordingSynthesizer{})
+
+	ctx := context.Background()
+	plain := addExperience(t, store, "ran the tests")
+	pinned := addExperience(t, store, "user decision")
+	require.NoError(t, store.PinNode(ctx, pinned.ID))
+
+	result, err := mgr.RecordTokens(ctx, 100)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Milestone.NodesCompressed)
+
+	got, err := store.GetNode(ctx, plain.ID)
+	require.NoError(t, err)
+	assert.Equal(t, hypergraph.TierArchive, got.Tier)
+	got, err = store.GetNode(ctx, pinned.ID)
+	require.NoError(t, err)
+	assert.Equal(t, hypergraph.TierSession, got.Tier, "pinned nodes are kept")
+}
+
+func TestSessionEnd_FoldsInMilestoneSummary(t *testing.T) {
+	store := createTestStoreLifecycle(t)
+	cfg := DefaultLifecycleConfig()
+	cfg.SummaryMilestones = []int{100}
+	cfg.CompressOnMilestone = true
+
+	mgr, err := NewLifecycleManager(store, cfg)
+	require.NoError(t, err)
+	defer mgr.Close()
+
+	synth := &recordingSynthesizer{}
+	mgr.SetSessionSynthesizer(synth)
+
+	ctx := context.Background()
+	addExperience(t, store, "early work")
+	_, err = mgr.RecordTokens(ctx, 150)
+	require.NoError(t, err)
+	addExperience(t, store, "late work")
+
+	_, err = mgr.SessionEnd(ctx)
+	require.NoError(t, err)
+
+	require.Len(t, synth.calls, 2)
+	final := synth.calls[1]
+	require.Len(t, final, 2)
+	assert.Equal(t, MilestoneSummarySubtype, final[0].Subtype)
+	assert.Equal(t, "late work", final[1].Content)
+
+	assert.Empty(t, milestoneNodes(t, store, hypergraph.TierLongterm), "the session summary supersedes it")
+	assert.Zero(t, mgr.SessionTokens())
+}
@@ -23,7 +23,7 @@ func NewSQLiteOutcomeStore(store *hypergraph.Store) *SQLiteOutcomeStore {
 func (s *SQLiteOutcomeStore) RecordOutcome(ctx context.Context, outcome hypergraph.RetrievalOutcome) error {
 	db := s.store.DB()
 	if db == nil {
-		return fmt.Errorf("database not available")
+		return hypergraph.ErrNoSQLBackend
 	}
 
 	query := `
@@ -55,7 +55,7 @@ func (s *SQLiteOutcomeStore) RecordOutcome(ctx context.Context, outcome hypergra
 func (s *SQLiteOutcomeStore) QueryOutcomes(ctx context.Context, since time.Time) ([]RetrievalOutcome, error) {
 	db := s.store.DB()
 	if db == nil {
-		return nil, fmt.Errorf("database not available")
+		return nil, hypergraph.ErrNoSQLBackend
 	}
 
 	query := `
@@ -79,7 +79,7 @@ func (s *SQLiteOutcomeStore) QueryOutcomes(ctx context.Context, since time.Time)
 func (s *SQLiteOutcomeStore) QueryOutcomesByNodeType(ctx context.Context, nodeType string, since time.Time) ([]RetrievalOutcome, error) {
 	db := s.store.DB()
 	if db == nil {
-		return nil, fmt.Errorf("database not available")
+		return nil, hypergraph.ErrNoSQLBackend
 	}
 
 	query := `
@@ -103,7 +103,7 @@ func (s *SQLiteOutcomeStore) QueryOutcomesByNodeType(ctx context.Context, nodeTy
 func (s *SQLiteOutcomeStore) QueryOutcomesByQueryType(ctx context.Context, queryType string, since time.Time) ([]RetrievalOutcome, error) {
 	db := s.store.DB()
 	if db == nil {
-		return nil, fmt.Errorf("database not available")
+		return nil, hypergraph.ErrNoSQLBackend
 	}
 
 	query := `
@@ -127,7 +127,7 @@ func (s *SQLiteOutcomeStore) QueryOutcomesByQueryType(ctx context.Context, query
 func (s *SQLiteOutcomeStore) GetOutcomeStats(ctx context.Context, since time.Time) (*OutcomeStats, error) {
 	db := s.store.DB()
 	if db == nil {
-		return nil, fmt.Errorf("database not available")
+		return nil, hypergraph.ErrNoSQLBackend
 	}
 
 	// Get overall stats
@@ -222,7 +222,7 @@ func (s *SQLiteOutcomeStore) GetOutcomeStats(ctx context.Context, since time.Tim
 func (s *SQLiteOutcomeStore) MarkUsed(ctx context.Context, nodeID, queryHash string) error {
 	db := s.store.DB()
 	if db == nil {
-		return fmt.Errorf("database not available")
+		return hypergraph.ErrNoSQLBackend
 	}
 
 	query := `
@@ -243,7 +243,7 @@ func (s *SQLiteOutcomeStore) MarkUsed(ctx context.Context, nodeID, queryHash str
 func (s *SQLiteOutcomeStore) Prune(ctx context.Context, before time.Time) (int64, error) {
 	db := s.store.DB()
 	if db == nil {
-		return 0, fmt.Errorf("database not available")
+		return 0, hypergraph.ErrNoSQLBackend
 	}
 
 	query := `DELETE FROM retrieval_outcomes WHERE timestamp < ?`
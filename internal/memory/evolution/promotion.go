@@ -259,6 +259,9 @@ func (p *Promoter) Demote(ctx context.Context, nodeID string, targetTier hypergr
 	if tierOrder[targetTier] >= tierOrder[node.Tier] && targetTier != hypergraph.TierArchive {
 		return fmt.Errorf("target tier %s is not lower than current tier %s", targetTier, node.Tier)
 	}
+	if node.Pinned {
+		return fmt.Errorf("node %s is pinned", nodeID)
+	}
 
 	node.Tier = targetTier
 	return p.store.UpdateNode(ctx, node)
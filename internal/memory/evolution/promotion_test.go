@@ -333,6 +333,25 @@ func TestDemote_InvalidTarget(t *testing.T) {
 	assert.Contains(t, err.Error(), "not lower than")
 }
 
+func TestDemote_Pinned(t *testing.T) {
+	store := createTestStore(t)
+	cfg := DefaultPromotionConfig()
+	cfg.ConsolidateOnPromotion = false
+	p := NewPromoter(store, cfg)
+	ctx := context.Background()
+
+	node := hypergraph.NewNode(hypergraph.NodeTypeFact, "Keep me")
+	node.Tier = hypergraph.TierLongterm
+	require.NoError(t, store.CreateNode(ctx, node))
+	require.NoError(t, store.PinNode(ctx, node.ID))
+
+	assert.Error(t, p.Demote(ctx, node.ID, hypergraph.TierArchive))
+
+	updated, err := store.GetNode(ctx, node.ID)
+	require.NoError(t, err)
+	assert.Equal(t, hypergraph.TierLongterm, updated.Tier)
+}
+
 func TestGetPromotionCandidates(t *testing.T) {
 	store := createTestStore(t)
 	cfg := DefaultPromotionConfig()
@@ -25,7 +25,7 @@ func NewSQLiteProposalStore(store *hypergraph.Store) *SQLiteProposalStore {
 func (s *SQLiteProposalStore) Save(ctx context.Context, proposal *Proposal) error {
 	db := s.store.DB()
 	if db == nil {
-		return fmt.Errorf("database not available")
+		return hypergraph.ErrNoSQLBackend
 	}
 
 	evidenceJSON, err := json.Marshal(proposal.Evidence)
@@ -89,7 +89,7 @@ func (s *SQLiteProposalStore) Save(ctx context.Context, proposal *Proposal) erro
 func (s *SQLiteProposalStore) Get(ctx context.Context, id string) (*Proposal, error) {
 	db := s.store.DB()
 	if db == nil {
-		return nil, fmt.Errorf("database not available")
+		return nil, hypergraph.ErrNoSQLBackend
 	}
 
 	query := `
@@ -108,7 +108,7 @@ func (s *SQLiteProposalStore) Get(ctx context.Context, id string) (*Proposal, er
 func (s *SQLiteProposalStore) List(ctx context.Context, filter ProposalFilter) ([]*Proposal, error) {
 	db := s.store.DB()
 	if db == nil {
-		return nil, fmt.Errorf("database not available")
+		return nil, hypergraph.ErrNoSQLBackend
 	}
 
 	// Build query dynamically
@@ -200,7 +200,7 @@ func (s *SQLiteProposalStore) List(ctx context.Context, filter ProposalFilter) (
 func (s *SQLiteProposalStore) Update(ctx context.Context, proposal *Proposal) error {
 	db := s.store.DB()
 	if db == nil {
-		return fmt.Errorf("database not available")
+		return hypergraph.ErrNoSQLBackend
 	}
 
 	evidenceJSON, err := json.Marshal(proposal.Evidence)
@@ -274,7 +274,7 @@ func (s *SQLiteProposalStore) Update(ctx context.Context, proposal *Proposal) er
 func (s *SQLiteProposalStore) Delete(ctx context.Context, id string) error {
 	db := s.store.DB()
 	if db == nil {
-		return fmt.Errorf("database not available")
+		return hypergraph.ErrNoSQLBackend
 	}
 
 	query := `DELETE FROM proposals WHERE id = ?`
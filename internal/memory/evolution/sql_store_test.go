@@ -0,0 +1,40 @@
+package evolution
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rand/recurse/internal/memory/hypergraph"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSQLiteStores_NonSQLBackend(t *testing.T) {
+	store, err := hypergraph.NewStore(hypergraph.Options{Backend: hypergraph.NewInMemoryBackend()})
+	require.NoError(t, err)
+	t.Cleanup(func() { store.Close() })
+	ctx := context.Background()
+
+	// Outcomes and proposals live in SQL tables, so they report the
+	// missing capability instead of dereferencing a nil database.
+	outcomes := NewSQLiteOutcomeStore(store)
+	err = outcomes.RecordOutcome(ctx, hypergraph.RetrievalOutcome{NodeID: "n1"})
+	assert.ErrorIs(t, err, hypergraph.ErrNoSQLBackend)
+	_, err = outcomes.QueryOutcomes(ctx, time.Time{})
+	assert.ErrorIs(t, err, hypergraph.ErrNoSQLBackend)
+	_, err = outcomes.GetOutcomeStats(ctx, time.Time{})
+	assert.ErrorIs(t, err, hypergraph.ErrNoSQLBackend)
+	_, err = outcomes.Prune(ctx, time.Now())
+	assert.ErrorIs(t, err, hypergraph.ErrNoSQLBackend)
+
+	proposals := NewSQLiteProposalStore(store)
+	err = proposals.Save(ctx, &Proposal{ID: "p1"})
+	assert.ErrorIs(t, err, hypergraph.ErrNoSQLBackend)
+	_, err = proposals.Get(ctx, "p1")
+	assert.ErrorIs(t, err, hypergraph.ErrNoSQLBackend)
+	_, err = proposals.List(ctx, ProposalFilter{})
+	assert.ErrorIs(t, err, hypergraph.ErrNoSQLBackend)
+	err = proposals.Delete(ctx, "p1")
+	assert.ErrorIs(t, err, hypergraph.ErrNoSQLBackend)
+}
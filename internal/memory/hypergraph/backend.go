@@ -3,16 +3,47 @@ package hypergraph
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"time"
 )
 
 // Backend defines the interface for hypergraph storage backends.
 // Implementations include SQLiteBackend for production and InMemoryBackend for testing.
+// Alternative backends (e.g., Postgres) are plugged in via Options.Backend and
+// should pass the conformance suite in the hypergraphtest package.
+//
+// Contract:
+//   - Get, Update, Delete, and membership removal return *ErrNotFound for
+//     missing entities so callers can use IsNotFound.
+//   - CreateNode and CreateHyperedge fill in a generated ID, timestamps,
+//     and defaults (tier task, confidence 1.0, weight 1.0) when unset.
+//   - CreateNode with an IdempotencyKey already held by a stored node
+//     (soft-deleted or not) inserts nothing and fills the argument in with
+//     the stored node. UpdateNode leaves the key unchanged.
+//   - Returned values are copies; mutating them does not affect stored data.
+//   - Soft-deleted nodes are invisible to GetNode, UpdateNode,
+//     IncrementAccess, searches, traversal, RecentNodes, and Stats;
+//     ListNodes and CountNodes include them only when
+//     NodeFilter.IncludeDeleted is set.
+//   - RestoreNode clears the soft-delete marker and sets UpdatedAt to the
+//     restore time; restoring a live or purged node returns *ErrNotFound.
+//   - Archived nodes are excluded from SearchByContent, GetConnected, and
+//     RecentNodes.
+//   - Node.Pinned is stored by CreateNode and UpdateNode and returned by
+//     every read, including GetConnected. SearchByContent returns pinned
+//     matches before unpinned ones, and GetConnected includes pinned nodes
+//     even if archived.
+//   - Implementations must be safe for concurrent use.
 type Backend interface {
 	// Node operations
 	CreateNode(ctx context.Context, node *Node) error
 	GetNode(ctx context.Context, id string) (*Node, error)
 	UpdateNode(ctx context.Context, node *Node) error
 	DeleteNode(ctx context.Context, id string) error
+	SoftDeleteNode(ctx context.Context, id string) error
+	RestoreNode(ctx context.Context, id string) error
+	PurgeDeleted(ctx context.Context, olderThan time.Duration) (int64, error)
 	ListNodes(ctx context.Context, filter NodeFilter) ([]*Node, error)
 	CountNodes(ctx context.Context, filter NodeFilter) (int64, error)
 	IncrementAccess(ctx context.Context, id string) error
@@ -43,6 +74,50 @@ type Backend interface {
 	Close() error
 }
 
+// SQLBackend is implemented by backends built on database/sql.
+// The Store uses the database for features outside the Backend interface:
+// transactions, the evolution log, and the embedding index.
+type SQLBackend interface {
+	Backend
+
+	// DB returns the underlying database connection.
+	DB() *sql.DB
+}
+
+// ReadTx is a consistent read-only snapshot: every read sees the data as it
+// was when the snapshot began, whatever is written meanwhile. Close releases
+// it. Use a ReadTx from one goroutine at a time.
+type ReadTx interface {
+	GetNode(ctx context.Context, id string) (*Node, error)
+	ListNodes(ctx context.Context, filter NodeFilter) ([]*Node, error)
+	CountNodes(ctx context.Context, filter NodeFilter) (int64, error)
+	GetHyperedge(ctx context.Context, id string) (*Hyperedge, error)
+	ListHyperedges(ctx context.Context, filter HyperedgeFilter) ([]*Hyperedge, error)
+	GetMembers(ctx context.Context, hyperedgeID string) ([]Membership, error)
+	GetMemberNodes(ctx context.Context, hyperedgeID string) ([]*Node, error)
+	GetNodeHyperedges(ctx context.Context, nodeID string) ([]*Hyperedge, error)
+	SearchByContent(ctx context.Context, query string, opts SearchOptions) ([]*SearchResult, error)
+	GetConnected(ctx context.Context, nodeID string, opts TraversalOptions) ([]*ConnectedNode, error)
+	RecentNodes(ctx context.Context, limit int, tiers []Tier) ([]*Node, error)
+	Stats(ctx context.Context) (*Stats, error)
+
+	// Close ends the snapshot. It is safe to call more than once.
+	Close() error
+}
+
+// ReadTxBackend is implemented by backends that support read snapshots.
+type ReadTxBackend interface {
+	Backend
+
+	// BeginReadTx starts a read snapshot. Writes made outside it proceed
+	// normally and are not visible to it.
+	BeginReadTx(ctx context.Context) (ReadTx, error)
+}
+
+// ErrReadTxUnsupported is returned by BeginReadTx when the backend cannot
+// provide snapshot isolation.
+var ErrReadTxUnsupported = errors.New("backend does not support read snapshots")
+
 // ErrNotFound is returned when an entity is not found.
 type ErrNotFound struct {
 	Entity string
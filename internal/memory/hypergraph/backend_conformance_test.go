@@ -0,0 +1,24 @@
+package hypergraph_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/rand/recurse/internal/memory/hypergraph"
+	"github.com/rand/recurse/internal/memory/hypergraph/hypergraphtest"
+)
+
+func TestInMemoryBackend(t *testing.T) {
+	hypergraphtest.RunBackendConformance(t, func() hypergraph.Backend {
+		return hypergraph.NewInMemoryBackend()
+	})
+}
+
+func TestSQLiteBackend(t *testing.T) {
+	hypergraphtest.RunBackendConformance(t, func() hypergraph.Backend {
+		backend, err := hypergraph.NewSQLiteBackend(hypergraph.SQLiteBackendOptions{})
+		require.NoError(t, err)
+		return backend
+	})
+}
@@ -69,215 +69,72 @@ func NewHyperedge(edgeType HyperedgeType, label string) *Hyperedge {
 }
 
 // CreateHyperedge inserts a new hyperedge into the database.
+// Unregistered types are rejected or logged according to the store's
+// edge type registry.
 func (s *Store) CreateHyperedge(ctx context.Context, edge *Hyperedge) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	if edge.ID == "" {
-		edge.ID = uuid.New().String()
-	}
-	if edge.CreatedAt.IsZero() {
-		edge.CreatedAt = time.Now().UTC()
-	}
-	if edge.Weight == 0 {
-		edge.Weight = 1.0
-	}
-
-	_, err := s.db.ExecContext(ctx, `
-		INSERT INTO hyperedges (id, type, label, weight, created_at, metadata)
-		VALUES (?, ?, ?, ?, ?, ?)
-	`, edge.ID, edge.Type, nullString(edge.Label), edge.Weight, edge.CreatedAt, edge.Metadata)
+	warning, err := s.edgeTypes.checkType(edge.Type)
 	if err != nil {
-		return fmt.Errorf("insert hyperedge: %w", err)
+		return err
 	}
-
-	return nil
+	if warning != "" {
+		s.logger.Warn("Creating hyperedge of unregistered type", "type", warning)
+	}
+	return s.backend.CreateHyperedge(ctx, edge)
 }
 
 // GetHyperedge retrieves a hyperedge by ID.
 func (s *Store) GetHyperedge(ctx context.Context, id string) (*Hyperedge, error) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-
-	row := s.db.QueryRowContext(ctx, `
-		SELECT id, type, label, weight, created_at, metadata
-		FROM hyperedges WHERE id = ?
-	`, id)
-
-	return scanHyperedge(row)
+	return s.backend.GetHyperedge(ctx, id)
 }
 
 // UpdateHyperedge updates an existing hyperedge.
 func (s *Store) UpdateHyperedge(ctx context.Context, edge *Hyperedge) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	result, err := s.db.ExecContext(ctx, `
-		UPDATE hyperedges SET type = ?, label = ?, weight = ?, metadata = ?
-		WHERE id = ?
-	`, edge.Type, nullString(edge.Label), edge.Weight, edge.Metadata, edge.ID)
-	if err != nil {
-		return fmt.Errorf("update hyperedge: %w", err)
-	}
-
-	rows, err := result.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("rows affected: %w", err)
-	}
-	if rows == 0 {
-		return fmt.Errorf("hyperedge not found: %s", edge.ID)
-	}
-
-	return nil
+	return s.backend.UpdateHyperedge(ctx, edge)
 }
 
 // DeleteHyperedge removes a hyperedge by ID.
 func (s *Store) DeleteHyperedge(ctx context.Context, id string) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	result, err := s.db.ExecContext(ctx, "DELETE FROM hyperedges WHERE id = ?", id)
-	if err != nil {
-		return fmt.Errorf("delete hyperedge: %w", err)
-	}
-
-	rows, err := result.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("rows affected: %w", err)
-	}
-	if rows == 0 {
-		return fmt.Errorf("hyperedge not found: %s", id)
-	}
-
-	return nil
+	return s.backend.DeleteHyperedge(ctx, id)
 }
 
 // AddMember adds a node to a hyperedge with the specified role.
+// It fails with ErrEdgeConstraint if the role or arity constraints of the
+// edge's type do not allow the member.
 func (s *Store) AddMember(ctx context.Context, m Membership) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	_, err := s.db.ExecContext(ctx, `
-		INSERT INTO membership (hyperedge_id, node_id, role, position)
-		VALUES (?, ?, ?, ?)
-	`, m.HyperedgeID, m.NodeID, m.Role, m.Position)
-	if err != nil {
-		return fmt.Errorf("add member: %w", err)
+	if s.edgeTypes.hasConstraints() {
+		edge, err := s.backend.GetHyperedge(ctx, m.HyperedgeID)
+		if err != nil {
+			return err
+		}
+		existing, err := s.backend.GetMembers(ctx, m.HyperedgeID)
+		if err != nil {
+			return err
+		}
+		if err := s.edgeTypes.checkMember(edge.Type, existing, m); err != nil {
+			return err
+		}
 	}
-
-	return nil
+	return s.backend.AddMember(ctx, m)
 }
 
 // RemoveMember removes a node from a hyperedge.
 func (s *Store) RemoveMember(ctx context.Context, hyperedgeID, nodeID string, role MemberRole) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	result, err := s.db.ExecContext(ctx, `
-		DELETE FROM membership WHERE hyperedge_id = ? AND node_id = ? AND role = ?
-	`, hyperedgeID, nodeID, role)
-	if err != nil {
-		return fmt.Errorf("remove member: %w", err)
-	}
-
-	rows, err := result.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("rows affected: %w", err)
-	}
-	if rows == 0 {
-		return fmt.Errorf("membership not found")
-	}
-
-	return nil
+	return s.backend.RemoveMember(ctx, hyperedgeID, nodeID, role)
 }
 
 // GetMembers returns all members of a hyperedge.
 func (s *Store) GetMembers(ctx context.Context, hyperedgeID string) ([]Membership, error) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-
-	rows, err := s.db.QueryContext(ctx, `
-		SELECT hyperedge_id, node_id, role, position
-		FROM membership WHERE hyperedge_id = ?
-		ORDER BY position
-	`, hyperedgeID)
-	if err != nil {
-		return nil, fmt.Errorf("query members: %w", err)
-	}
-	defer rows.Close()
-
-	var members []Membership
-	for rows.Next() {
-		var m Membership
-		var role sql.NullString
-		var position sql.NullInt64
-		if err := rows.Scan(&m.HyperedgeID, &m.NodeID, &role, &position); err != nil {
-			return nil, fmt.Errorf("scan member: %w", err)
-		}
-		m.Role = MemberRole(role.String)
-		m.Position = int(position.Int64)
-		members = append(members, m)
-	}
-
-	return members, rows.Err()
+	return s.backend.GetMembers(ctx, hyperedgeID)
 }
 
 // GetMemberNodes returns all nodes that are members of a hyperedge.
 func (s *Store) GetMemberNodes(ctx context.Context, hyperedgeID string) ([]*Node, error) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-
-	rows, err := s.db.QueryContext(ctx, `
-		SELECT n.id, n.type, n.subtype, n.content, n.embedding, n.created_at, n.updated_at,
-		       n.access_count, n.last_accessed, n.tier, n.confidence, n.provenance, n.metadata
-		FROM nodes n
-		JOIN membership m ON n.id = m.node_id
-		WHERE m.hyperedge_id = ?
-		ORDER BY m.position
-	`, hyperedgeID)
-	if err != nil {
-		return nil, fmt.Errorf("query member nodes: %w", err)
-	}
-	defer rows.Close()
-
-	var nodes []*Node
-	for rows.Next() {
-		node, err := scanNodeRows(rows)
-		if err != nil {
-			return nil, err
-		}
-		nodes = append(nodes, node)
-	}
-
-	return nodes, rows.Err()
+	return s.backend.GetMemberNodes(ctx, hyperedgeID)
 }
 
 // GetNodeHyperedges returns all hyperedges that a node belongs to.
 func (s *Store) GetNodeHyperedges(ctx context.Context, nodeID string) ([]*Hyperedge, error) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-
-	rows, err := s.db.QueryContext(ctx, `
-		SELECT h.id, h.type, h.label, h.weight, h.created_at, h.metadata
-		FROM hyperedges h
-		JOIN membership m ON h.id = m.hyperedge_id
-		WHERE m.node_id = ?
-	`, nodeID)
-	if err != nil {
-		return nil, fmt.Errorf("query node hyperedges: %w", err)
-	}
-	defer rows.Close()
-
-	var edges []*Hyperedge
-	for rows.Next() {
-		edge, err := scanHyperedgeRows(rows)
-		if err != nil {
-			return nil, err
-		}
-		edges = append(edges, edge)
-	}
-
-	return edges, rows.Err()
+	return s.backend.GetNodeHyperedges(ctx, nodeID)
 }
 
 // HyperedgeFilter defines criteria for filtering hyperedges.
@@ -290,52 +147,7 @@ type HyperedgeFilter struct {
 
 // ListHyperedges retrieves hyperedges matching the given filter.
 func (s *Store) ListHyperedges(ctx context.Context, filter HyperedgeFilter) ([]*Hyperedge, error) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-
-	query := "SELECT id, type, label, weight, created_at, metadata FROM hyperedges WHERE 1=1"
-	var args []any
-
-	if len(filter.Types) > 0 {
-		query += " AND type IN (?" + repeatString(",?", len(filter.Types)-1) + ")"
-		for _, t := range filter.Types {
-			args = append(args, t)
-		}
-	}
-
-	if filter.MinWeight > 0 {
-		query += " AND weight >= ?"
-		args = append(args, filter.MinWeight)
-	}
-
-	query += " ORDER BY created_at DESC"
-
-	if filter.Limit > 0 {
-		query += " LIMIT ?"
-		args = append(args, filter.Limit)
-	}
-
-	if filter.Offset > 0 {
-		query += " OFFSET ?"
-		args = append(args, filter.Offset)
-	}
-
-	rows, err := s.db.QueryContext(ctx, query, args...)
-	if err != nil {
-		return nil, fmt.Errorf("query hyperedges: %w", err)
-	}
-	defer rows.Close()
-
-	var edges []*Hyperedge
-	for rows.Next() {
-		edge, err := scanHyperedgeRows(rows)
-		if err != nil {
-			return nil, err
-		}
-		edges = append(edges, edge)
-	}
-
-	return edges, rows.Err()
+	return s.backend.ListHyperedges(ctx, filter)
 }
 
 // CreateRelation creates a hyperedge connecting nodes with a typed relationship.
@@ -372,26 +184,6 @@ func (s *Store) CreateRelation(ctx context.Context, label string, subjectID, obj
 
 // Helper functions
 
-func scanHyperedge(row *sql.Row) (*Hyperedge, error) {
-	var edge Hyperedge
-	var label, metadata sql.NullString
-
-	err := row.Scan(&edge.ID, &edge.Type, &label, &edge.Weight, &edge.CreatedAt, &metadata)
-	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("hyperedge not found")
-	}
-	if err != nil {
-		return nil, fmt.Errorf("scan hyperedge: %w", err)
-	}
-
-	edge.Label = label.String
-	if metadata.Valid {
-		edge.Metadata = json.RawMessage(metadata.String)
-	}
-
-	return &edge, nil
-}
-
 func scanHyperedgeRows(rows *sql.Rows) (*Hyperedge, error) {
 	var edge Hyperedge
 	var label, metadata sql.NullString
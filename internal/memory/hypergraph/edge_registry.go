@@ -0,0 +1,274 @@
+package hypergraph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ErrUnknownEdgeType is returned when creating a hyperedge of an
+// unregistered type while unknown types are rejected.
+var ErrUnknownEdgeType = errors.New("unknown hyperedge type")
+
+// ErrEdgeConstraint is returned when a hyperedge's members violate its
+// type's role or arity constraints.
+var ErrEdgeConstraint = errors.New("hyperedge constraint violated")
+
+// UnknownEdgeTypePolicy decides what happens to hyperedges of unregistered
+// types.
+type UnknownEdgeTypePolicy int
+
+const (
+	// UnknownEdgeTypeWarn allows unknown types, logging a warning.
+	UnknownEdgeTypeWarn UnknownEdgeTypePolicy = iota
+
+	// UnknownEdgeTypeReject refuses unknown types with ErrUnknownEdgeType.
+	UnknownEdgeTypeReject
+)
+
+// RoleConstraint bounds how many members of a hyperedge may take a role.
+type RoleConstraint struct {
+	Role MemberRole `json:"role"`
+
+	// Min is the fewest members the role needs, checked by
+	// ValidateHyperedge once the edge is complete.
+	Min int `json:"min,omitempty"`
+
+	// Max is the most members the role allows, checked as members are
+	// added. Zero means unbounded.
+	Max int `json:"max,omitempty"`
+}
+
+// EdgeTypeSpec describes a known hyperedge type.
+type EdgeTypeSpec struct {
+	Type        HyperedgeType `json:"type"`
+	Description string        `json:"description,omitempty"`
+
+	// Roles lists the roles members may take. Nil allows any role; when
+	// set, roles not listed are rejected.
+	Roles []RoleConstraint `json:"roles,omitempty"`
+
+	// MaxMembers bounds the edge's arity. Zero means unbounded.
+	MaxMembers int `json:"max_members,omitempty"`
+}
+
+// role returns the constraint for role, if the spec lists it.
+func (s *EdgeTypeSpec) role(role MemberRole) (RoleConstraint, bool) {
+	for _, rc := range s.Roles {
+		if rc.Role == role {
+			return rc, true
+		}
+	}
+	return RoleConstraint{}, false
+}
+
+// constrained reports whether the spec restricts memberships.
+func (s *EdgeTypeSpec) constrained() bool {
+	return s.Roles != nil || s.MaxMembers > 0
+}
+
+// EdgeTypeRegistry holds the known hyperedge types and their constraints.
+// It is safe for concurrent use.
+type EdgeTypeRegistry struct {
+	mu      sync.RWMutex
+	specs   map[HyperedgeType]*EdgeTypeSpec
+	unknown UnknownEdgeTypePolicy
+}
+
+// NewEdgeTypeRegistry creates an empty registry with the given policy for
+// unknown types.
+func NewEdgeTypeRegistry(unknown UnknownEdgeTypePolicy) *EdgeTypeRegistry {
+	return &EdgeTypeRegistry{
+		specs:   make(map[HyperedgeType]*EdgeTypeSpec),
+		unknown: unknown,
+	}
+}
+
+// DefaultEdgeTypeRegistry returns a registry of the built-in hyperedge
+// types, without role constraints, that warns on unknown types.
+func DefaultEdgeTypeRegistry() *EdgeTypeRegistry {
+	r := NewEdgeTypeRegistry(UnknownEdgeTypeWarn)
+	for _, t := range []HyperedgeType{
+		HyperedgeRelation, HyperedgeComposition, HyperedgeCausation, HyperedgeContext,
+		HyperedgeSpawns, HyperedgeConsiders, HyperedgeChooses, HyperedgeRejects,
+		HyperedgeImplements, HyperedgeProduces, HyperedgeInforms,
+	} {
+		r.specs[t] = &EdgeTypeSpec{Type: t}
+	}
+	return r
+}
+
+// Register adds or replaces a hyperedge type. The SQLite schema only stores
+// the built-in types and roles, so custom ones need another backend, such
+// as InMemoryBackend.
+func (r *EdgeTypeRegistry) Register(spec EdgeTypeSpec) error {
+	if spec.Type == "" {
+		return fmt.Errorf("register edge type: empty type")
+	}
+	seen := make(map[MemberRole]bool, len(spec.Roles))
+	for _, rc := range spec.Roles {
+		if seen[rc.Role] {
+			return fmt.Errorf("register edge type %q: duplicate role %q", spec.Type, rc.Role)
+		}
+		seen[rc.Role] = true
+		if rc.Min < 0 || rc.Max < 0 || (rc.Max > 0 && rc.Min > rc.Max) {
+			return fmt.Errorf("register edge type %q: invalid bounds for role %q", spec.Type, rc.Role)
+		}
+	}
+
+	spec.Roles = append([]RoleConstraint(nil), spec.Roles...)
+	r.mu.Lock()
+	r.specs[spec.Type] = &spec
+	r.mu.Unlock()
+	return nil
+}
+
+// Lookup returns the spec for a hyperedge type.
+func (r *EdgeTypeRegistry) Lookup(edgeType HyperedgeType) (EdgeTypeSpec, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	spec, ok := r.specs[edgeType]
+	if !ok {
+		return EdgeTypeSpec{}, false
+	}
+	return *spec, true
+}
+
+// Types returns the registered hyperedge types, sorted.
+func (r *EdgeTypeRegistry) Types() []HyperedgeType {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	types := make([]HyperedgeType, 0, len(r.specs))
+	for t := range r.specs {
+		types = append(types, t)
+	}
+	sort.Slice(types, func(i, j int) bool { return types[i] < types[j] })
+	return types
+}
+
+// SetUnknownPolicy sets what happens to hyperedges of unregistered types.
+func (r *EdgeTypeRegistry) SetUnknownPolicy(policy UnknownEdgeTypePolicy) {
+	r.mu.Lock()
+	r.unknown = policy
+	r.mu.Unlock()
+}
+
+// checkType checks that edgeType is registered, returning a warning to log
+// when it is not but unknown types are allowed.
+func (r *EdgeTypeRegistry) checkType(edgeType HyperedgeType) (warning string, err error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if _, ok := r.specs[edgeType]; ok {
+		return "", nil
+	}
+
+	msg := fmt.Sprintf("%q", edgeType)
+	if similar := r.similarType(edgeType); similar != "" {
+		msg += fmt.Sprintf(" (did you mean %q?)", similar)
+	}
+	if r.unknown == UnknownEdgeTypeReject {
+		return "", fmt.Errorf("%w: %s", ErrUnknownEdgeType, msg)
+	}
+	return msg, nil
+}
+
+// similarType returns a registered type spelled the same apart from case
+// and separators, such as "caused_by" for "causedBy". r.mu must be held.
+func (r *EdgeTypeRegistry) similarType(edgeType HyperedgeType) HyperedgeType {
+	want := normalizeEdgeType(edgeType)
+	for t := range r.specs {
+		if normalizeEdgeType(t) == want {
+			return t
+		}
+	}
+	return ""
+}
+
+func normalizeEdgeType(t HyperedgeType) string {
+	return strings.NewReplacer("_", "", "-", "", " ", "").Replace(strings.ToLower(string(t)))
+}
+
+// hasConstraints reports whether any registered type restricts
+// memberships, so stores can skip membership checks otherwise.
+func (r *EdgeTypeRegistry) hasConstraints() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, spec := range r.specs {
+		if spec.constrained() {
+			return true
+		}
+	}
+	return false
+}
+
+// checkMember checks that adding m keeps an edge of edgeType, with the
+// given existing members, within its constraints.
+func (r *EdgeTypeRegistry) checkMember(edgeType HyperedgeType, existing []Membership, m Membership) error {
+	spec, ok := r.Lookup(edgeType)
+	if !ok || !spec.constrained() {
+		return nil
+	}
+
+	if spec.MaxMembers > 0 && len(existing) >= spec.MaxMembers {
+		return fmt.Errorf("%w: %q edge allows at most %d members", ErrEdgeConstraint, edgeType, spec.MaxMembers)
+	}
+	if spec.Roles == nil {
+		return nil
+	}
+	rc, ok := spec.role(m.Role)
+	if !ok {
+		return fmt.Errorf("%w: %q edge does not allow role %q", ErrEdgeConstraint, edgeType, m.Role)
+	}
+	if rc.Max > 0 && countRole(existing, m.Role) >= rc.Max {
+		return fmt.Errorf("%w: %q edge allows at most %d %q members", ErrEdgeConstraint, edgeType, rc.Max, m.Role)
+	}
+	return nil
+}
+
+// checkComplete checks that an edge's members meet its type's minimums.
+func (r *EdgeTypeRegistry) checkComplete(edgeType HyperedgeType, members []Membership) error {
+	spec, ok := r.Lookup(edgeType)
+	if !ok {
+		return nil
+	}
+	for _, rc := range spec.Roles {
+		if n := countRole(members, rc.Role); n < rc.Min {
+			return fmt.Errorf("%w: %q edge needs at least %d %q members, has %d",
+				ErrEdgeConstraint, edgeType, rc.Min, rc.Role, n)
+		}
+	}
+	return nil
+}
+
+func countRole(members []Membership, role MemberRole) int {
+	n := 0
+	for _, m := range members {
+		if m.Role == role {
+			n++
+		}
+	}
+	return n
+}
+
+// EdgeTypes returns the store's hyperedge type registry.
+func (s *Store) EdgeTypes() *EdgeTypeRegistry {
+	return s.edgeTypes
+}
+
+// ValidateHyperedge checks that a hyperedge's members meet its type's role
+// minimums. Minimums cannot be enforced as members are added one at a time,
+// so call it once an edge is complete.
+func (s *Store) ValidateHyperedge(ctx context.Context, id string) error {
+	edge, err := s.backend.GetHyperedge(ctx, id)
+	if err != nil {
+		return err
+	}
+	members, err := s.backend.GetMembers(ctx, id)
+	if err != nil {
+		return err
+	}
+	return s.edgeTypes.checkComplete(edge.Type, members)
+}
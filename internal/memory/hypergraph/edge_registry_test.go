@@ -0,0 +1,188 @@
+package hypergraph
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	edgeCausedBy HyperedgeType = "caused_by"
+	roleCause    MemberRole    = "cause"
+	roleEffect   MemberRole    = "effect"
+)
+
+// newCausalStore returns an in-memory store, whose backend accepts custom
+// types and roles, where "caused_by" edges need exactly one cause and one
+// effect.
+func newCausalStore(t *testing.T, unknown UnknownEdgeTypePolicy) *Store {
+	t.Helper()
+	registry := DefaultEdgeTypeRegistry()
+	registry.SetUnknownPolicy(unknown)
+	require.NoError(t, registry.Register(EdgeTypeSpec{
+		Type:        edgeCausedBy,
+		Description: "effect was caused by cause",
+		Roles: []RoleConstraint{
+			{Role: roleCause, Min: 1, Max: 1},
+			{Role: roleEffect, Min: 1, Max: 1},
+		},
+	}))
+
+	store, err := NewStore(Options{Backend: NewInMemoryBackend(), EdgeTypes: registry})
+	require.NoError(t, err)
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+func createNodes(t *testing.T, store *Store, n int) []string {
+	t.Helper()
+	ids := make([]string, n)
+	for i := range ids {
+		node := NewNode(NodeTypeFact, "fact")
+		require.NoError(t, store.CreateNode(context.Background(), node))
+		ids[i] = node.ID
+	}
+	return ids
+}
+
+func TestStore_AddMember_RoleConstraints(t *testing.T) {
+	store := newCausalStore(t, UnknownEdgeTypeWarn)
+	ctx := context.Background()
+	ids := createNodes(t, store, 3)
+
+	edge := NewHyperedge(edgeCausedBy, "outage caused by deploy")
+	require.NoError(t, store.CreateHyperedge(ctx, edge))
+
+	// Valid memberships succeed
+	require.NoError(t, store.AddMember(ctx, Membership{HyperedgeID: edge.ID, NodeID: ids[0], Role: roleCause}))
+	require.NoError(t, store.AddMember(ctx, Membership{HyperedgeID: edge.ID, NodeID: ids[1], Role: roleEffect, Position: 1}))
+	require.NoError(t, store.ValidateHyperedge(ctx, edge.ID))
+
+	// A second cause exceeds the role's maximum
+	err := store.AddMember(ctx, Membership{HyperedgeID: edge.ID, NodeID: ids[2], Role: roleCause, Position: 2})
+	assert.ErrorIs(t, err, ErrEdgeConstraint)
+
+	// Roles outside the spec are rejected
+	err = store.AddMember(ctx, Membership{HyperedgeID: edge.ID, NodeID: ids[2], Role: RoleContext, Position: 2})
+	assert.ErrorIs(t, err, ErrEdgeConstraint)
+	assert.Contains(t, err.Error(), `does not allow role "context"`)
+
+	members, err := store.GetMembers(ctx, edge.ID)
+	require.NoError(t, err)
+	assert.Len(t, members, 2)
+}
+
+func TestStore_ValidateHyperedge_MissingRole(t *testing.T) {
+	store := newCausalStore(t, UnknownEdgeTypeWarn)
+	ctx := context.Background()
+	ids := createNodes(t, store, 1)
+
+	edge := NewHyperedge(edgeCausedBy, "effect without a cause")
+	require.NoError(t, store.CreateHyperedge(ctx, edge))
+	require.NoError(t, store.AddMember(ctx, Membership{HyperedgeID: edge.ID, NodeID: ids[0], Role: roleEffect}))
+
+	err := store.ValidateHyperedge(ctx, edge.ID)
+	assert.ErrorIs(t, err, ErrEdgeConstraint)
+	assert.Contains(t, err.Error(), `at least 1 "cause"`)
+}
+
+func TestStore_AddMember_MaxMembers(t *testing.T) {
+	registry := DefaultEdgeTypeRegistry()
+	require.NoError(t, registry.Register(EdgeTypeSpec{Type: "pair", MaxMembers: 2}))
+	store, err := NewStore(Options{Backend: NewInMemoryBackend(), EdgeTypes: registry})
+	require.NoError(t, err)
+	defer store.Close()
+
+	ctx := context.Background()
+	ids := createNodes(t, store, 3)
+	edge := NewHyperedge("pair", "two of a kind")
+	require.NoError(t, store.CreateHyperedge(ctx, edge))
+
+	// Any role is allowed, but only two members
+	require.NoError(t, store.AddMember(ctx, Membership{HyperedgeID: edge.ID, NodeID: ids[0], Role: RoleSubject}))
+	require.NoError(t, store.AddMember(ctx, Membership{HyperedgeID: edge.ID, NodeID: ids[1], Role: RoleParticipant}))
+	err = store.AddMember(ctx, Membership{HyperedgeID: edge.ID, NodeID: ids[2], Role: RoleParticipant})
+	assert.ErrorIs(t, err, ErrEdgeConstraint)
+
+	// Unconstrained types are unaffected
+	rel, err := store.CreateRelation(ctx, "related", ids[0], ids[2])
+	require.NoError(t, err)
+	assert.NotNil(t, rel)
+}
+
+func TestStore_AddMember_BuiltinTypeConstraints(t *testing.T) {
+	// Constraints on built-in types and roles work with the SQLite schema
+	registry := DefaultEdgeTypeRegistry()
+	require.NoError(t, registry.Register(EdgeTypeSpec{
+		Type: HyperedgeCausation,
+		Roles: []RoleConstraint{
+			{Role: RoleSubject, Min: 1, Max: 1},
+			{Role: RoleObject, Min: 1, Max: 1},
+			{Role: RoleContext},
+		},
+	}))
+	store, err := NewStore(Options{EdgeTypes: registry})
+	require.NoError(t, err)
+	defer store.Close()
+
+	ctx := context.Background()
+	ids := createNodes(t, store, 4)
+	edge := NewHyperedge(HyperedgeCausation, "decision led to outcome")
+	require.NoError(t, store.CreateHyperedge(ctx, edge))
+
+	require.NoError(t, store.AddMember(ctx, Membership{HyperedgeID: edge.ID, NodeID: ids[0], Role: RoleSubject}))
+	err = store.ValidateHyperedge(ctx, edge.ID)
+	assert.ErrorIs(t, err, ErrEdgeConstraint)
+
+	require.NoError(t, store.AddMember(ctx, Membership{HyperedgeID: edge.ID, NodeID: ids[1], Role: RoleObject, Position: 1}))
+	require.NoError(t, store.AddMember(ctx, Membership{HyperedgeID: edge.ID, NodeID: ids[2], Role: RoleContext, Position: 2}))
+	require.NoError(t, store.AddMember(ctx, Membership{HyperedgeID: edge.ID, NodeID: ids[3], Role: RoleContext, Position: 3}))
+	require.NoError(t, store.ValidateHyperedge(ctx, edge.ID))
+
+	err = store.AddMember(ctx, Membership{HyperedgeID: edge.ID, NodeID: ids[3], Role: RoleObject, Position: 4})
+	assert.ErrorIs(t, err, ErrEdgeConstraint)
+	err = store.AddMember(ctx, Membership{HyperedgeID: edge.ID, NodeID: ids[3], Role: RoleParticipant, Position: 4})
+	assert.ErrorIs(t, err, ErrEdgeConstraint)
+}
+
+func TestStore_CreateHyperedge_UnknownType(t *testing.T) {
+	ctx := context.Background()
+
+	// Allowed with a warning by default
+	warn := newCausalStore(t, UnknownEdgeTypeWarn)
+	require.NoError(t, warn.CreateHyperedge(ctx, NewHyperedge("causedBy", "typo")))
+
+	reject := newCausalStore(t, UnknownEdgeTypeReject)
+	err := reject.CreateHyperedge(ctx, NewHyperedge("causedBy", "typo"))
+	assert.ErrorIs(t, err, ErrUnknownEdgeType)
+	assert.Contains(t, err.Error(), `did you mean "caused_by"?`)
+
+	require.NoError(t, reject.CreateHyperedge(ctx, NewHyperedge(edgeCausedBy, "known")))
+	require.NoError(t, reject.CreateHyperedge(ctx, NewHyperedge(HyperedgeRelation, "built-in")))
+}
+
+func TestEdgeTypeRegistry_Register(t *testing.T) {
+	registry := NewEdgeTypeRegistry(UnknownEdgeTypeReject)
+
+	assert.Error(t, registry.Register(EdgeTypeSpec{}))
+	assert.Error(t, registry.Register(EdgeTypeSpec{
+		Type:  "dup",
+		Roles: []RoleConstraint{{Role: RoleSubject}, {Role: RoleSubject}},
+	}))
+	assert.Error(t, registry.Register(EdgeTypeSpec{
+		Type:  "bounds",
+		Roles: []RoleConstraint{{Role: RoleSubject, Min: 2, Max: 1}},
+	}))
+
+	require.NoError(t, registry.Register(EdgeTypeSpec{Type: "b"}))
+	require.NoError(t, registry.Register(EdgeTypeSpec{Type: "a", Description: "first"}))
+	assert.Equal(t, []HyperedgeType{"a", "b"}, registry.Types())
+
+	spec, ok := registry.Lookup("a")
+	require.True(t, ok)
+	assert.Equal(t, "first", spec.Description)
+	_, ok = registry.Lookup("c")
+	assert.False(t, ok)
+}
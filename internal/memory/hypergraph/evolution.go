@@ -37,6 +37,10 @@ func (s *Store) RecordEvolution(ctx context.Context, entry *EvolutionEntry) erro
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	if s.db == nil {
+		return ErrNoSQLBackend
+	}
+
 	if entry.Timestamp.IsZero() {
 		entry.Timestamp = time.Now().UTC()
 	}
@@ -86,6 +90,10 @@ func (s *Store) ListEvolutionLog(ctx context.Context, filter EvolutionFilter) ([
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
+	if s.db == nil {
+		return nil, ErrNoSQLBackend
+	}
+
 	query := `SELECT id, timestamp, operation, node_ids, from_tier, to_tier, reasoning, metadata
 		FROM evolution_log WHERE 1=1`
 	var args []any
@@ -156,6 +164,10 @@ func (s *Store) CountEvolutionLog(ctx context.Context, filter EvolutionFilter) (
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
+	if s.db == nil {
+		return 0, ErrNoSQLBackend
+	}
+
 	query := "SELECT COUNT(*) FROM evolution_log WHERE 1=1"
 	var args []any
 
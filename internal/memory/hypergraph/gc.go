@@ -0,0 +1,140 @@
+package hypergraph
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// GCOptions configures CollectGarbage.
+type GCOptions struct {
+	// MinEdgeAge spares hyperedges created more recently than this, since
+	// members are added one at a time and a new edge may still be filling
+	// up. Zero collects edges of any age.
+	MinEdgeAge time.Duration
+}
+
+// DefaultGCOptions returns the options idle maintenance uses.
+func DefaultGCOptions() GCOptions {
+	return GCOptions{MinEdgeAge: time.Hour}
+}
+
+// GCResult reports what CollectGarbage removed.
+type GCResult struct {
+	// MembershipsPruned counts memberships whose node or hyperedge no
+	// longer exists.
+	MembershipsPruned int64
+
+	// HyperedgesRemoved counts hyperedges removed for having no members
+	// left, or fewer than their type's role minimums.
+	HyperedgesRemoved int64
+
+	Duration time.Duration
+}
+
+// CollectGarbage removes what deletions left behind: memberships pointing
+// at nodes or hyperedges that no longer exist, then hyperedges whose
+// membership dropped to zero or below their type's role minimums.
+//
+// Soft-deleted nodes still exist and can be restored, so their memberships
+// are kept and count towards arity; purging them is what makes their
+// memberships dangle. Where the schema's foreign keys are enforced, hard
+// deletes already cascade to memberships and only the hyperedges are left
+// to collect.
+func (s *Store) CollectGarbage(ctx context.Context, opts GCOptions) (*GCResult, error) {
+	start := time.Now()
+	result := &GCResult{}
+
+	pruned, err := s.pruneDanglingMemberships(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result.MembershipsPruned = pruned
+
+	edges, err := s.backend.ListHyperedges(ctx, HyperedgeFilter{})
+	if err != nil {
+		return nil, fmt.Errorf("list hyperedges: %w", err)
+	}
+
+	cutoff := start.Add(-opts.MinEdgeAge)
+	for _, edge := range edges {
+		if edge.CreatedAt.After(cutoff) {
+			continue
+		}
+		members, err := s.backend.GetMembers(ctx, edge.ID)
+		if err != nil {
+			return nil, fmt.Errorf("get members of %s: %w", edge.ID, err)
+		}
+		if len(members) > 0 && s.edgeTypes.checkComplete(edge.Type, members) == nil {
+			continue
+		}
+
+		// Remove the remaining members first so no backend is left
+		// holding memberships of a deleted edge.
+		for _, m := range members {
+			if err := s.backend.RemoveMember(ctx, m.HyperedgeID, m.NodeID, m.Role); err != nil && !IsNotFound(err) {
+				return nil, fmt.Errorf("remove member of %s: %w", edge.ID, err)
+			}
+		}
+		if err := s.backend.DeleteHyperedge(ctx, edge.ID); err != nil {
+			if IsNotFound(err) {
+				continue
+			}
+			return nil, fmt.Errorf("delete hyperedge %s: %w", edge.ID, err)
+		}
+		result.HyperedgesRemoved++
+	}
+
+	result.Duration = time.Since(start)
+	return result, nil
+}
+
+// pruneDanglingMemberships removes memberships whose node or hyperedge no
+// longer exists, returning how many it removed.
+func (s *Store) pruneDanglingMemberships(ctx context.Context) (int64, error) {
+	if s.db != nil {
+		res, err := s.db.ExecContext(ctx, `
+			DELETE FROM membership
+			WHERE node_id NOT IN (SELECT id FROM nodes)
+			   OR hyperedge_id NOT IN (SELECT id FROM hyperedges)
+		`)
+		if err != nil {
+			return 0, fmt.Errorf("prune memberships: %w", err)
+		}
+		return res.RowsAffected()
+	}
+
+	nodes, err := s.backend.ListNodes(ctx, NodeFilter{IncludeDeleted: true})
+	if err != nil {
+		return 0, fmt.Errorf("list nodes: %w", err)
+	}
+	exists := make(map[string]bool, len(nodes))
+	for _, n := range nodes {
+		exists[n.ID] = true
+	}
+
+	edges, err := s.backend.ListHyperedges(ctx, HyperedgeFilter{})
+	if err != nil {
+		return 0, fmt.Errorf("list hyperedges: %w", err)
+	}
+	var pruned int64
+	for _, edge := range edges {
+		members, err := s.backend.GetMembers(ctx, edge.ID)
+		if err != nil {
+			return pruned, fmt.Errorf("get members of %s: %w", edge.ID, err)
+		}
+		for _, m := range members {
+			if exists[m.NodeID] {
+				continue
+			}
+			if err := s.backend.RemoveMember(ctx, m.HyperedgeID, m.NodeID, m.Role); err != nil {
+				if IsNotFound(err) {
+					continue
+				}
+				return pruned, fmt.Errorf("remove member of %s: %w", edge.ID, err)
+			}
+			pruned++
+		}
+	}
+	return pruned, nil
+}
@@ -0,0 +1,202 @@
+package hypergraph
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// gcStores returns the store configurations CollectGarbage must handle:
+// SQLite in memory and on disk, and a non-SQL backend.
+func gcStores(t *testing.T) map[string]*Store {
+	t.Helper()
+	stores := make(map[string]*Store)
+	for name, opts := range map[string]Options{
+		"sqlite-memory": {},
+		"sqlite-file":   {Path: filepath.Join(t.TempDir(), "gc.db"), CreateIfNotExists: true},
+		"in-memory":     {Backend: NewInMemoryBackend()},
+	} {
+		store, err := NewStore(opts)
+		require.NoError(t, err, name)
+		t.Cleanup(func() { store.Close() })
+		stores[name] = store
+	}
+	return stores
+}
+
+func createGCNode(t *testing.T, store *Store, content string) *Node {
+	t.Helper()
+	n := NewNode(NodeTypeEntity, content)
+	require.NoError(t, store.CreateNode(context.Background(), n))
+	return n
+}
+
+func createGCEdge(t *testing.T, store *Store, edgeType HyperedgeType, members map[MemberRole]*Node) *Hyperedge {
+	t.Helper()
+	ctx := context.Background()
+	edge := NewHyperedge(edgeType, "edge")
+	require.NoError(t, store.CreateHyperedge(ctx, edge))
+	for role, n := range members {
+		require.NoError(t, store.AddMember(ctx, Membership{HyperedgeID: edge.ID, NodeID: n.ID, Role: role}))
+	}
+	return edge
+}
+
+func countMemberships(t *testing.T, store *Store) int {
+	t.Helper()
+	if store.DB() == nil {
+		return -1
+	}
+	var n int
+	require.NoError(t, store.DB().QueryRow("SELECT COUNT(*) FROM membership").Scan(&n))
+	return n
+}
+
+func TestCollectGarbage_DeletedNodeEmptiesEdge(t *testing.T) {
+	ctx := context.Background()
+	for name, store := range gcStores(t) {
+		t.Run(name, func(t *testing.T) {
+			a := createGCNode(t, store, "a")
+			edge := createGCEdge(t, store, HyperedgeContext, map[MemberRole]*Node{RoleContext: a})
+
+			require.NoError(t, store.DeleteNode(ctx, a.ID))
+
+			result, err := store.CollectGarbage(ctx, GCOptions{})
+			require.NoError(t, err)
+			assert.Equal(t, int64(1), result.HyperedgesRemoved)
+
+			_, err = store.GetHyperedge(ctx, edge.ID)
+			assert.True(t, IsNotFound(err))
+			if store.DB() != nil {
+				assert.Zero(t, countMemberships(t, store), "no membership survives")
+			}
+
+			// Nothing is left for a second pass.
+			again, err := store.CollectGarbage(ctx, GCOptions{})
+			require.NoError(t, err)
+			assert.Zero(t, again.MembershipsPruned)
+			assert.Zero(t, again.HyperedgesRemoved)
+		})
+	}
+}
+
+func TestCollectGarbage_PrunesDanglingMemberships(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewStore(Options{})
+	require.NoError(t, err)
+	defer store.Close()
+
+	a := createGCNode(t, store, "a")
+	b := createGCNode(t, store, "b")
+	c := createGCNode(t, store, "c")
+	edge := createGCEdge(t, store, HyperedgeRelation, map[MemberRole]*Node{
+		RoleSubject: a, RoleObject: b, RoleContext: c,
+	})
+
+	// Delete the node on a connection without foreign key enforcement, as
+	// a database written by an older or external tool might have, so its
+	// membership dangles.
+	conn, err := store.DB().Conn(ctx)
+	require.NoError(t, err)
+	_, err = conn.ExecContext(ctx, "PRAGMA foreign_keys = OFF")
+	require.NoError(t, err)
+	_, err = conn.ExecContext(ctx, "DELETE FROM nodes WHERE id = ?", c.ID)
+	require.NoError(t, err)
+	require.NoError(t, conn.Close())
+	require.Equal(t, 3, countMemberships(t, store))
+
+	result, err := store.CollectGarbage(ctx, GCOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), result.MembershipsPruned)
+	assert.Zero(t, result.HyperedgesRemoved, "two members remain")
+
+	members, err := store.GetMembers(ctx, edge.ID)
+	require.NoError(t, err)
+	assert.Len(t, members, 2)
+}
+
+func TestCollectGarbage_BelowMinimumArity(t *testing.T) {
+	ctx := context.Background()
+	for name, store := range gcStores(t) {
+		t.Run(name, func(t *testing.T) {
+			require.NoError(t, store.EdgeTypes().Register(EdgeTypeSpec{
+				Type: HyperedgeRelation,
+				Roles: []RoleConstraint{
+					{Role: RoleSubject, Min: 1, Max: 1},
+					{Role: RoleObject, Min: 1, Max: 1},
+				},
+			}))
+
+			a := createGCNode(t, store, "a")
+			b := createGCNode(t, store, "b")
+			c := createGCNode(t, store, "c")
+			d := createGCNode(t, store, "d")
+			broken := createGCEdge(t, store, HyperedgeRelation, map[MemberRole]*Node{RoleSubject: a, RoleObject: b})
+			intact := createGCEdge(t, store, HyperedgeRelation, map[MemberRole]*Node{RoleSubject: c, RoleObject: d})
+
+			require.NoError(t, store.DeleteNode(ctx, b.ID))
+
+			result, err := store.CollectGarbage(ctx, GCOptions{})
+			require.NoError(t, err)
+			assert.Equal(t, int64(1), result.HyperedgesRemoved)
+
+			_, err = store.GetHyperedge(ctx, broken.ID)
+			assert.True(t, IsNotFound(err))
+			_, err = store.GetHyperedge(ctx, intact.ID)
+			assert.NoError(t, err)
+
+			// The surviving member's node is untouched, only its membership goes.
+			_, err = store.GetNode(ctx, a.ID)
+			assert.NoError(t, err)
+			edges, err := store.GetNodeHyperedges(ctx, a.ID)
+			require.NoError(t, err)
+			assert.Empty(t, edges)
+			if store.DB() != nil {
+				assert.Equal(t, 2, countMemberships(t, store))
+			}
+		})
+	}
+}
+
+func TestCollectGarbage_KeepsSoftDeletedMembers(t *testing.T) {
+	ctx := context.Background()
+	for name, store := range gcStores(t) {
+		t.Run(name, func(t *testing.T) {
+			a := createGCNode(t, store, "a")
+			edge := createGCEdge(t, store, HyperedgeContext, map[MemberRole]*Node{RoleContext: a})
+
+			require.NoError(t, store.SoftDeleteNode(ctx, a.ID))
+
+			result, err := store.CollectGarbage(ctx, GCOptions{})
+			require.NoError(t, err)
+			assert.Zero(t, result.MembershipsPruned)
+			assert.Zero(t, result.HyperedgesRemoved)
+
+			require.NoError(t, store.RestoreNode(ctx, a.ID))
+			members, err := store.GetMembers(ctx, edge.ID)
+			require.NoError(t, err)
+			assert.Len(t, members, 1)
+		})
+	}
+}
+
+func TestCollectGarbage_SparesYoungEdges(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewStore(Options{})
+	require.NoError(t, err)
+	defer store.Close()
+
+	edge := NewHyperedge(HyperedgeRelation, "filling up")
+	require.NoError(t, store.CreateHyperedge(ctx, edge))
+
+	result, err := store.CollectGarbage(ctx, GCOptions{MinEdgeAge: time.Hour})
+	require.NoError(t, err)
+	assert.Zero(t, result.HyperedgesRemoved)
+
+	_, err = store.GetHyperedge(ctx, edge.ID)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,326 @@
+package hypergraph
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+)
+
+// ErrHistoryNotTracked is returned by point-in-time queries on a store
+// opened without Options.TrackHistory.
+var ErrHistoryNotTracked = errors.New("node history is not tracked")
+
+// historyOp is the kind of change a node_history row records.
+type historyOp string
+
+const (
+	// historyBaseline records a node's state from before tracking, taken
+	// when it is first changed, as of its last update.
+	historyBaseline   historyOp = "baseline"
+	historyCreate     historyOp = "create"
+	historyUpdate     historyOp = "update"
+	historySoftDelete historyOp = "soft_delete"
+	historyRestore    historyOp = "restore"
+	historyDelete     historyOp = "delete"
+)
+
+// trackNode runs mutate, a change of kind op to node id, recording the
+// node's resulting state when history is tracked. A node changed for the
+// first time since tracking began also gets a baseline of its prior state.
+//
+// History is written after the change succeeds; failing to write it is
+// logged rather than failing the change.
+func (s *Store) trackNode(ctx context.Context, id string, op historyOp, mutate func() error) error {
+	if !s.trackHistory {
+		return mutate()
+	}
+
+	// Serialize tracked changes so history order matches the order they
+	// were applied in.
+	s.historyMu.Lock()
+	defer s.historyMu.Unlock()
+
+	before, err := s.backend.GetNode(ctx, id)
+	if err != nil {
+		before = nil
+	}
+	if before != nil {
+		if err := s.recordBaseline(ctx, before); err != nil {
+			s.logger.Warn("record node history baseline", "node", id, "error", err)
+		}
+	}
+
+	if err := mutate(); err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	var state *Node
+	switch op {
+	case historyDelete:
+		// Deleted nodes have no state
+	case historySoftDelete:
+		if before != nil {
+			state = before
+			state.DeletedAt = &now
+		}
+	default:
+		if state, err = s.backend.GetNode(ctx, id); err != nil {
+			s.logger.Warn("read node for history", "node", id, "error", err)
+			return nil
+		}
+	}
+	if err := s.insertHistory(ctx, id, now, op, state); err != nil {
+		s.logger.Warn("record node history", "node", id, "error", err)
+	}
+	return nil
+}
+
+// trackCreate records a newly created node. A node that already has
+// history, such as one returned by an idempotent create, is not recorded
+// again.
+func (s *Store) trackCreate(ctx context.Context, node *Node) {
+	s.historyMu.Lock()
+	defer s.historyMu.Unlock()
+
+	tracked, err := s.hasHistory(ctx, node.ID)
+	if err != nil || tracked {
+		return
+	}
+	if err := s.insertHistory(ctx, node.ID, time.Now().UTC(), historyCreate, node); err != nil {
+		s.logger.Warn("record node history", "node", node.ID, "error", err)
+	}
+}
+
+// recordBaseline records node's current state as of its last update, if it
+// has no history yet.
+func (s *Store) recordBaseline(ctx context.Context, node *Node) error {
+	tracked, err := s.hasHistory(ctx, node.ID)
+	if err != nil || tracked {
+		return err
+	}
+	return s.insertHistory(ctx, node.ID, node.UpdatedAt, historyBaseline, node)
+}
+
+func (s *Store) hasHistory(ctx context.Context, id string) (bool, error) {
+	var one int
+	err := s.db.QueryRowContext(ctx, "SELECT 1 FROM node_history WHERE node_id = ? LIMIT 1", id).Scan(&one)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("query node history: %w", err)
+	}
+	return true, nil
+}
+
+// insertHistory appends a history row; a nil state records a deletion.
+// Embeddings are left out of snapshots.
+func (s *Store) insertHistory(ctx context.Context, id string, at time.Time, op historyOp, state *Node) error {
+	var snapshot sql.NullString
+	if state != nil {
+		stripped := *state
+		stripped.Embedding = nil
+		data, err := json.Marshal(&stripped)
+		if err != nil {
+			return fmt.Errorf("marshal node snapshot: %w", err)
+		}
+		snapshot = sql.NullString{String: string(data), Valid: true}
+	}
+
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO node_history (node_id, recorded_at, operation, snapshot)
+		VALUES (?, ?, ?, ?)
+	`, id, at.UnixNano(), op, snapshot)
+	if err != nil {
+		return fmt.Errorf("insert node history: %w", err)
+	}
+	return nil
+}
+
+// NodeAsOf returns node id as it was at t, or *ErrNotFound if it did not
+// exist or was deleted then. Embeddings are not kept, and access counts are
+// as of the last recorded change since IncrementAccess is not tracked.
+//
+// History covers changes made through the Store since tracking began. A
+// node created before then is known from its last update before its first
+// tracked change, and not found before that. Requires Options.TrackHistory.
+func (s *Store) NodeAsOf(ctx context.Context, id string, t time.Time) (*Node, error) {
+	if !s.trackHistory {
+		return nil, ErrHistoryNotTracked
+	}
+
+	var snapshot sql.NullString
+	err := s.db.QueryRowContext(ctx, `
+		SELECT snapshot FROM node_history
+		WHERE node_id = ? AND recorded_at <= ?
+		ORDER BY recorded_at DESC, id DESC
+		LIMIT 1
+	`, id, t.UnixNano()).Scan(&snapshot)
+	switch {
+	case err == nil:
+		node, err := decodeSnapshot(snapshot)
+		if err != nil {
+			return nil, err
+		}
+		if node == nil || node.DeletedAt != nil {
+			return nil, &ErrNotFound{Entity: "node", ID: id}
+		}
+		return node, nil
+	case !errors.Is(err, sql.ErrNoRows):
+		return nil, fmt.Errorf("query node history: %w", err)
+	}
+
+	// Nothing recorded by t. A node with later history did not exist yet,
+	// as far as is known; one without is unchanged since before tracking.
+	tracked, err := s.hasHistory(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if tracked {
+		return nil, &ErrNotFound{Entity: "node", ID: id}
+	}
+	node, err := s.backend.GetNode(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if node.UpdatedAt.After(t) {
+		return nil, &ErrNotFound{Entity: "node", ID: id}
+	}
+	return node, nil
+}
+
+// ListNodesAsOf returns the nodes matching filter as they were at t, newest
+// first, with the same coverage as NodeAsOf. Requires Options.TrackHistory.
+func (s *Store) ListNodesAsOf(ctx context.Context, filter NodeFilter, t time.Time) ([]*Node, error) {
+	if !s.trackHistory {
+		return nil, ErrHistoryNotTracked
+	}
+
+	// Latest recorded state of every node with history by t
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT snapshot FROM (
+			SELECT snapshot, ROW_NUMBER() OVER (
+				PARTITION BY node_id ORDER BY recorded_at DESC, id DESC
+			) AS rn
+			FROM node_history
+			WHERE recorded_at <= ?
+		)
+		WHERE rn = 1
+	`, t.UnixNano())
+	if err != nil {
+		return nil, fmt.Errorf("query node history: %w", err)
+	}
+	var nodes []*Node
+	for rows.Next() {
+		var snapshot sql.NullString
+		if err := rows.Scan(&snapshot); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("scan node history: %w", err)
+		}
+		node, err := decodeSnapshot(snapshot)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if node != nil {
+			nodes = append(nodes, node)
+		}
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Plus nodes unchanged since before tracking
+	tracked, err := s.trackedNodeIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	current, err := s.backend.ListNodes(ctx, NodeFilter{IncludeDeleted: true})
+	if err != nil {
+		return nil, err
+	}
+	for _, node := range current {
+		if tracked[node.ID] || node.UpdatedAt.After(t) {
+			continue
+		}
+		if node.DeletedAt != nil && node.DeletedAt.After(t) {
+			node.DeletedAt = nil
+		}
+		nodes = append(nodes, node)
+	}
+
+	matched := nodes[:0]
+	for _, node := range nodes {
+		if matchesNodeFilter(node, filter) {
+			matched = append(matched, node)
+		}
+	}
+	sort.SliceStable(matched, func(i, j int) bool {
+		return matched[i].CreatedAt.After(matched[j].CreatedAt)
+	})
+
+	if filter.Offset > 0 {
+		matched = matched[min(filter.Offset, len(matched)):]
+	}
+	if filter.Limit > 0 && len(matched) > filter.Limit {
+		matched = matched[:filter.Limit]
+	}
+	return matched, nil
+}
+
+func (s *Store) trackedNodeIDs(ctx context.Context) (map[string]bool, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT DISTINCT node_id FROM node_history")
+	if err != nil {
+		return nil, fmt.Errorf("query node history: %w", err)
+	}
+	defer rows.Close()
+
+	ids := make(map[string]bool)
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("scan node history: %w", err)
+		}
+		ids[id] = true
+	}
+	return ids, rows.Err()
+}
+
+// decodeSnapshot decodes a history snapshot; NULL decodes to nil.
+func decodeSnapshot(snapshot sql.NullString) (*Node, error) {
+	if !snapshot.Valid {
+		return nil, nil
+	}
+	var node Node
+	if err := json.Unmarshal([]byte(snapshot.String), &node); err != nil {
+		return nil, fmt.Errorf("decode node snapshot: %w", err)
+	}
+	return &node, nil
+}
+
+// matchesNodeFilter reports whether node passes filter's criteria, apart
+// from Limit and Offset.
+func matchesNodeFilter(node *Node, filter NodeFilter) bool {
+	if node.DeletedAt != nil && !filter.IncludeDeleted {
+		return false
+	}
+	if len(filter.Types) > 0 && !slices.Contains(filter.Types, node.Type) {
+		return false
+	}
+	if len(filter.Subtypes) > 0 && !slices.Contains(filter.Subtypes, node.Subtype) {
+		return false
+	}
+	if len(filter.Tiers) > 0 && !slices.Contains(filter.Tiers, node.Tier) {
+		return false
+	}
+	return node.Confidence >= filter.MinConfidence
+}
@@ -0,0 +1,195 @@
+package hypergraph
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newHistoryStore(t *testing.T) *Store {
+	t.Helper()
+	store, err := NewStore(Options{TrackHistory: true})
+	require.NoError(t, err)
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+// tick returns the current time, sleeping around it so changes before and
+// after get distinct timestamps.
+func tick() time.Time {
+	time.Sleep(2 * time.Millisecond)
+	now := time.Now()
+	time.Sleep(2 * time.Millisecond)
+	return now
+}
+
+func TestStore_NodeAsOf(t *testing.T) {
+	store := newHistoryStore(t)
+	ctx := context.Background()
+
+	beforeCreate := tick()
+	node := NewNode(NodeTypeFact, "the API uses REST")
+	node.Confidence = 0.9
+	require.NoError(t, store.CreateNode(ctx, node))
+	created := tick()
+
+	node.Content = "the API uses gRPC"
+	node.Confidence = 0.5
+	require.NoError(t, store.UpdateNode(ctx, node))
+	revised := tick()
+
+	node.Tier = TierLongterm
+	require.NoError(t, store.UpdateNode(ctx, node))
+	promoted := tick()
+
+	require.NoError(t, store.SoftDeleteNode(ctx, node.ID))
+	softDeleted := tick()
+
+	require.NoError(t, store.RestoreNode(ctx, node.ID))
+	restored := tick()
+
+	require.NoError(t, store.DeleteNode(ctx, node.ID))
+	deleted := tick()
+
+	_, err := store.NodeAsOf(ctx, node.ID, beforeCreate)
+	assert.True(t, IsNotFound(err), "not yet created: %v", err)
+
+	got, err := store.NodeAsOf(ctx, node.ID, created)
+	require.NoError(t, err)
+	assert.Equal(t, "the API uses REST", got.Content)
+	assert.Equal(t, 0.9, got.Confidence)
+	assert.Equal(t, TierTask, got.Tier)
+
+	got, err = store.NodeAsOf(ctx, node.ID, revised)
+	require.NoError(t, err)
+	assert.Equal(t, "the API uses gRPC", got.Content)
+	assert.Equal(t, 0.5, got.Confidence)
+	assert.Equal(t, TierTask, got.Tier)
+
+	got, err = store.NodeAsOf(ctx, node.ID, promoted)
+	require.NoError(t, err)
+	assert.Equal(t, TierLongterm, got.Tier)
+
+	_, err = store.NodeAsOf(ctx, node.ID, softDeleted)
+	assert.True(t, IsNotFound(err), "soft-deleted: %v", err)
+
+	got, err = store.NodeAsOf(ctx, node.ID, restored)
+	require.NoError(t, err)
+	assert.Equal(t, "the API uses gRPC", got.Content)
+	assert.Nil(t, got.DeletedAt)
+
+	_, err = store.NodeAsOf(ctx, node.ID, deleted)
+	assert.True(t, IsNotFound(err), "deleted: %v", err)
+}
+
+func TestStore_ListNodesAsOf(t *testing.T) {
+	store := newHistoryStore(t)
+	ctx := context.Background()
+
+	a := NewNode(NodeTypeFact, "a")
+	require.NoError(t, store.CreateNode(ctx, a))
+	onlyA := tick()
+
+	b := NewNode(NodeTypeEntity, "b")
+	require.NoError(t, store.CreateNode(ctx, b))
+	a.Tier = TierSession
+	require.NoError(t, store.UpdateNode(ctx, a))
+	both := tick()
+
+	require.NoError(t, store.SoftDeleteNode(ctx, b.ID))
+	bDeleted := tick()
+
+	nodes, err := store.ListNodesAsOf(ctx, NodeFilter{}, onlyA)
+	require.NoError(t, err)
+	require.Len(t, nodes, 1)
+	assert.Equal(t, a.ID, nodes[0].ID)
+	assert.Equal(t, TierTask, nodes[0].Tier)
+
+	nodes, err = store.ListNodesAsOf(ctx, NodeFilter{}, both)
+	require.NoError(t, err)
+	assert.Equal(t, []string{b.ID, a.ID}, nodeIDs(nodes), "newest first")
+
+	nodes, err = store.ListNodesAsOf(ctx, NodeFilter{Tiers: []Tier{TierSession}}, both)
+	require.NoError(t, err)
+	assert.Equal(t, []string{a.ID}, nodeIDs(nodes))
+
+	nodes, err = store.ListNodesAsOf(ctx, NodeFilter{}, bDeleted)
+	require.NoError(t, err)
+	assert.Equal(t, []string{a.ID}, nodeIDs(nodes))
+
+	nodes, err = store.ListNodesAsOf(ctx, NodeFilter{IncludeDeleted: true}, bDeleted)
+	require.NoError(t, err)
+	require.Equal(t, []string{b.ID, a.ID}, nodeIDs(nodes))
+	assert.NotNil(t, nodes[0].DeletedAt)
+
+	nodes, err = store.ListNodesAsOf(ctx, NodeFilter{Limit: 1, Offset: 1, IncludeDeleted: true}, bDeleted)
+	require.NoError(t, err)
+	assert.Equal(t, []string{a.ID}, nodeIDs(nodes))
+}
+
+func TestStore_NodeAsOf_PredatesTracking(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "memory.db")
+
+	// Written without tracking
+	untracked, err := NewStore(Options{Path: path, CreateIfNotExists: true})
+	require.NoError(t, err)
+	node := NewNode(NodeTypeFact, "original")
+	require.NoError(t, untracked.CreateNode(ctx, node))
+	other := NewNode(NodeTypeFact, "untouched")
+	require.NoError(t, untracked.CreateNode(ctx, other))
+	require.NoError(t, untracked.Close())
+
+	store, err := NewStore(Options{Path: path, TrackHistory: true})
+	require.NoError(t, err)
+	defer store.Close()
+
+	beforeUpdate := tick()
+	node.Content = "revised"
+	require.NoError(t, store.UpdateNode(ctx, node))
+	afterUpdate := tick()
+
+	// The first tracked change keeps the prior state as a baseline
+	got, err := store.NodeAsOf(ctx, node.ID, beforeUpdate)
+	require.NoError(t, err)
+	assert.Equal(t, "original", got.Content)
+
+	got, err = store.NodeAsOf(ctx, node.ID, afterUpdate)
+	require.NoError(t, err)
+	assert.Equal(t, "revised", got.Content)
+
+	// Unchanged nodes are served from their current state
+	got, err = store.NodeAsOf(ctx, other.ID, afterUpdate)
+	require.NoError(t, err)
+	assert.Equal(t, "untouched", got.Content)
+
+	nodes, err := store.ListNodesAsOf(ctx, NodeFilter{}, beforeUpdate)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{node.ID, other.ID}, nodeIDs(nodes))
+}
+
+func TestStore_NodeAsOf_NotTracked(t *testing.T) {
+	store, err := NewStore(Options{})
+	require.NoError(t, err)
+	defer store.Close()
+
+	_, err = store.NodeAsOf(context.Background(), "id", time.Now())
+	assert.ErrorIs(t, err, ErrHistoryNotTracked)
+	_, err = store.ListNodesAsOf(context.Background(), NodeFilter{}, time.Now())
+	assert.ErrorIs(t, err, ErrHistoryNotTracked)
+
+	_, err = NewStore(Options{Backend: NewInMemoryBackend(), TrackHistory: true})
+	assert.ErrorIs(t, err, ErrNoSQLBackend)
+}
+
+func nodeIDs(nodes []*Node) []string {
+	ids := make([]string, len(nodes))
+	for i, n := range nodes {
+		ids[i] = n.ID
+	}
+	return ids
+}
@@ -234,6 +234,11 @@ func (h *HybridSearcher) hydrateResults(
 		})
 	}
 
+	// Pinned nodes rank above other relevant results
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Node.Pinned && !results[j].Node.Pinned
+	})
+
 	return results, nil
 }
 
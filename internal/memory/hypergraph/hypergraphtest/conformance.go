@@ -0,0 +1,776 @@
+// Package hypergraphtest provides a conformance suite for hypergraph backends.
+package hypergraphtest
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rand/recurse/internal/memory/hypergraph"
+)
+
+// RunBackendConformance runs the Backend contract tests against the backends
+// returned by newBackend. Each subtest gets a fresh, empty backend and closes
+// it when done.
+func RunBackendConformance(t *testing.T, newBackend func() hypergraph.Backend) {
+	t.Run("CreateNode", func(t *testing.T) {
+		backend := newBackend()
+		defer backend.Close()
+		ctx := context.Background()
+
+		node := hypergraph.NewNode(hypergraph.NodeTypeEntity, "test content")
+		err := backend.CreateNode(ctx, node)
+		require.NoError(t, err)
+		assert.NotEmpty(t, node.ID)
+
+		retrieved, err := backend.GetNode(ctx, node.ID)
+		require.NoError(t, err)
+		assert.Equal(t, node.ID, retrieved.ID)
+		assert.Equal(t, "test content", retrieved.Content)
+		assert.Equal(t, hypergraph.NodeTypeEntity, retrieved.Type)
+	})
+
+	t.Run("CreateNode_IdempotencyKey", func(t *testing.T) {
+		backend := newBackend()
+		defer backend.Close()
+		ctx := context.Background()
+
+		first := hypergraph.NewNode(hypergraph.NodeTypeFact, "first attempt")
+		first.IdempotencyKey = "record-fact-1"
+		require.NoError(t, backend.CreateNode(ctx, first))
+
+		retry := hypergraph.NewNode(hypergraph.NodeTypeFact, "retried attempt")
+		retry.IdempotencyKey = "record-fact-1"
+		require.NoError(t, backend.CreateNode(ctx, retry))
+		assert.Equal(t, first.ID, retry.ID)
+		assert.Equal(t, "first attempt", retry.Content)
+
+		count, err := backend.CountNodes(ctx, hypergraph.NodeFilter{})
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), count)
+
+		// Retrying with the same node value is also a no-op
+		require.NoError(t, backend.CreateNode(ctx, first))
+
+		// Updates keep the key
+		first.Content = "updated"
+		first.IdempotencyKey = "changed"
+		require.NoError(t, backend.UpdateNode(ctx, first))
+		got, err := backend.GetNode(ctx, first.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "updated", got.Content)
+		assert.Equal(t, "record-fact-1", got.IdempotencyKey)
+
+		// The key stays claimed after a soft delete
+		require.NoError(t, backend.SoftDeleteNode(ctx, first.ID))
+		again := hypergraph.NewNode(hypergraph.NodeTypeFact, "after delete")
+		again.IdempotencyKey = "record-fact-1"
+		require.NoError(t, backend.CreateNode(ctx, again))
+		assert.Equal(t, first.ID, again.ID)
+		assert.NotNil(t, again.DeletedAt)
+
+		// Nodes without a key are never deduplicated
+		a := hypergraph.NewNode(hypergraph.NodeTypeFact, "same")
+		b := hypergraph.NewNode(hypergraph.NodeTypeFact, "same")
+		require.NoError(t, backend.CreateNode(ctx, a))
+		require.NoError(t, backend.CreateNode(ctx, b))
+		assert.NotEqual(t, a.ID, b.ID)
+
+		count, err = backend.CountNodes(ctx, hypergraph.NodeFilter{IncludeDeleted: true})
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), count)
+	})
+
+	t.Run("CreateNode_IdempotencyKeyConcurrent", func(t *testing.T) {
+		backend := newBackend()
+		defer backend.Close()
+		ctx := context.Background()
+
+		const workers = 8
+		ids := make([]string, workers)
+		errs := make([]error, workers)
+		var wg sync.WaitGroup
+		for w := 0; w < workers; w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				node := hypergraph.NewNode(hypergraph.NodeTypeExperience, "concurrent retry")
+				node.IdempotencyKey = "experience-1"
+				errs[w] = backend.CreateNode(ctx, node)
+				ids[w] = node.ID
+			}()
+		}
+		wg.Wait()
+
+		for w := 0; w < workers; w++ {
+			require.NoError(t, errs[w])
+			assert.Equal(t, ids[0], ids[w])
+		}
+		count, err := backend.CountNodes(ctx, hypergraph.NodeFilter{})
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), count)
+	})
+
+	t.Run("GetNode_NotFound", func(t *testing.T) {
+		backend := newBackend()
+		defer backend.Close()
+		ctx := context.Background()
+
+		_, err := backend.GetNode(ctx, "nonexistent")
+		require.Error(t, err)
+		assert.True(t, hypergraph.IsNotFound(err))
+	})
+
+	t.Run("UpdateNode", func(t *testing.T) {
+		backend := newBackend()
+		defer backend.Close()
+		ctx := context.Background()
+
+		node := hypergraph.NewNode(hypergraph.NodeTypeEntity, "original")
+		require.NoError(t, backend.CreateNode(ctx, node))
+
+		node.Content = "updated"
+		require.NoError(t, backend.UpdateNode(ctx, node))
+
+		retrieved, err := backend.GetNode(ctx, node.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "updated", retrieved.Content)
+	})
+
+	t.Run("DeleteNode", func(t *testing.T) {
+		backend := newBackend()
+		defer backend.Close()
+		ctx := context.Background()
+
+		node := hypergraph.NewNode(hypergraph.NodeTypeEntity, "to delete")
+		require.NoError(t, backend.CreateNode(ctx, node))
+
+		require.NoError(t, backend.DeleteNode(ctx, node.ID))
+
+		_, err := backend.GetNode(ctx, node.ID)
+		require.Error(t, err)
+		assert.True(t, hypergraph.IsNotFound(err))
+	})
+
+	t.Run("ListNodes", func(t *testing.T) {
+		backend := newBackend()
+		defer backend.Close()
+		ctx := context.Background()
+
+		// Create nodes of different types
+		for i := 0; i < 3; i++ {
+			require.NoError(t, backend.CreateNode(ctx, hypergraph.NewNode(hypergraph.NodeTypeEntity, "entity")))
+		}
+		for i := 0; i < 2; i++ {
+			require.NoError(t, backend.CreateNode(ctx, hypergraph.NewNode(hypergraph.NodeTypeFact, "fact")))
+		}
+
+		// List all
+		nodes, err := backend.ListNodes(ctx, hypergraph.NodeFilter{})
+		require.NoError(t, err)
+		assert.Len(t, nodes, 5)
+
+		// Filter by type
+		nodes, err = backend.ListNodes(ctx, hypergraph.NodeFilter{Types: []hypergraph.NodeType{hypergraph.NodeTypeEntity}})
+		require.NoError(t, err)
+		assert.Len(t, nodes, 3)
+
+		// With limit
+		nodes, err = backend.ListNodes(ctx, hypergraph.NodeFilter{Limit: 2})
+		require.NoError(t, err)
+		assert.Len(t, nodes, 2)
+	})
+
+	t.Run("CountNodes", func(t *testing.T) {
+		backend := newBackend()
+		defer backend.Close()
+		ctx := context.Background()
+
+		for i := 0; i < 5; i++ {
+			require.NoError(t, backend.CreateNode(ctx, hypergraph.NewNode(hypergraph.NodeTypeEntity, "test")))
+		}
+
+		count, err := backend.CountNodes(ctx, hypergraph.NodeFilter{})
+		require.NoError(t, err)
+		assert.Equal(t, int64(5), count)
+	})
+
+	t.Run("IncrementAccess", func(t *testing.T) {
+		backend := newBackend()
+		defer backend.Close()
+		ctx := context.Background()
+
+		node := hypergraph.NewNode(hypergraph.NodeTypeEntity, "test")
+		require.NoError(t, backend.CreateNode(ctx, node))
+
+		require.NoError(t, backend.IncrementAccess(ctx, node.ID))
+		require.NoError(t, backend.IncrementAccess(ctx, node.ID))
+
+		retrieved, err := backend.GetNode(ctx, node.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 2, retrieved.AccessCount)
+		assert.NotNil(t, retrieved.LastAccessed)
+	})
+
+	t.Run("SoftDeleteNode", func(t *testing.T) {
+		backend := newBackend()
+		defer backend.Close()
+		ctx := context.Background()
+
+		kept := hypergraph.NewNode(hypergraph.NodeTypeFact, "kept searchable")
+		removed := hypergraph.NewNode(hypergraph.NodeTypeFact, "removed searchable")
+		require.NoError(t, backend.CreateNode(ctx, kept))
+		require.NoError(t, backend.CreateNode(ctx, removed))
+
+		require.NoError(t, backend.SoftDeleteNode(ctx, removed.ID))
+
+		_, err := backend.GetNode(ctx, removed.ID)
+		assert.True(t, hypergraph.IsNotFound(err))
+
+		nodes, err := backend.ListNodes(ctx, hypergraph.NodeFilter{})
+		require.NoError(t, err)
+		assert.Len(t, nodes, 1)
+
+		nodes, err = backend.ListNodes(ctx, hypergraph.NodeFilter{IncludeDeleted: true})
+		require.NoError(t, err)
+		assert.Len(t, nodes, 2)
+
+		results, err := backend.SearchByContent(ctx, "searchable", hypergraph.SearchOptions{})
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		assert.Equal(t, kept.ID, results[0].Node.ID)
+
+		require.NoError(t, backend.RestoreNode(ctx, removed.ID))
+		_, err = backend.GetNode(ctx, removed.ID)
+		assert.NoError(t, err)
+	})
+
+	t.Run("PurgeDeleted", func(t *testing.T) {
+		backend := newBackend()
+		defer backend.Close()
+		ctx := context.Background()
+
+		node := hypergraph.NewNode(hypergraph.NodeTypeFact, "purge me")
+		live := hypergraph.NewNode(hypergraph.NodeTypeFact, "live")
+		require.NoError(t, backend.CreateNode(ctx, node))
+		require.NoError(t, backend.CreateNode(ctx, live))
+		require.NoError(t, backend.SoftDeleteNode(ctx, node.ID))
+
+		// Within the retention window nothing is purged
+		purged, err := backend.PurgeDeleted(ctx, time.Hour)
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), purged)
+
+		purged, err = backend.PurgeDeleted(ctx, 0)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), purged)
+
+		// Live nodes are never purged
+		count, err := backend.CountNodes(ctx, hypergraph.NodeFilter{IncludeDeleted: true})
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), count)
+
+		err = backend.RestoreNode(ctx, node.ID)
+		assert.True(t, hypergraph.IsNotFound(err))
+	})
+
+	t.Run("SoftDeleteNode_HidesFromWrites", func(t *testing.T) {
+		backend := newBackend()
+		defer backend.Close()
+		ctx := context.Background()
+
+		node := hypergraph.NewNode(hypergraph.NodeTypeFact, "soon gone")
+		require.NoError(t, backend.CreateNode(ctx, node))
+		require.NoError(t, backend.SoftDeleteNode(ctx, node.ID))
+
+		node.Content = "edited after delete"
+		err := backend.UpdateNode(ctx, node)
+		assert.True(t, hypergraph.IsNotFound(err))
+
+		err = backend.IncrementAccess(ctx, node.ID)
+		assert.True(t, hypergraph.IsNotFound(err))
+
+		err = backend.SoftDeleteNode(ctx, node.ID)
+		assert.True(t, hypergraph.IsNotFound(err))
+
+		count, err := backend.CountNodes(ctx, hypergraph.NodeFilter{})
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), count)
+
+		count, err = backend.CountNodes(ctx, hypergraph.NodeFilter{IncludeDeleted: true})
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), count)
+
+		stats, err := backend.Stats(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), stats.NodeCount)
+		assert.Zero(t, stats.NodesByType[string(hypergraph.NodeTypeFact)])
+	})
+
+	t.Run("SoftDeleteNode_HidesFromTraversal", func(t *testing.T) {
+		backend := newBackend()
+		defer backend.Close()
+		ctx := context.Background()
+
+		a := hypergraph.NewNode(hypergraph.NodeTypeEntity, "a")
+		b := hypergraph.NewNode(hypergraph.NodeTypeEntity, "b")
+		require.NoError(t, backend.CreateNode(ctx, a))
+		require.NoError(t, backend.CreateNode(ctx, b))
+
+		edge := hypergraph.NewHyperedge(hypergraph.HyperedgeRelation, "a to b")
+		require.NoError(t, backend.CreateHyperedge(ctx, edge))
+		require.NoError(t, backend.AddMember(ctx, hypergraph.Membership{HyperedgeID: edge.ID, NodeID: a.ID, Role: hypergraph.RoleSubject, Position: 0}))
+		require.NoError(t, backend.AddMember(ctx, hypergraph.Membership{HyperedgeID: edge.ID, NodeID: b.ID, Role: hypergraph.RoleObject, Position: 1}))
+
+		require.NoError(t, backend.SoftDeleteNode(ctx, b.ID))
+
+		connected, err := backend.GetConnected(ctx, a.ID, hypergraph.TraversalOptions{Direction: hypergraph.TraverseBoth, MaxDepth: 2})
+		require.NoError(t, err)
+		assert.Empty(t, connected)
+
+		members, err := backend.GetMemberNodes(ctx, edge.ID)
+		require.NoError(t, err)
+		require.Len(t, members, 1)
+		assert.Equal(t, a.ID, members[0].ID)
+
+		recent, err := backend.RecentNodes(ctx, 10, nil)
+		require.NoError(t, err)
+		require.Len(t, recent, 1)
+		assert.Equal(t, a.ID, recent[0].ID)
+
+		require.NoError(t, backend.RestoreNode(ctx, b.ID))
+		connected, err = backend.GetConnected(ctx, a.ID, hypergraph.TraversalOptions{Direction: hypergraph.TraverseBoth, MaxDepth: 2})
+		require.NoError(t, err)
+		assert.Len(t, connected, 1)
+	})
+
+	t.Run("RestoreNode", func(t *testing.T) {
+		backend := newBackend()
+		defer backend.Close()
+		ctx := context.Background()
+
+		node := hypergraph.NewNode(hypergraph.NodeTypeFact, "restorable")
+		past := time.Now().UTC().Add(-time.Hour)
+		node.CreatedAt = past
+		node.UpdatedAt = past
+		require.NoError(t, backend.CreateNode(ctx, node))
+
+		// Restoring a live node is not found
+		err := backend.RestoreNode(ctx, node.ID)
+		assert.True(t, hypergraph.IsNotFound(err))
+
+		require.NoError(t, backend.SoftDeleteNode(ctx, node.ID))
+		require.NoError(t, backend.RestoreNode(ctx, node.ID))
+
+		got, err := backend.GetNode(ctx, node.ID)
+		require.NoError(t, err)
+		assert.Nil(t, got.DeletedAt)
+		assert.True(t, got.UpdatedAt.After(past), "restore sets UpdatedAt")
+		assert.WithinDuration(t, past, got.CreatedAt, time.Second)
+	})
+
+	t.Run("ConcurrentUse", func(t *testing.T) {
+		backend := newBackend()
+		defer backend.Close()
+		ctx := context.Background()
+
+		shared := hypergraph.NewNode(hypergraph.NodeTypeEntity, "shared")
+		require.NoError(t, backend.CreateNode(ctx, shared))
+
+		const workers = 8
+		const perWorker = 10
+
+		var wg sync.WaitGroup
+		errs := make(chan error, workers*perWorker*3)
+		for w := 0; w < workers; w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := 0; i < perWorker; i++ {
+					if err := backend.CreateNode(ctx, hypergraph.NewNode(hypergraph.NodeTypeFact, "concurrent")); err != nil {
+						errs <- err
+					}
+					if err := backend.IncrementAccess(ctx, shared.ID); err != nil {
+						errs <- err
+					}
+					if _, err := backend.ListNodes(ctx, hypergraph.NodeFilter{Limit: 5}); err != nil {
+						errs <- err
+					}
+				}
+			}()
+		}
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			require.NoError(t, err)
+		}
+
+		count, err := backend.CountNodes(ctx, hypergraph.NodeFilter{Types: []hypergraph.NodeType{hypergraph.NodeTypeFact}})
+		require.NoError(t, err)
+		assert.Equal(t, int64(workers*perWorker), count)
+
+		got, err := backend.GetNode(ctx, shared.ID)
+		require.NoError(t, err)
+		assert.Equal(t, workers*perWorker, got.AccessCount)
+	})
+
+	t.Run("CreateHyperedge", func(t *testing.T) {
+		backend := newBackend()
+		defer backend.Close()
+		ctx := context.Background()
+
+		edge := hypergraph.NewHyperedge(hypergraph.HyperedgeRelation, "test relation")
+		require.NoError(t, backend.CreateHyperedge(ctx, edge))
+		assert.NotEmpty(t, edge.ID)
+
+		retrieved, err := backend.GetHyperedge(ctx, edge.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "test relation", retrieved.Label)
+	})
+
+	t.Run("UpdateHyperedge", func(t *testing.T) {
+		backend := newBackend()
+		defer backend.Close()
+		ctx := context.Background()
+
+		edge := hypergraph.NewHyperedge(hypergraph.HyperedgeRelation, "original")
+		require.NoError(t, backend.CreateHyperedge(ctx, edge))
+
+		edge.Label = "updated"
+		require.NoError(t, backend.UpdateHyperedge(ctx, edge))
+
+		retrieved, err := backend.GetHyperedge(ctx, edge.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "updated", retrieved.Label)
+	})
+
+	t.Run("DeleteHyperedge", func(t *testing.T) {
+		backend := newBackend()
+		defer backend.Close()
+		ctx := context.Background()
+
+		edge := hypergraph.NewHyperedge(hypergraph.HyperedgeRelation, "to delete")
+		require.NoError(t, backend.CreateHyperedge(ctx, edge))
+
+		require.NoError(t, backend.DeleteHyperedge(ctx, edge.ID))
+
+		_, err := backend.GetHyperedge(ctx, edge.ID)
+		require.Error(t, err)
+		assert.True(t, hypergraph.IsNotFound(err))
+	})
+
+	t.Run("DeleteHyperedge_NotFound", func(t *testing.T) {
+		backend := newBackend()
+		defer backend.Close()
+		ctx := context.Background()
+
+		err := backend.DeleteHyperedge(ctx, "nonexistent")
+		require.Error(t, err)
+		assert.True(t, hypergraph.IsNotFound(err))
+	})
+
+	t.Run("ListHyperedges", func(t *testing.T) {
+		backend := newBackend()
+		defer backend.Close()
+		ctx := context.Background()
+
+		for i := 0; i < 3; i++ {
+			require.NoError(t, backend.CreateHyperedge(ctx, hypergraph.NewHyperedge(hypergraph.HyperedgeRelation, "relation")))
+		}
+		light := hypergraph.NewHyperedge(hypergraph.HyperedgeComposition, "composition")
+		light.Weight = 0.2
+		require.NoError(t, backend.CreateHyperedge(ctx, light))
+
+		edges, err := backend.ListHyperedges(ctx, hypergraph.HyperedgeFilter{})
+		require.NoError(t, err)
+		assert.Len(t, edges, 4)
+
+		edges, err = backend.ListHyperedges(ctx, hypergraph.HyperedgeFilter{Types: []hypergraph.HyperedgeType{hypergraph.HyperedgeComposition}})
+		require.NoError(t, err)
+		require.Len(t, edges, 1)
+		assert.Equal(t, light.ID, edges[0].ID)
+
+		edges, err = backend.ListHyperedges(ctx, hypergraph.HyperedgeFilter{MinWeight: 0.5})
+		require.NoError(t, err)
+		assert.Len(t, edges, 3)
+
+		edges, err = backend.ListHyperedges(ctx, hypergraph.HyperedgeFilter{Limit: 2})
+		require.NoError(t, err)
+		assert.Len(t, edges, 2)
+	})
+
+	t.Run("Membership", func(t *testing.T) {
+		backend := newBackend()
+		defer backend.Close()
+		ctx := context.Background()
+
+		// Create nodes and edge
+		node1 := hypergraph.NewNode(hypergraph.NodeTypeEntity, "subject")
+		node2 := hypergraph.NewNode(hypergraph.NodeTypeEntity, "object")
+		require.NoError(t, backend.CreateNode(ctx, node1))
+		require.NoError(t, backend.CreateNode(ctx, node2))
+
+		edge := hypergraph.NewHyperedge(hypergraph.HyperedgeRelation, "connects")
+		require.NoError(t, backend.CreateHyperedge(ctx, edge))
+
+		// Add members
+		require.NoError(t, backend.AddMember(ctx, hypergraph.Membership{
+			HyperedgeID: edge.ID,
+			NodeID:      node1.ID,
+			Role:        hypergraph.RoleSubject,
+			Position:    0,
+		}))
+		require.NoError(t, backend.AddMember(ctx, hypergraph.Membership{
+			HyperedgeID: edge.ID,
+			NodeID:      node2.ID,
+			Role:        hypergraph.RoleObject,
+			Position:    1,
+		}))
+
+		// Get members
+		members, err := backend.GetMembers(ctx, edge.ID)
+		require.NoError(t, err)
+		assert.Len(t, members, 2)
+
+		// Get member nodes
+		nodes, err := backend.GetMemberNodes(ctx, edge.ID)
+		require.NoError(t, err)
+		assert.Len(t, nodes, 2)
+
+		// Get node hyperedges
+		edges, err := backend.GetNodeHyperedges(ctx, node1.ID)
+		require.NoError(t, err)
+		assert.Len(t, edges, 1)
+
+		// Remove member
+		require.NoError(t, backend.RemoveMember(ctx, edge.ID, node1.ID, hypergraph.RoleSubject))
+		members, err = backend.GetMembers(ctx, edge.ID)
+		require.NoError(t, err)
+		assert.Len(t, members, 1)
+	})
+
+	t.Run("RemoveMember_NotFound", func(t *testing.T) {
+		backend := newBackend()
+		defer backend.Close()
+		ctx := context.Background()
+
+		edge := hypergraph.NewHyperedge(hypergraph.HyperedgeRelation, "empty")
+		require.NoError(t, backend.CreateHyperedge(ctx, edge))
+
+		err := backend.RemoveMember(ctx, edge.ID, "nonexistent", hypergraph.RoleSubject)
+		require.Error(t, err)
+		assert.True(t, hypergraph.IsNotFound(err))
+	})
+
+	t.Run("SearchByContent", func(t *testing.T) {
+		backend := newBackend()
+		defer backend.Close()
+		ctx := context.Background()
+
+		node1 := hypergraph.NewNode(hypergraph.NodeTypeEntity, "hello world")
+		node2 := hypergraph.NewNode(hypergraph.NodeTypeEntity, "goodbye world")
+		node3 := hypergraph.NewNode(hypergraph.NodeTypeEntity, "hello hello")
+		require.NoError(t, backend.CreateNode(ctx, node1))
+		require.NoError(t, backend.CreateNode(ctx, node2))
+		require.NoError(t, backend.CreateNode(ctx, node3))
+
+		results, err := backend.SearchByContent(ctx, "hello", hypergraph.SearchOptions{})
+		require.NoError(t, err)
+		assert.Len(t, results, 2)
+
+		results, err = backend.SearchByContent(ctx, "world", hypergraph.SearchOptions{})
+		require.NoError(t, err)
+		assert.Len(t, results, 2)
+
+		results, err = backend.SearchByContent(ctx, "goodbye", hypergraph.SearchOptions{})
+		require.NoError(t, err)
+		assert.Len(t, results, 1)
+	})
+
+	t.Run("SearchByContent_PinnedFirst", func(t *testing.T) {
+		backend := newBackend()
+		defer backend.Close()
+		ctx := context.Background()
+
+		popular := hypergraph.NewNode(hypergraph.NodeTypeFact, "deploy to staging first")
+		popular.AccessCount = 10
+		pinned := hypergraph.NewNode(hypergraph.NodeTypeFact, "never deploy on fridays")
+		pinned.Pinned = true
+		require.NoError(t, backend.CreateNode(ctx, popular))
+		require.NoError(t, backend.CreateNode(ctx, pinned))
+
+		retrieved, err := backend.GetNode(ctx, pinned.ID)
+		require.NoError(t, err)
+		assert.True(t, retrieved.Pinned)
+
+		results, err := backend.SearchByContent(ctx, "deploy", hypergraph.SearchOptions{Limit: 1})
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		assert.Equal(t, pinned.ID, results[0].Node.ID)
+
+		retrieved.Pinned = false
+		require.NoError(t, backend.UpdateNode(ctx, retrieved))
+		retrieved, err = backend.GetNode(ctx, pinned.ID)
+		require.NoError(t, err)
+		assert.False(t, retrieved.Pinned)
+	})
+
+	t.Run("GetConnected", func(t *testing.T) {
+		backend := newBackend()
+		defer backend.Close()
+		ctx := context.Background()
+
+		// Create a simple graph: A -> B -> C
+		nodeA := hypergraph.NewNode(hypergraph.NodeTypeEntity, "A")
+		nodeB := hypergraph.NewNode(hypergraph.NodeTypeEntity, "B")
+		nodeC := hypergraph.NewNode(hypergraph.NodeTypeEntity, "C")
+		require.NoError(t, backend.CreateNode(ctx, nodeA))
+		require.NoError(t, backend.CreateNode(ctx, nodeB))
+		require.NoError(t, backend.CreateNode(ctx, nodeC))
+
+		edge1 := hypergraph.NewHyperedge(hypergraph.HyperedgeRelation, "A to B")
+		edge2 := hypergraph.NewHyperedge(hypergraph.HyperedgeRelation, "B to C")
+		require.NoError(t, backend.CreateHyperedge(ctx, edge1))
+		require.NoError(t, backend.CreateHyperedge(ctx, edge2))
+
+		// A -> B
+		require.NoError(t, backend.AddMember(ctx, hypergraph.Membership{HyperedgeID: edge1.ID, NodeID: nodeA.ID, Role: hypergraph.RoleSubject, Position: 0}))
+		require.NoError(t, backend.AddMember(ctx, hypergraph.Membership{HyperedgeID: edge1.ID, NodeID: nodeB.ID, Role: hypergraph.RoleObject, Position: 1}))
+
+		// B -> C
+		require.NoError(t, backend.AddMember(ctx, hypergraph.Membership{HyperedgeID: edge2.ID, NodeID: nodeB.ID, Role: hypergraph.RoleSubject, Position: 0}))
+		require.NoError(t, backend.AddMember(ctx, hypergraph.Membership{HyperedgeID: edge2.ID, NodeID: nodeC.ID, Role: hypergraph.RoleObject, Position: 1}))
+
+		// Get immediate connections from A
+		connected, err := backend.GetConnected(ctx, nodeA.ID, hypergraph.TraversalOptions{MaxDepth: 1})
+		require.NoError(t, err)
+		assert.Len(t, connected, 1)
+		assert.Equal(t, nodeB.ID, connected[0].Node.ID)
+
+		// Get connections with depth 2
+		connected, err = backend.GetConnected(ctx, nodeA.ID, hypergraph.TraversalOptions{MaxDepth: 2})
+		require.NoError(t, err)
+		assert.Len(t, connected, 2)
+	})
+
+	t.Run("GetConnected_Pinned", func(t *testing.T) {
+		backend := newBackend()
+		defer backend.Close()
+		ctx := context.Background()
+
+		hub := hypergraph.NewNode(hypergraph.NodeTypeEntity, "hub")
+		pinned := hypergraph.NewNode(hypergraph.NodeTypeFact, "never deploy on fridays")
+		pinned.Pinned = true
+		pinned.Tier = hypergraph.TierArchive
+		archived := hypergraph.NewNode(hypergraph.NodeTypeFact, "old fact")
+		archived.Tier = hypergraph.TierArchive
+		for _, n := range []*hypergraph.Node{hub, pinned, archived} {
+			require.NoError(t, backend.CreateNode(ctx, n))
+		}
+		edge := hypergraph.NewHyperedge(hypergraph.HyperedgeRelation, "about")
+		require.NoError(t, backend.CreateHyperedge(ctx, edge))
+		require.NoError(t, backend.AddMember(ctx, hypergraph.Membership{HyperedgeID: edge.ID, NodeID: hub.ID, Role: hypergraph.RoleSubject, Position: 0}))
+		require.NoError(t, backend.AddMember(ctx, hypergraph.Membership{HyperedgeID: edge.ID, NodeID: pinned.ID, Role: hypergraph.RoleObject, Position: 1}))
+		require.NoError(t, backend.AddMember(ctx, hypergraph.Membership{HyperedgeID: edge.ID, NodeID: archived.ID, Role: hypergraph.RoleObject, Position: 2}))
+
+		connected, err := backend.GetConnected(ctx, hub.ID, hypergraph.TraversalOptions{MaxDepth: 1})
+		require.NoError(t, err)
+		require.Len(t, connected, 1, "the archived node is left out, the pinned one is not")
+		assert.Equal(t, pinned.ID, connected[0].Node.ID)
+		assert.True(t, connected[0].Node.Pinned)
+	})
+
+	t.Run("RecentNodes", func(t *testing.T) {
+		backend := newBackend()
+		defer backend.Close()
+		ctx := context.Background()
+
+		node1 := hypergraph.NewNode(hypergraph.NodeTypeEntity, "first")
+		node2 := hypergraph.NewNode(hypergraph.NodeTypeEntity, "second")
+		require.NoError(t, backend.CreateNode(ctx, node1))
+		require.NoError(t, backend.CreateNode(ctx, node2))
+
+		// Access node1 multiple times to ensure it's more recent
+		require.NoError(t, backend.IncrementAccess(ctx, node1.ID))
+		require.NoError(t, backend.IncrementAccess(ctx, node1.ID))
+
+		nodes, err := backend.RecentNodes(ctx, 10, nil)
+		require.NoError(t, err)
+		assert.Len(t, nodes, 2)
+
+		// Verify both nodes are returned (order may vary by backend)
+		ids := []string{nodes[0].ID, nodes[1].ID}
+		assert.Contains(t, ids, node1.ID)
+		assert.Contains(t, ids, node2.ID)
+	})
+
+	t.Run("Stats", func(t *testing.T) {
+		backend := newBackend()
+		defer backend.Close()
+		ctx := context.Background()
+
+		for i := 0; i < 3; i++ {
+			require.NoError(t, backend.CreateNode(ctx, hypergraph.NewNode(hypergraph.NodeTypeEntity, "entity")))
+		}
+		require.NoError(t, backend.CreateHyperedge(ctx, hypergraph.NewHyperedge(hypergraph.HyperedgeRelation, "edge")))
+
+		stats, err := backend.Stats(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), stats.NodeCount)
+		assert.Equal(t, int64(1), stats.HyperedgeCount)
+		assert.Equal(t, int64(3), stats.NodesByType[string(hypergraph.NodeTypeEntity)])
+	})
+
+	t.Run("UpdateNode_NotFound", func(t *testing.T) {
+		backend := newBackend()
+		defer backend.Close()
+		ctx := context.Background()
+
+		err := backend.UpdateNode(ctx, hypergraph.NewNode(hypergraph.NodeTypeEntity, "missing"))
+		require.Error(t, err)
+		assert.True(t, hypergraph.IsNotFound(err))
+	})
+
+	t.Run("ReturnsCopies", func(t *testing.T) {
+		backend := newBackend()
+		defer backend.Close()
+		ctx := context.Background()
+
+		node := hypergraph.NewNode(hypergraph.NodeTypeEntity, "original")
+		require.NoError(t, backend.CreateNode(ctx, node))
+
+		retrieved, err := backend.GetNode(ctx, node.ID)
+		require.NoError(t, err)
+		retrieved.Content = "mutated"
+
+		again, err := backend.GetNode(ctx, node.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "original", again.Content)
+	})
+
+	t.Run("ExcludesArchived", func(t *testing.T) {
+		backend := newBackend()
+		defer backend.Close()
+		ctx := context.Background()
+
+		live := hypergraph.NewNode(hypergraph.NodeTypeFact, "shared keyword")
+		archived := hypergraph.NewNode(hypergraph.NodeTypeFact, "shared keyword")
+		archived.Tier = hypergraph.TierArchive
+		require.NoError(t, backend.CreateNode(ctx, live))
+		require.NoError(t, backend.CreateNode(ctx, archived))
+
+		results, err := backend.SearchByContent(ctx, "keyword", hypergraph.SearchOptions{})
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		assert.Equal(t, live.ID, results[0].Node.ID)
+
+		recent, err := backend.RecentNodes(ctx, 10, nil)
+		require.NoError(t, err)
+		require.Len(t, recent, 1)
+		assert.Equal(t, live.ID, recent[0].ID)
+	})
+}
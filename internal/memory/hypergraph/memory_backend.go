@@ -48,6 +48,15 @@ func (b *InMemoryBackend) CreateNode(ctx context.Context, node *Node) error {
 		node.Confidence = 1.0
 	}
 
+	if node.IdempotencyKey != "" {
+		for _, existing := range b.nodes {
+			if existing.IdempotencyKey == node.IdempotencyKey {
+				*node = *existing
+				return nil
+			}
+		}
+	}
+
 	// Deep copy to prevent external mutations
 	nodeCopy := *node
 	b.nodes[node.ID] = &nodeCopy
@@ -61,7 +70,7 @@ func (b *InMemoryBackend) GetNode(ctx context.Context, id string) (*Node, error)
 	defer b.mu.RUnlock()
 
 	node, ok := b.nodes[id]
-	if !ok {
+	if !ok || node.DeletedAt != nil {
 		return nil, &ErrNotFound{Entity: "node", ID: id}
 	}
 
@@ -75,12 +84,15 @@ func (b *InMemoryBackend) UpdateNode(ctx context.Context, node *Node) error {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
-	if _, ok := b.nodes[node.ID]; !ok {
+	existing, ok := b.nodes[node.ID]
+	if !ok || existing.DeletedAt != nil {
 		return &ErrNotFound{Entity: "node", ID: node.ID}
 	}
 
 	node.UpdatedAt = time.Now().UTC()
 	nodeCopy := *node
+	nodeCopy.DeletedAt = nil
+	nodeCopy.IdempotencyKey = existing.IdempotencyKey
 	b.nodes[node.ID] = &nodeCopy
 
 	return nil
@@ -109,6 +121,65 @@ func (b *InMemoryBackend) DeleteNode(ctx context.Context, id string) error {
 	return nil
 }
 
+// SoftDeleteNode marks a node as deleted without removing it.
+func (b *InMemoryBackend) SoftDeleteNode(ctx context.Context, id string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	node, ok := b.nodes[id]
+	if !ok || node.DeletedAt != nil {
+		return &ErrNotFound{Entity: "node", ID: id}
+	}
+
+	now := time.Now().UTC()
+	node.DeletedAt = &now
+
+	return nil
+}
+
+// RestoreNode undoes a soft delete.
+func (b *InMemoryBackend) RestoreNode(ctx context.Context, id string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	node, ok := b.nodes[id]
+	if !ok || node.DeletedAt == nil {
+		return &ErrNotFound{Entity: "deleted node", ID: id}
+	}
+
+	node.DeletedAt = nil
+	node.UpdatedAt = time.Now().UTC()
+
+	return nil
+}
+
+// PurgeDeleted permanently removes nodes soft-deleted more than olderThan ago.
+func (b *InMemoryBackend) PurgeDeleted(ctx context.Context, olderThan time.Duration) (int64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	cutoff := time.Now().UTC().Add(-olderThan)
+	purged := make(map[string]bool)
+	for id, node := range b.nodes {
+		if node.DeletedAt != nil && !node.DeletedAt.After(cutoff) {
+			delete(b.nodes, id)
+			purged[id] = true
+		}
+	}
+
+	if len(purged) > 0 {
+		filtered := make([]Membership, 0, len(b.memberships))
+		for _, m := range b.memberships {
+			if !purged[m.NodeID] {
+				filtered = append(filtered, m)
+			}
+		}
+		b.memberships = filtered
+	}
+
+	return int64(len(purged)), nil
+}
+
 // ListNodes retrieves nodes matching the filter.
 func (b *InMemoryBackend) ListNodes(ctx context.Context, filter NodeFilter) ([]*Node, error) {
 	b.mu.RLock()
@@ -142,6 +213,10 @@ func (b *InMemoryBackend) ListNodes(ctx context.Context, filter NodeFilter) ([]*
 }
 
 func (b *InMemoryBackend) matchesNodeFilter(node *Node, filter NodeFilter) bool {
+	if node.DeletedAt != nil && !filter.IncludeDeleted {
+		return false
+	}
+
 	if len(filter.Types) > 0 {
 		found := false
 		for _, t := range filter.Types {
@@ -209,7 +284,7 @@ func (b *InMemoryBackend) IncrementAccess(ctx context.Context, id string) error
 	defer b.mu.Unlock()
 
 	node, ok := b.nodes[id]
-	if !ok {
+	if !ok || node.DeletedAt != nil {
 		return &ErrNotFound{Entity: "node", ID: id}
 	}
 
@@ -414,7 +489,7 @@ func (b *InMemoryBackend) GetMemberNodes(ctx context.Context, hyperedgeID string
 
 	var results []*Node
 	for _, id := range nodeIDs {
-		if node, ok := b.nodes[id]; ok {
+		if node, ok := b.nodes[id]; ok && node.DeletedAt == nil {
 			nodeCopy := *node
 			results = append(results, &nodeCopy)
 		}
@@ -460,8 +535,8 @@ func (b *InMemoryBackend) SearchByContent(ctx context.Context, query string, opt
 	var results []*SearchResult
 
 	for _, node := range b.nodes {
-		// Skip archived nodes
-		if node.Tier == TierArchive {
+		// Skip archived and soft-deleted nodes
+		if node.Tier == TierArchive || node.DeletedAt != nil {
 			continue
 		}
 
@@ -480,8 +555,11 @@ func (b *InMemoryBackend) SearchByContent(ctx context.Context, query string, opt
 		}
 	}
 
-	// Sort by access_count DESC, updated_at DESC
+	// Sort by pinned, access_count DESC, updated_at DESC
 	sort.Slice(results, func(i, j int) bool {
+		if results[i].Node.Pinned != results[j].Node.Pinned {
+			return results[i].Node.Pinned
+		}
 		if results[i].Node.AccessCount != results[j].Node.AccessCount {
 			return results[i].Node.AccessCount > results[j].Node.AccessCount
 		}
@@ -647,8 +725,8 @@ func (b *InMemoryBackend) getImmediateConnectionsLocked(nodeID string, opts Trav
 					continue
 				}
 
-				// Skip archived
-				if node.Tier == TierArchive {
+				// Skip archived, unless pinned, and soft-deleted
+				if (node.Tier == TierArchive && !node.Pinned) || node.DeletedAt != nil {
 					continue
 				}
 
@@ -705,7 +783,7 @@ func (b *InMemoryBackend) RecentNodes(ctx context.Context, limit int, tiers []Ti
 
 	var results []*Node
 	for _, node := range b.nodes {
-		if node.Tier == TierArchive {
+		if node.Tier == TierArchive || node.DeletedAt != nil {
 			continue
 		}
 
@@ -752,13 +830,16 @@ func (b *InMemoryBackend) Stats(ctx context.Context) (*Stats, error) {
 	defer b.mu.RUnlock()
 
 	stats := &Stats{
-		NodeCount:      int64(len(b.nodes)),
 		HyperedgeCount: int64(len(b.hyperedges)),
 		NodesByTier:    make(map[string]int64),
 		NodesByType:    make(map[string]int64),
 	}
 
 	for _, node := range b.nodes {
+		if node.DeletedAt != nil {
+			continue
+		}
+		stats.NodeCount++
 		stats.NodesByTier[string(node.Tier)]++
 		stats.NodesByType[string(node.Type)]++
 	}
@@ -766,6 +847,27 @@ func (b *InMemoryBackend) Stats(ctx context.Context) (*Stats, error) {
 	return stats, nil
 }
 
+// BeginReadTx returns a snapshot backed by a copy of the current data.
+func (b *InMemoryBackend) BeginReadTx(ctx context.Context) (ReadTx, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	snapshot := &InMemoryBackend{
+		nodes:       make(map[string]*Node, len(b.nodes)),
+		hyperedges:  make(map[string]*Hyperedge, len(b.hyperedges)),
+		memberships: append([]Membership(nil), b.memberships...),
+	}
+	for id, node := range b.nodes {
+		nodeCopy := *node
+		snapshot.nodes[id] = &nodeCopy
+	}
+	for id, edge := range b.hyperedges {
+		edgeCopy := *edge
+		snapshot.hyperedges[id] = &edgeCopy
+	}
+	return snapshot, nil
+}
+
 // Close is a no-op for in-memory backend.
 func (b *InMemoryBackend) Close() error {
 	return nil
@@ -773,3 +875,5 @@ func (b *InMemoryBackend) Close() error {
 
 // Verify InMemoryBackend implements Backend interface.
 var _ Backend = (*InMemoryBackend)(nil)
+
+var _ ReadTxBackend = (*InMemoryBackend)(nil)
@@ -46,6 +46,18 @@ type Node struct {
 	Confidence   float64         `json:"confidence"`
 	Provenance   json.RawMessage `json:"provenance,omitempty"`
 	Metadata     json.RawMessage `json:"metadata,omitempty"`
+	DeletedAt    *time.Time      `json:"deleted_at,omitempty"` // Set when soft-deleted
+
+	// IdempotencyKey makes creation safe to retry: creating a node with a key
+	// already in use returns the existing node instead of inserting another.
+	// The key stays claimed while that node exists, even if soft-deleted, and
+	// is not changed by updates.
+	IdempotencyKey string `json:"idempotency_key,omitempty"`
+
+	// Pinned marks a node the user wants kept: it is exempt from confidence
+	// decay, tier demotion, consolidation merging and archiving, and ranks
+	// first among search matches.
+	Pinned bool `json:"pinned,omitempty"`
 }
 
 // Provenance captures the source of a node.
@@ -72,130 +84,98 @@ func NewNode(nodeType NodeType, content string) *Node {
 }
 
 // CreateNode inserts a new node into the database.
+// If node.IdempotencyKey matches an existing node, node is filled in with
+// that node and nothing is inserted.
 func (s *Store) CreateNode(ctx context.Context, node *Node) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	if node.ID == "" {
-		node.ID = uuid.New().String()
-	}
-	if node.CreatedAt.IsZero() {
-		node.CreatedAt = time.Now().UTC()
-	}
-	if node.UpdatedAt.IsZero() {
-		node.UpdatedAt = time.Now().UTC()
+	s.filterContent(node)
+	if err := s.backend.CreateNode(ctx, node); err != nil {
+		return err
 	}
-	if node.Tier == "" {
-		node.Tier = TierTask
-	}
-	if node.Confidence == 0 {
-		node.Confidence = 1.0
+	if s.trackHistory {
+		s.trackCreate(ctx, node)
 	}
+	return nil
+}
 
-	_, err := s.db.ExecContext(ctx, `
-		INSERT INTO nodes (id, type, subtype, content, embedding, created_at, updated_at,
-		                   access_count, last_accessed, tier, confidence, provenance, metadata)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
-	`,
-		node.ID, node.Type, nullString(node.Subtype), node.Content, node.Embedding,
-		node.CreatedAt, node.UpdatedAt, node.AccessCount, nullTime(node.LastAccessed),
-		node.Tier, node.Confidence, node.Provenance, node.Metadata,
-	)
-	if err != nil {
-		return fmt.Errorf("insert node: %w", err)
+// filterContent applies the store's content filter to node.
+func (s *Store) filterContent(node *Node) {
+	if s.contentFilter != nil {
+		node.Content = s.contentFilter(node.Content)
 	}
-
-	return nil
 }
 
 // GetNode retrieves a node by ID.
 func (s *Store) GetNode(ctx context.Context, id string) (*Node, error) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-
-	return s.getNodeLocked(ctx, id)
+	return s.backend.GetNode(ctx, id)
 }
 
-func (s *Store) getNodeLocked(ctx context.Context, id string) (*Node, error) {
-	row := s.db.QueryRowContext(ctx, `
-		SELECT id, type, subtype, content, embedding, created_at, updated_at,
-		       access_count, last_accessed, tier, confidence, provenance, metadata
-		FROM nodes WHERE id = ?
-	`, id)
+// UpdateNode updates an existing node.
+func (s *Store) UpdateNode(ctx context.Context, node *Node) error {
+	s.filterContent(node)
+	return s.trackNode(ctx, node.ID, historyUpdate, func() error {
+		return s.backend.UpdateNode(ctx, node)
+	})
+}
 
-	return scanNode(row)
+// DeleteNode permanently removes a node by ID.
+// Automated processes should prefer SoftDeleteNode so mistakes can be undone.
+func (s *Store) DeleteNode(ctx context.Context, id string) error {
+	return s.trackNode(ctx, id, historyDelete, func() error {
+		return s.backend.DeleteNode(ctx, id)
+	})
 }
 
-// UpdateNode updates an existing node.
-func (s *Store) UpdateNode(ctx context.Context, node *Node) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	node.UpdatedAt = time.Now().UTC()
-
-	result, err := s.db.ExecContext(ctx, `
-		UPDATE nodes SET
-			type = ?, subtype = ?, content = ?, embedding = ?, updated_at = ?,
-			access_count = ?, last_accessed = ?, tier = ?, confidence = ?,
-			provenance = ?, metadata = ?
-		WHERE id = ?
-	`,
-		node.Type, nullString(node.Subtype), node.Content, node.Embedding, node.UpdatedAt,
-		node.AccessCount, nullTime(node.LastAccessed), node.Tier, node.Confidence,
-		node.Provenance, node.Metadata, node.ID,
-	)
-	if err != nil {
-		return fmt.Errorf("update node: %w", err)
-	}
+// SoftDeleteNode marks a node as deleted without removing it.
+// Soft-deleted nodes are hidden from GetNode, ListNodes, and searches until
+// restored with RestoreNode or permanently removed by PurgeDeleted.
+func (s *Store) SoftDeleteNode(ctx context.Context, id string) error {
+	return s.trackNode(ctx, id, historySoftDelete, func() error {
+		return s.backend.SoftDeleteNode(ctx, id)
+	})
+}
 
-	rows, err := result.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("rows affected: %w", err)
-	}
-	if rows == 0 {
-		return fmt.Errorf("node not found: %s", node.ID)
-	}
+// RestoreNode undoes a soft delete, making the node visible again.
+func (s *Store) RestoreNode(ctx context.Context, id string) error {
+	return s.trackNode(ctx, id, historyRestore, func() error {
+		return s.backend.RestoreNode(ctx, id)
+	})
+}
 
-	return nil
+// PinNode pins a node so maintenance never decays, demotes, merges or
+// archives it. A node already archived is restored to the long-term tier.
+func (s *Store) PinNode(ctx context.Context, id string) error {
+	return s.setPinned(ctx, id, true)
 }
 
-// DeleteNode removes a node by ID.
-func (s *Store) DeleteNode(ctx context.Context, id string) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+// UnpinNode returns a pinned node to normal maintenance.
+func (s *Store) UnpinNode(ctx context.Context, id string) error {
+	return s.setPinned(ctx, id, false)
+}
 
-	result, err := s.db.ExecContext(ctx, "DELETE FROM nodes WHERE id = ?", id)
+func (s *Store) setPinned(ctx context.Context, id string, pinned bool) error {
+	node, err := s.GetNode(ctx, id)
 	if err != nil {
-		return fmt.Errorf("delete node: %w", err)
+		return err
 	}
-
-	rows, err := result.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("rows affected: %w", err)
+	if node.Pinned == pinned {
+		return nil
 	}
-	if rows == 0 {
-		return fmt.Errorf("node not found: %s", id)
+	node.Pinned = pinned
+	if pinned && node.Tier == TierArchive {
+		node.Tier = TierLongterm
 	}
+	return s.UpdateNode(ctx, node)
+}
 
-	return nil
+// PurgeDeleted permanently removes nodes that were soft-deleted more than
+// olderThan ago. It returns the number of nodes removed.
+func (s *Store) PurgeDeleted(ctx context.Context, olderThan time.Duration) (int64, error) {
+	return s.backend.PurgeDeleted(ctx, olderThan)
 }
 
 // IncrementAccess increments the access count for a node.
 func (s *Store) IncrementAccess(ctx context.Context, id string) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	// Pass time.Time directly like CreateNode does for created_at/updated_at
-	now := time.Now().UTC()
-	_, err := s.db.ExecContext(ctx, `
-		UPDATE nodes SET access_count = access_count + 1, last_accessed = ?
-		WHERE id = ?
-	`, now, id)
-	if err != nil {
-		return fmt.Errorf("increment access: %w", err)
-	}
-
-	return nil
+	return s.backend.IncrementAccess(ctx, id)
 }
 
 // NodeFilter defines criteria for filtering nodes.
@@ -206,160 +186,46 @@ type NodeFilter struct {
 	MinConfidence float64
 	Limit    int
 	Offset   int
+
+	// IncludeDeleted includes soft-deleted nodes in the results.
+	IncludeDeleted bool
 }
 
 // ListNodes retrieves nodes matching the given filter.
 func (s *Store) ListNodes(ctx context.Context, filter NodeFilter) ([]*Node, error) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-
-	query := "SELECT id, type, subtype, content, embedding, created_at, updated_at, " +
-		"access_count, last_accessed, tier, confidence, provenance, metadata FROM nodes WHERE 1=1"
-	var args []any
-
-	if len(filter.Types) > 0 {
-		query += " AND type IN (?" + repeatString(",?", len(filter.Types)-1) + ")"
-		for _, t := range filter.Types {
-			args = append(args, t)
-		}
-	}
-
-	if len(filter.Subtypes) > 0 {
-		query += " AND subtype IN (?" + repeatString(",?", len(filter.Subtypes)-1) + ")"
-		for _, s := range filter.Subtypes {
-			args = append(args, s)
-		}
-	}
-
-	if len(filter.Tiers) > 0 {
-		query += " AND tier IN (?" + repeatString(",?", len(filter.Tiers)-1) + ")"
-		for _, t := range filter.Tiers {
-			args = append(args, t)
-		}
-	}
-
-	if filter.MinConfidence > 0 {
-		query += " AND confidence >= ?"
-		args = append(args, filter.MinConfidence)
-	}
-
-	query += " ORDER BY created_at DESC"
-
-	if filter.Limit > 0 {
-		query += " LIMIT ?"
-		args = append(args, filter.Limit)
-	}
-
-	if filter.Offset > 0 {
-		query += " OFFSET ?"
-		args = append(args, filter.Offset)
-	}
-
-	rows, err := s.db.QueryContext(ctx, query, args...)
-	if err != nil {
-		return nil, fmt.Errorf("query nodes: %w", err)
-	}
-	defer rows.Close()
-
-	var nodes []*Node
-	for rows.Next() {
-		node, err := scanNodeRows(rows)
-		if err != nil {
-			return nil, err
-		}
-		nodes = append(nodes, node)
-	}
-
-	return nodes, rows.Err()
+	return s.backend.ListNodes(ctx, filter)
 }
 
 // CountNodes returns the count of nodes matching the filter.
 func (s *Store) CountNodes(ctx context.Context, filter NodeFilter) (int64, error) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-
-	query := "SELECT COUNT(*) FROM nodes WHERE 1=1"
-	var args []any
-
-	if len(filter.Types) > 0 {
-		query += " AND type IN (?" + repeatString(",?", len(filter.Types)-1) + ")"
-		for _, t := range filter.Types {
-			args = append(args, t)
-		}
-	}
-
-	if len(filter.Tiers) > 0 {
-		query += " AND tier IN (?" + repeatString(",?", len(filter.Tiers)-1) + ")"
-		for _, t := range filter.Tiers {
-			args = append(args, t)
-		}
-	}
-
-	if filter.MinConfidence > 0 {
-		query += " AND confidence >= ?"
-		args = append(args, filter.MinConfidence)
-	}
-
-	var count int64
-	err := s.db.QueryRowContext(ctx, query, args...).Scan(&count)
-	if err != nil {
-		return 0, fmt.Errorf("count nodes: %w", err)
-	}
-
-	return count, nil
+	return s.backend.CountNodes(ctx, filter)
 }
 
 // Helper functions
 
-func scanNode(row *sql.Row) (*Node, error) {
-	var node Node
-	var subtype, provenance, metadata sql.NullString
-	var lastAccessed sql.NullTime
-
-	err := row.Scan(
-		&node.ID, &node.Type, &subtype, &node.Content, &node.Embedding,
-		&node.CreatedAt, &node.UpdatedAt, &node.AccessCount, &lastAccessed,
-		&node.Tier, &node.Confidence, &provenance, &metadata,
-	)
-	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("node not found")
-	}
-	if err != nil {
-		return nil, fmt.Errorf("scan node: %w", err)
-	}
-
-	node.Subtype = subtype.String
-	if lastAccessed.Valid {
-		node.LastAccessed = &lastAccessed.Time
-	}
-	if provenance.Valid {
-		node.Provenance = json.RawMessage(provenance.String)
-	}
-	if metadata.Valid {
-		node.Metadata = json.RawMessage(metadata.String)
-	}
-
-	return &node, nil
-}
-
 func scanNodeRows(rows *sql.Rows) (*Node, error) {
 	var node Node
-	var subtype, provenance, metadata sql.NullString
-	var lastAccessed sql.NullTime
+	var subtype, provenance, metadata, idempotencyKey sql.NullString
+	var lastAccessed, deletedAt sql.NullTime
 
 	err := rows.Scan(
 		&node.ID, &node.Type, &subtype, &node.Content, &node.Embedding,
 		&node.CreatedAt, &node.UpdatedAt, &node.AccessCount, &lastAccessed,
-		&node.Tier, &node.Confidence, &provenance, &metadata,
+		&node.Tier, &node.Confidence, &provenance, &metadata, &deletedAt,
+		&idempotencyKey, &node.Pinned,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("scan node: %w", err)
 	}
 
 	node.Subtype = subtype.String
+	node.IdempotencyKey = idempotencyKey.String
 	if lastAccessed.Valid {
 		node.LastAccessed = &lastAccessed.Time
 	}
+	if deletedAt.Valid {
+		node.DeletedAt = &deletedAt.Time
+	}
 	if provenance.Valid {
 		node.Provenance = json.RawMessage(provenance.String)
 	}
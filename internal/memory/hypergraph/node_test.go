@@ -3,7 +3,11 @@ package hypergraph
 import (
 	"context"
 	"encoding/json"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -44,6 +48,29 @@ func TestStore_CreateNode(t *testing.T) {
 	assert.Equal(t, TierTask, got.Tier)
 }
 
+func TestStore_ContentFilter(t *testing.T) {
+	store, err := NewStore(Options{
+		ContentFilter: func(s string) string { return strings.ReplaceAll(s, "hunter2", "[REDACTED]") },
+	})
+	require.NoError(t, err)
+	defer store.Close()
+
+	ctx := context.Background()
+	node := NewNode(NodeTypeFact, "the password is hunter2")
+	require.NoError(t, store.CreateNode(ctx, node))
+	assert.Equal(t, "the password is [REDACTED]", node.Content)
+
+	got, err := store.GetNode(ctx, node.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "the password is [REDACTED]", got.Content)
+
+	got.Content = "now it is hunter2"
+	require.NoError(t, store.UpdateNode(ctx, got))
+	got, err = store.GetNode(ctx, node.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "now it is [REDACTED]", got.Content)
+}
+
 func TestStore_CreateNode_WithProvenance(t *testing.T) {
 	store, err := NewStore(Options{})
 	require.NoError(t, err)
@@ -148,6 +175,183 @@ func TestStore_DeleteNode_NotFound(t *testing.T) {
 	assert.Contains(t, err.Error(), "not found")
 }
 
+func TestStore_SoftDeleteNode(t *testing.T) {
+	store, err := NewStore(Options{})
+	require.NoError(t, err)
+	defer store.Close()
+
+	ctx := context.Background()
+	kept := NewNode(NodeTypeFact, "kept fact about parsing")
+	removed := NewNode(NodeTypeFact, "removed fact about parsing")
+	require.NoError(t, store.CreateNode(ctx, kept))
+	require.NoError(t, store.CreateNode(ctx, removed))
+
+	require.NoError(t, store.SoftDeleteNode(ctx, removed.ID))
+
+	// Hidden from lookups, listings, counts, and searches
+	_, err = store.GetNode(ctx, removed.ID)
+	assert.Error(t, err)
+
+	nodes, err := store.ListNodes(ctx, NodeFilter{})
+	require.NoError(t, err)
+	require.Len(t, nodes, 1)
+	assert.Equal(t, kept.ID, nodes[0].ID)
+
+	count, err := store.CountNodes(ctx, NodeFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+
+	results, err := store.SearchByContent(ctx, "parsing", SearchOptions{})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, kept.ID, results[0].Node.ID)
+
+	recent, err := store.RecentNodes(ctx, 10, nil)
+	require.NoError(t, err)
+	assert.Len(t, recent, 1)
+
+	stats, err := store.Stats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, count, stats.NodeCount)
+	assert.Equal(t, int64(1), stats.NodesByType[string(NodeTypeFact)])
+
+	// Access tracking does not apply to deleted nodes
+	err = store.IncrementAccess(ctx, removed.ID)
+	assert.True(t, IsNotFound(err))
+
+	// Still visible when explicitly requested
+	nodes, err = store.ListNodes(ctx, NodeFilter{IncludeDeleted: true})
+	require.NoError(t, err)
+	assert.Len(t, nodes, 2)
+	for _, n := range nodes {
+		if n.ID == removed.ID {
+			assert.NotNil(t, n.DeletedAt)
+		} else {
+			assert.Nil(t, n.DeletedAt)
+		}
+	}
+
+	// Deleting twice reports not found
+	err = store.SoftDeleteNode(ctx, removed.ID)
+	assert.Error(t, err)
+}
+
+func TestStore_RestoreNode(t *testing.T) {
+	store, err := NewStore(Options{})
+	require.NoError(t, err)
+	defer store.Close()
+
+	ctx := context.Background()
+	node := NewNode(NodeTypeFact, "restorable")
+	require.NoError(t, store.CreateNode(ctx, node))
+	require.NoError(t, store.SoftDeleteNode(ctx, node.ID))
+
+	require.NoError(t, store.RestoreNode(ctx, node.ID))
+
+	got, err := store.GetNode(ctx, node.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "restorable", got.Content)
+	assert.Nil(t, got.DeletedAt)
+
+	// Restoring a live node is an error
+	err = store.RestoreNode(ctx, node.ID)
+	assert.Error(t, err)
+}
+
+func TestStore_PinNode(t *testing.T) {
+	store, err := NewStore(Options{})
+	require.NoError(t, err)
+	defer store.Close()
+
+	ctx := context.Background()
+	node := NewNode(NodeTypeFact, "the prod database must never be dropped")
+	node.Tier = TierArchive
+	require.NoError(t, store.CreateNode(ctx, node))
+
+	// Pinning brings an archived node back into retrieval
+	require.NoError(t, store.PinNode(ctx, node.ID))
+	pinned, err := store.GetNode(ctx, node.ID)
+	require.NoError(t, err)
+	assert.True(t, pinned.Pinned)
+	assert.Equal(t, TierLongterm, pinned.Tier)
+
+	require.NoError(t, store.UnpinNode(ctx, node.ID))
+	unpinned, err := store.GetNode(ctx, node.ID)
+	require.NoError(t, err)
+	assert.False(t, unpinned.Pinned)
+	assert.Equal(t, TierLongterm, unpinned.Tier)
+
+	assert.True(t, IsNotFound(store.PinNode(ctx, "missing")))
+}
+
+func TestStore_SoftDeleteHidesConnections(t *testing.T) {
+	store, err := NewStore(Options{})
+	require.NoError(t, err)
+	defer store.Close()
+
+	ctx := context.Background()
+	a := NewNode(NodeTypeEntity, "a")
+	b := NewNode(NodeTypeEntity, "b")
+	require.NoError(t, store.CreateNode(ctx, a))
+	require.NoError(t, store.CreateNode(ctx, b))
+	edge, err := store.CreateRelation(ctx, "uses", a.ID, b.ID)
+	require.NoError(t, err)
+
+	require.NoError(t, store.SoftDeleteNode(ctx, b.ID))
+
+	connected, err := store.GetConnected(ctx, a.ID, TraversalOptions{Direction: TraverseBoth})
+	require.NoError(t, err)
+	assert.Empty(t, connected)
+
+	members, err := store.GetMemberNodes(ctx, edge.ID)
+	require.NoError(t, err)
+	require.Len(t, members, 1)
+	assert.Equal(t, a.ID, members[0].ID)
+
+	// Restoring brings the connection back
+	require.NoError(t, store.RestoreNode(ctx, b.ID))
+	connected, err = store.GetConnected(ctx, a.ID, TraversalOptions{Direction: TraverseBoth})
+	require.NoError(t, err)
+	assert.Len(t, connected, 1)
+}
+
+func TestStore_PurgeDeleted(t *testing.T) {
+	store, err := NewStore(Options{})
+	require.NoError(t, err)
+	defer store.Close()
+
+	ctx := context.Background()
+	old := NewNode(NodeTypeFact, "deleted long ago")
+	recent := NewNode(NodeTypeFact, "deleted just now")
+	live := NewNode(NodeTypeFact, "never deleted")
+	for _, n := range []*Node{old, recent, live} {
+		require.NoError(t, store.CreateNode(ctx, n))
+	}
+
+	require.NoError(t, store.SoftDeleteNode(ctx, recent.ID))
+	_, err = store.DB().ExecContext(ctx,
+		"UPDATE nodes SET deleted_at = ? WHERE id = ?",
+		time.Now().UTC().Add(-48*time.Hour), old.ID)
+	require.NoError(t, err)
+
+	// Only the node outside the retention window is purged
+	purged, err := store.PurgeDeleted(ctx, 24*time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), purged)
+
+	nodes, err := store.ListNodes(ctx, NodeFilter{IncludeDeleted: true})
+	require.NoError(t, err)
+	ids := make([]string, 0, len(nodes))
+	for _, n := range nodes {
+		ids = append(ids, n.ID)
+	}
+	assert.ElementsMatch(t, []string{recent.ID, live.ID}, ids)
+
+	// Purged nodes cannot be restored, retained ones can
+	assert.Error(t, store.RestoreNode(ctx, old.ID))
+	assert.NoError(t, store.RestoreNode(ctx, recent.ID))
+}
+
 func TestStore_IncrementAccess(t *testing.T) {
 	store, err := NewStore(Options{})
 	require.NoError(t, err)
@@ -320,3 +524,41 @@ func TestStore_CountNodes(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, int64(3), count)
 }
+
+func TestSQLiteBackend_IdempotencyKeyAcrossConnections(t *testing.T) {
+	// Separate backends don't share a mutex, so only the unique index on the
+	// key prevents duplicates
+	path := filepath.Join(t.TempDir(), "idempotency.db")
+	backends := make([]*SQLiteBackend, 4)
+	for i := range backends {
+		b, err := NewSQLiteBackend(SQLiteBackendOptions{Path: path})
+		require.NoError(t, err)
+		defer b.Close()
+		backends[i] = b
+	}
+
+	ctx := context.Background()
+	ids := make([]string, 4*len(backends))
+	errs := make([]error, len(ids))
+	var wg sync.WaitGroup
+	for i := range ids {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			node := NewNode(NodeTypeFact, "retried fact")
+			node.IdempotencyKey = "fact-42"
+			errs[i] = backends[i%len(backends)].CreateNode(ctx, node)
+			ids[i] = node.ID
+		}()
+	}
+	wg.Wait()
+
+	for i := range ids {
+		require.NoError(t, errs[i])
+		assert.Equal(t, ids[0], ids[i])
+	}
+	var rows int
+	require.NoError(t, backends[0].DB().QueryRow(
+		"SELECT COUNT(*) FROM nodes WHERE idempotency_key = ?", "fact-42").Scan(&rows))
+	assert.Equal(t, 1, rows)
+}
@@ -17,7 +17,10 @@ CREATE TABLE IF NOT EXISTS nodes (
     tier TEXT DEFAULT 'task' CHECK(tier IN ('task', 'session', 'longterm', 'archive')),
     confidence REAL DEFAULT 1.0 CHECK(confidence >= 0 AND confidence <= 1),
     provenance TEXT,  -- JSON: source file, line, commit, etc
-    metadata TEXT     -- JSON: flexible additional data
+    metadata TEXT,    -- JSON: flexible additional data
+    deleted_at TIMESTAMP,  -- soft-delete marker; NULL for live nodes
+    idempotency_key TEXT,  -- caller-supplied key deduplicating retried creates
+    pinned INTEGER NOT NULL DEFAULT 0  -- exempt from decay, demotion, merging and archiving
 );
 
 -- Hyperedges connect multiple nodes with semantic relationships
@@ -68,6 +71,16 @@ CREATE TABLE IF NOT EXISTS evolution_log (
     metadata TEXT     -- JSON: additional context
 );
 
+-- Append-only node history for point-in-time queries (Options.TrackHistory)
+-- No foreign key: history outlives deleted nodes
+CREATE TABLE IF NOT EXISTS node_history (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    node_id TEXT NOT NULL,
+    recorded_at INTEGER NOT NULL,  -- Unix nanoseconds the state took effect
+    operation TEXT NOT NULL CHECK(operation IN ('baseline', 'create', 'update', 'soft_delete', 'restore', 'delete')),
+    snapshot TEXT                  -- JSON node; NULL once deleted
+);
+
 -- Retrieval outcome tracking for meta-evolution [SPEC-06.01]
 CREATE TABLE IF NOT EXISTS retrieval_outcomes (
     id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -148,6 +161,10 @@ CREATE INDEX IF NOT EXISTS idx_decisions_status ON decisions(status);
 CREATE INDEX IF NOT EXISTS idx_evolution_operation ON evolution_log(operation);
 CREATE INDEX IF NOT EXISTS idx_evolution_timestamp ON evolution_log(timestamp);
 
+-- Node history queries
+CREATE INDEX IF NOT EXISTS idx_node_history_node ON node_history(node_id, recorded_at);
+CREATE INDEX IF NOT EXISTS idx_node_history_recorded ON node_history(recorded_at);
+
 -- Retrieval outcomes queries
 CREATE INDEX IF NOT EXISTS idx_outcomes_timestamp ON retrieval_outcomes(timestamp);
 CREATE INDEX IF NOT EXISTS idx_outcomes_query_hash ON retrieval_outcomes(query_hash);
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	_ "embed"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -17,15 +18,22 @@ import (
 )
 
 //go:embed schema.sql
-var schemaSQLEmbed string
+var schemaSQL string
 
 // SQLiteBackend provides a SQLite implementation of Backend.
 type SQLiteBackend struct {
 	db   *sql.DB
+	q    querier // reads go through q: db, or a read snapshot's tx
 	mu   sync.RWMutex
 	path string
 }
 
+// querier is the read side of *sql.DB and *sql.Tx.
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
 // SQLiteBackendOptions configures the SQLite backend.
 type SQLiteBackendOptions struct {
 	// Path to the SQLite database file.
@@ -64,6 +72,7 @@ func NewSQLiteBackend(opts SQLiteBackendOptions) (*SQLiteBackend, error) {
 
 	backend := &SQLiteBackend{
 		db:   db,
+		q:    db,
 		path: opts.Path,
 	}
 
@@ -79,14 +88,81 @@ func (b *SQLiteBackend) initSchema() error {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
-	_, err := b.db.Exec(schemaSQLEmbed)
+	_, err := b.db.Exec(schemaSQL)
 	if err != nil {
 		return fmt.Errorf("execute schema: %w", err)
 	}
 
+	return migrateSchema(b.db)
+}
+
+// migrateSchema upgrades databases created by older schema versions.
+// CREATE TABLE IF NOT EXISTS leaves existing tables untouched, so columns
+// added after the initial release are applied here.
+func migrateSchema(db *sql.DB) error {
+	hasDeletedAt, err := hasColumn(db, "nodes", "deleted_at")
+	if err != nil {
+		return err
+	}
+	if !hasDeletedAt {
+		if _, err := db.Exec("ALTER TABLE nodes ADD COLUMN deleted_at TIMESTAMP"); err != nil {
+			return fmt.Errorf("add nodes.deleted_at: %w", err)
+		}
+	}
+
+	if _, err := db.Exec("CREATE INDEX IF NOT EXISTS idx_nodes_deleted ON nodes(deleted_at)"); err != nil {
+		return fmt.Errorf("create deleted_at index: %w", err)
+	}
+
+	hasIdempotencyKey, err := hasColumn(db, "nodes", "idempotency_key")
+	if err != nil {
+		return err
+	}
+	if !hasIdempotencyKey {
+		if _, err := db.Exec("ALTER TABLE nodes ADD COLUMN idempotency_key TEXT"); err != nil {
+			return fmt.Errorf("add nodes.idempotency_key: %w", err)
+		}
+	}
+
+	if _, err := db.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_nodes_idempotency_key
+		ON nodes(idempotency_key) WHERE idempotency_key IS NOT NULL`); err != nil {
+		return fmt.Errorf("create idempotency_key index: %w", err)
+	}
+
+	hasPinned, err := hasColumn(db, "nodes", "pinned")
+	if err != nil {
+		return err
+	}
+	if !hasPinned {
+		if _, err := db.Exec("ALTER TABLE nodes ADD COLUMN pinned INTEGER NOT NULL DEFAULT 0"); err != nil {
+			return fmt.Errorf("add nodes.pinned: %w", err)
+		}
+	}
+
 	return nil
 }
 
+// hasColumn reports whether the given table has a column with the given name.
+func hasColumn(db *sql.DB, table, column string) (bool, error) {
+	rows, err := db.Query("SELECT name FROM pragma_table_info(?)", table)
+	if err != nil {
+		return false, fmt.Errorf("table info %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return false, fmt.Errorf("scan column name: %w", err)
+		}
+		if name == column {
+			return true, nil
+		}
+	}
+
+	return false, rows.Err()
+}
+
 // DB returns the underlying database connection.
 func (b *SQLiteBackend) DB() *sql.DB {
 	return b.db
@@ -118,18 +194,42 @@ func (b *SQLiteBackend) CreateNode(ctx context.Context, node *Node) error {
 		node.Confidence = 1.0
 	}
 
-	_, err := b.db.ExecContext(ctx, `
+	// The unique index on idempotency_key also settles races with other
+	// connections: the loser inserts nothing and reads the winner's node.
+	result, err := b.db.ExecContext(ctx, `
 		INSERT INTO nodes (id, type, subtype, content, embedding, created_at, updated_at,
-		                   access_count, last_accessed, tier, confidence, provenance, metadata)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		                   access_count, last_accessed, tier, confidence, provenance, metadata,
+		                   idempotency_key, pinned)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (idempotency_key) WHERE idempotency_key IS NOT NULL DO NOTHING
 	`,
 		node.ID, node.Type, nullString(node.Subtype), node.Content, node.Embedding,
 		node.CreatedAt, node.UpdatedAt, node.AccessCount, nullTime(node.LastAccessed),
 		node.Tier, node.Confidence, node.Provenance, node.Metadata,
+		nullString(node.IdempotencyKey), node.Pinned,
 	)
 	if err != nil {
 		return fmt.Errorf("insert node: %w", err)
 	}
+	if node.IdempotencyKey == "" {
+		return nil
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected: %w", err)
+	}
+	if rows == 0 {
+		existing, err := scanNodeRow(b.db.QueryRowContext(ctx, `
+			SELECT id, type, subtype, content, embedding, created_at, updated_at,
+			       access_count, last_accessed, tier, confidence, provenance, metadata, deleted_at, idempotency_key, pinned
+			FROM nodes WHERE idempotency_key = ?
+		`, node.IdempotencyKey))
+		if err != nil {
+			return fmt.Errorf("get node by idempotency key: %w", err)
+		}
+		*node = *existing
+	}
 
 	return nil
 }
@@ -139,10 +239,10 @@ func (b *SQLiteBackend) GetNode(ctx context.Context, id string) (*Node, error) {
 	b.mu.RLock()
 	defer b.mu.RUnlock()
 
-	row := b.db.QueryRowContext(ctx, `
+	row := b.q.QueryRowContext(ctx, `
 		SELECT id, type, subtype, content, embedding, created_at, updated_at,
-		       access_count, last_accessed, tier, confidence, provenance, metadata
-		FROM nodes WHERE id = ?
+		       access_count, last_accessed, tier, confidence, provenance, metadata, deleted_at, idempotency_key, pinned
+		FROM nodes WHERE id = ? AND deleted_at IS NULL
 	`, id)
 
 	node, err := scanNodeRow(row)
@@ -167,12 +267,12 @@ func (b *SQLiteBackend) UpdateNode(ctx context.Context, node *Node) error {
 		UPDATE nodes SET
 			type = ?, subtype = ?, content = ?, embedding = ?, updated_at = ?,
 			access_count = ?, last_accessed = ?, tier = ?, confidence = ?,
-			provenance = ?, metadata = ?
-		WHERE id = ?
+			provenance = ?, metadata = ?, pinned = ?
+		WHERE id = ? AND deleted_at IS NULL
 	`,
 		node.Type, nullString(node.Subtype), node.Content, node.Embedding, node.UpdatedAt,
 		node.AccessCount, nullTime(node.LastAccessed), node.Tier, node.Confidence,
-		node.Provenance, node.Metadata, node.ID,
+		node.Provenance, node.Metadata, node.Pinned, node.ID,
 	)
 	if err != nil {
 		return fmt.Errorf("update node: %w", err)
@@ -216,7 +316,7 @@ func (b *SQLiteBackend) ListNodes(ctx context.Context, filter NodeFilter) ([]*No
 	defer b.mu.RUnlock()
 
 	query := "SELECT id, type, subtype, content, embedding, created_at, updated_at, " +
-		"access_count, last_accessed, tier, confidence, provenance, metadata FROM nodes WHERE 1=1"
+		"access_count, last_accessed, tier, confidence, provenance, metadata, deleted_at, idempotency_key, pinned FROM nodes WHERE 1=1"
 	var args []any
 
 	if len(filter.Types) > 0 {
@@ -245,6 +345,10 @@ func (b *SQLiteBackend) ListNodes(ctx context.Context, filter NodeFilter) ([]*No
 		args = append(args, filter.MinConfidence)
 	}
 
+	if !filter.IncludeDeleted {
+		query += " AND deleted_at IS NULL"
+	}
+
 	query += " ORDER BY created_at DESC"
 
 	if filter.Limit > 0 {
@@ -257,7 +361,7 @@ func (b *SQLiteBackend) ListNodes(ctx context.Context, filter NodeFilter) ([]*No
 		args = append(args, filter.Offset)
 	}
 
-	rows, err := b.db.QueryContext(ctx, query, args...)
+	rows, err := b.q.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("query nodes: %w", err)
 	}
@@ -302,8 +406,12 @@ func (b *SQLiteBackend) CountNodes(ctx context.Context, filter NodeFilter) (int6
 		args = append(args, filter.MinConfidence)
 	}
 
+	if !filter.IncludeDeleted {
+		query += " AND deleted_at IS NULL"
+	}
+
 	var count int64
-	err := b.db.QueryRowContext(ctx, query, args...).Scan(&count)
+	err := b.q.QueryRowContext(ctx, query, args...).Scan(&count)
 	if err != nil {
 		return 0, fmt.Errorf("count nodes: %w", err)
 	}
@@ -311,6 +419,74 @@ func (b *SQLiteBackend) CountNodes(ctx context.Context, filter NodeFilter) (int6
 	return count, nil
 }
 
+// SoftDeleteNode marks a node as deleted without removing it.
+func (b *SQLiteBackend) SoftDeleteNode(ctx context.Context, id string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	result, err := b.db.ExecContext(ctx,
+		"UPDATE nodes SET deleted_at = ? WHERE id = ? AND deleted_at IS NULL",
+		time.Now().UTC(), id)
+	if err != nil {
+		return fmt.Errorf("soft delete node: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected: %w", err)
+	}
+	if rows == 0 {
+		return &ErrNotFound{Entity: "node", ID: id}
+	}
+
+	return nil
+}
+
+// RestoreNode undoes a soft delete.
+func (b *SQLiteBackend) RestoreNode(ctx context.Context, id string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	result, err := b.db.ExecContext(ctx,
+		"UPDATE nodes SET deleted_at = NULL, updated_at = ? WHERE id = ? AND deleted_at IS NOT NULL",
+		time.Now().UTC(), id)
+	if err != nil {
+		return fmt.Errorf("restore node: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected: %w", err)
+	}
+	if rows == 0 {
+		return &ErrNotFound{Entity: "deleted node", ID: id}
+	}
+
+	return nil
+}
+
+// PurgeDeleted permanently removes nodes soft-deleted more than olderThan ago.
+func (b *SQLiteBackend) PurgeDeleted(ctx context.Context, olderThan time.Duration) (int64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	cutoff := time.Now().UTC().Add(-olderThan)
+	result, err := b.db.ExecContext(ctx, `
+		DELETE FROM nodes
+		WHERE deleted_at IS NOT NULL AND julianday(deleted_at) <= julianday(?)
+	`, cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("purge deleted nodes: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("rows affected: %w", err)
+	}
+
+	return rows, nil
+}
+
 // IncrementAccess increments the access count for a node.
 func (b *SQLiteBackend) IncrementAccess(ctx context.Context, id string) error {
 	b.mu.Lock()
@@ -319,7 +495,7 @@ func (b *SQLiteBackend) IncrementAccess(ctx context.Context, id string) error {
 	now := time.Now().UTC()
 	result, err := b.db.ExecContext(ctx, `
 		UPDATE nodes SET access_count = access_count + 1, last_accessed = ?
-		WHERE id = ?
+		WHERE id = ? AND deleted_at IS NULL
 	`, now, id)
 	if err != nil {
 		return fmt.Errorf("increment access: %w", err)
@@ -367,7 +543,7 @@ func (b *SQLiteBackend) GetHyperedge(ctx context.Context, id string) (*Hyperedge
 	b.mu.RLock()
 	defer b.mu.RUnlock()
 
-	row := b.db.QueryRowContext(ctx, `
+	row := b.q.QueryRowContext(ctx, `
 		SELECT id, type, label, weight, created_at, metadata
 		FROM hyperedges WHERE id = ?
 	`, id)
@@ -460,7 +636,7 @@ func (b *SQLiteBackend) ListHyperedges(ctx context.Context, filter HyperedgeFilt
 		args = append(args, filter.Offset)
 	}
 
-	rows, err := b.db.QueryContext(ctx, query, args...)
+	rows, err := b.q.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("query hyperedges: %w", err)
 	}
@@ -522,7 +698,7 @@ func (b *SQLiteBackend) GetMembers(ctx context.Context, hyperedgeID string) ([]M
 	b.mu.RLock()
 	defer b.mu.RUnlock()
 
-	rows, err := b.db.QueryContext(ctx, `
+	rows, err := b.q.QueryContext(ctx, `
 		SELECT hyperedge_id, node_id, role, position
 		FROM membership WHERE hyperedge_id = ?
 		ORDER BY position
@@ -553,12 +729,12 @@ func (b *SQLiteBackend) GetMemberNodes(ctx context.Context, hyperedgeID string)
 	b.mu.RLock()
 	defer b.mu.RUnlock()
 
-	rows, err := b.db.QueryContext(ctx, `
+	rows, err := b.q.QueryContext(ctx, `
 		SELECT n.id, n.type, n.subtype, n.content, n.embedding, n.created_at, n.updated_at,
-		       n.access_count, n.last_accessed, n.tier, n.confidence, n.provenance, n.metadata
+		       n.access_count, n.last_accessed, n.tier, n.confidence, n.provenance, n.metadata, n.deleted_at, n.idempotency_key, n.pinned
 		FROM nodes n
 		JOIN membership m ON n.id = m.node_id
-		WHERE m.hyperedge_id = ?
+		WHERE m.hyperedge_id = ? AND n.deleted_at IS NULL
 		ORDER BY m.position
 	`, hyperedgeID)
 	if err != nil {
@@ -583,7 +759,7 @@ func (b *SQLiteBackend) GetNodeHyperedges(ctx context.Context, nodeID string) ([
 	b.mu.RLock()
 	defer b.mu.RUnlock()
 
-	rows, err := b.db.QueryContext(ctx, `
+	rows, err := b.q.QueryContext(ctx, `
 		SELECT h.id, h.type, h.label, h.weight, h.created_at, h.metadata
 		FROM hyperedges h
 		JOIN membership m ON h.id = m.hyperedge_id
@@ -613,7 +789,7 @@ func (b *SQLiteBackend) SearchByContent(ctx context.Context, query string, opts
 
 	sqlQuery := `
 		SELECT id, type, subtype, content, embedding, created_at, updated_at,
-		       access_count, last_accessed, tier, confidence, provenance, metadata
+		       access_count, last_accessed, tier, confidence, provenance, metadata, deleted_at, idempotency_key, pinned
 		FROM nodes WHERE content LIKE ?
 	`
 	args := []any{"%" + query + "%"}
@@ -644,15 +820,15 @@ func (b *SQLiteBackend) SearchByContent(ctx context.Context, query string, opts
 		args = append(args, opts.MinConfidence)
 	}
 
-	sqlQuery += " AND tier != 'archive'"
-	sqlQuery += " ORDER BY access_count DESC, updated_at DESC"
+	sqlQuery += " AND tier != 'archive' AND deleted_at IS NULL"
+	sqlQuery += " ORDER BY pinned DESC, access_count DESC, updated_at DESC"
 
 	if opts.Limit > 0 {
 		sqlQuery += " LIMIT ?"
 		args = append(args, opts.Limit)
 	}
 
-	rows, err := b.db.QueryContext(ctx, sqlQuery, args...)
+	rows, err := b.q.QueryContext(ctx, sqlQuery, args...)
 	if err != nil {
 		return nil, fmt.Errorf("search by content: %w", err)
 	}
@@ -731,6 +907,7 @@ func (b *SQLiteBackend) getImmediateConnectionsLocked(ctx context.Context, nodeI
 	baseSelect := `
 		SELECT DISTINCT n.id, n.type, n.subtype, n.content, n.embedding, n.created_at, n.updated_at,
 		       n.access_count, n.last_accessed, n.tier, n.confidence, n.provenance, n.metadata,
+		       n.idempotency_key, n.pinned,
 		       h.id, h.type, h.label, h.weight, h.created_at, h.metadata,
 		       m2.role
 		FROM nodes n
@@ -773,9 +950,9 @@ func (b *SQLiteBackend) getImmediateConnectionsLocked(ctx context.Context, nodeI
 		}
 	}
 
-	query += " AND n.tier != 'archive'"
+	query += " AND (n.tier != 'archive' OR n.pinned) AND n.deleted_at IS NULL"
 
-	rows, err := b.db.QueryContext(ctx, query, args...)
+	rows, err := b.q.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("get connections: %w", err)
 	}
@@ -800,8 +977,8 @@ func (b *SQLiteBackend) RecentNodes(ctx context.Context, limit int, tiers []Tier
 
 	query := `
 		SELECT id, type, subtype, content, embedding, created_at, updated_at,
-		       access_count, last_accessed, tier, confidence, provenance, metadata
-		FROM nodes WHERE tier != 'archive'
+		       access_count, last_accessed, tier, confidence, provenance, metadata, deleted_at, idempotency_key, pinned
+		FROM nodes WHERE tier != 'archive' AND deleted_at IS NULL
 	`
 	var args []any
 
@@ -819,7 +996,7 @@ func (b *SQLiteBackend) RecentNodes(ctx context.Context, limit int, tiers []Tier
 		args = append(args, limit)
 	}
 
-	rows, err := b.db.QueryContext(ctx, query, args...)
+	rows, err := b.q.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("recent nodes: %w", err)
 	}
@@ -847,17 +1024,17 @@ func (b *SQLiteBackend) Stats(ctx context.Context) (*Stats, error) {
 		NodesByType: make(map[string]int64),
 	}
 
-	err := b.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM nodes").Scan(&stats.NodeCount)
+	err := b.q.QueryRowContext(ctx, "SELECT COUNT(*) FROM nodes WHERE deleted_at IS NULL").Scan(&stats.NodeCount)
 	if err != nil {
 		return nil, fmt.Errorf("count nodes: %w", err)
 	}
 
-	err = b.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM hyperedges").Scan(&stats.HyperedgeCount)
+	err = b.q.QueryRowContext(ctx, "SELECT COUNT(*) FROM hyperedges").Scan(&stats.HyperedgeCount)
 	if err != nil {
 		return nil, fmt.Errorf("count hyperedges: %w", err)
 	}
 
-	rows, err := b.db.QueryContext(ctx, "SELECT tier, COUNT(*) FROM nodes GROUP BY tier")
+	rows, err := b.q.QueryContext(ctx, "SELECT tier, COUNT(*) FROM nodes WHERE deleted_at IS NULL GROUP BY tier")
 	if err != nil {
 		return nil, fmt.Errorf("count by tier: %w", err)
 	}
@@ -872,7 +1049,7 @@ func (b *SQLiteBackend) Stats(ctx context.Context) (*Stats, error) {
 		stats.NodesByTier[tier] = count
 	}
 
-	rows, err = b.db.QueryContext(ctx, "SELECT type, COUNT(*) FROM nodes GROUP BY type")
+	rows, err = b.q.QueryContext(ctx, "SELECT type, COUNT(*) FROM nodes WHERE deleted_at IS NULL GROUP BY type")
 	if err != nil {
 		return nil, fmt.Errorf("count by type: %w", err)
 	}
@@ -901,26 +1078,71 @@ func (b *SQLiteBackend) Close() error {
 	return nil
 }
 
+// BeginReadTx starts a read snapshot in a deferred, read-only transaction.
+// With WAL, writers proceed while it is open. An in-memory database shares
+// its cache between connections and would lock writers out instead, so it
+// returns ErrReadTxUnsupported.
+func (b *SQLiteBackend) BeginReadTx(ctx context.Context) (ReadTx, error) {
+	if b.path == "" {
+		return nil, fmt.Errorf("in-memory SQLite: %w", ErrReadTxUnsupported)
+	}
+
+	tx, err := b.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("begin read transaction: %w", err)
+	}
+	// A deferred transaction takes its snapshot at the first read
+	var n int
+	if err := tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM sqlite_master").Scan(&n); err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("start snapshot: %w", err)
+	}
+
+	return &sqliteReadTx{
+		SQLiteBackend: &SQLiteBackend{db: b.db, q: tx, path: b.path},
+		tx:            tx,
+	}, nil
+}
+
+// sqliteReadTx runs the backend's reads against a read transaction.
+type sqliteReadTx struct {
+	*SQLiteBackend
+	tx *sql.Tx
+}
+
+// Close ends the transaction.
+func (t *sqliteReadTx) Close() error {
+	if err := t.tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+		return err
+	}
+	return nil
+}
+
 // Helper scan functions
 
 func scanNodeRow(row *sql.Row) (*Node, error) {
 	var node Node
-	var subtype, provenance, metadata sql.NullString
-	var lastAccessed sql.NullTime
+	var subtype, provenance, metadata, idempotencyKey sql.NullString
+	var lastAccessed, deletedAt sql.NullTime
 
 	err := row.Scan(
 		&node.ID, &node.Type, &subtype, &node.Content, &node.Embedding,
 		&node.CreatedAt, &node.UpdatedAt, &node.AccessCount, &lastAccessed,
-		&node.Tier, &node.Confidence, &provenance, &metadata,
+		&node.Tier, &node.Confidence, &provenance, &metadata, &deletedAt,
+		&idempotencyKey, &node.Pinned,
 	)
 	if err != nil {
 		return nil, err
 	}
 
 	node.Subtype = subtype.String
+	node.IdempotencyKey = idempotencyKey.String
 	if lastAccessed.Valid {
 		node.LastAccessed = &lastAccessed.Time
 	}
+	if deletedAt.Valid {
+		node.DeletedAt = &deletedAt.Time
+	}
 	if provenance.Valid {
 		node.Provenance = []byte(provenance.String)
 	}
@@ -951,7 +1173,7 @@ func scanHyperedgeRow(row *sql.Row) (*Hyperedge, error) {
 func scanConnectedNodeRow(rows *sql.Rows, includeEdge bool) (*ConnectedNode, error) {
 	var node Node
 	var edge Hyperedge
-	var subtype, nodeProvenance, nodeMetadata sql.NullString
+	var subtype, nodeProvenance, nodeMetadata, idempotencyKey sql.NullString
 	var lastAccessed sql.NullTime
 	var edgeLabel, edgeMetadata sql.NullString
 	var role sql.NullString
@@ -960,6 +1182,7 @@ func scanConnectedNodeRow(rows *sql.Rows, includeEdge bool) (*ConnectedNode, err
 		&node.ID, &node.Type, &subtype, &node.Content, &node.Embedding,
 		&node.CreatedAt, &node.UpdatedAt, &node.AccessCount, &lastAccessed,
 		&node.Tier, &node.Confidence, &nodeProvenance, &nodeMetadata,
+		&idempotencyKey, &node.Pinned,
 		&edge.ID, &edge.Type, &edgeLabel, &edge.Weight, &edge.CreatedAt, &edgeMetadata,
 		&role,
 	)
@@ -968,6 +1191,7 @@ func scanConnectedNodeRow(rows *sql.Rows, includeEdge bool) (*ConnectedNode, err
 	}
 
 	node.Subtype = subtype.String
+	node.IdempotencyKey = idempotencyKey.String
 	if lastAccessed.Valid {
 		node.LastAccessed = &lastAccessed.Time
 	}
@@ -997,3 +1221,5 @@ func scanConnectedNodeRow(rows *sql.Rows, includeEdge bool) (*ConnectedNode, err
 
 // Verify SQLiteBackend implements Backend interface.
 var _ Backend = (*SQLiteBackend)(nil)
+
+var _ ReadTxBackend = (*SQLiteBackend)(nil)
@@ -1,32 +1,37 @@
-// Package hypergraph provides a SQLite-backed hypergraph memory store.
 package hypergraph
 
 import (
 	"context"
 	"database/sql"
-	_ "embed"
+	"errors"
 	"fmt"
 	"log/slog"
-	"os"
-	"path/filepath"
 	"sync"
 
 	"github.com/rand/recurse/internal/memory/embeddings"
-	_ "github.com/ncruces/go-sqlite3/driver"
-	_ "github.com/ncruces/go-sqlite3/embed"
 )
 
-//go:embed schema.sql
-var schemaSQL string
+// ErrNoSQLBackend is returned by Store operations that need direct SQL access
+// (transactions, the evolution log, embeddings) when the configured backend
+// does not expose a *sql.DB.
+var ErrNoSQLBackend = errors.New("operation requires a SQL-backed hypergraph backend")
 
 // Store manages the hypergraph memory database.
+// Graph operations are delegated to a Backend; auxiliary features that need
+// raw SQL use the backend's database when it implements SQLBackend.
 type Store struct {
+	backend        Backend
 	db             *sql.DB
 	mu             sync.RWMutex
 	path           string
 	embeddingIndex *embeddings.Index
 	hybridSearcher *HybridSearcher
+	edgeTypes      *EdgeTypeRegistry
 	logger         *slog.Logger
+	contentFilter  func(string) string
+
+	trackHistory bool
+	historyMu    sync.Mutex
 }
 
 // Options configures the hypergraph store.
@@ -38,15 +43,35 @@ type Options struct {
 	// CreateIfNotExists creates the database file if it doesn't exist.
 	CreateIfNotExists bool
 
+	// Backend overrides the default SQLite backend (e.g., Postgres).
+	// The store takes ownership and closes it on Close.
+	// Path and CreateIfNotExists are ignored when set.
+	Backend Backend
+
 	// EmbeddingProvider enables semantic search with the given provider.
 	// If nil, only keyword search is available.
+	// Requires a backend that implements SQLBackend.
 	EmbeddingProvider embeddings.Provider
 
 	// EmbeddingConfig configures the embedding index.
 	EmbeddingConfig EmbeddingConfig
 
+	// TrackHistory records every node change in an append-only history,
+	// enabling NodeAsOf and ListNodesAsOf at the cost of extra writes.
+	// Requires a backend that implements SQLBackend.
+	TrackHistory bool
+
+	// EdgeTypes validates hyperedge types and memberships.
+	// If nil, uses DefaultEdgeTypeRegistry.
+	EdgeTypes *EdgeTypeRegistry
+
 	// Logger for store operations.
 	Logger *slog.Logger
+
+	// ContentFilter rewrites node content before it is written, e.g. to
+	// redact secrets. The filtered content is also set on the node passed
+	// in. If nil, content is stored as given.
+	ContentFilter func(string) string
 }
 
 // EmbeddingConfig configures the embedding index.
@@ -58,34 +83,36 @@ type EmbeddingConfig struct {
 	// HybridAlpha is the weight for semantic vs keyword search.
 	// 0 = keyword only, 1 = semantic only, default = 0.7.
 	HybridAlpha float64
+
+	// Quantization stores new embeddings at reduced precision (float16 or
+	// int8) to save space; search dequantizes on the fly. Default: float32.
+	// Use EmbeddingIndex().Compact to re-encode existing embeddings.
+	Quantization embeddings.Quantization
+
+	// ANN enables an in-memory HNSW index for semantic search once the
+	// store holds ANN.MinNodes embeddings; smaller stores and disabled
+	// configs use exact brute-force search. M, EfConstruction and EfSearch
+	// trade memory and latency for recall.
+	ANN embeddings.ANNConfig
 }
 
-// NewStore creates a new hypergraph store with the given options.
-func NewStore(opts Options) (*Store, error) {
-	var dsn string
-
-	if opts.Path == "" {
-		dsn = "file::memory:?cache=shared"
-	} else {
-		// Ensure directory exists
-		if opts.CreateIfNotExists {
-			dir := filepath.Dir(opts.Path)
-			if err := os.MkdirAll(dir, 0755); err != nil {
-				return nil, fmt.Errorf("create database directory: %w", err)
-			}
-		}
-		dsn = "file:" + opts.Path + "?_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)&_pragma=foreign_keys(ON)"
+// NewBackend returns the backend described by opts: opts.Backend when set,
+// otherwise a SQLiteBackend at opts.Path.
+func NewBackend(opts Options) (Backend, error) {
+	if opts.Backend != nil {
+		return opts.Backend, nil
 	}
+	return NewSQLiteBackend(SQLiteBackendOptions{
+		Path:              opts.Path,
+		CreateIfNotExists: opts.CreateIfNotExists,
+	})
+}
 
-	db, err := sql.Open("sqlite3", dsn)
+// NewStore creates a new hypergraph store with the given options.
+func NewStore(opts Options) (*Store, error) {
+	backend, err := NewBackend(opts)
 	if err != nil {
-		return nil, fmt.Errorf("open database: %w", err)
-	}
-
-	// Test connection
-	if err := db.Ping(); err != nil {
-		db.Close()
-		return nil, fmt.Errorf("ping database: %w", err)
+		return nil, err
 	}
 
 	logger := opts.Logger
@@ -93,29 +120,44 @@ func NewStore(opts Options) (*Store, error) {
 		logger = slog.Default()
 	}
 
-	store := &Store{
-		db:     db,
-		path:   opts.Path,
-		logger: logger,
+	edgeTypes := opts.EdgeTypes
+	if edgeTypes == nil {
+		edgeTypes = DefaultEdgeTypeRegistry()
 	}
 
-	// Initialize schema
-	if err := store.initSchema(); err != nil {
-		db.Close()
-		return nil, fmt.Errorf("init schema: %w", err)
+	store := &Store{
+		backend:       backend,
+		path:          opts.Path,
+		edgeTypes:     edgeTypes,
+		logger:        logger,
+		contentFilter: opts.ContentFilter,
+		trackHistory:  opts.TrackHistory,
+	}
+	if sqlBackend, ok := backend.(SQLBackend); ok {
+		store.db = sqlBackend.DB()
+	}
+	if opts.TrackHistory && store.db == nil {
+		backend.Close()
+		return nil, fmt.Errorf("init node history: %w", ErrNoSQLBackend)
 	}
 
 	// Initialize embedding index if provider is configured
 	if opts.EmbeddingProvider != nil {
-		idx, err := embeddings.NewIndex(db, embeddings.IndexConfig{
-			Provider:  opts.EmbeddingProvider,
-			BatchSize: opts.EmbeddingConfig.BatchSize,
-			Workers:   opts.EmbeddingConfig.Workers,
-			QueueSize: opts.EmbeddingConfig.QueueSize,
-			Logger:    logger,
+		if store.db == nil {
+			backend.Close()
+			return nil, fmt.Errorf("init embedding index: %w", ErrNoSQLBackend)
+		}
+		idx, err := embeddings.NewIndex(store.db, embeddings.IndexConfig{
+			Provider:     opts.EmbeddingProvider,
+			BatchSize:    opts.EmbeddingConfig.BatchSize,
+			Workers:      opts.EmbeddingConfig.Workers,
+			QueueSize:    opts.EmbeddingConfig.QueueSize,
+			Logger:       logger,
+			Quantization: opts.EmbeddingConfig.Quantization,
+			ANN:          opts.EmbeddingConfig.ANN,
 		})
 		if err != nil {
-			db.Close()
+			backend.Close()
 			return nil, fmt.Errorf("init embedding index: %w", err)
 		}
 		store.embeddingIndex = idx
@@ -128,19 +170,6 @@ func NewStore(opts Options) (*Store, error) {
 	return store, nil
 }
 
-// initSchema creates the database schema if it doesn't exist.
-func (s *Store) initSchema() error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	_, err := s.db.Exec(schemaSQL)
-	if err != nil {
-		return fmt.Errorf("execute schema: %w", err)
-	}
-
-	return nil
-}
-
 // Close closes the database connection and embedding index.
 func (s *Store) Close() error {
 	s.mu.Lock()
@@ -153,18 +182,21 @@ func (s *Store) Close() error {
 		}
 	}
 
-	if s.db != nil {
-		return s.db.Close()
-	}
-	return nil
+	return s.backend.Close()
 }
 
 // DB returns the underlying database connection for advanced queries.
 // Use with caution - prefer using the Store methods.
+// Returns nil when the backend is not SQL-backed.
 func (s *Store) DB() *sql.DB {
 	return s.db
 }
 
+// Backend returns the backend that stores the graph.
+func (s *Store) Backend() Backend {
+	return s.backend
+}
+
 // Path returns the database file path.
 func (s *Store) Path() string {
 	return s.path
@@ -188,66 +220,29 @@ type Stats struct {
 
 // Stats returns current database statistics.
 func (s *Store) Stats(ctx context.Context) (*Stats, error) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-
-	stats := &Stats{
-		NodesByTier: make(map[string]int64),
-		NodesByType: make(map[string]int64),
-	}
-
-	// Total nodes
-	err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM nodes").Scan(&stats.NodeCount)
-	if err != nil {
-		return nil, fmt.Errorf("count nodes: %w", err)
-	}
-
-	// Total hyperedges
-	err = s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM hyperedges").Scan(&stats.HyperedgeCount)
-	if err != nil {
-		return nil, fmt.Errorf("count hyperedges: %w", err)
-	}
-
-	// Nodes by tier
-	rows, err := s.db.QueryContext(ctx, "SELECT tier, COUNT(*) FROM nodes GROUP BY tier")
-	if err != nil {
-		return nil, fmt.Errorf("count by tier: %w", err)
-	}
-	defer rows.Close()
-
-	for rows.Next() {
-		var tier string
-		var count int64
-		if err := rows.Scan(&tier, &count); err != nil {
-			return nil, fmt.Errorf("scan tier count: %w", err)
-		}
-		stats.NodesByTier[tier] = count
-	}
-
-	// Nodes by type
-	rows, err = s.db.QueryContext(ctx, "SELECT type, COUNT(*) FROM nodes GROUP BY type")
-	if err != nil {
-		return nil, fmt.Errorf("count by type: %w", err)
-	}
-	defer rows.Close()
-
-	for rows.Next() {
-		var nodeType string
-		var count int64
-		if err := rows.Scan(&nodeType, &count); err != nil {
-			return nil, fmt.Errorf("scan type count: %w", err)
-		}
-		stats.NodesByType[nodeType] = count
-	}
-
-	return stats, nil
+	return s.backend.Stats(ctx)
 }
 
 // BeginTx starts a new transaction.
+// Returns ErrNoSQLBackend if the backend is not SQL-backed.
 func (s *Store) BeginTx(ctx context.Context) (*sql.Tx, error) {
+	if s.db == nil {
+		return nil, ErrNoSQLBackend
+	}
 	return s.db.BeginTx(ctx, nil)
 }
 
+// BeginReadTx starts a read snapshot: reads through it see the graph as of
+// this call while writes continue outside it. Close it when done.
+// Returns ErrReadTxUnsupported if the backend cannot provide snapshots.
+func (s *Store) BeginReadTx(ctx context.Context) (ReadTx, error) {
+	b, ok := s.backend.(ReadTxBackend)
+	if !ok {
+		return nil, ErrReadTxUnsupported
+	}
+	return b.BeginReadTx(ctx)
+}
+
 // WithTx executes a function within a transaction.
 // If the function returns an error, the transaction is rolled back.
 // Otherwise, the transaction is committed.
@@ -21,6 +21,45 @@ func TestNewStore_InMemory(t *testing.T) {
 	assert.Empty(t, store.Path())
 }
 
+func TestNewStore_CustomBackend(t *testing.T) {
+	backend := NewInMemoryBackend()
+	store, err := NewStore(Options{Backend: backend})
+	require.NoError(t, err)
+	defer store.Close()
+
+	assert.Same(t, backend, store.Backend())
+	assert.Nil(t, store.DB())
+
+	ctx := context.Background()
+	a := NewNode(NodeTypeEntity, "a")
+	b := NewNode(NodeTypeEntity, "b")
+	require.NoError(t, store.CreateNode(ctx, a))
+	require.NoError(t, store.CreateNode(ctx, b))
+	_, err = store.CreateRelation(ctx, "uses", a.ID, b.ID)
+	require.NoError(t, err)
+
+	// Graph operations go through the custom backend
+	got, err := backend.GetNode(ctx, a.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "a", got.Content)
+
+	subgraph, err := store.GetSubgraph(ctx, []string{a.ID}, 1)
+	require.NoError(t, err)
+	assert.Len(t, subgraph.Nodes, 2)
+	assert.Len(t, subgraph.Hyperedges, 1)
+	assert.Len(t, subgraph.Membership, 2)
+
+	stats, err := store.Stats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), stats.NodeCount)
+
+	// SQL-only features report the missing capability
+	err = store.WithTx(ctx, func(tx *sql.Tx) error { return nil })
+	assert.ErrorIs(t, err, ErrNoSQLBackend)
+	err = store.RecordEvolution(ctx, &EvolutionEntry{Operation: EvolutionCreate})
+	assert.ErrorIs(t, err, ErrNoSQLBackend)
+}
+
 func TestNewStore_File(t *testing.T) {
 	tmpDir := t.TempDir()
 	dbPath := filepath.Join(tmpDir, "test.db")
@@ -249,3 +288,93 @@ func TestStore_CascadeDelete(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, 0, count, "membership should be cascade deleted")
 }
+
+func TestStore_BeginReadTx_Snapshot(t *testing.T) {
+	stores := map[string]func(t *testing.T) *Store{
+		"sqlite": func(t *testing.T) *Store {
+			store, err := NewStore(Options{Path: filepath.Join(t.TempDir(), "snapshot.db"), CreateIfNotExists: true})
+			require.NoError(t, err)
+			return store
+		},
+		"memory": func(t *testing.T) *Store {
+			store, err := NewStore(Options{Backend: NewInMemoryBackend()})
+			require.NoError(t, err)
+			return store
+		},
+	}
+
+	for name, open := range stores {
+		t.Run(name, func(t *testing.T) {
+			store := open(t)
+			defer store.Close()
+			ctx := context.Background()
+
+			kept := NewNode(NodeTypeFact, "original")
+			removed := NewNode(NodeTypeFact, "to be deleted")
+			require.NoError(t, store.CreateNode(ctx, kept))
+			require.NoError(t, store.CreateNode(ctx, removed))
+
+			tx, err := store.BeginReadTx(ctx)
+			require.NoError(t, err)
+
+			// Write concurrently while the snapshot is open
+			added := NewNode(NodeTypeFact, "added later")
+			done := make(chan error, 1)
+			go func() {
+				updated := *kept
+				updated.Content = "updated"
+				if err := store.UpdateNode(ctx, &updated); err != nil {
+					done <- err
+					return
+				}
+				if err := store.DeleteNode(ctx, removed.ID); err != nil {
+					done <- err
+					return
+				}
+				done <- store.CreateNode(ctx, added)
+			}()
+			require.NoError(t, <-done)
+
+			got, err := tx.GetNode(ctx, kept.ID)
+			require.NoError(t, err)
+			assert.Equal(t, "original", got.Content)
+			_, err = tx.GetNode(ctx, removed.ID)
+			assert.NoError(t, err, "deleted node is still in the snapshot")
+			var notFound *ErrNotFound
+			_, err = tx.GetNode(ctx, added.ID)
+			assert.ErrorAs(t, err, &notFound)
+
+			count, err := tx.CountNodes(ctx, NodeFilter{})
+			require.NoError(t, err)
+			assert.Equal(t, int64(2), count)
+			nodes, err := tx.ListNodes(ctx, NodeFilter{})
+			require.NoError(t, err)
+			assert.Len(t, nodes, 2)
+			stats, err := tx.Stats(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, int64(2), stats.NodeCount)
+
+			require.NoError(t, tx.Close())
+			require.NoError(t, tx.Close())
+
+			// The store itself sees the writes
+			got, err = store.GetNode(ctx, kept.ID)
+			require.NoError(t, err)
+			assert.Equal(t, "updated", got.Content)
+			stats, err = store.Stats(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, int64(2), stats.NodeCount)
+			_, err = store.GetNode(ctx, removed.ID)
+			assert.ErrorAs(t, err, &notFound)
+		})
+	}
+}
+
+func TestStore_BeginReadTx_InMemorySQLite(t *testing.T) {
+	store, err := NewStore(Options{})
+	require.NoError(t, err)
+	defer store.Close()
+
+	_, err = store.BeginReadTx(context.Background())
+	assert.ErrorIs(t, err, ErrReadTxUnsupported)
+}
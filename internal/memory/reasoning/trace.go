@@ -480,6 +480,9 @@ func (tm *TraceManager) insertDecisionNode(ctx context.Context, dn *DecisionNode
 	filesJSON, _ := dn.MarshalFiles()
 
 	db := tm.store.DB()
+	if db == nil {
+		return hypergraph.ErrNoSQLBackend
+	}
 	_, err := db.ExecContext(ctx, `
 		INSERT INTO decisions (node_id, decision_type, confidence, prompt, files, branch, commit_hash, parent_id, status)
 		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
@@ -493,6 +496,9 @@ func (tm *TraceManager) insertDecisionNode(ctx context.Context, dn *DecisionNode
 // updateDecisionStatus updates the status of a decision node.
 func (tm *TraceManager) updateDecisionStatus(ctx context.Context, nodeID string, status DecisionStatus) error {
 	db := tm.store.DB()
+	if db == nil {
+		return hypergraph.ErrNoSQLBackend
+	}
 	_, err := db.ExecContext(ctx, `
 		UPDATE decisions SET status = ? WHERE node_id = ?
 	`, status, nodeID)
@@ -505,6 +511,9 @@ func (tm *TraceManager) updateDecisionStatus(ctx context.Context, nodeID string,
 // getChildDecisions retrieves decision nodes that have the given parent.
 func (tm *TraceManager) getChildDecisions(ctx context.Context, parentID string) ([]*DecisionNode, error) {
 	db := tm.store.DB()
+	if db == nil {
+		return nil, hypergraph.ErrNoSQLBackend
+	}
 	rows, err := db.QueryContext(ctx, `
 		SELECT node_id, decision_type, confidence, prompt, files, branch, commit_hash, parent_id, status
 		FROM decisions WHERE parent_id = ?
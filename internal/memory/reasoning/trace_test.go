@@ -70,6 +70,20 @@ func TestTraceManager_CreateGoal(t *testing.T) {
 	assert.Equal(t, "Implement feature X", node.Content)
 }
 
+func TestTraceManager_NonSQLBackend(t *testing.T) {
+	store, err := hypergraph.NewStore(hypergraph.Options{Backend: hypergraph.NewInMemoryBackend()})
+	require.NoError(t, err)
+	t.Cleanup(func() { store.Close() })
+
+	tm := NewTraceManager(store, t.TempDir())
+	ctx := context.Background()
+
+	// Decision records live in SQL tables, so they report the missing
+	// capability instead of dereferencing a nil database.
+	_, err = tm.CreateGoal(ctx, "Implement feature X")
+	assert.ErrorIs(t, err, hypergraph.ErrNoSQLBackend)
+}
+
 func TestTraceManager_CreateDecision(t *testing.T) {
 	store := setupTestStore(t)
 	tm := NewTraceManager(store, t.TempDir())
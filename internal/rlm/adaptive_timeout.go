@@ -0,0 +1,101 @@
+package rlm
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// adaptiveDeadline is an execution deadline that progress can push back.
+// It starts at the base timeout; each iteration that makes progress moves
+// it to at least extension past the iteration's end, never beyond the
+// maximum. An execution that stops making progress is cancelled at its
+// current deadline, with cause context.DeadlineExceeded.
+type adaptiveDeadline struct {
+	mu        sync.Mutex
+	timer     *time.Timer
+	start     time.Time
+	deadline  time.Time
+	limit     time.Time
+	extension time.Duration
+
+	// Normalized outputs seen so far; a repeat is not progress
+	seen map[string]bool
+}
+
+// withAdaptiveDeadline returns a context cancelled timeout after now unless
+// the deadline is extended, and a function releasing it.
+func withAdaptiveDeadline(ctx context.Context, timeout, maxTimeout, extension time.Duration) (context.Context, *adaptiveDeadline, context.CancelFunc) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	now := time.Now()
+	d := &adaptiveDeadline{
+		start:     now,
+		deadline:  now.Add(timeout),
+		limit:     now.Add(maxTimeout),
+		extension: extension,
+		seen:      make(map[string]bool),
+	}
+	d.timer = time.AfterFunc(timeout, func() { cancel(context.DeadlineExceeded) })
+	return ctx, d, func() {
+		d.timer.Stop()
+		cancel(context.Canceled)
+	}
+}
+
+// observe records an iteration's outcome, extending the deadline if it
+// made progress: it reached FINAL(), or ran without error and printed
+// output not seen before.
+func (d *adaptiveDeadline) observe(hasFinal bool, output, replErr string) {
+	if d == nil {
+		return
+	}
+	progressed := hasFinal
+	if answer := normalizeAnswer(output); replErr == "" && answer != "" && !d.seen[answer] {
+		d.seen[answer] = true
+		progressed = true
+	}
+	if progressed {
+		d.extend(time.Now())
+	}
+}
+
+// extend moves the deadline to extension past now, capped at the limit.
+func (d *adaptiveDeadline) extend(now time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	next := now.Add(d.extension)
+	if next.After(d.limit) {
+		next = d.limit
+	}
+	if !next.After(d.deadline) {
+		return
+	}
+	// A timer that already fired has cancelled the execution
+	if d.timer.Stop() {
+		d.deadline = next
+		d.timer.Reset(next.Sub(now))
+	}
+}
+
+// extended returns how far the deadline was moved past the base timeout.
+func (d *adaptiveDeadline) extended(timeout time.Duration) time.Duration {
+	if d == nil {
+		return 0
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.deadline.Sub(d.start) - timeout
+}
+
+// cancellationCause returns the cause ctx was cancelled with in place of
+// an err that only reports the cancellation, so an execution stopped at an
+// adaptive deadline reports a deadline rather than a cancellation.
+func cancellationCause(ctx context.Context, err error) error {
+	if errors.Is(err, context.Canceled) {
+		if cause := context.Cause(ctx); cause != nil && !errors.Is(cause, context.Canceled) {
+			return cause
+		}
+	}
+	return err
+}
@@ -0,0 +1,92 @@
+package rlm
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rand/recurse/internal/rlm/repl"
+)
+
+// runTimed runs an RLM execution whose iterations each run step(i).
+func runTimed(t *testing.T, cfg RLMConfig, step func(i int) string) *RLMExecutionResult {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	replMgr, err := repl.NewManager(repl.Options{})
+	require.NoError(t, err)
+	require.NoError(t, replMgr.Start(ctx))
+	defer replMgr.Stop()
+
+	var responses []string
+	for i := 1; i <= 100; i++ {
+		responses = append(responses, "```python\n"+step(i)+"\n```")
+	}
+	w := &Wrapper{replMgr: replMgr, client: &wrapperMockLLMClient{responses: responses}}
+
+	prepared := &PreparedPrompt{Mode: ModeRLM, SystemPrompt: "system", FinalPrompt: "task"}
+	result, err := w.ExecuteRLMWithConfig(ctx, prepared, cfg)
+	require.NoError(t, err)
+	return result
+}
+
+func TestExecuteRLM_AdaptiveTimeout(t *testing.T) {
+	cfg := RLMConfig{
+		MaxIterations:    100,
+		MaxTokensPerCall: 1024,
+		Timeout:          400 * time.Millisecond,
+		MaxTimeout:       1200 * time.Millisecond,
+		TimeoutExtension: 400 * time.Millisecond,
+	}
+
+	t.Run("progress extends up to the max", func(t *testing.T) {
+		result := runTimed(t, cfg, func(i int) string {
+			return fmt.Sprintf("import time\ntime.sleep(0.05)\nprint('step %d')", i)
+		})
+		assert.Contains(t, result.Error, "deadline exceeded")
+		assert.Equal(t, 800*time.Millisecond, result.TimeoutExtended)
+		assert.GreaterOrEqual(t, result.Duration, 1100*time.Millisecond)
+		assert.Less(t, result.Duration, 3*time.Second)
+	})
+
+	t.Run("no new output is killed at the base timeout", func(t *testing.T) {
+		result := runTimed(t, cfg, func(int) string {
+			return "import time\ntime.sleep(0.05)"
+		})
+		assert.Contains(t, result.Error, "deadline exceeded")
+		assert.Zero(t, result.TimeoutExtended)
+		assert.GreaterOrEqual(t, result.Duration, 350*time.Millisecond)
+		assert.Less(t, result.Duration, time.Second, "killed before the max")
+	})
+
+	t.Run("hard limit without MaxTimeout", func(t *testing.T) {
+		hard := cfg
+		hard.MaxTimeout = 0
+		result := runTimed(t, hard, func(i int) string {
+			return fmt.Sprintf("import time\ntime.sleep(0.05)\nprint('step %d')", i)
+		})
+		assert.Zero(t, result.TimeoutExtended)
+		assert.Less(t, result.Duration, time.Second)
+	})
+}
+
+func TestAdaptiveDeadline_RepeatedOutputIsNotProgress(t *testing.T) {
+	_, deadline, cancel := withAdaptiveDeadline(context.Background(), time.Second, time.Hour, time.Minute)
+	defer cancel()
+
+	deadline.observe(false, "same", "")
+	first := deadline.extended(time.Second)
+	assert.Positive(t, first)
+
+	deadline.observe(false, "same", "")
+	deadline.observe(false, "new but failing", "NameError")
+	assert.Equal(t, first, deadline.extended(time.Second))
+
+	deadline.observe(true, "", "")
+	assert.Greater(t, deadline.extended(time.Second), first, "FINAL() is progress")
+}
@@ -0,0 +1,321 @@
+package rlm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultAnswerCacheSize bounds an AnswerCache when its config leaves
+// MaxEntries unset.
+const DefaultAnswerCacheSize = 1000
+
+// AnswerCacheConfig configures caching of Service.Execute results.
+type AnswerCacheConfig struct {
+	// Enabled turns the cache on. Disabled by default.
+	Enabled bool
+
+	// TTL is how long an answer is reused. Zero means answers never
+	// expire.
+	TTL time.Duration
+
+	// MaxEntries bounds the cache; the oldest entries are evicted first.
+	// Zero uses DefaultAnswerCacheSize.
+	MaxEntries int
+}
+
+// AnswerCache memoizes whole executions, so a task repeated with the same
+// context and configuration returns its earlier result without any LLM
+// call. An answer is keyed by its scope, a hash of the task and the
+// configuration it ran under, and reused only while the digest of the
+// context it referenced is unchanged; looking it up with another digest
+// invalidates it. It is safe for concurrent use, and a nil cache never
+// hits.
+type AnswerCache struct {
+	mu      sync.Mutex
+	entries map[string]*answerEntry
+	order   []string
+
+	config AnswerCacheConfig
+	now    func() time.Time
+
+	hits          int64
+	misses        int64
+	invalidations int64
+	tokensSaved   int64
+}
+
+// AnswerKey identifies a cached answer.
+type AnswerKey struct {
+	// Scope hashes the task and the configuration that shapes its answer.
+	Scope string
+
+	// Context digests the externalized context the task referenced, empty
+	// if it referenced none.
+	Context string
+}
+
+// AnswerCacheStats reports an AnswerCache's lifetime activity.
+type AnswerCacheStats struct {
+	Entries int   `json:"entries"`
+	Hits    int64 `json:"hits"`
+	Misses  int64 `json:"misses"`
+
+	// Invalidations counts answers dropped because the context they
+	// referenced changed.
+	Invalidations int64 `json:"invalidations"`
+
+	// TokensSaved is the tokens the reused answers originally cost.
+	TokensSaved int64 `json:"tokens_saved"`
+}
+
+// answerEntry is a cached execution result.
+type answerEntry struct {
+	context   string
+	result    *ExecutionResult
+	createdAt time.Time
+}
+
+// NewAnswerCache creates an empty answer cache.
+func NewAnswerCache(cfg AnswerCacheConfig) *AnswerCache {
+	if cfg.MaxEntries <= 0 {
+		cfg.MaxEntries = DefaultAnswerCacheSize
+	}
+	return &AnswerCache{
+		entries: make(map[string]*answerEntry),
+		config:  cfg,
+		now:     time.Now,
+	}
+}
+
+// Get returns a copy of the cached result for key, marked Cached. An
+// expired answer, or one cached with another context digest, is dropped.
+func (c *AnswerCache) Get(key AnswerKey) (*ExecutionResult, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key.Scope]
+	if ok && entry.context != key.Context {
+		c.invalidations++
+		c.remove(key.Scope)
+		ok = false
+	}
+	if ok && c.config.TTL > 0 && c.now().Sub(entry.createdAt) > c.config.TTL {
+		c.remove(key.Scope)
+		ok = false
+	}
+	if !ok {
+		c.misses++
+		return nil, false
+	}
+	c.hits++
+	c.tokensSaved += int64(entry.result.TotalTokens)
+
+	result := entry.result.Clone()
+	result.Cached = true
+	result.CachedAt = entry.createdAt
+	return result, true
+}
+
+// Put caches result under key, replacing any earlier answer in its scope.
+func (c *AnswerCache) Put(key AnswerKey, result *ExecutionResult) {
+	if c == nil || result == nil {
+		return
+	}
+	entry := &answerEntry{
+		context:   key.Context,
+		result:    result.Clone(),
+		createdAt: c.now(),
+	}
+	entry.result.Cached = false
+	entry.result.CachedAt = time.Time{}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.remove(key.Scope)
+	c.entries[key.Scope] = entry
+	c.order = append(c.order, key.Scope)
+	for len(c.order) > c.config.MaxEntries {
+		c.remove(c.order[0])
+	}
+}
+
+// Clear drops every cached answer.
+func (c *AnswerCache) Clear() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[string]*answerEntry)
+	c.order = nil
+}
+
+// Stats returns the cache's lifetime activity.
+func (c *AnswerCache) Stats() AnswerCacheStats {
+	if c == nil {
+		return AnswerCacheStats{}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return AnswerCacheStats{
+		Entries:       len(c.entries),
+		Hits:          c.hits,
+		Misses:        c.misses,
+		Invalidations: c.invalidations,
+		TokensSaved:   c.tokensSaved,
+	}
+}
+
+// remove drops scope. c.mu must be held.
+func (c *AnswerCache) remove(scope string) {
+	if _, ok := c.entries[scope]; !ok {
+		return
+	}
+	delete(c.entries, scope)
+	if i := slices.Index(c.order, scope); i >= 0 {
+		c.order = slices.Delete(c.order, i, i+1)
+	}
+}
+
+type answerContextKey struct{}
+
+type forceRefreshKey struct{}
+
+// WithAnswerContext declares the externalized context an execution's task
+// refers to, so a cached answer is only reused while that context is
+// unchanged.
+func WithAnswerContext(ctx context.Context, loaded *LoadedContext) context.Context {
+	return context.WithValue(ctx, answerContextKey{}, loaded)
+}
+
+// AnswerContextFrom returns the context declared by WithAnswerContext, nil
+// if there is none.
+func AnswerContextFrom(ctx context.Context) *LoadedContext {
+	loaded, _ := ctx.Value(answerContextKey{}).(*LoadedContext)
+	return loaded
+}
+
+// WithForceRefresh makes Service.Execute run the task even if a cached
+// answer exists. The fresh result replaces the cached one.
+func WithForceRefresh(ctx context.Context) context.Context {
+	return context.WithValue(ctx, forceRefreshKey{}, true)
+}
+
+// isForceRefresh reports whether WithForceRefresh applies to ctx.
+func isForceRefresh(ctx context.Context) bool {
+	force, _ := ctx.Value(forceRefreshKey{}).(bool)
+	return force
+}
+
+// ContextDigest hashes the identity of each externalized variable: the
+// current content of the file behind a file-backed variable, so an edit on
+// disk changes the digest even before the variable is refreshed, and the
+// content hashes of its sources otherwise. It returns "" for no context.
+func ContextDigest(loaded *LoadedContext) string {
+	if loaded == nil || len(loaded.Variables) == 0 {
+		return ""
+	}
+	names := make([]string, 0, len(loaded.Variables))
+	for name := range loaded.Variables {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	h := sha256.New()
+	for _, name := range names {
+		v := loaded.Variables[name]
+		fmt.Fprintf(h, "%s\x00%s\x00%s\x00", name, v.Type, variableIdentity(v))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// variableIdentity identifies a variable's content.
+func variableIdentity(v VariableInfo) string {
+	if v.Type == ContextTypeFile && len(v.Provenance) == 1 && v.Provenance[0].Source != "" {
+		data, err := os.ReadFile(v.Provenance[0].Source)
+		if err != nil {
+			return "unreadable"
+		}
+		sum := sha256.Sum256(data)
+		return hex.EncodeToString(sum[:])
+	}
+	if len(v.Provenance) == 0 {
+		// Nothing to hash; fall back to what changes with the content
+		return fmt.Sprintf("size %d refreshed %d", v.Size, v.RefreshedAt.UnixNano())
+	}
+	hashes := make([]string, len(v.Provenance))
+	for i, p := range v.Provenance {
+		hashes[i] = p.Hash
+	}
+	return strings.Join(hashes, ",")
+}
+
+// answerKey builds the cache key for running task under ctx. The context
+// digest covers the contexts externalized in the REPL as well as any
+// declared with WithAnswerContext, so an answer is not reused after the
+// context the task can see has changed.
+func (s *Service) answerKey(ctx context.Context, task string) AnswerKey {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00tier %s\x00%s",
+		strings.TrimSpace(task),
+		s.modelTier(),
+		s.answerConfig())
+	// Answers under one profile are not reused under another
+	if profile := ExecutionProfileFrom(ctx); profile != ModeStandard {
+		fmt.Fprintf(h, "\x00profile %s", profile)
+	}
+	// Nor is an answer reused after a different conversation
+	if history := ConversationHistoryFrom(ctx); len(history) > 0 {
+		fmt.Fprintf(h, "\x00conversation %s", formatConversationTurns(history))
+	}
+	return AnswerKey{
+		Scope:   hex.EncodeToString(h.Sum(nil)),
+		Context: ContextDigest(mergeLoaded(AnswerContextFrom(ctx), s.wrapper.LoadedContext())),
+	}
+}
+
+// answerConfig describes the service configuration that shapes an answer.
+func (s *Service) answerConfig() string {
+	ctrl := s.config.Controller
+	return fmt.Sprintf("depth %d\x00budget %d\x00calls %d\x00escalation %v/%s\x00compression %t/%d\x00fast path %d\x00prompt budget %d\x00rank %d/%v\x00output verification %t",
+		ctrl.MaxRecursionDepth,
+		ctrl.MaxTokenBudget,
+		ctrl.MaxLLMCalls,
+		ctrl.Escalation.MinConfidence, ctrl.Escalation.Tier,
+		s.config.CompressionEnabled, s.config.CompressionThreshold,
+		s.config.FastPathMaxTokens,
+		s.config.SystemPromptBudget,
+		s.config.ContextRanking.TopK, s.config.ContextRanking.MinRelevance,
+		s.config.Hallucination.OutputVerificationEnabled)
+}
+
+// mergeLoaded returns the variables of both contexts, b's where both
+// have one. Either may be nil.
+func mergeLoaded(a, b *LoadedContext) *LoadedContext {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+	merged := &LoadedContext{Variables: make(map[string]VariableInfo, len(a.Variables)+len(b.Variables))}
+	maps.Copy(merged.Variables, a.Variables)
+	maps.Copy(merged.Variables, b.Variables)
+	return merged
+}
+
+// AnswerCache returns the service's answer cache, nil unless enabled.
+func (s *Service) AnswerCache() *AnswerCache {
+	return s.answerCache
+}
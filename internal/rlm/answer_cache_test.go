@@ -0,0 +1,263 @@
+package rlm
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rand/recurse/internal/rlm/orchestrator"
+	"github.com/rand/recurse/internal/rlm/repl"
+)
+
+// countingClient counts the LLM calls it answers.
+type countingClient struct {
+	mockLLMClient
+	calls int
+}
+
+func (c *countingClient) Complete(ctx context.Context, prompt string, maxTokens int) (string, error) {
+	c.calls++
+	return c.mockLLMClient.Complete(ctx, prompt, maxTokens)
+}
+
+// withAnswerCache enables the answer cache.
+func withAnswerCache(cfg *ServiceConfig) {
+	cfg.AnswerCache = AnswerCacheConfig{Enabled: true}
+}
+
+func loadedWithHash(hash string) *LoadedContext {
+	return &LoadedContext{Variables: map[string]VariableInfo{
+		"spec": {
+			Name:       "spec",
+			Type:       ContextTypeCustom,
+			Provenance: []SourceProvenance{{Name: "spec", Type: ContextTypeCustom, Hash: hash}},
+		},
+	}}
+}
+
+func TestService_Execute_AnswerCache(t *testing.T) {
+	client := &countingClient{}
+	svc := newTestService(t, client, withAnswerCache)
+	ctx := WithAnswerContext(context.Background(), loadedWithHash("aaaa"))
+
+	first, err := svc.Execute(ctx, "Summarize the spec")
+	require.NoError(t, err)
+	assert.False(t, first.Cached)
+	calls := client.calls
+	require.Greater(t, calls, 0)
+
+	second, err := svc.Execute(ctx, "Summarize the spec")
+	require.NoError(t, err)
+	assert.True(t, second.Cached)
+	assert.False(t, second.CachedAt.IsZero())
+	assert.Equal(t, first.Response, second.Response)
+	assert.Equal(t, calls, client.calls, "a cache hit makes no LLM call")
+
+	stats := svc.Stats()
+	assert.Equal(t, 2, stats.TotalExecutions)
+	assert.Equal(t, 1, stats.AnswerCacheHits)
+	assert.Equal(t, first.TotalTokens, stats.TotalTokens, "a hit spends no tokens")
+
+	cacheStats := svc.AnswerCache().Stats()
+	assert.Equal(t, int64(1), cacheStats.Hits)
+	assert.Equal(t, int64(first.TotalTokens), cacheStats.TokensSaved)
+
+	t.Run("changed context invalidates", func(t *testing.T) {
+		changed := WithAnswerContext(context.Background(), loadedWithHash("bbbb"))
+		result, err := svc.Execute(changed, "Summarize the spec")
+		require.NoError(t, err)
+		assert.False(t, result.Cached)
+		assert.Greater(t, client.calls, calls)
+		assert.Equal(t, int64(1), svc.AnswerCache().Stats().Invalidations)
+
+		// The old context's answer is gone, not just shadowed
+		result, err = svc.Execute(ctx, "Summarize the spec")
+		require.NoError(t, err)
+		assert.False(t, result.Cached)
+	})
+
+	t.Run("force refresh", func(t *testing.T) {
+		before := client.calls
+		result, err := svc.Execute(WithForceRefresh(ctx), "Summarize the spec")
+		require.NoError(t, err)
+		assert.False(t, result.Cached)
+		assert.Greater(t, client.calls, before)
+
+		// The refreshed answer is cached
+		result, err = svc.Execute(ctx, "Summarize the spec")
+		require.NoError(t, err)
+		assert.True(t, result.Cached)
+	})
+
+	t.Run("other task misses", func(t *testing.T) {
+		result, err := svc.Execute(ctx, "Review the spec")
+		require.NoError(t, err)
+		assert.False(t, result.Cached)
+	})
+}
+
+func TestAnswerKey_LoadedContexts(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	svc := newTestService(t, &countingClient{}, withAnswerCache)
+	replMgr, err := repl.NewManager(repl.Options{})
+	require.NoError(t, err)
+	require.NoError(t, replMgr.Start(ctx))
+	defer replMgr.Stop()
+	svc.SetREPLManager(replMgr)
+
+	w := svc.Wrapper()
+	none := svc.answerKey(ctx, "Summarize the spec")
+	assert.Empty(t, none.Context)
+
+	load := func(content string) AnswerKey {
+		_, err := w.contextLoader.Load(ctx, []ContextSource{{Name: "spec", Type: ContextTypeCustom, Content: content}})
+		require.NoError(t, err)
+		return svc.answerKey(ctx, "Summarize the spec")
+	}
+	v1 := load("version one")
+	assert.NotEmpty(t, v1.Context, "the externalized context is digested without WithAnswerContext")
+	assert.Equal(t, none.Scope, v1.Scope)
+	v2 := load("version two")
+	assert.NotEqual(t, v1.Context, v2.Context)
+	assert.Equal(t, v1, load("version one"))
+
+	require.NoError(t, w.ClearContext(ctx))
+	assert.Equal(t, none, svc.answerKey(ctx, "Summarize the spec"))
+}
+
+func TestAnswerKey_DiffersByConfig(t *testing.T) {
+	key := func(configure func(*ServiceConfig)) AnswerKey {
+		cfg := DefaultServiceConfig()
+		configure(&cfg)
+		svc, err := NewService(&mockLLMClient{}, cfg)
+		require.NoError(t, err)
+		defer svc.Stop()
+		return svc.answerKey(context.Background(), "task")
+	}
+
+	base := key(func(*ServiceConfig) {})
+	assert.Equal(t, base, key(func(*ServiceConfig) {}))
+	assert.NotEqual(t, base, key(func(cfg *ServiceConfig) { cfg.Controller.MaxLLMCalls = 3 }))
+	assert.NotEqual(t, base, key(func(cfg *ServiceConfig) { cfg.Controller.Escalation.MinConfidence = 0.9 }))
+	assert.NotEqual(t, base, key(func(cfg *ServiceConfig) { cfg.CompressionEnabled = !cfg.CompressionEnabled }))
+	assert.NotEqual(t, base, key(func(cfg *ServiceConfig) { cfg.Hallucination.OutputVerificationEnabled = true }))
+}
+
+func TestService_Execute_AnswerCacheDisabled(t *testing.T) {
+	svc := newTestService(t, &mockLLMClient{}, nil)
+	ctx := context.Background()
+	assert.Nil(t, svc.AnswerCache())
+
+	_, err := svc.Execute(ctx, "Same task")
+	require.NoError(t, err)
+	result, err := svc.Execute(ctx, "Same task")
+	require.NoError(t, err)
+	assert.False(t, result.Cached)
+}
+
+func TestContextDigest_FileChangedOnDisk(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notes.txt")
+	require.NoError(t, os.WriteFile(path, []byte("v1"), 0o644))
+	loaded := &LoadedContext{Variables: map[string]VariableInfo{
+		"notes": {
+			Name:       "notes",
+			Type:       ContextTypeFile,
+			Provenance: []SourceProvenance{{Name: "notes", Type: ContextTypeFile, Source: path, Hash: "loaded"}},
+		},
+	}}
+
+	before := ContextDigest(loaded)
+	assert.NotEmpty(t, before)
+	assert.Equal(t, before, ContextDigest(loaded))
+
+	// The file changes without the variable being refreshed
+	require.NoError(t, os.WriteFile(path, []byte("v2"), 0o644))
+	assert.NotEqual(t, before, ContextDigest(loaded))
+
+	assert.Empty(t, ContextDigest(nil))
+	assert.Empty(t, ContextDigest(&LoadedContext{}))
+}
+
+func TestAnswerCache_TTLAndEviction(t *testing.T) {
+	now := time.Now()
+	cache := NewAnswerCache(AnswerCacheConfig{TTL: time.Minute, MaxEntries: 2})
+	cache.now = func() time.Time { return now }
+
+	a := AnswerKey{Scope: "a"}
+	cache.Put(a, &ExecutionResult{Response: "A", TotalTokens: 10})
+	got, ok := cache.Get(a)
+	require.True(t, ok)
+	assert.Equal(t, "A", got.Response)
+
+	// Callers cannot change the cached result
+	got.Response = "changed"
+	got, _ = cache.Get(a)
+	assert.Equal(t, "A", got.Response)
+
+	now = now.Add(2 * time.Minute)
+	_, ok = cache.Get(a)
+	assert.False(t, ok, "expired")
+
+	for _, scope := range []string{"x", "y", "z"} {
+		cache.Put(AnswerKey{Scope: scope}, &ExecutionResult{Response: scope})
+	}
+	_, ok = cache.Get(AnswerKey{Scope: "x"})
+	assert.False(t, ok, "evicted")
+	assert.Equal(t, 2, cache.Stats().Entries)
+
+	cache.Clear()
+	assert.Zero(t, cache.Stats().Entries)
+
+	var nilCache *AnswerCache
+	_, ok = nilCache.Get(a)
+	assert.False(t, ok)
+}
+
+func TestAnswerCache_ReturnsDeepCopies(t *testing.T) {
+	cache := NewAnswerCache(AnswerCacheConfig{})
+	key := AnswerKey{Scope: "a"}
+	put := &ExecutionResult{
+		Response:         "A",
+		Models:           []string{"model-a"},
+		TimedOutActions:  []string{"DECOMPOSE"},
+		ContextOverflows: []orchestrator.ContextOverflowRecovery{{Action: "DIRECT", Recovered: true}},
+		Escalation: &Escalation{Reason: "confident", Attempts: []*ExecutionResult{
+			{Response: "A", Models: []string{"model-a"}},
+		}},
+		Compression: &CompressionStats{OriginalTokens: 100, CompressedTokens: 40},
+	}
+	cache.Put(key, put)
+
+	change := func(r *ExecutionResult) {
+		r.Models[0] = "changed"
+		r.Models = append(r.Models, "extra")
+		r.TimedOutActions[0] = "changed"
+		r.ContextOverflows[0].Recovered = false
+		r.Escalation.Reason = "changed"
+		r.Escalation.Attempts[0].Models[0] = "changed"
+		r.Compression.CompressedTokens = 0
+	}
+
+	// Changing the result passed to Put, or one Get returned, leaves the
+	// cached answer as it was
+	change(put)
+	got, ok := cache.Get(key)
+	require.True(t, ok)
+	change(got)
+
+	got, ok = cache.Get(key)
+	require.True(t, ok)
+	assert.Equal(t, []string{"model-a"}, got.Models)
+	assert.Equal(t, []string{"DECOMPOSE"}, got.TimedOutActions)
+	assert.True(t, got.ContextOverflows[0].Recovered)
+	assert.Equal(t, "confident", got.Escalation.Reason)
+	assert.Equal(t, []string{"model-a"}, got.Escalation.Attempts[0].Models)
+	assert.Equal(t, 40, got.Compression.CompressedTokens)
+}
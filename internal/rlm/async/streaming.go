@@ -0,0 +1,229 @@
+package async
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// PlannedOperation is an operation emitted by a streaming planner.
+type PlannedOperation struct {
+	// Op is the operation to execute.
+	Op *Operation
+
+	// DependsOn lists IDs of operations that must complete first. They may
+	// refer to operations emitted earlier or later in the stream.
+	DependsOn []string
+}
+
+// StreamingPlanner identifies operations incrementally. Plan calls emit for
+// each operation as soon as it is identified and returns once planning is
+// complete. emit returns an error when execution has been aborted; the
+// planner should stop and return it.
+type StreamingPlanner interface {
+	Plan(ctx context.Context, emit func(PlannedOperation) error) error
+}
+
+// PlannerFunc adapts a function to the StreamingPlanner interface.
+type PlannerFunc func(ctx context.Context, emit func(PlannedOperation) error) error
+
+// Plan implements StreamingPlanner.
+func (f PlannerFunc) Plan(ctx context.Context, emit func(PlannedOperation) error) error {
+	return f(ctx, emit)
+}
+
+// ResultCallback receives each operation result as soon as it is available.
+// Calls are serialized; implementations should be fast and non-blocking.
+type ResultCallback func(result *OperationResult)
+
+// ExecuteStreaming runs operations while the planner is still producing them.
+// Operations whose dependencies are satisfied start immediately, up to
+// MaxParallel at a time; the rest wait until their dependencies complete,
+// including dependencies that completed before the operation was emitted.
+// Responses of successful dependencies are appended to a dependent's
+// State.PartialResults before it runs.
+//
+// Dependencies that never resolve (unknown IDs or cycles) fail the waiting
+// operations once planning finishes. A planner error stops planning but lets
+// already-emitted operations finish; it is returned alongside the result.
+func (e *Executor) ExecuteStreaming(ctx context.Context, planner StreamingPlanner, onResult ResultCallback) (*ExecutionResult, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	start := time.Now()
+	result := NewExecutionResult()
+
+	planned := make(chan PlannedOperation)
+	planDone := make(chan error, 1)
+	go func() {
+		planDone <- planner.Plan(ctx, func(p PlannedOperation) error {
+			if p.Op == nil {
+				return errors.New("planned operation is nil")
+			}
+			select {
+			case planned <- p:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+	}()
+
+	limit := e.effectiveParallelism(ctx, e.config.MaxParallel)
+	done := make(chan *OperationResult)
+	seen := make(map[string]bool)
+	var waiting []PlannedOperation
+	running := 0
+
+	var planErr, failErr error
+	planning := true
+	incoming := planned
+
+	report := func(r *OperationResult) {
+		result.AddResult(r)
+		if onResult != nil {
+			onResult(r)
+		}
+	}
+
+	// schedule starts or fails every waiting operation whose dependencies
+	// have resolved, repeating until no dependency failure cascades further.
+	schedule := func() {
+		for changed := true; changed && failErr == nil; {
+			changed = false
+			var still []PlannedOperation
+			for _, p := range waiting {
+				if running >= limit {
+					still = append(still, p)
+					continue
+				}
+				op, ready, depErr := e.resolveDependencies(p, result)
+				switch {
+				case depErr != nil:
+					report(&OperationResult{ID: p.Op.ID, Error: depErr})
+					changed = true
+				case ready:
+					running++
+					go func() { done <- e.runOperation(ctx, op) }()
+				default:
+					still = append(still, p)
+				}
+			}
+			waiting = still
+		}
+	}
+
+	for {
+		schedule()
+		if !planning && running == 0 {
+			break
+		}
+
+		select {
+		case p := <-incoming:
+			if seen[p.Op.ID] {
+				report(&OperationResult{ID: p.Op.ID, Error: fmt.Errorf("duplicate operation ID %q", p.Op.ID)})
+				continue
+			}
+			seen[p.Op.ID] = true
+			waiting = append(waiting, p)
+			sortPlannedByPriority(waiting)
+
+		case err := <-planDone:
+			planning = false
+			incoming = nil
+			if err != nil && failErr == nil {
+				planErr = err
+			}
+
+		case r := <-done:
+			running--
+			report(r)
+			if r.Error != nil && e.config.PartialFailure == FailFast && failErr == nil {
+				failErr = r.Error
+				incoming = nil
+				cancel()
+			}
+		}
+	}
+
+	for _, p := range waiting {
+		err := failErr
+		if err == nil {
+			err = fmt.Errorf("unresolved dependencies %v", p.DependsOn)
+		}
+		report(&OperationResult{ID: p.Op.ID, Error: err})
+	}
+
+	result.Duration = time.Since(start)
+	if failErr != nil {
+		return result, failErr
+	}
+	if planErr != nil {
+		return result, fmt.Errorf("streaming planner: %w", planErr)
+	}
+	return result, nil
+}
+
+// resolveDependencies reports whether p can run given the results so far.
+// When ready, it returns a copy of the operation with dependency responses
+// appended to its partial results. It returns an error if a dependency
+// failed and the failure strategy does not allow continuing.
+func (e *Executor) resolveDependencies(p PlannedOperation, result *ExecutionResult) (*Operation, bool, error) {
+	var responses []string
+	for _, dep := range p.DependsOn {
+		depResult, ok := result.Results[dep]
+		if !ok {
+			return nil, false, nil
+		}
+		if depResult.Error != nil {
+			if e.config.PartialFailure != ContinueOnError {
+				return nil, false, fmt.Errorf("dependency %s failed: %w", dep, depResult.Error)
+			}
+			continue
+		}
+		responses = append(responses, depResult.Response)
+	}
+
+	op := *p.Op
+	if len(responses) > 0 {
+		partial := make([]string, 0, len(op.State.PartialResults)+len(responses))
+		partial = append(partial, op.State.PartialResults...)
+		op.State.PartialResults = append(partial, responses...)
+	}
+	return &op, true, nil
+}
+
+// runOperation executes a single operation with the configured timeout.
+func (e *Executor) runOperation(ctx context.Context, op *Operation) *OperationResult {
+	if op.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, op.Timeout)
+		defer cancel()
+	} else if e.config.TimeoutPerOp > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, e.config.TimeoutPerOp)
+		defer cancel()
+	}
+
+	start := time.Now()
+	response, tokens, err := e.orchestrator.Orchestrate(ctx, op)
+	return &OperationResult{
+		ID:       op.ID,
+		Response: response,
+		Tokens:   tokens,
+		Duration: time.Since(start),
+		Error:    err,
+	}
+}
+
+// sortPlannedByPriority sorts planned operations by priority (higher first),
+// keeping emission order for equal priorities.
+func sortPlannedByPriority(ops []PlannedOperation) {
+	for i := 1; i < len(ops); i++ {
+		for j := i; j > 0 && ops[j].Op.Priority > ops[j-1].Op.Priority; j-- {
+			ops[j], ops[j-1] = ops[j-1], ops[j]
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package async
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// funcOrchestrator adapts a function to the Orchestrator interface.
+type funcOrchestrator func(ctx context.Context, op *Operation) (string, int, error)
+
+func (f funcOrchestrator) Orchestrate(ctx context.Context, op *Operation) (string, int, error) {
+	return f(ctx, op)
+}
+
+func plannedOp(id string, deps ...string) PlannedOperation {
+	return PlannedOperation{Op: &Operation{ID: id, Task: "task " + id}, DependsOn: deps}
+}
+
+func TestExecuteStreaming_StartsBeforePlanningFinishes(t *testing.T) {
+	started := make(chan string, 4)
+	orch := funcOrchestrator(func(ctx context.Context, op *Operation) (string, int, error) {
+		started <- op.ID
+		return "done " + op.ID, 10, nil
+	})
+	executor := NewExecutor(orch, ExecutorConfig{MaxParallel: 2, PartialFailure: ContinueOnError})
+
+	var planFinished time.Time
+	var firstStartedDuringPlanning bool
+	planner := PlannerFunc(func(ctx context.Context, emit func(PlannedOperation) error) error {
+		require.NoError(t, emit(plannedOp("first")))
+
+		// The first subtask must start while the planner is still running.
+		select {
+		case id := <-started:
+			firstStartedDuringPlanning = id == "first"
+		case <-time.After(2 * time.Second):
+		}
+
+		require.NoError(t, emit(plannedOp("second")))
+		planFinished = time.Now()
+		return nil
+	})
+
+	result, err := executor.ExecuteStreaming(context.Background(), planner, nil)
+	require.NoError(t, err)
+
+	assert.True(t, firstStartedDuringPlanning, "first subtask should start before planning completes")
+	assert.False(t, planFinished.IsZero())
+	assert.Len(t, result.Results, 2)
+	assert.Equal(t, 20, result.TotalTokens)
+}
+
+func TestExecuteStreaming_LateDependencyOnCompleted(t *testing.T) {
+	var mu sync.Mutex
+	seenPartials := make(map[string][]string)
+	orch := funcOrchestrator(func(ctx context.Context, op *Operation) (string, int, error) {
+		mu.Lock()
+		seenPartials[op.ID] = op.State.PartialResults
+		mu.Unlock()
+		return "answer " + op.ID, 5, nil
+	})
+	executor := NewExecutor(orch, ExecutorConfig{MaxParallel: 2, PartialFailure: ContinueOnError})
+
+	completed := make(chan string, 4)
+	planner := PlannerFunc(func(ctx context.Context, emit func(PlannedOperation) error) error {
+		require.NoError(t, emit(plannedOp("a")))
+
+		// Wait for "a" to finish before emitting its dependent.
+		select {
+		case id := <-completed:
+			assert.Equal(t, "a", id)
+		case <-time.After(2 * time.Second):
+			return errors.New("a never completed")
+		}
+		return emit(plannedOp("b", "a"))
+	})
+
+	var order []string
+	result, err := executor.ExecuteStreaming(context.Background(), planner, func(r *OperationResult) {
+		order = append(order, r.ID)
+		completed <- r.ID
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"a", "b"}, order)
+	assert.NoError(t, result.Results["b"].Error)
+	assert.Equal(t, []string{"answer a"}, seenPartials["b"], "dependent receives dependency output")
+}
+
+func TestExecuteStreaming_ForwardDependency(t *testing.T) {
+	var mu sync.Mutex
+	var order []string
+	orch := funcOrchestrator(func(ctx context.Context, op *Operation) (string, int, error) {
+		mu.Lock()
+		order = append(order, op.ID)
+		mu.Unlock()
+		return op.ID, 1, nil
+	})
+	executor := NewExecutor(orch, ExecutorConfig{MaxParallel: 4, PartialFailure: ContinueOnError})
+
+	planner := PlannerFunc(func(ctx context.Context, emit func(PlannedOperation) error) error {
+		// "summary" names a dependency that has not been planned yet.
+		if err := emit(plannedOp("summary", "detail")); err != nil {
+			return err
+		}
+		return emit(plannedOp("detail"))
+	})
+
+	result, err := executor.ExecuteStreaming(context.Background(), planner, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"detail", "summary"}, order)
+	assert.Equal(t, 2, result.SuccessCount())
+}
+
+func TestExecuteStreaming_UnresolvedDependency(t *testing.T) {
+	executor := NewExecutor(newMockOrchestrator(), ExecutorConfig{PartialFailure: ContinueOnError})
+
+	planner := PlannerFunc(func(ctx context.Context, emit func(PlannedOperation) error) error {
+		if err := emit(plannedOp("ok")); err != nil {
+			return err
+		}
+		return emit(plannedOp("orphan", "missing"))
+	})
+
+	result, err := executor.ExecuteStreaming(context.Background(), planner, nil)
+	require.NoError(t, err)
+	assert.NoError(t, result.Results["ok"].Error)
+	require.Error(t, result.Results["orphan"].Error)
+	assert.Contains(t, result.Results["orphan"].Error.Error(), "unresolved dependencies")
+}
+
+func TestExecuteStreaming_DependencyFailureCascades(t *testing.T) {
+	mock := newMockOrchestrator()
+	mock.errors["root"] = errors.New("boom")
+	executor := NewExecutor(mock, ExecutorConfig{PartialFailure: RetryFailed})
+
+	planner := PlannerFunc(func(ctx context.Context, emit func(PlannedOperation) error) error {
+		for _, p := range []PlannedOperation{plannedOp("root"), plannedOp("mid", "root"), plannedOp("leaf", "mid")} {
+			if err := emit(p); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	result, err := executor.ExecuteStreaming(context.Background(), planner, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 3, result.FailureCount())
+	assert.ErrorContains(t, result.Results["mid"].Error, "dependency root failed")
+	assert.ErrorContains(t, result.Results["leaf"].Error, "dependency mid failed")
+}
+
+func TestExecuteStreaming_FailFast(t *testing.T) {
+	mock := newMockOrchestrator()
+	mock.errors["bad"] = errors.New("boom")
+	executor := NewExecutor(mock, ExecutorConfig{PartialFailure: FailFast})
+
+	planner := PlannerFunc(func(ctx context.Context, emit func(PlannedOperation) error) error {
+		if err := emit(plannedOp("bad")); err != nil {
+			return err
+		}
+		<-ctx.Done()
+		return emit(plannedOp("never"))
+	})
+
+	result, err := executor.ExecuteStreaming(context.Background(), planner, nil)
+	require.Error(t, err)
+	assert.Equal(t, "boom", err.Error())
+	assert.NotContains(t, result.Results, "never")
+}
+
+func TestExecuteStreaming_PlannerError(t *testing.T) {
+	executor := NewExecutor(newMockOrchestrator(), ExecutorConfig{PartialFailure: ContinueOnError})
+
+	planner := PlannerFunc(func(ctx context.Context, emit func(PlannedOperation) error) error {
+		if err := emit(plannedOp("emitted")); err != nil {
+			return err
+		}
+		return errors.New("analysis failed")
+	})
+
+	result, err := executor.ExecuteStreaming(context.Background(), planner, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "analysis failed")
+	require.Contains(t, result.Results, "emitted")
+	assert.NoError(t, result.Results["emitted"].Error, "already-emitted operations still finish")
+}
+
+func TestExecuteStreaming_DuplicateID(t *testing.T) {
+	executor := NewExecutor(newMockOrchestrator(), ExecutorConfig{PartialFailure: ContinueOnError})
+
+	var results []*OperationResult
+	planner := PlannerFunc(func(ctx context.Context, emit func(PlannedOperation) error) error {
+		if err := emit(plannedOp("x")); err != nil {
+			return err
+		}
+		return emit(plannedOp("x"))
+	})
+
+	_, err := executor.ExecuteStreaming(context.Background(), planner, func(r *OperationResult) {
+		results = append(results, r)
+	})
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+
+	var dupErrors int
+	for _, r := range results {
+		if r.Error != nil {
+			dupErrors++
+			assert.Contains(t, r.Error.Error(), "duplicate")
+		}
+	}
+	assert.Equal(t, 1, dupErrors)
+}
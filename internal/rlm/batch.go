@@ -0,0 +1,109 @@
+package rlm
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Prices used for a batch ceiling when the controller has none, matching the
+// estimate used for budget tracking.
+const (
+	defaultInputCostPer1M  = 3.0
+	defaultOutputCostPer1M = 15.0
+)
+
+// BatchOptions configures Service.ExecuteBatch.
+type BatchOptions struct {
+	// Concurrency caps the tasks running at once (default: 4).
+	Concurrency int
+
+	// MaxCost is a cost ceiling in USD shared by the whole batch. Once it
+	// is reached, running tasks abort with a partial answer and pending
+	// tasks are not started. Zero disables it.
+	MaxCost float64
+}
+
+// ExecuteBatch runs independent tasks with bounded concurrency and returns
+// their results in input order.
+//
+// Tasks run on the service's shared controller, REPL and caches, so state
+// warmed by one task benefits the others. A failed task records its error in
+// its result and does not stop the batch. Tasks not started because ctx was
+// cancelled or the batch ceiling was reached get a result carrying that
+// error, which ExecuteBatch also returns.
+func (s *Service) ExecuteBatch(ctx context.Context, tasks []string, opts BatchOptions) ([]*ExecutionResult, error) {
+	s.mu.RLock()
+	running := s.running
+	s.mu.RUnlock()
+	if !running {
+		return nil, ErrServiceNotRunning
+	}
+
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = 4
+	}
+	concurrency = min(concurrency, len(tasks))
+
+	// Every task's guard is a child of the batch guard, so spend adds up
+	// across the batch.
+	var guard *CostGuard
+	if opts.MaxCost > 0 {
+		ceiling := s.config.Controller.CostCeiling
+		if ceiling.InputCostPer1M == 0 && ceiling.OutputCostPer1M == 0 {
+			ceiling.InputCostPer1M = defaultInputCostPer1M
+			ceiling.OutputCostPer1M = defaultOutputCostPer1M
+		}
+		ceiling.MaxCost = opts.MaxCost
+		guard = NewCostGuard(ceiling)
+		ctx = WithCostGuard(ctx, guard)
+	}
+
+	results := make([]*ExecutionResult, len(tasks))
+	indices := make(chan int)
+	var wg sync.WaitGroup
+	for range concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				results[i] = s.executeBatchTask(ctx, guard, tasks[i])
+			}
+		}()
+	}
+	for i := range tasks {
+		indices <- i
+	}
+	close(indices)
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return results, fmt.Errorf("batch cancelled: %w", err)
+	}
+	if err := guard.Err(); err != nil {
+		return results, err
+	}
+	return results, nil
+}
+
+// executeBatchTask runs one batch task, or records why it was not started.
+func (s *Service) executeBatchTask(ctx context.Context, guard *CostGuard, task string) *ExecutionResult {
+	skip := ctx.Err()
+	if skip == nil {
+		skip = guard.Err()
+	}
+	if skip != nil {
+		return &ExecutionResult{Task: task, StartTime: time.Now(), Error: skip.Error()}
+	}
+
+	result, err := s.Execute(ctx, task)
+	if result == nil {
+		result = &ExecutionResult{Task: task, StartTime: time.Now()}
+	}
+	if err != nil && result.Error == "" {
+		result.Error = err.Error()
+	}
+	return result
+}
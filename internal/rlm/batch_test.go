@@ -0,0 +1,188 @@
+package rlm
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var batchTaskID = regexp.MustCompile(`task-\d+`)
+
+// batchClient answers meta-controller prompts with DIRECT and other prompts
+// with the task ID they mention. Tasks containing "fail" fail. It is safe
+// for concurrent use and tracks peak concurrency.
+type batchClient struct {
+	delay time.Duration
+
+	// onCall, if set, runs before every call.
+	onCall func(prompt string)
+
+	mu       sync.Mutex
+	calls    int
+	inFlight int
+	peak     int
+}
+
+func (c *batchClient) Complete(ctx context.Context, prompt string, maxTokens int) (string, error) {
+	c.mu.Lock()
+	c.calls++
+	c.inFlight++
+	c.peak = max(c.peak, c.inFlight)
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		c.inFlight--
+		c.mu.Unlock()
+	}()
+
+	if c.onCall != nil {
+		c.onCall(prompt)
+	}
+	time.Sleep(c.delay)
+
+	id := batchTaskID.FindString(prompt)
+	if strings.Contains(prompt, id+"-fail") {
+		return "", fmt.Errorf("model error for %s", id)
+	}
+	if strings.Contains(prompt, "1. DIRECT") {
+		return `{"action": "DIRECT", "reasoning": "simple"}`, nil
+	}
+	return "answer for " + id, nil
+}
+
+func (c *batchClient) stats() (calls, peak int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.calls, c.peak
+}
+
+// batchConfig answers each task directly, so failures are not degraded
+// into partial answers.
+func batchConfig(cfg *ServiceConfig) {
+	withoutOrchestrator(cfg)
+	cfg.Controller.Recovery.EnableDegradation = false
+}
+
+func TestService_ExecuteBatch_OrderAndErrorIsolation(t *testing.T) {
+	client := &batchClient{delay: 5 * time.Millisecond}
+	svc := newTestService(t, client, batchConfig)
+
+	var tasks []string
+	for i := 0; i < 12; i++ {
+		task := fmt.Sprintf("task-%d", i)
+		if i%4 == 1 {
+			task += "-fail"
+		}
+		tasks = append(tasks, task)
+	}
+
+	results, err := svc.ExecuteBatch(context.Background(), tasks, BatchOptions{Concurrency: 3})
+	require.NoError(t, err)
+	require.Len(t, results, len(tasks))
+
+	for i, result := range results {
+		require.NotNil(t, result, "task %d", i)
+		assert.Equal(t, tasks[i], result.Task)
+		if strings.HasSuffix(tasks[i], "-fail") {
+			assert.NotEmpty(t, result.Error, "task %d", i)
+			assert.Empty(t, result.Response, "task %d", i)
+		} else {
+			assert.Empty(t, result.Error, "task %d", i)
+			assert.Equal(t, fmt.Sprintf("answer for task-%d", i), result.Response)
+		}
+	}
+
+	stats := svc.Stats()
+	assert.Equal(t, 12, stats.TotalExecutions)
+	assert.Equal(t, 3, stats.Errors)
+}
+
+func TestService_ExecuteBatch_BoundsConcurrency(t *testing.T) {
+	client := &batchClient{delay: 20 * time.Millisecond}
+	svc := newTestService(t, client, batchConfig)
+
+	tasks := make([]string, 10)
+	for i := range tasks {
+		tasks[i] = fmt.Sprintf("task-%d", i)
+	}
+
+	results, err := svc.ExecuteBatch(context.Background(), tasks, BatchOptions{Concurrency: 3})
+	require.NoError(t, err)
+	require.Len(t, results, 10)
+
+	// Each execution makes its calls one at a time, so in-flight calls
+	// equal running tasks.
+	_, peak := client.stats()
+	assert.LessOrEqual(t, peak, 3)
+	assert.Greater(t, peak, 1, "tasks should overlap")
+}
+
+func TestService_ExecuteBatch_CostCeiling(t *testing.T) {
+	client := &batchClient{}
+	svc := newTestService(t, client, batchConfig)
+
+	tasks := make([]string, 20)
+	for i := range tasks {
+		tasks[i] = fmt.Sprintf("task-%d", i)
+	}
+
+	// Room for a few calls at the default prices, not the whole batch.
+	results, err := svc.ExecuteBatch(context.Background(), tasks, BatchOptions{Concurrency: 1, MaxCost: 0.0003})
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrBudgetExceeded)
+	require.Len(t, results, 20)
+
+	assert.Empty(t, results[0].Error)
+	assert.Equal(t, "answer for task-0", results[0].Response)
+	for _, result := range results[10:] {
+		assert.Contains(t, result.Error, "cost ceiling")
+	}
+
+	calls, _ := client.stats()
+	assert.Less(t, calls, 2*len(tasks))
+}
+
+func TestService_ExecuteBatch_Cancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	client := &batchClient{}
+	client.onCall = func(prompt string) {
+		if strings.Contains(prompt, "task-2") {
+			cancel()
+		}
+	}
+	svc := newTestService(t, client, batchConfig)
+
+	tasks := make([]string, 8)
+	for i := range tasks {
+		tasks[i] = fmt.Sprintf("task-%d", i)
+	}
+
+	results, err := svc.ExecuteBatch(ctx, tasks, BatchOptions{Concurrency: 1})
+	assert.ErrorIs(t, err, context.Canceled)
+	require.Len(t, results, 8)
+
+	assert.Empty(t, results[0].Error)
+	assert.Empty(t, results[1].Error)
+	for i, result := range results[3:] {
+		assert.Equal(t, tasks[i+3], result.Task)
+		assert.Contains(t, result.Error, "context canceled")
+	}
+}
+
+func TestService_ExecuteBatch_NotRunning(t *testing.T) {
+	svc, err := NewService(&batchClient{}, DefaultServiceConfig())
+	require.NoError(t, err)
+	defer svc.Stop()
+
+	_, err = svc.ExecuteBatch(context.Background(), []string{"task-0"}, BatchOptions{})
+	assert.ErrorIs(t, err, ErrServiceNotRunning)
+}
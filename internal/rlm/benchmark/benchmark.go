@@ -7,6 +7,7 @@
 //   - Partition+map efficiency
 //   - Cost/accuracy tradeoffs
 //   - RLM vs direct prompting comparisons
+//   - Orchestration overhead on tasks answered directly anyway
 package benchmark
 
 import (
@@ -114,6 +115,9 @@ type Result struct {
 	// Error contains any error message if the task failed.
 	Error string
 
+	// FailureMode categorizes why the answer was wrong (empty if correct).
+	FailureMode FailureMode
+
 	// Metadata contains additional result information.
 	Metadata map[string]any
 }
@@ -240,6 +244,9 @@ type ReportSummary struct {
 
 	// ByContextLength breaks down metrics by context length buckets.
 	ByContextLength map[string]ContextLengthSummary
+
+	// FailureModes counts incorrect results by failure mode.
+	FailureModes map[FailureMode]int
 }
 
 // ComplexitySummary contains metrics for a specific complexity level.
@@ -264,8 +271,9 @@ type ContextLengthSummary struct {
 
 // Runner executes benchmark suites.
 type Runner struct {
-	executor Executor
-	scorer   Scorer
+	executor   Executor
+	scorer     Scorer
+	classifier *FailureClassifier
 }
 
 // Executor runs individual tasks and returns results.
@@ -274,6 +282,13 @@ type Executor interface {
 	Execute(ctx context.Context, task Task, config RunConfig) (Result, error)
 }
 
+// ResultObserver is implemented by executors that learn from graded
+// results. The runner calls ObserveResult after scoring each task that ran
+// without error.
+type ResultObserver interface {
+	ObserveResult(task Task, result Result)
+}
+
 // Scorer evaluates answers against expected values.
 type Scorer interface {
 	// Score evaluates an answer and returns a score (0-1).
@@ -283,8 +298,9 @@ type Scorer interface {
 // NewRunner creates a new benchmark runner.
 func NewRunner(executor Executor, scorer Scorer) *Runner {
 	return &Runner{
-		executor: executor,
-		scorer:   scorer,
+		executor:   executor,
+		scorer:     scorer,
+		classifier: NewFailureClassifier(),
 	}
 }
 
@@ -332,7 +348,12 @@ func (r *Runner) Run(ctx context.Context, suite Suite, config RunConfig) (*Repor
 			score, correct := r.scorer.Score(result.Answer, task.ExpectedAnswer, task.AnswerType)
 			result.Score = score
 			result.Correct = correct
+
+			if observer, ok := r.executor.(ResultObserver); ok {
+				observer.ObserveResult(task, result)
+			}
 		}
+		result.FailureMode = r.classifier.Classify(task, result)
 
 		report.Results = append(report.Results, result)
 	}
@@ -350,6 +371,7 @@ func (r *Runner) computeSummary(tasks []Task, results []Result) ReportSummary {
 		TaskCount:       len(results),
 		ByComplexity:    make(map[TaskComplexity]ComplexitySummary),
 		ByContextLength: make(map[string]ContextLengthSummary),
+		FailureModes:    make(map[FailureMode]int),
 	}
 
 	if len(results) == 0 {
@@ -382,6 +404,9 @@ func (r *Runner) computeSummary(tasks []Task, results []Result) ReportSummary {
 	}
 
 	for _, result := range results {
+		if result.FailureMode != "" {
+			summary.FailureModes[result.FailureMode]++
+		}
 		if result.Error != "" {
 			summary.ErrorCount++
 			continue
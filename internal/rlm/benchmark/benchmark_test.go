@@ -59,6 +59,59 @@ func TestDefaultScorer_NumericMatch(t *testing.T) {
 	}
 }
 
+func TestDefaultScorer_NumericNormalization(t *testing.T) {
+	scorer := NewDefaultScorer()
+
+	tests := []struct {
+		name        string
+		answer      string
+		expected    string
+		wantCorrect bool
+	}{
+		{"comma grouped", "2,305", "2305", true},
+		{"comma grouped in text", "There are 1,234,567 rows.", "1234567", true},
+		{"expected comma grouped", "2305", "2,305", true},
+		{"dollar decimals", "$100.00", "100", true},
+		{"euro", "€1,250.50", "1250.5", true},
+		{"pound", "£42", "42", true},
+		{"negative currency", "-$75", "-75", true},
+		{"percent", "15%", "15", true},
+		{"float within epsilon", "0.30000000000000004", "0.3", true},
+		{"zero within epsilon", "0.0000000001", "0", true},
+		{"hyphenated code", "CODE-2305", "2305", true},
+		{"wrong number", "2,350", "2305", false},
+		{"wrong currency amount", "$10.00", "100", false},
+		{"wrong sign", "-100", "100", false},
+		{"separate numbers not merged", "3, 4", "34", false},
+		{"nonzero against zero", "0.5", "0", false},
+		{"no number", "none", "100", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, correct := scorer.Score(tt.answer, tt.expected, AnswerNumeric)
+			assert.Equal(t, tt.wantCorrect, correct)
+		})
+	}
+}
+
+func TestDefaultScorer_NumericEpsilon(t *testing.T) {
+	scorer := &DefaultScorer{NumericEpsilon: 0.01}
+
+	_, correct := scorer.Score("3.14", "3.14159", AnswerNumeric)
+	assert.True(t, correct)
+	_, correct = scorer.Score("3.2", "3.14159", AnswerNumeric)
+	assert.False(t, correct)
+	_, correct = scorer.Score("0.005", "0", AnswerNumeric)
+	assert.True(t, correct)
+
+	// String modes still compare strings.
+	_, correct = scorer.Score("2,305", "2305", AnswerExact)
+	assert.False(t, correct)
+	_, correct = scorer.Score("The code is 2,305", "2305", AnswerContains)
+	assert.False(t, correct)
+}
+
 func TestDefaultScorer_ContainsMatch(t *testing.T) {
 	scorer := NewDefaultScorer()
 
@@ -270,6 +323,39 @@ func (e *slowExecutor) Execute(ctx context.Context, task Task, config RunConfig)
 	}
 }
 
+// observingExecutor answers every task correctly and records the graded
+// results it is shown.
+type observingExecutor struct {
+	slowExecutor
+	observed []Result
+}
+
+func (e *observingExecutor) ObserveResult(task Task, result Result) {
+	e.observed = append(e.observed, result)
+}
+
+func TestRunner_ObservesGradedResults(t *testing.T) {
+	executor := &observingExecutor{}
+	runner := NewRunner(executor, NewDefaultScorer())
+
+	suite := Suite{
+		Name: "Observer Test",
+		Tasks: []Task{
+			{ID: "task-1", ExpectedAnswer: "42", AnswerType: AnswerExact},
+			{ID: "task-2", ExpectedAnswer: "yes", AnswerType: AnswerExact},
+		},
+	}
+
+	report, err := runner.Run(context.Background(), suite, DefaultRunConfig())
+	require.NoError(t, err)
+	require.Len(t, report.Results, 2)
+	require.Len(t, executor.observed, 2)
+	for i, result := range executor.observed {
+		assert.Equal(t, suite.Tasks[i].ID, result.TaskID)
+		assert.True(t, result.Correct, "observed results are graded")
+	}
+}
+
 func TestContextRotAnalyzer(t *testing.T) {
 	analyzer := NewContextRotAnalyzer()
 
@@ -334,6 +420,11 @@ func TestExtractNumber(t *testing.T) {
 		{"The answer is 100.", 100},
 		{"-50", -50},
 		{"3.14159", 3.14159},
+		{"2,305 items", 2305},
+		{"€1,250.50", 1250.5},
+		{"CODE-2305", 2305},
+		{"-$75", -75},
+		{"3, 4", 3},
 		{"no number here", 0}, // NaN case handled in scorer
 	}
 
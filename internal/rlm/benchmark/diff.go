@@ -0,0 +1,212 @@
+package benchmark
+
+import "time"
+
+// =============================================================================
+// Report Diffing
+// =============================================================================
+
+// TaskChange classifies how a task's result changed between two runs.
+type TaskChange string
+
+const (
+	// TaskRegressed means the task was answered correctly before but not now.
+	TaskRegressed TaskChange = "regressed"
+
+	// TaskImproved means the task was answered wrongly before but correctly now.
+	TaskImproved TaskChange = "improved"
+
+	// TaskUnchanged means the task's correctness is the same in both runs.
+	TaskUnchanged TaskChange = "unchanged"
+
+	// TaskAdded means the task only appears in the new run.
+	TaskAdded TaskChange = "added"
+
+	// TaskRemoved means the task only appears in the old run.
+	TaskRemoved TaskChange = "removed"
+)
+
+// TaskDiff compares one task's results across two runs.
+type TaskDiff struct {
+	// TaskID identifies the task.
+	TaskID string
+
+	// Change classifies the difference.
+	Change TaskChange
+
+	// Old is the result in the old run (nil if added).
+	Old *Result
+
+	// New is the result in the new run (nil if removed).
+	New *Result
+
+	// ScoreDelta is New.Score - Old.Score (0 unless both runs have the task).
+	ScoreDelta float64
+
+	// TokenDelta is New.TotalTokens - Old.TotalTokens (0 unless both runs
+	// have the task).
+	TokenDelta int
+}
+
+// ReportDiff compares two benchmark runs task by task.
+type ReportDiff struct {
+	// Tasks holds one entry per task ID, in the order of the new run with
+	// removed tasks last.
+	Tasks []TaskDiff
+
+	// Matched is the number of tasks present in both runs.
+	Matched int
+
+	// The deltas below are new minus old, computed over matched tasks only
+	// so that added or removed tasks do not skew them.
+
+	// AccuracyDelta is the change in the fraction of correct answers.
+	AccuracyDelta float64
+
+	// MeanScoreDelta is the change in mean score.
+	MeanScoreDelta float64
+
+	// TotalTokensDelta is the change in tokens used.
+	TotalTokensDelta int
+
+	// MeanDurationDelta is the change in mean task duration.
+	MeanDurationDelta time.Duration
+
+	// ErrorCountDelta is the change in the number of tasks that errored.
+	ErrorCountDelta int
+}
+
+// DiffReports aligns the results of two runs by task ID and reports which
+// tasks regressed or improved, and how the aggregate metrics moved. Task IDs
+// are derived from task content, so runs of the same suite and seed align
+// exactly. If a report holds a task ID more than once, its first result is
+// used.
+func DiffReports(old, new *Report) *ReportDiff {
+	diff := &ReportDiff{}
+	oldByID := resultsByID(old)
+	newByID := resultsByID(new)
+
+	var oldCorrect, newCorrect, oldErrors, newErrors int
+	var oldScore, newScore float64
+	var oldDuration, newDuration time.Duration
+	seen := make(map[string]bool)
+
+	for _, id := range resultOrder(new) {
+		seen[id] = true
+		n := newByID[id]
+		o, ok := oldByID[id]
+		if !ok {
+			diff.Tasks = append(diff.Tasks, TaskDiff{TaskID: id, Change: TaskAdded, New: n})
+			continue
+		}
+
+		td := TaskDiff{
+			TaskID:     id,
+			Change:     TaskUnchanged,
+			Old:        o,
+			New:        n,
+			ScoreDelta: n.Score - o.Score,
+			TokenDelta: n.TotalTokens - o.TotalTokens,
+		}
+		switch {
+		case o.Correct && !n.Correct:
+			td.Change = TaskRegressed
+		case !o.Correct && n.Correct:
+			td.Change = TaskImproved
+		}
+		diff.Tasks = append(diff.Tasks, td)
+
+		diff.Matched++
+		diff.TotalTokensDelta += td.TokenDelta
+		oldScore += o.Score
+		newScore += n.Score
+		oldDuration += o.Duration
+		newDuration += n.Duration
+		if o.Correct {
+			oldCorrect++
+		}
+		if n.Correct {
+			newCorrect++
+		}
+		if o.Error != "" {
+			oldErrors++
+		}
+		if n.Error != "" {
+			newErrors++
+		}
+	}
+
+	for _, id := range resultOrder(old) {
+		if !seen[id] {
+			diff.Tasks = append(diff.Tasks, TaskDiff{TaskID: id, Change: TaskRemoved, Old: oldByID[id]})
+		}
+	}
+
+	if diff.Matched > 0 {
+		n := float64(diff.Matched)
+		diff.AccuracyDelta = float64(newCorrect-oldCorrect) / n
+		diff.MeanScoreDelta = (newScore - oldScore) / n
+		diff.MeanDurationDelta = (newDuration - oldDuration) / time.Duration(diff.Matched)
+	}
+	diff.ErrorCountDelta = newErrors - oldErrors
+
+	return diff
+}
+
+// Regressions returns the tasks that were correct in the old run but not
+// in the new one.
+func (d *ReportDiff) Regressions() []TaskDiff {
+	return d.withChange(TaskRegressed)
+}
+
+// Improvements returns the tasks that were wrong in the old run but
+// correct in the new one.
+func (d *ReportDiff) Improvements() []TaskDiff {
+	return d.withChange(TaskImproved)
+}
+
+// HasRegressions reports whether any task regressed.
+func (d *ReportDiff) HasRegressions() bool {
+	return len(d.Regressions()) > 0
+}
+
+func (d *ReportDiff) withChange(change TaskChange) []TaskDiff {
+	var out []TaskDiff
+	for _, td := range d.Tasks {
+		if td.Change == change {
+			out = append(out, td)
+		}
+	}
+	return out
+}
+
+// resultsByID indexes a report's results by task ID, keeping the first
+// result for each ID.
+func resultsByID(r *Report) map[string]*Result {
+	byID := make(map[string]*Result)
+	if r == nil {
+		return byID
+	}
+	for i := range r.Results {
+		if _, ok := byID[r.Results[i].TaskID]; !ok {
+			byID[r.Results[i].TaskID] = &r.Results[i]
+		}
+	}
+	return byID
+}
+
+// resultOrder returns a report's distinct task IDs in result order.
+func resultOrder(r *Report) []string {
+	if r == nil {
+		return nil
+	}
+	seen := make(map[string]bool)
+	var ids []string
+	for _, res := range r.Results {
+		if !seen[res.TaskID] {
+			seen[res.TaskID] = true
+			ids = append(ids, res.TaskID)
+		}
+	}
+	return ids
+}
@@ -0,0 +1,121 @@
+package benchmark
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func taskIDs(tasks []Task) []string {
+	ids := make([]string, len(tasks))
+	for i, task := range tasks {
+		ids[i] = task.ID
+	}
+	return ids
+}
+
+func TestGenerators_DeterministicIDs(t *testing.T) {
+	suites := map[string]func(seed int64) Suite{
+		"quick":       QuickSuite,
+		"context-rot": ContextRotSuite,
+		"aggregation": AggregationSuite,
+		"pairing":     PairingSuite,
+	}
+	for name, build := range suites {
+		t.Run(name, func(t *testing.T) {
+			first := taskIDs(build(42).Tasks)
+			second := taskIDs(build(42).Tasks)
+			require.NotEmpty(t, first)
+			assert.Equal(t, first, second)
+
+			unique := make(map[string]bool)
+			for _, id := range first {
+				unique[id] = true
+			}
+			assert.Len(t, unique, len(first), "task IDs should be unique within a suite")
+
+			other := taskIDs(build(43).Tasks)
+			assert.NotEqual(t, first, other)
+		})
+	}
+
+	t.Run("multi-generator", func(t *testing.T) {
+		a, err := NewMultiGenerator(7).Generate(4000, 8)
+		require.NoError(t, err)
+		b, err := NewMultiGenerator(7).Generate(4000, 8)
+		require.NoError(t, err)
+		assert.Equal(t, taskIDs(a), taskIDs(b))
+	})
+}
+
+func TestDiffReports_SurfacesRegressions(t *testing.T) {
+	suite := QuickSuite(42)
+	require.GreaterOrEqual(t, len(suite.Tasks), 4)
+
+	correct := make(map[string]string)
+	for _, task := range suite.Tasks {
+		correct[task.ID] = task.ExpectedAnswer
+	}
+	degraded := make(map[string]string)
+	for id, answer := range correct {
+		degraded[id] = answer
+	}
+	broken := []string{suite.Tasks[0].ID, suite.Tasks[3].ID}
+	for _, id := range broken {
+		degraded[id] = "no idea"
+	}
+
+	ctx := context.Background()
+	config := DefaultRunConfig()
+	baseline, err := NewRunner(NewMockExecutor(correct), NewDefaultScorer()).Run(ctx, suite, config)
+	require.NoError(t, err)
+	regressed, err := NewRunner(NewMockExecutor(degraded), NewDefaultScorer()).Run(ctx, QuickSuite(42), config)
+	require.NoError(t, err)
+
+	diff := DiffReports(baseline, regressed)
+	assert.Equal(t, len(suite.Tasks), diff.Matched)
+	assert.True(t, diff.HasRegressions())
+	assert.Empty(t, diff.Improvements())
+
+	var regressedIDs []string
+	for _, td := range diff.Regressions() {
+		regressedIDs = append(regressedIDs, td.TaskID)
+		assert.Less(t, td.ScoreDelta, 0.0)
+	}
+	assert.ElementsMatch(t, broken, regressedIDs)
+	assert.InDelta(t, -2.0/float64(len(suite.Tasks)), diff.AccuracyDelta, 1e-9)
+	assert.Less(t, diff.MeanScoreDelta, 0.0)
+
+	// Reversed, the same tasks show up as improvements.
+	reverse := DiffReports(regressed, baseline)
+	assert.False(t, reverse.HasRegressions())
+	assert.Len(t, reverse.Improvements(), len(broken))
+}
+
+func TestDiffReports_AddedAndRemoved(t *testing.T) {
+	old := &Report{Results: []Result{
+		{TaskID: "a", Correct: true, Score: 1, TotalTokens: 100},
+		{TaskID: "b", Correct: true, Score: 1, TotalTokens: 100},
+	}}
+	new := &Report{Results: []Result{
+		{TaskID: "a", Correct: true, Score: 1, TotalTokens: 150, Error: "retried"},
+		{TaskID: "c", Correct: false},
+	}}
+
+	diff := DiffReports(old, new)
+	require.Len(t, diff.Tasks, 3)
+	assert.Equal(t, TaskDiff{TaskID: "a", Change: TaskUnchanged, Old: &old.Results[0], New: &new.Results[0], TokenDelta: 50}, diff.Tasks[0])
+	assert.Equal(t, TaskAdded, diff.Tasks[1].Change)
+	assert.Equal(t, "c", diff.Tasks[1].TaskID)
+	assert.Equal(t, TaskRemoved, diff.Tasks[2].Change)
+	assert.Equal(t, "b", diff.Tasks[2].TaskID)
+
+	// Only the matched task counts toward the aggregates.
+	assert.Equal(t, 1, diff.Matched)
+	assert.Equal(t, 50, diff.TotalTokensDelta)
+	assert.Zero(t, diff.AccuracyDelta)
+	assert.Equal(t, 1, diff.ErrorCountDelta)
+	assert.False(t, diff.HasRegressions())
+}
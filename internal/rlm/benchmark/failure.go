@@ -0,0 +1,179 @@
+package benchmark
+
+import (
+	"math"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// FailureMode categorizes why a task was answered incorrectly.
+type FailureMode string
+
+const (
+	// FailureError - the executor failed and produced no answer to grade.
+	FailureError FailureMode = "error"
+
+	// FailureEmpty - the answer was blank.
+	FailureEmpty FailureMode = "empty"
+
+	// FailureNotFound - the model reported that the answer was not in the context.
+	FailureNotFound FailureMode = "not_found"
+
+	// FailureOffByOne - a numeric answer one away from the expected value,
+	// typically a miscount.
+	FailureOffByOne FailureMode = "off_by_one"
+
+	// FailureWrongNumber - a numeric answer further off than one.
+	FailureWrongNumber FailureMode = "wrong_number"
+
+	// FailureDistractor - a value shaped like the expected one that does
+	// appear in the context, so the model found the wrong needle.
+	FailureDistractor FailureMode = "distractor"
+
+	// FailureHallucinated - a value shaped like the expected one that
+	// appears nowhere in the context.
+	FailureHallucinated FailureMode = "hallucinated"
+
+	// FailurePartial - part of the expected answer, such as the number
+	// without its prefix or some of the expected set.
+	FailurePartial FailureMode = "partial"
+
+	// FailureOther - none of the above.
+	FailureOther FailureMode = "other"
+)
+
+// notFoundPattern matches answers in which the model says it came up empty.
+var notFoundPattern = regexp.MustCompile(`(?i)\b(could ?n[o']t|can ?n[o']t|unable to|did ?n[o']t|failed to) (find|locate|identify|determine)\b|` +
+	`\b(no|not any) [\w\s-]{0,30}(found|mentioned|present)\b|` +
+	`\bnot (found|mentioned|present|provided)\b|` +
+	`\b(does ?n[o']t|do not) (contain|mention|include)\b`)
+
+// FailureClassifier categorizes incorrect benchmark results into failure
+// modes using the expected answer, the model's answer and the task.
+//
+// Tasks may list wrong-but-plausible values in Metadata["distractors"]
+// ([]string); otherwise a value is a distractor if it occurs in the
+// task's context.
+type FailureClassifier struct{}
+
+// NewFailureClassifier creates a failure classifier.
+func NewFailureClassifier() *FailureClassifier {
+	return &FailureClassifier{}
+}
+
+// Classify returns the failure mode of result, or "" if it is correct.
+func (c *FailureClassifier) Classify(task Task, result Result) FailureMode {
+	if result.Error != "" {
+		return FailureError
+	}
+	if result.Correct {
+		return ""
+	}
+
+	answer := strings.TrimSpace(result.Answer)
+	expected := strings.TrimSpace(task.ExpectedAnswer)
+	if answer == "" {
+		return FailureEmpty
+	}
+
+	if task.AnswerType == AnswerNumeric {
+		got, want := extractNumber(answer), extractNumber(expected)
+		switch {
+		case math.IsNaN(got) && notFoundPattern.MatchString(answer):
+			return FailureNotFound
+		case math.IsNaN(got) || math.IsNaN(want):
+			return FailureOther
+		case math.Abs(got-want) == 1:
+			return FailureOffByOne
+		default:
+			return FailureWrongNumber
+		}
+	}
+
+	if shape := valueShape(expected); shape != nil {
+		for _, candidate := range shape.FindAllString(answer, -1) {
+			if strings.EqualFold(candidate, expected) {
+				continue
+			}
+			if isDistractor(task, candidate) {
+				return FailureDistractor
+			}
+			return FailureHallucinated
+		}
+	}
+
+	if notFoundPattern.MatchString(answer) {
+		return FailureNotFound
+	}
+	if result.Score > 0 || containsPart(answer, expected) {
+		return FailurePartial
+	}
+	return FailureOther
+}
+
+// valueShape returns a pattern matching values shaped like expected, such
+// as CODE-\d+ for CODE-2305, or nil if expected is not an identifier that
+// mixes letters and digits.
+func valueShape(expected string) *regexp.Regexp {
+	if strings.ContainsFunc(expected, unicode.IsSpace) ||
+		!strings.ContainsFunc(expected, unicode.IsLetter) ||
+		!strings.ContainsFunc(expected, unicode.IsDigit) {
+		return nil
+	}
+
+	var sb strings.Builder
+	sb.WriteString(`(?i)\b`)
+	inDigits := false
+	for _, r := range expected {
+		if unicode.IsDigit(r) {
+			if !inDigits {
+				sb.WriteString(`\d+`)
+			}
+			inDigits = true
+			continue
+		}
+		inDigits = false
+		sb.WriteString(regexp.QuoteMeta(string(r)))
+	}
+	sb.WriteString(`\b`)
+	return regexp.MustCompile(sb.String())
+}
+
+// isDistractor reports whether value is one of the task's listed
+// distractors or, without a list, occurs in its context.
+func isDistractor(task Task, value string) bool {
+	if distractors, ok := task.Metadata["distractors"].([]string); ok {
+		for _, d := range distractors {
+			if strings.EqualFold(d, value) {
+				return true
+			}
+		}
+		return false
+	}
+	return strings.Contains(strings.ToLower(task.Context), strings.ToLower(value))
+}
+
+// containsPart reports whether answer contains a distinctive piece of a
+// multi-part expected answer: one of its runs of letters and digits, only
+// the ones with digits if there are any, since a prefix like "CODE" says
+// little on its own.
+func containsPart(answer, expected string) bool {
+	answer = strings.ToLower(answer)
+	parts := strings.FieldsFunc(strings.ToLower(expected), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(parts) < 2 {
+		return false
+	}
+	numeric := strings.ContainsFunc(expected, unicode.IsDigit)
+	for _, part := range parts {
+		if numeric && !strings.ContainsFunc(part, unicode.IsDigit) {
+			continue
+		}
+		if len(part) >= 2 && strings.Contains(answer, part) {
+			return true
+		}
+	}
+	return false
+}
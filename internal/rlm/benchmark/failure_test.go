@@ -0,0 +1,126 @@
+package benchmark
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func needleTask() Task {
+	return Task{
+		ID:             "needle",
+		Context:        "Old badges used CODE-1111. The secret access code is CODE-2305. Visitors get CODE-7777.",
+		ExpectedAnswer: "CODE-2305",
+		AnswerType:     AnswerContains,
+	}
+}
+
+func countingTask() Task {
+	return Task{
+		ID:             "count",
+		ExpectedAnswer: "12",
+		AnswerType:     AnswerNumeric,
+	}
+}
+
+func TestFailureClassifier_Classify(t *testing.T) {
+	classifier := NewFailureClassifier()
+	scorer := NewDefaultScorer()
+
+	withDistractors := needleTask()
+	withDistractors.Context = ""
+	withDistractors.Metadata = map[string]any{"distractors": []string{"CODE-1111", "CODE-7777"}}
+
+	setTask := Task{ExpectedAnswer: "apple, banana, cherry", AnswerType: AnswerF1}
+
+	tests := []struct {
+		name   string
+		task   Task
+		answer string
+		want   FailureMode
+	}{
+		{"empty", needleTask(), "   ", FailureEmpty},
+		{"no code found", needleTask(), "I could not find any secret code in the document.", FailureNotFound},
+		{"not mentioned", needleTask(), "The access code is not mentioned in the text.", FailureNotFound},
+		{"hallucinated different code", needleTask(), "The code is CODE-1234", FailureHallucinated},
+		{"wrong needle from context", needleTask(), "The code is CODE-7777.", FailureDistractor},
+		{"wrong needle from metadata", withDistractors, "It is code-1111", FailureDistractor},
+		{"unlisted value is hallucinated", withDistractors, "CODE-2306", FailureHallucinated},
+		{"number without prefix", needleTask(), "The answer is 2305", FailurePartial},
+		{"unrelated", needleTask(), "The quarterly report looks positive.", FailureOther},
+		{"off by one over", countingTask(), "13", FailureOffByOne},
+		{"off by one under", countingTask(), "There are 11 occurrences.", FailureOffByOne},
+		{"wrong count", countingTask(), "20", FailureWrongNumber},
+		{"count not found", countingTask(), "I couldn't find any matching items.", FailureNotFound},
+		{"no number", countingTask(), "Many times.", FailureOther},
+		{"incomplete set", setTask, "apple, banana", FailurePartial},
+		{"disjoint set", setTask, "date, fig", FailureOther},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			score, correct := scorer.Score(tt.answer, tt.task.ExpectedAnswer, tt.task.AnswerType)
+			require.False(t, correct, "crafted answer must be wrong")
+
+			got := classifier.Classify(tt.task, Result{Answer: tt.answer, Score: score})
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestFailureClassifier_CorrectAndErrored(t *testing.T) {
+	classifier := NewFailureClassifier()
+
+	assert.Empty(t, classifier.Classify(needleTask(), Result{Answer: "CODE-2305", Correct: true, Score: 1}))
+	assert.Equal(t, FailureError, classifier.Classify(needleTask(), Result{Error: "timeout"}))
+}
+
+func TestValueShape(t *testing.T) {
+	shape := valueShape("CODE-2305")
+	require.NotNil(t, shape)
+	assert.Equal(t, []string{"CODE-1", "code-99999"}, shape.FindAllString("CODE-1 and code-99999 but not CODEX-12", -1))
+
+	assert.Nil(t, valueShape("42"), "plain numbers are handled numerically")
+	assert.Nil(t, valueShape("apple"))
+	assert.Nil(t, valueShape("room 101"))
+}
+
+func TestRunner_FailureModeBreakdown(t *testing.T) {
+	executor := NewMockExecutor(map[string]string{
+		"right":      "CODE-2305",
+		"invented":   "CODE-4242",
+		"distractor": "CODE-7777",
+		"miscount":   "13",
+	})
+	runner := NewRunner(executor, NewDefaultScorer())
+
+	right, invented, distractor := needleTask(), needleTask(), needleTask()
+	right.ID, invented.ID, distractor.ID = "right", "invented", "distractor"
+	miscount := countingTask()
+	miscount.ID = "miscount"
+
+	report, err := runner.Run(context.Background(), Suite{
+		Name:  "failures",
+		Tasks: []Task{right, invented, distractor, miscount},
+	}, DefaultRunConfig())
+	require.NoError(t, err)
+
+	modes := make(map[string]FailureMode)
+	for _, r := range report.Results {
+		modes[r.TaskID] = r.FailureMode
+	}
+	assert.Equal(t, map[string]FailureMode{
+		"right":      "",
+		"invented":   FailureHallucinated,
+		"distractor": FailureDistractor,
+		"miscount":   FailureOffByOne,
+	}, modes)
+
+	assert.Equal(t, map[FailureMode]int{
+		FailureHallucinated: 1,
+		FailureDistractor:   1,
+		FailureOffByOne:     1,
+	}, report.Summary.FailureModes)
+}
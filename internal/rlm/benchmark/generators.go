@@ -1,6 +1,7 @@
 package benchmark
 
 import (
+	"crypto/sha256"
 	"fmt"
 	"math/rand"
 	"strings"
@@ -10,6 +11,18 @@ import (
 // OOLONG-style Synthetic Task Generators
 // =============================================================================
 
+// contentTaskID derives a task's ID from its kind, context length and
+// content, so a generator given the same seed produces the same IDs in every
+// run, and tasks from different seeds never share one.
+func contentTaskID(kind string, task Task) string {
+	h := sha256.New()
+	for _, part := range []string{task.Query, task.ExpectedAnswer, task.Context} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return fmt.Sprintf("%s-%d-%x", kind, task.ContextTokens, h.Sum(nil)[:6])
+}
+
 // CountingGenerator creates tasks that require counting occurrences in context.
 // This tests linear complexity: difficulty scales with context length.
 type CountingGenerator struct {
@@ -28,7 +41,7 @@ func (g *CountingGenerator) Generate(contextTokens int, count int) ([]Task, erro
 	tasks := make([]Task, count)
 
 	for i := 0; i < count; i++ {
-		task, err := g.generateOne(contextTokens, i)
+		task, err := g.generateOne(contextTokens)
 		if err != nil {
 			return nil, err
 		}
@@ -38,7 +51,7 @@ func (g *CountingGenerator) Generate(contextTokens int, count int) ([]Task, erro
 	return tasks, nil
 }
 
-func (g *CountingGenerator) generateOne(contextTokens int, idx int) (Task, error) {
+func (g *CountingGenerator) generateOne(contextTokens int) (Task, error) {
 	// Generate a context with embedded items to count
 	categories := []string{"apple", "banana", "cherry", "date", "elderberry"}
 	targetCategory := categories[g.rng.Intn(len(categories))]
@@ -72,8 +85,7 @@ func (g *CountingGenerator) generateOne(contextTokens int, idx int) (Task, error
 		totalCount++
 	}
 
-	return Task{
-		ID:             fmt.Sprintf("counting-%d-%d", contextTokens, idx),
+	task := Task{
 		Name:           "Counting Task",
 		Description:    fmt.Sprintf("Count occurrences of '%s' in the text", targetCategory),
 		Complexity:     ComplexityLinear,
@@ -86,7 +98,9 @@ func (g *CountingGenerator) generateOne(contextTokens int, idx int) (Task, error
 			"target_category": targetCategory,
 			"total_items":     totalCount,
 		},
-	}, nil
+	}
+	task.ID = contentTaskID("counting", task)
+	return task, nil
 }
 
 // PairingGenerator creates tasks requiring pairwise relationship reasoning.
@@ -107,7 +121,7 @@ func (g *PairingGenerator) Generate(contextTokens int, count int) ([]Task, error
 	tasks := make([]Task, count)
 
 	for i := 0; i < count; i++ {
-		task, err := g.generateOne(contextTokens, i)
+		task, err := g.generateOne(contextTokens)
 		if err != nil {
 			return nil, err
 		}
@@ -117,7 +131,7 @@ func (g *PairingGenerator) Generate(contextTokens int, count int) ([]Task, error
 	return tasks, nil
 }
 
-func (g *PairingGenerator) generateOne(contextTokens int, idx int) (Task, error) {
+func (g *PairingGenerator) generateOne(contextTokens int) (Task, error) {
 	// Generate entities with relationships
 	firstNames := []string{"Alice", "Bob", "Carol", "David", "Eve", "Frank", "Grace", "Henry"}
 	relationships := []string{"works with", "reported to", "collaborated with", "mentored"}
@@ -184,8 +198,7 @@ func (g *PairingGenerator) generateOne(contextTokens int, idx int) (Task, error)
 		expectedAnswer = "yes"
 	}
 
-	return Task{
-		ID:             fmt.Sprintf("pairing-%d-%d", contextTokens, idx),
+	task := Task{
 		Name:           "Relationship Pairing",
 		Description:    "Determine if two entities have a relationship",
 		Complexity:     ComplexityQuadratic,
@@ -200,7 +213,9 @@ func (g *PairingGenerator) generateOne(contextTokens int, idx int) (Task, error)
 			"has_relationship": hasRelationship,
 			"num_entities":     numEntities,
 		},
-	}, nil
+	}
+	task.ID = contentTaskID("pairing", task)
+	return task, nil
 }
 
 // NeedleGenerator creates needle-in-haystack tasks.
@@ -221,7 +236,7 @@ func (g *NeedleGenerator) Generate(contextTokens int, count int) ([]Task, error)
 	tasks := make([]Task, count)
 
 	for i := 0; i < count; i++ {
-		task, err := g.generateOne(contextTokens, i)
+		task, err := g.generateOne(contextTokens)
 		if err != nil {
 			return nil, err
 		}
@@ -231,7 +246,7 @@ func (g *NeedleGenerator) Generate(contextTokens int, count int) ([]Task, error)
 	return tasks, nil
 }
 
-func (g *NeedleGenerator) generateOne(contextTokens int, idx int) (Task, error) {
+func (g *NeedleGenerator) generateOne(contextTokens int) (Task, error) {
 	// The "needle" - a specific fact to find
 	secretCode := fmt.Sprintf("CODE-%04d", g.rng.Intn(10000))
 	needle := fmt.Sprintf("The secret access code is %s.", secretCode)
@@ -275,8 +290,7 @@ func (g *NeedleGenerator) generateOne(contextTokens int, idx int) (Task, error)
 		sb.WriteString(needle)
 	}
 
-	return Task{
-		ID:             fmt.Sprintf("needle-%d-%d", contextTokens, idx),
+	task := Task{
 		Name:           "Needle in Haystack",
 		Description:    "Find a specific piece of information in a large context",
 		Complexity:     ComplexityConstant,
@@ -289,7 +303,9 @@ func (g *NeedleGenerator) generateOne(contextTokens int, idx int) (Task, error)
 			"needle_position": needlePosition,
 			"secret_code":     secretCode,
 		},
-	}, nil
+	}
+	task.ID = contentTaskID("needle", task)
+	return task, nil
 }
 
 // AggregationGenerator creates tasks requiring multi-step aggregation.
@@ -310,7 +326,7 @@ func (g *AggregationGenerator) Generate(contextTokens int, count int) ([]Task, e
 	tasks := make([]Task, count)
 
 	for i := 0; i < count; i++ {
-		task, err := g.generateOne(contextTokens, i)
+		task, err := g.generateOne(contextTokens)
 		if err != nil {
 			return nil, err
 		}
@@ -320,7 +336,7 @@ func (g *AggregationGenerator) Generate(contextTokens int, count int) ([]Task, e
 	return tasks, nil
 }
 
-func (g *AggregationGenerator) generateOne(contextTokens int, idx int) (Task, error) {
+func (g *AggregationGenerator) generateOne(contextTokens int) (Task, error) {
 	// Generate sales data across multiple regions
 	regions := []string{"North", "South", "East", "West", "Central"}
 	targetChars := contextTokens * 4
@@ -368,8 +384,7 @@ func (g *AggregationGenerator) generateOne(contextTokens int, idx int) (Task, er
 		}
 	}
 
-	return Task{
-		ID:             fmt.Sprintf("aggregation-%d-%d", contextTokens, idx),
+	task := Task{
 		Name:           "Sales Aggregation",
 		Description:    "Sum sales figures from multiple regions",
 		Complexity:     ComplexityLinear,
@@ -382,5 +397,7 @@ func (g *AggregationGenerator) generateOne(contextTokens int, idx int) (Task, er
 			"regional_sales": sales,
 			"total_sales":    totalSales,
 		},
-	}, nil
+	}
+	task.ID = contentTaskID("aggregation", task)
+	return task, nil
 }
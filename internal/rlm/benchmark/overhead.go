@@ -0,0 +1,336 @@
+package benchmark
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// OverheadStage names a step the RLM front end runs before a task that
+// needs no RLM is answered directly.
+type OverheadStage string
+
+const (
+	// StageClassification is task type classification.
+	StageClassification OverheadStage = "classification"
+
+	// StageMetaController is the meta-controller's orchestration decision.
+	StageMetaController OverheadStage = "meta_controller"
+
+	// StageContextPrep is mode selection and building the prompt sent.
+	StageContextPrep OverheadStage = "context_prep"
+
+	// StageOther is the rest of the overhead: glue between stages and the
+	// difference between answering the prepared prompt and the bare one.
+	// It may be negative when the prepared prompt is answered faster.
+	StageOther OverheadStage = "other"
+)
+
+// OverheadStages lists the stages in the order they run.
+var OverheadStages = []OverheadStage{StageClassification, StageMetaController, StageContextPrep, StageOther}
+
+// OverheadPipeline is the RLM front end an overhead benchmark measures.
+// Each stage returns the LLM tokens it spent; Prepare also returns the
+// prompt that is then answered in place of the bare direct prompt.
+type OverheadPipeline interface {
+	Classify(ctx context.Context, task Task) (tokens int, err error)
+	Decide(ctx context.Context, task Task) (tokens int, err error)
+	Prepare(ctx context.Context, task Task) (prompt string, tokens int, err error)
+}
+
+// OverheadConfig configures an overhead benchmark.
+type OverheadConfig struct {
+	// Repetitions is how many times each task is run both ways; the
+	// measurements are averaged to smooth out latency noise (default: 1).
+	Repetitions int
+
+	// MaxTokensPerCall limits tokens per answering call.
+	MaxTokensPerCall int
+
+	// Timeout is the maximum time per task and repetition, both ways.
+	Timeout time.Duration
+}
+
+// DefaultOverheadConfig returns sensible defaults for overhead benchmarks.
+func DefaultOverheadConfig() OverheadConfig {
+	return OverheadConfig{
+		Repetitions:      3,
+		MaxTokensPerCall: 1024,
+		Timeout:          time.Minute,
+	}
+}
+
+// StageOverhead is the latency and tokens one stage adds.
+type StageOverhead struct {
+	Duration time.Duration
+	Tokens   int
+}
+
+func (s StageOverhead) add(o StageOverhead) StageOverhead {
+	return StageOverhead{Duration: s.Duration + o.Duration, Tokens: s.Tokens + o.Tokens}
+}
+
+func (s StageOverhead) sub(o StageOverhead) StageOverhead {
+	return StageOverhead{Duration: s.Duration - o.Duration, Tokens: s.Tokens - o.Tokens}
+}
+
+func (s StageOverhead) div(n int) StageOverhead {
+	return StageOverhead{Duration: s.Duration / time.Duration(n), Tokens: s.Tokens / n}
+}
+
+// TaskOverhead compares one task answered by a bare direct call with the
+// same task through the RLM front end, averaged over repetitions.
+type TaskOverhead struct {
+	// TaskID identifies which task was run.
+	TaskID string
+
+	// Direct and RLM are the end-to-end latency and tokens of each way.
+	Direct StageOverhead
+	RLM    StageOverhead
+
+	// Overhead is RLM minus Direct.
+	Overhead StageOverhead
+
+	// Stages attributes Overhead to each stage; the stages sum to it.
+	Stages map[OverheadStage]StageOverhead
+
+	// Error contains any error message if the task failed.
+	Error string
+}
+
+// OverheadReport quantifies the cost of the RLM front end on tasks that
+// are answered directly anyway.
+type OverheadReport struct {
+	// SuiteName identifies which suite was run.
+	SuiteName string
+
+	// Config is the configuration used.
+	Config OverheadConfig
+
+	// Tasks contains the per-task measurements.
+	Tasks []TaskOverhead
+
+	// Summary contains the means across tasks that ran without error.
+	Summary OverheadSummary
+}
+
+// OverheadSummary contains mean overhead across tasks.
+type OverheadSummary struct {
+	// TaskCount is the number of tasks run.
+	TaskCount int
+
+	// ErrorCount is the number of failed tasks.
+	ErrorCount int
+
+	// MeanDirect and MeanRLM are the mean latency and tokens of each way.
+	MeanDirect StageOverhead
+	MeanRLM    StageOverhead
+
+	// MeanOverhead is MeanRLM minus MeanDirect.
+	MeanOverhead StageOverhead
+
+	// ByStage attributes MeanOverhead to each stage; the stages sum to it.
+	ByStage map[OverheadStage]StageOverhead
+
+	// LatencyOverhead and TokenOverhead are MeanOverhead as a fraction of
+	// MeanDirect.
+	LatencyOverhead float64
+	TokenOverhead   float64
+}
+
+// OverheadRunner measures the latency and tokens the RLM front end adds to
+// tasks it ends up answering directly: the "RLM tax" on easy requests.
+type OverheadRunner struct {
+	client   LLMClient
+	pipeline OverheadPipeline
+}
+
+// NewOverheadRunner creates a runner answering tasks with client, bare and
+// after pipeline has run.
+func NewOverheadRunner(client LLMClient, pipeline OverheadPipeline) *OverheadRunner {
+	return &OverheadRunner{
+		client:   client,
+		pipeline: pipeline,
+	}
+}
+
+// Run measures every task of suite both ways and returns a report.
+func (r *OverheadRunner) Run(ctx context.Context, suite Suite, config OverheadConfig) (*OverheadReport, error) {
+	if config.Repetitions <= 0 {
+		config.Repetitions = 1
+	}
+	report := &OverheadReport{
+		SuiteName: suite.Name,
+		Config:    config,
+		Tasks:     make([]TaskOverhead, 0, len(suite.Tasks)),
+	}
+
+	for _, task := range suite.Tasks {
+		if err := ctx.Err(); err != nil {
+			report.Summary = summarizeOverhead(report.Tasks)
+			return report, err
+		}
+		report.Tasks = append(report.Tasks, r.measure(ctx, task, config))
+	}
+
+	report.Summary = summarizeOverhead(report.Tasks)
+	return report, nil
+}
+
+// measure runs task both ways config.Repetitions times.
+func (r *OverheadRunner) measure(ctx context.Context, task Task, config OverheadConfig) TaskOverhead {
+	result := TaskOverhead{
+		TaskID: task.ID,
+		Stages: make(map[OverheadStage]StageOverhead),
+	}
+
+	for i := 0; i < config.Repetitions; i++ {
+		direct, err := r.runDirect(ctx, task, config)
+		if err != nil {
+			result.Error = fmt.Sprintf("direct: %v", err)
+			return result
+		}
+		rlm, stages, err := r.runRLM(ctx, task, config)
+		if err != nil {
+			result.Error = fmt.Sprintf("rlm: %v", err)
+			return result
+		}
+		result.Direct = result.Direct.add(direct)
+		result.RLM = result.RLM.add(rlm)
+		for stage, s := range stages {
+			result.Stages[stage] = result.Stages[stage].add(s)
+		}
+	}
+
+	result.Direct = result.Direct.div(config.Repetitions)
+	result.RLM = result.RLM.div(config.Repetitions)
+	for stage, s := range result.Stages {
+		result.Stages[stage] = s.div(config.Repetitions)
+	}
+	result.Overhead = result.RLM.sub(result.Direct)
+	// StageOther held the answering call; offsetting it by the direct run,
+	// and by any rounding from averaging, makes the stages sum to Overhead
+	result.Stages[StageOther] = result.Stages[StageOther].add(result.Overhead.sub(sumStages(result.Stages)))
+	return result
+}
+
+// runDirect answers task with a bare direct call.
+func (r *OverheadRunner) runDirect(ctx context.Context, task Task, config OverheadConfig) (StageOverhead, error) {
+	if config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, config.Timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	prompt := buildDirectPrompt(task)
+	response, err := r.client.Complete(ctx, prompt, config.MaxTokensPerCall)
+	if err != nil {
+		return StageOverhead{}, err
+	}
+	return StageOverhead{
+		Duration: time.Since(start),
+		Tokens:   estimateTokens(prompt) + estimateTokens(response),
+	}, nil
+}
+
+// runRLM runs the pipeline's stages and answers the prepared prompt,
+// returning the total and what each stage added. direct is subtracted
+// by the caller, so StageOther here holds the answering call; it becomes
+// the remainder once averaged.
+func (r *OverheadRunner) runRLM(ctx context.Context, task Task, config OverheadConfig) (StageOverhead, map[OverheadStage]StageOverhead, error) {
+	if config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, config.Timeout)
+		defer cancel()
+	}
+
+	stages := make(map[OverheadStage]StageOverhead, len(OverheadStages))
+	start := time.Now()
+
+	stageStart := time.Now()
+	tokens, err := r.pipeline.Classify(ctx, task)
+	if err != nil {
+		return StageOverhead{}, nil, fmt.Errorf("classification: %w", err)
+	}
+	stages[StageClassification] = StageOverhead{Duration: time.Since(stageStart), Tokens: tokens}
+
+	stageStart = time.Now()
+	tokens, err = r.pipeline.Decide(ctx, task)
+	if err != nil {
+		return StageOverhead{}, nil, fmt.Errorf("meta-controller: %w", err)
+	}
+	stages[StageMetaController] = StageOverhead{Duration: time.Since(stageStart), Tokens: tokens}
+
+	stageStart = time.Now()
+	prompt, tokens, err := r.pipeline.Prepare(ctx, task)
+	if err != nil {
+		return StageOverhead{}, nil, fmt.Errorf("context prep: %w", err)
+	}
+	stages[StageContextPrep] = StageOverhead{Duration: time.Since(stageStart), Tokens: tokens}
+
+	response, err := r.client.Complete(ctx, prompt, config.MaxTokensPerCall)
+	if err != nil {
+		return StageOverhead{}, nil, err
+	}
+	total := StageOverhead{Duration: time.Since(start)}
+	total.Tokens = estimateTokens(prompt) + estimateTokens(response)
+	for _, s := range stages {
+		total.Tokens += s.Tokens
+	}
+
+	// Left for the caller to offset by the direct measurement
+	stages[StageOther] = total.sub(sumStages(stages))
+	return total, stages, nil
+}
+
+// sumStages adds up the overhead of every stage.
+func sumStages(stages map[OverheadStage]StageOverhead) StageOverhead {
+	var sum StageOverhead
+	for _, s := range stages {
+		sum = sum.add(s)
+	}
+	return sum
+}
+
+// summarizeOverhead computes the mean overhead of the tasks without error.
+func summarizeOverhead(tasks []TaskOverhead) OverheadSummary {
+	summary := OverheadSummary{
+		TaskCount: len(tasks),
+		ByStage:   make(map[OverheadStage]StageOverhead),
+	}
+
+	valid := 0
+	for _, task := range tasks {
+		if task.Error != "" {
+			summary.ErrorCount++
+			continue
+		}
+		valid++
+		summary.MeanDirect = summary.MeanDirect.add(task.Direct)
+		summary.MeanRLM = summary.MeanRLM.add(task.RLM)
+		for stage, s := range task.Stages {
+			summary.ByStage[stage] = summary.ByStage[stage].add(s)
+		}
+	}
+	if valid == 0 {
+		return summary
+	}
+
+	summary.MeanDirect = summary.MeanDirect.div(valid)
+	summary.MeanRLM = summary.MeanRLM.div(valid)
+	for stage, s := range summary.ByStage {
+		summary.ByStage[stage] = s.div(valid)
+	}
+	summary.MeanOverhead = summary.MeanRLM.sub(summary.MeanDirect)
+	// Absorb rounding from averaging, so the stages sum to MeanOverhead
+	summary.ByStage[StageOther] = summary.ByStage[StageOther].add(summary.MeanOverhead.sub(sumStages(summary.ByStage)))
+
+	if summary.MeanDirect.Duration > 0 {
+		summary.LatencyOverhead = float64(summary.MeanOverhead.Duration) / float64(summary.MeanDirect.Duration)
+	}
+	if summary.MeanDirect.Tokens > 0 {
+		summary.TokenOverhead = float64(summary.MeanOverhead.Tokens) / float64(summary.MeanDirect.Tokens)
+	}
+	return summary
+}
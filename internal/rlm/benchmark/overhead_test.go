@@ -0,0 +1,167 @@
+package benchmark
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rand/recurse/internal/rlm/meta"
+)
+
+// overheadMockClient answers every prompt after a fixed delay.
+type overheadMockClient struct {
+	delay    time.Duration
+	response string
+
+	mu      sync.Mutex
+	prompts []string
+}
+
+func (c *overheadMockClient) Complete(ctx context.Context, prompt string, maxTokens int) (string, error) {
+	c.mu.Lock()
+	c.prompts = append(c.prompts, prompt)
+	c.mu.Unlock()
+	select {
+	case <-time.After(c.delay):
+		return c.response, nil
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// overheadMockPipeline spends a fixed delay and tokens per stage.
+type overheadMockPipeline struct {
+	classifyDelay, decideDelay, prepareDelay    time.Duration
+	classifyTokens, decideTokens, prepareTokens int
+	preamble                                    string
+	decideErr                                   error
+}
+
+func (p *overheadMockPipeline) Classify(ctx context.Context, task Task) (int, error) {
+	time.Sleep(p.classifyDelay)
+	return p.classifyTokens, nil
+}
+
+func (p *overheadMockPipeline) Decide(ctx context.Context, task Task) (int, error) {
+	time.Sleep(p.decideDelay)
+	return p.decideTokens, p.decideErr
+}
+
+func (p *overheadMockPipeline) Prepare(ctx context.Context, task Task) (string, int, error) {
+	time.Sleep(p.prepareDelay)
+	return p.preamble + buildDirectPrompt(task), p.prepareTokens, nil
+}
+
+func assertStagesSum(t *testing.T, total StageOverhead, stages map[OverheadStage]StageOverhead) {
+	t.Helper()
+	require.Len(t, stages, len(OverheadStages))
+	assert.Equal(t, total, sumStages(stages), "stages must sum to the overhead")
+}
+
+func TestOverheadRunner_Breakdown(t *testing.T) {
+	client := &overheadMockClient{delay: 5 * time.Millisecond, response: "The answer is 12."}
+	pipeline := &overheadMockPipeline{
+		classifyDelay: 2 * time.Millisecond,
+		decideDelay:   20 * time.Millisecond,
+		prepareDelay:  4 * time.Millisecond,
+		decideTokens:  300,
+		prepareTokens: 7,
+		preamble:      strings.Repeat("x", 400), // 100 more prompt tokens
+	}
+	runner := NewOverheadRunner(client, pipeline)
+
+	suite := TrivialSuite()
+	report, err := runner.Run(context.Background(), suite, OverheadConfig{Repetitions: 2, MaxTokensPerCall: 256})
+	require.NoError(t, err)
+	require.Len(t, report.Tasks, len(suite.Tasks))
+	assert.Len(t, client.prompts, 2*2*len(suite.Tasks), "each repetition answers once each way")
+
+	for _, task := range report.Tasks {
+		assert.Empty(t, task.Error)
+		assert.Equal(t, task.RLM.sub(task.Direct), task.Overhead)
+		assertStagesSum(t, task.Overhead, task.Stages)
+
+		assert.Equal(t, 0, task.Stages[StageClassification].Tokens)
+		assert.Equal(t, 300, task.Stages[StageMetaController].Tokens)
+		assert.Equal(t, 7, task.Stages[StageContextPrep].Tokens)
+		assert.Equal(t, 100, task.Stages[StageOther].Tokens, "the longer prepared prompt")
+		assert.Equal(t, 407, task.Overhead.Tokens)
+
+		assert.GreaterOrEqual(t, task.Stages[StageClassification].Duration, 2*time.Millisecond)
+		assert.GreaterOrEqual(t, task.Stages[StageMetaController].Duration, 20*time.Millisecond)
+		assert.GreaterOrEqual(t, task.Stages[StageContextPrep].Duration, 4*time.Millisecond)
+		assert.Greater(t, task.Overhead.Duration, 15*time.Millisecond)
+	}
+
+	summary := report.Summary
+	assert.Equal(t, len(suite.Tasks), summary.TaskCount)
+	assert.Zero(t, summary.ErrorCount)
+	assert.Equal(t, summary.MeanRLM.sub(summary.MeanDirect), summary.MeanOverhead)
+	assertStagesSum(t, summary.MeanOverhead, summary.ByStage)
+	assert.Equal(t, 300, summary.ByStage[StageMetaController].Tokens)
+	assert.Greater(t, summary.ByStage[StageMetaController].Duration, summary.ByStage[StageClassification].Duration,
+		"the meta-controller dominates")
+	assert.Greater(t, summary.LatencyOverhead, 1.0)
+	assert.InDelta(t, float64(summary.MeanOverhead.Tokens)/float64(summary.MeanDirect.Tokens), summary.TokenOverhead, 1e-9)
+}
+
+func TestOverheadRunner_StageError(t *testing.T) {
+	client := &overheadMockClient{response: "yes"}
+	pipeline := &overheadMockPipeline{decideErr: errors.New("meta-controller unavailable")}
+	runner := NewOverheadRunner(client, pipeline)
+
+	suite := TrivialSuite()
+	report, err := runner.Run(context.Background(), suite, OverheadConfig{})
+	require.NoError(t, err)
+	assert.Equal(t, len(suite.Tasks), report.Summary.ErrorCount)
+	assert.Contains(t, report.Tasks[0].Error, "meta-controller: meta-controller unavailable")
+	assert.Zero(t, report.Summary.MeanOverhead)
+}
+
+func TestTrivialSuite(t *testing.T) {
+	suite := TrivialSuite()
+	require.NotEmpty(t, suite.Tasks)
+	for _, task := range suite.Tasks {
+		assert.NotEmpty(t, task.ExpectedAnswer, task.ID)
+		assert.Less(t, task.ContextTokens, 100, task.ID)
+	}
+}
+
+// imageMockClient is an overheadMockClient that accepts images and routes
+// across tiers.
+type imageMockClient struct {
+	overheadMockClient
+}
+
+func (c *imageMockClient) SupportsImages() bool { return true }
+
+func (c *imageMockClient) CompleteWithImages(ctx context.Context, prompt string, images []meta.Image, maxTokens int) (string, error) {
+	return c.Complete(ctx, prompt, maxTokens)
+}
+
+func (c *imageMockClient) Capabilities() meta.Capabilities {
+	return meta.Capabilities{ContextWindow: 200000, Images: true, TierRouting: true}
+}
+
+func TestCountingClient_ForwardsCapabilities(t *testing.T) {
+	client := &countingClient{client: &imageMockClient{overheadMockClient{response: "a cat"}}}
+
+	assert.Equal(t, meta.Capabilities{ContextWindow: 200000, Images: true, TierRouting: true}, meta.CapabilitiesOf(client))
+	mm, ok := meta.AcceptsImages(client)
+	require.True(t, ok)
+	response, err := mm.CompleteWithImages(context.Background(), "What is in the picture?", []meta.Image{{Name: "cat.png"}}, 100)
+	require.NoError(t, err)
+	assert.Equal(t, "a cat", response)
+	assert.Positive(t, client.tokens.Load(), "image completions are counted")
+
+	plain := &countingClient{client: &overheadMockClient{response: "ok"}}
+	_, ok = meta.AcceptsImages(plain)
+	assert.False(t, ok)
+	assert.Nil(t, plain.ExplainRoute(context.Background(), "prompt"))
+}
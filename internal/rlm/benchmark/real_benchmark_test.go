@@ -911,32 +911,36 @@ func TestRealBenchmark_DiagnosticOutputs(t *testing.T) {
 func TestRealBenchmark_PartialMatchAnalysis(t *testing.T) {
 	// This test analyzes scoring behavior without API calls
 	scorer := NewDefaultScorer()
+	classifier := NewFailureClassifier()
 
 	testCases := []struct {
 		name     string
 		answer   string
 		expected string
 		wantOK   bool
+		wantMode FailureMode
 	}{
-		{"exact match", "CODE-2305", "CODE-2305", true},
-		{"answer contains expected", "The code is CODE-2305.", "CODE-2305", true},
-		{"partial - number only", "2305", "CODE-2305", false},
-		{"partial - with prefix", "The answer is 2305", "CODE-2305", false},
-		{"case insensitive", "code-2305", "CODE-2305", true},
-		{"extra context", "Based on my analysis, the secret access code is CODE-2305 as mentioned in the document.", "CODE-2305", true},
-		{"hallucinated different code", "The code is CODE-1234", "CODE-2305", false},
-		{"no code found", "I could not find any secret code in the document.", "CODE-2305", false},
+		{"exact match", "CODE-2305", "CODE-2305", true, ""},
+		{"answer contains expected", "The code is CODE-2305.", "CODE-2305", true, ""},
+		{"partial - number only", "2305", "CODE-2305", false, FailurePartial},
+		{"partial - with prefix", "The answer is 2305", "CODE-2305", false, FailurePartial},
+		{"case insensitive", "code-2305", "CODE-2305", true, ""},
+		{"extra context", "Based on my analysis, the secret access code is CODE-2305 as mentioned in the document.", "CODE-2305", true, ""},
+		{"hallucinated different code", "The code is CODE-1234", "CODE-2305", false, FailureHallucinated},
+		{"no code found", "I could not find any secret code in the document.", "CODE-2305", false, FailureNotFound},
 	}
 
 	t.Log("=== AnswerContains Scoring Analysis ===")
 	for _, tc := range testCases {
 		score, correct := scorer.Score(tc.answer, tc.expected, AnswerContains)
+		task := Task{ExpectedAnswer: tc.expected, AnswerType: AnswerContains}
+		mode := classifier.Classify(task, Result{Answer: tc.answer, Score: score, Correct: correct})
 		status := "✓"
-		if correct != tc.wantOK {
+		if correct != tc.wantOK || mode != tc.wantMode {
 			status = "✗ UNEXPECTED"
 		}
-		t.Logf("%s %s: answer=%q expected=%q => score=%.2f correct=%v",
-			status, tc.name, truncateString(tc.answer, 50), tc.expected, score, correct)
+		t.Logf("%s %s: answer=%q expected=%q => score=%.2f correct=%v mode=%q",
+			status, tc.name, truncateString(tc.answer, 50), tc.expected, score, correct, mode)
 	}
 }
 
@@ -2,10 +2,13 @@ package benchmark
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sync/atomic"
 	"time"
 
 	"github.com/rand/recurse/internal/rlm"
+	"github.com/rand/recurse/internal/rlm/hallucination"
 	"github.com/rand/recurse/internal/rlm/meta"
 	"github.com/rand/recurse/internal/rlm/repl"
 )
@@ -14,7 +17,7 @@ import (
 type RealRLMExecutor struct {
 	service   *rlm.Service
 	replMgr   *repl.Manager
-	llmClient meta.LLMClient
+	llmClient *countingClient
 }
 
 // RealExecutorConfig configures the real RLM executor.
@@ -22,6 +25,11 @@ type RealExecutorConfig struct {
 	// OpenRouterAPIKey is the API key for OpenRouter.
 	OpenRouterAPIKey string
 
+	// KeylessFallback selects the client used when no OpenRouter API key
+	// is available, e.g. meta.KeylessStub to exercise the pipeline
+	// offline. The default fails.
+	KeylessFallback meta.KeylessFallback
+
 	// UseREPL enables the Python REPL for context externalization.
 	UseREPL bool
 
@@ -32,17 +40,24 @@ type RealExecutorConfig struct {
 	// For RLM mode with LLM callbacks, this should be several minutes.
 	// Default is 5 minutes if not set.
 	REPLTimeout time.Duration
+
+	// ClassificationCache reuses task classifications across prompts and
+	// executors, and can be seeded from an earlier run with LoadFile. Nil
+	// classifies every prompt.
+	ClassificationCache *rlm.ClassificationCache
 }
 
 // NewRealRLMExecutor creates an executor using the actual RLM system.
 func NewRealRLMExecutor(cfg RealExecutorConfig) (*RealRLMExecutor, error) {
 	// Create OpenRouter client
-	llmClient, err := meta.NewOpenRouterClient(meta.OpenRouterConfig{
-		APIKey: cfg.OpenRouterAPIKey,
+	client, err := meta.NewOpenRouterClientOrFallback(meta.OpenRouterConfig{
+		APIKey:          cfg.OpenRouterAPIKey,
+		KeylessFallback: cfg.KeylessFallback,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("create LLM client: %w", err)
 	}
+	llmClient := &countingClient{client: client}
 
 	// Create RLM service
 	svcConfig := rlm.DefaultServiceConfig()
@@ -59,6 +74,7 @@ func NewRealRLMExecutor(cfg RealExecutorConfig) (*RealRLMExecutor, error) {
 	// Configure wrapper with LLM client for RLM execution
 	if service.Wrapper() != nil {
 		service.Wrapper().SetLLMClient(llmClient)
+		service.Wrapper().SetClassificationCache(cfg.ClassificationCache)
 	}
 
 	// Optionally set up REPL with appropriate timeout for LLM callbacks
@@ -145,6 +161,13 @@ func (e *RealRLMExecutor) Execute(ctx context.Context, task Task, config RunConf
 		result.CompletionTokens = rlmResult.CompletionTokens
 		result.TotalTokens = rlmResult.TotalTokens
 		result.Metadata["rlm_mode"] = true
+		if p := rlmResult.prepared; p != nil {
+			result.Metadata["mode"] = string(p.Mode)
+			result.Metadata["context_tokens"] = p.TotalTokens
+			if p.Classification != nil {
+				result.Metadata["task_type"] = string(p.Classification.Type)
+			}
+		}
 	} else {
 		// Direct prompting mode
 		directResult, err := e.executeDirect(ctx, task, config)
@@ -192,7 +215,7 @@ func (e *RealRLMExecutor) executeRLM(ctx context.Context, task Task, config RunC
 		return nil, fmt.Errorf("prepare context: %w", err)
 	}
 
-	result := &rlmExecutionResult{}
+	result := &rlmExecutionResult{prepared: prepared}
 
 	if prepared.Mode == rlm.ModeRLM {
 		// Execute RLM loop
@@ -219,7 +242,12 @@ func (e *RealRLMExecutor) executeRLM(ctx context.Context, task Task, config RunC
 		}
 	} else {
 		// Direct mode (small context)
-		return e.executeDirect(ctx, task, config)
+		direct, err := e.executeDirect(ctx, task, config)
+		if err != nil {
+			return nil, err
+		}
+		direct.prepared = prepared
+		return direct, nil
 	}
 
 	return result, nil
@@ -254,6 +282,32 @@ type rlmExecutionResult struct {
 	PromptTokens     int
 	CompletionTokens int
 	TotalTokens      int
+
+	// prepared is the wrapper's preparation, whose mode was used
+	prepared *rlm.PreparedPrompt
+}
+
+var _ ResultObserver = (*RealRLMExecutor)(nil)
+
+// ObserveResult feeds a graded result back into the wrapper's mode
+// selection, for tasks whose mode the wrapper chose.
+func (e *RealRLMExecutor) ObserveResult(task Task, result Result) {
+	mode, ok := result.Metadata["mode"].(string)
+	if !ok || e.service.Wrapper() == nil {
+		return
+	}
+	tracker := e.service.Wrapper().ModeOutcomes()
+	if tracker == nil {
+		return
+	}
+	taskType, _ := result.Metadata["task_type"].(string)
+	tokens, _ := result.Metadata["context_tokens"].(int)
+	tracker.Record(rlm.ModeOutcome{
+		TaskType:      rlm.TaskType(taskType),
+		ContextTokens: tokens,
+		Mode:          rlm.ExecutionMode(mode),
+		Correct:       result.Correct,
+	})
 }
 
 // Service returns the underlying RLM service.
@@ -261,6 +315,153 @@ func (e *RealRLMExecutor) Service() *rlm.Service {
 	return e.service
 }
 
+// countingClient estimates the tokens of every call made through it, so
+// the overhead pipeline can attribute them to stages. Besides Complete it forwards
+// image and logprob completions, route explanations and capabilities, so
+// benchmarks measure the client as the service would use it.
+type countingClient struct {
+	client meta.LLMClient
+	tokens atomic.Int64
+}
+
+// routeExplainingClient matches clients that explain how they route a
+// prompt.
+type routeExplainingClient interface {
+	ExplainRoute(ctx context.Context, prompt string) *meta.RouteDecision
+}
+
+var (
+	_ meta.CapabilityReporter         = (*countingClient)(nil)
+	_ meta.MultimodalClient           = (*countingClient)(nil)
+	_ hallucination.LogprobsCompleter = (*countingClient)(nil)
+	_ routeExplainingClient           = (*countingClient)(nil)
+)
+
+// count adds the estimated tokens of one call.
+func (c *countingClient) count(prompt, response string) {
+	c.tokens.Add(int64(estimateTokens(prompt) + estimateTokens(response)))
+}
+
+// Complete implements meta.LLMClient.
+func (c *countingClient) Complete(ctx context.Context, prompt string, maxTokens int) (string, error) {
+	response, err := c.client.Complete(ctx, prompt, maxTokens)
+	c.count(prompt, response)
+	return response, err
+}
+
+// Capabilities implements meta.CapabilityReporter with the wrapped
+// client's capabilities.
+func (c *countingClient) Capabilities() meta.Capabilities {
+	return meta.CapabilitiesOf(c.client)
+}
+
+// SupportsImages implements meta.MultimodalClient.
+func (c *countingClient) SupportsImages() bool {
+	mm, ok := c.client.(meta.MultimodalClient)
+	return ok && mm.SupportsImages()
+}
+
+// CompleteWithImages implements meta.MultimodalClient.
+func (c *countingClient) CompleteWithImages(ctx context.Context, prompt string, images []meta.Image, maxTokens int) (string, error) {
+	mm, ok := c.client.(meta.MultimodalClient)
+	if !ok {
+		return "", errors.New("counting client: wrapped client does not accept images")
+	}
+	response, err := mm.CompleteWithImages(ctx, prompt, images, maxTokens)
+	c.count(prompt, response)
+	return response, err
+}
+
+// CompleteWithLogprobs implements hallucination.LogprobsCompleter.
+func (c *countingClient) CompleteWithLogprobs(ctx context.Context, prompt string, maxTokens int) (string, map[string]float64, error) {
+	lc, ok := c.client.(hallucination.LogprobsCompleter)
+	if !ok {
+		return "", nil, errors.New("counting client: wrapped client does not report logprobs")
+	}
+	response, logprobs, err := lc.CompleteWithLogprobs(ctx, prompt, maxTokens)
+	c.count(prompt, response)
+	return response, logprobs, err
+}
+
+// ExplainRoute explains how the wrapped client routes prompt, nil if it
+// does not say.
+func (c *countingClient) ExplainRoute(ctx context.Context, prompt string) *meta.RouteDecision {
+	if explainer, ok := c.client.(routeExplainingClient); ok {
+		return explainer.ExplainRoute(ctx, prompt)
+	}
+	return nil
+}
+
+// OverheadPipeline returns the executor's service as an OverheadPipeline:
+// rule-based classification, the service's prompt analysis for the
+// meta-controller, and the wrapper's context preparation. A classification
+// cache is installed on the wrapper if it has none, so preparation reuses
+// the classification stage's result instead of repeating it; an LLM
+// classification fallback during mode selection counts as preparation.
+func (e *RealRLMExecutor) OverheadPipeline() OverheadPipeline {
+	wrapper := e.service.Wrapper()
+	if wrapper != nil && wrapper.ClassificationCache() == nil {
+		wrapper.SetClassificationCache(rlm.NewClassificationCache(rlm.ClassificationCacheConfig{}))
+	}
+	return &realOverheadPipeline{
+		executor:   e,
+		classifier: rlm.NewTaskClassifier(),
+	}
+}
+
+// realOverheadPipeline runs the RLM front end of a RealRLMExecutor.
+type realOverheadPipeline struct {
+	executor   *RealRLMExecutor
+	classifier *rlm.TaskClassifier
+}
+
+var _ OverheadPipeline = (*realOverheadPipeline)(nil)
+
+// Classify implements OverheadPipeline.
+func (p *realOverheadPipeline) Classify(ctx context.Context, task Task) (int, error) {
+	contexts := overheadContexts(task)
+	class := p.classifier.Classify(task.Query, contexts)
+	if wrapper := p.executor.service.Wrapper(); wrapper != nil {
+		wrapper.ClassificationCache().Put(task.Query, contexts, class)
+	}
+	return 0, nil
+}
+
+// Decide implements OverheadPipeline.
+func (p *realOverheadPipeline) Decide(ctx context.Context, task Task) (int, error) {
+	before := p.executor.llmClient.tokens.Load()
+	if _, err := p.executor.service.AnalyzePrompt(ctx, task.Query, task.ContextTokens); err != nil {
+		return 0, err
+	}
+	return int(p.executor.llmClient.tokens.Load() - before), nil
+}
+
+// Prepare implements OverheadPipeline. A task the wrapper would run in
+// RLM mode is an error: it is not trivial.
+func (p *realOverheadPipeline) Prepare(ctx context.Context, task Task) (string, int, error) {
+	before := p.executor.llmClient.tokens.Load()
+	prepared, err := p.executor.service.PrepareContext(ctx, task.Query, overheadContexts(task))
+	if err != nil {
+		return "", 0, err
+	}
+	if prepared.Mode == rlm.ModeRLM {
+		return "", 0, fmt.Errorf("task selected RLM mode: %s", prepared.ModeReason)
+	}
+	return prepared.FinalPrompt, int(p.executor.llmClient.tokens.Load() - before), nil
+}
+
+// overheadContexts returns the task's context as a context source, if any.
+func overheadContexts(task Task) []rlm.ContextSource {
+	if task.Context == "" {
+		return nil
+	}
+	return []rlm.ContextSource{{
+		Name:    "benchmark_context",
+		Type:    rlm.ContextTypeCustom,
+		Content: task.Context,
+	}}
+}
+
 // RealDirectExecutor runs benchmark tasks using direct LLM calls only.
 type RealDirectExecutor struct {
 	llmClient meta.LLMClient
@@ -9,14 +9,21 @@ import (
 
 // DefaultScorer implements the Scorer interface with common evaluation logic.
 type DefaultScorer struct {
-	// NumericTolerance is the allowed difference for numeric comparisons.
+	// NumericTolerance is the allowed difference for numeric comparisons,
+	// relative to the expected value.
 	NumericTolerance float64
+
+	// NumericEpsilon is the allowed absolute difference for numeric
+	// comparisons, so float answers that differ only by rounding match
+	// even when the expected value is zero.
+	NumericEpsilon float64
 }
 
 // NewDefaultScorer creates a scorer with default settings.
 func NewDefaultScorer() *DefaultScorer {
 	return &DefaultScorer{
 		NumericTolerance: 0.01, // 1% tolerance
+		NumericEpsilon:   1e-9,
 	}
 }
 
@@ -59,10 +66,10 @@ func (s *DefaultScorer) scoreNumeric(answer, expected string) (float64, bool) {
 	}
 
 	// Check if within tolerance
+	if math.Abs(answerNum-expectedNum) <= s.NumericEpsilon {
+		return 1.0, true
+	}
 	if expectedNum == 0 {
-		if answerNum == 0 {
-			return 1.0, true
-		}
 		return 0.0, false
 	}
 
@@ -121,24 +128,35 @@ func (s *DefaultScorer) scoreContains(answer, expected string) (float64, bool) {
 	return 0.0, false
 }
 
-// extractNumber extracts the first number from a string.
+// numberPattern matches a number with an optional sign and currency
+// symbol, either in comma-grouped thousands ("2,305") or plain.
+var numberPattern = regexp.MustCompile(`([-+]?)[$€£¥]?([-+]?)(\d{1,3}(?:,\d{3})+(?:\.\d+)?|\d+(?:\.\d+)?|\.\d+)`)
+
+// extractNumber extracts the first number from a string, ignoring thousands
+// separators, currency symbols and trailing percent signs. A sign directly
+// after a letter or digit is taken as a hyphen ("CODE-2305" is 2305). It
+// returns NaN if the string holds no number.
 func extractNumber(s string) float64 {
-	// Remove common formatting
-	s = strings.ReplaceAll(s, ",", "")
-	s = strings.ReplaceAll(s, "$", "")
-	s = strings.ReplaceAll(s, "%", "")
-
-	// Find number pattern
-	re := regexp.MustCompile(`-?\d+\.?\d*`)
-	match := re.FindString(s)
-	if match == "" {
+	m := numberPattern.FindStringSubmatchIndex(s)
+	if m == nil {
 		return math.NaN()
 	}
 
-	num, err := strconv.ParseFloat(match, 64)
+	sign := s[m[2]:m[3]] + s[m[4]:m[5]]
+	if m[2] != m[3] && m[0] > 0 {
+		prev := s[m[0]-1]
+		if prev >= '0' && prev <= '9' || prev >= 'a' && prev <= 'z' || prev >= 'A' && prev <= 'Z' {
+			sign = s[m[4]:m[5]]
+		}
+	}
+
+	num, err := strconv.ParseFloat(strings.ReplaceAll(s[m[6]:m[7]], ",", ""), 64)
 	if err != nil {
 		return math.NaN()
 	}
+	if strings.Contains(sign, "-") {
+		num = -num
+	}
 	return num
 }
 
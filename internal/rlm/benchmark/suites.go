@@ -88,6 +88,38 @@ func QuickSuite(seed int64) Suite {
 	}
 }
 
+// TrivialSuite returns tasks small and simple enough that RLM should answer
+// them directly, for measuring orchestration overhead with OverheadRunner.
+func TrivialSuite() Suite {
+	trivial := func(id, context, query, answer string, answerType AnswerType) Task {
+		return Task{
+			ID:             id,
+			Name:           "Trivial",
+			Description:    "Answerable at a glance; needs no RLM",
+			Complexity:     ComplexityConstant,
+			Context:        context,
+			ContextTokens:  estimateTokens(context),
+			Query:          query,
+			ExpectedAnswer: answer,
+			AnswerType:     answerType,
+		}
+	}
+
+	return Suite{
+		Name:        "Trivial",
+		Description: "Tiny tasks that should take the Direct path, measuring the RLM tax",
+		Tasks: []Task{
+			trivial("trivial-arith", "", "What is 7 + 5?", "12", AnswerNumeric),
+			trivial("trivial-capital", "", "What is the capital of France?", "Paris", AnswerContains),
+			trivial("trivial-lookup", "Owner: Dana\nService: billing\nRegion: eu-west-1",
+				"Which region is the service in?", "eu-west-1", AnswerContains),
+			trivial("trivial-count", "apple, pear, plum", "How many fruits are listed?", "3", AnswerNumeric),
+			trivial("trivial-yesno", "The build finished with 0 failures.",
+				"Did the build fail?", "no", AnswerContains),
+		},
+	}
+}
+
 // FullSuite returns a comprehensive benchmark suite.
 func FullSuite(seed int64) Suite {
 	return Suite{
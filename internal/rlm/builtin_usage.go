@@ -0,0 +1,147 @@
+package rlm
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"maps"
+	"strings"
+	"sync"
+)
+
+// BuiltinUsage counts the calls RLM code made to each REPL builtin, such
+// as "grep" or "llm_call", by name. Calls the builtins make to each other
+// are not counted.
+type BuiltinUsage map[string]int
+
+// llmBuiltins are the builtins that make sub-LLM calls.
+var llmBuiltins = []string{"llm_call", "llm_batch", "map_reduce", "summarize", "find_relevant"}
+
+// Total returns the number of builtin calls.
+func (u BuiltinUsage) Total() int {
+	total := 0
+	for _, n := range u {
+		total += n
+	}
+	return total
+}
+
+// LLMCalls returns the number of calls to builtins that make sub-LLM
+// calls, the expensive ones.
+func (u BuiltinUsage) LLMCalls() int {
+	total := 0
+	for _, name := range llmBuiltins {
+		total += u[name]
+	}
+	return total
+}
+
+// add adds other's counts to u.
+func (u BuiltinUsage) add(other BuiltinUsage) {
+	for name, n := range other {
+		u[name] += n
+	}
+}
+
+// BuiltinUsageSummary totals the builtin calls of a set of executions.
+type BuiltinUsageSummary struct {
+	Executions int          `json:"executions"`
+	Calls      BuiltinUsage `json:"calls"`
+}
+
+// PerExecution returns the mean calls to the named builtin per execution.
+func (s BuiltinUsageSummary) PerExecution(name string) float64 {
+	if s.Executions == 0 {
+		return 0
+	}
+	return float64(s.Calls[name]) / float64(s.Executions)
+}
+
+// BuiltinUsageStats aggregates the builtin calls of every RLM execution a
+// Wrapper ran, overall and by task type, showing e.g. whether models reach
+// for llm_call on counting tasks that Python would solve.
+type BuiltinUsageStats struct {
+	BuiltinUsageSummary
+	ByTaskType map[TaskType]BuiltinUsageSummary `json:"by_task_type"`
+}
+
+// builtinUsageTracker accumulates BuiltinUsageStats. It is safe for
+// concurrent use, and a nil tracker records nothing.
+type builtinUsageTracker struct {
+	mu    sync.Mutex
+	stats BuiltinUsageStats
+}
+
+func newBuiltinUsageTracker() *builtinUsageTracker {
+	return &builtinUsageTracker{stats: BuiltinUsageStats{
+		BuiltinUsageSummary: BuiltinUsageSummary{Calls: make(BuiltinUsage)},
+		ByTaskType:          make(map[TaskType]BuiltinUsageSummary),
+	}}
+}
+
+// record adds one run's calls. A continued execution adds its calls
+// without counting as another execution.
+func (t *builtinUsageTracker) record(taskType TaskType, usage BuiltinUsage, newExecution bool) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	byType := t.stats.ByTaskType[taskType]
+	if byType.Calls == nil {
+		byType.Calls = make(BuiltinUsage)
+	}
+	if newExecution {
+		t.stats.Executions++
+		byType.Executions++
+	}
+	t.stats.Calls.add(usage)
+	byType.Calls.add(usage)
+	t.stats.ByTaskType[taskType] = byType
+}
+
+// snapshot returns a copy of the stats.
+func (t *builtinUsageTracker) snapshot() BuiltinUsageStats {
+	if t == nil {
+		return BuiltinUsageStats{}
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	stats := BuiltinUsageStats{
+		BuiltinUsageSummary: BuiltinUsageSummary{
+			Executions: t.stats.Executions,
+			Calls:      maps.Clone(t.stats.Calls),
+		},
+		ByTaskType: make(map[TaskType]BuiltinUsageSummary, len(t.stats.ByTaskType)),
+	}
+	for taskType, summary := range t.stats.ByTaskType {
+		stats.ByTaskType[taskType] = BuiltinUsageSummary{
+			Executions: summary.Executions,
+			Calls:      maps.Clone(summary.Calls),
+		}
+	}
+	return stats
+}
+
+// readBuiltinUsage returns and resets the builtin call counts the REPL
+// has recorded.
+func (w *Wrapper) readBuiltinUsage(ctx context.Context) BuiltinUsage {
+	result, err := w.replMgr.Execute(ctx, "print(get_builtin_usage_json())")
+	if err != nil || result.Error != "" {
+		return nil
+	}
+	var usage BuiltinUsage
+	if err := json.Unmarshal([]byte(strings.TrimSpace(result.Output)), &usage); err != nil {
+		slog.Warn("Failed to read builtin usage", "error", err)
+		return nil
+	}
+	return usage
+}
+
+// BuiltinUsage returns the builtin calls of every RLM execution run so
+// far, overall and by task type.
+func (w *Wrapper) BuiltinUsage() BuiltinUsageStats {
+	return w.builtinUsage.snapshot()
+}
@@ -0,0 +1,99 @@
+package rlm
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func runBuiltinUsageRLM(t *testing.T, w *Wrapper) (*RLMExecutionResult, *PreparedPrompt) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	prepared, err := w.PrepareContextWithOptions(ctx, "How many widgets are listed?",
+		[]ContextSource{{Name: "notes", Type: ContextTypeCustom, Content: "widget A\nwidget B\ngadget C\nwidget D"}},
+		PrepareOptions{ModeOverride: ModeOverrideRLM})
+	require.NoError(t, err)
+
+	result, err := w.ExecuteRLMWithConfig(ctx, prepared, RLMConfig{
+		MaxIterations:    4,
+		MaxTokensPerCall: 1024,
+		Timeout:          10 * time.Second,
+	})
+	require.NoError(t, err)
+	return result, prepared
+}
+
+func TestExecuteRLM_RecordsBuiltinUsage(t *testing.T) {
+	w := newREPLWrapper(t, DefaultWrapperConfig(),
+		"```python\n"+
+			"disable_callbacks()\n"+
+			"hits = grep(notes, 'widget')\n"+
+			"head = peek(notes, 0, 10)\n"+
+			"parts = partition(notes, n=2)\n"+
+			"checked = llm_call('Count the widgets', notes)\n"+
+			"merged = map_reduce(notes, 'List widgets', 'Merge lists', n_chunks=2)\n"+
+			"print(len(hits))\n"+
+			"```",
+		"```python\nhits = grep(notes, 'widget')\nFINAL(str(len(hits)))\n```",
+		"```python\nanswer = '3'\nFINAL_VAR('answer')\n```",
+	)
+
+	result, prepared := runBuiltinUsageRLM(t, w)
+	require.Empty(t, result.Error)
+	assert.Equal(t, "3", result.FinalOutput)
+
+	// map_reduce's own partition, llm_batch and llm_call calls are not counted
+	assert.Equal(t, BuiltinUsage{
+		"grep":       2,
+		"peek":       1,
+		"partition":  1,
+		"llm_call":   1,
+		"map_reduce": 1,
+		"FINAL":      1,
+	}, result.BuiltinUsage)
+	assert.Equal(t, 2, result.BuiltinUsage.LLMCalls())
+	assert.Equal(t, 7, result.BuiltinUsage.Total())
+
+	// A second execution starts from zero and adds to the aggregate
+	second, _ := runBuiltinUsageRLM(t, w)
+	require.Empty(t, second.Error)
+	assert.Equal(t, "3", second.FinalOutput)
+	assert.Equal(t, BuiltinUsage{"FINAL_VAR": 1}, second.BuiltinUsage)
+
+	stats := w.BuiltinUsage()
+	assert.Equal(t, 2, stats.Executions)
+	assert.Equal(t, 2, stats.Calls["grep"])
+	assert.Equal(t, 1, stats.Calls["FINAL_VAR"])
+	assert.InDelta(t, 1.0, stats.PerExecution("grep"), 1e-9)
+
+	taskType := TaskTypeUnknown
+	if prepared.Classification != nil {
+		taskType = prepared.Classification.Type
+	}
+	require.Contains(t, stats.ByTaskType, taskType)
+	assert.Equal(t, 2, stats.ByTaskType[taskType].Executions)
+	assert.Equal(t, 1, stats.ByTaskType[taskType].Calls["llm_call"])
+}
+
+func TestBuiltinUsageTracker_ContinuationIsNotANewExecution(t *testing.T) {
+	tracker := newBuiltinUsageTracker()
+	tracker.record(TaskTypeComputational, BuiltinUsage{"llm_call": 2}, true)
+	tracker.record(TaskTypeComputational, BuiltinUsage{"FINAL": 1}, false)
+	tracker.record(TaskTypeRetrieval, BuiltinUsage{"grep": 1, "FINAL": 1}, true)
+
+	stats := tracker.snapshot()
+	assert.Equal(t, 2, stats.Executions)
+	assert.Equal(t, BuiltinUsage{"llm_call": 2, "FINAL": 2, "grep": 1}, stats.Calls)
+	assert.Equal(t, BuiltinUsageSummary{Executions: 1, Calls: BuiltinUsage{"llm_call": 2, "FINAL": 1}},
+		stats.ByTaskType[TaskTypeComputational])
+	assert.InDelta(t, 2.0, stats.ByTaskType[TaskTypeComputational].PerExecution("llm_call"), 1e-9)
+
+	// Snapshots are copies
+	stats.Calls["grep"] = 100
+	assert.Equal(t, 1, tracker.snapshot().Calls["grep"])
+}
@@ -0,0 +1,566 @@
+package rlm
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/rand/recurse/internal/rlm/meta"
+	"github.com/rand/recurse/internal/rlm/redact"
+)
+
+// ExecutionBundleVersion is the format version written to bundle manifests.
+const ExecutionBundleVersion = 2
+
+// maxExecutionCaptures bounds how many recent executions a wrapper keeps
+// the inputs of for export.
+const maxExecutionCaptures = 32
+
+// Files inside a bundle archive.
+const (
+	bundleManifestFile  = "manifest.json"
+	bundleContextsFile  = "contexts.json"
+	bundleResponsesFile = "responses.json"
+	bundleSubCallsFile  = "subcalls.json"
+	bundleTraceFile     = "trace.json"
+)
+
+// ExecutionBundle holds everything needed to reproduce an RLM execution:
+// the task, the externalized contexts, the loop configuration, the model's
+// responses in the order they were returned, and what the REPL code's
+// llm_call() and llm_batch() calls returned.
+type ExecutionBundle struct {
+	Version     int       `json:"version"`
+	ExecutionID string    `json:"execution_id"`
+	CreatedAt   time.Time `json:"created_at"`
+
+	// Task is the user's prompt; Prompt and SystemPrompt are what the loop
+	// started from.
+	Task         string `json:"task"`
+	Prompt       string `json:"prompt"`
+	SystemPrompt string `json:"system_prompt"`
+
+	Config BundleConfig `json:"config"`
+
+	// Answer and Error are how the recorded execution ended.
+	Answer string `json:"answer"`
+	Error  string `json:"error,omitempty"`
+
+	Contexts  []BundleContext `json:"-"`
+	Responses []string        `json:"-"`
+	SubCalls  []BundleSubCall `json:"-"`
+	Trace     []TraceEvent    `json:"-"`
+}
+
+// BundleConfig is the part of RLMConfig that shapes an execution and can be
+// serialized. Callbacks, cost ceilings and output schemas are not kept.
+type BundleConfig struct {
+	TaskType               TaskType      `json:"task_type,omitempty"`
+	MaxIterations          int           `json:"max_iterations"`
+	MinIterations          int           `json:"min_iterations,omitempty"`
+	MaxTokensPerCall       int           `json:"max_tokens_per_call"`
+	ContextWindow          int           `json:"context_window,omitempty"`
+	HistoryTokenBudget     int           `json:"history_token_budget,omitempty"`
+	RecentTurns            int           `json:"recent_turns,omitempty"`
+	Timeout                time.Duration `json:"timeout"`
+	EnableEarlyTermination bool          `json:"enable_early_termination"`
+	VerifyComputation      bool          `json:"verify_computation"`
+}
+
+// rlmConfig returns the loop configuration the bundle was recorded with.
+func (c BundleConfig) rlmConfig() RLMConfig {
+	return RLMConfig{
+		MaxIterations:          c.MaxIterations,
+		MinIterations:          c.MinIterations,
+		MaxTokensPerCall:       c.MaxTokensPerCall,
+		ContextWindow:          c.ContextWindow,
+		HistoryTokenBudget:     c.HistoryTokenBudget,
+		RecentTurns:            c.RecentTurns,
+		Timeout:                c.Timeout,
+		EnableEarlyTermination: c.EnableEarlyTermination,
+		VerifyComputation:      c.VerifyComputation,
+	}
+}
+
+// BundleContext is an externalized context source as stored in a bundle.
+type BundleContext struct {
+	Name     string         `json:"name"`
+	Content  string         `json:"content"`
+	Type     ContextType    `json:"type"`
+	Metadata map[string]any `json:"metadata,omitempty"`
+}
+
+// BundleSubCall is one llm_call() or llm_batch() call made by the REPL
+// code, with what Python received: a result per prompt, or the error the
+// call raised.
+type BundleSubCall struct {
+	Prompts []string `json:"prompts"`
+	Results []string `json:"results,omitempty"`
+	Error   string   `json:"error,omitempty"`
+}
+
+// redact replaces the secrets in the bundle's text with redactor's
+// placeholders. The trace is redacted when it is recorded.
+func (b *ExecutionBundle) redact(redactor *redact.Redactor) {
+	if redactor == nil {
+		return
+	}
+	for _, s := range []*string{&b.Task, &b.Prompt, &b.SystemPrompt, &b.Answer, &b.Error} {
+		*s = redactor.Redact(*s)
+	}
+	for i := range b.Contexts {
+		b.Contexts[i].Content = redactor.Redact(b.Contexts[i].Content)
+	}
+	for i := range b.Responses {
+		b.Responses[i] = redactor.Redact(b.Responses[i])
+	}
+	for i := range b.SubCalls {
+		call := &b.SubCalls[i]
+		for j := range call.Prompts {
+			call.Prompts[j] = redactor.Redact(call.Prompts[j])
+		}
+		for j := range call.Results {
+			call.Results[j] = redactor.Redact(call.Results[j])
+		}
+		call.Error = redactor.Redact(call.Error)
+	}
+}
+
+// WriteTo writes the bundle to w as a zip archive.
+func (b *ExecutionBundle) WriteTo(w io.Writer) (int64, error) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	files := []struct {
+		name string
+		v    any
+	}{
+		{bundleManifestFile, b},
+		{bundleContextsFile, b.Contexts},
+		{bundleResponsesFile, b.Responses},
+		{bundleSubCallsFile, b.SubCalls},
+		{bundleTraceFile, b.Trace},
+	}
+	for _, f := range files {
+		data, err := json.MarshalIndent(f.v, "", "  ")
+		if err != nil {
+			return 0, fmt.Errorf("encode bundle %s: %w", f.name, err)
+		}
+		fw, err := zw.Create(f.name)
+		if err != nil {
+			return 0, fmt.Errorf("write bundle %s: %w", f.name, err)
+		}
+		if _, err := fw.Write(data); err != nil {
+			return 0, fmt.Errorf("write bundle %s: %w", f.name, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return 0, fmt.Errorf("write bundle: %w", err)
+	}
+	return buf.WriteTo(w)
+}
+
+// ReadExecutionBundle reads a bundle archive written by
+// ExecutionBundle.WriteTo.
+func ReadExecutionBundle(r io.Reader) (*ExecutionBundle, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("read bundle: %w", err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("read bundle: %w", err)
+	}
+
+	bundle := &ExecutionBundle{}
+	targets := map[string]any{
+		bundleManifestFile:  bundle,
+		bundleContextsFile:  &bundle.Contexts,
+		bundleResponsesFile: &bundle.Responses,
+		bundleSubCallsFile:  &bundle.SubCalls,
+		bundleTraceFile:     &bundle.Trace,
+	}
+	found := make(map[string]bool, len(targets))
+	for _, f := range zr.File {
+		target, ok := targets[f.Name]
+		if !ok {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("read bundle %s: %w", f.Name, err)
+		}
+		err = json.NewDecoder(rc).Decode(target)
+		rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("decode bundle %s: %w", f.Name, err)
+		}
+		found[f.Name] = true
+	}
+	for _, name := range []string{bundleManifestFile, bundleResponsesFile} {
+		if !found[name] {
+			return nil, fmt.Errorf("read bundle: missing %s", name)
+		}
+	}
+	if bundle.Version > ExecutionBundleVersion {
+		return nil, fmt.Errorf("read bundle: unsupported version %d", bundle.Version)
+	}
+	return bundle, nil
+}
+
+// ErrExecutionNotRecorded is returned when exporting an execution the
+// wrapper holds no capture of: it was untraced, resumed, or too old.
+var ErrExecutionNotRecorded = errors.New("execution not recorded")
+
+// executionCapture accumulates a running execution's inputs and the
+// model's responses.
+type executionCapture struct {
+	mu     sync.Mutex
+	bundle ExecutionBundle
+}
+
+// recordingClient records the response of each call made through it.
+type recordingClient struct {
+	client  meta.LLMClient
+	capture *executionCapture
+}
+
+func (c *recordingClient) Complete(ctx context.Context, prompt string, maxTokens int) (string, error) {
+	response, err := c.client.Complete(ctx, prompt, maxTokens)
+	if err == nil {
+		c.capture.mu.Lock()
+		c.capture.bundle.Responses = append(c.capture.bundle.Responses, response)
+		c.capture.mu.Unlock()
+	}
+	return response, err
+}
+
+// Capabilities implements meta.CapabilityReporter with the wrapped
+// client's limits.
+func (c *recordingClient) Capabilities() meta.Capabilities {
+	return meta.CapabilitiesOf(c.client).CompletionOnly()
+}
+
+// recordSubCall records what a REPL sub-call returned to Python.
+func (c *executionCapture) recordSubCall(prompts, results []string, err error) {
+	call := BundleSubCall{Prompts: slices.Clone(prompts), Results: slices.Clone(results)}
+	if err != nil {
+		call.Error = err.Error()
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.bundle.SubCalls = append(c.bundle.SubCalls, call)
+}
+
+// finish records how the execution ended.
+func (c *executionCapture) finish(result *RLMExecutionResult) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.bundle.Answer = result.FinalOutput
+	c.bundle.Error = result.Error
+}
+
+// snapshot returns a copy of the bundle captured so far.
+func (c *executionCapture) snapshot() *ExecutionBundle {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	b := c.bundle
+	b.Contexts = append([]BundleContext(nil), c.bundle.Contexts...)
+	b.Responses = append([]string(nil), c.bundle.Responses...)
+	b.SubCalls = make([]BundleSubCall, len(c.bundle.SubCalls))
+	for i, call := range c.bundle.SubCalls {
+		b.SubCalls[i] = BundleSubCall{
+			Prompts: slices.Clone(call.Prompts),
+			Results: slices.Clone(call.Results),
+			Error:   call.Error,
+		}
+	}
+	return &b
+}
+
+// executionCaptureKey is the context key of the running execution's
+// capture.
+type executionCaptureKey struct{}
+
+// withExecutionCapture returns ctx carrying c, so the REPL's sub-calls are
+// recorded with the execution's model responses.
+func withExecutionCapture(ctx context.Context, c *executionCapture) context.Context {
+	return context.WithValue(ctx, executionCaptureKey{}, c)
+}
+
+// executionCaptureFrom returns the capture in ctx, or nil.
+func executionCaptureFrom(ctx context.Context) *executionCapture {
+	c, _ := ctx.Value(executionCaptureKey{}).(*executionCapture)
+	return c
+}
+
+// executionCaptures keeps the captures of a wrapper's most recent
+// executions. The zero value is ready to use.
+type executionCaptures struct {
+	mu    sync.Mutex
+	byID  map[string]*executionCapture
+	order []string
+}
+
+// start begins capturing the execution id run from prepared with cfg.
+func (e *executionCaptures) start(id string, prepared *PreparedPrompt, cfg RLMConfig) *executionCapture {
+	c := &executionCapture{bundle: ExecutionBundle{
+		Version:      ExecutionBundleVersion,
+		ExecutionID:  id,
+		CreatedAt:    time.Now(),
+		Task:         prepared.OriginalPrompt,
+		Prompt:       prepared.FinalPrompt,
+		SystemPrompt: prepared.SystemPrompt,
+		Config: BundleConfig{
+			MaxIterations:          cfg.MaxIterations,
+			MinIterations:          cfg.MinIterations,
+			MaxTokensPerCall:       cfg.MaxTokensPerCall,
+			ContextWindow:          cfg.ContextWindow,
+			HistoryTokenBudget:     cfg.HistoryTokenBudget,
+			RecentTurns:            cfg.RecentTurns,
+			Timeout:                cfg.Timeout,
+			EnableEarlyTermination: cfg.EnableEarlyTermination,
+			VerifyComputation:      cfg.VerifyComputation,
+		},
+	}}
+	if prepared.Classification != nil {
+		c.bundle.Config.TaskType = prepared.Classification.Type
+	}
+	for _, src := range prepared.sources {
+		c.bundle.Contexts = append(c.bundle.Contexts, BundleContext{
+			Name:     src.Name,
+			Content:  src.Content,
+			Type:     src.Type,
+			Metadata: src.Metadata,
+		})
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.byID == nil {
+		e.byID = make(map[string]*executionCapture)
+	}
+	e.byID[id] = c
+	e.order = append(e.order, id)
+	for len(e.order) > maxExecutionCaptures {
+		delete(e.byID, e.order[0])
+		e.order = e.order[1:]
+	}
+	return c
+}
+
+// get returns the capture of execution id, if it is still kept.
+func (e *executionCaptures) get(id string) (*executionCapture, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	c, ok := e.byID[id]
+	return c, ok
+}
+
+// ExecutionBundle returns the inputs and model responses of the recent
+// execution id, as reported in RLMExecutionResult.ExecutionID.
+func (w *Wrapper) ExecutionBundle(id string) (*ExecutionBundle, error) {
+	c, ok := w.captures.get(id)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrExecutionNotRecorded, id)
+	}
+	return c.snapshot(), nil
+}
+
+// replayClient returns recorded responses in order, ignoring the prompt.
+type replayClient struct {
+	mu        sync.Mutex
+	responses []string
+	next      int
+}
+
+func (c *replayClient) Complete(ctx context.Context, prompt string, maxTokens int) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.next >= len(c.responses) {
+		return "", fmt.Errorf("replay: no recorded response for call %d", c.next+1)
+	}
+	response := c.responses[c.next]
+	c.next++
+	return response, nil
+}
+
+// subCallReplay answers REPL sub-calls with the ones a bundle recorded, in
+// order, instead of calling a model.
+type subCallReplay struct {
+	mu    sync.Mutex
+	calls []BundleSubCall
+	next  int
+}
+
+// subCallReplayKey is the context key of a replay's recorded sub-calls.
+type subCallReplayKey struct{}
+
+// subCallReplayFrom returns the sub-call replay in ctx, or nil.
+func subCallReplayFrom(ctx context.Context) *subCallReplay {
+	r, _ := ctx.Value(subCallReplayKey{}).(*subCallReplay)
+	return r
+}
+
+// take returns the next recorded sub-call's results, which must answer n
+// prompts.
+func (r *subCallReplay) take(n int) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.next >= len(r.calls) {
+		return nil, &CallbackError{Message: fmt.Sprintf("replay: no recorded sub-call %d", r.next+1)}
+	}
+	call := r.calls[r.next]
+	r.next++
+	if call.Error != "" {
+		return nil, &CallbackError{Message: call.Error}
+	}
+	if len(call.Results) != n {
+		return nil, &CallbackError{Message: fmt.Sprintf("replay: sub-call %d recorded %d results, want %d", r.next, len(call.Results), n)}
+	}
+	return slices.Clone(call.Results), nil
+}
+
+// ExportExecutionBundle writes a recent RLM execution to w as a single
+// archive holding its task, externalized contexts, configuration, recorded
+// model and sub-call responses and trace, for ReplayBundle to reproduce
+// elsewhere. With redaction enabled, the bundle's text is redacted as the
+// trace is, and a replay runs over the placeholders.
+func (s *Service) ExportExecutionBundle(executionID string, w io.Writer) error {
+	s.mu.RLock()
+	wrapper, tracer, redactor := s.wrapper, s.tracer, s.redactor
+	s.mu.RUnlock()
+	if wrapper == nil {
+		return fmt.Errorf("wrapper %w", ErrNotConfigured)
+	}
+
+	bundle, err := wrapper.ExecutionBundle(executionID)
+	if err != nil {
+		return err
+	}
+	bundle.redact(redactor)
+	if tracer != nil {
+		if bundle.Trace, err = executionEvents(tracer, bundle); err != nil {
+			return fmt.Errorf("export bundle: read trace: %w", err)
+		}
+	}
+
+	_, err = bundle.WriteTo(w)
+	return err
+}
+
+// executionEvents returns the trace events recorded for the bundle's
+// execution: its start event and the steps under it.
+func executionEvents(tracer traceRecorder, bundle *ExecutionBundle) ([]TraceEvent, error) {
+	id := bundle.ExecutionID
+	backend, ok := tracer.(TraceBackend)
+	if !ok {
+		events, err := tracer.GetEvents(0)
+		if err != nil {
+			return nil, err
+		}
+		var matched []TraceEvent
+		for _, event := range events {
+			if event.ID == id || event.ParentID == id {
+				matched = append(matched, fromRLMTraceEvent(event))
+			}
+		}
+		return matched, nil
+	}
+
+	var events []TraceEvent
+	if root, err := backend.GetEvent(id); err == nil && root != nil {
+		events = append(events, fromRLMTraceEvent(*root))
+	}
+	// Each iteration records at most a response and a code step
+	steps, err := backend.Query(TraceQuery{ParentID: id, Limit: 2*bundle.Config.MaxIterations + 1})
+	if err != nil {
+		return nil, err
+	}
+	for _, step := range steps {
+		events = append(events, fromRLMTraceEvent(step))
+	}
+	return events, nil
+}
+
+// ReplayBundle re-runs the execution in a bundle written by
+// ExportExecutionBundle, answering each model call and each llm_call() or
+// llm_batch() from the REPL with the recorded response, so the run is
+// reproduced deterministically. The contexts are reloaded into the
+// service's REPL.
+func (s *Service) ReplayBundle(r io.Reader) (*ExecutionResult, error) {
+	bundle, err := ReadExecutionBundle(r)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	replMgr := s.replMgr
+	s.mu.RUnlock()
+	if replMgr == nil {
+		return nil, fmt.Errorf("REPL manager %w", ErrNotConfigured)
+	}
+
+	ctx := context.WithValue(context.Background(), subCallReplayKey{}, &subCallReplay{calls: bundle.SubCalls})
+	if bundle.Config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, bundle.Config.Timeout)
+		defer cancel()
+	}
+
+	loader := NewContextLoader(replMgr)
+	sources := make([]ContextSource, len(bundle.Contexts))
+	for i, c := range bundle.Contexts {
+		sources[i] = ContextSource{Name: c.Name, Content: c.Content, Type: c.Type, Metadata: c.Metadata}
+	}
+	prepared := &PreparedPrompt{
+		OriginalPrompt: bundle.Task,
+		FinalPrompt:    bundle.Prompt,
+		SystemPrompt:   bundle.SystemPrompt,
+		Mode:           ModeRLM,
+	}
+	if len(sources) > 0 {
+		loaded, err := loader.Load(ctx, sources)
+		if err != nil {
+			return nil, fmt.Errorf("replay bundle: load contexts: %w", err)
+		}
+		prepared.LoadedContext = loaded
+	}
+	if bundle.Config.TaskType != "" {
+		prepared.Classification = &Classification{Type: bundle.Config.TaskType}
+	}
+
+	w := &Wrapper{
+		replMgr:       replMgr,
+		contextLoader: loader,
+		client:        &replayClient{responses: bundle.Responses},
+	}
+	rlmResult, err := w.ExecuteRLMWithConfig(ctx, prepared, bundle.Config.rlmConfig())
+	if err != nil {
+		return nil, fmt.Errorf("replay bundle: %w", err)
+	}
+
+	result := &ExecutionResult{
+		Task:            bundle.Task,
+		Response:        rlmResult.FinalOutput,
+		TotalTokens:     rlmResult.TotalTokens,
+		InputTokens:     rlmResult.InputTokens,
+		OutputTokens:    rlmResult.OutputTokens,
+		EstimatedTokens: rlmResult.EstimatedTokens,
+		StartTime:       rlmResult.StartTime,
+		Duration:        rlmResult.Duration,
+		Error:           rlmResult.Error,
+		Cost:            rlmResult.TotalCost,
+		Partial:         rlmResult.Partial,
+	}
+	if result.Task == "" {
+		result.Task = bundle.Prompt
+	}
+	return result, nil
+}
@@ -0,0 +1,173 @@
+package rlm
+
+import (
+	"bytes"
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/rand/recurse/internal/rlm/redact"
+	"github.com/rand/recurse/internal/rlm/repl"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_ExportAndReplayBundle(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	client := &wrapperMockLLMClient{
+		responses: []string{
+			"```python\nvalues = [int(v) for v in numbers.split()]\nprint(len(values))\n```",
+			"```python\nFINAL(str(sum(values)))\n```",
+		},
+	}
+	cfg := DefaultServiceConfig()
+	cfg.Lifecycle.IdleInterval = 0
+	svc, err := NewService(client, cfg)
+	require.NoError(t, err)
+	defer svc.Stop()
+
+	replMgr, err := repl.NewManager(repl.Options{})
+	require.NoError(t, err)
+	require.NoError(t, replMgr.Start(ctx))
+	defer replMgr.Stop()
+	svc.SetREPLManager(replMgr)
+
+	w := svc.Wrapper()
+	w.SetLLMClient(client)
+	contexts := []ContextSource{{Name: "numbers", Content: "3 4 5 30", Type: ContextTypeCustom}}
+	prepared, err := w.prepareRLMMode(ctx, "Sum the numbers", contexts, 10, nil)
+	require.NoError(t, err)
+	require.Equal(t, ModeRLM, prepared.Mode)
+
+	original, err := w.ExecuteRLMWithConfig(ctx, prepared, RLMConfig{MaxIterations: 5, MaxTokensPerCall: 1024, Timeout: 20 * time.Second})
+	require.NoError(t, err)
+	require.Equal(t, "42", original.FinalOutput)
+	require.NotEmpty(t, original.ExecutionID)
+
+	var archive bytes.Buffer
+	require.NoError(t, svc.ExportExecutionBundle(original.ExecutionID, &archive))
+
+	bundle, err := ReadExecutionBundle(bytes.NewReader(archive.Bytes()))
+	require.NoError(t, err)
+	assert.Equal(t, original.ExecutionID, bundle.ExecutionID)
+	assert.Equal(t, "Sum the numbers", bundle.Task)
+	assert.Equal(t, "42", bundle.Answer)
+	assert.Equal(t, client.responses, bundle.Responses)
+	require.Len(t, bundle.Contexts, 1)
+	assert.Equal(t, "3 4 5 30", bundle.Contexts[0].Content)
+	assert.Equal(t, 5, bundle.Config.MaxIterations)
+	replay, err := ReplayExecution(bundle.Trace)
+	require.NoError(t, err)
+	assert.Equal(t, "42", replay.FinalAnswer)
+
+	// Replaying in a fresh REPL reloads the contexts and makes no live calls
+	_, err = replMgr.Execute(ctx, "del numbers, values")
+	require.NoError(t, err)
+	calls := len(client.calls)
+	result, err := svc.ReplayBundle(bytes.NewReader(archive.Bytes()))
+	require.NoError(t, err)
+	assert.Equal(t, "42", result.Response)
+	assert.Equal(t, "Sum the numbers", result.Task)
+	assert.Empty(t, result.Error)
+	assert.Equal(t, calls, len(client.calls))
+}
+
+func TestService_ExportExecutionBundle_Unknown(t *testing.T) {
+	cfg := DefaultServiceConfig()
+	cfg.Lifecycle.IdleInterval = 0
+	svc, err := NewService(&mockLLMClient{}, cfg)
+	require.NoError(t, err)
+	defer svc.Stop()
+
+	err = svc.ExportExecutionBundle("rlm-exec-missing", &bytes.Buffer{})
+	assert.ErrorIs(t, err, ErrExecutionNotRecorded)
+
+	_, err = svc.ReplayBundle(bytes.NewReader([]byte("not a zip")))
+	assert.Error(t, err)
+}
+
+// runBundledExecution runs task over contexts on a service configured by
+// cfg, whose model answers with responses, and returns the service and the
+// execution's ID.
+func runBundledExecution(t *testing.T, cfg ServiceConfig, client *wrapperMockLLMClient, task string, contexts []ContextSource) (*Service, string) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	cfg.Lifecycle.IdleInterval = 0
+	svc, err := NewService(client, cfg)
+	require.NoError(t, err)
+	t.Cleanup(func() { svc.Stop() })
+
+	replMgr, err := repl.NewManager(repl.Options{})
+	require.NoError(t, err)
+	require.NoError(t, replMgr.Start(ctx))
+	t.Cleanup(func() { replMgr.Stop() })
+	svc.SetREPLManager(replMgr)
+
+	w := svc.Wrapper()
+	w.SetLLMClient(client)
+	prepared, err := w.prepareRLMMode(ctx, task, contexts, 10, nil)
+	require.NoError(t, err)
+	result, err := w.ExecuteRLMWithConfig(ctx, prepared, RLMConfig{MaxIterations: 5, MaxTokensPerCall: 1024, Timeout: 20 * time.Second})
+	require.NoError(t, err)
+	require.Empty(t, result.Error)
+	require.NotEmpty(t, result.ExecutionID)
+	return svc, result.ExecutionID
+}
+
+func TestService_ReplayBundle_ServesRecordedSubCalls(t *testing.T) {
+	client := &wrapperMockLLMClient{
+		responses: []string{
+			"```python\ncolor = llm_call('Name the color', notes)\nboth = llm_batch(['First word', 'Last word'], [notes, notes])\nprint(color)\n```",
+			"blue",
+			"The",
+			"sky",
+			"```python\nFINAL(color + ' ' + '/'.join(both))\n```",
+		},
+	}
+	contexts := []ContextSource{{Name: "notes", Content: "The sky is blue", Type: ContextTypeCustom}}
+	svc, id := runBundledExecution(t, DefaultServiceConfig(), client, "What color is the sky?", contexts)
+
+	var archive bytes.Buffer
+	require.NoError(t, svc.ExportExecutionBundle(id, &archive))
+	bundle, err := ReadExecutionBundle(bytes.NewReader(archive.Bytes()))
+	require.NoError(t, err)
+	assert.Equal(t, "blue The/sky", bundle.Answer)
+	assert.Equal(t, []string{client.responses[0], client.responses[4]}, bundle.Responses)
+	require.Len(t, bundle.SubCalls, 2)
+	assert.Equal(t, BundleSubCall{Prompts: []string{"Name the color"}, Results: []string{"blue"}}, bundle.SubCalls[0])
+	assert.Equal(t, []string{"The", "sky"}, bundle.SubCalls[1].Results)
+
+	// The replay's sub-calls get the recorded answers, not live ones
+	calls := len(client.calls)
+	result, err := svc.ReplayBundle(bytes.NewReader(archive.Bytes()))
+	require.NoError(t, err)
+	assert.Empty(t, result.Error)
+	assert.Equal(t, "blue The/sky", result.Response)
+	assert.Equal(t, calls, len(client.calls))
+}
+
+func TestService_ExportExecutionBundle_Redacted(t *testing.T) {
+	client := &wrapperMockLLMClient{
+		responses: []string{"```python\nFINAL(str(len(config)))\n```"},
+	}
+	cfg := DefaultServiceConfig()
+	cfg.Redaction = redact.DefaultConfig()
+	contexts := []ContextSource{{Name: "config", Content: "Authorization: Bearer " + testBearerSecret, Type: ContextTypeCustom}}
+	svc, id := runBundledExecution(t, cfg, client, "How long is the config?", contexts)
+
+	var archive bytes.Buffer
+	require.NoError(t, svc.ExportExecutionBundle(id, &archive))
+	bundle, err := ReadExecutionBundle(bytes.NewReader(archive.Bytes()))
+	require.NoError(t, err)
+	require.Len(t, bundle.Contexts, 1)
+	assert.NotContains(t, bundle.Contexts[0].Content, testBearerSecret)
+	assert.Contains(t, bundle.Contexts[0].Content, "Authorization: Bearer")
+
+	// The execution itself ran over the real value
+	assert.Equal(t, strconv.Itoa(len(contexts[0].Content)), bundle.Answer)
+}
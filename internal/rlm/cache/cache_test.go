@@ -8,6 +8,8 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/rand/recurse/internal/rlm/meta"
 )
 
 func TestEstimateTokens(t *testing.T) {
@@ -392,6 +394,23 @@ func TestCacheAwareClient_CachingDisabled(t *testing.T) {
 	assert.Equal(t, int64(1), atomic.LoadInt64(&mock.completeCalled))
 }
 
+// reportingClient reports fixed capabilities.
+type reportingClient struct {
+	*mockLLMClient
+	caps meta.Capabilities
+}
+
+func (c *reportingClient) Capabilities() meta.Capabilities { return c.caps }
+
+func TestCacheAwareClient_Capabilities(t *testing.T) {
+	inner := &reportingClient{
+		mockLLMClient: newMockLLMClient(),
+		caps:          meta.Capabilities{ContextWindow: 200000, MaxOutputTokens: 8192, Logprobs: true},
+	}
+	client := NewCacheAwareClient(inner)
+	assert.Equal(t, meta.Capabilities{ContextWindow: 200000, MaxOutputTokens: 8192}, meta.CapabilitiesOf(client))
+}
+
 func TestAnalytics_RecordCall(t *testing.T) {
 	analytics := NewAnalytics()
 
@@ -5,6 +5,8 @@ import (
 	"sync"
 	"sync/atomic"
 	"time"
+
+	"github.com/rand/recurse/internal/rlm/meta"
 )
 
 // LLMClient is the interface for the underlying LLM client.
@@ -95,6 +97,12 @@ func (c *CacheAwareClient) Complete(ctx context.Context, prompt string, maxToken
 	return c.inner.Complete(ctx, prompt, maxTokens)
 }
 
+// Capabilities implements meta.CapabilityReporter with the inner client's
+// limits.
+func (c *CacheAwareClient) Capabilities() meta.Capabilities {
+	return meta.CapabilitiesOf(c.inner).CompletionOnly()
+}
+
 // CompleteWithSession sends a prompt using session-based caching.
 func (c *CacheAwareClient) CompleteWithSession(
 	ctx context.Context,
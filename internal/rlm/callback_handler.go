@@ -2,8 +2,11 @@ package rlm
 
 import (
 	"context"
+	"fmt"
+	"strings"
 
 	"github.com/rand/recurse/internal/rlm/repl"
+	"github.com/rand/recurse/internal/rlm/verify"
 )
 
 // REPLCallbackHandler implements repl.CallbackHandler using SubCallRouter.
@@ -35,6 +38,12 @@ func (h *REPLCallbackHandler) WithContext(ctx context.Context) *REPLCallbackHand
 	}
 }
 
+// ForContext implements repl.ContextCallbackHandler, so sub-calls run with
+// the context of the REPL execution that made them.
+func (h *REPLCallbackHandler) ForContext(ctx context.Context) repl.CallbackHandler {
+	return h.WithContext(ctx)
+}
+
 // WithDepth returns a copy with the given recursion depth.
 func (h *REPLCallbackHandler) WithDepth(depth int) *REPLCallbackHandler {
 	return &REPLCallbackHandler{
@@ -57,29 +66,57 @@ func (h *REPLCallbackHandler) WithBudget(budget int) *REPLCallbackHandler {
 
 // HandleLLMCall handles a single LLM call from Python.
 func (h *REPLCallbackHandler) HandleLLMCall(prompt, context, model string) (string, error) {
-	if h.router == nil {
-		return "", nil
-	}
+	return h.HandleLLMCallWithOptions(prompt, context, model, repl.LLMCallOptions{})
+}
 
-	resp := h.router.Call(h.ctx, SubCallRequest{
-		Prompt:  prompt,
-		Context: context,
-		Model:   model,
-		Depth:   h.depth,
-		Budget:  h.budget,
-	})
+// HandleLLMCallWithOptions handles a single LLM call from Python with its
+// optional arguments.
+func (h *REPLCallbackHandler) HandleLLMCallWithOptions(prompt, context, model string, opts repl.LLMCallOptions) (string, error) {
+	results, err := h.recorded([]string{prompt}, func() ([]string, error) {
+		if h.router == nil {
+			return []string{""}, nil
+		}
 
-	if resp.Error != "" {
-		return "", &CallbackError{Message: resp.Error}
-	}
+		resp := h.router.Call(h.ctx, SubCallRequest{
+			Prompt:        prompt,
+			Context:       context,
+			Model:         model,
+			Depth:         h.depth,
+			Budget:        h.budget,
+			Verify:        opts.Verify,
+			VerifyRetries: opts.VerifyRetries,
+			ForSynthesis:  opts.Synthesis,
+		})
 
-	return resp.Response, nil
+		if resp.Error != "" {
+			return nil, &CallbackError{Message: resp.Error}
+		}
+
+		return []string{responseText(resp)}, nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return results[0], nil
 }
 
 // HandleLLMBatch handles a batch of LLM calls from Python.
 func (h *REPLCallbackHandler) HandleLLMBatch(prompts, contexts []string, model string) ([]string, error) {
+	return h.HandleLLMBatchWithOptions(prompts, contexts, model, repl.LLMCallOptions{})
+}
+
+// HandleLLMBatchWithOptions handles a batch of LLM calls from Python with
+// their optional arguments.
+func (h *REPLCallbackHandler) HandleLLMBatchWithOptions(prompts, contexts []string, model string, opts repl.LLMCallOptions) ([]string, error) {
+	return h.recorded(prompts, func() ([]string, error) {
+		return h.batch(prompts, contexts, model, opts), nil
+	})
+}
+
+// batch makes the calls of a batch through the router.
+func (h *REPLCallbackHandler) batch(prompts, contexts []string, model string, opts repl.LLMCallOptions) []string {
 	if h.router == nil {
-		return make([]string, len(prompts)), nil
+		return make([]string, len(prompts))
 	}
 
 	// Build batch request
@@ -90,11 +127,14 @@ func (h *REPLCallbackHandler) HandleLLMBatch(prompts, contexts []string, model s
 			ctx = contexts[i]
 		}
 		requests[i] = SubCallRequest{
-			Prompt:  prompts[i],
-			Context: ctx,
-			Model:   model,
-			Depth:   h.depth,
-			Budget:  h.budget / len(prompts), // Divide budget among batch
+			Prompt:        prompts[i],
+			Context:       ctx,
+			Model:         model,
+			Depth:         h.depth,
+			Budget:        h.budget / len(prompts), // Divide budget among batch
+			Verify:        opts.Verify,
+			VerifyRetries: opts.VerifyRetries,
+			ForSynthesis:  opts.Synthesis,
 		}
 	}
 
@@ -106,11 +146,49 @@ func (h *REPLCallbackHandler) HandleLLMBatch(prompts, contexts []string, model s
 			// Include error in result rather than failing entire batch
 			results[i] = "[ERROR: " + resp.Error + "]"
 		} else {
-			results[i] = resp.Response
+			results[i] = responseText(resp)
 		}
 	}
 
-	return results, nil
+	return results
+}
+
+// recorded makes a sub-call for prompts with call, recording what it
+// returns with the execution's capture, or answers it from the recording
+// when the execution is a bundle replay.
+func (h *REPLCallbackHandler) recorded(prompts []string, call func() ([]string, error)) ([]string, error) {
+	if replay := subCallReplayFrom(h.ctx); replay != nil {
+		return replay.take(len(prompts))
+	}
+	results, err := call()
+	if capture := executionCaptureFrom(h.ctx); capture != nil {
+		capture.recordSubCall(prompts, results, err)
+	}
+	return results, err
+}
+
+// responseText is what Python receives for resp: the response, followed by
+// a report when its code violated its constraints or its result check
+// flagged it, so the model can act on the failure.
+func responseText(resp *SubCallResponse) string {
+	text := resp.Response
+	if v := resp.Verification; v != nil && v.Status == verify.StatusViolated {
+		text += "\n\n[VERIFICATION FAILED]\n" + verificationReport(v)
+	}
+	if check := resp.ResultCheck; check != nil && check.Flagged {
+		text += "\n\n[RESULT CHECK FAILED]\n" + resultCheckReport(check)
+	}
+	return text
+}
+
+// resultCheckReport describes a flagged result check for the model.
+func resultCheckReport(check *ResultVerification) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Risk of being wrong: %.2f\n", check.Risk)
+	for _, d := range check.Discrepancies {
+		fmt.Fprintf(&sb, "- %s\n", d)
+	}
+	return sb.String()
 }
 
 // CallbackError represents an error from a callback.
@@ -123,4 +201,4 @@ func (e *CallbackError) Error() string {
 }
 
 // Verify interface compliance
-var _ repl.CallbackHandler = (*REPLCallbackHandler)(nil)
+var _ repl.OptionsCallbackHandler = (*REPLCallbackHandler)(nil)
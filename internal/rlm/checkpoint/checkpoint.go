@@ -9,6 +9,8 @@ import (
 	"path/filepath"
 	"sync"
 	"time"
+
+	"github.com/rand/recurse/internal/rlm/redact"
 )
 
 // Config configures checkpoint behavior.
@@ -54,6 +56,9 @@ type Checkpoint struct {
 
 	// ServiceStats contains service statistics.
 	ServiceStats *ServiceStats `json:"service_stats,omitempty"`
+
+	// Interrupted lists executions cut short by shutdown.
+	Interrupted []InterruptedExecution `json:"interrupted,omitempty"`
 }
 
 // TaskState contains task memory information.
@@ -83,6 +88,23 @@ type RLMState struct {
 	Mode string `json:"mode"`
 }
 
+// InterruptedExecution records an execution that was still running when
+// the service shut down.
+type InterruptedExecution struct {
+	// Task is the task being executed.
+	Task string `json:"task"`
+
+	StartedAt     time.Time `json:"started_at"`
+	InterruptedAt time.Time `json:"interrupted_at"`
+
+	// PartialResponse is the best answer produced before the execution
+	// was cancelled, empty if it produced none or never returned.
+	PartialResponse string `json:"partial_response,omitempty"`
+
+	// Tokens is what the execution spent before it was cancelled.
+	Tokens int `json:"tokens,omitempty"`
+}
+
 // ServiceStats contains service-level statistics.
 type ServiceStats struct {
 	TotalExecutions int           `json:"total_executions"`
@@ -101,6 +123,9 @@ type Manager struct {
 	// Current checkpoint data
 	current *Checkpoint
 
+	// Redacts secrets from free text before it is written
+	redactor *redact.Redactor
+
 	// Background checkpoint goroutine
 	stopCh chan struct{}
 	wg     sync.WaitGroup
@@ -149,6 +174,15 @@ func (m *Manager) Stop() error {
 	return nil
 }
 
+// SetRedactor sets the redactor applied to free text, such as the last
+// task, when checkpoints are written. The in-memory checkpoint keeps the
+// original text.
+func (m *Manager) SetRedactor(r *redact.Redactor) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.redactor = r
+}
+
 // Update updates the current checkpoint data.
 func (m *Manager) Update(cp *Checkpoint) {
 	m.mu.Lock()
@@ -189,6 +223,17 @@ func (m *Manager) UpdateServiceStats(stats *ServiceStats) {
 	m.current.CreatedAt = time.Now()
 }
 
+// RecordInterrupted adds an execution cut short by shutdown.
+func (m *Manager) RecordInterrupted(exec InterruptedExecution) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.current == nil {
+		m.current = &Checkpoint{Version: 1}
+	}
+	m.current.Interrupted = append(m.current.Interrupted, exec)
+	m.current.CreatedAt = time.Now()
+}
+
 // SetSessionID sets the session ID for checkpoints.
 func (m *Manager) SetSessionID(sessionID string) {
 	m.mu.Lock()
@@ -203,6 +248,7 @@ func (m *Manager) SetSessionID(sessionID string) {
 func (m *Manager) Save() error {
 	m.mu.RLock()
 	cp := m.current
+	redactor := m.redactor
 	m.mu.RUnlock()
 
 	if cp == nil {
@@ -212,7 +258,7 @@ func (m *Manager) Save() error {
 	cp.CreatedAt = time.Now()
 	cp.Version = 1
 
-	return m.writeCheckpoint(cp)
+	return m.writeCheckpoint(cp.redacted(redactor))
 }
 
 // Load reads the most recent checkpoint from disk.
@@ -293,6 +339,29 @@ func (m *Manager) writeCheckpoint(cp *Checkpoint) error {
 	return nil
 }
 
+// redacted returns a copy of cp with secrets redacted from its free text,
+// or cp itself when there is no redactor.
+func (cp *Checkpoint) redacted(r *redact.Redactor) *Checkpoint {
+	if r == nil || (cp.RLMState == nil && len(cp.Interrupted) == 0) {
+		return cp
+	}
+	out := *cp
+	if cp.RLMState != nil {
+		state := *cp.RLMState
+		state.LastTask = r.Redact(state.LastTask)
+		out.RLMState = &state
+	}
+	if len(cp.Interrupted) > 0 {
+		out.Interrupted = make([]InterruptedExecution, len(cp.Interrupted))
+		for i, exec := range cp.Interrupted {
+			exec.Task = r.Redact(exec.Task)
+			exec.PartialResponse = r.Redact(exec.PartialResponse)
+			out.Interrupted[i] = exec
+		}
+	}
+	return &out
+}
+
 func (m *Manager) checkpointPath() string {
 	return filepath.Join(m.config.Path, "session_checkpoint.json")
 }
@@ -331,6 +400,10 @@ func (cp *Checkpoint) Summary() string {
 		summary += fmt.Sprintf(" | %d executions", cp.ServiceStats.TotalExecutions)
 	}
 
+	if n := len(cp.Interrupted); n > 0 {
+		summary += fmt.Sprintf(" | %d interrupted", n)
+	}
+
 	return summary
 }
 
@@ -349,5 +422,5 @@ func (cp *Checkpoint) IsRecoverable() bool {
 		return true
 	}
 
-	return false
+	return len(cp.Interrupted) > 0
 }
@@ -2,6 +2,7 @@ package rlm
 
 import (
 	"regexp"
+	"slices"
 	"strings"
 )
 
@@ -265,9 +266,10 @@ func (c *TaskClassifier) Classify(query string, contexts []ContextSource) Classi
 		}
 	}
 
-	// 3. Context analysis (if available)
-	if len(contexts) > 0 {
-		ctxSignals := c.analyzeContext(contexts[0].Content)
+	// 3. Context analysis of the first text context (if available); an
+	// image's surrogate describes data rather than holding it
+	if i := slices.IndexFunc(contexts, func(c ContextSource) bool { return c.Type != ContextTypeImage }); i >= 0 {
+		ctxSignals := c.analyzeContext(contexts[i].Content)
 		if ctxSignals.numericDensity > 0.005 {
 			scores[TaskTypeComputational] += 0.3
 			signals = append(signals, "context:high_numeric_density")
@@ -0,0 +1,279 @@
+package rlm
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// DefaultClassificationCacheSize bounds a ClassificationCache when its
+// config leaves MaxEntries unset.
+const DefaultClassificationCacheSize = 10000
+
+// classificationCacheVersion is the format written by Save.
+const classificationCacheVersion = 2
+
+// ClassificationCache remembers the final classification of each prompt
+// and its contexts, including one resolved by the LLM fallback, so a
+// prompt seen before with the same contexts skips both the rules and the
+// fallback call. Prompts are matched after normalizing case and
+// whitespace, contexts by a digest of their types and contents. A cache
+// can be shared by several Wrappers and saved for seeding later runs. It
+// is safe for concurrent use, and a nil cache never hits.
+type ClassificationCache struct {
+	mu         sync.Mutex
+	entries    map[string]Classification
+	order      []string
+	maxEntries int
+
+	hits           int64
+	misses         int64
+	evictions      int64
+	fallbacksSaved int64
+}
+
+// ClassificationCacheConfig configures a ClassificationCache.
+type ClassificationCacheConfig struct {
+	// MaxEntries bounds the cache; the oldest entries are evicted first.
+	// Zero uses DefaultClassificationCacheSize.
+	MaxEntries int
+}
+
+// ClassificationCacheStats reports a ClassificationCache's activity.
+type ClassificationCacheStats struct {
+	Entries   int   `json:"entries"`
+	Hits      int64 `json:"hits"`
+	Misses    int64 `json:"misses"`
+	Evictions int64 `json:"evictions"`
+
+	// FallbacksSaved counts hits on classifications that came from the
+	// LLM fallback, each an LLM call avoided.
+	FallbacksSaved int64 `json:"fallbacks_saved"`
+}
+
+// HitRate returns the fraction of lookups that hit.
+func (s ClassificationCacheStats) HitRate() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
+}
+
+// cachedClassificationEntry is the persisted form of a cache entry.
+type cachedClassificationEntry struct {
+	Prompt     string   `json:"prompt"`
+	Contexts   string   `json:"contexts,omitempty"`
+	Type       TaskType `json:"type"`
+	Confidence float64  `json:"confidence"`
+	Signals    []string `json:"signals,omitempty"`
+}
+
+// classificationCacheFile is the document written by Save.
+type classificationCacheFile struct {
+	Version int                         `json:"version"`
+	Entries []cachedClassificationEntry `json:"entries"`
+}
+
+// NewClassificationCache creates an empty classification cache.
+func NewClassificationCache(cfg ClassificationCacheConfig) *ClassificationCache {
+	if cfg.MaxEntries <= 0 {
+		cfg.MaxEntries = DefaultClassificationCacheSize
+	}
+	return &ClassificationCache{
+		entries:    make(map[string]Classification),
+		maxEntries: cfg.MaxEntries,
+	}
+}
+
+// Get returns the cached classification of prompt with contexts.
+func (c *ClassificationCache) Get(prompt string, contexts []ContextSource) (Classification, bool) {
+	if c == nil {
+		return Classification{}, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cached, ok := c.entries[classificationCacheKey(prompt, contextsDigest(contexts))]
+	if !ok {
+		c.misses++
+		return Classification{}, false
+	}
+	c.hits++
+	if slices.Contains(cached.Signals, "source:llm_fallback") {
+		c.fallbacksSaved++
+	}
+
+	// Callers may append to the signals; keep the entry's own
+	cached.Signals = append(slices.Clone(cached.Signals), "source:classification_cache")
+	return cached, true
+}
+
+// Put caches the classification of prompt with contexts, replacing any
+// earlier one.
+func (c *ClassificationCache) Put(prompt string, contexts []ContextSource, class Classification) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.putLocked(classificationCacheKey(prompt, contextsDigest(contexts)), class)
+}
+
+// putLocked stores an entry under key, evicting the oldest entries past
+// the bound. Must be called with the lock held.
+func (c *ClassificationCache) putLocked(key string, class Classification) {
+	class.Signals = slices.Clone(class.Signals)
+	if _, ok := c.entries[key]; !ok {
+		c.order = append(c.order, key)
+	}
+	c.entries[key] = class
+
+	for len(c.order) > c.maxEntries {
+		delete(c.entries, c.order[0])
+		c.order = c.order[1:]
+		c.evictions++
+	}
+}
+
+// Len returns the number of cached classifications.
+func (c *ClassificationCache) Len() int {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+// Stats returns the cache's activity since it was created.
+func (c *ClassificationCache) Stats() ClassificationCacheStats {
+	if c == nil {
+		return ClassificationCacheStats{}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return ClassificationCacheStats{
+		Entries:        len(c.entries),
+		Hits:           c.hits,
+		Misses:         c.misses,
+		Evictions:      c.evictions,
+		FallbacksSaved: c.fallbacksSaved,
+	}
+}
+
+// Save writes the cached classifications as JSON, oldest first. A nil
+// cache writes an empty one.
+func (c *ClassificationCache) Save(w io.Writer) error {
+	file := classificationCacheFile{Version: classificationCacheVersion}
+	if c != nil {
+		file.Entries = c.snapshot()
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(file); err != nil {
+		return fmt.Errorf("write classification cache: %w", err)
+	}
+	return nil
+}
+
+// snapshot returns the cached entries in their persisted form, oldest
+// first.
+func (c *ClassificationCache) snapshot() []cachedClassificationEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entries := make([]cachedClassificationEntry, 0, len(c.order))
+	for _, key := range c.order {
+		class := c.entries[key]
+		prompt, contexts, _ := strings.Cut(key, "\x00")
+		entries = append(entries, cachedClassificationEntry{
+			Prompt:     prompt,
+			Contexts:   contexts,
+			Type:       class.Type,
+			Confidence: class.Confidence,
+			Signals:    class.Signals,
+		})
+	}
+	return entries
+}
+
+// Load seeds the cache with classifications written by Save, returning
+// how many were read. Loaded entries replace cached ones for the same
+// prompt and contexts; past the bound, the oldest are evicted.
+func (c *ClassificationCache) Load(r io.Reader) (int, error) {
+	if c == nil {
+		return 0, errors.New("read classification cache: nil cache")
+	}
+	var file classificationCacheFile
+	if err := json.NewDecoder(r).Decode(&file); err != nil {
+		return 0, fmt.Errorf("read classification cache: %w", err)
+	}
+	if file.Version != classificationCacheVersion {
+		return 0, fmt.Errorf("read classification cache: unsupported version %d", file.Version)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, entry := range file.Entries {
+		c.putLocked(classificationCacheKey(entry.Prompt, entry.Contexts), Classification{
+			Type:       entry.Type,
+			Confidence: entry.Confidence,
+			Signals:    entry.Signals,
+		})
+	}
+	return len(file.Entries), nil
+}
+
+// SaveFile writes the cache to path with Save.
+func (c *ClassificationCache) SaveFile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("write classification cache: %w", err)
+	}
+	if err := c.Save(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// LoadFile seeds the cache from a file written by SaveFile. A missing file
+// seeds nothing, so the first run of a suite needs no special casing.
+func (c *ClassificationCache) LoadFile(path string) (int, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("read classification cache: %w", err)
+	}
+	defer f.Close()
+	return c.Load(f)
+}
+
+// classificationCacheKey normalizes a prompt so prompts differing only in
+// case or whitespace share an entry, and pairs it with the digest of its
+// contexts.
+func classificationCacheKey(prompt, contexts string) string {
+	return strings.Join(strings.Fields(strings.ToLower(prompt)), " ") + "\x00" + contexts
+}
+
+// contextsDigest hashes the types and contents of contexts, which is what
+// the classifier reads. It returns "" for no contexts.
+func contextsDigest(contexts []ContextSource) string {
+	if len(contexts) == 0 {
+		return ""
+	}
+	h := sha256.New()
+	for _, src := range contexts {
+		fmt.Fprintf(h, "%s\x00%d\x00%s\x00", src.Type, len(src.Content), src.Content)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
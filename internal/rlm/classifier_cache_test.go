@@ -0,0 +1,180 @@
+package rlm
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/rand/recurse/internal/rlm/repl"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// cacheTestContexts are the contexts classifyWithCache prepares with.
+var cacheTestContexts = []ContextSource{{Type: ContextTypeCustom, Content: "alpha beta gamma"}}
+
+// classifyWithCache prepares prompt with a context on a Wrapper using cache.
+func classifyWithCache(t *testing.T, w *Wrapper, prompt string) *Classification {
+	t.Helper()
+	prepared, err := w.PrepareContext(context.Background(), prompt, cacheTestContexts)
+	require.NoError(t, err)
+	require.NotNil(t, prepared.Classification)
+	return prepared.Classification
+}
+
+func TestClassificationCache_SeededCacheServesMatchingPrompts(t *testing.T) {
+	// A prior run classified the prompt differently from today's rules
+	prior := NewClassificationCache(ClassificationCacheConfig{})
+	prior.Put("How many rows are there?", cacheTestContexts, Classification{
+		Type:       TaskTypeRetrieval,
+		Confidence: 0.95,
+		Signals:    []string{"keyword:seeded"},
+	})
+	var saved bytes.Buffer
+	require.NoError(t, prior.Save(&saved))
+
+	cache := NewClassificationCache(ClassificationCacheConfig{})
+	n, err := cache.Load(&saved)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	cfg := DefaultWrapperConfig()
+	cfg.ClassificationCache = cache
+	w := NewWrapper(&Service{}, cfg)
+
+	class := classifyWithCache(t, w, "  how MANY rows\nare there? ")
+	assert.Equal(t, TaskTypeRetrieval, class.Type, "served from the cache, not the rules")
+	assert.Equal(t, 0.95, class.Confidence)
+	assert.Contains(t, class.Signals, "keyword:seeded")
+	assert.Contains(t, class.Signals, "source:classification_cache")
+
+	stats := cache.Stats()
+	assert.Equal(t, int64(1), stats.Hits)
+	assert.Zero(t, stats.Misses)
+	assert.Equal(t, 1, stats.Entries)
+}
+
+func TestClassificationCache_NovelPromptsPopulateCache(t *testing.T) {
+	cache := NewClassificationCache(ClassificationCacheConfig{})
+	cfg := DefaultWrapperConfig()
+	cfg.ClassificationCache = cache
+	w := NewWrapper(&Service{}, cfg)
+
+	class := classifyWithCache(t, w, "How many rows are there?")
+	assert.Equal(t, TaskTypeComputational, class.Type)
+	assert.NotContains(t, class.Signals, "source:classification_cache")
+	assert.Equal(t, 1, cache.Len())
+
+	cached, ok := cache.Get("how many rows are there?", cacheTestContexts)
+	require.True(t, ok)
+	assert.Equal(t, class.Type, cached.Type)
+
+	stats := cache.Stats()
+	assert.Equal(t, int64(1), stats.Misses)
+	assert.Equal(t, int64(1), stats.Hits)
+	assert.InDelta(t, 0.5, stats.HitRate(), 1e-9)
+}
+
+func TestClassificationCache_SharedAcrossWrappersSkipsLLMFallback(t *testing.T) {
+	replMgr, err := repl.NewManager(repl.Options{})
+	require.NoError(t, err)
+
+	calls := 0
+	client := &countingMockClient{
+		inner: &classifierMockLLMClient{
+			response: `{"task_type": "computational", "confidence": 0.9, "reasoning": "aggregates totals"}`,
+		},
+		callCount: &calls,
+	}
+	cache := NewClassificationCache(ClassificationCacheConfig{})
+	newWrapper := func() *Wrapper {
+		cfg := DefaultWrapperConfig()
+		cfg.ClassificationCache = cache
+		w := NewWrapper(&Service{}, cfg)
+		w.replMgr = replMgr
+		w.SetLLMClient(client)
+		return w
+	}
+
+	// The rules are unsure of this prompt, so the first Wrapper asks the LLM
+	prompt := "What about the totals and the names?"
+	first := classifyWithCache(t, newWrapper(), prompt)
+	require.Equal(t, 1, calls)
+	assert.Contains(t, first.Signals, "source:llm_fallback")
+
+	second := classifyWithCache(t, newWrapper(), prompt)
+	assert.Equal(t, 1, calls, "the cached LLM classification is reused")
+	assert.Equal(t, TaskTypeComputational, second.Type)
+	assert.Equal(t, 0.9, second.Confidence)
+	assert.Equal(t, int64(1), cache.Stats().FallbacksSaved)
+}
+
+func TestClassificationCache_SizeBound(t *testing.T) {
+	cache := NewClassificationCache(ClassificationCacheConfig{MaxEntries: 2})
+	cache.Put("a", nil, Classification{Type: TaskTypeRetrieval})
+	cache.Put("b", nil, Classification{Type: TaskTypeAnalytical})
+	cache.Put("A", nil, Classification{Type: TaskTypeComputational}) // replaces a
+	cache.Put("c", nil, Classification{Type: TaskTypeTransformational})
+
+	assert.Equal(t, 2, cache.Len())
+	assert.Equal(t, int64(1), cache.Stats().Evictions)
+	_, ok := cache.Get("a", nil)
+	assert.False(t, ok, "the oldest entry is evicted")
+	_, ok = cache.Get("c", nil)
+	assert.True(t, ok)
+}
+
+func TestClassificationCache_Files(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "classifications.json")
+
+	cache := NewClassificationCache(ClassificationCacheConfig{})
+	n, err := cache.LoadFile(path)
+	require.NoError(t, err, "a missing file seeds nothing")
+	assert.Zero(t, n)
+
+	cache.Put("Sum the values", cacheTestContexts, Classification{Type: TaskTypeComputational, Confidence: 0.9})
+	require.NoError(t, cache.SaveFile(path))
+
+	seeded := NewClassificationCache(ClassificationCacheConfig{})
+	n, err = seeded.LoadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	class, ok := seeded.Get("sum the values", cacheTestContexts)
+	require.True(t, ok, "the contexts' digest is saved with the prompt")
+	assert.Equal(t, TaskTypeComputational, class.Type)
+	_, ok = seeded.Get("sum the values", nil)
+	assert.False(t, ok)
+
+	_, err = seeded.Load(bytes.NewBufferString(`{"version": 99, "entries": []}`))
+	assert.ErrorContains(t, err, "unsupported version")
+}
+
+func TestClassificationCache_Nil(t *testing.T) {
+	var cache *ClassificationCache
+	cache.Put("x", nil, Classification{Type: TaskTypeRetrieval})
+	_, ok := cache.Get("x", nil)
+	assert.False(t, ok)
+	assert.Zero(t, cache.Len())
+	assert.Zero(t, cache.Stats())
+
+	var saved bytes.Buffer
+	require.NoError(t, cache.Save(&saved))
+	_, err := cache.Load(&saved)
+	assert.Error(t, err)
+}
+
+func TestClassificationCache_KeyedOnContexts(t *testing.T) {
+	cache := NewClassificationCache(ClassificationCacheConfig{})
+	csv := []ContextSource{{Type: ContextTypeCustom, Content: "id,amount\n1,10\n2,20"}}
+	prose := []ContextSource{{Type: ContextTypeCustom, Content: "The meeting covered three topics."}}
+	cache.Put("Summarize this", csv, Classification{Type: TaskTypeComputational})
+
+	class, ok := cache.Get("summarize  this", csv)
+	require.True(t, ok)
+	assert.Equal(t, TaskTypeComputational, class.Type)
+	_, ok = cache.Get("Summarize this", prose)
+	assert.False(t, ok, "the same prompt over other contexts is classified afresh")
+	_, ok = cache.Get("Summarize this", nil)
+	assert.False(t, ok)
+}
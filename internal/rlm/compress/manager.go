@@ -4,6 +4,7 @@ package compress
 import (
 	"context"
 	"fmt"
+	"math"
 	"sort"
 	"strings"
 	"sync"
@@ -21,7 +22,8 @@ type Manager struct {
 	incremental  *IncrementalCompressor
 	embedder     embeddings.Provider
 
-	defaultOpts Options
+	defaultOpts  Options
+	depthScaling DepthScaling
 
 	mu    sync.Mutex
 	stats ManagerStats
@@ -45,6 +47,61 @@ type ManagerConfig struct {
 
 	// Default compression options.
 	DefaultOptions Options
+
+	// DepthScaling tightens compression at deeper recursion levels.
+	// The zero value disables depth scaling.
+	DepthScaling DepthScaling
+}
+
+// DepthScaling is the curve that makes compression more aggressive as
+// recursion deepens, keeping cumulative context bounded. Each level
+// multiplies the token budget by ThresholdDecay and the target ratio by
+// RatioDecay, down to the configured floors.
+type DepthScaling struct {
+	// ThresholdDecay is the per-level multiplier for the compression
+	// threshold and token budget (0-1). Zero or one disables it.
+	ThresholdDecay float64
+
+	// RatioDecay is the per-level multiplier for the target ratio (0-1).
+	// Zero or one disables it.
+	RatioDecay float64
+
+	// MinThresholdFactor floors the cumulative threshold multiplier.
+	MinThresholdFactor float64
+
+	// MinRatio floors the scaled target ratio.
+	MinRatio float64
+}
+
+// DefaultDepthScaling returns a curve that shrinks the budget by a quarter
+// and the target ratio by a fifth per recursion level.
+func DefaultDepthScaling() DepthScaling {
+	return DepthScaling{
+		ThresholdDecay:     0.75,
+		RatioDecay:         0.8,
+		MinThresholdFactor: 0.25,
+		MinRatio:           0.1,
+	}
+}
+
+// ThresholdFactor returns the multiplier applied to the compression
+// threshold and token budget at depth.
+func (s DepthScaling) ThresholdFactor(depth int) float64 {
+	return decayAt(s.ThresholdDecay, s.MinThresholdFactor, depth)
+}
+
+// TargetRatio scales the base target ratio for depth. The result is never
+// below MinRatio unless base itself is.
+func (s DepthScaling) TargetRatio(base float64, depth int) float64 {
+	return math.Max(base*decayAt(s.RatioDecay, 0, depth), math.Min(s.MinRatio, base))
+}
+
+// decayAt returns decay^depth, floored at floor.
+func decayAt(decay, floor float64, depth int) float64 {
+	if depth <= 0 || decay <= 0 || decay >= 1 {
+		return 1.0
+	}
+	return math.Max(math.Pow(decay, float64(depth)), floor)
 }
 
 // DefaultManagerConfig returns sensible defaults.
@@ -56,6 +113,7 @@ func DefaultManagerConfig() ManagerConfig {
 		CacheTTL:           time.Hour,
 		ChangeThreshold:    0.1,
 		DefaultOptions:     DefaultOptions(),
+		DepthScaling:       DefaultDepthScaling(),
 	}
 }
 
@@ -75,8 +133,9 @@ func NewManager(cfg ManagerConfig) *Manager {
 			CacheTTL:        cfg.CacheTTL,
 			ChangeThreshold: cfg.ChangeThreshold,
 		}),
-		embedder:    cfg.Embedder,
-		defaultOpts: cfg.DefaultOptions,
+		embedder:     cfg.Embedder,
+		defaultOpts:  cfg.DefaultOptions,
+		depthScaling: cfg.DepthScaling,
 		stats: ManagerStats{
 			ByMethod:   make(map[Method]int64),
 			ByChunkType: make(map[string]int64),
@@ -149,6 +208,13 @@ type ChunkResult struct {
 // PrepareContext compresses multiple chunks to fit within a token budget.
 // It allocates budget based on relevance scores and concatenates results.
 func (m *Manager) PrepareContext(ctx context.Context, chunks []ContextChunk, query string, tokenBudget int) (*PreparedContext, error) {
+	return m.PrepareContextAtDepth(ctx, chunks, query, tokenBudget, 0)
+}
+
+// PrepareContextAtDepth is PrepareContext for a call at the given recursion
+// depth. Below the top level the budget shrinks and is capped at the
+// depth-scaled target ratio of the original size, per the DepthScaling curve.
+func (m *Manager) PrepareContextAtDepth(ctx context.Context, chunks []ContextChunk, query string, tokenBudget, depth int) (*PreparedContext, error) {
 	start := time.Now()
 
 	if len(chunks) == 0 {
@@ -167,6 +233,13 @@ func (m *Manager) PrepareContext(ctx context.Context, chunks []ContextChunk, que
 		originalTokens += tokens
 	}
 
+	if depth > 0 {
+		tokenBudget = int(float64(tokenBudget) * m.depthScaling.ThresholdFactor(depth))
+		if ratio := m.TargetRatioAt(depth); ratio > 0 && ratio < m.defaultOpts.TargetRatio {
+			tokenBudget = min(tokenBudget, int(float64(originalTokens)*ratio))
+		}
+	}
+
 	// If already under budget, no compression needed
 	if originalTokens <= tokenBudget {
 		var content strings.Builder
@@ -259,6 +332,19 @@ func (m *Manager) PrepareContext(ctx context.Context, chunks []ContextChunk, que
 	return prepared, nil
 }
 
+// EffectiveThreshold scales a compression threshold for depth.
+func (m *Manager) EffectiveThreshold(threshold, depth int) int {
+	return int(float64(threshold) * m.depthScaling.ThresholdFactor(depth))
+}
+
+// TargetRatioAt returns the default target ratio scaled for depth.
+func (m *Manager) TargetRatioAt(depth int) float64 {
+	if m.defaultOpts.TargetRatio <= 0 {
+		return 0
+	}
+	return m.depthScaling.TargetRatio(m.defaultOpts.TargetRatio, depth)
+}
+
 // CompressChunk compresses a single chunk with the given options.
 func (m *Manager) CompressChunk(ctx context.Context, chunk ContextChunk, opts Options) (*Result, error) {
 	return m.incremental.Compress(ctx, chunk.ID, chunk.Content, opts)
@@ -101,6 +101,79 @@ func TestManager_PrepareContext(t *testing.T) {
 	})
 }
 
+func TestManager_PrepareContextAtDepth(t *testing.T) {
+	cfg := DefaultManagerConfig()
+	cfg.DepthScaling = DepthScaling{
+		ThresholdDecay:     0.5,
+		RatioDecay:         0.5,
+		MinThresholdFactor: 0.1,
+		MinRatio:           0.05,
+	}
+
+	chunks := []ContextChunk{
+		{ID: "doc1", Content: generateTestContent(40), Type: "file"},
+		{ID: "doc2", Content: generateTestContent(40), Type: "search"},
+	}
+	original := 0
+	for _, c := range chunks {
+		original += estimateTokens(c.Content)
+	}
+	budget := original / 2
+
+	// Separate managers so the incremental cache does not leak between depths.
+	shallow, err := NewManager(cfg).PrepareContextAtDepth(context.Background(), chunks, "", budget, 0)
+	require.NoError(t, err)
+	deep, err := NewManager(cfg).PrepareContextAtDepth(context.Background(), chunks, "", budget, 3)
+	require.NoError(t, err)
+
+	assert.Less(t, shallow.CompressedTokens, shallow.OriginalTokens)
+	assert.Less(t, deep.CompressedTokens, shallow.CompressedTokens)
+	assert.Less(t, deep.Ratio, shallow.Ratio)
+
+	t.Run("depth lowers the passthrough threshold", func(t *testing.T) {
+		m := NewManager(cfg)
+		roomy := original + 10
+
+		result, err := m.PrepareContextAtDepth(context.Background(), chunks, "", roomy, 0)
+		require.NoError(t, err)
+		assert.Equal(t, 1.0, result.Ratio)
+
+		result, err = m.PrepareContextAtDepth(context.Background(), chunks, "", roomy, 2)
+		require.NoError(t, err)
+		assert.Less(t, result.Ratio, 1.0)
+	})
+
+	t.Run("zero scaling ignores depth", func(t *testing.T) {
+		cfg := DefaultManagerConfig()
+		cfg.DepthScaling = DepthScaling{}
+
+		a, err := NewManager(cfg).PrepareContextAtDepth(context.Background(), chunks, "", budget, 0)
+		require.NoError(t, err)
+		b, err := NewManager(cfg).PrepareContextAtDepth(context.Background(), chunks, "", budget, 3)
+		require.NoError(t, err)
+		assert.Equal(t, a.CompressedTokens, b.CompressedTokens)
+	})
+}
+
+func TestDepthScaling(t *testing.T) {
+	s := DepthScaling{ThresholdDecay: 0.5, RatioDecay: 0.5, MinThresholdFactor: 0.2, MinRatio: 0.05}
+
+	assert.Equal(t, 1.0, s.ThresholdFactor(0))
+	assert.Equal(t, 0.5, s.ThresholdFactor(1))
+	assert.Equal(t, 0.25, s.ThresholdFactor(2))
+	assert.Equal(t, 0.2, s.ThresholdFactor(5), "floored at MinThresholdFactor")
+
+	assert.Equal(t, 0.3, s.TargetRatio(0.3, 0))
+	assert.InDelta(t, 0.15, s.TargetRatio(0.3, 1), 1e-9)
+	assert.Equal(t, 0.05, s.TargetRatio(0.3, 10), "floored at MinRatio")
+	assert.Equal(t, 0.02, s.TargetRatio(0.02, 3), "never raised above the base")
+
+	m := NewManager(ManagerConfig{DefaultOptions: DefaultOptions(), DepthScaling: s})
+	assert.Equal(t, 10000, m.EffectiveThreshold(10000, 0))
+	assert.Equal(t, 2500, m.EffectiveThreshold(10000, 2))
+	assert.InDelta(t, 0.075, m.TargetRatioAt(2), 1e-9)
+}
+
 func TestManager_CompressChunk(t *testing.T) {
 	m := NewManager(DefaultManagerConfig())
 
@@ -0,0 +1,236 @@
+package rlm
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/rand/recurse/internal/rlm/repl"
+)
+
+// ContextLeakPolicy decides what the RLM loop does with REPL output that
+// reproduces most of an externalized context variable. Dumping a variable
+// defeats externalization: its content lands in the conversation, the
+// trace and the logs.
+type ContextLeakPolicy string
+
+const (
+	// ContextLeakOff does not check output.
+	ContextLeakOff ContextLeakPolicy = ""
+
+	// ContextLeakFlag keeps the output, records the leak and tells the
+	// model to read the variable with grep() or peek() instead.
+	ContextLeakFlag ContextLeakPolicy = "flag"
+
+	// ContextLeakTruncate also replaces the output with a short excerpt
+	// before it is traced, reported or fed back.
+	ContextLeakTruncate ContextLeakPolicy = "truncate"
+)
+
+// ContextLeakConfig configures the check for code that prints or returns
+// whole externalized variables.
+type ContextLeakConfig struct {
+	// Policy is what to do with a leak. The zero value disables the check.
+	Policy ContextLeakPolicy
+
+	// MinSize is the size in characters below which a variable is not
+	// guarded, since printing a small one is harmless (default: 4000).
+	MinSize int
+
+	// MinRatio is how large output must be, as a fraction of a variable's
+	// size, to count as dumping it (default: 0.75).
+	MinRatio float64
+}
+
+func (c ContextLeakConfig) withDefaults() ContextLeakConfig {
+	if c.MinSize <= 0 {
+		c.MinSize = 4000
+	}
+	if c.MinRatio <= 0 {
+		c.MinRatio = 0.75
+	}
+	return c
+}
+
+// ContextLeak records REPL output that reproduced an externalized variable.
+type ContextLeak struct {
+	// Iteration is the round whose code produced the output.
+	Iteration int `json:"iteration"`
+
+	// Variable is the externalized variable reproduced.
+	Variable string `json:"variable"`
+
+	// VariableSize and OutputSize are in characters.
+	VariableSize int `json:"variable_size"`
+	OutputSize   int `json:"output_size"`
+
+	// Truncated is true when the output was replaced by an excerpt.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+const (
+	// leakProbes is how many evenly spaced windows of output are looked up
+	// in a variable's content to confirm the output came from it.
+	leakProbes = 8
+
+	// leakProbeSize is the length of each window.
+	leakProbeSize = 64
+
+	// leakExcerpt is how much of leaked output ContextLeakTruncate keeps.
+	leakExcerpt = 200
+)
+
+// contextLeakGuard checks REPL output against the externalized variables
+// of one execution.
+type contextLeakGuard struct {
+	config    ContextLeakConfig
+	variables []guardedVariable
+}
+
+type guardedVariable struct {
+	name    string
+	size    int
+	content string // empty when the source is unknown; size alone decides
+}
+
+// newContextLeakGuard returns a guard for prepared's variables, or nil if
+// the check is off or no variable is large enough to guard.
+func newContextLeakGuard(config ContextLeakConfig, prepared *PreparedPrompt) *contextLeakGuard {
+	if config.Policy == ContextLeakOff || prepared.LoadedContext == nil {
+		return nil
+	}
+	config = config.withDefaults()
+
+	contents := make(map[string]string, len(prepared.sources))
+	for _, src := range prepared.sources {
+		contents[src.Name] = src.Content
+	}
+	g := &contextLeakGuard{config: config}
+	for name, info := range prepared.LoadedContext.Variables {
+		if info.Size >= config.MinSize {
+			g.variables = append(g.variables, guardedVariable{name: name, size: info.Size, content: contents[name]})
+		}
+	}
+	if len(g.variables) == 0 {
+		return nil
+	}
+	sort.Slice(g.variables, func(i, j int) bool { return g.variables[i].name < g.variables[j].name })
+	return g
+}
+
+// check looks for a variable reproduced by result's output or return
+// value. Under ContextLeakTruncate the returned result is a copy with the
+// leaked text cut to an excerpt; otherwise it is result itself.
+func (g *contextLeakGuard) check(iteration int, result *repl.ExecuteResult) (*repl.ExecuteResult, []ContextLeak) {
+	if g == nil || result == nil {
+		return result, nil
+	}
+
+	var leaks []ContextLeak
+	guarded := result
+	for _, field := range []*string{&result.Output, &result.ReturnVal} {
+		text := *field
+		if field == &result.ReturnVal {
+			text = unrepr(text)
+		}
+		v := g.reproduced(text)
+		if v == nil {
+			continue
+		}
+		leak := ContextLeak{
+			Iteration:    iteration,
+			Variable:     v.name,
+			VariableSize: v.size,
+			OutputSize:   len(*field),
+		}
+		if g.config.Policy == ContextLeakTruncate {
+			if guarded == result {
+				copied := *result
+				guarded = &copied
+			}
+			excerpt := withheldExcerpt(*field, v)
+			if field == &result.Output {
+				guarded.Output = excerpt
+			} else {
+				guarded.ReturnVal = excerpt
+			}
+			leak.Truncated = true
+		}
+		leaks = append(leaks, leak)
+	}
+	return guarded, leaks
+}
+
+// reproduced returns the variable text dumps, or nil.
+func (g *contextLeakGuard) reproduced(text string) *guardedVariable {
+	for i := range g.variables {
+		v := &g.variables[i]
+		if float64(len(text)) < g.config.MinRatio*float64(v.size) {
+			continue
+		}
+		if v.content == "" || sharesContent(text, v.content) {
+			return v
+		}
+	}
+	return nil
+}
+
+// sharesContent reports whether most of evenly spaced windows of text
+// appear verbatim in content, so large output computed from a variable is
+// not mistaken for a copy of it.
+func sharesContent(text, content string) bool {
+	size := min(leakProbeSize, len(text))
+	if size == 0 {
+		return false
+	}
+	step := max((len(text)-size)/(leakProbes-1), 1)
+	found, probes := 0, 0
+	for start := 0; start+size <= len(text) && probes < leakProbes; start += step {
+		probes++
+		if strings.Contains(content, text[start:start+size]) {
+			found++
+		}
+	}
+	return found*2 > probes
+}
+
+// reprEscapes undoes the escapes Python's repr() applies to strings.
+var reprEscapes = strings.NewReplacer(`\\`, `\`, `\n`, "\n", `\t`, "\t", `\'`, "'", `\"`, `"`)
+
+// unrepr returns the string a Python string repr stands for, so a returned
+// variable compares equal to its content. Other values are unchanged.
+func unrepr(s string) string {
+	if len(s) < 2 || (s[0] != '\'' && s[0] != '"') || s[len(s)-1] != s[0] {
+		return s
+	}
+	return reprEscapes.Replace(s[1 : len(s)-1])
+}
+
+// withheldExcerpt cuts leaked text to its start and says how to read the
+// rest.
+func withheldExcerpt(text string, v *guardedVariable) string {
+	return fmt.Sprintf("%s\n[output withheld: %d chars reproducing most of `%s` (%d chars); "+
+		"use grep(%s, pattern) or peek(%s, start, end) to read only what you need]",
+		truncateAtLine(text, min(leakExcerpt, len(text))), len(text), v.name, v.size, v.name, v.name)
+}
+
+// ContextLeakNotice tells the model its code dumped externalized context
+// and how to work with it instead.
+func ContextLeakNotice(leaks []ContextLeak) string {
+	if len(leaks) == 0 {
+		return ""
+	}
+	names := make([]string, 0, len(leaks))
+	seen := make(map[string]bool, len(leaks))
+	for _, leak := range leaks {
+		if !seen[leak.Variable] {
+			seen[leak.Variable] = true
+			names = append(names, "`"+leak.Variable+"`")
+		}
+	}
+	return fmt.Sprintf("## Context Dumped\nYour code printed or returned nearly all of %s. "+
+		"The context is kept in the REPL so it does not have to be read in full: "+
+		"use grep() to find the relevant parts and peek() to read them, "+
+		"or llm_call() over partition() chunks to process all of it.",
+		strings.Join(names, ", "))
+}
@@ -0,0 +1,132 @@
+package rlm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rand/recurse/internal/rlm/repl"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// leakTestDocument returns a log of n distinct lines with one needle.
+func leakTestDocument(n int) string {
+	var sb strings.Builder
+	for i := 0; i < n; i++ {
+		if i == n/2 {
+			sb.WriteString("ERROR disk quota exceeded on volume data-7\n")
+			continue
+		}
+		fmt.Fprintf(&sb, "INFO request %05d served in %dms\n", i, i%97)
+	}
+	return sb.String()
+}
+
+func leakTestPrepared(doc string) *PreparedPrompt {
+	return &PreparedPrompt{
+		Mode: ModeRLM,
+		LoadedContext: &LoadedContext{Variables: map[string]VariableInfo{
+			"logs":  {Name: "logs", Size: len(doc)},
+			"notes": {Name: "notes", Size: 20},
+		}},
+		sources: []ContextSource{
+			{Name: "logs", Content: doc},
+			{Name: "notes", Content: "short notes content"},
+		},
+	}
+}
+
+func TestContextLeakGuard_Check(t *testing.T) {
+	doc := leakTestDocument(400)
+	prepared := leakTestPrepared(doc)
+
+	assert.Nil(t, newContextLeakGuard(ContextLeakConfig{}, prepared), "off by default")
+	assert.Nil(t, newContextLeakGuard(ContextLeakConfig{Policy: ContextLeakFlag, MinSize: len(doc) + 1}, prepared),
+		"nothing large enough to guard")
+
+	guard := newContextLeakGuard(ContextLeakConfig{Policy: ContextLeakFlag}, prepared)
+	require.NotNil(t, guard)
+
+	// Printing the whole variable is caught
+	dump := &repl.ExecuteResult{Output: doc}
+	got, leaks := guard.check(1, dump)
+	require.Len(t, leaks, 1)
+	assert.Equal(t, ContextLeak{Iteration: 1, Variable: "logs", VariableSize: len(doc), OutputSize: len(doc)}, leaks[0])
+	assert.Same(t, dump, got, "flagging keeps the output")
+
+	// So is returning it, as a repr
+	_, leaks = guard.check(2, &repl.ExecuteResult{ReturnVal: fmt.Sprintf("%q", doc)})
+	require.Len(t, leaks, 1)
+	assert.Equal(t, "logs", leaks[0].Variable)
+
+	// Grepping passes
+	_, leaks = guard.check(3, &repl.ExecuteResult{Output: "[{'line': 200, 'text': 'ERROR disk quota exceeded on volume data-7'}]"})
+	assert.Empty(t, leaks)
+
+	// Large output computed from the variable rather than copied passes
+	_, leaks = guard.check(4, &repl.ExecuteResult{Output: strings.ToUpper(doc)})
+	assert.Empty(t, leaks)
+
+	// Truncating replaces the output with an excerpt and a hint
+	guard = newContextLeakGuard(ContextLeakConfig{Policy: ContextLeakTruncate}, prepared)
+	got, leaks = guard.check(5, dump)
+	require.Len(t, leaks, 1)
+	assert.True(t, leaks[0].Truncated)
+	assert.Less(t, len(got.Output), 500)
+	assert.Contains(t, got.Output, "output withheld")
+	assert.Contains(t, got.Output, "grep(logs, pattern)")
+	assert.Equal(t, doc, dump.Output, "the REPL's result is not modified")
+}
+
+func TestExecuteRLM_ContextLeakTruncated(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	replMgr, err := repl.NewManager(repl.Options{})
+	require.NoError(t, err)
+	require.NoError(t, replMgr.Start(ctx))
+	defer replMgr.Stop()
+
+	doc := leakTestDocument(400)
+	sources := []ContextSource{{Name: "logs", Content: doc, Type: ContextTypeCustom}}
+	loaded, err := NewContextLoader(replMgr).Load(ctx, sources)
+	require.NoError(t, err)
+
+	client := &wrapperMockLLMClient{
+		responses: []string{
+			"```python\nprint(logs)\n```",
+			"```python\nhits = grep(logs, 'ERROR')\nprint(hits)\n```",
+			"```python\nFINAL(hits[0]['line'])\n```",
+		},
+	}
+	w := &Wrapper{replMgr: replMgr, client: client}
+	prepared := &PreparedPrompt{
+		Mode:          ModeRLM,
+		SystemPrompt:  "You are an RLM assistant.",
+		FinalPrompt:   "Find the error in the logs",
+		LoadedContext: loaded,
+		sources:       sources,
+	}
+
+	result, err := w.ExecuteRLMWithConfig(ctx, prepared, RLMConfig{
+		MaxIterations:    5,
+		MaxTokensPerCall: 1024,
+		Timeout:          20 * time.Second,
+		ContextLeak:      ContextLeakConfig{Policy: ContextLeakTruncate},
+	})
+	require.NoError(t, err)
+	assert.Contains(t, result.FinalOutput, "disk quota exceeded")
+
+	require.Len(t, result.ContextLeaks, 1, "only the dump is caught, not the grep")
+	assert.Equal(t, 1, result.ContextLeaks[0].Iteration)
+	assert.Equal(t, "logs", result.ContextLeaks[0].Variable)
+	assert.True(t, result.ContextLeaks[0].Truncated)
+
+	require.Len(t, client.calls, 3)
+	assert.Contains(t, client.calls[1], "Context Dumped")
+	assert.NotContains(t, client.calls[1], "request 00300 served", "the dumped context never reaches the model")
+	assert.Contains(t, client.calls[2], "disk quota exceeded on volume data-7", "grep output is fed back")
+}
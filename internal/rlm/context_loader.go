@@ -29,7 +29,13 @@ func NewExtendedContextLoader(replMgr *repl.Manager) *ExtendedContextLoader {
 	}
 }
 
-// Load loads multiple context sources into the REPL.
+// SetDedupConfig configures deduplication of sources before loading.
+func (cl *ExtendedContextLoader) SetDedupConfig(cfg orchestrator.DedupConfig) {
+	cl.loader.SetDedupConfig(cfg)
+}
+
+// Load loads multiple context sources into the REPL. Identical or
+// overlapping sources are merged into a single variable before loading.
 func (cl *ExtendedContextLoader) Load(ctx context.Context, sources []ContextSource) (*LoadedContext, error) {
 	if cl.repl == nil {
 		return nil, fmt.Errorf("REPL manager not available")
@@ -41,7 +47,7 @@ func (cl *ExtendedContextLoader) Load(ctx context.Context, sources []ContextSour
 
 	var summaryParts []string
 
-	for _, src := range sources {
+	for _, src := range orchestrator.DeduplicateSources(sources, cl.loader.DedupConfig()) {
 		// Sanitize variable name
 		varName := sanitizeVarName(src.Name)
 
@@ -50,11 +56,24 @@ func (cl *ExtendedContextLoader) Load(ctx context.Context, sources []ContextSour
 			return nil, fmt.Errorf("set var %s: %w", varName, err)
 		}
 
+		// Bind merged-away names to the same value
+		var aliases []string
+		for _, alias := range src.Aliases {
+			aliasName := sanitizeVarName(alias)
+			if aliasName == varName {
+				continue
+			}
+			if _, err := cl.repl.Execute(ctx, fmt.Sprintf("%s = %s", aliasName, varName)); err != nil {
+				return nil, fmt.Errorf("alias var %s: %w", aliasName, err)
+			}
+			aliases = append(aliases, aliasName)
+		}
+
 		// Calculate token estimate
 		tokenCount := len(src.Content) / 4
 
 		// Build description
-		desc := buildDescription(src)
+		desc := buildDescription(src.ContextSource)
 
 		info := VariableInfo{
 			Name:          varName,
@@ -62,6 +81,8 @@ func (cl *ExtendedContextLoader) Load(ctx context.Context, sources []ContextSour
 			Size:          len(src.Content),
 			TokenEstimate: tokenCount,
 			Description:   desc,
+			Provenance:    src.Provenance,
+			Aliases:       aliases,
 		}
 
 		if source, ok := src.Metadata["source"].(string); ok {
@@ -163,6 +184,9 @@ func (cl *ExtendedContextLoader) GenerateContextPrompt(loaded *LoadedContext) st
 		if info.Source != "" {
 			sb.WriteString(fmt.Sprintf("  - Source: %s\n", info.Source))
 		}
+		if len(info.Aliases) > 0 {
+			sb.WriteString(fmt.Sprintf("  - Also available as: %s\n", strings.Join(info.Aliases, ", ")))
+		}
 	}
 
 	sb.WriteString(fmt.Sprintf("\nTotal context: ~%d tokens\n\n", loaded.TotalTokens))
@@ -249,6 +273,18 @@ func buildDescription(src ContextSource) string {
 	case ContextTypeMemory:
 		return "Memory context from hypergraph"
 
+	case ContextTypeImage:
+		if src.Metadata["surrogate"] == "placeholder" {
+			return "Placeholder for an image that could not be described"
+		}
+		return "Text description of an image"
+
+	case ContextTypeConversation:
+		if turns, ok := src.Metadata["turns"].(int); ok {
+			return fmt.Sprintf("Conversation history (%d turns)", turns)
+		}
+		return "Conversation history"
+
 	default:
 		return "Custom context"
 	}
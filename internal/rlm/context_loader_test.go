@@ -1,9 +1,14 @@
 package rlm
 
 import (
+	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rand/recurse/internal/rlm/repl"
 )
 
 func TestSanitizeVarName(t *testing.T) {
@@ -177,3 +182,38 @@ func TestNewContextLoader_NilREPL(t *testing.T) {
 	loader := NewContextLoader(nil)
 	assert.NotNil(t, loader)
 }
+
+func TestExtendedContextLoader_Load_Deduplicates(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	replMgr, err := repl.NewManager(repl.Options{})
+	require.NoError(t, err)
+	require.NoError(t, replMgr.Start(ctx))
+	defer replMgr.Stop()
+
+	head := "package main\n\nimport \"fmt\"\n\nfunc main() {\n"
+	tail := "import \"fmt\"\n\nfunc main() {\n\tfmt.Println(\"hi\")\n}\n"
+	merged := "package main\n\nimport \"fmt\"\n\nfunc main() {\n\tfmt.Println(\"hi\")\n}\n"
+
+	loader := NewExtendedContextLoader(replMgr)
+	loaded, err := loader.Load(ctx, []ContextSource{
+		{Name: "main.go", Content: head, Type: ContextTypeFile, Metadata: map[string]any{"source": "main.go"}},
+		{Name: "search-hit", Content: tail, Type: ContextTypeSearch},
+	})
+	require.NoError(t, err)
+
+	require.Len(t, loaded.Variables, 1)
+	info, ok := loaded.Variables["main_go"]
+	require.True(t, ok)
+	assert.Equal(t, len(merged), info.Size)
+	assert.Equal(t, len(merged)/4, info.TokenEstimate)
+	assert.Equal(t, []string{"search_hit"}, info.Aliases)
+	require.Len(t, info.Provenance, 2)
+	assert.Equal(t, "main.go", info.Provenance[0].Source)
+	assert.Equal(t, "search-hit", info.Provenance[1].Name)
+
+	v, err := replMgr.GetVar(ctx, "search_hit", 0, 0)
+	require.NoError(t, err)
+	assert.Equal(t, merged, v.Value)
+}
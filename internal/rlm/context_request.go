@@ -0,0 +1,182 @@
+package rlm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// ContextProvider fetches context the model asks for with request_context()
+// during an RLM execution, such as files matching a description or
+// memories relevant to it. Returning no sources means nothing was found.
+type ContextProvider interface {
+	ProvideContext(ctx context.Context, description string) ([]ContextSource, error)
+}
+
+// ContextProviderFunc adapts a function to a ContextProvider.
+type ContextProviderFunc func(ctx context.Context, description string) ([]ContextSource, error)
+
+// ProvideContext calls f.
+func (f ContextProviderFunc) ProvideContext(ctx context.Context, description string) ([]ContextSource, error) {
+	return f(ctx, description)
+}
+
+// ContextProviders asks each provider in turn, returning the sources of the
+// first that finds any. A provider that fails is logged and skipped.
+type ContextProviders []ContextProvider
+
+// ProvideContext implements ContextProvider.
+func (p ContextProviders) ProvideContext(ctx context.Context, description string) ([]ContextSource, error) {
+	var errs []error
+	for _, provider := range p {
+		sources, err := provider.ProvideContext(ctx, description)
+		if err != nil {
+			slog.Warn("Context provider failed", "description", description, "error", err)
+			errs = append(errs, err)
+			continue
+		}
+		if len(sources) > 0 {
+			return sources, nil
+		}
+	}
+	return nil, errors.Join(errs...)
+}
+
+// ContextRequest records one request_context() call and what it loaded.
+type ContextRequest struct {
+	// Iteration is the iteration whose code made the request.
+	Iteration int `json:"iteration"`
+
+	// Description is what the model said was missing.
+	Description string `json:"description"`
+
+	// Variables are the REPL variables the fetched context was loaded as;
+	// empty if the request went unfulfilled.
+	Variables []string `json:"variables,omitempty"`
+
+	// Tokens estimates the size of the loaded context.
+	Tokens int `json:"tokens,omitempty"`
+
+	// Error says why the request went unfulfilled, if it did.
+	Error string `json:"error,omitempty"`
+}
+
+// Fulfilled reports whether any context was loaded for the request.
+func (r ContextRequest) Fulfilled() bool {
+	return len(r.Variables) > 0
+}
+
+// pythonIdentifier matches names usable as REPL variables.
+var pythonIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// fulfillContextRequests loads context for the requests the last code made
+// with request_context(), adding the new variables to prepared's loaded
+// context. Without a provider there is nothing to load, and the REPL is not
+// asked.
+func (w *Wrapper) fulfillContextRequests(ctx context.Context, iteration int, prepared *PreparedPrompt) []ContextRequest {
+	if w.contextProvider == nil {
+		return nil
+	}
+	result, err := w.replMgr.Execute(ctx, "print(get_context_requests_json())")
+	if err != nil || result.Error != "" {
+		return nil
+	}
+	var descriptions []string
+	if err := json.Unmarshal([]byte(strings.TrimSpace(result.Output)), &descriptions); err != nil {
+		slog.Warn("Failed to read context requests", "error", err)
+		return nil
+	}
+
+	requests := make([]ContextRequest, 0, len(descriptions))
+	for _, description := range descriptions {
+		req := ContextRequest{Iteration: iteration, Description: description}
+		if err := w.loadRequestedContext(ctx, prepared, &req); err != nil {
+			req.Error = err.Error()
+		}
+		requests = append(requests, req)
+	}
+	return requests
+}
+
+// loadRequestedContext fetches and externalizes the context for req.
+func (w *Wrapper) loadRequestedContext(ctx context.Context, prepared *PreparedPrompt, req *ContextRequest) error {
+	if w.contextLoader == nil {
+		return ErrNotConfigured
+	}
+	sources, err := w.contextProvider.ProvideContext(ctx, req.Description)
+	if err != nil {
+		return err
+	}
+	if len(sources) == 0 {
+		return errors.New("no matching context found")
+	}
+
+	if prepared.LoadedContext == nil {
+		prepared.LoadedContext = &LoadedContext{Variables: make(map[string]VariableInfo)}
+	}
+	taken := func(name string) bool {
+		_, ok := prepared.LoadedContext.Variables[name]
+		return ok
+	}
+	for i := range sources {
+		sources[i].Name = requestedVariableName(sources[i].Name, taken)
+		prepared.LoadedContext.Variables[sources[i].Name] = VariableInfo{}
+	}
+
+	loaded, err := w.contextLoader.Load(ctx, sources)
+	for _, src := range sources {
+		delete(prepared.LoadedContext.Variables, src.Name)
+	}
+	if err != nil {
+		return err
+	}
+	for name, info := range loaded.Variables {
+		prepared.LoadedContext.Variables[name] = info
+		req.Variables = append(req.Variables, name)
+	}
+	slices.Sort(req.Variables)
+	prepared.LoadedContext.TotalTokens += loaded.TotalTokens
+	req.Tokens = loaded.TotalTokens
+	return nil
+}
+
+// requestedVariableName returns name if it is a free identifier, else the
+// first free "requested_context_N".
+func requestedVariableName(name string, taken func(string) bool) string {
+	if pythonIdentifier.MatchString(name) && !taken(name) {
+		return name
+	}
+	for n := 1; ; n++ {
+		candidate := fmt.Sprintf("requested_context_%d", n)
+		if !taken(candidate) {
+			return candidate
+		}
+	}
+}
+
+// ContextRequestNotice tells the model what its context requests loaded.
+func ContextRequestNotice(requests []ContextRequest) string {
+	if len(requests) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString("## Requested Context\n")
+	for _, req := range requests {
+		if req.Fulfilled() {
+			vars := make([]string, len(req.Variables))
+			for i, name := range req.Variables {
+				vars[i] = "`" + name + "`"
+			}
+			fmt.Fprintf(&sb, "- %q: loaded as %s (~%d tokens)\n", req.Description, strings.Join(vars, ", "), req.Tokens)
+		} else {
+			fmt.Fprintf(&sb, "- %q: not available (%s)\n", req.Description, req.Error)
+		}
+	}
+	sb.WriteString("Use the new variables like the others; work with what you have for requests that were not available.")
+	return sb.String()
+}
@@ -0,0 +1,140 @@
+package rlm
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rand/recurse/internal/rlm/repl"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runContextRequestRLM runs an execution over a "notes" variable whose
+// answers come from responses.
+func runContextRequestRLM(t *testing.T, provider ContextProvider, responses ...string) (*RLMExecutionResult, *PreparedPrompt, *wrapperMockLLMClient) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	replMgr, err := repl.NewManager(repl.Options{})
+	require.NoError(t, err)
+	require.NoError(t, replMgr.Start(ctx))
+	t.Cleanup(func() { replMgr.Stop() })
+
+	client := &wrapperMockLLMClient{responses: responses}
+	cfg := DefaultWrapperConfig()
+	cfg.ContextProvider = provider
+	w := NewWrapper(&Service{}, cfg)
+	w.SetREPLManager(replMgr)
+	w.SetLLMClient(client)
+
+	prepared, err := w.PrepareContextWithOptions(ctx, "What does a widget cost?",
+		[]ContextSource{{Name: "notes", Type: ContextTypeCustom, Content: "Widgets are sold in the shop."}},
+		PrepareOptions{ModeOverride: ModeOverrideRLM})
+	require.NoError(t, err)
+
+	result, err := w.ExecuteRLMWithConfig(ctx, prepared, RLMConfig{
+		MaxIterations:    4,
+		MaxTokensPerCall: 1024,
+		Timeout:          10 * time.Second,
+	})
+	require.NoError(t, err)
+	return result, prepared, client
+}
+
+func TestExecuteRLM_RequestContextLoadsNewVariable(t *testing.T) {
+	var asked []string
+	provider := ContextProviderFunc(func(_ context.Context, description string) ([]ContextSource, error) {
+		asked = append(asked, description)
+		return []ContextSource{{Name: "pricing", Type: ContextTypeFile, Content: "widget: 42"}}, nil
+	})
+
+	result, prepared, client := runContextRequestRLM(t, provider,
+		"```python\nif 'widget:' not in notes:\n    request_context('the pricing table')\n```",
+		"```python\nFINAL(pricing.split(': ')[1])\n```",
+	)
+
+	assert.Empty(t, result.Error)
+	assert.Equal(t, "42", result.FinalOutput)
+	assert.Equal(t, []string{"the pricing table"}, asked)
+
+	require.Len(t, result.ContextRequests, 1)
+	req := result.ContextRequests[0]
+	assert.True(t, req.Fulfilled())
+	assert.Equal(t, 1, req.Iteration)
+	assert.Equal(t, []string{"pricing"}, req.Variables)
+	assert.Contains(t, prepared.LoadedContext.Variables, "pricing")
+
+	// The model is told about the builtin and about what was loaded
+	require.Len(t, client.calls, 2)
+	assert.Contains(t, client.calls[0], "request_context(description)")
+	assert.Contains(t, client.calls[1], "## Requested Context")
+	assert.Contains(t, client.calls[1], "`pricing`")
+}
+
+func TestExecuteRLM_RequestContextUnfulfilled(t *testing.T) {
+	result, _, client := runContextRequestRLM(t, nil,
+		"```python\nrequest_context('the pricing table')\n```",
+		"```python\nFINAL('unknown')\n```",
+	)
+
+	assert.Equal(t, "unknown", result.FinalOutput)
+	assert.Empty(t, result.ContextRequests, "nothing to fetch with, so nothing is asked")
+	assert.NotContains(t, client.calls[0], "request_context(description)")
+	assert.NotContains(t, client.calls[1], "Requested context")
+}
+
+func TestExecuteRLM_RequestContextProviderFails(t *testing.T) {
+	provider := ContextProviderFunc(func(ctx context.Context, description string) ([]ContextSource, error) {
+		return nil, errors.New("index offline")
+	})
+	result, _, client := runContextRequestRLM(t, provider,
+		"```python\nrequest_context('the pricing table')\n```",
+		"```python\nFINAL('unknown')\n```",
+	)
+
+	assert.Equal(t, "unknown", result.FinalOutput)
+	require.Len(t, result.ContextRequests, 1)
+	assert.False(t, result.ContextRequests[0].Fulfilled())
+	assert.Equal(t, "index offline", result.ContextRequests[0].Error)
+	assert.Contains(t, client.calls[1], "not available")
+}
+
+func TestExecuteRLM_RequestContextRenamesTakenVariables(t *testing.T) {
+	provider := ContextProviderFunc(func(_ context.Context, _ string) ([]ContextSource, error) {
+		return []ContextSource{
+			{Name: "notes", Type: ContextTypeCustom, Content: "widget: 42"},
+			{Name: "not a name", Type: ContextTypeCustom, Content: "gadget: 7"},
+		}, nil
+	})
+
+	result, _, _ := runContextRequestRLM(t, provider,
+		"```python\nrequest_context('prices')\n```",
+		"```python\nFINAL(notes + ' | ' + requested_context_1 + ' | ' + requested_context_2)\n```",
+	)
+
+	require.Len(t, result.ContextRequests, 1)
+	assert.Equal(t, []string{"requested_context_1", "requested_context_2"}, result.ContextRequests[0].Variables)
+	assert.Equal(t, "Widgets are sold in the shop. | widget: 42 | gadget: 7", result.FinalOutput)
+}
+
+func TestContextProviders_FirstMatchWins(t *testing.T) {
+	failing := ContextProviderFunc(func(context.Context, string) ([]ContextSource, error) {
+		return nil, errors.New("index offline")
+	})
+	empty := ContextProviderFunc(func(context.Context, string) ([]ContextSource, error) {
+		return nil, nil
+	})
+	found := ContextProviderFunc(func(_ context.Context, description string) ([]ContextSource, error) {
+		return []ContextSource{{Content: description}}, nil
+	})
+
+	sources, err := ContextProviders{failing, empty, found}.ProvideContext(context.Background(), "x")
+	require.NoError(t, err)
+	require.Len(t, sources, 1)
+
+	_, err = ContextProviders{failing, empty}.ProvideContext(context.Background(), "x")
+	assert.ErrorContains(t, err, "index offline")
+}
@@ -8,15 +8,46 @@ import (
 	"context"
 
 	"github.com/rand/recurse/internal/memory/hypergraph"
+	"github.com/rand/recurse/internal/rlm/async"
+	"github.com/rand/recurse/internal/rlm/decompose"
 	"github.com/rand/recurse/internal/rlm/meta"
 	"github.com/rand/recurse/internal/rlm/orchestrator"
+	"github.com/rand/recurse/internal/rlm/tot"
 )
 
 // Re-export types from orchestrator package for backwards compatibility.
 type (
-	ExecutionResult = orchestrator.ExecutionResult
-	TraceEvent      = orchestrator.TraceEvent
-	TraceRecorder   = orchestrator.TraceRecorder
+	ExecutionResult    = orchestrator.ExecutionResult
+	TraceEvent         = orchestrator.TraceEvent
+	TraceRecorder      = orchestrator.TraceRecorder
+	InterestingMarker  = orchestrator.InterestingMarker
+	EscalationPolicy   = orchestrator.EscalationPolicy
+	Escalation         = orchestrator.Escalation
+	AnswerScorer       = orchestrator.AnswerScorer
+	SubtaskCache       = orchestrator.SubtaskCache
+	SubtaskCacheConfig = orchestrator.SubtaskCacheConfig
+	SubtaskCacheStats  = orchestrator.SubtaskCacheStats
+	CompressionStats   = orchestrator.CompressionStats
+	ActionTimeouts     = orchestrator.ActionTimeouts
+	ExecutionSummary   = orchestrator.ExecutionSummary
+	WarmStartConfig    = orchestrator.WarmStartConfig
+	ExecutionRetry     = orchestrator.ExecutionRetry
+)
+
+// NewVerifierScorer scores answers by their hallucination risk.
+var NewVerifierScorer = orchestrator.NewVerifierScorer
+
+// DefaultWarmStartConfig returns the recommended warm start settings.
+var DefaultWarmStartConfig = orchestrator.DefaultWarmStartConfig
+
+// NewSubtaskCache creates a cache of decomposition subtask results.
+var NewSubtaskCache = orchestrator.NewSubtaskCache
+
+// WithRecursionDepth and RecursionDepthFrom carry the orchestration depth
+// of a call in its context.
+var (
+	WithRecursionDepth = orchestrator.WithRecursionDepth
+	RecursionDepthFrom = orchestrator.RecursionDepthFrom
 )
 
 // Controller orchestrates RLM operations with integrated memory.
@@ -36,6 +67,10 @@ type ControllerConfig struct {
 	// MemoryQueryLimit is max results from memory queries.
 	MemoryQueryLimit int
 
+	// MemoryMinConfidence is the confidence a memory needs to be returned
+	// by MEMORY_QUERY. Zero returns memories of any confidence.
+	MemoryMinConfidence float64
+
 	// StoreDecisions persists decisions to memory graph.
 	StoreDecisions bool
 
@@ -50,37 +85,76 @@ type ControllerConfig struct {
 
 	// MaxParallelOps is the maximum concurrent operations (default: 4).
 	MaxParallelOps int
+
+	// CostCeiling aborts an execution whose LLM calls would cost more than
+	// CostCeiling.MaxCost, returning the best partial answer. Zero disables it.
+	CostCeiling CostCeiling
+
+	// MaxLLMCalls aborts an execution about to make more LLM calls than
+	// this, returning the best partial answer. Every call counts whatever
+	// its size: the main model's, the meta-controller's, sub-calls and
+	// answer verification. Zero leaves calls unbounded.
+	MaxLLMCalls int
+
+	// Escalation re-executes a low-confidence answer once on a higher model
+	// tier. Zero disables it.
+	Escalation EscalationPolicy
+
+	// ActionTimeouts bounds DIRECT, DECOMPOSE, MEMORY_QUERY, SUBCALL and
+	// SYNTHESIZE individually, so a stuck action is retried or degraded
+	// instead of running into the overall deadline. Zero disables it.
+	ActionTimeouts ActionTimeouts
+
+	// WarmStart blends the meta-controller's proposed action with the
+	// success rates of actions on similar recorded executions, so routing
+	// improves with experience. Zero disables it.
+	WarmStart WarmStartConfig
+
+	// ExecutionRetry re-runs a whole execution that failed with a transient
+	// error, such as a provider outage outlasting the client's retries,
+	// with exponential backoff. Zero disables it.
+	ExecutionRetry ExecutionRetry
 }
 
 // DefaultControllerConfig returns sensible defaults.
 func DefaultControllerConfig() ControllerConfig {
 	return ControllerConfig{
-		MaxTokenBudget:    100000,
-		MaxRecursionDepth: 5,
-		MemoryQueryLimit:  10,
-		StoreDecisions:    true,
-		TraceEnabled:      true,
-		Recovery:          DefaultRecoveryConfig(),
+		MaxTokenBudget:      100000,
+		MaxRecursionDepth:   5,
+		MemoryQueryLimit:    10,
+		MemoryMinConfidence: 0.5,
+		StoreDecisions:      true,
+		TraceEnabled:        true,
+		Recovery:            DefaultRecoveryConfig(),
 	}
 }
 
 // NewController creates a new RLM controller with memory integration.
+// policy makes each orchestration decision: the LLM-backed meta.Controller,
+// or a custom meta.DecisionPolicy that bypasses it.
 func NewController(
-	metaCtrl *meta.Controller,
+	policy meta.DecisionPolicy,
 	mainClient meta.LLMClient,
 	store *hypergraph.Store,
 	cfg ControllerConfig,
 ) *Controller {
 	return &Controller{
-		core: orchestrator.NewCore(metaCtrl, mainClient, store, orchestrator.CoreConfig{
+		core: orchestrator.NewCore(policy, mainClient, store, orchestrator.CoreConfig{
 			MaxTokenBudget:       cfg.MaxTokenBudget,
 			MaxRecursionDepth:    cfg.MaxRecursionDepth,
 			MemoryQueryLimit:     cfg.MemoryQueryLimit,
+			MemoryMinConfidence:  cfg.MemoryMinConfidence,
 			StoreDecisions:       cfg.StoreDecisions,
 			TraceEnabled:         cfg.TraceEnabled,
 			Recovery:             toOrchestratorRecoveryConfig(cfg.Recovery),
 			EnableAsyncExecution: cfg.EnableAsyncExecution,
 			MaxParallelOps:       cfg.MaxParallelOps,
+			CostCeiling:          cfg.CostCeiling,
+			MaxLLMCalls:          cfg.MaxLLMCalls,
+			Escalation:           cfg.Escalation,
+			ActionTimeouts:       cfg.ActionTimeouts,
+			WarmStart:            cfg.WarmStart,
+			ExecutionRetry:       cfg.ExecutionRetry,
 		}),
 	}
 }
@@ -95,6 +169,57 @@ func (c *Controller) Tracer() TraceRecorder {
 	return c.core.Tracer()
 }
 
+// SetStreamingDecomposer enables incremental decomposition, in which subtasks
+// start before planning completes. Requires EnableAsyncExecution.
+func (c *Controller) SetStreamingDecomposer(d decompose.StreamingDecomposer) {
+	c.core.SetStreamingDecomposer(d)
+}
+
+// SetToT sets the evaluator and generator used when the meta-controller
+// routes a deep-reasoning task to Tree of Thoughts.
+func (c *Controller) SetToT(evaluator tot.Evaluator, generator tot.Generator) {
+	c.core.SetToT(evaluator, generator)
+}
+
+// ExplainLastDecision explains why the most recent orchestration action
+// was chosen.
+func (c *Controller) ExplainLastDecision() (*meta.DecisionExplanation, error) {
+	return c.core.ExplainLastDecision()
+}
+
+// SetProgressCallback streams subtask results to the callback as
+// ProgressSubtaskComplete events while decomposition runs.
+func (c *Controller) SetProgressCallback(callback ProgressCallback) {
+	progress := NewProgressEmitter(callback, 0)
+	if progress == nil {
+		c.core.SetSubtaskProgress(nil)
+		return
+	}
+	c.core.SetSubtaskProgress(func(result *async.OperationResult) {
+		errMsg := ""
+		if result.Error != nil {
+			errMsg = result.Error.Error()
+		}
+		progress.EmitSubtaskComplete(result.ID, result.Duration, result.Tokens, result.Response, errMsg)
+	})
+}
+
+// SetAnswerScorer sets the scorer the escalation policy judges answers by.
+func (c *Controller) SetAnswerScorer(scorer AnswerScorer) {
+	c.core.SetAnswerScorer(scorer)
+}
+
+// SetAssuranceScorer sets the scorer for high-assurance executions when no
+// answer scorer is set.
+func (c *Controller) SetAssuranceScorer(scorer AnswerScorer) {
+	c.core.SetAssuranceScorer(scorer)
+}
+
+// SetSubtaskCache makes decomposition reuse results of identical subtasks.
+func (c *Controller) SetSubtaskCache(cache *SubtaskCache) {
+	c.core.SetSubtaskCache(cache)
+}
+
 // Execute runs the RLM orchestration loop for a task.
 func (c *Controller) Execute(ctx context.Context, task string) (*ExecutionResult, error) {
 	return c.core.Execute(ctx, task)
@@ -2,6 +2,8 @@ package rlm
 
 import (
 	"context"
+	"errors"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -43,6 +45,7 @@ func TestDefaultControllerConfig(t *testing.T) {
 	assert.Equal(t, 100000, cfg.MaxTokenBudget)
 	assert.Equal(t, 5, cfg.MaxRecursionDepth)
 	assert.Equal(t, 10, cfg.MemoryQueryLimit)
+	assert.Equal(t, 0.5, cfg.MemoryMinConfidence)
 	assert.True(t, cfg.StoreDecisions)
 	assert.True(t, cfg.TraceEnabled)
 }
@@ -179,6 +182,106 @@ func TestExecute_Decompose(t *testing.T) {
 	assert.NotEmpty(t, result.Response)
 }
 
+// keywordPolicy maps tasks to actions by keyword, deciding DIRECT when
+// none matches, and records the tasks it decided.
+type keywordPolicy struct {
+	rules []struct {
+		keyword string
+		action  meta.Action
+	}
+	tasks []string
+}
+
+func (p *keywordPolicy) Decide(ctx context.Context, state meta.State) (*meta.Decision, error) {
+	p.tasks = append(p.tasks, state.Task)
+	for _, rule := range p.rules {
+		if strings.Contains(strings.ToLower(state.Task), rule.keyword) {
+			return &meta.Decision{Action: rule.action, Reasoning: "rule: " + rule.keyword}, nil
+		}
+	}
+	return &meta.Decision{Action: meta.ActionDirect, Reasoning: "rule: default"}, nil
+}
+
+func TestExecute_CustomDecisionPolicy(t *testing.T) {
+	store := createTestStore(t)
+	ctx := context.Background()
+	fact := hypergraph.NewNode(hypergraph.NodeTypeFact, "The project uses Go language")
+	fact.Confidence = 0.9
+	require.NoError(t, store.CreateNode(ctx, fact))
+
+	policy := &keywordPolicy{}
+	policy.rules = append(policy.rules, struct {
+		keyword string
+		action  meta.Action
+	}{"remember", meta.ActionMemoryQuery})
+
+	client := &mockLLMClient{responses: []string{"The answer is 4", "It uses Go"}}
+	cfg := DefaultControllerConfig()
+	cfg.StoreDecisions = false
+	ctrl := NewController(policy, client, store, cfg)
+	tracer := &mockTraceRecorder{}
+	ctrl.SetTracer(tracer)
+
+	result, err := ctrl.Execute(ctx, "What is 2+2?")
+	require.NoError(t, err)
+	assert.Equal(t, "DIRECT", result.Action)
+	assert.Equal(t, "The answer is 4", result.Response)
+	assert.Equal(t, 1, client.callCount, "only the answer is generated; no meta-controller call")
+
+	result, err = ctrl.Execute(ctx, "Remember which language the project uses")
+	require.NoError(t, err)
+	assert.Equal(t, "MEMORY_QUERY", result.Action)
+	assert.Equal(t, []string{"What is 2+2?", "Remember which language the project uses"}, policy.tasks)
+
+	// The trace records the policy's decisions
+	var decided []string
+	for _, event := range tracer.events {
+		if event.Status == "completed" {
+			decided = append(decided, event.Type+" "+event.Action)
+		}
+	}
+	assert.Contains(t, decided, "DIRECT rule: default")
+	assert.Contains(t, decided, "MEMORY_QUERY rule: remember")
+
+	_, err = ctrl.ExplainLastDecision()
+	assert.ErrorIs(t, err, meta.ErrNoDecision)
+}
+
+func TestExecute_DecisionPolicyFunc(t *testing.T) {
+	calls := 0
+	policy := meta.DecisionPolicyFunc(func(ctx context.Context, state meta.State) (*meta.Decision, error) {
+		calls++
+		return nil, errors.New("policy unavailable")
+	})
+	cfg := DefaultControllerConfig()
+	cfg.StoreDecisions = false
+	ctrl := NewController(policy, &mockLLMClient{}, createTestStore(t), cfg)
+
+	_, err := ctrl.Execute(context.Background(), "What is 2+2?")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "policy unavailable")
+	assert.Equal(t, 1, calls)
+}
+
+func TestExecute_CarriesRecursionDepth(t *testing.T) {
+	var depths []int
+	policy := meta.DecisionPolicyFunc(func(ctx context.Context, state meta.State) (*meta.Decision, error) {
+		assert.Equal(t, state.RecursionDepth, RecursionDepthFrom(ctx))
+		depths = append(depths, RecursionDepthFrom(ctx))
+		if state.RecursionDepth == 0 {
+			return &meta.Decision{Action: meta.ActionSubcall, Params: meta.DecisionParams{Prompt: "Answer"}}, nil
+		}
+		return &meta.Decision{Action: meta.ActionDirect}, nil
+	})
+	cfg := DefaultControllerConfig()
+	cfg.StoreDecisions = false
+	ctrl := NewController(policy, &mockLLMClient{}, createTestStore(t), cfg)
+
+	_, err := ctrl.Execute(context.Background(), "What is 2+2?")
+	require.NoError(t, err)
+	assert.Equal(t, []int{0, 1}, depths)
+}
+
 func TestEstimateTokens(t *testing.T) {
 	tests := []struct {
 		text     string
@@ -0,0 +1,89 @@
+package rlm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// ConversationVar is the REPL variable prior conversation turns are loaded
+// into.
+const ConversationVar = "conversation"
+
+// ConversationTurn is one message of a prior conversation.
+type ConversationTurn struct {
+	// Role is who sent the message, typically "user" or "assistant".
+	Role string `json:"role"`
+
+	// Content is the message text.
+	Content string `json:"content"`
+}
+
+type conversationHistoryKey struct{}
+
+// WithConversationHistory returns a context carrying the conversation that
+// led to an execution's task, oldest turn first. Service.Execute loads it
+// as the `conversation` variable when it prepares the task's context.
+func WithConversationHistory(ctx context.Context, turns []ConversationTurn) context.Context {
+	return context.WithValue(ctx, conversationHistoryKey{}, turns)
+}
+
+// ConversationHistoryFrom returns the conversation set by
+// WithConversationHistory, nil if there is none.
+func ConversationHistoryFrom(ctx context.Context) []ConversationTurn {
+	turns, _ := ctx.Value(conversationHistoryKey{}).([]ConversationTurn)
+	return turns
+}
+
+// ConversationContext returns turns, oldest first, as a ContextSource for the
+// `conversation` variable. Each turn starts with a "### Turn N (role)" line,
+// so the model can grep for what was said and peek at whole turns.
+func ConversationContext(turns []ConversationTurn) ContextSource {
+	return ContextSource{
+		Name:    ConversationVar,
+		Content: formatConversationTurns(turns),
+		Type:    ContextTypeConversation,
+		Metadata: map[string]any{
+			"turns": len(turns),
+		},
+	}
+}
+
+// formatConversationTurns renders turns in the layout ConversationContext
+// describes.
+func formatConversationTurns(turns []ConversationTurn) string {
+	var sb strings.Builder
+	for i, turn := range turns {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		role := turn.Role
+		if role == "" {
+			role = "user"
+		}
+		fmt.Fprintf(&sb, "### Turn %d (%s)\n%s\n", i+1, role, strings.TrimRight(turn.Content, "\n"))
+	}
+	return sb.String()
+}
+
+// conversationGuidance tells the model how to use an externalized
+// conversation, or returns "" when none was loaded.
+func conversationGuidance(loaded *LoadedContext) string {
+	if loaded == nil {
+		return ""
+	}
+	info, ok := loaded.Variables[ConversationVar]
+	if !ok || info.Type != ContextTypeConversation {
+		return ""
+	}
+	turns, _ := info.Metadata["turns"].(int)
+	return fmt.Sprintf(`## Conversation History
+The %d earlier turns of this conversation are in `+"`conversation`"+`, not repeated here.
+Each turn starts with a "### Turn N (role)" line.
+- grep(conversation, pattern) finds what was said about a topic
+- grep(conversation, r"### Turn \d+ \(user\)") lists the user's turns
+- peek(conversation, start, end, by_lines=True) rereads turns in full
+Look up earlier turns when the request refers back to them instead of guessing.
+
+`, turns)
+}
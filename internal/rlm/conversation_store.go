@@ -0,0 +1,206 @@
+package rlm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/rand/recurse/internal/memory/hypergraph"
+)
+
+// ExecutionConversation is the complete conversation of an RLM execution:
+// the system prompt, the task, each code block the model wrote and the
+// feedback it was given, in order. Unlike the prompts sent to the model,
+// nothing is elided to fit the history budget.
+type ExecutionConversation struct {
+	ExecutionID string    `json:"execution_id"`
+	CreatedAt   time.Time `json:"created_at"`
+
+	// Task is the user's prompt.
+	Task string `json:"task"`
+
+	Turns []ConversationTurn `json:"turns"`
+
+	// Iterations, Answer and Error are how the execution ended.
+	Iterations int    `json:"iterations"`
+	Answer     string `json:"answer,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// ConversationStore persists the conversations of RLM executions, keyed by
+// execution ID, for debugging and building fine-tuning datasets. A
+// continuation saves the extended conversation over the original's.
+type ConversationStore interface {
+	// SaveConversation stores conv, replacing any conversation saved
+	// under its execution ID.
+	SaveConversation(ctx context.Context, conv *ExecutionConversation) error
+
+	// LoadConversation returns the conversation saved under executionID,
+	// or an error wrapping ErrExecutionNotRecorded if there is none.
+	LoadConversation(ctx context.Context, executionID string) (*ExecutionConversation, error)
+}
+
+// FileConversationStore stores each conversation as a JSON file named
+// after its execution ID in a directory.
+type FileConversationStore struct {
+	dir string
+}
+
+// NewFileConversationStore creates a store writing to dir, which is
+// created on the first save.
+func NewFileConversationStore(dir string) *FileConversationStore {
+	return &FileConversationStore{dir: dir}
+}
+
+// path returns the file holding the conversation of executionID.
+func (s *FileConversationStore) path(executionID string) (string, error) {
+	if executionID == "" || executionID != filepath.Base(executionID) || strings.HasPrefix(executionID, ".") {
+		return "", fmt.Errorf("invalid execution id %q", executionID)
+	}
+	return filepath.Join(s.dir, executionID+".json"), nil
+}
+
+// SaveConversation implements ConversationStore. The file is replaced
+// atomically, so a reader never sees a partial conversation.
+func (s *FileConversationStore) SaveConversation(ctx context.Context, conv *ExecutionConversation) error {
+	path, err := s.path(conv.ExecutionID)
+	if err != nil {
+		return fmt.Errorf("save conversation: %w", err)
+	}
+	data, err := json.MarshalIndent(conv, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal conversation: %w", err)
+	}
+	if err := os.MkdirAll(s.dir, 0o700); err != nil {
+		return fmt.Errorf("save conversation: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(s.dir, ".conversation-*")
+	if err != nil {
+		return fmt.Errorf("save conversation: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("save conversation: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("save conversation: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("save conversation: %w", err)
+	}
+	return nil
+}
+
+// LoadConversation implements ConversationStore.
+func (s *FileConversationStore) LoadConversation(ctx context.Context, executionID string) (*ExecutionConversation, error) {
+	path, err := s.path(executionID)
+	if err != nil {
+		return nil, fmt.Errorf("load conversation: %w", err)
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrExecutionNotRecorded, executionID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load conversation: %w", err)
+	}
+	var conv ExecutionConversation
+	if err := json.Unmarshal(data, &conv); err != nil {
+		return nil, fmt.Errorf("unmarshal conversation: %w", err)
+	}
+	return &conv, nil
+}
+
+// hypergraphConversationSubtype marks snippet nodes that hold execution
+// conversations.
+const hypergraphConversationSubtype = "execution_conversation"
+
+// hypergraphConversationIDPrefix keeps conversation node IDs apart from
+// memory node IDs.
+const hypergraphConversationIDPrefix = "conversation:"
+
+// HypergraphConversationStore stores conversations as nodes in a
+// hypergraph store, so instances sharing a store share them.
+// Conversations are archive-tier snippet nodes, which keeps them out of
+// memory retrieval.
+type HypergraphConversationStore struct {
+	store *hypergraph.Store
+}
+
+// NewHypergraphConversationStore creates a conversation store on store.
+func NewHypergraphConversationStore(store *hypergraph.Store) *HypergraphConversationStore {
+	return &HypergraphConversationStore{store: store}
+}
+
+// SaveConversation implements ConversationStore.
+func (s *HypergraphConversationStore) SaveConversation(ctx context.Context, conv *ExecutionConversation) error {
+	metadata, err := json.Marshal(conv)
+	if err != nil {
+		return fmt.Errorf("marshal conversation: %w", err)
+	}
+
+	nodeID := hypergraphConversationIDPrefix + conv.ExecutionID
+	if existing, err := s.store.GetNode(ctx, nodeID); err == nil && existing != nil {
+		existing.Content = conv.Task
+		existing.Metadata = metadata
+		if err := s.store.UpdateNode(ctx, existing); err != nil {
+			return fmt.Errorf("update conversation: %w", err)
+		}
+		return nil
+	}
+
+	node := hypergraph.NewNode(hypergraph.NodeTypeSnippet, conv.Task)
+	node.ID = nodeID
+	node.Subtype = hypergraphConversationSubtype
+	node.Tier = hypergraph.TierArchive
+	node.Metadata = metadata
+	if err := s.store.CreateNode(ctx, node); err != nil {
+		return fmt.Errorf("insert conversation: %w", err)
+	}
+	return nil
+}
+
+// LoadConversation implements ConversationStore.
+func (s *HypergraphConversationStore) LoadConversation(ctx context.Context, executionID string) (*ExecutionConversation, error) {
+	node, err := s.store.GetNode(ctx, hypergraphConversationIDPrefix+executionID)
+	if err != nil || node == nil || node.Subtype != hypergraphConversationSubtype {
+		return nil, fmt.Errorf("%w: %s", ErrExecutionNotRecorded, executionID)
+	}
+	var conv ExecutionConversation
+	if err := json.Unmarshal(node.Metadata, &conv); err != nil {
+		return nil, fmt.Errorf("unmarshal conversation: %w", err)
+	}
+	return &conv, nil
+}
+
+// saveConversation persists an execution's conversation, redacted, to the
+// wrapper's conversation store. A failure is logged, not returned: the
+// execution's answer stands without it.
+func (w *Wrapper) saveConversation(ctx context.Context, executionID string, prepared *PreparedPrompt, conversation []conversationMessage, result *RLMExecutionResult) {
+	if w.conversationStore == nil || executionID == "" {
+		return
+	}
+	conv := &ExecutionConversation{
+		ExecutionID: executionID,
+		CreatedAt:   result.StartTime,
+		Task:        w.redactor.Redact(prepared.OriginalPrompt),
+		Turns:       make([]ConversationTurn, len(conversation)),
+		Iterations:  result.Iterations,
+		Answer:      w.redactor.Redact(result.FinalOutput),
+		Error:       w.redactor.Redact(result.Error),
+	}
+	for i, msg := range conversation {
+		conv.Turns[i] = ConversationTurn{Role: msg.Role, Content: w.redactor.Redact(msg.Content)}
+	}
+	if err := w.conversationStore.SaveConversation(context.WithoutCancel(ctx), conv); err != nil {
+		slog.Warn("Failed to save execution conversation", "execution_id", executionID, "error", err)
+	}
+}
@@ -0,0 +1,141 @@
+package rlm
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rand/recurse/internal/memory/hypergraph"
+	"github.com/rand/recurse/internal/rlm/redact"
+)
+
+// conversationWrapperConfig persists conversations to store, redacting
+// them first.
+func conversationWrapperConfig(t *testing.T, store ConversationStore) WrapperConfig {
+	t.Helper()
+	redactor, err := redact.New(redact.DefaultConfig())
+	require.NoError(t, err)
+
+	cfg := DefaultWrapperConfig()
+	cfg.Redactor = redactor
+	cfg.ConversationStore = store
+	return cfg
+}
+
+func TestWrapper_PersistsConversation(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	store := NewFileConversationStore(t.TempDir())
+	w := newREPLWrapper(t, conversationWrapperConfig(t, store),
+		"```python\nprint('step one')\n```",
+		"```python\nprint('step two')\n```",
+		"```python\nFINAL('done')\n```",
+	)
+
+	task := "Check the deploy with token Bearer " + testBearerSecret
+	prepared := &PreparedPrompt{Mode: ModeRLM, OriginalPrompt: task, SystemPrompt: "system", FinalPrompt: task}
+	result, err := w.ExecuteRLM(ctx, prepared)
+	require.NoError(t, err)
+	require.Equal(t, "done", result.FinalOutput)
+	require.NotEmpty(t, result.ExecutionID, "an ID is assigned without tracing")
+
+	conv, err := store.LoadConversation(ctx, result.ExecutionID)
+	require.NoError(t, err)
+	assert.Equal(t, result.ExecutionID, conv.ExecutionID)
+	assert.Equal(t, 3, conv.Iterations)
+	assert.Equal(t, "done", conv.Answer)
+
+	roles := make([]string, len(conv.Turns))
+	for i, turn := range conv.Turns {
+		roles[i] = turn.Role
+	}
+	assert.Equal(t, []string{"system", "user", "assistant", "user", "assistant", "user", "assistant"}, roles)
+	assert.Equal(t, "system", conv.Turns[0].Content)
+	assert.Contains(t, conv.Turns[2].Content, "print('step one')")
+	assert.Contains(t, conv.Turns[3].Content, "step one")
+	assert.Contains(t, conv.Turns[4].Content, "print('step two')")
+	assert.Contains(t, conv.Turns[5].Content, "step two")
+	assert.Contains(t, conv.Turns[6].Content, "FINAL('done')", "the closing turn is kept")
+
+	// Secrets are redacted
+	assert.NotContains(t, conv.Task, testBearerSecret)
+	assert.NotContains(t, conv.Turns[1].Content, testBearerSecret)
+	assert.Contains(t, conv.Turns[1].Content, "Check the deploy")
+
+	_, err = store.LoadConversation(ctx, "rlm-exec-unknown")
+	assert.ErrorIs(t, err, ErrExecutionNotRecorded)
+}
+
+func TestWrapper_PersistsContinuedConversation(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	store := NewFileConversationStore(t.TempDir())
+	w := newREPLWrapper(t, conversationWrapperConfig(t, store),
+		"```python\nprint('step one')\n```",
+		"```python\nFINAL('done')\n```",
+	)
+
+	prepared := &PreparedPrompt{Mode: ModeRLM, OriginalPrompt: "task", SystemPrompt: "system", FinalPrompt: "task"}
+	result, err := w.ExecuteRLMWithConfig(ctx, prepared, RLMConfig{MaxIterations: 1, MaxTokensPerCall: 1024})
+	require.NoError(t, err)
+	require.NotNil(t, result.Resume)
+	id := result.ExecutionID
+
+	conv, err := store.LoadConversation(ctx, id)
+	require.NoError(t, err)
+	assert.Len(t, conv.Turns, 4)
+	assert.NotEmpty(t, conv.Error)
+
+	result, err = w.ContinueRLM(ctx, result.Resume, 1)
+	require.NoError(t, err)
+	require.Equal(t, "done", result.FinalOutput)
+
+	conv, err = store.LoadConversation(ctx, id)
+	require.NoError(t, err)
+	assert.Len(t, conv.Turns, 5, "saved over the original")
+	assert.Contains(t, conv.Turns[4].Content, "FINAL('done')")
+	assert.Equal(t, "done", conv.Answer)
+}
+
+func TestHypergraphConversationStore(t *testing.T) {
+	ctx := context.Background()
+	graph, err := hypergraph.NewStore(hypergraph.Options{})
+	require.NoError(t, err)
+	defer graph.Close()
+	store := NewHypergraphConversationStore(graph)
+
+	conv := &ExecutionConversation{
+		ExecutionID: "rlm-exec-1",
+		Task:        "task",
+		Turns: []ConversationTurn{
+			{Role: "system", Content: "system"},
+			{Role: "user", Content: "task"},
+		},
+	}
+	require.NoError(t, store.SaveConversation(ctx, conv))
+
+	conv.Turns = append(conv.Turns, ConversationTurn{Role: "assistant", Content: "FINAL('x')"})
+	conv.Answer = "x"
+	require.NoError(t, store.SaveConversation(ctx, conv))
+
+	loaded, err := store.LoadConversation(ctx, "rlm-exec-1")
+	require.NoError(t, err)
+	assert.Equal(t, conv.Turns, loaded.Turns)
+	assert.Equal(t, "x", loaded.Answer)
+
+	_, err = store.LoadConversation(ctx, "rlm-exec-2")
+	assert.ErrorIs(t, err, ErrExecutionNotRecorded)
+}
+
+func TestFileConversationStore_RejectsPathIDs(t *testing.T) {
+	store := NewFileConversationStore(t.TempDir())
+	for _, id := range []string{"", "../escape", "a/b", ".hidden"} {
+		err := store.SaveConversation(context.Background(), &ExecutionConversation{ExecutionID: id})
+		assert.ErrorContains(t, err, "invalid execution id", id)
+	}
+}
@@ -0,0 +1,129 @@
+package rlm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rand/recurse/internal/rlm/repl"
+)
+
+// syntheticHistory returns n alternating user and assistant turns of
+// filler, with fact planted in the user turn at index factAt.
+func syntheticHistory(n, factAt int, fact string) []ConversationTurn {
+	turns := make([]ConversationTurn, n)
+	for i := range turns {
+		role := "user"
+		if i%2 == 1 {
+			role = "assistant"
+		}
+		content := fmt.Sprintf("Message %d discussing the quarterly roadmap, staffing and the release checklist in some detail.", i)
+		if i == factAt {
+			content = fact
+		}
+		turns[i] = ConversationTurn{Role: role, Content: content}
+	}
+	return turns
+}
+
+func TestConversationContext(t *testing.T) {
+	src := ConversationContext([]ConversationTurn{
+		{Role: "user", Content: "What is the deploy window?"},
+		{Role: "assistant", Content: "Tuesdays after 14:00.\n"},
+		{Content: "Thanks"},
+	})
+
+	assert.Equal(t, ConversationVar, src.Name)
+	assert.Equal(t, ContextTypeConversation, src.Type)
+	assert.Equal(t, 3, src.Metadata["turns"])
+	assert.Equal(t, "### Turn 1 (user)\nWhat is the deploy window?\n"+
+		"\n### Turn 2 (assistant)\nTuesdays after 14:00.\n"+
+		"\n### Turn 3 (user)\nThanks\n", src.Content)
+	assert.Equal(t, "Conversation history (3 turns)", buildDescription(src))
+}
+
+func TestPrepareContextWithOptions_History(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	replMgr, err := repl.NewManager(repl.Options{})
+	require.NoError(t, err)
+	require.NoError(t, replMgr.Start(ctx))
+	defer replMgr.Stop()
+
+	w := NewWrapper(&Service{}, DefaultWrapperConfig())
+	w.SetREPLManager(replMgr)
+
+	t.Run("long history is externalized", func(t *testing.T) {
+		history := syntheticHistory(400, 16, "Remember that the staging password rotates every 9 days.")
+		historyTokens := estimateTokens(formatConversationTurns(history))
+		require.Greater(t, historyTokens, 8000)
+
+		prompt := "How often did I say the staging password rotates?"
+		prepared, err := w.PrepareContextWithOptions(ctx, prompt, nil, PrepareOptions{History: history})
+		require.NoError(t, err)
+		require.Equal(t, ModeRLM, prepared.Mode)
+
+		require.NotNil(t, prepared.LoadedContext)
+		info, ok := prepared.LoadedContext.Variables[ConversationVar]
+		require.True(t, ok)
+		assert.Equal(t, ContextTypeConversation, info.Type)
+		assert.Equal(t, historyTokens, info.TokenEstimate)
+
+		// The history stays out of the prompt sent each iteration
+		assert.Less(t, estimateTokens(prepared.FinalPrompt), 200)
+		assert.NotContains(t, prepared.FinalPrompt, "quarterly roadmap")
+		assert.Contains(t, prepared.FinalPrompt, "`conversation`")
+		assert.Contains(t, prepared.SystemPrompt, "## Conversation History")
+		assert.Contains(t, prepared.SystemPrompt, "The 400 earlier turns")
+
+		// The model can retrieve past turns from the REPL
+		result, err := replMgr.Execute(ctx, `m = grep(conversation, "staging password")[0]
+print(peek(conversation, m["line_num"] - 2, m["line_num"], by_lines=True))`)
+		require.NoError(t, err)
+		require.Empty(t, result.Error)
+		assert.Contains(t, result.Output, "### Turn 17 (user)\nRemember that the staging password rotates every 9 days.\n")
+	})
+
+	t.Run("executions load the history they carry", func(t *testing.T) {
+		history := syntheticHistory(400, 16, "Remember that the staging password rotates every 9 days.")
+		ctx := WithConversationHistory(ctx, history)
+
+		prepared, err := (&wrapperContextPreparer{wrapper: w}).PrepareContext(ctx, "How often does the staging password rotate?", 0)
+		require.NoError(t, err)
+		assert.True(t, prepared.Externalized)
+		assert.Contains(t, prepared.SystemPrompt, "The 400 earlier turns")
+
+		result, err := replMgr.Execute(ctx, `print(len(grep(conversation, "staging password")))`)
+		require.NoError(t, err)
+		require.Empty(t, result.Error)
+		assert.Equal(t, "1", strings.TrimSpace(result.Output))
+	})
+
+	t.Run("short history is inlined", func(t *testing.T) {
+		history := []ConversationTurn{
+			{Role: "user", Content: "Call me Sam."},
+			{Role: "assistant", Content: "Will do, Sam."},
+		}
+		prepared, err := w.PrepareContextWithOptions(ctx, "What is my name?", nil, PrepareOptions{History: history})
+		require.NoError(t, err)
+		assert.Equal(t, ModeDirecte, prepared.Mode)
+		assert.Contains(t, prepared.FinalPrompt, "## conversation\n### Turn 1 (user)\nCall me Sam.")
+	})
+}
+
+func TestAnswerKey_DiffersByConversation(t *testing.T) {
+	svc, err := NewService(&mockLLMClient{}, DefaultServiceConfig())
+	require.NoError(t, err)
+	defer svc.Stop()
+
+	ctx := context.Background()
+	history := []ConversationTurn{{Role: "user", Content: "Call me Sam."}}
+	assert.Equal(t, svc.answerKey(ctx, "What is my name?"), svc.answerKey(WithConversationHistory(ctx, nil), "What is my name?"))
+	assert.NotEqual(t, svc.answerKey(ctx, "What is my name?"), svc.answerKey(WithConversationHistory(ctx, history), "What is my name?"))
+}
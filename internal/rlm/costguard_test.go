@@ -0,0 +1,229 @@
+package rlm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rand/recurse/internal/rlm/meta"
+	"github.com/rand/recurse/internal/rlm/repl"
+)
+
+// escalatingClient answers call n (from 1) with n*100 tokens of text, so
+// each call costs more than the last.
+type escalatingClient struct {
+	calls int
+}
+
+func (c *escalatingClient) Complete(ctx context.Context, prompt string, maxTokens int) (string, error) {
+	c.calls++
+	return fmt.Sprintf("answer %d ", c.calls) + strings.Repeat("x", c.calls*400), nil
+}
+
+// perTokenCeiling charges $0.001 per output token and nothing for input.
+func perTokenCeiling(maxCost float64) CostCeiling {
+	return CostCeiling{MaxCost: maxCost, OutputCostPer1M: 1000}
+}
+
+func TestExecuteRLM_CostCeiling_AbortsWithPartial(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	replMgr, err := repl.NewManager(repl.Options{})
+	require.NoError(t, err)
+	require.NoError(t, replMgr.Start(ctx))
+	defer replMgr.Stop()
+
+	// Each response prints a step and is padded so iteration n costs about
+	// n*0.1: cumulative 0.1, 0.3, 0.6.
+	var responses []string
+	for i := 1; i <= 5; i++ {
+		responses = append(responses, fmt.Sprintf("```python\nprint('partial %d')\n# %s\n```", i, strings.Repeat("x", i*400)))
+	}
+	client := &wrapperMockLLMClient{responses: responses}
+	w := &Wrapper{replMgr: replMgr, client: client}
+
+	prepared := &PreparedPrompt{Mode: ModeRLM, SystemPrompt: "system", FinalPrompt: "task"}
+	result, err := w.ExecuteRLMWithConfig(ctx, prepared, RLMConfig{
+		MaxIterations:    10,
+		MaxTokensPerCall: 1024,
+		Timeout:          10 * time.Second,
+		CostCeiling:      perTokenCeiling(0.5),
+	})
+	require.NoError(t, err)
+
+	// The third call crosses the ceiling; the fourth is refused.
+	assert.Equal(t, 3, client.callIndex)
+	assert.ErrorIs(t, result.Err, ErrBudgetExceeded)
+	var ceilingErr *CostCeilingError
+	require.ErrorAs(t, result.Err, &ceilingErr)
+	assert.Equal(t, 0.5, ceilingErr.Ceiling)
+
+	assert.True(t, result.Partial)
+	assert.Equal(t, "partial 3", result.FinalOutput)
+	assert.Equal(t, 4, result.Iterations)
+	assert.GreaterOrEqual(t, result.TotalCost, 0.5)
+	assert.Less(t, result.TotalCost, 0.7)
+}
+
+func TestExecuteRLM_CostCeiling_RefusedSubCall(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	replMgr, err := repl.NewManager(repl.Options{})
+	require.NoError(t, err)
+	require.NoError(t, replMgr.Start(ctx))
+	defer replMgr.Stop()
+
+	subClient := &subCallMockClient{response: "sub answer"}
+	router := NewSubCallRouter(SubCallConfig{Client: subClient})
+	replMgr.SetCallbackHandler(NewREPLCallbackHandler(router))
+
+	// Main calls are free; the first sub-call's input alone breaks the
+	// ceiling, so it is refused and the loop stops after that iteration.
+	client := &wrapperMockLLMClient{responses: []string{
+		"```python\nprint('before sub-call')\ntry:\n    llm_call('summarize', 'some context')\nexcept Exception:\n    pass\n```",
+		"```python\nFINAL('should not run')\n```",
+	}}
+	w := &Wrapper{replMgr: replMgr, client: client}
+
+	prepared := &PreparedPrompt{Mode: ModeRLM, SystemPrompt: "system", FinalPrompt: "task"}
+	result, err := w.ExecuteRLMWithConfig(ctx, prepared, RLMConfig{
+		MaxIterations:    5,
+		MaxTokensPerCall: 1024,
+		Timeout:          10 * time.Second,
+		CostCeiling:      CostCeiling{MaxCost: 1e-9},
+	})
+	require.NoError(t, err)
+
+	assert.Empty(t, subClient.calls)
+	assert.Equal(t, 1, client.callIndex)
+	assert.ErrorIs(t, result.Err, ErrBudgetExceeded)
+	assert.True(t, result.Partial)
+	assert.Equal(t, "before sub-call", result.FinalOutput)
+}
+
+func TestSubCallRouter_CostCeiling(t *testing.T) {
+	// A 10k-token answer overshoots the ceiling on any default model.
+	client := &subCallMockClient{response: strings.Repeat("y", 40000)}
+	router := NewSubCallRouter(SubCallConfig{Client: client})
+
+	guard := NewCostGuard(CostCeiling{MaxCost: 1e-3})
+	ctx := WithCostGuard(context.Background(), guard)
+
+	first := router.Call(ctx, SubCallRequest{Prompt: "p", Model: "fast"})
+	require.Empty(t, first.Error)
+	assert.Equal(t, first.Cost, guard.Spent())
+
+	second := router.Call(ctx, SubCallRequest{Prompt: "p", Model: "fast"})
+	assert.Contains(t, second.Error, "cost ceiling")
+	assert.Len(t, client.calls, 1)
+	assert.ErrorIs(t, guard.Err(), ErrBudgetExceeded)
+}
+
+func TestExecute_CostCeiling_AbortsDecomposition(t *testing.T) {
+	store := createTestStore(t)
+	metaClient := &mockLLMClient{
+		responses: []string{`{"action": "DECOMPOSE", "params": {"strategy": "file"}, "reasoning": "Four files"}`},
+	}
+	metaCtrl := meta.NewController(metaClient, meta.DefaultConfig())
+	mainClient := &escalatingClient{}
+
+	cfg := DefaultControllerConfig()
+	cfg.StoreDecisions = false
+	cfg.CostCeiling = perTokenCeiling(0.5)
+	ctrl := NewController(metaCtrl, mainClient, store, cfg)
+
+	var task strings.Builder
+	for _, name := range []string{"a.go", "b.go", "c.go", "d.go"} {
+		fmt.Fprintf(&task, "// File: %s\npackage %s\n", name, strings.TrimSuffix(name, ".go"))
+	}
+
+	// Chunk answers cost 0.1, 0.2 and 0.3; the fourth chunk is refused.
+	result, err := ctrl.Execute(context.Background(), task.String())
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrBudgetExceeded)
+	assert.Equal(t, 3, mainClient.calls)
+
+	require.NotNil(t, result)
+	assert.True(t, result.Partial)
+	assert.Contains(t, result.Response, "answer 1")
+	assert.Contains(t, result.Response, "answer 3")
+	assert.InDelta(t, 0.6, result.Cost, 0.01)
+}
+
+func TestExecuteRLM_MaxLLMCalls_AbortsWithPartial(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	replMgr, err := repl.NewManager(repl.Options{})
+	require.NoError(t, err)
+	require.NoError(t, replMgr.Start(ctx))
+	defer replMgr.Stop()
+
+	var responses []string
+	for i := 1; i <= 5; i++ {
+		responses = append(responses, fmt.Sprintf("```python\nprint('partial %d')\n```", i))
+	}
+	client := &wrapperMockLLMClient{responses: responses}
+	w := &Wrapper{replMgr: replMgr, client: client}
+
+	prepared := &PreparedPrompt{Mode: ModeRLM, SystemPrompt: "system", FinalPrompt: "task"}
+	result, err := w.ExecuteRLMWithConfig(ctx, prepared, RLMConfig{
+		MaxIterations:    10,
+		MaxTokensPerCall: 1024,
+		Timeout:          10 * time.Second,
+		MaxLLMCalls:      2,
+	})
+	require.NoError(t, err)
+
+	// The third call is refused however cheap it is.
+	assert.Equal(t, 2, client.callIndex)
+	assert.Equal(t, 2, result.LLMCalls)
+	assert.ErrorIs(t, result.Err, ErrBudgetExceeded)
+	var limitErr *CallLimitError
+	require.ErrorAs(t, result.Err, &limitErr)
+	assert.Equal(t, 2, limitErr.Limit)
+
+	assert.True(t, result.Partial)
+	assert.Equal(t, "partial 2", result.FinalOutput)
+}
+
+func TestExecute_MaxLLMCalls_AbortsDecomposition(t *testing.T) {
+	store := createTestStore(t)
+	metaClient := &mockLLMClient{
+		responses: []string{`{"action": "DECOMPOSE", "params": {"strategy": "file"}, "reasoning": "Four files"}`},
+	}
+	// The meta-controller's calls count as well
+	metaCtrl := meta.NewController(GuardCalls(metaClient), meta.DefaultConfig())
+	mainClient := &escalatingClient{}
+
+	cfg := DefaultControllerConfig()
+	cfg.StoreDecisions = false
+	cfg.MaxLLMCalls = 5
+	ctrl := NewController(metaCtrl, mainClient, store, cfg)
+
+	var task strings.Builder
+	for _, name := range []string{"a.go", "b.go", "c.go", "d.go"} {
+		fmt.Fprintf(&task, "// File: %s\npackage %s\n", name, strings.TrimSuffix(name, ".go"))
+	}
+
+	// The top-level decision, then a decision and an answer for each of two
+	// chunks; the third chunk's decision is refused.
+	result, err := ctrl.Execute(context.Background(), task.String())
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrBudgetExceeded)
+	assert.Equal(t, 2, mainClient.calls)
+
+	require.NotNil(t, result)
+	assert.Equal(t, 5, result.LLMCalls)
+	assert.True(t, result.Partial)
+	assert.Contains(t, result.Response, "answer 1")
+	assert.Contains(t, result.Response, "answer 2")
+	assert.NotContains(t, result.Response, "answer 3")
+}
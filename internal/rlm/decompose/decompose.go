@@ -3,6 +3,7 @@ package decompose
 
 import (
 	"bufio"
+	"context"
 	"regexp"
 	"strings"
 )
@@ -36,6 +37,10 @@ type Chunk struct {
 
 	// Metadata contains additional context about the chunk.
 	Metadata map[string]string `json:"metadata,omitempty"`
+
+	// DependsOn lists IDs of chunks whose results this chunk needs.
+	// Only streaming decomposers set it.
+	DependsOn []string `json:"depends_on,omitempty"`
 }
 
 // Decomposer breaks down content into chunks.
@@ -47,6 +52,15 @@ type Decomposer interface {
 	Strategy() Strategy
 }
 
+// StreamingDecomposer identifies chunks incrementally so that processing can
+// begin before decomposition completes. DecomposeStream calls emit for each
+// chunk as soon as it is identified; a chunk may depend on chunks emitted
+// before or after it. If emit returns an error, DecomposeStream should stop
+// and return it.
+type StreamingDecomposer interface {
+	DecomposeStream(ctx context.Context, content string, emit func(Chunk) error) error
+}
+
 // FileDecomposer breaks content into file-sized chunks based on markers.
 type FileDecomposer struct {
 	// FileMarker is a regex pattern that identifies file boundaries.
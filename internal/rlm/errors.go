@@ -0,0 +1,56 @@
+package rlm
+
+import (
+	"github.com/rand/recurse/internal/rlm/orchestrator"
+)
+
+// Re-export the RLM error taxonomy. Match these with errors.Is/errors.As;
+// RecoveryManager.ClassifyError maps each to a recovery category.
+var (
+	ErrServiceNotRunning = orchestrator.ErrServiceNotRunning
+	ErrServiceRunning    = orchestrator.ErrServiceRunning
+	ErrServiceStopping   = orchestrator.ErrServiceStopping
+	ErrNotConfigured     = orchestrator.ErrNotConfigured
+	ErrREPLUnavailable   = orchestrator.ErrREPLUnavailable
+	ErrInvalidMode       = orchestrator.ErrInvalidMode
+	ErrUnknownAction     = orchestrator.ErrUnknownAction
+	ErrBudgetExceeded    = orchestrator.ErrBudgetExceeded
+	ErrMaxIterations     = orchestrator.ErrMaxIterations
+	ErrLLMCall           = orchestrator.ErrLLMCall
+	ErrActionTimeout     = orchestrator.ErrActionTimeout
+)
+
+// MaxIterationsError reports an RLM loop that ended without FINAL().
+type MaxIterationsError = orchestrator.MaxIterationsError
+
+// ActionTimeoutError reports an action that exceeded its per-action timeout.
+type ActionTimeoutError = orchestrator.ActionTimeoutError
+
+// Per-execution cost ceiling and call limit. A CostGuard carried in the
+// context is checked before every main-model call and sub-call of the
+// execution, and before the calls of clients wrapped with GuardCalls.
+type (
+	CostCeiling      = orchestrator.CostCeiling
+	CostCeilingError = orchestrator.CostCeilingError
+	CallLimitError   = orchestrator.CallLimitError
+	CostGuard        = orchestrator.CostGuard
+)
+
+var (
+	NewCostGuard  = orchestrator.NewCostGuard
+	WithCostGuard = orchestrator.WithCostGuard
+	CostGuardFrom = orchestrator.CostGuardFrom
+	GuardCalls    = orchestrator.GuardCalls
+)
+
+// Fact provenance. Facts recorded under a context carrying an Execution
+// are linked to its decision node with a derived_from relation.
+type Execution = orchestrator.Execution
+
+const DerivedFromLabel = orchestrator.DerivedFromLabel
+
+var (
+	WithExecution  = orchestrator.WithExecution
+	ExecutionFrom  = orchestrator.ExecutionFrom
+	LinkProvenance = orchestrator.LinkProvenance
+)
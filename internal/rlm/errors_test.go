@@ -0,0 +1,145 @@
+package rlm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rand/recurse/internal/rlm/repl"
+)
+
+// failingLLMClient returns err from every call.
+type failingLLMClient struct {
+	err error
+}
+
+func (c *failingLLMClient) Complete(ctx context.Context, prompt string, maxTokens int) (string, error) {
+	return "", c.err
+}
+
+func TestErrors_Service(t *testing.T) {
+	cfg := DefaultServiceConfig()
+	cfg.Lifecycle.IdleInterval = 0
+
+	svc, err := NewService(&mockLLMClient{}, cfg)
+	require.NoError(t, err)
+	defer svc.Stop()
+
+	_, err = svc.Execute(context.Background(), "task")
+	assert.ErrorIs(t, err, ErrServiceNotRunning)
+
+	require.NoError(t, svc.Start(context.Background()))
+	assert.ErrorIs(t, svc.Start(context.Background()), ErrServiceRunning)
+}
+
+func TestErrors_WrapperPreconditions(t *testing.T) {
+	ctx := context.Background()
+
+	_, err := (&Wrapper{}).ExecuteRLM(ctx, &PreparedPrompt{Mode: ModeDirecte})
+	assert.ErrorIs(t, err, ErrInvalidMode)
+
+	_, err = (&Wrapper{}).ExecuteRLM(ctx, &PreparedPrompt{Mode: ModeRLM})
+	assert.ErrorIs(t, err, ErrNotConfigured)
+
+	_, err = (&Wrapper{}).GetFinalOutput(ctx)
+	assert.ErrorIs(t, err, ErrREPLUnavailable)
+	assert.ErrorIs(t, (&Wrapper{}).ClearContext(ctx), ErrREPLUnavailable)
+}
+
+func TestErrors_RLMLoop(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	replMgr, err := repl.NewManager(repl.Options{})
+	require.NoError(t, err)
+	require.NoError(t, replMgr.Start(ctx))
+	defer replMgr.Stop()
+
+	prepared := &PreparedPrompt{Mode: ModeRLM, SystemPrompt: "system", FinalPrompt: "task"}
+	cfg := RLMConfig{MaxIterations: 2, MaxTokensPerCall: 256, Timeout: 10 * time.Second}
+
+	t.Run("LLM failure", func(t *testing.T) {
+		cause := errors.New("connection reset by peer")
+		w := &Wrapper{replMgr: replMgr, client: &failingLLMClient{err: cause}}
+
+		result, err := w.ExecuteRLMWithConfig(ctx, prepared, cfg)
+		require.NoError(t, err)
+		assert.ErrorIs(t, result.Err, ErrLLMCall)
+		assert.ErrorIs(t, result.Err, cause)
+		assert.Equal(t, result.Err.Error(), result.Error)
+	})
+
+	t.Run("max iterations", func(t *testing.T) {
+		w := &Wrapper{replMgr: replMgr, client: &wrapperMockLLMClient{
+			responses: []string{"```python\nx = 1\n```", "```python\nx = 2\n```"},
+		}}
+
+		result, err := w.ExecuteRLMWithConfig(ctx, prepared, cfg)
+		require.NoError(t, err)
+		assert.ErrorIs(t, result.Err, ErrMaxIterations)
+
+		var maxErr *MaxIterationsError
+		require.ErrorAs(t, result.Err, &maxErr)
+		assert.Equal(t, 2, maxErr.Iterations)
+		assert.Equal(t, "max iterations (2) reached without FINAL() call", result.Error)
+	})
+}
+
+func TestErrors_GuaranteeLimits(t *testing.T) {
+	assert.ErrorIs(t, ErrBudgetExhausted, ErrBudgetExceeded)
+	assert.ErrorIs(t, ErrMaxCallsExceeded, ErrBudgetExceeded)
+}
+
+func TestRecoveryManager_ClassifyError_Typed(t *testing.T) {
+	mgr := NewRecoveryManager(DefaultRecoveryConfig())
+
+	// Each message would be misclassified by the substring heuristics,
+	// so a correct category proves the typed path was taken.
+	tests := []struct {
+		name string
+		err  error
+		want ErrorCategory
+	}{
+		{"service not running", fmt.Errorf("execute: %w", ErrServiceNotRunning), ErrorCategoryTerminal},
+		{"service stopping", fmt.Errorf("execute: %w", ErrServiceStopping), ErrorCategoryTerminal},
+		{"not configured", fmt.Errorf("REPL manager %w", ErrNotConfigured), ErrorCategoryTerminal},
+		{"invalid mode", fmt.Errorf("orchestrate: %w", ErrInvalidMode), ErrorCategoryTerminal},
+		{"REPL unavailable", fmt.Errorf("REPL connection: %w", ErrREPLUnavailable), ErrorCategoryDegradable},
+		{"budget", fmt.Errorf("retry: %w", ErrBudgetExhausted), ErrorCategoryResource},
+		{"max iterations", fmt.Errorf("invalid state: %w", &MaxIterationsError{Iterations: 3}), ErrorCategoryDegradable},
+		{"LLM timeout", fmt.Errorf("%w: %w", ErrLLMCall, context.DeadlineExceeded), ErrorCategoryTimeout},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, mgr.ClassifyError(tt.err))
+		})
+	}
+}
+
+func TestRecoveryManager_ClassifyError_LLMCallByCause(t *testing.T) {
+	mgr := NewRecoveryManager(DefaultRecoveryConfig())
+
+	tests := []struct {
+		name string
+		err  error
+		want ErrorCategory
+	}{
+		{"unexplained", fmt.Errorf("main %w: empty completion", ErrLLMCall), ErrorCategoryRetryable},
+		{"connection", fmt.Errorf("main %w: connection reset by peer", ErrLLMCall), ErrorCategoryRetryable},
+		{"unauthorized", fmt.Errorf("main %w: 401 unauthorized", ErrLLMCall), ErrorCategoryTerminal},
+		{"bad key", fmt.Errorf("main %w: authentication failed: bad API key", ErrLLMCall), ErrorCategoryTerminal},
+		{"deadline", fmt.Errorf("main %w: request deadline exceeded", ErrLLMCall), ErrorCategoryTimeout},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, mgr.ClassifyError(tt.err))
+		})
+	}
+}
@@ -0,0 +1,204 @@
+package rlm
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rand/recurse/internal/rlm/meta"
+)
+
+// tierClient answers shakily unless the context forces the powerful tier.
+type tierClient struct {
+	tiers []string
+}
+
+func (c *tierClient) Complete(ctx context.Context, prompt string, maxTokens int) (string, error) {
+	tier := "routed"
+	if minTier, ok := meta.MinTierFrom(ctx); ok {
+		tier = minTier.String()
+	}
+	c.tiers = append(c.tiers, tier)
+	if tier == meta.TierPowerful.String() {
+		return "solid answer", nil
+	}
+	return "shaky answer", nil
+}
+
+// Capabilities reports routing by tier, which escalation needs.
+func (c *tierClient) Capabilities() meta.Capabilities {
+	return meta.Capabilities{TierRouting: true}
+}
+
+// fixedScorer scores answers from a table.
+type fixedScorer map[string]float64
+
+func (s fixedScorer) ScoreAnswer(ctx context.Context, task, answer string) (float64, error) {
+	confidence, ok := s[answer]
+	if !ok {
+		return 0, fmt.Errorf("no score for %q", answer)
+	}
+	return confidence, nil
+}
+
+func newEscalationController(t *testing.T, client meta.LLMClient, scorer AnswerScorer, configure func(*ControllerConfig)) *Controller {
+	t.Helper()
+	cfg := DefaultControllerConfig()
+	cfg.StoreDecisions = false
+	cfg.Escalation = EscalationPolicy{MinConfidence: 0.7}
+	if configure != nil {
+		configure(&cfg)
+	}
+	metaCtrl := meta.NewController(&mockLLMClient{}, meta.DefaultConfig())
+	ctrl := NewController(metaCtrl, client, createTestStore(t), cfg)
+	if scorer != nil {
+		ctrl.SetAnswerScorer(scorer)
+	}
+	return ctrl
+}
+
+func TestExecute_Escalation_RerunsLowConfidence(t *testing.T) {
+	client := &tierClient{}
+	tracer := &mockTraceRecorder{}
+	ctrl := newEscalationController(t, client, fixedScorer{"shaky answer": 0.4, "solid answer": 0.9}, nil)
+	ctrl.SetTracer(tracer)
+
+	result, err := ctrl.Execute(context.Background(), "What does this function return?")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"routed", "powerful"}, client.tiers)
+	assert.Equal(t, "solid answer", result.Response)
+	assert.Equal(t, 0.9, result.Confidence)
+
+	esc := result.Escalation
+	require.NotNil(t, esc)
+	assert.True(t, esc.Escalated)
+	assert.Equal(t, "powerful", esc.Tier)
+	assert.Equal(t, 0.7, esc.Threshold)
+	assert.Contains(t, esc.Reason, "0.40 below threshold 0.70")
+	require.Len(t, esc.Attempts, 2)
+	assert.Equal(t, 1, esc.Chosen)
+	assert.Equal(t, "shaky answer", esc.Attempts[0].Response)
+	assert.Equal(t, 0.4, esc.Attempts[0].Confidence)
+	assert.Equal(t, "solid answer", esc.Attempts[1].Response)
+	assert.Equal(t, esc.Attempts[0].TotalTokens+esc.Attempts[1].TotalTokens, result.TotalTokens)
+
+	var escalations int
+	for _, event := range tracer.events {
+		if event.Type == "escalation" {
+			escalations++
+			assert.Equal(t, "completed", event.Status)
+		}
+	}
+	assert.Equal(t, 1, escalations)
+}
+
+func TestExecute_Escalation_KeepsMoreConfidentAnswer(t *testing.T) {
+	client := &tierClient{}
+	ctrl := newEscalationController(t, client, fixedScorer{"shaky answer": 0.5, "solid answer": 0.3}, nil)
+
+	result, err := ctrl.Execute(context.Background(), "What does this function return?")
+	require.NoError(t, err)
+
+	assert.Len(t, client.tiers, 2, "escalated once, not again after a worse answer")
+	assert.Equal(t, "shaky answer", result.Response)
+	assert.Equal(t, 0.5, result.Confidence)
+	require.NotNil(t, result.Escalation)
+	assert.True(t, result.Escalation.Escalated)
+	assert.Equal(t, 0, result.Escalation.Chosen)
+}
+
+func TestExecute_Escalation_NotNeeded(t *testing.T) {
+	tests := []struct {
+		name      string
+		scorer    AnswerScorer
+		configure func(*ControllerConfig)
+		reason    string
+	}{
+		{"confident answer", fixedScorer{"shaky answer": 0.8}, nil, "meets threshold"},
+		{"over token budget", fixedScorer{"shaky answer": 0.2}, func(cfg *ControllerConfig) {
+			cfg.MaxTokenBudget = 10
+		}, "budget does not allow"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &tierClient{}
+			ctrl := newEscalationController(t, client, tt.scorer, tt.configure)
+
+			result, err := ctrl.Execute(context.Background(), "What does this function return?")
+			require.NoError(t, err)
+
+			assert.Len(t, client.tiers, 1)
+			assert.Equal(t, "shaky answer", result.Response)
+			require.NotNil(t, result.Escalation)
+			assert.False(t, result.Escalation.Escalated)
+			assert.Contains(t, result.Escalation.Reason, tt.reason)
+			assert.Len(t, result.Escalation.Attempts, 1)
+		})
+	}
+}
+
+// singleModelClient is a tierClient that does not report routing by tier,
+// like a plain Anthropic or OpenAI client.
+type singleModelClient struct {
+	inner *tierClient
+}
+
+func (c *singleModelClient) Complete(ctx context.Context, prompt string, maxTokens int) (string, error) {
+	return c.inner.Complete(ctx, prompt, maxTokens)
+}
+
+func TestExecute_Escalation_SkippedForSingleModelClient(t *testing.T) {
+	client := &singleModelClient{inner: &tierClient{}}
+	ctrl := newEscalationController(t, client, fixedScorer{"shaky answer": 0.2}, nil)
+
+	result, err := ctrl.Execute(context.Background(), "What does this function return?")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"routed"}, client.inner.tiers, "the same model is not re-run")
+	require.NotNil(t, result.Escalation)
+	assert.False(t, result.Escalation.Escalated)
+	assert.Contains(t, result.Escalation.Reason, "one model")
+	assert.Len(t, result.Escalation.Attempts, 1)
+}
+
+func TestExecute_Escalation_FallsBackToAnswerConfidence(t *testing.T) {
+	tests := []struct {
+		name   string
+		scorer AnswerScorer
+	}{
+		{"no scorer", nil},
+		{"scoring fails", fixedScorer{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &tierClient{}
+			ctrl := newEscalationController(t, client, tt.scorer, nil)
+
+			result, err := ctrl.Execute(context.Background(), "What does this function return?")
+			require.NoError(t, err)
+
+			// The terse answer's own confidence is below the threshold
+			assert.Equal(t, []string{"routed", "powerful"}, client.tiers)
+			require.NotNil(t, result.Escalation)
+			assert.True(t, result.Escalation.Escalated)
+			assert.Greater(t, result.Escalation.Attempts[0].Confidence, 0.0)
+			assert.Contains(t, result.Escalation.Reason, "below threshold")
+		})
+	}
+}
+
+func TestExecute_Escalation_Disabled(t *testing.T) {
+	client := &tierClient{}
+	ctrl := newEscalationController(t, client, fixedScorer{"shaky answer": 0.1}, func(cfg *ControllerConfig) {
+		cfg.Escalation = EscalationPolicy{}
+	})
+
+	result, err := ctrl.Execute(context.Background(), "What does this function return?")
+	require.NoError(t, err)
+	assert.Len(t, client.tiers, 1)
+	assert.Nil(t, result.Escalation)
+}
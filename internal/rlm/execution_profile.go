@@ -0,0 +1,44 @@
+package rlm
+
+import (
+	"github.com/rand/recurse/internal/rlm/orchestrator"
+)
+
+// Re-export execution profiles from the orchestrator package.
+type (
+	ExecutionProfile = orchestrator.ExecutionProfile
+	ProfileSettings  = orchestrator.ProfileSettings
+	Verification     = orchestrator.Verification
+)
+
+const (
+	ModeStandard      = orchestrator.ModeStandard
+	ModeBestEffort    = orchestrator.ModeBestEffort
+	ModeHighAssurance = orchestrator.ModeHighAssurance
+
+	VerifyConfigured = orchestrator.VerifyConfigured
+	VerifyNone       = orchestrator.VerifyNone
+	VerifyAll        = orchestrator.VerifyAll
+)
+
+var (
+	WithExecutionProfile = orchestrator.WithExecutionProfile
+	ExecutionProfileFrom = orchestrator.ExecutionProfileFrom
+	ProfileSettingsFrom  = orchestrator.ProfileSettingsFrom
+)
+
+// withProfile returns cfg with the RLM loop settings overridden by a
+// profile's: its iteration limit and whether computed answers are
+// re-derived.
+func (cfg RLMConfig) withProfile(settings ProfileSettings) RLMConfig {
+	if settings.MaxIterations > 0 {
+		cfg.MaxIterations = settings.MaxIterations
+	}
+	switch settings.Verification {
+	case VerifyNone:
+		cfg.VerifyComputation = false
+	case VerifyAll:
+		cfg.VerifyComputation = true
+	}
+	return cfg
+}
@@ -0,0 +1,260 @@
+package rlm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rand/recurse/internal/rlm/meta"
+	"github.com/rand/recurse/internal/rlm/repl"
+)
+
+// cappedTierClient is a tierClient that also records the maximum tier of
+// each call's context.
+type cappedTierClient struct {
+	tierClient
+	maxTiers []string
+}
+
+func (c *cappedTierClient) Complete(ctx context.Context, prompt string, maxTokens int) (string, error) {
+	maxTier := "uncapped"
+	if tier, ok := meta.MaxTierFrom(ctx); ok {
+		maxTier = tier.String()
+	}
+	c.maxTiers = append(c.maxTiers, maxTier)
+	return c.tierClient.Complete(ctx, prompt, maxTokens)
+}
+
+func TestExecutionProfile_Settings(t *testing.T) {
+	assert.Equal(t, ProfileSettings{}, ModeStandard.Settings())
+	assert.Equal(t, ProfileSettings{}, ExecutionProfile("unknown").Settings())
+
+	bestEffort := ModeBestEffort.Settings()
+	assert.True(t, bestEffort.TierCapped)
+	assert.Equal(t, meta.TierFast, bestEffort.MaxTier)
+	assert.Equal(t, VerifyNone, bestEffort.Verification)
+	require.NotNil(t, bestEffort.Escalation)
+	assert.False(t, bestEffort.Escalation.Enabled())
+	assert.Less(t, bestEffort.MaxIterations, DefaultRLMConfig().MaxIterations)
+	assert.Less(t, bestEffort.MaxRecursionDepth, DefaultControllerConfig().MaxRecursionDepth)
+	assert.True(t, bestEffort.AcceptPartial)
+
+	highAssurance := ModeHighAssurance.Settings()
+	assert.False(t, highAssurance.TierCapped)
+	assert.Equal(t, VerifyAll, highAssurance.Verification)
+	require.NotNil(t, highAssurance.Escalation)
+	assert.True(t, highAssurance.Escalation.Enabled())
+	assert.Greater(t, highAssurance.MaxIterations, DefaultRLMConfig().MaxIterations)
+	assert.Greater(t, highAssurance.MaxRecursionDepth, DefaultControllerConfig().MaxRecursionDepth)
+	assert.False(t, highAssurance.AcceptPartial)
+}
+
+func TestWithExecutionProfile(t *testing.T) {
+	ctx := context.Background()
+	assert.Equal(t, ModeStandard, ExecutionProfileFrom(ctx))
+
+	ctx = WithExecutionProfile(context.Background(), ModeBestEffort)
+	assert.Equal(t, ModeBestEffort, ExecutionProfileFrom(ctx))
+	tier, ok := meta.MaxTierFrom(ctx)
+	require.True(t, ok)
+	assert.Equal(t, meta.TierFast, tier)
+
+	ctx = WithExecutionProfile(context.Background(), ModeHighAssurance)
+	assert.Equal(t, ModeHighAssurance, ExecutionProfileFrom(ctx))
+	_, ok = meta.MaxTierFrom(ctx)
+	assert.False(t, ok, "high assurance does not cap the tier")
+}
+
+func TestRLMConfig_WithProfile(t *testing.T) {
+	cfg := DefaultRLMConfig()
+	cfg.VerifyComputation = true
+
+	bestEffort := cfg.withProfile(ModeBestEffort.Settings())
+	assert.Equal(t, 3, bestEffort.MaxIterations)
+	assert.False(t, bestEffort.VerifyComputation)
+
+	cfg.VerifyComputation = false
+	highAssurance := cfg.withProfile(ModeHighAssurance.Settings())
+	assert.Equal(t, 20, highAssurance.MaxIterations)
+	assert.True(t, highAssurance.VerifyComputation)
+
+	assert.Equal(t, cfg, cfg.withProfile(ModeStandard.Settings()))
+}
+
+func TestExecute_BestEffortSkipsEscalation(t *testing.T) {
+	client := &cappedTierClient{}
+	ctrl := newEscalationController(t, client, fixedScorer{"shaky answer": 0.2, "solid answer": 0.9}, nil)
+
+	ctx := WithExecutionProfile(context.Background(), ModeBestEffort)
+	result, err := ctrl.Execute(ctx, "What does this function return?")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"routed"}, client.tiers, "not escalated despite the configured policy")
+	assert.Equal(t, []string{"fast"}, client.maxTiers)
+	assert.Nil(t, result.Escalation)
+	assert.Equal(t, "shaky answer", result.Response)
+	assert.Equal(t, ModeBestEffort, result.Profile)
+}
+
+func TestExecute_HighAssuranceEscalates(t *testing.T) {
+	client := &cappedTierClient{}
+	ctrl := newEscalationController(t, client, fixedScorer{"shaky answer": 0.75, "solid answer": 0.9}, func(cfg *ControllerConfig) {
+		cfg.Escalation = EscalationPolicy{}
+	})
+
+	ctx := WithExecutionProfile(context.Background(), ModeHighAssurance)
+	result, err := ctrl.Execute(ctx, "What does this function return?")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"routed", "powerful"}, client.tiers)
+	assert.Equal(t, []string{"uncapped", "uncapped"}, client.maxTiers)
+	require.NotNil(t, result.Escalation)
+	assert.True(t, result.Escalation.Escalated)
+	assert.Equal(t, 0.8, result.Escalation.Threshold)
+	assert.Equal(t, "solid answer", result.Response)
+	assert.Equal(t, ModeHighAssurance, result.Profile)
+}
+
+func TestExecute_HighAssuranceUsesAssuranceScorer(t *testing.T) {
+	client := &cappedTierClient{}
+	ctrl := newEscalationController(t, client, nil, func(cfg *ControllerConfig) {
+		cfg.Escalation = EscalationPolicy{MinConfidence: 0.5}
+	})
+	ctrl.SetAssuranceScorer(fixedScorer{"shaky answer": 0.3, "solid answer": 0.9})
+
+	// Standard executions are judged by their synthesis confidence
+	result, err := ctrl.Execute(context.Background(), "What does this function return?")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"routed"}, client.tiers)
+	require.NotNil(t, result.Escalation)
+	assert.False(t, result.Escalation.Escalated)
+
+	client.tiers, client.maxTiers = nil, nil
+	ctx := WithExecutionProfile(context.Background(), ModeHighAssurance)
+	result, err = ctrl.Execute(ctx, "What does this function return?")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"routed", "powerful"}, client.tiers)
+	require.NotNil(t, result.Escalation)
+	assert.Contains(t, result.Escalation.Reason, "confidence 0.30 below threshold 0.80")
+	assert.Equal(t, "solid answer", result.Response)
+	assert.Equal(t, 0.9, result.Confidence)
+}
+
+func TestExecute_BestEffortAcceptsPartial(t *testing.T) {
+	metaClient := &mockLLMClient{
+		responses: []string{`{"action": "DECOMPOSE", "params": {"strategy": "file"}, "reasoning": "Four files"}`},
+	}
+	metaCtrl := meta.NewController(GuardCalls(metaClient), meta.DefaultConfig())
+	mainClient := &escalatingClient{}
+
+	cfg := DefaultControllerConfig()
+	cfg.StoreDecisions = false
+	cfg.MaxLLMCalls = 5
+	ctrl := NewController(metaCtrl, mainClient, createTestStore(t), cfg)
+
+	var task strings.Builder
+	for _, name := range []string{"a.go", "b.go", "c.go", "d.go"} {
+		fmt.Fprintf(&task, "// File: %s\npackage %s\n", name, strings.TrimSuffix(name, ".go"))
+	}
+
+	ctx := WithExecutionProfile(context.Background(), ModeBestEffort)
+	result, err := ctrl.Execute(ctx, task.String())
+	require.NoError(t, err, "the partial answer is accepted")
+
+	require.NotNil(t, result)
+	assert.True(t, result.Partial)
+	assert.Contains(t, result.Response, "answer 1")
+	assert.Contains(t, result.Error, "call limit", "the error still says why")
+}
+
+func TestSubCallRouter_ProfileVerification(t *testing.T) {
+	req := SubCallRequest{Prompt: "Summarize", Context: "text", Model: "fast"}
+
+	t.Run("high assurance verifies every result", func(t *testing.T) {
+		verifier := &recordingResultVerifier{}
+		router := newSamplingRouter("Summary", VerificationSampling{}, verifier)
+		ctx := WithExecutionProfile(context.Background(), ModeHighAssurance)
+
+		for range 5 {
+			resp := router.Call(ctx, req)
+			require.Empty(t, resp.Error)
+			assert.NotNil(t, resp.ResultCheck)
+		}
+		assert.Len(t, verifier.verified, 5)
+	})
+
+	t.Run("best effort verifies none", func(t *testing.T) {
+		verifier := &recordingResultVerifier{}
+		router := newSamplingRouter("Summary", VerificationSampling{Rate: 1}, verifier)
+		ctx := WithExecutionProfile(context.Background(), ModeBestEffort)
+
+		for range 5 {
+			resp := router.Call(ctx, req)
+			require.Empty(t, resp.Error)
+			assert.Nil(t, resp.ResultCheck)
+		}
+		assert.Empty(t, verifier.verified)
+	})
+}
+
+func TestSubCallRouter_BestEffortCapsRequestedTier(t *testing.T) {
+	router := NewSubCallRouter(SubCallConfig{Client: &subCallMockClient{response: "ok"}})
+	ctx := WithExecutionProfile(context.Background(), ModeBestEffort)
+
+	resp := router.Call(ctx, SubCallRequest{Prompt: "Prove this", Model: "powerful"})
+	require.Empty(t, resp.Error)
+	require.NotNil(t, resp.Route)
+	assert.Equal(t, meta.TierFast, resp.Route.Tier)
+	assert.True(t, resp.Route.MaxTierApplied)
+	assert.Contains(t, resp.Route.TierReason, "lowered to the maximum tier")
+}
+
+func TestExecuteRLM_BestEffortAcceptsPartial(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	replMgr, err := repl.NewManager(repl.Options{})
+	require.NoError(t, err)
+	require.NoError(t, replMgr.Start(ctx))
+	defer replMgr.Stop()
+
+	var responses []string
+	for i := 1; i <= 10; i++ {
+		responses = append(responses, fmt.Sprintf("```python\nprint('partial %d')\n```", i))
+	}
+	client := &wrapperMockLLMClient{responses: responses}
+	w := &Wrapper{replMgr: replMgr, client: client}
+
+	prepared := &PreparedPrompt{Mode: ModeRLM, SystemPrompt: "system", FinalPrompt: "task"}
+	result, err := w.ExecuteRLMWithConfig(WithExecutionProfile(ctx, ModeBestEffort), prepared, RLMConfig{
+		MaxIterations:    10,
+		MaxTokensPerCall: 1024,
+		Timeout:          10 * time.Second,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, 3, result.Iterations, "capped by the profile")
+	assert.Empty(t, result.Error)
+	assert.Nil(t, result.Resume)
+	assert.True(t, result.Partial)
+	assert.Equal(t, "partial 3", result.FinalOutput)
+}
+
+func TestAnswerKey_DiffersByProfile(t *testing.T) {
+	svc, err := NewService(&mockLLMClient{}, DefaultServiceConfig())
+	require.NoError(t, err)
+	defer svc.Stop()
+
+	ctx := context.Background()
+	standard := svc.answerKey(ctx, "task")
+	assert.Equal(t, standard, svc.answerKey(WithExecutionProfile(ctx, ModeStandard), "task"))
+	assert.NotEqual(t, standard, svc.answerKey(WithExecutionProfile(ctx, ModeBestEffort), "task"))
+	assert.NotEqual(t,
+		svc.answerKey(WithExecutionProfile(ctx, ModeBestEffort), "task"),
+		svc.answerKey(WithExecutionProfile(ctx, ModeHighAssurance), "task"))
+}
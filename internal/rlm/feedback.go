@@ -0,0 +1,152 @@
+package rlm
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxFeedbackOutput is how much REPL output execution feedback shows.
+const maxFeedbackOutput = 2000
+
+// truncateFeedbackOutput shortens REPL output to at most limit bytes for
+// execution feedback. JSON output is cut between elements so what is shown
+// still parses; other output is cut at a line boundary. The marker says how
+// much was shown and how to read the rest, and is empty when output fits.
+// Its sizes and offsets count characters, as Python's peek does.
+func truncateFeedbackOutput(output string, limit int) (shown, marker string) {
+	if len(output) <= limit {
+		return output, ""
+	}
+	total := utf8.RuneCountInString(output)
+
+	if shown, kept, items, ok := truncateJSON(output, limit); ok {
+		return shown, fmt.Sprintf("[output truncated: valid JSON with %d/%d %s shown, %d chars in full; "+
+			"assign the result to a variable and index it for the rest]",
+			kept, items, jsonUnit(output), total)
+	}
+
+	shown = truncateAtLine(output, limit)
+	chars := utf8.RuneCountInString(shown)
+	return shown, fmt.Sprintf("[output truncated: %d/%d chars shown, %d/%d lines; "+
+		"assign the output to a variable and use peek(var, %d) for the rest]",
+		chars, total, lineCount(shown), lineCount(output), chars)
+}
+
+// truncateAtLine cuts s to at most limit bytes, at the last line break if
+// one falls in the second half of the limit and at a rune boundary otherwise.
+func truncateAtLine(s string, limit int) string {
+	cut := s[:limit]
+	if idx := strings.LastIndexByte(cut, '\n'); idx >= limit/2 {
+		return cut[:idx]
+	}
+	end := limit
+	for end > 0 && !utf8.RuneStart(s[end]) {
+		end--
+	}
+	return s[:end]
+}
+
+// lineCount counts the lines of s, not counting a trailing newline.
+func lineCount(s string) int {
+	s = strings.TrimSuffix(s, "\n")
+	if s == "" {
+		return 0
+	}
+	return strings.Count(s, "\n") + 1
+}
+
+// truncateJSON keeps the leading elements of a JSON array or the leading
+// members of a JSON object that fit in limit, reformatted like the original
+// (indented or compact). It reports false when output is not a JSON array
+// or object or not even one element fits.
+func truncateJSON(output string, limit int) (shown string, kept, total int, ok bool) {
+	trimmed := strings.TrimSpace(output)
+	if trimmed == "" || (trimmed[0] != '[' && trimmed[0] != '{') || !json.Valid([]byte(trimmed)) {
+		return "", 0, 0, false
+	}
+
+	var items []json.RawMessage
+	var err error
+	if trimmed[0] == '[' {
+		err = json.Unmarshal([]byte(trimmed), &items)
+	} else {
+		items, err = objectMembers(trimmed)
+	}
+	if err != nil || len(items) == 0 {
+		return "", 0, 0, false
+	}
+
+	indented := strings.Contains(trimmed, "\n")
+	opening, closing := trimmed[:1], trimmed[len(trimmed)-1:]
+	for n := 1; n <= len(items); n++ {
+		candidate, err := formatJSON(opening, closing, items[:n], indented)
+		if err != nil || len(candidate) > limit {
+			break
+		}
+		shown, kept = candidate, n
+	}
+	if kept == 0 {
+		return "", 0, 0, false
+	}
+	return shown, kept, len(items), true
+}
+
+// objectMembers splits a JSON object into its `"key":value` members, in
+// order.
+func objectMembers(object string) ([]json.RawMessage, error) {
+	dec := json.NewDecoder(strings.NewReader(object))
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+	var members []json.RawMessage
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return nil, err
+		}
+		name, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, json.RawMessage(string(name)+":"+string(value)))
+	}
+	return members, nil
+}
+
+// formatJSON joins items between the opening and closing brackets and
+// formats the result.
+func formatJSON(opening, closing string, items []json.RawMessage, indented bool) (string, error) {
+	var raw bytes.Buffer
+	raw.WriteString(opening)
+	for i, item := range items {
+		if i > 0 {
+			raw.WriteByte(',')
+		}
+		raw.Write(item)
+	}
+	raw.WriteString(closing)
+
+	var out bytes.Buffer
+	var err error
+	if indented {
+		err = json.Indent(&out, raw.Bytes(), "", "  ")
+	} else {
+		err = json.Compact(&out, raw.Bytes())
+	}
+	return out.String(), err
+}
+
+// jsonUnit names the parts of a JSON array or object.
+func jsonUnit(output string) string {
+	if strings.HasPrefix(strings.TrimSpace(output), "{") {
+		return "keys"
+	}
+	return "elements"
+}
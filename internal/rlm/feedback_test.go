@@ -0,0 +1,105 @@
+package rlm
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTruncateFeedbackOutput_FitsUnchanged(t *testing.T) {
+	shown, marker := truncateFeedbackOutput("short output\n", 2000)
+	assert.Equal(t, "short output\n", shown)
+	assert.Empty(t, marker)
+}
+
+func TestTruncateFeedbackOutput_LineBoundary(t *testing.T) {
+	var sb strings.Builder
+	for i := 0; i < 300; i++ {
+		fmt.Fprintf(&sb, "row %03d: value=%d status=ok\n", i, i*7)
+	}
+	output := sb.String()
+
+	shown, marker := truncateFeedbackOutput(output, 2000)
+	require.LessOrEqual(t, len(shown), 2000)
+	assert.True(t, strings.HasPrefix(output, shown))
+
+	// Whole lines only: the next character in the original is a line break
+	assert.Equal(t, byte('\n'), output[len(shown)])
+	lines := strings.Split(shown, "\n")
+	assert.Regexp(t, `^row \d{3}: value=\d+ status=ok$`, lines[len(lines)-1])
+
+	assert.Equal(t, fmt.Sprintf("[output truncated: %d/%d chars shown, %d/300 lines; "+
+		"assign the output to a variable and use peek(var, %d) for the rest]",
+		len(shown), len(output), len(lines), len(shown)), marker)
+}
+
+func TestTruncateFeedbackOutput_LongLine(t *testing.T) {
+	// No usable line break, so the cut falls inside the line on a rune boundary
+	output := "header\n" + strings.Repeat("é", 3000)
+
+	shown, marker := truncateFeedbackOutput(output, 2000)
+	assert.LessOrEqual(t, len(shown), 2000)
+	assert.Greater(t, len(shown), 1990)
+	assert.True(t, utf8.ValidString(shown))
+
+	// Sizes and the peek offset count characters, not bytes
+	chars := utf8.RuneCountInString(shown)
+	assert.Equal(t, 7+(len(shown)-7)/2, chars)
+	assert.Contains(t, marker, fmt.Sprintf("%d/3007 chars shown, 2/2 lines", chars))
+	assert.Contains(t, marker, fmt.Sprintf("peek(var, %d)", chars))
+	assert.Equal(t, shown, string([]rune(output)[:chars]))
+}
+
+func TestTruncateFeedbackOutput_JSON(t *testing.T) {
+	records := make([]map[string]any, 200)
+	for i := range records {
+		records[i] = map[string]any{"id": i, "name": fmt.Sprintf("record-%d", i), "tags": []string{"a", "b"}}
+	}
+
+	t.Run("compact array", func(t *testing.T) {
+		data, err := json.Marshal(records)
+		require.NoError(t, err)
+
+		shown, marker := truncateFeedbackOutput(string(data), 2000)
+		require.LessOrEqual(t, len(shown), 2000)
+
+		var kept []map[string]any
+		require.NoError(t, json.Unmarshal([]byte(shown), &kept), "shown output stays valid JSON")
+		require.NotEmpty(t, kept)
+		assert.NotContains(t, shown, "\n")
+		assert.Equal(t, fmt.Sprintf("[output truncated: valid JSON with %d/200 elements shown, %d chars in full; "+
+			"assign the result to a variable and index it for the rest]", len(kept), len(data)), marker)
+	})
+
+	t.Run("indented object", func(t *testing.T) {
+		object := make(map[string]any, len(records))
+		for i, r := range records {
+			object[fmt.Sprintf("key%03d", i)] = r
+		}
+		data, err := json.MarshalIndent(object, "", "  ")
+		require.NoError(t, err)
+
+		shown, marker := truncateFeedbackOutput(string(data)+"\n", 2000)
+		require.LessOrEqual(t, len(shown), 2000)
+
+		var kept map[string]any
+		require.NoError(t, json.Unmarshal([]byte(shown), &kept))
+		assert.Contains(t, kept, "key000", "members keep their order")
+		assert.True(t, strings.HasPrefix(string(data), shown[:len(shown)-2]), "indentation is preserved")
+		assert.Contains(t, marker, fmt.Sprintf("valid JSON with %d/200 keys shown", len(kept)))
+	})
+
+	t.Run("oversized element falls back to lines", func(t *testing.T) {
+		data, err := json.Marshal([]string{strings.Repeat("x", 5000)})
+		require.NoError(t, err)
+
+		shown, marker := truncateFeedbackOutput(string(data), 2000)
+		assert.Len(t, shown, 2000)
+		assert.Contains(t, marker, "peek(var, 2000)")
+	})
+}
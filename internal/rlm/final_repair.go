@@ -0,0 +1,113 @@
+package rlm
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// repairPythonLiteral converts the repr of a Python literal (dicts, lists,
+// tuples, strings, numbers, None, True, False) into JSON. Strings are
+// decoded and re-encoded whole, so quotes and keywords inside them are
+// preserved.
+func repairPythonLiteral(repr string) (string, error) {
+	var sb strings.Builder
+	for i := 0; i < len(repr); {
+		c := repr[i]
+		switch {
+		case c == '\'' || c == '"':
+			s, n, err := decodePythonString(repr[i:])
+			if err != nil {
+				return "", err
+			}
+			encoded, err := json.Marshal(s)
+			if err != nil {
+				return "", err
+			}
+			sb.Write(encoded)
+			i += n
+		case isIdentStart(c):
+			j := i
+			for j < len(repr) && (isIdentStart(repr[j]) || (repr[j] >= '0' && repr[j] <= '9')) {
+				j++
+			}
+			switch word := repr[i:j]; word {
+			case "None":
+				sb.WriteString("null")
+			case "True":
+				sb.WriteString("true")
+			case "False":
+				sb.WriteString("false")
+			default:
+				return "", fmt.Errorf("unsupported Python literal %q at offset %d", word, i)
+			}
+			i = j
+		case c == '(':
+			sb.WriteByte('[')
+			i++
+		case c == ')':
+			sb.WriteByte(']')
+			i++
+		default:
+			sb.WriteByte(c)
+			i++
+		}
+	}
+	if !json.Valid([]byte(sb.String())) {
+		return "", fmt.Errorf("repaired literal is not valid JSON")
+	}
+	return sb.String(), nil
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// decodePythonString decodes the quoted string literal at the start of s,
+// returning its value and the number of bytes it spans.
+func decodePythonString(s string) (string, int, error) {
+	quote := s[0]
+	var sb strings.Builder
+	for i := 1; i < len(s); {
+		c := s[i]
+		switch {
+		case c == quote:
+			return sb.String(), i + 1, nil
+		case c != '\\':
+			sb.WriteByte(c)
+			i++
+		case i+1 >= len(s):
+			return "", 0, fmt.Errorf("unterminated escape in string literal")
+		default:
+			esc := s[i+1]
+			i += 2
+			switch esc {
+			case 'n':
+				sb.WriteByte('\n')
+			case 't':
+				sb.WriteByte('\t')
+			case 'r':
+				sb.WriteByte('\r')
+			case '\\', '\'', '"':
+				sb.WriteByte(esc)
+			case 'x', 'u', 'U':
+				width := map[byte]int{'x': 2, 'u': 4, 'U': 8}[esc]
+				if i+width > len(s) {
+					return "", 0, fmt.Errorf("short \\%c escape in string literal", esc)
+				}
+				code, err := strconv.ParseUint(s[i:i+width], 16, 32)
+				if err != nil {
+					return "", 0, fmt.Errorf("bad \\%c escape in string literal: %w", esc, err)
+				}
+				sb.WriteRune(rune(code))
+				i += width
+			default:
+				// Python keeps unknown escapes verbatim
+				sb.WriteByte('\\')
+				sb.WriteByte(esc)
+			}
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated string literal")
+}
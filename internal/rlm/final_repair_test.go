@@ -0,0 +1,122 @@
+package rlm
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rand/recurse/internal/rlm/repl"
+)
+
+func TestRepairPythonLiteral(t *testing.T) {
+	// As printed by Python's repr()
+	repr := `{'content': 'It\'s "None" of your business', 'type': 'json', ` +
+		`'metadata': {'ok': True, 'n': None, 't': (1, 2.5), 'u': 'café \x01', 'b': 'back\\slash', "q": "don't"}}`
+
+	repaired, err := repairPythonLiteral(repr)
+	require.NoError(t, err)
+
+	var got map[string]any
+	require.NoError(t, json.Unmarshal([]byte(repaired), &got))
+	assert.Equal(t, map[string]any{
+		"content": `It's "None" of your business`,
+		"type":    "json",
+		"metadata": map[string]any{
+			"ok": true,
+			"n":  nil,
+			"t":  []any{1.0, 2.5},
+			"u":  "café \x01",
+			"b":  `back\slash`,
+			"q":  "don't",
+		},
+	}, got)
+}
+
+func TestRepairPythonLiteral_Invalid(t *testing.T) {
+	for _, repr := range []string{
+		`{'a': 'unterminated}`,
+		`{'a': inf}`,
+		`<object at 0x10>`,
+		`{'a': '\x4'}`,
+	} {
+		_, err := repairPythonLiteral(repr)
+		assert.Error(t, err, repr)
+	}
+}
+
+func TestGetFinalOutputWithMetadata_RoundTrip(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	replMgr, err := repl.NewManager(repl.Options{})
+	require.NoError(t, err)
+	require.NoError(t, replMgr.Start(ctx))
+	defer replMgr.Stop()
+
+	w := &Wrapper{replMgr: replMgr}
+
+	// FINAL_JSON with apostrophes, Python keywords as data and nested quotes
+	_, err = replMgr.Execute(ctx, `FINAL_JSON({"quote": "it's", "word": "None", "flags": ["True", False], "nested": 'say "hi" and \'bye\'', "none": None})`)
+	require.NoError(t, err)
+
+	output, err := w.GetFinalOutputWithMetadata(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, output)
+	assert.Equal(t, "json", output.Type)
+
+	var payload map[string]any
+	require.NoError(t, json.Unmarshal([]byte(output.Content), &payload))
+	assert.Equal(t, map[string]any{
+		"quote":  "it's",
+		"word":   "None",
+		"flags":  []any{"True", false},
+		"nested": `say "hi" and 'bye'`,
+		"none":   nil,
+	}, payload)
+
+	// Plain FINAL text with the same hazards
+	_, err = replMgr.Execute(ctx, `FINAL("It's None of True's business")`)
+	require.NoError(t, err)
+	output, err = w.GetFinalOutputWithMetadata(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, output)
+	assert.Equal(t, "It's None of True's business", output.Content)
+	assert.Equal(t, "text", output.Type)
+
+	_, err = replMgr.Execute(ctx, "clear_final_output()")
+	require.NoError(t, err)
+	output, err = w.GetFinalOutputWithMetadata(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, output)
+}
+
+func TestGetFinalOutputWithMetadata_LegacyBootstrap(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	replMgr, err := repl.NewManager(repl.Options{})
+	require.NoError(t, err)
+	require.NoError(t, replMgr.Start(ctx))
+	defer replMgr.Stop()
+
+	w := &Wrapper{replMgr: replMgr}
+
+	// Simulate a bootstrap without get_final_metadata_json
+	_, err = replMgr.Execute(ctx, "del get_final_metadata_json")
+	require.NoError(t, err)
+	_, err = replMgr.Execute(ctx, `FINAL_JSON({"answer": "it's \"None\""})`)
+	require.NoError(t, err)
+
+	output, err := w.GetFinalOutputWithMetadata(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, output)
+	assert.Equal(t, "json", output.Type)
+
+	var payload map[string]string
+	require.NoError(t, json.Unmarshal([]byte(output.Content), &payload))
+	assert.Equal(t, `it's "None"`, payload["answer"])
+}
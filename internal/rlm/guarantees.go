@@ -10,12 +10,12 @@ import (
 	"time"
 )
 
-// Guarantee-related errors.
+// Guarantee-related errors. The budget and call limits match ErrBudgetExceeded.
 var (
-	ErrBudgetExhausted  = errors.New("execution budget exhausted")
+	ErrBudgetExhausted  = fmt.Errorf("execution %w", ErrBudgetExceeded)
 	ErrTimeoutExceeded  = errors.New("execution timeout exceeded")
 	ErrMaxDepthExceeded = errors.New("maximum recursion depth exceeded")
-	ErrMaxCallsExceeded = errors.New("maximum LLM calls exceeded")
+	ErrMaxCallsExceeded = fmt.Errorf("%w: maximum LLM calls reached", ErrBudgetExceeded)
 )
 
 // ExecutionGuarantees provides hard limits and tracking for RLM execution.
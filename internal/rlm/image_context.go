@@ -0,0 +1,125 @@
+package rlm
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+
+	"github.com/rand/recurse/internal/rlm/meta"
+)
+
+// ImageContext returns a context source for an image, such as a diagram or
+// screenshot. A vision-capable client receives the image itself; for a
+// text-only one it is replaced by a text surrogate before externalization.
+func ImageContext(name, mediaType string, data []byte) ContextSource {
+	return ContextSource{
+		Name: name,
+		Type: ContextTypeImage,
+		Metadata: map[string]any{
+			"source":     name,
+			"media_type": mediaType,
+		},
+		Image: &meta.Image{Name: name, MediaType: mediaType, Data: data},
+	}
+}
+
+// imagesOnceNotice tells the model that the images it sees arrive with the
+// first message of an RLM execution only.
+const imagesOnceNotice = "The attached images are sent with this message only. " +
+	"Record what you need from them, e.g. in REPL variables, before moving on."
+
+// ImageDescriber makes the text surrogate of an image for models that
+// cannot see it: a caption, an OCR transcript, or both.
+type ImageDescriber interface {
+	DescribeImage(ctx context.Context, image meta.Image) (string, error)
+}
+
+// ImageDescriberFunc adapts a function to an ImageDescriber.
+type ImageDescriberFunc func(ctx context.Context, image meta.Image) (string, error)
+
+// DescribeImage calls f.
+func (f ImageDescriberFunc) DescribeImage(ctx context.Context, image meta.Image) (string, error) {
+	return f(ctx, image)
+}
+
+// describeImagePrompt asks a vision model for a surrogate another model
+// can reason over without the image.
+const describeImagePrompt = `Describe this image for someone who cannot see it. Transcribe all visible text exactly, then describe the structure: for a diagram, its components and how they connect; for a screenshot, the layout and any errors or values shown. Be complete and factual; do not speculate.`
+
+// describeImageMaxTokens bounds a vision model's description of one image.
+const describeImageMaxTokens = 2048
+
+// NewVisionDescriber returns an ImageDescriber that has a vision-capable
+// client caption and transcribe each image, for use when the model doing
+// the work is text-only.
+func NewVisionDescriber(client meta.MultimodalClient) ImageDescriber {
+	return ImageDescriberFunc(func(ctx context.Context, image meta.Image) (string, error) {
+		return client.CompleteWithImages(ctx, describeImagePrompt, []meta.Image{image}, describeImageMaxTokens)
+	})
+}
+
+// resolveImages separates the images among contexts from the text. When
+// the client accepts images they are returned for sending with the prompt
+// and removed from contexts; otherwise each is replaced by its text
+// surrogate, which is externalized like any other context.
+func (w *Wrapper) resolveImages(ctx context.Context, contexts []ContextSource) ([]ContextSource, []meta.Image) {
+	hasImages := false
+	for _, c := range contexts {
+		if c.Image != nil {
+			hasImages = true
+			break
+		}
+	}
+	if !hasImages {
+		return contexts, nil
+	}
+
+	_, passThrough := meta.AcceptsImages(w.client)
+	resolved := make([]ContextSource, 0, len(contexts))
+	var images []meta.Image
+	for _, c := range contexts {
+		switch {
+		case c.Image == nil:
+			resolved = append(resolved, c)
+		case passThrough:
+			images = append(images, *c.Image)
+		default:
+			resolved = append(resolved, w.imageSurrogate(ctx, c))
+		}
+	}
+	return resolved, images
+}
+
+// imageSurrogate returns src with its image replaced by a text surrogate.
+// Without a describer, or if describing fails, the surrogate only says
+// what the image is.
+func (w *Wrapper) imageSurrogate(ctx context.Context, src ContextSource) ContextSource {
+	image := *src.Image
+	header := fmt.Sprintf("[Image %s (%s, %d bytes)", image.Name, image.MediaType, len(image.Data))
+
+	description := ""
+	if w.imageDescriber != nil {
+		var err error
+		description, err = w.imageDescriber.DescribeImage(ctx, image)
+		if err != nil {
+			slog.Warn("Failed to describe image, using a placeholder", "image", image.Name, "error", err)
+			description = ""
+		}
+	}
+
+	metadata := maps.Clone(src.Metadata)
+	if metadata == nil {
+		metadata = make(map[string]any)
+	}
+	if description == "" {
+		src.Content = header + ": no description available]"
+		metadata["surrogate"] = "placeholder"
+	} else {
+		src.Content = header + ", described as text]\n" + description
+		metadata["surrogate"] = "description"
+	}
+	src.Metadata = metadata
+	src.Image = nil
+	return src
+}
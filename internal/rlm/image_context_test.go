@@ -0,0 +1,220 @@
+package rlm
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rand/recurse/internal/rlm/meta"
+	"github.com/rand/recurse/internal/rlm/repl"
+)
+
+// visionMockClient is a wrapperMockLLMClient whose model may accept images.
+type visionMockClient struct {
+	wrapperMockLLMClient
+	vision bool
+
+	imageCalls [][]meta.Image
+}
+
+func (m *visionMockClient) SupportsImages() bool { return m.vision }
+
+func (m *visionMockClient) CompleteWithImages(ctx context.Context, prompt string, images []meta.Image, maxTokens int) (string, error) {
+	m.imageCalls = append(m.imageCalls, images)
+	return m.Complete(ctx, prompt, maxTokens)
+}
+
+var testDiagram = []byte("\x89PNG\r\n\x1a\n fake diagram bytes")
+
+func imageTestContexts() []ContextSource {
+	return []ContextSource{
+		ImageContext("architecture.png", "image/png", testDiagram),
+		{Name: "notes", Type: ContextTypeCustom, Content: "The gateway forwards requests to the billing service."},
+	}
+}
+
+func TestPrepareContext_ImageToVisionClient(t *testing.T) {
+	client := &visionMockClient{vision: true}
+	w := NewWrapper(&Service{}, DefaultWrapperConfig())
+	w.SetLLMClient(client)
+
+	prepared, err := w.PrepareContext(context.Background(), "Which service does the gateway call?", imageTestContexts())
+	require.NoError(t, err)
+
+	require.Len(t, prepared.Images, 1)
+	assert.Equal(t, "architecture.png", prepared.Images[0].Name)
+	assert.Equal(t, "image/png", prepared.Images[0].MediaType)
+	assert.Equal(t, testDiagram, prepared.Images[0].Data)
+	assert.Contains(t, prepared.FinalPrompt, "billing service", "text contexts are kept")
+	assert.NotContains(t, prepared.FinalPrompt, "fake diagram bytes")
+	assert.NotContains(t, prepared.FinalPrompt, "[Image", "no surrogate is made")
+}
+
+func TestPrepareContext_ImageSurrogateForTextOnlyClient(t *testing.T) {
+	t.Run("described", func(t *testing.T) {
+		var described []meta.Image
+		w := NewWrapper(&Service{}, DefaultWrapperConfig())
+		w.SetLLMClient(&wrapperMockLLMClient{})
+		w.SetImageDescriber(ImageDescriberFunc(func(ctx context.Context, image meta.Image) (string, error) {
+			described = append(described, image)
+			return "Boxes: gateway -> billing -> ledger DB", nil
+		}))
+
+		prepared, err := w.PrepareContext(context.Background(), "Which service does the gateway call?", imageTestContexts())
+		require.NoError(t, err)
+
+		assert.Empty(t, prepared.Images)
+		require.Len(t, described, 1)
+		assert.Equal(t, testDiagram, described[0].Data)
+		assert.Contains(t, prepared.FinalPrompt, "[Image architecture.png (image/png, 27 bytes), described as text]")
+		assert.Contains(t, prepared.FinalPrompt, "Boxes: gateway -> billing -> ledger DB")
+		assert.NotContains(t, prepared.FinalPrompt, "fake diagram bytes")
+	})
+
+	t.Run("vision client without a vision model", func(t *testing.T) {
+		describer := &visionMockClient{vision: true, wrapperMockLLMClient: wrapperMockLLMClient{
+			responses: []string{"A sequence diagram with three lanes."},
+		}}
+		w := NewWrapper(&Service{}, WrapperConfig{ImageDescriber: NewVisionDescriber(describer)})
+		client := &visionMockClient{vision: false}
+		w.SetLLMClient(client)
+
+		prepared, err := w.PrepareContext(context.Background(), "Summarize the diagram", imageTestContexts())
+		require.NoError(t, err)
+
+		assert.Empty(t, prepared.Images)
+		assert.Empty(t, client.imageCalls)
+		require.Len(t, describer.imageCalls, 1, "the describer's vision model saw the image")
+		assert.Contains(t, prepared.FinalPrompt, "A sequence diagram with three lanes.")
+	})
+
+	t.Run("placeholder", func(t *testing.T) {
+		w := NewWrapper(&Service{}, DefaultWrapperConfig())
+		w.SetLLMClient(&wrapperMockLLMClient{})
+		w.SetImageDescriber(ImageDescriberFunc(func(ctx context.Context, image meta.Image) (string, error) {
+			return "", errors.New("OCR unavailable")
+		}))
+
+		prepared, err := w.PrepareContext(context.Background(), "Summarize the diagram", imageTestContexts())
+		require.NoError(t, err)
+		assert.Contains(t, prepared.FinalPrompt, "[Image architecture.png (image/png, 27 bytes): no description available]")
+	})
+}
+
+func TestExecuteRLM_SendsImagesToVisionClient(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	replMgr, err := repl.NewManager(repl.Options{})
+	require.NoError(t, err)
+	require.NoError(t, replMgr.Start(ctx))
+	defer replMgr.Stop()
+
+	client := &visionMockClient{vision: true, wrapperMockLLMClient: wrapperMockLLMClient{
+		responses: []string{
+			"```python\ngateway_calls = 'billing'\n```",
+			"```python\nFINAL(gateway_calls)\n```",
+		},
+	}}
+	w := &Wrapper{replMgr: replMgr, client: client}
+	image := meta.Image{Name: "architecture.png", MediaType: "image/png", Data: testDiagram}
+	prepared := &PreparedPrompt{
+		Mode:         ModeRLM,
+		SystemPrompt: "You are an RLM assistant.",
+		FinalPrompt:  "Which service does the gateway call?",
+		Images:       []meta.Image{image},
+	}
+
+	result, err := w.ExecuteRLMWithConfig(ctx, prepared, RLMConfig{
+		MaxIterations:    2,
+		MaxTokensPerCall: 1024,
+		Timeout:          20 * time.Second,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "billing", result.FinalOutput)
+	require.Len(t, client.calls, 2)
+	require.Len(t, client.imageCalls, 1, "images are sent with the first call only")
+	assert.Equal(t, []meta.Image{image}, client.imageCalls[0])
+	assert.Contains(t, client.calls[0], imagesOnceNotice)
+}
+
+func TestContextLoader_RejectsUnresolvedImage(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	replMgr, err := repl.NewManager(repl.Options{})
+	require.NoError(t, err)
+	require.NoError(t, replMgr.Start(ctx))
+	defer replMgr.Stop()
+
+	_, err = NewContextLoader(replMgr).Load(ctx, []ContextSource{ImageContext("architecture.png", "image/png", testDiagram)})
+	assert.ErrorContains(t, err, "image has no text surrogate")
+}
+
+func TestTaskClassifier_IgnoresImageSurrogates(t *testing.T) {
+	classifier := NewTaskClassifier()
+	numbers := ContextSource{Name: "readings", Type: ContextTypeImage, Content: "1 2 3 4 5 6 7 8 9 10 11 12 13 14 15 16"}
+	prose := ContextSource{Name: "notes", Type: ContextTypeCustom, Content: "The gateway forwards requests to billing."}
+
+	class := classifier.Classify("Tell me about this", []ContextSource{numbers, prose})
+	assert.NotContains(t, class.Signals, "context:high_numeric_density")
+}
+
+// limitedMockClient is a vision client whose reported capabilities rule
+// images out and bound its context window and output.
+type limitedMockClient struct {
+	visionMockClient
+	caps meta.Capabilities
+}
+
+func (m *limitedMockClient) Capabilities() meta.Capabilities { return m.caps }
+
+func TestWrapper_DegradesToClientCapabilities(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	replMgr, err := repl.NewManager(repl.Options{})
+	require.NoError(t, err)
+	require.NoError(t, replMgr.Start(ctx))
+	defer replMgr.Stop()
+
+	client := &limitedMockClient{
+		visionMockClient: visionMockClient{vision: true, wrapperMockLLMClient: wrapperMockLLMClient{
+			responses: []string{"```python\nprint('gateway')\n```", "```python\nFINAL('billing')\n```"},
+		}},
+		caps: meta.Capabilities{ContextWindow: 8000, MaxOutputTokens: 256},
+	}
+	w := NewWrapper(&Service{}, DefaultWrapperConfig())
+	w.SetREPLManager(replMgr)
+	w.SetLLMClient(client)
+
+	// Images are described in text rather than sent
+	prepared, err := w.PrepareContext(ctx, "Which service does the gateway call?", imageTestContexts())
+	require.NoError(t, err)
+	assert.Empty(t, prepared.Images)
+	assert.Contains(t, prepared.FinalPrompt, "[Image architecture.png")
+
+	// Calls are sized to the reported limits, not the configured ones
+	result, err := w.ExecuteRLMWithConfig(ctx, &PreparedPrompt{
+		Mode:         ModeRLM,
+		SystemPrompt: "You are an RLM assistant.",
+		FinalPrompt:  "Which service does the gateway call?",
+		Images:       []meta.Image{{Name: "architecture.png", MediaType: "image/png", Data: testDiagram}},
+	}, RLMConfig{
+		MaxIterations:    3,
+		MaxTokensPerCall: 8192,
+		ContextWindow:    200000,
+		Timeout:          20 * time.Second,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "billing", result.FinalOutput)
+	assert.Empty(t, client.imageCalls)
+	require.Len(t, client.maxTokens, 2)
+	for _, limit := range client.maxTokens {
+		assert.LessOrEqual(t, limit, 256)
+	}
+}
@@ -2,6 +2,7 @@ package rlm
 
 import (
 	"context"
+	"log/slog"
 	"time"
 
 	"github.com/rand/recurse/internal/memory/hypergraph"
@@ -89,6 +90,7 @@ func (h *MemoryCallbackHandler) MemoryAddFact(content string, confidence float64
 	if err != nil {
 		return "", err
 	}
+	h.linkProvenance(node.ID)
 	return node.ID, nil
 }
 
@@ -103,9 +105,18 @@ func (h *MemoryCallbackHandler) MemoryAddFactWithEvidence(content string, confid
 	if err != nil {
 		return "", err
 	}
+	h.linkProvenance(node.ID)
 	return node.ID, nil
 }
 
+// linkProvenance links a fact to the execution in the handler's context,
+// if any. A failed link is logged; the fact is kept.
+func (h *MemoryCallbackHandler) linkProvenance(factID string) {
+	if err := LinkProvenance(h.ctx, factID); err != nil {
+		slog.Warn("Failed to link fact provenance", "fact", factID, "error", err)
+	}
+}
+
 // MemoryAddExperience adds an experience to memory.
 func (h *MemoryCallbackHandler) MemoryAddExperience(content, outcome string, success bool) (string, error) {
 	if h.taskMem == nil {
@@ -189,5 +200,11 @@ func (h *MemoryCallbackHandler) MemoryRelate(label, subjectID, objectID string)
 	return edge.ID, nil
 }
 
+// ForContext implements repl.ContextMemoryHandler, so memory callbacks work
+// with the context of the execution whose code made them.
+func (h *MemoryCallbackHandler) ForContext(ctx context.Context) repl.MemoryCallbackHandler {
+	return h.WithContext(ctx)
+}
+
 // Verify interface compliance
-var _ repl.MemoryCallbackHandler = (*MemoryCallbackHandler)(nil)
+var _ repl.ContextMemoryHandler = (*MemoryCallbackHandler)(nil)
@@ -2,9 +2,12 @@ package rlm
 
 import (
 	"context"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/rand/recurse/internal/memory/hypergraph"
+	"github.com/rand/recurse/internal/rlm/repl"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -81,3 +84,74 @@ func TestMemoryCallbackHandler_WithContext(t *testing.T) {
 	assert.NotNil(t, newHandler)
 	assert.Equal(t, ctx, newHandler.ctx)
 }
+
+func TestMemoryCallbackHandler_AddFactLinksExecution(t *testing.T) {
+	store, err := hypergraph.NewStore(hypergraph.Options{})
+	require.NoError(t, err)
+	defer store.Close()
+
+	ctx, exec := WithExecution(context.Background(), store, "Index the repository")
+	require.NotNil(t, exec)
+	handler := NewMemoryCallbackHandler(store).WithContext(ctx)
+
+	factID, err := handler.MemoryAddFact("main.go defines the CLI entry point", 0.9)
+	require.NoError(t, err)
+	otherID, err := handler.MemoryAddFactWithEvidence("go.mod pins Go 1.24", 0.8, "go 1.24")
+	require.NoError(t, err)
+
+	execID, err := exec.NodeID(ctx)
+	require.NoError(t, err)
+	for _, id := range []string{factID, otherID} {
+		connected, err := store.GetConnected(ctx, id, hypergraph.TraversalOptions{MaxDepth: 1, IncludeEdge: true})
+		require.NoError(t, err)
+		require.Len(t, connected, 1)
+		assert.Equal(t, execID, connected[0].Node.ID)
+		assert.Equal(t, DerivedFromLabel, connected[0].Edge.Label)
+	}
+
+	// Without an execution in the context no edge is made
+	plainID, err := NewMemoryCallbackHandler(store).MemoryAddFact("README describes setup", 0.9)
+	require.NoError(t, err)
+	connected, err := store.GetConnected(ctx, plainID, hypergraph.TraversalOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, connected)
+}
+
+func TestMemoryCallbackHandler_REPLFactsFollowTheirExecution(t *testing.T) {
+	store, err := hypergraph.NewStore(hypergraph.Options{})
+	require.NoError(t, err)
+	defer store.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	replMgr, err := repl.NewManager(repl.Options{})
+	require.NoError(t, err)
+	require.NoError(t, replMgr.Start(ctx))
+	defer replMgr.Stop()
+	replMgr.SetMemoryHandler(NewMemoryCallbackHandler(store))
+
+	// Two executions share the REPL; each fact goes to the one whose code
+	// recorded it
+	first, firstExec := WithExecution(ctx, store, "first task")
+	second, secondExec := WithExecution(ctx, store, "second task")
+	for _, tc := range []struct {
+		ctx  context.Context
+		exec *Execution
+		fact string
+	}{
+		{second, secondExec, "second fact"},
+		{first, firstExec, "first fact"},
+	} {
+		result, err := replMgr.Execute(tc.ctx, "memory_add_fact('"+tc.fact+"', 0.9)")
+		require.NoError(t, err)
+		require.Empty(t, result.Error)
+		factID := strings.Trim(result.ReturnVal, "'")
+
+		execID, err := tc.exec.NodeID(ctx)
+		require.NoError(t, err)
+		connected, err := store.GetConnected(ctx, factID, hypergraph.TraversalOptions{MaxDepth: 1})
+		require.NoError(t, err)
+		require.Len(t, connected, 1, tc.fact)
+		assert.Equal(t, execID, connected[0].Node.ID, tc.fact)
+	}
+}
@@ -0,0 +1,262 @@
+package rlm
+
+import (
+	"context"
+	"log/slog"
+	"maps"
+	"math"
+	"os"
+	"regexp"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Memory pressure defaults.
+const (
+	DefaultPressureThresholdFactor = 0.5
+	DefaultPressureEvictFraction   = 0.5
+)
+
+// MemoryPressureConfig escalates compression and evicts externalized
+// contexts while the process is under memory pressure, so heavy
+// multi-context sessions do not run a memory-constrained host out of
+// memory. Pressure is checked each time a prompt is prepared, and the
+// escalation lasts only as long as the pressure does.
+type MemoryPressureConfig struct {
+	// RSSThreshold is the resident set size, in bytes, above which the
+	// process is under pressure. Zero disables the monitor.
+	RSSThreshold uint64
+
+	// ThresholdFactor scales the compression threshold under pressure,
+	// so contexts are compressed sooner and harder. It has no effect
+	// unless compression is enabled. Default: 0.5.
+	ThresholdFactor float64
+
+	// EvictFraction is the fraction of the contexts externalized by
+	// earlier prompts that each check under pressure evicts from the
+	// REPL, least used first. At least one is evicted while any remain.
+	// Default: 0.5.
+	EvictFraction float64
+
+	// Signal returns the resident set size in bytes. Nil reads the
+	// REPL's, where the externalized contexts live, so evicting them
+	// relieves the pressure; or this process's while no REPL is running.
+	// A caller can add other processes, or simulate pressure.
+	Signal func() uint64
+}
+
+// Enabled reports whether memory pressure is monitored.
+func (c MemoryPressureConfig) Enabled() bool {
+	return c.RSSThreshold > 0
+}
+
+// MemoryPressureActions reports what was done to relieve memory pressure
+// before a prompt was prepared.
+type MemoryPressureActions struct {
+	// RSS is the resident set size, in bytes, that triggered the actions.
+	RSS uint64 `json:"rss"`
+
+	// CompressionThreshold is the lowered token count above which the
+	// prompt's contexts were compressed.
+	CompressionThreshold int `json:"compression_threshold"`
+
+	// Evicted names the externalized contexts removed from the REPL.
+	Evicted []string `json:"evicted,omitempty"`
+
+	// EvictedTokens is the estimated size of the evicted contexts.
+	EvictedTokens int `json:"evicted_tokens,omitempty"`
+}
+
+// compressionThreshold returns the compression threshold in effect:
+// the lowered one under pressure, otherwise threshold.
+func (a *MemoryPressureActions) compressionThreshold(threshold int) int {
+	if a == nil {
+		return threshold
+	}
+	return a.CompressionThreshold
+}
+
+// externalizedUse is the use an externalized context has had.
+type externalizedUse struct {
+	tokens int
+	uses   int
+	last   int64 // Sequence number of the load or use
+}
+
+// memoryPressureMonitor checks memory pressure and tracks the use of
+// externalized contexts, to pick the least used to evict. It is safe for
+// concurrent use.
+type memoryPressureMonitor struct {
+	cfg MemoryPressureConfig
+
+	mu       sync.Mutex
+	seq      int64
+	contexts map[string]*externalizedUse
+}
+
+func newMemoryPressureMonitor(cfg MemoryPressureConfig) *memoryPressureMonitor {
+	if cfg.ThresholdFactor <= 0 || cfg.ThresholdFactor > 1 {
+		cfg.ThresholdFactor = DefaultPressureThresholdFactor
+	}
+	if cfg.EvictFraction <= 0 || cfg.EvictFraction > 1 {
+		cfg.EvictFraction = DefaultPressureEvictFraction
+	}
+	if cfg.Signal == nil {
+		cfg.Signal = processRSS
+	}
+	return &memoryPressureMonitor{cfg: cfg, contexts: make(map[string]*externalizedUse)}
+}
+
+// loaded records the variables of an externalized context as unused.
+func (m *memoryPressureMonitor) loaded(loaded *LoadedContext) {
+	if m == nil || loaded == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, name := range slices.Sorted(maps.Keys(loaded.Variables)) {
+		m.seq++
+		m.contexts[name] = &externalizedUse{tokens: loaded.Variables[name].TokenEstimate, last: m.seq}
+	}
+}
+
+// used records a use of each externalized context code refers to.
+func (m *memoryPressureMonitor) used(code string) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.seq++
+	for name, use := range m.contexts {
+		if refersTo(code, name) {
+			use.uses++
+			use.last = m.seq
+		}
+	}
+}
+
+// forget stops tracking the named contexts.
+func (m *memoryPressureMonitor) forget(names []string) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, name := range names {
+		delete(m.contexts, name)
+	}
+}
+
+// check returns the actions to take under the current memory pressure, or
+// nil if there is none. The contexts in keep, about to be externalized,
+// are not picked for eviction.
+func (m *memoryPressureMonitor) check(threshold int, keep map[string]bool) *MemoryPressureActions {
+	if m == nil {
+		return nil
+	}
+	rss := m.cfg.Signal()
+	if rss <= m.cfg.RSSThreshold {
+		return nil
+	}
+	actions := &MemoryPressureActions{
+		RSS:                  rss,
+		CompressionThreshold: max(1, int(float64(threshold)*m.cfg.ThresholdFactor)),
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var candidates []string
+	for name := range m.contexts {
+		if !keep[name] {
+			candidates = append(candidates, name)
+		}
+	}
+	slices.SortFunc(candidates, func(a, b string) int {
+		ua, ub := m.contexts[a], m.contexts[b]
+		if ua.uses != ub.uses {
+			return ua.uses - ub.uses
+		}
+		return int(ua.last - ub.last)
+	})
+	evict := int(math.Ceil(float64(len(candidates)) * m.cfg.EvictFraction))
+	for _, name := range candidates[:evict] {
+		actions.Evicted = append(actions.Evicted, name)
+		actions.EvictedTokens += m.contexts[name].tokens
+	}
+	return actions
+}
+
+// relieveMemoryPressure checks memory pressure before contexts are
+// prepared, evicting the least used contexts earlier prompts externalized
+// if the process is under pressure. It returns the actions taken, or nil
+// if there was no pressure.
+func (w *Wrapper) relieveMemoryPressure(ctx context.Context, contexts []ContextSource) *MemoryPressureActions {
+	if w.memoryPressure == nil {
+		return nil
+	}
+	keep := make(map[string]bool, len(contexts))
+	for _, c := range contexts {
+		keep[c.Name] = true
+	}
+	actions := w.memoryPressure.check(w.compressionThreshold, keep)
+	if actions == nil {
+		return nil
+	}
+
+	if len(actions.Evicted) > 0 {
+		if w.contextLoader == nil {
+			actions.Evicted, actions.EvictedTokens = nil, 0
+		} else if err := w.contextLoader.ClearContext(ctx, actions.Evicted); err != nil {
+			slog.Warn("Failed to evict externalized contexts under memory pressure", "error", err)
+			actions.Evicted, actions.EvictedTokens = nil, 0
+		} else {
+			w.memoryPressure.forget(actions.Evicted)
+		}
+	}
+
+	slog.Warn("Memory pressure, escalating compression",
+		"rss", actions.RSS,
+		"rss_threshold", w.memoryPressure.cfg.RSSThreshold,
+		"compression_threshold", actions.CompressionThreshold,
+		"evicted", len(actions.Evicted),
+		"evicted_tokens", actions.EvictedTokens)
+	return actions
+}
+
+// replRSS returns the resident set size of the wrapper's REPL, or of this
+// process while no REPL is running.
+func (w *Wrapper) replRSS() uint64 {
+	if w.replMgr != nil {
+		if rss := w.replMgr.RSS(); rss > 0 {
+			return rss
+		}
+	}
+	return processRSS()
+}
+
+// refersTo reports whether code contains name as an identifier.
+func refersTo(code, name string) bool {
+	if !strings.Contains(code, name) {
+		return false
+	}
+	return regexp.MustCompile(`\b` + regexp.QuoteMeta(name) + `\b`).MatchString(code)
+}
+
+// processRSS returns this process's resident set size in bytes, or the
+// memory the Go runtime holds where it cannot be read.
+func processRSS() uint64 {
+	if data, err := os.ReadFile("/proc/self/statm"); err == nil {
+		if fields := strings.Fields(string(data)); len(fields) > 1 {
+			if pages, err := strconv.ParseUint(fields[1], 10, 64); err == nil {
+				return pages * uint64(os.Getpagesize())
+			}
+		}
+	}
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	return stats.Sys
+}
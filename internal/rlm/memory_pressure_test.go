@@ -0,0 +1,214 @@
+package rlm
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rand/recurse/internal/rlm/repl"
+)
+
+// pressureSignal simulates the process's RSS.
+type pressureSignal struct {
+	rss atomic.Uint64
+}
+
+func (s *pressureSignal) read() uint64 { return s.rss.Load() }
+
+const pressureRSSThreshold = 1 << 30
+
+func TestMemoryPressureMonitor_EvictsLeastUsed(t *testing.T) {
+	signal := &pressureSignal{}
+	m := newMemoryPressureMonitor(MemoryPressureConfig{RSSThreshold: pressureRSSThreshold, Signal: signal.read})
+
+	m.loaded(&LoadedContext{Variables: map[string]VariableInfo{
+		"logs":   {TokenEstimate: 100},
+		"config": {TokenEstimate: 200},
+		"notes":  {TokenEstimate: 300},
+		"diff":   {TokenEstimate: 400},
+	}})
+	m.used("print(len(logs))")
+	m.used("grep(logs, 'ERROR')")
+	m.used("peek(diff, 0, 100)")
+	m.used("config_2 = 1") // Not a reference to config
+
+	signal.rss.Store(pressureRSSThreshold / 2)
+	assert.Nil(t, m.check(8000, nil), "no pressure")
+
+	signal.rss.Store(pressureRSSThreshold + 1)
+	actions := m.check(8000, map[string]bool{"notes": true})
+	require.NotNil(t, actions)
+	assert.EqualValues(t, pressureRSSThreshold+1, actions.RSS)
+	assert.Equal(t, 4000, actions.CompressionThreshold)
+	// Of the three candidates, the two least used go, oldest first
+	assert.Equal(t, []string{"config", "diff"}, actions.Evicted)
+	assert.Equal(t, 600, actions.EvictedTokens)
+
+	t.Run("one is evicted while any remain", func(t *testing.T) {
+		m.forget(actions.Evicted)
+		actions := m.check(8000, nil)
+		require.NotNil(t, actions)
+		assert.Len(t, actions.Evicted, 1)
+	})
+}
+
+func TestWrapper_MemoryPressure_EscalatesCompression(t *testing.T) {
+	ctx := context.Background()
+	signal := &pressureSignal{}
+
+	cfg := DefaultWrapperConfig()
+	cfg.CompressionEnabled = true
+	cfg.CompressionThreshold = 2000
+	cfg.MemoryPressure = MemoryPressureConfig{
+		RSSThreshold:    pressureRSSThreshold,
+		ThresholdFactor: 0.25,
+		Signal:          signal.read,
+	}
+	w := NewWrapper(&Service{}, cfg)
+
+	// About 1100 tokens: under the threshold, but not the lowered one
+	var sb strings.Builder
+	for i := 0; i < 50; i++ {
+		fmt.Fprintf(&sb, "Entry %d: the service restarted after a routine health check and resumed normal operation.\n", i)
+	}
+	contexts := []ContextSource{{Name: "logs", Type: ContextTypeFile, Content: sb.String()}}
+	prepare := func() *PreparedPrompt {
+		prepared, err := w.PrepareContextWithOptions(ctx, "Summarize the log", contexts, PrepareOptions{
+			ModeOverride: ModeOverrideDirect,
+		})
+		require.NoError(t, err)
+		return prepared
+	}
+
+	prepared := prepare()
+	assert.Nil(t, prepared.MemoryPressure)
+	assert.Nil(t, prepared.Compression)
+
+	signal.rss.Store(pressureRSSThreshold * 2)
+	prepared = prepare()
+	require.NotNil(t, prepared.MemoryPressure)
+	assert.Equal(t, 500, prepared.MemoryPressure.CompressionThreshold)
+	require.NotNil(t, prepared.Compression, "compressed under pressure")
+	assert.Less(t, prepared.Compression.CompressedTokens, prepared.Compression.OriginalTokens)
+
+	signal.rss.Store(pressureRSSThreshold - 1)
+	prepared = prepare()
+	assert.Nil(t, prepared.MemoryPressure)
+	assert.Nil(t, prepared.Compression, "relaxed once pressure subsides")
+}
+
+func TestWrapper_MemoryPressure_EvictsExternalizedContexts(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	replMgr, err := repl.NewManager(repl.Options{})
+	require.NoError(t, err)
+	require.NoError(t, replMgr.Start(ctx))
+	defer replMgr.Stop()
+
+	signal := &pressureSignal{}
+	cfg := DefaultWrapperConfig()
+	cfg.MemoryPressure = MemoryPressureConfig{RSSThreshold: pressureRSSThreshold, Signal: signal.read}
+	w := NewWrapper(&Service{}, cfg)
+	w.SetREPLManager(replMgr)
+
+	prepare := func(contexts ...ContextSource) *PreparedPrompt {
+		prepared, err := w.PrepareContextWithOptions(ctx, "Count the errors", contexts, PrepareOptions{
+			ModeOverride: ModeOverrideRLM,
+		})
+		require.NoError(t, err)
+		require.Equal(t, ModeRLM, prepared.Mode)
+		return prepared
+	}
+	defined := func() map[string]bool {
+		vars, err := replMgr.ListVars(ctx)
+		require.NoError(t, err)
+		names := make(map[string]bool)
+		for _, v := range vars.Variables {
+			names[v.Name] = true
+		}
+		return names
+	}
+
+	prepare(
+		ContextSource{Name: "app_log", Type: ContextTypeFile, Content: "ERROR a\nINFO b"},
+		ContextSource{Name: "db_log", Type: ContextTypeFile, Content: "ERROR c"},
+		ContextSource{Name: "web_log", Type: ContextTypeFile, Content: "INFO d"},
+	)
+	w.memoryPressure.used("print(db_log.count('ERROR'))")
+
+	// No pressure: everything stays
+	prepared := prepare(ContextSource{Name: "cache_log", Type: ContextTypeFile, Content: "WARN e"})
+	assert.Nil(t, prepared.MemoryPressure)
+	vars := defined()
+	for _, name := range []string{"app_log", "db_log", "web_log", "cache_log"} {
+		assert.True(t, vars[name], name)
+	}
+
+	// Under pressure the least used half of the others is evicted
+	signal.rss.Store(pressureRSSThreshold + 1)
+	prepared = prepare(ContextSource{Name: "cache_log", Type: ContextTypeFile, Content: "WARN e"})
+	require.NotNil(t, prepared.MemoryPressure)
+	assert.Equal(t, []string{"app_log", "web_log"}, prepared.MemoryPressure.Evicted)
+	vars = defined()
+	assert.False(t, vars["app_log"])
+	assert.False(t, vars["web_log"])
+	assert.True(t, vars["db_log"], "the used context is kept")
+	assert.True(t, vars["cache_log"], "the prompt's own context is kept")
+
+	// Pressure subsides: nothing more is evicted
+	signal.rss.Store(0)
+	prepared = prepare(ContextSource{Name: "cache_log", Type: ContextTypeFile, Content: "WARN e"})
+	assert.Nil(t, prepared.MemoryPressure)
+	assert.True(t, defined()["db_log"])
+}
+
+func TestWrapper_MemoryPressure_EvictionRelievesREPLMemory(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	replMgr, err := repl.NewManager(repl.Options{})
+	require.NoError(t, err)
+	require.NoError(t, replMgr.Start(ctx))
+	defer replMgr.Stop()
+	baseline := replMgr.RSS()
+	if baseline == 0 {
+		t.Skip("the REPL's memory cannot be read here")
+	}
+
+	// The threshold is set once the large context is in, by the default
+	// signal, which reads the REPL's memory
+	cfg := DefaultWrapperConfig()
+	cfg.MemoryPressure = MemoryPressureConfig{RSSThreshold: math.MaxUint64}
+	w := NewWrapper(&Service{}, cfg)
+	w.SetREPLManager(replMgr)
+
+	prepare := func(contexts ...ContextSource) *PreparedPrompt {
+		prepared, err := w.PrepareContextWithOptions(ctx, "Count the errors", contexts, PrepareOptions{
+			ModeOverride: ModeOverrideRLM,
+		})
+		require.NoError(t, err)
+		return prepared
+	}
+
+	prepare(ContextSource{Name: "dump", Type: ContextTypeFile, Content: strings.Repeat("ERROR disk full\n", 1<<20)})
+	loaded := replMgr.RSS()
+	require.Greater(t, loaded, baseline+8<<20, "the context lives in the REPL")
+	w.memoryPressure.cfg.RSSThreshold = baseline + (loaded-baseline)/2
+
+	prepared := prepare(ContextSource{Name: "notes", Type: ContextTypeFile, Content: "ERROR a"})
+	require.NotNil(t, prepared.MemoryPressure)
+	assert.Equal(t, []string{"dump"}, prepared.MemoryPressure.Evicted)
+	assert.Less(t, replMgr.RSS(), w.memoryPressure.cfg.RSSThreshold, "eviction freed the REPL's memory")
+
+	// The pressure has eased, so nothing more is evicted
+	prepared = prepare(ContextSource{Name: "more_notes", Type: ContextTypeFile, Content: "ERROR b"})
+	assert.Nil(t, prepared.MemoryPressure)
+}
@@ -0,0 +1,98 @@
+package meta
+
+import "context"
+
+// Capabilities describes what a client, and the models behind it, support.
+// Callers consult it to leave out features a client lacks rather than fail
+// when they use them. Zero values mean unsupported or, for the limits,
+// unknown.
+type Capabilities struct {
+	// ContextWindow is the largest prompt plus completion, in tokens, every
+	// model the client may answer with accepts.
+	ContextWindow int
+
+	// MaxOutputTokens caps the tokens of a single completion.
+	MaxOutputTokens int
+
+	// Images means prompts may carry images; see MultimodalClient.
+	Images bool
+
+	// Logprobs means completions can report token log probabilities.
+	Logprobs bool
+
+	// TierRouting means completions are routed across models of more than
+	// one tier, honoring WithMinTier and WithMaxTier. A client without it
+	// answers with the same model whatever the tier asked for.
+	TierRouting bool
+}
+
+// CompletionOnly returns c reduced to what a wrapper forwarding only
+// Complete passes on: the model's limits and routing, without the features
+// other methods provide.
+func (c Capabilities) CompletionOnly() Capabilities {
+	return Capabilities{ContextWindow: c.ContextWindow, MaxOutputTokens: c.MaxOutputTokens, TierRouting: c.TierRouting}
+}
+
+// CapabilityReporter is implemented by clients that describe their own
+// capabilities. Wrappers implement it to pass on their inner client's.
+type CapabilityReporter interface {
+	LLMClient
+
+	// Capabilities returns what the client supports now.
+	Capabilities() Capabilities
+}
+
+// logprobsCompleter matches clients that complete with logprobs, such as
+// the hallucination verifier's LogprobsCompleter.
+type logprobsCompleter interface {
+	CompleteWithLogprobs(ctx context.Context, prompt string, maxTokens int) (string, map[string]float64, error)
+}
+
+// CapabilitiesOf returns client's capabilities. A client that does not
+// report them is assumed to support only plain completion, plus images and
+// logprobs if it implements the methods for them; its limits are unknown.
+func CapabilitiesOf(client LLMClient) Capabilities {
+	if client == nil {
+		return Capabilities{}
+	}
+	if r, ok := client.(CapabilityReporter); ok {
+		return r.Capabilities()
+	}
+
+	var caps Capabilities
+	if mm, ok := client.(MultimodalClient); ok {
+		caps.Images = mm.SupportsImages()
+	}
+	_, caps.Logprobs = client.(logprobsCompleter)
+	return caps
+}
+
+// CommonCapabilities returns the capabilities every one of caps has, for a
+// client that may answer with any of several: features all of them
+// support, and the smallest known limits.
+func CommonCapabilities(caps ...Capabilities) Capabilities {
+	if len(caps) == 0 {
+		return Capabilities{}
+	}
+	common := caps[0]
+	for _, c := range caps[1:] {
+		common.ContextWindow = minKnown(common.ContextWindow, c.ContextWindow)
+		common.MaxOutputTokens = minKnown(common.MaxOutputTokens, c.MaxOutputTokens)
+		common.Images = common.Images && c.Images
+		common.Logprobs = common.Logprobs && c.Logprobs
+		common.TierRouting = common.TierRouting && c.TierRouting
+	}
+	return common
+}
+
+// minKnown returns the smaller of two limits, where zero is unknown.
+func minKnown(a, b int) int {
+	switch {
+	case a == 0:
+		return b
+	case b == 0:
+		return a
+	default:
+		return min(a, b)
+	}
+}
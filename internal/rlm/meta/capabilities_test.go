@@ -0,0 +1,85 @@
+package meta
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// reportingTestClient is a multimodal client whose reported capabilities
+// may deny what its methods offer.
+type reportingTestClient struct {
+	multimodalTestClient
+	caps Capabilities
+}
+
+func (c *reportingTestClient) Capabilities() Capabilities { return c.caps }
+
+type logprobsTestClient struct{ StubClient }
+
+func (c *logprobsTestClient) CompleteWithLogprobs(ctx context.Context, prompt string, maxTokens int) (string, map[string]float64, error) {
+	return "YES", map[string]float64{"YES": 0}, nil
+}
+
+func TestCapabilitiesOf_Defaults(t *testing.T) {
+	assert.Equal(t, Capabilities{}, CapabilitiesOf(nil))
+	assert.Equal(t, Capabilities{}, CapabilitiesOf(NewStubClient(StubConfig{})), "plain completion only")
+	assert.Equal(t, Capabilities{Images: true}, CapabilitiesOf(&multimodalTestClient{vision: true}))
+	assert.Equal(t, Capabilities{}, CapabilitiesOf(&multimodalTestClient{vision: false}))
+	assert.Equal(t, Capabilities{Logprobs: true}, CapabilitiesOf(&logprobsTestClient{}))
+}
+
+func TestCapabilitiesOf_Reported(t *testing.T) {
+	caps := Capabilities{ContextWindow: 8000, MaxOutputTokens: 512, Logprobs: true}
+	client := &reportingTestClient{multimodalTestClient: multimodalTestClient{vision: true}, caps: caps}
+	assert.Equal(t, caps, CapabilitiesOf(client))
+
+	// The report overrides SupportsImages
+	_, ok := AcceptsImages(client)
+	assert.False(t, ok)
+
+	client.caps.Images = true
+	_, ok = AcceptsImages(client)
+	assert.True(t, ok)
+	assert.True(t, CapabilitiesOf(WithImages(client, nil)).Images, "passed on by WithImages")
+}
+
+func TestCommonCapabilities(t *testing.T) {
+	assert.Equal(t, Capabilities{}, CommonCapabilities())
+
+	common := CommonCapabilities(
+		Capabilities{ContextWindow: 200000, MaxOutputTokens: 4096, Logprobs: true, Images: true},
+		Capabilities{ContextWindow: 32000, Logprobs: true},
+		Capabilities{MaxOutputTokens: 8192, Logprobs: true, TierRouting: true},
+	)
+	assert.Equal(t, Capabilities{ContextWindow: 32000, MaxOutputTokens: 4096, Logprobs: true}, common)
+}
+
+func TestOpenRouterClient_Capabilities(t *testing.T) {
+	client := &OpenRouterClient{models: []ModelSpec{
+		{ID: "a", ContextSize: 200000},
+		{ID: "b", ContextSize: 128000},
+		{ID: "c"},
+	}}
+	assert.Equal(t, Capabilities{ContextWindow: 128000}, client.Capabilities())
+
+	client.visionModel = "vision"
+	assert.True(t, client.Capabilities().Images)
+
+	// A catalog spanning tiers is routed by tier.
+	client.models = append(client.models, ModelSpec{ID: "d", Tier: TierPowerful})
+	assert.True(t, client.Capabilities().TierRouting)
+}
+
+func TestOpenAICompatibleClient_Capabilities(t *testing.T) {
+	single := &OpenAICompatibleClient{model: "local"}
+	assert.False(t, single.Capabilities().TierRouting)
+
+	same := &OpenAICompatibleClient{model: "local", tierModels: map[ModelTier]string{TierPowerful: "local"}}
+	assert.False(t, same.Capabilities().TierRouting, "every tier maps to the default model")
+
+	routed := &OpenAICompatibleClient{model: "small", tierModels: map[ModelTier]string{TierPowerful: "large"}}
+	assert.True(t, routed.Capabilities().TierRouting)
+	assert.True(t, CapabilitiesOf(routed).CompletionOnly().TierRouting, "routing is part of Complete")
+}
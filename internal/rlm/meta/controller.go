@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+	"sync"
 )
 
 // Action represents the orchestration action to take.
@@ -30,6 +31,10 @@ const (
 	// ActionExecute runs code in the Python REPL.
 	// [SPEC-09.05] For computational tasks, data transformation, verification.
 	ActionExecute Action = "EXECUTE"
+
+	// ActionToT explores alternative reasoning paths with Tree of Thoughts.
+	// For deep-reasoning tasks such as proofs and multi-step planning.
+	ActionToT Action = "TOT"
 )
 
 // DecomposeStrategy specifies how to break down a task.
@@ -65,6 +70,9 @@ type DecisionParams struct {
 	// For EXECUTE [SPEC-09.05]
 	Code string `json:"code,omitempty"`
 
+	// For TOT: exploration strategy (bfs, dfs or best_first)
+	ToTStrategy string `json:"tot_strategy,omitempty"`
+
 	// For budget allocation
 	TokenBudget int `json:"token_budget,omitempty"`
 }
@@ -79,6 +87,10 @@ type State struct {
 	MemoryHints    []string `json:"memory_hints,omitempty"`
 	PartialResults []string `json:"partial_results,omitempty"`
 
+	// PartialConfidences holds the confidence (0.0 to 1.0) of each entry in
+	// PartialResults, by index. Missing or zero entries are unknown.
+	PartialConfidences []float64 `json:"partial_confidences,omitempty"`
+
 	// ExternalizedContext indicates context has been loaded into REPL variables.
 	// [SPEC-09.06] When true, meta-controller can leverage externalized context.
 	ExternalizedContext bool `json:"externalized_context,omitempty"`
@@ -99,6 +111,11 @@ type Controller struct {
 	client       LLMClient
 	maxDepth     int
 	systemPrompt string
+	disableToT   bool
+	totMinBudget int
+
+	mu   sync.Mutex
+	last *decisionRecord
 }
 
 // Config configures the meta-controller.
@@ -108,12 +125,19 @@ type Config struct {
 
 	// SystemPrompt overrides the default system prompt.
 	SystemPrompt string
+
+	// DisableToT stops deep-reasoning tasks from being routed to TOT.
+	DisableToT bool
+
+	// ToTMinBudget is the token budget a task needs for TOT (default 20000).
+	ToTMinBudget int
 }
 
 // DefaultConfig returns the default configuration.
 func DefaultConfig() Config {
 	return Config{
-		MaxDepth: 5,
+		MaxDepth:     5,
+		ToTMinBudget: 20000,
 	}
 }
 
@@ -122,6 +146,9 @@ func NewController(client LLMClient, cfg Config) *Controller {
 	if cfg.MaxDepth == 0 {
 		cfg.MaxDepth = 5
 	}
+	if cfg.ToTMinBudget == 0 {
+		cfg.ToTMinBudget = 20000
+	}
 
 	systemPrompt := cfg.SystemPrompt
 	if systemPrompt == "" {
@@ -132,6 +159,8 @@ func NewController(client LLMClient, cfg Config) *Controller {
 		client:       client,
 		maxDepth:     cfg.MaxDepth,
 		systemPrompt: systemPrompt,
+		disableToT:   cfg.DisableToT,
+		totMinBudget: cfg.ToTMinBudget,
 	}
 }
 
@@ -141,19 +170,37 @@ func (c *Controller) Decide(ctx context.Context, state State) (*Decision, error)
 		state.MaxDepth = c.maxDepth
 	}
 
+	// Capture how a routing client chose the model, for the trace
+	ctx, routes := TrackRoutes(ctx)
+	decision, source, err := c.decide(ctx, state)
+	if err != nil {
+		return nil, err
+	}
+	c.recordDecision(state, decision, source, routes.Last())
+	return decision, nil
+}
+
+// decide makes the decision and reports which rule produced it.
+func (c *Controller) decide(ctx context.Context, state State) (*Decision, DecisionSource, error) {
 	// Check termination conditions
 	if state.RecursionDepth >= state.MaxDepth {
 		return &Decision{
 			Action:    ActionDirect,
 			Reasoning: "Maximum recursion depth reached, must answer directly",
-		}, nil
+		}, SourceDepthLimit, nil
 	}
 
 	if state.BudgetRemain <= 0 {
 		return &Decision{
 			Action:    ActionDirect,
 			Reasoning: "Budget exhausted, must answer directly",
-		}, nil
+		}, SourceBudgetExhausted, nil
+	}
+
+	// Deep-reasoning tasks go to Tree of Thoughts when enough budget
+	// remains to explore several paths.
+	if decision := c.decideToT(state); decision != nil {
+		return decision, SourceReasoningRoute, nil
 	}
 
 	// Build prompt for meta-controller
@@ -162,7 +209,7 @@ func (c *Controller) Decide(ctx context.Context, state State) (*Decision, error)
 	// Call LLM
 	response, err := c.client.Complete(ctx, prompt, 500)
 	if err != nil {
-		return nil, fmt.Errorf("meta-controller call: %w", err)
+		return nil, "", fmt.Errorf("meta-controller call: %w", err)
 	}
 
 	// Parse decision
@@ -172,10 +219,10 @@ func (c *Controller) Decide(ctx context.Context, state State) (*Decision, error)
 		return &Decision{
 			Action:    ActionDirect,
 			Reasoning: fmt.Sprintf("Failed to parse meta-controller response: %v", err),
-		}, nil
+		}, SourceParseFallback, nil
 	}
 
-	return decision, nil
+	return decision, SourceLLM, nil
 }
 
 // buildPrompt constructs the prompt for the meta-controller.
@@ -213,6 +260,7 @@ func (c *Controller) buildPrompt(state State) string {
 	sb.WriteString("4. SUBCALL - Invoke sub-LM on specific snippet\n")
 	sb.WriteString("5. SYNTHESIZE - Combine existing partial results\n")
 	sb.WriteString("6. EXECUTE - Run Python code in REPL (for computation, data processing, verification)\n")
+	sb.WriteString("7. TOT - Explore alternative reasoning paths (for proofs, multi-step planning)\n")
 	sb.WriteString("\n")
 	sb.WriteString(`Output JSON: {"action": "...", "params": {...}, "reasoning": "..."}`)
 
@@ -241,7 +289,7 @@ func parseDecision(response string) (*Decision, error) {
 
 	// Validate action
 	switch decision.Action {
-	case ActionDirect, ActionDecompose, ActionMemoryQuery, ActionSubcall, ActionSynthesize, ActionExecute:
+	case ActionDirect, ActionDecompose, ActionMemoryQuery, ActionSubcall, ActionSynthesize, ActionExecute, ActionToT:
 		// Valid
 	default:
 		return nil, fmt.Errorf("unknown action: %s", decision.Action)
@@ -268,6 +316,8 @@ Guidelines:
   - Include Python code in params.code
   - Good for: math calculations, JSON/CSV processing, testing code, data analysis
   - Example: {"action": "EXECUTE", "params": {"code": "print(sum([1,2,3]))"}, "reasoning": "..."}
+- Use TOT when the task needs deep reasoning with dead ends, such as proofs or multi-step plans
+  - Set params.tot_strategy to bfs, dfs or best_first (default best_first)
 
 Consider:
 - Budget constraints: don't decompose if budget is low
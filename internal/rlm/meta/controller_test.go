@@ -146,6 +146,75 @@ func TestController_Decide_BudgetExhausted(t *testing.T) {
 	assert.Contains(t, decision.Reasoning, "Budget exhausted")
 }
 
+func TestController_Decide_ToT(t *testing.T) {
+	// The client fails, so any decision must come from keyword routing.
+	client := &mockLLMClient{err: assert.AnError}
+	ctrl := NewController(client, DefaultConfig())
+
+	decision, err := ctrl.Decide(context.Background(), State{
+		Task:         "Prove that the square root of 2 is irrational",
+		BudgetRemain: 50000,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, ActionToT, decision.Action)
+	assert.Equal(t, "best_first", decision.Params.ToTStrategy)
+
+	decision, err = ctrl.Decide(context.Background(), State{
+		Task:         "Plan out the migration in a multi-step plan",
+		BudgetRemain: 50000,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, ActionToT, decision.Action)
+	assert.Equal(t, "bfs", decision.Params.ToTStrategy)
+}
+
+func TestController_Decide_ToTNeedsBudget(t *testing.T) {
+	client := &mockLLMClient{
+		response: `{"action": "DIRECT", "params": {}, "reasoning": "fallback"}`,
+	}
+	task := "Prove the lemma by induction"
+
+	tests := []struct {
+		name  string
+		cfg   Config
+		state State
+	}{
+		{"low token budget", DefaultConfig(), State{Task: task, BudgetRemain: 5000}},
+		{"near max depth", DefaultConfig(), State{Task: task, BudgetRemain: 50000, RecursionDepth: 4, MaxDepth: 5}},
+		{"disabled", Config{DisableToT: true}, State{Task: task, BudgetRemain: 50000}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decision, err := NewController(client, tt.cfg).Decide(context.Background(), tt.state)
+			require.NoError(t, err)
+			assert.Equal(t, ActionDirect, decision.Action)
+		})
+	}
+}
+
+func TestClassifyReasoning(t *testing.T) {
+	tests := []struct {
+		task string
+		want ReasoningKind
+	}{
+		{"Prove that there are infinitely many primes", ReasoningProof},
+		{"Derive the closed form of the recurrence", ReasoningProof},
+		{"Show that f is continuous", ReasoningProof},
+		{"Solve this puzzle: move the disks between pegs", ReasoningPlanning},
+		{"Plan out the steps to deploy the service", ReasoningPlanning},
+		{"Refactor the codebase", ReasoningNone},
+		{"What is 2+2?", ReasoningNone},
+		{"Show the proofreading notes", ReasoningNone},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.task, func(t *testing.T) {
+			assert.Equal(t, tt.want, ClassifyReasoning(tt.task))
+		})
+	}
+}
+
 func TestParseDecision_Valid(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -167,6 +236,11 @@ func TestParseDecision_Valid(t *testing.T) {
 			response: "Here's my decision:\n{\"action\": \"SUBCALL\", \"params\": {}, \"reasoning\": \"test\"}\nThat's it.",
 			want:     ActionSubcall,
 		},
+		{
+			name:     "tree of thoughts",
+			response: `{"action": "TOT", "params": {"tot_strategy": "dfs"}, "reasoning": "test"}`,
+			want:     ActionToT,
+		},
 	}
 
 	for _, tt := range tests {
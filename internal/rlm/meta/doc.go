@@ -7,10 +7,12 @@
 //
 // # Architecture
 //
-// The package provides two LLM client implementations:
+// The package provides three LLM client implementations:
 //
 //   - HaikuClient: Single-model client using Claude Haiku via Anthropic API
 //   - OpenRouterClient: Multi-model client with intelligent routing via OpenRouter
+//   - OpenAICompatibleClient: Any chat-completions server (vLLM, Ollama, LM Studio),
+//     with optional per-tier model mapping
 //
 // # Model Routing (OpenRouter)
 //
@@ -21,6 +23,16 @@
 //   - Recursion depth: Deeper recursion uses simpler models
 //   - Cost optimization: Prefers cheaper models when capabilities are equal
 //
+// A context from WithMinTier raises the selected tier to at least the given
+// one, e.g. to re-run a low-confidence answer on a more capable model; one
+// from WithMaxTier lowers it to at most the given one.
+//
+// Each routing choice is explained by a RouteDecision: the budget and depth
+// read from the prompt, the matched keywords, the tier rule, and the scored
+// candidates. OpenRouterClient.ExplainRoute returns it without a call;
+// completions record it in a context from TrackRoutes, and the controller's
+// ExplainLastDecision includes the route of its own call.
+//
 // # Model Tiers
 //
 //   - TierFast: Quick decisions, low latency (Haiku 4.5, Gemini Flash, GPT-5 Mini)
@@ -35,6 +47,11 @@
 //	    APIKey: os.Getenv("OPENROUTER_API_KEY"),
 //	})
 //
+//	// Without a key, fall back to an offline stub for development
+//	client, err := meta.NewOpenRouterClientOrFallback(meta.OpenRouterConfig{
+//	    KeylessFallback: meta.KeylessStub,
+//	})
+//
 //	// Create meta-controller
 //	ctrl := meta.NewController(client, meta.DefaultConfig())
 //
@@ -59,4 +76,9 @@
 // # Environment Variables
 //
 //   - OPENROUTER_API_KEY: API key for OpenRouter (required for OpenRouterClient)
+//   - OPENAI_API_KEY: Default API key for OpenAICompatibleClient (optional)
+//   - RECURSE_RLM_OPENAI_BASE_URL, RECURSE_RLM_OPENAI_MODEL: Point the app's RLM
+//     client at an OpenAI-compatible server
+//   - RECURSE_RLM_KEYLESS_FALLBACK: Set to "stub" to run the app's RLM on an
+//     offline stub client when no provider is configured
 package meta
@@ -0,0 +1,323 @@
+package meta
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrNoDecision is returned by ExplainLastDecision before any decision.
+var ErrNoDecision = errors.New("no decision has been made")
+
+// DecisionSource identifies which rule produced a decision.
+type DecisionSource string
+
+const (
+	// SourceDepthLimit means the recursion limit forced a direct answer.
+	SourceDepthLimit DecisionSource = "depth_limit"
+
+	// SourceBudgetExhausted means no token budget remained.
+	SourceBudgetExhausted DecisionSource = "budget_exhausted"
+
+	// SourceReasoningRoute means reasoning keywords routed the task to TOT.
+	SourceReasoningRoute DecisionSource = "reasoning_route"
+
+	// SourceLLM means the meta-controller model chose the action.
+	SourceLLM DecisionSource = "llm"
+
+	// SourceParseFallback means the model's answer was unusable and the
+	// controller fell back to a direct answer.
+	SourceParseFallback DecisionSource = "parse_fallback"
+)
+
+// ActionScore is a heuristic fit of an action to the decision state.
+type ActionScore struct {
+	Action Action `json:"action"`
+
+	// Score is the fit from 0.0 to 1.0.
+	Score float64 `json:"score"`
+
+	// MatchedKeywords are task keywords that favour this action.
+	MatchedKeywords []string `json:"matched_keywords,omitempty"`
+
+	// Reasons lists the signals behind the score.
+	Reasons []string `json:"reasons,omitempty"`
+}
+
+// DecisionExplanation describes why the most recent decision was made.
+type DecisionExplanation struct {
+	Action    Action         `json:"action"`
+	Reasoning string         `json:"reasoning"`
+	Source    DecisionSource `json:"source"`
+	Task      string         `json:"task"`
+	DecidedAt time.Time      `json:"decided_at"`
+
+	// Factors are human-readable statements of what drove the choice.
+	Factors []string `json:"factors"`
+
+	// MatchedKeywords are task keywords that favour the chosen action.
+	MatchedKeywords []string `json:"matched_keywords,omitempty"`
+
+	// Classification is the deep-reasoning classifier's output.
+	Classification ReasoningKind `json:"classification,omitempty"`
+
+	BudgetRemaining int `json:"budget_remaining"`
+	RecursionDepth  int `json:"recursion_depth"`
+	MaxDepth        int `json:"max_depth"`
+
+	// Chosen is the heuristic score of the chosen action.
+	Chosen ActionScore `json:"chosen"`
+
+	// Alternatives are the rejected actions, best first.
+	Alternatives []ActionScore `json:"alternatives"`
+
+	// Route is how the client routed the meta-controller call, if it was
+	// made through a routing client such as OpenRouterClient.
+	Route *RouteDecision `json:"route,omitempty"`
+}
+
+// decisionRecord is the input to the most recent decision.
+type decisionRecord struct {
+	state     State
+	decision  Decision
+	source    DecisionSource
+	route     *RouteDecision
+	decidedAt time.Time
+}
+
+// recordDecision remembers a decision for ExplainLastDecision.
+func (c *Controller) recordDecision(state State, decision *Decision, source DecisionSource, route *RouteDecision) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.last = &decisionRecord{
+		state:     state,
+		decision:  *decision,
+		source:    source,
+		route:     route,
+		decidedAt: time.Now(),
+	}
+}
+
+// ExplainLastDecision explains the most recent decision: the rule that made
+// it, the task keywords, budget and recursion headroom behind it, and how
+// the rejected actions scored. It is read-only.
+func (c *Controller) ExplainLastDecision() (*DecisionExplanation, error) {
+	c.mu.Lock()
+	last := c.last
+	c.mu.Unlock()
+	if last == nil {
+		return nil, ErrNoDecision
+	}
+
+	state := last.state
+	scores := c.scoreActions(state)
+
+	explanation := &DecisionExplanation{
+		Action:          last.decision.Action,
+		Reasoning:       last.decision.Reasoning,
+		Source:          last.source,
+		Task:            state.Task,
+		DecidedAt:       last.decidedAt,
+		Classification:  ClassifyReasoning(state.Task),
+		BudgetRemaining: state.BudgetRemain,
+		RecursionDepth:  state.RecursionDepth,
+		MaxDepth:        state.MaxDepth,
+		Route:           last.route,
+	}
+	for _, score := range scores {
+		if score.Action == last.decision.Action {
+			explanation.Chosen = score
+			explanation.MatchedKeywords = score.MatchedKeywords
+			continue
+		}
+		explanation.Alternatives = append(explanation.Alternatives, score)
+	}
+	explanation.Factors = c.explainFactors(last, explanation)
+
+	return explanation, nil
+}
+
+// explainFactors states the signals behind a decision in plain language.
+func (c *Controller) explainFactors(last *decisionRecord, e *DecisionExplanation) []string {
+	state := last.state
+	var factors []string
+
+	switch last.source {
+	case SourceDepthLimit:
+		factors = append(factors, fmt.Sprintf("Recursion depth %d reached the limit of %d", state.RecursionDepth, state.MaxDepth))
+	case SourceBudgetExhausted:
+		factors = append(factors, "Token budget exhausted")
+	case SourceReasoningRoute:
+		factors = append(factors, fmt.Sprintf("Task classified as %s reasoning", e.Classification))
+	case SourceLLM:
+		factors = append(factors, "Chosen by the meta-controller model")
+	case SourceParseFallback:
+		factors = append(factors, "Model response could not be parsed; fell back to a direct answer")
+	}
+
+	if len(e.MatchedKeywords) > 0 {
+		factors = append(factors, fmt.Sprintf("Task keywords: %s", strings.Join(e.MatchedKeywords, ", ")))
+	}
+
+	factors = append(factors, fmt.Sprintf("Recursion headroom: %d of %d levels left",
+		max(state.MaxDepth-state.RecursionDepth, 0), state.MaxDepth))
+
+	budget := fmt.Sprintf("Budget: %d tokens remaining", state.BudgetRemain)
+	if c.totMinBudget > 0 && state.BudgetRemain > 0 {
+		budget += fmt.Sprintf(" (%.1fx the %d needed for TOT)", float64(state.BudgetRemain)/float64(c.totMinBudget), c.totMinBudget)
+	}
+	factors = append(factors, budget)
+
+	if len(state.PartialResults) > 0 {
+		factors = append(factors, fmt.Sprintf("%d partial results available", len(state.PartialResults)))
+	}
+	if len(state.MemoryHints) > 0 {
+		factors = append(factors, fmt.Sprintf("%d memory hints available", len(state.MemoryHints)))
+	}
+	if state.ExternalizedContext {
+		factors = append(factors, "Context externalized to REPL variables")
+	}
+
+	if e.Route != nil {
+		factors = append(factors, fmt.Sprintf("Routed to %s", e.Route))
+	}
+
+	if len(e.Alternatives) > 0 {
+		runnerUp := e.Alternatives[0]
+		factors = append(factors, fmt.Sprintf("Runner-up: %s (score %.2f vs %.2f)", runnerUp.Action, runnerUp.Score, e.Chosen.Score))
+	}
+
+	return factors
+}
+
+// actionKeywords are task words that favour each action.
+var actionKeywords = map[Action][]string{
+	ActionDecompose:   {"all", "each", "every", "across", "multiple", "refactor", "files", "modules", "compare"},
+	ActionMemoryQuery: {"remember", "recall", "previous", "previously", "earlier", "last time", "history"},
+	ActionSubcall:     {"snippet", "excerpt", "this function", "this file", "this block"},
+	ActionSynthesize:  {"combine", "merge", "summarize", "consolidate"},
+	ActionExecute:     {"calculate", "compute", "count", "sum", "average", "parse", "csv", "json"},
+}
+
+var keywordPatterns = func() map[string]*regexp.Regexp {
+	patterns := make(map[string]*regexp.Regexp)
+	for _, keywords := range actionKeywords {
+		for _, kw := range keywords {
+			patterns[kw] = regexp.MustCompile(`(?i)\b` + regexp.QuoteMeta(kw) + `\b`)
+		}
+	}
+	return patterns
+}()
+
+// matchKeywords returns the keywords for action that occur in task.
+func matchKeywords(task string, action Action) []string {
+	var matched []string
+	if action == ActionToT {
+		for _, m := range append(proofPattern.FindAllString(task, -1), planningPattern.FindAllString(task, -1)...) {
+			matched = append(matched, strings.ToLower(m))
+		}
+		return matched
+	}
+	for _, kw := range actionKeywords[action] {
+		if keywordPatterns[kw].MatchString(task) {
+			matched = append(matched, kw)
+		}
+	}
+	return matched
+}
+
+// scoreActions rates how well each action fits state, best first. The
+// scores are heuristics for explanation only; they do not drive decisions.
+func (c *Controller) scoreActions(state State) []ActionScore {
+	depthLeft := state.MaxDepth - state.RecursionDepth
+	lowBudget := state.BudgetRemain < c.totMinBudget
+
+	actions := []Action{ActionDirect, ActionDecompose, ActionMemoryQuery, ActionSubcall, ActionSynthesize, ActionExecute, ActionToT}
+	scores := make([]ActionScore, 0, len(actions))
+	for _, action := range actions {
+		s := ActionScore{Action: action, MatchedKeywords: matchKeywords(state.Task, action)}
+		keywordBoost := 0.2 * float64(len(s.MatchedKeywords))
+		if len(s.MatchedKeywords) > 0 {
+			s.Reasons = append(s.Reasons, fmt.Sprintf("%d matching keywords", len(s.MatchedKeywords)))
+		}
+
+		switch action {
+		case ActionDirect:
+			s.Score = 0.4
+			if state.ContextTokens < 4000 {
+				s.Score += 0.2
+				s.Reasons = append(s.Reasons, "small context")
+			}
+			if depthLeft <= 1 || state.BudgetRemain <= 0 {
+				s.Score += 0.4
+				s.Reasons = append(s.Reasons, "no room to recurse")
+			}
+
+		case ActionDecompose:
+			s.Score = 0.1 + keywordBoost
+			if state.ContextTokens > 50000 {
+				s.Score += 0.3
+				s.Reasons = append(s.Reasons, "large context")
+			}
+			if depthLeft < 2 {
+				s.Score -= 0.3
+				s.Reasons = append(s.Reasons, "little recursion headroom")
+			}
+			if lowBudget {
+				s.Score -= 0.2
+				s.Reasons = append(s.Reasons, "low budget")
+			}
+
+		case ActionMemoryQuery:
+			s.Score = 0.1 + keywordBoost
+			if len(state.MemoryHints) > 0 {
+				s.Score += 0.2
+				s.Reasons = append(s.Reasons, "memory hints available")
+			}
+
+		case ActionSubcall:
+			s.Score = 0.1 + keywordBoost
+			if depthLeft < 2 {
+				s.Score -= 0.1
+				s.Reasons = append(s.Reasons, "little recursion headroom")
+			}
+
+		case ActionSynthesize:
+			s.Score = 0.05 + keywordBoost
+			if len(state.PartialResults) > 0 {
+				s.Score += 0.5
+				s.Reasons = append(s.Reasons, "partial results available")
+			}
+
+		case ActionExecute:
+			s.Score = 0.1 + keywordBoost
+			if state.ExternalizedContext {
+				s.Score += 0.3
+				s.Reasons = append(s.Reasons, "context externalized")
+			}
+
+		case ActionToT:
+			s.Score = 0.05
+			if kind := ClassifyReasoning(state.Task); kind != ReasoningNone {
+				s.Score = 0.8
+				s.Reasons = append(s.Reasons, fmt.Sprintf("%s task", kind))
+				if c.disableToT {
+					s.Score = 0
+					s.Reasons = append(s.Reasons, "TOT disabled")
+				} else if depthLeft < minToTDepthRemaining || lowBudget {
+					s.Score -= 0.5
+					s.Reasons = append(s.Reasons, "insufficient budget or depth for TOT")
+				}
+			}
+		}
+
+		s.Score = min(max(s.Score, 0), 1)
+		scores = append(scores, s)
+	}
+
+	sort.SliceStable(scores, func(i, j int) bool { return scores[i].Score > scores[j].Score })
+	return scores
+}
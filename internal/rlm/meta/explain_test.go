@@ -0,0 +1,135 @@
+package meta
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExplainLastDecision_NoDecision(t *testing.T) {
+	ctrl := NewController(&mockLLMClient{}, DefaultConfig())
+
+	_, err := ctrl.ExplainLastDecision()
+	assert.ErrorIs(t, err, ErrNoDecision)
+}
+
+func TestExplainLastDecision_LLMDecision(t *testing.T) {
+	client := &mockLLMClient{
+		response: `{"action": "DECOMPOSE", "params": {"strategy": "file"}, "reasoning": "Several files"}`,
+	}
+	ctrl := NewController(client, DefaultConfig())
+
+	_, err := ctrl.Decide(context.Background(), State{
+		Task:          "Refactor each of the files across the storage modules",
+		ContextTokens: 80000,
+		BudgetRemain:  50000,
+	})
+	require.NoError(t, err)
+
+	e, err := ctrl.ExplainLastDecision()
+	require.NoError(t, err)
+
+	assert.Equal(t, ActionDecompose, e.Action)
+	assert.Equal(t, SourceLLM, e.Source)
+	assert.Equal(t, "Several files", e.Reasoning)
+	assert.Equal(t, 5, e.MaxDepth)
+	assert.Equal(t, ReasoningNone, e.Classification)
+	assert.Subset(t, e.MatchedKeywords, []string{"refactor", "each", "files", "across", "modules"})
+
+	assertFactor(t, e.Factors, "Chosen by the meta-controller model")
+	assertFactor(t, e.Factors, "Task keywords: ")
+	assertFactor(t, e.Factors, "Recursion headroom: 5 of 5 levels left")
+	assertFactor(t, e.Factors, "Budget: 50000 tokens remaining (2.5x")
+	assertFactor(t, e.Factors, "Runner-up: ")
+
+	// Every other action is listed as a rejected alternative, best first.
+	require.Len(t, e.Alternatives, 6)
+	for i, alt := range e.Alternatives {
+		assert.NotEqual(t, ActionDecompose, alt.Action)
+		if i > 0 {
+			assert.LessOrEqual(t, alt.Score, e.Alternatives[i-1].Score)
+		}
+	}
+	assert.Greater(t, e.Chosen.Score, e.Alternatives[0].Score)
+}
+
+func TestExplainLastDecision_ReasoningRoute(t *testing.T) {
+	ctrl := NewController(&mockLLMClient{err: assert.AnError}, DefaultConfig())
+
+	_, err := ctrl.Decide(context.Background(), State{
+		Task:         "Prove the lemma by induction on n",
+		BudgetRemain: 40000,
+	})
+	require.NoError(t, err)
+
+	e, err := ctrl.ExplainLastDecision()
+	require.NoError(t, err)
+
+	assert.Equal(t, ActionToT, e.Action)
+	assert.Equal(t, SourceReasoningRoute, e.Source)
+	assert.Equal(t, ReasoningProof, e.Classification)
+	assert.Contains(t, e.MatchedKeywords, "prove")
+	assertFactor(t, e.Factors, "Task classified as proof reasoning")
+	assert.Greater(t, e.Chosen.Score, e.Alternatives[0].Score)
+}
+
+func TestExplainLastDecision_DepthLimit(t *testing.T) {
+	ctrl := NewController(&mockLLMClient{}, DefaultConfig())
+
+	_, err := ctrl.Decide(context.Background(), State{
+		Task:           "Compare all modules",
+		BudgetRemain:   50000,
+		RecursionDepth: 3,
+		MaxDepth:       3,
+	})
+	require.NoError(t, err)
+
+	e, err := ctrl.ExplainLastDecision()
+	require.NoError(t, err)
+
+	assert.Equal(t, ActionDirect, e.Action)
+	assert.Equal(t, SourceDepthLimit, e.Source)
+	assertFactor(t, e.Factors, "Recursion depth 3 reached the limit of 3")
+	assert.Contains(t, e.Chosen.Reasons, "no room to recurse")
+
+	// Decompose matched keywords but is penalized for lack of headroom.
+	for _, alt := range e.Alternatives {
+		if alt.Action == ActionDecompose {
+			assert.NotEmpty(t, alt.MatchedKeywords)
+			assert.Contains(t, alt.Reasons, "little recursion headroom")
+		}
+	}
+}
+
+func TestExplainLastDecision_TracksMostRecent(t *testing.T) {
+	client := &mockLLMClient{response: `{"action": "SYNTHESIZE", "params": {}, "reasoning": "combine"}`}
+	ctrl := NewController(client, DefaultConfig())
+
+	_, err := ctrl.Decide(context.Background(), State{Task: "first", BudgetRemain: 0})
+	require.NoError(t, err)
+	_, err = ctrl.Decide(context.Background(), State{
+		Task:           "Combine the findings",
+		BudgetRemain:   1000,
+		PartialResults: []string{"a", "b"},
+	})
+	require.NoError(t, err)
+
+	e, err := ctrl.ExplainLastDecision()
+	require.NoError(t, err)
+	assert.Equal(t, ActionSynthesize, e.Action)
+	assert.Equal(t, "Combine the findings", e.Task)
+	assertFactor(t, e.Factors, "2 partial results available")
+}
+
+func assertFactor(t *testing.T, factors []string, prefix string) {
+	t.Helper()
+	for _, f := range factors {
+		if strings.HasPrefix(f, prefix) {
+			return
+		}
+	}
+	t.Errorf("no factor starting with %q in %v", prefix, factors)
+}
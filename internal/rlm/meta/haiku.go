@@ -69,6 +69,10 @@ func (h *HaikuClient) Complete(ctx context.Context, prompt string, maxTokens int
 	if text == "" {
 		return "", fmt.Errorf("empty response from haiku")
 	}
+	RecordUsage(ctx, Usage{
+		PromptTokens:     int(resp.Usage.InputTokens),
+		CompletionTokens: int(resp.Usage.OutputTokens),
+	})
 
 	return text, nil
 }
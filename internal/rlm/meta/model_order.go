@@ -0,0 +1,82 @@
+package meta
+
+import "strings"
+
+// Cost returns the model's blended cost per million tokens: CostPerMToken
+// when set, otherwise the mean of InputCost and OutputCost.
+func (m *ModelSpec) Cost() float64 {
+	if m.CostPerMToken > 0 {
+		return m.CostPerMToken
+	}
+	return (m.InputCost + m.OutputCost) / 2
+}
+
+// CompareModels orders two models by preference, returning a negative
+// number when a is preferred, positive when b is, and zero only for equal
+// IDs. The rules, applied in turn, are:
+//
+//  1. higher Priority
+//  2. lower Cost
+//  3. ID, alphabetically
+//
+// Preference never depends on catalog order, so the same catalog in any
+// order routes the same way.
+func CompareModels(a, b *ModelSpec) int {
+	if a.Priority != b.Priority {
+		if a.Priority > b.Priority {
+			return -1
+		}
+		return 1
+	}
+	if ca, cb := a.Cost(), b.Cost(); ca != cb {
+		if ca < cb {
+			return -1
+		}
+		return 1
+	}
+	return strings.Compare(a.ID, b.ID)
+}
+
+// SelectForTier returns the preferred model of tier by CompareModels, or nil
+// when models has none.
+func SelectForTier(models []ModelSpec, tier ModelTier) *ModelSpec {
+	var best *ModelSpec
+	for i := range models {
+		m := &models[i]
+		if m.Tier == tier && (best == nil || CompareModels(m, best) < 0) {
+			best = m
+		}
+	}
+	return best
+}
+
+// PreferredModel returns the preferred fast model, or the preferred model
+// of any tier when there is no fast one. It returns nil for no models.
+func PreferredModel(models []ModelSpec) *ModelSpec {
+	if m := SelectForTier(models, TierFast); m != nil {
+		return m
+	}
+	var best *ModelSpec
+	for i := range models {
+		if best == nil || CompareModels(&models[i], best) < 0 {
+			best = &models[i]
+		}
+	}
+	return best
+}
+
+// tieBreak names the rule that made chosen preferred over the other models
+// in tied, for route rationales.
+func tieBreak(chosen *ModelSpec, tied []*ModelSpec) string {
+	rule := "first by ID"
+	for _, m := range tied {
+		switch {
+		case m == chosen:
+		case m.Priority != chosen.Priority:
+			return "highest priority"
+		case m.Cost() != chosen.Cost():
+			rule = "cheapest"
+		}
+	}
+	return rule
+}
@@ -0,0 +1,157 @@
+package meta
+
+import (
+	"context"
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompareModels(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b ModelSpec
+	}{
+		{
+			name: "priority before cost",
+			a:    ModelSpec{ID: "z/pricey", Priority: 1, InputCost: 10, OutputCost: 30},
+			b:    ModelSpec{ID: "a/cheap", InputCost: 0.1, OutputCost: 0.2},
+		},
+		{
+			name: "cost breaks priority ties",
+			a:    ModelSpec{ID: "z/cheap", InputCost: 1, OutputCost: 1},
+			b:    ModelSpec{ID: "a/pricey", InputCost: 1, OutputCost: 3},
+		},
+		{
+			name: "blended cost overrides input and output",
+			a:    ModelSpec{ID: "z/blended", InputCost: 5, OutputCost: 25, CostPerMToken: 0.5},
+			b:    ModelSpec{ID: "a/plain", InputCost: 0.5, OutputCost: 1},
+		},
+		{
+			name: "ID breaks cost ties",
+			a:    ModelSpec{ID: "a/model", InputCost: 1, OutputCost: 2},
+			b:    ModelSpec{ID: "b/model", CostPerMToken: 1.5},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Negative(t, CompareModels(&tt.a, &tt.b))
+			assert.Positive(t, CompareModels(&tt.b, &tt.a))
+		})
+	}
+
+	m := ModelSpec{ID: "same"}
+	assert.Zero(t, CompareModels(&m, &m))
+}
+
+func TestModelSpec_Cost(t *testing.T) {
+	assert.Equal(t, 3.0, (&ModelSpec{InputCost: 1, OutputCost: 5}).Cost())
+	assert.Equal(t, 0.7, (&ModelSpec{InputCost: 1, OutputCost: 5, CostPerMToken: 0.7}).Cost())
+}
+
+func TestSelectForTier_DefaultPrimaries(t *testing.T) {
+	models := DefaultModels()
+	primaries := map[ModelTier]string{
+		TierFast:      "anthropic/claude-haiku-4.5",
+		TierBalanced:  "anthropic/claude-sonnet-4.5",
+		TierPowerful:  "anthropic/claude-opus-4.5",
+		TierReasoning: "deepseek/deepseek-r1-0528",
+	}
+	for tier, id := range primaries {
+		require.NotNil(t, SelectForTier(models, tier))
+		assert.Equal(t, id, SelectForTier(models, tier).ID, tier.String())
+	}
+	assert.Equal(t, "anthropic/claude-haiku-4.5", PreferredModel(models).ID)
+
+	assert.Nil(t, SelectForTier(nil, TierFast))
+	assert.Nil(t, PreferredModel(nil))
+}
+
+func TestSelectForTier_CostTieBreak(t *testing.T) {
+	models := []ModelSpec{
+		{ID: "b/mid", Tier: TierBalanced, InputCost: 1, OutputCost: 3},
+		{ID: "c/cheap", Tier: TierBalanced, InputCost: 0.5, OutputCost: 1.5},
+		{ID: "a/cheap", Tier: TierBalanced, CostPerMToken: 1},
+		{ID: "d/fast", Tier: TierFast, InputCost: 0.1},
+	}
+	assert.Equal(t, "a/cheap", SelectForTier(models, TierBalanced).ID, "equal cost falls to ID")
+
+	models[2].CostPerMToken = 1.1
+	assert.Equal(t, "c/cheap", SelectForTier(models, TierBalanced).ID)
+
+	models[0].Priority = 2
+	assert.Equal(t, "b/mid", SelectForTier(models, TierBalanced).ID, "priority outranks cost")
+
+	// Without a fast model the preferred model of any tier is used
+	assert.Equal(t, "b/mid", PreferredModel(models[:3]).ID)
+}
+
+// TestModelSelection_StableAcrossOrderings checks that reordering the
+// catalog never changes which model is chosen.
+func TestModelSelection_StableAcrossOrderings(t *testing.T) {
+	tasks := []struct {
+		task   string
+		budget int
+		depth  int
+	}{
+		{"Summarize the notes", 10000, 0},
+		{"Summarize the notes", 500, 0},
+		{"Calculate the math behind the totals", 10000, 0},
+		{"Refactor the coding layer", 10000, 0},
+		{"Review the design", 3000, 2},
+		{"Find the typo", 10000, 4},
+	}
+	tiers := []ModelTier{TierFast, TierBalanced, TierPowerful, TierReasoning}
+
+	selectAll := func(models []ModelSpec) []string {
+		var ids []string
+		for _, tier := range tiers {
+			ids = append(ids, SelectForTier(models, tier).ID)
+		}
+		selector := &AdaptiveSelector{models: models}
+		for _, tt := range tasks {
+			route := selector.ExplainRoute(context.Background(), tt.task, tt.budget, tt.depth)
+			ids = append(ids, route.Model, route.Rationale)
+		}
+		return ids
+	}
+
+	want := selectAll(DefaultModels())
+
+	reversed := DefaultModels()
+	slices.Reverse(reversed)
+	assert.Equal(t, want, selectAll(reversed))
+
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 20; i++ {
+		models := DefaultModels()
+		rng.Shuffle(len(models), func(a, b int) { models[a], models[b] = models[b], models[a] })
+		assert.Equal(t, want, selectAll(models), "shuffle %d", i)
+	}
+}
+
+func TestAdaptiveSelector_ExplainRoute_TieBreaks(t *testing.T) {
+	models := []ModelSpec{
+		{ID: "test/b", Tier: TierBalanced, InputCost: 1},
+		{ID: "test/a", Tier: TierBalanced, InputCost: 1},
+		{ID: "test/pinned", Tier: TierBalanced, InputCost: 5},
+	}
+	selector := &AdaptiveSelector{models: models}
+
+	route := selector.ExplainRoute(context.Background(), "hello", 3000, 0)
+	assert.Equal(t, "test/a", route.Model)
+	assert.Equal(t, "no candidate matched the task; cheapest of 3", route.Rationale)
+
+	models[2].Priority = 1
+	route = selector.ExplainRoute(context.Background(), "hello", 3000, 0)
+	assert.Equal(t, "test/pinned", route.Model)
+	assert.Equal(t, "no candidate matched the task; highest priority of 3", route.Rationale)
+
+	route = (&AdaptiveSelector{models: models[:2]}).ExplainRoute(context.Background(), "hello", 3000, 0)
+	assert.Equal(t, "test/a", route.Model)
+	assert.Equal(t, "no candidate matched the task; first by ID of 2", route.Rationale)
+}
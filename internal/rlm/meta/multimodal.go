@@ -0,0 +1,81 @@
+package meta
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// Image is an image sent to a model alongside a prompt.
+type Image struct {
+	// Name identifies the image, e.g. its file name.
+	Name string
+
+	// MediaType is the image's MIME type, e.g. "image/png".
+	MediaType string
+
+	// Data is the encoded image.
+	Data []byte
+}
+
+// MultimodalClient is implemented by clients that can send images with a
+// prompt. SupportsImages says whether the model behind the client actually
+// accepts them; when it does not, callers describe images in text instead.
+type MultimodalClient interface {
+	LLMClient
+
+	// SupportsImages reports whether CompleteWithImages may be called.
+	SupportsImages() bool
+
+	// CompleteWithImages sends a prompt together with images and returns
+	// the completion.
+	CompleteWithImages(ctx context.Context, prompt string, images []Image, maxTokens int) (string, error)
+}
+
+// AcceptsImages returns client as a MultimodalClient if it can send images
+// to its model now and does not report otherwise in its Capabilities.
+func AcceptsImages(client LLMClient) (MultimodalClient, bool) {
+	mm, ok := client.(MultimodalClient)
+	if !ok || !mm.SupportsImages() || !CapabilitiesOf(client).Images {
+		return nil, false
+	}
+	return mm, true
+}
+
+// WithImages returns an LLMClient that sends images with every prompt, for
+// callers that only speak LLMClient. Nil images return client's plain
+// completions.
+func WithImages(client MultimodalClient, images []Image) LLMClient {
+	return &imageClient{client: client, images: images}
+}
+
+// WithImagesOnce is like WithImages but sends the images with the first
+// successful completion only, for a conversation that is resent whole
+// each turn and would otherwise pay for the images on every call.
+func WithImagesOnce(client MultimodalClient, images []Image) LLMClient {
+	return &imageClient{client: client, images: images, once: true}
+}
+
+type imageClient struct {
+	client MultimodalClient
+	images []Image
+
+	// once sends images until a completion succeeds
+	once bool
+	sent atomic.Bool
+}
+
+// Capabilities implements CapabilityReporter with the wrapped client's.
+func (c *imageClient) Capabilities() Capabilities {
+	return CapabilitiesOf(c.client)
+}
+
+func (c *imageClient) Complete(ctx context.Context, prompt string, maxTokens int) (string, error) {
+	if len(c.images) == 0 || c.sent.Load() {
+		return c.client.Complete(ctx, prompt, maxTokens)
+	}
+	response, err := c.client.CompleteWithImages(ctx, prompt, c.images, maxTokens)
+	if err == nil && c.once {
+		c.sent.Store(true)
+	}
+	return response, err
+}
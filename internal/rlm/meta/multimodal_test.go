@@ -0,0 +1,78 @@
+package meta
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type multimodalTestClient struct {
+	vision     bool
+	textCalls  int
+	imageCalls [][]Image
+}
+
+func (c *multimodalTestClient) Complete(ctx context.Context, prompt string, maxTokens int) (string, error) {
+	c.textCalls++
+	return "text", nil
+}
+
+func (c *multimodalTestClient) SupportsImages() bool { return c.vision }
+
+func (c *multimodalTestClient) CompleteWithImages(ctx context.Context, prompt string, images []Image, maxTokens int) (string, error) {
+	c.imageCalls = append(c.imageCalls, images)
+	return "vision", nil
+}
+
+func TestAcceptsImages(t *testing.T) {
+	_, ok := AcceptsImages(NewStubClient(StubConfig{}))
+	assert.False(t, ok, "a text-only client")
+
+	_, ok = AcceptsImages(&multimodalTestClient{vision: false})
+	assert.False(t, ok, "a multimodal client without vision")
+
+	client := &multimodalTestClient{vision: true}
+	mm, ok := AcceptsImages(client)
+	require.True(t, ok)
+	assert.Same(t, client, mm)
+}
+
+func TestWithImages(t *testing.T) {
+	client := &multimodalTestClient{vision: true}
+	images := []Image{{Name: "chart.png", MediaType: "image/png", Data: []byte{1, 2, 3}}}
+
+	response, err := WithImages(client, images).Complete(context.Background(), "describe", 100)
+	require.NoError(t, err)
+	assert.Equal(t, "vision", response)
+	require.Len(t, client.imageCalls, 1)
+	assert.Equal(t, images, client.imageCalls[0])
+
+	response, err = WithImages(client, nil).Complete(context.Background(), "describe", 100)
+	require.NoError(t, err)
+	assert.Equal(t, "text", response)
+	assert.Equal(t, 1, client.textCalls)
+}
+
+func TestWithImagesOnce(t *testing.T) {
+	client := &multimodalTestClient{vision: true}
+	images := []Image{{Name: "chart.png", MediaType: "image/png", Data: []byte{1, 2, 3}}}
+	once := WithImagesOnce(client, images)
+
+	for _, want := range []string{"vision", "text", "text"} {
+		response, err := once.Complete(context.Background(), "describe", 100)
+		require.NoError(t, err)
+		assert.Equal(t, want, response)
+	}
+	assert.Len(t, client.imageCalls, 1)
+	assert.Equal(t, 2, client.textCalls)
+}
+
+func TestOpenRouterClient_SupportsImages(t *testing.T) {
+	assert.False(t, (&OpenRouterClient{}).SupportsImages())
+	assert.True(t, (&OpenRouterClient{visionModel: "google/gemini-2.5-flash"}).SupportsImages())
+
+	_, err := (&OpenRouterClient{}).CompleteWithImages(context.Background(), "describe", nil, 0)
+	assert.Error(t, err)
+}
@@ -0,0 +1,242 @@
+package meta
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// defaultOpenAICompatTimeout bounds a single chat-completions request.
+const defaultOpenAICompatTimeout = 2 * time.Minute
+
+// OpenAICompatibleClient implements LLMClient against any server speaking the
+// OpenAI chat-completions protocol (vLLM, Ollama, LM Studio, OpenAI itself).
+type OpenAICompatibleClient struct {
+	baseURL    string
+	apiKey     string
+	model      string
+	tierModels map[ModelTier]string
+	selector   *AdaptiveSelector
+	httpClient *http.Client
+}
+
+// OpenAICompatibleConfig configures the OpenAI-compatible client.
+type OpenAICompatibleConfig struct {
+	// BaseURL is the API root, e.g. http://localhost:8000/v1.
+	// Requests are sent to BaseURL + "/chat/completions".
+	BaseURL string
+
+	// APIKey is sent as a bearer token. Falls back to OPENAI_API_KEY;
+	// local servers usually need none.
+	APIKey string
+
+	// Model is used when no tier mapping matches.
+	Model string
+
+	// TierModels maps routing tiers to model names on the server, so tasks
+	// are routed as with OpenRouter. Unmapped tiers use Model.
+	TierModels map[ModelTier]string
+
+	// HTTPClient overrides the default HTTP client.
+	HTTPClient *http.Client
+}
+
+// OpenAIAPIError is returned when the server answers with a non-2xx status.
+type OpenAIAPIError struct {
+	StatusCode int
+	Type       string
+	Message    string
+}
+
+func (e *OpenAIAPIError) Error() string {
+	if e.Type != "" {
+		return fmt.Sprintf("openai-compatible API error %d (%s): %s", e.StatusCode, e.Type, e.Message)
+	}
+	return fmt.Sprintf("openai-compatible API error %d: %s", e.StatusCode, e.Message)
+}
+
+// NewOpenAICompatibleClient creates a client for an OpenAI-compatible server.
+func NewOpenAICompatibleClient(cfg OpenAICompatibleConfig) (*OpenAICompatibleClient, error) {
+	if cfg.BaseURL == "" {
+		return nil, fmt.Errorf("base URL is required")
+	}
+	if cfg.Model == "" && len(cfg.TierModels) == 0 {
+		return nil, fmt.Errorf("model or tier models are required")
+	}
+
+	apiKey := cfg.APIKey
+	if apiKey == "" {
+		apiKey = os.Getenv("OPENAI_API_KEY")
+	}
+
+	model := cfg.Model
+	if model == "" {
+		// Fall back to the cheapest mapped tier
+		for _, tier := range []ModelTier{TierFast, TierBalanced, TierPowerful, TierReasoning} {
+			if m := cfg.TierModels[tier]; m != "" {
+				model = m
+				break
+			}
+		}
+	}
+
+	httpClient := cfg.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: defaultOpenAICompatTimeout}
+	}
+
+	return &OpenAICompatibleClient{
+		baseURL:    strings.TrimRight(cfg.BaseURL, "/"),
+		apiKey:     apiKey,
+		model:      model,
+		tierModels: cfg.TierModels,
+		selector:   &AdaptiveSelector{},
+		httpClient: httpClient,
+	}, nil
+}
+
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type chatCompletionRequest struct {
+	Model     string        `json:"model"`
+	Messages  []chatMessage `json:"messages"`
+	MaxTokens int           `json:"max_tokens,omitempty"`
+}
+
+type chatCompletionResponse struct {
+	Choices []struct {
+		Message      chatMessage `json:"message"`
+		FinishReason string      `json:"finish_reason"`
+	} `json:"choices"`
+	Usage struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+	} `json:"usage"`
+}
+
+type chatErrorResponse struct {
+	Error struct {
+		Message string `json:"message"`
+		Type    string `json:"type"`
+	} `json:"error"`
+}
+
+// Complete implements LLMClient.
+func (c *OpenAICompatibleClient) Complete(ctx context.Context, prompt string, maxTokens int) (string, error) {
+	if maxTokens == 0 {
+		maxTokens = 4096 // Default to 4K tokens for responses
+	}
+
+	body, err := json.Marshal(chatCompletionRequest{
+		Model:     c.modelFor(ctx, prompt),
+		Messages:  []chatMessage{{Role: "user", Content: prompt}},
+		MaxTokens: maxTokens,
+	})
+	if err != nil {
+		return "", fmt.Errorf("marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("openai-compatible request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("read response: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		apiErr := &OpenAIAPIError{StatusCode: resp.StatusCode}
+		var errResp chatErrorResponse
+		if json.Unmarshal(data, &errResp) == nil && errResp.Error.Message != "" {
+			apiErr.Type = errResp.Error.Type
+			apiErr.Message = errResp.Error.Message
+		} else {
+			apiErr.Message = strings.TrimSpace(string(data))
+		}
+		return "", apiErr
+	}
+
+	var completion chatCompletionResponse
+	if err := json.Unmarshal(data, &completion); err != nil {
+		return "", fmt.Errorf("decode response: %w", err)
+	}
+	if len(completion.Choices) == 0 || completion.Choices[0].Message.Content == "" {
+		return "", fmt.Errorf("empty response")
+	}
+	RecordUsage(ctx, Usage{
+		PromptTokens:     completion.Usage.PromptTokens,
+		CompletionTokens: completion.Usage.CompletionTokens,
+	})
+
+	return completion.Choices[0].Message.Content, nil
+}
+
+// modelFor routes the prompt to a tier and returns the mapped model.
+func (c *OpenAICompatibleClient) modelFor(ctx context.Context, prompt string) string {
+	if len(c.tierModels) == 0 {
+		return c.model
+	}
+	budget, depth := extractContext(prompt)
+	tier := c.selector.determineTier(prompt, budget, depth)
+	if maxTier, ok := MaxTierFrom(ctx); ok && tier >= maxTier {
+		// Use the most capable mapped tier at or below the maximum
+		for t := maxTier; t >= TierFast; t-- {
+			if m := c.tierModels[t]; m != "" {
+				return m
+			}
+		}
+		return c.model
+	}
+	if minTier, ok := MinTierFrom(ctx); ok && tier < minTier {
+		// Use the cheapest mapped tier at or above the minimum
+		for t := minTier; t <= TierReasoning; t++ {
+			if m := c.tierModels[t]; m != "" {
+				return m
+			}
+		}
+		return c.model
+	}
+	if m := c.tierModels[tier]; m != "" {
+		return m
+	}
+	return c.model
+}
+
+// Model returns the default model name.
+func (c *OpenAICompatibleClient) Model() string {
+	return c.model
+}
+
+var _ CapabilityReporter = (*OpenAICompatibleClient)(nil)
+
+// Capabilities implements CapabilityReporter. The model's limits are not
+// known; tiers are routed when a tier maps to a model other than the
+// default.
+func (c *OpenAICompatibleClient) Capabilities() Capabilities {
+	var caps Capabilities
+	for _, m := range c.tierModels {
+		caps.TierRouting = caps.TierRouting || (m != "" && m != c.model)
+	}
+	return caps
+}
@@ -0,0 +1,183 @@
+package meta
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// chatServer records chat-completions requests and answers with handler.
+func chatServer(t *testing.T, handler func(w http.ResponseWriter, req chatCompletionRequest)) (*httptest.Server, *[]*http.Request) {
+	t.Helper()
+	var requests []*http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r)
+		var req chatCompletionRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		handler(w, req)
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func TestOpenAICompatibleClient_Complete(t *testing.T) {
+	var got chatCompletionRequest
+	server, requests := chatServer(t, func(w http.ResponseWriter, req chatCompletionRequest) {
+		got = req
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{
+			"id": "chatcmpl-1",
+			"object": "chat.completion",
+			"choices": [{"index": 0, "message": {"role": "assistant", "content": "hello back"}, "finish_reason": "stop"}],
+			"usage": {"prompt_tokens": 5, "completion_tokens": 2, "total_tokens": 7}
+		}`))
+	})
+
+	client, err := NewOpenAICompatibleClient(OpenAICompatibleConfig{
+		BaseURL: server.URL + "/v1/",
+		APIKey:  "sk-test",
+		Model:   "llama-3.1-8b",
+	})
+	require.NoError(t, err)
+
+	ctx, usage := TrackUsage(context.Background())
+	text, err := client.Complete(ctx, "hello", 256)
+	require.NoError(t, err)
+	assert.Equal(t, "hello back", text)
+
+	reported, calls := usage.Reported()
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, Usage{PromptTokens: 5, CompletionTokens: 2}, reported)
+
+	require.Len(t, *requests, 1)
+	r := (*requests)[0]
+	assert.Equal(t, http.MethodPost, r.Method)
+	assert.Equal(t, "/v1/chat/completions", r.URL.Path)
+	assert.Equal(t, "Bearer sk-test", r.Header.Get("Authorization"))
+	assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+
+	assert.Equal(t, chatCompletionRequest{
+		Model:     "llama-3.1-8b",
+		Messages:  []chatMessage{{Role: "user", Content: "hello"}},
+		MaxTokens: 256,
+	}, got)
+}
+
+func TestOpenAICompatibleClient_NoAPIKey(t *testing.T) {
+	t.Setenv("OPENAI_API_KEY", "")
+	server, requests := chatServer(t, func(w http.ResponseWriter, req chatCompletionRequest) {
+		w.Write([]byte(`{"choices": [{"message": {"role": "assistant", "content": "ok"}}]}`))
+	})
+
+	client, err := NewOpenAICompatibleClient(OpenAICompatibleConfig{BaseURL: server.URL, Model: "local"})
+	require.NoError(t, err)
+
+	_, err = client.Complete(context.Background(), "hi", 0)
+	require.NoError(t, err)
+	assert.Empty(t, (*requests)[0].Header.Get("Authorization"))
+}
+
+func TestOpenAICompatibleClient_TierRouting(t *testing.T) {
+	var models []string
+	server, _ := chatServer(t, func(w http.ResponseWriter, req chatCompletionRequest) {
+		models = append(models, req.Model)
+		w.Write([]byte(`{"choices": [{"message": {"role": "assistant", "content": "ok"}}]}`))
+	})
+
+	client, err := NewOpenAICompatibleClient(OpenAICompatibleConfig{
+		BaseURL: server.URL,
+		TierModels: map[ModelTier]string{
+			TierFast:      "qwen3-8b",
+			TierReasoning: "qwq-32b",
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "qwen3-8b", client.Model(), "default model falls back to the cheapest mapped tier")
+
+	ctx := context.Background()
+	for _, prompt := range []string{
+		"Task: simple\nBudget remaining: 500 tokens", // fast
+		"Task: prove this theorem",                   // reasoning
+		"Task: moderate work",                        // balanced, unmapped
+	} {
+		_, err := client.Complete(ctx, prompt, 100)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, []string{"qwen3-8b", "qwq-32b", "qwen3-8b"}, models)
+
+	// A minimum tier picks the cheapest mapped tier at or above it
+	models = nil
+	_, err = client.Complete(WithMinTier(ctx, TierPowerful), "Task: simple\nBudget remaining: 500 tokens", 100)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"qwq-32b"}, models)
+
+	// A maximum tier picks the most capable mapped tier at or below it
+	models = nil
+	_, err = client.Complete(WithMaxTier(ctx, TierPowerful), "Task: prove this theorem", 100)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"qwen3-8b"}, models)
+}
+
+func TestOpenAICompatibleClient_Non2xx(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		errType string
+		message string
+	}{
+		{"openai error body", http.StatusUnauthorized, `{"error": {"message": "invalid api key", "type": "invalid_request_error"}}`, "invalid_request_error", "invalid api key"},
+		{"plain text body", http.StatusBadGateway, "upstream unavailable\n", "", "upstream unavailable"},
+		{"rate limited", http.StatusTooManyRequests, `{"error": {"message": "slow down"}}`, "", "slow down"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, _ := chatServer(t, func(w http.ResponseWriter, req chatCompletionRequest) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			})
+			client, err := NewOpenAICompatibleClient(OpenAICompatibleConfig{BaseURL: server.URL, Model: "m"})
+			require.NoError(t, err)
+
+			_, err = client.Complete(context.Background(), "hi", 10)
+			var apiErr *OpenAIAPIError
+			require.ErrorAs(t, err, &apiErr)
+			assert.Equal(t, tt.status, apiErr.StatusCode)
+			assert.Equal(t, tt.errType, apiErr.Type)
+			assert.Equal(t, tt.message, apiErr.Message)
+		})
+	}
+}
+
+func TestOpenAICompatibleClient_BadResponses(t *testing.T) {
+	for name, body := range map[string]string{
+		"no choices":    `{"choices": []}`,
+		"empty content": `{"choices": [{"message": {"role": "assistant", "content": ""}}]}`,
+		"malformed":     `{"choices": [`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			server, _ := chatServer(t, func(w http.ResponseWriter, req chatCompletionRequest) {
+				w.Write([]byte(body))
+			})
+			client, err := NewOpenAICompatibleClient(OpenAICompatibleConfig{BaseURL: server.URL, Model: "m"})
+			require.NoError(t, err)
+
+			_, err = client.Complete(context.Background(), "hi", 10)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestNewOpenAICompatibleClient_Validation(t *testing.T) {
+	_, err := NewOpenAICompatibleClient(OpenAICompatibleConfig{Model: "m"})
+	assert.ErrorContains(t, err, "base URL")
+
+	_, err = NewOpenAICompatibleClient(OpenAICompatibleConfig{BaseURL: "http://localhost:8000/v1"})
+	assert.ErrorContains(t, err, "model")
+}
@@ -2,7 +2,9 @@ package meta
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 	"os"
 	"strings"
 
@@ -24,6 +26,52 @@ const (
 	TierReasoning
 )
 
+// String returns the tier name as used in REPL model hints.
+func (t ModelTier) String() string {
+	switch t {
+	case TierFast:
+		return "fast"
+	case TierBalanced:
+		return "balanced"
+	case TierPowerful:
+		return "powerful"
+	case TierReasoning:
+		return "reasoning"
+	default:
+		return "unknown"
+	}
+}
+
+type minTierKey struct{}
+
+// WithMinTier returns a context whose completions tier-routing clients
+// (OpenRouter, OpenAI-compatible with tier models) route to tier or above.
+// Clients with a single model ignore it.
+func WithMinTier(ctx context.Context, tier ModelTier) context.Context {
+	return context.WithValue(ctx, minTierKey{}, tier)
+}
+
+// MinTierFrom returns the minimum tier carried by ctx, if any.
+func MinTierFrom(ctx context.Context) (ModelTier, bool) {
+	tier, ok := ctx.Value(minTierKey{}).(ModelTier)
+	return tier, ok
+}
+
+type maxTierKey struct{}
+
+// WithMaxTier returns a context whose completions tier-routing clients
+// route to tier or below, e.g. to hold a low-stakes execution to cheap
+// models. It wins over a minimum tier above it.
+func WithMaxTier(ctx context.Context, tier ModelTier) context.Context {
+	return context.WithValue(ctx, maxTierKey{}, tier)
+}
+
+// MaxTierFrom returns the maximum tier carried by ctx, if any.
+func MaxTierFrom(ctx context.Context) (ModelTier, bool) {
+	tier, ok := ctx.Value(maxTierKey{}).(ModelTier)
+	return tier, ok
+}
+
 // ModelSpec defines a model's characteristics.
 type ModelSpec struct {
 	ID          string
@@ -33,10 +81,21 @@ type ModelSpec struct {
 	OutputCost  float64 // per million tokens
 	ContextSize int
 	Strengths   []string
+
+	// Priority ranks models within a tier; higher is preferred. It decides
+	// before cost, so a tier's primary model can be pinned whatever the
+	// catalog order. See CompareModels.
+	Priority int
+
+	// CostPerMToken is the blended cost per million tokens used to break
+	// ties. Zero uses the mean of InputCost and OutputCost.
+	CostPerMToken float64
 }
 
 // DefaultModels returns the default model catalog for OpenRouter routing.
 // Updated January 2026 with latest models and pricing from OpenRouter.
+// Each tier's primary model has Priority 1, so it is chosen whenever nothing
+// else distinguishes the candidates.
 func DefaultModels() []ModelSpec {
 	return []ModelSpec{
 		// ============================================================
@@ -50,6 +109,7 @@ func DefaultModels() []ModelSpec {
 			OutputCost:  5.00,
 			ContextSize: 200000,
 			Strengths:   []string{"fast", "orchestration", "efficient"},
+			Priority:    1,
 		},
 		{
 			ID:          "google/gemini-2.5-flash-lite",
@@ -99,6 +159,7 @@ func DefaultModels() []ModelSpec {
 			OutputCost:  15.00,
 			ContextSize: 1000000,
 			Strengths:   []string{"balanced", "coding", "agentic", "large-context"},
+			Priority:    1,
 		},
 		{
 			ID:          "google/gemini-2.5-flash",
@@ -148,6 +209,7 @@ func DefaultModels() []ModelSpec {
 			OutputCost:  25.00,
 			ContextSize: 200000,
 			Strengths:   []string{"powerful", "complex-reasoning", "agentic", "coding"},
+			Priority:    1,
 		},
 		{
 			ID:          "google/gemini-3-pro-preview",
@@ -188,6 +250,7 @@ func DefaultModels() []ModelSpec {
 			OutputCost:  1.75,
 			ContextSize: 164000,
 			Strengths:   []string{"reasoning", "math", "logic", "cheap"},
+			Priority:    1,
 		},
 		{
 			ID:          "qwen/qwq-32b",
@@ -225,6 +288,9 @@ type OpenRouterClient struct {
 	models   []ModelSpec
 	selector ModelSelector
 	fallback string
+
+	// Model sent prompts with images, empty if none accepts them
+	visionModel string
 }
 
 // ModelSelector chooses the best model for a task.
@@ -245,8 +311,46 @@ type OpenRouterConfig struct {
 
 	// FallbackModel is used when selection fails.
 	FallbackModel string
+
+	// VisionModel is the model prompts with images are sent to, bypassing
+	// routing. Empty leaves the client text-only, so images are described
+	// in text before they reach it.
+	VisionModel string
+
+	// KeylessFallback selects the client NewOpenRouterClientOrFallback
+	// uses when no API key is found. The default fails.
+	KeylessFallback KeylessFallback
+
+	// OpenAICompatible configures the client used by
+	// KeylessOpenAICompatible.
+	OpenAICompatible OpenAICompatibleConfig
+
+	// Stub configures the client used by KeylessStub.
+	Stub StubConfig
 }
 
+// ErrOpenRouterKeyMissing is returned when no OpenRouter API key is
+// configured or set in the environment.
+var ErrOpenRouterKeyMissing = errors.New("OpenRouter API key not provided")
+
+// KeylessFallback is the client used in place of OpenRouter when no API key
+// is available, for local and offline development. OpenRouter's model
+// catalog is unavailable under either: the stub answers every prompt the
+// same way, and an OpenAI-compatible server routes only by its TierModels.
+type KeylessFallback string
+
+const (
+	// KeylessFail returns ErrOpenRouterKeyMissing.
+	KeylessFail KeylessFallback = ""
+
+	// KeylessStub answers with an offline StubClient.
+	KeylessStub KeylessFallback = "stub"
+
+	// KeylessOpenAICompatible uses an OpenAI-compatible server such as a
+	// local vLLM or Ollama.
+	KeylessOpenAICompatible KeylessFallback = "openai-compatible"
+)
+
 // NewOpenRouterClient creates an OpenRouter client with intelligent routing.
 func NewOpenRouterClient(cfg OpenRouterConfig) (*OpenRouterClient, error) {
 	apiKey := cfg.APIKey
@@ -254,7 +358,7 @@ func NewOpenRouterClient(cfg OpenRouterConfig) (*OpenRouterClient, error) {
 		apiKey = os.Getenv("OPENROUTER_API_KEY")
 	}
 	if apiKey == "" {
-		return nil, fmt.Errorf("OpenRouter API key not provided (set OPENROUTER_API_KEY)")
+		return nil, fmt.Errorf("%w (set OPENROUTER_API_KEY)", ErrOpenRouterKeyMissing)
 	}
 
 	provider, err := openrouter.New(openrouter.WithAPIKey(apiKey))
@@ -278,43 +382,129 @@ func NewOpenRouterClient(cfg OpenRouterConfig) (*OpenRouterClient, error) {
 	}
 
 	return &OpenRouterClient{
-		provider: provider,
-		models:   models,
-		selector: selector,
-		fallback: fallback,
+		provider:    provider,
+		models:      models,
+		selector:    selector,
+		fallback:    fallback,
+		visionModel: cfg.VisionModel,
 	}, nil
 }
 
-// Complete implements LLMClient with intelligent model selection.
+// NewOpenRouterClientOrFallback creates an OpenRouter client, or, if no API
+// key is available, the client cfg.KeylessFallback selects. A fallback is
+// logged, since it disables model routing.
+func NewOpenRouterClientOrFallback(cfg OpenRouterConfig) (LLMClient, error) {
+	client, err := NewOpenRouterClient(cfg)
+	if err == nil {
+		return client, nil
+	}
+	if !errors.Is(err, ErrOpenRouterKeyMissing) {
+		return nil, err
+	}
+
+	switch cfg.KeylessFallback {
+	case KeylessFail:
+		return nil, err
+	case KeylessStub:
+		slog.Warn("OpenRouter API key not set; model routing disabled, answering with stub client")
+		return NewStubClient(cfg.Stub), nil
+	case KeylessOpenAICompatible:
+		compat, err := NewOpenAICompatibleClient(cfg.OpenAICompatible)
+		if err != nil {
+			return nil, fmt.Errorf("create keyless fallback client: %w", err)
+		}
+		slog.Warn("OpenRouter API key not set; OpenRouter routing disabled, using OpenAI-compatible server",
+			"base_url", cfg.OpenAICompatible.BaseURL, "model", compat.Model())
+		return compat, nil
+	default:
+		return nil, fmt.Errorf("unknown keyless fallback %q", cfg.KeylessFallback)
+	}
+}
+
+// Complete implements LLMClient with intelligent model selection. The
+// routing decision is recorded with RecordRoute.
 func (c *OpenRouterClient) Complete(ctx context.Context, prompt string, maxTokens int) (string, error) {
 	if maxTokens == 0 {
 		maxTokens = 4096 // Default to 4K tokens for responses
 	}
 
-	// Extract task context from prompt for model selection
-	budget, depth := extractContext(prompt)
-
 	// Select best model for this task
-	spec := c.selector.SelectModel(ctx, prompt, budget, depth)
-	modelID := c.fallback
-	if spec != nil {
-		modelID = spec.ID
-	}
+	route := c.ExplainRoute(ctx, prompt)
 
 	// Get language model
-	lm, err := c.provider.LanguageModel(ctx, modelID)
-	if err != nil {
+	lm, err := c.provider.LanguageModel(ctx, route.Model)
+	if err != nil && !route.Fallback {
 		// Try fallback
+		route.Fallback = true
+		route.Rationale = fmt.Sprintf("%s unavailable, used fallback %s", route.Model, c.fallback)
+		route.Model = c.fallback
 		lm, err = c.provider.LanguageModel(ctx, c.fallback)
-		if err != nil {
-			return "", fmt.Errorf("get language model: %w", err)
-		}
 	}
+	RecordRoute(ctx, route)
+	if err != nil {
+		return "", fmt.Errorf("get language model: %w", err)
+	}
+
+	return generate(ctx, lm, prompt, nil, maxTokens)
+}
+
+var _ MultimodalClient = (*OpenRouterClient)(nil)
+
+// SupportsImages implements MultimodalClient: images are accepted when a
+// VisionModel is configured.
+func (c *OpenRouterClient) SupportsImages() bool {
+	return c.visionModel != ""
+}
+
+var _ CapabilityReporter = (*OpenRouterClient)(nil)
+
+// Capabilities implements CapabilityReporter. Any catalog model may be
+// routed to, so the context window is the smallest among them, and tiers
+// are routed when the catalog spans more than one.
+func (c *OpenRouterClient) Capabilities() Capabilities {
+	caps := Capabilities{Images: c.SupportsImages()}
+	for _, m := range c.models {
+		caps.ContextWindow = minKnown(caps.ContextWindow, m.ContextSize)
+		caps.TierRouting = caps.TierRouting || m.Tier != c.models[0].Tier
+	}
+	return caps
+}
 
-	// Build and execute call
+// CompleteWithImages implements MultimodalClient by sending the prompt and
+// images to the VisionModel.
+func (c *OpenRouterClient) CompleteWithImages(ctx context.Context, prompt string, images []Image, maxTokens int) (string, error) {
+	if c.visionModel == "" {
+		return "", fmt.Errorf("no vision model configured")
+	}
+	if maxTokens == 0 {
+		maxTokens = 4096
+	}
+
+	budget, depth := extractContext(prompt)
+	RecordRoute(ctx, &RouteDecision{
+		Budget:     budget,
+		Depth:      depth,
+		TierReason: "images attached",
+		Model:      c.visionModel,
+		Rationale:  "vision model",
+	})
+	lm, err := c.provider.LanguageModel(ctx, c.visionModel)
+	if err != nil {
+		return "", fmt.Errorf("get vision model: %w", err)
+	}
+
+	files := make([]fantasy.FilePart, len(images))
+	for i, img := range images {
+		files[i] = fantasy.FilePart{Filename: img.Name, Data: img.Data, MediaType: img.MediaType}
+	}
+	return generate(ctx, lm, prompt, files, maxTokens)
+}
+
+// generate sends prompt and any files to lm, recording the usage reported.
+func generate(ctx context.Context, lm fantasy.LanguageModel, prompt string, files []fantasy.FilePart, maxTokens int) (string, error) {
 	maxTokens64 := int64(maxTokens)
 	call := fantasy.Call{
-		Prompt:          fantasy.Prompt{fantasy.NewUserMessage(prompt)},
+		Prompt:          fantasy.Prompt{fantasy.NewUserMessage(prompt, files...)},
 		MaxOutputTokens: &maxTokens64,
 	}
 
@@ -327,23 +517,56 @@ func (c *OpenRouterClient) Complete(ctx context.Context, prompt string, maxToken
 	if text == "" {
 		return "", fmt.Errorf("empty response")
 	}
+	RecordUsage(ctx, Usage{
+		PromptTokens:     int(resp.Usage.InputTokens),
+		CompletionTokens: int(resp.Usage.OutputTokens),
+	})
 
 	return text, nil
 }
 
-// extractContext parses budget and depth from the prompt.
+// ExplainRoute returns the routing decision Complete would make for prompt,
+// without calling the model. Selectors that are not RouteExplainers are
+// only reported by the model they chose.
+func (c *OpenRouterClient) ExplainRoute(ctx context.Context, prompt string) *RouteDecision {
+	// Extract task context from prompt for model selection
+	budget, depth := extractContext(prompt)
+	task := extractTask(prompt)
+
+	var route *RouteDecision
+	if explainer, ok := c.selector.(RouteExplainer); ok {
+		route = explainer.ExplainRoute(ctx, task, budget, depth)
+	} else {
+		route = &RouteDecision{Budget: budget, Depth: depth, TierReason: "custom selector"}
+		if spec := c.selector.SelectModel(ctx, task, budget, depth); spec != nil {
+			route.Tier = spec.Tier
+			route.Model = spec.ID
+			route.Rationale = "chosen by custom selector"
+		}
+	}
+	if route.Model == "" {
+		route.Model = c.fallback
+		route.Fallback = true
+		route.Rationale = fmt.Sprintf("no model selected, used fallback %s", c.fallback)
+	}
+	return route
+}
+
+// extractContext parses budget and depth from the prompt. The state
+// section follows the instructions, which may mention the same labels, so
+// the last occurrence is read.
 func extractContext(prompt string) (budget, depth int) {
 	// Default values
 	budget = 10000
 	depth = 0
 
 	// Look for budget info
-	if idx := strings.Index(prompt, "Budget remaining:"); idx != -1 {
+	if idx := strings.LastIndex(prompt, "Budget remaining:"); idx != -1 {
 		fmt.Sscanf(prompt[idx:], "Budget remaining: %d", &budget)
 	}
 
 	// Look for depth info
-	if idx := strings.Index(prompt, "Recursion depth:"); idx != -1 {
+	if idx := strings.LastIndex(prompt, "Recursion depth:"); idx != -1 {
 		fmt.Sscanf(prompt[idx:], "Recursion depth: %d", &depth)
 	}
 
@@ -355,107 +578,188 @@ type AdaptiveSelector struct {
 	models []ModelSpec
 }
 
+// NewAdaptiveSelector creates a selector choosing among models.
+func NewAdaptiveSelector(models []ModelSpec) *AdaptiveSelector {
+	return &AdaptiveSelector{models: models}
+}
+
 // SelectModel chooses the best model based on task, budget, and depth.
 func (s *AdaptiveSelector) SelectModel(ctx context.Context, task string, budget int, depth int) *ModelSpec {
+	return s.ExplainRoute(ctx, task, budget, depth).spec
+}
+
+// ExplainRoute chooses the best model based on task, budget, and depth,
+// explaining the choice. SelectModel returns the same model.
+func (s *AdaptiveSelector) ExplainRoute(ctx context.Context, task string, budget int, depth int) *RouteDecision {
 	// Determine required tier based on context
-	tier := s.determineTier(task, budget, depth)
+	tier, rule, reason, keywords := s.routeTier(task, budget, depth)
+	route := &RouteDecision{
+		Budget:          budget,
+		Depth:           depth,
+		MatchedKeywords: keywords,
+		Tier:            tier,
+		TierReason:      reason,
+		Rule:            rule,
+	}
+	if minTier, ok := MinTierFrom(ctx); ok && tier < minTier {
+		route.Tier = minTier
+		route.TierReason = fmt.Sprintf("%s, raised from %s to the minimum tier", reason, tier)
+		route.MinTierApplied = true
+	}
+	if maxTier, ok := MaxTierFrom(ctx); ok && route.Tier > maxTier {
+		route.TierReason = fmt.Sprintf("%s, lowered from %s to the maximum tier", route.TierReason, route.Tier)
+		route.Tier = maxTier
+		route.MaxTierApplied = true
+	}
 
 	// Find best model for tier
 	var candidates []*ModelSpec
 	for i := range s.models {
-		if s.models[i].Tier == tier {
+		if s.models[i].Tier == route.Tier {
 			candidates = append(candidates, &s.models[i])
 		}
 	}
 
 	if len(candidates) == 0 {
 		// Fall back to fast tier
-		for i := range s.models {
-			if s.models[i].Tier == TierFast {
-				return &s.models[i]
-			}
+		if spec := SelectForTier(s.models, TierFast); spec != nil {
+			route.spec = spec
+			route.Model = spec.ID
+			route.Rationale = fmt.Sprintf("no %s models in the catalog, used the preferred fast model", route.Tier)
 		}
-		return nil
+		return route
 	}
 
 	// Select based on task keywords
-	return s.rankCandidates(candidates, task)
+	scored, best := scoreCandidates(candidates, task)
+	route.Candidates = scored
+	route.spec = candidates[best]
+	route.Model = route.spec.ID
+	route.Rationale = rankRationale(scored, candidates, best)
+	return route
 }
 
 // determineTier chooses model tier based on context.
 func (s *AdaptiveSelector) determineTier(task string, budget int, depth int) ModelTier {
+	tier, _, _, _ := s.routeTier(task, budget, depth)
+	return tier
+}
+
+// routeTier chooses model tier based on context, returning the rule that
+// chose it, the rule's reason and any keywords it matched.
+func (s *AdaptiveSelector) routeTier(task string, budget int, depth int) (ModelTier, RouteRule, string, []string) {
 	taskLower := strings.ToLower(task)
 
 	// Depth-based selection first (use simpler models at higher depth to save resources)
 	// This takes priority over keywords to ensure we don't recurse with expensive models
 	if depth >= 3 {
-		return TierFast
+		return TierFast, RuleDeepRecursion, fmt.Sprintf("recursion depth %d >= 3", depth), nil
 	}
 
 	// Check for reasoning-specific keywords
-	reasoningKeywords := []string{"prove", "theorem", "logic", "math", "calculate", "reason"}
-	for _, kw := range reasoningKeywords {
-		if strings.Contains(taskLower, kw) {
-			return TierReasoning
-		}
+	if matched := containsKeywords(taskLower, reasoningKeywords); len(matched) > 0 {
+		return TierReasoning, RuleReasoningKeywords, "reasoning keywords", matched
 	}
 
 	// Check for complex task keywords (only if budget allows)
-	complexKeywords := []string{"analyze", "refactor", "design", "architect", "complex"}
-	for _, kw := range complexKeywords {
-		if strings.Contains(taskLower, kw) && budget > 5000 {
-			return TierPowerful
-		}
+	if matched := containsKeywords(taskLower, complexKeywords); len(matched) > 0 && budget > 5000 {
+		return TierPowerful, RuleComplexKeywords, fmt.Sprintf("complex-task keywords with budget %d > 5000", budget), matched
 	}
 
 	// Budget-based selection
 	if budget < 1000 {
-		return TierFast
+		return TierFast, RuleLowBudget, fmt.Sprintf("low budget %d < 1000", budget), nil
 	}
 
 	// Moderate depth prefers balanced
 	if depth >= 2 {
-		return TierBalanced
+		return TierBalanced, RuleModerateDepth, fmt.Sprintf("recursion depth %d >= 2", depth), nil
 	}
 
 	if budget < 5000 {
-		return TierBalanced
+		return TierBalanced, RuleModerateBudget, fmt.Sprintf("moderate budget %d < 5000", budget), nil
 	}
 
 	// Default to balanced for meta-controller decisions
-	return TierBalanced
+	return TierBalanced, RuleDefault, "default", nil
+}
+
+var (
+	reasoningKeywords = []string{"prove", "theorem", "logic", "math", "calculate", "reason"}
+	complexKeywords   = []string{"analyze", "refactor", "design", "architect", "complex"}
+)
+
+// containsKeywords returns the keywords that occur in taskLower.
+func containsKeywords(taskLower string, keywords []string) []string {
+	var matched []string
+	for _, kw := range keywords {
+		if strings.Contains(taskLower, kw) {
+			matched = append(matched, kw)
+		}
+	}
+	return matched
 }
 
 // rankCandidates selects best candidate based on task content.
 func (s *AdaptiveSelector) rankCandidates(candidates []*ModelSpec, task string) *ModelSpec {
+	if len(candidates) == 0 {
+		return nil
+	}
+	_, best := scoreCandidates(candidates, task)
+	return candidates[best]
+}
+
+// scoreCandidates scores each candidate against the task and returns the
+// scores with the index of the best candidate: the highest score, with ties
+// broken by CompareModels. It needs at least one candidate.
+func scoreCandidates(candidates []*ModelSpec, task string) ([]RouteCandidate, int) {
 	taskLower := strings.ToLower(task)
 
 	// Score each candidate
-	var bestScore int
-	var best *ModelSpec
+	scored := make([]RouteCandidate, len(candidates))
+	best := 0
 
-	for _, c := range candidates {
-		score := 0
+	for i, c := range candidates {
+		scored[i] = RouteCandidate{Model: c.ID, Priority: c.Priority, InputCost: c.InputCost}
 
 		// Match strengths to task
 		for _, strength := range c.Strengths {
 			if strings.Contains(taskLower, strength) {
-				score += 10
+				scored[i].Score += 10
+				scored[i].MatchedStrengths = append(scored[i].MatchedStrengths, strength)
 			}
 		}
 
-		// Prefer cheaper models when scores are tied
-		if score > bestScore || (score == bestScore && best != nil && c.InputCost < best.InputCost) {
-			bestScore = score
-			best = c
+		score := scored[i].Score
+		if score > scored[best].Score || (score == scored[best].Score && CompareModels(c, candidates[best]) < 0) {
+			best = i
 		}
 	}
 
-	if best == nil && len(candidates) > 0 {
-		best = candidates[0]
-	}
+	return scored, best
+}
 
-	return best
+// rankRationale explains why candidate best won.
+func rankRationale(scored []RouteCandidate, candidates []*ModelSpec, best int) string {
+	chosen := scored[best]
+	if len(scored) == 1 {
+		return "only candidate in tier"
+	}
+	var tied []*ModelSpec
+	for i, c := range scored {
+		if c.Score == chosen.Score {
+			tied = append(tied, candidates[i])
+		}
+	}
+	rule := tieBreak(candidates[best], tied)
+	if chosen.Score == 0 {
+		return fmt.Sprintf("no candidate matched the task; %s of %d", rule, len(tied))
+	}
+	reason := fmt.Sprintf("highest score %d (strengths: %s)", chosen.Score, strings.Join(chosen.MatchedStrengths, ", "))
+	if len(tied) > 1 {
+		reason += fmt.Sprintf(", %s of %d tied", rule, len(tied))
+	}
+	return reason
 }
 
 // Provider returns the underlying OpenRouter provider.
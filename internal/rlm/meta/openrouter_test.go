@@ -82,6 +82,39 @@ func TestAdaptiveSelector_SelectModel(t *testing.T) {
 	}
 }
 
+func TestAdaptiveSelector_SelectModel_MinTier(t *testing.T) {
+	selector := &AdaptiveSelector{models: DefaultModels()}
+	ctx := WithMinTier(context.Background(), TierPowerful)
+
+	// A fast-tier task is raised to the minimum
+	spec := selector.SelectModel(ctx, "simple task", 500, 0)
+	require.NotNil(t, spec)
+	assert.Equal(t, TierPowerful, spec.Tier)
+
+	// Higher tiers are kept
+	spec = selector.SelectModel(ctx, "prove this theorem", 10000, 0)
+	require.NotNil(t, spec)
+	assert.Equal(t, TierReasoning, spec.Tier)
+}
+
+func TestAdaptiveSelector_SelectModel_MaxTier(t *testing.T) {
+	selector := &AdaptiveSelector{models: DefaultModels()}
+	ctx := WithMaxTier(context.Background(), TierFast)
+
+	// A reasoning task is lowered to the maximum
+	route := selector.ExplainRoute(ctx, "prove this theorem", 10000, 0)
+	assert.Equal(t, TierFast, route.Tier)
+	assert.True(t, route.MaxTierApplied)
+	spec := selector.SelectModel(ctx, "prove this theorem", 10000, 0)
+	require.NotNil(t, spec)
+	assert.Equal(t, TierFast, spec.Tier)
+
+	// It wins over a minimum tier
+	spec = selector.SelectModel(WithMinTier(ctx, TierPowerful), "simple task", 500, 0)
+	require.NotNil(t, spec)
+	assert.Equal(t, TierFast, spec.Tier)
+}
+
 func TestAdaptiveSelector_DetermineTier(t *testing.T) {
 	selector := &AdaptiveSelector{models: DefaultModels()}
 
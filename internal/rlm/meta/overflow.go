@@ -0,0 +1,172 @@
+package meta
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"charm.land/fantasy"
+)
+
+// ErrContextOverflow is matched by errors reporting that a prompt did not
+// fit the model's context window.
+var ErrContextOverflow = errors.New("context window exceeded")
+
+// ContextOverflowError is a provider error recognized as a context-window
+// overflow. It matches ErrContextOverflow and unwraps to the provider's
+// error.
+type ContextOverflowError struct {
+	// Provider names the provider whose signature matched, e.g.
+	// "anthropic" or "openai-compatible"; "unknown" when only the status
+	// code or a generic message matched.
+	Provider string
+
+	// StatusCode is the HTTP status of the response, zero if unknown.
+	StatusCode int
+
+	Err error
+}
+
+func (e *ContextOverflowError) Error() string {
+	return fmt.Sprintf("%s: %v", ErrContextOverflow, e.Err)
+}
+
+func (e *ContextOverflowError) Unwrap() error { return e.Err }
+
+// Is reports whether target is ErrContextOverflow.
+func (e *ContextOverflowError) Is(target error) bool {
+	return target == ErrContextOverflow
+}
+
+// overflowSignatures are lowercase message fragments each provider uses
+// for a prompt that does not fit the context window.
+var overflowSignatures = map[string][]string{
+	"openai": {
+		"context_length_exceeded",
+		"maximum context length",
+	},
+	"anthropic": {
+		"prompt is too long",
+		"exceed context limit",
+	},
+	"openrouter": {
+		"maximum context length",
+		"context length exceeded",
+	},
+	"google": {
+		"exceeds the maximum number of tokens",
+		"input token count",
+	},
+	// vLLM, llama.cpp and Ollama servers behind the OpenAI-compatible client
+	"openai-compatible": {
+		"context_length_exceeded",
+		"maximum context length",
+		"exceeds the available context size",
+		"exceed the available context size",
+		"exceeds the context window",
+		"prompt is too long",
+	},
+}
+
+// genericOverflowSignatures are matched for providers without their own
+// entry, and for errors that carry no status.
+var genericOverflowSignatures = []string{
+	"context_length_exceeded",
+	"maximum context length",
+	"exceeds the context window",
+	"prompt is too long",
+}
+
+// DetectContextOverflow reports whether err is a context-window overflow,
+// returning it as a ContextOverflowError. Provider errors are matched by
+// their status and the message signature of the provider that sent them:
+// an overflow is a 400 (or 413) with an overflow message, so a 429 about
+// tokens per minute is not one. Errors carrying no status are matched by
+// generic signatures only.
+func DetectContextOverflow(err error) (*ContextOverflowError, bool) {
+	if err == nil {
+		return nil, false
+	}
+	var overflow *ContextOverflowError
+	if errors.As(err, &overflow) {
+		return overflow, true
+	}
+
+	var compatErr *OpenAIAPIError
+	if errors.As(err, &compatErr) {
+		message := compatErr.Type + " " + compatErr.Message
+		if isOverflowStatus(compatErr.StatusCode, message, overflowSignatures["openai-compatible"]) {
+			return &ContextOverflowError{Provider: "openai-compatible", StatusCode: compatErr.StatusCode, Err: err}, true
+		}
+		return nil, false
+	}
+
+	var providerErr *fantasy.ProviderError
+	if errors.As(err, &providerErr) {
+		provider := providerFromURL(providerErr.URL)
+		signatures, ok := overflowSignatures[provider]
+		if !ok {
+			provider, signatures = "unknown", genericOverflowSignatures
+		}
+		message := providerErr.Title + " " + providerErr.Message + " " + string(providerErr.ResponseBody)
+		if isOverflowStatus(providerErr.StatusCode, message, signatures) {
+			return &ContextOverflowError{Provider: provider, StatusCode: providerErr.StatusCode, Err: err}, true
+		}
+		return nil, false
+	}
+
+	if containsAny(strings.ToLower(err.Error()), genericOverflowSignatures) {
+		return &ContextOverflowError{Provider: "unknown", Err: err}, true
+	}
+	return nil, false
+}
+
+// IsContextOverflow reports whether err is a context-window overflow.
+func IsContextOverflow(err error) bool {
+	_, ok := DetectContextOverflow(err)
+	return ok
+}
+
+// isOverflowStatus reports whether a response with status and message is
+// an overflow. A 413 is one whatever its message; an unknown status is
+// judged by the message alone.
+func isOverflowStatus(status int, message string, signatures []string) bool {
+	switch status {
+	case http.StatusRequestEntityTooLarge:
+		return true
+	case 0, http.StatusBadRequest, http.StatusUnprocessableEntity:
+		return containsAny(strings.ToLower(message), signatures)
+	}
+	return false
+}
+
+// providerFromURL names the provider serving a request URL.
+func providerFromURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+	host := strings.ToLower(u.Hostname())
+	switch {
+	case strings.HasSuffix(host, "anthropic.com"):
+		return "anthropic"
+	case strings.HasSuffix(host, "openrouter.ai"):
+		return "openrouter"
+	case strings.HasSuffix(host, "openai.com"):
+		return "openai"
+	case strings.HasSuffix(host, "googleapis.com"):
+		return "google"
+	}
+	return ""
+}
+
+func containsAny(s string, fragments []string) bool {
+	for _, f := range fragments {
+		if strings.Contains(s, f) {
+			return true
+		}
+	}
+	return false
+}
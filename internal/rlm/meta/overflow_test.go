@@ -0,0 +1,133 @@
+package meta
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"charm.land/fantasy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectContextOverflow(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		want     bool
+		provider string
+	}{
+		{
+			name: "anthropic prompt too long",
+			err: &fantasy.ProviderError{
+				URL:        "https://api.anthropic.com/v1/messages",
+				StatusCode: 400,
+				Title:      "invalid_request_error",
+				Message:    "prompt is too long: 210000 tokens > 200000 maximum",
+			},
+			want:     true,
+			provider: "anthropic",
+		},
+		{
+			name: "openai context_length_exceeded",
+			err: &fantasy.ProviderError{
+				URL:          "https://api.openai.com/v1/chat/completions",
+				StatusCode:   400,
+				Message:      "This model's maximum context length is 128000 tokens.",
+				ResponseBody: []byte(`{"error": {"code": "context_length_exceeded"}}`),
+			},
+			want:     true,
+			provider: "openai",
+		},
+		{
+			name: "openrouter wrapped by the client",
+			err: fmt.Errorf("openrouter generate: %w", &fantasy.ProviderError{
+				URL:        "https://openrouter.ai/api/v1/chat/completions",
+				StatusCode: 400,
+				Message:    "This endpoint's maximum context length is 65536 tokens. However, you requested about 90000 tokens.",
+			}),
+			want:     true,
+			provider: "openrouter",
+		},
+		{
+			name: "request entity too large",
+			err: &fantasy.ProviderError{
+				URL:        "https://api.anthropic.com/v1/messages",
+				StatusCode: 413,
+				Message:    "Request exceeds the maximum allowed number of bytes.",
+			},
+			want:     true,
+			provider: "anthropic",
+		},
+		{
+			name: "tokens-per-minute rate limit is not an overflow",
+			err: &fantasy.ProviderError{
+				URL:        "https://api.openai.com/v1/chat/completions",
+				StatusCode: 429,
+				Message:    "Request too large for gpt-4o: maximum context length per minute exceeded; limit 30000 tokens per min.",
+			},
+			want: false,
+		},
+		{
+			name: "anthropic signature is not trusted from openai",
+			err: &fantasy.ProviderError{
+				URL:        "https://api.openai.com/v1/chat/completions",
+				StatusCode: 400,
+				Message:    "exceed context limit",
+			},
+			want: false,
+		},
+		{
+			name:     "llama.cpp behind the openai-compatible client",
+			err:      &OpenAIAPIError{StatusCode: 400, Message: "the request exceeds the available context size, try increasing it"},
+			want:     true,
+			provider: "openai-compatible",
+		},
+		{
+			name:     "vllm context_length_exceeded type",
+			err:      &OpenAIAPIError{StatusCode: 400, Type: "context_length_exceeded", Message: "too many tokens"},
+			want:     true,
+			provider: "openai-compatible",
+		},
+		{
+			name: "unrelated bad request",
+			err:  &OpenAIAPIError{StatusCode: 400, Type: "invalid_request_error", Message: "temperature must be at most 2"},
+			want: false,
+		},
+		{
+			name:     "plain error with a generic signature",
+			err:      errors.New("prompt is too long"),
+			want:     true,
+			provider: "unknown",
+		},
+		{
+			name: "plain unrelated error",
+			err:  errors.New("connection reset by peer"),
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			overflow, ok := DetectContextOverflow(tt.err)
+			assert.Equal(t, tt.want, ok)
+			assert.Equal(t, tt.want, IsContextOverflow(tt.err))
+			if !tt.want {
+				assert.Nil(t, overflow)
+				return
+			}
+			require.NotNil(t, overflow)
+			assert.Equal(t, tt.provider, overflow.Provider)
+			assert.ErrorIs(t, overflow, ErrContextOverflow)
+			assert.ErrorIs(t, overflow, tt.err, "unwraps to the provider error")
+		})
+	}
+
+	assert.False(t, IsContextOverflow(nil))
+
+	// An already detected overflow is recognized through wrapping
+	detected, _ := DetectContextOverflow(tests[0].err)
+	again, ok := DetectContextOverflow(fmt.Errorf("main LLM call failed: %w", detected))
+	require.True(t, ok)
+	assert.Same(t, detected, again)
+}
@@ -0,0 +1,30 @@
+package meta
+
+import "context"
+
+// DecisionPolicy chooses the orchestration action for a state. Controller
+// is the default, LLM-backed policy; a custom one can route by rules for
+// predictability and cost, replay fixed decisions in tests, or run a
+// trained model.
+type DecisionPolicy interface {
+	Decide(ctx context.Context, state State) (*Decision, error)
+}
+
+// DecisionExplainer is implemented by policies that can explain their most
+// recent decision, as Controller does.
+type DecisionExplainer interface {
+	ExplainLastDecision() (*DecisionExplanation, error)
+}
+
+// DecisionPolicyFunc adapts a function to a DecisionPolicy.
+type DecisionPolicyFunc func(ctx context.Context, state State) (*Decision, error)
+
+// Decide calls f.
+func (f DecisionPolicyFunc) Decide(ctx context.Context, state State) (*Decision, error) {
+	return f(ctx, state)
+}
+
+var (
+	_ DecisionPolicy    = (*Controller)(nil)
+	_ DecisionExplainer = (*Controller)(nil)
+)
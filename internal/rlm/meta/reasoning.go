@@ -0,0 +1,70 @@
+package meta
+
+import "regexp"
+
+// ReasoningKind classifies tasks that benefit from exploring several
+// reasoning paths rather than answering in one pass.
+type ReasoningKind string
+
+const (
+	// ReasoningNone is an ordinary task.
+	ReasoningNone ReasoningKind = ""
+
+	// ReasoningProof covers proofs, derivations and similar math reasoning.
+	ReasoningProof ReasoningKind = "proof"
+
+	// ReasoningPlanning covers multi-step plans and puzzles.
+	ReasoningPlanning ReasoningKind = "planning"
+)
+
+// minToTDepthRemaining is the recursion headroom TOT needs; near the depth
+// limit a direct answer is cheaper.
+const minToTDepthRemaining = 2
+
+var (
+	proofPattern = regexp.MustCompile(
+		`(?i)\b(prove|proof|theorem|lemma|corollary|derive|derivation|by induction|show that)\b`)
+	planningPattern = regexp.MustCompile(
+		`(?i)\b(plan (out|how)|multi-step plan|step-by-step plan|puzzle|sequence of (moves|steps))\b`)
+)
+
+// ClassifyReasoning reports whether task is a deep-reasoning task.
+func ClassifyReasoning(task string) ReasoningKind {
+	switch {
+	case proofPattern.MatchString(task):
+		return ReasoningProof
+	case planningPattern.MatchString(task):
+		return ReasoningPlanning
+	default:
+		return ReasoningNone
+	}
+}
+
+// decideToT returns a TOT decision for deep-reasoning tasks when the
+// remaining recursion depth and token budget allow it, or nil otherwise.
+func (c *Controller) decideToT(state State) *Decision {
+	if c.disableToT {
+		return nil
+	}
+	if state.MaxDepth-state.RecursionDepth < minToTDepthRemaining || state.BudgetRemain < c.totMinBudget {
+		return nil
+	}
+
+	var strategy string
+	switch ClassifyReasoning(state.Task) {
+	case ReasoningProof:
+		// Proof steps have clear quality signals, so follow the best one.
+		strategy = "best_first"
+	case ReasoningPlanning:
+		// Plans need alternatives compared level by level.
+		strategy = "bfs"
+	default:
+		return nil
+	}
+
+	return &Decision{
+		Action:    ActionToT,
+		Params:    DecisionParams{ToTStrategy: strategy},
+		Reasoning: "Deep-reasoning task, exploring alternative paths with Tree of Thoughts",
+	}
+}
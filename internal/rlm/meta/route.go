@@ -0,0 +1,189 @@
+package meta
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// RouteDecision explains how a tier-routing client picked the model for a
+// completion: the inputs read from the prompt, the rule that set the tier,
+// and how the candidate models in it scored. It is deterministic for a
+// given prompt, catalog and minimum tier.
+type RouteDecision struct {
+	// Budget and Depth are the remaining token budget and recursion depth
+	// read from the prompt, or their defaults.
+	Budget int `json:"budget"`
+	Depth  int `json:"depth"`
+
+	// MatchedKeywords are the task keywords behind the tier, if a keyword
+	// rule set it.
+	MatchedKeywords []string `json:"matched_keywords,omitempty"`
+
+	// Tier is the tier routed to, and TierReason the rule that chose it.
+	Tier       ModelTier `json:"tier"`
+	TierReason string    `json:"tier_reason"`
+
+	// Rule identifies the rule behind TierReason, for aggregating
+	// decisions. It is empty for models chosen by custom selectors.
+	Rule RouteRule `json:"rule,omitempty"`
+
+	// MinTierApplied is set when the context's minimum tier (WithMinTier)
+	// raised the tier the rules chose.
+	MinTierApplied bool `json:"min_tier_applied,omitempty"`
+
+	// MaxTierApplied is set when the context's maximum tier (WithMaxTier)
+	// lowered it.
+	MaxTierApplied bool `json:"max_tier_applied,omitempty"`
+
+	// Candidates are the models of Tier that were considered, in catalog
+	// order.
+	Candidates []RouteCandidate `json:"candidates,omitempty"`
+
+	// Model is the model the completion was sent to, and Rationale why it
+	// was chosen among the candidates.
+	Model     string `json:"model"`
+	Rationale string `json:"rationale"`
+
+	// Fallback is set when the selected model was unavailable or none was
+	// selected, and the client's fallback model was used instead.
+	Fallback bool `json:"fallback,omitempty"`
+
+	spec *ModelSpec
+}
+
+// RouteRule identifies a tier routing rule. AdaptiveSelector applies its
+// rules in the order listed; RuleRequested is for tiers a caller asked for.
+type RouteRule string
+
+const (
+	// RuleDeepRecursion routes depth 3 and deeper to the fast tier, so
+	// deeper recursion uses simpler models.
+	RuleDeepRecursion RouteRule = "deep_recursion"
+
+	// RuleReasoningKeywords routes tasks with reasoning keywords to the
+	// reasoning tier.
+	RuleReasoningKeywords RouteRule = "reasoning_keywords"
+
+	// RuleComplexKeywords routes tasks with complex-task keywords to the
+	// powerful tier when the budget exceeds 5000 tokens.
+	RuleComplexKeywords RouteRule = "complex_keywords"
+
+	// RuleLowBudget downgrades to the fast tier under 1000 tokens of
+	// budget.
+	RuleLowBudget RouteRule = "low_budget"
+
+	// RuleModerateDepth routes depth 2 to the balanced tier.
+	RuleModerateDepth RouteRule = "moderate_depth"
+
+	// RuleModerateBudget routes budgets under 5000 tokens to the balanced
+	// tier.
+	RuleModerateBudget RouteRule = "moderate_budget"
+
+	// RuleDefault routes everything else to the balanced tier.
+	RuleDefault RouteRule = "default"
+
+	// RuleRequested is the tier the caller asked for.
+	RuleRequested RouteRule = "requested"
+)
+
+// RouteCandidate is a model considered for a completion.
+type RouteCandidate struct {
+	Model     string  `json:"model"`
+	Score     int     `json:"score"`
+	Priority  int     `json:"priority,omitempty"`
+	InputCost float64 `json:"input_cost"`
+
+	// MatchedStrengths are the model's strengths found in the task, each
+	// worth 10 points.
+	MatchedStrengths []string `json:"matched_strengths,omitempty"`
+}
+
+// String summarizes the decision in one line, e.g. for traces.
+func (d *RouteDecision) String() string {
+	s := fmt.Sprintf("%s (%s tier: %s)", d.Model, d.Tier, d.TierReason)
+	if d.Rationale != "" {
+		s += "; " + d.Rationale
+	}
+	return s
+}
+
+// RouteExplainer is implemented by model selectors that can explain their
+// choice. OpenRouterClient records the explanation of every completion.
+type RouteExplainer interface {
+	ExplainRoute(ctx context.Context, task string, budget int, depth int) *RouteDecision
+}
+
+var _ RouteExplainer = (*AdaptiveSelector)(nil)
+
+type routeRecorderKey struct{}
+
+// RouteRecorder collects the routing decisions of the completions made with
+// a context. Like UsageRecorder it travels in the context, and decisions
+// recorded here are also recorded by the recorder it was created under.
+// Methods are safe for concurrent use and no-ops on a nil recorder.
+type RouteRecorder struct {
+	parent *RouteRecorder
+
+	mu        sync.Mutex
+	decisions []*RouteDecision
+}
+
+// TrackRoutes returns a context whose routing decisions are recorded by a
+// new recorder nested under the one already in ctx, if any.
+func TrackRoutes(ctx context.Context) (context.Context, *RouteRecorder) {
+	rec := &RouteRecorder{parent: routeRecorderFrom(ctx)}
+	return context.WithValue(ctx, routeRecorderKey{}, rec), rec
+}
+
+func routeRecorderFrom(ctx context.Context) *RouteRecorder {
+	rec, _ := ctx.Value(routeRecorderKey{}).(*RouteRecorder)
+	return rec
+}
+
+// RecordRoute records the routing decision of one completion. Routing
+// clients call it before sending the completion.
+func RecordRoute(ctx context.Context, d *RouteDecision) {
+	for r := routeRecorderFrom(ctx); r != nil; r = r.parent {
+		r.mu.Lock()
+		r.decisions = append(r.decisions, d)
+		r.mu.Unlock()
+	}
+}
+
+// Decisions returns the recorded decisions, oldest first.
+func (r *RouteRecorder) Decisions() []*RouteDecision {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*RouteDecision(nil), r.decisions...)
+}
+
+// Last returns the most recent decision, or nil.
+func (r *RouteRecorder) Last() *RouteDecision {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.decisions) == 0 {
+		return nil
+	}
+	return r.decisions[len(r.decisions)-1]
+}
+
+// extractTask returns the task line of a meta-controller prompt, so routing
+// keywords come from the task rather than the instructions around it. Other
+// prompts are returned whole.
+func extractTask(prompt string) string {
+	for _, line := range strings.Split(prompt, "\n") {
+		line = strings.TrimPrefix(strings.TrimSpace(line), "- ")
+		if task, ok := strings.CutPrefix(line, "Task:"); ok {
+			return strings.TrimSpace(task)
+		}
+	}
+	return prompt
+}
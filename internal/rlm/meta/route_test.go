@@ -0,0 +1,184 @@
+package meta
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newRouteTestClient(t *testing.T) *OpenRouterClient {
+	t.Helper()
+	client, err := NewOpenRouterClient(OpenRouterConfig{APIKey: "test-key"})
+	require.NoError(t, err)
+	return client
+}
+
+// routedClient records the route an OpenRouterClient would take, without
+// calling a model.
+type routedClient struct {
+	router   *OpenRouterClient
+	response string
+}
+
+func (c *routedClient) Complete(ctx context.Context, prompt string, maxTokens int) (string, error) {
+	RecordRoute(ctx, c.router.ExplainRoute(ctx, prompt))
+	return c.response, nil
+}
+
+func TestOpenRouterClient_ExplainRoute(t *testing.T) {
+	client := newRouteTestClient(t)
+	ctrl := NewController(nil, DefaultConfig())
+
+	tests := []struct {
+		name       string
+		state      State
+		tier       ModelTier
+		keywords   []string
+		reason     string
+		candidates int
+	}{
+		{
+			name:       "math task",
+			state:      State{Task: "Calculate the math behind the interest totals", BudgetRemain: 10000, MaxDepth: 5},
+			tier:       TierReasoning,
+			keywords:   []string{"math", "calculate"},
+			reason:     "reasoning keywords",
+			candidates: 4,
+		},
+		{
+			name:     "complex task",
+			state:    State{Task: "Refactor the storage layer", BudgetRemain: 10000, MaxDepth: 5},
+			tier:     TierPowerful,
+			keywords: []string{"refactor"},
+			reason:   "complex-task keywords with budget 10000 > 5000",
+		},
+		{
+			name:   "low budget",
+			state:  State{Task: "Summarize the notes", BudgetRemain: 500, MaxDepth: 5},
+			tier:   TierFast,
+			reason: "low budget 500 < 1000",
+		},
+		{
+			name:   "deep recursion",
+			state:  State{Task: "Prove the lemma", BudgetRemain: 10000, RecursionDepth: 3, MaxDepth: 5},
+			tier:   TierFast,
+			reason: "recursion depth 3 >= 3",
+		},
+		{
+			name:   "moderate budget",
+			state:  State{Task: "Summarize the notes", BudgetRemain: 3000, MaxDepth: 5},
+			tier:   TierBalanced,
+			reason: "moderate budget 3000 < 5000",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Keywords come from the task, not the controller's instructions
+			route := client.ExplainRoute(context.Background(), ctrl.buildPrompt(tt.state))
+
+			assert.Equal(t, tt.state.BudgetRemain, route.Budget)
+			assert.Equal(t, tt.state.RecursionDepth, route.Depth)
+			assert.Equal(t, tt.tier, route.Tier)
+			assert.Equal(t, tt.reason, route.TierReason)
+			assert.ElementsMatch(t, tt.keywords, route.MatchedKeywords)
+			assert.False(t, route.Fallback)
+
+			require.NotEmpty(t, route.Candidates)
+			if tt.candidates > 0 {
+				assert.Len(t, route.Candidates, tt.candidates)
+			}
+			assert.Contains(t, candidateModels(route), route.Model)
+			assert.NotEmpty(t, route.Rationale)
+
+			// The decision agrees with model selection
+			spec := client.selector.SelectModel(context.Background(), tt.state.Task, route.Budget, route.Depth)
+			assert.Equal(t, spec.ID, route.Model)
+		})
+	}
+}
+
+func TestAdaptiveSelector_ExplainRoute_Ranking(t *testing.T) {
+	models := []ModelSpec{
+		{ID: "test/plain", Tier: TierReasoning, InputCost: 0.1},
+		{ID: "test/math-pricey", Tier: TierReasoning, InputCost: 2.0, Strengths: []string{"math"}},
+		{ID: "test/math-cheap", Tier: TierReasoning, InputCost: 1.0, Strengths: []string{"math"}},
+		{ID: "test/fast", Tier: TierFast, InputCost: 0.05},
+	}
+	selector := &AdaptiveSelector{models: models}
+
+	route := selector.ExplainRoute(context.Background(), "check the math", 10000, 0)
+	assert.Equal(t, "test/math-cheap", route.Model)
+	assert.Equal(t, "highest score 10 (strengths: math), cheapest of 2 tied", route.Rationale)
+	assert.Equal(t, []RouteCandidate{
+		{Model: "test/plain", InputCost: 0.1},
+		{Model: "test/math-pricey", Score: 10, InputCost: 2.0, MatchedStrengths: []string{"math"}},
+		{Model: "test/math-cheap", Score: 10, InputCost: 1.0, MatchedStrengths: []string{"math"}},
+	}, route.Candidates)
+
+	// A minimum tier above the rules' choice is noted
+	route = selector.ExplainRoute(WithMinTier(context.Background(), TierPowerful), "hello", 500, 0)
+	assert.True(t, route.MinTierApplied)
+	assert.Equal(t, TierPowerful, route.Tier)
+	assert.Equal(t, "low budget 500 < 1000, raised from fast to the minimum tier", route.TierReason)
+	assert.Equal(t, "test/fast", route.Model, "no powerful models, so the fast tier is used")
+	assert.Empty(t, route.Candidates)
+}
+
+func TestController_ExplainLastDecision_Route(t *testing.T) {
+	client := &routedClient{
+		router:   newRouteTestClient(t),
+		response: `{"action": "DIRECT", "reasoning": "Small task"}`,
+	}
+	ctrl := NewController(client, DefaultConfig())
+
+	_, err := ctrl.Decide(context.Background(), State{
+		Task:         "Summarize the notes",
+		BudgetRemain: 800,
+	})
+	require.NoError(t, err)
+
+	e, err := ctrl.ExplainLastDecision()
+	require.NoError(t, err)
+	require.NotNil(t, e.Route)
+	assert.Equal(t, TierFast, e.Route.Tier)
+	assert.Equal(t, 800, e.Route.Budget)
+	assertFactor(t, e.Factors, "Routed to "+e.Route.Model+" (fast tier: low budget 800 < 1000)")
+
+	// Decisions made without a model call have no route
+	_, err = ctrl.Decide(context.Background(), State{Task: "Summarize the notes"})
+	require.NoError(t, err)
+	e, err = ctrl.ExplainLastDecision()
+	require.NoError(t, err)
+	assert.Nil(t, e.Route)
+}
+
+func TestRouteRecorder_Nested(t *testing.T) {
+	ctx, outer := TrackRoutes(context.Background())
+	innerCtx, inner := TrackRoutes(ctx)
+
+	first := &RouteDecision{Model: "a"}
+	second := &RouteDecision{Model: "b"}
+	RecordRoute(innerCtx, first)
+	RecordRoute(ctx, second)
+
+	assert.Equal(t, []*RouteDecision{first}, inner.Decisions())
+	assert.Equal(t, []*RouteDecision{first, second}, outer.Decisions())
+	assert.Same(t, second, outer.Last())
+
+	// Untracked contexts and nil recorders are no-ops
+	RecordRoute(context.Background(), first)
+	var none *RouteRecorder
+	assert.Nil(t, none.Last())
+	assert.Nil(t, none.Decisions())
+}
+
+func candidateModels(route *RouteDecision) []string {
+	ids := make([]string, len(route.Candidates))
+	for i, c := range route.Candidates {
+		ids[i] = c.Model
+	}
+	return ids
+}
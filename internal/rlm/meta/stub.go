@@ -0,0 +1,55 @@
+package meta
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// DefaultStubResponse is what a StubClient answers when configured with no
+// response of its own.
+const DefaultStubResponse = "[stub] No model is configured, so this is a placeholder response. Set OPENROUTER_API_KEY for real completions."
+
+// StubClient is an offline LLMClient that answers every prompt without
+// calling a model. It lets development and tests that don't need real
+// answers run the full service without an API key. It is safe for
+// concurrent use.
+type StubClient struct {
+	respond func(ctx context.Context, prompt string) (string, error)
+	calls   atomic.Int64
+}
+
+// StubConfig configures a StubClient.
+type StubConfig struct {
+	// Response is returned for every prompt (default DefaultStubResponse).
+	Response string
+
+	// Respond, if set, computes the response to each prompt instead.
+	Respond func(ctx context.Context, prompt string) (string, error)
+}
+
+// NewStubClient creates an offline stub client.
+func NewStubClient(cfg StubConfig) *StubClient {
+	respond := cfg.Respond
+	if respond == nil {
+		response := cfg.Response
+		if response == "" {
+			response = DefaultStubResponse
+		}
+		respond = func(context.Context, string) (string, error) { return response, nil }
+	}
+	return &StubClient{respond: respond}
+}
+
+// Complete implements LLMClient.
+func (c *StubClient) Complete(ctx context.Context, prompt string, maxTokens int) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	c.calls.Add(1)
+	return c.respond(ctx, prompt)
+}
+
+// Calls returns how many prompts the client has answered.
+func (c *StubClient) Calls() int {
+	return int(c.calls.Load())
+}
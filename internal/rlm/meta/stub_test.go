@@ -0,0 +1,88 @@
+package meta
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStubClient_Responses(t *testing.T) {
+	ctx := context.Background()
+
+	client := NewStubClient(StubConfig{})
+	resp, err := client.Complete(ctx, "anything", 100)
+	require.NoError(t, err)
+	assert.Equal(t, DefaultStubResponse, resp)
+
+	fixed := NewStubClient(StubConfig{Response: "42"})
+	resp, err = fixed.Complete(ctx, "anything", 100)
+	require.NoError(t, err)
+	assert.Equal(t, "42", resp)
+
+	echo := NewStubClient(StubConfig{Respond: func(_ context.Context, prompt string) (string, error) {
+		return strings.ToUpper(prompt), nil
+	}})
+	resp, err = echo.Complete(ctx, "hello", 100)
+	require.NoError(t, err)
+	assert.Equal(t, "HELLO", resp)
+	assert.Equal(t, 1, echo.Calls())
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = echo.Complete(cancelled, "hello", 100)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 1, echo.Calls())
+}
+
+func TestNewOpenRouterClientOrFallback(t *testing.T) {
+	t.Setenv("OPENROUTER_API_KEY", "")
+
+	t.Run("fails by default", func(t *testing.T) {
+		_, err := NewOpenRouterClientOrFallback(OpenRouterConfig{})
+		assert.ErrorIs(t, err, ErrOpenRouterKeyMissing)
+		assert.Contains(t, err.Error(), "set OPENROUTER_API_KEY")
+	})
+
+	t.Run("stub", func(t *testing.T) {
+		client, err := NewOpenRouterClientOrFallback(OpenRouterConfig{
+			KeylessFallback: KeylessStub,
+			Stub:            StubConfig{Response: "offline"},
+		})
+		require.NoError(t, err)
+		require.IsType(t, &StubClient{}, client)
+		resp, err := client.Complete(context.Background(), "hi", 10)
+		require.NoError(t, err)
+		assert.Equal(t, "offline", resp)
+	})
+
+	t.Run("openai-compatible", func(t *testing.T) {
+		client, err := NewOpenRouterClientOrFallback(OpenRouterConfig{
+			KeylessFallback:  KeylessOpenAICompatible,
+			OpenAICompatible: OpenAICompatibleConfig{BaseURL: "http://localhost:8000/v1", Model: "local"},
+		})
+		require.NoError(t, err)
+		compat, ok := client.(*OpenAICompatibleClient)
+		require.True(t, ok)
+		assert.Equal(t, "local", compat.Model())
+
+		_, err = NewOpenRouterClientOrFallback(OpenRouterConfig{KeylessFallback: KeylessOpenAICompatible})
+		assert.Error(t, err)
+	})
+
+	t.Run("unknown fallback", func(t *testing.T) {
+		_, err := NewOpenRouterClientOrFallback(OpenRouterConfig{KeylessFallback: "bogus"})
+		assert.ErrorContains(t, err, "unknown keyless fallback")
+	})
+
+	t.Run("key present ignores fallback", func(t *testing.T) {
+		client, err := NewOpenRouterClientOrFallback(OpenRouterConfig{
+			APIKey:          "test-key",
+			KeylessFallback: KeylessStub,
+		})
+		require.NoError(t, err)
+		assert.IsType(t, &OpenRouterClient{}, client)
+	})
+}
@@ -0,0 +1,68 @@
+package meta
+
+import "context"
+
+// TierLimits caps the completions in flight to each model tier, so a burst
+// of calls to an expensive tier cannot exhaust its provider quota while
+// other tiers sit idle. A tier without a positive limit is unlimited.
+type TierLimits map[ModelTier]int
+
+// DefaultTierLimits returns caps that favor the cheap tiers. Caps are
+// opt-in: nothing applies them unless configured.
+func DefaultTierLimits() TierLimits {
+	return TierLimits{
+		TierFast:      8,
+		TierBalanced:  4,
+		TierPowerful:  2,
+		TierReasoning: 2,
+	}
+}
+
+// TierLimiter holds an independent semaphore per model tier. Callers wait
+// only for a slot of the tier they call, never for other tiers. It is safe
+// for concurrent use, and a nil limiter never blocks.
+type TierLimiter struct {
+	slots map[ModelTier]chan struct{}
+}
+
+// NewTierLimiter creates a limiter enforcing limits.
+func NewTierLimiter(limits TierLimits) *TierLimiter {
+	l := &TierLimiter{slots: make(map[ModelTier]chan struct{})}
+	for tier, n := range limits {
+		if n > 0 {
+			l.slots[tier] = make(chan struct{}, n)
+		}
+	}
+	return l
+}
+
+// Acquire waits for a slot of tier, returning the function that frees it,
+// or ctx's error if ctx ends first.
+func (l *TierLimiter) Acquire(ctx context.Context, tier ModelTier) (release func(), err error) {
+	if l == nil || l.slots[tier] == nil {
+		return func() {}, nil
+	}
+	slots := l.slots[tier]
+	select {
+	case slots <- struct{}{}:
+		return func() { <-slots }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Limit returns the cap of tier, or 0 if it is unlimited.
+func (l *TierLimiter) Limit(tier ModelTier) int {
+	if l == nil {
+		return 0
+	}
+	return cap(l.slots[tier])
+}
+
+// InFlight returns how many slots of tier are held.
+func (l *TierLimiter) InFlight(tier ModelTier) int {
+	if l == nil {
+		return 0
+	}
+	return len(l.slots[tier])
+}
@@ -0,0 +1,63 @@
+package meta
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTierLimiter_CapsEachTierIndependently(t *testing.T) {
+	l := NewTierLimiter(TierLimits{TierPowerful: 2, TierFast: 1})
+	ctx := context.Background()
+
+	var releases []func()
+	for range 2 {
+		release, err := l.Acquire(ctx, TierPowerful)
+		require.NoError(t, err)
+		releases = append(releases, release)
+	}
+	assert.Equal(t, 2, l.InFlight(TierPowerful))
+
+	// Powerful is full, but fast has its own slot
+	release, err := l.Acquire(ctx, TierFast)
+	require.NoError(t, err)
+	release()
+
+	waitCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	_, err = l.Acquire(waitCtx, TierPowerful)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	releases[0]()
+	release, err = l.Acquire(ctx, TierPowerful)
+	require.NoError(t, err)
+	release()
+	releases[1]()
+	assert.Zero(t, l.InFlight(TierPowerful))
+}
+
+func TestTierLimiter_Unlimited(t *testing.T) {
+	l := NewTierLimiter(TierLimits{TierFast: 0})
+	assert.Zero(t, l.Limit(TierFast))
+	assert.Zero(t, l.Limit(TierReasoning))
+	for range 100 {
+		_, err := l.Acquire(context.Background(), TierFast)
+		require.NoError(t, err)
+	}
+
+	var none *TierLimiter
+	release, err := none.Acquire(context.Background(), TierPowerful)
+	require.NoError(t, err)
+	release()
+	assert.Zero(t, none.Limit(TierPowerful))
+	assert.Zero(t, none.InFlight(TierPowerful))
+}
+
+func TestDefaultTierLimits(t *testing.T) {
+	l := NewTierLimiter(DefaultTierLimits())
+	assert.Equal(t, 8, l.Limit(TierFast))
+	assert.Equal(t, 2, l.Limit(TierPowerful))
+}
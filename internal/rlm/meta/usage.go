@@ -0,0 +1,136 @@
+package meta
+
+import (
+	"context"
+	"sync"
+)
+
+// Usage is the token usage of one or more completions.
+type Usage struct {
+	PromptTokens     int
+	CompletionTokens int
+}
+
+// Total returns prompt plus completion tokens.
+func (u Usage) Total() int {
+	return u.PromptTokens + u.CompletionTokens
+}
+
+// Add returns the sum of u and other.
+func (u Usage) Add(other Usage) Usage {
+	return Usage{
+		PromptTokens:     u.PromptTokens + other.PromptTokens,
+		CompletionTokens: u.CompletionTokens + other.CompletionTokens,
+	}
+}
+
+// IsZero reports whether no tokens are counted.
+func (u Usage) IsZero() bool {
+	return u.PromptTokens == 0 && u.CompletionTokens == 0
+}
+
+type usageRecorderKey struct{}
+
+// UsageRecorder accumulates token usage for the calls made with a context.
+// Clients report what the provider returned with RecordUsage; callers that
+// fall back to estimation record that separately, so totals say how much is
+// actual. It travels in the context so wrapping clients (caches, rate
+// limiters) don't hide it. Usage recorded here is also recorded by the
+// recorder it was created under. Methods are safe for concurrent use and
+// no-ops on a nil recorder.
+type UsageRecorder struct {
+	parent *UsageRecorder
+
+	mu        sync.Mutex
+	reported  Usage
+	estimated Usage
+	calls     int
+}
+
+// TrackUsage returns a context whose usage is recorded by a new recorder
+// nested under the one already in ctx, if any.
+func TrackUsage(ctx context.Context) (context.Context, *UsageRecorder) {
+	rec := &UsageRecorder{parent: UsageRecorderFrom(ctx)}
+	return context.WithValue(ctx, usageRecorderKey{}, rec), rec
+}
+
+// UsageRecorderFrom returns the recorder carried by ctx, or nil.
+func UsageRecorderFrom(ctx context.Context) *UsageRecorder {
+	rec, _ := ctx.Value(usageRecorderKey{}).(*UsageRecorder)
+	return rec
+}
+
+// RecordUsage records provider-reported usage for one call. Clients call it
+// after each successful completion; zero usage means the provider omitted it
+// and is ignored.
+func RecordUsage(ctx context.Context, u Usage) {
+	if u.IsZero() {
+		return
+	}
+	UsageRecorderFrom(ctx).record(u, false)
+}
+
+// Estimate records estimated usage for a call the provider reported nothing for.
+func (r *UsageRecorder) Estimate(u Usage) {
+	r.record(u, true)
+}
+
+func (r *UsageRecorder) record(u Usage, estimated bool) {
+	for ; r != nil; r = r.parent {
+		r.mu.Lock()
+		if estimated {
+			r.estimated = r.estimated.Add(u)
+		} else {
+			r.reported = r.reported.Add(u)
+			r.calls++
+		}
+		r.mu.Unlock()
+	}
+}
+
+// Reported returns provider-reported usage and how many calls reported it.
+func (r *UsageRecorder) Reported() (Usage, int) {
+	if r == nil {
+		return Usage{}, 0
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.reported, r.calls
+}
+
+// Estimated returns usage recorded as estimates.
+func (r *UsageRecorder) Estimated() Usage {
+	if r == nil {
+		return Usage{}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.estimated
+}
+
+// Total returns reported plus estimated usage.
+func (r *UsageRecorder) Total() Usage {
+	if r == nil {
+		return Usage{}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.reported.Add(r.estimated)
+}
+
+// CompleteWithUsage calls client and returns the usage the provider reported
+// for the call. If it reported none, the usage is estimated with estimate
+// and recorded as such.
+func CompleteWithUsage(ctx context.Context, client LLMClient, prompt string, maxTokens int, estimate func(string) int) (string, Usage, error) {
+	callCtx, rec := TrackUsage(ctx)
+	response, err := client.Complete(callCtx, prompt, maxTokens)
+	if err != nil {
+		return "", Usage{}, err
+	}
+	if usage, calls := rec.Reported(); calls > 0 {
+		return response, usage, nil
+	}
+	usage := Usage{PromptTokens: estimate(prompt), CompletionTokens: estimate(response)}
+	UsageRecorderFrom(ctx).Estimate(usage)
+	return response, usage, nil
+}
@@ -0,0 +1,84 @@
+package meta
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// usageReportingClient echoes the prompt and reports usage, if set.
+type usageReportingClient struct {
+	usage Usage
+}
+
+func (c *usageReportingClient) Complete(ctx context.Context, prompt string, maxTokens int) (string, error) {
+	RecordUsage(ctx, c.usage)
+	return "echo: " + prompt, nil
+}
+
+// passthroughClient stands in for caching or rate-limiting wrappers.
+type passthroughClient struct {
+	inner LLMClient
+}
+
+func (c *passthroughClient) Complete(ctx context.Context, prompt string, maxTokens int) (string, error) {
+	return c.inner.Complete(ctx, prompt, maxTokens)
+}
+
+func charCount(s string) int { return len(s) }
+
+func TestCompleteWithUsage_Reported(t *testing.T) {
+	ctx, outer := TrackUsage(context.Background())
+	client := &passthroughClient{inner: &usageReportingClient{usage: Usage{PromptTokens: 40, CompletionTokens: 7}}}
+
+	response, usage, err := CompleteWithUsage(ctx, client, "hi", 100, charCount)
+	require.NoError(t, err)
+	assert.Equal(t, "echo: hi", response)
+	assert.Equal(t, Usage{PromptTokens: 40, CompletionTokens: 7}, usage)
+
+	reported, calls := outer.Reported()
+	assert.Equal(t, usage, reported)
+	assert.Equal(t, 1, calls)
+	assert.True(t, outer.Estimated().IsZero())
+}
+
+func TestCompleteWithUsage_EstimatesWhenOmitted(t *testing.T) {
+	ctx, outer := TrackUsage(context.Background())
+	client := &usageReportingClient{}
+
+	_, usage, err := CompleteWithUsage(ctx, client, "hi", 100, charCount)
+	require.NoError(t, err)
+	assert.Equal(t, Usage{PromptTokens: 2, CompletionTokens: 8}, usage)
+
+	_, calls := outer.Reported()
+	assert.Zero(t, calls)
+	assert.Equal(t, usage, outer.Estimated())
+	assert.Equal(t, usage, outer.Total())
+}
+
+func TestUsageRecorder_Nesting(t *testing.T) {
+	ctx, outer := TrackUsage(context.Background())
+	innerCtx, inner := TrackUsage(ctx)
+
+	RecordUsage(innerCtx, Usage{PromptTokens: 10, CompletionTokens: 1})
+	RecordUsage(ctx, Usage{PromptTokens: 5, CompletionTokens: 5})
+	RecordUsage(ctx, Usage{}) // omitted usage is ignored
+	inner.Estimate(Usage{PromptTokens: 3})
+
+	reported, calls := inner.Reported()
+	assert.Equal(t, Usage{PromptTokens: 10, CompletionTokens: 1}, reported)
+	assert.Equal(t, 1, calls)
+
+	reported, calls = outer.Reported()
+	assert.Equal(t, Usage{PromptTokens: 15, CompletionTokens: 6}, reported)
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, Usage{PromptTokens: 18, CompletionTokens: 6}, outer.Total())
+
+	// Without a recorder everything is a no-op
+	RecordUsage(context.Background(), Usage{PromptTokens: 1})
+	var none *UsageRecorder
+	none.Estimate(Usage{PromptTokens: 1})
+	assert.True(t, none.Total().IsZero())
+}
@@ -24,6 +24,9 @@ type ModeSelectionInfo struct {
 	// ContextInfo contains information about the context that influenced selection.
 	ContextInfo *ContextSelectionInfo `json:"context_info,omitempty"`
 
+	// Evidence is set when graded outcomes overrode the rule-based mode.
+	Evidence *ModeEvidence `json:"evidence,omitempty"`
+
 	// Timestamp when the decision was made.
 	Timestamp time.Time `json:"timestamp"`
 }
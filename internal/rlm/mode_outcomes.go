@@ -0,0 +1,224 @@
+package rlm
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// ContextBucket groups context sizes for mode-selection statistics.
+type ContextBucket int
+
+// contextBucketBounds are the exclusive upper token bounds of each bucket
+// but the last.
+var contextBucketBounds = []int{2000, 8000, 32000, 128000}
+
+// BucketForTokens returns the bucket of a context of the given size.
+func BucketForTokens(tokens int) ContextBucket {
+	return ContextBucket(sort.SearchInts(contextBucketBounds, tokens+1))
+}
+
+// String names the bucket's range, e.g. "8K-32K".
+func (b ContextBucket) String() string {
+	k := func(tokens int) string { return fmt.Sprintf("%dK", tokens/1000) }
+	switch {
+	case b <= 0:
+		return "<" + k(contextBucketBounds[0])
+	case int(b) >= len(contextBucketBounds):
+		return k(contextBucketBounds[len(contextBucketBounds)-1]) + "+"
+	default:
+		return k(contextBucketBounds[b-1]) + "-" + k(contextBucketBounds[b])
+	}
+}
+
+// MarshalText encodes the bucket as its name.
+func (b ContextBucket) MarshalText() ([]byte, error) {
+	return []byte(b.String()), nil
+}
+
+// ModeOutcome is the graded result of one execution, for learning which
+// mode works for a kind of task.
+type ModeOutcome struct {
+	TaskType      TaskType
+	ContextTokens int
+	Mode          ExecutionMode
+	Correct       bool
+}
+
+// ModeAccuracy counts graded executions in one mode.
+type ModeAccuracy struct {
+	Trials  int `json:"trials"`
+	Correct int `json:"correct"`
+}
+
+// Accuracy returns the share of trials that were correct, 0 with none.
+func (a ModeAccuracy) Accuracy() float64 {
+	if a.Trials == 0 {
+		return 0
+	}
+	return float64(a.Correct) / float64(a.Trials)
+}
+
+// ModeOutcomeConfig sets how much evidence overrides the static rules.
+type ModeOutcomeConfig struct {
+	// MinTrials is how many graded executions each mode needs in a bucket
+	// before the two are compared.
+	// Default: 5
+	MinTrials int
+
+	// MinMargin is how far apart the modes' accuracies must be for the
+	// better one to be preferred.
+	// Default: 0.1
+	MinMargin float64
+}
+
+// DefaultModeOutcomeConfig returns the default configuration.
+func DefaultModeOutcomeConfig() ModeOutcomeConfig {
+	return ModeOutcomeConfig{
+		MinTrials: 5,
+		MinMargin: 0.1,
+	}
+}
+
+// ModeEvidence is a comparison of the modes for one task type and bucket.
+type ModeEvidence struct {
+	TaskType TaskType      `json:"task_type"`
+	Bucket   ContextBucket `json:"bucket"`
+	RLM      ModeAccuracy  `json:"rlm"`
+	Direct   ModeAccuracy  `json:"direct"`
+
+	// Preferred is the more accurate mode, empty when the evidence is too
+	// sparse or too close to call.
+	Preferred ExecutionMode `json:"preferred,omitempty"`
+}
+
+// String summarizes the evidence, e.g. for mode reasons.
+func (e ModeEvidence) String() string {
+	return fmt.Sprintf("%s tasks at %s tokens: rlm %d/%d correct, direct %d/%d",
+		e.TaskType, e.Bucket, e.RLM.Correct, e.RLM.Trials, e.Direct.Correct, e.Direct.Trials)
+}
+
+type modeOutcomeKey struct {
+	taskType TaskType
+	bucket   ContextBucket
+	mode     ExecutionMode
+}
+
+// ModeOutcomeTracker accumulates graded outcomes by task type, context
+// bucket and mode, so mode selection can follow what has worked. It is safe
+// for concurrent use.
+type ModeOutcomeTracker struct {
+	config ModeOutcomeConfig
+
+	mu    sync.Mutex
+	stats map[modeOutcomeKey]ModeAccuracy
+}
+
+// NewModeOutcomeTracker creates an empty tracker.
+func NewModeOutcomeTracker(config ModeOutcomeConfig) *ModeOutcomeTracker {
+	if config.MinTrials <= 0 {
+		config.MinTrials = 5
+	}
+	if config.MinMargin <= 0 {
+		config.MinMargin = 0.1
+	}
+	return &ModeOutcomeTracker{
+		config: config,
+		stats:  make(map[modeOutcomeKey]ModeAccuracy),
+	}
+}
+
+// Record adds one graded outcome.
+func (t *ModeOutcomeTracker) Record(o ModeOutcome) {
+	if o.TaskType == "" {
+		o.TaskType = TaskTypeUnknown
+	}
+	key := modeOutcomeKey{taskType: o.TaskType, bucket: BucketForTokens(o.ContextTokens), mode: o.Mode}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	acc := t.stats[key]
+	acc.Trials++
+	if o.Correct {
+		acc.Correct++
+	}
+	t.stats[key] = acc
+}
+
+// Evidence compares the modes for taskType at a context of contextTokens.
+func (t *ModeOutcomeTracker) Evidence(taskType TaskType, contextTokens int) ModeEvidence {
+	if taskType == "" {
+		taskType = TaskTypeUnknown
+	}
+	bucket := BucketForTokens(contextTokens)
+
+	t.mu.Lock()
+	e := ModeEvidence{
+		TaskType: taskType,
+		Bucket:   bucket,
+		RLM:      t.stats[modeOutcomeKey{taskType, bucket, ModeRLM}],
+		Direct:   t.stats[modeOutcomeKey{taskType, bucket, ModeDirecte}],
+	}
+	t.mu.Unlock()
+
+	if e.RLM.Trials < t.config.MinTrials || e.Direct.Trials < t.config.MinTrials {
+		return e
+	}
+	switch diff := e.RLM.Accuracy() - e.Direct.Accuracy(); {
+	case diff >= t.config.MinMargin:
+		e.Preferred = ModeRLM
+	case -diff >= t.config.MinMargin:
+		e.Preferred = ModeDirecte
+	}
+	return e
+}
+
+// RecordModeOutcome grades an execution of prepared, attributing it to the
+// task type and context size its mode was chosen for.
+func (w *Wrapper) RecordModeOutcome(prepared *PreparedPrompt, correct bool) {
+	if w.modeOutcomes == nil || prepared == nil {
+		return
+	}
+	taskType := TaskTypeUnknown
+	if prepared.Classification != nil {
+		taskType = prepared.Classification.Type
+	}
+	w.modeOutcomes.Record(ModeOutcome{
+		TaskType:      taskType,
+		ContextTokens: prepared.TotalTokens,
+		Mode:          prepared.Mode,
+		Correct:       correct,
+	})
+}
+
+// SetModeOutcomes replaces the tracker mode selection consults; nil turns
+// evidence-based selection off.
+func (w *Wrapper) SetModeOutcomes(tracker *ModeOutcomeTracker) {
+	w.modeOutcomes = tracker
+}
+
+// ModeOutcomes returns the tracker mode selection consults, or nil.
+func (w *Wrapper) ModeOutcomes() *ModeOutcomeTracker {
+	return w.modeOutcomes
+}
+
+// applyModeEvidence overrides a rule-based mode when graded outcomes for
+// the task type and context size clearly favour the other mode. Sparse or
+// close evidence leaves the rules' choice in place.
+func (w *Wrapper) applyModeEvidence(result *modeSelectionResult, contextCount, totalTokens int) {
+	if w.modeOutcomes == nil || contextCount == 0 || w.replMgr == nil {
+		return
+	}
+	taskType := TaskTypeUnknown
+	if result.classification != nil {
+		taskType = result.classification.Type
+	}
+	evidence := w.modeOutcomes.Evidence(taskType, totalTokens)
+	if evidence.Preferred == "" || evidence.Preferred == result.mode {
+		return
+	}
+	result.reason = fmt.Sprintf("outcomes favour %s over the rules' %s (%s); rules: %s",
+		evidence.Preferred, result.mode, evidence, result.reason)
+	result.mode = evidence.Preferred
+	result.evidence = &evidence
+}
@@ -0,0 +1,181 @@
+package rlm
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/rand/recurse/internal/rlm/repl"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBucketForTokens(t *testing.T) {
+	tests := []struct {
+		tokens int
+		want   string
+	}{
+		{0, "<2K"},
+		{1999, "<2K"},
+		{2000, "2K-8K"},
+		{7999, "2K-8K"},
+		{8000, "8K-32K"},
+		{20000, "8K-32K"},
+		{100000, "32K-128K"},
+		{128000, "128K+"},
+		{1000000, "128K+"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, BucketForTokens(tt.tokens).String(), "tokens=%d", tt.tokens)
+	}
+
+	text, err := json.Marshal(BucketForTokens(5000))
+	require.NoError(t, err)
+	assert.Equal(t, `"2K-8K"`, string(text))
+}
+
+// recordOutcomes records trials outcomes of mode, the first correct of them
+// correct.
+func recordOutcomes(tracker *ModeOutcomeTracker, taskType TaskType, tokens int, mode ExecutionMode, trials, correct int) {
+	for i := 0; i < trials; i++ {
+		tracker.Record(ModeOutcome{
+			TaskType:      taskType,
+			ContextTokens: tokens,
+			Mode:          mode,
+			Correct:       i < correct,
+		})
+	}
+}
+
+func TestModeOutcomeTracker_Evidence(t *testing.T) {
+	t.Run("sparse evidence prefers nothing", func(t *testing.T) {
+		tracker := NewModeOutcomeTracker(DefaultModeOutcomeConfig())
+		recordOutcomes(tracker, TaskTypeComputational, 5000, ModeRLM, 10, 10)
+		recordOutcomes(tracker, TaskTypeComputational, 5000, ModeDirecte, 4, 0)
+
+		e := tracker.Evidence(TaskTypeComputational, 5000)
+		assert.Equal(t, ModeAccuracy{Trials: 10, Correct: 10}, e.RLM)
+		assert.Equal(t, ModeAccuracy{Trials: 4, Correct: 0}, e.Direct)
+		assert.Empty(t, e.Preferred)
+	})
+
+	t.Run("close accuracies prefer nothing", func(t *testing.T) {
+		tracker := NewModeOutcomeTracker(DefaultModeOutcomeConfig())
+		recordOutcomes(tracker, TaskTypeRetrieval, 5000, ModeRLM, 20, 15)
+		recordOutcomes(tracker, TaskTypeRetrieval, 5000, ModeDirecte, 20, 14)
+
+		assert.Empty(t, tracker.Evidence(TaskTypeRetrieval, 5000).Preferred)
+	})
+
+	t.Run("clear margin prefers the better mode", func(t *testing.T) {
+		tracker := NewModeOutcomeTracker(DefaultModeOutcomeConfig())
+		recordOutcomes(tracker, TaskTypeRetrieval, 5000, ModeRLM, 10, 9)
+		recordOutcomes(tracker, TaskTypeRetrieval, 5000, ModeDirecte, 10, 5)
+
+		assert.Equal(t, ModeRLM, tracker.Evidence(TaskTypeRetrieval, 5000).Preferred)
+		assert.Equal(t, ModeRLM, tracker.Evidence(TaskTypeRetrieval, 3000).Preferred, "same bucket")
+		assert.Empty(t, tracker.Evidence(TaskTypeRetrieval, 20000).Preferred, "other bucket")
+		assert.Empty(t, tracker.Evidence(TaskTypeAnalytical, 5000).Preferred, "other task type")
+	})
+
+	t.Run("untyped outcomes count as unknown", func(t *testing.T) {
+		tracker := NewModeOutcomeTracker(DefaultModeOutcomeConfig())
+		recordOutcomes(tracker, "", 5000, ModeDirecte, 5, 5)
+		recordOutcomes(tracker, "", 5000, ModeRLM, 5, 0)
+
+		e := tracker.Evidence(TaskTypeUnknown, 5000)
+		assert.Equal(t, ModeDirecte, e.Preferred)
+		assert.Equal(t, "unknown tasks at 2K-8K tokens: rlm 0/5 correct, direct 5/5", e.String())
+	})
+}
+
+func TestWrapper_SelectMode_FollowsOutcomes(t *testing.T) {
+	ctx := context.Background()
+	replMgr, err := repl.NewManager(repl.Options{})
+	require.NoError(t, err)
+
+	w := NewWrapper(&Service{}, DefaultWrapperConfig())
+	w.SetREPLManager(replMgr)
+	contexts := []ContextSource{{Type: ContextTypeFile, Content: "data"}}
+	computational := &Classification{Type: TaskTypeComputational, Confidence: 0.9}
+	retrieval := &Classification{Type: TaskTypeRetrieval, Confidence: 0.9}
+
+	t.Run("rules apply without evidence", func(t *testing.T) {
+		result := w.selectModeDetailed(ctx, "q", 5000, contexts, computational)
+		assert.Equal(t, ModeRLM, result.mode)
+		assert.Nil(t, result.evidence)
+	})
+
+	tracker := NewModeOutcomeTracker(DefaultModeOutcomeConfig())
+	w.SetModeOutcomes(tracker)
+	recordOutcomes(tracker, TaskTypeComputational, 5000, ModeRLM, 10, 4)
+	recordOutcomes(tracker, TaskTypeComputational, 5000, ModeDirecte, 10, 9)
+	recordOutcomes(tracker, TaskTypeRetrieval, 20000, ModeRLM, 10, 9)
+	recordOutcomes(tracker, TaskTypeRetrieval, 20000, ModeDirecte, 10, 3)
+
+	t.Run("evidence overrides RLM", func(t *testing.T) {
+		result := w.selectModeDetailed(ctx, "q", 5000, contexts, computational)
+		assert.Equal(t, ModeDirecte, result.mode)
+		require.NotNil(t, result.evidence)
+		assert.Equal(t, ModeDirecte, result.evidence.Preferred)
+		assert.Contains(t, result.reason, "outcomes favour direct over the rules' rlm")
+		assert.Contains(t, result.reason, "rules: computational task")
+	})
+
+	t.Run("evidence overrides Direct", func(t *testing.T) {
+		result := w.selectModeDetailed(ctx, "q", 20000, contexts, retrieval)
+		assert.Equal(t, ModeRLM, result.mode)
+		require.NotNil(t, result.evidence)
+		assert.Contains(t, result.reason, "rules: retrieval task")
+	})
+
+	t.Run("rules apply where evidence is missing", func(t *testing.T) {
+		result := w.selectModeDetailed(ctx, "q", 50000, contexts, computational)
+		assert.Equal(t, ModeRLM, result.mode)
+		assert.Nil(t, result.evidence)
+	})
+
+	t.Run("evidence never needs a missing REPL", func(t *testing.T) {
+		noREPL := NewWrapper(&Service{}, DefaultWrapperConfig())
+		noREPL.SetModeOutcomes(tracker)
+		result := noREPL.selectModeDetailed(ctx, "q", 20000, contexts, retrieval)
+		assert.Equal(t, ModeDirecte, result.mode)
+		assert.Nil(t, result.evidence)
+	})
+}
+
+func TestWrapper_RecordModeOutcome(t *testing.T) {
+	ctx := context.Background()
+	replMgr, err := repl.NewManager(repl.Options{})
+	require.NoError(t, err)
+
+	w := NewWrapper(&Service{}, DefaultWrapperConfig())
+	w.SetREPLManager(replMgr)
+	require.NotNil(t, w.ModeOutcomes())
+
+	// Size-based selection picks RLM for an unclassified 5K-token context;
+	// graded outcomes showing Direct does better flip it.
+	contexts := []ContextSource{{Type: ContextTypeFile, Content: strings.Repeat("Large content. ", 1500)}}
+	prepared := &PreparedPrompt{Mode: ModeRLM, TotalTokens: 5000}
+	for i := 0; i < 5; i++ {
+		w.RecordModeOutcome(prepared, false)
+	}
+	prepared.Mode = ModeDirecte
+	for i := 0; i < 5; i++ {
+		w.RecordModeOutcome(prepared, true)
+	}
+
+	got, err := w.PrepareContextWithOptions(ctx, "test", contexts, PrepareOptions{SkipClassification: true})
+	require.NoError(t, err)
+	assert.Equal(t, ModeDirecte, got.Mode)
+	require.NotNil(t, got.ModeInfo)
+	require.NotNil(t, got.ModeInfo.Evidence)
+	assert.Equal(t, TaskTypeUnknown, got.ModeInfo.Evidence.TaskType)
+	assert.Equal(t, 5, got.ModeInfo.Evidence.Direct.Correct)
+
+	w.SetModeOutcomes(nil)
+	w.RecordModeOutcome(prepared, true)
+	assert.Nil(t, w.ModeOutcomes())
+}
@@ -0,0 +1,167 @@
+package rlm
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/rand/recurse/internal/rlm/meta"
+)
+
+// Verification metadata values stored under FinalMetadata["verification"].
+const (
+	VerificationAgreed      = "agreed"
+	VerificationDiscrepancy = "discrepancy"
+	VerificationFailed      = "failed"
+)
+
+// NumericVerification is the outcome of re-deriving a computational answer
+// with an independent Python approach.
+type NumericVerification struct {
+	// Original is the numeric answer from the RLM loop.
+	Original float64
+
+	// Independent is the answer from the verification code, if it ran.
+	Independent float64
+
+	// Agreed is true when both answers match.
+	Agreed bool
+
+	// Discrepancy is true when both approaches produced numbers that differ.
+	Discrepancy bool
+
+	// Code is the independent verification code that was executed.
+	Code string
+
+	// Error explains why verification could not complete.
+	Error string
+}
+
+// Status returns the metadata value summarizing the verification.
+func (v *NumericVerification) Status() string {
+	switch {
+	case v.Agreed:
+		return VerificationAgreed
+	case v.Discrepancy:
+		return VerificationDiscrepancy
+	default:
+		return VerificationFailed
+	}
+}
+
+// numberPattern matches integers and decimals, with optional sign and
+// thousands separators.
+var numberPattern = regexp.MustCompile(`[-+]?\d[\d,]*(?:\.\d+)?(?:[eE][-+]?\d+)?`)
+
+// parseNumericAnswer extracts the number from an answer that is a single
+// number, possibly with surrounding text. Answers with several numbers are
+// ambiguous and are not treated as numeric.
+func parseNumericAnswer(answer string) (float64, bool) {
+	matches := numberPattern.FindAllString(answer, -1)
+	if len(matches) != 1 {
+		return 0, false
+	}
+	v, err := strconv.ParseFloat(strings.ReplaceAll(matches[0], ",", ""), 64)
+	if err != nil {
+		return 0, false
+	}
+	return v, true
+}
+
+// numbersAgree compares two answers with a small relative tolerance so
+// floating-point noise from different algorithms is not a discrepancy.
+func numbersAgree(a, b float64) bool {
+	const eps = 1e-9
+	diff := math.Abs(a - b)
+	return diff <= eps || diff <= eps*math.Max(math.Abs(a), math.Abs(b))
+}
+
+// alternativeApproach suggests an algorithm unlike the one in code, so the
+// verification does not repeat the same bug.
+func alternativeApproach(code string) string {
+	switch {
+	case strings.Contains(code, "re.findall") || strings.Contains(code, "re.finditer"):
+		return "Count without regular expressions, for example by splitting the text and counting matching tokens."
+	case strings.Contains(code, ".split(") || strings.Contains(code, ".count("):
+		return "Count with a regular expression, for example len(re.findall(...))."
+	case strings.Contains(code, "sum("):
+		return "Accumulate the total with an explicit loop instead of sum()."
+	case strings.Contains(code, "len("):
+		return "Count with an explicit loop instead of len()."
+	default:
+		return "Use a different algorithm from the original code."
+	}
+}
+
+// buildVerificationPrompt asks for independent code that recomputes the
+// answer and prints only the number.
+func buildVerificationPrompt(task, originalCode, answer string) string {
+	var sb strings.Builder
+	sb.WriteString("Independently verify the numeric answer to this task.\n\n")
+	sb.WriteString("Task:\n")
+	sb.WriteString(task)
+	sb.WriteString("\n\n")
+	if originalCode != "" {
+		sb.WriteString("Original code:\n```python\n")
+		sb.WriteString(originalCode)
+		sb.WriteString("\n```\n\n")
+	}
+	sb.WriteString(fmt.Sprintf("Original answer: %s\n\n", answer))
+	sb.WriteString("Write Python code that recomputes the answer from the same REPL variables. ")
+	sb.WriteString(alternativeApproach(originalCode))
+	sb.WriteString(" Do not reuse the original answer or call FINAL(). Print only the resulting number.")
+	return sb.String()
+}
+
+// verifyNumericAnswer re-derives answer with a second, independently written
+// Python approach and compares the two. It returns nil if answer is not
+// numeric. The returned token count covers the verification LLM call.
+func (w *Wrapper) verifyNumericAnswer(ctx context.Context, task, originalCode, answer string, maxTokens int) (*NumericVerification, int) {
+	original, ok := parseNumericAnswer(answer)
+	if !ok {
+		return nil, 0
+	}
+	v := &NumericVerification{Original: original}
+
+	prompt := buildVerificationPrompt(task, originalCode, answer)
+	response, usage, err := meta.CompleteWithUsage(ctx, w.client, prompt, maxTokens, estimateTokens)
+	if err != nil {
+		v.Error = fmt.Sprintf("verification LLM call failed: %v", err)
+		return v, estimateTokens(prompt)
+	}
+	tokens := usage.Total()
+
+	v.Code = extractPythonCode(response)
+	if v.Code == "" {
+		v.Error = "verification response contained no code"
+		return v, tokens
+	}
+
+	execResult, err := w.replMgr.Execute(ctx, v.Code)
+	if err != nil {
+		v.Error = fmt.Sprintf("verification execution failed: %v", err)
+		return v, tokens
+	}
+	if execResult.Error != "" {
+		v.Error = fmt.Sprintf("verification code raised: %s", firstLine(execResult.Error))
+		return v, tokens
+	}
+
+	output := strings.TrimSpace(execResult.Output)
+	if output == "" {
+		output = strings.TrimSpace(execResult.ReturnVal)
+	}
+	independent, ok := parseNumericAnswer(output)
+	if !ok {
+		v.Error = fmt.Sprintf("verification output is not a single number: %q", truncate(output, 100))
+		return v, tokens
+	}
+
+	v.Independent = independent
+	v.Agreed = numbersAgree(original, independent)
+	v.Discrepancy = !v.Agreed
+	return v, tokens
+}
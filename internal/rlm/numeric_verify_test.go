@@ -0,0 +1,128 @@
+package rlm
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rand/recurse/internal/rlm/repl"
+)
+
+// countingTaskCode counts "cat" with a regex and reports it via FINAL.
+const countingTaskCode = "```python\nimport re\ntext = 'cat dog cat bird cat catalog'\n" +
+	"FINAL(str(len(re.findall(r'\\bcat\\b', text))))\n```"
+
+func runVerifiedCount(t *testing.T, verificationResponse string, classification *Classification) (*RLMExecutionResult, *wrapperMockLLMClient) {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	replMgr, err := repl.NewManager(repl.Options{})
+	require.NoError(t, err)
+	require.NoError(t, replMgr.Start(ctx))
+	t.Cleanup(func() { replMgr.Stop() })
+
+	client := &wrapperMockLLMClient{
+		responses: []string{countingTaskCode, verificationResponse},
+	}
+	w := &Wrapper{replMgr: replMgr, client: client}
+
+	prepared := &PreparedPrompt{
+		Mode:           ModeRLM,
+		SystemPrompt:   "You are an RLM assistant.",
+		FinalPrompt:    "How many times does the word 'cat' appear in text?",
+		Classification: classification,
+	}
+
+	result, err := w.ExecuteRLMWithConfig(ctx, prepared, RLMConfig{
+		MaxIterations:     3,
+		MaxTokensPerCall:  1024,
+		Timeout:           10 * time.Second,
+		VerifyComputation: true,
+	})
+	require.NoError(t, err)
+	require.Equal(t, "3", result.FinalOutput)
+	return result, client
+}
+
+func TestExecuteRLM_VerifyComputation_Agrees(t *testing.T) {
+	result, client := runVerifiedCount(t,
+		"```python\nprint(text.split().count('cat'))\n```",
+		&Classification{Type: TaskTypeComputational, Confidence: 0.9})
+
+	require.NotNil(t, result.Verification)
+	assert.True(t, result.Verification.Agreed)
+	assert.False(t, result.Verification.Discrepancy)
+	assert.Equal(t, 3.0, result.Verification.Independent)
+	assert.Equal(t, VerificationAgreed, result.FinalMetadata["verification"])
+
+	// The verifier saw the regex solution and was steered away from it.
+	require.Len(t, client.calls, 2)
+	assert.Contains(t, client.calls[1], "re.findall")
+	assert.Contains(t, client.calls[1], "without regular expressions")
+}
+
+func TestExecuteRLM_VerifyComputation_FlagsDiscrepancy(t *testing.T) {
+	// A rigged split-count that also matches "catalog".
+	result, _ := runVerifiedCount(t,
+		"```python\nprint(sum(1 for w in text.split() if w.startswith('cat')))\n```",
+		&Classification{Type: TaskTypeComputational, Confidence: 0.9})
+
+	require.NotNil(t, result.Verification)
+	assert.False(t, result.Verification.Agreed)
+	assert.True(t, result.Verification.Discrepancy)
+	assert.Equal(t, 3.0, result.Verification.Original)
+	assert.Equal(t, 4.0, result.Verification.Independent)
+	assert.Equal(t, VerificationDiscrepancy, result.FinalMetadata["verification"])
+}
+
+func TestExecuteRLM_VerifyComputation_SkipsOtherTaskTypes(t *testing.T) {
+	result, client := runVerifiedCount(t, "",
+		&Classification{Type: TaskTypeRetrieval, Confidence: 0.9})
+
+	assert.Nil(t, result.Verification)
+	assert.NotContains(t, result.FinalMetadata, "verification")
+	assert.Len(t, client.calls, 1)
+}
+
+func TestParseNumericAnswer(t *testing.T) {
+	tests := []struct {
+		answer string
+		want   float64
+		ok     bool
+	}{
+		{"42", 42, true},
+		{" 1,234 ", 1234, true},
+		{"The total is 3.5.", 3.5, true},
+		{"-7", -7, true},
+		{"2.5e3", 2500, true},
+		{"between 3 and 4", 0, false},
+		{"no numbers here", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.answer, func(t *testing.T) {
+			got, ok := parseNumericAnswer(tt.answer)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestNumbersAgree(t *testing.T) {
+	assert.True(t, numbersAgree(3, 3))
+	assert.True(t, numbersAgree(0.1+0.2, 0.3))
+	assert.False(t, numbersAgree(3, 4))
+	assert.False(t, numbersAgree(1000000, 1000001))
+}
+
+func TestAlternativeApproach(t *testing.T) {
+	assert.Contains(t, alternativeApproach("len(re.findall(r'x', s))"), "without regular expressions")
+	assert.Contains(t, alternativeApproach("s.split().count('x')"), "regular expression")
+	assert.Contains(t, alternativeApproach("sum(values)"), "explicit loop")
+	assert.Contains(t, alternativeApproach(""), "different algorithm")
+}
@@ -0,0 +1,85 @@
+package observability
+
+import (
+	"time"
+)
+
+// Per-execution metric names.
+const (
+	MetricExecutionsTotal     = "rlm_executions_total"
+	MetricExecutionDuration   = "rlm_execution_duration_seconds"
+	MetricExecutionTokens     = "rlm_execution_tokens"
+	MetricExecutionIterations = "rlm_execution_iterations"
+	MetricExecutionErrors     = "rlm_execution_errors_total"
+	MetricActionsTotal        = "rlm_actions_total"
+	MetricModelCallsTotal     = "rlm_model_calls_total"
+)
+
+// TokenBuckets are histogram buckets for tokens per execution.
+var TokenBuckets = []float64{
+	100, 500, 1000, 2500, 5000, 10000, 25000, 50000, 100000, 250000,
+}
+
+// IterationBuckets are histogram buckets for orchestration steps per execution.
+var IterationBuckets = []float64{1, 2, 3, 5, 8, 13, 21}
+
+// ExecutionSample describes one completed execution.
+type ExecutionSample struct {
+	// Mode is "rlm" when context was externalized to the REPL, else "direct".
+	Mode string
+
+	// Action is the meta-controller's top-level action.
+	Action string
+
+	// ModelTier is the tier of the model that answered.
+	ModelTier string
+
+	Duration   time.Duration
+	Tokens     int
+	Iterations int
+
+	// ErrorCategory is the recovery category of a failed execution, empty
+	// on success.
+	ErrorCategory string
+}
+
+// ExecutionMetrics records per-execution histograms and counters into a
+// Registry. Series are looked up by label set on each call, so an unused
+// collector costs nothing.
+type ExecutionMetrics struct {
+	registry *Registry
+}
+
+// NewExecutionMetrics creates an execution collector on registry, or on the
+// default registry if registry is nil.
+func NewExecutionMetrics(registry *Registry) *ExecutionMetrics {
+	if registry == nil {
+		registry = defaultRegistry
+	}
+	return &ExecutionMetrics{registry: registry}
+}
+
+// Registry returns the registry the collector writes to.
+func (m *ExecutionMetrics) Registry() *Registry {
+	return m.registry
+}
+
+// RecordExecution updates all execution metrics for one sample.
+func (m *ExecutionMetrics) RecordExecution(s ExecutionSample) {
+	labels := Labels{"mode": s.Mode, "action": s.Action, "tier": s.ModelTier}
+
+	m.registry.Counter(MetricExecutionsTotal, labels).Inc()
+	m.registry.Histogram(MetricExecutionDuration, labels, DefaultBuckets).Observe(s.Duration.Seconds())
+	m.registry.Histogram(MetricExecutionTokens, labels, TokenBuckets).Observe(float64(s.Tokens))
+	m.registry.Histogram(MetricExecutionIterations, labels, IterationBuckets).Observe(float64(s.Iterations))
+	m.registry.Counter(MetricActionsTotal, Labels{"action": s.Action}).Inc()
+
+	if s.ErrorCategory != "" {
+		m.registry.Counter(MetricExecutionErrors, Labels{"category": s.ErrorCategory}).Inc()
+	}
+}
+
+// RecordModelCall counts a call routed to a model.
+func (m *ExecutionMetrics) RecordModelCall(tier, model string) {
+	m.registry.Counter(MetricModelCallsTotal, Labels{"tier": tier, "model": model}).Inc()
+}
@@ -2,6 +2,7 @@
 package observability
 
 import (
+	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -49,12 +50,45 @@ const (
 	MetricMemorySearches    = "rlm_memory_searches_total"
 )
 
+// metricHelp describes the package's metrics for export.
+var metricHelp = map[string]string{
+	MetricCallsTotal:        "Total RLM controller calls.",
+	MetricCallDuration:      "Duration of RLM controller calls in seconds.",
+	MetricDecomposeDepth:    "Depth of task decompositions.",
+	MetricSubcallsTotal:     "Total RLM sub-calls.",
+	MetricTokensInput:       "Total input tokens sent to models.",
+	MetricTokensOutput:      "Total output tokens received from models.",
+	MetricAsyncExecutions:   "Total asynchronous executions.",
+	MetricAsyncParallel:     "Asynchronous operations running in parallel.",
+	MetricAsyncSpeculative:  "Total speculative asynchronous operations.",
+	MetricAsyncCancelled:    "Total cancelled asynchronous operations.",
+	MetricCacheHits:         "Total cache hits.",
+	MetricCacheMisses:       "Total cache misses.",
+	MetricCacheTokensSaved:  "Total tokens saved by cache hits.",
+	MetricCacheCostSaved:    "Total cost saved by cache hits.",
+	MetricBreakerState:      "Circuit breaker state: 0 closed, 1 open, 2 half-open.",
+	MetricBreakerTrips:      "Total circuit breaker trips.",
+	MetricBreakerRejections: "Total calls rejected by an open circuit breaker.",
+	MetricMemoryNodes:       "Nodes in the memory hypergraph.",
+	MetricMemoryEdges:       "Edges in the memory hypergraph.",
+	MetricMemorySearches:    "Total memory searches.",
+
+	MetricExecutionsTotal:     "Total RLM executions by mode, top-level action and model tier.",
+	MetricExecutionDuration:   "Duration of RLM executions in seconds.",
+	MetricExecutionTokens:     "Tokens used per RLM execution.",
+	MetricExecutionIterations: "Meta-controller decisions per RLM execution.",
+	MetricExecutionErrors:     "Total failed RLM executions by error category.",
+	MetricActionsTotal:        "Total top-level meta-controller actions.",
+	MetricModelCallsTotal:     "Total sub-calls routed to each model.",
+}
+
 // Labels for metrics.
 type Labels map[string]string
 
 // Counter is a monotonically increasing metric.
 type Counter struct {
 	value  int64
+	name   string
 	labels Labels
 }
 
@@ -76,6 +110,7 @@ func (c *Counter) Value() int64 {
 // Gauge is a metric that can go up and down.
 type Gauge struct {
 	value  int64
+	name   string
 	labels Labels
 }
 
@@ -110,6 +145,7 @@ type Histogram struct {
 	counts  []int64
 	sum     float64
 	count   int64
+	name    string
 	labels  Labels
 	mu      sync.Mutex
 }
@@ -217,6 +253,7 @@ type Registry struct {
 	counters   map[string]*Counter
 	gauges     map[string]*Gauge
 	histograms map[string]*Histogram
+	help       map[string]string
 	mu         sync.RWMutex
 }
 
@@ -226,9 +263,27 @@ func NewRegistry() *Registry {
 		counters:   make(map[string]*Counter),
 		gauges:     make(map[string]*Gauge),
 		histograms: make(map[string]*Histogram),
+		help:       make(map[string]string),
 	}
 }
 
+// Describe sets the help text exported for the metric called name. The
+// package's own metrics are described already.
+func (r *Registry) Describe(name, help string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.help[name] = help
+}
+
+// helpFor returns the help text of the metric called name, or "".
+// Callers hold r.mu.
+func (r *Registry) helpFor(name string) string {
+	if help, ok := r.help[name]; ok {
+		return help
+	}
+	return metricHelp[name]
+}
+
 // Counter returns or creates a counter with the given name and labels.
 func (r *Registry) Counter(name string, labels Labels) *Counter {
 	key := metricKey(name, labels)
@@ -247,7 +302,7 @@ func (r *Registry) Counter(name string, labels Labels) *Counter {
 		return c
 	}
 
-	c := &Counter{labels: labels}
+	c := &Counter{name: name, labels: labels}
 	r.counters[key] = c
 	return c
 }
@@ -270,7 +325,7 @@ func (r *Registry) Gauge(name string, labels Labels) *Gauge {
 		return g
 	}
 
-	g := &Gauge{labels: labels}
+	g := &Gauge{name: name, labels: labels}
 	r.gauges[key] = g
 	return g
 }
@@ -294,6 +349,7 @@ func (r *Registry) Histogram(name string, labels Labels, buckets []float64) *His
 	}
 
 	h := NewHistogram(buckets, labels)
+	h.name = name
 	r.histograms[key] = h
 	return h
 }
@@ -334,10 +390,16 @@ func metricKey(name string, labels Labels) string {
 		return name
 	}
 
-	// Simple key generation - could be more sophisticated
+	// Sort label names so the same label set always maps to one metric
+	names := make([]string, 0, len(labels))
+	for k := range labels {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
 	key := name
-	for k, v := range labels {
-		key += "," + k + "=" + v
+	for _, k := range names {
+		key += "," + k + "=" + labels[k]
 	}
 	return key
 }
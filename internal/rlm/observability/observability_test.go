@@ -5,6 +5,8 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"net/http"
+	"net/http/httptest"
 	"sync"
 	"testing"
 	"time"
@@ -170,6 +172,90 @@ func TestMetrics_Concurrent(t *testing.T) {
 	assert.Equal(t, int64(100), c.Value())
 }
 
+func TestRegistry_LabelOrderIndependent(t *testing.T) {
+	reg := NewRegistry()
+
+	c1 := reg.Counter("labeled", Labels{"a": "1", "b": "2", "c": "3"})
+	for i := 0; i < 20; i++ {
+		assert.Same(t, c1, reg.Counter("labeled", Labels{"c": "3", "b": "2", "a": "1"}))
+	}
+}
+
+func TestExecutionMetrics_RecordExecution(t *testing.T) {
+	reg := NewRegistry()
+	m := NewExecutionMetrics(reg)
+
+	m.RecordExecution(ExecutionSample{Mode: "rlm", Action: "DECOMPOSE", ModelTier: "main",
+		Duration: 2 * time.Second, Tokens: 3000, Iterations: 4})
+	m.RecordExecution(ExecutionSample{Mode: "rlm", Action: "DECOMPOSE", ModelTier: "main",
+		Duration: 500 * time.Millisecond, Tokens: 800, Iterations: 2})
+	m.RecordExecution(ExecutionSample{Mode: "direct", Action: "DIRECT", ModelTier: "main",
+		Duration: 100 * time.Millisecond, Tokens: 200, Iterations: 1, ErrorCategory: "timeout"})
+	m.RecordModelCall("fast", "model-a")
+
+	labels := Labels{"mode": "rlm", "action": "DECOMPOSE", "tier": "main"}
+	assert.Equal(t, int64(2), reg.Counter(MetricExecutionsTotal, labels).Value())
+	assert.Equal(t, int64(1), reg.Counter(MetricActionsTotal, Labels{"action": "DIRECT"}).Value())
+	assert.Equal(t, int64(1), reg.Counter(MetricExecutionErrors, Labels{"category": "timeout"}).Value())
+	assert.Equal(t, int64(1), reg.Counter(MetricModelCallsTotal, Labels{"tier": "fast", "model": "model-a"}).Value())
+
+	duration := reg.Histogram(MetricExecutionDuration, labels, DefaultBuckets).Snapshot()
+	assert.Equal(t, int64(2), duration.Count)
+	assert.InDelta(t, 2.5, duration.Sum, 1e-9)
+
+	tokens := reg.Histogram(MetricExecutionTokens, labels, TokenBuckets).Snapshot()
+	assert.InDelta(t, 3800, tokens.Sum, 1e-9)
+
+	iterations := reg.Histogram(MetricExecutionIterations, labels, IterationBuckets).Snapshot()
+	assert.InDelta(t, 3, iterations.Mean(), 1e-9)
+}
+
+func TestRegistry_WritePrometheus(t *testing.T) {
+	reg := NewRegistry()
+	reg.Counter("req_total", Labels{"path": `/a"b`, "code": "200"}).Add(3)
+	reg.Counter("req_total", Labels{"path": "/", "code": "500"}).Inc()
+	reg.Gauge("in_flight", nil).Set(2)
+	reg.Describe("req_total", "Requests served,\nby path.")
+	h := reg.Histogram("latency_seconds", Labels{"mode": "rlm"}, []float64{0.1, 1})
+	h.Observe(0.05)
+	h.Observe(0.5)
+	h.Observe(5)
+
+	var buf bytes.Buffer
+	require.NoError(t, reg.WritePrometheus(&buf))
+
+	want := `# HELP in_flight 
+# TYPE in_flight gauge
+in_flight 2
+# HELP latency_seconds 
+# TYPE latency_seconds histogram
+latency_seconds_bucket{mode="rlm",le="0.1"} 1
+latency_seconds_bucket{mode="rlm",le="1"} 2
+latency_seconds_bucket{mode="rlm",le="+Inf"} 3
+latency_seconds_sum{mode="rlm"} 5.55
+latency_seconds_count{mode="rlm"} 3
+# HELP req_total Requests served,\nby path.
+# TYPE req_total counter
+req_total{code="200",path="/a\"b"} 3
+req_total{code="500",path="/"} 1
+`
+	assert.Equal(t, want, buf.String())
+}
+
+func TestRegistry_Handler(t *testing.T) {
+	reg := NewRegistry()
+	NewExecutionMetrics(reg).RecordExecution(ExecutionSample{Mode: "direct", Action: "DIRECT", ModelTier: "main"})
+
+	rec := httptest.NewRecorder()
+	reg.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Header().Get("Content-Type"), "text/plain; version=0.0.4")
+	assert.Contains(t, rec.Body.String(), `rlm_executions_total{action="DIRECT",mode="direct",tier="main"} 1`)
+	assert.Contains(t, rec.Body.String(), "# TYPE rlm_execution_duration_seconds histogram")
+	assert.Contains(t, rec.Body.String(), "# HELP rlm_execution_duration_seconds Duration of RLM executions in seconds.\n")
+}
+
 // Tracer tests
 
 func TestTracer_StartSpan(t *testing.T) {
@@ -0,0 +1,125 @@
+package observability
+
+import (
+	"io"
+	"net/http"
+	"sort"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/common/expfmt"
+)
+
+// registryCollector exposes a Registry's metrics to Prometheus. The
+// registry's metrics are created on demand, so it is an unchecked
+// collector: Describe sends nothing and Collect reports whatever exists at
+// scrape time.
+type registryCollector struct {
+	registry *Registry
+}
+
+// Describe implements prometheus.Collector.
+func (c registryCollector) Describe(chan<- *prometheus.Desc) {}
+
+// Collect implements prometheus.Collector.
+func (c registryCollector) Collect(ch chan<- prometheus.Metric) {
+	r := c.registry
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, m := range r.counters {
+		if m.name == "" {
+			continue
+		}
+		desc := r.promDesc(m.name, m.labels)
+		ch <- promMetric(desc)(prometheus.NewConstMetric(desc, prometheus.CounterValue, float64(m.Value()), promLabelValues(m.labels)...))
+	}
+	for _, m := range r.gauges {
+		if m.name == "" {
+			continue
+		}
+		desc := r.promDesc(m.name, m.labels)
+		ch <- promMetric(desc)(prometheus.NewConstMetric(desc, prometheus.GaugeValue, float64(m.Value()), promLabelValues(m.labels)...))
+	}
+	for _, m := range r.histograms {
+		if m.name == "" {
+			continue
+		}
+		snap := m.Snapshot()
+		buckets := make(map[float64]uint64, len(snap.Buckets))
+		var cumulative int64
+		for i, bound := range snap.Buckets {
+			cumulative += snap.Counts[i]
+			buckets[bound] = uint64(cumulative)
+		}
+		desc := r.promDesc(m.name, m.labels)
+		ch <- promMetric(desc)(prometheus.NewConstHistogram(desc, uint64(snap.Count), snap.Sum, buckets, promLabelValues(m.labels)...))
+	}
+}
+
+// promDesc describes a metric with the registry's help text for name. The
+// caller holds r.mu.
+func (r *Registry) promDesc(name string, labels Labels) *prometheus.Desc {
+	return prometheus.NewDesc(name, r.helpFor(name), promLabelNames(labels), nil)
+}
+
+// promMetric returns a function that passes on a metric, or an invalid
+// metric reporting err to the gatherer if it could not be built, e.g.
+// for a label name Prometheus does not accept.
+func promMetric(desc *prometheus.Desc) func(prometheus.Metric, error) prometheus.Metric {
+	return func(m prometheus.Metric, err error) prometheus.Metric {
+		if err != nil {
+			return prometheus.NewInvalidMetric(desc, err)
+		}
+		return m
+	}
+}
+
+// promLabelNames returns the label names sorted, the order
+// promLabelValues returns their values in.
+func promLabelNames(labels Labels) []string {
+	names := make([]string, 0, len(labels))
+	for k := range labels {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func promLabelValues(labels Labels) []string {
+	names := promLabelNames(labels)
+	values := make([]string, len(names))
+	for i, k := range names {
+		values[i] = labels[k]
+	}
+	return values
+}
+
+// Gatherer returns a Prometheus gatherer holding the registry's metrics.
+func (r *Registry) Gatherer() prometheus.Gatherer {
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(registryCollector{registry: r})
+	return reg
+}
+
+// WritePrometheus renders every metric in the registry in the Prometheus
+// text exposition format, with the help text of described metrics.
+func (r *Registry) WritePrometheus(w io.Writer) error {
+	families, err := r.Gatherer().Gather()
+	if err != nil {
+		return err
+	}
+	enc := expfmt.NewEncoder(w, expfmt.NewFormat(expfmt.TypeTextPlain))
+	for _, f := range families {
+		if err := enc.Encode(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Handler returns an http.Handler that serves the registry in the
+// Prometheus exposition format, suitable for mounting at /metrics.
+func (r *Registry) Handler() http.Handler {
+	return promhttp.HandlerFor(r.Gatherer(), promhttp.HandlerOpts{})
+}
@@ -22,15 +22,37 @@ type (
 	VariableInfo   = orchestrator.VariableInfo
 	ContextType    = orchestrator.ContextType
 	ContextSource  = orchestrator.ContextSource
+
+	SourceProvenance = orchestrator.SourceProvenance
+	DedupConfig      = orchestrator.DedupConfig
+	RankConfig       = orchestrator.RankConfig
+	SourceRelevance  = orchestrator.SourceRelevance
+	DroppedSource    = orchestrator.DroppedSource
+	RefreshConfig    = orchestrator.RefreshConfig
+	ContextRefresh   = orchestrator.ContextRefresh
+
+	FileContextProvider   = orchestrator.FileContextProvider
+	MemoryContextProvider = orchestrator.MemoryContextProvider
 )
 
+// DefaultRankConfig returns the default context ranking weights.
+var DefaultRankConfig = orchestrator.DefaultRankConfig
+
+// DefaultRefreshConfig returns the default context refresh schedule.
+var DefaultRefreshConfig = orchestrator.DefaultRefreshConfig
+
+// RefreshNotice tells the model which context variables were refreshed.
+var RefreshNotice = orchestrator.RefreshNotice
+
 // Re-export constants.
 const (
-	ContextTypeFile   = orchestrator.ContextTypeFile
-	ContextTypeSearch = orchestrator.ContextTypeSearch
-	ContextTypeMemory = orchestrator.ContextTypeMemory
-	ContextTypeCustom = orchestrator.ContextTypeCustom
-	ContextTypePrompt = orchestrator.ContextTypePrompt
+	ContextTypeFile         = orchestrator.ContextTypeFile
+	ContextTypeSearch       = orchestrator.ContextTypeSearch
+	ContextTypeMemory       = orchestrator.ContextTypeMemory
+	ContextTypeCustom       = orchestrator.ContextTypeCustom
+	ContextTypePrompt       = orchestrator.ContextTypePrompt
+	ContextTypeConversation = orchestrator.ContextTypeConversation
+	ContextTypeImage        = orchestrator.ContextTypeImage
 )
 
 // Orchestrator handles intelligent prompt pre-processing and task routing.
@@ -0,0 +1,92 @@
+package orchestrator
+
+import (
+	"context"
+	"sync"
+
+	"github.com/rand/recurse/internal/rlm/synthesize"
+)
+
+type confidenceKey struct{}
+
+// confidenceRecorder captures the aggregate confidence produced by synthesis
+// within one orchestrate call, so it can be propagated to the caller without
+// widening every action's return values. Async operations get their own
+// child recorder keyed by operation ID.
+type confidenceRecorder struct {
+	mu    sync.Mutex
+	value float64
+	ops   map[string]*confidenceRecorder
+}
+
+// withConfidenceRecorder returns a context carrying a fresh recorder.
+func withConfidenceRecorder(ctx context.Context) (context.Context, *confidenceRecorder) {
+	rec := &confidenceRecorder{}
+	return context.WithValue(ctx, confidenceKey{}, rec), rec
+}
+
+// recordConfidence stores the confidence in the context's recorder, if any.
+func recordConfidence(ctx context.Context, confidence float64) {
+	if rec, ok := ctx.Value(confidenceKey{}).(*confidenceRecorder); ok {
+		rec.mu.Lock()
+		rec.value = confidence
+		rec.mu.Unlock()
+	}
+}
+
+// leafScorer scores answers the main model gives without synthesis.
+var leafScorer = synthesize.NewHeuristicScorer()
+
+// recordLeafConfidence records the heuristic confidence of an answer given
+// directly, so executions that never synthesize still carry a confidence.
+func recordLeafConfidence(ctx context.Context, response string) {
+	scored, err := leafScorer.Score(ctx, &synthesize.SubCallResult{Response: response})
+	if err != nil {
+		return
+	}
+	recordConfidence(ctx, scored.Score)
+}
+
+// recordedConfidence returns the confidence in the context's recorder, or
+// zero without one.
+func recordedConfidence(ctx context.Context) float64 {
+	if rec, ok := ctx.Value(confidenceKey{}).(*confidenceRecorder); ok {
+		return rec.Confidence()
+	}
+	return 0
+}
+
+// withOpConfidenceRecorder scopes the context's recorder to an async
+// operation. Without a recorder in ctx, ctx is returned unchanged.
+func withOpConfidenceRecorder(ctx context.Context, opID string) context.Context {
+	rec, ok := ctx.Value(confidenceKey{}).(*confidenceRecorder)
+	if !ok {
+		return ctx
+	}
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	if rec.ops == nil {
+		rec.ops = make(map[string]*confidenceRecorder)
+	}
+	child := &confidenceRecorder{}
+	rec.ops[opID] = child
+	return context.WithValue(ctx, confidenceKey{}, child)
+}
+
+// Confidence returns the recorded confidence, or zero if none was recorded.
+func (r *confidenceRecorder) Confidence() float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.value
+}
+
+// OpConfidence returns the confidence recorded for an async operation.
+func (r *confidenceRecorder) OpConfidence(opID string) float64 {
+	r.mu.Lock()
+	child := r.ops[opID]
+	r.mu.Unlock()
+	if child == nil {
+		return 0
+	}
+	return child.Confidence()
+}
@@ -3,8 +3,11 @@ package orchestrator
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"slices"
+	"sort"
 	"strings"
 	"sync/atomic"
 	"time"
@@ -16,12 +19,14 @@ import (
 	"github.com/rand/recurse/internal/rlm/meta"
 	"github.com/rand/recurse/internal/rlm/repl"
 	"github.com/rand/recurse/internal/rlm/synthesize"
+	"github.com/rand/recurse/internal/rlm/tot"
 )
 
 // Core is the central orchestration controller with memory integration.
 type Core struct {
-	meta          *meta.Controller
+	policy        meta.DecisionPolicy
 	mainClient    meta.LLMClient
+	tierClient    *tierLimitedClient
 	store         *hypergraph.Store
 	synthesizer   synthesize.Synthesizer
 	tracer        TraceRecorder
@@ -30,11 +35,29 @@ type Core struct {
 	asyncExecutor *async.Executor
 	replManager   *repl.Manager // [SPEC-09.05] For EXECUTE action
 
+	// Streaming decomposition: subtasks start while planning continues.
+	streamingDecomposer decompose.StreamingDecomposer
+	subtaskProgress     async.ResultCallback
+
+	// Tree of Thoughts for TOT; nil uses LLM components on mainClient.
+	totEvaluator tot.Evaluator
+	totGenerator tot.Generator
+
 	// Context externalization [SPEC-09.06]
 	contextPreparer ContextPreparer
 
 	// Hallucination detection [SPEC-08.23-26]
 	traceAuditor *hallucination.TraceAuditor
+
+	// Scores answers for the escalation policy; nil uses synthesis confidence.
+	answerScorer AnswerScorer
+
+	// Scores answers of executions whose profile verifies everything when
+	// answerScorer is nil.
+	assuranceScorer AnswerScorer
+
+	// Reuses results of identical decomposition subtasks; nil disables.
+	subtaskCache *SubtaskCache
 }
 
 // CoreConfig configures the orchestration core.
@@ -48,6 +71,11 @@ type CoreConfig struct {
 	// MemoryQueryLimit is max results from memory queries.
 	MemoryQueryLimit int
 
+	// MemoryMinConfidence is the confidence a memory needs to be returned
+	// by MEMORY_QUERY, keeping doubtful or decayed facts out of context.
+	// Zero returns memories of any confidence.
+	MemoryMinConfidence float64
+
 	// StoreDecisions persists decisions to memory graph.
 	StoreDecisions bool
 
@@ -62,30 +90,65 @@ type CoreConfig struct {
 
 	// MaxParallelOps is the maximum concurrent operations (default: 4).
 	MaxParallelOps int
+
+	// CostCeiling aborts an execution whose LLM calls would cost more than
+	// CostCeiling.MaxCost. Zero disables it.
+	CostCeiling CostCeiling
+
+	// MaxLLMCalls aborts an execution about to make more LLM calls than
+	// this, counting the meta-controller's, sub-calls and the answer
+	// scorer's. It sets CostCeiling.MaxCalls. Zero leaves calls unbounded.
+	MaxLLMCalls int
+
+	// Escalation re-executes low-confidence answers on a higher model tier.
+	// Zero disables it.
+	Escalation EscalationPolicy
+
+	// ActionTimeouts bounds each action separately from the overall
+	// deadline; a timed-out action goes through recovery. Zero disables it.
+	ActionTimeouts ActionTimeouts
+
+	// WarmStart biases top-level decisions toward actions that succeeded
+	// on similar recorded tasks. Zero disables it.
+	WarmStart WarmStartConfig
+
+	// ExecutionRetry re-runs an execution that failed with a transient
+	// error. Zero disables it.
+	ExecutionRetry ExecutionRetry
 }
 
 // DefaultCoreConfig returns sensible defaults.
 func DefaultCoreConfig() CoreConfig {
 	return CoreConfig{
-		MaxTokenBudget:    100000,
-		MaxRecursionDepth: 5,
-		MemoryQueryLimit:  10,
-		StoreDecisions:    true,
-		TraceEnabled:      true,
-		Recovery:          DefaultRecoveryConfig(),
+		MaxTokenBudget:      100000,
+		MaxRecursionDepth:   5,
+		MemoryQueryLimit:    10,
+		MemoryMinConfidence: 0.5,
+		StoreDecisions:      true,
+		TraceEnabled:        true,
+		Recovery:            DefaultRecoveryConfig(),
 	}
 }
 
 // NewCore creates a new orchestration core with memory integration.
+// policy makes each orchestration decision, usually the LLM-backed
+// meta.Controller.
 func NewCore(
-	metaCtrl *meta.Controller,
+	policy meta.DecisionPolicy,
 	mainClient meta.LLMClient,
 	store *hypergraph.Store,
 	cfg CoreConfig,
 ) *Core {
+	var tierClient *tierLimitedClient
+	if mainClient != nil {
+		tierClient = &tierLimitedClient{client: mainClient}
+		mainClient = &costGuardedClient{client: tierClient}
+	}
+
 	c := &Core{
-		meta:        metaCtrl,
+		policy:      policy,
 		mainClient:  mainClient,
+		tierClient:  tierClient,
 		store:       store,
 		synthesizer: synthesize.NewConcatenateSynthesizer(),
 		config:      cfg,
@@ -121,14 +184,58 @@ func (c *Core) Tracer() TraceRecorder {
 	return c.tracer
 }
 
+// SetSynthesizer sets the synthesizer used by DECOMPOSE and SYNTHESIZE.
+func (c *Core) SetSynthesizer(s synthesize.Synthesizer) {
+	c.synthesizer = s
+}
+
+// SetStreamingDecomposer makes DECOMPOSE plan incrementally, starting each
+// subtask as soon as it and its dependencies are ready instead of after the
+// whole decomposition is known. It takes precedence over the strategy chosen
+// by the meta-controller and requires EnableAsyncExecution.
+func (c *Core) SetStreamingDecomposer(d decompose.StreamingDecomposer) {
+	c.streamingDecomposer = d
+}
+
+// SetSubtaskProgress sets a callback invoked as each streamed subtask
+// completes.
+func (c *Core) SetSubtaskProgress(cb async.ResultCallback) {
+	c.subtaskProgress = cb
+}
+
+// SetToT sets the evaluator and generator used by the TOT action. Without
+// them, TOT evaluates and generates thoughts with the main LLM client.
+func (c *Core) SetToT(evaluator tot.Evaluator, generator tot.Generator) {
+	c.totEvaluator = evaluator
+	c.totGenerator = generator
+}
+
+// ExplainLastDecision explains the decision policy's most recent decision.
+// It returns meta.ErrNoDecision for policies that cannot explain theirs.
+func (c *Core) ExplainLastDecision() (*meta.DecisionExplanation, error) {
+	explainer, ok := c.policy.(meta.DecisionExplainer)
+	if !ok {
+		return nil, fmt.Errorf("decision policy %T keeps no explanation: %w", c.policy, meta.ErrNoDecision)
+	}
+	return explainer.ExplainLastDecision()
+}
+
 // SetREPLManager sets the REPL manager for EXECUTE action.
 // [SPEC-09.05]
 func (c *Core) SetREPLManager(mgr *repl.Manager) {
 	c.replManager = mgr
 }
 
+// ClassifyError returns the recovery category of err, as the core's
+// recovery classifies it.
+func (c *Core) ClassifyError(err error) ErrorCategory {
+	return c.recovery.ClassifyError(err)
+}
+
 // ContextPreparer is an interface for preparing context with optional externalization.
-// [SPEC-09.06] This allows the wrapper to decide execution mode.
+// [SPEC-09.06] This allows the wrapper to decide execution mode. The
+// recursion depth of the call being prepared is carried by ctx; see
+// RecursionDepthFrom.
 type ContextPreparer interface {
 	// PrepareContext prepares context, potentially externalizing it to REPL.
 	// Returns the prepared result with mode, system prompt, and loaded context info.
@@ -149,6 +256,29 @@ type PreparedContext struct {
 
 	// ExternalizedTokens is the token count that was externalized.
 	ExternalizedTokens int
+
+	// Compression is set when the context was compressed during
+	// preparation.
+	Compression *CompressionStats
+
+	// ContextDigest identifies the content of the loaded context, so
+	// results derived from it can be keyed on it. Empty when there is none.
+	ContextDigest string
+}
+
+type recursionDepthKey struct{}
+
+// WithRecursionDepth returns a context for work at depth in the recursion.
+// Core sets it for each orchestration level.
+func WithRecursionDepth(ctx context.Context, depth int) context.Context {
+	return context.WithValue(ctx, recursionDepthKey{}, depth)
+}
+
+// RecursionDepthFrom returns the recursion depth carried by ctx, zero if
+// there is none.
+func RecursionDepthFrom(ctx context.Context) int {
+	depth, _ := ctx.Value(recursionDepthKey{}).(int)
+	return depth
 }
 
 // SetContextPreparer sets the context preparer for externalization.
@@ -168,9 +298,57 @@ func (c *Core) TraceAuditor() *hallucination.TraceAuditor {
 	return c.traceAuditor
 }
 
-// Execute runs the RLM orchestration loop for a task.
+// Execute runs the RLM orchestration loop for a task. With a retry policy,
+// an execution failing with a transient error is run again; with an
+// escalation policy, a low-confidence answer is re-executed once on a
+// higher tier. The execution profile carried by ctx overrides the
+// configured escalation, verification and depth.
 func (c *Core) Execute(ctx context.Context, task string) (*ExecutionResult, error) {
+	profile := ExecutionProfileFrom(ctx)
+	settings := profile.Settings()
+
+	// Facts recorded while executing are linked to this execution's node,
+	// or to that of the execution already in the context.
+	ctx, _ = WithExecution(ctx, c.store, task)
+
+	// A guard already in the context (e.g. a batch ceiling) also bounds
+	// this execution, escalation included. Without any limit the guard
+	// still counts the calls.
+	ceiling := c.config.CostCeiling
+	if c.config.MaxLLMCalls > 0 {
+		ceiling.MaxCalls = c.config.MaxLLMCalls
+	}
+	guard := CostGuardFrom(ctx).Child(ceiling)
+	if guard == nil {
+		guard = NewCostGuard(ceiling)
+	}
+	ctx = WithCostGuard(ctx, guard)
+
+	policy := c.config.Escalation
+	if settings.Escalation != nil {
+		policy = *settings.Escalation
+	}
+
+	result, err := c.executeWithRetry(ctx, task, guard)
+	if err == nil && policy.Enabled() && settings.Verification != VerifyNone {
+		result = c.escalate(ctx, task, guard, policy, result)
+	}
+	if err != nil && settings.AcceptPartial && result != nil && result.Partial && result.Response != "" {
+		// The partial answer stands; Error still says why it is partial
+		err = nil
+	}
+	if result != nil {
+		// Every call of the execution, the answer scoring included
+		result.LLMCalls = guard.Calls()
+		result.Profile = profile
+	}
+	return result, err
+}
+
+// execute runs one attempt at task, charging guard.
+func (c *Core) execute(ctx context.Context, task string, guard *CostGuard) (*ExecutionResult, error) {
 	start := time.Now()
+	spentBefore, callsBefore := guard.Spent(), guard.Calls()
 	result := &ExecutionResult{
 		Task:      task,
 		StartTime: start,
@@ -184,6 +362,9 @@ func (c *Core) Execute(ctx context.Context, task string) (*ExecutionResult, erro
 		RecursionDepth: 0,
 		MaxDepth:       c.config.MaxRecursionDepth,
 	}
+	if depth := ProfileSettingsFrom(ctx).MaxRecursionDepth; depth > 0 {
+		state.MaxDepth = depth
+	}
 
 	// Check memory for relevant context
 	memoryHints, err := c.queryMemoryContext(ctx, task)
@@ -192,8 +373,46 @@ func (c *Core) Execute(ctx context.Context, task string) (*ExecutionResult, erro
 	}
 
 	// Run orchestration loop
+	ctx, confidence := withConfidenceRecorder(ctx)
+	ctx, stats := withExecStats(ctx)
+	ctx, usage := meta.TrackUsage(ctx)
+	ctx, routes := meta.TrackRoutes(ctx)
+	defer func() {
+		total := usage.Total()
+		result.InputTokens = total.PromptTokens
+		result.OutputTokens = total.CompletionTokens
+		result.EstimatedTokens = usage.Estimated().Total()
+	}()
 	response, tokens, err := c.orchestrate(ctx, state, "")
+	result.Action = string(stats.action)
+	result.Mode = stats.mode()
+	result.Decisions = int(stats.decisions.Load())
+	if last := routes.Last(); last != nil {
+		result.Tier = last.Tier.String()
+	}
+	result.Models = c.modelsUsed(routes)
+	result.SubtaskCacheHits = int(stats.subtaskCacheHits.Load())
+	result.TimedOutActions = stats.timedOutActions()
+	result.ContextOverflows = stats.contextOverflows()
+	result.Compression = stats.compression
+	result.Cost = guard.Spent() - spentBefore
+	result.LLMCalls = guard.Calls() - callsBefore
+
+	// A refused call anywhere in the tree aborts the execution, even if a
+	// degraded or synthesized answer was still produced.
+	if ceilingErr := guard.Err(); ceilingErr != nil {
+		if response == "" {
+			response = guard.Partial()
+		}
+		result.Response = response
+		result.Partial = true
+		result.TotalTokens = tokens
+		result.Error = ceilingErr.Error()
+		result.Duration = time.Since(start)
+		return result, ceilingErr
+	}
 	if err != nil {
+		result.TotalTokens = tokens
 		result.Error = err.Error()
 		result.Duration = time.Since(start)
 		return result, err
@@ -201,6 +420,7 @@ func (c *Core) Execute(ctx context.Context, task string) (*ExecutionResult, erro
 
 	result.Response = response
 	result.TotalTokens = tokens
+	result.Confidence = confidence.Confidence()
 	result.Duration = time.Since(start)
 
 	// Store execution as decision node
@@ -211,25 +431,60 @@ func (c *Core) Execute(ctx context.Context, task string) (*ExecutionResult, erro
 	return result, nil
 }
 
+// modelsUsed returns the distinct models routes recorded, in order of first
+// use, or the main client's model when it recorded none.
+func (c *Core) modelsUsed(routes *meta.RouteRecorder) []string {
+	var models []string
+	for _, decision := range routes.Decisions() {
+		model := decision.Model
+		if model == "" {
+			model = decision.Tier.String()
+		}
+		if !slices.Contains(models, model) {
+			models = append(models, model)
+		}
+	}
+	if len(models) > 0 || c.tierClient == nil {
+		return models
+	}
+	if named, ok := c.tierClient.client.(interface{ Model() string }); ok && named.Model() != "" {
+		return []string{named.Model()}
+	}
+	return nil
+}
+
 // orchestrate is the recursive orchestration loop with error recovery.
 func (c *Core) orchestrate(ctx context.Context, state meta.State, parentID string) (string, int, error) {
 	eventID := generateID()
 	totalTokens := 0
+	ctx = WithRecursionDepth(ctx, state.RecursionDepth)
 
-	// Reset retry counter for this orchestration
-	c.recovery.ResetRetry()
+	// Stop recursing once the execution has hit its cost ceiling.
+	if err := CostGuardFrom(ctx).Err(); err != nil {
+		return "", 0, err
+	}
 
 	// [SPEC-09.06] Check for context externalization at depth 0
 	if c.contextPreparer != nil && state.RecursionDepth == 0 {
-		prepared, err := c.contextPreparer.PrepareContext(ctx, state.Task, state.ContextTokens)
+		prepared, err := c.prepareContext(ctx, state.Task, state.ContextTokens)
 		if err != nil {
 			slog.Warn("Context preparation failed, continuing without externalization", "error", err)
-		} else if prepared != nil && prepared.Externalized {
-			state.ExternalizedContext = true
-			state.SystemPrompt = prepared.SystemPrompt
-			slog.Info("Context externalized to REPL",
-				"mode", prepared.Mode,
-				"externalized_tokens", prepared.ExternalizedTokens)
+		} else if prepared != nil {
+			if prepared.ContextDigest != "" {
+				ctx = withContextDigest(ctx, prepared.ContextDigest)
+			}
+			if prepared.Compression != nil {
+				// Decide on the size actually sent
+				state.ContextTokens = prepared.Compression.CompressedTokens
+				recordCompression(ctx, prepared.Compression)
+			}
+			if prepared.Externalized {
+				state.ExternalizedContext = true
+				state.SystemPrompt = prepared.SystemPrompt
+				slog.Info("Context externalized to REPL",
+					"mode", prepared.Mode,
+					"externalized_tokens", prepared.ExternalizedTokens)
+			}
 		}
 	}
 
@@ -246,17 +501,22 @@ func (c *Core) orchestrate(ctx context.Context, state meta.State, parentID strin
 		})
 	}
 
-	// Get decision from meta-controller
-	decision, err := c.meta.Decide(ctx, state)
+	// Get decision from the policy, normally the meta-controller
+	decision, err := c.policy.Decide(ctx, state)
 	if err != nil {
 		return "", 0, fmt.Errorf("meta decision: %w", err)
 	}
+	decision = c.warmStart(ctx, state, decision)
+	recordDecision(ctx, state, decision)
 
 	// Execute the decision with error recovery
 	response, totalTokens, err := c.executeWithRecovery(ctx, state, decision, eventID)
 
 	// Record trace event completion
 	if c.tracer != nil && c.config.TraceEnabled {
+		if marker, ok := c.tracer.(InterestingMarker); ok && CostGuardFrom(ctx).NearCeiling() {
+			marker.MarkInteresting(eventID)
+		}
 		status := "completed"
 		if err != nil {
 			status = "failed"
@@ -280,6 +540,7 @@ func (c *Core) executeWithRecovery(ctx context.Context, state meta.State, decisi
 	var response string
 	var totalTokens int
 	var err error
+	retries := 0 // this orchestration's, apart from concurrent ones
 
 	for {
 		// Execute the decision
@@ -290,7 +551,7 @@ func (c *Core) executeWithRecovery(ctx context.Context, state meta.State, decisi
 		}
 
 		// Determine recovery action
-		recoveryAction := c.recovery.DetermineAction(err, decision.Action, state)
+		recoveryAction := c.recovery.determineAction(err, decision.Action, state, retries)
 
 		// Record the error
 		c.recovery.RecordError(ErrorRecord{
@@ -298,8 +559,8 @@ func (c *Core) executeWithRecovery(ctx context.Context, state meta.State, decisi
 			Action:     string(decision.Action),
 			Error:      err.Error(),
 			Context:    truncate(state.Task, 200),
-			Recovered:  recoveryAction.ShouldRetry || recoveryAction.Degraded,
-			RetryCount: c.recovery.RetryCount(),
+			Recovered:  recoveryAction.ShouldRetry || recoveryAction.Degraded || recoveryAction.Chunked,
+			RetryCount: retries,
 			Degraded:   recoveryAction.Degraded,
 		})
 
@@ -318,7 +579,7 @@ func (c *Core) executeWithRecovery(ctx context.Context, state meta.State, decisi
 
 		// Handle retry
 		if recoveryAction.ShouldRetry {
-			c.recovery.IncrementRetry()
+			retries++
 
 			// Add recovery context to state
 			if recoveryAction.RetryPrompt != "" {
@@ -335,6 +596,11 @@ func (c *Core) executeWithRecovery(ctx context.Context, state meta.State, decisi
 			continue
 		}
 
+		// Handle a context overflow by answering chunk by chunk
+		if recoveryAction.Chunked {
+			return c.executeChunked(ctx, state, decision, eventID, err)
+		}
+
 		// Handle degradation to direct mode
 		if recoveryAction.Degraded {
 			// Record degradation in trace
@@ -352,7 +618,10 @@ func (c *Core) executeWithRecovery(ctx context.Context, state meta.State, decisi
 			}
 
 			// Execute in direct mode
-			response, totalTokens, err = c.executeDirect(ctx, state)
+			recordDegraded(ctx)
+			response, totalTokens, err = c.runWithTimeout(ctx, meta.ActionDirect, func(ctx context.Context) (string, int, error) {
+				return c.executeDirect(ctx, state)
+			})
 			if err != nil {
 				return "", totalTokens, fmt.Errorf("degraded execution failed: %w", err)
 			}
@@ -364,8 +633,128 @@ func (c *Core) executeWithRecovery(ctx context.Context, state meta.State, decisi
 	}
 }
 
-// executeAction executes the appropriate action based on decision.
+// executeChunked retries an action whose prompt overflowed the model's
+// context window by decomposing its task into concept chunks, each small
+// enough to answer on its own, and synthesizing their answers.
+func (c *Core) executeChunked(ctx context.Context, state meta.State, decision *meta.Decision, eventID string, overflowErr error) (string, int, error) {
+	overflow := ContextOverflowRecovery{
+		Action: string(decision.Action),
+		Depth:  state.RecursionDepth,
+	}
+	if detected, ok := meta.DetectContextOverflow(overflowErr); ok {
+		overflow.Provider = detected.Provider
+	}
+	slog.Info("Context overflow, retrying in chunks",
+		"action", decision.Action,
+		"provider", overflow.Provider,
+		"depth", state.RecursionDepth)
+
+	if c.tracer != nil && c.config.TraceEnabled {
+		c.tracer.RecordEvent(TraceEvent{
+			ID:        generateID(),
+			Type:      "overflow_recovery",
+			Action:    "Retrying in chunks after context overflow",
+			Details:   overflowErr.Error(),
+			Timestamp: time.Now(),
+			Depth:     state.RecursionDepth,
+			ParentID:  eventID,
+			Status:    "chunked",
+		})
+	}
+
+	response, tokens, err := c.runWithTimeout(ctx, meta.ActionDecompose, func(ctx context.Context) (string, int, error) {
+		return c.executeOverflowChunks(ctx, state, eventID)
+	})
+	overflow.Recovered = err == nil
+	recordContextOverflow(ctx, overflow)
+	if err != nil {
+		return "", tokens, fmt.Errorf("chunked retry after %w failed: %w", meta.ErrContextOverflow, err)
+	}
+	return response, tokens, nil
+}
+
+// Overflow chunks, in characters. Chunks are not made smaller than
+// minOverflowChunkSize, so splitting stops at about 250 tokens. Each chunk
+// carries up to overflowQuestionSize characters from each end of the task,
+// where its instruction or question usually is.
+const (
+	minOverflowChunkSize = 1000
+	overflowChunkOverlap = 100
+	overflowQuestionSize = 300
+)
+
+// overflowChunkPrompt frames a chunk with the question of the task it was
+// split from.
+const overflowChunkPrompt = `%s
+
+(Part %d of %d of the material for the task above, which was too large to answer at once. Answer the task from this part only.)
+
+%s`
+
+// executeOverflowChunks splits a task that overflowed the context window
+// into halves along paragraph boundaries, answers each as a subtask that
+// carries the task's question, and synthesizes the answers against the
+// question. A half that still overflows is split again by its own
+// recovery, down to the depth limit.
+func (c *Core) executeOverflowChunks(ctx context.Context, state meta.State, parentID string) (string, int, error) {
+	size := max(len(state.Task)/2, minOverflowChunkSize)
+	chunks, err := decompose.NewConceptDecomposer(size, overflowChunkOverlap).Decompose(state.Task)
+	if err != nil {
+		return "", 0, fmt.Errorf("decompose: %w", err)
+	}
+	if len(chunks) < 2 {
+		return "", 0, errors.New("task cannot be split into smaller chunks")
+	}
+	question := overflowQuestion(state.Task)
+	for i := range chunks {
+		chunks[i].Content = fmt.Sprintf(overflowChunkPrompt, question, i+1, len(chunks), chunks[i].Content)
+	}
+
+	var results []synthesize.SubCallResult
+	var totalTokens int
+	if c.asyncExecutor != nil {
+		results, totalTokens, err = c.executeDecomposeAsync(ctx, state, chunks, parentID)
+		if err != nil {
+			return "", totalTokens, err
+		}
+	} else {
+		results, totalTokens = c.executeDecomposeSerial(ctx, state, chunks, parentID)
+	}
+
+	// The whole task would overflow the synthesis prompt too
+	state.Task = question
+	return c.synthesizeDecomposition(ctx, state, strategyOverflow, chunks, results, totalTokens)
+}
+
+// overflowQuestion returns the part of an overflowing task that states
+// what to do with it: its first and last paragraphs, each cut to
+// overflowQuestionSize characters.
+func overflowQuestion(task string) string {
+	paragraphs := strings.Split(strings.TrimSpace(task), "\n\n")
+	first := paragraphs[0]
+	if len(first) > overflowQuestionSize {
+		first = first[:overflowQuestionSize] + "..."
+	}
+	if len(paragraphs) == 1 {
+		return first
+	}
+	last := paragraphs[len(paragraphs)-1]
+	if len(last) > overflowQuestionSize {
+		last = "..." + last[len(last)-overflowQuestionSize:]
+	}
+	return first + "\n\n[...]\n\n" + last
+}
+
+// executeAction executes the appropriate action based on decision, bounded
+// by the action's timeout.
 func (c *Core) executeAction(ctx context.Context, state meta.State, decision *meta.Decision, eventID string) (string, int, error) {
+	return c.runWithTimeout(ctx, decision.Action, func(ctx context.Context) (string, int, error) {
+		return c.dispatchAction(ctx, state, decision, eventID)
+	})
+}
+
+// dispatchAction runs the handler for decision's action.
+func (c *Core) dispatchAction(ctx context.Context, state meta.State, decision *meta.Decision, eventID string) (string, int, error) {
 	switch decision.Action {
 	case meta.ActionDirect:
 		return c.executeDirect(ctx, state)
@@ -385,8 +774,11 @@ func (c *Core) executeAction(ctx context.Context, state meta.State, decision *me
 	case meta.ActionExecute:
 		return c.executeREPL(ctx, state, decision)
 
+	case meta.ActionToT:
+		return c.executeToT(ctx, state, decision)
+
 	default:
-		return "", 0, fmt.Errorf("unknown action: %s", decision.Action)
+		return "", 0, fmt.Errorf("%w: %s", ErrUnknownAction, decision.Action)
 	}
 }
 
@@ -428,23 +820,37 @@ func (c *Core) executeDirect(ctx context.Context, state meta.State) (string, int
 		"inputTokens", inputTokens,
 		"maxOutputTokens", maxOutputTokens,
 		"externalized", state.ExternalizedContext)
-	response, err := c.mainClient.Complete(ctx, prompt.String(), maxOutputTokens)
+	response, usage, err := meta.CompleteWithUsage(ctx, c.mainClient, prompt.String(), maxOutputTokens, estimateTokens)
 	if err != nil {
-		return "", inputTokens, fmt.Errorf("main LLM call: %w", err)
+		return "", inputTokens, fmt.Errorf("main %w: %w", ErrLLMCall, err)
 	}
 	slog.Debug("executeDirect LLM response", "responseLen", len(response), "response", response)
+	CostGuardFrom(ctx).RecordPartial(response)
+	recordLeafConfidence(ctx, response)
 
-	totalTokens := inputTokens + estimateTokens(response)
-	return response, totalTokens, nil
+	return response, usage.Total(), nil
 }
 
 // executeDecompose breaks task into subtasks and processes them.
 func (c *Core) executeDecompose(ctx context.Context, state meta.State, decision *meta.Decision, parentID string) (string, int, error) {
 	totalTokens := 0
 
-	// Select decomposer based on strategy
+	var chunks []decompose.Chunk
+	var results []synthesize.SubCallResult
+	var err error
+	if c.streamingDecomposer != nil && c.asyncExecutor != nil {
+		chunks, results, totalTokens, err = c.executeDecomposeStreaming(ctx, state, parentID)
+		if err != nil {
+			return "", totalTokens, err
+		}
+		return c.synthesizeDecomposition(ctx, state, strategyStreaming, chunks, results, totalTokens)
+	}
+
+	// Select decomposer based on strategy, or the one a similar task
+	// decomposed well with
+	strategy := c.seedStrategy(ctx, state, decision)
 	var decomposer decompose.Decomposer
-	switch decision.Params.Strategy {
+	switch strategy {
 	case meta.StrategyFunction:
 		decomposer = decompose.NewFunctionDecomposer("go")
 	case meta.StrategyConcept:
@@ -452,17 +858,17 @@ func (c *Core) executeDecompose(ctx context.Context, state meta.State, decision
 	case meta.StrategyCustom:
 		decomposer = decompose.Auto(state.Task)
 	default:
+		strategy = meta.StrategyFile
 		decomposer = decompose.NewFileDecomposer()
 	}
 
 	// Decompose the task
-	chunks, err := decomposer.Decompose(state.Task)
+	chunks, err = decomposer.Decompose(state.Task)
 	if err != nil {
 		return "", 0, fmt.Errorf("decompose: %w", err)
 	}
 
 	// Use async executor if available, otherwise fall back to serial
-	var results []synthesize.SubCallResult
 	if c.asyncExecutor != nil && len(chunks) > 1 {
 		results, totalTokens, err = c.executeDecomposeAsync(ctx, state, chunks, parentID)
 		if err != nil {
@@ -472,13 +878,28 @@ func (c *Core) executeDecompose(ctx context.Context, state meta.State, decision
 		results, totalTokens = c.executeDecomposeSerial(ctx, state, chunks, parentID)
 	}
 
-	// Synthesize results
+	return c.synthesizeDecomposition(ctx, state, string(strategy), chunks, results, totalTokens)
+}
+
+// synthesizeDecomposition combines subtask results into the final response
+// and records the decomposition as a plan.
+func (c *Core) synthesizeDecomposition(
+	ctx context.Context,
+	state meta.State,
+	strategy string,
+	chunks []decompose.Chunk,
+	results []synthesize.SubCallResult,
+	totalTokens int,
+) (string, int, error) {
 	synthesized, err := c.synthesizer.Synthesize(ctx, state.Task, results)
 	if err != nil {
 		return "", totalTokens, fmt.Errorf("synthesize: %w", err)
 	}
+	recordConfidence(ctx, synthesized.Confidence)
 
-	return synthesized.Response, totalTokens + synthesized.TotalTokensUsed, nil
+	totalTokens += synthesized.TotalTokensUsed
+	c.recordPlan(ctx, state, strategy, chunks, results, synthesized.Confidence, totalTokens)
+	return synthesized.Response, totalTokens, nil
 }
 
 // executeDecomposeSerial processes chunks sequentially.
@@ -500,7 +921,8 @@ func (c *Core) executeDecomposeSerial(
 			MaxDepth:       state.MaxDepth,
 		}
 
-		response, tokens, err := c.orchestrate(ctx, childState, parentID)
+		childCtx, confidence := withConfidenceRecorder(ctx)
+		response, tokens, err := c.runSubtask(childCtx, childState, parentID)
 		totalTokens += tokens
 
 		result := synthesize.SubCallResult{
@@ -508,6 +930,7 @@ func (c *Core) executeDecomposeSerial(
 			Name:       chunk.Name,
 			Response:   response,
 			TokensUsed: tokens,
+			Confidence: confidence.Confidence(),
 		}
 		if err != nil {
 			result.Error = err.Error()
@@ -544,6 +967,7 @@ func (c *Core) executeDecomposeAsync(
 	}
 
 	// Execute in parallel
+	ctx, confidence := withConfidenceRecorder(ctx)
 	execResult, err := c.asyncExecutor.ExecuteParallel(ctx, ops)
 	if err != nil {
 		return nil, 0, fmt.Errorf("async execution: %w", err)
@@ -563,6 +987,7 @@ func (c *Core) executeDecomposeAsync(
 		if opResult != nil {
 			result.Response = opResult.Response
 			result.TokensUsed = opResult.Tokens
+			result.Confidence = confidence.OpConfidence(opID)
 			if opResult.Error != nil {
 				result.Error = opResult.Error.Error()
 			}
@@ -576,48 +1001,161 @@ func (c *Core) executeDecomposeAsync(
 	return results, execResult.TotalTokens, nil
 }
 
-// executeMemoryQuery retrieves context from hypergraph memory.
+// executeDecomposeStreaming runs subtasks as the streaming decomposer emits
+// them. Chunks and results are returned in emission order.
+func (c *Core) executeDecomposeStreaming(
+	ctx context.Context,
+	state meta.State,
+	parentID string,
+) ([]decompose.Chunk, []synthesize.SubCallResult, int, error) {
+	opID := func(chunkID string) string {
+		return fmt.Sprintf("%s-%s", parentID, chunkID)
+	}
+
+	// The chunk count is unknown up front, so budget each subtask against
+	// the number that can run at once.
+	maxParallel := c.config.MaxParallelOps
+	if maxParallel <= 0 {
+		maxParallel = 4
+	}
+
+	// chunks is only appended to by the planner; ExecuteStreaming returns
+	// after planning has finished.
+	var chunks []decompose.Chunk
+	planner := async.PlannerFunc(func(ctx context.Context, emit func(async.PlannedOperation) error) error {
+		return c.streamingDecomposer.DecomposeStream(ctx, state.Task, func(chunk decompose.Chunk) error {
+			chunks = append(chunks, chunk)
+			deps := make([]string, len(chunk.DependsOn))
+			for i, dep := range chunk.DependsOn {
+				deps[i] = opID(dep)
+			}
+			return emit(async.PlannedOperation{
+				Op: &async.Operation{
+					ID:       opID(chunk.ID),
+					Task:     chunk.Content,
+					ParentID: parentID,
+					State: meta.State{
+						Task:           chunk.Content,
+						ContextTokens:  estimateTokens(chunk.Content),
+						BudgetRemain:   state.BudgetRemain / maxParallel,
+						RecursionDepth: state.RecursionDepth + 1,
+						MaxDepth:       state.MaxDepth,
+					},
+				},
+				DependsOn: deps,
+			})
+		})
+	})
+
+	ctx, confidence := withConfidenceRecorder(ctx)
+	execResult, err := c.asyncExecutor.ExecuteStreaming(ctx, planner, c.subtaskProgress)
+	if err != nil {
+		return nil, nil, execResult.TotalTokens, fmt.Errorf("streaming decompose: %w", err)
+	}
+
+	results := make([]synthesize.SubCallResult, len(chunks))
+	for i, chunk := range chunks {
+		id := opID(chunk.ID)
+		result := synthesize.SubCallResult{
+			ID:   chunk.ID,
+			Name: chunk.Name,
+		}
+		if opResult := execResult.Results[id]; opResult != nil {
+			result.Response = opResult.Response
+			result.TokensUsed = opResult.Tokens
+			result.Confidence = confidence.OpConfidence(id)
+			if opResult.Error != nil {
+				result.Error = opResult.Error.Error()
+			}
+		} else {
+			result.Error = "operation result not found"
+		}
+		results[i] = result
+	}
+
+	return chunks, results, execResult.TotalTokens, nil
+}
+
+// uncertainMemoryConfidence is the confidence below which a memory
+// returned by MEMORY_QUERY is marked as uncertain.
+const uncertainMemoryConfidence = 0.7
+
+// executeMemoryQuery retrieves context from hypergraph memory. Facts and
+// experiences holding at least half of the query's keywords are returned,
+// ranked by confidence × relevance, where relevance is the share of
+// keywords held. Memories below MemoryMinConfidence are left out and those
+// below uncertainMemoryConfidence are marked as uncertain.
 func (c *Core) executeMemoryQuery(ctx context.Context, state meta.State, decision *meta.Decision) (string, int, error) {
 	query := decision.Params.Query
 	if query == "" {
 		query = state.Task
 	}
+	keywords := planKeywords(query)
+	if len(keywords) == 0 {
+		keywords = map[string]bool{strings.ToLower(strings.TrimSpace(query)): true}
+	}
 
-	// Search memory by content
-	nodes, err := c.store.ListNodes(ctx, hypergraph.NodeFilter{
+	// Search memory by content, one keyword at a time
+	opts := hypergraph.SearchOptions{
 		Types: []hypergraph.NodeType{
 			hypergraph.NodeTypeFact,
 			hypergraph.NodeTypeExperience,
 		},
-		Limit: c.config.MemoryQueryLimit,
-	})
-	if err != nil {
-		return "", 0, fmt.Errorf("memory query: %w", err)
+		MinConfidence: c.config.MemoryMinConfidence,
 	}
-
-	// Filter by content relevance
-	var relevant []*hypergraph.Node
-	queryLower := strings.ToLower(query)
-	for _, node := range nodes {
-		if strings.Contains(strings.ToLower(node.Content), queryLower) {
-			relevant = append(relevant, node)
+	hits := make(map[string]int)
+	byID := make(map[string]*hypergraph.Node)
+	for word := range keywords {
+		results, err := c.store.SearchByContent(ctx, word, opts)
+		if err != nil {
+			return "", 0, fmt.Errorf("memory query: %w", err)
+		}
+		for _, r := range results {
+			hits[r.Node.ID]++
+			byID[r.Node.ID] = r.Node
 		}
 	}
 
+	type rankedMemory struct {
+		node  *hypergraph.Node
+		score float64
+	}
+	var relevant []rankedMemory
+	for id, n := range hits {
+		if 2*n < len(keywords) {
+			continue
+		}
+		node := byID[id]
+		relevance := float64(n) / float64(len(keywords))
+		relevant = append(relevant, rankedMemory{node: node, score: node.Confidence * relevance})
+	}
 	if len(relevant) == 0 {
 		return "No relevant memory found.", 0, nil
 	}
+	sort.Slice(relevant, func(i, j int) bool {
+		if relevant[i].score != relevant[j].score {
+			return relevant[i].score > relevant[j].score
+		}
+		return relevant[i].node.ID < relevant[j].node.ID
+	})
+	if limit := c.config.MemoryQueryLimit; limit > 0 && len(relevant) > limit {
+		relevant = relevant[:limit]
+	}
 
 	// Format results
 	var sb strings.Builder
 	sb.WriteString(fmt.Sprintf("Found %d relevant memories:\n\n", len(relevant)))
-	for _, node := range relevant {
-		sb.WriteString(fmt.Sprintf("- [%s] %s\n", node.Type, truncate(node.Content, 200)))
+	for _, m := range relevant {
+		if m.node.Confidence < uncertainMemoryConfidence {
+			sb.WriteString(fmt.Sprintf("- [%s, uncertain: confidence %.2f] %s\n", m.node.Type, m.node.Confidence, truncate(m.node.Content, 200)))
+		} else {
+			sb.WriteString(fmt.Sprintf("- [%s] %s\n", m.node.Type, truncate(m.node.Content, 200)))
+		}
 	}
 
 	// Increment access counts
-	for _, node := range relevant {
-		c.store.IncrementAccess(ctx, node.ID)
+	for _, m := range relevant {
+		c.store.IncrementAccess(ctx, m.node.ID)
 	}
 
 	return sb.String(), estimateTokens(sb.String()), nil
@@ -660,17 +1198,22 @@ func (c *Core) executeSynthesize(ctx context.Context, state meta.State) (string,
 	// Convert partial results to SubCallResult format
 	var results []synthesize.SubCallResult
 	for i, partial := range state.PartialResults {
-		results = append(results, synthesize.SubCallResult{
+		result := synthesize.SubCallResult{
 			ID:       fmt.Sprintf("partial-%d", i),
 			Name:     fmt.Sprintf("Part %d", i+1),
 			Response: partial,
-		})
+		}
+		if i < len(state.PartialConfidences) {
+			result.Confidence = state.PartialConfidences[i]
+		}
+		results = append(results, result)
 	}
 
 	synthesized, err := c.synthesizer.Synthesize(ctx, state.Task, results)
 	if err != nil {
 		return "", 0, fmt.Errorf("synthesize: %w", err)
 	}
+	recordConfidence(ctx, synthesized.Confidence)
 
 	return synthesized.Response, synthesized.TotalTokensUsed, nil
 }
@@ -693,11 +1236,13 @@ func (c *Core) executeREPL(ctx context.Context, state meta.State, decision *meta
 			defer cancel()
 			if err := c.replManager.Start(startCtx); err != nil {
 				slog.Warn("REPL not available, falling back to DIRECT", "error", err)
+				recordDegraded(ctx)
 				return c.executeDirect(ctx, state)
 			}
 		} else {
 			// No REPL manager at all, fallback to DIRECT
 			slog.Warn("REPL manager not configured, falling back to DIRECT")
+			recordDegraded(ctx)
 			return c.executeDirect(ctx, state)
 		}
 	}
@@ -715,6 +1260,8 @@ func (c *Core) executeREPL(ctx context.Context, state meta.State, decision *meta
 	if result.Error != "" {
 		response.WriteString("\nError: ")
 		response.WriteString(result.Error)
+	} else {
+		recordLeafConfidence(ctx, result.Output)
 	}
 
 	// Store execution as experience for learning
@@ -726,6 +1273,51 @@ func (c *Core) executeREPL(ctx context.Context, state meta.State, decision *meta
 	return response.String(), tokens, nil
 }
 
+// executeToT explores the task with Tree of Thoughts and answers with the
+// reasoning path that reached a solution.
+func (c *Core) executeToT(ctx context.Context, state meta.State, decision *meta.Decision) (string, int, error) {
+	evaluator := c.totEvaluator
+	if evaluator == nil {
+		evaluator = tot.NewLLMEvaluator(c.mainClient)
+	}
+	generator := c.totGenerator
+	if generator == nil {
+		generator = tot.NewLLMGenerator(c.mainClient)
+	}
+
+	cfg := tot.DefaultToTConfig()
+	if s := decision.Params.ToTStrategy; s != "" {
+		cfg.Strategy = tot.Strategy(s)
+	}
+
+	tree := tot.NewThoughtTree(cfg, evaluator, generator)
+	tree.Initialize(state.Task)
+	result, err := tree.Explore(ctx, state.Task)
+	if err != nil {
+		return "", 0, fmt.Errorf("tree of thoughts: %w", err)
+	}
+	if result.Solution == nil {
+		return "", 0, fmt.Errorf("tree of thoughts (%s after %d nodes): %w",
+			result.TerminatedBy, result.NodesExplored, tot.ErrNoSolution)
+	}
+
+	var response strings.Builder
+	response.WriteString("Reasoning path:\n")
+	for i, node := range result.BestPath[1:] {
+		fmt.Fprintf(&response, "%d. %s\n", i+1, node.Thought)
+	}
+	response.WriteString("\nSolution: ")
+	response.WriteString(result.Solution.Thought)
+	recordConfidence(ctx, result.Solution.Confidence)
+
+	// Rough estimate: every generator and evaluator call carries the task.
+	metrics := tree.Metrics()
+	calls := int(metrics.Expansions + metrics.Evaluations)
+	totalTokens := calls*estimateTokens(state.Task) + estimateTokens(response.String())
+
+	return response.String(), totalTokens, nil
+}
+
 // storeREPLExecution saves a REPL execution as an experience node.
 func (c *Core) storeREPLExecution(ctx context.Context, code string, result *repl.ExecuteResult) {
 	node := hypergraph.NewNode(hypergraph.NodeTypeExperience, truncate(code, 200))
@@ -802,28 +1394,14 @@ func (c *Core) queryMemoryContext(ctx context.Context, task string) ([]string, e
 	return hints, nil
 }
 
-// storeExecution saves the execution as a decision node.
-func (c *Core) storeExecution(ctx context.Context, task, response string, tokens int) error {
-	node := hypergraph.NewNode(hypergraph.NodeTypeDecision, task)
-	node.Subtype = "rlm_execution"
-
-	metadata := map[string]any{
-		"response": truncate(response, 500),
-		"tokens":   tokens,
-	}
-	metadataJSON, _ := json.Marshal(metadata)
-	node.Metadata = metadataJSON
-
-	return c.store.CreateNode(ctx, node)
-}
-
 // coreOrchestrator adapts Core to the async.Orchestrator interface.
 type coreOrchestrator struct {
 	c *Core
 }
 
 func (o *coreOrchestrator) Orchestrate(ctx context.Context, op *async.Operation) (string, int, error) {
-	return o.c.orchestrate(ctx, op.State, op.ParentID)
+	ctx = withOpConfidenceRecorder(ctx, op.ID)
+	return o.c.runSubtask(ctx, op.State, op.ParentID)
 }
 
 // Helper functions
@@ -0,0 +1,302 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/rand/recurse/internal/rlm/meta"
+)
+
+// CostCeiling is a hard cost limit for a single execution. It is separate
+// from session budgets: hitting it aborts only the execution that spent it.
+type CostCeiling struct {
+	// MaxCost is the ceiling in USD. Zero leaves cost unbounded.
+	MaxCost float64
+
+	// MaxCalls caps the LLM calls, whatever their size. Zero leaves the
+	// number of calls unbounded.
+	MaxCalls int
+
+	// InputCostPer1M and OutputCostPer1M price main-model calls, in USD per
+	// million tokens. Sub-calls are priced by the model they are routed to.
+	InputCostPer1M  float64
+	OutputCostPer1M float64
+}
+
+// Enabled reports whether the ceiling limits anything.
+func (c CostCeiling) Enabled() bool {
+	return c.MaxCost > 0 || c.MaxCalls > 0
+}
+
+// Cost prices a main-model call.
+func (c CostCeiling) Cost(inputTokens, outputTokens int) float64 {
+	return float64(inputTokens)*c.InputCostPer1M/1_000_000 +
+		float64(outputTokens)*c.OutputCostPer1M/1_000_000
+}
+
+// CostCeilingError reports an execution aborted at its cost ceiling. It
+// matches ErrBudgetExceeded.
+type CostCeilingError struct {
+	Ceiling float64
+	Spent   float64
+}
+
+func (e *CostCeilingError) Error() string {
+	return fmt.Sprintf("cost ceiling $%.4f reached (spent $%.4f)", e.Ceiling, e.Spent)
+}
+
+// Is reports whether target is ErrBudgetExceeded.
+func (e *CostCeilingError) Is(target error) bool {
+	return target == ErrBudgetExceeded
+}
+
+// CallLimitError reports an execution aborted at its LLM call limit. It
+// matches ErrBudgetExceeded.
+type CallLimitError struct {
+	Limit int
+	Calls int
+}
+
+func (e *CallLimitError) Error() string {
+	return fmt.Sprintf("LLM call limit %d reached (made %d calls)", e.Limit, e.Calls)
+}
+
+// Is reports whether target is ErrBudgetExceeded.
+func (e *CallLimitError) Is(target error) bool {
+	return target == ErrBudgetExceeded
+}
+
+type costGuardKey struct{}
+
+// CostGuard enforces a CostCeiling across every LLM call of one execution,
+// including sub-calls made on its behalf. It travels in the context; all
+// methods are safe for concurrent use and no-ops on a nil guard.
+type CostGuard struct {
+	ceiling CostCeiling
+	parent  *CostGuard // also charged and checked, e.g. a batch-wide guard
+
+	mu      sync.Mutex
+	spent   float64
+	calls   int
+	err     error
+	partial string
+}
+
+// NewCostGuard creates a guard with nothing spent.
+func NewCostGuard(ceiling CostCeiling) *CostGuard {
+	return &CostGuard{ceiling: ceiling}
+}
+
+// Child creates a guard for one execution under g: it enforces its own
+// ceiling (if enabled) as well as g's, and charges both. A child without its
+// own prices uses g's. On a nil guard Child is NewCostGuard(ceiling), or nil
+// if the ceiling is disabled.
+func (g *CostGuard) Child(ceiling CostCeiling) *CostGuard {
+	if g == nil {
+		if !ceiling.Enabled() {
+			return nil
+		}
+		return NewCostGuard(ceiling)
+	}
+	if ceiling.InputCostPer1M == 0 && ceiling.OutputCostPer1M == 0 {
+		ceiling.InputCostPer1M = g.ceiling.InputCostPer1M
+		ceiling.OutputCostPer1M = g.ceiling.OutputCostPer1M
+	}
+	return &CostGuard{ceiling: ceiling, parent: g}
+}
+
+// WithCostGuard returns a context carrying guard.
+func WithCostGuard(ctx context.Context, guard *CostGuard) context.Context {
+	return context.WithValue(ctx, costGuardKey{}, guard)
+}
+
+// CostGuardFrom returns the guard carried by ctx, or nil.
+func CostGuardFrom(ctx context.Context) *CostGuard {
+	guard, _ := ctx.Value(costGuardKey{}).(*CostGuard)
+	return guard
+}
+
+// Ceiling returns the guard's ceiling.
+func (g *CostGuard) Ceiling() CostCeiling {
+	if g == nil {
+		return CostCeiling{}
+	}
+	return g.ceiling
+}
+
+// Allow checks whether a call estimated to cost estimate may start, and
+// counts it if so. Once the ceiling is reached every later call is refused
+// with the same error.
+func (g *CostGuard) Allow(estimate float64) error {
+	if g == nil {
+		return nil
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.err == nil && g.ceiling.MaxCost > 0 && (g.spent >= g.ceiling.MaxCost || g.spent+estimate > g.ceiling.MaxCost) {
+		g.err = &CostCeilingError{Ceiling: g.ceiling.MaxCost, Spent: g.spent}
+	}
+	if g.err == nil && g.ceiling.MaxCalls > 0 && g.calls >= g.ceiling.MaxCalls {
+		g.err = &CallLimitError{Limit: g.ceiling.MaxCalls, Calls: g.calls}
+	}
+	if g.err == nil {
+		g.err = g.parent.Allow(estimate)
+	}
+	if g.err == nil {
+		g.calls++
+	}
+	return g.err
+}
+
+// Charge records the cost of a completed call.
+func (g *CostGuard) Charge(cost float64) {
+	if g == nil {
+		return
+	}
+	g.mu.Lock()
+	g.spent += cost
+	g.mu.Unlock()
+	g.parent.Charge(cost)
+}
+
+// Spent returns the cost charged so far.
+func (g *CostGuard) Spent() float64 {
+	if g == nil {
+		return 0
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.spent
+}
+
+// Calls returns the number of calls allowed so far.
+func (g *CostGuard) Calls() int {
+	if g == nil {
+		return 0
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.calls
+}
+
+// costWarningFraction is the share of a ceiling past which a guard is
+// near it, matching the budget manager's default warning threshold.
+const costWarningFraction = 0.8
+
+// NearCeiling reports whether the execution has used at least 80% of its
+// cost or call ceiling, here or in a parent guard: a budget warning.
+func (g *CostGuard) NearCeiling() bool {
+	if g == nil {
+		return false
+	}
+	g.mu.Lock()
+	near := g.err != nil ||
+		(g.ceiling.MaxCost > 0 && g.spent >= costWarningFraction*g.ceiling.MaxCost) ||
+		(g.ceiling.MaxCalls > 0 && float64(g.calls) >= costWarningFraction*float64(g.ceiling.MaxCalls))
+	g.mu.Unlock()
+	return near || g.parent.NearCeiling()
+}
+
+// Err returns the ceiling error once a call has been refused, here or by a
+// parent guard, else nil.
+func (g *CostGuard) Err() error {
+	if g == nil {
+		return nil
+	}
+	g.mu.Lock()
+	err := g.err
+	g.mu.Unlock()
+	if err == nil {
+		err = g.parent.Err()
+	}
+	return err
+}
+
+// RecordPartial remembers the latest usable answer, returned if the
+// execution is aborted before it completes.
+func (g *CostGuard) RecordPartial(answer string) {
+	if g == nil || answer == "" {
+		return
+	}
+	g.mu.Lock()
+	g.partial = answer
+	g.mu.Unlock()
+}
+
+// Partial returns the latest answer recorded with RecordPartial.
+func (g *CostGuard) Partial() string {
+	if g == nil {
+		return ""
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.partial
+}
+
+// costGuardedClient checks and charges the context's CostGuard around each
+// call to the main model. Without a guard in the context it is a plain
+// pass-through.
+type costGuardedClient struct {
+	client meta.LLMClient
+}
+
+func (c *costGuardedClient) Complete(ctx context.Context, prompt string, maxTokens int) (string, error) {
+	guard := CostGuardFrom(ctx)
+	if guard == nil {
+		return c.client.Complete(ctx, prompt, maxTokens)
+	}
+
+	inputTokens := estimateTokens(prompt)
+	if err := guard.Allow(guard.ceiling.Cost(inputTokens, 0)); err != nil {
+		return "", err
+	}
+	callCtx, rec := meta.TrackUsage(ctx)
+	response, err := c.client.Complete(callCtx, prompt, maxTokens)
+	if err != nil {
+		return "", err
+	}
+	// Charge what the provider reported, else the estimate
+	if usage, calls := rec.Reported(); calls > 0 {
+		guard.Charge(guard.ceiling.Cost(usage.PromptTokens, usage.CompletionTokens))
+	} else {
+		guard.Charge(guard.ceiling.Cost(inputTokens, estimateTokens(response)))
+	}
+	return response, nil
+}
+
+// Capabilities implements meta.CapabilityReporter with the wrapped
+// client's limits.
+func (c *costGuardedClient) Capabilities() meta.Capabilities {
+	return meta.CapabilitiesOf(c.client).CompletionOnly()
+}
+
+// callGuardedClient checks the context's CostGuard before each call, so
+// calls made outside the main model's path, e.g. by the meta-controller,
+// count against the execution's call limit. They are not priced.
+type callGuardedClient struct {
+	client meta.LLMClient
+}
+
+// GuardCalls wraps client so that each call is allowed, and counted, by the
+// CostGuard in its context. Nil stays nil.
+func GuardCalls(client meta.LLMClient) meta.LLMClient {
+	if client == nil {
+		return nil
+	}
+	return &callGuardedClient{client: client}
+}
+
+func (c *callGuardedClient) Complete(ctx context.Context, prompt string, maxTokens int) (string, error) {
+	if err := CostGuardFrom(ctx).Allow(0); err != nil {
+		return "", err
+	}
+	return c.client.Complete(ctx, prompt, maxTokens)
+}
+
+// Capabilities implements meta.CapabilityReporter with the wrapped
+// client's limits.
+func (c *callGuardedClient) Capabilities() meta.Capabilities {
+	return meta.CapabilitiesOf(c.client).CompletionOnly()
+}
@@ -0,0 +1,264 @@
+package orchestrator
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// SourceProvenance records where a piece of externalized context came from.
+// When several sources are merged into one variable, each contributes an entry.
+type SourceProvenance struct {
+	// Name is the variable name the source was requested under.
+	Name string `json:"name"`
+
+	// Type is the original context type.
+	Type ContextType `json:"type"`
+
+	// Source is the origin (file path, query, etc.) if known.
+	Source string `json:"source,omitempty"`
+
+	// Hash is the SHA-256 of the source content (hex, truncated).
+	Hash string `json:"hash"`
+}
+
+// DedupConfig controls context-source deduplication.
+type DedupConfig struct {
+	// Enabled turns deduplication on.
+	Enabled bool
+
+	// MinOverlapLines is the minimum number of shared consecutive lines
+	// required before two sources are considered overlapping.
+	MinOverlapLines int
+
+	// MinOverlapRatio is the fraction of the smaller source's lines that
+	// must be shared before the sources are merged.
+	MinOverlapRatio float64
+
+	// MaxCompareLines bounds the overlap search; sources with more lines
+	// are only deduplicated by exact hash.
+	MaxCompareLines int
+}
+
+// DefaultDedupConfig returns sensible defaults for deduplication.
+func DefaultDedupConfig() DedupConfig {
+	return DedupConfig{
+		Enabled:         true,
+		MinOverlapLines: 3,
+		MinOverlapRatio: 0.5,
+		MaxCompareLines: 5000,
+	}
+}
+
+// DedupedSource is a context source after deduplication, along with the
+// provenance of every input source merged into it.
+type DedupedSource struct {
+	ContextSource
+
+	// Aliases are the names of merged-away sources. Loaders bind each alias
+	// to the same value so references to any original name still resolve.
+	Aliases []string
+
+	Provenance []SourceProvenance
+}
+
+// DeduplicateSources collapses identical and overlapping sources.
+// Exact duplicates (same content hash) are merged first; then any source
+// fully contained in another, or sharing an aligned prefix/suffix span with
+// it, is merged into a single source. The containing source keeps its name
+// and the others become aliases. Order of first appearance is preserved.
+func DeduplicateSources(sources []ContextSource, cfg DedupConfig) []DedupedSource {
+	result := make([]DedupedSource, 0, len(sources))
+	if !cfg.Enabled {
+		for _, src := range sources {
+			result = append(result, DedupedSource{
+				ContextSource: src,
+				Provenance:    []SourceProvenance{provenanceFor(src)},
+			})
+		}
+		return result
+	}
+
+	byHash := make(map[string]int)
+	for _, src := range sources {
+		prov := provenanceFor(src)
+		if idx, ok := byHash[prov.Hash]; ok {
+			result[idx].Provenance = append(result[idx].Provenance, prov)
+			result[idx].Aliases = appendAlias(result[idx].Aliases, result[idx].Name, src.Name)
+			continue
+		}
+		byHash[prov.Hash] = len(result)
+		result = append(result, DedupedSource{
+			ContextSource: src,
+			Provenance:    []SourceProvenance{prov},
+		})
+	}
+
+	// Merge overlapping sources. A merge changes result[i], so its
+	// candidates are rescanned, but earlier entries are not revisited.
+	for i := 0; i < len(result); i++ {
+		for j := i + 1; j < len(result); j++ {
+			content, keep, ok := mergeOverlap(result[i].Content, result[j].Content, cfg)
+			if !ok {
+				continue
+			}
+			primary, other := result[i], result[j]
+			if keep == keepSecond {
+				primary, other = other, primary
+			}
+			primary.Content = content
+			primary.Aliases = appendAlias(primary.Aliases, primary.Name, other.Name)
+			for _, alias := range other.Aliases {
+				primary.Aliases = appendAlias(primary.Aliases, primary.Name, alias)
+			}
+			primary.Provenance = append(append([]SourceProvenance{}, result[i].Provenance...), result[j].Provenance...)
+			result[i] = primary
+			result = append(result[:j], result[j+1:]...)
+			j = i
+		}
+	}
+
+	for i := range result {
+		if len(result[i].Provenance) > 1 {
+			result[i].Metadata = mergedMetadata(result[i].Metadata, result[i].Provenance)
+		}
+	}
+
+	return result
+}
+
+// mergeSide reports which input's name and metadata a merge keeps.
+type mergeSide int
+
+const (
+	keepFirst mergeSide = iota
+	keepSecond
+)
+
+// mergeOverlap returns the combined content of a and b if they overlap
+// sufficiently on an aligned span (containment, or suffix of one equal to
+// the prefix of the other). The returned side is the one that contains the
+// other; for a prefix/suffix join it is always the first.
+func mergeOverlap(a, b string, cfg DedupConfig) (string, mergeSide, bool) {
+	if a == "" || b == "" {
+		return "", keepFirst, false
+	}
+
+	// A trailing newline would otherwise leave an empty last line that
+	// breaks suffix alignment; strip it here and restore it on join.
+	trailing := strings.HasSuffix(a, "\n") || strings.HasSuffix(b, "\n")
+	la := strings.Split(strings.TrimSuffix(a, "\n"), "\n")
+	lb := strings.Split(strings.TrimSuffix(b, "\n"), "\n")
+	if len(lb) >= cfg.MinOverlapLines && strings.Contains(a, b) {
+		return a, keepFirst, true
+	}
+	if len(la) >= cfg.MinOverlapLines && strings.Contains(b, a) {
+		return b, keepSecond, true
+	}
+	if cfg.MaxCompareLines > 0 && (len(la) > cfg.MaxCompareLines || len(lb) > cfg.MaxCompareLines) {
+		return "", keepFirst, false
+	}
+
+	// An aligned join requires the first line of one source to appear in
+	// the other; skip the quadratic span search when neither does.
+	if !containsLine(la, lb[0]) && !containsLine(lb, la[0]) {
+		return "", keepFirst, false
+	}
+
+	startA, startB, length := longestCommonSpan(la, lb)
+	if length < cfg.MinOverlapLines {
+		return "", keepFirst, false
+	}
+	smaller := min(len(la), len(lb))
+	if float64(length) < cfg.MinOverlapRatio*float64(smaller) {
+		return "", keepFirst, false
+	}
+
+	var joined []string
+	switch {
+	case startA+length == len(la) && startB == 0:
+		// Tail of a continues into b.
+		joined = append(la[:len(la):len(la)], lb[length:]...)
+	case startB+length == len(lb) && startA == 0:
+		// Tail of b continues into a.
+		joined = append(lb[:len(lb):len(lb)], la[length:]...)
+	default:
+		return "", keepFirst, false
+	}
+
+	content := strings.Join(joined, "\n")
+	if trailing {
+		content += "\n"
+	}
+	return content, keepFirst, true
+}
+
+func containsLine(lines []string, line string) bool {
+	for _, l := range lines {
+		if l == line {
+			return true
+		}
+	}
+	return false
+}
+
+// appendAlias adds name to aliases unless it is the primary name or
+// already present.
+func appendAlias(aliases []string, primary, name string) []string {
+	if name == "" || name == primary {
+		return aliases
+	}
+	for _, a := range aliases {
+		if a == name {
+			return aliases
+		}
+	}
+	return append(aliases, name)
+}
+
+// longestCommonSpan finds the longest run of consecutive identical lines
+// shared by a and b, returning its start in each and its length.
+func longestCommonSpan(a, b []string) (startA, startB, length int) {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			if a[i-1] == b[j-1] {
+				curr[j] = prev[j-1] + 1
+				if curr[j] > length {
+					length = curr[j]
+					startA = i - length
+					startB = j - length
+				}
+			} else {
+				curr[j] = 0
+			}
+		}
+		prev, curr = curr, prev
+	}
+	return startA, startB, length
+}
+
+func provenanceFor(src ContextSource) SourceProvenance {
+	sum := sha256.Sum256([]byte(src.Content))
+	prov := SourceProvenance{
+		Name: src.Name,
+		Type: src.Type,
+		Hash: hex.EncodeToString(sum[:])[:16],
+	}
+	if source, ok := src.Metadata["source"].(string); ok {
+		prov.Source = source
+	}
+	return prov
+}
+
+// mergedMetadata copies the primary source's metadata and records the
+// combined provenance under the "provenance" key.
+func mergedMetadata(base map[string]any, provenance []SourceProvenance) map[string]any {
+	md := make(map[string]any, len(base)+1)
+	for k, v := range base {
+		md[k] = v
+	}
+	md["provenance"] = provenance
+	return md
+}
@@ -0,0 +1,87 @@
+package orchestrator
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Sentinel errors for RLM failure paths. Callers should match them with
+// errors.Is rather than inspecting error strings; ClassifyError maps each
+// one to a recovery category.
+var (
+	// ErrServiceNotRunning is returned when a stopped service is used.
+	ErrServiceNotRunning = errors.New("service not running")
+
+	// ErrServiceRunning is returned when a running service is started again.
+	ErrServiceRunning = errors.New("service already running")
+
+	// ErrServiceStopping is returned by an execution cancelled because the
+	// service stopped before it finished.
+	ErrServiceStopping = errors.New("service stopping")
+
+	// ErrNotConfigured is returned when a required component is missing.
+	ErrNotConfigured = errors.New("not configured")
+
+	// ErrREPLUnavailable is returned when an operation needs a REPL that
+	// is not available.
+	ErrREPLUnavailable = errors.New("REPL not available")
+
+	// ErrInvalidMode is returned when an operation is called in the wrong
+	// execution mode.
+	ErrInvalidMode = errors.New("invalid execution mode")
+
+	// ErrUnknownAction is returned for meta-controller actions the
+	// orchestrator cannot execute.
+	ErrUnknownAction = errors.New("unknown action")
+
+	// ErrBudgetExceeded is returned when the token or cost budget runs out.
+	ErrBudgetExceeded = errors.New("budget exceeded")
+
+	// ErrMaxIterations is returned when the RLM loop ends without FINAL().
+	ErrMaxIterations = errors.New("max iterations reached")
+
+	// ErrLLMCall is wrapped around failed LLM client calls.
+	ErrLLMCall = errors.New("LLM call failed")
+
+	// ErrActionTimeout is returned when an action exceeds its per-action
+	// timeout; see ActionTimeoutError.
+	ErrActionTimeout = errors.New("action timed out")
+)
+
+// MaxIterationsError reports an RLM loop that hit its iteration limit
+// without producing a FINAL() answer. It matches ErrMaxIterations.
+type MaxIterationsError struct {
+	Iterations int
+}
+
+func (e *MaxIterationsError) Error() string {
+	return fmt.Sprintf("max iterations (%d) reached without FINAL() call", e.Iterations)
+}
+
+// Is reports whether target is ErrMaxIterations.
+func (e *MaxIterationsError) Is(target error) bool {
+	return target == ErrMaxIterations
+}
+
+// classifySentinel maps the package's sentinel errors to a category. The
+// second result is false for errors outside the taxonomy. ErrLLMCall is
+// left out: a failed call is classified by its cause, see ClassifyError.
+func classifySentinel(err error) (ErrorCategory, bool) {
+	switch {
+	case errors.Is(err, ErrBudgetExceeded):
+		return ErrorCategoryResource, true
+	case errors.Is(err, ErrActionTimeout):
+		return ErrorCategoryTimeout, true
+	case errors.Is(err, ErrREPLUnavailable),
+		errors.Is(err, ErrMaxIterations),
+		errors.Is(err, ErrUnknownAction):
+		return ErrorCategoryDegradable, true
+	case errors.Is(err, ErrServiceNotRunning),
+		errors.Is(err, ErrServiceRunning),
+		errors.Is(err, ErrServiceStopping),
+		errors.Is(err, ErrNotConfigured),
+		errors.Is(err, ErrInvalidMode):
+		return ErrorCategoryTerminal, true
+	}
+	return 0, false
+}
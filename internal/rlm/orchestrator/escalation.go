@@ -0,0 +1,261 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/rand/recurse/internal/rlm/hallucination"
+	"github.com/rand/recurse/internal/rlm/meta"
+)
+
+// EscalationPolicy re-executes a task on a more capable model tier when its
+// answer comes back with low confidence, returning the more confident of the
+// two answers. A task is escalated at most once, and only with a main
+// client that reports meta.Capabilities.TierRouting: re-running on the same
+// model would cost without helping.
+type EscalationPolicy struct {
+	// MinConfidence is the confidence (0.0 to 1.0) below which an answer is
+	// escalated. Zero disables escalation.
+	MinConfidence float64
+
+	// Tier is the lowest tier the re-execution is routed to, via
+	// meta.WithMinTier. TierFast, the zero value, means TierPowerful:
+	// forcing the cheapest tier would escalate nothing.
+	Tier meta.ModelTier
+}
+
+// Enabled reports whether the policy escalates anything.
+func (p EscalationPolicy) Enabled() bool {
+	return p.MinConfidence > 0
+}
+
+func (p EscalationPolicy) tier() meta.ModelTier {
+	if p.Tier == meta.TierFast {
+		return meta.TierPowerful
+	}
+	return p.Tier
+}
+
+// AnswerScorer scores the confidence (0.0 to 1.0) of an answer to a task.
+type AnswerScorer interface {
+	ScoreAnswer(ctx context.Context, task, answer string) (float64, error)
+}
+
+// SetAnswerScorer sets the scorer the escalation policy judges answers by.
+// Without one, answers are judged by their synthesis confidence, and
+// unsynthesized answers are never escalated.
+func (c *Core) SetAnswerScorer(scorer AnswerScorer) {
+	c.answerScorer = scorer
+}
+
+// SetAssuranceScorer sets the scorer for executions whose profile verifies
+// everything (VerifyAll), such as ModeHighAssurance, when no answer scorer
+// is set. It lets them be escalated without scoring every execution.
+func (c *Core) SetAssuranceScorer(scorer AnswerScorer) {
+	c.assuranceScorer = scorer
+}
+
+// Escalation records an escalation decision and the attempts it covers.
+type Escalation struct {
+	// Escalated is true when the task was re-executed on a higher tier.
+	Escalated bool `json:"escalated"`
+
+	// Reason explains the decision.
+	Reason string `json:"reason"`
+
+	// Threshold is the policy's MinConfidence.
+	Threshold float64 `json:"threshold"`
+
+	// Tier is the tier the re-execution was routed to (or would have been).
+	Tier string `json:"tier"`
+
+	// Attempts are the executions, original first. Each has its own tokens
+	// and cost; the returned result totals them.
+	Attempts []*ExecutionResult `json:"attempts"`
+
+	// Chosen indexes the attempt whose answer was returned.
+	Chosen int `json:"chosen"`
+}
+
+// escalate applies the escalation policy to a successful first attempt.
+func (c *Core) escalate(ctx context.Context, task string, guard *CostGuard, policy EscalationPolicy, first *ExecutionResult) *ExecutionResult {
+	tier := policy.tier()
+	decision := &Escalation{
+		Threshold: policy.MinConfidence,
+		Tier:      tier.String(),
+		Attempts:  []*ExecutionResult{first},
+	}
+	withDecision := func(result *ExecutionResult) *ExecutionResult {
+		final := *result
+		final.Escalation = decision
+		return &final
+	}
+
+	confidence, scored := c.scoreAnswer(ctx, task, first)
+	if scored {
+		first.Confidence = confidence
+	}
+	switch {
+	case !scored:
+		decision.Reason = "answer has no confidence score"
+	case confidence >= policy.MinConfidence:
+		decision.Reason = fmt.Sprintf("confidence %.2f meets threshold %.2f", confidence, policy.MinConfidence)
+	case escalatedContext(ctx, tier):
+		decision.Reason = "already running at the escalation tier"
+	case !c.routesByTier():
+		decision.Reason = fmt.Sprintf("confidence %.2f below threshold %.2f, but the client answers with one model whatever the tier",
+			confidence, policy.MinConfidence)
+	case !c.canAffordEscalation(guard, first):
+		decision.Reason = fmt.Sprintf("confidence %.2f below threshold %.2f, but budget does not allow a re-run",
+			confidence, policy.MinConfidence)
+	default:
+		decision.Escalated = true
+		decision.Reason = fmt.Sprintf("confidence %.2f below threshold %.2f", confidence, policy.MinConfidence)
+	}
+	c.traceEscalation(decision)
+	if !decision.Escalated {
+		return withDecision(first)
+	}
+
+	slog.Info("Escalating low-confidence answer",
+		"confidence", confidence,
+		"threshold", policy.MinConfidence,
+		"tier", tier.String())
+
+	second, err := c.execute(meta.WithMinTier(ctx, tier), task, guard)
+	decision.Attempts = append(decision.Attempts, second)
+	if err != nil {
+		// Keep the complete first answer over a failed re-run
+		slog.Warn("Escalated execution failed, keeping original answer", "error", err)
+		return withDecision(totalAttempts(first, decision.Attempts))
+	}
+	if confidence, ok := c.scoreAnswer(ctx, task, second); ok {
+		second.Confidence = confidence
+	}
+
+	chosen := first
+	if second.Confidence > first.Confidence {
+		chosen = second
+		decision.Chosen = 1
+	}
+	return withDecision(totalAttempts(chosen, decision.Attempts))
+}
+
+// scoreAnswer returns the answer's confidence from the scorer, falling back
+// to its synthesis confidence. It reports false when neither is available.
+func (c *Core) scoreAnswer(ctx context.Context, task string, result *ExecutionResult) (float64, bool) {
+	scorer := c.answerScorer
+	if scorer == nil && ProfileSettingsFrom(ctx).Verification == VerifyAll {
+		scorer = c.assuranceScorer
+	}
+	if scorer != nil {
+		confidence, err := scorer.ScoreAnswer(ctx, task, result.Response)
+		if err == nil {
+			return confidence, true
+		}
+		slog.Warn("Answer scoring failed", "error", err)
+	}
+	return result.Confidence, result.Confidence > 0
+}
+
+// escalatedContext reports whether ctx is already routed to tier or above,
+// i.e. the execution is itself an escalation.
+func escalatedContext(ctx context.Context, tier meta.ModelTier) bool {
+	minTier, ok := meta.MinTierFrom(ctx)
+	return ok && minTier >= tier
+}
+
+// routesByTier reports whether the main client routes completions by tier,
+// so a re-run at a higher tier reaches a different model.
+func (c *Core) routesByTier() bool {
+	return c.tierClient != nil && meta.CapabilitiesOf(c.tierClient.client).TierRouting
+}
+
+// canAffordEscalation reports whether the token budget, cost ceiling and
+// call limit leave room for a re-run costing at least as much as the first
+// attempt.
+func (c *Core) canAffordEscalation(guard *CostGuard, first *ExecutionResult) bool {
+	if c.config.MaxTokenBudget > 0 && 2*first.TotalTokens > c.config.MaxTokenBudget {
+		return false
+	}
+	if guard.Err() != nil {
+		return false
+	}
+	ceiling := guard.Ceiling()
+	if ceiling.MaxCost > 0 && ceiling.MaxCost-guard.Spent() < first.Cost {
+		return false
+	}
+	if ceiling.MaxCalls > 0 && ceiling.MaxCalls-guard.Calls() < first.LLMCalls {
+		return false
+	}
+	return true
+}
+
+// totalAttempts returns a copy of chosen whose tokens, cost, calls,
+// duration, and models cover every attempt.
+func totalAttempts(chosen *ExecutionResult, attempts []*ExecutionResult) *ExecutionResult {
+	total := *chosen
+	total.TotalTokens, total.InputTokens, total.OutputTokens, total.EstimatedTokens = 0, 0, 0, 0
+	total.Cost, total.LLMCalls, total.Duration = 0, 0, 0
+	total.Models = nil
+	for _, attempt := range attempts {
+		for _, model := range attempt.Models {
+			if !slices.Contains(total.Models, model) {
+				total.Models = append(total.Models, model)
+			}
+		}
+		total.TotalTokens += attempt.TotalTokens
+		total.InputTokens += attempt.InputTokens
+		total.OutputTokens += attempt.OutputTokens
+		total.EstimatedTokens += attempt.EstimatedTokens
+		total.Cost += attempt.Cost
+		total.LLMCalls += attempt.LLMCalls
+		total.Duration += attempt.Duration
+	}
+	return &total
+}
+
+// traceEscalation records the escalation decision as a trace event.
+func (c *Core) traceEscalation(decision *Escalation) {
+	if c.tracer == nil || !c.config.TraceEnabled {
+		return
+	}
+	status := "skipped"
+	if decision.Escalated {
+		status = "completed"
+	}
+	c.tracer.RecordEvent(TraceEvent{
+		ID:        generateID(),
+		Type:      "escalation",
+		Action:    "Escalate to " + decision.Tier + " tier",
+		Details:   decision.Reason,
+		Timestamp: time.Now(),
+		Status:    status,
+	})
+}
+
+// verifierScorer scores answers by their hallucination risk.
+type verifierScorer struct {
+	verifier *hallucination.OutputVerifier
+}
+
+// NewVerifierScorer returns an AnswerScorer that verifies an answer's claims
+// against the task and scores it 1 - overall hallucination risk.
+func NewVerifierScorer(verifier *hallucination.OutputVerifier) AnswerScorer {
+	return &verifierScorer{verifier: verifier}
+}
+
+// ScoreAnswer implements AnswerScorer.
+func (s *verifierScorer) ScoreAnswer(ctx context.Context, task, answer string) (float64, error) {
+	result, err := s.verifier.VerifyOutput(ctx, answer, task)
+	if err != nil {
+		return 0, err
+	}
+	if result.Skipped {
+		return 0, fmt.Errorf("verification skipped: %s", result.SkipReason)
+	}
+	return 1 - result.OverallRisk, nil
+}
@@ -0,0 +1,111 @@
+package orchestrator
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"sync/atomic"
+
+	"github.com/rand/recurse/internal/rlm/meta"
+)
+
+type execStatsKey struct{}
+
+// execStats collects per-execution facts for ExecutionResult across the
+// recursive orchestrate calls of one Execute.
+type execStats struct {
+	decisions        atomic.Int64
+	subtaskCacheHits atomic.Int64
+	action           meta.Action
+	externalized     bool
+	compression      *CompressionStats
+
+	mu        sync.Mutex
+	timedOut  []meta.Action
+	overflows []ContextOverflowRecovery
+}
+
+// withExecStats returns a context carrying fresh execution stats.
+func withExecStats(ctx context.Context) (context.Context, *execStats) {
+	stats := &execStats{}
+	return context.WithValue(ctx, execStatsKey{}, stats), stats
+}
+
+// recordDecision counts a meta-controller decision and remembers the
+// top-level one.
+func recordDecision(ctx context.Context, state meta.State, decision *meta.Decision) {
+	stats, ok := ctx.Value(execStatsKey{}).(*execStats)
+	if !ok {
+		return
+	}
+	stats.decisions.Add(1)
+	if state.RecursionDepth == 0 {
+		stats.action = decision.Action
+		stats.externalized = state.ExternalizedContext
+	}
+}
+
+// mode returns "rlm" if the top-level call externalized context to the
+// REPL, else "direct".
+func (s *execStats) mode() string {
+	if s.externalized {
+		return "rlm"
+	}
+	return "direct"
+}
+
+// recordCompression remembers the compression applied while preparing the
+// top-level context.
+func recordCompression(ctx context.Context, compression *CompressionStats) {
+	if stats, ok := ctx.Value(execStatsKey{}).(*execStats); ok {
+		stats.compression = compression
+	}
+}
+
+// recordSubtaskCacheHit counts a subtask served from the subtask cache.
+func recordSubtaskCacheHit(ctx context.Context) {
+	if stats, ok := ctx.Value(execStatsKey{}).(*execStats); ok {
+		stats.subtaskCacheHits.Add(1)
+	}
+}
+
+// recordActionTimeout remembers an action that exceeded its per-action
+// timeout.
+func recordActionTimeout(ctx context.Context, action meta.Action) {
+	if stats, ok := ctx.Value(execStatsKey{}).(*execStats); ok {
+		stats.mu.Lock()
+		stats.timedOut = append(stats.timedOut, action)
+		stats.mu.Unlock()
+	}
+}
+
+// recordContextOverflow remembers an overflow that recovery retried on
+// chunks.
+func recordContextOverflow(ctx context.Context, overflow ContextOverflowRecovery) {
+	if stats, ok := ctx.Value(execStatsKey{}).(*execStats); ok {
+		stats.mu.Lock()
+		stats.overflows = append(stats.overflows, overflow)
+		stats.mu.Unlock()
+	}
+}
+
+// contextOverflows returns the recorded overflows, in order.
+func (s *execStats) contextOverflows() []ContextOverflowRecovery {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.overflows)
+}
+
+// timedOutActions returns the actions that timed out, in order.
+func (s *execStats) timedOutActions() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.timedOut) == 0 {
+		return nil
+	}
+	actions := make([]string, len(s.timedOut))
+	for i, a := range s.timedOut {
+		actions[i] = string(a)
+	}
+	return actions
+}
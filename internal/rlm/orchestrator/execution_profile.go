@@ -0,0 +1,125 @@
+package orchestrator
+
+import (
+	"context"
+
+	"github.com/rand/recurse/internal/rlm/meta"
+)
+
+// ExecutionProfile names a bundle of settings that trades cost against
+// answer quality for one execution, so callers state their intent instead
+// of setting each option. Select one per execution with
+// WithExecutionProfile.
+type ExecutionProfile string
+
+const (
+	// ModeStandard runs with the configured settings.
+	ModeStandard ExecutionProfile = ""
+
+	// ModeBestEffort favors cost for low-stakes bulk tasks: only Fast-tier
+	// models, no verification or escalation, few iterations, and a partial
+	// answer accepted where the execution would otherwise fail. The tier
+	// cap binds only clients that route by tier (meta.Capabilities.TierRouting)
+	// and sub-call model choices; a single-model client answers with its
+	// model regardless.
+	ModeBestEffort ExecutionProfile = "best_effort"
+
+	// ModeHighAssurance favors quality: every checkable answer is verified,
+	// low-confidence answers are escalated, and executions may iterate and
+	// decompose further.
+	ModeHighAssurance ExecutionProfile = "high_assurance"
+)
+
+// Verification overrides how much of an execution's work is verified.
+type Verification int
+
+const (
+	// VerifyConfigured keeps the configured verification.
+	VerifyConfigured Verification = iota
+
+	// VerifyNone skips answer scoring, sub-call result checks, sub-call
+	// constraint checks and independent re-derivation of computed answers.
+	VerifyNone
+
+	// VerifyAll checks every sub-call result rather than a sample and
+	// re-derives computed answers.
+	VerifyAll
+)
+
+// ProfileSettings are the overrides an execution profile applies. The
+// zero value overrides nothing.
+type ProfileSettings struct {
+	// MaxTier caps the tier every call is routed to, via meta.WithMaxTier,
+	// when TierCapped is set. It wins over escalation's minimum tier. A
+	// client that does not route by tier ignores it.
+	MaxTier    meta.ModelTier
+	TierCapped bool
+
+	// Verification overrides the configured verification.
+	Verification Verification
+
+	// Escalation replaces the configured escalation policy; a zero policy
+	// disables escalation. Nil keeps the configured policy.
+	Escalation *EscalationPolicy
+
+	// MaxRecursionDepth replaces the configured decomposition depth, and
+	// MaxIterations the RLM loop's iteration limit, when positive.
+	MaxRecursionDepth int
+	MaxIterations     int
+
+	// AcceptPartial returns the best partial answer as the result instead
+	// of failing: an execution stopped at its cost ceiling or call limit
+	// returns no error, and an RLM loop out of iterations without FINAL()
+	// answers with its last output. Such results are marked Partial.
+	AcceptPartial bool
+}
+
+// Settings returns the overrides p applies. ModeStandard and unknown
+// profiles override nothing.
+func (p ExecutionProfile) Settings() ProfileSettings {
+	switch p {
+	case ModeBestEffort:
+		return ProfileSettings{
+			MaxTier:           meta.TierFast,
+			TierCapped:        true,
+			Verification:      VerifyNone,
+			Escalation:        &EscalationPolicy{},
+			MaxRecursionDepth: 2,
+			MaxIterations:     3,
+			AcceptPartial:     true,
+		}
+	case ModeHighAssurance:
+		return ProfileSettings{
+			Verification:      VerifyAll,
+			Escalation:        &EscalationPolicy{MinConfidence: 0.8, Tier: meta.TierPowerful},
+			MaxRecursionDepth: 8,
+			MaxIterations:     20,
+		}
+	default:
+		return ProfileSettings{}
+	}
+}
+
+type executionProfileKey struct{}
+
+// WithExecutionProfile returns a context whose execution runs under
+// profile. A tier cap the profile sets is applied to ctx's completions.
+func WithExecutionProfile(ctx context.Context, profile ExecutionProfile) context.Context {
+	ctx = context.WithValue(ctx, executionProfileKey{}, profile)
+	if settings := profile.Settings(); settings.TierCapped {
+		ctx = meta.WithMaxTier(ctx, settings.MaxTier)
+	}
+	return ctx
+}
+
+// ExecutionProfileFrom returns the profile carried by ctx, ModeStandard if
+// there is none.
+func ExecutionProfileFrom(ctx context.Context) ExecutionProfile {
+	profile, _ := ctx.Value(executionProfileKey{}).(ExecutionProfile)
+	return profile
+}
+
+// ProfileSettingsFrom returns the overrides of the profile carried by ctx.
+func ProfileSettingsFrom(ctx context.Context) ProfileSettings {
+	return ExecutionProfileFrom(ctx).Settings()
+}
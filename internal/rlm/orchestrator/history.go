@@ -0,0 +1,211 @@
+package orchestrator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/rand/recurse/internal/memory/hypergraph"
+)
+
+// maxHistoryCandidates bounds how many recent execution summaries are
+// compared with a query.
+const maxHistoryCandidates = 2000
+
+// ExecutionSummary is how a past execution handled its task, kept so
+// similar tasks can learn from it.
+type ExecutionSummary struct {
+	// ID is the hypergraph node ID of the execution.
+	ID string `json:"id"`
+
+	// Task is the executed task.
+	Task string `json:"task"`
+
+	// Actions are the top-level meta-controller actions taken, one per
+	// attempt when the task was escalated.
+	Actions []string `json:"actions,omitempty"`
+
+	// Models are the models the execution's completions were sent to, in
+	// order of first use.
+	Models []string `json:"models,omitempty"`
+
+	// Mode is "rlm" or "direct".
+	Mode string `json:"mode,omitempty"`
+
+	// Success is true when the execution returned a complete answer.
+	Success bool `json:"success"`
+
+	// Error is the execution's error, if it failed.
+	Error string `json:"error,omitempty"`
+
+	// Tokens and Cost are what the execution spent, across all attempts.
+	Tokens int     `json:"tokens"`
+	Cost   float64 `json:"cost,omitempty"`
+
+	// Duration is how long the execution took.
+	Duration time.Duration `json:"duration"`
+
+	// CreatedAt is when the summary was recorded.
+	CreatedAt time.Time `json:"created_at"`
+
+	// Similarity is the keyword overlap (0.0 to 1.0) with the task passed
+	// to FindSimilarExecutions.
+	Similarity float64 `json:"similarity,omitempty"`
+}
+
+// executionSummaryMetadata is the metadata of a summarized execution node;
+// the task is its content. Response is what storeExecution recorded.
+type executionSummaryMetadata struct {
+	Summarized bool   `json:"summarized"`
+	Response   string `json:"response,omitempty"`
+
+	Actions  []string      `json:"actions,omitempty"`
+	Models   []string      `json:"models,omitempty"`
+	Mode     string        `json:"mode,omitempty"`
+	Success  bool          `json:"success"`
+	Error    string        `json:"error,omitempty"`
+	Tokens   int           `json:"tokens"`
+	Cost     float64       `json:"cost,omitempty"`
+	Duration time.Duration `json:"duration"`
+}
+
+// RecordExecutionSummary records a summary of result on the node of the
+// execution carried by ctx, so that FindSimilarExecutions can find it.
+// Outside an execution of store, it creates the execution's node.
+func RecordExecutionSummary(ctx context.Context, store *hypergraph.Store, result *ExecutionResult) (*ExecutionSummary, error) {
+	var node *hypergraph.Node
+	if exec := ExecutionFrom(ctx); exec != nil && exec.store == store {
+		id, err := exec.NodeID(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if node, err = store.GetNode(ctx, id); err != nil {
+			return nil, fmt.Errorf("get execution node: %w", err)
+		}
+	}
+
+	var data executionSummaryMetadata
+	if node != nil && len(node.Metadata) > 0 {
+		if err := json.Unmarshal(node.Metadata, &data); err != nil {
+			return nil, fmt.Errorf("decode execution node %s: %w", node.ID, err)
+		}
+	}
+	data.Summarized = true
+	data.Models = result.Models
+	data.Mode = result.Mode
+	data.Success = result.Error == "" && !result.Partial
+	data.Error = result.Error
+	data.Tokens = result.TotalTokens
+	data.Cost = result.Cost
+	data.Duration = result.Duration
+	data.Actions = nil
+	if esc := result.Escalation; esc != nil && len(esc.Attempts) > 0 {
+		for _, attempt := range esc.Attempts {
+			if attempt.Action != "" {
+				data.Actions = append(data.Actions, attempt.Action)
+			}
+		}
+	} else if result.Action != "" {
+		data.Actions = []string{result.Action}
+	}
+
+	metadata, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("marshal execution summary: %w", err)
+	}
+	if node == nil {
+		node = hypergraph.NewNode(hypergraph.NodeTypeDecision, result.Task)
+		node.Subtype = executionSubtype
+		node.Metadata = metadata
+		if err := store.CreateNode(ctx, node); err != nil {
+			return nil, fmt.Errorf("create execution node: %w", err)
+		}
+	} else {
+		node.Metadata = metadata
+		if err := store.UpdateNode(ctx, node); err != nil {
+			return nil, fmt.Errorf("update execution node: %w", err)
+		}
+	}
+	return executionSummaryFromNode(node, data), nil
+}
+
+// FindSimilarExecutions returns up to limit recorded executions whose task
+// shares keywords with task, most similar first and newest first on ties.
+// Only the most recent summaries are compared.
+func FindSimilarExecutions(ctx context.Context, store *hypergraph.Store, task string, limit int) ([]ExecutionSummary, error) {
+	if store == nil {
+		return nil, nil
+	}
+	if limit <= 0 {
+		limit = 10
+	}
+	nodes, err := store.ListNodes(ctx, hypergraph.NodeFilter{
+		Types:    []hypergraph.NodeType{hypergraph.NodeTypeDecision},
+		Subtypes: []string{executionSubtype},
+		Limit:    maxHistoryCandidates,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list execution summaries: %w", err)
+	}
+
+	keywords := planKeywords(task)
+	var summaries []ExecutionSummary
+	for _, node := range nodes {
+		similarity := jaccard(keywords, planKeywords(node.Content))
+		if similarity == 0 {
+			continue
+		}
+		data, ok, err := decodeExecutionSummary(node)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		summary := executionSummaryFromNode(node, data)
+		summary.Similarity = similarity
+		summaries = append(summaries, *summary)
+	}
+
+	sort.SliceStable(summaries, func(i, j int) bool {
+		if summaries[i].Similarity != summaries[j].Similarity {
+			return summaries[i].Similarity > summaries[j].Similarity
+		}
+		return summaries[i].CreatedAt.After(summaries[j].CreatedAt)
+	})
+	if len(summaries) > limit {
+		summaries = summaries[:limit]
+	}
+	return summaries, nil
+}
+
+// decodeExecutionSummary decodes the summary recorded on an execution
+// node, reporting false for executions that were not summarized.
+func decodeExecutionSummary(node *hypergraph.Node) (executionSummaryMetadata, bool, error) {
+	var data executionSummaryMetadata
+	if len(node.Metadata) == 0 {
+		return data, false, nil
+	}
+	if err := json.Unmarshal(node.Metadata, &data); err != nil {
+		return data, false, fmt.Errorf("decode execution summary %s: %w", node.ID, err)
+	}
+	return data, data.Summarized, nil
+}
+
+func executionSummaryFromNode(node *hypergraph.Node, data executionSummaryMetadata) *ExecutionSummary {
+	return &ExecutionSummary{
+		ID:        node.ID,
+		Task:      node.Content,
+		Actions:   data.Actions,
+		Models:    data.Models,
+		Mode:      data.Mode,
+		Success:   data.Success,
+		Error:     data.Error,
+		Tokens:    data.Tokens,
+		Cost:      data.Cost,
+		Duration:  data.Duration,
+		CreatedAt: node.CreatedAt,
+	}
+}
@@ -179,11 +179,8 @@ func (i *Intelligent) determineRouting(prompt string, decision *meta.Decision) *
 	}
 
 	// Find best model for the tier
-	for _, m := range i.models {
-		if m.Tier == routing.PrimaryTier {
-			routing.PrimaryModel = m.ID
-			break
-		}
+	if m := meta.SelectForTier(i.models, routing.PrimaryTier); m != nil {
+		routing.PrimaryModel = m.ID
 	}
 
 	// Set subtask routing defaults
@@ -295,15 +292,15 @@ func (i *Intelligent) createSubtasks(prompt string, decision *meta.Decision) []S
 	return subtasks
 }
 
-// findModelForTier finds a model ID for the given tier.
+// findModelForTier finds a model ID for the given tier, preferring models
+// as meta.CompareModels orders them. Without one it falls back to the
+// preferred fast model.
 func (i *Intelligent) findModelForTier(tier meta.ModelTier) string {
-	for _, m := range i.models {
-		if m.Tier == tier {
-			return m.ID
-		}
+	if m := meta.SelectForTier(i.models, tier); m != nil {
+		return m.ID
 	}
-	if len(i.models) > 0 {
-		return i.models[0].ID
+	if m := meta.PreferredModel(i.models); m != nil {
+		return m.ID
 	}
 	return "anthropic/claude-haiku-4.5"
 }
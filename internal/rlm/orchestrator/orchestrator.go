@@ -77,6 +77,13 @@ func (o *Orchestrator) Execute(ctx context.Context, task string) (*ExecutionResu
 	return o.core.Execute(ctx, task)
 }
 
+// FindSimilarPlan returns a recorded decomposition plan for a task similar
+// to task, or nil if there is none. Plans are recorded when decompositions
+// succeed with StoreDecisions enabled.
+func (o *Orchestrator) FindSimilarPlan(ctx context.Context, task string) (*Plan, error) {
+	return o.core.FindSimilarPlan(ctx, task)
+}
+
 // Analyze performs intelligent analysis of a user prompt.
 func (o *Orchestrator) Analyze(ctx context.Context, prompt string, contextTokens int) (*AnalysisResult, error) {
 	result, err := o.intelligent.Analyze(ctx, prompt, contextTokens)
@@ -3,14 +3,28 @@ package orchestrator
 import (
 	"context"
 	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"charm.land/fantasy"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/rand/recurse/internal/memory/embeddings"
+	"github.com/rand/recurse/internal/memory/hypergraph"
+	"github.com/rand/recurse/internal/rlm/async"
+	"github.com/rand/recurse/internal/rlm/decompose"
 	"github.com/rand/recurse/internal/rlm/meta"
 	"github.com/rand/recurse/internal/rlm/repl"
+	"github.com/rand/recurse/internal/rlm/synthesize"
+	"github.com/rand/recurse/internal/rlm/tot"
 )
 
 // =============================================================================
@@ -351,6 +365,24 @@ func TestIntelligent_FindModelForTier(t *testing.T) {
 	assert.Equal(t, "powerful-model", intel.findModelForTier(meta.TierPowerful))
 }
 
+func TestIntelligent_FindModelForTier_IgnoresOrder(t *testing.T) {
+	models := []meta.ModelSpec{
+		{ID: "fast-pricey", Tier: meta.TierFast, InputCost: 1, OutputCost: 5},
+		{ID: "fast-cheap", Tier: meta.TierFast, InputCost: 0.1, OutputCost: 0.4},
+		{ID: "balanced-cheap", Tier: meta.TierBalanced, InputCost: 0.3, OutputCost: 2.5},
+		{ID: "balanced-primary", Tier: meta.TierBalanced, InputCost: 3, OutputCost: 15, Priority: 1},
+	}
+	reversed := slices.Clone(models)
+	slices.Reverse(reversed)
+
+	for _, catalog := range [][]meta.ModelSpec{models, reversed} {
+		intel := NewIntelligent(nil, IntelligentConfig{Enabled: true, Models: catalog})
+		assert.Equal(t, "fast-cheap", intel.findModelForTier(meta.TierFast), "cheapest wins at equal priority")
+		assert.Equal(t, "balanced-primary", intel.findModelForTier(meta.TierBalanced), "priority wins over cost")
+		assert.Equal(t, "fast-cheap", intel.findModelForTier(meta.TierReasoning), "missing tier falls back to the preferred fast model")
+	}
+}
+
 func TestIntelligent_FindModelForTier_Fallback(t *testing.T) {
 	intel := NewIntelligent(nil, IntelligentConfig{
 		Enabled: true,
@@ -953,6 +985,173 @@ func TestContextLoader_GenerateContextPrompt_WithVariables(t *testing.T) {
 	assert.Contains(t, prompt, "1500")
 }
 
+// =============================================================================
+// Deduplication Tests
+// =============================================================================
+
+func TestDeduplicateSources_ExactDuplicates(t *testing.T) {
+	content := "package main\n\nfunc main() {}\n"
+	sources := []ContextSource{
+		{Name: "file_main", Content: content, Type: ContextTypeFile, Metadata: map[string]any{"source": "main.go"}},
+		{Name: "search_hit", Content: content, Type: ContextTypeSearch, Metadata: map[string]any{"query": "main"}},
+		{Name: "other", Content: "unrelated", Type: ContextTypeCustom},
+	}
+
+	deduped := DeduplicateSources(sources, DefaultDedupConfig())
+	require.Len(t, deduped, 2)
+
+	assert.Equal(t, "file_main", deduped[0].Name)
+	assert.Equal(t, content, deduped[0].Content)
+	assert.Equal(t, []string{"search_hit"}, deduped[0].Aliases)
+	require.Len(t, deduped[0].Provenance, 2)
+	assert.Equal(t, "file_main", deduped[0].Provenance[0].Name)
+	assert.Equal(t, "main.go", deduped[0].Provenance[0].Source)
+	assert.Equal(t, "search_hit", deduped[0].Provenance[1].Name)
+	assert.Equal(t, ContextTypeSearch, deduped[0].Provenance[1].Type)
+	assert.Equal(t, deduped[0].Provenance[0].Hash, deduped[0].Provenance[1].Hash)
+	assert.Equal(t, deduped[0].Provenance, deduped[0].Metadata["provenance"])
+	assert.Equal(t, "main.go", deduped[0].Metadata["source"])
+
+	assert.Equal(t, "other", deduped[1].Name)
+	assert.Len(t, deduped[1].Provenance, 1)
+}
+
+func TestDeduplicateSources_Contained(t *testing.T) {
+	full := "line1\nline2\nline3\nline4\nline5"
+	sources := []ContextSource{
+		{Name: "snippet", Content: "line2\nline3\nline4", Type: ContextTypeSearch},
+		{Name: "file", Content: full, Type: ContextTypeFile},
+	}
+
+	deduped := DeduplicateSources(sources, DefaultDedupConfig())
+	require.Len(t, deduped, 1)
+	assert.Equal(t, "file", deduped[0].Name, "containing source keeps its name")
+	assert.Equal(t, ContextTypeFile, deduped[0].Type)
+	assert.Equal(t, []string{"snippet"}, deduped[0].Aliases)
+	assert.Equal(t, full, deduped[0].Content)
+	require.Len(t, deduped[0].Provenance, 2)
+	assert.Equal(t, "snippet", deduped[0].Provenance[0].Name)
+	assert.Equal(t, "file", deduped[0].Provenance[1].Name)
+}
+
+func TestDeduplicateSources_PartialOverlap(t *testing.T) {
+	sources := []ContextSource{
+		{Name: "first_half", Content: "a\nb\nc\nd\ne\nf", Type: ContextTypeFile},
+		{Name: "second_half", Content: "c\nd\ne\nf\ng\nh", Type: ContextTypeFile},
+	}
+
+	deduped := DeduplicateSources(sources, DefaultDedupConfig())
+	require.Len(t, deduped, 1)
+	assert.Equal(t, "a\nb\nc\nd\ne\nf\ng\nh", deduped[0].Content)
+	require.Len(t, deduped[0].Provenance, 2)
+	assert.Equal(t, "first_half", deduped[0].Provenance[0].Name)
+	assert.Equal(t, "second_half", deduped[0].Provenance[1].Name)
+
+	// Reversed order merges the same way around.
+	deduped = DeduplicateSources([]ContextSource{sources[1], sources[0]}, DefaultDedupConfig())
+	require.Len(t, deduped, 1)
+	assert.Equal(t, "a\nb\nc\nd\ne\nf\ng\nh", deduped[0].Content)
+}
+
+func TestDeduplicateSources_PartialOverlap_TrailingNewline(t *testing.T) {
+	sources := []ContextSource{
+		{Name: "first_half", Content: "a\nb\nc\nd\ne\nf\n", Type: ContextTypeFile},
+		{Name: "second_half", Content: "c\nd\ne\nf\ng\nh\n", Type: ContextTypeFile},
+	}
+
+	deduped := DeduplicateSources(sources, DefaultDedupConfig())
+	require.Len(t, deduped, 1)
+	assert.Equal(t, "a\nb\nc\nd\ne\nf\ng\nh\n", deduped[0].Content)
+	assert.Equal(t, []string{"second_half"}, deduped[0].Aliases)
+}
+
+func TestDeduplicateSources_ChainedMerges(t *testing.T) {
+	sources := []ContextSource{
+		{Name: "part1", Content: "a\nb\nc\nd\n", Type: ContextTypeFile},
+		{Name: "unrelated", Content: "x\ny\nz\n", Type: ContextTypeCustom},
+		{Name: "part2", Content: "b\nc\nd\ne\nf\n", Type: ContextTypeFile},
+		{Name: "part3", Content: "d\ne\nf\ng\n", Type: ContextTypeFile},
+	}
+
+	deduped := DeduplicateSources(sources, DefaultDedupConfig())
+	require.Len(t, deduped, 2)
+	assert.Equal(t, "part1", deduped[0].Name)
+	assert.Equal(t, "a\nb\nc\nd\ne\nf\ng\n", deduped[0].Content)
+	assert.Equal(t, []string{"part2", "part3"}, deduped[0].Aliases)
+	assert.Len(t, deduped[0].Provenance, 3)
+	assert.Equal(t, "unrelated", deduped[1].Name)
+}
+
+func TestDeduplicateSources_SmallOverlapKept(t *testing.T) {
+	sources := []ContextSource{
+		{Name: "a", Content: "x\ny\nshared\nz", Type: ContextTypeFile},
+		{Name: "b", Content: "shared\np\nq\nr", Type: ContextTypeFile},
+	}
+
+	deduped := DeduplicateSources(sources, DefaultDedupConfig())
+	assert.Len(t, deduped, 2)
+}
+
+func TestDeduplicateSources_Disabled(t *testing.T) {
+	sources := []ContextSource{
+		{Name: "a", Content: "same", Type: ContextTypeFile},
+		{Name: "b", Content: "same", Type: ContextTypeFile},
+	}
+
+	deduped := DeduplicateSources(sources, DedupConfig{})
+	require.Len(t, deduped, 2)
+	assert.Len(t, deduped[0].Provenance, 1)
+}
+
+func TestContextLoader_Load_Deduplicates(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	replMgr, err := repl.NewManager(repl.Options{})
+	require.NoError(t, err)
+	require.NoError(t, replMgr.Start(ctx))
+	defer replMgr.Stop()
+
+	full := "line1\nline2\nline3\nline4\nline5\n"
+	loader := NewContextLoader(replMgr)
+	loaded, err := loader.Load(ctx, []ContextSource{
+		{Name: "snippet", Content: "line2\nline3\nline4\n", Type: ContextTypeSearch},
+		{Name: "file", Content: full, Type: ContextTypeFile, Metadata: map[string]any{"source": "a.txt"}},
+		{Name: "copy", Content: full, Type: ContextTypeFile},
+	})
+	require.NoError(t, err)
+
+	require.Len(t, loaded.Variables, 1)
+	info, ok := loaded.Variables["file"]
+	require.True(t, ok)
+	assert.Equal(t, len(full), info.Size)
+	assert.Equal(t, len(full)/4, info.TokenEstimate)
+	assert.Equal(t, len(full)/4, loaded.TotalTokens)
+	assert.ElementsMatch(t, []string{"copy", "snippet"}, info.Aliases)
+	require.Len(t, info.Provenance, 3)
+	assert.Equal(t, "a.txt", info.Provenance[1].Source)
+
+	// Merged-away names still resolve in the REPL.
+	for _, name := range []string{"file", "snippet", "copy"} {
+		v, err := replMgr.GetVar(ctx, name, 0, 0)
+		require.NoError(t, err, name)
+		assert.Equal(t, len(full), v.Length, name)
+	}
+
+	prompt := loader.GenerateContextPrompt(loaded)
+	assert.Contains(t, prompt, "Also available as")
+}
+
+func TestLongestCommonSpan(t *testing.T) {
+	startA, startB, length := longestCommonSpan(
+		[]string{"x", "a", "b", "c", "y"},
+		[]string{"a", "b", "c", "z"},
+	)
+	assert.Equal(t, 1, startA)
+	assert.Equal(t, 0, startB)
+	assert.Equal(t, 3, length)
+}
+
 // =============================================================================
 // Integration Tests
 // =============================================================================
@@ -990,3 +1189,1987 @@ func TestAnalysisResult_FullFlow(t *testing.T) {
 	assert.Len(t, result.Subtasks, 3)
 	assert.Equal(t, 9, result.ContextNeeds.Priority)
 }
+
+// =============================================================================
+// Confidence Propagation Tests
+// =============================================================================
+
+// scriptedClient returns canned completions: meta-controller prompts get
+// metaResponse, everything else gets answer.
+type scriptedClient struct {
+	metaResponse string
+	answer       string
+}
+
+func (c *scriptedClient) Complete(ctx context.Context, prompt string, maxTokens int) (string, error) {
+	if strings.Contains(prompt, "Current state:") {
+		return c.metaResponse, nil
+	}
+	return c.answer, nil
+}
+
+// fixedConfidenceSynthesizer records its inputs and reports a fixed confidence.
+type fixedConfidenceSynthesizer struct {
+	confidence float64
+	inputs     []synthesize.SubCallResult
+}
+
+func (s *fixedConfidenceSynthesizer) Synthesize(ctx context.Context, task string, results []synthesize.SubCallResult) (*synthesize.SynthesisResult, error) {
+	s.inputs = results
+	return &synthesize.SynthesisResult{Response: "combined", Confidence: s.confidence}, nil
+}
+
+func TestCore_ExecuteSynthesize_MixedConfidence(t *testing.T) {
+	core := NewCore(nil, nil, nil, DefaultCoreConfig())
+	ctx, rec := withConfidenceRecorder(context.Background())
+
+	state := meta.State{
+		Task:               "combine",
+		PartialResults:     []string{"confident answer", "shaky answer", "unscored answer"},
+		PartialConfidences: []float64{0.9, 0.3},
+	}
+
+	response, _, err := core.executeSynthesize(ctx, state)
+	require.NoError(t, err)
+	assert.Contains(t, response, "confident answer")
+
+	// The third partial has no confidence and is excluded from the aggregate.
+	assert.InDelta(t, 0.9*0.9+0.3*0.1, rec.Confidence(), 0.001)
+}
+
+func TestCore_Execute_SurfacesSynthesisConfidence(t *testing.T) {
+	store, err := hypergraph.NewStore(hypergraph.Options{})
+	require.NoError(t, err)
+	defer store.Close()
+
+	client := &scriptedClient{
+		metaResponse: `{"action": "DECOMPOSE", "params": {"strategy": "concept"}, "reasoning": "split"}`,
+		answer:       "part answer",
+	}
+	cfg := DefaultCoreConfig()
+	cfg.MaxRecursionDepth = 1
+	cfg.StoreDecisions = false
+	core := NewCore(meta.NewController(client, meta.Config{}), client, store, cfg)
+
+	synth := &fixedConfidenceSynthesizer{confidence: 0.72}
+	core.SetSynthesizer(synth)
+
+	result, err := core.Execute(context.Background(), "analyze this task")
+	require.NoError(t, err)
+	assert.Equal(t, "combined", result.Response)
+	assert.InDelta(t, 0.72, result.Confidence, 0.001)
+
+	// Children answered directly and carry the confidence of their answers
+	require.NotEmpty(t, synth.inputs)
+	for _, in := range synth.inputs {
+		assert.Greater(t, in.Confidence, 0.0)
+	}
+}
+
+func TestCore_Execute_RecordsDirectConfidence(t *testing.T) {
+	store, err := hypergraph.NewStore(hypergraph.Options{})
+	require.NoError(t, err)
+	defer store.Close()
+
+	execute := func(answer string) float64 {
+		client := &scriptedClient{
+			metaResponse: `{"action": "DIRECT", "params": {}, "reasoning": "simple"}`,
+			answer:       answer,
+		}
+		cfg := DefaultCoreConfig()
+		cfg.StoreDecisions = false
+		core := NewCore(meta.NewController(client, meta.Config{}), client, store, cfg)
+
+		result, err := core.Execute(context.Background(), "What does parseConfig return?")
+		require.NoError(t, err)
+		return result.Confidence
+	}
+
+	sure := execute("parseConfig returns a *Config and an error; the error is non-nil when the file is missing.")
+	unsure := execute("I think it might return something, maybe.")
+	assert.Greater(t, sure, 0.0)
+	assert.Greater(t, unsure, 0.0)
+	assert.Greater(t, sure, unsure, "hedged answers score lower")
+}
+
+func TestConfidenceRecorder_OpScoping(t *testing.T) {
+	ctx, rec := withConfidenceRecorder(context.Background())
+
+	opCtx := withOpConfidenceRecorder(ctx, "op-1")
+	recordConfidence(opCtx, 0.6)
+
+	assert.InDelta(t, 0.6, rec.OpConfidence("op-1"), 0.001)
+	assert.Zero(t, rec.OpConfidence("op-2"))
+	assert.Zero(t, rec.Confidence(), "op confidence must not leak to the parent")
+
+	// Without a recorder, recording is a no-op.
+	recordConfidence(context.Background(), 0.5)
+	assert.Equal(t, context.Background(), withOpConfidenceRecorder(context.Background(), "op"))
+}
+
+// =============================================================================
+// Streaming Decomposition Tests
+// =============================================================================
+
+// steppedDecomposer emits one chunk, waits until it has completed, then emits
+// a second chunk that depends on the first.
+type steppedDecomposer struct {
+	firstDone    chan struct{}
+	startedEarly bool
+}
+
+func (d *steppedDecomposer) DecomposeStream(ctx context.Context, content string, emit func(decompose.Chunk) error) error {
+	if err := emit(decompose.Chunk{ID: "c1", Name: "first", Content: "first part"}); err != nil {
+		return err
+	}
+	select {
+	case <-d.firstDone:
+		d.startedEarly = true
+	case <-time.After(5 * time.Second):
+		return errors.New("first chunk never completed")
+	}
+	return emit(decompose.Chunk{ID: "c2", Name: "second", Content: "second part", DependsOn: []string{"c1"}})
+}
+
+func TestCore_Execute_StreamingDecomposition(t *testing.T) {
+	store, err := hypergraph.NewStore(hypergraph.Options{})
+	require.NoError(t, err)
+	defer store.Close()
+
+	client := &scriptedClient{
+		metaResponse: `{"action": "DECOMPOSE", "params": {"strategy": "file"}, "reasoning": "split"}`,
+		answer:       "part answer",
+	}
+	cfg := DefaultCoreConfig()
+	cfg.MaxRecursionDepth = 1
+	cfg.StoreDecisions = false
+	cfg.EnableAsyncExecution = true
+	core := NewCore(meta.NewController(client, meta.Config{}), client, store, cfg)
+
+	decomposer := &steppedDecomposer{firstDone: make(chan struct{})}
+	core.SetStreamingDecomposer(decomposer)
+
+	var completed []string
+	core.SetSubtaskProgress(func(r *async.OperationResult) {
+		completed = append(completed, r.ID)
+		if strings.HasSuffix(r.ID, "-c1") {
+			close(decomposer.firstDone)
+		}
+	})
+
+	synth := &fixedConfidenceSynthesizer{}
+	core.SetSynthesizer(synth)
+
+	result, err := core.Execute(context.Background(), "analyze a large task")
+	require.NoError(t, err)
+
+	assert.True(t, decomposer.startedEarly, "first subtask should finish before planning completes")
+	require.Len(t, completed, 2)
+	assert.True(t, strings.HasSuffix(completed[0], "-c1"))
+	assert.True(t, strings.HasSuffix(completed[1], "-c2"))
+
+	require.Len(t, synth.inputs, 2)
+	assert.Equal(t, "first", synth.inputs[0].Name)
+	assert.Equal(t, "second", synth.inputs[1].Name)
+	for _, in := range synth.inputs {
+		assert.Empty(t, in.Error)
+		assert.Equal(t, "part answer", in.Response)
+	}
+	assert.Equal(t, "combined", result.Response)
+}
+
+// =============================================================================
+// Tree of Thoughts Tests
+// =============================================================================
+
+func TestCore_Execute_ProofRoutesToToT(t *testing.T) {
+	store, err := hypergraph.NewStore(hypergraph.Options{})
+	require.NoError(t, err)
+	defer store.Close()
+
+	// The meta-controller would answer DIRECT if it were asked.
+	client := &scriptedClient{
+		metaResponse: `{"action": "DIRECT", "params": {}, "reasoning": "simple"}`,
+		answer:       "direct answer",
+	}
+	cfg := DefaultCoreConfig()
+	cfg.StoreDecisions = false
+	core := NewCore(meta.NewController(client, meta.DefaultConfig()), client, store, cfg)
+
+	evaluator := &tot.MockEvaluator{
+		ValueFunc: func(n *tot.ThoughtNode) float64 {
+			if strings.Contains(n.Thought, "contradiction") {
+				return 0.9
+			}
+			return 0.6
+		},
+		TerminalFunc: func(n *tot.ThoughtNode) bool {
+			return strings.Contains(n.Thought, "therefore")
+		},
+	}
+	generator := &tot.MockGenerator{
+		ThoughtsFunc: func(n *tot.ThoughtNode, _ int) []string {
+			if n.Depth == 0 {
+				return []string{"assume sqrt(2) = p/q in lowest terms", "try a contradiction"}
+			}
+			return []string{"p and q are both even, therefore sqrt(2) is irrational"}
+		},
+	}
+	core.SetToT(evaluator, generator)
+
+	result, err := core.Execute(context.Background(), "Prove that the square root of 2 is irrational")
+	require.NoError(t, err)
+
+	assert.Contains(t, result.Response, "Reasoning path:")
+	assert.Contains(t, result.Response, "1. try a contradiction")
+	assert.Contains(t, result.Response, "Solution: p and q are both even, therefore sqrt(2) is irrational")
+	assert.NotContains(t, result.Response, "direct answer")
+	assert.InDelta(t, 0.8, result.Confidence, 0.001)
+	assert.Positive(t, result.TotalTokens)
+}
+
+func TestCore_ExecuteToT_NoSolution(t *testing.T) {
+	client := &scriptedClient{}
+	core := NewCore(meta.NewController(client, meta.DefaultConfig()), client, nil, DefaultCoreConfig())
+	core.SetToT(&tot.MockEvaluator{}, &tot.MockGenerator{})
+
+	decision := &meta.Decision{Action: meta.ActionToT, Params: meta.DecisionParams{ToTStrategy: "bfs"}}
+	_, _, err := core.executeToT(context.Background(), meta.State{Task: "Prove it"}, decision)
+	assert.ErrorIs(t, err, tot.ErrNoSolution)
+}
+
+// =============================================================================
+// Cost Guard Tests
+// =============================================================================
+
+func TestCostGuard_Allow(t *testing.T) {
+	guard := NewCostGuard(CostCeiling{MaxCost: 1.0})
+
+	require.NoError(t, guard.Allow(0.4))
+	guard.Charge(0.4)
+	require.NoError(t, guard.Allow(0.5))
+	guard.Charge(0.7)
+
+	// Overshooting is only possible by the call already in flight.
+	err := guard.Allow(0)
+	require.ErrorIs(t, err, ErrBudgetExceeded)
+	assert.Equal(t, err, guard.Err())
+	assert.InDelta(t, 1.1, guard.Spent(), 1e-9)
+
+	var ceilingErr *CostCeilingError
+	require.ErrorAs(t, err, &ceilingErr)
+	assert.Equal(t, 1.0, ceilingErr.Ceiling)
+	assert.Equal(t, ErrorCategoryResource, NewRecoveryManager(DefaultRecoveryConfig()).ClassifyError(err))
+}
+
+func TestCostGuard_RefusesEstimateOverCeiling(t *testing.T) {
+	guard := NewCostGuard(CostCeiling{MaxCost: 1.0})
+	guard.Charge(0.8)
+	assert.ErrorIs(t, guard.Allow(0.3), ErrBudgetExceeded)
+}
+
+func TestCostGuard_NilIsNoop(t *testing.T) {
+	var guard *CostGuard
+	assert.NoError(t, guard.Allow(100))
+	guard.Charge(1)
+	guard.RecordPartial("x")
+	assert.Zero(t, guard.Spent())
+	assert.NoError(t, guard.Err())
+	assert.Empty(t, guard.Partial())
+	assert.Nil(t, CostGuardFrom(context.Background()))
+}
+
+func TestCostGuard_Child(t *testing.T) {
+	parent := NewCostGuard(CostCeiling{MaxCost: 1.0, InputCostPer1M: 3, OutputCostPer1M: 15})
+
+	// A child without a ceiling of its own is bounded by the parent and
+	// inherits its prices.
+	a := parent.Child(CostCeiling{})
+	b := parent.Child(CostCeiling{MaxCost: 0.5})
+	assert.Equal(t, 3.0, a.Ceiling().InputCostPer1M)
+
+	a.Charge(0.4)
+	b.Charge(0.3)
+	assert.InDelta(t, 0.4, a.Spent(), 1e-9)
+	assert.InDelta(t, 0.7, parent.Spent(), 1e-9)
+
+	// b's own ceiling refuses first; a is unaffected.
+	assert.ErrorIs(t, b.Allow(0.25), ErrBudgetExceeded)
+	assert.NoError(t, a.Allow(0.2))
+	assert.NoError(t, parent.Err())
+
+	// Tripping the parent aborts every child.
+	assert.ErrorIs(t, a.Allow(0.4), ErrBudgetExceeded)
+	assert.ErrorIs(t, parent.Err(), ErrBudgetExceeded)
+	assert.ErrorIs(t, parent.Child(CostCeiling{}).Err(), ErrBudgetExceeded)
+
+	// Partial answers stay with the child that recorded them.
+	a.RecordPartial("from a")
+	assert.Empty(t, parent.Partial())
+
+	var none *CostGuard
+	assert.Nil(t, none.Child(CostCeiling{}))
+	assert.NotNil(t, none.Child(CostCeiling{MaxCost: 1}))
+}
+
+func TestCostGuard_CallLimit(t *testing.T) {
+	parent := NewCostGuard(CostCeiling{})
+	guard := parent.Child(CostCeiling{MaxCalls: 2})
+	assert.True(t, guard.Ceiling().Enabled())
+
+	require.NoError(t, guard.Allow(0))
+	require.NoError(t, guard.Allow(0))
+	err := guard.Allow(0)
+	require.ErrorIs(t, err, ErrBudgetExceeded)
+	assert.Equal(t, ErrorCategoryResource, NewRecoveryManager(DefaultRecoveryConfig()).ClassifyError(err))
+
+	var limitErr *CallLimitError
+	require.ErrorAs(t, err, &limitErr)
+	assert.Equal(t, 2, limitErr.Limit)
+
+	// Only allowed calls are counted, in the parent as well.
+	assert.Equal(t, 2, guard.Calls())
+	assert.Equal(t, 2, parent.Calls())
+	assert.NoError(t, parent.Allow(0))
+
+	// A limit without prices never refuses on cost.
+	cheap := NewCostGuard(CostCeiling{MaxCalls: 5})
+	cheap.Charge(100)
+	assert.NoError(t, cheap.Allow(100))
+}
+
+func TestCostGuard_NearCeiling(t *testing.T) {
+	var none *CostGuard
+	assert.False(t, none.NearCeiling())
+
+	guard := NewCostGuard(CostCeiling{MaxCost: 1.0})
+	guard.Charge(0.5)
+	assert.False(t, guard.NearCeiling())
+	guard.Charge(0.3)
+	assert.True(t, guard.NearCeiling())
+
+	// A child is near the ceiling when its parent is.
+	parent := NewCostGuard(CostCeiling{MaxCalls: 5})
+	child := parent.Child(CostCeiling{})
+	for range 3 {
+		require.NoError(t, child.Allow(0))
+	}
+	assert.False(t, child.NearCeiling())
+	require.NoError(t, child.Allow(0))
+	assert.True(t, child.NearCeiling())
+}
+
+// markingTracer records events and the IDs marked interesting.
+type markingTracer struct {
+	mu     sync.Mutex
+	events []TraceEvent
+	marked []string
+}
+
+func (t *markingTracer) RecordEvent(event TraceEvent) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.events = append(t.events, event)
+	return nil
+}
+
+func (t *markingTracer) MarkInteresting(eventID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.marked = append(t.marked, eventID)
+}
+
+func TestCore_Execute_MarksBudgetWarningInteresting(t *testing.T) {
+	client := &scriptedClient{metaResponse: `{"action": "DIRECT", "reasoning": "simple"}`, answer: "the answer"}
+
+	for _, tc := range []struct {
+		name     string
+		maxCalls int
+		marked   bool
+	}{
+		{"near the ceiling", 1, true},
+		{"well under the ceiling", 10, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			core := newRetryCore(t, client, 1)
+			core.config.TraceEnabled = true
+			core.config.CostCeiling = CostCeiling{MaxCalls: tc.maxCalls}
+			tracer := &markingTracer{}
+			core.SetTracer(tracer)
+
+			_, err := core.Execute(context.Background(), "What is the answer?")
+			require.NoError(t, err)
+
+			require.NotEmpty(t, tracer.events)
+			if tc.marked {
+				assert.Equal(t, []string{tracer.events[0].ID}, tracer.marked)
+			} else {
+				assert.Empty(t, tracer.marked)
+			}
+		})
+	}
+}
+
+func TestGuardCalls(t *testing.T) {
+	client := &countingClient{scriptedClient: scriptedClient{answer: "answer"}}
+	guarded := GuardCalls(client)
+	assert.Nil(t, GuardCalls(nil))
+
+	// Without a guard in the context calls pass through.
+	_, err := guarded.Complete(context.Background(), "p", 10)
+	require.NoError(t, err)
+
+	guard := NewCostGuard(CostCeiling{MaxCalls: 1})
+	ctx := WithCostGuard(context.Background(), guard)
+	_, err = guarded.Complete(ctx, "p", 10)
+	require.NoError(t, err)
+	_, err = guarded.Complete(ctx, "p", 10)
+	assert.ErrorIs(t, err, ErrBudgetExceeded)
+	assert.Equal(t, int64(2), client.answers.Load())
+}
+
+func TestCostCeiling_Cost(t *testing.T) {
+	ceiling := CostCeiling{MaxCost: 1, InputCostPer1M: 3, OutputCostPer1M: 15}
+	assert.True(t, ceiling.Enabled())
+	assert.False(t, CostCeiling{}.Enabled())
+	assert.InDelta(t, 0.018, ceiling.Cost(1000, 1000), 1e-9)
+}
+
+// =============================================================================
+// Decomposition Plan Tests
+// =============================================================================
+
+const planTask = `Review the error handling in these files
+// File: open.go
+func Open(path string) error { return nil }
+// File: close.go
+func Close() error { return nil }
+`
+
+func newPlanCore(t *testing.T, store *hypergraph.Store, metaResponse string) (*Core, *fixedConfidenceSynthesizer) {
+	t.Helper()
+	client := &scriptedClient{metaResponse: metaResponse, answer: "part answer"}
+	cfg := DefaultCoreConfig()
+	cfg.MaxRecursionDepth = 1
+	core := NewCore(meta.NewController(client, meta.Config{}), client, store, cfg)
+	synth := &fixedConfidenceSynthesizer{confidence: 0.8}
+	core.SetSynthesizer(synth)
+	return core, synth
+}
+
+func TestCore_Execute_RecordsPlan(t *testing.T) {
+	store, err := hypergraph.NewStore(hypergraph.Options{})
+	require.NoError(t, err)
+	defer store.Close()
+	ctx := context.Background()
+
+	core, _ := newPlanCore(t, store, `{"action": "DECOMPOSE", "params": {"strategy": "file"}, "reasoning": "split"}`)
+	_, err = core.Execute(ctx, planTask)
+	require.NoError(t, err)
+
+	similar := strings.Replace(planTask, "close.go", "flush.go", 1)
+	plan, err := core.FindSimilarPlan(ctx, similar)
+	require.NoError(t, err)
+	require.NotNil(t, plan)
+	assert.Equal(t, planTask, plan.Task)
+	assert.Equal(t, "file", plan.Strategy)
+	assert.InDelta(t, 0.8, plan.Confidence, 0.001)
+	assert.GreaterOrEqual(t, plan.Similarity, minPlanSimilarity)
+	require.Len(t, plan.Steps, 2)
+	assert.Equal(t, "open.go", plan.Steps[0].Name)
+	assert.Equal(t, "close.go", plan.Steps[1].Name)
+
+	// The plan node links to a node per step, in order.
+	edges, err := store.GetNodeHyperedges(ctx, plan.ID)
+	require.NoError(t, err)
+	require.Len(t, edges, 1)
+	members, err := store.GetMemberNodes(ctx, edges[0].ID)
+	require.NoError(t, err)
+	require.Len(t, members, 3)
+	var steps []string
+	for _, node := range members {
+		if node.Subtype == planStepSubtype {
+			steps = append(steps, node.Content)
+		}
+	}
+	assert.ElementsMatch(t, []string{"open.go", "close.go"}, steps)
+
+	plan, err = core.FindSimilarPlan(ctx, "Summarize the release notes")
+	require.NoError(t, err)
+	assert.Nil(t, plan)
+}
+
+func TestCore_Execute_ReusesSimilarPlanStrategy(t *testing.T) {
+	store, err := hypergraph.NewStore(hypergraph.Options{})
+	require.NoError(t, err)
+	defer store.Close()
+	ctx := context.Background()
+
+	core, _ := newPlanCore(t, store, `{"action": "DECOMPOSE", "params": {"strategy": "function", "language": "go"}, "reasoning": "split"}`)
+	_, err = core.Execute(ctx, planTask)
+	require.NoError(t, err)
+
+	// Without a strategy the task would decompose by file; the recorded
+	// plan says by function.
+	core, synth := newPlanCore(t, store, `{"action": "DECOMPOSE", "params": {"language": "go"}, "reasoning": "split"}`)
+	_, err = core.Execute(ctx, planTask)
+	require.NoError(t, err)
+
+	var names []string
+	for _, in := range synth.inputs {
+		names = append(names, in.Name)
+	}
+	assert.Contains(t, names, "Open")
+	assert.Contains(t, names, "Close")
+
+	plan, err := core.FindSimilarPlan(ctx, planTask)
+	require.NoError(t, err)
+	require.NotNil(t, plan)
+	assert.Equal(t, "function", plan.Strategy)
+}
+
+func TestCore_Execute_NoPlanWithoutStoredDecisions(t *testing.T) {
+	store, err := hypergraph.NewStore(hypergraph.Options{})
+	require.NoError(t, err)
+	defer store.Close()
+	ctx := context.Background()
+
+	core, _ := newPlanCore(t, store, `{"action": "DECOMPOSE", "params": {"strategy": "file"}, "reasoning": "split"}`)
+	core.config.StoreDecisions = false
+	_, err = core.Execute(ctx, planTask)
+	require.NoError(t, err)
+
+	plan, err := core.FindSimilarPlan(ctx, planTask)
+	require.NoError(t, err)
+	assert.Nil(t, plan)
+}
+
+func TestPlanKeywords_Similarity(t *testing.T) {
+	a := planKeywords("Review the error handling in parser.go")
+	assert.Equal(t, map[string]bool{"review": true, "error": true, "handling": true, "parser": true}, a)
+	assert.InDelta(t, 0.6, jaccard(a, planKeywords("Review error handling in lexer.go")), 0.001)
+	assert.Zero(t, jaccard(planKeywords("a b"), planKeywords("")))
+}
+
+// =============================================================================
+// Subtask Cache Tests
+// =============================================================================
+
+// countingClient counts the non-meta (answer) calls of a scriptedClient.
+type countingClient struct {
+	scriptedClient
+	answers atomic.Int64
+}
+
+func (c *countingClient) Complete(ctx context.Context, prompt string, maxTokens int) (string, error) {
+	if !strings.Contains(prompt, "Current state:") {
+		c.answers.Add(1)
+	}
+	return c.scriptedClient.Complete(ctx, prompt, maxTokens)
+}
+
+func TestCore_Execute_SubtaskCache(t *testing.T) {
+	for _, async := range []bool{false, true} {
+		t.Run(fmt.Sprintf("async=%v", async), func(t *testing.T) {
+			client := &countingClient{scriptedClient: scriptedClient{
+				metaResponse: `{"action": "DECOMPOSE", "params": {"strategy": "file"}, "reasoning": "split"}`,
+				answer:       "part answer",
+			}}
+			cfg := DefaultCoreConfig()
+			cfg.MaxRecursionDepth = 1
+			cfg.StoreDecisions = false
+			cfg.EnableAsyncExecution = async
+			store, err := hypergraph.NewStore(hypergraph.Options{})
+			require.NoError(t, err)
+			defer store.Close()
+			core := NewCore(meta.NewController(client, meta.Config{}), client, store, cfg)
+
+			cache, err := NewSubtaskCache(context.Background(), SubtaskCacheConfig{})
+			require.NoError(t, err)
+			core.SetSubtaskCache(cache)
+
+			first, err := core.Execute(context.Background(), planTask)
+			require.NoError(t, err)
+			assert.Zero(t, first.SubtaskCacheHits)
+			answers := client.answers.Load()
+			require.Equal(t, int64(2), answers)
+
+			second, err := core.Execute(context.Background(), planTask)
+			require.NoError(t, err)
+			assert.Equal(t, 2, second.SubtaskCacheHits)
+			assert.Equal(t, answers, client.answers.Load(), "cached subtasks made no calls")
+			assert.Equal(t, first.Response, second.Response)
+			assert.Less(t, second.TotalTokens, first.TotalTokens)
+
+			stats := cache.Stats()
+			assert.Equal(t, int64(2), stats.Hits)
+			assert.Equal(t, int64(2), stats.Misses)
+			assert.Equal(t, 2, stats.Entries)
+			assert.Positive(t, stats.TokensSaved)
+
+			// A changed file is a different subtask
+			_, err = core.Execute(context.Background(), strings.Replace(planTask, "return nil }\n", "return io.EOF }\n", 1))
+			require.NoError(t, err)
+			assert.Equal(t, answers+1, client.answers.Load())
+		})
+	}
+}
+
+// digestPreparer reports a loaded context with a fixed digest.
+type digestPreparer struct {
+	digest string
+}
+
+func (p *digestPreparer) PrepareContext(ctx context.Context, task string, contextTokens int) (*PreparedContext, error) {
+	return &PreparedContext{Mode: "rlm", ContextDigest: p.digest}, nil
+}
+
+func TestCore_Execute_SubtaskCacheKeyedOnContext(t *testing.T) {
+	client := &countingClient{scriptedClient: scriptedClient{
+		metaResponse: `{"action": "DECOMPOSE", "params": {"strategy": "file"}, "reasoning": "split"}`,
+		answer:       "part answer",
+	}}
+	cfg := DefaultCoreConfig()
+	cfg.MaxRecursionDepth = 1
+	cfg.StoreDecisions = false
+	store, err := hypergraph.NewStore(hypergraph.Options{})
+	require.NoError(t, err)
+	defer store.Close()
+	core := NewCore(meta.NewController(client, meta.Config{}), client, store, cfg)
+	cache, err := NewSubtaskCache(context.Background(), SubtaskCacheConfig{})
+	require.NoError(t, err)
+	core.SetSubtaskCache(cache)
+
+	preparer := &digestPreparer{digest: "v1"}
+	core.SetContextPreparer(preparer)
+	_, err = core.Execute(context.Background(), planTask)
+	require.NoError(t, err)
+	answers := client.answers.Load()
+
+	_, err = core.Execute(context.Background(), planTask)
+	require.NoError(t, err)
+	assert.Equal(t, answers, client.answers.Load(), "same context reuses the results")
+
+	preparer.digest = "v2"
+	result, err := core.Execute(context.Background(), planTask)
+	require.NoError(t, err)
+	assert.Zero(t, result.SubtaskCacheHits, "a changed context misses")
+	assert.Equal(t, 2*answers, client.answers.Load())
+}
+
+// depthScriptedClient decomposes at the top level and asks for code below
+// it.
+type depthScriptedClient struct {
+	scriptedClient
+}
+
+func (c *depthScriptedClient) Complete(ctx context.Context, prompt string, maxTokens int) (string, error) {
+	if strings.Contains(prompt, "Current state:") && !strings.Contains(prompt, "Recursion depth: 0/") {
+		return `{"action": "EXECUTE", "params": {"code": "print(1)"}, "reasoning": "compute"}`, nil
+	}
+	return c.scriptedClient.Complete(ctx, prompt, maxTokens)
+}
+
+func TestCore_Execute_SubtaskCacheSkipsDegradedAnswers(t *testing.T) {
+	client := &depthScriptedClient{scriptedClient{
+		metaResponse: `{"action": "DECOMPOSE", "params": {"strategy": "file"}, "reasoning": "split"}`,
+		answer:       "part answer",
+	}}
+	cfg := DefaultCoreConfig()
+	cfg.MaxRecursionDepth = 2
+	cfg.StoreDecisions = false
+	// No REPL manager: every subtask falls back to a direct answer.
+	store, err := hypergraph.NewStore(hypergraph.Options{})
+	require.NoError(t, err)
+	defer store.Close()
+	core := NewCore(meta.NewController(client, meta.Config{}), client, store, cfg)
+	cache, err := NewSubtaskCache(context.Background(), SubtaskCacheConfig{})
+	require.NoError(t, err)
+	core.SetSubtaskCache(cache)
+
+	_, err = core.Execute(context.Background(), planTask)
+	require.NoError(t, err)
+	assert.Zero(t, cache.Stats().Entries, "fallback answers are not cached")
+
+	result, err := core.Execute(context.Background(), planTask)
+	require.NoError(t, err)
+	assert.Zero(t, result.SubtaskCacheHits)
+}
+
+func TestWithDegradedFlag_MarksEnclosingSubtasks(t *testing.T) {
+	outer, outerFlag := withDegradedFlag(context.Background())
+	inner, innerFlag := withDegradedFlag(outer)
+	_, siblingFlag := withDegradedFlag(outer)
+
+	recordDegraded(inner)
+	assert.True(t, innerFlag.set.Load())
+	assert.True(t, outerFlag.set.Load())
+	assert.False(t, siblingFlag.set.Load())
+
+	recordDegraded(context.Background()) // no flag, no panic
+}
+
+func TestSubtaskCache_TTLAndInvalidation(t *testing.T) {
+	ctx := context.Background()
+	cache, err := NewSubtaskCache(ctx, SubtaskCacheConfig{TTL: time.Minute, MaxEntries: 2})
+	require.NoError(t, err)
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+
+	key := SubtaskKey("summarize main.go", "package main")
+	assert.NotEqual(t, key, SubtaskKey("summarize main.go", "package other"))
+	assert.NotEqual(t, SubtaskKey("ab", "c"), SubtaskKey("a", "bc"))
+
+	cache.Put(ctx, key, CachedSubtask{Response: "a summary", Tokens: 40, Confidence: 0.7})
+	cached, ok := cache.Get(ctx, key)
+	require.True(t, ok)
+	assert.Equal(t, "a summary", cached.Response)
+	assert.Equal(t, 40, cached.Tokens)
+	assert.Equal(t, 0.7, cached.Confidence)
+
+	now = now.Add(2 * time.Minute)
+	_, ok = cache.Get(ctx, key)
+	assert.False(t, ok, "expired")
+	assert.Zero(t, cache.Stats().Entries)
+
+	cache.Put(ctx, key, CachedSubtask{Response: "a summary"})
+	cache.Invalidate(ctx, key)
+	_, ok = cache.Get(ctx, key)
+	assert.False(t, ok, "invalidated")
+
+	for _, k := range []string{"a", "b", "c"} {
+		cache.Put(ctx, k, CachedSubtask{Response: k})
+	}
+	_, ok = cache.Get(ctx, "a")
+	assert.False(t, ok, "oldest evicted")
+	assert.Equal(t, 2, cache.Stats().Entries)
+}
+
+func TestSubtaskCache_Persistence(t *testing.T) {
+	store, err := hypergraph.NewStore(hypergraph.Options{})
+	require.NoError(t, err)
+	defer store.Close()
+	ctx := context.Background()
+	countResults := func() int64 {
+		n, err := store.CountNodes(ctx, hypergraph.NodeFilter{Subtypes: []string{subtaskResultSubtype}})
+		require.NoError(t, err)
+		return n
+	}
+
+	cache, err := NewSubtaskCache(ctx, SubtaskCacheConfig{Store: store})
+	require.NoError(t, err)
+	cache.Put(ctx, "key", CachedSubtask{Response: "old", Tokens: 10})
+	cache.Put(ctx, "key", CachedSubtask{Response: "new", Tokens: 12, Confidence: 0.9})
+	assert.Equal(t, int64(1), countResults(), "replaced result is deleted")
+
+	// A new cache over the same store starts warm
+	reloaded, err := NewSubtaskCache(ctx, SubtaskCacheConfig{Store: store})
+	require.NoError(t, err)
+	cached, ok := reloaded.Get(ctx, "key")
+	require.True(t, ok)
+	assert.Equal(t, "new", cached.Response)
+	assert.Equal(t, 12, cached.Tokens)
+	assert.Equal(t, 0.9, cached.Confidence)
+
+	reloaded.Clear(ctx)
+	assert.Zero(t, countResults())
+
+	// Persisted results past their TTL are not loaded
+	cache.Put(ctx, "stale", CachedSubtask{Response: "stale"})
+	time.Sleep(5 * time.Millisecond)
+	expiring, err := NewSubtaskCache(ctx, SubtaskCacheConfig{Store: store, TTL: time.Millisecond})
+	require.NoError(t, err)
+	assert.Zero(t, expiring.Stats().Entries)
+}
+
+// =============================================================================
+// Context Ranking Tests
+// =============================================================================
+
+const rankTask = "Why does the login handler reject expired session tokens?"
+
+func rankSources() []ContextSource {
+	return []ContextSource{
+		{Name: "readme", Type: ContextTypeFile, Content: "# Project\n\nInstall with make. See CONTRIBUTING for style."},
+		{Name: "auth", Type: ContextTypeFile, Content: "func LoginHandler(w http.ResponseWriter, r *http.Request) {\n\t// reject expired session tokens\n}",
+			Metadata: map[string]any{"source": "internal/auth/login.go"}},
+		{Name: "changelog", Type: ContextTypeMemory, Content: "v1.2: faster builds, new logo"},
+		{Name: "hits", Type: ContextTypeSearch, Content: "session.go:40: if token.Expired() { return ErrSession }"},
+	}
+}
+
+func sourceNames(sources []ContextSource) []string {
+	names := make([]string, len(sources))
+	for i, src := range sources {
+		names[i] = src.Name
+	}
+	return names
+}
+
+func TestRankSources_DropsIrrelevant(t *testing.T) {
+	kept, dropped := RankSources(context.Background(), rankTask, rankSources(), RankConfig{MinRelevance: 0.3})
+
+	assert.Equal(t, []string{"auth", "hits"}, sourceNames(kept), "original order is kept")
+	require.Len(t, dropped, 2)
+	assert.Equal(t, "readme", dropped[0].Name, "file type outranks memory")
+	assert.Equal(t, "changelog", dropped[1].Name)
+	for _, d := range dropped {
+		assert.Zero(t, d.Keyword)
+		assert.Less(t, d.Score, 0.3)
+		assert.Contains(t, d.Reason, "below threshold 0.30")
+	}
+	assert.Equal(t, 3, dropped[0].Rank)
+	assert.Equal(t, 4, dropped[1].Rank)
+}
+
+func TestRankSources_TopK(t *testing.T) {
+	kept, dropped := RankSources(context.Background(), rankTask, rankSources(), RankConfig{TopK: 1})
+	assert.Equal(t, []string{"auth"}, sourceNames(kept))
+	require.Len(t, dropped, 3)
+	assert.Equal(t, "hits", dropped[0].Name)
+	assert.Equal(t, "ranked 2 of 4, beyond top 1", dropped[0].Reason)
+}
+
+func TestRankSources_KeepsAllWithoutLimits(t *testing.T) {
+	kept, dropped := RankSources(context.Background(), rankTask, rankSources(), DefaultRankConfig())
+	assert.Len(t, kept, 4)
+	assert.Empty(t, dropped)
+
+	// The best source survives even when nothing clears the threshold.
+	kept, dropped = RankSources(context.Background(), "unrelated question", rankSources(), RankConfig{MinRelevance: 0.9})
+	assert.Equal(t, []string{"readme"}, sourceNames(kept))
+	assert.Len(t, dropped, 3)
+}
+
+// fixedEmbedder embeds known texts as given vectors and others as a vector
+// orthogonal to all of them.
+type fixedEmbedder map[string]embeddings.Vector
+
+func (e fixedEmbedder) Embed(ctx context.Context, texts []string) ([]embeddings.Vector, error) {
+	vecs := make([]embeddings.Vector, len(texts))
+	for i, text := range texts {
+		if v, ok := e[text]; ok {
+			vecs[i] = v
+		} else {
+			vecs[i] = embeddings.Vector{0, 0, 1}
+		}
+	}
+	return vecs, nil
+}
+
+func (e fixedEmbedder) Dimensions() int { return 3 }
+func (e fixedEmbedder) Model() string   { return "fixed" }
+
+func TestRankSources_EmbeddingSimilarity(t *testing.T) {
+	sources := rankSources()
+	// The changelog shares no keywords but is semantically close.
+	embedder := fixedEmbedder{
+		rankTask:           {1, 0, 0},
+		sources[2].Content: {0.9, 0.1, 0},
+	}
+	kept, dropped := RankSources(context.Background(), rankTask, sources, RankConfig{
+		MinRelevance: 0.3,
+		Embedder:     embedder,
+	})
+	assert.Contains(t, sourceNames(kept), "changelog")
+	assert.NotContains(t, sourceNames(kept), "readme")
+	for _, d := range dropped {
+		assert.Zero(t, d.Embedding)
+	}
+}
+
+func TestContextLoader_LoadForTask_RecordsDropped(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	replMgr, err := repl.NewManager(repl.Options{})
+	require.NoError(t, err)
+	require.NoError(t, replMgr.Start(ctx))
+	defer replMgr.Stop()
+
+	loader := NewContextLoader(replMgr)
+	cfg := DefaultRankConfig()
+	cfg.MinRelevance = 0.3
+	loader.SetRankConfig(cfg)
+
+	loaded, err := loader.LoadForTask(ctx, rankTask, rankSources())
+	require.NoError(t, err)
+	assert.Len(t, loaded.Variables, 2)
+	assert.Contains(t, loaded.Variables, "auth")
+	assert.Contains(t, loaded.Variables, "hits")
+	require.Len(t, loaded.Dropped, 2)
+	assert.Equal(t, "readme", loaded.Dropped[0].Name)
+
+	_, err = replMgr.GetVar(ctx, "readme", 0, 0)
+	assert.Error(t, err, "dropped sources are not externalized")
+
+	prompt := loader.GenerateContextPrompt(loaded)
+	assert.Contains(t, prompt, "Omitted as less relevant")
+	assert.Contains(t, prompt, "`changelog` (memory): relevance")
+}
+
+// compressingPreparer reports a fixed context compression.
+type compressingPreparer struct {
+	compression *CompressionStats
+}
+
+func (p *compressingPreparer) PrepareContext(ctx context.Context, task string, contextTokens int) (*PreparedContext, error) {
+	return &PreparedContext{Mode: "direct", Compression: p.compression}, nil
+}
+
+// promptRecorder records the meta-controller prompts of a scriptedClient.
+type promptRecorder struct {
+	scriptedClient
+	metaPrompts []string
+}
+
+func (c *promptRecorder) Complete(ctx context.Context, prompt string, maxTokens int) (string, error) {
+	if strings.Contains(prompt, "Current state:") {
+		c.metaPrompts = append(c.metaPrompts, prompt)
+	}
+	return c.scriptedClient.Complete(ctx, prompt, maxTokens)
+}
+
+func TestCore_Execute_ReportsCompression(t *testing.T) {
+	client := &promptRecorder{scriptedClient: scriptedClient{
+		metaResponse: `{"action": "DIRECT", "reasoning": "small"}`,
+		answer:       "answer",
+	}}
+	store, err := hypergraph.NewStore(hypergraph.Options{})
+	require.NoError(t, err)
+	defer store.Close()
+	cfg := DefaultCoreConfig()
+	cfg.StoreDecisions = false
+	core := NewCore(meta.NewController(client, meta.Config{}), client, store, cfg)
+
+	compression := &CompressionStats{OriginalTokens: 40000, CompressedTokens: 10000}
+	core.SetContextPreparer(&compressingPreparer{compression: compression})
+
+	result, err := core.Execute(context.Background(), "summarize the report")
+	require.NoError(t, err)
+	require.NotNil(t, result.Compression)
+	assert.Equal(t, 30000, result.Compression.SavedTokens())
+	assert.InDelta(t, 0.25, result.Compression.Ratio(), 0.001)
+
+	// The meta-controller decides on the compressed size
+	require.NotEmpty(t, client.metaPrompts)
+	assert.Contains(t, client.metaPrompts[0], "- Context size: 10000 tokens")
+
+	// Without compression nothing is reported
+	core.SetContextPreparer(&compressingPreparer{})
+	result, err = core.Execute(context.Background(), "summarize the report")
+	require.NoError(t, err)
+	assert.Nil(t, result.Compression)
+}
+
+func TestCompressionStats(t *testing.T) {
+	stats := CompressionStats{OriginalTokens: 1000, CompressedTokens: 300}
+	assert.Equal(t, 700, stats.SavedTokens())
+	assert.InDelta(t, 0.3, stats.Ratio(), 0.001)
+
+	var empty CompressionStats
+	assert.Zero(t, empty.SavedTokens())
+	assert.Equal(t, 1.0, empty.Ratio())
+}
+
+// loadFileSource writes content to a file and loads it as variable name.
+func loadFileSource(t *testing.T, ctx context.Context, loader *ContextLoader, name, content string) (string, *LoadedContext) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name+".txt")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	loaded, err := loader.Load(ctx, []ContextSource{
+		{Name: name, Content: content, Type: ContextTypeFile, Metadata: map[string]any{"source": path}},
+	})
+	require.NoError(t, err)
+	return path, loaded
+}
+
+// rewriteFile replaces a file's content and moves its mtime forward, so the
+// change is seen even on filesystems with coarse timestamps.
+func rewriteFile(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(path, later, later))
+}
+
+func TestContextLoader_Refresh_ReloadsChangedFile(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	replMgr, err := repl.NewManager(repl.Options{})
+	require.NoError(t, err)
+	require.NoError(t, replMgr.Start(ctx))
+	defer replMgr.Stop()
+
+	loader := NewContextLoader(replMgr)
+	path, loaded := loadFileSource(t, ctx, loader, "config", "region: us-east-1\n")
+
+	// Nothing changed yet.
+	refreshes, err := loader.Refresh(ctx, loaded)
+	require.NoError(t, err)
+	assert.Empty(t, refreshes)
+
+	updated := "region: eu-west-1\nreplicas: 3\n"
+	rewriteFile(t, path, updated)
+
+	refreshes, err = loader.Refresh(ctx, loaded)
+	require.NoError(t, err)
+	require.Len(t, refreshes, 1)
+	assert.Equal(t, "config", refreshes[0].Name)
+	assert.Equal(t, path, refreshes[0].Source)
+	assert.NotEqual(t, refreshes[0].PreviousHash, refreshes[0].Hash)
+	assert.False(t, refreshes[0].Removed)
+
+	v, err := replMgr.GetVar(ctx, "config", 0, 0)
+	require.NoError(t, err)
+	assert.Equal(t, updated, v.Value)
+
+	info := loaded.Variables["config"]
+	assert.Equal(t, len(updated), info.Size)
+	assert.Equal(t, len(updated)/4, loaded.TotalTokens)
+	assert.False(t, info.RefreshedAt.IsZero())
+
+	notice := RefreshNotice(refreshes)
+	assert.Contains(t, notice, "`config`: reloaded from "+path)
+
+	// The refreshed state is the new baseline.
+	refreshes, err = loader.Refresh(ctx, loaded)
+	require.NoError(t, err)
+	assert.Empty(t, refreshes)
+}
+
+func TestContextLoader_Refresh_IgnoresTouchedFile(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	replMgr, err := repl.NewManager(repl.Options{})
+	require.NoError(t, err)
+	require.NoError(t, replMgr.Start(ctx))
+	defer replMgr.Stop()
+
+	loader := NewContextLoader(replMgr)
+	path, loaded := loadFileSource(t, ctx, loader, "notes", "unchanged\n")
+	rewriteFile(t, path, "unchanged\n")
+
+	refreshes, err := loader.Refresh(ctx, loaded)
+	require.NoError(t, err)
+	assert.Empty(t, refreshes)
+	assert.True(t, loaded.Variables["notes"].RefreshedAt.IsZero())
+}
+
+func TestContextLoader_Refresh_RemovedFile(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	replMgr, err := repl.NewManager(repl.Options{})
+	require.NoError(t, err)
+	require.NoError(t, replMgr.Start(ctx))
+	defer replMgr.Stop()
+
+	loader := NewContextLoader(replMgr)
+	path, loaded := loadFileSource(t, ctx, loader, "draft", "first draft\n")
+	require.NoError(t, os.Remove(path))
+
+	refreshes, err := loader.Refresh(ctx, loaded)
+	require.NoError(t, err)
+	require.Len(t, refreshes, 1)
+	assert.True(t, refreshes[0].Removed)
+	assert.Contains(t, RefreshNotice(refreshes), "was deleted")
+
+	v, err := replMgr.GetVar(ctx, "draft", 0, 0)
+	require.NoError(t, err)
+	assert.Equal(t, "first draft\n", v.Value, "the last content is kept")
+
+	// A removed file is reported once.
+	refreshes, err = loader.Refresh(ctx, loaded)
+	require.NoError(t, err)
+	assert.Empty(t, refreshes)
+}
+
+func TestContextLoader_RefreshIfDue(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	replMgr, err := repl.NewManager(repl.Options{})
+	require.NoError(t, err)
+	require.NoError(t, replMgr.Start(ctx))
+	defer replMgr.Stop()
+
+	loader := NewContextLoader(replMgr)
+	assert.Equal(t, DefaultRefreshConfig(), loader.RefreshConfig())
+	path, loaded := loadFileSource(t, ctx, loader, "data", "1,2,3\n")
+
+	// Disabled: never checks.
+	loader.SetRefreshConfig(RefreshConfig{})
+	rewriteFile(t, path, "4,5,6\n")
+	refreshes, err := loader.RefreshIfDue(ctx, loaded)
+	require.NoError(t, err)
+	assert.Empty(t, refreshes)
+
+	// The first check is due at once, the next only after the interval.
+	loader.SetRefreshConfig(RefreshConfig{Interval: time.Hour})
+	refreshes, err = loader.RefreshIfDue(ctx, loaded)
+	require.NoError(t, err)
+	assert.Len(t, refreshes, 1)
+
+	rewriteFile(t, path, "7,8,9\n")
+	refreshes, err = loader.RefreshIfDue(ctx, loaded)
+	require.NoError(t, err)
+	assert.Empty(t, refreshes)
+
+	// On demand it is picked up regardless.
+	refreshes, err = loader.Refresh(ctx, loaded)
+	require.NoError(t, err)
+	assert.Len(t, refreshes, 1)
+}
+
+func TestContextLoader_Refresh_TracksOnlyFileSources(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	replMgr, err := repl.NewManager(repl.Options{})
+	require.NoError(t, err)
+	require.NoError(t, replMgr.Start(ctx))
+	defer replMgr.Stop()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "search.txt")
+	require.NoError(t, os.WriteFile(path, []byte("hit\n"), 0o644))
+
+	loader := NewContextLoader(replMgr)
+	loaded, err := loader.Load(ctx, []ContextSource{
+		{Name: "results", Content: "hit\n", Type: ContextTypeSearch, Metadata: map[string]any{"source": path}},
+		{Name: "virtual", Content: "x\n", Type: ContextTypeFile, Metadata: map[string]any{"source": filepath.Join(dir, "missing.txt")}},
+	})
+	require.NoError(t, err)
+	rewriteFile(t, path, "changed\n")
+
+	refreshes, err := loader.Refresh(ctx, loaded)
+	require.NoError(t, err)
+	assert.Empty(t, refreshes)
+
+	// Cleared variables are no longer checked.
+	filePath, loaded := loadFileSource(t, ctx, loader, "doc", "v1\n")
+	require.NoError(t, loader.ClearContext(ctx, []string{"doc"}))
+	rewriteFile(t, filePath, "v2\n")
+	refreshes, err = loader.Refresh(ctx, loaded)
+	require.NoError(t, err)
+	assert.Empty(t, refreshes)
+}
+
+func TestRefreshNotice_Empty(t *testing.T) {
+	assert.Empty(t, RefreshNotice(nil))
+}
+
+// =============================================================================
+// Tier Limit Tests
+// =============================================================================
+
+// routedBlockingClient routes prompts mentioning "fast" to the fast tier and
+// everything else to powerful, holding powerful calls until released.
+type routedBlockingClient struct {
+	release  chan struct{}
+	inFlight atomic.Int32
+}
+
+func (c *routedBlockingClient) ExplainRoute(_ context.Context, prompt string) *meta.RouteDecision {
+	if strings.Contains(prompt, "fast") {
+		return &meta.RouteDecision{Tier: meta.TierFast}
+	}
+	return &meta.RouteDecision{Tier: meta.TierPowerful}
+}
+
+func (c *routedBlockingClient) Complete(ctx context.Context, prompt string, _ int) (string, error) {
+	if strings.Contains(prompt, "fast") {
+		return "fast answer", nil
+	}
+	c.inFlight.Add(1)
+	defer c.inFlight.Add(-1)
+	select {
+	case <-c.release:
+		return "powerful answer", nil
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+func TestCore_SetTierLimiter(t *testing.T) {
+	client := &routedBlockingClient{release: make(chan struct{})}
+	core := NewCore(nil, client, nil, DefaultCoreConfig())
+	limiter := meta.NewTierLimiter(meta.TierLimits{meta.TierPowerful: 1})
+	core.SetTierLimiter(limiter)
+
+	done := make(chan error)
+	go func() {
+		_, err := core.mainClient.Complete(context.Background(), "analyze", 100)
+		done <- err
+	}()
+	require.Eventually(t, func() bool { return limiter.InFlight(meta.TierPowerful) == 1 }, time.Second, time.Millisecond)
+
+	// A second powerful call waits for the held slot; fast calls do not
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := core.mainClient.Complete(ctx, "analyze again", 100)
+	assert.ErrorContains(t, err, "waiting for powerful tier")
+
+	response, err := core.mainClient.Complete(context.Background(), "fast lookup", 100)
+	require.NoError(t, err)
+	assert.Equal(t, "fast answer", response)
+
+	close(client.release)
+	require.NoError(t, <-done)
+	assert.Zero(t, limiter.InFlight(meta.TierPowerful))
+}
+
+func TestTierLimitedClient_UnknownTierIsUncapped(t *testing.T) {
+	client := &tierLimitedClient{client: &countingClient{}}
+	_, ok := client.tier(context.Background(), "anything")
+	assert.False(t, ok)
+	tier, ok := client.tier(meta.WithMinTier(context.Background(), meta.TierFast), "anything")
+	require.True(t, ok)
+	assert.Equal(t, meta.TierFast, tier)
+
+	// A client without routing is never held back by the caps
+	client.limiter.Store(meta.NewTierLimiter(meta.TierLimits{meta.TierPowerful: 1, meta.TierFast: 1}))
+	for range 3 {
+		_, err := client.Complete(context.Background(), "anything", 100)
+		require.NoError(t, err)
+	}
+}
+
+// capsClient reports fixed capabilities.
+type capsClient struct {
+	countingClient
+	caps meta.Capabilities
+}
+
+func (c *capsClient) Capabilities() meta.Capabilities { return c.caps }
+
+func TestCore_MainClientForwardsCapabilities(t *testing.T) {
+	client := &capsClient{caps: meta.Capabilities{ContextWindow: 32000, MaxOutputTokens: 4096, Images: true, TierRouting: true}}
+	core := NewCore(nil, client, nil, DefaultCoreConfig())
+
+	// The wrappers forward only Complete, so images are not passed on
+	assert.Equal(t, meta.Capabilities{ContextWindow: 32000, MaxOutputTokens: 4096, TierRouting: true},
+		meta.CapabilitiesOf(core.mainClient))
+}
+
+// =============================================================================
+// Context Provider Tests
+// =============================================================================
+
+func TestFileContextProvider_FindsMatchingFiles(t *testing.T) {
+	root := t.TempDir()
+	write := func(rel, content string) {
+		path := filepath.Join(root, rel)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+	write("config/loader.go", "package config\n\nfunc LoadConfig() {}\n")
+	write("pricing/table.go", "package pricing\n\nvar prices = map[string]int{}\n")
+	write("README.md", "Nothing about it here.\n")
+	write(".git/config", "config loader\n")
+
+	provider := &FileContextProvider{Root: root}
+	sources, err := provider.ProvideContext(context.Background(), "the config loader")
+	require.NoError(t, err)
+	require.Len(t, sources, 1)
+	assert.Equal(t, ContextTypeFile, sources[0].Type)
+	assert.Equal(t, filepath.Join(root, "config", "loader.go"), sources[0].Metadata["source"])
+	assert.Contains(t, sources[0].Content, "LoadConfig")
+
+	sources, err = provider.ProvideContext(context.Background(), "the billing ledger")
+	require.NoError(t, err)
+	assert.Empty(t, sources)
+}
+
+func TestFileContextProvider_MaxFiles(t *testing.T) {
+	root := t.TempDir()
+	for i := range 4 {
+		name := filepath.Join(root, fmt.Sprintf("pricing_%d.txt", i))
+		require.NoError(t, os.WriteFile(name, []byte("pricing table"), 0o644))
+	}
+
+	sources, err := (&FileContextProvider{Root: root, MaxFiles: 2}).ProvideContext(context.Background(), "pricing table")
+	require.NoError(t, err)
+	assert.Len(t, sources, 2)
+}
+
+func TestMemoryContextProvider_FindsMatchingMemories(t *testing.T) {
+	ctx := context.Background()
+	store, err := hypergraph.NewStore(hypergraph.Options{})
+	require.NoError(t, err)
+	defer store.Close()
+
+	for _, content := range []string{
+		"The deploy pipeline runs integration tests before release",
+		"The deploy pipeline uses blue-green releases",
+		"Lunch is at noon",
+	} {
+		require.NoError(t, store.CreateNode(ctx, hypergraph.NewNode(hypergraph.NodeTypeFact, content)))
+	}
+
+	provider := &MemoryContextProvider{Store: store}
+	sources, err := provider.ProvideContext(ctx, "how the deploy pipeline releases")
+	require.NoError(t, err)
+	require.Len(t, sources, 1)
+	assert.Equal(t, ContextTypeMemory, sources[0].Type)
+	assert.Contains(t, sources[0].Content, "blue-green")
+	assert.Contains(t, sources[0].Content, "integration tests")
+	assert.NotContains(t, sources[0].Content, "Lunch")
+	assert.Len(t, sources[0].Metadata["nodes"], 2)
+
+	sources, err = provider.ProvideContext(ctx, "quarterly revenue")
+	require.NoError(t, err)
+	assert.Empty(t, sources)
+}
+
+// =============================================================================
+// Memory Query Tests
+// =============================================================================
+
+func TestExecuteMemoryQuery_FiltersByConfidence(t *testing.T) {
+	store, err := hypergraph.NewStore(hypergraph.Options{})
+	require.NoError(t, err)
+	defer store.Close()
+	ctx := context.Background()
+
+	seed := func(content string, confidence float64) {
+		node := hypergraph.NewNode(hypergraph.NodeTypeFact, content)
+		node.Confidence = confidence
+		require.NoError(t, store.CreateNode(ctx, node))
+	}
+	seed("The deploy pipeline runs on every merge", 0.95)
+	seed("The deploy pipeline uses blue-green releases", 0.6)
+	seed("The deploy pipeline was rewritten in Rust", 0.3)
+	seed("Lunch is served at noon", 0.99)
+
+	query := func(minConfidence float64) string {
+		cfg := DefaultCoreConfig()
+		cfg.MemoryMinConfidence = minConfidence
+		core := NewCore(nil, nil, store, cfg)
+		out, _, err := core.executeMemoryQuery(ctx, meta.State{}, &meta.Decision{
+			Action: meta.ActionMemoryQuery,
+			Params: meta.DecisionParams{Query: "deploy pipeline"},
+		})
+		require.NoError(t, err)
+		return out
+	}
+
+	out := query(0.5)
+	assert.Contains(t, out, "Found 2 relevant memories")
+	assert.NotContains(t, out, "Rust")
+	assert.NotContains(t, out, "Lunch")
+	assert.Contains(t, out, "- [fact] The deploy pipeline runs on every merge")
+	assert.Contains(t, out, "- [fact, uncertain: confidence 0.60] The deploy pipeline uses blue-green releases")
+	assert.Less(t, strings.Index(out, "every merge"), strings.Index(out, "blue-green"),
+		"more confident memories rank first")
+
+	out = query(0.8)
+	assert.Contains(t, out, "Found 1 relevant memories")
+	assert.NotContains(t, out, "blue-green")
+
+	out = query(0)
+	assert.Contains(t, out, "Found 3 relevant memories")
+	assert.Contains(t, out, "uncertain: confidence 0.30] The deploy pipeline was rewritten in Rust")
+
+	out = query(0.99)
+	assert.Equal(t, "No relevant memory found.", out)
+}
+
+func TestExecuteMemoryQuery_RanksByConfidenceTimesRelevance(t *testing.T) {
+	store, err := hypergraph.NewStore(hypergraph.Options{})
+	require.NoError(t, err)
+	defer store.Close()
+	ctx := context.Background()
+
+	partial := hypergraph.NewNode(hypergraph.NodeTypeFact, "Billing retries failed charges")
+	partial.Confidence = 1.0
+	require.NoError(t, store.CreateNode(ctx, partial))
+	full := hypergraph.NewNode(hypergraph.NodeTypeFact, "Billing retries failed invoices nightly")
+	full.Confidence = 0.8
+	require.NoError(t, store.CreateNode(ctx, full))
+
+	core := NewCore(nil, nil, store, DefaultCoreConfig())
+	out, _, err := core.executeMemoryQuery(ctx, meta.State{Task: "billing retries failed invoices"}, &meta.Decision{
+		Action: meta.ActionMemoryQuery,
+	})
+	require.NoError(t, err)
+
+	// 0.8 × 4/4 beats 1.0 × 3/4
+	assert.Less(t, strings.Index(out, "invoices nightly"), strings.Index(out, "failed charges"))
+}
+
+// =============================================================================
+// Fact Provenance Tests
+// =============================================================================
+
+func TestWithExecution_SharesOuterExecution(t *testing.T) {
+	store, err := hypergraph.NewStore(hypergraph.Options{})
+	require.NoError(t, err)
+	defer store.Close()
+
+	ctx, exec := WithExecution(context.Background(), store, "outer task")
+	require.NotNil(t, exec)
+	inner, innerExec := WithExecution(ctx, store, "escalated task")
+	assert.Same(t, exec, innerExec)
+	assert.Same(t, exec, ExecutionFrom(inner))
+
+	// Nothing is created until a fact needs the node
+	n, err := store.CountNodes(ctx, hypergraph.NodeFilter{Subtypes: []string{executionSubtype}})
+	require.NoError(t, err)
+	assert.Zero(t, n)
+
+	fact := hypergraph.NewNode(hypergraph.NodeTypeFact, "config lives in app.yaml")
+	require.NoError(t, store.CreateNode(ctx, fact))
+	require.NoError(t, LinkProvenance(inner, fact.ID))
+
+	execID, err := exec.NodeID(ctx)
+	require.NoError(t, err)
+	connected, err := store.GetConnected(ctx, fact.ID, hypergraph.TraversalOptions{MaxDepth: 1})
+	require.NoError(t, err)
+	require.Len(t, connected, 1)
+	assert.Equal(t, execID, connected[0].Node.ID)
+	assert.Equal(t, "outer task", connected[0].Node.Content)
+
+	// Without a store, or an execution, there is nothing to link to
+	plain, none := WithExecution(context.Background(), nil, "task")
+	assert.Nil(t, none)
+	assert.Nil(t, ExecutionFrom(plain))
+	assert.NoError(t, LinkProvenance(plain, fact.ID))
+}
+
+// =============================================================================
+// Action Timeout Tests
+// =============================================================================
+
+// hangingClient answers meta-controller prompts with metaResponse and
+// blocks the first hangs answer calls until release is closed, ignoring
+// their context like a stuck connection would.
+type hangingClient struct {
+	metaResponse string
+	answer       string
+	hangs        int32
+	calls        atomic.Int32
+	release      chan struct{}
+}
+
+func (c *hangingClient) Complete(ctx context.Context, prompt string, maxTokens int) (string, error) {
+	if strings.Contains(prompt, "Current state:") {
+		return c.metaResponse, nil
+	}
+	if c.calls.Add(1) <= c.hangs {
+		<-c.release
+	}
+	return c.answer, nil
+}
+
+func TestCore_Execute_ActionTimeoutTriggersRecovery(t *testing.T) {
+	store, err := hypergraph.NewStore(hypergraph.Options{})
+	require.NoError(t, err)
+	defer store.Close()
+
+	client := &hangingClient{
+		metaResponse: `{"action": "DIRECT", "reasoning": "simple"}`,
+		answer:       "direct answer",
+		hangs:        2,
+		release:      make(chan struct{}),
+	}
+	t.Cleanup(func() { close(client.release) })
+
+	cfg := DefaultCoreConfig()
+	cfg.StoreDecisions = false
+	cfg.Recovery.RetryDelay = time.Millisecond
+	cfg.ActionTimeouts = ActionTimeouts{Direct: 50 * time.Millisecond}
+	core := NewCore(meta.NewController(client, meta.Config{}), client, store, cfg)
+
+	start := time.Now()
+	result, err := core.Execute(context.Background(), "what is 2+2")
+	require.NoError(t, err)
+	assert.Less(t, time.Since(start), 2*time.Second, "hung action fails fast")
+
+	// The first attempt and its retry time out; degradation then answers
+	assert.Equal(t, "direct answer", result.Response)
+	assert.Equal(t, []string{"DIRECT", "DIRECT"}, result.TimedOutActions)
+
+	history := core.recovery.ErrorHistory()
+	require.Len(t, history, 2)
+	for _, record := range history {
+		assert.Equal(t, ErrorCategoryTimeout, record.Category)
+		assert.Equal(t, "DIRECT", record.Action)
+		assert.Contains(t, record.Error, "DIRECT action timed out after 50ms")
+	}
+	assert.True(t, history[1].Degraded)
+}
+
+func TestCore_RunWithTimeout(t *testing.T) {
+	cfg := DefaultCoreConfig()
+	cfg.ActionTimeouts = ActionTimeouts{MemoryQuery: 20 * time.Millisecond, Subcall: time.Hour}
+	core := NewCore(nil, nil, nil, cfg)
+	hang := make(chan struct{})
+	t.Cleanup(func() { close(hang) })
+	stuck := func(context.Context) (string, int, error) {
+		<-hang
+		return "late", 0, nil
+	}
+
+	ctx, stats := withExecStats(context.Background())
+	_, _, err := core.runWithTimeout(ctx, meta.ActionMemoryQuery, stuck)
+	var timeoutErr *ActionTimeoutError
+	require.ErrorAs(t, err, &timeoutErr)
+	assert.Equal(t, meta.ActionMemoryQuery, timeoutErr.Action)
+	assert.ErrorIs(t, err, ErrActionTimeout)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, ErrorCategoryTimeout, core.recovery.ClassifyError(err))
+	assert.Equal(t, []string{"MEMORY_QUERY"}, stats.timedOutActions())
+
+	// The overall deadline is the caller's error, not an action timeout
+	deadline, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	_, _, err = core.runWithTimeout(deadline, meta.ActionSubcall, stuck)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.NotErrorIs(t, err, ErrActionTimeout)
+
+	// Actions without a timeout run inline and unbounded
+	response, _, err := core.runWithTimeout(ctx, meta.ActionExecute, func(context.Context) (string, int, error) {
+		return "ok", 1, nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "ok", response)
+	assert.Equal(t, []string{"MEMORY_QUERY"}, stats.timedOutActions())
+}
+
+func TestActionTimeouts_For(t *testing.T) {
+	timeouts := ActionTimeouts{
+		Direct:      1 * time.Second,
+		Decompose:   2 * time.Second,
+		MemoryQuery: 3 * time.Second,
+		Subcall:     4 * time.Second,
+		Synthesize:  5 * time.Second,
+	}
+	assert.Equal(t, 1*time.Second, timeouts.For(meta.ActionDirect))
+	assert.Equal(t, 2*time.Second, timeouts.For(meta.ActionDecompose))
+	assert.Equal(t, 3*time.Second, timeouts.For(meta.ActionMemoryQuery))
+	assert.Equal(t, 4*time.Second, timeouts.For(meta.ActionSubcall))
+	assert.Equal(t, 5*time.Second, timeouts.For(meta.ActionSynthesize))
+	assert.Zero(t, timeouts.For(meta.ActionExecute))
+}
+
+// seedOutcomes records trials execution summaries of action on tasks made
+// by task, the first successes of them successful.
+func seedOutcomes(t *testing.T, store *hypergraph.Store, task func(int) string, action meta.Action, trials, successes int) {
+	t.Helper()
+	for i := 0; i < trials; i++ {
+		result := &ExecutionResult{Task: task(i), Action: string(action)}
+		if i >= successes {
+			result.Error = "subtasks failed"
+		}
+		_, err := RecordExecutionSummary(context.Background(), store, result)
+		require.NoError(t, err)
+	}
+}
+
+func TestCore_Execute_WarmStart(t *testing.T) {
+	shortTask := func(i int) string { return fmt.Sprintf("rename variable %d", i) }
+	longTask := func(i int) string {
+		return fmt.Sprintf("review module %d: ", i) + strings.Repeat("check every handler for missing error checks. ", 50)
+	}
+	client := &scriptedClient{
+		metaResponse: `{"action": "DECOMPOSE", "params": {"strategy": "concept"}, "reasoning": "split it"}`,
+		answer:       "done",
+	}
+	execute := func(t *testing.T, store *hypergraph.Store, warmStart WarmStartConfig, task string) *ExecutionResult {
+		cfg := DefaultCoreConfig()
+		cfg.MaxRecursionDepth = 1
+		cfg.StoreDecisions = false
+		cfg.WarmStart = warmStart
+		core := NewCore(meta.NewController(client, meta.Config{}), client, store, cfg)
+		result, err := core.Execute(context.Background(), task)
+		require.NoError(t, err)
+		return result
+	}
+	newStore := func(t *testing.T) *hypergraph.Store {
+		store, err := hypergraph.NewStore(hypergraph.Options{})
+		require.NoError(t, err)
+		t.Cleanup(func() { store.Close() })
+		return store
+	}
+
+	t.Run("disfavoured action is avoided for matching states", func(t *testing.T) {
+		store := newStore(t)
+		seedOutcomes(t, store, shortTask, meta.ActionDecompose, 20, 2)
+		seedOutcomes(t, store, shortTask, meta.ActionDirect, 20, 18)
+		seedOutcomes(t, store, longTask, meta.ActionDecompose, 10, 9)
+
+		history, err := ActionHistory(context.Background(), store, PromptShort)
+		require.NoError(t, err)
+		assert.Equal(t, ActionRecord{Trials: 20, Successes: 2}, history[meta.ActionDecompose])
+		assert.Equal(t, ActionRecord{Trials: 20, Successes: 18}, history[meta.ActionDirect])
+
+		result := execute(t, store, WarmStartConfig{Weight: 0.8}, "rename variable total")
+		assert.Equal(t, "DIRECT", result.Action)
+
+		// Long prompts decomposed well, so the proposal stands
+		result = execute(t, store, WarmStartConfig{Weight: 0.8}, longTask(99))
+		assert.Equal(t, "DECOMPOSE", result.Action)
+
+		// Disabled, the proposal stands
+		result = execute(t, store, WarmStartConfig{}, "rename variable total")
+		assert.Equal(t, "DECOMPOSE", result.Action)
+	})
+
+	t.Run("default weight shifts away from a disfavoured action", func(t *testing.T) {
+		store := newStore(t)
+		seedOutcomes(t, store, shortTask, meta.ActionDecompose, 20, 2)
+		seedOutcomes(t, store, shortTask, meta.ActionDirect, 20, 18)
+
+		result := execute(t, store, DefaultWarmStartConfig(), "rename variable total")
+		assert.Equal(t, "DIRECT", result.Action)
+	})
+
+	t.Run("mixed history at the default weight leaves the proposal", func(t *testing.T) {
+		store := newStore(t)
+		seedOutcomes(t, store, shortTask, meta.ActionDecompose, 10, 6)
+		seedOutcomes(t, store, shortTask, meta.ActionDirect, 10, 8)
+
+		result := execute(t, store, DefaultWarmStartConfig(), "rename variable total")
+		assert.Equal(t, "DECOMPOSE", result.Action)
+	})
+
+	t.Run("sparse history leaves the proposal", func(t *testing.T) {
+		store := newStore(t)
+		seedOutcomes(t, store, shortTask, meta.ActionDecompose, 3, 0)
+		seedOutcomes(t, store, shortTask, meta.ActionDirect, 3, 3)
+
+		result := execute(t, store, WarmStartConfig{Weight: 0.8}, "rename variable total")
+		assert.Equal(t, "DECOMPOSE", result.Action)
+	})
+}
+
+// =============================================================================
+// Context Overflow Tests
+// =============================================================================
+
+// overflowClient answers meta-controller prompts with DIRECT and fails
+// answer prompts longer than limit characters the way Anthropic reports
+// an overflowing prompt.
+type overflowClient struct {
+	limit     int
+	overflows atomic.Int32
+	answers   atomic.Int32
+
+	mu       sync.Mutex
+	answered []string
+}
+
+func (c *overflowClient) Complete(ctx context.Context, prompt string, maxTokens int) (string, error) {
+	if strings.Contains(prompt, "Current state:") {
+		return `{"action": "DIRECT", "reasoning": "answer directly"}`, nil
+	}
+	if len(prompt) > c.limit {
+		c.overflows.Add(1)
+		return "", fmt.Errorf("anthropic generate: %w", &fantasy.ProviderError{
+			URL:        "https://api.anthropic.com/v1/messages",
+			StatusCode: 400,
+			Title:      "invalid_request_error",
+			Message:    fmt.Sprintf("prompt is too long: %d tokens > %d maximum", len(prompt)/4, c.limit/4),
+		})
+	}
+	c.answers.Add(1)
+	c.mu.Lock()
+	c.answered = append(c.answered, prompt)
+	c.mu.Unlock()
+	return "answered part", nil
+}
+
+func overflowTask(paragraphs int) string {
+	var sb strings.Builder
+	sb.WriteString("Summarize the incident log.\n\n")
+	for i := range paragraphs {
+		fmt.Fprintf(&sb, "Entry %d: %s\n\n", i, strings.Repeat("service degraded and recovered ", 12))
+	}
+	return sb.String()
+}
+
+func TestCore_Execute_ContextOverflowRetriesChunked(t *testing.T) {
+	client := &overflowClient{limit: 2700}
+	cfg := DefaultCoreConfig()
+	cfg.StoreDecisions = false
+	cfg.Recovery.RetryDelay = time.Millisecond
+	store, err := hypergraph.NewStore(hypergraph.Options{})
+	require.NoError(t, err)
+	defer store.Close()
+	core := NewCore(meta.NewController(client, meta.Config{}), client, store, cfg)
+
+	task := overflowTask(10)
+	require.Greater(t, len(task), client.limit)
+
+	result, err := core.Execute(context.Background(), task)
+	require.NoError(t, err)
+	assert.NotEmpty(t, result.Response)
+
+	// Only the whole task overflowed; its halves were answered
+	assert.Equal(t, int32(1), client.overflows.Load())
+	assert.GreaterOrEqual(t, client.answers.Load(), int32(2))
+	for _, prompt := range client.answered {
+		assert.Contains(t, prompt, "Summarize the incident log.", "every part carries the question")
+	}
+	assert.Equal(t, []ContextOverflowRecovery{
+		{Action: "DIRECT", Provider: "anthropic", Depth: 0, Recovered: true},
+	}, result.ContextOverflows)
+
+	history := core.recovery.ErrorHistory()
+	require.NotEmpty(t, history)
+	assert.Equal(t, ErrorCategoryContextOverflow, history[0].Category)
+	assert.True(t, history[0].Recovered)
+}
+
+func TestCore_Execute_ContextOverflowUnsplittable(t *testing.T) {
+	client := &overflowClient{limit: 500}
+	cfg := DefaultCoreConfig()
+	cfg.StoreDecisions = false
+	cfg.Recovery.RetryDelay = time.Millisecond
+	store, err := hypergraph.NewStore(hypergraph.Options{})
+	require.NoError(t, err)
+	defer store.Close()
+	core := NewCore(meta.NewController(client, meta.Config{}), client, store, cfg)
+
+	// One paragraph cannot be split along paragraph boundaries
+	_, err = core.Execute(context.Background(), strings.Repeat("one long line of log output ", 40))
+	require.Error(t, err)
+	assert.ErrorIs(t, err, meta.ErrContextOverflow)
+}
+
+func TestRecoveryManager_ContextOverflow(t *testing.T) {
+	m := NewRecoveryManager(DefaultRecoveryConfig())
+	overflow := fmt.Errorf("main %w: %w", ErrLLMCall, &meta.OpenAIAPIError{
+		StatusCode: 400,
+		Message:    "This model's maximum context length is 8192 tokens",
+	})
+	assert.Equal(t, ErrorCategoryContextOverflow, m.ClassifyError(overflow), "overflow wins over the retryable LLM call")
+
+	state := meta.State{RecursionDepth: 0, MaxDepth: 5}
+	action := m.DetermineAction(overflow, meta.ActionDirect, state)
+	assert.True(t, action.Chunked)
+	assert.False(t, action.ShouldRetry)
+	assert.False(t, action.Degraded)
+
+	// Not while decomposing, nor at the depth limit
+	assert.False(t, m.DetermineAction(overflow, meta.ActionDecompose, state).Chunked)
+	assert.False(t, m.DetermineAction(overflow, meta.ActionDirect, meta.State{RecursionDepth: 5, MaxDepth: 5}).Chunked)
+
+	// A rate limit mentioning tokens is still retryable
+	rateLimited := fmt.Errorf("main %w: %w", ErrLLMCall, &meta.OpenAIAPIError{StatusCode: 429, Message: "maximum context length per minute"})
+	assert.Equal(t, ErrorCategoryRetryable, m.ClassifyError(rateLimited))
+}
+
+// =============================================================================
+// Execution Retry Tests
+// =============================================================================
+
+// flakyClient fails its first failures calls with err, then answers as a
+// scriptedClient.
+type flakyClient struct {
+	scriptedClient
+	failures int
+	err      error
+	calls    atomic.Int64
+}
+
+func (c *flakyClient) Complete(ctx context.Context, prompt string, maxTokens int) (string, error) {
+	if int(c.calls.Add(1)) <= c.failures {
+		return "", c.err
+	}
+	return c.scriptedClient.Complete(ctx, prompt, maxTokens)
+}
+
+// interleavedClient fails the "broken" task every time, and the "flaky"
+// task once, after waiting for brokenDone.
+type interleavedClient struct {
+	scriptedClient
+	flakyStarted chan struct{}
+	brokenDone   chan struct{}
+	flakyCalls   atomic.Int64
+}
+
+func (c *interleavedClient) Complete(ctx context.Context, prompt string, maxTokens int) (string, error) {
+	switch {
+	case strings.Contains(prompt, "Current state:"):
+	case strings.Contains(prompt, "broken"):
+		return "", errors.New("provider returned 503: service unavailable")
+	case strings.Contains(prompt, "flaky") && c.flakyCalls.Add(1) == 1:
+		close(c.flakyStarted)
+		<-c.brokenDone
+		return "", errors.New("provider returned 503: service unavailable")
+	}
+	return c.scriptedClient.Complete(ctx, prompt, maxTokens)
+}
+
+func TestCore_Execute_RetriesCountedPerTask(t *testing.T) {
+	client := &interleavedClient{
+		scriptedClient: scriptedClient{metaResponse: `{"action": "DIRECT", "reasoning": "simple"}`, answer: "the answer"},
+		flakyStarted:   make(chan struct{}),
+		brokenDone:     make(chan struct{}),
+	}
+	core := newRetryCore(t, client, 1)
+	core.config.Recovery.EnableDegradation = false
+	core.recovery = NewRecoveryManager(core.config.Recovery)
+
+	type outcome struct {
+		result *ExecutionResult
+		err    error
+	}
+	flaky := make(chan outcome, 1)
+	go func() {
+		result, err := core.Execute(context.Background(), "Answer the flaky question")
+		flaky <- outcome{result, err}
+	}()
+
+	// The broken task uses up its retries while the flaky one is in flight.
+	<-client.flakyStarted
+	_, err := core.Execute(context.Background(), "Answer the broken question")
+	require.Error(t, err)
+	close(client.brokenDone)
+
+	got := <-flaky
+	require.NoError(t, got.err, "the flaky task still has its own retry")
+	assert.Equal(t, "the answer", got.result.Response)
+}
+
+// countingPreparer counts the context preparations it makes.
+type countingPreparer struct {
+	calls int
+}
+
+func (p *countingPreparer) PrepareContext(ctx context.Context, task string, contextTokens int) (*PreparedContext, error) {
+	p.calls++
+	return &PreparedContext{Mode: "rlm", SystemPrompt: "context in REPL", Externalized: true, ExternalizedTokens: 5000}, nil
+}
+
+func newRetryCore(t *testing.T, client meta.LLMClient, attempts int) *Core {
+	t.Helper()
+	store, err := hypergraph.NewStore(hypergraph.Options{})
+	require.NoError(t, err)
+	t.Cleanup(func() { store.Close() })
+
+	cfg := DefaultCoreConfig()
+	cfg.StoreDecisions = false
+	cfg.Recovery.RetryDelay = time.Millisecond
+	cfg.ExecutionRetry = ExecutionRetry{MaxAttempts: attempts, Backoff: time.Millisecond}
+	return NewCore(meta.NewController(client, meta.DefaultConfig()), client, store, cfg)
+}
+
+func TestCore_Execute_RetriesTransientFailure(t *testing.T) {
+	client := &flakyClient{
+		scriptedClient: scriptedClient{metaResponse: `{"action": "DIRECT", "reasoning": "simple"}`, answer: "the answer"},
+		failures:       2,
+		err:            errors.New("provider returned 503: service unavailable"),
+	}
+	core := newRetryCore(t, client, 3)
+	preparer := &countingPreparer{}
+	core.SetContextPreparer(preparer)
+
+	result, err := core.Execute(context.Background(), "What is the answer?")
+	require.NoError(t, err)
+	assert.Equal(t, "the answer", result.Response)
+	assert.Equal(t, 2, result.ExecutionRetries)
+	assert.Equal(t, "rlm", result.Mode)
+	assert.Equal(t, 1, preparer.calls, "externalized context is reused by retries")
+
+	t.Run("gives up after MaxAttempts", func(t *testing.T) {
+		client.calls.Store(0)
+		client.failures = 100
+		result, err := newRetryCore(t, client, 3).Execute(context.Background(), "What is the answer?")
+		require.Error(t, err)
+		assert.Equal(t, 2, result.ExecutionRetries)
+		assert.EqualValues(t, 3, client.calls.Load())
+	})
+}
+
+// answerFlakyClient fails its first failures answer calls with a
+// transient error, and reports usage for the calls it answers.
+type answerFlakyClient struct {
+	scriptedClient
+	failures    int
+	answerCalls atomic.Int64
+}
+
+func (c *answerFlakyClient) Complete(ctx context.Context, prompt string, maxTokens int) (string, error) {
+	if !strings.Contains(prompt, "Current state:") && int(c.answerCalls.Add(1)) <= c.failures {
+		return "", errors.New("provider returned 503: service unavailable")
+	}
+	meta.RecordUsage(ctx, meta.Usage{PromptTokens: 100, CompletionTokens: 10})
+	return c.scriptedClient.Complete(ctx, prompt, maxTokens)
+}
+
+func TestCore_Execute_RetryUsageCountsFailedAttempts(t *testing.T) {
+	script := scriptedClient{metaResponse: `{"action": "DIRECT", "reasoning": "simple"}`, answer: "the answer"}
+	core := newRetryCore(t, &answerFlakyClient{scriptedClient: script}, 3)
+	core.config.Recovery.EnableDegradation = false
+	core.recovery = NewRecoveryManager(core.config.Recovery)
+	single, err := core.Execute(context.Background(), "What is the answer?")
+	require.NoError(t, err)
+	require.Positive(t, single.InputTokens)
+
+	// The first attempt fails even after its in-loop retry
+	core = newRetryCore(t, &answerFlakyClient{scriptedClient: script, failures: 2}, 3)
+	core.config.Recovery.EnableDegradation = false
+	core.recovery = NewRecoveryManager(core.config.Recovery)
+	retried, err := core.Execute(context.Background(), "What is the answer?")
+	require.NoError(t, err)
+	require.Equal(t, 1, retried.ExecutionRetries)
+
+	// The failed attempt's meta decision is charged too
+	assert.Greater(t, retried.InputTokens, single.InputTokens)
+	assert.Greater(t, retried.OutputTokens, single.OutputTokens)
+}
+
+func TestCore_Execute_TerminalFailureNotRetried(t *testing.T) {
+	// The meta decision fails outright
+	client := &flakyClient{
+		scriptedClient: scriptedClient{answer: "the answer"},
+		failures:       100,
+		err:            errors.New("401 unauthorized"),
+	}
+	result, err := newRetryCore(t, client, 3).Execute(context.Background(), "What is the answer?")
+	require.Error(t, err)
+	assert.Zero(t, result.ExecutionRetries)
+	assert.EqualValues(t, 1, client.calls.Load())
+
+	// The answer's LLM call fails for a cause not worth retrying, within
+	// the execution or by re-running it
+	answerFails := &answerFailingClient{
+		scriptedClient: scriptedClient{metaResponse: `{"action": "DIRECT", "reasoning": "simple"}`},
+		err:            errors.New("401 unauthorized"),
+	}
+	result, err = newRetryCore(t, answerFails, 3).Execute(context.Background(), "What is the answer?")
+	require.ErrorIs(t, err, ErrLLMCall)
+	assert.Zero(t, result.ExecutionRetries)
+	assert.EqualValues(t, 1, answerFails.metaCalls.Load())
+}
+
+// answerFailingClient answers meta-controller prompts and fails every
+// answer call with err.
+type answerFailingClient struct {
+	scriptedClient
+	err       error
+	metaCalls atomic.Int64
+}
+
+func (c *answerFailingClient) Complete(ctx context.Context, prompt string, maxTokens int) (string, error) {
+	if strings.Contains(prompt, "Current state:") {
+		c.metaCalls.Add(1)
+		return c.metaResponse, nil
+	}
+	return "", c.err
+}
+
+func TestExecutionRetry_Delay(t *testing.T) {
+	policy := ExecutionRetry{MaxAttempts: 5, Backoff: time.Second, MaxBackoff: 3 * time.Second}
+	assert.Equal(t, time.Second, policy.delay(1))
+	assert.Equal(t, 2*time.Second, policy.delay(2))
+	assert.Equal(t, 3*time.Second, policy.delay(3))
+	assert.Equal(t, DefaultRetryBackoff, ExecutionRetry{}.delay(1))
+	assert.False(t, ExecutionRetry{MaxAttempts: 1}.Enabled())
+}
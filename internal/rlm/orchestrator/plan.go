@@ -0,0 +1,268 @@
+package orchestrator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/rand/recurse/internal/memory/hypergraph"
+	"github.com/rand/recurse/internal/rlm/decompose"
+	"github.com/rand/recurse/internal/rlm/meta"
+	"github.com/rand/recurse/internal/rlm/synthesize"
+)
+
+const (
+	// planSubtype marks decision nodes holding a decomposition plan.
+	planSubtype = "plan"
+
+	// planStepSubtype marks the subtask nodes a plan links to.
+	planStepSubtype = "plan_step"
+
+	// strategyStreaming records plans produced by a streaming decomposer.
+	strategyStreaming = "streaming"
+
+	// strategyOverflow records plans that split a task after it
+	// overflowed the context window.
+	strategyOverflow = "overflow"
+
+	// minPlanSimilarity is the keyword overlap a stored plan's task needs
+	// with a new task to be considered similar.
+	minPlanSimilarity = 0.5
+
+	// maxPlanCandidates bounds how many recent plans are compared.
+	maxPlanCandidates = 200
+)
+
+// Plan is a decomposition that completed successfully, kept so similar
+// tasks can reuse how it was broken down.
+type Plan struct {
+	// ID is the plan's hypergraph node ID.
+	ID string `json:"id"`
+
+	// Task is the decomposed task.
+	Task string `json:"task"`
+
+	// Strategy is the decomposition strategy (file, function, concept,
+	// custom, or streaming).
+	Strategy string `json:"strategy"`
+
+	// Steps are the subtasks in execution order.
+	Steps []PlanStep `json:"steps"`
+
+	// Confidence is the synthesized answer's confidence, zero if unknown.
+	Confidence float64 `json:"confidence,omitempty"`
+
+	// Tokens is the tokens used by the subtasks and synthesis.
+	Tokens int `json:"tokens"`
+
+	// CreatedAt is when the plan was recorded.
+	CreatedAt time.Time `json:"created_at"`
+
+	// Similarity is the keyword overlap (0.0 to 1.0) with the task passed
+	// to FindSimilarPlan.
+	Similarity float64 `json:"similarity,omitempty"`
+}
+
+// PlanStep is one subtask of a plan.
+type PlanStep struct {
+	ID         string   `json:"id"`
+	Name       string   `json:"name,omitempty"`
+	DependsOn  []string `json:"depends_on,omitempty"`
+	Tokens     int      `json:"tokens"`
+	Confidence float64  `json:"confidence,omitempty"`
+}
+
+// planMetadata is the plan node's metadata; the task is its content.
+type planMetadata struct {
+	Strategy   string     `json:"strategy"`
+	Steps      []PlanStep `json:"steps"`
+	Confidence float64    `json:"confidence,omitempty"`
+	Tokens     int        `json:"tokens"`
+}
+
+// recordPlan stores a top-level decomposition whose subtasks all succeeded
+// as a plan node linked to a node per subtask.
+func (c *Core) recordPlan(ctx context.Context, state meta.State, strategy string, chunks []decompose.Chunk, results []synthesize.SubCallResult, confidence float64, tokens int) {
+	if c.store == nil || !c.config.StoreDecisions || state.RecursionDepth != 0 || len(results) == 0 {
+		return
+	}
+	for _, r := range results {
+		if r.Error != "" {
+			return
+		}
+	}
+
+	dependsOn := make(map[string][]string, len(chunks))
+	for _, chunk := range chunks {
+		dependsOn[chunk.ID] = chunk.DependsOn
+	}
+	steps := make([]PlanStep, len(results))
+	for i, r := range results {
+		steps[i] = PlanStep{
+			ID:         r.ID,
+			Name:       r.Name,
+			DependsOn:  dependsOn[r.ID],
+			Tokens:     r.TokensUsed,
+			Confidence: r.Confidence,
+		}
+	}
+
+	if err := c.storePlan(ctx, state.Task, planMetadata{
+		Strategy:   strategy,
+		Steps:      steps,
+		Confidence: confidence,
+		Tokens:     tokens,
+	}); err != nil {
+		slog.Warn("Failed to store decomposition plan", "error", err)
+	}
+}
+
+func (c *Core) storePlan(ctx context.Context, task string, plan planMetadata) error {
+	metadata, err := json.Marshal(plan)
+	if err != nil {
+		return fmt.Errorf("marshal plan: %w", err)
+	}
+	node := hypergraph.NewNode(hypergraph.NodeTypeDecision, task)
+	node.Subtype = planSubtype
+	node.Metadata = metadata
+	if plan.Confidence > 0 {
+		node.Confidence = plan.Confidence
+	}
+	if err := c.store.CreateNode(ctx, node); err != nil {
+		return fmt.Errorf("create plan node: %w", err)
+	}
+
+	edge := hypergraph.NewHyperedge(hypergraph.HyperedgeComposition, "plan")
+	if err := c.store.CreateHyperedge(ctx, edge); err != nil {
+		return fmt.Errorf("create plan edge: %w", err)
+	}
+	if err := c.store.AddMember(ctx, hypergraph.Membership{
+		HyperedgeID: edge.ID,
+		NodeID:      node.ID,
+		Role:        hypergraph.RoleSubject,
+	}); err != nil {
+		return fmt.Errorf("link plan: %w", err)
+	}
+	for i, step := range plan.Steps {
+		stepNode := hypergraph.NewNode(hypergraph.NodeTypeDecision, step.Name)
+		if step.Name == "" {
+			stepNode.Content = step.ID
+		}
+		stepNode.Subtype = planStepSubtype
+		if err := c.store.CreateNode(ctx, stepNode); err != nil {
+			return fmt.Errorf("create plan step node: %w", err)
+		}
+		if err := c.store.AddMember(ctx, hypergraph.Membership{
+			HyperedgeID: edge.ID,
+			NodeID:      stepNode.ID,
+			Role:        hypergraph.RoleParticipant,
+			Position:    i + 1,
+		}); err != nil {
+			return fmt.Errorf("link plan step: %w", err)
+		}
+	}
+	return nil
+}
+
+// FindSimilarPlan returns the recorded plan whose task is most similar to
+// task, preferring the newest on ties, or nil if none is similar enough.
+func (c *Core) FindSimilarPlan(ctx context.Context, task string) (*Plan, error) {
+	if c.store == nil {
+		return nil, nil
+	}
+	nodes, err := c.store.ListNodes(ctx, hypergraph.NodeFilter{
+		Types:    []hypergraph.NodeType{hypergraph.NodeTypeDecision},
+		Subtypes: []string{planSubtype},
+		Limit:    maxPlanCandidates,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list plans: %w", err)
+	}
+
+	keywords := planKeywords(task)
+	var best *hypergraph.Node
+	var bestSimilarity float64
+	for _, node := range nodes {
+		if similarity := jaccard(keywords, planKeywords(node.Content)); similarity > bestSimilarity {
+			best, bestSimilarity = node, similarity
+		}
+	}
+	if best == nil || bestSimilarity < minPlanSimilarity {
+		return nil, nil
+	}
+
+	var metadata planMetadata
+	if err := json.Unmarshal(best.Metadata, &metadata); err != nil {
+		return nil, fmt.Errorf("decode plan %s: %w", best.ID, err)
+	}
+	c.store.IncrementAccess(ctx, best.ID)
+
+	return &Plan{
+		ID:         best.ID,
+		Task:       best.Content,
+		Strategy:   metadata.Strategy,
+		Steps:      metadata.Steps,
+		Confidence: metadata.Confidence,
+		Tokens:     metadata.Tokens,
+		CreatedAt:  best.CreatedAt,
+		Similarity: bestSimilarity,
+	}, nil
+}
+
+// seedStrategy fills in a missing decomposition strategy from a similar
+// plan, so a task decomposes the way a similar one did successfully.
+func (c *Core) seedStrategy(ctx context.Context, state meta.State, decision *meta.Decision) meta.DecomposeStrategy {
+	if decision.Params.Strategy != "" || state.RecursionDepth != 0 || c.store == nil {
+		return decision.Params.Strategy
+	}
+	plan, err := c.FindSimilarPlan(ctx, state.Task)
+	if err != nil || plan == nil || plan.Strategy == strategyStreaming || plan.Strategy == strategyOverflow {
+		return decision.Params.Strategy
+	}
+	slog.Info("Reusing decomposition strategy from similar plan",
+		"plan", plan.ID,
+		"strategy", plan.Strategy,
+		"similarity", plan.Similarity)
+	return meta.DecomposeStrategy(plan.Strategy)
+}
+
+// planStopWords are ignored when comparing tasks.
+var planStopWords = map[string]bool{
+	"the": true, "and": true, "for": true, "with": true, "this": true,
+	"that": true, "these": true, "those": true, "from": true, "into": true,
+	"what": true, "how": true, "why": true, "are": true, "each": true,
+	"all": true, "its": true, "their": true, "please": true,
+}
+
+// planKeywords returns the distinct lowercase words of at least three
+// characters in s, minus stop words.
+func planKeywords(s string) map[string]bool {
+	words := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	})
+	keywords := make(map[string]bool, len(words))
+	for _, w := range words {
+		if len(w) >= 3 && !planStopWords[w] {
+			keywords[w] = true
+		}
+	}
+	return keywords
+}
+
+// jaccard returns |a ∩ b| / |a ∪ b|, or zero when both are empty.
+func jaccard(a, b map[string]bool) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 0
+	}
+	shared := 0
+	for w := range a {
+		if b[w] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(a)+len(b)-shared)
+}
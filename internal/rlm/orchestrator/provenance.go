@@ -0,0 +1,120 @@
+package orchestrator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/rand/recurse/internal/memory/hypergraph"
+)
+
+// DerivedFromLabel labels the relation hyperedge linking a fact (subject)
+// to the execution it was recorded during (object). GetConnected from a
+// fact reaches its execution; from an execution, traversing incoming edges
+// reaches the facts it produced.
+const DerivedFromLabel = "derived_from"
+
+// executionSubtype marks the decision node recording an execution.
+const executionSubtype = "rlm_execution"
+
+type executionKey struct{}
+
+// Execution is the provenance anchor of one running execution: the
+// decision node that facts recorded during it are derived from. The node
+// is created on first use, so it exists before the execution ends. An
+// Execution travels in the context; its methods are safe for concurrent
+// use.
+type Execution struct {
+	store *hypergraph.Store
+	task  string
+
+	mu     sync.Mutex
+	nodeID string
+}
+
+// WithExecution returns a context carrying the execution of task, whose
+// node is created in store. A context already carrying an execution is
+// returned unchanged with that execution, so escalations and subtasks
+// share the node of the execution they belong to. With a nil store it
+// returns ctx and nil.
+func WithExecution(ctx context.Context, store *hypergraph.Store, task string) (context.Context, *Execution) {
+	if exec := ExecutionFrom(ctx); exec != nil {
+		return ctx, exec
+	}
+	if store == nil {
+		return ctx, nil
+	}
+	exec := &Execution{store: store, task: task}
+	return context.WithValue(ctx, executionKey{}, exec), exec
+}
+
+// ExecutionFrom returns the execution carried by ctx, or nil.
+func ExecutionFrom(ctx context.Context) *Execution {
+	exec, _ := ctx.Value(executionKey{}).(*Execution)
+	return exec
+}
+
+// NodeID returns the ID of the execution's decision node, creating the
+// node on first call.
+func (e *Execution) NodeID(ctx context.Context) (string, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.nodeID != "" {
+		return e.nodeID, nil
+	}
+
+	node := hypergraph.NewNode(hypergraph.NodeTypeDecision, e.task)
+	node.Subtype = executionSubtype
+	if err := e.store.CreateNode(ctx, node); err != nil {
+		return "", fmt.Errorf("create execution node: %w", err)
+	}
+	e.nodeID = node.ID
+	return e.nodeID, nil
+}
+
+// LinkProvenance links the fact factID to the execution carried by ctx
+// with a derived_from relation. Outside an execution it does nothing.
+func LinkProvenance(ctx context.Context, factID string) error {
+	exec := ExecutionFrom(ctx)
+	if exec == nil {
+		return nil
+	}
+	execID, err := exec.NodeID(ctx)
+	if err != nil {
+		return err
+	}
+	if _, err := exec.store.CreateRelation(ctx, DerivedFromLabel, factID, execID); err != nil {
+		return fmt.Errorf("link fact provenance: %w", err)
+	}
+	return nil
+}
+
+// storeExecution saves the execution as a decision node: the node of the
+// execution carried by ctx, which facts link to and the summary is later
+// recorded on, or a new one outside an execution.
+func (c *Core) storeExecution(ctx context.Context, task, response string, tokens int) error {
+	metadata := map[string]any{
+		"response": truncate(response, 500),
+		"tokens":   tokens,
+	}
+	metadataJSON, _ := json.Marshal(metadata)
+
+	if exec := ExecutionFrom(ctx); exec != nil && exec.store == c.store {
+		id, err := exec.NodeID(ctx)
+		if err != nil {
+			return err
+		}
+		node, err := c.store.GetNode(ctx, id)
+		if err != nil {
+			return err
+		}
+		node.Metadata = metadataJSON
+		return c.store.UpdateNode(ctx, node)
+	}
+
+	node := hypergraph.NewNode(hypergraph.NodeTypeDecision, task)
+	node.Subtype = executionSubtype
+	node.Metadata = metadataJSON
+	return c.store.CreateNode(ctx, node)
+}
@@ -0,0 +1,232 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/rand/recurse/internal/memory/hypergraph"
+)
+
+// errScanLimit stops a file walk that has read enough files.
+var errScanLimit = errors.New("scan limit reached")
+
+// FileContextProvider finds the files under Root best matching a
+// description of missing context, by the share of the description's
+// keywords found in each file's path and content. Matches in the path
+// count double.
+type FileContextProvider struct {
+	// Root is the directory searched.
+	Root string
+
+	// MaxFiles is how many of the best matching files are returned
+	// (default 3).
+	MaxFiles int
+
+	// MaxFileBytes skips larger files (default 256 KiB).
+	MaxFileBytes int64
+
+	// MaxScan bounds how many files are read per request (default 5000).
+	MaxScan int
+
+	// MinRelevance is the score a file needs to be returned, from 0.0 to
+	// 1.0 (default 0.5).
+	MinRelevance float64
+}
+
+// ProvideContext returns the best matching files as file sources, so
+// changes to them are picked up by context refresh.
+func (p *FileContextProvider) ProvideContext(ctx context.Context, description string) ([]ContextSource, error) {
+	keywords := planKeywords(description)
+	if len(keywords) == 0 {
+		return nil, nil
+	}
+	maxFiles, maxBytes, maxScan, minRelevance := p.MaxFiles, p.MaxFileBytes, p.MaxScan, p.MinRelevance
+	if maxFiles <= 0 {
+		maxFiles = 3
+	}
+	if maxBytes <= 0 {
+		maxBytes = 256 << 10
+	}
+	if maxScan <= 0 {
+		maxScan = 5000
+	}
+	if minRelevance <= 0 {
+		minRelevance = 0.5
+	}
+
+	type match struct {
+		path    string
+		content string
+		score   float64
+	}
+	var matches []match
+	scanned := 0
+	err := filepath.WalkDir(p.Root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if d.IsDir() {
+			name := d.Name()
+			if path != p.Root && (strings.HasPrefix(name, ".") || name == "node_modules" || name == "vendor") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		if scanned >= maxScan {
+			return errScanLimit
+		}
+		info, err := d.Info()
+		if err != nil || info.Size() > maxBytes {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil || !utf8.Valid(data) {
+			return nil
+		}
+		scanned++
+
+		rel, _ := filepath.Rel(p.Root, path)
+		pathCoverage := coverage(keywords, planKeywords(strings.NewReplacer("_", " ", "-", " ").Replace(rel)))
+		contentCoverage := coverage(keywords, planKeywords(string(data)))
+		score := min(1, (2*pathCoverage+contentCoverage)/2)
+		if score >= minRelevance {
+			matches = append(matches, match{path: path, content: string(data), score: score})
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errScanLimit) {
+		return nil, fmt.Errorf("search files for context: %w", err)
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].score > matches[j].score })
+	if len(matches) > maxFiles {
+		matches = matches[:maxFiles]
+	}
+	sources := make([]ContextSource, len(matches))
+	for i, m := range matches {
+		sources[i] = ContextSource{
+			Content: m.content,
+			Type:    ContextTypeFile,
+			Metadata: map[string]any{
+				"source":    m.path,
+				"relevance": m.score,
+			},
+		}
+	}
+	return sources, nil
+}
+
+// MemoryContextProvider finds memories matching a description of missing
+// context: by hybrid search if the store has embeddings, else by the
+// memories holding the most of the description's keywords.
+type MemoryContextProvider struct {
+	// Store is the memory searched.
+	Store *hypergraph.Store
+
+	// Limit is how many memories are returned (default 5).
+	Limit int
+}
+
+// ProvideContext returns the matching memories as one memory source.
+func (p *MemoryContextProvider) ProvideContext(ctx context.Context, description string) ([]ContextSource, error) {
+	limit := p.Limit
+	if limit <= 0 {
+		limit = 5
+	}
+
+	var nodes []*hypergraph.Node
+	if p.Store.HasEmbeddings() {
+		results, err := p.Store.Search(ctx, description, hypergraph.SearchOptions{Limit: limit})
+		if err != nil {
+			return nil, fmt.Errorf("search memory for context: %w", err)
+		}
+		for _, r := range results {
+			nodes = append(nodes, r.Node)
+		}
+	} else {
+		var err error
+		if nodes, err = p.searchKeywords(ctx, description, limit); err != nil {
+			return nil, err
+		}
+	}
+	if len(nodes) == 0 {
+		return nil, nil
+	}
+
+	var sb strings.Builder
+	ids := make([]string, len(nodes))
+	for i, node := range nodes {
+		ids[i] = node.ID
+		fmt.Fprintf(&sb, "- [%s] %s\n", node.Type, node.Content)
+	}
+	return []ContextSource{{
+		Content: sb.String(),
+		Type:    ContextTypeMemory,
+		Metadata: map[string]any{
+			"source": "memory",
+			"nodes":  ids,
+		},
+	}}, nil
+}
+
+// searchKeywords returns the memories containing at least half of the
+// description's keywords, those containing the most first.
+func (p *MemoryContextProvider) searchKeywords(ctx context.Context, description string, limit int) ([]*hypergraph.Node, error) {
+	keywords := planKeywords(description)
+	hits := make(map[string]int)
+	byID := make(map[string]*hypergraph.Node)
+	for word := range keywords {
+		results, err := p.Store.SearchByContent(ctx, word, hypergraph.SearchOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("search memory for context: %w", err)
+		}
+		for _, r := range results {
+			hits[r.Node.ID]++
+			byID[r.Node.ID] = r.Node
+		}
+	}
+
+	var nodes []*hypergraph.Node
+	for id, n := range hits {
+		if 2*n >= len(keywords) {
+			nodes = append(nodes, byID[id])
+		}
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		if hits[nodes[i].ID] != hits[nodes[j].ID] {
+			return hits[nodes[i].ID] > hits[nodes[j].ID]
+		}
+		return nodes[i].ID < nodes[j].ID
+	})
+	if len(nodes) > limit {
+		nodes = nodes[:limit]
+	}
+	return nodes, nil
+}
+
+// coverage returns the fraction of want found in have.
+func coverage(want, have map[string]bool) float64 {
+	if len(want) == 0 {
+		return 0
+	}
+	shared := 0
+	for w := range want {
+		if have[w] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(want))
+}
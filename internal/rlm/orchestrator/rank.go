@@ -0,0 +1,237 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+
+	"github.com/rand/recurse/internal/memory/embeddings"
+)
+
+// maxEmbedChars bounds how much of a source is embedded for ranking.
+const maxEmbedChars = 4000
+
+// RankConfig controls ranking of context sources by relevance to the task
+// before they are externalized. Ranking only drops sources when TopK or
+// MinRelevance is set; the highest-ranked source is always kept.
+type RankConfig struct {
+	// TopK keeps at most this many sources. Zero keeps any number.
+	TopK int
+
+	// MinRelevance drops sources scoring below it (0.0 to 1.0). Zero keeps
+	// any score.
+	MinRelevance float64
+
+	// KeywordWeight, EmbeddingWeight, and TypeWeight weigh the relevance
+	// signals; all zero uses the defaults. Signals that are unavailable,
+	// such as embedding similarity without an Embedder, are left out and
+	// the rest renormalized.
+	KeywordWeight   float64
+	EmbeddingWeight float64
+	TypeWeight      float64
+
+	// TypePriority scores each context type (0.0 to 1.0). Types not listed
+	// score 0.5; nil uses the defaults.
+	TypePriority map[ContextType]float64
+
+	// Embedder scores semantic similarity between the task and each source
+	// (optional).
+	Embedder embeddings.Provider
+}
+
+// DefaultRankConfig returns default weights and type priorities. It drops
+// nothing until TopK or MinRelevance is set.
+func DefaultRankConfig() RankConfig {
+	return RankConfig{
+		KeywordWeight:   0.5,
+		EmbeddingWeight: 0.3,
+		TypeWeight:      0.2,
+		TypePriority: map[ContextType]float64{
+			ContextTypePrompt:       1.0,
+			ContextTypeConversation: 0.9,
+			ContextTypeFile:         0.8,
+			ContextTypeImage:        0.7,
+			ContextTypeSearch:       0.7,
+			ContextTypeMemory:       0.6,
+			ContextTypeCustom:       0.5,
+		},
+	}
+}
+
+// withDefaults fills in the default weights when none are set and the
+// default type priorities when TypePriority is nil.
+func (c RankConfig) withDefaults() RankConfig {
+	defaults := DefaultRankConfig()
+	if c.KeywordWeight == 0 && c.EmbeddingWeight == 0 && c.TypeWeight == 0 {
+		c.KeywordWeight = defaults.KeywordWeight
+		c.EmbeddingWeight = defaults.EmbeddingWeight
+		c.TypeWeight = defaults.TypeWeight
+	}
+	if c.TypePriority == nil {
+		c.TypePriority = defaults.TypePriority
+	}
+	return c
+}
+
+// Enabled reports whether ranking can drop sources.
+func (c RankConfig) Enabled() bool {
+	return c.TopK > 0 || c.MinRelevance > 0
+}
+
+// SourceRelevance is a context source's relevance to the task.
+type SourceRelevance struct {
+	// Name is the source's variable name.
+	Name string `json:"name"`
+
+	// Type is the source's context type.
+	Type ContextType `json:"type"`
+
+	// Score is the weighted relevance (0.0 to 1.0).
+	Score float64 `json:"score"`
+
+	// Keyword is the fraction of the task's keywords found in the source.
+	Keyword float64 `json:"keyword"`
+
+	// Embedding is the task-source embedding similarity, zero without an
+	// embedder.
+	Embedding float64 `json:"embedding,omitempty"`
+
+	// TypePriority is the priority of the source's type.
+	TypePriority float64 `json:"type_priority"`
+
+	// Rank is the source's position by score, starting at 1.
+	Rank int `json:"rank"`
+}
+
+// DroppedSource is a context source left out of externalization.
+type DroppedSource struct {
+	SourceRelevance
+
+	// Reason explains why the source was dropped.
+	Reason string `json:"reason"`
+}
+
+// RankSources scores sources for relevance to task and returns those to
+// externalize, in their original order, along with the dropped ones, best
+// first. Without TopK or MinRelevance every source is kept.
+func RankSources(ctx context.Context, task string, sources []ContextSource, cfg RankConfig) ([]ContextSource, []DroppedSource) {
+	if !cfg.Enabled() || len(sources) <= 1 {
+		return sources, nil
+	}
+
+	scores := scoreSources(ctx, task, sources, cfg.withDefaults())
+	order := make([]int, len(sources))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return scores[order[a]].Score > scores[order[b]].Score
+	})
+
+	keep := make([]bool, len(sources))
+	var dropped []DroppedSource
+	for rank, i := range order {
+		scores[i].Rank = rank + 1
+		var reason string
+		switch {
+		case rank == 0:
+			// Always externalize something
+		case cfg.MinRelevance > 0 && scores[i].Score < cfg.MinRelevance:
+			reason = fmt.Sprintf("relevance %.2f below threshold %.2f", scores[i].Score, cfg.MinRelevance)
+		case cfg.TopK > 0 && rank >= cfg.TopK:
+			reason = fmt.Sprintf("ranked %d of %d, beyond top %d", rank+1, len(sources), cfg.TopK)
+		}
+		if reason != "" {
+			dropped = append(dropped, DroppedSource{SourceRelevance: scores[i], Reason: reason})
+			continue
+		}
+		keep[i] = true
+	}
+
+	kept := make([]ContextSource, 0, len(sources)-len(dropped))
+	for i, src := range sources {
+		if keep[i] {
+			kept = append(kept, src)
+		}
+	}
+	if len(dropped) > 0 {
+		slog.Debug("Dropped less relevant context sources",
+			"kept", len(kept),
+			"dropped", len(dropped))
+	}
+	return kept, dropped
+}
+
+// scoreSources computes each source's relevance to task.
+func scoreSources(ctx context.Context, task string, sources []ContextSource, cfg RankConfig) []SourceRelevance {
+	taskKeywords := planKeywords(task)
+	similarities := embedSimilarities(ctx, task, sources, cfg.Embedder)
+
+	scores := make([]SourceRelevance, len(sources))
+	for i, src := range sources {
+		s := SourceRelevance{
+			Name:    src.Name,
+			Type:    src.Type,
+			Keyword: keywordCoverage(taskKeywords, src),
+		}
+		s.TypePriority = 0.5
+		if p, ok := cfg.TypePriority[src.Type]; ok {
+			s.TypePriority = p
+		}
+
+		weighted := cfg.KeywordWeight*s.Keyword + cfg.TypeWeight*s.TypePriority
+		total := cfg.KeywordWeight + cfg.TypeWeight
+		if similarities != nil {
+			s.Embedding = similarities[i]
+			weighted += cfg.EmbeddingWeight * s.Embedding
+			total += cfg.EmbeddingWeight
+		}
+		if total > 0 {
+			s.Score = weighted / total
+		}
+		scores[i] = s
+	}
+	return scores
+}
+
+// keywordCoverage returns the fraction of the task's keywords found in the
+// source's name, origin, or content.
+func keywordCoverage(taskKeywords map[string]bool, src ContextSource) float64 {
+	if len(taskKeywords) == 0 {
+		return 0
+	}
+	text := src.Name + " " + src.Content
+	if origin, ok := src.Metadata["source"].(string); ok {
+		text += " " + origin
+	}
+	return coverage(taskKeywords, planKeywords(text))
+}
+
+// embedSimilarities returns each source's embedding similarity to task,
+// clamped to 0.0 to 1.0, or nil without an embedder or if embedding fails.
+func embedSimilarities(ctx context.Context, task string, sources []ContextSource, embedder embeddings.Provider) []float64 {
+	if embedder == nil {
+		return nil
+	}
+	texts := make([]string, 0, len(sources)+1)
+	texts = append(texts, task)
+	for _, src := range sources {
+		content := src.Content
+		if len(content) > maxEmbedChars {
+			content = content[:maxEmbedChars]
+		}
+		texts = append(texts, content)
+	}
+	vecs, err := embedder.Embed(ctx, texts)
+	if err != nil || len(vecs) != len(texts) {
+		slog.Warn("Embedding context sources failed, ranking without similarity", "error", err)
+		return nil
+	}
+
+	similarities := make([]float64, len(sources))
+	for i := range sources {
+		similarities[i] = max(0, min(1, float64(vecs[0].Similarity(vecs[i+1]))))
+	}
+	return similarities
+}
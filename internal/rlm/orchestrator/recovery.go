@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/rand/recurse/internal/rlm/meta"
@@ -54,14 +55,39 @@ const (
 
 	// ErrorCategoryResource indicates a resource limit was hit.
 	ErrorCategoryResource
+
+	// ErrorCategoryContextOverflow indicates a prompt overflowed the
+	// model's context window; the action is retried on chunks.
+	ErrorCategoryContextOverflow
 )
 
+// String returns the category name used in logs and metrics.
+func (c ErrorCategory) String() string {
+	switch c {
+	case ErrorCategoryRetryable:
+		return "retryable"
+	case ErrorCategoryDegradable:
+		return "degradable"
+	case ErrorCategoryTerminal:
+		return "terminal"
+	case ErrorCategoryTimeout:
+		return "timeout"
+	case ErrorCategoryResource:
+		return "resource"
+	case ErrorCategoryContextOverflow:
+		return "context_overflow"
+	default:
+		return "unknown"
+	}
+}
+
 // RecoveryAction describes what action to take for an error.
 type RecoveryAction struct {
 	Category    ErrorCategory
 	ShouldRetry bool
 	RetryPrompt string // Additional context for retry
 	Degraded    bool   // If true, fell back to degraded mode
+	Chunked     bool   // If true, retry by decomposing the task into chunks
 	Message     string // User-facing message
 }
 
@@ -79,7 +105,9 @@ type ErrorRecord struct {
 
 // RecoveryManager handles error recovery in the RLM loop.
 type RecoveryManager struct {
-	config       RecoveryConfig
+	config RecoveryConfig
+
+	mu           sync.Mutex
 	errorHistory []ErrorRecord
 	retryCount   int
 }
@@ -92,23 +120,36 @@ func NewRecoveryManager(config RecoveryConfig) *RecoveryManager {
 	}
 }
 
-// ClassifyError determines the category of an error.
+// ClassifyError determines the category of an error. Typed and sentinel
+// errors are matched with errors.As/errors.Is; substring heuristics are only
+// used for errors outside the taxonomy. A failed LLM call is classified by
+// the heuristics on its cause, so an unauthorized call is not retried, and
+// is retryable only if they find nothing.
 func (m *RecoveryManager) ClassifyError(err error) ErrorCategory {
 	if err == nil {
 		return ErrorCategoryRetryable
 	}
 
-	errStr := strings.ToLower(err.Error())
-
-	// Check for resource errors
+	// Typed errors first: resource limits, deadlines, then the sentinels
 	var resourceErr *repl.ResourceError
 	if errors.As(err, &resourceErr) {
 		return ErrorCategoryResource
 	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ErrorCategoryTimeout
+	}
+	if meta.IsContextOverflow(err) {
+		return ErrorCategoryContextOverflow
+	}
+	if category, ok := classifySentinel(err); ok {
+		return category
+	}
+
+	// Fall back to message heuristics for errors from other packages
+	errStr := strings.ToLower(err.Error())
 
 	// Check for timeout errors
-	if errors.Is(err, context.DeadlineExceeded) ||
-		strings.Contains(errStr, "timeout") ||
+	if strings.Contains(errStr, "timeout") ||
 		strings.Contains(errStr, "deadline exceeded") {
 		return ErrorCategoryTimeout
 	}
@@ -141,16 +182,31 @@ func (m *RecoveryManager) ClassifyError(err error) ErrorCategory {
 	if strings.Contains(errStr, "invalid") ||
 		strings.Contains(errStr, "not found") ||
 		strings.Contains(errStr, "permission denied") ||
-		strings.Contains(errStr, "unauthorized") {
+		strings.Contains(errStr, "unauthorized") ||
+		strings.Contains(errStr, "authentication") ||
+		strings.Contains(errStr, "forbidden") {
 		return ErrorCategoryTerminal
 	}
 
+	// A failed LLM call whose cause says nothing else is worth retrying
+	if errors.Is(err, ErrLLMCall) {
+		return ErrorCategoryRetryable
+	}
+
 	// Default: treat as degradable
 	return ErrorCategoryDegradable
 }
 
-// DetermineAction decides what action to take for an error.
+// DetermineAction decides what action to take for an error, counting
+// retries with the manager's own counter.
 func (m *RecoveryManager) DetermineAction(err error, action meta.Action, state meta.State) *RecoveryAction {
+	return m.determineAction(err, action, state, m.RetryCount())
+}
+
+// determineAction decides what action to take for an error after
+// retryCount retries. The core counts retries per orchestration, so tasks
+// sharing the manager, such as a batch's, do not use up each other's.
+func (m *RecoveryManager) determineAction(err error, action meta.Action, state meta.State, retryCount int) *RecoveryAction {
 	category := m.ClassifyError(err)
 
 	result := &RecoveryAction{
@@ -160,11 +216,11 @@ func (m *RecoveryManager) DetermineAction(err error, action meta.Action, state m
 
 	switch category {
 	case ErrorCategoryRetryable:
-		if m.retryCount < m.config.MaxRetries {
+		if retryCount < m.config.MaxRetries {
 			result.ShouldRetry = true
 			result.RetryPrompt = m.buildRetryPrompt(err, action)
 			result.Message = fmt.Sprintf("Retrying after error: %v (attempt %d/%d)",
-				err, m.retryCount+1, m.config.MaxRetries)
+				err, retryCount+1, m.config.MaxRetries)
 		} else if m.config.EnableDegradation {
 			result.Degraded = true
 			result.Message = fmt.Sprintf("Max retries reached, degrading to direct mode: %v", err)
@@ -179,7 +235,7 @@ func (m *RecoveryManager) DetermineAction(err error, action meta.Action, state m
 		}
 
 	case ErrorCategoryTimeout:
-		if m.retryCount < m.config.MaxRetries {
+		if retryCount < m.config.MaxRetries {
 			result.ShouldRetry = true
 			result.RetryPrompt = "The previous operation timed out. Please try a simpler approach."
 			result.Message = "Timeout, retrying with simpler approach"
@@ -194,6 +250,16 @@ func (m *RecoveryManager) DetermineAction(err error, action meta.Action, state m
 			result.Message = fmt.Sprintf("Resource limit hit, falling back to direct mode: %v", err)
 		}
 
+	case ErrorCategoryContextOverflow:
+		// A chunk that still overflows could be chunked again, down to
+		// the depth limit; a decomposition's own overflow cannot
+		if action != meta.ActionDecompose && state.RecursionDepth < state.MaxDepth {
+			result.Chunked = true
+			result.Message = fmt.Sprintf("Context overflow in %s, retrying in chunks: %v", action, err)
+		} else {
+			result.Message = fmt.Sprintf("Context overflow, cannot chunk further: %v", err)
+		}
+
 	case ErrorCategoryTerminal:
 		result.Message = fmt.Sprintf("Unrecoverable error: %v", err)
 	}
@@ -208,6 +274,8 @@ func (m *RecoveryManager) RecordError(record ErrorRecord) {
 	}
 
 	record.Timestamp = time.Now()
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.errorHistory = append(m.errorHistory, record)
 
 	// Keep history bounded
@@ -218,21 +286,29 @@ func (m *RecoveryManager) RecordError(record ErrorRecord) {
 
 // IncrementRetry increments the retry counter.
 func (m *RecoveryManager) IncrementRetry() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.retryCount++
 }
 
 // ResetRetry resets the retry counter for a new operation.
 func (m *RecoveryManager) ResetRetry() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.retryCount = 0
 }
 
 // RetryCount returns the current retry count.
 func (m *RecoveryManager) RetryCount() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	return m.retryCount
 }
 
 // SetRetryCount sets the retry count (for testing).
 func (m *RecoveryManager) SetRetryCount(count int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.retryCount = count
 }
 
@@ -243,7 +319,9 @@ func (m *RecoveryManager) Config() RecoveryConfig {
 
 // ErrorHistory returns the error history for analysis.
 func (m *RecoveryManager) ErrorHistory() []ErrorRecord {
-	return m.errorHistory
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]ErrorRecord(nil), m.errorHistory...)
 }
 
 // ErrorStats returns statistics about errors.
@@ -252,6 +330,8 @@ func (m *RecoveryManager) ErrorStats() ErrorStats {
 		CategoryCounts: make(map[ErrorCategory]int),
 	}
 
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	for _, record := range m.errorHistory {
 		stats.TotalErrors++
 		stats.CategoryCounts[record.Category]++
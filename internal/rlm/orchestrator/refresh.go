@@ -0,0 +1,251 @@
+package orchestrator
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// RefreshConfig controls reloading file-backed context whose files change
+// on disk after it was externalized.
+type RefreshConfig struct {
+	// Interval is how often RefreshIfDue checks the files. Zero disables
+	// scheduled checks; Refresh still reloads on demand.
+	Interval time.Duration
+}
+
+// DefaultRefreshConfig returns the default refresh schedule.
+func DefaultRefreshConfig() RefreshConfig {
+	return RefreshConfig{Interval: 30 * time.Second}
+}
+
+// ContextRefresh records a file-backed variable whose file changed after
+// it was loaded.
+type ContextRefresh struct {
+	// Name is the REPL variable.
+	Name string `json:"name"`
+
+	// Source is the file path.
+	Source string `json:"source"`
+
+	// PreviousHash and Hash are the file's content hashes when it was
+	// last loaded and now (hex, truncated). Hash is empty if Removed.
+	PreviousHash string `json:"previous_hash"`
+	Hash         string `json:"hash,omitempty"`
+
+	// Removed reports that the file no longer exists. The variable keeps
+	// its last content and is no longer checked.
+	Removed bool `json:"removed,omitempty"`
+
+	RefreshedAt time.Time `json:"refreshed_at"`
+}
+
+// trackedFile is the state of a file when its variable was last loaded.
+type trackedFile struct {
+	path    string
+	modTime time.Time
+	size    int64
+	hash    string
+}
+
+// fileSourcePath returns the path a source was read from, or "" if it is
+// not file-backed. Merged sources are not tracked, since reloading one
+// file would not reproduce the merged value.
+func fileSourcePath(src DedupedSource) string {
+	if src.Type != ContextTypeFile || len(src.Provenance) > 1 {
+		return ""
+	}
+	path, _ := src.Metadata["source"].(string)
+	return path
+}
+
+// statFile reads the state of the file at path.
+func statFile(path string) (*trackedFile, []byte, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &trackedFile{
+		path:    path,
+		modTime: info.ModTime(),
+		size:    info.Size(),
+		hash:    fileHash(data),
+	}, data, nil
+}
+
+// fileHash hashes file content the way SourceProvenance hashes sources.
+func fileHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])[:16]
+}
+
+// SetRefreshConfig configures how often RefreshIfDue checks files.
+func (cl *ContextLoader) SetRefreshConfig(cfg RefreshConfig) {
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+	cl.refresh = cfg
+}
+
+// RefreshConfig returns the current refresh configuration.
+func (cl *ContextLoader) RefreshConfig() RefreshConfig {
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+	return cl.refresh
+}
+
+// track records the file behind a loaded source so later refreshes can
+// tell whether it changed. Sources that are not file-backed, or whose file
+// cannot be read, are not tracked.
+func (cl *ContextLoader) track(src DedupedSource) {
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+	delete(cl.tracked, src.Name)
+
+	path := fileSourcePath(src)
+	if path == "" {
+		return
+	}
+	f, _, err := statFile(path)
+	if err != nil {
+		return
+	}
+	if cl.tracked == nil {
+		cl.tracked = make(map[string]*trackedFile)
+	}
+	cl.tracked[src.Name] = f
+}
+
+// RefreshIfDue calls Refresh if the configured interval has passed since
+// the last check, and otherwise does nothing.
+func (cl *ContextLoader) RefreshIfDue(ctx context.Context, loaded *LoadedContext) ([]ContextRefresh, error) {
+	cl.mu.Lock()
+	due := cl.refresh.Interval > 0 && time.Since(cl.lastRefresh) >= cl.refresh.Interval
+	cl.mu.Unlock()
+	if !due {
+		return nil, nil
+	}
+	return cl.Refresh(ctx, loaded)
+}
+
+// Refresh reloads every file-backed variable whose file changed since it
+// was loaded, returning what it refreshed in name order. A file that is
+// only touched, keeping its content, is not reloaded. If loaded is not
+// nil, its variable sizes and token estimates are updated to match.
+func (cl *ContextLoader) Refresh(ctx context.Context, loaded *LoadedContext) ([]ContextRefresh, error) {
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+	cl.lastRefresh = time.Now()
+
+	names := make([]string, 0, len(cl.tracked))
+	for name := range cl.tracked {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	var refreshes []ContextRefresh
+	for _, name := range names {
+		old := cl.tracked[name]
+		info, err := os.Stat(old.path)
+		if errors.Is(err, fs.ErrNotExist) {
+			delete(cl.tracked, name)
+			refreshes = append(refreshes, ContextRefresh{
+				Name:         name,
+				Source:       old.path,
+				PreviousHash: old.hash,
+				Removed:      true,
+				RefreshedAt:  time.Now(),
+			})
+			continue
+		}
+		if err != nil {
+			return refreshes, fmt.Errorf("refresh context %s: %w", name, err)
+		}
+		if info.ModTime().Equal(old.modTime) && info.Size() == old.size {
+			continue
+		}
+
+		f, data, err := statFile(old.path)
+		if err != nil {
+			return refreshes, fmt.Errorf("refresh context %s: %w", name, err)
+		}
+		cl.tracked[name] = f
+		if f.hash == old.hash {
+			continue
+		}
+
+		content := string(data)
+		if _, err := cl.replMgr.Execute(ctx, fmt.Sprintf("%s = %q", name, content)); err != nil {
+			return refreshes, fmt.Errorf("refresh context %s: %w", name, err)
+		}
+
+		refresh := ContextRefresh{
+			Name:         name,
+			Source:       old.path,
+			PreviousHash: old.hash,
+			Hash:         f.hash,
+			RefreshedAt:  time.Now(),
+		}
+		refreshes = append(refreshes, refresh)
+
+		if v, ok := cl.loaded[name]; ok {
+			v.Size = len(content)
+			v.TokenEstimate = len(content) / 4
+			v.RefreshedAt = refresh.RefreshedAt
+			cl.loaded[name] = v
+		}
+		if loaded != nil {
+			if v, ok := loaded.Variables[name]; ok {
+				tokens := len(content) / 4
+				loaded.TotalTokens += tokens - v.TokenEstimate
+				v.Size = len(content)
+				v.TokenEstimate = tokens
+				v.RefreshedAt = refresh.RefreshedAt
+				loaded.Variables[name] = v
+			}
+		}
+	}
+	return refreshes, nil
+}
+
+// untrack stops checking the files behind names, and forgets they are
+// loaded.
+func (cl *ContextLoader) untrack(names []string) {
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+	for _, name := range names {
+		delete(cl.tracked, name)
+		delete(cl.loaded, name)
+	}
+}
+
+// RefreshNotice tells the model which variables were refreshed mid-session,
+// so it does not rely on what it read from them before. It returns "" when
+// nothing was refreshed.
+func RefreshNotice(refreshes []ContextRefresh) string {
+	if len(refreshes) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString("Context refreshed: files behind these variables changed on disk since they were loaded. ")
+	sb.WriteString("Anything read from them earlier may be stale.\n")
+	for _, r := range refreshes {
+		if r.Removed {
+			sb.WriteString(fmt.Sprintf("- `%s`: %s was deleted; the variable keeps its last loaded content\n", r.Name, r.Source))
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("- `%s`: reloaded from %s\n", r.Name, r.Source))
+	}
+	return sb.String()
+}
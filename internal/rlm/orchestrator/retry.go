@@ -0,0 +1,137 @@
+package orchestrator
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// Execution retry defaults.
+const (
+	DefaultRetryBackoff    = time.Second
+	DefaultRetryMaxBackoff = 30 * time.Second
+)
+
+// ExecutionRetry re-runs a whole execution that failed with a transient
+// error, such as a provider 503 that outlasted the client's own retries,
+// instead of failing the task. Errors that are not transient fail at once.
+type ExecutionRetry struct {
+	// MaxAttempts is the most times an execution runs, the first
+	// included. Zero or one disables retries.
+	MaxAttempts int
+
+	// Backoff is the delay before the first retry, doubled before each
+	// further one. Default: 1 second.
+	Backoff time.Duration
+
+	// MaxBackoff caps the delay between attempts. Default: 30 seconds.
+	MaxBackoff time.Duration
+}
+
+// Enabled reports whether the policy retries anything.
+func (r ExecutionRetry) Enabled() bool {
+	return r.MaxAttempts > 1
+}
+
+// delay returns the backoff before the given retry, counting from one.
+func (r ExecutionRetry) delay(retry int) time.Duration {
+	backoff, limit := r.Backoff, r.MaxBackoff
+	if backoff <= 0 {
+		backoff = DefaultRetryBackoff
+	}
+	if limit <= 0 {
+		limit = DefaultRetryMaxBackoff
+	}
+	for i := 1; i < retry && backoff < limit; i++ {
+		backoff *= 2
+	}
+	return min(backoff, limit)
+}
+
+// executeWithRetry runs task, re-running it after a backoff while it fails
+// with a transient error and attempts remain. Retries reuse the context
+// the first attempt externalized. The result's usage totals every attempt,
+// the failed ones included.
+func (c *Core) executeWithRetry(ctx context.Context, task string, guard *CostGuard) (*ExecutionResult, error) {
+	policy := c.config.ExecutionRetry
+	if !policy.Enabled() {
+		return c.execute(ctx, task, guard)
+	}
+	ctx = withPreparedMemo(ctx)
+	spentBefore := guard.Spent()
+
+	var attempts []*ExecutionResult
+	for attempt := 1; ; attempt++ {
+		result, err := c.execute(ctx, task, guard)
+		if result != nil {
+			attempts = append(attempts, result)
+			result = totalAttempts(result, attempts)
+			result.ExecutionRetries = attempt - 1
+			result.Cost = guard.Spent() - spentBefore
+		}
+		if err == nil || attempt >= policy.MaxAttempts || !c.transientExecutionError(ctx, guard, err) {
+			return result, err
+		}
+
+		delay := policy.delay(attempt)
+		slog.Warn("Execution failed with a transient error, retrying",
+			"attempt", attempt,
+			"max_attempts", policy.MaxAttempts,
+			"delay", delay,
+			"error", err)
+		if c.tracer != nil && c.config.TraceEnabled {
+			c.tracer.RecordEvent(TraceEvent{
+				ID:        generateID(),
+				Type:      "execution_retry",
+				Action:    truncate(task, 50),
+				Details:   err.Error(),
+				Timestamp: time.Now(),
+				Status:    "retrying",
+			})
+		}
+
+		select {
+		case <-ctx.Done():
+			return result, err
+		case <-time.After(delay):
+		}
+	}
+}
+
+// transientExecutionError reports whether a failed execution is worth
+// running again: the recovery manager classifies the error that failed it
+// as retryable, and neither the context nor the cost ceiling has ended it.
+func (c *Core) transientExecutionError(ctx context.Context, guard *CostGuard, err error) bool {
+	if ctx.Err() != nil || guard.Err() != nil {
+		return false
+	}
+	return c.recovery.ClassifyError(err) == ErrorCategoryRetryable
+}
+
+type preparedMemoKey struct{}
+
+// preparedMemo keeps an execution's top-level context preparation across
+// its attempts, so a retry reuses the context already externalized to the
+// REPL instead of preparing it again.
+type preparedMemo struct {
+	prepared *PreparedContext
+}
+
+// withPreparedMemo returns a context carrying an empty preparation memo.
+func withPreparedMemo(ctx context.Context) context.Context {
+	return context.WithValue(ctx, preparedMemoKey{}, &preparedMemo{})
+}
+
+// prepareContext prepares the top-level context for task, or returns the
+// preparation an earlier attempt of the execution made.
+func (c *Core) prepareContext(ctx context.Context, task string, contextTokens int) (*PreparedContext, error) {
+	memo, _ := ctx.Value(preparedMemoKey{}).(*preparedMemo)
+	if memo != nil && memo.prepared != nil {
+		return memo.prepared, nil
+	}
+	prepared, err := c.contextPreparer.PrepareContext(ctx, task, contextTokens)
+	if err == nil && memo != nil {
+		memo.prepared = prepared
+	}
+	return prepared, err
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/rand/recurse/internal/rlm/repl"
@@ -147,7 +148,7 @@ externalized as Python variables that you can explore and manipulate via code ex
 - partition_by_lines(ctx, n=4) - Split by lines
 - extract_functions(ctx, language) - Find function definitions
 - count_tokens_approx(text) - Estimate token count
-- llm_call(prompt, context, model) - Make sub-LLM call
+- llm_call(prompt, context, model, verify=False) - Make sub-LLM call, optionally checking code it returns
 - llm_batch(prompts, contexts, model) - Batch LLM calls
 - FINAL(response) - Mark final output
 
@@ -190,21 +191,73 @@ FINAL(answer)
 // ContextLoader handles loading context into the REPL.
 type ContextLoader struct {
 	replMgr *repl.Manager
+	dedup   DedupConfig
+	rank    RankConfig
+
+	// File-backed variables checked for changes on disk
+	mu          sync.Mutex
+	refresh     RefreshConfig
+	tracked     map[string]*trackedFile
+	lastRefresh time.Time
+
+	// Variables currently in the REPL, by name
+	loaded map[string]VariableInfo
 }
 
 // NewContextLoader creates a new context loader.
 func NewContextLoader(replMgr *repl.Manager) *ContextLoader {
-	return &ContextLoader{replMgr: replMgr}
+	return &ContextLoader{
+		replMgr: replMgr,
+		dedup:   DefaultDedupConfig(),
+		rank:    DefaultRankConfig(),
+		refresh: DefaultRefreshConfig(),
+	}
+}
+
+// SetDedupConfig configures deduplication of sources before loading.
+func (cl *ContextLoader) SetDedupConfig(cfg DedupConfig) {
+	cl.dedup = cfg
+}
+
+// DedupConfig returns the current deduplication configuration.
+func (cl *ContextLoader) DedupConfig() DedupConfig {
+	return cl.dedup
+}
+
+// SetRankConfig configures relevance ranking of sources in LoadForTask.
+func (cl *ContextLoader) SetRankConfig(cfg RankConfig) {
+	cl.rank = cfg
+}
+
+// RankConfig returns the current ranking configuration.
+func (cl *ContextLoader) RankConfig() RankConfig {
+	return cl.rank
+}
+
+// LoadForTask loads the sources most relevant to task into the REPL,
+// recording the ones it dropped in the result.
+func (cl *ContextLoader) LoadForTask(ctx context.Context, task string, sources []ContextSource) (*LoadedContext, error) {
+	kept, dropped := RankSources(ctx, task, sources, cl.rank)
+	loaded, err := cl.Load(ctx, kept)
+	if err != nil {
+		return nil, err
+	}
+	loaded.Dropped = dropped
+	return loaded, nil
 }
 
-// Load loads context sources into the REPL.
+// Load loads context sources into the REPL. Identical or overlapping
+// sources are merged into a single variable before loading.
 func (cl *ContextLoader) Load(ctx context.Context, sources []ContextSource) (*LoadedContext, error) {
 	loaded := &LoadedContext{
 		Variables: make(map[string]VariableInfo),
 		LoadTime:  time.Now(),
 	}
 
-	for _, src := range sources {
+	for _, src := range DeduplicateSources(sources, cl.dedup) {
+		if src.Image != nil && src.Content == "" {
+			return nil, fmt.Errorf("load context %s: image has no text surrogate", src.Name)
+		}
 		// Create Python assignment code
 		code := fmt.Sprintf("%s = %q", src.Name, src.Content)
 
@@ -214,21 +267,61 @@ func (cl *ContextLoader) Load(ctx context.Context, sources []ContextSource) (*Lo
 			return nil, fmt.Errorf("load context %s: %w", src.Name, err)
 		}
 
+		// Bind merged-away names to the same value
+		for _, alias := range src.Aliases {
+			if _, err := cl.replMgr.Execute(ctx, fmt.Sprintf("%s = %s", alias, src.Name)); err != nil {
+				return nil, fmt.Errorf("alias context %s: %w", alias, err)
+			}
+		}
+
+		// Watch the file behind it for changes
+		cl.track(src)
+
 		// Track variable info
 		tokens := len(src.Content) / 4
-		loaded.Variables[src.Name] = VariableInfo{
+		info := VariableInfo{
 			Name:          src.Name,
 			Type:          src.Type,
 			Size:          len(src.Content),
 			TokenEstimate: tokens,
+			Provenance:    src.Provenance,
+			Aliases:       src.Aliases,
 			Metadata:      src.Metadata,
 		}
+		loaded.Variables[src.Name] = info
 		loaded.TotalTokens += tokens
+		cl.remember(info)
 	}
 
 	return loaded, nil
 }
 
+// Loaded returns the variables every earlier Load put in the REPL that
+// have not been cleared since, nil if there are none.
+func (cl *ContextLoader) Loaded() *LoadedContext {
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+	if len(cl.loaded) == 0 {
+		return nil
+	}
+	loaded := &LoadedContext{Variables: make(map[string]VariableInfo, len(cl.loaded))}
+	for name, info := range cl.loaded {
+		loaded.Variables[name] = info
+		loaded.TotalTokens += info.TokenEstimate
+	}
+	return loaded
+}
+
+// remember records info as loaded.
+func (cl *ContextLoader) remember(info VariableInfo) {
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+	if cl.loaded == nil {
+		cl.loaded = make(map[string]VariableInfo)
+	}
+	cl.loaded[info.Name] = info
+}
+
 // GenerateContextPrompt generates a prompt section describing loaded context.
 func (cl *ContextLoader) GenerateContextPrompt(loaded *LoadedContext) string {
 	if loaded == nil || len(loaded.Variables) == 0 {
@@ -241,10 +334,20 @@ func (cl *ContextLoader) GenerateContextPrompt(loaded *LoadedContext) string {
 	for name, info := range loaded.Variables {
 		sb.WriteString(fmt.Sprintf("- `%s` (%s): ~%d tokens\n",
 			name, info.Type, info.TokenEstimate))
+		if len(info.Aliases) > 0 {
+			sb.WriteString(fmt.Sprintf("  - Also available as: %s\n", strings.Join(info.Aliases, ", ")))
+		}
 	}
 
 	sb.WriteString(fmt.Sprintf("\nTotal: ~%d tokens externalized\n", loaded.TotalTokens))
 
+	if len(loaded.Dropped) > 0 {
+		sb.WriteString("\nOmitted as less relevant:\n")
+		for _, d := range loaded.Dropped {
+			sb.WriteString(fmt.Sprintf("- `%s` (%s): %s\n", d.Name, d.Type, d.Reason))
+		}
+	}
+
 	return sb.String()
 }
 
@@ -253,6 +356,7 @@ func (cl *ContextLoader) ClearContext(ctx context.Context, varNames []string) er
 	if len(varNames) == 0 {
 		return nil
 	}
+	cl.untrack(varNames)
 	deleteCode := "del " + strings.Join(varNames, ", ")
 	_, err := cl.replMgr.Execute(ctx, deleteCode)
 	return err
@@ -0,0 +1,369 @@
+package orchestrator
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rand/recurse/internal/memory/hypergraph"
+	"github.com/rand/recurse/internal/rlm/meta"
+)
+
+// subtaskResultSubtype marks decision nodes holding a cached subtask result.
+const subtaskResultSubtype = "subtask_result"
+
+// SubtaskCache memoizes the results of decomposition subtasks so an
+// identical subtask, within one decomposition or a later one, skips its LLM
+// calls. Entries are keyed by a hash of the subtask and the context it ran
+// with, so a subtask whose context changed misses rather than reusing a
+// stale answer. It is safe for concurrent use.
+type SubtaskCache struct {
+	mu      sync.Mutex
+	entries map[string]*subtaskEntry
+	order   []string
+
+	config SubtaskCacheConfig
+	now    func() time.Time
+
+	hits        int64
+	misses      int64
+	tokensSaved int64
+}
+
+// SubtaskCacheConfig configures a SubtaskCache.
+type SubtaskCacheConfig struct {
+	// Enabled turns the cache on where a config is consumed, as by the
+	// service; NewSubtaskCache itself ignores it.
+	Enabled bool
+
+	// TTL is how long a result is reused. Zero means results never expire.
+	TTL time.Duration
+
+	// MaxEntries bounds the in-memory cache; the oldest entries are evicted
+	// first. Zero means unbounded.
+	MaxEntries int
+
+	// Store persists results as hypergraph nodes, so they survive restarts
+	// and are shared by caches over the same store. Nil keeps them in
+	// memory only.
+	Store *hypergraph.Store
+}
+
+// SubtaskCacheStats reports a SubtaskCache's lifetime activity.
+type SubtaskCacheStats struct {
+	Hits    int64 `json:"hits"`
+	Misses  int64 `json:"misses"`
+	Entries int   `json:"entries"`
+
+	// TokensSaved is the tokens the cached results originally cost.
+	TokensSaved int64 `json:"tokens_saved"`
+}
+
+// CachedSubtask is a cached subtask result.
+type CachedSubtask struct {
+	Response string `json:"-"`
+
+	// Tokens is what the subtask cost when it ran.
+	Tokens int `json:"tokens"`
+
+	// Confidence is the subtask's synthesis confidence, zero if unknown.
+	Confidence float64 `json:"confidence,omitempty"`
+
+	// CreatedAt is when the result was cached.
+	CreatedAt time.Time `json:"-"`
+}
+
+// subtaskEntry is a cache entry; its JSON form is a persisted node's
+// metadata, the response being the node's content.
+type subtaskEntry struct {
+	CachedSubtask
+	Key string `json:"key"`
+
+	nodeID string
+}
+
+// NewSubtaskCache creates a subtask cache, loading unexpired results
+// persisted in config.Store.
+func NewSubtaskCache(ctx context.Context, config SubtaskCacheConfig) (*SubtaskCache, error) {
+	c := &SubtaskCache{
+		entries: make(map[string]*subtaskEntry),
+		config:  config,
+		now:     time.Now,
+	}
+	if config.Store == nil {
+		return c, nil
+	}
+
+	nodes, err := config.Store.ListNodes(ctx, hypergraph.NodeFilter{
+		Types:    []hypergraph.NodeType{hypergraph.NodeTypeDecision},
+		Subtypes: []string{subtaskResultSubtype},
+		Limit:    config.MaxEntries,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("load subtask cache: %w", err)
+	}
+	// Nodes are newest first; load oldest first so newer results win and
+	// eviction order matches age.
+	for i := len(nodes) - 1; i >= 0; i-- {
+		node := nodes[i]
+		var entry subtaskEntry
+		if err := json.Unmarshal(node.Metadata, &entry); err != nil || entry.Key == "" {
+			slog.Warn("Skipping unreadable cached subtask result", "node", node.ID, "error", err)
+			continue
+		}
+		entry.Response = node.Content
+		entry.CreatedAt = node.CreatedAt
+		entry.nodeID = node.ID
+		if c.expired(&entry) {
+			continue
+		}
+		c.add(&entry)
+	}
+	return c, nil
+}
+
+// SubtaskKey builds the cache key for a subtask from its description and
+// the context it runs with.
+func SubtaskKey(task string, context ...string) string {
+	h := sha256.New()
+	h.Write([]byte(strings.TrimSpace(task)))
+	for _, c := range context {
+		// NUL separates parts so ("ab", "c") and ("a", "bc") differ.
+		h.Write([]byte{0})
+		h.Write([]byte(c))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Get returns the cached result for key, if one exists and has not expired.
+func (c *SubtaskCache) Get(ctx context.Context, key string) (*CachedSubtask, bool) {
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	var stale []string
+	if ok && c.expired(entry) {
+		stale = c.remove(key)
+		ok = false
+	}
+	if ok {
+		c.hits++
+		c.tokensSaved += int64(entry.Tokens)
+	} else {
+		c.misses++
+	}
+	c.mu.Unlock()
+
+	c.deletePersisted(ctx, stale...)
+	if !ok {
+		return nil, false
+	}
+	result := entry.CachedSubtask
+	return &result, true
+}
+
+// Put caches a subtask's result, replacing any earlier result for key.
+func (c *SubtaskCache) Put(ctx context.Context, key string, result CachedSubtask) {
+	if result.CreatedAt.IsZero() {
+		result.CreatedAt = c.now()
+	}
+	entry := &subtaskEntry{CachedSubtask: result, Key: key}
+	if c.config.Store != nil {
+		if err := c.persist(ctx, entry); err != nil {
+			slog.Warn("Failed to persist subtask result", "error", err)
+		}
+	}
+
+	c.mu.Lock()
+	stale := c.add(entry)
+	c.mu.Unlock()
+	c.deletePersisted(ctx, stale...)
+}
+
+// Invalidate drops the cached result for key.
+func (c *SubtaskCache) Invalidate(ctx context.Context, key string) {
+	c.mu.Lock()
+	stale := c.remove(key)
+	c.mu.Unlock()
+	c.deletePersisted(ctx, stale...)
+}
+
+// Clear drops every cached result, including persisted ones.
+func (c *SubtaskCache) Clear(ctx context.Context) {
+	c.mu.Lock()
+	var stale []string
+	for _, entry := range c.entries {
+		if entry.nodeID != "" {
+			stale = append(stale, entry.nodeID)
+		}
+	}
+	c.entries = make(map[string]*subtaskEntry)
+	c.order = nil
+	c.mu.Unlock()
+	c.deletePersisted(ctx, stale...)
+}
+
+// Stats returns the cache's lifetime hits, misses, and tokens saved.
+func (c *SubtaskCache) Stats() SubtaskCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return SubtaskCacheStats{
+		Hits:        c.hits,
+		Misses:      c.misses,
+		Entries:     len(c.entries),
+		TokensSaved: c.tokensSaved,
+	}
+}
+
+func (c *SubtaskCache) expired(entry *subtaskEntry) bool {
+	return c.config.TTL > 0 && c.now().Sub(entry.CreatedAt) > c.config.TTL
+}
+
+// add stores entry and returns the node IDs of the results it replaced or
+// evicted. c.mu must be held.
+func (c *SubtaskCache) add(entry *subtaskEntry) []string {
+	stale := c.remove(entry.Key)
+	c.entries[entry.Key] = entry
+	c.order = append(c.order, entry.Key)
+	for c.config.MaxEntries > 0 && len(c.order) > c.config.MaxEntries {
+		stale = append(stale, c.remove(c.order[0])...)
+	}
+	return stale
+}
+
+// remove drops key and returns its persisted node ID, if any. c.mu must be
+// held.
+func (c *SubtaskCache) remove(key string) []string {
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil
+	}
+	delete(c.entries, key)
+	for i, k := range c.order {
+		if k == key {
+			c.order = append(c.order[:i], c.order[i+1:]...)
+			break
+		}
+	}
+	if entry.nodeID == "" {
+		return nil
+	}
+	return []string{entry.nodeID}
+}
+
+func (c *SubtaskCache) persist(ctx context.Context, entry *subtaskEntry) error {
+	metadata, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("marshal subtask result: %w", err)
+	}
+	node := hypergraph.NewNode(hypergraph.NodeTypeDecision, entry.Response)
+	node.Subtype = subtaskResultSubtype
+	node.Metadata = metadata
+	if entry.Confidence > 0 {
+		node.Confidence = entry.Confidence
+	}
+	if err := c.config.Store.CreateNode(ctx, node); err != nil {
+		return fmt.Errorf("create subtask result node: %w", err)
+	}
+	entry.nodeID = node.ID
+	return nil
+}
+
+func (c *SubtaskCache) deletePersisted(ctx context.Context, nodeIDs ...string) {
+	if c.config.Store == nil {
+		return
+	}
+	for _, id := range nodeIDs {
+		if err := c.config.Store.DeleteNode(ctx, id); err != nil {
+			slog.Warn("Failed to delete cached subtask result", "node", id, "error", err)
+		}
+	}
+}
+
+// SetSubtaskCache makes decomposition reuse subtask results from cache. The
+// same cache may be shared by several cores.
+func (c *Core) SetSubtaskCache(cache *SubtaskCache) {
+	c.subtaskCache = cache
+}
+
+// SubtaskCache returns the subtask cache, nil if none is set.
+func (c *Core) SubtaskCache() *SubtaskCache {
+	return c.subtaskCache
+}
+
+// runSubtask orchestrates a decomposition subtask, serving it from the
+// subtask cache when an identical subtask already succeeded.
+func (c *Core) runSubtask(ctx context.Context, state meta.State, parentID string) (string, int, error) {
+	if c.subtaskCache == nil {
+		return c.orchestrate(ctx, state, parentID)
+	}
+
+	// A subtask's answer depends on its content, on the loaded context it
+	// may consult, and on how much deeper it may recurse.
+	key := SubtaskKey(state.Task,
+		"context "+contextDigestFrom(ctx),
+		fmt.Sprintf("depth %d/%d", state.RecursionDepth, state.MaxDepth))
+	if cached, ok := c.subtaskCache.Get(ctx, key); ok {
+		recordConfidence(ctx, cached.Confidence)
+		recordSubtaskCacheHit(ctx)
+		return cached.Response, 0, nil
+	}
+
+	ctx, degraded := withDegradedFlag(ctx)
+	response, tokens, err := c.orchestrate(ctx, state, parentID)
+	// A degraded or fallback answer is not what the subtask would answer
+	// when healthy, so it is not reused.
+	if err == nil && !degraded.set.Load() && CostGuardFrom(ctx).Err() == nil {
+		c.subtaskCache.Put(ctx, key, CachedSubtask{
+			Response:   response,
+			Tokens:     tokens,
+			Confidence: recordedConfidence(ctx),
+		})
+	}
+	return response, tokens, err
+}
+
+type contextDigestKey struct{}
+
+// withContextDigest returns a context carrying the digest of the loaded
+// context the orchestration works against.
+func withContextDigest(ctx context.Context, digest string) context.Context {
+	return context.WithValue(ctx, contextDigestKey{}, digest)
+}
+
+// contextDigestFrom returns the loaded context's digest, empty if none.
+func contextDigestFrom(ctx context.Context) string {
+	digest, _ := ctx.Value(contextDigestKey{}).(string)
+	return digest
+}
+
+type degradedKey struct{}
+
+// degradedFlag notes that a subtask's answer came through a degraded or
+// fallback path. Flags nest: marking one marks the subtasks it runs under.
+type degradedFlag struct {
+	parent *degradedFlag
+	set    atomic.Bool
+}
+
+// withDegradedFlag returns a context carrying a fresh flag nested under
+// any flag ctx already carries.
+func withDegradedFlag(ctx context.Context) (context.Context, *degradedFlag) {
+	parent, _ := ctx.Value(degradedKey{}).(*degradedFlag)
+	flag := &degradedFlag{parent: parent}
+	return context.WithValue(ctx, degradedKey{}, flag), flag
+}
+
+// recordDegraded marks the running subtask, and those enclosing it, as
+// answered through a degraded path.
+func recordDegraded(ctx context.Context) {
+	flag, _ := ctx.Value(degradedKey{}).(*degradedFlag)
+	for ; flag != nil; flag = flag.parent {
+		flag.set.Store(true)
+	}
+}
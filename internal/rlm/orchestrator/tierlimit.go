@@ -0,0 +1,68 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/rand/recurse/internal/rlm/meta"
+)
+
+// routeExplainer is implemented by clients that can tell which model a
+// prompt would be routed to, such as meta.OpenRouterClient.
+type routeExplainer interface {
+	ExplainRoute(ctx context.Context, prompt string) *meta.RouteDecision
+}
+
+// tierLimitedClient waits for a slot of the tier a call goes to before
+// calling the main model, so orchestration shares per-tier caps with the
+// sub-call router. Without a limiter it is a plain pass-through.
+type tierLimitedClient struct {
+	client  meta.LLMClient
+	limiter atomic.Pointer[meta.TierLimiter]
+}
+
+func (c *tierLimitedClient) Complete(ctx context.Context, prompt string, maxTokens int) (string, error) {
+	limiter := c.limiter.Load()
+	if limiter == nil {
+		return c.client.Complete(ctx, prompt, maxTokens)
+	}
+
+	tier, ok := c.tier(ctx, prompt)
+	if !ok {
+		return c.client.Complete(ctx, prompt, maxTokens)
+	}
+	release, err := limiter.Acquire(ctx, tier)
+	if err != nil {
+		return "", fmt.Errorf("waiting for %s tier: %w", tier, err)
+	}
+	defer release()
+	return c.client.Complete(ctx, prompt, maxTokens)
+}
+
+// Capabilities implements meta.CapabilityReporter with the wrapped
+// client's limits.
+func (c *tierLimitedClient) Capabilities() meta.Capabilities {
+	return meta.CapabilitiesOf(c.client).CompletionOnly()
+}
+
+// tier returns the tier prompt will be routed to: the client's own routing
+// decision if it explains one, else the context's minimum tier. A client
+// that does not route by tier, such as a plain Anthropic or OpenAI client,
+// has no known tier, and its calls are not counted against any cap.
+func (c *tierLimitedClient) tier(ctx context.Context, prompt string) (meta.ModelTier, bool) {
+	if explainer, ok := c.client.(routeExplainer); ok {
+		if route := explainer.ExplainRoute(ctx, prompt); route != nil {
+			return route.Tier, true
+		}
+	}
+	return meta.MinTierFrom(ctx)
+}
+
+// SetTierLimiter caps the main model's calls in flight per tier, sharing
+// the caps of whatever else holds the limiter. Nil removes the caps.
+func (c *Core) SetTierLimiter(limiter *meta.TierLimiter) {
+	if c.tierClient != nil {
+		c.tierClient.limiter.Store(limiter)
+	}
+}
@@ -0,0 +1,97 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rand/recurse/internal/rlm/meta"
+)
+
+// ActionTimeouts bounds how long each meta-controller action may run,
+// independently of the execution's overall deadline, so a stuck action
+// fails fast and recovery can retry or degrade it. Zero leaves an action
+// bounded only by the context.
+type ActionTimeouts struct {
+	Direct      time.Duration
+	Decompose   time.Duration
+	MemoryQuery time.Duration
+	Subcall     time.Duration
+	Synthesize  time.Duration
+}
+
+// For returns the timeout for action, zero if it has none.
+func (t ActionTimeouts) For(action meta.Action) time.Duration {
+	switch action {
+	case meta.ActionDirect:
+		return t.Direct
+	case meta.ActionDecompose:
+		return t.Decompose
+	case meta.ActionMemoryQuery:
+		return t.MemoryQuery
+	case meta.ActionSubcall:
+		return t.Subcall
+	case meta.ActionSynthesize:
+		return t.Synthesize
+	default:
+		return 0
+	}
+}
+
+// ActionTimeoutError reports an action that exceeded its per-action
+// timeout. It matches ErrActionTimeout and context.DeadlineExceeded.
+type ActionTimeoutError struct {
+	Action  meta.Action
+	Timeout time.Duration
+}
+
+func (e *ActionTimeoutError) Error() string {
+	return fmt.Sprintf("%s action timed out after %s", e.Action, e.Timeout)
+}
+
+// Is reports whether target is ErrActionTimeout or context.DeadlineExceeded.
+func (e *ActionTimeoutError) Is(target error) bool {
+	return target == ErrActionTimeout || target == context.DeadlineExceeded
+}
+
+// runWithTimeout runs fn under the configured timeout for action. fn runs
+// on its own goroutine so that an action ignoring its context still
+// returns control to recovery when the timeout fires.
+func (c *Core) runWithTimeout(ctx context.Context, action meta.Action, fn func(context.Context) (string, int, error)) (string, int, error) {
+	timeout := c.config.ActionTimeouts.For(action)
+	if timeout <= 0 {
+		return fn(ctx)
+	}
+
+	actionCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	type outcome struct {
+		response string
+		tokens   int
+		err      error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		response, tokens, err := fn(actionCtx)
+		done <- outcome{response, tokens, err}
+	}()
+
+	select {
+	case out := <-done:
+		// An action that failed because its own deadline passed timed out
+		if out.err != nil && ctx.Err() == nil && errors.Is(actionCtx.Err(), context.DeadlineExceeded) {
+			recordActionTimeout(ctx, action)
+			return "", out.tokens, &ActionTimeoutError{Action: action, Timeout: timeout}
+		}
+		return out.response, out.tokens, out.err
+	case <-actionCtx.Done():
+		// The overall deadline or a cancellation is the caller's, not the action's
+		if err := ctx.Err(); err != nil {
+			return "", 0, err
+		}
+		recordActionTimeout(ctx, action)
+		return "", 0, &ActionTimeoutError{Action: action, Timeout: timeout}
+	}
+}
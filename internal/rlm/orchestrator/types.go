@@ -2,6 +2,7 @@
 package orchestrator
 
 import (
+	"slices"
 	"time"
 
 	"github.com/rand/recurse/internal/rlm/meta"
@@ -110,6 +111,10 @@ type LoadedContext struct {
 
 	// LoadTime is when the context was loaded.
 	LoadTime time.Time
+
+	// Dropped lists sources left out as less relevant to the task, best
+	// first. Empty unless ranking was configured.
+	Dropped []DroppedSource
 }
 
 // VariableInfo describes a loaded context variable.
@@ -132,8 +137,20 @@ type VariableInfo struct {
 	// Source indicates where the context came from.
 	Source string `json:"source,omitempty"`
 
+	// Provenance lists every source merged into this variable by
+	// deduplication. A variable loaded from a single source has one entry.
+	Provenance []SourceProvenance `json:"provenance,omitempty"`
+
+	// Aliases are additional names bound to this variable in the REPL,
+	// one for each merged-away source.
+	Aliases []string `json:"aliases,omitempty"`
+
 	// Metadata contains additional info about the source.
 	Metadata map[string]any `json:"-"`
+
+	// RefreshedAt is when the variable was last reloaded because its file
+	// changed on disk, zero if it never was.
+	RefreshedAt time.Time `json:"refreshed_at,omitzero"`
 }
 
 // Length returns the size for backwards compatibility.
@@ -150,11 +167,17 @@ func (v VariableInfo) TokenCount() int {
 type ContextType string
 
 const (
-	ContextTypeFile    ContextType = "file"
-	ContextTypeSearch  ContextType = "search"
-	ContextTypeMemory  ContextType = "memory"
-	ContextTypeCustom  ContextType = "custom"
-	ContextTypePrompt  ContextType = "prompt"
+	ContextTypeFile         ContextType = "file"
+	ContextTypeSearch       ContextType = "search"
+	ContextTypeMemory       ContextType = "memory"
+	ContextTypeCustom       ContextType = "custom"
+	ContextTypePrompt       ContextType = "prompt"
+	ContextTypeConversation ContextType = "conversation"
+
+	// ContextTypeImage is an image, such as a diagram or screenshot. It is
+	// sent to vision-capable models as is and externalized as a text
+	// surrogate (a caption or OCR transcript) for text-only ones.
+	ContextTypeImage ContextType = "image"
 )
 
 // ContextSource defines a source of context to load.
@@ -170,6 +193,10 @@ type ContextSource struct {
 
 	// Metadata contains additional source info.
 	Metadata map[string]any
+
+	// Image is the image of a ContextTypeImage source whose text
+	// surrogate has not been made yet; Content is empty until then.
+	Image *meta.Image
 }
 
 // ExecutionResult contains the outcome of an RLM execution.
@@ -180,6 +207,148 @@ type ExecutionResult struct {
 	StartTime   time.Time     `json:"start_time"`
 	Duration    time.Duration `json:"duration"`
 	Error       string        `json:"error,omitempty"`
+
+	// Confidence is the aggregate confidence reported by synthesis, the
+	// heuristic confidence of a directly given answer, or the escalation
+	// policy's score when it judged the answer (0.0 to 1.0). Zero when
+	// none of them applied, e.g. for a failed REPL execution.
+	Confidence float64 `json:"confidence,omitempty"`
+
+	// Action is the meta-controller's top-level action.
+	Action string `json:"action,omitempty"`
+
+	// Mode is "rlm" when context was externalized to the REPL, else "direct".
+	Mode string `json:"mode,omitempty"`
+
+	// Decisions counts meta-controller decisions across all recursion levels.
+	Decisions int `json:"decisions,omitempty"`
+
+	// Tier is the model tier of the execution's last routed completion,
+	// normally the answer's. Empty when the client does not route by tier.
+	Tier string `json:"tier,omitempty"`
+
+	// Models are the models the execution's completions were sent to, in
+	// order of first use. When the client does not route, it is the
+	// client's model if the client names one.
+	Models []string `json:"models,omitempty"`
+
+	// SubtaskCacheHits counts decomposition subtasks served from the
+	// subtask cache instead of being executed.
+	SubtaskCacheHits int `json:"subtask_cache_hits,omitempty"`
+
+	// TimedOutActions lists, in order, the actions that exceeded their
+	// per-action timeout at any recursion level, including ones recovery
+	// then retried or degraded.
+	TimedOutActions []string `json:"timed_out_actions,omitempty"`
+
+	// ContextOverflows lists, in order, the actions at any recursion level
+	// whose LLM call overflowed the model's context window and that
+	// recovery retried on chunks.
+	ContextOverflows []ContextOverflowRecovery `json:"context_overflows,omitempty"`
+
+	// InputTokens and OutputTokens total every LLM call made for the
+	// execution. They use the usage providers report, falling back to
+	// estimates for calls whose provider reported none; EstimatedTokens is
+	// how much of the total is estimated. All three are zero when no call
+	// was accounted, e.g. with clients that report nothing and no estimate.
+	InputTokens     int `json:"input_tokens,omitempty"`
+	OutputTokens    int `json:"output_tokens,omitempty"`
+	EstimatedTokens int `json:"estimated_tokens,omitempty"`
+
+	// Cost is the spend charged against the cost ceiling, zero without one.
+	Cost float64 `json:"cost,omitempty"`
+
+	// LLMCalls counts the LLM calls the execution made: the main model's,
+	// the meta-controller's, sub-calls and answer scoring.
+	LLMCalls int `json:"llm_calls,omitempty"`
+
+	// ExecutionRetries counts the times the execution was re-run after a
+	// transient failure.
+	ExecutionRetries int `json:"execution_retries,omitempty"`
+
+	// Partial is true when the execution was aborted at its cost ceiling
+	// or call limit and Response holds the best answer produced before the abort.
+	Partial bool `json:"partial,omitempty"`
+
+	// Escalation records the escalation policy's decision, nil without a
+	// policy. When the task was re-executed, tokens, cost, and duration
+	// above total both attempts.
+	Escalation *Escalation `json:"escalation,omitempty"`
+
+	// Profile is the execution profile the execution ran under, empty for
+	// ModeStandard.
+	Profile ExecutionProfile `json:"profile,omitempty"`
+
+	// Compression reports the context compression applied while preparing
+	// the task, nil when none was. Token counts above are of what was sent,
+	// after compression.
+	Compression *CompressionStats `json:"compression,omitempty"`
+
+	// Cached is true when the result was served from an answer cache
+	// instead of executing; the other fields then describe the execution
+	// that produced it, at CachedAt.
+	Cached   bool      `json:"cached,omitempty"`
+	CachedAt time.Time `json:"cached_at,omitzero"`
+}
+
+// Clone returns a deep copy of r: changing the copy's slices, escalation
+// or compression stats leaves r unchanged. Clone of nil is nil.
+func (r *ExecutionResult) Clone() *ExecutionResult {
+	if r == nil {
+		return nil
+	}
+	c := *r
+	c.Models = slices.Clone(r.Models)
+	c.TimedOutActions = slices.Clone(r.TimedOutActions)
+	c.ContextOverflows = slices.Clone(r.ContextOverflows)
+	if r.Escalation != nil {
+		escalation := *r.Escalation
+		escalation.Attempts = slices.Clone(r.Escalation.Attempts)
+		for i, attempt := range escalation.Attempts {
+			escalation.Attempts[i] = attempt.Clone()
+		}
+		c.Escalation = &escalation
+	}
+	if r.Compression != nil {
+		compression := *r.Compression
+		c.Compression = &compression
+	}
+	return &c
+}
+
+// ContextOverflowRecovery records an action whose prompt overflowed the
+// model's context window and was retried by decomposing its task into
+// chunks.
+type ContextOverflowRecovery struct {
+	Action string `json:"action"`
+
+	// Provider is the provider whose error signature matched.
+	Provider string `json:"provider,omitempty"`
+
+	Depth int `json:"depth"`
+
+	// Recovered is false if the chunked retry failed as well.
+	Recovered bool `json:"recovered"`
+}
+
+// CompressionStats compares the estimated size of context before and after
+// compression.
+type CompressionStats struct {
+	OriginalTokens   int `json:"original_tokens"`
+	CompressedTokens int `json:"compressed_tokens"`
+}
+
+// SavedTokens returns how many tokens compression removed.
+func (s *CompressionStats) SavedTokens() int {
+	return max(s.OriginalTokens-s.CompressedTokens, 0)
+}
+
+// Ratio returns the compressed size as a fraction of the original.
+func (s *CompressionStats) Ratio() float64 {
+	if s.OriginalTokens == 0 {
+		return 1
+	}
+	return float64(s.CompressedTokens) / float64(s.OriginalTokens)
 }
 
 // TraceEvent represents a trace event for the RLM trace view.
@@ -200,3 +369,10 @@ type TraceEvent struct {
 type TraceRecorder interface {
 	RecordEvent(event TraceEvent) error
 }
+
+// InterestingMarker is implemented by trace recorders that sample events.
+// The core calls MarkInteresting on signals its events do not carry, such
+// as a budget warning, to keep full detail for the event's execution.
+type InterestingMarker interface {
+	MarkInteresting(eventID string)
+}
@@ -0,0 +1,191 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/rand/recurse/internal/memory/hypergraph"
+	"github.com/rand/recurse/internal/rlm/meta"
+)
+
+// WarmStartConfig blends the decision policy's proposed action with how
+// actions fared on similar past tasks, as recorded by execution summaries,
+// so top-level routing improves with experience.
+type WarmStartConfig struct {
+	// Weight is history's share (0.0 to 1.0) of an action's score once it
+	// has PriorTrials recorded outcomes; the share grows toward 1 with more
+	// outcomes. Zero disables warm start.
+	Weight float64
+
+	// MinTrials is how many recorded outcomes an action needs for similar
+	// tasks before its success rate is considered (default: 5).
+	MinTrials int
+
+	// PriorTrials is the number of outcomes at which history's share
+	// reaches Weight; with fewer the proposal dominates (default: 5).
+	PriorTrials int
+}
+
+// DefaultWarmStartConfig returns the recommended settings for enabling
+// warm start: history and proposal count equally at five outcomes.
+func DefaultWarmStartConfig() WarmStartConfig {
+	return WarmStartConfig{Weight: 0.5, MinTrials: 5, PriorTrials: 5}
+}
+
+// Enabled reports whether warm start is on.
+func (c WarmStartConfig) Enabled() bool {
+	return c.Weight > 0
+}
+
+func (c WarmStartConfig) withDefaults() WarmStartConfig {
+	if c.Weight > 1 {
+		c.Weight = 1
+	}
+	if c.MinTrials <= 0 {
+		c.MinTrials = 5
+	}
+	if c.PriorTrials <= 0 {
+		c.PriorTrials = 5
+	}
+	return c
+}
+
+// warmStartActions are the actions warm start may switch to: they need no
+// parameters from the policy.
+var warmStartActions = []meta.Action{meta.ActionDirect, meta.ActionDecompose, meta.ActionMemoryQuery}
+
+// PromptSize classifies tasks by length, the state feature past outcomes
+// are grouped by.
+type PromptSize string
+
+const (
+	PromptShort  PromptSize = "short"
+	PromptMedium PromptSize = "medium"
+	PromptLong   PromptSize = "long"
+)
+
+// PromptSizeOf returns the size class of task: short under 50 tokens,
+// long from 500.
+func PromptSizeOf(task string) PromptSize {
+	switch tokens := estimateTokens(task); {
+	case tokens < 50:
+		return PromptShort
+	case tokens < 500:
+		return PromptMedium
+	default:
+		return PromptLong
+	}
+}
+
+// ActionRecord counts recorded outcomes of one action.
+type ActionRecord struct {
+	Trials    int `json:"trials"`
+	Successes int `json:"successes"`
+}
+
+// SuccessRate returns the Laplace-smoothed share of successful trials, so
+// a few outcomes do not read as certainty.
+func (r ActionRecord) SuccessRate() float64 {
+	return float64(r.Successes+1) / float64(r.Trials+2)
+}
+
+// ActionHistory returns how each top-level action fared on recorded tasks
+// of the given size. Every attempt of an escalated execution counts: those
+// before the last as failures, the last as the execution's outcome.
+func ActionHistory(ctx context.Context, store *hypergraph.Store, size PromptSize) (map[meta.Action]ActionRecord, error) {
+	history := make(map[meta.Action]ActionRecord)
+	if store == nil {
+		return history, nil
+	}
+	nodes, err := store.ListNodes(ctx, hypergraph.NodeFilter{
+		Types:    []hypergraph.NodeType{hypergraph.NodeTypeDecision},
+		Subtypes: []string{executionSubtype},
+		Limit:    maxHistoryCandidates,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list execution summaries: %w", err)
+	}
+
+	for _, node := range nodes {
+		if PromptSizeOf(node.Content) != size {
+			continue
+		}
+		data, ok, err := decodeExecutionSummary(node)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		for i, action := range data.Actions {
+			record := history[meta.Action(action)]
+			record.Trials++
+			if i == len(data.Actions)-1 && data.Success {
+				record.Successes++
+			}
+			history[meta.Action(action)] = record
+		}
+	}
+	return history, nil
+}
+
+// warmStart returns decision, or the action history favours over it for
+// tasks the size of state's. Each action scores a convex combination of
+// the proposal's prior (1 for the proposed action, 0.5 for the others) and
+// its success rate, history's share being trials / (trials + k)
+// where k = PriorTrials × (1 − Weight) / Weight, i.e. Weight at
+// PriorTrials outcomes. Only actions with at least MinTrials outcomes may
+// replace the proposal, and their history counts only from then on.
+func (c *Core) warmStart(ctx context.Context, state meta.State, decision *meta.Decision) *meta.Decision {
+	if !c.config.WarmStart.Enabled() || c.store == nil ||
+		state.RecursionDepth != 0 || state.BudgetRemain <= 0 {
+		return decision
+	}
+	cfg := c.config.WarmStart.withDefaults()
+	size := PromptSizeOf(state.Task)
+	history, err := ActionHistory(ctx, c.store, size)
+	if err != nil {
+		slog.Warn("Warm start skipped", "error", err)
+		return decision
+	}
+
+	// Pseudo-outcomes the proposal's prior is worth
+	k := float64(cfg.PriorTrials) * (1 - cfg.Weight) / cfg.Weight
+	score := func(action meta.Action) float64 {
+		prior := 0.5
+		if action == decision.Action {
+			prior = 1
+		}
+		record := history[action]
+		if record.Trials < cfg.MinTrials {
+			return prior
+		}
+		share := float64(record.Trials) / (float64(record.Trials) + k)
+		return (1-share)*prior + share*record.SuccessRate()
+	}
+
+	best, bestScore := decision.Action, score(decision.Action)
+	for _, action := range warmStartActions {
+		if history[action].Trials < cfg.MinTrials {
+			continue
+		}
+		if s := score(action); s > bestScore {
+			best, bestScore = action, s
+		}
+	}
+	if best == decision.Action {
+		return decision
+	}
+
+	proposed, chosen := history[decision.Action], history[best]
+	slog.Info("Warm start overrode proposed action",
+		"proposed", decision.Action,
+		"chosen", best,
+		"prompt_size", size)
+	return &meta.Decision{
+		Action: best,
+		Reasoning: fmt.Sprintf("history favours %s over proposed %s for %s prompts (%d/%d vs %d/%d succeeded); proposal: %s",
+			best, decision.Action, size, chosen.Successes, chosen.Trials, proposed.Successes, proposed.Trials, decision.Reasoning),
+	}
+}
@@ -0,0 +1,68 @@
+package rlm
+
+import (
+	"strings"
+
+	"github.com/rand/recurse/internal/rlm/repl"
+)
+
+// AnswerSource is a part of a REPL execution's result that can stand as
+// the answer.
+type AnswerSource string
+
+const (
+	// AnswerSourceStdout is what the code printed.
+	AnswerSourceStdout AnswerSource = "stdout"
+
+	// AnswerSourceReturnValue is the value of the code's last expression.
+	AnswerSourceReturnValue AnswerSource = "return_value"
+)
+
+// OutputPolicy decides which part of a REPL execution's result is taken
+// as the answer when the loop terminates early without FINAL(): printed
+// output or the last expression's value. FINAL() always wins over both.
+// The zero value takes printed output, falling back to the return value.
+type OutputPolicy struct {
+	// Authoritative is the source answers are taken from and early
+	// termination checks for a stable answer. Default: AnswerSourceStdout.
+	Authoritative AnswerSource
+
+	// Strict takes answers only from the authoritative source. Otherwise
+	// the other source is used when the authoritative one is empty.
+	Strict bool
+}
+
+// authoritative returns the policy's authoritative source.
+func (p OutputPolicy) authoritative() AnswerSource {
+	if p.Authoritative == "" {
+		return AnswerSourceStdout
+	}
+	return p.Authoritative
+}
+
+// answer returns the answer the policy takes from a result's printed
+// output and return value, trimmed, or "" if there is none.
+func (p OutputPolicy) answer(output, returnVal string) string {
+	stdout := strings.TrimSpace(output)
+	value := strings.TrimSpace(returnVal)
+	if value == "None" {
+		value = ""
+	}
+
+	primary, secondary := stdout, value
+	if p.authoritative() == AnswerSourceReturnValue {
+		primary, secondary = value, stdout
+	}
+	if primary != "" || p.Strict {
+		return primary
+	}
+	return secondary
+}
+
+// resultAnswer returns the answer the policy takes from result.
+func (p OutputPolicy) resultAnswer(result *repl.ExecuteResult) string {
+	if result == nil {
+		return ""
+	}
+	return p.answer(result.Output, result.ReturnVal)
+}
@@ -0,0 +1,87 @@
+package rlm
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rand/recurse/internal/rlm/repl"
+)
+
+func TestOutputPolicy_Answer(t *testing.T) {
+	tests := []struct {
+		name              string
+		policy            OutputPolicy
+		output, returnVal string
+		want              string
+	}{
+		{"default prefers stdout", OutputPolicy{}, "3\n", "7", "3"},
+		{"default falls back to the value", OutputPolicy{}, "", "7", "7"},
+		{"return value preferred", OutputPolicy{Authoritative: AnswerSourceReturnValue}, "3\n", "7", "7"},
+		{"return value falls back to stdout", OutputPolicy{Authoritative: AnswerSourceReturnValue}, "3\n", "None", "3"},
+		{"strict stdout", OutputPolicy{Strict: true}, "", "7", ""},
+		{"strict return value", OutputPolicy{Authoritative: AnswerSourceReturnValue, Strict: true}, "3", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.policy.answer(tt.output, tt.returnVal))
+		})
+	}
+}
+
+func TestTerminationTracker_StabilityFollowsOutputPolicy(t *testing.T) {
+	tracker := NewTerminationTracker(TaskTypeAnalytical)
+	tracker.SetOutputPolicy(OutputPolicy{Authoritative: AnswerSourceReturnValue, Strict: true})
+
+	// Printed progress differs; the value does not
+	check := tracker.CheckTermination(&IterationResult{REPLOutput: "scanning 1", ReturnValue: "'42'", Iteration: 1})
+	assert.False(t, check.ShouldTerminate)
+	check = tracker.CheckTermination(&IterationResult{REPLOutput: "scanning 2", ReturnValue: "'42'", Iteration: 2})
+	assert.True(t, check.ShouldTerminate)
+	assert.Equal(t, "answer stabilized across iterations", check.Reason)
+}
+
+// TestExecuteRLM_EarlyTerminationAnswerSource runs code that prints one
+// value while its last expression evaluates to another.
+func TestExecuteRLM_EarlyTerminationAnswerSource(t *testing.T) {
+	const code = "```python\nprint(len('abc'))\nlen('abcdefg')\n```"
+
+	run := func(t *testing.T, policy OutputPolicy) *RLMExecutionResult {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		replMgr, err := repl.NewManager(repl.Options{})
+		require.NoError(t, err)
+		require.NoError(t, replMgr.Start(ctx))
+		defer replMgr.Stop()
+
+		w := &Wrapper{replMgr: replMgr, client: &wrapperMockLLMClient{responses: []string{code, code, code}}}
+		prepared := &PreparedPrompt{
+			Mode:           ModeRLM,
+			SystemPrompt:   "system",
+			FinalPrompt:    "How many characters?",
+			Classification: &Classification{Type: TaskTypeComputational, Confidence: 0.9},
+		}
+		result, err := w.ExecuteRLMWithConfig(ctx, prepared, RLMConfig{
+			MaxIterations:          3,
+			MaxTokensPerCall:       1024,
+			EnableEarlyTermination: true,
+			OutputPolicy:           policy,
+		})
+		require.NoError(t, err)
+		require.True(t, result.EarlyTerminated)
+		assert.Equal(t, 1, result.Iterations)
+		return result
+	}
+
+	t.Run("printed output by default", func(t *testing.T) {
+		assert.Equal(t, "3", run(t, OutputPolicy{}).FinalOutput)
+	})
+
+	t.Run("return value when authoritative", func(t *testing.T) {
+		assert.Equal(t, "7", run(t, OutputPolicy{Authoritative: AnswerSourceReturnValue}).FinalOutput)
+	})
+}
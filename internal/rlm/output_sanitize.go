@@ -0,0 +1,203 @@
+package rlm
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// OutputSanitizeConfig configures the cleanup of FINAL() output before it
+// reaches consumers. Models occasionally emit terminal escapes, stray
+// control characters or a phrase repeated thousands of times, which
+// corrupt terminals and trip downstream parsers.
+type OutputSanitizeConfig struct {
+	// Enabled turns sanitization on. Off by default.
+	Enabled bool
+
+	// MaxSize is the largest output kept, in bytes; longer output is cut
+	// at a character boundary and ends with a truncation marker
+	// (default: 100000).
+	MaxSize int
+
+	// MaxRepeatRun is the longest run of one repeated sequence kept, in
+	// bytes; the rest of the run is replaced by a marker saying how many
+	// repetitions were elided (default: 1000).
+	MaxRepeatRun int
+
+	// IncludeStructured also sanitizes "json" and "code" outputs, whose
+	// control characters and repetition may be legitimate. By default
+	// they are passed through untouched.
+	IncludeStructured bool
+}
+
+func (c OutputSanitizeConfig) withDefaults() OutputSanitizeConfig {
+	if c.MaxSize <= 0 {
+		c.MaxSize = 100000
+	}
+	if c.MaxRepeatRun <= 0 {
+		c.MaxRepeatRun = 1000
+	}
+	return c
+}
+
+// appliesTo reports whether output of the given FINAL type is sanitized.
+func (c OutputSanitizeConfig) appliesTo(finalType string) bool {
+	if !c.Enabled {
+		return false
+	}
+	switch finalType {
+	case "json", "code":
+		return c.IncludeStructured
+	default:
+		return true
+	}
+}
+
+// OutputSanitizeReport says what sanitization changed.
+type OutputSanitizeReport struct {
+	// EscapesRemoved counts ANSI escape sequences stripped.
+	EscapesRemoved int `json:"escapes_removed,omitempty"`
+
+	// ControlsEscaped counts other control characters replaced by a
+	// visible \xNN escape.
+	ControlsEscaped int `json:"controls_escaped,omitempty"`
+
+	// RepetitionsElided counts repetitions dropped from overlong runs.
+	RepetitionsElided int `json:"repetitions_elided,omitempty"`
+
+	// Truncated is true when the output was cut to MaxSize.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// Changed reports whether sanitization modified the output.
+func (r OutputSanitizeReport) Changed() bool {
+	return r.EscapesRemoved > 0 || r.ControlsEscaped > 0 || r.RepetitionsElided > 0 || r.Truncated
+}
+
+// maxRepeatUnit is the longest sequence, in bytes, checked for repetition.
+const maxRepeatUnit = 64
+
+// ansiEscape matches CSI sequences (colors, cursor movement), OSC
+// sequences (titles, hyperlinks) terminated by BEL or ST, and two-byte
+// escapes.
+var ansiEscape = regexp.MustCompile(`\x1b(?:\[[0-9;?<=>!]*[ -/]*[@-~]|\][^\x07\x1b]*(?:\x07|\x1b\\)|[@-Z\\-_])`)
+
+// SanitizeOutput strips ANSI escapes, escapes other control characters,
+// collapses overlong repetition and caps the size of output, in that
+// order. Newlines and tabs are kept, and CRLF becomes LF.
+func SanitizeOutput(output string, cfg OutputSanitizeConfig) (string, OutputSanitizeReport) {
+	cfg = cfg.withDefaults()
+	var report OutputSanitizeReport
+
+	output = ansiEscape.ReplaceAllStringFunc(output, func(string) string {
+		report.EscapesRemoved++
+		return ""
+	})
+	output, report.ControlsEscaped = escapeControls(output)
+	output, report.RepetitionsElided = collapseRepetition(output, cfg.MaxRepeatRun)
+	output, report.Truncated = capSize(output, cfg.MaxSize)
+	return output, report
+}
+
+// escapeControls replaces control characters other than newline and tab
+// with \xNN (\u00NN for C1 controls), returning the count replaced.
+func escapeControls(s string) (string, int) {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	if strings.IndexFunc(s, isEscapedControl) < 0 {
+		return s, 0
+	}
+	var sb strings.Builder
+	count := 0
+	for _, r := range s {
+		switch {
+		case !isEscapedControl(r):
+			sb.WriteRune(r)
+		case r < 0x80:
+			fmt.Fprintf(&sb, `\x%02x`, r)
+			count++
+		default:
+			fmt.Fprintf(&sb, `\u%04x`, r)
+			count++
+		}
+	}
+	return sb.String(), count
+}
+
+// isEscapedControl reports whether r is a C0 or C1 control character
+// other than newline and tab.
+func isEscapedControl(r rune) bool {
+	if r == '\n' || r == '\t' {
+		return false
+	}
+	return r < 0x20 || r >= 0x7f && r < 0xa0
+}
+
+// collapseRepetition cuts every run of a sequence of up to maxRepeatUnit
+// bytes repeated back to back over maxRun bytes down to the whole
+// repetitions fitting in maxRun, followed by a marker. Shorter sequences
+// are collapsed first, so "abab…" is treated as a run of "ab", not of
+// "abab". It returns the number of repetitions elided.
+func collapseRepetition(s string, maxRun int) (string, int) {
+	total := 0
+	for unit := 1; unit <= maxRepeatUnit && unit <= maxRun; unit++ {
+		var elided int
+		s, elided = collapsePeriod(s, unit, maxRun)
+		total += elided
+	}
+	return s, total
+}
+
+// collapsePeriod collapses the runs of s with period unit.
+func collapsePeriod(s string, unit, maxRun int) (string, int) {
+	var sb strings.Builder
+	written, total := 0, 0
+	for i := 0; i+unit < len(s); {
+		if s[i] != s[i+unit] {
+			i++
+			continue
+		}
+		// s[start:end] repeats its first unit bytes throughout
+		start := i
+		for i+unit < len(s) && s[i] == s[i+unit] {
+			i++
+		}
+		end := i + unit
+		if end-start <= maxRun {
+			continue
+		}
+
+		keep := maxRun / unit * unit
+		reps := (end - start - keep) / unit
+		cut, resume := start+keep, start+keep+reps*unit
+		// Both ends sit at the same offset in the unit, so moving them
+		// back together onto a character boundary keeps the run whole
+		for cut > start && !utf8.RuneStart(s[cut]) {
+			cut--
+			resume--
+		}
+		sb.WriteString(s[written:cut])
+		fmt.Fprintf(&sb, "[... %d more repetitions elided ...]", reps)
+		written = resume
+		total += reps
+		i = end
+	}
+	if total == 0 {
+		return s, 0
+	}
+	sb.WriteString(s[written:])
+	return sb.String(), total
+}
+
+// capSize cuts s to at most maxSize bytes on a character boundary,
+// appending a marker with the original size.
+func capSize(s string, maxSize int) (string, bool) {
+	if len(s) <= maxSize {
+		return s, false
+	}
+	cut := maxSize
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut] + fmt.Sprintf("\n[... output truncated: %d of %d bytes shown ...]", cut, len(s)), true
+}
@@ -0,0 +1,143 @@
+package rlm
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rand/recurse/internal/rlm/repl"
+)
+
+func TestSanitizeOutput(t *testing.T) {
+	cfg := OutputSanitizeConfig{Enabled: true}
+
+	t.Run("ANSI escapes are stripped", func(t *testing.T) {
+		out, report := SanitizeOutput("\x1b[1;31mError\x1b[0m: \x1b]0;pwned\x07disk full\x1b[2K", cfg)
+		assert.Equal(t, "Error: disk full", out)
+		assert.Equal(t, 4, report.EscapesRemoved)
+		assert.True(t, report.Changed())
+	})
+
+	t.Run("other control characters are escaped", func(t *testing.T) {
+		out, report := SanitizeOutput("line one\r\nbell\a\ttab\x00\u0085end\rover", cfg)
+		assert.Equal(t, `line one`+"\n"+`bell\x07`+"\t"+`tab\x00\u0085end\x0dover`, out)
+		assert.Equal(t, 4, report.ControlsEscaped)
+	})
+
+	t.Run("huge repetition is collapsed", func(t *testing.T) {
+		input := "The answer is " + strings.Repeat("yes ", 100000) + "final."
+		out, report := SanitizeOutput(input, cfg)
+		assert.True(t, strings.HasPrefix(out, "The answer is yes yes "))
+		assert.Contains(t, out, "[... 99750 more repetitions elided ...]")
+		assert.True(t, strings.HasSuffix(out, " final."))
+		assert.Equal(t, 100000-250, report.RepetitionsElided)
+		assert.Less(t, len(out), 1100)
+		assert.False(t, report.Truncated)
+	})
+
+	t.Run("multibyte repetition keeps characters whole", func(t *testing.T) {
+		out, report := SanitizeOutput(strings.Repeat("é", 5000), OutputSanitizeConfig{Enabled: true, MaxRepeatRun: 101})
+		assert.True(t, utf8.ValidString(out))
+		assert.Positive(t, report.RepetitionsElided)
+	})
+
+	t.Run("ordinary repetition is kept", func(t *testing.T) {
+		input := "| a | b |\n|---|---|\n" + strings.Repeat("=", 80) + "\n" + strings.Repeat("row\n", 20)
+		out, report := SanitizeOutput(input, cfg)
+		assert.Equal(t, input, out)
+		assert.False(t, report.Changed())
+	})
+
+	t.Run("oversized output is truncated with a marker", func(t *testing.T) {
+		var sb strings.Builder
+		for i := 0; sb.Len() < 5000; i++ {
+			sb.WriteString("ünïque sentence number ")
+			sb.WriteString(strings.Repeat("x", i%7))
+			sb.WriteString(". ")
+		}
+		out, report := SanitizeOutput(sb.String(), OutputSanitizeConfig{Enabled: true, MaxSize: 1001})
+		assert.True(t, report.Truncated)
+		assert.True(t, utf8.ValidString(out))
+		assert.Contains(t, out, "output truncated")
+		assert.LessOrEqual(t, len(strings.SplitN(out, "\n[...", 2)[0]), 1001)
+	})
+}
+
+func TestOutputSanitizeConfig_AppliesTo(t *testing.T) {
+	assert.False(t, OutputSanitizeConfig{}.appliesTo("text"))
+
+	cfg := OutputSanitizeConfig{Enabled: true}
+	assert.True(t, cfg.appliesTo("text"))
+	assert.True(t, cfg.appliesTo("markdown"))
+	assert.False(t, cfg.appliesTo("json"))
+	assert.False(t, cfg.appliesTo("code"))
+
+	cfg.IncludeStructured = true
+	assert.True(t, cfg.appliesTo("json"))
+	assert.True(t, cfg.appliesTo("code"))
+}
+
+func TestExecuteRLM_OutputSanitized(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	replMgr, err := repl.NewManager(repl.Options{})
+	require.NoError(t, err)
+	require.NoError(t, replMgr.Start(ctx))
+	defer replMgr.Stop()
+
+	run := func(t *testing.T, code string, cfg OutputSanitizeConfig) *RLMExecutionResult {
+		client := &wrapperMockLLMClient{responses: []string{"```python\n" + code + "\n```"}}
+		w := &Wrapper{replMgr: replMgr, client: client, outputSanitize: cfg}
+		prepared := &PreparedPrompt{
+			Mode:         ModeRLM,
+			SystemPrompt: "You are an RLM assistant.",
+			FinalPrompt:  "Report the build status",
+		}
+		result, err := w.ExecuteRLMWithConfig(ctx, prepared, RLMConfig{
+			MaxIterations:    2,
+			MaxTokensPerCall: 1024,
+			Timeout:          20 * time.Second,
+		})
+		require.NoError(t, err)
+		return result
+	}
+	code := `FINAL("\x1b[32mBuild passed\x1b[0m " + "ok " * 50000)`
+
+	t.Run("text output is sanitized", func(t *testing.T) {
+		result := run(t, code, OutputSanitizeConfig{Enabled: true})
+		assert.True(t, strings.HasPrefix(result.FinalOutput, "Build passed ok ok "))
+		assert.NotContains(t, result.FinalOutput, "\x1b")
+		assert.Contains(t, result.FinalOutput, "repetitions elided")
+		assert.Less(t, len(result.FinalOutput), 2000)
+		require.NotNil(t, result.Sanitization)
+		assert.Equal(t, 2, result.Sanitization.EscapesRemoved)
+	})
+
+	t.Run("disabled leaves output as is", func(t *testing.T) {
+		result := run(t, code, OutputSanitizeConfig{})
+		assert.Contains(t, result.FinalOutput, "\x1b[32m")
+		assert.Greater(t, len(result.FinalOutput), 150000)
+		assert.Nil(t, result.Sanitization)
+	})
+
+	t.Run("JSON output is left alone", func(t *testing.T) {
+		result := run(t, `FINAL_JSON({"log": "\x1b[32mok\x1b[0m"})`, OutputSanitizeConfig{Enabled: true})
+		assert.Equal(t, "json", result.FinalType)
+		assert.Nil(t, result.Sanitization)
+	})
+
+	t.Run("fallback output is sanitized and reported", func(t *testing.T) {
+		// A NaN output type makes the metadata invalid JSON
+		result := run(t, strings.Replace(code, ")", `, float("nan"))`, 1), OutputSanitizeConfig{Enabled: true})
+		assert.Equal(t, "text", result.FinalType)
+		assert.Contains(t, result.FinalOutput, "repetitions elided")
+		require.NotNil(t, result.Sanitization)
+		assert.Positive(t, result.Sanitization.RepetitionsElided)
+	})
+}
@@ -0,0 +1,78 @@
+package rlm
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/google/jsonschema-go/jsonschema"
+)
+
+// OutputSchema is a JSON Schema (draft 2020-12) that the answer to a
+// structured task must satisfy. In RLM mode it is shown to the model, and a
+// FINAL_JSON answer that violates it is sent back once for correction.
+type OutputSchema struct {
+	raw      json.RawMessage
+	resolved *jsonschema.Resolved
+}
+
+// NewOutputSchema parses and resolves a JSON Schema document.
+func NewOutputSchema(schema []byte) (*OutputSchema, error) {
+	var s jsonschema.Schema
+	if err := json.Unmarshal(schema, &s); err != nil {
+		return nil, fmt.Errorf("parse output schema: %w", err)
+	}
+	resolved, err := s.Resolve(nil)
+	if err != nil {
+		return nil, fmt.Errorf("resolve output schema: %w", err)
+	}
+
+	// Keep an indented copy for the prompt
+	raw, err := json.MarshalIndent(json.RawMessage(schema), "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("format output schema: %w", err)
+	}
+	return &OutputSchema{raw: raw, resolved: resolved}, nil
+}
+
+// String returns the schema document.
+func (s *OutputSchema) String() string {
+	return string(s.raw)
+}
+
+// Validate checks that answer is JSON satisfying the schema.
+func (s *OutputSchema) Validate(answer string) error {
+	var instance any
+	if err := json.Unmarshal([]byte(strings.TrimSpace(answer)), &instance); err != nil {
+		return fmt.Errorf("answer is not valid JSON: %w", err)
+	}
+	return s.resolved.Validate(instance)
+}
+
+// promptSection tells the model the shape its answer must take.
+func (s *OutputSchema) promptSection() string {
+	var sb strings.Builder
+	sb.WriteString("## Required Output Format\n")
+	sb.WriteString("Return your answer with FINAL_JSON(obj), where obj satisfies this JSON Schema. ")
+	sb.WriteString("Include every required field and use the types it specifies.\n")
+	sb.WriteString("```json\n")
+	sb.WriteString(s.String())
+	sb.WriteString("\n```\n\n")
+	return sb.String()
+}
+
+// buildSchemaCorrectionPrompt asks the model to fix a FINAL_JSON answer that
+// failed validation against the output schema.
+func buildSchemaCorrectionPrompt(answer string, err error, schema *OutputSchema) string {
+	var sb strings.Builder
+	sb.WriteString("Your FINAL_JSON answer does not match the required output schema:\n```\n")
+	sb.WriteString(truncate(answer, 2000))
+	sb.WriteString("\n```\n")
+	sb.WriteString("Validation error: ")
+	sb.WriteString(err.Error())
+	sb.WriteString("\n\nThe schema is:\n```json\n")
+	sb.WriteString(schema.String())
+	sb.WriteString("\n```\n")
+	sb.WriteString("Fix the answer and call FINAL_JSON() again with an object that satisfies the schema.")
+	return sb.String()
+}
@@ -0,0 +1,170 @@
+package rlm
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rand/recurse/internal/rlm/repl"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const personSchema = `{
+	"type": "object",
+	"properties": {
+		"name": {"type": "string"},
+		"age": {"type": "integer", "minimum": 0}
+	},
+	"required": ["name", "age"]
+}`
+
+func TestOutputSchema_Validate(t *testing.T) {
+	schema, err := NewOutputSchema([]byte(personSchema))
+	require.NoError(t, err)
+
+	assert.NoError(t, schema.Validate(`{"name": "Ada", "age": 36}`))
+	assert.NoError(t, schema.Validate("\n{\"name\": \"Ada\", \"age\": 36}\n"))
+
+	assert.ErrorContains(t, schema.Validate(`{"name": "Ada"}`), "age")
+	assert.ErrorContains(t, schema.Validate(`{"name": "Ada", "age": "36"}`), "age")
+	assert.ErrorContains(t, schema.Validate(`{"name": "Ada", "age": -1}`), "minimum")
+	assert.ErrorContains(t, schema.Validate("Ada is 36"), "not valid JSON")
+}
+
+func TestNewOutputSchema_Invalid(t *testing.T) {
+	_, err := NewOutputSchema([]byte(`{"type": `))
+	assert.ErrorContains(t, err, "parse output schema")
+
+	_, err = NewOutputSchema([]byte(`{"$ref": "#/$defs/missing"}`))
+	assert.ErrorContains(t, err, "resolve output schema")
+}
+
+func TestOutputSchema_PromptSection(t *testing.T) {
+	schema, err := NewOutputSchema([]byte(`{"type":"object","required":["name"]}`))
+	require.NoError(t, err)
+
+	section := schema.promptSection()
+	assert.Contains(t, section, "FINAL_JSON")
+	assert.Contains(t, section, "\"required\": [\n    \"name\"\n  ]", "schema is shown indented")
+}
+
+// runSchemaRLM runs an execution whose answers come from responses.
+func runSchemaRLM(t *testing.T, prepared *PreparedPrompt, cfg RLMConfig, responses ...string) (*RLMExecutionResult, *wrapperMockLLMClient) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	replMgr, err := repl.NewManager(repl.Options{})
+	require.NoError(t, err)
+	require.NoError(t, replMgr.Start(ctx))
+	t.Cleanup(func() { replMgr.Stop() })
+
+	client := &wrapperMockLLMClient{responses: responses}
+	w := &Wrapper{replMgr: replMgr, client: client}
+
+	cfg.MaxTokensPerCall = 1024
+	cfg.Timeout = 10 * time.Second
+	result, err := w.ExecuteRLMWithConfig(ctx, prepared, cfg)
+	require.NoError(t, err)
+	return result, client
+}
+
+func TestExecuteRLM_OutputSchemaCorrection(t *testing.T) {
+	schema, err := NewOutputSchema([]byte(personSchema))
+	require.NoError(t, err)
+
+	prepared := &PreparedPrompt{
+		Mode:         ModeRLM,
+		SystemPrompt: "You are an RLM assistant.",
+		FinalPrompt:  "Who is in the record?",
+	}
+	result, client := runSchemaRLM(t, prepared, RLMConfig{MaxIterations: 5, OutputSchema: schema},
+		"```python\nFINAL_JSON({\"name\": \"Ada\", \"age\": \"36\"})\n```",
+		"```python\nFINAL_JSON({\"name\": \"Ada\", \"age\": 36})\n```",
+	)
+
+	assert.Empty(t, result.Error)
+	assert.Equal(t, 1, result.SchemaCorrections)
+	assert.Empty(t, result.SchemaError)
+	assert.Equal(t, "json", result.FinalType)
+	assert.JSONEq(t, `{"name": "Ada", "age": 36}`, result.FinalOutput)
+	assert.Equal(t, 2, result.Iterations)
+
+	// The schema is in the system prompt from the start, and the
+	// validation error is fed back with the rejected answer.
+	require.Len(t, client.calls, 2)
+	assert.Contains(t, client.calls[0], "## Required Output Format")
+	assert.Contains(t, client.calls[1], "does not match the required output schema")
+	assert.Contains(t, client.calls[1], "\"36\"")
+	assert.Contains(t, client.calls[1], "Validation error:")
+}
+
+func TestExecuteRLM_OutputSchemaSingleCorrectionRound(t *testing.T) {
+	schema, err := NewOutputSchema([]byte(personSchema))
+	require.NoError(t, err)
+
+	// The prepared prompt already describes its schema
+	prepared := &PreparedPrompt{
+		Mode:         ModeRLM,
+		SystemPrompt: "You are an RLM assistant.\n" + schema.promptSection(),
+		FinalPrompt:  "Who is in the record?",
+		OutputSchema: schema,
+	}
+	result, client := runSchemaRLM(t, prepared, RLMConfig{MaxIterations: 5},
+		"```python\nFINAL_JSON({\"name\": \"Ada\"})\n```",
+		"```python\nFINAL_JSON({\"name\": \"Ada\", \"age\": -1})\n```",
+	)
+
+	// The second answer is accepted despite failing again, flagged
+	assert.Empty(t, result.Error)
+	assert.Equal(t, 1, result.SchemaCorrections)
+	assert.Contains(t, result.SchemaError, "minimum")
+	assert.JSONEq(t, `{"name": "Ada", "age": -1}`, result.FinalOutput)
+	assert.Equal(t, 2, result.Iterations)
+	assert.Equal(t, 1, strings.Count(client.calls[0], "## Required Output Format"))
+}
+
+func TestExecuteRLM_OutputSchemaUncorrectedAnswerStands(t *testing.T) {
+	schema, err := NewOutputSchema([]byte(personSchema))
+	require.NoError(t, err)
+
+	prepared := &PreparedPrompt{
+		Mode:         ModeRLM,
+		SystemPrompt: "You are an RLM assistant.",
+		FinalPrompt:  "Who is in the record?",
+	}
+	result, _ := runSchemaRLM(t, prepared, RLMConfig{MaxIterations: 2, OutputSchema: schema},
+		"```python\nFINAL_JSON({\"name\": \"Ada\"})\n```",
+		"```python\nprint('checking')\n```",
+	)
+
+	assert.Empty(t, result.Error)
+	assert.JSONEq(t, `{"name": "Ada"}`, result.FinalOutput)
+	assert.Contains(t, result.SchemaError, "age")
+}
+
+func TestPrepareContextWithOptions_OutputSchema(t *testing.T) {
+	ctx := context.Background()
+	schema, err := NewOutputSchema([]byte(personSchema))
+	require.NoError(t, err)
+
+	w := NewWrapper(&Service{}, DefaultWrapperConfig())
+	replMgr, err := repl.NewManager(repl.Options{})
+	require.NoError(t, err)
+	require.NoError(t, replMgr.Start(ctx))
+	defer replMgr.Stop()
+	w.SetREPLManager(replMgr)
+
+	contexts := []ContextSource{{Type: ContextTypeFile, Content: "Ada Lovelace, 36"}}
+	prepared, err := w.PrepareContextWithOptions(ctx, "Who is in the record?", contexts, PrepareOptions{
+		ModeOverride: ModeOverrideRLM,
+		OutputSchema: schema,
+	})
+	require.NoError(t, err)
+	require.Equal(t, ModeRLM, prepared.Mode)
+	assert.Same(t, schema, prepared.OutputSchema)
+	assert.Contains(t, prepared.SystemPrompt, "## Required Output Format")
+	assert.Contains(t, prepared.SystemPrompt, `"minimum": 0`)
+}
@@ -6,8 +6,10 @@ import (
 	_ "embed"
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 
@@ -25,6 +27,7 @@ type PersistentTraceProvider struct {
 	mu        sync.RWMutex
 	ownsDB    bool // whether we own the db connection
 	sessionID string
+	buffer    *traceBuffer // nil when events are written synchronously
 }
 
 // PersistentTraceConfig configures the persistent trace provider.
@@ -39,6 +42,11 @@ type PersistentTraceConfig struct {
 
 	// SessionID optionally links trace events to a session.
 	SessionID string
+
+	// Buffer makes RecordEvent write-behind, batching writes on a
+	// background goroutine. Reads flush pending events first. Write errors
+	// are then logged and counted rather than returned to the recorder.
+	Buffer TraceBufferConfig
 }
 
 // NewPersistentTraceProvider creates a new persistent trace provider.
@@ -88,6 +96,10 @@ func NewPersistentTraceProvider(cfg PersistentTraceConfig) (*PersistentTraceProv
 		return nil, fmt.Errorf("init schema: %w", err)
 	}
 
+	if cfg.Buffer.Enabled() {
+		p.buffer = newTraceBuffer(cfg.Buffer, p.insertEvents)
+	}
+
 	return p, nil
 }
 
@@ -104,8 +116,13 @@ func (p *PersistentTraceProvider) initSchema() error {
 	return nil
 }
 
-// Close closes the database if owned.
+// Close writes any buffered events and closes the database if owned.
 func (p *PersistentTraceProvider) Close() error {
+	if p.buffer != nil {
+		if err := p.buffer.close(); err != nil {
+			slog.Warn("Failed to write trace events", "error", err)
+		}
+	}
 	if p.ownsDB && p.db != nil {
 		return p.db.Close()
 	}
@@ -121,119 +138,168 @@ func (p *PersistentTraceProvider) SetSessionID(sessionID string) {
 
 // RecordEvent implements TraceRecorder interface for the RLM controller.
 func (p *PersistentTraceProvider) RecordEvent(event TraceEvent) error {
-	p.mu.Lock()
-	defer p.mu.Unlock()
-
-	ctx := context.Background()
+	p.mu.RLock()
+	item := pendingTraceEvent{event: event, sessionID: p.sessionID}
+	p.mu.RUnlock()
 
-	var sessionID sql.NullString
-	if p.sessionID != "" {
-		sessionID = sql.NullString{String: p.sessionID, Valid: true}
+	if p.buffer != nil {
+		return p.buffer.add(item)
 	}
+	return p.insertEvents([]pendingTraceEvent{item})
+}
 
-	var parentID sql.NullString
-	if event.ParentID != "" {
-		parentID = sql.NullString{String: event.ParentID, Valid: true}
-	}
+// insertEvents writes events in one transaction.
+func (p *PersistentTraceProvider) insertEvents(events []pendingTraceEvent) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
 
-	var details sql.NullString
-	if event.Details != "" {
-		details = sql.NullString{String: event.Details, Valid: true}
+	ctx := context.Background()
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin trace transaction: %w", err)
 	}
+	defer tx.Rollback()
 
-	_, err := p.db.ExecContext(ctx, `
+	stmt, err := tx.PrepareContext(ctx, `
 		INSERT INTO trace_events (
 			id, session_id, type, action, details, tokens,
 			duration_ns, depth, parent_id, status, created_at
 		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
-	`,
-		event.ID,
-		sessionID,
-		mapEventType(event.Type),
-		event.Action,
-		details,
-		event.Tokens,
-		event.Duration.Nanoseconds(),
-		event.Depth,
-		parentID,
-		event.Status,
-		event.Timestamp.UnixMilli(),
-	)
+	`)
 	if err != nil {
-		return fmt.Errorf("insert trace event: %w", err)
+		return fmt.Errorf("prepare trace insert: %w", err)
 	}
+	defer stmt.Close()
+
+	for _, item := range events {
+		event := item.event
 
+		var sessionID sql.NullString
+		if item.sessionID != "" {
+			sessionID = sql.NullString{String: item.sessionID, Valid: true}
+		}
+
+		var parentID sql.NullString
+		if event.ParentID != "" {
+			parentID = sql.NullString{String: event.ParentID, Valid: true}
+		}
+
+		var details sql.NullString
+		if event.Details != "" {
+			details = sql.NullString{String: event.Details, Valid: true}
+		}
+
+		if _, err := stmt.ExecContext(ctx,
+			event.ID,
+			sessionID,
+			mapEventType(event.Type),
+			event.Action,
+			details,
+			event.Tokens,
+			event.Duration.Nanoseconds(),
+			event.Depth,
+			parentID,
+			event.Status,
+			event.Timestamp.UnixMilli(),
+		); err != nil {
+			return fmt.Errorf("insert trace event: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit trace events: %w", err)
+	}
 	return nil
 }
 
+// Flush writes any buffered events. It is a no-op without a buffer.
+func (p *PersistentTraceProvider) Flush() error {
+	if p.buffer == nil {
+		return nil
+	}
+	return p.buffer.flush()
+}
+
+// BufferMetrics returns write-behind buffer statistics, zero without a
+// buffer.
+func (p *PersistentTraceProvider) BufferMetrics() TraceBufferMetrics {
+	if p.buffer == nil {
+		return TraceBufferMetrics{}
+	}
+	return p.buffer.metrics()
+}
+
+// flushForRead makes buffered events visible to a read. Write errors were
+// already counted, so the read goes ahead regardless.
+func (p *PersistentTraceProvider) flushForRead() {
+	if err := p.Flush(); err != nil {
+		slog.Warn("Failed to write trace events", "error", err)
+	}
+}
+
 // GetEvents implements rlmtrace.TraceProvider.
 func (p *PersistentTraceProvider) GetEvents(limit int) ([]rlmtrace.TraceEvent, error) {
+	return p.Query(TraceQuery{Limit: limit})
+}
+
+// Query implements TraceBackend.
+func (p *PersistentTraceProvider) Query(q TraceQuery) ([]rlmtrace.TraceEvent, error) {
+	p.flushForRead()
 	p.mu.RLock()
 	defer p.mu.RUnlock()
 
-	if limit <= 0 {
-		limit = 100
+	var where []string
+	var args []any
+	if q.SessionID != "" {
+		where = append(where, "session_id = ?")
+		args = append(args, q.SessionID)
+	}
+	if q.ParentID != "" {
+		where = append(where, "parent_id = ?")
+		args = append(args, q.ParentID)
+	}
+	if q.Status != "" {
+		where = append(where, "status = ?")
+		args = append(args, q.Status)
+	}
+	if len(q.Types) > 0 {
+		placeholders := make([]string, len(q.Types))
+		for i, t := range q.Types {
+			placeholders[i] = "?"
+			args = append(args, string(t))
+		}
+		where = append(where, "type IN ("+strings.Join(placeholders, ", ")+")")
+	}
+	if !q.Since.IsZero() {
+		where = append(where, "created_at >= ?")
+		args = append(args, q.Since.UnixMilli())
+	}
+	if !q.Until.IsZero() {
+		where = append(where, "created_at < ?")
+		args = append(args, q.Until.UnixMilli())
 	}
 
-	ctx := context.Background()
-	rows, err := p.db.QueryContext(ctx, `
+	query := `
 		SELECT id, session_id, type, action, details, tokens,
 		       duration_ns, depth, parent_id, status, created_at
-		FROM trace_events
-		ORDER BY created_at DESC
-		LIMIT ?
-	`, limit)
+		FROM trace_events`
+	if len(where) > 0 {
+		query += "\n\t\tWHERE " + strings.Join(where, " AND ")
+	}
+	query += `
+		ORDER BY created_at DESC, rowid DESC
+		LIMIT ?`
+	args = append(args, q.limit())
+
+	rows, err := p.db.QueryContext(context.Background(), query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("query trace events: %w", err)
 	}
 	defer rows.Close()
 
-	var events []rlmtrace.TraceEvent
-	for rows.Next() {
-		var (
-			id         string
-			sessionID  sql.NullString
-			eventType  string
-			action     string
-			details    sql.NullString
-			tokens     int
-			durationNs int64
-			depth      int
-			parentID   sql.NullString
-			status     string
-			createdAt  int64
-		)
-
-		if err := rows.Scan(
-			&id, &sessionID, &eventType, &action, &details, &tokens,
-			&durationNs, &depth, &parentID, &status, &createdAt,
-		); err != nil {
-			return nil, fmt.Errorf("scan trace event: %w", err)
-		}
-
-		event := rlmtrace.TraceEvent{
-			ID:        id,
-			Type:      rlmtrace.TraceEventType(eventType),
-			Action:    action,
-			Tokens:    tokens,
-			Duration:  time.Duration(durationNs),
-			Timestamp: time.UnixMilli(createdAt),
-			Depth:     depth,
-			Status:    status,
-		}
-
-		if details.Valid {
-			event.Details = details.String
-		}
-		if parentID.Valid {
-			event.ParentID = parentID.String
-		}
-
-		events = append(events, event)
-	}
-
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("iterate trace events: %w", err)
+	events, err := p.scanEvents(rows)
+	if err != nil {
+		return nil, err
 	}
 
 	// Reverse to get chronological order (oldest first)
@@ -246,6 +312,7 @@ func (p *PersistentTraceProvider) GetEvents(limit int) ([]rlmtrace.TraceEvent, e
 
 // GetEvent implements rlmtrace.TraceProvider.
 func (p *PersistentTraceProvider) GetEvent(id string) (*rlmtrace.TraceEvent, error) {
+	p.flushForRead()
 	p.mu.RLock()
 	defer p.mu.RUnlock()
 
@@ -304,6 +371,7 @@ func (p *PersistentTraceProvider) GetEvent(id string) (*rlmtrace.TraceEvent, err
 
 // ClearEvents implements rlmtrace.TraceProvider.
 func (p *PersistentTraceProvider) ClearEvents() error {
+	p.flushForRead()
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
@@ -332,6 +400,7 @@ func (p *PersistentTraceProvider) ClearEvents() error {
 
 // Stats implements rlmtrace.TraceProvider.
 func (p *PersistentTraceProvider) Stats() rlmtrace.TraceStats {
+	p.flushForRead()
 	p.mu.RLock()
 	defer p.mu.RUnlock()
 
@@ -378,6 +447,7 @@ func (p *PersistentTraceProvider) Stats() rlmtrace.TraceStats {
 
 // GetEventsBySession returns events for a specific session.
 func (p *PersistentTraceProvider) GetEventsBySession(sessionID string, limit int) ([]rlmtrace.TraceEvent, error) {
+	p.flushForRead()
 	p.mu.RLock()
 	defer p.mu.RUnlock()
 
@@ -404,6 +474,7 @@ func (p *PersistentTraceProvider) GetEventsBySession(sessionID string, limit int
 
 // GetEventsByParent returns child events of a parent.
 func (p *PersistentTraceProvider) GetEventsByParent(parentID string) ([]rlmtrace.TraceEvent, error) {
+	p.flushForRead()
 	p.mu.RLock()
 	defer p.mu.RUnlock()
 
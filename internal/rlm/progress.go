@@ -37,6 +37,9 @@ const (
 
 	// ProgressComplete signals execution is complete.
 	ProgressComplete ProgressEventType = "complete"
+
+	// ProgressSubtaskComplete signals a streamed decomposition subtask finished.
+	ProgressSubtaskComplete ProgressEventType = "subtask_complete"
 )
 
 // ProgressEvent contains information about RLM execution progress.
@@ -88,6 +91,9 @@ type ProgressData struct {
 
 	// TerminationReason explains why execution terminated.
 	TerminationReason string
+
+	// SubtaskID identifies the subtask (for SubtaskComplete events).
+	SubtaskID string
 }
 
 // ProgressCallback is called for each progress event during RLM execution.
@@ -220,6 +226,21 @@ func (e *ProgressEmitter) EmitComplete(iterations int, duration time.Duration, o
 	})
 }
 
+// EmitSubtaskComplete emits a subtask completion event.
+func (e *ProgressEmitter) EmitSubtaskComplete(id string, duration time.Duration, tokens int, output string, err string) {
+	msg := "Subtask complete"
+	if err != "" {
+		msg = "Subtask failed"
+	}
+	e.Emit(ProgressSubtaskComplete, 0, msg, ProgressData{
+		SubtaskID:  id,
+		Duration:   duration,
+		TokensUsed: tokens,
+		Output:     truncateOutput(output, 200),
+		Error:      err,
+	})
+}
+
 // truncateOutput truncates output for progress display.
 func truncateOutput(s string, maxLen int) string {
 	if len(s) <= maxLen {
@@ -275,6 +296,12 @@ func FormatProgressEvent(event ProgressEvent) string {
 	case ProgressComplete:
 		return prefix + "Complete in " + formatDuration(event.Data.Duration)
 
+	case ProgressSubtaskComplete:
+		if event.Data.Error != "" {
+			return prefix + "Subtask " + event.Data.SubtaskID + " failed: " + firstLine(event.Data.Error)
+		}
+		return prefix + "Subtask " + event.Data.SubtaskID + " done in " + formatDuration(event.Data.Duration)
+
 	default:
 		return prefix + event.Message
 	}
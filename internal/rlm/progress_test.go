@@ -120,6 +120,24 @@ func TestProgressEmitter_DataFields(t *testing.T) {
 	assert.Equal(t, "early termination", capturedEvent.Data.TerminationReason)
 }
 
+func TestProgressEmitter_EmitSubtaskComplete(t *testing.T) {
+	var capturedEvent ProgressEvent
+	emitter := NewProgressEmitter(func(e ProgressEvent) {
+		capturedEvent = e
+	}, 0)
+
+	emitter.EmitSubtaskComplete("op-1", 250*time.Millisecond, 42, "partial answer", "")
+	assert.Equal(t, ProgressSubtaskComplete, capturedEvent.Type)
+	assert.Equal(t, "op-1", capturedEvent.Data.SubtaskID)
+	assert.Equal(t, 42, capturedEvent.Data.TokensUsed)
+	assert.Equal(t, "partial answer", capturedEvent.Data.Output)
+	assert.Equal(t, "Subtask complete", capturedEvent.Message)
+
+	emitter.EmitSubtaskComplete("op-2", 0, 0, "", "boom")
+	assert.Equal(t, "boom", capturedEvent.Data.Error)
+	assert.Equal(t, "Subtask failed", capturedEvent.Message)
+}
+
 func TestProgressEmitter_CodeTruncation(t *testing.T) {
 	var capturedEvent ProgressEvent
 	callback := func(e ProgressEvent) {
@@ -198,6 +216,22 @@ func TestFormatProgressEvent(t *testing.T) {
 			},
 			contains: "Complete",
 		},
+		{
+			name: "subtask complete",
+			event: ProgressEvent{
+				Type: ProgressSubtaskComplete,
+				Data: ProgressData{SubtaskID: "chunk-2", Duration: time.Second},
+			},
+			contains: "Subtask chunk-2 done",
+		},
+		{
+			name: "subtask failed",
+			event: ProgressEvent{
+				Type: ProgressSubtaskComplete,
+				Data: ProgressData{SubtaskID: "chunk-3", Error: "timeout"},
+			},
+			contains: "Subtask chunk-3 failed: timeout",
+		},
 	}
 
 	for _, tt := range tests {
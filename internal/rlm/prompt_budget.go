@@ -0,0 +1,48 @@
+package rlm
+
+// systemPromptCompaction is how much of the RLM system prompt is trimmed to
+// fit the system-prompt budget. Each level drops everything the previous
+// one did, starting with the examples, which only restate the function
+// docs, and ending with the core instructions reduced to a terse form.
+type systemPromptCompaction int
+
+const (
+	// compactNone keeps the whole prompt.
+	compactNone systemPromptCompaction = iota
+
+	// compactOneExample keeps only the most relevant example.
+	compactOneExample
+
+	// compactNoExamples drops the examples.
+	compactNoExamples
+
+	// compactNoSuggestion also drops the computation suggestion.
+	compactNoSuggestion
+
+	// compactNoGuidance also drops the task-type guidance.
+	compactNoGuidance
+
+	// compactTerse also shortens the efficiency rules and function docs
+	// to the function signatures and the FINAL() contract.
+	compactTerse
+)
+
+// terseRLMPreamble replaces the efficiency rules at compactTerse.
+const terseRLMPreamble = `You are in RLM mode: context is in Python variables; process it with code.
+Compute mechanically in Python, use llm_call() only for reasoning, and call FINAL() as soon as you have the answer.
+
+`
+
+// terseRLMFunctions replaces the function docs at compactTerse.
+const terseRLMFunctions = `
+## Functions
+- grep(ctx, pattern, context_lines=0)
+- grep(ctx, text, fuzzy=True, max_distance=2) - near-matches if exact grep finds nothing
+- peek(ctx, start, end, by_lines=False)
+- partition(ctx, n=4, overlap=0)
+- count_tokens_approx(text)
+- llm_call(prompt, context, model)
+- map_reduce(ctx, map_prompt, reduce_prompt, n_chunks=4)
+- FINAL(response) - return your answer (string)
+- FINAL_JSON(obj) - return structured data
+`
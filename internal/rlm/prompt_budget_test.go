@@ -0,0 +1,105 @@
+package rlm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func budgetTestInputs() (*LoadedContext, *Classification, *REPLSuggestion) {
+	loaded := &LoadedContext{
+		Variables: map[string]VariableInfo{
+			"orders": {Description: "Order log", TokenEstimate: 12000},
+		},
+	}
+	classification := &Classification{Type: TaskTypeComputational, Confidence: 0.9}
+	suggestion := &REPLSuggestion{
+		Pattern:    "sum_values",
+		Approach:   "Extract the amounts with a regex and sum them",
+		CodeHint:   "sum(float(x) for x in re.findall(r'\\d+', orders))",
+		Confidence: 0.9,
+	}
+	return loaded, classification, suggestion
+}
+
+// assertCoreContract checks the parts of the system prompt no budget trims.
+func assertCoreContract(t *testing.T, prompt string) {
+	t.Helper()
+	for _, want := range []string{
+		"RLM",
+		"orders: Order log",
+		"grep(ctx, pattern, context_lines=0)",
+		"fuzzy=True",
+		"peek(ctx, start, end, by_lines=False)",
+		"partition(ctx, n=4, overlap=0)",
+		"llm_call(prompt, context, model)",
+		"map_reduce(ctx, map_prompt, reduce_prompt, n_chunks=4)",
+		"FINAL(response)",
+		"FINAL_JSON(obj)",
+	} {
+		assert.Contains(t, prompt, want)
+	}
+}
+
+func TestSystemPromptBudget_ZeroKeepsWholePrompt(t *testing.T) {
+	loaded, classification, suggestion := budgetTestInputs()
+	w := NewWrapper(&Service{}, DefaultWrapperConfig())
+
+	prompt := w.generateRLMSystemPrompt(loaded, classification, suggestion)
+	assert.Equal(t, w.buildRLMSystemPrompt(loaded, classification, suggestion, compactNone), prompt)
+	assert.Contains(t, prompt, "### Counting")
+	assert.Contains(t, prompt, "### Summing")
+	assert.Contains(t, prompt, "Computation Suggestion")
+	assert.Contains(t, prompt, "Task Type: COMPUTATIONAL")
+}
+
+func TestSystemPromptBudget_DropsExamplesFirst(t *testing.T) {
+	loaded, classification, suggestion := budgetTestInputs()
+	w := NewWrapper(&Service{}, DefaultWrapperConfig())
+	full := w.generateRLMSystemPrompt(loaded, classification, suggestion)
+
+	// Just under the full size, only the redundant second example goes.
+	w.SetSystemPromptBudget(estimateTokens(full) - 1)
+	prompt := w.generateRLMSystemPrompt(loaded, classification, suggestion)
+	assert.Contains(t, prompt, "### Counting")
+	assert.NotContains(t, prompt, "### Summing")
+	assert.Contains(t, prompt, "Computation Suggestion")
+	assert.Contains(t, prompt, "Task Type: COMPUTATIONAL")
+	assert.Contains(t, prompt, "Efficiency First")
+	assertCoreContract(t, prompt)
+}
+
+func TestSystemPromptBudget_TightBudgetKeepsCoreContract(t *testing.T) {
+	loaded, classification, suggestion := budgetTestInputs()
+	cfg := DefaultWrapperConfig()
+	w := NewWrapper(&Service{}, cfg)
+	full := w.generateRLMSystemPrompt(loaded, classification, suggestion)
+
+	cfg.SystemPromptBudget = 200
+	w = NewWrapper(&Service{}, cfg)
+	prompt := w.generateRLMSystemPrompt(loaded, classification, suggestion)
+
+	assert.LessOrEqual(t, estimateTokens(prompt), 200)
+	assert.Less(t, len(prompt), len(full)/2)
+	assert.NotContains(t, prompt, "```python")
+	assert.NotContains(t, prompt, "Computation Suggestion")
+	assert.NotContains(t, prompt, "Task Type:")
+	assertCoreContract(t, prompt)
+}
+
+func TestSystemPromptBudget_TerseKeepsRequestContext(t *testing.T) {
+	loaded, classification, suggestion := budgetTestInputs()
+	cfg := DefaultWrapperConfig()
+	cfg.SystemPromptBudget = 1
+	cfg.ContextProvider = ContextProviderFunc(func(context.Context, string) ([]ContextSource, error) {
+		return nil, nil
+	})
+	w := NewWrapper(&Service{}, cfg)
+
+	// An unreachable budget still yields the most compact prompt.
+	prompt := w.generateRLMSystemPrompt(loaded, classification, suggestion)
+	assert.Equal(t, w.buildRLMSystemPrompt(loaded, classification, suggestion, compactTerse), prompt)
+	assert.Contains(t, prompt, "request_context(description)")
+	assertCoreContract(t, prompt)
+}
@@ -0,0 +1,61 @@
+package rlm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ContextRef identifies a region of an externalized context that was read
+// through peek() or grep() during execution.
+type ContextRef struct {
+	// Variable is the REPL variable the context was loaded under.
+	Variable string `json:"variable"`
+
+	// Kind is the builtin that read the region: "grep" or "peek".
+	Kind string `json:"kind"`
+
+	// StartLine and EndLine are the 1-indexed, inclusive lines read.
+	StartLine int `json:"start_line"`
+	EndLine   int `json:"end_line"`
+
+	// Start and End are character offsets for character-based peeks.
+	Start int `json:"start,omitempty"`
+	End   int `json:"end,omitempty"`
+
+	// Snippet is the text read, truncated.
+	Snippet string `json:"snippet"`
+
+	// Matched is true when the snippet and the final answer overlap; false
+	// refs are regions read during execution that the answer does not quote.
+	Matched bool `json:"matched"`
+}
+
+// GetFinalProvenance retrieves the context regions the FINAL() output was
+// derived from. It returns nil if FINAL() has not been called or no loaded
+// context was read.
+func (w *Wrapper) GetFinalProvenance(ctx context.Context) ([]ContextRef, error) {
+	if w.replMgr == nil {
+		return nil, ErrREPLUnavailable
+	}
+
+	// Printed as JSON rather than read from the repr, since snippets are
+	// arbitrary text.
+	result, err := w.replMgr.Execute(ctx, "print(json.dumps(get_final_provenance()))")
+	if err != nil {
+		return nil, err
+	}
+	if result.Error != "" {
+		return nil, fmt.Errorf("get provenance: %s", result.Error)
+	}
+
+	var refs []ContextRef
+	if err := json.Unmarshal([]byte(strings.TrimSpace(result.Output)), &refs); err != nil {
+		return nil, fmt.Errorf("parse provenance: %w", err)
+	}
+	if len(refs) == 0 {
+		return nil, nil
+	}
+	return refs, nil
+}
@@ -0,0 +1,92 @@
+package rlm
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rand/recurse/internal/rlm/repl"
+)
+
+const provenanceLog = "boot ok\nconfig loaded\nERROR disk full on /var\nshutdown"
+
+func runProvenance(t *testing.T, responses ...string) *RLMExecutionResult {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	replMgr, err := repl.NewManager(repl.Options{})
+	require.NoError(t, err)
+	require.NoError(t, replMgr.Start(ctx))
+	t.Cleanup(func() { replMgr.Stop() })
+
+	require.NoError(t, replMgr.SetVar(ctx, "notes", "nothing to see here"))
+	require.NoError(t, replMgr.SetVar(ctx, "server_log", provenanceLog))
+
+	w := &Wrapper{replMgr: replMgr, client: &wrapperMockLLMClient{responses: responses}}
+	prepared := &PreparedPrompt{
+		Mode:         ModeRLM,
+		SystemPrompt: "You are an RLM assistant.",
+		FinalPrompt:  "What error did the server report?",
+	}
+
+	result, err := w.ExecuteRLMWithConfig(ctx, prepared, RLMConfig{
+		MaxIterations:    3,
+		MaxTokensPerCall: 1024,
+		Timeout:          10 * time.Second,
+	})
+	require.NoError(t, err)
+	require.Empty(t, result.Error)
+	return result
+}
+
+func TestExecuteRLM_Provenance_GrepToFinal(t *testing.T) {
+	result := runProvenance(t,
+		"```python\nlog = server_log\nhits = grep(notes, 'error') + grep(log, 'error')\n"+
+			"FINAL(hits[0]['line'])\n```")
+
+	assert.Equal(t, "ERROR disk full on /var", result.FinalOutput)
+	require.Len(t, result.Provenance, 1)
+	ref := result.Provenance[0]
+	assert.Equal(t, "server_log", ref.Variable)
+	assert.Equal(t, "grep", ref.Kind)
+	assert.Equal(t, 3, ref.StartLine)
+	assert.Equal(t, 3, ref.EndLine)
+	assert.Equal(t, "ERROR disk full on /var", ref.Snippet)
+	assert.True(t, ref.Matched)
+}
+
+func TestExecuteRLM_Provenance_PeekSubstring(t *testing.T) {
+	result := runProvenance(t,
+		"```python\nidx = server_log.find('disk')\nFINAL(peek(server_log, idx, idx + 9))\n```")
+
+	assert.Equal(t, "disk full", result.FinalOutput)
+	require.Len(t, result.Provenance, 1)
+	ref := result.Provenance[0]
+	assert.Equal(t, "server_log", ref.Variable)
+	assert.Equal(t, "peek", ref.Kind)
+	assert.Equal(t, 3, ref.StartLine)
+	assert.Equal(t, 3, ref.EndLine)
+	assert.Equal(t, len("boot ok\nconfig loaded\nERROR "), ref.Start)
+	assert.True(t, ref.Matched)
+}
+
+func TestExecuteRLM_Provenance_UnmatchedAnswer(t *testing.T) {
+	result := runProvenance(t,
+		"```python\nhits = grep(server_log, 'error')\nFINAL('The disk filled up.' if hits else 'none')\n```")
+
+	// The answer paraphrases what was read, so the access is reported
+	// without a match.
+	require.Len(t, result.Provenance, 1)
+	assert.Equal(t, "server_log", result.Provenance[0].Variable)
+	assert.False(t, result.Provenance[0].Matched)
+}
+
+func TestExecuteRLM_Provenance_ClearedBetweenExecutions(t *testing.T) {
+	result := runProvenance(t, "```python\nFINAL('no context used')\n```")
+	assert.Empty(t, result.Provenance)
+}
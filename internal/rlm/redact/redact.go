@@ -0,0 +1,184 @@
+// Package redact replaces secrets in text with stable placeholders before
+// the text leaves the process: sent to a model, traced or persisted.
+package redact
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Pattern is a named regular expression matching one kind of secret.
+type Pattern struct {
+	// Name labels the placeholders of matches, e.g. "aws_access_key".
+	// Letters, digits and underscores only.
+	Name string
+
+	// Expr matches the secret. When it has a group named "secret", only
+	// that group is replaced, so surrounding text such as a "Bearer "
+	// prefix stays readable.
+	Expr string
+}
+
+// Config configures redaction.
+type Config struct {
+	// Enabled turns redaction on.
+	Enabled bool
+
+	// Patterns are matched in addition to DefaultPatterns.
+	Patterns []Pattern
+
+	// NoDefaults leaves DefaultPatterns out, matching only Patterns.
+	NoDefaults bool
+}
+
+// DefaultConfig returns a config redacting DefaultPatterns.
+func DefaultConfig() Config {
+	return Config{Enabled: true}
+}
+
+// DefaultPatterns returns the built-in patterns: AWS access and secret
+// keys, GitHub tokens, bearer tokens and PEM private keys.
+func DefaultPatterns() []Pattern {
+	return []Pattern{
+		{Name: "private_key", Expr: `-----BEGIN[A-Z ]*PRIVATE KEY-----[\s\S]*?-----END[A-Z ]*PRIVATE KEY-----`},
+		{Name: "aws_access_key", Expr: `\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`},
+		{Name: "aws_secret_key", Expr: `(?i)aws_?secret_?(?:access_?)?key["']?\s*[:=]\s*["']?(?P<secret>[A-Za-z0-9/+=]{40})`},
+		{Name: "github_token", Expr: `\bgh[pousr]_[A-Za-z0-9]{36,}\b`},
+		{Name: "bearer_token", Expr: `(?i)\bbearer\s+(?P<secret>[A-Za-z0-9\-._~+/]{16,}=*)`},
+	}
+}
+
+var (
+	validName   = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+	placeholder = regexp.MustCompile(`\[REDACTED:[A-Za-z0-9_]+:[0-9a-f]{8}\]`)
+)
+
+// rule is a compiled Pattern.
+type rule struct {
+	name  string
+	re    *regexp.Regexp
+	group int // submatch replaced; 0 for the whole match
+}
+
+// Redactor replaces secrets with placeholders of the form
+// [REDACTED:<pattern>:<hash>]. The hash is keyed per Redactor, so one
+// secret always gets the same placeholder from the same Redactor, while
+// placeholders cannot be matched against guessed secrets elsewhere.
+//
+// A Redactor remembers the secrets it replaced, in memory only, so Reveal
+// can restore them for live use. Nothing it returns from Redact carries
+// them. A nil *Redactor leaves text unchanged. It is safe for concurrent
+// use.
+type Redactor struct {
+	rules []rule
+	key   []byte
+
+	mu      sync.RWMutex
+	secrets map[string]string // placeholder -> secret
+}
+
+// New compiles cfg into a Redactor. It returns nil when redaction is
+// disabled.
+func New(cfg Config) (*Redactor, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+
+	patterns := cfg.Patterns
+	if !cfg.NoDefaults {
+		patterns = append(DefaultPatterns(), cfg.Patterns...)
+	}
+
+	r := &Redactor{
+		key:     make([]byte, 32),
+		secrets: make(map[string]string),
+	}
+	for _, p := range patterns {
+		if !validName.MatchString(p.Name) {
+			return nil, fmt.Errorf("redact pattern %q: name must be letters, digits and underscores", p.Name)
+		}
+		re, err := regexp.Compile(p.Expr)
+		if err != nil {
+			return nil, fmt.Errorf("redact pattern %s: %w", p.Name, err)
+		}
+		group := re.SubexpIndex("secret")
+		if group < 0 {
+			group = 0
+		}
+		r.rules = append(r.rules, rule{name: p.Name, re: re, group: group})
+	}
+	if _, err := rand.Read(r.key); err != nil {
+		return nil, fmt.Errorf("generate redaction key: %w", err)
+	}
+	return r, nil
+}
+
+// Redact returns s with every secret replaced by its placeholder.
+func (r *Redactor) Redact(s string) string {
+	if r == nil || s == "" {
+		return s
+	}
+	for _, rl := range r.rules {
+		s = r.apply(rl, s)
+	}
+	return s
+}
+
+// apply replaces the matches of one rule in s.
+func (r *Redactor) apply(rl rule, s string) string {
+	matches := rl.re.FindAllStringSubmatchIndex(s, -1)
+	if matches == nil {
+		return s
+	}
+
+	var sb strings.Builder
+	last := 0
+	for _, m := range matches {
+		start, end := m[2*rl.group], m[2*rl.group+1]
+		if start < 0 || start == end {
+			continue
+		}
+		sb.WriteString(s[last:start])
+		sb.WriteString(r.placeholder(rl.name, s[start:end]))
+		last = end
+	}
+	sb.WriteString(s[last:])
+	return sb.String()
+}
+
+// placeholder returns the placeholder of secret, remembering the secret.
+func (r *Redactor) placeholder(name, secret string) string {
+	mac := hmac.New(sha256.New, r.key)
+	mac.Write([]byte(secret))
+	p := fmt.Sprintf("[REDACTED:%s:%s]", name, hex.EncodeToString(mac.Sum(nil))[:8])
+
+	r.mu.Lock()
+	if _, ok := r.secrets[p]; !ok {
+		r.secrets[p] = secret
+	}
+	r.mu.Unlock()
+	return p
+}
+
+// Reveal returns s with the placeholders this Redactor produced replaced
+// by their secrets. Use it only for text that stays in the live process;
+// never persist what it returns.
+func (r *Redactor) Reveal(s string) string {
+	if r == nil {
+		return s
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return placeholder.ReplaceAllStringFunc(s, func(p string) string {
+		if secret, ok := r.secrets[p]; ok {
+			return secret
+		}
+		return p
+	})
+}
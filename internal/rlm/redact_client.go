@@ -0,0 +1,82 @@
+package rlm
+
+import (
+	"context"
+	"errors"
+
+	"github.com/rand/recurse/internal/rlm/hallucination"
+	"github.com/rand/recurse/internal/rlm/meta"
+	"github.com/rand/recurse/internal/rlm/redact"
+)
+
+// redactingClient redacts secrets from prompts before they reach the model
+// and reveals the placeholders in its responses. The model never sees a
+// secret, while code it writes against a placeholder still runs on the real
+// value in the live REPL. Besides Complete it forwards image and logprob
+// completions and route explanations, keeping the wrapped client's
+// capabilities.
+type redactingClient struct {
+	client   meta.LLMClient
+	redactor *redact.Redactor
+}
+
+// routeExplainingClient matches clients that explain how they route a
+// prompt.
+type routeExplainingClient interface {
+	ExplainRoute(ctx context.Context, prompt string) *meta.RouteDecision
+}
+
+// redactPrompts wraps client so its prompts are redacted by redactor. It
+// returns client itself when either is nil.
+func redactPrompts(client meta.LLMClient, redactor *redact.Redactor) meta.LLMClient {
+	if client == nil || redactor == nil {
+		return client
+	}
+	return &redactingClient{client: client, redactor: redactor}
+}
+
+func (c *redactingClient) Complete(ctx context.Context, prompt string, maxTokens int) (string, error) {
+	response, err := c.client.Complete(ctx, c.redactor.Redact(prompt), maxTokens)
+	return c.redactor.Reveal(response), err
+}
+
+// Capabilities implements meta.CapabilityReporter with the wrapped
+// client's capabilities, all of which it forwards.
+func (c *redactingClient) Capabilities() meta.Capabilities {
+	return meta.CapabilitiesOf(c.client)
+}
+
+// SupportsImages implements meta.MultimodalClient.
+func (c *redactingClient) SupportsImages() bool {
+	mm, ok := c.client.(meta.MultimodalClient)
+	return ok && mm.SupportsImages()
+}
+
+// CompleteWithImages implements meta.MultimodalClient.
+func (c *redactingClient) CompleteWithImages(ctx context.Context, prompt string, images []meta.Image, maxTokens int) (string, error) {
+	mm, ok := c.client.(meta.MultimodalClient)
+	if !ok {
+		return "", errors.New("redacting client: wrapped client does not accept images")
+	}
+	response, err := mm.CompleteWithImages(ctx, c.redactor.Redact(prompt), images, maxTokens)
+	return c.redactor.Reveal(response), err
+}
+
+// CompleteWithLogprobs implements hallucination.LogprobsCompleter.
+func (c *redactingClient) CompleteWithLogprobs(ctx context.Context, prompt string, maxTokens int) (string, map[string]float64, error) {
+	lc, ok := c.client.(hallucination.LogprobsCompleter)
+	if !ok {
+		return "", nil, errors.New("redacting client: wrapped client does not report logprobs")
+	}
+	response, logprobs, err := lc.CompleteWithLogprobs(ctx, c.redactor.Redact(prompt), maxTokens)
+	return c.redactor.Reveal(response), logprobs, err
+}
+
+// ExplainRoute explains how the wrapped client routes prompt, nil if it
+// does not say.
+func (c *redactingClient) ExplainRoute(ctx context.Context, prompt string) *meta.RouteDecision {
+	if explainer, ok := c.client.(routeExplainingClient); ok {
+		return explainer.ExplainRoute(ctx, c.redactor.Redact(prompt))
+	}
+	return nil
+}
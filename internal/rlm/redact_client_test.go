@@ -0,0 +1,30 @@
+package rlm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rand/recurse/internal/rlm/hallucination"
+	"github.com/rand/recurse/internal/rlm/meta"
+	"github.com/rand/recurse/internal/rlm/redact"
+)
+
+func TestRedactPrompts(t *testing.T) {
+	client := &mockLLMClient{}
+	assert.Same(t, client, redactPrompts(client, nil), "nothing to redact with")
+
+	redactor, err := redact.New(redact.DefaultConfig())
+	require.NoError(t, err)
+	inner := &logprobsMockClient{caps: meta.Capabilities{ContextWindow: 8000, Logprobs: true}}
+	wrapped := redactPrompts(inner, redactor)
+
+	assert.Equal(t, inner.caps, meta.CapabilitiesOf(wrapped), "capabilities are kept")
+	lc, ok := wrapped.(hallucination.LogprobsCompleter)
+	require.True(t, ok)
+	response, _, err := lc.CompleteWithLogprobs(context.Background(), "Bearer "+testBearerSecret, 10)
+	require.NoError(t, err)
+	assert.Equal(t, "YES", response)
+}
@@ -9,6 +9,7 @@ for RLM orchestration.
 
 import ast
 import collections
+import functools
 import io
 import itertools
 import json
@@ -22,7 +23,7 @@ import subprocess
 import sys
 import time
 import traceback
-from contextlib import redirect_stderr, redirect_stdout
+from contextlib import contextmanager, redirect_stderr, redirect_stdout
 from typing import Any
 
 
@@ -334,6 +335,48 @@ class RLMContext:
         return self.content[key]
 
 
+# Contexts loaded through set_var, by variable name. Accesses through peek()
+# and grep() are recorded against these so FINAL() can report which regions
+# of which source the answer came from.
+_context_registry: dict[str, Any] = {}
+_context_accesses: list[dict] = []
+
+# Snippets stored per access are truncated to keep provenance small.
+_MAX_SNIPPET_CHARS = 500
+
+
+def _context_source(ctx) -> str | None:
+    """Return the variable name a context was loaded under, if known."""
+    if isinstance(ctx, RLMContext):
+        return ctx.name
+    for name, value in _context_registry.items():
+        if value is ctx:
+            return name
+    return None
+
+
+def _record_access(ctx, kind: str, start_line: int, end_line: int,
+                   snippet: str, start: int = None, end: int = None) -> None:
+    """Record that a region of a loaded context was read."""
+    variable = _context_source(ctx)
+    if variable is None:
+        return
+    access = {
+        "variable": variable,
+        "kind": kind,
+        "start_line": start_line,
+        "end_line": end_line,
+        "snippet": snippet[:_MAX_SNIPPET_CHARS],
+    }
+    if start is not None:
+        access["start"] = start
+        access["end"] = end
+    # Re-reading a region (or re-evaluating the last expression of a cell)
+    # adds nothing to provenance.
+    if access not in _context_accesses:
+        _context_accesses.append(access)
+
+
 def peek(ctx, start: int = 0, end: int = None, by_lines: bool = False) -> str:
     """
     View a slice of context.
@@ -355,57 +398,122 @@ def peek(ctx, start: int = 0, end: int = None, by_lines: bool = False) -> str:
 
     if by_lines:
         lines = content.split('\n')
-        if end is None:
-            selected = lines[start:]
-        else:
-            selected = lines[start:end]
-        return '\n'.join(selected)
-    else:
-        if end is None:
-            return content[start:]
-        return content[start:end]
+        first, last, _ = slice(start, end).indices(len(lines))
+        result = '\n'.join(lines[first:last])
+        if last > first:
+            _record_access(ctx, "peek", first + 1, last, result)
+        return result
 
+    first, last, _ = slice(start, end).indices(len(content))
+    result = content[first:last]
+    if last > first:
+        start_line = content.count('\n', 0, first) + 1
+        end_line = start_line + result.count('\n')
+        _record_access(ctx, "peek", start_line, end_line, result, first, last)
+    return result
 
-def grep(ctx, pattern: str, context_lines: int = 0, ignore_case: bool = True) -> list[dict]:
+
+def grep(ctx, pattern: str, context_lines: int = 0, ignore_case: bool = True,
+         fuzzy: bool = False, max_distance: int = 2) -> list[dict]:
     """
     Search for pattern in context, returning matching lines with context.
 
     Args:
         ctx: Context string or RLMContext object
-        pattern: Regex pattern to search for
+        pattern: Regex pattern to search for, or literal text when fuzzy
         context_lines: Number of lines before/after each match to include
         ignore_case: Whether to ignore case in pattern matching
+        fuzzy: Match lines containing text within max_distance edits of
+            pattern, to find typos and variants exact search misses
+        max_distance: Maximum Levenshtein distance for a fuzzy match
 
     Returns:
-        List of dicts with 'line_num', 'line', 'context_before', 'context_after'
+        List of dicts with 'line_num', 'line', 'context_before', 'context_after';
+        fuzzy matches also have 'match' (the text matched) and 'distance'
 
     Example:
         >>> matches = grep(context, r"def \\w+")
         >>> matches = grep(context, "error", context_lines=2)
+        >>> matches = grep(context, "authentication", fuzzy=True)
     """
     content = ctx.content if isinstance(ctx, RLMContext) else str(ctx)
     lines = content.split('\n')
 
-    flags = re.IGNORECASE if ignore_case else 0
-    compiled = re.compile(pattern, flags)
+    if fuzzy:
+        if max_distance < 0:
+            raise ValueError("max_distance must not be negative")
+        needle = pattern.lower() if ignore_case else pattern
+        match_line = lambda line: _fuzzy_search(line.lower() if ignore_case else line, needle, max_distance)
+    else:
+        flags = re.IGNORECASE if ignore_case else 0
+        compiled = re.compile(pattern, flags)
+        match_line = compiled.search
 
     results = []
     for i, line in enumerate(lines):
-        if compiled.search(line):
+        found = match_line(line)
+        if found:
             result = {
                 'line_num': i + 1,  # 1-indexed
                 'line': line,
             }
+            if fuzzy:
+                distance, start, end = found
+                result['match'] = line[start:end]
+                result['distance'] = distance
             if context_lines > 0:
                 start = max(0, i - context_lines)
                 end = min(len(lines), i + context_lines + 1)
                 result['context_before'] = lines[start:i]
                 result['context_after'] = lines[i+1:end]
             results.append(result)
+            _record_access(ctx, "grep", i + 1, i + 1, line)
 
     return results
 
 
+def _fuzzy_search(text: str, pattern: str, max_distance: int):
+    """
+    Find the closest approximate occurrence of pattern in text.
+
+    Returns (distance, start, end) for the best substring of text within
+    max_distance edits of pattern, or None if there is none.
+    """
+    m = len(pattern)
+    if m == 0:
+        return None
+
+    # A match with k edits leaves at least one of k+1 pattern pieces intact,
+    # so lines holding none of them can be skipped without the full search
+    pieces = min(max_distance + 1, m)
+    size = m // pieces
+    if size > 0 and not any(pattern[p*size:(p+1)*size] in text for p in range(pieces)):
+        return None
+
+    # Edit distance where a match may start and end anywhere in text; start
+    # tracks where the alignment ending in each cell began
+    dist = list(range(m + 1))
+    start = [0] * (m + 1)
+    best = None
+    for j, c in enumerate(text):
+        prev_dist, prev_start = dist[0], start[0]
+        dist[0], start[0] = 0, j + 1
+        for i in range(1, m + 1):
+            cur_dist, cur_start = dist[i], start[i]
+            d, s = prev_dist + (pattern[i-1] != c), prev_start
+            if cur_dist + 1 < d:
+                d, s = cur_dist + 1, cur_start
+            if dist[i-1] + 1 < d:
+                d, s = dist[i-1] + 1, start[i-1]
+            dist[i], start[i] = d, s
+            prev_dist, prev_start = cur_dist, cur_start
+        if dist[m] <= max_distance and (best is None or dist[m] < best[0]):
+            best = (dist[m], start[m], j + 1)
+            if best[0] == 0:
+                break
+    return best
+
+
 def partition(ctx, n: int = 4, overlap: int = 0) -> list[str]:
     """
     Split context into n roughly equal chunks.
@@ -724,7 +832,9 @@ def _make_callback(callback_type: str, params: dict) -> dict:
     return response
 
 
-def llm_call(prompt: str, context: str = "", model: str = "auto") -> str:
+def llm_call(prompt: str, context: str = "", model: str = "auto",
+             verify: bool = False, verify_retries: int = 0,
+             synthesis: bool = False) -> str:
     """
     Make a sub-LLM call from within the REPL.
 
@@ -735,6 +845,13 @@ def llm_call(prompt: str, context: str = "", model: str = "auto") -> str:
         prompt: The prompt to send to the LLM
         context: Optional context to include
         model: Model tier - 'fast', 'balanced', 'powerful', 'reasoning', or 'auto'
+        verify: Check code in the response against constraints extracted
+            from it; a violation is reported after the response
+        verify_retries: How many times to regenerate code that violates
+            its constraints (requires verify)
+        synthesis: Mark the result as an input to the final answer, so it
+            can always be checked against its context; a failed check is
+            reported after the response
 
     Returns:
         The LLM's response string
@@ -751,7 +868,8 @@ def llm_call(prompt: str, context: str = "", model: str = "auto") -> str:
         response = _make_callback("llm_call", {
             "prompt": prompt,
             "context": context,
-            "model": model
+            "model": model,
+            **_call_options(verify, verify_retries, synthesis),
         })
         return response.get("result", "")
     except Exception as e:
@@ -759,7 +877,19 @@ def llm_call(prompt: str, context: str = "", model: str = "auto") -> str:
         return f"[LLM_CALL_ERROR: {e}]"
 
 
-def llm_batch(prompts: list[str], contexts: list[str] = None, model: str = "auto") -> list[str]:
+def _call_options(verify: bool, verify_retries: int, synthesis: bool) -> dict:
+    """Build the optional llm_call/llm_batch callback params."""
+    options = {}
+    if verify:
+        options.update(verify=True, verify_retries=verify_retries)
+    if synthesis:
+        options["synthesis"] = True
+    return options
+
+
+def llm_batch(prompts: list[str], contexts: list[str] = None, model: str = "auto",
+              verify: bool = False, verify_retries: int = 0,
+              synthesis: bool = False) -> list[str]:
     """
     Make batch LLM calls (for map operations over partitioned context).
 
@@ -767,6 +897,10 @@ def llm_batch(prompts: list[str], contexts: list[str] = None, model: str = "auto
         prompts: List of prompts
         contexts: Optional list of contexts (same length as prompts)
         model: Model tier to use
+        verify: Check code in each response, as for llm_call
+        verify_retries: How many times to regenerate violating code
+        synthesis: Mark the results as inputs to the final answer, as for
+            llm_call
 
     Returns:
         List of LLM responses
@@ -791,12 +925,13 @@ def llm_batch(prompts: list[str], contexts: list[str] = None, model: str = "auto
         response = _make_callback("llm_batch", {
             "prompts": prompts,
             "contexts": contexts,
-            "model": model
+            "model": model,
+            **_call_options(verify, verify_retries, synthesis),
         })
         return response.get("results", [""] * len(prompts))
     except Exception as e:
         # Fallback to individual calls if batch fails
-        return [llm_call(p, c, model) for p, c in zip(prompts, contexts)]
+        return [llm_call(p, c, model, verify, verify_retries, synthesis) for p, c in zip(prompts, contexts)]
 
 
 def disable_callbacks():
@@ -1236,6 +1371,7 @@ class FinalOutput:
         self.content = content
         self.type = output_type  # "text", "json", "code", "markdown"
         self.metadata = metadata or {}
+        self.provenance = _answer_provenance(content)
 
     def __str__(self) -> str:
         return self.content
@@ -1254,6 +1390,26 @@ class FinalOutput:
 _final_output: FinalOutput | None = None
 
 
+def _answer_provenance(content: str) -> list[dict]:
+    """
+    Associate recorded context accesses with a final answer.
+
+    Accesses whose snippet contains the answer, or which the answer quotes,
+    are returned with matched=True. If none match, every access made during
+    the execution is returned unmatched, since the answer was still derived
+    from what was read.
+    """
+    answer = content.strip()
+    matched = []
+    for access in _context_accesses:
+        snippet = access["snippet"].strip()
+        if answer and snippet and (answer in snippet or snippet in answer):
+            matched.append(dict(access, matched=True))
+    if matched:
+        return matched
+    return [dict(access, matched=False) for access in _context_accesses]
+
+
 def FINAL(response: str, output_type: str = "text") -> str:
     """
     Mark a response as the final output.
@@ -1297,6 +1453,9 @@ def FINAL_VAR(variable_name: str) -> str:
         ...     answer += llm_call("Summarize", chunk) + "\\n"
         >>> FINAL_VAR("answer")
     """
+    # Counted here rather than wrapped, as it reads its caller's frame
+    _count_builtin("FINAL_VAR")
+
     # Access the variable from the caller's frame
     import inspect
     frame = inspect.currentframe()
@@ -1369,15 +1528,132 @@ def get_final_metadata() -> dict | None:
     return _final_output.to_dict()
 
 
+def get_final_metadata_json() -> str:
+    """Get full final output including metadata, serialized as JSON."""
+    if _final_output is None:
+        return "null"
+    return json.dumps(_final_output.to_dict(), default=str)
+
+
+def get_final_provenance() -> list[dict]:
+    """Get the context regions the final output was derived from."""
+    if _final_output is None:
+        return []
+    return _final_output.provenance
+
+
 def has_final_output() -> bool:
     """Check if FINAL() has been called."""
     return _final_output is not None
 
 
 def clear_final_output():
-    """Clear the final output (for new execution)."""
+    """Clear the final output and recorded context accesses (for new execution)."""
     global _final_output
     _final_output = None
+    _context_accesses.clear()
+
+
+# =============================================================================
+# Context Requests
+# =============================================================================
+
+_context_requests: list[str] = []
+
+
+def request_context(description: str) -> str:
+    """
+    Ask for context that was not loaded into the REPL.
+
+    Call this when the loaded variables lack what the task needs, rather
+    than guessing. The request is handled after the current code finishes:
+    any context found is loaded as new variables, which are named in the
+    next message.
+
+    Args:
+        description: What is missing, e.g. "the config loader in config.go"
+
+    Returns:
+        A note that the request was recorded
+
+    Example:
+        >>> if "def load_config" not in file_0:
+        ...     request_context("the definition of load_config")
+    """
+    description = str(description).strip()
+    if not description:
+        raise ValueError("request_context() needs a description of the missing context")
+    _context_requests.append(description)
+    return f"Context requested: {description}. It will be loaded before your next turn."
+
+
+def get_context_requests_json() -> str:
+    """Return the pending context requests as a JSON list and clear them."""
+    pending = list(_context_requests)
+    _context_requests.clear()
+    return json.dumps(pending)
+
+
+def clear_context_requests():
+    """Discard pending context requests (for new execution)."""
+    _context_requests.clear()
+
+
+# =============================================================================
+# Builtin Usage
+# =============================================================================
+
+# Builtins whose calls from RLM code are counted, to show which ones models
+# lean on. Calls the builtins make to each other (map_reduce calling
+# llm_call) are not counted, since only the namespace copies are wrapped.
+_TRACKED_BUILTINS = (
+    "peek", "grep", "partition", "partition_by_lines", "summarize",
+    "map_reduce", "find_relevant", "llm_call", "llm_batch",
+    "FINAL", "FINAL_JSON", "FINAL_CODE",
+)
+
+_builtin_usage: dict[str, int] = {}
+
+# False while a statement block's last expression is re-evaluated for its
+# value, so its calls are counted once
+_counting_builtins = True
+
+
+def _count_builtin(name: str) -> None:
+    if _counting_builtins:
+        _builtin_usage[name] = _builtin_usage.get(name, 0) + 1
+
+
+@contextmanager
+def _uncounted():
+    """Pause builtin counting for the duration of the block."""
+    global _counting_builtins
+    _counting_builtins = False
+    try:
+        yield
+    finally:
+        _counting_builtins = True
+
+
+def _tracked(name: str, fn):
+    """Wrap a builtin so each call from RLM code is counted."""
+    @functools.wraps(fn)
+    def wrapper(*args, **kwargs):
+        _count_builtin(name)
+        return fn(*args, **kwargs)
+    return wrapper
+
+
+def get_builtin_usage_json() -> str:
+    """Return the builtin call counts as a JSON object and reset them."""
+    counts = dict(_builtin_usage)
+    _builtin_usage.clear()
+    return json.dumps(counts)
+
+
+def clear_builtin_usage():
+    """Reset the builtin call counts (for new execution)."""
+    _builtin_usage.clear()
 
 
 class REPLNamespace:
@@ -1414,8 +1690,15 @@ class REPLNamespace:
             "FinalOutput": FinalOutput,
             "get_final_output": get_final_output,
             "get_final_metadata": get_final_metadata,
+            "get_final_metadata_json": get_final_metadata_json,
+            "get_final_provenance": get_final_provenance,
             "has_final_output": has_final_output,
             "clear_final_output": clear_final_output,
+            "request_context": request_context,
+            "get_context_requests_json": get_context_requests_json,
+            "clear_context_requests": clear_context_requests,
+            "get_builtin_usage_json": get_builtin_usage_json,
+            "clear_builtin_usage": clear_builtin_usage,
             "disable_callbacks": disable_callbacks,
             "enable_callbacks": enable_callbacks,
             # Memory functions
@@ -1441,6 +1724,8 @@ class REPLNamespace:
             "disable_hallucination_detection": disable_hallucination_detection,
             "enable_hallucination_detection": enable_hallucination_detection,
         }
+        for name in _TRACKED_BUILTINS:
+            self._globals[name] = _tracked(name, self._globals[name])
         if PYDANTIC_AVAILABLE:
             self._globals["pydantic"] = pydantic
         # Data science libraries
@@ -1461,6 +1746,7 @@ class REPLNamespace:
         """Store a string value as a variable."""
         self._vars[name] = value
         self._globals[name] = value
+        _context_registry[name] = value
 
     def get_var(self, name: str) -> Any:
         """Get a variable's value."""
@@ -1510,7 +1796,10 @@ class REPLNamespace:
             "extract_functions", "count_tokens_approx", "summarize", "map_reduce",
             "find_relevant", "llm_call", "llm_batch", "FINAL", "FINAL_VAR",
             "FINAL_JSON", "FINAL_CODE", "FinalOutput", "get_final_output",
-            "get_final_metadata", "has_final_output", "clear_final_output",
+            "get_final_metadata", "get_final_metadata_json", "get_final_provenance", "has_final_output",
+            "clear_final_output",
+            "request_context", "get_context_requests_json", "clear_context_requests",
+            "get_builtin_usage_json", "clear_builtin_usage",
             "disable_callbacks", "enable_callbacks",
             # Memory functions
             "MemoryNode", "memory_query", "memory_add_fact", "memory_add_experience",
@@ -1529,6 +1818,12 @@ class REPLNamespace:
                 self._vars[name] = value
                 self._globals[name] = value
 
+        # Drop variables the code deleted, so their memory is freed
+        for name in [n for n in self._vars if n not in new_globals]:
+            del self._vars[name]
+            self._globals.pop(name, None)
+            _context_registry.pop(name, None)
+
 
 class REPL:
     """JSON-RPC style Python REPL."""
@@ -1613,13 +1908,15 @@ class REPL:
                     exec(compile(tree, '<repl>', 'exec'), globals_dict)
                     self.namespace.update_from_exec(globals_dict)
 
-                    # Try to get value of last expression if it exists
+                    # Try to get value of last expression if it exists. Its
+                    # builtin calls were counted when the block ran.
                     if tree.body:
                         last = tree.body[-1]
                         if isinstance(last, ast.Expr):
                             try:
                                 last_expr = ast.Expression(body=last.value)
-                                result = eval(compile(last_expr, '<repl>', 'eval'), globals_dict)
+                                with _uncounted():
+                                    result = eval(compile(last_expr, '<repl>', 'eval'), globals_dict)
                                 if result is not None:
                                     return_value = repr(result)
                             except Exception:
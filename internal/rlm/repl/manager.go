@@ -48,6 +48,10 @@ type Manager struct {
 	// resourceMonitor tracks resource usage for the REPL process.
 	resourceMonitor *ResourceMonitor
 
+	// pid is the REPL process's, readable without mu, which an execution
+	// holds throughout.
+	pid atomic.Int64
+
 	// resourceCallback is called when resource events occur.
 	resourceCallback ResourceCallback
 
@@ -204,6 +208,7 @@ func (m *Manager) Start(ctx context.Context) error {
 
 	// Initialize resource monitor
 	m.resourceMonitor = NewResourceMonitor(cmd.Process.Pid, m.sandbox.Resources)
+	m.pid.Store(int64(cmd.Process.Pid))
 
 	// Wait for ready signal
 	if err := m.waitReady(ctx); err != nil {
@@ -377,6 +382,20 @@ func (m *Manager) ResourceStats() *ResourceStats {
 	return &stats
 }
 
+// RSS returns the REPL process's current resident set size in bytes, zero
+// if the REPL is not running or its memory cannot be read. Unlike the
+// other accessors it does not wait for a running execution.
+func (m *Manager) RSS() uint64 {
+	if !m.running.Load() {
+		return 0
+	}
+	rss, err := processRSS(int(m.pid.Load()))
+	if err != nil {
+		return 0
+	}
+	return rss
+}
+
 // Execute runs Python code and returns the result.
 // If the code calls llm_call() or llm_batch(), these are handled via callbacks
 // to the registered CallbackHandler.
@@ -486,17 +505,32 @@ func (m *Manager) handleCallback(ctx context.Context, data []byte) error {
 	var resp CallbackResponse
 	resp.CallbackID = req.CallbackID
 
+	handler := m.callbackHandler
+	if h, ok := handler.(ContextCallbackHandler); ok {
+		handler = h.ForContext(ctx)
+	}
+	memoryHandler := m.memoryHandler
+	if h, ok := memoryHandler.(ContextMemoryHandler); ok {
+		memoryHandler = h.ForContext(ctx)
+	}
+
 	switch req.Callback {
 	// LLM callbacks
 	case "llm_call":
-		if m.callbackHandler == nil {
+		if handler == nil {
 			resp.Error = "LLM callback handler not configured"
 		} else {
 			prompt, _ := req.Params["prompt"].(string)
 			context, _ := req.Params["context"].(string)
 			model, _ := req.Params["model"].(string)
 
-			result, err := m.callbackHandler.HandleLLMCall(prompt, context, model)
+			var result string
+			var err error
+			if h, ok := handler.(OptionsCallbackHandler); ok {
+				result, err = h.HandleLLMCallWithOptions(prompt, context, model, callOptions(req.Params))
+			} else {
+				result, err = handler.HandleLLMCall(prompt, context, model)
+			}
 			if err != nil {
 				resp.Error = err.Error()
 			} else {
@@ -505,7 +539,7 @@ func (m *Manager) handleCallback(ctx context.Context, data []byte) error {
 		}
 
 	case "llm_batch":
-		if m.callbackHandler == nil {
+		if handler == nil {
 			resp.Error = "LLM callback handler not configured"
 		} else {
 			promptsRaw, _ := req.Params["prompts"].([]interface{})
@@ -521,7 +555,13 @@ func (m *Manager) handleCallback(ctx context.Context, data []byte) error {
 				contexts[i], _ = c.(string)
 			}
 
-			results, err := m.callbackHandler.HandleLLMBatch(prompts, contexts, model)
+			var results []string
+			var err error
+			if h, ok := handler.(OptionsCallbackHandler); ok {
+				results, err = h.HandleLLMBatchWithOptions(prompts, contexts, model, callOptions(req.Params))
+			} else {
+				results, err = handler.HandleLLMBatch(prompts, contexts, model)
+			}
 			if err != nil {
 				resp.Error = err.Error()
 			} else {
@@ -531,13 +571,13 @@ func (m *Manager) handleCallback(ctx context.Context, data []byte) error {
 
 	// Memory callbacks
 	case "memory_query":
-		if m.memoryHandler == nil {
+		if memoryHandler == nil {
 			resp.Error = "Memory callback handler not configured"
 		} else {
 			query, _ := req.Params["query"].(string)
 			limit, _ := req.Params["limit"].(float64) // JSON numbers are float64
 
-			nodes, err := m.memoryHandler.MemoryQuery(query, int(limit))
+			nodes, err := memoryHandler.MemoryQuery(query, int(limit))
 			if err != nil {
 				resp.Error = err.Error()
 			} else {
@@ -548,13 +588,13 @@ func (m *Manager) handleCallback(ctx context.Context, data []byte) error {
 		}
 
 	case "memory_add_fact":
-		if m.memoryHandler == nil {
+		if memoryHandler == nil {
 			resp.Error = "Memory callback handler not configured"
 		} else {
 			content, _ := req.Params["content"].(string)
 			confidence, _ := req.Params["confidence"].(float64)
 
-			nodeID, err := m.memoryHandler.MemoryAddFact(content, confidence)
+			nodeID, err := memoryHandler.MemoryAddFact(content, confidence)
 			if err != nil {
 				resp.Error = err.Error()
 			} else {
@@ -563,7 +603,7 @@ func (m *Manager) handleCallback(ctx context.Context, data []byte) error {
 		}
 
 	case "memory_add_experience":
-		if m.memoryHandler == nil {
+		if memoryHandler == nil {
 			resp.Error = "Memory callback handler not configured"
 		} else {
 			// Parse params including extended fields [SPEC-09.02]
@@ -585,9 +625,9 @@ func (m *Manager) handleCallback(ctx context.Context, data []byte) error {
 			var nodeID string
 			var err error
 			if params.HasExtendedFields() {
-				nodeID, err = m.memoryHandler.MemoryAddExperienceWithOptions(params)
+				nodeID, err = memoryHandler.MemoryAddExperienceWithOptions(params)
 			} else {
-				nodeID, err = m.memoryHandler.MemoryAddExperience(params.Content, params.Outcome, params.Success)
+				nodeID, err = memoryHandler.MemoryAddExperience(params.Content, params.Outcome, params.Success)
 			}
 			if err != nil {
 				resp.Error = err.Error()
@@ -597,12 +637,12 @@ func (m *Manager) handleCallback(ctx context.Context, data []byte) error {
 		}
 
 	case "memory_get_context":
-		if m.memoryHandler == nil {
+		if memoryHandler == nil {
 			resp.Error = "Memory callback handler not configured"
 		} else {
 			limit, _ := req.Params["limit"].(float64)
 
-			nodes, err := m.memoryHandler.MemoryGetContext(int(limit))
+			nodes, err := memoryHandler.MemoryGetContext(int(limit))
 			if err != nil {
 				resp.Error = err.Error()
 			} else {
@@ -612,14 +652,14 @@ func (m *Manager) handleCallback(ctx context.Context, data []byte) error {
 		}
 
 	case "memory_relate":
-		if m.memoryHandler == nil {
+		if memoryHandler == nil {
 			resp.Error = "Memory callback handler not configured"
 		} else {
 			label, _ := req.Params["label"].(string)
 			subjectID, _ := req.Params["subject_id"].(string)
 			objectID, _ := req.Params["object_id"].(string)
 
-			edgeID, err := m.memoryHandler.MemoryRelate(label, subjectID, objectID)
+			edgeID, err := memoryHandler.MemoryRelate(label, subjectID, objectID)
 			if err != nil {
 				resp.Error = err.Error()
 			} else {
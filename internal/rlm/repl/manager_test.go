@@ -69,6 +69,40 @@ x + y
 	assert.Contains(t, result.Error, "SyntaxError")
 }
 
+func TestManager_Execute_LastExpressionOfStatements(t *testing.T) {
+	m, err := NewManager(Options{})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	require.NoError(t, m.Start(ctx))
+	defer m.Stop()
+
+	// The block runs whole, then its last expression is evaluated again
+	// for its value
+	result, err := m.Execute(ctx, "calls = []\ndef f():\n    calls.append(1)\n    return len(calls)\nf()")
+	require.NoError(t, err)
+	assert.Empty(t, result.Error)
+	assert.Equal(t, "2", result.ReturnVal)
+
+	// A value that cannot be produced again is dropped, not an error
+	result, err = m.Execute(ctx, "it = iter([1])\nnext(it)")
+	require.NoError(t, err)
+	assert.Empty(t, result.Error)
+	assert.Empty(t, result.ReturnVal)
+
+	// Builtins in the last expression are counted once
+	_, err = m.Execute(ctx, "clear_builtin_usage()")
+	require.NoError(t, err)
+	result, err = m.Execute(ctx, "text = 'a b c'\npeek(text, 0, 1)")
+	require.NoError(t, err)
+	assert.Equal(t, "'a'", result.ReturnVal)
+	result, err = m.Execute(ctx, "get_builtin_usage_json()")
+	require.NoError(t, err)
+	assert.Equal(t, `'{"peek": 1}'`, result.ReturnVal)
+}
+
 func TestManager_Variables(t *testing.T) {
 	m, err := NewManager(Options{
 		Sandbox: DefaultSandboxConfig(),
@@ -116,6 +150,17 @@ func TestManager_Variables(t *testing.T) {
 		}
 	}
 	assert.True(t, found, "my_content variable not found in list")
+
+	// Deleted variables are gone
+	_, err = m.Execute(ctx, "del my_content")
+	require.NoError(t, err)
+	listResult, err = m.ListVars(ctx)
+	require.NoError(t, err)
+	for _, v := range listResult.Variables {
+		assert.NotEqual(t, "my_content", v.Name)
+	}
+	_, err = m.GetVar(ctx, "my_content", 0, 0)
+	assert.Error(t, err)
 }
 
 func TestManager_Status(t *testing.T) {
@@ -210,3 +255,38 @@ func TestManager_DataScienceLibraries(t *testing.T) {
 	assert.Empty(t, result.Error, "seaborn should work")
 	assert.Equal(t, "'seaborn'", result.ReturnVal)
 }
+
+func TestManager_FuzzyGrep(t *testing.T) {
+	m, err := NewManager(Options{
+		Sandbox: DefaultSandboxConfig(),
+	})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	require.NoError(t, m.Start(ctx))
+	defer m.Stop()
+
+	require.NoError(t, m.SetVar(ctx, "log", "login ok\nuser authentcation failed\nAuthenticaton retry\nauthorization granted"))
+
+	// Exact search misses the misspellings
+	result, err := m.Execute(ctx, "len(grep(log, 'authentication'))")
+	require.NoError(t, err)
+	assert.Empty(t, result.Error)
+	assert.Equal(t, "0", result.ReturnVal)
+
+	result, err = m.Execute(ctx, "[(h['line_num'], h['match'], h['distance']) for h in grep(log, 'authentication', fuzzy=True)]")
+	require.NoError(t, err)
+	assert.Empty(t, result.Error)
+	assert.Equal(t, "[(2, 'authentcation', 1), (3, 'Authenticaton', 1)]", result.ReturnVal)
+
+	// The distance bound is respected
+	result, err = m.Execute(ctx, "len(grep(log, 'authentication', fuzzy=True, max_distance=0))")
+	require.NoError(t, err)
+	assert.Equal(t, "0", result.ReturnVal)
+
+	result, err = m.Execute(ctx, "len(grep(log, 'authentication', fuzzy=True, max_distance=1, ignore_case=False))")
+	require.NoError(t, err)
+	assert.Equal(t, "1", result.ReturnVal)
+}
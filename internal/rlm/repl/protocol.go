@@ -2,6 +2,7 @@
 package repl
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 )
@@ -144,6 +145,7 @@ type LLMCallParams struct {
 	Prompt  string `json:"prompt"`
 	Context string `json:"context"`
 	Model   string `json:"model"` // "fast", "balanced", "powerful", "reasoning", "auto"
+	LLMCallOptions
 }
 
 // LLMBatchParams contains parameters for an llm_batch callback.
@@ -151,6 +153,31 @@ type LLMBatchParams struct {
 	Prompts  []string `json:"prompts"`
 	Contexts []string `json:"contexts"`
 	Model    string   `json:"model"`
+	LLMCallOptions
+}
+
+// LLMCallOptions are the optional arguments of llm_call and llm_batch
+// beyond prompt, context and model.
+type LLMCallOptions struct {
+	// Verify asks for code in the response to be checked against the
+	// constraints extracted from it.
+	Verify bool `json:"verify,omitempty"`
+
+	// VerifyRetries is how many times code that fails verification is
+	// regenerated. Requires Verify.
+	VerifyRetries int `json:"verify_retries,omitempty"`
+
+	// Synthesis marks results that feed the final answer's synthesis.
+	Synthesis bool `json:"synthesis,omitempty"`
+}
+
+// callOptions reads the optional llm_call and llm_batch arguments from a
+// callback's params.
+func callOptions(params map[string]interface{}) LLMCallOptions {
+	verify, _ := params["verify"].(bool)
+	retries, _ := params["verify_retries"].(float64) // JSON numbers are float64
+	synthesis, _ := params["synthesis"].(bool)
+	return LLMCallOptions{Verify: verify, VerifyRetries: int(retries), Synthesis: synthesis}
 }
 
 // CallbackResponse is sent by Go in response to a callback request.
@@ -194,6 +221,29 @@ type CallbackHandler interface {
 	HandleLLMBatch(prompts, contexts []string, model string) ([]string, error)
 }
 
+// OptionsCallbackHandler is a CallbackHandler that accepts the optional
+// arguments of llm_call and llm_batch. Other handlers get their calls with
+// the options dropped.
+type OptionsCallbackHandler interface {
+	CallbackHandler
+
+	// HandleLLMCallWithOptions handles a single LLM call from Python.
+	HandleLLMCallWithOptions(prompt, context, model string, opts LLMCallOptions) (string, error)
+
+	// HandleLLMBatchWithOptions handles a batch of LLM calls from Python.
+	HandleLLMBatchWithOptions(prompts, contexts []string, model string, opts LLMCallOptions) ([]string, error)
+}
+
+// ContextCallbackHandler is a CallbackHandler that accepts the context of
+// the Execute call a callback arrives during, so values it carries (such
+// as a per-execution cost guard) reach the LLM calls made for Python.
+type ContextCallbackHandler interface {
+	CallbackHandler
+
+	// ForContext returns a handler that makes its calls with ctx.
+	ForContext(ctx context.Context) CallbackHandler
+}
+
 // MemoryCallbackHandler handles memory operations from Python.
 type MemoryCallbackHandler interface {
 	// MemoryQuery searches memory for relevant nodes.
@@ -216,6 +266,16 @@ type MemoryCallbackHandler interface {
 	MemoryRelate(label, subjectID, objectID string) (string, error)
 }
 
+// ContextMemoryHandler is a MemoryCallbackHandler that accepts the context
+// of the Execute call a callback arrives during, so facts recorded by
+// concurrent executions are attributed to the execution that made them.
+type ContextMemoryHandler interface {
+	MemoryCallbackHandler
+
+	// ForContext returns a handler that works with ctx.
+	ForContext(ctx context.Context) MemoryCallbackHandler
+}
+
 // MemoryNode represents a memory node returned to Python.
 type MemoryNode struct {
 	ID         string  `json:"id"`
@@ -5,6 +5,7 @@ import (
 	"os"
 	"runtime"
 	"strconv"
+	"strings"
 	"syscall"
 	"time"
 )
@@ -252,6 +253,24 @@ func getProcessRusage(pid int) (*syscall.Rusage, error) {
 	return &rusage, nil
 }
 
+// processRSS reads the resident set size of process pid, in bytes, from
+// /proc.
+func processRSS(pid int) (uint64, error) {
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/statm", pid))
+	if err != nil {
+		return 0, err
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) < 2 {
+		return 0, fmt.Errorf("malformed statm: %q", data)
+	}
+	pages, err := strconv.ParseUint(fields[1], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("malformed statm: %w", err)
+	}
+	return pages * uint64(os.Getpagesize()), nil
+}
+
 // timevalToMicros converts a syscall.Timeval to microseconds.
 func timevalToMicros(tv syscall.Timeval) int64 {
 	return tv.Sec*1000000 + int64(tv.Usec)
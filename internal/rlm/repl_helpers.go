@@ -0,0 +1,138 @@
+package rlm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// ErrHelperConflict is returned when a custom REPL helper would shadow an
+// RLM builtin.
+var ErrHelperConflict = errors.New("helper name conflicts with an RLM builtin")
+
+// REPLHelper is a user-supplied Python function made available to RLM code
+// alongside the builtins, e.g. a parse_log(ctx) for a team's log format.
+type REPLHelper struct {
+	// Name is the function's Python name. Code must define it.
+	Name string
+
+	// Signature is how the function is listed in the system prompt, e.g.
+	// "parse_log(ctx, level='ERROR')". Empty lists it as Name(...).
+	Signature string
+
+	// Description says what the function does and returns.
+	Description string
+
+	// Code is the Python source defining the function. It runs in the REPL
+	// namespace, so it can call the builtins.
+	Code string
+}
+
+var helperNamePattern = regexp.MustCompile(`^[A-Za-z]\w*$`)
+
+// signature returns how the helper is listed in the system prompt.
+func (h REPLHelper) signature() string {
+	if h.Signature != "" {
+		return h.Signature
+	}
+	return h.Name + "(...)"
+}
+
+// validate checks that the helper can be loaded without shadowing a
+// builtin.
+func (h REPLHelper) validate() error {
+	if !helperNamePattern.MatchString(h.Name) {
+		return fmt.Errorf("invalid helper name %q", h.Name)
+	}
+	if isBuiltinRLMVar(h.Name) {
+		return fmt.Errorf("%w: %s", ErrHelperConflict, h.Name)
+	}
+	def := regexp.MustCompile(`(?m)^(?:async\s+)?def\s+` + regexp.QuoteMeta(h.Name) + `\s*\(`)
+	if !def.MatchString(h.Code) {
+		return fmt.Errorf("helper code does not define %s()", h.Name)
+	}
+	return nil
+}
+
+// RegisterHelper makes a custom Python helper available to RLM executions:
+// it is loaded into the REPL at the start of each execution, survives
+// context clearing, and is listed with the builtins in the system prompt
+// of prompts prepared afterwards. Registering a name again replaces the
+// earlier helper. A name that shadows a builtin is refused with
+// ErrHelperConflict. If the REPL is running, the code is loaded right away
+// and refused if it raises.
+func (w *Wrapper) RegisterHelper(ctx context.Context, helper REPLHelper) error {
+	if err := helper.validate(); err != nil {
+		return fmt.Errorf("register helper: %w", err)
+	}
+	if w.replMgr != nil && w.replMgr.Running() {
+		result, err := w.replMgr.Execute(ctx, helper.Code)
+		if err != nil {
+			return fmt.Errorf("register helper %s: %w", helper.Name, err)
+		}
+		if result.Error != "" {
+			return fmt.Errorf("register helper %s: %s", helper.Name, result.Error)
+		}
+	}
+
+	i := slices.IndexFunc(w.helpers, func(h REPLHelper) bool { return h.Name == helper.Name })
+	if i >= 0 {
+		w.helpers[i] = helper
+	} else {
+		w.helpers = append(w.helpers, helper)
+	}
+	return nil
+}
+
+// Helpers returns the registered custom REPL helpers, in registration
+// order.
+func (w *Wrapper) Helpers() []REPLHelper {
+	return slices.Clone(w.helpers)
+}
+
+// isHelper reports whether name is a registered custom helper.
+func (w *Wrapper) isHelper(name string) bool {
+	return slices.ContainsFunc(w.helpers, func(h REPLHelper) bool { return h.Name == name })
+}
+
+// loadHelpers defines the custom helpers in the REPL, which may have been
+// restarted since they were registered. A helper that fails to load is
+// logged and left out.
+func (w *Wrapper) loadHelpers(ctx context.Context) {
+	for _, h := range w.helpers {
+		result, err := w.replMgr.Execute(ctx, h.Code)
+		if err == nil && result.Error != "" {
+			err = errors.New(result.Error)
+		}
+		if err != nil {
+			slog.Warn("Failed to load REPL helper", "helper", h.Name, "error", err)
+		}
+	}
+}
+
+// helpersPromptSection lists the custom helpers for the system prompt, or
+// returns "" when there are none. Terse lists signatures only.
+func (w *Wrapper) helpersPromptSection(terse bool) string {
+	if len(w.helpers) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	if !terse {
+		sb.WriteString("### Custom Helpers\n")
+	}
+	for _, h := range w.helpers {
+		sb.WriteString("- " + h.signature())
+		if h.Description != "" && !terse {
+			sb.WriteString(" - " + h.Description)
+		}
+		sb.WriteString("\n")
+	}
+	if !terse {
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
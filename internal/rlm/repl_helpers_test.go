@@ -0,0 +1,94 @@
+package rlm
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rand/recurse/internal/rlm/repl"
+)
+
+var parseLogHelper = REPLHelper{
+	Name:        "parse_log",
+	Signature:   "parse_log(ctx, level='ERROR')",
+	Description: "Lines of a log at the given level",
+	Code: `def parse_log(ctx, level="ERROR"):
+    return [line for line in str(ctx).splitlines() if line.startswith(level)]
+`,
+}
+
+func TestWrapper_RegisterHelper(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	replMgr, err := repl.NewManager(repl.Options{})
+	require.NoError(t, err)
+	require.NoError(t, replMgr.Start(ctx))
+	defer replMgr.Stop()
+
+	w := NewWrapper(nil, WrapperConfig{})
+	w.SetREPLManager(replMgr)
+	require.NoError(t, w.RegisterHelper(ctx, parseLogHelper))
+	assert.Equal(t, []REPLHelper{parseLogHelper}, w.Helpers())
+
+	// Available in the REPL right away
+	result, err := replMgr.Execute(ctx, `print(parse_log("ERROR disk full\nINFO ok\nERROR timeout"))`)
+	require.NoError(t, err)
+	require.Empty(t, result.Error)
+	assert.Contains(t, result.Output, "['ERROR disk full', 'ERROR timeout']")
+
+	// Listed with the builtins
+	prompt := w.buildRLMSystemPrompt(nil, nil, nil, compactNone)
+	assert.Contains(t, prompt, "### Custom Helpers\n- parse_log(ctx, level='ERROR') - Lines of a log at the given level\n")
+	assert.Contains(t, w.buildRLMSystemPrompt(nil, nil, nil, compactTerse), "- parse_log(ctx, level='ERROR')\n")
+
+	// Clearing the context keeps it
+	require.NoError(t, w.ClearContext(ctx))
+	result, err = replMgr.Execute(ctx, `print(len(parse_log("ERROR x")))`)
+	require.NoError(t, err)
+	assert.Empty(t, result.Error)
+
+	t.Run("refused", func(t *testing.T) {
+		err := w.RegisterHelper(ctx, REPLHelper{Name: "grep", Code: "def grep(ctx):\n    return []\n"})
+		assert.ErrorIs(t, err, ErrHelperConflict)
+
+		err = w.RegisterHelper(ctx, REPLHelper{Name: "stack_traces", Code: "def other(ctx):\n    return []\n"})
+		assert.ErrorContains(t, err, "does not define stack_traces()")
+
+		err = w.RegisterHelper(ctx, REPLHelper{Name: "_private", Code: "def _private():\n    pass\n"})
+		assert.Error(t, err)
+
+		err = w.RegisterHelper(ctx, REPLHelper{Name: "broken", Code: "def broken(:\n    pass\n"})
+		assert.Error(t, err, "code that does not run is refused")
+
+		assert.Len(t, w.Helpers(), 1)
+	})
+}
+
+func TestExecuteRLM_LoadsHelpers(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	replMgr, err := repl.NewManager(repl.Options{})
+	require.NoError(t, err)
+
+	// Registered before the REPL runs; loaded when the execution starts
+	w := &Wrapper{client: &wrapperMockLLMClient{responses: []string{
+		"```python\nFINAL(str(len(parse_log(logs))))\n```",
+	}}}
+	require.NoError(t, w.RegisterHelper(ctx, parseLogHelper))
+
+	require.NoError(t, replMgr.Start(ctx))
+	defer replMgr.Stop()
+	w.SetREPLManager(replMgr)
+	require.NoError(t, replMgr.SetVar(ctx, "logs", "ERROR a\nINFO b\nERROR c"))
+
+	prepared := &PreparedPrompt{Mode: ModeRLM, SystemPrompt: "system", FinalPrompt: "count errors"}
+	result, err := w.ExecuteRLMWithConfig(ctx, prepared, RLMConfig{MaxIterations: 2, Timeout: 10 * time.Second})
+	require.NoError(t, err)
+	require.NoError(t, result.Err)
+	assert.Equal(t, "2", result.FinalOutput)
+}
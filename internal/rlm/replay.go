@@ -0,0 +1,329 @@
+package rlm
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync/atomic"
+	"time"
+
+	"github.com/rand/recurse/internal/rlm/repl"
+	"github.com/rand/recurse/internal/tui/components/dialogs/rlmtrace"
+)
+
+// RLMStep identifies what an RLM loop trace event records.
+type RLMStep string
+
+const (
+	// RLMStepStart opens an execution; the other steps are its children.
+	RLMStepStart RLMStep = "start"
+
+	// RLMStepResponse is the model's response in an iteration.
+	RLMStepResponse RLMStep = "response"
+
+	// RLMStepCode is a code block run in the REPL and what it produced.
+	RLMStepCode RLMStep = "code"
+
+	// RLMStepFinal records how the execution ended.
+	RLMStepFinal RLMStep = "final"
+)
+
+// RLMStepPayload is the typed payload the RLM loop stores, as JSON, in the
+// Details of its trace events. Trace backends map event types onto a few
+// display categories, so the step kind travels in the payload instead.
+type RLMStepPayload struct {
+	Step      RLMStep `json:"rlm_step"`
+	Iteration int     `json:"iteration,omitempty"`
+
+	// Start
+	Task string `json:"task,omitempty"`
+
+	// Response
+	Response string `json:"response,omitempty"`
+
+	// Code
+	Code        string `json:"code,omitempty"`
+	Output      string `json:"output,omitempty"`
+	ReturnValue string `json:"return_value,omitempty"`
+	Error       string `json:"error,omitempty"`
+
+	// Final
+	Answer string `json:"answer,omitempty"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// decodeRLMStep extracts the payload of an RLM loop event. It returns false
+// for events recorded by anything else.
+func decodeRLMStep(details string) (RLMStepPayload, bool) {
+	var p RLMStepPayload
+	if details == "" || json.Unmarshal([]byte(details), &p) != nil || p.Step == "" {
+		return RLMStepPayload{}, false
+	}
+	return p, true
+}
+
+// ExecutionReplay is an RLM execution reconstructed from its trace events.
+type ExecutionReplay struct {
+	// ID is the ID of the execution's start event.
+	ID string
+
+	// Task is the prompt the execution ran on.
+	Task string
+
+	StartTime time.Time
+
+	// Iterations holds each recorded iteration in order.
+	Iterations []ReplayIteration
+
+	// Completed reports whether the end of the execution was recorded.
+	// Without it the execution was still running or its trace is partial.
+	Completed bool
+
+	// FinalAnswer, TerminationReason and Error describe how it ended.
+	FinalAnswer       string
+	TerminationReason string
+	Error             string
+}
+
+// ReplayIteration is one iteration of a replayed execution.
+type ReplayIteration struct {
+	Number int
+
+	// Response is the model's response and Tokens what the call used.
+	Response string
+	Tokens   int
+
+	// Code is the block run in the REPL, empty if the response had none.
+	Code        string
+	Output      string
+	ReturnValue string
+	Error       string
+
+	// Duration is how long the code took to run.
+	Duration time.Duration
+}
+
+// Iteration returns the iteration numbered n, counting from 1.
+func (r *ExecutionReplay) Iteration(n int) (*ReplayIteration, bool) {
+	for i := range r.Iterations {
+		if r.Iterations[i].Number == n {
+			return &r.Iterations[i], true
+		}
+	}
+	return nil, false
+}
+
+// ReplayExecution reconstructs an RLM execution from its recorded trace
+// events, in any order, without rerunning any model. Events from outside
+// the RLM loop are ignored, but events of more than one execution are an
+// error. Steps dropped by trace sampling are missing from the replay.
+func ReplayExecution(events []TraceEvent) (*ExecutionReplay, error) {
+	var root *TraceEvent
+	steps := make(map[string]RLMStepPayload, len(events))
+	for i, event := range events {
+		p, ok := decodeRLMStep(event.Details)
+		if !ok {
+			continue
+		}
+		if p.Step == RLMStepStart {
+			if root != nil {
+				return nil, fmt.Errorf("replay: events hold more than one execution (%s, %s)", root.ID, event.ID)
+			}
+			root = &events[i]
+		}
+		steps[event.ID] = p
+	}
+	if root == nil {
+		return nil, errors.New("replay: no RLM execution start event")
+	}
+
+	replay := &ExecutionReplay{
+		ID:        root.ID,
+		Task:      steps[root.ID].Task,
+		StartTime: root.Timestamp,
+	}
+	iterations := make(map[int]*ReplayIteration)
+	iteration := func(n int) *ReplayIteration {
+		it, ok := iterations[n]
+		if !ok {
+			it = &ReplayIteration{Number: n}
+			iterations[n] = it
+		}
+		return it
+	}
+
+	for _, event := range events {
+		p, ok := steps[event.ID]
+		if !ok || event.ParentID != root.ID {
+			continue
+		}
+		switch p.Step {
+		case RLMStepResponse:
+			it := iteration(p.Iteration)
+			it.Response = p.Response
+			it.Tokens = event.Tokens
+		case RLMStepCode:
+			it := iteration(p.Iteration)
+			it.Code = p.Code
+			it.Output = p.Output
+			it.ReturnValue = p.ReturnValue
+			it.Error = p.Error
+			it.Duration = event.Duration
+		case RLMStepFinal:
+			replay.Completed = true
+			replay.FinalAnswer = p.Answer
+			replay.TerminationReason = p.Reason
+			replay.Error = p.Error
+		}
+	}
+
+	for _, it := range iterations {
+		replay.Iterations = append(replay.Iterations, *it)
+	}
+	slices.SortFunc(replay.Iterations, func(a, b ReplayIteration) int { return a.Number - b.Number })
+	return replay, nil
+}
+
+// ReplayTrace reconstructs an RLM execution from events read back from a
+// trace provider.
+func ReplayTrace(events []rlmtrace.TraceEvent) (*ExecutionReplay, error) {
+	internal := make([]TraceEvent, len(events))
+	for i, event := range events {
+		internal[i] = fromRLMTraceEvent(event)
+	}
+	return ReplayExecution(internal)
+}
+
+// fromRLMTraceEvent converts a display trace event back to a TraceEvent.
+func fromRLMTraceEvent(event rlmtrace.TraceEvent) TraceEvent {
+	return TraceEvent{
+		ID:        event.ID,
+		Type:      string(event.Type),
+		Action:    event.Action,
+		Details:   event.Details,
+		Tokens:    event.Tokens,
+		Duration:  event.Duration,
+		Timestamp: event.Timestamp,
+		Depth:     event.Depth,
+		ParentID:  event.ParentID,
+		Status:    event.Status,
+	}
+}
+
+var executionTraceCounter uint64
+
+// newExecutionID returns a unique ID for an RLM execution.
+func newExecutionID() string {
+	count := atomic.AddUint64(&executionTraceCounter, 1)
+	return fmt.Sprintf("rlm-exec-%d-%d", time.Now().UnixNano(), count)
+}
+
+// executionTrace records the steps of one RLM loop run. A nil trace
+// records nothing.
+type executionTrace struct {
+	recorder TraceRecorder
+	rootID   string
+}
+
+// startExecutionTrace records the start of an execution on task.
+func startExecutionTrace(recorder TraceRecorder, task string) *executionTrace {
+	if recorder == nil {
+		return nil
+	}
+	t := &executionTrace{
+		recorder: recorder,
+		rootID:   newExecutionID(),
+	}
+	t.record(TraceEvent{
+		ID:     t.rootID,
+		Type:   "execute",
+		Action: "RLM: " + truncate(task, 50),
+		Status: "running",
+	}, RLMStepPayload{Step: RLMStepStart, Task: task})
+	return t
+}
+
+// response records the model's response in an iteration.
+func (t *executionTrace) response(iteration int, response string, tokens int, dur time.Duration) {
+	if t == nil {
+		return
+	}
+	t.record(TraceEvent{
+		ID:       fmt.Sprintf("%s-%d-response", t.rootID, iteration),
+		Type:     "decision",
+		Action:   fmt.Sprintf("Iteration %d: model response", iteration),
+		Tokens:   tokens,
+		Duration: dur,
+		Status:   "completed",
+	}, RLMStepPayload{Step: RLMStepResponse, Iteration: iteration, Response: response})
+}
+
+// code records a code block run in the REPL. execErr is a failure to run
+// it at all; errors raised by the code are in result.
+func (t *executionTrace) code(iteration int, code string, result *repl.ExecuteResult, execErr error, dur time.Duration) {
+	if t == nil {
+		return
+	}
+	p := RLMStepPayload{Step: RLMStepCode, Iteration: iteration, Code: code}
+	status := "completed"
+	if result != nil {
+		p.Output = result.Output
+		p.ReturnValue = result.ReturnVal
+		p.Error = result.Error
+	}
+	if execErr != nil {
+		p.Error = execErr.Error()
+		status = "failed"
+	}
+	t.record(TraceEvent{
+		ID:       fmt.Sprintf("%s-%d-code", t.rootID, iteration),
+		Type:     "execute",
+		Action:   fmt.Sprintf("Iteration %d: run code", iteration),
+		Duration: dur,
+		Status:   status,
+	}, p)
+}
+
+// final records how the execution ended.
+func (t *executionTrace) final(result *RLMExecutionResult) {
+	if t == nil {
+		return
+	}
+	status := "completed"
+	if result.Error != "" {
+		status = "failed"
+	}
+	t.record(TraceEvent{
+		ID:       t.rootID + "-final",
+		Type:     "decision",
+		Action:   "RLM: " + truncate(result.FinalOutput, 50),
+		Tokens:   result.TotalTokens,
+		Duration: result.Duration,
+		Status:   status,
+	}, RLMStepPayload{
+		Step:      RLMStepFinal,
+		Iteration: result.Iterations,
+		Answer:    result.FinalOutput,
+		Reason:    result.TerminationReason,
+		Error:     result.Error,
+	})
+}
+
+func (t *executionTrace) record(event TraceEvent, p RLMStepPayload) {
+	details, err := json.Marshal(p)
+	if err != nil {
+		slog.Debug("Failed to encode RLM trace payload", "error", err)
+		return
+	}
+	event.Details = string(details)
+	event.Timestamp = time.Now()
+	if event.ID != t.rootID {
+		event.ParentID = t.rootID
+		event.Depth = 1
+	}
+	if err := t.recorder.RecordEvent(event); err != nil {
+		slog.Debug("Failed to record RLM trace event", "id", event.ID, "error", err)
+	}
+}
@@ -0,0 +1,161 @@
+package rlm
+
+import (
+	"context"
+	"math/rand/v2"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rand/recurse/internal/rlm/repl"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runTracedRLM runs a three-iteration execution, recording its trace to
+// recorder.
+func runTracedRLM(t *testing.T, recorder TraceRecorder) *RLMExecutionResult {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	replMgr, err := repl.NewManager(repl.Options{})
+	require.NoError(t, err)
+	require.NoError(t, replMgr.Start(ctx))
+	defer replMgr.Stop()
+
+	client := &wrapperMockLLMClient{
+		responses: []string{
+			"Start with the base value.\n```python\nx = 21\nprint(x)\nbase = x\n```",
+			"```python\nprint(undefined_name)\n```",
+			"```python\ny = x * 2\nprint(y)\nFINAL(str(y))\n```",
+		},
+	}
+	w := &Wrapper{replMgr: replMgr, client: client, tracer: recorder}
+
+	result, err := w.ExecuteRLMWithConfig(ctx, &PreparedPrompt{
+		Mode:         ModeRLM,
+		SystemPrompt: "You are an RLM assistant.",
+		FinalPrompt:  "Double 21",
+	}, RLMConfig{MaxIterations: 5, MaxTokensPerCall: 1024, Timeout: 10 * time.Second})
+	require.NoError(t, err)
+	require.Equal(t, "42", result.FinalOutput)
+	return result
+}
+
+func assertReplaysTracedRLM(t *testing.T, replay *ExecutionReplay, result *RLMExecutionResult) {
+	t.Helper()
+	assert.Equal(t, "Double 21", replay.Task)
+	assert.True(t, replay.Completed)
+	assert.Equal(t, "42", replay.FinalAnswer)
+	assert.Equal(t, "FINAL() called", replay.TerminationReason)
+	assert.Empty(t, replay.Error)
+
+	require.Len(t, replay.Iterations, 3)
+	for i, it := range replay.Iterations {
+		assert.Equal(t, i+1, it.Number)
+	}
+
+	first := replay.Iterations[0]
+	assert.Contains(t, first.Response, "Start with the base value.")
+	assert.Equal(t, "x = 21\nprint(x)\nbase = x", first.Code)
+	assert.Equal(t, "21", strings.TrimSpace(first.Output))
+	assert.Empty(t, first.Error)
+	assert.Positive(t, first.Tokens)
+
+	second, ok := replay.Iteration(2)
+	require.True(t, ok)
+	assert.Equal(t, "print(undefined_name)", second.Code)
+	assert.Contains(t, second.Error, "undefined_name")
+
+	third, ok := replay.Iteration(3)
+	require.True(t, ok)
+	assert.Equal(t, "42", strings.TrimSpace(third.Output))
+
+	var tokens int
+	for _, it := range replay.Iterations {
+		tokens += it.Tokens
+	}
+	assert.Equal(t, result.TotalTokens, tokens)
+
+	_, ok = replay.Iteration(4)
+	assert.False(t, ok)
+}
+
+func TestReplayExecution_MultiIteration(t *testing.T) {
+	recorder := &mockTraceRecorder{}
+	result := runTracedRLM(t, recorder)
+
+	// A start, three responses, three code blocks and the end
+	require.Len(t, recorder.events, 8)
+
+	replay, err := ReplayExecution(recorder.events)
+	require.NoError(t, err)
+	assertReplaysTracedRLM(t, replay, result)
+	assert.Equal(t, recorder.events[0].ID, replay.ID)
+
+	// Order of the events does not matter.
+	shuffled := append([]TraceEvent(nil), recorder.events...)
+	rand.New(rand.NewPCG(1, 2)).Shuffle(len(shuffled), func(i, j int) {
+		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+	})
+	again, err := ReplayExecution(shuffled)
+	require.NoError(t, err)
+	assert.Equal(t, replay, again)
+}
+
+func TestReplayTrace_FromPersistedTrace(t *testing.T) {
+	provider, err := NewPersistentTraceProvider(PersistentTraceConfig{})
+	require.NoError(t, err)
+	defer provider.Close()
+
+	result := runTracedRLM(t, provider)
+
+	events, err := provider.GetEvents(100)
+	require.NoError(t, err)
+	require.Len(t, events, 8)
+
+	replay, err := ReplayTrace(events)
+	require.NoError(t, err)
+	assertReplaysTracedRLM(t, replay, result)
+}
+
+func TestReplayExecution_PartialTrace(t *testing.T) {
+	recorder := &mockTraceRecorder{}
+	runTracedRLM(t, recorder)
+
+	// Without the end event the execution reads as unfinished.
+	replay, err := ReplayExecution(recorder.events[:len(recorder.events)-1])
+	require.NoError(t, err)
+	assert.False(t, replay.Completed)
+	assert.Empty(t, replay.FinalAnswer)
+	assert.Len(t, replay.Iterations, 3)
+}
+
+func TestReplayExecution_IgnoresOtherEvents(t *testing.T) {
+	recorder := &mockTraceRecorder{}
+	runTracedRLM(t, recorder)
+
+	events := append([]TraceEvent{
+		{ID: "other-1", Type: "decision", Action: "Evaluating", Details: "not json"},
+		{ID: "other-2", Type: "execute", Details: `{"status": "ok"}`},
+	}, recorder.events...)
+
+	replay, err := ReplayExecution(events)
+	require.NoError(t, err)
+	assert.Len(t, replay.Iterations, 3)
+}
+
+func TestReplayExecution_Errors(t *testing.T) {
+	_, err := ReplayExecution(nil)
+	assert.ErrorContains(t, err, "no RLM execution start event")
+
+	_, err = ReplayExecution([]TraceEvent{{ID: "e1", Details: "plain text"}})
+	assert.ErrorContains(t, err, "no RLM execution start event")
+
+	first, second := &mockTraceRecorder{}, &mockTraceRecorder{}
+	runTracedRLM(t, first)
+	runTracedRLM(t, second)
+	_, err = ReplayExecution(append(first.events, second.events...))
+	assert.ErrorContains(t, err, "more than one execution")
+}
@@ -0,0 +1,356 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/rand/recurse/internal/rlm/meta"
+)
+
+// ErrNoHealthyClients is returned when every client in a pool is unhealthy
+// and none is due for a recovery probe.
+var ErrNoHealthyClients = errors.New("no healthy clients in pool")
+
+// PoolStrategy decides which healthy client serves a call.
+type PoolStrategy int
+
+const (
+	// PoolRoundRobin rotates through the healthy clients.
+	PoolRoundRobin PoolStrategy = iota
+
+	// PoolLeastLoaded picks the healthy client with the fewest calls in
+	// flight, rotating among ties.
+	PoolLeastLoaded
+)
+
+func (s PoolStrategy) String() string {
+	switch s {
+	case PoolRoundRobin:
+		return "round-robin"
+	case PoolLeastLoaded:
+		return "least-loaded"
+	default:
+		return "unknown"
+	}
+}
+
+// PoolConfig configures a PooledClient.
+type PoolConfig struct {
+	// Strategy picks among the healthy clients.
+	// Default: PoolRoundRobin
+	Strategy PoolStrategy
+
+	// Window is how many of a client's latest calls its error rate is
+	// measured over.
+	// Default: 20
+	Window int
+
+	// MinCalls is how many calls the window needs before a client can be
+	// marked unhealthy.
+	// Default: 3
+	MinCalls int
+
+	// MaxErrorRate is the error rate above which a client is unhealthy.
+	// Default: 0.5
+	MaxErrorRate float64
+
+	// Cooldown is how long an unhealthy client is skipped before a single
+	// probe call tests whether it has recovered.
+	// Default: 30 seconds
+	Cooldown time.Duration
+
+	// MaxAttempts is how many clients a call tries before returning the
+	// last error; 1 disables failover.
+	// Default: 2
+	MaxAttempts int
+}
+
+// DefaultPoolConfig returns the default configuration.
+func DefaultPoolConfig() PoolConfig {
+	return PoolConfig{
+		Strategy:     PoolRoundRobin,
+		Window:       20,
+		MinCalls:     3,
+		MaxErrorRate: 0.5,
+		Cooldown:     30 * time.Second,
+		MaxAttempts:  2,
+	}
+}
+
+// PoolMember is a named client in a pool, such as one API key or provider.
+type PoolMember struct {
+	Name   string
+	Client meta.LLMClient
+}
+
+// PooledClient is a meta.LLMClient that spreads calls across several
+// clients, skipping those whose recent error rate marks them unhealthy. An
+// unhealthy client is probed again after the cooldown and rejoins the pool
+// once a probe succeeds. Like RateLimitedClient it only forwards Complete.
+type PooledClient struct {
+	config  PoolConfig
+	members []*poolMember
+
+	mu   sync.Mutex
+	next int
+}
+
+var _ meta.LLMClient = (*PooledClient)(nil)
+
+// poolMember tracks the health of one client. Fields other than name and
+// client are guarded by the pool's mutex.
+type poolMember struct {
+	name   string
+	client meta.LLMClient
+
+	// outcomes is a ring of the latest call results, true for failures.
+	outcomes []bool
+	head     int
+	filled   int
+
+	inFlight       int
+	unhealthySince time.Time // zero while healthy
+	probing        bool
+
+	calls    int64
+	failures int64
+}
+
+// NewPooledClient creates a pool over members, all healthy to start with.
+func NewPooledClient(config PoolConfig, members ...PoolMember) *PooledClient {
+	if config.Window <= 0 {
+		config.Window = 20
+	}
+	if config.MinCalls <= 0 {
+		config.MinCalls = 3
+	}
+	if config.MaxErrorRate <= 0 {
+		config.MaxErrorRate = 0.5
+	}
+	if config.Cooldown <= 0 {
+		config.Cooldown = 30 * time.Second
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = 2
+	}
+
+	p := &PooledClient{config: config}
+	for i, m := range members {
+		name := m.Name
+		if name == "" {
+			name = fmt.Sprintf("client-%d", i)
+		}
+		p.members = append(p.members, &poolMember{
+			name:     name,
+			client:   m.Client,
+			outcomes: make([]bool, config.Window),
+		})
+	}
+	return p
+}
+
+// Complete calls a healthy client, failing over to another on error up to
+// MaxAttempts clients. Errors caused by ctx ending do not count against a
+// client's health.
+func (p *PooledClient) Complete(ctx context.Context, prompt string, maxTokens int) (string, error) {
+	tried := make(map[*poolMember]bool, p.config.MaxAttempts)
+	var lastErr error
+	for len(tried) < p.config.MaxAttempts {
+		m := p.acquire(tried)
+		if m == nil {
+			break
+		}
+		tried[m] = true
+
+		response, err := m.client.Complete(ctx, prompt, maxTokens)
+		if err != nil && ctx.Err() != nil {
+			p.abandon(m)
+			return "", err
+		}
+		p.release(m, err)
+		if err == nil {
+			return response, nil
+		}
+		lastErr = fmt.Errorf("pool client %s: %w", m.name, err)
+	}
+	if lastErr == nil {
+		return "", ErrNoHealthyClients
+	}
+	return "", lastErr
+}
+
+// Capabilities implements meta.CapabilityReporter with the limits every
+// member's client meets, since a call may go to any of them.
+func (p *PooledClient) Capabilities() meta.Capabilities {
+	caps := make([]meta.Capabilities, len(p.members))
+	for i, m := range p.members {
+		caps[i] = meta.CapabilitiesOf(m.client)
+	}
+	return meta.CommonCapabilities(caps...).CompletionOnly()
+}
+
+// acquire picks a client not in tried and marks a call in flight on it.
+// It returns nil when no client is available.
+func (p *PooledClient) acquire(tried map[*poolMember]bool) *poolMember {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	n := len(p.members)
+	var chosen *poolMember
+	chosenAt := 0
+	for i := 0; i < n; i++ {
+		idx := (p.next + i) % n
+		m := p.members[idx]
+		if tried[m] || !p.available(m, now) {
+			continue
+		}
+		if chosen == nil || (p.config.Strategy == PoolLeastLoaded && m.inFlight < chosen.inFlight) {
+			chosen, chosenAt = m, idx
+		}
+		if p.config.Strategy == PoolRoundRobin {
+			break
+		}
+	}
+	if chosen == nil {
+		return nil
+	}
+
+	p.next = (chosenAt + 1) % n
+	if !chosen.unhealthySince.IsZero() {
+		chosen.probing = true
+	}
+	chosen.inFlight++
+	return chosen
+}
+
+// available reports whether m may take a call: it is healthy, or its
+// cooldown has passed and no probe is in flight. p.mu must be held.
+func (p *PooledClient) available(m *poolMember, now time.Time) bool {
+	if m.unhealthySince.IsZero() {
+		return true
+	}
+	return !m.probing && now.Sub(m.unhealthySince) >= p.config.Cooldown
+}
+
+// abandon ends a call on m without recording an outcome.
+func (p *PooledClient) abandon(m *poolMember) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	m.inFlight--
+	m.probing = false
+}
+
+// release ends a call on m, recording its outcome and updating m's health.
+func (p *PooledClient) release(m *poolMember, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	m.inFlight--
+	failed := err != nil
+	m.calls++
+	if failed {
+		m.failures++
+	}
+
+	if !m.unhealthySince.IsZero() {
+		if !m.probing {
+			return
+		}
+		m.probing = false
+		if failed {
+			// Still failing: wait out another cooldown
+			m.unhealthySince = time.Now()
+			return
+		}
+		// Recovered: start afresh
+		m.unhealthySince = time.Time{}
+		m.head, m.filled = 0, 0
+	}
+
+	m.outcomes[m.head] = failed
+	m.head = (m.head + 1) % len(m.outcomes)
+	m.filled = min(m.filled+1, len(m.outcomes))
+	if m.filled >= p.config.MinCalls && m.errorRate() > p.config.MaxErrorRate {
+		m.unhealthySince = time.Now()
+	}
+}
+
+// errorRate is the failure share of the recorded window.
+func (m *poolMember) errorRate() float64 {
+	if m.filled == 0 {
+		return 0
+	}
+	failures := 0
+	for i := 0; i < m.filled; i++ {
+		if m.outcomes[i] {
+			failures++
+		}
+	}
+	return float64(failures) / float64(m.filled)
+}
+
+// MemberHealth is the health of one client in a pool.
+type MemberHealth struct {
+	Name      string
+	Healthy   bool
+	ErrorRate float64 // over the recent window
+	InFlight  int
+	Calls     int64
+	Failures  int64
+
+	// UnhealthySince is when the client was last marked unhealthy; zero
+	// while healthy.
+	UnhealthySince time.Time
+}
+
+// PoolHealth is the aggregate health of a pool.
+type PoolHealth struct {
+	Members []MemberHealth
+
+	// Healthy is how many members are taking calls.
+	Healthy int
+
+	// ErrorRate is the failure share of the members' recent windows
+	// combined.
+	ErrorRate float64
+
+	Calls    int64
+	Failures int64
+}
+
+// Health returns the pool's current health.
+func (p *PooledClient) Health() PoolHealth {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var h PoolHealth
+	var windowCalls, windowFailures float64
+	for _, m := range p.members {
+		rate := m.errorRate()
+		mh := MemberHealth{
+			Name:           m.name,
+			Healthy:        m.unhealthySince.IsZero(),
+			ErrorRate:      rate,
+			InFlight:       m.inFlight,
+			Calls:          m.calls,
+			Failures:       m.failures,
+			UnhealthySince: m.unhealthySince,
+		}
+		h.Members = append(h.Members, mh)
+		if mh.Healthy {
+			h.Healthy++
+		}
+		h.Calls += m.calls
+		h.Failures += m.failures
+		windowCalls += float64(m.filled)
+		windowFailures += rate * float64(m.filled)
+	}
+	if windowCalls > 0 {
+		h.ErrorRate = windowFailures / windowCalls
+	}
+	return h
+}
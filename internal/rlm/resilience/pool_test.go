@@ -0,0 +1,189 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rand/recurse/internal/rlm/meta"
+)
+
+// switchableClient fails while failing is set.
+type switchableClient struct {
+	failing atomic.Bool
+	calls   atomic.Int64
+	block   chan struct{} // if set, calls wait on it
+}
+
+func (c *switchableClient) Complete(ctx context.Context, prompt string, maxTokens int) (string, error) {
+	c.calls.Add(1)
+	if c.block != nil {
+		select {
+		case <-c.block:
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+	if c.failing.Load() {
+		return "", errors.New("upstream unavailable")
+	}
+	return "ok", nil
+}
+
+func newTestPool(config PoolConfig, clients ...*switchableClient) *PooledClient {
+	members := make([]PoolMember, len(clients))
+	for i, c := range clients {
+		members[i] = PoolMember{Client: c}
+	}
+	return NewPooledClient(config, members...)
+}
+
+func TestPooledClient_RoundRobin(t *testing.T) {
+	a, b, c := &switchableClient{}, &switchableClient{}, &switchableClient{}
+	pool := newTestPool(DefaultPoolConfig(), a, b, c)
+
+	for i := 0; i < 9; i++ {
+		_, err := pool.Complete(context.Background(), "p", 10)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, int64(3), a.calls.Load())
+	assert.Equal(t, int64(3), b.calls.Load())
+	assert.Equal(t, int64(3), c.calls.Load())
+
+	h := pool.Health()
+	assert.Equal(t, 3, h.Healthy)
+	assert.Equal(t, int64(9), h.Calls)
+	assert.Equal(t, []string{"client-0", "client-1", "client-2"},
+		[]string{h.Members[0].Name, h.Members[1].Name, h.Members[2].Name})
+}
+
+func TestPooledClient_ShiftsAwayAndRecovers(t *testing.T) {
+	healthy1, broken, healthy2 := &switchableClient{}, &switchableClient{}, &switchableClient{}
+	broken.failing.Store(true)
+	pool := newTestPool(PoolConfig{MinCalls: 2, Cooldown: 30 * time.Millisecond}, healthy1, broken, healthy2)
+	ctx := context.Background()
+
+	// Callers never see the failures: each fails over to a healthy client
+	for i := 0; i < 30; i++ {
+		_, err := pool.Complete(ctx, "p", 10)
+		require.NoError(t, err)
+	}
+
+	// Two failures were enough to take it out of rotation
+	assert.Equal(t, int64(2), broken.calls.Load())
+	assert.Equal(t, int64(30), healthy1.calls.Load()+healthy2.calls.Load())
+	h := pool.Health()
+	assert.Equal(t, 2, h.Healthy)
+	assert.False(t, h.Members[1].Healthy)
+	assert.Equal(t, 1.0, h.Members[1].ErrorRate)
+	assert.False(t, h.Members[1].UnhealthySince.IsZero())
+
+	// A probe after the cooldown that still fails keeps it out
+	time.Sleep(40 * time.Millisecond)
+	for i := 0; i < 10; i++ {
+		_, err := pool.Complete(ctx, "p", 10)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, int64(3), broken.calls.Load())
+	assert.False(t, pool.Health().Members[1].Healthy)
+
+	// Once healed, the next probe brings it back into rotation
+	broken.failing.Store(false)
+	time.Sleep(40 * time.Millisecond)
+	before := broken.calls.Load()
+	for i := 0; i < 9; i++ {
+		_, err := pool.Complete(ctx, "p", 10)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, int64(3), broken.calls.Load()-before)
+
+	h = pool.Health()
+	assert.Equal(t, 3, h.Healthy)
+	assert.Equal(t, 0.0, h.Members[1].ErrorRate)
+}
+
+func TestPooledClient_AllUnhealthy(t *testing.T) {
+	a, b := &switchableClient{}, &switchableClient{}
+	a.failing.Store(true)
+	b.failing.Store(true)
+	pool := newTestPool(PoolConfig{MinCalls: 1, MaxAttempts: 1, Cooldown: time.Hour}, a, b)
+
+	_, err := pool.Complete(context.Background(), "p", 10)
+	assert.ErrorContains(t, err, "pool client client-0: upstream unavailable")
+	_, err = pool.Complete(context.Background(), "p", 10)
+	assert.ErrorContains(t, err, "pool client client-1")
+
+	_, err = pool.Complete(context.Background(), "p", 10)
+	assert.ErrorIs(t, err, ErrNoHealthyClients)
+	assert.Equal(t, 0, pool.Health().Healthy)
+	assert.Equal(t, 1.0, pool.Health().ErrorRate)
+
+	_, err = NewPooledClient(DefaultPoolConfig()).Complete(context.Background(), "p", 10)
+	assert.ErrorIs(t, err, ErrNoHealthyClients)
+}
+
+func TestPooledClient_LeastLoaded(t *testing.T) {
+	busy := &switchableClient{block: make(chan struct{})}
+	idle := &switchableClient{}
+	pool := newTestPool(PoolConfig{Strategy: PoolLeastLoaded}, busy, idle)
+
+	// Hold a call open on the first client
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		_, err := pool.Complete(context.Background(), "p", 10)
+		assert.NoError(t, err)
+	}()
+	require.Eventually(t, func() bool { return busy.calls.Load() == 1 }, time.Second, time.Millisecond)
+
+	for i := 0; i < 5; i++ {
+		_, err := pool.Complete(context.Background(), "p", 10)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, int64(5), idle.calls.Load())
+	assert.Equal(t, 1, pool.Health().Members[0].InFlight)
+
+	close(busy.block)
+	wg.Wait()
+}
+
+func TestPooledClient_CancelledCallsDoNotCount(t *testing.T) {
+	client := &switchableClient{block: make(chan struct{})}
+	pool := newTestPool(PoolConfig{MinCalls: 1}, client)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := pool.Complete(ctx, "p", 10)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	h := pool.Health()
+	assert.Equal(t, 1, h.Healthy)
+	assert.Zero(t, h.Members[0].Calls)
+	assert.Zero(t, h.Members[0].InFlight)
+}
+
+// reportingClient reports fixed capabilities.
+type reportingClient struct {
+	switchableClient
+	caps meta.Capabilities
+}
+
+func (c *reportingClient) Capabilities() meta.Capabilities { return c.caps }
+
+func TestPooledClient_Capabilities(t *testing.T) {
+	pool := NewPooledClient(PoolConfig{},
+		PoolMember{Name: "large", Client: &reportingClient{caps: meta.Capabilities{ContextWindow: 200000, MaxOutputTokens: 8192, Logprobs: true}}},
+		PoolMember{Name: "small", Client: &reportingClient{caps: meta.Capabilities{ContextWindow: 32000, Logprobs: true}}},
+		PoolMember{Name: "unknown", Client: &switchableClient{}},
+	)
+
+	// Only the limits pass through, since the pool forwards only Complete
+	assert.Equal(t, meta.Capabilities{ContextWindow: 32000, MaxOutputTokens: 8192}, meta.CapabilitiesOf(pool))
+}
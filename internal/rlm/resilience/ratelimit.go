@@ -0,0 +1,245 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rand/recurse/internal/rlm/meta"
+)
+
+// RateLimitConfig configures a RateLimiter.
+type RateLimitConfig struct {
+	// RequestsPerSecond is the sustained request rate.
+	// Zero disables rate limiting.
+	RequestsPerSecond float64
+
+	// Burst is how many requests may start at once after an idle period.
+	// Default: RequestsPerSecond rounded up, at least 1.
+	Burst int
+
+	// MaxConcurrent caps requests in flight. Zero means no cap.
+	MaxConcurrent int
+}
+
+// Enabled reports whether the config limits anything.
+func (c RateLimitConfig) Enabled() bool {
+	return c.RequestsPerSecond > 0 || c.MaxConcurrent > 0
+}
+
+// RateLimiter is a token bucket combined with a concurrency cap. One
+// limiter shared by several callers gives them a single combined budget.
+type RateLimiter struct {
+	config RateLimitConfig
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+
+	// slots holds one entry per request in flight; nil without a cap.
+	slots chan struct{}
+
+	// Metrics
+	acquired  int64
+	waited    int64
+	cancelled int64
+}
+
+// NewRateLimiter creates a rate limiter with a full bucket.
+func NewRateLimiter(config RateLimitConfig) *RateLimiter {
+	if config.Burst <= 0 {
+		config.Burst = max(1, int(math.Ceil(config.RequestsPerSecond)))
+	}
+
+	l := &RateLimiter{
+		config: config,
+		tokens: float64(config.Burst),
+		last:   time.Now(),
+	}
+	if config.MaxConcurrent > 0 {
+		l.slots = make(chan struct{}, config.MaxConcurrent)
+	}
+	return l
+}
+
+// Acquire blocks until a request may start, or ctx is done. On success the
+// caller must call release when the request finishes.
+func (l *RateLimiter) Acquire(ctx context.Context) (release func(), err error) {
+	if err := ctx.Err(); err != nil {
+		atomic.AddInt64(&l.cancelled, 1)
+		return nil, fmt.Errorf("rate limiter: %w", err)
+	}
+
+	// Take a concurrency slot first so queued requests do not consume
+	// rate tokens they cannot use yet.
+	release = func() {}
+	if l.slots != nil {
+		select {
+		case l.slots <- struct{}{}:
+		default:
+			atomic.AddInt64(&l.waited, 1)
+			select {
+			case l.slots <- struct{}{}:
+			case <-ctx.Done():
+				atomic.AddInt64(&l.cancelled, 1)
+				return nil, fmt.Errorf("rate limiter: %w", ctx.Err())
+			}
+		}
+		var once sync.Once
+		release = func() { once.Do(func() { <-l.slots }) }
+	}
+
+	if err := l.waitToken(ctx); err != nil {
+		release()
+		atomic.AddInt64(&l.cancelled, 1)
+		return nil, err
+	}
+
+	atomic.AddInt64(&l.acquired, 1)
+	return release, nil
+}
+
+// waitToken reserves a token and sleeps until it is due. A cancelled
+// reservation is returned to the bucket.
+func (l *RateLimiter) waitToken(ctx context.Context) error {
+	if l.config.RequestsPerSecond <= 0 {
+		return nil
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens = math.Min(float64(l.config.Burst), l.tokens+now.Sub(l.last).Seconds()*l.config.RequestsPerSecond)
+	l.last = now
+	l.tokens--
+	deficit := -l.tokens
+	l.mu.Unlock()
+
+	if deficit <= 0 {
+		return nil
+	}
+
+	atomic.AddInt64(&l.waited, 1)
+	timer := time.NewTimer(time.Duration(deficit / l.config.RequestsPerSecond * float64(time.Second)))
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+		return fmt.Errorf("rate limiter: %w", ctx.Err())
+	}
+}
+
+// Metrics returns limiter statistics.
+func (l *RateLimiter) Metrics() RateLimiterMetrics {
+	m := RateLimiterMetrics{
+		Acquired:  atomic.LoadInt64(&l.acquired),
+		Waited:    atomic.LoadInt64(&l.waited),
+		Cancelled: atomic.LoadInt64(&l.cancelled),
+	}
+	if l.slots != nil {
+		m.InFlight = len(l.slots)
+	}
+	return m
+}
+
+// RateLimiterMetrics contains rate limiter statistics.
+type RateLimiterMetrics struct {
+	Acquired  int64
+	Waited    int64 // acquisitions that had to wait
+	Cancelled int64 // acquisitions abandoned because the context ended
+	InFlight  int   // requests holding a concurrency slot (0 without a cap)
+}
+
+// RateLimitedClient is a meta.LLMClient that acquires from a shared
+// RateLimiter before every call. Besides Complete it forwards image and
+// logprob completions, so wrapping a client keeps the features it reports
+// in its Capabilities.
+type RateLimitedClient struct {
+	client  meta.LLMClient
+	limiter *RateLimiter
+}
+
+// logprobsCompleter matches clients that complete with token logprobs.
+type logprobsCompleter interface {
+	CompleteWithLogprobs(ctx context.Context, prompt string, maxTokens int) (string, map[string]float64, error)
+}
+
+// NewRateLimitedClient wraps client so its calls draw from limiter.
+func NewRateLimitedClient(client meta.LLMClient, limiter *RateLimiter) *RateLimitedClient {
+	return &RateLimitedClient{client: client, limiter: limiter}
+}
+
+// Complete waits for the limiter, then calls the wrapped client.
+func (c *RateLimitedClient) Complete(ctx context.Context, prompt string, maxTokens int) (string, error) {
+	release, err := c.limiter.Acquire(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer release()
+	return c.client.Complete(ctx, prompt, maxTokens)
+}
+
+var _ meta.MultimodalClient = (*RateLimitedClient)(nil)
+
+// SupportsImages implements meta.MultimodalClient: images are accepted
+// when the wrapped client accepts them.
+func (c *RateLimitedClient) SupportsImages() bool {
+	mm, ok := c.client.(meta.MultimodalClient)
+	return ok && mm.SupportsImages()
+}
+
+// CompleteWithImages waits for the limiter, then sends the prompt and
+// images through the wrapped client.
+func (c *RateLimitedClient) CompleteWithImages(ctx context.Context, prompt string, images []meta.Image, maxTokens int) (string, error) {
+	mm, ok := c.client.(meta.MultimodalClient)
+	if !ok {
+		return "", errors.New("rate-limited client does not accept images")
+	}
+	release, err := c.limiter.Acquire(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer release()
+	return mm.CompleteWithImages(ctx, prompt, images, maxTokens)
+}
+
+// CompleteWithLogprobs waits for the limiter, then completes with logprobs
+// through the wrapped client.
+func (c *RateLimitedClient) CompleteWithLogprobs(ctx context.Context, prompt string, maxTokens int) (string, map[string]float64, error) {
+	lc, ok := c.client.(logprobsCompleter)
+	if !ok {
+		return "", nil, errors.New("rate-limited client does not report logprobs")
+	}
+	release, err := c.limiter.Acquire(ctx)
+	if err != nil {
+		return "", nil, err
+	}
+	defer release()
+	return lc.CompleteWithLogprobs(ctx, prompt, maxTokens)
+}
+
+// Capabilities implements meta.CapabilityReporter with the wrapped
+// client's limits, and its images and logprobs support when the client
+// has the methods the wrapper forwards them to.
+func (c *RateLimitedClient) Capabilities() meta.Capabilities {
+	inner := meta.CapabilitiesOf(c.client)
+	caps := inner.CompletionOnly()
+	_, images := c.client.(meta.MultimodalClient)
+	_, logprobs := c.client.(logprobsCompleter)
+	caps.Images = inner.Images && images
+	caps.Logprobs = inner.Logprobs && logprobs
+	return caps
+}
+
+// Limiter returns the shared limiter.
+func (c *RateLimitedClient) Limiter() *RateLimiter {
+	return c.limiter
+}
@@ -0,0 +1,191 @@
+package resilience
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rand/recurse/internal/rlm/meta"
+)
+
+// countingClient is a fast LLM client that tracks concurrent calls.
+type countingClient struct {
+	delay    time.Duration
+	calls    int64
+	inFlight int64
+	peak     int64
+}
+
+func (c *countingClient) Complete(ctx context.Context, prompt string, maxTokens int) (string, error) {
+	atomic.AddInt64(&c.calls, 1)
+	n := atomic.AddInt64(&c.inFlight, 1)
+	defer atomic.AddInt64(&c.inFlight, -1)
+	for {
+		peak := atomic.LoadInt64(&c.peak)
+		if n <= peak || atomic.CompareAndSwapInt64(&c.peak, peak, n) {
+			break
+		}
+	}
+	time.Sleep(c.delay)
+	return "ok", nil
+}
+
+func TestRateLimitConfig_Enabled(t *testing.T) {
+	assert.False(t, RateLimitConfig{}.Enabled())
+	assert.True(t, RateLimitConfig{RequestsPerSecond: 1}.Enabled())
+	assert.True(t, RateLimitConfig{MaxConcurrent: 1}.Enabled())
+}
+
+func TestRateLimitedClient_CapsThroughput(t *testing.T) {
+	client := &countingClient{}
+	limiter := NewRateLimiter(RateLimitConfig{RequestsPerSecond: 50, Burst: 5})
+	limited := NewRateLimitedClient(client, limiter)
+
+	// 5 burst + 15 paced at 50/s should take about 300ms.
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := limited.Complete(context.Background(), "p", 10)
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	assert.Equal(t, int64(20), atomic.LoadInt64(&client.calls))
+	assert.GreaterOrEqual(t, elapsed, 250*time.Millisecond)
+	assert.Less(t, elapsed, 2*time.Second)
+
+	m := limiter.Metrics()
+	assert.Equal(t, int64(20), m.Acquired)
+	assert.GreaterOrEqual(t, m.Waited, int64(15))
+}
+
+func TestRateLimitedClient_CapsConcurrency(t *testing.T) {
+	client := &countingClient{delay: 20 * time.Millisecond}
+	limited := NewRateLimitedClient(client, NewRateLimiter(RateLimitConfig{MaxConcurrent: 3}))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 12; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := limited.Complete(context.Background(), "p", 10)
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int64(12), atomic.LoadInt64(&client.calls))
+	assert.LessOrEqual(t, atomic.LoadInt64(&client.peak), int64(3))
+	assert.Equal(t, 0, limited.Limiter().Metrics().InFlight)
+}
+
+func TestRateLimiter_WaitRespectsCancellation(t *testing.T) {
+	limiter := NewRateLimiter(RateLimitConfig{RequestsPerSecond: 1, Burst: 1})
+
+	release, err := limiter.Acquire(context.Background())
+	require.NoError(t, err)
+	release()
+
+	// The bucket is empty; the next token is a second away.
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err = limiter.Acquire(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+	assert.Equal(t, int64(1), limiter.Metrics().Cancelled)
+
+	// The cancelled reservation was returned, so the wait is not doubled.
+	limiter.mu.Lock()
+	assert.Greater(t, limiter.tokens, -0.5)
+	limiter.mu.Unlock()
+}
+
+func TestRateLimiter_SlotWaitRespectsCancellation(t *testing.T) {
+	limiter := NewRateLimiter(RateLimitConfig{MaxConcurrent: 1})
+
+	release, err := limiter.Acquire(context.Background())
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		_, err := limiter.Acquire(ctx)
+		done <- err
+	}()
+	cancel()
+
+	select {
+	case err := <-done:
+		assert.ErrorIs(t, err, context.Canceled)
+	case <-time.After(time.Second):
+		t.Fatal("waiter did not return after cancellation")
+	}
+
+	// Releasing twice is harmless and frees the only slot.
+	release()
+	release()
+	release2, err := limiter.Acquire(context.Background())
+	require.NoError(t, err)
+	release2()
+}
+
+func TestRateLimitedClient_Capabilities(t *testing.T) {
+	inner := &reportingClient{caps: meta.Capabilities{ContextWindow: 16000, MaxOutputTokens: 1024, Images: true, Logprobs: true}}
+	client := NewRateLimitedClient(inner, NewRateLimiter(RateLimitConfig{MaxConcurrent: 1}))
+	assert.Equal(t, meta.Capabilities{ContextWindow: 16000, MaxOutputTokens: 1024}, meta.CapabilitiesOf(client),
+		"images are not reported for a client that cannot send them")
+
+	_, ok := meta.AcceptsImages(client)
+	assert.False(t, ok)
+}
+
+// featureClient sends images and reports logprobs.
+type featureClient struct {
+	countingClient
+	images int
+}
+
+func (c *featureClient) SupportsImages() bool { return true }
+
+func (c *featureClient) CompleteWithImages(ctx context.Context, prompt string, images []meta.Image, maxTokens int) (string, error) {
+	c.images += len(images)
+	return c.Complete(ctx, prompt, maxTokens)
+}
+
+func (c *featureClient) CompleteWithLogprobs(ctx context.Context, prompt string, maxTokens int) (string, map[string]float64, error) {
+	resp, err := c.Complete(ctx, prompt, maxTokens)
+	return resp, map[string]float64{"yes": -0.1}, err
+}
+
+func TestRateLimitedClient_ForwardsImagesAndLogprobs(t *testing.T) {
+	inner := &featureClient{}
+	limiter := NewRateLimiter(RateLimitConfig{MaxConcurrent: 1})
+	client := NewRateLimitedClient(inner, limiter)
+
+	caps := meta.CapabilitiesOf(client)
+	assert.True(t, caps.Images)
+	assert.True(t, caps.Logprobs)
+
+	mm, ok := meta.AcceptsImages(client)
+	require.True(t, ok)
+	_, err := mm.CompleteWithImages(context.Background(), "describe", []meta.Image{{Name: "a.png"}}, 100)
+	require.NoError(t, err)
+	assert.Equal(t, 1, inner.images)
+
+	_, logprobs, err := client.CompleteWithLogprobs(context.Background(), "true?", 1)
+	require.NoError(t, err)
+	assert.Contains(t, logprobs, "yes")
+
+	assert.Equal(t, int64(2), limiter.Metrics().Acquired, "both calls drew from the limiter")
+}
@@ -2,9 +2,13 @@ package rlm
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"net/http"
 	"sync"
 	"time"
 
@@ -16,8 +20,12 @@ import (
 	"github.com/rand/recurse/internal/rlm/compress"
 	"github.com/rand/recurse/internal/rlm/hallucination"
 	"github.com/rand/recurse/internal/rlm/meta"
+	"github.com/rand/recurse/internal/rlm/observability"
 	"github.com/rand/recurse/internal/rlm/orchestrator"
+	"github.com/rand/recurse/internal/rlm/redact"
 	"github.com/rand/recurse/internal/rlm/repl"
+	"github.com/rand/recurse/internal/rlm/resilience"
+	"github.com/rand/recurse/internal/rlm/verify"
 	"github.com/rand/recurse/internal/tui/components/dialogs/rlmtrace"
 )
 
@@ -32,6 +40,10 @@ type ServiceConfig struct {
 	// Meta-controller configuration
 	Meta meta.Config
 
+	// DecisionPolicy, if set, makes orchestration decisions in place of the
+	// LLM-backed meta-controller, e.g. deterministic rule-based routing.
+	DecisionPolicy meta.DecisionPolicy
+
 	// MaxTraceEvents is the maximum trace events to retain (in-memory provider only).
 	MaxTraceEvents int
 
@@ -42,6 +54,22 @@ type ServiceConfig struct {
 	// When set, trace events persist across sessions.
 	TracePath string
 
+	// TraceBuffer buffers writes to TracePath so a slow disk does not stall
+	// executions. The zero value writes each event synchronously.
+	TraceBuffer TraceBufferConfig
+
+	// TraceBackend stores trace events, taking precedence over TraceInStore
+	// and TracePath. The caller owns it and must close it if needed.
+	TraceBackend TraceBackend
+
+	// TraceInStore stores trace events in the hypergraph store, so
+	// instances sharing a store share their traces.
+	TraceInStore bool
+
+	// TraceSampling samples routine trace events on high-volume executions.
+	// The zero value keeps every event.
+	TraceSampling TraceSamplingConfig
+
 	// OrchestratorEnabled enables RLM orchestration for prompt pre-processing.
 	// When enabled, every prompt is analyzed by RLM before being sent to the main agent.
 	OrchestratorEnabled bool
@@ -71,9 +99,149 @@ type ServiceConfig struct {
 	// Default: 8000 tokens.
 	CompressionThreshold int
 
+	// MemoryPressure compresses contexts sooner and evicts the least used
+	// externalized contexts while the process's RSS is above a threshold.
+	// Disabled by default.
+	MemoryPressure MemoryPressureConfig
+
+	// FastPathMaxTokens is the prompt size at or below which a prompt with
+	// no context skips orchestrator analysis and classification and goes
+	// straight to Direct mode. Zero disables the fast path.
+	// Default: 32 tokens.
+	FastPathMaxTokens int
+
+	// ContextRanking limits externalized contexts to those most relevant
+	// to the prompt. The zero value keeps every context.
+	ContextRanking RankConfig
+
+	// ContextRefresh reloads externalized files that change on disk during
+	// an RLM execution. A zero Interval disables it.
+	ContextRefresh RefreshConfig
+
+	// ContextProvider fetches the context an RLM execution asks for with
+	// request_context(), e.g. a FileContextProvider or MemoryContextProvider.
+	// Nil leaves such requests unfulfilled.
+	ContextProvider ContextProvider
+
+	// SystemPromptBudget is the token budget of the RLM system prompt,
+	// which is trimmed to fit, examples first. Zero leaves it whole.
+	SystemPromptBudget int
+
+	// ConversationStore persists the complete model conversation of each
+	// RLM execution, redacted, keyed by execution ID, e.g. a
+	// FileConversationStore or HypergraphConversationStore. Nil persists
+	// nothing.
+	ConversationStore ConversationStore
+
+	// TierLimits caps the LLM calls in flight to each model tier, shared by
+	// REPL sub-calls and orchestration. Nil leaves every tier uncapped.
+	TierLimits meta.TierLimits
+
+	// VerificationSolver checks the constraints of sub-calls that ask to
+	// verify their code, e.g. verify.Z3Backend. Nil uses CPMpy.
+	VerificationSolver verify.SolverBackend
+
 	// Hallucination configures hallucination detection.
 	// [SPEC-08.19-22] Output verification integration.
 	Hallucination HallucinationConfig
+
+	// SubCallSampling verifies a fraction of REPL sub-call results against
+	// their context with the hallucination detector, flagging results that
+	// disagree with it. The zero value verifies none.
+	SubCallSampling VerificationSampling
+
+	// Metrics configures per-execution metrics collection.
+	Metrics MetricsConfig
+
+	// RateLimit caps the combined rate and concurrency of LLM calls made
+	// by every subsystem. The zero value disables it.
+	RateLimit resilience.RateLimitConfig
+
+	// Clients gives individual subsystems their own LLM client, e.g. a
+	// Fast model for classification while reasoning runs on a Powerful
+	// one. Subsystems left unset use the client passed to NewService.
+	Clients SubsystemClients
+
+	// Redaction replaces secrets with placeholders wherever text leaves
+	// the process: prompts sent to models, trace events, checkpoints and
+	// stored memory. Contexts loaded into the live REPL keep their values.
+	// The zero value, the default, disables it.
+	Redaction redact.Config
+
+	// AnswerCache reuses the results of tasks repeated with unchanged
+	// context. The zero value disables it.
+	AnswerCache AnswerCacheConfig
+
+	// SubtaskCache reuses the results of identical decomposition
+	// subtasks. The zero value disables it.
+	SubtaskCache SubtaskCacheConfig
+
+	// ShutdownTimeout is how long Stop waits for in-flight executions to
+	// finish before cancelling them. Zero cancels them immediately.
+	// Default: 30 seconds.
+	ShutdownTimeout time.Duration
+}
+
+// SubsystemClients are the LLM clients of the service's subsystems. A nil
+// client uses the shared client passed to NewService.
+type SubsystemClients struct {
+	// Decisions makes the meta-controller's orchestration decisions.
+	Decisions meta.LLMClient
+
+	// Reasoning answers tasks in the main controller.
+	Reasoning meta.LLMClient
+
+	// Classification is the wrapper's LLM classification fallback. Unset,
+	// it is the client given to Wrapper.SetLLMClient.
+	Classification meta.LLMClient
+
+	// SubCalls serves llm_call() from REPL code.
+	SubCalls meta.LLMClient
+
+	// Synthesis writes session summaries.
+	Synthesis meta.LLMClient
+
+	// Verification estimates probabilities for hallucination detection.
+	Verification meta.LLMClient
+}
+
+// resolve returns the clients with unset ones replaced by shared, each
+// drawing from limiter and redacting its prompts with redactor when they
+// are not nil. Classification is left unset.
+func (c SubsystemClients) resolve(shared meta.LLMClient, limiter *resilience.RateLimiter, redactor *redact.Redactor) SubsystemClients {
+	pick := func(client meta.LLMClient) meta.LLMClient {
+		if client == nil {
+			client = shared
+		}
+		if limiter != nil {
+			client = resilience.NewRateLimitedClient(client, limiter)
+		}
+		return redactPrompts(client, redactor)
+	}
+	resolved := SubsystemClients{
+		Decisions:    pick(c.Decisions),
+		Reasoning:    pick(c.Reasoning),
+		SubCalls:     pick(c.SubCalls),
+		Synthesis:    pick(c.Synthesis),
+		Verification: pick(c.Verification),
+	}
+	if c.Classification != nil {
+		resolved.Classification = pick(c.Classification)
+	}
+	return resolved
+}
+
+// MetricsConfig configures per-execution metrics for Prometheus export.
+type MetricsConfig struct {
+	// Enabled turns on metrics collection. Disabled by default.
+	Enabled bool
+
+	// Registry receives the metrics. Defaults to a new registry.
+	Registry *observability.Registry
+
+	// ModelTier labels executions whose client does not route by tier,
+	// so no routed tier is known. Default: "main".
+	ModelTier string
 }
 
 // HallucinationConfig configures hallucination detection for the RLM service.
@@ -96,6 +264,14 @@ type HallucinationConfig struct {
 	DetectorConfig hallucination.DetectorConfig
 }
 
+// DefaultShutdownTimeout is how long Stop waits for in-flight executions
+// by default.
+const DefaultShutdownTimeout = 30 * time.Second
+
+// shutdownCancelGrace is how long Stop waits for cancelled executions to
+// return before tearing down under them.
+const shutdownCancelGrace = 5 * time.Second
+
 // DefaultServiceConfig returns sensible defaults for the RLM service.
 func DefaultServiceConfig() ServiceConfig {
 	return ServiceConfig{
@@ -115,7 +291,10 @@ func DefaultServiceConfig() ServiceConfig {
 		},
 		CompressionEnabled:   true, // Enable compression by default
 		CompressionThreshold: 8000, // Compress when context exceeds 8K tokens
+		FastPathMaxTokens:    DefaultFastPathMaxTokens,
 		Compression:          compress.DefaultManagerConfig(),
+		ContextRefresh:       DefaultRefreshConfig(),
+		ShutdownTimeout:      DefaultShutdownTimeout,
 		Hallucination: HallucinationConfig{
 			OutputVerificationEnabled: false, // Disabled by default for performance
 			TraceAuditEnabled:         false, // Disabled by default for performance
@@ -142,10 +321,14 @@ type Service struct {
 	lifecycle       *evolution.LifecycleManager
 	metaEvolution   *evolution.MetaEvolutionManager // meta-evolution for schema adaptation
 	tracer          traceRecorder
+	recorder        TraceRecorder            // tracer, behind any redactor and sampler
 	persistentTrace *PersistentTraceProvider // non-nil if using persistent storage
 	orchestrator    *Orchestrator            // prompt pre-processing
 	subCallRouter   *SubCallRouter           // routes REPL llm_call() to models
 	wrapper         *Wrapper                 // RLM wrapper for context externalization
+	replMgr         *repl.Manager            // REPL shared by the orchestrator and wrapper
+	memHandler      *MemoryCallbackHandler   // serves the REPL's memory_* functions
+	codeVerifier    *replVerifier            // verifies sub-call code in a REPL of its own
 	checkpoint      *checkpoint.Manager      // session state persistence
 	learner         *learning.Engine         // continuous learning engine
 	budgetMgr       *budget.Manager          // budget tracking and enforcement
@@ -155,14 +338,37 @@ type Service struct {
 	outputVerifier *hallucination.OutputVerifier // verifies agent responses
 	traceAuditor   *hallucination.TraceAuditor   // audits reasoning traces [SPEC-08.23-26]
 
+	// Per-execution metrics (nil unless enabled)
+	metrics *observability.ExecutionMetrics
+
+	// Secret redaction for externalized context, traces and checkpoints
+	// (nil unless enabled)
+	redactor *redact.Redactor
+
+	// Shared LLM rate limiter (nil unless enabled)
+	rateLimiter *resilience.RateLimiter
+
+	// Results of earlier executions (nil unless enabled)
+	answerCache *AnswerCache
+
+	// What the shared LLM client supports
+	capabilities meta.Capabilities
+
 	// Configuration
 	config ServiceConfig
 
 	// State
 	running   bool
+	closed    bool // subsystems torn down by Stop
 	startTime time.Time
 	sessionID string // current session ID for learning
 
+	// In-flight executions, drained by Stop
+	inflight    sync.WaitGroup
+	executions  map[uint64]*inflightExecution
+	nextExecID  uint64
+	cancelGrace time.Duration
+
 	// Statistics
 	stats ServiceStats
 }
@@ -175,12 +381,31 @@ type ServiceStats struct {
 	TasksCompleted  int
 	SessionsEnded   int
 	Errors          int
+
+	// AnswerCacheHits counts executions served from the answer cache,
+	// which are included in TotalExecutions.
+	AnswerCacheHits int
 }
 
 // NewService creates a new unified RLM service.
 func NewService(llmClient meta.LLMClient, config ServiceConfig) (*Service, error) {
-	// Create hypergraph store
+	// Share one rate limiter across all subsystems by wrapping their
+	// clients before they are handed out
+	var limiter *resilience.RateLimiter
+	if config.RateLimit.Enabled() {
+		limiter = resilience.NewRateLimiter(config.RateLimit)
+	}
+	redactor, err := redact.New(config.Redaction)
+	if err != nil {
+		return nil, fmt.Errorf("create redactor: %w", err)
+	}
+	clients := config.Clients.resolve(llmClient, limiter, redactor)
+
+	// Create hypergraph store, redacting what it persists
 	storeOpts := hypergraph.Options{}
+	if redactor != nil {
+		storeOpts.ContentFilter = redactor.Redact
+	}
 	if config.StorePath != "" {
 		storeOpts.Path = config.StorePath
 		storeOpts.CreateIfNotExists = true
@@ -192,19 +417,37 @@ func NewService(llmClient meta.LLMClient, config ServiceConfig) (*Service, error
 	}
 
 	// Create meta-controller
-	metaCtrl := meta.NewController(llmClient, config.Meta)
+	metaCtrl := meta.NewController(GuardCalls(clients.Decisions), config.Meta)
 
-	// Create RLM controller with the main LLM client for response generation
-	controller := NewController(metaCtrl, llmClient, store, config.Controller)
+	// Create RLM controller with the reasoning client for response generation
+	var policy meta.DecisionPolicy = metaCtrl
+	if config.DecisionPolicy != nil {
+		policy = config.DecisionPolicy
+	}
+	controller := NewController(policy, clients.Reasoning, store, config.Controller)
+	if config.SubtaskCache.Enabled {
+		subtaskCache, err := NewSubtaskCache(context.Background(), config.SubtaskCache)
+		if err != nil {
+			store.Close()
+			return nil, fmt.Errorf("create subtask cache: %w", err)
+		}
+		controller.SetSubtaskCache(subtaskCache)
+	}
 
-	// Create trace provider (persistent or in-memory)
+	// Create trace provider (configured backend, hypergraph, persistent or in-memory)
 	var tracer traceRecorder
 	var persistentTrace *PersistentTraceProvider
 
-	if config.TracePath != "" {
+	switch {
+	case config.TraceBackend != nil:
+		tracer = config.TraceBackend
+	case config.TraceInStore:
+		tracer = NewHypergraphTraceBackend(store)
+	case config.TracePath != "":
 		// Use persistent trace provider with file-based database
 		pt, err := NewPersistentTraceProvider(PersistentTraceConfig{
-			Path: config.TracePath,
+			Path:   config.TracePath,
+			Buffer: config.TraceBuffer,
 		})
 		if err != nil {
 			store.Close()
@@ -212,11 +455,18 @@ func NewService(llmClient meta.LLMClient, config ServiceConfig) (*Service, error
 		}
 		tracer = pt
 		persistentTrace = pt
-	} else {
+	default:
 		// Use in-memory trace provider
 		tracer = NewTraceProvider(config.MaxTraceEvents)
 	}
-	controller.SetTracer(tracer)
+	var recorder TraceRecorder = tracer
+	if redactor != nil {
+		recorder = NewTraceRedactor(recorder, redactor)
+	}
+	if config.TraceSampling.Enabled() {
+		recorder = NewTraceSampler(recorder, config.TraceSampling)
+	}
+	controller.SetTracer(recorder)
 
 	// Create lifecycle manager
 	lifecycle, err := evolution.NewLifecycleManager(store, config.Lifecycle)
@@ -243,7 +493,7 @@ func NewService(llmClient meta.LLMClient, config ServiceConfig) (*Service, error
 	lifecycle.SetMetaEvolution(metaEvolution)
 
 	// Create session synthesizer for session summaries [SPEC-09.01]
-	synthesizer := NewLLMSynthesizer(llmClient)
+	synthesizer := NewLLMSynthesizer(clients.Synthesis)
 	lifecycle.SetSessionSynthesizer(synthesizer)
 
 	// Wire outcome recorder to hybrid search for meta-evolution tracking
@@ -256,16 +506,31 @@ func NewService(llmClient meta.LLMClient, config ServiceConfig) (*Service, error
 		ContextEnabled: true, // Enable context externalization by default
 	})
 
+	// Create metrics collector if enabled
+	var metrics *observability.ExecutionMetrics
+	if config.Metrics.Enabled {
+		registry := config.Metrics.Registry
+		if registry == nil {
+			registry = observability.NewRegistry()
+		}
+		metrics = observability.NewExecutionMetrics(registry)
+	}
+
 	// Create sub-call router for REPL llm_call() support
 	subCallRouter := NewSubCallRouter(SubCallConfig{
-		Client:      llmClient,
+		Client:      clients.SubCalls,
 		Models:      meta.DefaultModels(),
 		MaxDepth:    config.Controller.MaxRecursionDepth,
 		BudgetLimit: config.Controller.MaxTokenBudget,
+		Metrics:     metrics,
+		TierLimits:  config.TierLimits,
+		Sampling:    config.SubCallSampling,
 	})
+	controller.Core().SetTierLimiter(subCallRouter.TierLimiter())
 
 	// Create checkpoint manager for session state persistence
 	checkpointMgr := checkpoint.NewManager(config.Checkpoint)
+	checkpointMgr.SetRedactor(redactor)
 
 	// Create learning engine if enabled
 	var learner *learning.Engine
@@ -286,31 +551,39 @@ func NewService(llmClient meta.LLMClient, config ServiceConfig) (*Service, error
 	var outputVerifier *hallucination.OutputVerifier
 	var traceAuditor *hallucination.TraceAuditor
 
-	// Create detector if any hallucination detection is enabled
-	if config.Hallucination.OutputVerificationEnabled || config.Hallucination.TraceAuditEnabled {
-		// Create backend using the LLM client for probability estimation
-		// [SPEC-08.27] Uses self-verification backend by default
-		backendCfg := hallucination.DefaultBackendConfig()
-		backend, err := hallucination.NewBackend(backendCfg, llmClient)
-		if err != nil {
-			slog.Warn("failed to create hallucination backend, disabling detection", "error", err)
-		} else {
-			// Create detector with configured settings
-			detector = hallucination.NewDetector(backend, config.Hallucination.DetectorConfig)
-
-			// Create output verifier if enabled [SPEC-08.19-22]
-			if config.Hallucination.OutputVerificationEnabled {
-				verifierConfig := config.Hallucination.OutputVerifierConfig
-				verifierConfig.Enabled = true
-				outputVerifier = hallucination.NewOutputVerifier(detector, verifierConfig)
-			}
+	// Create the detector even with detection disabled, so sub-call
+	// results can be verified when an execution's profile asks for it.
+	// Create backend using the LLM client for probability estimation
+	// [SPEC-08.27] Uses self-verification backend by default
+	backendCfg := hallucination.DefaultBackendConfig()
+	backend, err := hallucination.NewBackend(backendCfg, guardVerifierCalls(clients.Verification))
+	if err != nil {
+		slog.Warn("failed to create hallucination backend, disabling detection", "error", err)
+	} else {
+		// Create detector with configured settings
+		detector = hallucination.NewDetector(backend, config.Hallucination.DetectorConfig)
+
+		// Create output verifier if enabled [SPEC-08.19-22]
+		if config.Hallucination.OutputVerificationEnabled {
+			verifierConfig := config.Hallucination.OutputVerifierConfig
+			verifierConfig.Enabled = true
+			outputVerifier = hallucination.NewOutputVerifier(detector, verifierConfig)
+		}
 
-			// Create trace auditor if enabled [SPEC-08.23-26]
-			if config.Hallucination.TraceAuditEnabled {
-				auditorConfig := config.Hallucination.TraceAuditorConfig
-				auditorConfig.Enabled = true
-				traceAuditor = hallucination.NewTraceAuditor(detector, auditorConfig)
-			}
+		// Verify sub-call results chosen by sampling or the execution
+		// profile, and score high-assurance answers for escalation, with a
+		// verifier of their own
+		verifierConfig := config.Hallucination.OutputVerifierConfig
+		verifierConfig.Enabled = true
+		profileVerifier := hallucination.NewOutputVerifier(detector, verifierConfig)
+		subCallRouter.SetResultVerifier(NewOutputResultVerifier(profileVerifier))
+		controller.SetAssuranceScorer(NewVerifierScorer(profileVerifier))
+
+		// Create trace auditor if enabled [SPEC-08.23-26]
+		if config.Hallucination.TraceAuditEnabled {
+			auditorConfig := config.Hallucination.TraceAuditorConfig
+			auditorConfig.Enabled = true
+			traceAuditor = hallucination.NewTraceAuditor(detector, auditorConfig)
 		}
 	}
 
@@ -320,22 +593,43 @@ func NewService(llmClient meta.LLMClient, config ServiceConfig) (*Service, error
 		lifecycle:       lifecycle,
 		metaEvolution:   metaEvolution,
 		tracer:          tracer,
+		recorder:        recorder,
 		persistentTrace: persistentTrace,
 		orchestrator:    orchestrator,
 		subCallRouter:   subCallRouter,
 		checkpoint:      checkpointMgr,
 		learner:         learner,
 		budgetMgr:       budgetMgr,
+		metrics:         metrics,
+		rateLimiter:     limiter,
+		redactor:        redactor,
 		detector:        detector,
 		outputVerifier:  outputVerifier,
 		traceAuditor:    traceAuditor,
 		config:          config,
+		executions:      make(map[uint64]*inflightExecution),
+		cancelGrace:     shutdownCancelGrace,
+		capabilities:    meta.CapabilitiesOf(llmClient),
+	}
+	slog.Debug("LLM client capabilities", "capabilities", svc.capabilities)
+	if config.AnswerCache.Enabled {
+		svc.answerCache = NewAnswerCache(config.AnswerCache)
 	}
 
 	// Create RLM wrapper for context externalization with compression
 	wrapperConfig := DefaultWrapperConfig()
 	wrapperConfig.CompressionEnabled = config.CompressionEnabled
 	wrapperConfig.CompressionThreshold = config.CompressionThreshold
+	wrapperConfig.MemoryPressure = config.MemoryPressure
+	wrapperConfig.FastPathMaxTokens = config.FastPathMaxTokens
+	wrapperConfig.ContextRanking = config.ContextRanking
+	wrapperConfig.ContextRefresh = config.ContextRefresh
+	wrapperConfig.Redactor = redactor
+	wrapperConfig.Tracer = recorder
+	wrapperConfig.ContextProvider = config.ContextProvider
+	wrapperConfig.SystemPromptBudget = config.SystemPromptBudget
+	wrapperConfig.ConversationStore = config.ConversationStore
+	wrapperConfig.ClassifierClient = clients.Classification
 	if config.CompressionEnabled {
 		wrapperConfig.CompressionConfig = &config.Compression
 	}
@@ -350,6 +644,11 @@ func NewService(llmClient meta.LLMClient, config ServiceConfig) (*Service, error
 		controller.Core().SetTraceAuditor(traceAuditor)
 	}
 
+	// Judge answers for escalation by their hallucination risk when verified
+	if outputVerifier != nil {
+		controller.SetAnswerScorer(NewVerifierScorer(outputVerifier))
+	}
+
 	// Wire up lifecycle callbacks for statistics
 	lifecycle.OnTaskComplete(func(result *evolution.LifecycleResult) {
 		svc.mu.Lock()
@@ -382,13 +681,38 @@ func NewService(llmClient meta.LLMClient, config ServiceConfig) (*Service, error
 	return svc, nil
 }
 
+// guardedLogprobsClient is GuardCalls for a client that also completes
+// with logprobs, which the verifier prefers.
+type guardedLogprobsClient struct {
+	meta.LLMClient
+	logprobs hallucination.LogprobsCompleter
+}
+
+func (c *guardedLogprobsClient) CompleteWithLogprobs(ctx context.Context, prompt string, maxTokens int) (string, map[string]float64, error) {
+	if err := CostGuardFrom(ctx).Allow(0); err != nil {
+		return "", nil, err
+	}
+	return c.logprobs.CompleteWithLogprobs(ctx, prompt, maxTokens)
+}
+
+// guardVerifierCalls counts the hallucination verifier's calls against the
+// execution's call limit, keeping logprob completion if the client has it
+// and its capabilities do not rule logprobs out. Without it the verifier
+// falls back to sampling.
+func guardVerifierCalls(client meta.LLMClient) hallucination.LLMCompleter {
+	if lc, ok := client.(hallucination.LogprobsCompleter); ok && meta.CapabilitiesOf(client).Logprobs {
+		return &guardedLogprobsClient{LLMClient: GuardCalls(client), logprobs: lc}
+	}
+	return GuardCalls(client)
+}
+
 // Start starts the RLM service, including background tasks.
 func (s *Service) Start(ctx context.Context) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	if s.running {
-		return fmt.Errorf("service already running")
+		return ErrServiceRunning
 	}
 
 	s.running = true
@@ -435,16 +759,24 @@ func (s *Service) Start(ctx context.Context) error {
 	return nil
 }
 
-// Stop stops the RLM service and releases resources.
+// Stop stops the RLM service and releases resources. It stops accepting
+// executions, waits up to ShutdownTimeout for those in flight to finish,
+// and cancels the rest with ErrServiceStopping, recording them in the
+// checkpoint as interrupted, before tearing down subsystems.
 func (s *Service) Stop() error {
 	s.mu.Lock()
-	defer s.mu.Unlock()
-
 	if !s.running {
+		s.mu.Unlock()
 		return nil
 	}
-
 	s.running = false
+	s.mu.Unlock()
+
+	s.drain()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
 
 	// End budget session first (needs store to persist)
 	if s.budgetMgr != nil {
@@ -460,6 +792,12 @@ func (s *Service) Stop() error {
 		s.learner.Stop()
 	}
 
+	if s.codeVerifier != nil {
+		if err := s.codeVerifier.Stop(); err != nil {
+			slog.Warn("Failed to stop verification REPL", "error", err)
+		}
+	}
+
 	// Stop checkpoint manager and persist stats for next session
 	if s.checkpoint != nil {
 		// Save final stats before stopping (clear task/RLM state since those are session-specific)
@@ -502,15 +840,143 @@ func (s *Service) Stop() error {
 	return nil
 }
 
+// inflightExecution is an execution Stop waits for.
+type inflightExecution struct {
+	id      uint64
+	task    string
+	started time.Time
+	cancel  context.CancelCauseFunc
+
+	interrupted sync.Once
+}
+
+// trackExecution registers an execution of task, returning its context,
+// which Stop cancels with ErrServiceStopping. s.mu must be held.
+func (s *Service) trackExecution(ctx context.Context, task string) (context.Context, *inflightExecution) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	s.nextExecID++
+	exec := &inflightExecution{
+		id:      s.nextExecID,
+		task:    task,
+		started: time.Now(),
+		cancel:  cancel,
+	}
+	s.executions[exec.id] = exec
+	s.inflight.Add(1)
+	return ctx, exec
+}
+
+// untrackExecution marks exec finished.
+func (s *Service) untrackExecution(exec *inflightExecution) {
+	s.mu.Lock()
+	delete(s.executions, exec.id)
+	s.mu.Unlock()
+	exec.cancel(nil)
+	s.inflight.Done()
+}
+
+// drain waits for in-flight executions: up to ShutdownTimeout for them to
+// finish, then, having cancelled the rest, up to the cancel grace for them
+// to return. Executions still running after that are recorded as
+// interrupted and abandoned.
+func (s *Service) drain() {
+	if waitTimeout(&s.inflight, s.config.ShutdownTimeout) {
+		return
+	}
+
+	s.mu.Lock()
+	for _, exec := range s.executions {
+		exec.cancel(ErrServiceStopping)
+	}
+	s.mu.Unlock()
+	if waitTimeout(&s.inflight, s.cancelGrace) {
+		return
+	}
+
+	s.mu.Lock()
+	stragglers := make([]*inflightExecution, 0, len(s.executions))
+	for _, exec := range s.executions {
+		stragglers = append(stragglers, exec)
+	}
+	s.mu.Unlock()
+	for _, exec := range stragglers {
+		slog.Warn("Abandoning execution that ignored shutdown", "task", exec.task, "started", exec.started)
+		s.interrupt(exec, nil)
+	}
+}
+
+// interrupt records exec in the checkpoint as cut short by shutdown, with
+// its partial result if it returned one. Only the first call records.
+func (s *Service) interrupt(exec *inflightExecution, result *ExecutionResult) {
+	if s.checkpoint == nil {
+		return
+	}
+	exec.interrupted.Do(func() {
+		state := checkpoint.InterruptedExecution{
+			Task:          exec.task,
+			StartedAt:     exec.started,
+			InterruptedAt: time.Now(),
+		}
+		if result != nil {
+			state.PartialResponse = result.Response
+			state.Tokens = result.TotalTokens
+		}
+		s.checkpoint.RecordInterrupted(state)
+	})
+}
+
+// waitTimeout waits for wg for up to timeout, reporting whether it
+// finished.
+func waitTimeout(wg *sync.WaitGroup, timeout time.Duration) bool {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	if timeout <= 0 {
+		select {
+		case <-done:
+			return true
+		default:
+			return false
+		}
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
+
 // Execute runs an RLM task and returns the result.
 func (s *Service) Execute(ctx context.Context, task string) (*ExecutionResult, error) {
 	s.mu.Lock()
 	if !s.running {
 		s.mu.Unlock()
-		return nil, fmt.Errorf("service not running")
+		return nil, ErrServiceNotRunning
 	}
 	execNum := s.stats.TotalExecutions + 1
+	ctx, exec := s.trackExecution(ctx, task)
 	s.mu.Unlock()
+	defer s.untrackExecution(exec)
+
+	// Serve a repeated task from the answer cache
+	var answerKey AnswerKey
+	if s.answerCache != nil {
+		answerKey = s.answerKey(ctx, task)
+		if !isForceRefresh(ctx) {
+			if cached, ok := s.answerCache.Get(answerKey); ok {
+				s.mu.Lock()
+				s.stats.TotalExecutions++
+				s.stats.AnswerCacheHits++
+				s.mu.Unlock()
+				return cached, nil
+			}
+		}
+	}
 
 	// Update checkpoint before execution
 	if s.checkpoint != nil {
@@ -524,7 +990,21 @@ func (s *Service) Execute(ctx context.Context, task string) (*ExecutionResult, e
 		})
 	}
 
+	// Link facts recorded during the execution, through RecordFact or the
+	// REPL's memory_add_fact, to the execution's node. The memory handler
+	// takes each callback's execution from the context of the REPL call.
+	ctx, _ = WithExecution(ctx, s.store, task)
+
 	result, err := s.controller.Execute(ctx, task)
+	if err != nil && errors.Is(context.Cause(ctx), ErrServiceStopping) {
+		err = fmt.Errorf("%w: %w", ErrServiceStopping, err)
+		s.interrupt(exec, result)
+	}
+	if s.answerCache != nil && err == nil && result != nil && result.Error == "" && !result.Partial {
+		// Keyed on the context the execution left loaded, which the next
+		// identical task sees
+		s.answerCache.Put(s.answerKey(ctx, task), result)
+	}
 
 	s.mu.Lock()
 	s.stats.TotalExecutions++
@@ -536,18 +1016,54 @@ func (s *Service) Execute(ctx context.Context, task string) (*ExecutionResult, e
 		s.stats.Errors++
 	}
 	stats := s.stats
+	closed := s.closed
 	s.mu.Unlock()
 
+	if s.metrics != nil {
+		s.recordMetrics(result, err)
+	}
+
+	// Stop abandoned this execution and closed the store under it
+	if closed {
+		return result, err
+	}
+
+	if result != nil && s.config.Controller.StoreDecisions {
+		if result.Task == "" {
+			result.Task = task
+		}
+		if _, err := orchestrator.RecordExecutionSummary(ctx, s.store, result); err != nil {
+			slog.Warn("Failed to record execution summary", "error", err)
+		}
+	}
+
 	// Track tokens in budget manager
 	if s.budgetMgr != nil && result != nil {
-		// Estimate input/output split (controller doesn't provide this breakdown)
-		// Use a rough 2:1 input:output ratio as approximation
-		inputTokens := int64(result.TotalTokens * 2 / 3)
-		outputTokens := int64(result.TotalTokens / 3)
+		// Use the reconciled split when the execution accounted its calls,
+		// else approximate it with a rough 2:1 input:output ratio
+		inputTokens := int64(result.InputTokens)
+		outputTokens := int64(result.OutputTokens)
+		if inputTokens+outputTokens == 0 {
+			inputTokens = int64(result.TotalTokens * 2 / 3)
+			outputTokens = int64(result.TotalTokens / 3)
+		}
+		// AddTokens takes per-token prices
 		// TODO: Get actual costs from model pricing
-		inputCost := float64(inputTokens) * 0.000003  // $3/M tokens estimate
-		outputCost := float64(outputTokens) * 0.000015 // $15/M tokens estimate
-		s.budgetMgr.AddTokens(inputTokens, outputTokens, 0, inputCost, outputCost)
+		const inputCostPerToken = 0.000003   // $3/M tokens estimate
+		const outputCostPerToken = 0.000015 // $15/M tokens estimate
+		s.budgetMgr.AddTokens(inputTokens, outputTokens, 0, inputCostPerToken, outputCostPerToken)
+
+		// Tokens above are what was sent; record what compression kept out
+		if c := result.Compression; c != nil && c.SavedTokens() > 0 {
+			s.budgetMgr.AddCompressionSavings(int64(c.SavedTokens()), c.Ratio())
+		}
+	}
+
+	// Count tokens toward the session's summary milestones
+	if result != nil {
+		if _, err := s.lifecycle.RecordTokens(ctx, result.TotalTokens); err != nil {
+			slog.Warn("Failed to summarize session milestone", "error", err)
+		}
 	}
 
 	// Update checkpoint after execution with current stats
@@ -565,11 +1081,25 @@ func (s *Service) Execute(ctx context.Context, task string) (*ExecutionResult, e
 	return result, err
 }
 
+// FindSimilarExecutions returns up to limit past executions whose task
+// shares keywords with task, most similar first, with the actions, model
+// tiers, outcome and token cost of each. Executions are recorded when
+// Controller.StoreDecisions is set.
+func (s *Service) FindSimilarExecutions(ctx context.Context, task string, limit int) ([]ExecutionSummary, error) {
+	return orchestrator.FindSimilarExecutions(ctx, s.store, task, limit)
+}
+
 // TaskComplete signals task completion to the lifecycle manager.
 func (s *Service) TaskComplete(ctx context.Context) (*evolution.LifecycleResult, error) {
 	return s.lifecycle.TaskComplete(ctx)
 }
 
+// RecordSessionTokens counts tokens spent outside Execute toward the
+// session's summary milestones.
+func (s *Service) RecordSessionTokens(ctx context.Context, tokens int) (*evolution.LifecycleResult, error) {
+	return s.lifecycle.RecordTokens(ctx, tokens)
+}
+
 // SessionEnd signals session end to the lifecycle manager.
 func (s *Service) SessionEnd(ctx context.Context) (*evolution.LifecycleResult, error) {
 	return s.lifecycle.SessionEnd(ctx)
@@ -608,6 +1138,73 @@ func (s *Service) Stats() ServiceStats {
 	return s.stats
 }
 
+// modelTier names the tier executions run on, for metrics.
+func (s *Service) modelTier() string {
+	if s.config.Metrics.ModelTier == "" {
+		return "main"
+	}
+	return s.config.Metrics.ModelTier
+}
+
+// recordMetrics updates the execution metrics for one Execute call.
+func (s *Service) recordMetrics(result *ExecutionResult, err error) {
+	sample := observability.ExecutionSample{
+		Mode:      "direct",
+		Action:    "unknown",
+		ModelTier: s.modelTier(),
+	}
+	if result != nil {
+		if result.Mode != "" {
+			sample.Mode = result.Mode
+		}
+		if result.Action != "" {
+			sample.Action = result.Action
+		}
+		if result.Tier != "" {
+			sample.ModelTier = result.Tier
+		}
+		sample.Duration = result.Duration
+		sample.Tokens = result.TotalTokens
+		sample.Iterations = result.Decisions
+	}
+	if err != nil {
+		sample.ErrorCategory = s.controller.core.ClassifyError(err).String()
+	}
+	s.metrics.RecordExecution(sample)
+}
+
+// Metrics returns the execution metrics collector, or nil if metrics are
+// disabled.
+func (s *Service) Metrics() *observability.ExecutionMetrics {
+	return s.metrics
+}
+
+// MetricsHandler returns an http.Handler serving the metrics in the
+// Prometheus text format, or nil if metrics are disabled.
+func (s *Service) MetricsHandler() http.Handler {
+	if s.metrics == nil {
+		return nil
+	}
+	return s.metrics.Registry().Handler()
+}
+
+// RateLimiter returns the shared LLM rate limiter, or nil if rate limiting
+// is disabled.
+func (s *Service) RateLimiter() *resilience.RateLimiter {
+	return s.rateLimiter
+}
+
+// Capabilities returns what the LLM client passed to NewService supports.
+func (s *Service) Capabilities() meta.Capabilities {
+	return s.capabilities
+}
+
+// Redactor returns the redactor applied to prompts, traces, checkpoints
+// and stored memory, or nil if redaction is disabled.
+func (s *Service) Redactor() *redact.Redactor {
+	return s.redactor
+}
+
 // IsRunning returns whether the service is running.
 func (s *Service) IsRunning() bool {
 	s.mu.RLock()
@@ -693,27 +1290,63 @@ func (s *Service) QueryMemory(ctx context.Context, query string, limit int) ([]*
 	})
 }
 
-// RecordFact records a fact in the hypergraph memory.
+// RecordFact records a fact in the hypergraph memory. Within an execution
+// (see WithExecution) the fact is linked to the execution's node with a
+// derived_from relation. Recording is safe to retry; see recordKey.
 func (s *Service) RecordFact(ctx context.Context, content string, confidence float64) error {
 	node := hypergraph.NewNode(hypergraph.NodeTypeFact, content)
 	node.Confidence = confidence
 	node.Tier = hypergraph.TierTask
-	return s.store.CreateNode(ctx, node)
+	node.IdempotencyKey = s.recordKey(ctx, node.Type, content)
+	if err := s.store.CreateNode(ctx, node); err != nil {
+		return err
+	}
+	return LinkProvenance(ctx, node.ID)
 }
 
 // RecordExperience records an experience in the hypergraph memory.
+// Recording is safe to retry; see recordKey.
 func (s *Service) RecordExperience(ctx context.Context, content string) error {
 	node := hypergraph.NewNode(hypergraph.NodeTypeExperience, content)
 	node.Tier = hypergraph.TierTask
+	node.IdempotencyKey = s.recordKey(ctx, node.Type, content)
 	return s.store.CreateNode(ctx, node)
 }
 
+// recordKey returns the idempotency key for recording content as a node of
+// nodeType. Recording the same content again within the same execution, or
+// outside one within the same session, is taken for a retry and returns
+// the node already recorded. Outside both it returns "", so every call
+// creates a node.
+func (s *Service) recordKey(ctx context.Context, nodeType hypergraph.NodeType, content string) string {
+	s.mu.RLock()
+	scope := "session:" + s.sessionID
+	if s.sessionID == "" {
+		scope = ""
+	}
+	s.mu.RUnlock()
+	if exec := ExecutionFrom(ctx); exec != nil {
+		if execID, err := exec.NodeID(ctx); err == nil {
+			scope = "execution:" + execID
+		}
+	}
+	if scope == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(string(nodeType) + "\x00" + scope + "\x00" + content))
+	return "record:" + hex.EncodeToString(sum[:16])
+}
+
 // SessionContext contains context for resuming a session.
 // [SPEC-09.08] Returned by ResumeSession for "What was I working on?" queries.
 type SessionContext struct {
 	// PreviousSession is the most recent session summary
 	PreviousSession *evolution.SessionSummary `json:"previous_session,omitempty"`
 
+	// InProgress is set when PreviousSession is a milestone summary of a
+	// session that has not ended
+	InProgress bool `json:"in_progress,omitempty"`
+
 	// UnfinishedWork describes what wasn't completed
 	UnfinishedWork string `json:"unfinished_work,omitempty"`
 
@@ -729,64 +1362,88 @@ type SessionContext struct {
 
 // ResumeSession queries for session resumption context.
 // [SPEC-09.08] Answers "What was I working on?" with actionable context.
+// A session still in progress is represented by its latest milestone
+// summary.
 func (s *Service) ResumeSession(ctx context.Context) (*SessionContext, error) {
-	// Query recent session_summary nodes from longterm tier
-	summaries, err := s.store.ListNodes(ctx, hypergraph.NodeFilter{
+	// Query recent session and milestone summary nodes from longterm tier
+	nodes, err := s.store.ListNodes(ctx, hypergraph.NodeFilter{
 		Types:    []hypergraph.NodeType{hypergraph.NodeTypeExperience},
-		Subtypes: []string{"session_summary"},
+		Subtypes: []string{"session_summary", evolution.MilestoneSummarySubtype},
 		Tiers:    []hypergraph.Tier{hypergraph.TierLongterm},
-		Limit:    5,
+		Limit:    20,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("query session summaries: %w", err)
 	}
 
+	// Keep the most recent summary of each session
+	var summaries []*evolution.SessionSummary
+	var inProgress []bool
+	seen := make(map[string]bool)
+	for _, node := range nodes {
+		summary := parseSessionSummary(node)
+		if summary.SessionID != "" {
+			if seen[summary.SessionID] {
+				continue
+			}
+			seen[summary.SessionID] = true
+		}
+		summaries = append(summaries, summary)
+		inProgress = append(inProgress, node.Subtype == evolution.MilestoneSummarySubtype)
+		if len(summaries) == 5 {
+			break
+		}
+	}
+
 	if len(summaries) == 0 {
 		return nil, nil // No previous sessions
 	}
 
-	// Parse the most recent summary
 	latest := summaries[0]
-	var latestSummary evolution.SessionSummary
-	if len(latest.Metadata) > 0 {
-		if err := json.Unmarshal(latest.Metadata, &latestSummary); err != nil {
-			// Fallback to basic info from content
-			latestSummary.Summary = latest.Content
-		}
-	} else {
-		latestSummary.Summary = latest.Content
-	}
-
 	result := &SessionContext{
-		PreviousSession:  &latestSummary,
-		UnfinishedWork:   latestSummary.UnfinishedWork,
-		RecommendedStart: latestSummary.NextSteps,
-		ActiveFiles:      latestSummary.ActiveFiles,
+		PreviousSession:  latest,
+		InProgress:       inProgress[0],
+		UnfinishedWork:   latest.UnfinishedWork,
+		RecommendedStart: latest.NextSteps,
+		ActiveFiles:      latest.ActiveFiles,
 	}
 
 	// Include additional recent sessions if available
 	if len(summaries) > 1 {
-		for _, node := range summaries[1:] {
-			var summary evolution.SessionSummary
-			if len(node.Metadata) > 0 {
-				if err := json.Unmarshal(node.Metadata, &summary); err != nil {
-					summary.Summary = node.Content
-				}
-			} else {
-				summary.Summary = node.Content
-			}
-			result.RecentSessions = append(result.RecentSessions, &summary)
-		}
+		result.RecentSessions = summaries[1:]
 	}
 
 	return result, nil
 }
 
+// parseSessionSummary decodes a summary node, falling back to its content.
+func parseSessionSummary(node *hypergraph.Node) *evolution.SessionSummary {
+	var summary evolution.SessionSummary
+	if len(node.Metadata) > 0 {
+		if err := json.Unmarshal(node.Metadata, &summary); err != nil {
+			summary = evolution.SessionSummary{Summary: node.Content}
+		}
+	} else {
+		summary.Summary = node.Content
+	}
+	return &summary
+}
+
 // GetTraceEvents returns recent trace events.
 func (s *Service) GetTraceEvents(limit int) ([]rlmtrace.TraceEvent, error) {
 	return s.tracer.GetEvents(limit)
 }
 
+// TraceBufferMetrics returns statistics for the persistent trace buffer,
+// including events dropped on overflow. It is zero unless TracePath and
+// TraceBuffer are set.
+func (s *Service) TraceBufferMetrics() TraceBufferMetrics {
+	if s.persistentTrace == nil {
+		return TraceBufferMetrics{}
+	}
+	return s.persistentTrace.BufferMetrics()
+}
+
 // GetTraceStats returns trace statistics.
 func (s *Service) GetTraceStats() rlmtrace.TraceStats {
 	return s.tracer.Stats()
@@ -799,19 +1456,7 @@ func (s *Service) ClearTrace() error {
 
 // RecordTraceEvent records a trace event from external callers (e.g., rlm_execute tool).
 func (s *Service) RecordTraceEvent(event rlmtrace.TraceEvent) error {
-	internalEvent := TraceEvent{
-		ID:        event.ID,
-		Type:      string(event.Type),
-		Action:    event.Action,
-		Details:   event.Details,
-		Tokens:    event.Tokens,
-		Duration:  event.Duration,
-		Timestamp: event.Timestamp,
-		Depth:     event.Depth,
-		ParentID:  event.ParentID,
-		Status:    event.Status,
-	}
-	return s.tracer.RecordEvent(internalEvent)
+	return s.recorder.RecordEvent(fromRLMTraceEvent(event))
 }
 
 // Orchestrator returns the RLM orchestrator for prompt pre-processing.
@@ -829,16 +1474,23 @@ func (s *Service) SetREPLManager(replMgr *repl.Manager) {
 		s.wrapper.SetREPLManager(replMgr)
 	}
 
-	// Wire up the callback handler so Python's llm_call() works
+	// Wire up the callback handler so Python's llm_call() works, and let
+	// sub-calls that ask for it verify their code. Verification needs a
+	// REPL of its own: the calls arrive while replMgr is busy executing.
 	if replMgr != nil && s.subCallRouter != nil {
 		handler := NewREPLCallbackHandler(s.subCallRouter)
 		replMgr.SetCallbackHandler(handler)
+		if s.codeVerifier == nil {
+			s.codeVerifier = newREPLVerifier(s.config.VerificationSolver)
+			s.subCallRouter.SetVerifier(s.codeVerifier)
+		}
 	}
 
 	// Wire up the memory handler so Python's memory_* functions work
+	s.replMgr, s.memHandler = replMgr, nil
 	if replMgr != nil && s.store != nil {
-		memHandler := NewMemoryCallbackHandler(s.store)
-		replMgr.SetMemoryHandler(memHandler)
+		s.memHandler = NewMemoryCallbackHandler(s.store)
+		replMgr.SetMemoryHandler(s.memHandler)
 	}
 }
 
@@ -895,6 +1547,8 @@ func (s *Service) SubCallStats() SubCallStats {
 // AnalyzePrompt performs RLM analysis on a user prompt.
 // This should be called before sending the prompt to the main agent.
 // If learning is enabled, it enhances the prompt with learned knowledge.
+// Prompts within FastPathMaxTokens and without further context get a DIRECT
+// decision without consulting the meta-controller.
 func (s *Service) AnalyzePrompt(ctx context.Context, prompt string, contextTokens int) (*AnalysisResult, error) {
 	// First enhance with learned knowledge if available
 	enhancedPrompt := prompt
@@ -912,6 +1566,21 @@ func (s *Service) AnalyzePrompt(ctx context.Context, prompt string, contextToken
 		}, nil
 	}
 
+	// Skip the meta-controller for trivial prompts. Callers may count the
+	// prompt itself in contextTokens, so only context beyond it counts.
+	promptTokens := estimateTokens(prompt)
+	if s.orchestrator.IsEnabled() && contextTokens <= promptTokens &&
+		isFastPath(promptTokens, 0, s.config.FastPathMaxTokens) {
+		return &AnalysisResult{
+			OriginalPrompt: prompt,
+			EnhancedPrompt: enhancedPrompt,
+			Decision: &meta.Decision{
+				Action:    meta.ActionDirect,
+				Reasoning: fastPathReason,
+			},
+		}, nil
+	}
+
 	// Run orchestrator analysis on the enhanced prompt
 	result, err := s.orchestrator.Analyze(ctx, enhancedPrompt, contextTokens)
 	if err != nil {
@@ -1171,7 +1840,7 @@ func (s *Service) OutputVerifier() *hallucination.OutputVerifier {
 }
 
 // Detector returns the hallucination detector.
-// Returns nil if hallucination detection is not enabled.
+// Returns nil if its verification backend could not be created.
 func (s *Service) Detector() *hallucination.Detector {
 	return s.detector
 }
@@ -1221,11 +1890,14 @@ type wrapperContextPreparer struct {
 // PrepareContext implements orchestrator.ContextPreparer.
 func (w *wrapperContextPreparer) PrepareContext(ctx context.Context, task string, contextTokens int) (*orchestrator.PreparedContext, error) {
 	if w.wrapper == nil {
-		return nil, fmt.Errorf("wrapper not initialized")
+		return nil, fmt.Errorf("wrapper %w", ErrNotConfigured)
 	}
 
-	// Call the wrapper's PrepareContext with task as prompt and no additional contexts
-	prepared, err := w.wrapper.PrepareContext(ctx, task, nil)
+	// Prepare the task as prompt with the conversation that led to it, if
+	// the caller gave one, and no additional contexts
+	prepared, err := w.wrapper.PrepareContextWithOptions(ctx, task, nil, PrepareOptions{
+		History: ConversationHistoryFrom(ctx),
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -1233,7 +1905,9 @@ func (w *wrapperContextPreparer) PrepareContext(ctx context.Context, task string
 	// Convert PreparedPrompt to orchestrator.PreparedContext
 	result := &orchestrator.PreparedContext{
 		Mode:         string(prepared.Mode),
-		SystemPrompt: prepared.SystemPrompt,
+		SystemPrompt:  prepared.SystemPrompt,
+		Compression:   prepared.Compression,
+		ContextDigest: ContextDigest(prepared.LoadedContext),
 	}
 
 	// Check if context was externalized (loaded into REPL)
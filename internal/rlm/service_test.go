@@ -2,6 +2,9 @@ package rlm
 
 import (
 	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
 	"testing"
 	"time"
 
@@ -11,6 +14,11 @@ import (
 	"github.com/rand/recurse/internal/memory/evolution"
 	"github.com/rand/recurse/internal/memory/hypergraph"
 	"github.com/rand/recurse/internal/rlm/checkpoint"
+	"github.com/rand/recurse/internal/rlm/hallucination"
+	"github.com/rand/recurse/internal/rlm/meta"
+	"github.com/rand/recurse/internal/rlm/observability"
+	"github.com/rand/recurse/internal/rlm/orchestrator"
+	"github.com/rand/recurse/internal/rlm/resilience"
 )
 
 func TestDefaultServiceConfig(t *testing.T) {
@@ -36,6 +44,52 @@ func TestNewService(t *testing.T) {
 	assert.NotNil(t, svc.tracer)
 }
 
+func TestNewService_SubtaskCache(t *testing.T) {
+	svc, err := NewService(&mockLLMClient{}, DefaultServiceConfig())
+	require.NoError(t, err)
+	assert.Nil(t, svc.Controller().Core().SubtaskCache(), "off by default")
+	svc.Stop()
+
+	cfg := DefaultServiceConfig()
+	cfg.SubtaskCache = SubtaskCacheConfig{Enabled: true, MaxEntries: 10}
+	svc, err = NewService(&mockLLMClient{}, cfg)
+	require.NoError(t, err)
+	defer svc.Stop()
+	assert.NotNil(t, svc.Controller().Core().SubtaskCache())
+}
+
+// logprobsMockClient completes with logprobs, which its reported
+// capabilities may rule out.
+type logprobsMockClient struct {
+	mockLLMClient
+	caps meta.Capabilities
+}
+
+func (c *logprobsMockClient) Capabilities() meta.Capabilities { return c.caps }
+
+func (c *logprobsMockClient) CompleteWithLogprobs(ctx context.Context, prompt string, maxTokens int) (string, map[string]float64, error) {
+	return "YES", map[string]float64{"YES": 0}, nil
+}
+
+func TestNewService_DegradesToClientCapabilities(t *testing.T) {
+	client := &logprobsMockClient{caps: meta.Capabilities{ContextWindow: 8000, MaxOutputTokens: 512}}
+	cfg := DefaultServiceConfig()
+	cfg.Hallucination.OutputVerificationEnabled = true
+
+	svc, err := NewService(client, cfg)
+	require.NoError(t, err)
+	defer svc.Stop()
+	assert.Equal(t, client.caps, svc.Capabilities())
+
+	// The verifier samples instead of asking for logprobs
+	_, ok := guardVerifierCalls(client).(hallucination.LogprobsCompleter)
+	assert.False(t, ok)
+
+	client.caps.Logprobs = true
+	_, ok = guardVerifierCalls(client).(hallucination.LogprobsCompleter)
+	assert.True(t, ok)
+}
+
 func TestService_StartStop(t *testing.T) {
 	client := &mockLLMClient{}
 	cfg := DefaultServiceConfig()
@@ -92,6 +146,212 @@ func TestService_Execute(t *testing.T) {
 	assert.Greater(t, stats.TotalTokens, 0)
 }
 
+// factRecordingClient records a fact, as a tool would mid-execution,
+// from inside the first LLM call it answers.
+type factRecordingClient struct {
+	mockLLMClient
+	svc      *Service
+	fact     string
+	recorded bool
+}
+
+func (c *factRecordingClient) Complete(ctx context.Context, prompt string, maxTokens int) (string, error) {
+	if !c.recorded {
+		c.recorded = true
+		if err := c.svc.RecordFact(ctx, c.fact, 0.9); err != nil {
+			return "", err
+		}
+	}
+	return c.mockLLMClient.Complete(ctx, prompt, maxTokens)
+}
+
+func TestService_RecordFact_LinksExecutionProvenance(t *testing.T) {
+	client := &factRecordingClient{fact: "The auth flow refreshes tokens hourly"}
+	cfg := DefaultServiceConfig()
+	cfg.Lifecycle.IdleInterval = 0
+
+	svc, err := NewService(client, cfg)
+	require.NoError(t, err)
+	defer svc.Stop()
+	client.svc = svc
+
+	ctx := context.Background()
+	require.NoError(t, svc.Start(ctx))
+	_, err = svc.Execute(ctx, "Explain the auth flow")
+	require.NoError(t, err)
+
+	facts, err := svc.Store().ListNodes(ctx, hypergraph.NodeFilter{Types: []hypergraph.NodeType{hypergraph.NodeTypeFact}})
+	require.NoError(t, err)
+	require.Len(t, facts, 1)
+
+	connected, err := svc.Store().GetConnected(ctx, facts[0].ID, hypergraph.TraversalOptions{MaxDepth: 1, IncludeEdge: true})
+	require.NoError(t, err)
+	require.NotEmpty(t, connected)
+	execNode := connected[0].Node
+	assert.Equal(t, hypergraph.NodeTypeDecision, execNode.Type)
+	assert.Equal(t, "rlm_execution", execNode.Subtype)
+	assert.Equal(t, "Explain the auth flow", execNode.Content)
+	assert.Equal(t, DerivedFromLabel, connected[0].Edge.Label)
+	assert.Equal(t, hypergraph.RoleObject, connected[0].Role)
+
+	// The stored execution is the node the fact was linked to
+	executions, err := svc.Store().ListNodes(ctx, hypergraph.NodeFilter{Types: []hypergraph.NodeType{hypergraph.NodeTypeDecision}, Subtypes: []string{"rlm_execution"}})
+	require.NoError(t, err)
+	require.Len(t, executions, 1)
+	assert.Equal(t, execNode.ID, executions[0].ID)
+	assert.Contains(t, string(executions[0].Metadata), "response")
+
+	// From the execution, the provenance graph leads back to the fact
+	derived, err := svc.Store().GetConnected(ctx, execNode.ID, hypergraph.TraversalOptions{Direction: hypergraph.TraverseIncoming, MaxDepth: 1})
+	require.NoError(t, err)
+	require.Len(t, derived, 1)
+	assert.Equal(t, facts[0].ID, derived[0].Node.ID)
+}
+
+func TestService_RecordFact_OutsideExecution(t *testing.T) {
+	svc, err := NewService(&mockLLMClient{}, DefaultServiceConfig())
+	require.NoError(t, err)
+	defer svc.Stop()
+
+	ctx := context.Background()
+	require.NoError(t, svc.RecordFact(ctx, "Unattributed fact", 0.8))
+
+	edges, err := svc.Store().ListHyperedges(ctx, hypergraph.HyperedgeFilter{})
+	require.NoError(t, err)
+	assert.Empty(t, edges)
+}
+
+func TestService_KeylessStubFallback(t *testing.T) {
+	t.Setenv("OPENROUTER_API_KEY", "")
+
+	client, err := meta.NewOpenRouterClientOrFallback(meta.OpenRouterConfig{
+		KeylessFallback: meta.KeylessStub,
+	})
+	require.NoError(t, err)
+	stub, ok := client.(*meta.StubClient)
+	require.True(t, ok, "expected the stub client, got %T", client)
+
+	cfg := DefaultServiceConfig()
+	cfg.Controller.StoreDecisions = false
+	cfg.Lifecycle.IdleInterval = 0
+
+	svc, err := NewService(client, cfg)
+	require.NoError(t, err)
+	defer svc.Stop()
+
+	ctx := context.Background()
+	require.NoError(t, svc.Start(ctx))
+
+	result, err := svc.Execute(ctx, "Summarize the release notes")
+	require.NoError(t, err)
+	assert.Contains(t, result.Response, "[stub]")
+	assert.Positive(t, stub.Calls())
+}
+
+func TestService_Metrics(t *testing.T) {
+	client := &mockLLMClient{
+		responses: []string{`{"action": "DIRECT", "reasoning": "Test task"}`},
+	}
+	cfg := DefaultServiceConfig()
+	cfg.Controller.StoreDecisions = false
+	cfg.Lifecycle.IdleInterval = 0
+	cfg.Metrics = MetricsConfig{Enabled: true, ModelTier: "balanced"}
+
+	svc, err := NewService(client, cfg)
+	require.NoError(t, err)
+	defer svc.Stop()
+
+	ctx := context.Background()
+	require.NoError(t, svc.Start(ctx))
+
+	result, err := svc.Execute(ctx, "Test task")
+	require.NoError(t, err)
+	assert.Equal(t, "DIRECT", result.Action)
+	assert.Equal(t, "direct", result.Mode)
+	assert.Equal(t, 1, result.Decisions)
+
+	reg := svc.Metrics().Registry()
+	labels := observability.Labels{"mode": "direct", "action": "DIRECT", "tier": "balanced"}
+	assert.Equal(t, int64(1), reg.Counter(observability.MetricExecutionsTotal, labels).Value())
+	tokens := reg.Histogram(observability.MetricExecutionTokens, labels, observability.TokenBuckets).Snapshot()
+	assert.Equal(t, float64(result.TotalTokens), tokens.Sum)
+
+	rec := httptest.NewRecorder()
+	svc.MetricsHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Contains(t, rec.Body.String(), `rlm_actions_total{action="DIRECT"} 1`)
+}
+
+// routedMockClient is a mockLLMClient that reports routing every
+// completion to tier.
+type routedMockClient struct {
+	mockLLMClient
+	tier meta.ModelTier
+}
+
+func (c *routedMockClient) Complete(ctx context.Context, prompt string, maxTokens int) (string, error) {
+	meta.RecordRoute(ctx, &meta.RouteDecision{Tier: c.tier, Model: "routed-model"})
+	return c.mockLLMClient.Complete(ctx, prompt, maxTokens)
+}
+
+func TestService_MetricsLabelRoutedTier(t *testing.T) {
+	client := &routedMockClient{
+		mockLLMClient: mockLLMClient{responses: []string{`{"action": "DIRECT", "reasoning": "Test task"}`}},
+		tier:          meta.TierPowerful,
+	}
+	cfg := DefaultServiceConfig()
+	cfg.Controller.StoreDecisions = false
+	cfg.Lifecycle.IdleInterval = 0
+	cfg.Metrics = MetricsConfig{Enabled: true}
+
+	svc, err := NewService(client, cfg)
+	require.NoError(t, err)
+	defer svc.Stop()
+
+	ctx := context.Background()
+	require.NoError(t, svc.Start(ctx))
+
+	result, err := svc.Execute(ctx, "Test task")
+	require.NoError(t, err)
+	assert.Equal(t, "powerful", result.Tier)
+
+	labels := observability.Labels{"mode": "direct", "action": "DIRECT", "tier": "powerful"}
+	assert.Equal(t, int64(1), svc.Metrics().Registry().Counter(observability.MetricExecutionsTotal, labels).Value())
+}
+
+func TestService_MetricsDisabled(t *testing.T) {
+	svc, err := NewService(&mockLLMClient{}, DefaultServiceConfig())
+	require.NoError(t, err)
+	defer svc.Stop()
+
+	assert.Nil(t, svc.Metrics())
+	assert.Nil(t, svc.MetricsHandler())
+}
+
+func TestService_RateLimitSharedAcrossSubsystems(t *testing.T) {
+	client := &mockLLMClient{
+		responses: []string{`{"action": "DIRECT", "reasoning": "Test task"}`},
+	}
+	cfg := DefaultServiceConfig()
+	cfg.Controller.StoreDecisions = false
+	cfg.Lifecycle.IdleInterval = 0
+	cfg.RateLimit = resilience.RateLimitConfig{RequestsPerSecond: 1000, MaxConcurrent: 4}
+
+	svc, err := NewService(client, cfg)
+	require.NoError(t, err)
+	defer svc.Stop()
+
+	ctx := context.Background()
+	require.NoError(t, svc.Start(ctx))
+
+	_, err = svc.Execute(ctx, "Test task")
+	require.NoError(t, err)
+
+	// The meta-controller decision and the direct answer both went through
+	// the one limiter.
+	require.NotNil(t, svc.RateLimiter())
+	assert.GreaterOrEqual(t, svc.RateLimiter().Metrics().Acquired, int64(2))
+}
+
 func TestService_ExecuteNotRunning(t *testing.T) {
 	client := &mockLLMClient{}
 	cfg := DefaultServiceConfig()
@@ -339,6 +599,46 @@ func TestService_RecordFact(t *testing.T) {
 	assert.Equal(t, 0.85, nodes[0].Confidence)
 }
 
+func TestService_RecordFact_RetryIsIdempotent(t *testing.T) {
+	svc, err := NewService(&mockLLMClient{}, DefaultServiceConfig())
+	require.NoError(t, err)
+	defer svc.Stop()
+
+	countFacts := func() int {
+		nodes, err := svc.Store().ListNodes(context.Background(), hypergraph.NodeFilter{Types: []hypergraph.NodeType{hypergraph.NodeTypeFact}})
+		require.NoError(t, err)
+		return len(nodes)
+	}
+
+	// Without an execution or session, nothing scopes a retry.
+	ctx := context.Background()
+	require.NoError(t, svc.RecordFact(ctx, "Unscoped fact", 0.8))
+	require.NoError(t, svc.RecordFact(ctx, "Unscoped fact", 0.8))
+	assert.Equal(t, 2, countFacts())
+
+	// Within an execution, a retry returns the recorded fact.
+	execCtx, _ := WithExecution(ctx, svc.Store(), "task")
+	require.NoError(t, svc.RecordFact(execCtx, "Scoped fact", 0.8))
+	require.NoError(t, svc.RecordFact(execCtx, "Scoped fact", 0.8))
+	assert.Equal(t, 3, countFacts())
+
+	// Another execution records the same content afresh.
+	otherCtx, _ := WithExecution(ctx, svc.Store(), "other task")
+	require.NoError(t, svc.RecordFact(otherCtx, "Scoped fact", 0.8))
+	assert.Equal(t, 4, countFacts())
+
+	// So does a session, for calls outside an execution.
+	svc.SetSessionID("session-1")
+	require.NoError(t, svc.RecordFact(ctx, "Session fact", 0.8))
+	require.NoError(t, svc.RecordFact(ctx, "Session fact", 0.8))
+	assert.Equal(t, 5, countFacts())
+	require.NoError(t, svc.RecordExperience(ctx, "Session experience"))
+	require.NoError(t, svc.RecordExperience(ctx, "Session experience"))
+	experiences, err := svc.Store().ListNodes(ctx, hypergraph.NodeFilter{Types: []hypergraph.NodeType{hypergraph.NodeTypeExperience}})
+	require.NoError(t, err)
+	assert.Len(t, experiences, 1)
+}
+
 func TestService_RecordExperience(t *testing.T) {
 	client := &mockLLMClient{}
 	cfg := DefaultServiceConfig()
@@ -612,3 +912,447 @@ func TestService_CheckpointStatsPersistOnStop(t *testing.T) {
 	assert.Nil(t, cp.RLMState, "RLM state should be cleared on normal exit")
 	assert.NotNil(t, cp.ServiceStats, "service stats should persist across sessions")
 }
+
+// usageClient answers like mockLLMClient and reports usage for each call.
+type usageClient struct {
+	mockLLMClient
+	usage []meta.Usage
+	calls int
+}
+
+func (c *usageClient) Complete(ctx context.Context, prompt string, maxTokens int) (string, error) {
+	response, err := c.mockLLMClient.Complete(ctx, prompt, maxTokens)
+	if c.calls < len(c.usage) {
+		meta.RecordUsage(ctx, c.usage[c.calls])
+	}
+	c.calls++
+	return response, err
+}
+
+// newTestService starts a service answering with client, configured by
+// configure (if non-nil) on top of defaults that skip decision storage and
+// idle maintenance. It is stopped when the test ends.
+func newTestService(t *testing.T, client meta.LLMClient, configure func(*ServiceConfig)) *Service {
+	t.Helper()
+	cfg := DefaultServiceConfig()
+	cfg.Controller.StoreDecisions = false
+	cfg.Lifecycle.IdleInterval = 0
+	if configure != nil {
+		configure(&cfg)
+	}
+
+	svc, err := NewService(client, cfg)
+	require.NoError(t, err)
+	t.Cleanup(func() { svc.Stop() })
+	require.NoError(t, svc.Start(context.Background()))
+	return svc
+}
+
+// withoutOrchestrator answers tasks through the controller alone, without
+// the orchestrator or learning.
+func withoutOrchestrator(cfg *ServiceConfig) {
+	cfg.OrchestratorEnabled = false
+	cfg.LearningEnabled = false
+}
+
+func TestService_Execute_ReconcilesProviderUsage(t *testing.T) {
+	// Meta-controller decision, then the direct answer
+	client := &usageClient{
+		mockLLMClient: mockLLMClient{responses: []string{
+			`{"action": "DIRECT", "reasoning": "simple"}`,
+			"the answer",
+		}},
+		usage: []meta.Usage{
+			{PromptTokens: 300, CompletionTokens: 20},
+			{PromptTokens: 1200, CompletionTokens: 450},
+		},
+	}
+	svc := newTestService(t, client, withoutOrchestrator)
+
+	result, err := svc.Execute(context.Background(), "Test task")
+	require.NoError(t, err)
+	assert.Equal(t, "the answer", result.Response)
+
+	assert.Equal(t, 1500, result.InputTokens)
+	assert.Equal(t, 470, result.OutputTokens)
+	assert.Zero(t, result.EstimatedTokens)
+	assert.Equal(t, 1650, result.TotalTokens, "direct call tokens come from the provider")
+
+	state := svc.BudgetState()
+	assert.Equal(t, int64(1500), state.InputTokens)
+	assert.Equal(t, int64(470), state.OutputTokens)
+	assert.InDelta(t, 1500*0.000003+470*0.000015, state.TotalCost, 1e-9)
+}
+
+func TestService_Execute_EstimatesWithoutProviderUsage(t *testing.T) {
+	client := &usageClient{
+		mockLLMClient: mockLLMClient{responses: []string{
+			`{"action": "DIRECT", "reasoning": "simple"}`,
+			"the answer",
+		}},
+	}
+	svc := newTestService(t, client, withoutOrchestrator)
+
+	result, err := svc.Execute(context.Background(), "Test task")
+	require.NoError(t, err)
+
+	// Only the direct call is estimated; the decision was never counted
+	assert.Positive(t, result.InputTokens)
+	assert.Equal(t, estimateTokens("the answer"), result.OutputTokens)
+	assert.Equal(t, result.InputTokens+result.OutputTokens, result.EstimatedTokens)
+	assert.Equal(t, result.TotalTokens, result.EstimatedTokens)
+
+	state := svc.BudgetState()
+	assert.Equal(t, int64(result.InputTokens), state.InputTokens)
+	assert.Equal(t, int64(result.OutputTokens), state.OutputTokens)
+}
+
+// compressedPreparer reports that the context was compressed.
+type compressedPreparer struct {
+	compression *CompressionStats
+}
+
+func (p *compressedPreparer) PrepareContext(ctx context.Context, task string, contextTokens int) (*orchestrator.PreparedContext, error) {
+	return &orchestrator.PreparedContext{Mode: string(ModeDirecte), Compression: p.compression}, nil
+}
+
+func TestService_Execute_RecordsCompressionSavings(t *testing.T) {
+	client := &usageClient{
+		mockLLMClient: mockLLMClient{responses: []string{
+			`{"action": "DIRECT", "reasoning": "simple"}`,
+			"the answer",
+		}},
+		usage: []meta.Usage{
+			{PromptTokens: 300, CompletionTokens: 20},
+			{PromptTokens: 2500, CompletionTokens: 100},
+		},
+	}
+	svc := newTestService(t, client, withoutOrchestrator)
+	svc.controller.Core().SetContextPreparer(&compressedPreparer{
+		compression: &CompressionStats{OriginalTokens: 10000, CompressedTokens: 2500},
+	})
+
+	result, err := svc.Execute(context.Background(), "Test task")
+	require.NoError(t, err)
+	require.NotNil(t, result.Compression)
+	assert.Equal(t, 7500, result.Compression.SavedTokens())
+
+	// Spend counts the compressed input; the savings are tracked beside it
+	state := svc.BudgetState()
+	assert.Equal(t, int64(2800), state.InputTokens)
+	assert.Equal(t, int64(7500), state.CompressionSavedTokens)
+	assert.Equal(t, 1, state.CompressionCount)
+	assert.InDelta(t, 0.25, state.CompressionRatio, 0.001)
+}
+
+func TestService_AnalyzePrompt_FastPath(t *testing.T) {
+	client := &usageClient{}
+	cfg := DefaultServiceConfig()
+	cfg.Controller.StoreDecisions = false
+	cfg.Lifecycle.IdleInterval = 0
+	cfg.LearningEnabled = false
+
+	svc, err := NewService(client, cfg)
+	require.NoError(t, err)
+	defer svc.Stop()
+	ctx := context.Background()
+
+	// Callers may count the prompt itself as context.
+	prompt := "What is 2+2?"
+	result, err := svc.AnalyzePrompt(ctx, prompt, len(prompt)/4)
+	require.NoError(t, err)
+	require.NotNil(t, result.Decision)
+	assert.Equal(t, meta.ActionDirect, result.Decision.Action)
+	assert.Equal(t, "fast-path direct", result.Decision.Reasoning)
+	assert.Nil(t, result.ContextNeeds)
+	assert.Zero(t, client.calls, "meta-controller was not consulted")
+
+	prompt = "Explain how the error handling works across the storage layer, " +
+		"which functions should be refactored to return wrapped errors, " +
+		"and what the callers would need to change."
+	result, err = svc.AnalyzePrompt(ctx, prompt, 0)
+	require.NoError(t, err)
+	require.NotNil(t, result.Decision)
+	assert.NotEqual(t, "fast-path direct", result.Decision.Reasoning)
+	assert.NotNil(t, result.ContextNeeds)
+	assert.Positive(t, client.calls)
+
+	// A tiny prompt with real context still gets full analysis.
+	calls := client.calls
+	_, err = svc.AnalyzePrompt(ctx, "Fix this", 5000)
+	require.NoError(t, err)
+	assert.Greater(t, client.calls, calls)
+}
+
+// routingMockClient is a mockLLMClient that routes every completion to
+// model.
+type routingMockClient struct {
+	mockLLMClient
+	model string
+}
+
+func (c *routingMockClient) Complete(ctx context.Context, prompt string, maxTokens int) (string, error) {
+	meta.RecordRoute(ctx, &meta.RouteDecision{Tier: meta.TierBalanced, Model: c.model})
+	return c.mockLLMClient.Complete(ctx, prompt, maxTokens)
+}
+
+func TestService_FindSimilarExecutions(t *testing.T) {
+	cfg := DefaultServiceConfig()
+	cfg.Lifecycle.IdleInterval = 0
+	cfg.Metrics.ModelTier = "main"
+
+	svc, err := NewService(&routingMockClient{model: "sonnet"}, cfg)
+	require.NoError(t, err)
+	defer svc.Stop()
+
+	ctx := context.Background()
+	require.NoError(t, svc.Start(ctx))
+
+	tasks := []string{
+		"Summarize the release notes for version two",
+		"Explain the authentication token refresh flow",
+		"Refactor the database connection pool settings",
+		"Explain the authentication session flow in the gateway",
+	}
+	for _, task := range tasks {
+		_, err := svc.Execute(ctx, task)
+		require.NoError(t, err)
+	}
+
+	found, err := svc.FindSimilarExecutions(ctx, "How does the authentication token refresh work?", 10)
+	require.NoError(t, err)
+	require.Len(t, found, 2)
+	assert.Equal(t, tasks[1], found[0].Task)
+	assert.Equal(t, tasks[3], found[1].Task)
+	assert.Greater(t, found[0].Similarity, found[1].Similarity)
+
+	best := found[0]
+	assert.True(t, best.Success)
+	assert.Equal(t, []string{"DIRECT"}, best.Actions)
+	assert.Equal(t, []string{"sonnet"}, best.Models, "the routed model, not the metrics label")
+	assert.Equal(t, "direct", best.Mode)
+	assert.Positive(t, best.Tokens)
+
+	// The summary is recorded on the execution's node, one per execution
+	executions, err := svc.Store().ListNodes(ctx, hypergraph.NodeFilter{Types: []hypergraph.NodeType{hypergraph.NodeTypeDecision}})
+	require.NoError(t, err)
+	assert.Len(t, executions, len(tasks))
+
+	found, err = svc.FindSimilarExecutions(ctx, "authentication", 1)
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+
+	found, err = svc.FindSimilarExecutions(ctx, "Plot the quarterly revenue", 10)
+	require.NoError(t, err)
+	assert.Empty(t, found)
+}
+
+// blockingClient holds each LLM call until release is closed. Unless it
+// ignores cancellation, a call also returns when its context is done.
+type blockingClient struct {
+	mockLLMClient
+	started     chan struct{}
+	release     chan struct{}
+	ignoreCtx   bool
+	startedOnce sync.Once
+}
+
+func newBlockingClient() *blockingClient {
+	return &blockingClient{started: make(chan struct{}), release: make(chan struct{})}
+}
+
+func (c *blockingClient) Complete(ctx context.Context, prompt string, maxTokens int) (string, error) {
+	c.startedOnce.Do(func() { close(c.started) })
+	if c.ignoreCtx {
+		<-c.release
+	} else {
+		select {
+		case <-c.release:
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+	return c.mockLLMClient.Complete(ctx, prompt, maxTokens)
+}
+
+// withShutdown checkpoints to a fresh directory and waits timeout for
+// in-flight executions on Stop.
+func withShutdown(t *testing.T, timeout time.Duration) func(*ServiceConfig) {
+	dir := t.TempDir()
+	return func(cfg *ServiceConfig) {
+		cfg.Checkpoint.Path = dir
+		cfg.ShutdownTimeout = timeout
+	}
+}
+
+type executeOutcome struct {
+	result *ExecutionResult
+	err    error
+}
+
+func executeAsync(svc *Service, task string) <-chan executeOutcome {
+	done := make(chan executeOutcome, 1)
+	go func() {
+		result, err := svc.Execute(context.Background(), task)
+		done <- executeOutcome{result, err}
+	}()
+	return done
+}
+
+func TestService_Stop_WaitsForInFlightExecution(t *testing.T) {
+	client := newBlockingClient()
+	svc := newTestService(t, client, withShutdown(t, 5*time.Second))
+
+	done := executeAsync(svc, "Finish before shutdown")
+	<-client.started
+
+	stopped := make(chan error, 1)
+	go func() { stopped <- svc.Stop() }()
+
+	// New executions are refused while draining
+	require.Eventually(t, func() bool { return !svc.IsRunning() }, time.Second, time.Millisecond)
+	_, err := svc.Execute(context.Background(), "Too late")
+	assert.ErrorIs(t, err, ErrServiceNotRunning)
+
+	select {
+	case <-stopped:
+		t.Fatal("Stop returned before the execution finished")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(client.release)
+	outcome := <-done
+	require.NoError(t, outcome.err)
+	assert.NotEmpty(t, outcome.result.Response)
+	require.NoError(t, <-stopped)
+	assert.Equal(t, 1, svc.Stats().TotalExecutions)
+}
+
+func TestService_Stop_CancelsAndCheckpointsExecution(t *testing.T) {
+	client := newBlockingClient()
+	svc := newTestService(t, client, withShutdown(t, 20*time.Millisecond))
+
+	done := executeAsync(svc, "Interrupted by shutdown")
+	<-client.started
+
+	require.NoError(t, svc.Stop())
+	outcome := <-done
+	require.Error(t, outcome.err)
+	assert.ErrorIs(t, outcome.err, ErrServiceStopping)
+
+	cp, err := checkpoint.NewManager(svc.config.Checkpoint).Load()
+	require.NoError(t, err)
+	require.NotNil(t, cp)
+	require.Len(t, cp.Interrupted, 1)
+	assert.Equal(t, "Interrupted by shutdown", cp.Interrupted[0].Task)
+	assert.False(t, cp.Interrupted[0].InterruptedAt.Before(cp.Interrupted[0].StartedAt))
+	assert.True(t, cp.IsRecoverable())
+}
+
+func TestService_Stop_AbandonsExecutionIgnoringCancellation(t *testing.T) {
+	client := newBlockingClient()
+	client.ignoreCtx = true
+	svc := newTestService(t, client, withShutdown(t, 0))
+	svc.cancelGrace = 20 * time.Millisecond
+
+	done := executeAsync(svc, "Ignores shutdown")
+	<-client.started
+	require.NoError(t, svc.Stop())
+
+	cp, err := checkpoint.NewManager(svc.config.Checkpoint).Load()
+	require.NoError(t, err)
+	require.NotNil(t, cp)
+	require.Len(t, cp.Interrupted, 1)
+	assert.Empty(t, cp.Interrupted[0].PartialResponse, "it never returned")
+
+	// Finishing after the store closed must not panic
+	close(client.release)
+	assert.NotPanics(t, func() { <-done })
+}
+
+// exerciseSubsystems makes each subsystem of svc call its LLM client once.
+func exerciseSubsystems(t *testing.T, svc *Service) {
+	t.Helper()
+	ctx := context.Background()
+
+	// Decisions and reasoning
+	_, err := svc.Execute(ctx, "Test task")
+	require.NoError(t, err)
+
+	// Sub-calls
+	resp := svc.SubCallRouter().Call(ctx, SubCallRequest{Prompt: "summarize", Context: "text"})
+	require.Empty(t, resp.Error)
+
+	// Classification
+	require.NotNil(t, svc.Wrapper().llmClassifier)
+	svc.Wrapper().llmClassifier.Classify(ctx, "what changed?", nil)
+
+	// Verification
+	require.NotNil(t, svc.detector)
+	svc.detector.VerifyClaimWithEvidence(ctx, &hallucination.Claim{Content: "The sky is blue"}, "The sky is blue")
+
+	// Synthesis
+	require.NoError(t, svc.RecordExperience(ctx, "Fixed the login bug"))
+	_, err = svc.SessionEnd(ctx)
+	require.NoError(t, err)
+}
+
+// withClients verifies output and gives the subsystems clients.
+func withClients(clients SubsystemClients) func(*ServiceConfig) {
+	return func(cfg *ServiceConfig) {
+		cfg.Hallucination.OutputVerificationEnabled = true
+		cfg.Clients = clients
+	}
+}
+
+func TestService_SubsystemClients(t *testing.T) {
+	shared := &countingClient{}
+	clients := map[string]*countingClient{
+		"decisions":      {},
+		"reasoning":      {},
+		"classification": {},
+		"subcalls":       {},
+		"synthesis":      {},
+		"verification":   {},
+	}
+	svc := newTestService(t, shared, withClients(SubsystemClients{
+		Decisions:      clients["decisions"],
+		Reasoning:      clients["reasoning"],
+		Classification: clients["classification"],
+		SubCalls:       clients["subcalls"],
+		Synthesis:      clients["synthesis"],
+		Verification:   clients["verification"],
+	}))
+	svc.Wrapper().SetLLMClient(shared)
+	exerciseSubsystems(t, svc)
+
+	for name, client := range clients {
+		assert.Positive(t, client.calls, name)
+	}
+	assert.Zero(t, shared.calls, "every subsystem has its own client")
+}
+
+func TestService_SubsystemClients_DefaultToShared(t *testing.T) {
+	shared := &countingClient{}
+	fast := &countingClient{}
+	svc := newTestService(t, shared, withClients(SubsystemClients{Classification: fast}))
+	svc.Wrapper().SetLLMClient(shared)
+	exerciseSubsystems(t, svc)
+
+	assert.Equal(t, 1, fast.calls, "only classification uses the configured client")
+	// decision, answer, sub-call, verification and synthesis
+	assert.GreaterOrEqual(t, shared.calls, 5)
+
+	t.Run("rate limit covers configured clients", func(t *testing.T) {
+		decisions := &countingClient{}
+		svc := newTestService(t, &countingClient{}, func(cfg *ServiceConfig) {
+			cfg.RateLimit = resilience.RateLimitConfig{RequestsPerSecond: 1000, MaxConcurrent: 4}
+			cfg.Clients = SubsystemClients{Decisions: decisions}
+		})
+
+		_, err := svc.Execute(context.Background(), "Test task")
+		require.NoError(t, err)
+		assert.Positive(t, decisions.calls)
+		assert.GreaterOrEqual(t, svc.RateLimiter().Metrics().Acquired, int64(2))
+	})
+}
@@ -208,6 +208,48 @@ func TestSpec09_ResumeSession(t *testing.T) {
 	})
 }
 
+// TestSpec09_ResumeInProgressSession validates that ResumeSession uses the
+// latest milestone summary of a session that has not ended.
+func TestSpec09_ResumeInProgressSession(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	config := DefaultServiceConfig()
+	config.Lifecycle.SummaryMilestones = []int{5000, 10000}
+	svc, err := NewService(newSpec09MockLLM(), config)
+	require.NoError(t, err)
+	defer svc.Stop()
+	require.NoError(t, svc.Start(ctx))
+	svc.LifecycleManager().StartSession("in-progress-session")
+
+	exp := hypergraph.NewNode(hypergraph.NodeTypeExperience, "Added session synthesis")
+	exp.Tier = hypergraph.TierSession
+	require.NoError(t, svc.store.CreateNode(ctx, exp))
+
+	result, err := svc.RecordSessionTokens(ctx, 6000)
+	require.NoError(t, err)
+	require.NotNil(t, result)
+	require.NotNil(t, result.Milestone.Summary)
+
+	sessionCtx, err := svc.ResumeSession(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, sessionCtx)
+	assert.True(t, sessionCtx.InProgress)
+	assert.Equal(t, "in-progress-session", sessionCtx.PreviousSession.SessionID)
+	assert.Equal(t, 5000, sessionCtx.PreviousSession.Milestone)
+	assert.Equal(t, "Need to add more tests", sessionCtx.UnfinishedWork)
+
+	// Once the session ends its summary replaces the milestone summary
+	_, _ = svc.SessionEnd(ctx)
+	sessionCtx, err = svc.ResumeSession(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, sessionCtx)
+	assert.False(t, sessionCtx.InProgress)
+	assert.Equal(t, "in-progress-session", sessionCtx.PreviousSession.SessionID)
+	assert.Zero(t, sessionCtx.PreviousSession.Milestone)
+	assert.Empty(t, sessionCtx.RecentSessions)
+}
+
 // TestSpec09_NoSessionContext validates behavior when no previous session exists.
 func TestSpec09_NoSessionContext(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
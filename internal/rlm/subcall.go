@@ -4,12 +4,16 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"log/slog"
+	"maps"
 	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/rand/recurse/internal/rlm/meta"
+	"github.com/rand/recurse/internal/rlm/observability"
+	"github.com/rand/recurse/internal/rlm/verify"
 )
 
 // SubCallRouter routes sub-LLM calls from the REPL to appropriate models.
@@ -20,17 +24,29 @@ type SubCallRouter struct {
 	selector    meta.ModelSelector
 	maxDepth    int
 	budgetLimit int
+	metrics     *observability.ExecutionMetrics
+	verifier    CodeVerifier
+	tierLimiter *meta.TierLimiter
+
+	// Verification of sampled results
+	sampling       VerificationSampling
+	resultVerifier ResultVerifier
 
 	// Statistics
-	mu             sync.RWMutex
-	totalCalls     int64
-	totalTokens    int64
-	totalCost      float64
-	callsByTier    map[meta.ModelTier]int64
-	callsByModel   map[string]int64
-	errors         int64
-	currentDepth   int32
-	maxDepthSeen   int32
+	mu           sync.RWMutex
+	totalCalls   int64
+	totalTokens  int64
+	totalCost    float64
+	callsByTier  map[meta.ModelTier]int64
+	callsByModel map[string]int64
+	byRule       map[meta.RouteRule]SubCallRouteStats
+	byTier       map[meta.ModelTier]SubCallRouteStats
+	errors       int64
+	currentDepth int32
+	maxDepthSeen int32
+
+	resultsVerified int64
+	resultsFlagged  int64
 }
 
 // SubCallConfig configures the sub-call router.
@@ -46,6 +62,25 @@ type SubCallConfig struct {
 
 	// BudgetLimit is the total token budget (default 100000).
 	BudgetLimit int
+
+	// Metrics counts routed calls by model tier (optional).
+	Metrics *observability.ExecutionMetrics
+
+	// Verifier checks code in responses to requests that ask for
+	// verification (optional).
+	Verifier CodeVerifier
+
+	// TierLimits caps the calls in flight to each model tier; a call
+	// waits for a slot of its tier. Nil leaves every tier uncapped, and
+	// meta.DefaultTierLimits suits a single provider account.
+	TierLimits meta.TierLimits
+
+	// Sampling verifies a fraction of results against their context with
+	// ResultVerifier, flagging discrepancies. The zero value verifies none.
+	Sampling VerificationSampling
+
+	// ResultVerifier checks sampled results (optional).
+	ResultVerifier ResultVerifier
 }
 
 // NewSubCallRouter creates a new sub-call router.
@@ -65,14 +100,26 @@ func NewSubCallRouter(cfg SubCallConfig) *SubCallRouter {
 		budgetLimit = 100000
 	}
 
+	var tierLimiter *meta.TierLimiter
+	if cfg.TierLimits != nil {
+		tierLimiter = meta.NewTierLimiter(cfg.TierLimits)
+	}
+
 	return &SubCallRouter{
-		client:       cfg.Client,
-		models:       models,
-		selector:     &meta.AdaptiveSelector{},
-		maxDepth:     maxDepth,
-		budgetLimit:  budgetLimit,
-		callsByTier:  make(map[meta.ModelTier]int64),
-		callsByModel: make(map[string]int64),
+		client:         cfg.Client,
+		models:         models,
+		selector:       meta.NewAdaptiveSelector(models),
+		maxDepth:       maxDepth,
+		budgetLimit:    budgetLimit,
+		metrics:        cfg.Metrics,
+		verifier:       cfg.Verifier,
+		tierLimiter:    tierLimiter,
+		sampling:       cfg.Sampling,
+		resultVerifier: cfg.ResultVerifier,
+		callsByTier:    make(map[meta.ModelTier]int64),
+		callsByModel:   make(map[string]int64),
+		byRule:         make(map[meta.RouteRule]SubCallRouteStats),
+		byTier:         make(map[meta.ModelTier]SubCallRouteStats),
 	}
 }
 
@@ -95,6 +142,18 @@ type SubCallRequest struct {
 
 	// MaxTokens limits the response length.
 	MaxTokens int `json:"max_tokens"`
+
+	// Verify checks code in the response against constraints extracted
+	// from it, attaching the result to the response.
+	Verify bool `json:"verify,omitempty"`
+
+	// VerifyRetries is how many times a response whose code violates its
+	// constraints is rejected and regenerated. Requires Verify.
+	VerifyRetries int `json:"verify_retries,omitempty"`
+
+	// ForSynthesis marks a result that feeds the final synthesis, which
+	// VerificationSampling.AlwaysVerifySynthesis always verifies.
+	ForSynthesis bool `json:"for_synthesis,omitempty"`
 }
 
 // SubCallResponse is returned to the REPL.
@@ -116,6 +175,18 @@ type SubCallResponse struct {
 
 	// Error is set if the call failed.
 	Error string `json:"error,omitempty"`
+
+	// Route explains why the model was chosen: the tier, the routing rule
+	// that chose it and how the tier's models scored.
+	Route *meta.RouteDecision `json:"route,omitempty"`
+
+	// Verification is the result of verifying the response's code, set
+	// when the request asked for verification and the response has code.
+	Verification *SubCallVerification `json:"verification,omitempty"`
+
+	// ResultCheck is the result of verifying the response against its
+	// context, set when the response was sampled for verification.
+	ResultCheck *ResultVerification `json:"result_check,omitempty"`
 }
 
 // Call makes a sub-LLM call with intelligent routing.
@@ -148,8 +219,9 @@ func (r *SubCallRouter) Call(ctx context.Context, req SubCallRequest) *SubCallRe
 	fullPrompt := r.buildPrompt(req)
 
 	// Select model based on tier hint and content
-	model := r.selectModel(ctx, req)
+	model, route := r.routeModel(ctx, req)
 	resp.ModelUsed = model.ID
+	resp.Route = route
 
 	// Make the call
 	if r.client == nil {
@@ -163,22 +235,45 @@ func (r *SubCallRouter) Call(ctx context.Context, req SubCallRequest) *SubCallRe
 		maxTokens = 1000
 	}
 
-	response, err := r.client.Complete(ctx, fullPrompt, maxTokens)
+	// Wait for a slot of the model's tier, including any verification retries
+	release, err := r.tierLimiter.Acquire(ctx, model.Tier)
 	if err != nil {
-		resp.Error = fmt.Sprintf("LLM call failed: %v", err)
+		resp.Error = fmt.Sprintf("waiting for %s tier: %v", model.Tier, err)
 		atomic.AddInt64(&r.errors, 1)
 		return resp
 	}
+	defer release()
 
-	resp.Response = response
-	resp.Duration = time.Since(start)
+	if err := r.complete(ctx, fullPrompt, model, maxTokens, resp); err != nil {
+		resp.Error = err.Error()
+		atomic.AddInt64(&r.errors, 1)
+		return resp
+	}
+
+	// Reject and regenerate code that violates its constraints, unless the
+	// execution profile skips verification
+	if req.Verify && ProfileSettingsFrom(ctx).Verification != VerifyNone {
+		for attempt := 1; ; attempt++ {
+			v := r.verifyResponse(ctx, req, resp.Response)
+			if v == nil {
+				break
+			}
+			v.Attempts = attempt
+			resp.Verification = v
+			if v.Status != verify.StatusViolated || attempt > req.VerifyRetries {
+				break
+			}
+			// A failed retry keeps the violating response and its result
+			if err := r.complete(ctx, fullPrompt+violationFeedback(v), model, maxTokens, resp); err != nil {
+				slog.Warn("Sub-call verification retry failed", "attempt", attempt, "error", err)
+				break
+			}
+		}
+	}
 
-	// Estimate tokens and cost
-	inputTokens := len(fullPrompt) / 4
-	outputTokens := len(response) / 4
-	resp.TokensUsed = inputTokens + outputTokens
-	resp.Cost = (float64(inputTokens) * model.InputCost / 1_000_000) +
-		(float64(outputTokens) * model.OutputCost / 1_000_000)
+	// Check a sample of results against their context
+	resp.ResultCheck = r.checkResult(ctx, req, resp.Response)
+	resp.Duration = time.Since(start)
 
 	// Update statistics
 	r.recordStats(model, resp)
@@ -186,6 +281,31 @@ func (r *SubCallRouter) Call(ctx context.Context, req SubCallRequest) *SubCallRe
 	return resp
 }
 
+// complete makes one LLM call, setting resp's response and adding its
+// tokens and cost.
+func (r *SubCallRouter) complete(ctx context.Context, prompt string, model *meta.ModelSpec, maxTokens int, resp *SubCallResponse) error {
+	// Refuse the call if it would break the execution's cost ceiling
+	guard := CostGuardFrom(ctx)
+	if err := guard.Allow(float64(len(prompt)/4) * model.InputCost / 1_000_000); err != nil {
+		return err
+	}
+
+	response, usage, err := meta.CompleteWithUsage(ctx, r.client, prompt, maxTokens, estimateTokens)
+	if err != nil {
+		return fmt.Errorf("LLM call failed: %w", err)
+	}
+
+	resp.Response = response
+
+	// Price the reported usage, or the estimate if the provider gave none
+	cost := (float64(usage.PromptTokens) * model.InputCost / 1_000_000) +
+		(float64(usage.CompletionTokens) * model.OutputCost / 1_000_000)
+	resp.TokensUsed += usage.Total()
+	resp.Cost += cost
+	guard.Charge(cost)
+	return nil
+}
+
 // BatchCall makes multiple sub-LLM calls, potentially in parallel.
 func (r *SubCallRouter) BatchCall(ctx context.Context, requests []SubCallRequest) []*SubCallResponse {
 	responses := make([]*SubCallResponse, len(requests))
@@ -227,6 +347,19 @@ func (r *SubCallRouter) buildPrompt(req SubCallRequest) string {
 
 // selectModel chooses the best model based on the request.
 func (r *SubCallRouter) selectModel(ctx context.Context, req SubCallRequest) *meta.ModelSpec {
+	model, _ := r.routeModel(ctx, req)
+	return model
+}
+
+// routeModel chooses the best model based on the request and explains the
+// choice. An explicit tier hint is honored; otherwise the selector routes
+// by recursion depth, task keywords and budget.
+func (r *SubCallRouter) routeModel(ctx context.Context, req SubCallRequest) (*meta.ModelSpec, *meta.RouteDecision) {
+	budget := req.Budget
+	if budget == 0 {
+		budget = r.budgetLimit
+	}
+
 	// Honor explicit tier hint
 	var targetTier meta.ModelTier
 	switch strings.ToLower(req.Model) {
@@ -240,42 +373,71 @@ func (r *SubCallRouter) selectModel(ctx context.Context, req SubCallRequest) *me
 		targetTier = meta.TierReasoning
 	default:
 		// Auto-select based on content
-		return r.autoSelectModel(ctx, req)
+		return r.autoSelectModel(ctx, req, budget)
 	}
 
-	// Find model for specified tier
-	for i := range r.models {
-		if r.models[i].Tier == targetTier {
-			return &r.models[i]
-		}
+	route := &meta.RouteDecision{
+		Budget:     budget,
+		Depth:      req.Depth,
+		Tier:       targetTier,
+		TierReason: fmt.Sprintf("requested %s tier", targetTier),
+		Rule:       meta.RuleRequested,
+	}
+	if maxTier, ok := meta.MaxTierFrom(ctx); ok && targetTier > maxTier {
+		route.TierReason = fmt.Sprintf("%s, lowered to the maximum tier %s", route.TierReason, maxTier)
+		route.Tier = maxTier
+		route.MaxTierApplied = true
+		targetTier = maxTier
 	}
 
-	// Fallback
-	return &r.models[0]
+	// Find model for specified tier
+	spec := meta.SelectForTier(r.models, targetTier)
+	if spec != nil {
+		route.Rationale = "preferred model of the tier"
+	} else {
+		spec = meta.PreferredModel(r.models)
+		route.Fallback = true
+		route.Rationale = fmt.Sprintf("no %s models in the catalog, used the preferred model", targetTier)
+	}
+	route.Model = spec.ID
+	return spec, route
 }
 
 // autoSelectModel uses the adaptive selector for smart routing.
-func (r *SubCallRouter) autoSelectModel(ctx context.Context, req SubCallRequest) *meta.ModelSpec {
+func (r *SubCallRouter) autoSelectModel(ctx context.Context, req SubCallRequest, budget int) (*meta.ModelSpec, *meta.RouteDecision) {
 	task := req.Prompt + " " + req.Context[:min(500, len(req.Context))]
 
-	budget := req.Budget
-	if budget == 0 {
-		budget = r.budgetLimit
+	var spec *meta.ModelSpec
+	route := &meta.RouteDecision{Budget: budget, Depth: req.Depth}
+	if explainer, ok := r.selector.(meta.RouteExplainer); ok {
+		route = explainer.ExplainRoute(ctx, task, budget, req.Depth)
+		spec = r.findModel(route.Model)
+	} else if r.selector != nil {
+		spec = r.selector.SelectModel(ctx, task, budget, req.Depth)
+		route.Rationale = "chosen by custom selector"
 	}
 
-	if r.selector != nil {
-		if spec := r.selector.SelectModel(ctx, task, budget, req.Depth); spec != nil {
-			return spec
-		}
+	// Default to fast for sub-calls
+	if spec == nil {
+		spec = meta.PreferredModel(r.models)
+		route.Fallback = true
+		route.Rationale = "no model selected, used the preferred model"
+	}
+	if route.Rule == "" {
+		route.Tier = spec.Tier
 	}
+	route.Model = spec.ID
+	return spec, route
+}
 
-	// Default to fast for sub-calls
+// findModel returns the catalog model with the given ID, or nil.
+func (r *SubCallRouter) findModel(id string) *meta.ModelSpec {
 	for i := range r.models {
-		if r.models[i].Tier == meta.TierFast {
+		if r.models[i].ID == id {
 			return &r.models[i]
 		}
 	}
-	return &r.models[0]
+	return nil
 }
 
 // recordStats updates call statistics.
@@ -288,6 +450,14 @@ func (r *SubCallRouter) recordStats(model *meta.ModelSpec, resp *SubCallResponse
 	r.totalCost += resp.Cost
 	r.callsByTier[model.Tier]++
 	r.callsByModel[model.ID]++
+	r.byTier[model.Tier] = r.byTier[model.Tier].add(resp)
+	if resp.Route != nil && resp.Route.Rule != "" {
+		r.byRule[resp.Route.Rule] = r.byRule[resp.Route.Rule].add(resp)
+	}
+
+	if r.metrics != nil {
+		r.metrics.RecordModelCall(model.Tier.String(), model.ID)
+	}
 }
 
 // Stats returns current statistics.
@@ -313,18 +483,50 @@ func (r *SubCallRouter) Stats() SubCallStats {
 		MaxDepthSeen: int(atomic.LoadInt32(&r.maxDepthSeen)),
 		CallsByTier:  tierCopy,
 		CallsByModel: modelCopy,
+		ByRule:       maps.Clone(r.byRule),
+		ByTier:       maps.Clone(r.byTier),
+
+		ResultsVerified: atomic.LoadInt64(&r.resultsVerified),
+		ResultsFlagged:  atomic.LoadInt64(&r.resultsFlagged),
 	}
 }
 
 // SubCallStats contains statistics about sub-calls.
 type SubCallStats struct {
-	TotalCalls   int64                   `json:"total_calls"`
-	TotalTokens  int64                   `json:"total_tokens"`
-	TotalCost    float64                 `json:"total_cost"`
-	Errors       int64                   `json:"errors"`
-	MaxDepthSeen int                     `json:"max_depth_seen"`
+	TotalCalls   int64                    `json:"total_calls"`
+	TotalTokens  int64                    `json:"total_tokens"`
+	TotalCost    float64                  `json:"total_cost"`
+	Errors       int64                    `json:"errors"`
+	MaxDepthSeen int                      `json:"max_depth_seen"`
 	CallsByTier  map[meta.ModelTier]int64 `json:"calls_by_tier"`
-	CallsByModel map[string]int64        `json:"calls_by_model"`
+	CallsByModel map[string]int64         `json:"calls_by_model"`
+
+	// ByRule totals the calls by the routing rule that chose their tier,
+	// and ByTier by the tier of the model used, for auditing routing cost.
+	// Calls to custom selectors' models have no rule.
+	ByRule map[meta.RouteRule]SubCallRouteStats `json:"by_rule,omitempty"`
+	ByTier map[meta.ModelTier]SubCallRouteStats `json:"by_tier,omitempty"`
+
+	// ResultsVerified counts the results sampled for verification, and
+	// ResultsFlagged those found to disagree with their context.
+	ResultsVerified int64 `json:"results_verified,omitempty"`
+	ResultsFlagged  int64 `json:"results_flagged,omitempty"`
+}
+
+// SubCallRouteStats totals the calls, tokens and cost of a group of
+// sub-calls.
+type SubCallRouteStats struct {
+	Calls  int64   `json:"calls"`
+	Tokens int64   `json:"tokens"`
+	Cost   float64 `json:"cost"`
+}
+
+// add returns s with the call resp added.
+func (s SubCallRouteStats) add(resp *SubCallResponse) SubCallRouteStats {
+	s.Calls++
+	s.Tokens += int64(resp.TokensUsed)
+	s.Cost += resp.Cost
+	return s
 }
 
 // ToJSON returns stats as JSON string.
@@ -342,9 +544,13 @@ func (r *SubCallRouter) ResetStats() {
 	atomic.StoreInt64(&r.totalTokens, 0)
 	atomic.StoreInt64(&r.errors, 0)
 	atomic.StoreInt32(&r.maxDepthSeen, 0)
+	atomic.StoreInt64(&r.resultsVerified, 0)
+	atomic.StoreInt64(&r.resultsFlagged, 0)
 	r.totalCost = 0
 	r.callsByTier = make(map[meta.ModelTier]int64)
 	r.callsByModel = make(map[string]int64)
+	r.byRule = make(map[meta.RouteRule]SubCallRouteStats)
+	r.byTier = make(map[meta.ModelTier]SubCallRouteStats)
 }
 
 // SetClient sets the LLM client (used for late initialization).
@@ -352,6 +558,18 @@ func (r *SubCallRouter) SetClient(client meta.LLMClient) {
 	r.client = client
 }
 
+// SetVerifier sets the verifier for code in responses to requests that ask
+// for verification.
+func (r *SubCallRouter) SetVerifier(verifier CodeVerifier) {
+	r.verifier = verifier
+}
+
+// TierLimiter returns the limiter capping calls per model tier, so other
+// components calling the same models can share its caps.
+func (r *SubCallRouter) TierLimiter() *meta.TierLimiter {
+	return r.tierLimiter
+}
+
 // IsConfigured returns true if the router has an LLM client.
 func (r *SubCallRouter) IsConfigured() bool {
 	return r.client != nil
@@ -0,0 +1,133 @@
+package rlm
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"sync/atomic"
+
+	"github.com/rand/recurse/internal/rlm/hallucination"
+)
+
+// VerificationSampling verifies a fraction of sub-call results against
+// their context, so errors are caught without paying to check every result.
+// The zero value verifies none.
+type VerificationSampling struct {
+	// Rate is the fraction (0.0 to 1.0) of sub-call results verified,
+	// chosen at random.
+	Rate float64
+
+	// AlwaysVerifySynthesis verifies every result feeding the final
+	// synthesis, those requested with ForSynthesis, whatever the Rate.
+	AlwaysVerifySynthesis bool
+}
+
+// Enabled reports whether any result is verified.
+func (s VerificationSampling) Enabled() bool {
+	return s.Rate > 0 || s.AlwaysVerifySynthesis
+}
+
+// sample reports whether the result of req is verified.
+func (s VerificationSampling) sample(req SubCallRequest) bool {
+	if req.ForSynthesis && s.AlwaysVerifySynthesis {
+		return true
+	}
+	return s.Rate >= 1 || (s.Rate > 0 && rand.Float64() < s.Rate)
+}
+
+// ResultVerifier checks a sub-call's response against the context it was
+// given.
+type ResultVerifier interface {
+	VerifyResult(ctx context.Context, req SubCallRequest, response string) (*ResultVerification, error)
+}
+
+// ResultVerification is the outcome of checking a sampled sub-call result.
+type ResultVerification struct {
+	// Flagged is true when the result disagrees with its context.
+	Flagged bool `json:"flagged"`
+
+	// Risk is the verifier's estimate (0.0 to 1.0) that the result is
+	// wrong.
+	Risk float64 `json:"risk"`
+
+	// Discrepancies describe the parts of the result that disagree with
+	// its context.
+	Discrepancies []string `json:"discrepancies,omitempty"`
+
+	// Error is set if verification could not run.
+	Error string `json:"error,omitempty"`
+}
+
+// OutputResultVerifier verifies sub-call results with a hallucination
+// output verifier, flagging claims the context does not support.
+type OutputResultVerifier struct {
+	verifier *hallucination.OutputVerifier
+}
+
+// NewOutputResultVerifier creates a result verifier backed by verifier.
+func NewOutputResultVerifier(verifier *hallucination.OutputVerifier) *OutputResultVerifier {
+	return &OutputResultVerifier{verifier: verifier}
+}
+
+// VerifyResult verifies the claims in response against the request's
+// context.
+func (v *OutputResultVerifier) VerifyResult(ctx context.Context, req SubCallRequest, response string) (*ResultVerification, error) {
+	result, err := v.verifier.VerifyOutput(ctx, response, req.Context)
+	if err != nil {
+		return nil, err
+	}
+	check := &ResultVerification{Flagged: result.Flagged, Risk: result.OverallRisk}
+	for _, cr := range result.ClaimResults {
+		if !cr.Flagged {
+			continue
+		}
+		discrepancy := cr.Claim.Content
+		if cr.Explanation != "" {
+			discrepancy += " (" + cr.Explanation + ")"
+		}
+		check.Discrepancies = append(check.Discrepancies, discrepancy)
+	}
+	return check, nil
+}
+
+// SetResultVerifier sets the verifier for sampled sub-call results.
+func (r *SubCallRouter) SetResultVerifier(verifier ResultVerifier) {
+	r.resultVerifier = verifier
+}
+
+// checkResult verifies response if req is sampled, returning nil if it is
+// not. The execution profile may verify every result that has a verifier,
+// or none. A flagged result is logged and counted.
+func (r *SubCallRouter) checkResult(ctx context.Context, req SubCallRequest, response string) *ResultVerification {
+	switch ProfileSettingsFrom(ctx).Verification {
+	case VerifyNone:
+		return nil
+	case VerifyAll:
+		if r.resultVerifier == nil {
+			return nil
+		}
+	default:
+		if !r.sampling.sample(req) {
+			return nil
+		}
+	}
+	atomic.AddInt64(&r.resultsVerified, 1)
+
+	if r.resultVerifier == nil {
+		return &ResultVerification{Error: "result verifier not configured"}
+	}
+	check, err := r.resultVerifier.VerifyResult(ctx, req, response)
+	if err != nil {
+		return &ResultVerification{Error: fmt.Sprintf("verify result: %v", err)}
+	}
+	if check.Flagged {
+		atomic.AddInt64(&r.resultsFlagged, 1)
+		slog.Warn("Sub-call result disagrees with its context",
+			"prompt", truncate(req.Prompt, 80),
+			"risk", check.Risk,
+			"discrepancies", len(check.Discrepancies),
+			"for_synthesis", req.ForSynthesis)
+	}
+	return check
+}
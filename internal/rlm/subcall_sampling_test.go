@@ -0,0 +1,177 @@
+package rlm
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rand/recurse/internal/rlm/repl"
+)
+
+// recordingResultVerifier records the results it verifies, flagging those
+// equal to flag.
+type recordingResultVerifier struct {
+	flag string
+	err  error
+
+	mu       sync.Mutex
+	verified []SubCallRequest
+}
+
+func (v *recordingResultVerifier) VerifyResult(ctx context.Context, req SubCallRequest, response string) (*ResultVerification, error) {
+	v.mu.Lock()
+	v.verified = append(v.verified, req)
+	v.mu.Unlock()
+	if v.err != nil {
+		return nil, v.err
+	}
+	if response == v.flag {
+		return &ResultVerification{Flagged: true, Risk: 0.9, Discrepancies: []string{"unsupported claim"}}, nil
+	}
+	return &ResultVerification{Risk: 0.1}, nil
+}
+
+func newSamplingRouter(response string, sampling VerificationSampling, verifier ResultVerifier) *SubCallRouter {
+	return NewSubCallRouter(SubCallConfig{
+		Client:         &subCallMockClient{response: response},
+		Sampling:       sampling,
+		ResultVerifier: verifier,
+	})
+}
+
+func TestSubCallRouter_SamplesResultsForVerification(t *testing.T) {
+	verifier := &recordingResultVerifier{}
+	router := newSamplingRouter("Summary", VerificationSampling{Rate: 0.25}, verifier)
+
+	const calls = 2000
+	checked := 0
+	for range calls {
+		resp := router.Call(context.Background(), SubCallRequest{Prompt: "Summarize", Context: "text", Model: "fast"})
+		require.Empty(t, resp.Error)
+		if resp.ResultCheck != nil {
+			checked++
+		}
+	}
+
+	// 500 expected; the standard deviation is about 19
+	assert.InDelta(t, calls/4, checked, 100)
+	assert.Len(t, verifier.verified, checked)
+	assert.EqualValues(t, checked, router.Stats().ResultsVerified)
+
+	t.Run("zero rate verifies none", func(t *testing.T) {
+		verifier := &recordingResultVerifier{}
+		router := newSamplingRouter("Summary", VerificationSampling{}, verifier)
+		for range 100 {
+			assert.Nil(t, router.Call(context.Background(), SubCallRequest{Prompt: "Summarize", ForSynthesis: true}).ResultCheck)
+		}
+		assert.Empty(t, verifier.verified)
+	})
+}
+
+func TestSubCallRouter_AlwaysVerifiesSynthesisInputs(t *testing.T) {
+	for _, rate := range []float64{0, 0.1} {
+		verifier := &recordingResultVerifier{}
+		router := newSamplingRouter("Part answer", VerificationSampling{Rate: rate, AlwaysVerifySynthesis: true}, verifier)
+
+		for range 200 {
+			resp := router.Call(context.Background(), SubCallRequest{Prompt: "Answer part", ForSynthesis: true})
+			require.NotNil(t, resp.ResultCheck, "rate %v", rate)
+		}
+		assert.Len(t, verifier.verified, 200)
+	}
+
+	// Other results are still only sampled
+	verifier := &recordingResultVerifier{}
+	router := newSamplingRouter("Part answer", VerificationSampling{AlwaysVerifySynthesis: true}, verifier)
+	assert.Nil(t, router.Call(context.Background(), SubCallRequest{Prompt: "Answer part"}).ResultCheck)
+	assert.Empty(t, verifier.verified)
+}
+
+func TestSubCallRouter_FlagsDiscrepancies(t *testing.T) {
+	verifier := &recordingResultVerifier{flag: "The sky is green"}
+	router := newSamplingRouter("The sky is green", VerificationSampling{Rate: 1}, verifier)
+
+	resp := router.Call(context.Background(), SubCallRequest{Prompt: "What color is the sky?", Context: "The sky is blue."})
+	require.NotNil(t, resp.ResultCheck)
+	assert.True(t, resp.ResultCheck.Flagged)
+	assert.Equal(t, []string{"unsupported claim"}, resp.ResultCheck.Discrepancies)
+	assert.Equal(t, "The sky is green", resp.Response, "a flagged result is still returned")
+	assert.Equal(t, "The sky is blue.", verifier.verified[0].Context)
+
+	stats := router.Stats()
+	assert.EqualValues(t, 1, stats.ResultsVerified)
+	assert.EqualValues(t, 1, stats.ResultsFlagged)
+
+	t.Run("verifier failure", func(t *testing.T) {
+		router := newSamplingRouter("answer", VerificationSampling{Rate: 1}, &recordingResultVerifier{err: errors.New("backend down")})
+		resp := router.Call(context.Background(), SubCallRequest{Prompt: "q"})
+		require.NotNil(t, resp.ResultCheck)
+		assert.False(t, resp.ResultCheck.Flagged)
+		assert.Contains(t, resp.ResultCheck.Error, "backend down")
+	})
+
+	t.Run("no verifier", func(t *testing.T) {
+		router := newSamplingRouter("answer", VerificationSampling{Rate: 1}, nil)
+		resp := router.Call(context.Background(), SubCallRequest{Prompt: "q"})
+		require.NotNil(t, resp.ResultCheck)
+		assert.Equal(t, "result verifier not configured", resp.ResultCheck.Error)
+	})
+}
+
+func TestNewService_SubCallSampling(t *testing.T) {
+	cfg := DefaultServiceConfig()
+	cfg.SubCallSampling = VerificationSampling{Rate: 0.1, AlwaysVerifySynthesis: true}
+
+	svc, err := NewService(&mockLLMClient{}, cfg)
+	require.NoError(t, err)
+	defer svc.Stop()
+
+	assert.IsType(t, &OutputResultVerifier{}, svc.SubCallRouter().resultVerifier)
+	assert.Equal(t, cfg.SubCallSampling, svc.SubCallRouter().sampling)
+	assert.Nil(t, svc.outputVerifier, "answers are not verified unless asked")
+}
+
+func TestService_LLMCallSynthesis_ThroughREPLCallback(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	cfg := DefaultServiceConfig()
+	cfg.SubCallSampling = VerificationSampling{AlwaysVerifySynthesis: true}
+	svc, err := NewService(&subCallMockClient{response: "The sky is green"}, cfg)
+	require.NoError(t, err)
+	defer svc.Stop()
+	verifier := &recordingResultVerifier{flag: "The sky is green"}
+	svc.SubCallRouter().resultVerifier = verifier
+
+	replMgr, err := repl.NewManager(repl.Options{})
+	require.NoError(t, err)
+	require.NoError(t, replMgr.Start(ctx))
+	defer replMgr.Stop()
+	svc.SetREPLManager(replMgr)
+
+	result, err := replMgr.Execute(ctx, `print(llm_call("What color is the sky?", "The sky is blue.", "fast", synthesis=True))`)
+	require.NoError(t, err)
+	require.Empty(t, result.Error)
+	assert.Contains(t, result.Output, "The sky is green", "the response")
+	assert.Contains(t, result.Output, "[RESULT CHECK FAILED]")
+	assert.Contains(t, result.Output, "unsupported claim")
+	require.Len(t, verifier.verified, 1)
+	assert.True(t, verifier.verified[0].ForSynthesis)
+
+	result, err = replMgr.Execute(ctx, `print(llm_batch(["Part one", "Part two"], ["a", "b"], "fast", synthesis=True)[1])`)
+	require.NoError(t, err)
+	require.Empty(t, result.Error)
+	assert.Contains(t, result.Output, "[RESULT CHECK FAILED]")
+	assert.Len(t, verifier.verified, 3)
+
+	// Results not marked for synthesis are only sampled
+	result, err = replMgr.Execute(ctx, `print(llm_call("What color is the sky?", "The sky is blue.", "fast"))`)
+	require.NoError(t, err)
+	assert.NotContains(t, result.Output, "RESULT CHECK")
+	assert.Len(t, verifier.verified, 3)
+}
@@ -2,7 +2,11 @@ package rlm
 
 import (
 	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/rand/recurse/internal/rlm/meta"
 	"github.com/stretchr/testify/assert"
@@ -222,3 +226,228 @@ func TestBuildPrompt(t *testing.T) {
 	assert.Contains(t, prompt, "## Context")
 	assert.Contains(t, prompt, "Long content here")
 }
+
+// tierBlockingClient holds calls for powerful tasks until released and
+// records how many were in flight at once.
+type tierBlockingClient struct {
+	release     chan struct{}
+	inFlight    atomic.Int32
+	maxInFlight atomic.Int32
+}
+
+func (c *tierBlockingClient) Complete(ctx context.Context, prompt string, maxTokens int) (string, error) {
+	if !strings.Contains(prompt, "powerful task") {
+		return "fast answer", nil
+	}
+	n := c.inFlight.Add(1)
+	defer c.inFlight.Add(-1)
+	for {
+		seen := c.maxInFlight.Load()
+		if n <= seen || c.maxInFlight.CompareAndSwap(seen, n) {
+			break
+		}
+	}
+	select {
+	case <-c.release:
+		return "powerful answer", nil
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+func TestSubCallRouter_Call_TierLimits(t *testing.T) {
+	client := &tierBlockingClient{release: make(chan struct{})}
+	router := NewSubCallRouter(SubCallConfig{
+		Client:     client,
+		MaxDepth:   20,
+		TierLimits: meta.TierLimits{meta.TierPowerful: 2, meta.TierFast: 8},
+	})
+
+	var wg sync.WaitGroup
+	responses := make([]*SubCallResponse, 5)
+	for i := range responses {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			responses[i] = router.Call(context.Background(), SubCallRequest{Prompt: "powerful task", Model: "powerful"})
+		}()
+	}
+	require.Eventually(t, func() bool {
+		return router.TierLimiter().InFlight(meta.TierPowerful) == 2
+	}, time.Second, time.Millisecond)
+
+	// Fast calls go through while powerful calls wait
+	for range 3 {
+		resp := router.Call(context.Background(), SubCallRequest{Prompt: "quick", Model: "fast"})
+		require.Empty(t, resp.Error)
+		assert.Equal(t, "fast answer", resp.Response)
+	}
+	assert.Equal(t, int32(2), client.inFlight.Load())
+
+	close(client.release)
+	wg.Wait()
+	for _, resp := range responses {
+		assert.Empty(t, resp.Error)
+		assert.Equal(t, "powerful answer", resp.Response)
+	}
+	assert.Equal(t, int32(2), client.maxInFlight.Load())
+	assert.Zero(t, router.TierLimiter().InFlight(meta.TierPowerful))
+}
+
+func TestSubCallRouter_Call_TierWaitCancelled(t *testing.T) {
+	client := &tierBlockingClient{release: make(chan struct{})}
+	router := NewSubCallRouter(SubCallConfig{
+		Client:     client,
+		TierLimits: meta.TierLimits{meta.TierPowerful: 1},
+	})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		router.Call(context.Background(), SubCallRequest{Prompt: "powerful task", Model: "powerful"})
+	}()
+	require.Eventually(t, func() bool {
+		return router.TierLimiter().InFlight(meta.TierPowerful) == 1
+	}, time.Second, time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	resp := router.Call(ctx, SubCallRequest{Prompt: "powerful task", Model: "powerful"})
+	assert.Contains(t, resp.Error, "waiting for powerful tier")
+	assert.Equal(t, int32(1), client.maxInFlight.Load())
+
+	close(client.release)
+	<-done
+}
+
+func TestSubCallRouter_TierLimitsOptIn(t *testing.T) {
+	router := NewSubCallRouter(SubCallConfig{Client: &subCallMockClient{response: "ok"}})
+	assert.Nil(t, router.TierLimiter(), "uncapped unless configured")
+
+	for range 5 {
+		resp := router.Call(context.Background(), SubCallRequest{Prompt: "powerful task", Model: "powerful"})
+		require.Empty(t, resp.Error)
+	}
+}
+
+func TestSubCallRouter_Call_RecordsRoute(t *testing.T) {
+	router := NewSubCallRouter(SubCallConfig{Client: &subCallMockClient{response: "done"}})
+
+	tests := []struct {
+		name     string
+		req      SubCallRequest
+		rule     meta.RouteRule
+		tier     meta.ModelTier
+		reason   string
+		keywords []string
+	}{
+		{
+			name:   "deep recursion uses the fast tier whatever the task",
+			req:    SubCallRequest{Prompt: "Analyze and prove this", Depth: 3},
+			rule:   meta.RuleDeepRecursion,
+			tier:   meta.TierFast,
+			reason: "recursion depth 3 >= 3",
+		},
+		{
+			name:     "reasoning keywords",
+			req:      SubCallRequest{Prompt: "Prove the theorem"},
+			rule:     meta.RuleReasoningKeywords,
+			tier:     meta.TierReasoning,
+			reason:   "reasoning keywords",
+			keywords: []string{"prove", "theorem"},
+		},
+		{
+			name:     "complex keywords with budget",
+			req:      SubCallRequest{Prompt: "Refactor this module", Budget: 20000},
+			rule:     meta.RuleComplexKeywords,
+			tier:     meta.TierPowerful,
+			reason:   "complex-task keywords with budget 20000 > 5000",
+			keywords: []string{"refactor"},
+		},
+		{
+			name:   "budget downgrade",
+			req:    SubCallRequest{Prompt: "Refactor this module", Budget: 800},
+			rule:   meta.RuleLowBudget,
+			tier:   meta.TierFast,
+			reason: "low budget 800 < 1000",
+		},
+		{
+			name:   "moderate depth",
+			req:    SubCallRequest{Prompt: "Summarize this", Depth: 2},
+			rule:   meta.RuleModerateDepth,
+			tier:   meta.TierBalanced,
+			reason: "recursion depth 2 >= 2",
+		},
+		{
+			name:   "default",
+			req:    SubCallRequest{Prompt: "Summarize this"},
+			rule:   meta.RuleDefault,
+			tier:   meta.TierBalanced,
+			reason: "default",
+		},
+		{
+			name:   "requested tier",
+			req:    SubCallRequest{Prompt: "Prove the theorem", Model: "fast"},
+			rule:   meta.RuleRequested,
+			tier:   meta.TierFast,
+			reason: "requested fast tier",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := router.Call(context.Background(), tt.req)
+			require.Empty(t, resp.Error)
+			route := resp.Route
+			require.NotNil(t, route)
+			assert.Equal(t, tt.rule, route.Rule)
+			assert.Equal(t, tt.tier, route.Tier)
+			assert.Equal(t, tt.reason, route.TierReason)
+			assert.Equal(t, tt.keywords, route.MatchedKeywords)
+			assert.Equal(t, tt.req.Depth, route.Depth)
+			assert.False(t, route.Fallback)
+			assert.NotEmpty(t, route.Rationale)
+
+			// The model used is the one the route chose, of its tier
+			assert.Equal(t, resp.ModelUsed, route.Model)
+			assert.Equal(t, tt.tier, router.findModel(resp.ModelUsed).Tier)
+			assert.Positive(t, resp.TokensUsed)
+			assert.Positive(t, resp.Cost)
+		})
+	}
+
+	stats := router.Stats()
+	var calls, tokens int64
+	for _, rule := range stats.ByRule {
+		calls += rule.Calls
+		tokens += rule.Tokens
+	}
+	assert.Equal(t, stats.TotalCalls, calls)
+	assert.Equal(t, stats.TotalTokens, tokens)
+	assert.EqualValues(t, 1, stats.ByRule[meta.RuleDeepRecursion].Calls)
+	assert.EqualValues(t, 3, stats.ByTier[meta.TierFast].Calls)
+	assert.InDelta(t, stats.TotalCost, stats.ByTier[meta.TierFast].Cost+stats.ByTier[meta.TierBalanced].Cost+
+		stats.ByTier[meta.TierPowerful].Cost+stats.ByTier[meta.TierReasoning].Cost, 1e-12)
+
+	router.ResetStats()
+	assert.Empty(t, router.Stats().ByRule)
+}
+
+func TestSubCallRouter_Call_RouteFallback(t *testing.T) {
+	fast := meta.ModelSpec{ID: "test/fast", Tier: meta.TierFast, InputCost: 1, OutputCost: 1}
+	router := NewSubCallRouter(SubCallConfig{
+		Client: &subCallMockClient{response: "done"},
+		Models: []meta.ModelSpec{fast},
+	})
+
+	resp := router.Call(context.Background(), SubCallRequest{Prompt: "Summarize this", Model: "powerful"})
+	require.Empty(t, resp.Error)
+	assert.Equal(t, "test/fast", resp.ModelUsed)
+	assert.True(t, resp.Route.Fallback)
+	assert.Equal(t, "no powerful models in the catalog, used the preferred model", resp.Route.Rationale)
+
+	resp = router.Call(context.Background(), SubCallRequest{Prompt: "Summarize this"})
+	assert.Equal(t, meta.RuleDefault, resp.Route.Rule)
+	assert.Equal(t, "no balanced models in the catalog, used the preferred fast model", resp.Route.Rationale)
+	assert.Equal(t, "test/fast", resp.ModelUsed)
+}
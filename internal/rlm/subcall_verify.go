@@ -0,0 +1,220 @@
+package rlm
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/rand/recurse/internal/rlm/repl"
+	"github.com/rand/recurse/internal/rlm/verify"
+)
+
+// CodeVerifier checks generated code against constraints extracted from it.
+// verify.VerificationChain implements it.
+type CodeVerifier interface {
+	VerifyChange(ctx context.Context, change verify.CodeChange) (*verify.VerificationResult, error)
+}
+
+var _ CodeVerifier = (*verify.VerificationChain)(nil)
+
+// SubCallVerification is the outcome of verifying the code in a sub-call's
+// response.
+type SubCallVerification struct {
+	// Satisfied is true when every extracted constraint held.
+	Satisfied bool `json:"satisfied"`
+
+	// Status is the verification status.
+	Status verify.VerificationStatus `json:"status"`
+
+	// Confidence is how far to trust the verdict, from the confidence of
+	// the constraints behind it.
+	Confidence float64 `json:"confidence"`
+
+	// Language is the language the code was verified as.
+	Language string `json:"language,omitempty"`
+
+	// Constraints lists the constraints the code was checked against, with
+	// any error raised checking them.
+	Constraints []string `json:"constraints,omitempty"`
+
+	// CounterExample is a failing case when the constraints were violated.
+	CounterExample *verify.CounterExample `json:"counter_example,omitempty"`
+
+	// Attempts is how many responses were generated, including retries
+	// after violations.
+	Attempts int `json:"attempts"`
+
+	// Error is set if verification could not run.
+	Error string `json:"error,omitempty"`
+}
+
+// codeFencePattern matches a fenced code block and its language tag.
+var codeFencePattern = regexp.MustCompile("(?s)```([A-Za-z0-9_+-]*)[^\\n]*\\n(.*?)```")
+
+// extractCode returns the fenced code in a response and its language, or
+// false if the response holds no code. Several blocks are joined; the
+// language is the first block's tag, defaulting to python.
+func extractCode(response string) (code, language string, ok bool) {
+	matches := codeFencePattern.FindAllStringSubmatch(response, -1)
+	var blocks []string
+	for _, m := range matches {
+		if strings.TrimSpace(m[2]) == "" {
+			continue
+		}
+		if language == "" {
+			language = strings.ToLower(m[1])
+		}
+		blocks = append(blocks, m[2])
+	}
+	if len(blocks) == 0 {
+		return "", "", false
+	}
+	switch language {
+	case "", "py", "python3":
+		language = "python"
+	case "golang":
+		language = "go"
+	}
+	return strings.Join(blocks, "\n"), language, true
+}
+
+// verifyResponse verifies the code in response, returning nil if it holds
+// none.
+func (r *SubCallRouter) verifyResponse(ctx context.Context, req SubCallRequest, response string) *SubCallVerification {
+	code, language, ok := extractCode(response)
+	if !ok {
+		return nil
+	}
+
+	v := &SubCallVerification{Language: language}
+	if r.verifier == nil {
+		v.Status = verify.StatusUnknown
+		v.Error = "code verifier not configured"
+		return v
+	}
+
+	result, err := r.verifier.VerifyChange(ctx, verify.CodeChange{
+		After:    code,
+		Language: language,
+		Context:  req.Context,
+	})
+	if err != nil {
+		v.Status = verify.StatusError
+		v.Error = err.Error()
+		return v
+	}
+
+	v.Satisfied = result.Satisfied
+	v.Status = result.Status
+	v.Confidence = result.Confidence
+	v.CounterExample = result.CounterExample
+	for _, cr := range result.CheckedConstraints {
+		if cr.Constraint == nil {
+			continue
+		}
+		constraint := cr.Constraint.Name
+		if cr.Constraint.Expression != "" {
+			constraint += ": " + cr.Constraint.Expression
+		}
+		if cr.Message != "" {
+			constraint += " (" + cr.Message + ")"
+		}
+		v.Constraints = append(v.Constraints, constraint)
+	}
+	return v
+}
+
+// violationFeedback builds the prompt suffix asking for a corrected
+// response after a verification failure.
+func violationFeedback(v *SubCallVerification) string {
+	var sb strings.Builder
+	sb.WriteString("\n## Verification Failed\n")
+	sb.WriteString("The code in your previous response violated its constraints.\n")
+	sb.WriteString(verificationReport(v))
+	sb.WriteString("Respond again with corrected code that satisfies them.\n")
+	return sb.String()
+}
+
+// verificationReport lists the constraints v checked and its
+// counter-example, one per line.
+func verificationReport(v *SubCallVerification) string {
+	var sb strings.Builder
+	for _, constraint := range v.Constraints {
+		sb.WriteString(fmt.Sprintf("- %s\n", constraint))
+	}
+	if ce := v.CounterExample; ce != nil {
+		if ce.Explanation != "" {
+			sb.WriteString(fmt.Sprintf("Counter-example: %s\n", ce.Explanation))
+		}
+		names := make([]string, 0, len(ce.Variables))
+		for name := range ce.Variables {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			sb.WriteString(fmt.Sprintf("- %s = %v\n", name, ce.Variables[name]))
+		}
+	}
+	return sb.String()
+}
+
+// replVerifier verifies code in a REPL of its own, started on first use.
+// The execution's REPL cannot serve: a sub-call that asks for verification
+// runs inside that REPL's llm_call callback, while the Execute making the
+// call holds the REPL until it returns.
+type replVerifier struct {
+	solver verify.SolverBackend
+
+	mu    sync.Mutex
+	repl  *repl.Manager
+	chain *verify.VerificationChain
+}
+
+// newREPLVerifier creates a verifier solving with solver, nil for the
+// default.
+func newREPLVerifier(solver verify.SolverBackend) *replVerifier {
+	return &replVerifier{solver: solver}
+}
+
+// VerifyChange verifies change, starting the verifier's REPL if needed.
+func (v *replVerifier) VerifyChange(ctx context.Context, change verify.CodeChange) (*verify.VerificationResult, error) {
+	chain, err := v.start(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return chain.VerifyChange(ctx, change)
+}
+
+func (v *replVerifier) start(ctx context.Context) (*verify.VerificationChain, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.repl == nil {
+		mgr, err := repl.NewManager(repl.Options{})
+		if err != nil {
+			return nil, fmt.Errorf("create verification REPL: %w", err)
+		}
+		v.repl = mgr
+		v.chain = verify.NewVerificationChain(mgr)
+		v.chain.SetSolver(v.solver)
+	}
+	if !v.repl.Running() {
+		if err := v.repl.Start(ctx); err != nil {
+			return nil, fmt.Errorf("start verification REPL: %w", err)
+		}
+	}
+	return v.chain, nil
+}
+
+// Stop stops the verifier's REPL, if it was started.
+func (v *replVerifier) Stop() error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.repl == nil || !v.repl.Running() {
+		return nil
+	}
+	return v.repl.Stop()
+}
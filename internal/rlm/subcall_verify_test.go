@@ -0,0 +1,244 @@
+package rlm
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rand/recurse/internal/rlm/repl"
+	"github.com/rand/recurse/internal/rlm/verify"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sequenceClient returns its responses in order, repeating the last.
+type sequenceClient struct {
+	responses []string
+	calls     []string
+}
+
+func (m *sequenceClient) Complete(ctx context.Context, prompt string, maxTokens int) (string, error) {
+	m.calls = append(m.calls, prompt)
+	i := min(len(m.calls), len(m.responses)) - 1
+	return m.responses[i], nil
+}
+
+// scriptedVerifier returns its results in order, repeating the last.
+type scriptedVerifier struct {
+	results []*verify.VerificationResult
+	changes []verify.CodeChange
+}
+
+func (v *scriptedVerifier) VerifyChange(ctx context.Context, change verify.CodeChange) (*verify.VerificationResult, error) {
+	v.changes = append(v.changes, change)
+	i := min(len(v.changes), len(v.results)) - 1
+	return v.results[i], nil
+}
+
+const (
+	violatingCode = "Here it is:\n```python\ndef half(x):\n    assert x > 0\n    return x // 2\n```\n"
+	fixedCode     = "Fixed:\n```python\ndef half(x):\n    assert x >= 0\n    return x // 2\n```\n"
+)
+
+var positiveConstraint = verify.Constraint{
+	Type:       verify.ConstraintTypePrecondition,
+	Name:       "assert_0",
+	Expression: "x > 0",
+	Variables:  []string{"x"},
+}
+
+func violatedResult() *verify.VerificationResult {
+	return &verify.VerificationResult{
+		Status: verify.StatusViolated,
+		CheckedConstraints: []verify.ConstraintResult{
+			{Constraint: &positiveConstraint, Satisfied: true},
+		},
+		CounterExample: &verify.CounterExample{
+			Variables:   map[string]any{"x": "0"},
+			Explanation: "Constraints could not be satisfied",
+		},
+	}
+}
+
+func satisfiedResult() *verify.VerificationResult {
+	return &verify.VerificationResult{Satisfied: true, Status: verify.StatusSatisfied, Confidence: 0.9}
+}
+
+func TestSubCallRouter_Call_VerifyViolation(t *testing.T) {
+	verifier := &scriptedVerifier{results: []*verify.VerificationResult{violatedResult()}}
+	router := NewSubCallRouter(SubCallConfig{
+		Client:   &subCallMockClient{response: violatingCode},
+		Verifier: verifier,
+	})
+
+	resp := router.Call(context.Background(), SubCallRequest{
+		Prompt: "Write a function halving a natural number",
+		Model:  "fast",
+		Verify: true,
+	})
+
+	require.Empty(t, resp.Error)
+	assert.Equal(t, violatingCode, resp.Response)
+	require.NotNil(t, resp.Verification)
+	assert.False(t, resp.Verification.Satisfied)
+	assert.Equal(t, verify.StatusViolated, resp.Verification.Status)
+	assert.Equal(t, 1, resp.Verification.Attempts)
+	assert.Equal(t, []string{"assert_0: x > 0"}, resp.Verification.Constraints)
+	require.NotNil(t, resp.Verification.CounterExample)
+	assert.Equal(t, "0", resp.Verification.CounterExample.Variables["x"])
+
+	require.Len(t, verifier.changes, 1)
+	assert.Equal(t, "python", verifier.changes[0].Language)
+	assert.Contains(t, verifier.changes[0].After, "assert x > 0")
+	assert.NotContains(t, verifier.changes[0].After, "```")
+}
+
+func TestSubCallRouter_Call_VerifyRetriesOnViolation(t *testing.T) {
+	client := &sequenceClient{responses: []string{violatingCode, fixedCode}}
+	verifier := &scriptedVerifier{results: []*verify.VerificationResult{violatedResult(), satisfiedResult()}}
+	router := NewSubCallRouter(SubCallConfig{Client: client, Verifier: verifier})
+
+	resp := router.Call(context.Background(), SubCallRequest{
+		Prompt:        "Write a function halving a natural number",
+		Model:         "fast",
+		Verify:        true,
+		VerifyRetries: 2,
+	})
+
+	require.Empty(t, resp.Error)
+	assert.Equal(t, fixedCode, resp.Response)
+	require.NotNil(t, resp.Verification)
+	assert.True(t, resp.Verification.Satisfied)
+	assert.InDelta(t, 0.9, resp.Verification.Confidence, 1e-9)
+	assert.Equal(t, 2, resp.Verification.Attempts)
+
+	// The retry was told what failed
+	require.Len(t, client.calls, 2)
+	assert.Contains(t, client.calls[1], "## Verification Failed")
+	assert.Contains(t, client.calls[1], "assert_0: x > 0")
+	assert.Contains(t, client.calls[1], "- x = 0")
+
+	// Tokens from both attempts are counted
+	assert.Greater(t, resp.TokensUsed, estimateTokens(client.calls[0]))
+}
+
+func TestSubCallRouter_Call_VerifyRetriesExhausted(t *testing.T) {
+	client := &sequenceClient{responses: []string{violatingCode}}
+	verifier := &scriptedVerifier{results: []*verify.VerificationResult{violatedResult()}}
+	router := NewSubCallRouter(SubCallConfig{Client: client, Verifier: verifier})
+
+	resp := router.Call(context.Background(), SubCallRequest{
+		Prompt:        "Write a function halving a natural number",
+		Model:         "fast",
+		Verify:        true,
+		VerifyRetries: 1,
+	})
+
+	require.Empty(t, resp.Error)
+	assert.Len(t, client.calls, 2)
+	require.NotNil(t, resp.Verification)
+	assert.Equal(t, verify.StatusViolated, resp.Verification.Status)
+	assert.Equal(t, 2, resp.Verification.Attempts)
+	assert.NotNil(t, resp.Verification.CounterExample)
+}
+
+func TestSubCallRouter_Call_VerifyOptIn(t *testing.T) {
+	verifier := &scriptedVerifier{results: []*verify.VerificationResult{violatedResult()}}
+	router := NewSubCallRouter(SubCallConfig{
+		Client:   &subCallMockClient{response: violatingCode},
+		Verifier: verifier,
+	})
+
+	resp := router.Call(context.Background(), SubCallRequest{Prompt: "Write it", Model: "fast"})
+	assert.Nil(t, resp.Verification)
+	assert.Empty(t, verifier.changes)
+
+	// Responses without code are not verified
+	router.SetClient(&subCallMockClient{response: "Halve it with integer division."})
+	resp = router.Call(context.Background(), SubCallRequest{Prompt: "Explain it", Model: "fast", Verify: true})
+	assert.Nil(t, resp.Verification)
+	assert.Empty(t, verifier.changes)
+}
+
+func TestSubCallRouter_Call_VerifyWithoutVerifier(t *testing.T) {
+	router := NewSubCallRouter(SubCallConfig{Client: &subCallMockClient{response: violatingCode}})
+
+	resp := router.Call(context.Background(), SubCallRequest{Prompt: "Write it", Model: "fast", Verify: true})
+
+	require.Empty(t, resp.Error)
+	require.NotNil(t, resp.Verification)
+	assert.Equal(t, verify.StatusUnknown, resp.Verification.Status)
+	assert.Contains(t, resp.Verification.Error, "not configured")
+}
+
+func TestExtractCode(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		code     string
+		language string
+		ok       bool
+	}{
+		{"no code", "Just prose.", "", "", false},
+		{"untagged", "```\nx = 1\n```", "x = 1\n", "python", true},
+		{"go", "```go\nfunc f() {}\n```", "func f() {}\n", "go", true},
+		{"golang alias", "```golang\nvar x int\n```", "var x int\n", "go", true},
+		{"joins blocks", "```py\na = 1\n```\nthen\n```py\nb = 2\n```", "a = 1\n\nb = 2\n", "python", true},
+		{"skips empty", "```python\n```", "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, language, ok := extractCode(tt.response)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.code, code)
+			assert.Equal(t, tt.language, language)
+		})
+	}
+}
+
+// violatingSolver reports every constraint set as violated without needing
+// a solver library in the REPL.
+type violatingSolver struct{}
+
+func (violatingSolver) Name() string { return "violating" }
+
+func (violatingSolver) BuildCode(constraints []verify.Constraint, code string) string {
+	return "import json\njson.dumps({'satisfied': False, 'status': 'violated', " +
+		"'counter_example': {'variables': {'x': '0'}, 'explanation': 'x = 0 breaks x > 0'}})\n"
+}
+
+func (violatingSolver) ParseResult(output string, constraints []verify.Constraint, result *verify.VerificationResult) (*verify.VerificationResult, error) {
+	return verify.Z3Backend{}.ParseResult(output, constraints, result)
+}
+
+func TestService_LLMCallVerify_ThroughREPLCallback(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	cfg := DefaultServiceConfig()
+	cfg.VerificationSolver = violatingSolver{}
+	svc, err := NewService(&subCallMockClient{response: violatingCode}, cfg)
+	require.NoError(t, err)
+	defer svc.Stop()
+
+	replMgr, err := repl.NewManager(repl.Options{})
+	require.NoError(t, err)
+	require.NoError(t, replMgr.Start(ctx))
+	defer replMgr.Stop()
+	svc.SetREPLManager(replMgr)
+
+	// The verified sub-call runs while this Execute holds the REPL
+	result, err := replMgr.Execute(ctx, `print(llm_call("Write a function halving a natural number", "", "fast", verify=True))`)
+	require.NoError(t, err)
+	require.Empty(t, result.Error)
+	assert.Contains(t, result.Output, "assert x > 0", "the response")
+	assert.Contains(t, result.Output, "[VERIFICATION FAILED]")
+	assert.Contains(t, result.Output, "x = 0 breaks x > 0")
+
+	// Unverified calls are returned as they are
+	result, err = replMgr.Execute(ctx, `print(llm_batch(["Write it"], model="fast")[0])`)
+	require.NoError(t, err)
+	require.Empty(t, result.Error)
+	assert.NotContains(t, result.Output, "VERIFICATION")
+}
@@ -25,6 +25,10 @@ type SubCallResult struct {
 
 	// Error contains any error message if the sub-call failed.
 	Error string `json:"error,omitempty"`
+
+	// Confidence is the reliability of this result (0.0 to 1.0), propagated
+	// from the subtask that produced it. Zero means unknown.
+	Confidence float64 `json:"confidence,omitempty"`
 }
 
 // SynthesisResult contains the synthesized output.
@@ -37,6 +41,10 @@ type SynthesisResult struct {
 
 	// PartCount is the number of sub-call results that were synthesized.
 	PartCount int `json:"part_count"`
+
+	// Confidence is the aggregate confidence of the synthesized response
+	// (0.0 to 1.0). Zero means no input carried a confidence.
+	Confidence float64 `json:"confidence,omitempty"`
 }
 
 // Strategy specifies how to combine results.
@@ -111,6 +119,7 @@ func (s *ConcatenateSynthesizer) Synthesize(ctx context.Context, task string, re
 		Response:        strings.Join(parts, sep),
 		TotalTokensUsed: totalTokens,
 		PartCount:       len(parts),
+		Confidence:      AggregateConfidence(results),
 	}, nil
 }
 
@@ -139,39 +148,13 @@ func (s *LLMSynthesizer) Synthesize(ctx context.Context, task string, results []
 		}, nil
 	}
 
-	// Build synthesis prompt
-	var sb strings.Builder
-	sb.WriteString("You are synthesizing results from multiple analysis passes into a coherent response.\n\n")
-	sb.WriteString(fmt.Sprintf("Original task: %s\n\n", task))
-	sb.WriteString("Results to synthesize:\n\n")
-
-	totalInputTokens := 0
-	validResults := 0
-
-	for i, r := range results {
-		if r.Error != "" {
-			continue
-		}
-		validResults++
-		sb.WriteString(fmt.Sprintf("### Part %d: %s\n\n", i+1, r.Name))
-		sb.WriteString(r.Response)
-		sb.WriteString("\n\n")
-		totalInputTokens += r.TokensUsed
-	}
-
+	prompt, totalInputTokens, validResults := buildSynthesisPrompt(task, results)
 	if validResults == 0 {
 		return &SynthesisResult{
 			Response: "(all sub-calls failed)",
 		}, nil
 	}
 
-	sb.WriteString("---\n\n")
-	sb.WriteString("Create a coherent, unified response that:\n")
-	sb.WriteString("1. Combines insights from all parts\n")
-	sb.WriteString("2. Removes redundancy\n")
-	sb.WriteString("3. Maintains important details\n")
-	sb.WriteString("4. Presents information in a logical order\n")
-
 	// Call LLM
 	lm, err := s.provider.LanguageModel(ctx, s.model)
 	if err != nil {
@@ -180,7 +163,7 @@ func (s *LLMSynthesizer) Synthesize(ctx context.Context, task string, results []
 
 	maxTokens := int64(8192) // Allow room for comprehensive synthesis
 	call := fantasy.Call{
-		Prompt:          fantasy.Prompt{fantasy.NewUserMessage(sb.String())},
+		Prompt:          fantasy.Prompt{fantasy.NewUserMessage(prompt)},
 		MaxOutputTokens: &maxTokens,
 	}
 
@@ -193,9 +176,104 @@ func (s *LLMSynthesizer) Synthesize(ctx context.Context, task string, results []
 		Response:        resp.Content.Text(),
 		TotalTokensUsed: totalInputTokens + int(resp.Usage.TotalTokens),
 		PartCount:       validResults,
+		Confidence:      AggregateConfidence(results),
 	}, nil
 }
 
+// buildSynthesisPrompt builds the LLM synthesis prompt, returning it along
+// with the input tokens and number of successful results it covers. When any
+// result carries a confidence, each part is annotated with its confidence and
+// normalized weight and the model is told to weight the parts accordingly.
+func buildSynthesisPrompt(task string, results []SubCallResult) (string, int, int) {
+	var valid []SubCallResult
+	for _, r := range results {
+		if r.Error == "" {
+			valid = append(valid, r)
+		}
+	}
+
+	weighted := false
+	for _, r := range valid {
+		if r.Confidence > 0 {
+			weighted = true
+			break
+		}
+	}
+
+	var weights []float64
+	if weighted {
+		weights = NormalizeWeights(scoredFromConfidence(valid))
+	}
+
+	var sb strings.Builder
+	sb.WriteString("You are synthesizing results from multiple analysis passes into a coherent response.\n\n")
+	sb.WriteString(fmt.Sprintf("Original task: %s\n\n", task))
+	sb.WriteString("Results to synthesize:\n\n")
+
+	totalInputTokens := 0
+	for i, r := range valid {
+		if weighted {
+			sb.WriteString(fmt.Sprintf("### Part %d: %s (Confidence: %.0f%%, Weight: %.0f%%)\n\n",
+				i+1, r.Name, r.Confidence*100, weights[i]*100))
+		} else {
+			sb.WriteString(fmt.Sprintf("### Part %d: %s\n\n", i+1, r.Name))
+		}
+		sb.WriteString(r.Response)
+		sb.WriteString("\n\n")
+		totalInputTokens += r.TokensUsed
+	}
+
+	sb.WriteString("---\n\n")
+	sb.WriteString("Create a coherent, unified response that:\n")
+	sb.WriteString("1. Combines insights from all parts\n")
+	sb.WriteString("2. Removes redundancy\n")
+	sb.WriteString("3. Maintains important details\n")
+	sb.WriteString("4. Presents information in a logical order\n")
+	if weighted {
+		sb.WriteString("5. Weights each part by its stated weight; if parts disagree, prefer the higher-confidence part\n")
+		sb.WriteString("6. Treats claims supported only by low-confidence parts (below 50%) as tentative and says so\n")
+	}
+
+	return sb.String(), totalInputTokens, len(valid)
+}
+
+// AggregateConfidence combines the confidences of successful results into a
+// single score, weighting each by NormalizeWeights so that confident results
+// dominate. Results without a confidence are ignored; it returns zero when no
+// result carries one.
+func AggregateConfidence(results []SubCallResult) float64 {
+	var known []SubCallResult
+	for _, r := range results {
+		if r.Error == "" && r.Confidence > 0 {
+			known = append(known, r)
+		}
+	}
+	if len(known) == 0 {
+		return 0
+	}
+
+	scored := scoredFromConfidence(known)
+	weights := NormalizeWeights(scored)
+
+	var sum float64
+	for i, r := range scored {
+		sum += r.Confidence.Score * weights[i]
+	}
+	return sum
+}
+
+// scoredFromConfidence wraps results using their propagated confidence.
+func scoredFromConfidence(results []SubCallResult) []*ScoredResult {
+	scored := make([]*ScoredResult, len(results))
+	for i, r := range results {
+		scored[i] = &ScoredResult{
+			SubCallResult: r,
+			Confidence:    Confidence{Score: r.Confidence},
+		}
+	}
+	return scored
+}
+
 // MergeSynthesizer combines results by merging similar sections.
 type MergeSynthesizer struct {
 	// MaxOutputLength caps the merged output length.
@@ -285,6 +363,7 @@ func (s *MergeSynthesizer) Synthesize(ctx context.Context, task string, results
 		Response:        strings.TrimSpace(sb.String()),
 		TotalTokensUsed: totalTokens,
 		PartCount:       validCount,
+		Confidence:      AggregateConfidence(results),
 	}, nil
 }
 
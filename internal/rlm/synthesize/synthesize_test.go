@@ -209,6 +209,91 @@ func TestAuto_LargeResults(t *testing.T) {
 	assert.True(t, ok, "should use merge for large results")
 }
 
+func TestBuildSynthesisPrompt_MixedConfidence(t *testing.T) {
+	results := []SubCallResult{
+		{Name: "Schema", Response: "Uses table users", TokensUsed: 10, Confidence: 0.9},
+		{Name: "Guess", Response: "Maybe uses table accounts", TokensUsed: 5, Confidence: 0.3},
+		{Name: "Failed", Error: "timeout"},
+	}
+
+	prompt, tokens, valid := buildSynthesisPrompt("which table?", results)
+
+	assert.Equal(t, 15, tokens)
+	assert.Equal(t, 2, valid)
+	// Weights are confidence squared, normalized: 0.81/0.90 and 0.09/0.90.
+	assert.Contains(t, prompt, "### Part 1: Schema (Confidence: 90%, Weight: 90%)")
+	assert.Contains(t, prompt, "### Part 2: Guess (Confidence: 30%, Weight: 10%)")
+	assert.Contains(t, prompt, "prefer the higher-confidence part")
+	assert.Contains(t, prompt, "low-confidence parts (below 50%) as tentative")
+	assert.NotContains(t, prompt, "Failed")
+}
+
+func TestBuildSynthesisPrompt_NoConfidence(t *testing.T) {
+	results := []SubCallResult{
+		{Name: "A", Response: "first"},
+		{Name: "B", Response: "second"},
+	}
+
+	prompt, _, valid := buildSynthesisPrompt("task", results)
+
+	assert.Equal(t, 2, valid)
+	assert.Contains(t, prompt, "### Part 1: A\n\n")
+	assert.NotContains(t, prompt, "Confidence:")
+	assert.NotContains(t, prompt, "higher-confidence")
+}
+
+func TestAggregateConfidence(t *testing.T) {
+	tests := []struct {
+		name     string
+		results  []SubCallResult
+		expected float64
+	}{
+		{
+			name:     "empty",
+			expected: 0,
+		},
+		{
+			name:     "no confidences",
+			results:  []SubCallResult{{Response: "a"}, {Response: "b"}},
+			expected: 0,
+		},
+		{
+			name: "mixed confidences favor the confident result",
+			results: []SubCallResult{
+				{Response: "a", Confidence: 0.9},
+				{Response: "b", Confidence: 0.3},
+			},
+			expected: 0.9*0.9 + 0.3*0.1,
+		},
+		{
+			name: "ignores failed and unscored results",
+			results: []SubCallResult{
+				{Response: "a", Confidence: 0.8},
+				{Response: "b"},
+				{Error: "failed", Confidence: 0.1},
+			},
+			expected: 0.8,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.InDelta(t, tt.expected, AggregateConfidence(tt.results), 0.001)
+		})
+	}
+}
+
+func TestConcatenateSynthesizer_Confidence(t *testing.T) {
+	s := NewConcatenateSynthesizer()
+
+	out, err := s.Synthesize(context.Background(), "task", []SubCallResult{
+		{Response: "a", Confidence: 0.9},
+		{Response: "b", Confidence: 0.3},
+	})
+	require.NoError(t, err)
+	assert.InDelta(t, 0.84, out.Confidence, 0.001)
+}
+
 func TestSubCallResult_Fields(t *testing.T) {
 	r := SubCallResult{
 		ID:         "test-id",
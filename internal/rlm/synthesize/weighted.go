@@ -363,12 +363,18 @@ func (s *WeightedSynthesizer) SynthesizeWeighted(ctx context.Context, task strin
 		}, nil
 	}
 
-	// Score each result
+	// Score each result, preferring confidence propagated from the subtask
 	scored := make([]*ScoredResult, 0, len(results))
 	for i := range results {
-		conf, err := s.scorer.Score(ctx, &results[i])
-		if err != nil {
-			conf = &Confidence{Score: 0.5}
+		var conf *Confidence
+		if results[i].Confidence > 0 && results[i].Error == "" {
+			conf = &Confidence{Score: results[i].Confidence}
+		} else {
+			var err error
+			conf, err = s.scorer.Score(ctx, &results[i])
+			if err != nil {
+				conf = &Confidence{Score: 0.5}
+			}
 		}
 		scored = append(scored, &ScoredResult{
 			SubCallResult: results[i],
@@ -419,6 +425,7 @@ func (s *WeightedSynthesizer) SynthesizeWeighted(ctx context.Context, task strin
 			Response:        response,
 			TotalTokensUsed: totalTokens,
 			PartCount:       len(filtered),
+			Confidence:      overallConf,
 		},
 		OverallConfidence: overallConf,
 		ScoredResults:     scored,
@@ -484,7 +491,31 @@ func (s *WeightedSynthesizer) generateWeightedSynthesis(
 		return results[0].Response, 0, nil
 	}
 
-	// Build weighted synthesis prompt
+	prompt := buildWeightedPrompt(task, results, weights)
+
+	// Call LLM
+	lm, err := s.provider.LanguageModel(ctx, s.model)
+	if err != nil {
+		return "", 0, fmt.Errorf("get language model: %w", err)
+	}
+
+	maxTokens := int64(8192) // Allow room for comprehensive synthesis
+	call := fantasy.Call{
+		Prompt:          fantasy.Prompt{fantasy.NewUserMessage(prompt)},
+		MaxOutputTokens: &maxTokens,
+	}
+
+	resp, err := lm.Generate(ctx, call)
+	if err != nil {
+		return "", 0, fmt.Errorf("synthesis generation: %w", err)
+	}
+
+	return resp.Content.Text(), int(resp.Usage.TotalTokens), nil
+}
+
+// buildWeightedPrompt builds the synthesis prompt, annotating each source
+// with its confidence and normalized weight.
+func buildWeightedPrompt(task string, results []*ScoredResult, weights []float64) string {
 	var sb strings.Builder
 	sb.WriteString("You are synthesizing results from multiple analysis passes into a coherent response.\n\n")
 	sb.WriteString(fmt.Sprintf("Original task: %s\n\n", task))
@@ -512,24 +543,7 @@ func (s *WeightedSynthesizer) generateWeightedSynthesis(
 	sb.WriteString("5. If sources disagree, prefer the higher-confidence source\n\n")
 	sb.WriteString("Synthesized Response:")
 
-	// Call LLM
-	lm, err := s.provider.LanguageModel(ctx, s.model)
-	if err != nil {
-		return "", 0, fmt.Errorf("get language model: %w", err)
-	}
-
-	maxTokens := int64(8192) // Allow room for comprehensive synthesis
-	call := fantasy.Call{
-		Prompt:          fantasy.Prompt{fantasy.NewUserMessage(sb.String())},
-		MaxOutputTokens: &maxTokens,
-	}
-
-	resp, err := lm.Generate(ctx, call)
-	if err != nil {
-		return "", 0, fmt.Errorf("synthesis generation: %w", err)
-	}
-
-	return resp.Content.Text(), int(resp.Usage.TotalTokens), nil
+	return sb.String()
 }
 
 func (s *WeightedSynthesizer) computeOverallConfidence(results []*ScoredResult, weights []float64) float64 {
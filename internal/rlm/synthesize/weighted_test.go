@@ -361,6 +361,39 @@ func TestWeightedSynthesizer_ComputeOverallConfidence_Empty(t *testing.T) {
 	assert.Equal(t, 0.0, overall)
 }
 
+func TestWeightedSynthesizer_PropagatedConfidence(t *testing.T) {
+	synth := NewWeightedSynthesizer(nil, "")
+
+	// The low-confidence result falls below MinConfidence, leaving a single
+	// source, so no LLM call is made.
+	out, err := synth.SynthesizeWeighted(context.Background(), "task", []SubCallResult{
+		{Name: "good", Response: "ok", Confidence: 0.85},
+		{Name: "bad", Response: "definitely, certainly, clearly right", Confidence: 0.1},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "ok", out.Response)
+	assert.Equal(t, 1, out.FilteredCount)
+	assert.InDelta(t, 0.85, out.OverallConfidence, 0.001)
+	assert.InDelta(t, 0.85, out.Confidence, 0.001)
+	for _, r := range out.ScoredResults {
+		assert.Equal(t, r.SubCallResult.Confidence, r.Confidence.Score, "propagated confidence overrides the scorer")
+	}
+}
+
+func TestBuildWeightedPrompt(t *testing.T) {
+	results := []*ScoredResult{
+		{SubCallResult: SubCallResult{Name: "high", Response: "A"}, Confidence: Confidence{Score: 0.9}},
+		{SubCallResult: SubCallResult{Name: "low", Response: "B"}, Confidence: Confidence{Score: 0.3}},
+	}
+
+	prompt := buildWeightedPrompt("task", results, NormalizeWeights(results))
+
+	assert.Contains(t, prompt, "### Source 1 (Confidence: 90%, Weight: 90%)")
+	assert.Contains(t, prompt, "### Source 2 (Confidence: 30%, Weight: 10%)")
+	assert.Contains(t, prompt, "prefer the higher-confidence source")
+}
+
 func TestDefaultWeightedConfig(t *testing.T) {
 	cfg := DefaultWeightedConfig()
 
@@ -25,6 +25,9 @@ type TerminationTracker struct {
 	// Task classification for context-aware decisions
 	taskType TaskType
 
+	// Which of printed output and return value is the answer
+	policy OutputPolicy
+
 	// Configuration
 	stabilityThreshold int     // Number of stable answers needed
 	minConfidence      float64 // Minimum confidence for early termination
@@ -40,6 +43,12 @@ func NewTerminationTracker(taskType TaskType) *TerminationTracker {
 	}
 }
 
+// SetOutputPolicy sets which part of an iteration's result is checked for a
+// stable or simple answer.
+func (t *TerminationTracker) SetOutputPolicy(policy OutputPolicy) {
+	t.policy = policy
+}
+
 // IterationResult contains information about an iteration for termination checking.
 type IterationResult struct {
 	// HasFinal indicates if FINAL() was called
@@ -54,6 +63,9 @@ type IterationResult struct {
 	// REPLOutput is the output from REPL execution
 	REPLOutput string
 
+	// ReturnValue is the value of the code's last expression
+	ReturnValue string
+
 	// REPLError is any error from REPL execution
 	REPLError string
 
@@ -99,8 +111,8 @@ func (t *TerminationTracker) CheckTermination(result *IterationResult) Terminati
 
 // checkAnswerStability checks if the answer has stabilized across iterations.
 func (t *TerminationTracker) checkAnswerStability(result *IterationResult) TerminationCheck {
-	// Use REPL output as the "answer" for stability checking
-	currentAnswer := normalizeAnswer(result.REPLOutput)
+	// Use the output policy's answer for stability checking
+	currentAnswer := normalizeAnswer(t.policy.answer(result.REPLOutput, result.ReturnValue))
 	if currentAnswer == "" {
 		return TerminationCheck{ShouldTerminate: false}
 	}
@@ -130,10 +142,11 @@ func (t *TerminationTracker) checkSimpleTaskCompletion(result *IterationResult)
 		return TerminationCheck{ShouldTerminate: false}
 	}
 
-	// Check if the code is a simple one-liner that produced output
-	if isSimpleComputation(result.CodeExecuted) && result.REPLOutput != "" && result.REPLError == "" {
-		// Check if output looks like a final answer (number, short string)
-		if looksLikeSimpleAnswer(result.REPLOutput) {
+	// Check if the code is a simple one-liner that produced an answer
+	answer := t.policy.answer(result.REPLOutput, result.ReturnValue)
+	if isSimpleComputation(result.CodeExecuted) && answer != "" && result.REPLError == "" {
+		// Check if the answer looks final (number, short string)
+		if looksLikeSimpleAnswer(answer) {
 			return TerminationCheck{
 				ShouldTerminate: true,
 				Reason:          "simple computation completed with clear output",
@@ -0,0 +1,116 @@
+package tot
+
+import (
+	"strings"
+	"sync"
+)
+
+// EvaluationCache memoizes evaluator results by normalized thought text so
+// identical thoughts in different branches, or in later explorations, skip
+// the evaluator. It is safe for concurrent use and may be shared by several
+// trees via SetEvaluationCache.
+type EvaluationCache struct {
+	mu      sync.Mutex
+	entries map[string]EvaluationResult
+	order   []string
+
+	contextSensitive bool
+	maxEntries       int
+
+	hits   int64
+	misses int64
+}
+
+// EvaluationCacheConfig configures an EvaluationCache.
+type EvaluationCacheConfig struct {
+	// ContextSensitive keys entries by the whole path from the root rather
+	// than the thought alone. Use it when the evaluator judges a thought
+	// relative to its ancestors, so a result is never reused under a
+	// different ancestry.
+	ContextSensitive bool
+
+	// MaxEntries bounds the cache size; the oldest entries are evicted
+	// first. Zero means unbounded.
+	MaxEntries int
+}
+
+// NewEvaluationCache creates an empty evaluation cache.
+func NewEvaluationCache(config EvaluationCacheConfig) *EvaluationCache {
+	return &EvaluationCache{
+		entries:          make(map[string]EvaluationResult),
+		contextSensitive: config.ContextSensitive,
+		maxEntries:       config.MaxEntries,
+	}
+}
+
+// Get returns the cached evaluation for node, if any.
+func (c *EvaluationCache) Get(node *ThoughtNode) (*EvaluationResult, bool) {
+	key := c.key(node)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	result, ok := c.entries[key]
+	if !ok {
+		c.misses++
+		return nil, false
+	}
+	c.hits++
+	return &result, true
+}
+
+// Put stores the evaluation for node.
+func (c *EvaluationCache) Put(node *ThoughtNode, result *EvaluationResult) {
+	if result == nil {
+		return
+	}
+	key := c.key(node)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.entries[key]; !ok {
+		c.order = append(c.order, key)
+	}
+	c.entries[key] = *result
+
+	for c.maxEntries > 0 && len(c.order) > c.maxEntries {
+		delete(c.entries, c.order[0])
+		c.order = c.order[1:]
+	}
+}
+
+// Len returns the number of cached evaluations.
+func (c *EvaluationCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+// Stats returns the cache's lifetime hit and miss counts across all trees
+// sharing it.
+func (c *EvaluationCache) Stats() (hits, misses int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.hits, c.misses
+}
+
+// key builds the cache key for node.
+func (c *EvaluationCache) key(node *ThoughtNode) string {
+	if !c.contextSensitive {
+		return normalizeThought(node.Thought)
+	}
+
+	path := node.PathThoughts()
+	for i := range path {
+		path[i] = normalizeThought(path[i])
+	}
+	// Thoughts are plain text, so NUL separates path elements unambiguously.
+	return strings.Join(path, "\x00")
+}
+
+// normalizeThought lowercases a thought and collapses whitespace so trivial
+// formatting differences share a cache entry.
+func normalizeThought(thought string) string {
+	return strings.Join(strings.Fields(strings.ToLower(thought)), " ")
+}
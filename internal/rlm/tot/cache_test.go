@@ -0,0 +1,175 @@
+package tot
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingEvaluator counts evaluator calls and scores thoughts relative to
+// their parent: "refine" is good after "plan" and poor anywhere else.
+type countingEvaluator struct {
+	calls int64
+}
+
+func (e *countingEvaluator) EvaluateThought(_ context.Context, node *ThoughtNode) (*EvaluationResult, error) {
+	atomic.AddInt64(&e.calls, 1)
+
+	value := 0.5
+	if node.Thought == "refine" && node.Parent != nil {
+		if node.Parent.Thought == "plan" {
+			value = 0.9
+		} else {
+			value = 0.1
+		}
+	}
+	return &EvaluationResult{Value: value, Confidence: 0.8}, nil
+}
+
+func repeatingTree(cache *EvaluationCache, evaluator Evaluator) *ThoughtTree {
+	config := ToTConfig{
+		MaxBranches:    2,
+		MaxDepth:       3,
+		ValueThreshold: 0.3,
+		Strategy:       StrategyBFS,
+		MaxNodes:       100,
+		Timeout:        10 * time.Second,
+	}
+	generator := &MockGenerator{
+		ThoughtsFunc: func(*ThoughtNode, int) []string {
+			return []string{"Same idea", "same   IDEA"}
+		},
+	}
+
+	tree := NewThoughtTree(config, evaluator, generator)
+	tree.SetEvaluationCache(cache)
+	return tree
+}
+
+func TestEvaluationCache_RepeatedThoughtsHit(t *testing.T) {
+	evaluator := &countingEvaluator{}
+	cache := NewEvaluationCache(EvaluationCacheConfig{})
+	tree := repeatingTree(cache, evaluator)
+
+	result, err := tree.ExploreWithBFS(context.Background(), "problem")
+	if err != nil {
+		t.Fatalf("ExploreWithBFS failed: %v", err)
+	}
+
+	// Only the root and the first "same idea" reach the evaluator; every
+	// other node normalizes to the same key.
+	if got := atomic.LoadInt64(&evaluator.calls); got != 2 {
+		t.Errorf("evaluator calls = %d, want 2", got)
+	}
+	if result.CacheMisses != 2 {
+		t.Errorf("CacheMisses = %d, want 2", result.CacheMisses)
+	}
+	if result.CacheHits != int64(result.NodesExplored)-2 {
+		t.Errorf("CacheHits = %d, want %d", result.CacheHits, result.NodesExplored-2)
+	}
+	if rate := result.CacheHitRate(); rate <= 0.5 {
+		t.Errorf("CacheHitRate = %v, want > 0.5", rate)
+	}
+
+	metrics := tree.Metrics()
+	if metrics.CacheHits != result.CacheHits || metrics.CacheMisses != result.CacheMisses {
+		t.Errorf("tree metrics %d/%d do not match result %d/%d",
+			metrics.CacheHits, metrics.CacheMisses, result.CacheHits, result.CacheMisses)
+	}
+}
+
+func TestEvaluationCache_SharedAcrossTrees(t *testing.T) {
+	evaluator := &countingEvaluator{}
+	cache := NewEvaluationCache(EvaluationCacheConfig{})
+
+	if _, err := repeatingTree(cache, evaluator).ExploreWithBFS(context.Background(), "problem"); err != nil {
+		t.Fatalf("first exploration failed: %v", err)
+	}
+	calls := atomic.LoadInt64(&evaluator.calls)
+
+	result, err := repeatingTree(cache, evaluator).ExploreWithBFS(context.Background(), "problem")
+	if err != nil {
+		t.Fatalf("second exploration failed: %v", err)
+	}
+
+	if got := atomic.LoadInt64(&evaluator.calls); got != calls {
+		t.Errorf("second exploration called the evaluator %d times", got-calls)
+	}
+	if result.CacheMisses != 0 {
+		t.Errorf("CacheMisses = %d, want 0", result.CacheMisses)
+	}
+	if result.CacheHitRate() != 1 {
+		t.Errorf("CacheHitRate = %v, want 1", result.CacheHitRate())
+	}
+
+	hits, misses := cache.Stats()
+	if misses != calls || hits == 0 {
+		t.Errorf("cache Stats() = %d hits, %d misses", hits, misses)
+	}
+}
+
+func TestEvaluationCache_ContextSensitive(t *testing.T) {
+	root := NewThoughtNode("root", "problem", nil)
+	plan := NewThoughtNode("a", "plan", root)
+	guess := NewThoughtNode("b", "guess", root)
+	afterPlan := NewThoughtNode("c", "refine", plan)
+	afterGuess := NewThoughtNode("d", "refine", guess)
+
+	evaluator := &countingEvaluator{}
+	for _, tc := range []struct {
+		name          string
+		config        EvaluationCacheConfig
+		wantReuse     bool
+		wantEvaluated int64
+	}{
+		{"thought only", EvaluationCacheConfig{}, true, 1},
+		{"context sensitive", EvaluationCacheConfig{ContextSensitive: true}, false, 2},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			atomic.StoreInt64(&evaluator.calls, 0)
+			tree := NewThoughtTree(DefaultToTConfig(), evaluator, &MockGenerator{})
+			tree.SetEvaluationCache(NewEvaluationCache(tc.config))
+
+			if err := tree.Evaluate(context.Background(), afterPlan); err != nil {
+				t.Fatalf("Evaluate failed: %v", err)
+			}
+			if err := tree.Evaluate(context.Background(), afterGuess); err != nil {
+				t.Fatalf("Evaluate failed: %v", err)
+			}
+
+			if got := atomic.LoadInt64(&evaluator.calls); got != tc.wantEvaluated {
+				t.Errorf("evaluator calls = %d, want %d", got, tc.wantEvaluated)
+			}
+			reused := afterGuess.ValueEstimate == afterPlan.ValueEstimate
+			if reused != tc.wantReuse {
+				t.Errorf("value after guess = %v, after plan = %v; reuse = %v, want %v",
+					afterGuess.ValueEstimate, afterPlan.ValueEstimate, reused, tc.wantReuse)
+			}
+		})
+	}
+}
+
+func TestEvaluationCache_MaxEntries(t *testing.T) {
+	cache := NewEvaluationCache(EvaluationCacheConfig{MaxEntries: 2})
+	for _, thought := range []string{"one", "two", "three"} {
+		cache.Put(NewThoughtNode(thought, thought, nil), &EvaluationResult{Value: 0.5})
+	}
+
+	if cache.Len() != 2 {
+		t.Fatalf("Len = %d, want 2", cache.Len())
+	}
+	if _, ok := cache.Get(NewThoughtNode("x", "one", nil)); ok {
+		t.Error("oldest entry should have been evicted")
+	}
+	if _, ok := cache.Get(NewThoughtNode("y", "Three", nil)); !ok {
+		t.Error("newest entry should still be cached")
+	}
+}
+
+func TestNormalizeThought(t *testing.T) {
+	got := normalizeThought("  Try\tthe   SECOND\napproach ")
+	if want := "try the second approach"; got != want {
+		t.Errorf("normalizeThought = %q, want %q", got, want)
+	}
+}
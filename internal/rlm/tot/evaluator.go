@@ -34,8 +34,25 @@ type EvaluationResult struct {
 
 // Generator generates child thoughts from a node.
 type Generator interface {
-	// GenerateThoughts generates up to n child thoughts.
-	GenerateThoughts(ctx context.Context, node *ThoughtNode, n int) ([]string, error)
+	// GenerateThoughts generates up to n child thoughts. temperature is the
+	// sampling temperature chosen by the tree's schedule for this node's
+	// depth; generators that cannot control sampling may ignore it.
+	GenerateThoughts(ctx context.Context, node *ThoughtNode, n int, temperature float64) ([]string, error)
+}
+
+// PathVerifier checks a complete reasoning path for internal consistency.
+type PathVerifier interface {
+	// VerifyPath scores the path from the root to a candidate solution.
+	VerifyPath(ctx context.Context, path []*ThoughtNode) (*PathVerification, error)
+}
+
+// PathVerification is the result of verifying a reasoning path.
+type PathVerification struct {
+	// Score is the path quality (0-1).
+	Score float64
+
+	// Reasoning explains the score.
+	Reasoning string
 }
 
 // LLMClient is the interface for LLM operations.
@@ -44,6 +61,15 @@ type LLMClient interface {
 	Complete(ctx context.Context, prompt string, maxTokens int) (string, error)
 }
 
+// TemperatureClient is an LLMClient that can sample at a given temperature.
+// LLMGenerator uses it when available and falls back to Complete otherwise.
+type TemperatureClient interface {
+	LLMClient
+
+	// CompleteWithTemperature is Complete with an explicit sampling temperature.
+	CompleteWithTemperature(ctx context.Context, prompt string, maxTokens int, temperature float64) (string, error)
+}
+
 // LLMEvaluator uses an LLM to evaluate thoughts.
 type LLMEvaluator struct {
 	client       LLMClient
@@ -142,6 +168,72 @@ func parseEvaluationResponse(response string) (*EvaluationResult, error) {
 	return result, nil
 }
 
+// LLMPathVerifier uses an LLM to check a reasoning path for consistency.
+type LLMPathVerifier struct {
+	client       LLMClient
+	systemPrompt string
+}
+
+// NewLLMPathVerifier creates an LLM-based path verifier.
+func NewLLMPathVerifier(client LLMClient) *LLMPathVerifier {
+	return &LLMPathVerifier{
+		client: client,
+		systemPrompt: `You are verifying a complete chain of reasoning that claims to solve a problem.
+Check that each step follows from the previous ones, that no step contradicts
+another, and that the final step actually answers the problem.
+Rate the path on a scale of 0 to 1, where 0 is incoherent and 1 is fully consistent.
+
+Respond in this exact format:
+SCORE: [0.0-1.0]
+REASONING: [brief explanation]`,
+	}
+}
+
+// VerifyPath scores the reasoning path using the LLM.
+func (v *LLMPathVerifier) VerifyPath(ctx context.Context, path []*ThoughtNode) (*PathVerification, error) {
+	var contextBuilder strings.Builder
+
+	contextBuilder.WriteString("Problem: ")
+	if len(path) > 0 {
+		contextBuilder.WriteString(path[0].Thought)
+	}
+	contextBuilder.WriteString("\n\nReasoning path:\n")
+
+	for i, node := range path[1:] {
+		contextBuilder.WriteString(fmt.Sprintf("%d. %s\n", i+1, node.Thought))
+	}
+
+	prompt := fmt.Sprintf("%s\n\n%s\n\nVerify the reasoning path.",
+		v.systemPrompt, contextBuilder.String())
+
+	response, err := v.client.Complete(ctx, prompt, 500)
+	if err != nil {
+		return nil, fmt.Errorf("llm complete: %w", err)
+	}
+
+	return parseVerificationResponse(response), nil
+}
+
+// parseVerificationResponse parses the LLM response into a PathVerification.
+// A missing score is treated as zero so an unparseable answer never passes.
+func parseVerificationResponse(response string) *PathVerification {
+	result := &PathVerification{}
+
+	scoreRe := regexp.MustCompile(`SCORE:\s*([\d.]+)`)
+	if matches := scoreRe.FindStringSubmatch(response); len(matches) > 1 {
+		if s, err := strconv.ParseFloat(matches[1], 64); err == nil {
+			result.Score = clamp(s, 0, 1)
+		}
+	}
+
+	reasonRe := regexp.MustCompile(`REASONING:\s*(.+?)$`)
+	if matches := reasonRe.FindStringSubmatch(response); len(matches) > 1 {
+		result.Reasoning = strings.TrimSpace(matches[1])
+	}
+
+	return result
+}
+
 // LLMGenerator uses an LLM to generate child thoughts.
 type LLMGenerator struct {
 	client       LLMClient
@@ -165,7 +257,7 @@ Format your response as a numbered list:
 }
 
 // GenerateThoughts generates child thoughts using the LLM.
-func (g *LLMGenerator) GenerateThoughts(ctx context.Context, node *ThoughtNode, n int) ([]string, error) {
+func (g *LLMGenerator) GenerateThoughts(ctx context.Context, node *ThoughtNode, n int, temperature float64) ([]string, error) {
 	// Build context from path
 	path := node.PathThoughts()
 	var contextBuilder strings.Builder
@@ -183,7 +275,13 @@ func (g *LLMGenerator) GenerateThoughts(ctx context.Context, node *ThoughtNode,
 	prompt := fmt.Sprintf("%s\n\n%s\n\nGenerate %d possible next reasoning steps.",
 		g.systemPrompt, contextBuilder.String(), n)
 
-	response, err := g.client.Complete(ctx, prompt, 1000)
+	var response string
+	var err error
+	if tc, ok := g.client.(TemperatureClient); ok {
+		response, err = tc.CompleteWithTemperature(ctx, prompt, 1000, temperature)
+	} else {
+		response, err = g.client.Complete(ctx, prompt, 1000)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("llm complete: %w", err)
 	}
@@ -248,8 +346,8 @@ type MockGenerator struct {
 	ThoughtsFunc func(*ThoughtNode, int) []string
 }
 
-// GenerateThoughts generates using the mock function.
-func (g *MockGenerator) GenerateThoughts(_ context.Context, node *ThoughtNode, n int) ([]string, error) {
+// GenerateThoughts generates using the mock function; temperature is ignored.
+func (g *MockGenerator) GenerateThoughts(_ context.Context, node *ThoughtNode, n int, _ float64) ([]string, error) {
 	if g.ThoughtsFunc != nil {
 		return g.ThoughtsFunc(node, n), nil
 	}
@@ -0,0 +1,215 @@
+package tot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// MultiStrategyReport describes an ExploreMultiStrategy run.
+type MultiStrategyReport struct {
+	// Winner is the strategy whose solution was returned, or empty if no
+	// strategy found one.
+	Winner Strategy
+
+	// Runs holds each strategy's run in the order the strategies were
+	// given. Losing runs are as they stood when they were cancelled.
+	Runs []StrategyRun
+}
+
+// Run returns the run of strategy, if it took part.
+func (r *MultiStrategyReport) Run(strategy Strategy) (StrategyRun, bool) {
+	for _, run := range r.Runs {
+		if run.Strategy == strategy {
+			return run, true
+		}
+	}
+	return StrategyRun{}, false
+}
+
+// StrategyRun is one strategy's share of a multi-strategy exploration.
+type StrategyRun struct {
+	Strategy Strategy
+
+	// NodesExplored is the nodes the strategy evaluated and NodesCreated
+	// the nodes its tree holds, root included.
+	NodesExplored int
+	NodesCreated  int64
+
+	MaxDepthReached int
+	Duration        time.Duration
+	TerminatedBy    TerminationReason
+
+	// Err is the error the run failed with. Runs cancelled because another
+	// strategy won have no error.
+	Err error
+}
+
+// nodeBudget caps the nodes created by trees exploring concurrently. A nil
+// budget is unlimited.
+type nodeBudget struct {
+	limit int64
+	used  int64
+}
+
+// exhausted reports whether no nodes are left.
+func (b *nodeBudget) exhausted() bool {
+	return b != nil && atomic.LoadInt64(&b.used) >= b.limit
+}
+
+// take reserves one node, reporting false if none are left.
+func (b *nodeBudget) take() bool {
+	if b == nil {
+		return true
+	}
+	for {
+		used := atomic.LoadInt64(&b.used)
+		if used >= b.limit {
+			return false
+		}
+		if atomic.CompareAndSwapInt64(&b.used, used, used+1) {
+			return true
+		}
+	}
+}
+
+// ExploreMultiStrategy explores problem with several strategies at once,
+// each on its own copy of the tree, and returns the first solution found,
+// cancelling the other strategies. It hedges against picking a strategy
+// that suits the problem poorly.
+//
+// The copies share the tree's configuration, evaluator, generator and path
+// verifier. They also share its evaluation cache, or a fresh one if it has
+// none, so a thought evaluated by one strategy is not evaluated again by
+// another. MaxNodes bounds the nodes created by all strategies together.
+// ProgressCallback receives events from every strategy and may be called
+// concurrently.
+//
+// The result is the winning strategy's, with NodesExplored and Duration
+// covering the whole run, and MultiStrategy reporting each strategy. If no
+// strategy finds a solution, the result is that of the strategy whose best
+// path ends highest.
+func (t *ThoughtTree) ExploreMultiStrategy(ctx context.Context, problem string, strategies []Strategy) (*ExplorationResult, error) {
+	if len(strategies) == 0 {
+		return nil, errors.New("no strategies to explore with")
+	}
+
+	start := time.Now()
+	cache := t.cache
+	if cache == nil {
+		cache = NewEvaluationCache(EvaluationCacheConfig{})
+	}
+	budget := &nodeBudget{limit: int64(t.config.MaxNodes)}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type outcome struct {
+		index  int
+		result *ExplorationResult
+		err    error
+	}
+	outcomes := make(chan outcome, len(strategies))
+	trees := make([]*ThoughtTree, len(strategies))
+	for i, strategy := range strategies {
+		config := t.config
+		config.Strategy = strategy
+		tree := NewThoughtTree(config, t.evaluator, t.generator)
+		tree.verifier = t.verifier
+		tree.cache = cache
+		tree.budget = budget
+		tree.Initialize(problem)
+		atomic.AddInt64(&budget.used, 1) // the root
+		trees[i] = tree
+
+		go func() {
+			result, err := tree.Explore(runCtx, problem)
+			outcomes <- outcome{index: i, result: result, err: err}
+		}()
+	}
+
+	// Wait for every strategy, so the losers' progress is final when
+	// reported.
+	results := make([]*ExplorationResult, len(strategies))
+	errs := make([]error, len(strategies))
+	winner := -1
+	for range strategies {
+		o := <-outcomes
+		results[o.index], errs[o.index] = o.result, o.err
+		if winner < 0 && o.err == nil && o.result != nil && o.result.Solution != nil {
+			winner = o.index
+			cancel()
+		}
+	}
+
+	report := &MultiStrategyReport{Runs: make([]StrategyRun, len(strategies))}
+	var explored int
+	for i, strategy := range strategies {
+		run := StrategyRun{
+			Strategy:     strategy,
+			NodesCreated: trees[i].NodeCount(),
+			Err:          errs[i],
+		}
+		if r := results[i]; r != nil {
+			run.NodesExplored = r.NodesExplored
+			run.MaxDepthReached = r.MaxDepthReached
+			run.Duration = r.Duration
+			run.TerminatedBy = r.TerminatedBy
+			explored += r.NodesExplored
+		} else if errors.Is(errs[i], context.Canceled) {
+			run.TerminatedBy = TerminatedCancelled
+		}
+		if winner >= 0 && i != winner && ctx.Err() == nil && errors.Is(run.Err, context.Canceled) {
+			run.Err = nil
+		}
+		report.Runs[i] = run
+	}
+
+	best := winner
+	if best < 0 {
+		best = bestFallback(results)
+	}
+	if best < 0 {
+		for i, err := range errs {
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", strategies[i], err)
+			}
+		}
+	}
+
+	result := results[best]
+	result.NodesExplored = explored
+	result.Duration = time.Since(start)
+	result.MultiStrategy = report
+	if winner >= 0 {
+		report.Winner = strategies[winner]
+		return result, nil
+	}
+	if err := ctx.Err(); err != nil {
+		result.TerminatedBy = TerminatedCancelled
+		return result, err
+	}
+	return result, nil
+}
+
+// bestFallback returns the index of the result whose best path ends at the
+// highest value, preferring earlier results on ties, or -1 if there are no
+// results.
+func bestFallback(results []*ExplorationResult) int {
+	best, bestValue := -1, -1.0
+	for i, r := range results {
+		if r == nil {
+			continue
+		}
+		value := 0.0
+		if n := len(r.BestPath); n > 0 {
+			value = r.BestPath[n-1].ValueEstimate
+		}
+		if value > bestValue {
+			best, bestValue = i, value
+		}
+	}
+	return best
+}
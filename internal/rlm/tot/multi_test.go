@@ -0,0 +1,189 @@
+package tot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// pathGenerator names each child after its parent and branch, so every
+// thought spells out its path from the root: "p", "p.0", "p.0.2", ...
+type pathGenerator struct{}
+
+func (pathGenerator) GenerateThoughts(_ context.Context, node *ThoughtNode, n int, _ float64) ([]string, error) {
+	thoughts := make([]string, n)
+	for i := range thoughts {
+		thoughts[i] = fmt.Sprintf("%s.%d", node.Thought, i)
+	}
+	return thoughts, nil
+}
+
+func newMultiStrategyTree(maxNodes int, solution string) *ThoughtTree {
+	config := ToTConfig{
+		MaxBranches:    3,
+		MaxDepth:       5,
+		ValueThreshold: 0.3,
+		MaxNodes:       maxNodes,
+	}
+	evaluator := &MockEvaluator{
+		// The solution path looks unpromising, so value-guided strategies
+		// explore everything else first, while depth-first search walks
+		// straight down to it.
+		ValueFunc: func(node *ThoughtNode) float64 {
+			time.Sleep(time.Millisecond)
+			if solution != "" && strings.HasPrefix(solution, node.Thought) {
+				return 0.4
+			}
+			return 0.9
+		},
+		TerminalFunc: func(node *ThoughtNode) bool {
+			return node.Thought == solution
+		},
+	}
+	return NewThoughtTree(config, evaluator, pathGenerator{})
+}
+
+func TestExploreMultiStrategy_FastestStrategyWins(t *testing.T) {
+	tree := newMultiStrategyTree(5000, "p.0.0.0.0.0")
+	strategies := []Strategy{StrategyBFS, StrategyBestFirst, StrategyDFS}
+
+	result, err := tree.ExploreMultiStrategy(context.Background(), "p", strategies)
+	if err != nil {
+		t.Fatalf("ExploreMultiStrategy failed: %v", err)
+	}
+
+	if result.Solution == nil || result.Solution.Thought != "p.0.0.0.0.0" {
+		t.Fatalf("Expected solution p.0.0.0.0.0, got %+v", result.Solution)
+	}
+	if result.TerminatedBy != TerminatedSolution {
+		t.Errorf("Expected TerminatedSolution, got %s", result.TerminatedBy)
+	}
+
+	report := result.MultiStrategy
+	if report == nil {
+		t.Fatal("Expected a multi-strategy report")
+	}
+	if report.Winner != StrategyDFS {
+		t.Errorf("Expected DFS to win, got %q", report.Winner)
+	}
+	if len(report.Runs) != len(strategies) {
+		t.Fatalf("Expected %d runs, got %d", len(strategies), len(report.Runs))
+	}
+
+	var explored int
+	for i, run := range report.Runs {
+		if run.Strategy != strategies[i] {
+			t.Errorf("Run %d: expected %s, got %s", i, strategies[i], run.Strategy)
+		}
+		if run.Err != nil {
+			t.Errorf("%s: unexpected error %v", run.Strategy, run.Err)
+		}
+		explored += run.NodesExplored
+	}
+	if result.NodesExplored != explored {
+		t.Errorf("Expected %d nodes explored across runs, got %d", explored, result.NodesExplored)
+	}
+
+	dfs, _ := report.Run(StrategyDFS)
+	if dfs.TerminatedBy != TerminatedSolution {
+		t.Errorf("DFS: expected TerminatedSolution, got %s", dfs.TerminatedBy)
+	}
+	for _, strategy := range []Strategy{StrategyBFS, StrategyBestFirst} {
+		run, ok := report.Run(strategy)
+		if !ok {
+			t.Fatalf("Missing run for %s", strategy)
+		}
+		if run.TerminatedBy != TerminatedCancelled {
+			t.Errorf("%s: expected TerminatedCancelled, got %s", strategy, run.TerminatedBy)
+		}
+		if run.NodesExplored == 0 {
+			t.Errorf("%s: expected progress before cancellation", strategy)
+		}
+	}
+}
+
+func TestExploreMultiStrategy_SharedNodeBudget(t *testing.T) {
+	tree := newMultiStrategyTree(30, "")
+	cache := NewEvaluationCache(EvaluationCacheConfig{})
+	tree.SetEvaluationCache(cache)
+
+	result, err := tree.ExploreMultiStrategy(context.Background(), "p",
+		[]Strategy{StrategyBFS, StrategyDFS, StrategyBestFirst})
+	if err != nil {
+		t.Fatalf("ExploreMultiStrategy failed: %v", err)
+	}
+
+	if result.Solution != nil {
+		t.Errorf("Expected no solution, got %s", result.Solution.Thought)
+	}
+	if result.MultiStrategy.Winner != "" {
+		t.Errorf("Expected no winner, got %q", result.MultiStrategy.Winner)
+	}
+	if len(result.BestPath) == 0 {
+		t.Error("Expected a best path without a solution")
+	}
+
+	var created int64
+	for _, run := range result.MultiStrategy.Runs {
+		if run.TerminatedBy != TerminatedExhausted {
+			t.Errorf("%s: expected TerminatedExhausted, got %s", run.Strategy, run.TerminatedBy)
+		}
+		created += run.NodesCreated
+	}
+	if created > 30 {
+		t.Errorf("Expected at most 30 nodes across strategies, got %d", created)
+	}
+
+	if cache.Len() == 0 {
+		t.Error("Expected the tree's evaluation cache to be used")
+	}
+}
+
+func TestExploreMultiStrategy_ParentCancelled(t *testing.T) {
+	tree := newMultiStrategyTree(5000, "")
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	result, err := tree.ExploreMultiStrategy(ctx, "p", []Strategy{StrategyBFS, StrategyBestFirst})
+	if err == nil {
+		t.Fatal("Expected cancellation error")
+	}
+	if result == nil {
+		t.Fatal("Expected a partial result")
+	}
+	if result.TerminatedBy != TerminatedCancelled {
+		t.Errorf("Expected TerminatedCancelled, got %s", result.TerminatedBy)
+	}
+	for _, run := range result.MultiStrategy.Runs {
+		if run.Err == nil {
+			t.Errorf("%s: expected the cancellation to be reported", run.Strategy)
+		}
+	}
+}
+
+func TestExploreMultiStrategy_NoStrategies(t *testing.T) {
+	tree := newMultiStrategyTree(10, "")
+	if _, err := tree.ExploreMultiStrategy(context.Background(), "p", nil); err == nil {
+		t.Error("Expected error without strategies")
+	}
+}
+
+func TestNodeBudget(t *testing.T) {
+	var unlimited *nodeBudget
+	if unlimited.exhausted() || !unlimited.take() {
+		t.Error("nil budget should be unlimited")
+	}
+
+	b := &nodeBudget{limit: 2}
+	if !b.take() || !b.take() {
+		t.Fatal("Expected two nodes")
+	}
+	if b.take() {
+		t.Error("Expected budget to be spent")
+	}
+	if !b.exhausted() {
+		t.Error("Expected exhausted budget")
+	}
+}
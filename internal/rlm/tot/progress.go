@@ -0,0 +1,82 @@
+package tot
+
+import "time"
+
+// ProgressEventType indicates the type of exploration progress event.
+type ProgressEventType string
+
+const (
+	// ProgressExpand signals a node was expanded into children.
+	ProgressExpand ProgressEventType = "expand"
+
+	// ProgressEvaluate signals a node was evaluated.
+	ProgressEvaluate ProgressEventType = "evaluate"
+
+	// ProgressPrune signals a node was pruned below the value threshold.
+	ProgressPrune ProgressEventType = "prune"
+
+	// ProgressSolution signals a solution was accepted.
+	ProgressSolution ProgressEventType = "solution"
+
+	// ProgressComplete signals exploration finished. It is always the last
+	// event of an exploration, including cancelled ones.
+	ProgressComplete ProgressEventType = "complete"
+)
+
+// ProgressEvent contains information about exploration progress.
+type ProgressEvent struct {
+	// Type is the event type.
+	Type ProgressEventType
+
+	// Timestamp is when the event occurred.
+	Timestamp time.Time
+
+	// NodeID is the node the event concerns (empty for Complete events).
+	NodeID string
+
+	// Depth is the node's depth, or the deepest node for Complete events.
+	Depth int
+
+	// NodesExplored is the number of nodes evaluated so far.
+	NodesExplored int
+
+	// BestValue is the highest value of any evaluated node so far.
+	BestValue float64
+
+	// Children is the number of children created (for Expand events).
+	Children int
+
+	// TerminatedBy is why exploration stopped (for Complete events).
+	TerminatedBy TerminationReason
+}
+
+// ProgressCallback is called for each progress event during exploration.
+// It runs on the exploring goroutine, so implementations should be
+// non-blocking and fast.
+type ProgressCallback func(event ProgressEvent)
+
+// emit sends a progress event if a callback is configured.
+func (t *ThoughtTree) emit(event ProgressEvent) {
+	if t.config.ProgressCallback == nil {
+		return
+	}
+	event.Timestamp = time.Now()
+	event.NodesExplored = int(t.Metrics().Evaluations)
+	event.BestValue = t.bestValue()
+	t.config.ProgressCallback(event)
+}
+
+// recordValue tracks the best value seen for progress reporting.
+func (t *ThoughtTree) recordValue(value float64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if value > t.best {
+		t.best = value
+	}
+}
+
+func (t *ThoughtTree) bestValue() float64 {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.best
+}
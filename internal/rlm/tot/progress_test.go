@@ -0,0 +1,172 @@
+package tot
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func progressConfig(events *[]ProgressEvent) ToTConfig {
+	return ToTConfig{
+		MaxBranches:    2,
+		MaxDepth:       3,
+		ValueThreshold: 0.3,
+		Strategy:       StrategyBFS,
+		MaxNodes:       100,
+		Timeout:        10 * time.Second,
+		ProgressCallback: func(e ProgressEvent) {
+			*events = append(*events, e)
+		},
+	}
+}
+
+func TestProgress_EventsFireForEachType(t *testing.T) {
+	var events []ProgressEvent
+	evaluator := &testEvaluator{
+		solutionKeyword: "solution",
+		valueFunc: func(s string) float64 {
+			if strings.HasSuffix(s, "-b1") {
+				return 0.1 // pruned
+			}
+			return 0.7
+		},
+	}
+	generator := &testGenerator{childrenPerNode: 2, solutionDepth: 2, solutionBranch: 0}
+	tree := NewThoughtTree(progressConfig(&events), evaluator, generator)
+
+	result, err := tree.ExploreWithBFS(context.Background(), "goal")
+	if err != nil {
+		t.Fatalf("ExploreWithBFS failed: %v", err)
+	}
+	if result.Solution == nil {
+		t.Fatal("expected a solution")
+	}
+
+	seen := make(map[ProgressEventType]int)
+	for i, e := range events {
+		seen[e.Type]++
+		if e.Timestamp.IsZero() {
+			t.Errorf("event %d has no timestamp", i)
+		}
+		if i > 0 && e.NodesExplored < events[i-1].NodesExplored {
+			t.Errorf("NodesExplored went backwards at event %d", i)
+		}
+	}
+	for _, typ := range []ProgressEventType{ProgressExpand, ProgressEvaluate, ProgressPrune, ProgressSolution, ProgressComplete} {
+		if seen[typ] == 0 {
+			t.Errorf("no %s event", typ)
+		}
+	}
+	if seen[ProgressComplete] != 1 {
+		t.Errorf("got %d complete events, want 1", seen[ProgressComplete])
+	}
+
+	last := events[len(events)-1]
+	if last.Type != ProgressComplete || last.TerminatedBy != TerminatedSolution {
+		t.Errorf("last event = %s/%s, want complete/%s", last.Type, last.TerminatedBy, TerminatedSolution)
+	}
+	if last.BestValue != 0.7 {
+		t.Errorf("BestValue = %v, want 0.7", last.BestValue)
+	}
+	if last.NodesExplored != int(tree.Metrics().Evaluations) {
+		t.Errorf("NodesExplored = %d, want %d", last.NodesExplored, tree.Metrics().Evaluations)
+	}
+}
+
+// cancellingGenerator cancels the exploration after its first expansion.
+type cancellingGenerator struct {
+	cancel context.CancelFunc
+}
+
+func (g *cancellingGenerator) GenerateThoughts(_ context.Context, node *ThoughtNode, n int, _ float64) ([]string, error) {
+	g.cancel()
+	return []string{"a", "b"}, nil
+}
+
+// countingMockEvaluator counts evaluations.
+type countingMockEvaluator struct {
+	calls int64
+}
+
+func (e *countingMockEvaluator) EvaluateThought(_ context.Context, _ *ThoughtNode) (*EvaluationResult, error) {
+	atomic.AddInt64(&e.calls, 1)
+	return &EvaluationResult{Value: 0.5, Confidence: 0.5}, nil
+}
+
+func TestProgress_CancellationEmitsTerminalEvent(t *testing.T) {
+	strategies := []Strategy{StrategyBFS, StrategyDFS, StrategyBestFirst}
+
+	for _, strategy := range strategies {
+		t.Run(string(strategy), func(t *testing.T) {
+			var events []ProgressEvent
+			config := progressConfig(&events)
+			config.Strategy = strategy
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			evaluator := &countingMockEvaluator{}
+			tree := NewThoughtTree(config, evaluator, &cancellingGenerator{cancel: cancel})
+
+			result, err := tree.Explore(ctx, "goal")
+			if !errors.Is(err, context.Canceled) {
+				t.Fatalf("err = %v, want context.Canceled", err)
+			}
+			if result.TerminatedBy != TerminatedCancelled {
+				t.Errorf("TerminatedBy = %s, want %s", result.TerminatedBy, TerminatedCancelled)
+			}
+
+			// Only the root is evaluated; children created after the
+			// cancellation never reach the evaluator.
+			if calls := atomic.LoadInt64(&evaluator.calls); calls != 1 {
+				t.Errorf("evaluator called %d times after cancellation", calls-1)
+			}
+
+			last := events[len(events)-1]
+			if last.Type != ProgressComplete || last.TerminatedBy != TerminatedCancelled {
+				t.Errorf("last event = %s/%s, want complete/%s", last.Type, last.TerminatedBy, TerminatedCancelled)
+			}
+		})
+	}
+}
+
+func TestProgress_CancelledBeforeStart(t *testing.T) {
+	var events []ProgressEvent
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tree := NewThoughtTree(progressConfig(&events), &MockEvaluator{}, &MockGenerator{})
+	if _, err := tree.ExploreWithMCTS(ctx, "goal", 10); !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+
+	if len(events) != 1 || events[0].Type != ProgressComplete || events[0].TerminatedBy != TerminatedCancelled {
+		t.Errorf("events = %+v, want a single cancelled complete event", events)
+	}
+}
+
+func TestProgress_IDDFSEmitsSingleComplete(t *testing.T) {
+	var events []ProgressEvent
+	evaluator := &testEvaluator{solutionKeyword: "solution"}
+	generator := &testGenerator{childrenPerNode: 2, solutionDepth: 3, solutionBranch: 1}
+	tree := NewThoughtTree(progressConfig(&events), evaluator, generator)
+
+	if _, err := tree.ExploreWithIDDFS(context.Background(), "goal"); err != nil {
+		t.Fatalf("ExploreWithIDDFS failed: %v", err)
+	}
+
+	var completes int
+	for _, e := range events {
+		if e.Type == ProgressComplete {
+			completes++
+		}
+	}
+	if completes != 1 {
+		t.Errorf("got %d complete events, want 1", completes)
+	}
+	if last := events[len(events)-1]; last.Type != ProgressComplete {
+		t.Errorf("last event = %s, want complete", last.Type)
+	}
+}
@@ -0,0 +1,67 @@
+package tot
+
+import (
+	"math"
+	"math/rand/v2"
+)
+
+// newSampler returns the random source for stochastic selection, or nil
+// when config.SamplingTemperature leaves selection deterministic. A zero
+// SamplingSeed seeds from the runtime's random source.
+func newSampler(config ToTConfig) *rand.Rand {
+	if config.SamplingTemperature <= 0 {
+		return nil
+	}
+	seed := uint64(config.SamplingSeed)
+	if seed == 0 {
+		seed = rand.Uint64()
+	}
+	return rand.New(rand.NewPCG(seed, seed))
+}
+
+// sampleIndex picks an index into nodes with probability proportional to
+// softmax(value/temperature). Values are shifted by their maximum first so
+// the exponentials cannot overflow.
+func sampleIndex(nodes []*ThoughtNode, temperature float64, rng *rand.Rand) int {
+	maxValue := math.Inf(-1)
+	for _, n := range nodes {
+		maxValue = math.Max(maxValue, n.ValueEstimate)
+	}
+
+	weights := make([]float64, len(nodes))
+	var total float64
+	for i, n := range nodes {
+		weights[i] = math.Exp((n.ValueEstimate - maxValue) / temperature)
+		total += weights[i]
+	}
+
+	r := rng.Float64() * total
+	for i, w := range weights {
+		r -= w
+		if r < 0 {
+			return i
+		}
+	}
+	return len(nodes) - 1
+}
+
+// sampleK selects k nodes without replacement by repeated softmax
+// sampling, in the order they were drawn. With no sampler it falls back to
+// selectTopK.
+func sampleK(nodes []*ThoughtNode, k int, temperature float64, rng *rand.Rand) []*ThoughtNode {
+	if rng == nil {
+		return selectTopK(nodes, k)
+	}
+	if len(nodes) <= k {
+		return nodes
+	}
+
+	pool := append([]*ThoughtNode(nil), nodes...)
+	result := make([]*ThoughtNode, 0, k)
+	for len(result) < k {
+		i := sampleIndex(pool, temperature, rng)
+		result = append(result, pool[i])
+		pool = append(pool[:i], pool[i+1:]...)
+	}
+	return result
+}
@@ -0,0 +1,184 @@
+package tot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// expansionRecorder records the thoughts of the nodes it generates
+// children for.
+type expansionRecorder struct {
+	testGenerator
+	mu       sync.Mutex
+	expanded []string
+}
+
+func (g *expansionRecorder) GenerateThoughts(ctx context.Context, node *ThoughtNode, n int, temp float64) ([]string, error) {
+	g.mu.Lock()
+	g.expanded = append(g.expanded, node.Thought)
+	g.mu.Unlock()
+	return g.testGenerator.GenerateThoughts(ctx, node, n, temp)
+}
+
+// offTopExpansions counts expanded nodes that are not on the all-b0 path,
+// which is the highest-valued under branchValue.
+func (g *expansionRecorder) offTopExpansions() int {
+	var n int
+	for _, thought := range g.expanded {
+		if thought != "root" && !strings.HasSuffix(thought, "-b0") {
+			n++
+		}
+	}
+	return n
+}
+
+// branchValue prefers branch 0, then 1, then 2.
+func branchValue(node *ThoughtNode) float64 {
+	switch {
+	case strings.HasSuffix(node.Thought, "-b0"):
+		return 0.9
+	case strings.HasSuffix(node.Thought, "-b1"):
+		return 0.6
+	default:
+		return 0.3
+	}
+}
+
+func samplingConfig(temperature float64, seed int64) ToTConfig {
+	return ToTConfig{
+		MaxBranches:         3,
+		MaxDepth:            4,
+		MaxNodes:            200,
+		SamplingTemperature: temperature,
+		SamplingSeed:        seed,
+	}
+}
+
+func valuedNodes(values ...float64) []*ThoughtNode {
+	nodes := make([]*ThoughtNode, len(values))
+	for i, v := range values {
+		nodes[i] = &ThoughtNode{ID: fmt.Sprintf("node-%d", i), ValueEstimate: v}
+	}
+	return nodes
+}
+
+func TestNewSampler_ZeroTemperatureIsDeterministic(t *testing.T) {
+	if newSampler(samplingConfig(0, 42)) != nil {
+		t.Fatal("Expected no sampler at temperature 0")
+	}
+
+	nodes := valuedNodes(0.3, 0.9, 0.1, 0.7, 0.5)
+	got := sampleK(nodes, 3, 0, nil)
+	want := selectTopK(nodes, 3)
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Position %d: expected %s, got %s", i, want[i].ID, got[i].ID)
+		}
+	}
+}
+
+func TestSampleK_HighTemperatureSometimesPicksNonTop(t *testing.T) {
+	nodes := valuedNodes(0.9, 0.6, 0.3)
+	rng := newSampler(samplingConfig(1.0, 7))
+
+	picked := make(map[string]int)
+	for i := 0; i < 200; i++ {
+		chosen := sampleK(nodes, 1, 1.0, rng)
+		if len(chosen) != 1 {
+			t.Fatalf("Expected 1 node, got %d", len(chosen))
+		}
+		picked[chosen[0].ID]++
+	}
+
+	if picked["node-0"] == 200 {
+		t.Error("Expected some picks other than the top node")
+	}
+	if picked["node-0"] < picked["node-2"] {
+		t.Errorf("Expected the top node to be favoured, got %v", picked)
+	}
+}
+
+func TestSampleK_WithoutReplacement(t *testing.T) {
+	nodes := valuedNodes(0.9, 0.6, 0.3, 0.1)
+	chosen := sampleK(nodes, 3, 5.0, newSampler(samplingConfig(5.0, 3)))
+
+	if len(chosen) != 3 {
+		t.Fatalf("Expected 3 nodes, got %d", len(chosen))
+	}
+	seen := make(map[string]bool)
+	for _, n := range chosen {
+		if seen[n.ID] {
+			t.Errorf("Node %s selected twice", n.ID)
+		}
+		seen[n.ID] = true
+	}
+}
+
+func TestSampleIndex_LowTemperatureApproachesArgmax(t *testing.T) {
+	nodes := valuedNodes(0.3, 0.9, 0.6)
+	rng := newSampler(samplingConfig(0.001, 11))
+	for i := 0; i < 100; i++ {
+		if got := sampleIndex(nodes, 0.001, rng); got != 1 {
+			t.Fatalf("Expected the top node, got index %d", got)
+		}
+	}
+}
+
+func TestExploreWithBeam_Sampling(t *testing.T) {
+	explore := func(temperature float64, seed int64) *expansionRecorder {
+		gen := &expansionRecorder{testGenerator: testGenerator{childrenPerNode: 3}}
+		tree := NewThoughtTree(samplingConfig(temperature, seed), &MockEvaluator{ValueFunc: branchValue}, gen)
+		if _, err := tree.ExploreWithBeam(context.Background(), "root", 1); err != nil {
+			t.Fatalf("ExploreWithBeam failed: %v", err)
+		}
+		return gen
+	}
+
+	deterministic := explore(0, 1)
+	if n := deterministic.offTopExpansions(); n != 0 {
+		t.Errorf("Expected temperature 0 to follow the top branch, got %d other expansions: %v", n, deterministic.expanded)
+	}
+
+	var offTop int
+	for seed := int64(1); seed <= 10; seed++ {
+		offTop += explore(1.0, seed).offTopExpansions()
+	}
+	if offTop == 0 {
+		t.Error("Expected sampling to expand some non-top nodes")
+	}
+
+	// The same seed reproduces the same exploration.
+	first, second := explore(1.0, 5), explore(1.0, 5)
+	if strings.Join(first.expanded, ",") != strings.Join(second.expanded, ",") {
+		t.Errorf("Expected identical runs for one seed:\n%v\n%v", first.expanded, second.expanded)
+	}
+}
+
+func TestExploreWithBestFirst_Sampling(t *testing.T) {
+	explore := func(temperature float64, seed int64) *expansionRecorder {
+		cfg := samplingConfig(temperature, seed)
+		cfg.MaxNodes = 10
+		gen := &expansionRecorder{testGenerator: testGenerator{childrenPerNode: 3}}
+		tree := NewThoughtTree(cfg, &MockEvaluator{ValueFunc: branchValue}, gen)
+		if _, err := tree.ExploreWithBestFirst(context.Background(), "root"); err != nil {
+			t.Fatalf("ExploreWithBestFirst failed: %v", err)
+		}
+		return gen
+	}
+
+	deterministic := explore(0, 1)
+	if n := deterministic.offTopExpansions(); n != 0 {
+		t.Errorf("Expected temperature 0 to follow the top branch, got %d other expansions: %v", n, deterministic.expanded)
+	}
+
+	var offTop int
+	for seed := int64(1); seed <= 10; seed++ {
+		offTop += explore(1.0, seed).offTopExpansions()
+	}
+	if offTop == 0 {
+		t.Error("Expected sampling to expand some non-top nodes")
+	}
+}
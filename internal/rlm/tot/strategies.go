@@ -4,6 +4,7 @@ import (
 	"container/heap"
 	"context"
 	"fmt"
+	"sync/atomic"
 	"time"
 )
 
@@ -26,6 +27,36 @@ type ExplorationResult struct {
 
 	// TerminatedBy indicates why exploration stopped.
 	TerminatedBy TerminationReason
+
+	// SolutionPathScore is the PathVerifier's score for the path to
+	// Solution. If every candidate was rejected it scores BestPath instead.
+	// It is zero when no verifier is configured.
+	SolutionPathScore float64
+
+	// RejectedSolutions counts terminal nodes whose paths failed verification.
+	RejectedSolutions int
+
+	// CacheHits and CacheMisses count evaluation cache lookups during this
+	// exploration. Both are zero when no EvaluationCache is set.
+	CacheHits   int64
+	CacheMisses int64
+
+	// MultiStrategy reports every strategy's run when the result comes
+	// from ExploreMultiStrategy; it is nil otherwise.
+	MultiStrategy *MultiStrategyReport
+
+	// Best rejected candidate, used as BestPath if no solution is accepted.
+	rejectedPath  []*ThoughtNode
+	rejectedScore float64
+}
+
+// CacheHitRate returns the fraction of evaluations served from the cache.
+func (r *ExplorationResult) CacheHitRate() float64 {
+	total := r.CacheHits + r.CacheMisses
+	if total == 0 {
+		return 0
+	}
+	return float64(r.CacheHits) / float64(total)
 }
 
 // TerminationReason indicates why exploration stopped.
@@ -49,6 +80,10 @@ func (t *ThoughtTree) Explore(ctx context.Context, goal string) (*ExplorationRes
 		return t.ExploreWithDFS(ctx, goal)
 	case StrategyBestFirst:
 		return t.ExploreWithBestFirst(ctx, goal)
+	case StrategyBeam:
+		return t.ExploreWithBeam(ctx, goal, t.config.MaxBranches)
+	case StrategyMCTS:
+		return t.ExploreWithMCTS(ctx, goal, t.config.MaxNodes)
 	default:
 		return t.ExploreWithBestFirst(ctx, goal)
 	}
@@ -60,6 +95,7 @@ func (t *ThoughtTree) Explore(ctx context.Context, goal string) (*ExplorationRes
 func (t *ThoughtTree) ExploreWithBFS(ctx context.Context, goal string) (*ExplorationResult, error) {
 	start := time.Now()
 	result := &ExplorationResult{}
+	defer t.beginExploration(ctx, result)()
 
 	if t.root == nil {
 		t.Initialize(goal)
@@ -71,9 +107,7 @@ func (t *ThoughtTree) ExploreWithBFS(ctx context.Context, goal string) (*Explora
 	}
 	result.NodesExplored++
 
-	if t.root.Status == StatusTerminal {
-		result.Solution = t.root
-		result.BestPath = t.root.Path()
+	if t.root.Status == StatusTerminal && t.acceptSolution(ctx, t.root, result) {
 		result.Duration = time.Since(start)
 		result.TerminatedBy = TerminatedSolution
 		return result, nil
@@ -90,7 +124,7 @@ func (t *ThoughtTree) ExploreWithBFS(ctx context.Context, goal string) (*Explora
 			result.Duration = time.Since(start)
 			result.MaxDepthReached = maxDepth
 			result.TerminatedBy = TerminatedCancelled
-			result.BestPath = t.findBestLeafPath()
+			result.BestPath = t.fallbackPath(result)
 			return result, ctx.Err()
 		default:
 		}
@@ -100,7 +134,7 @@ func (t *ThoughtTree) ExploreWithBFS(ctx context.Context, goal string) (*Explora
 			result.Duration = time.Since(start)
 			result.MaxDepthReached = maxDepth
 			result.TerminatedBy = TerminatedTimeout
-			result.BestPath = t.findBestLeafPath()
+			result.BestPath = t.fallbackPath(result)
 			return result, nil
 		}
 
@@ -138,9 +172,7 @@ func (t *ThoughtTree) ExploreWithBFS(ctx context.Context, goal string) (*Explora
 			result.NodesExplored++
 
 			// Check for solution
-			if child.Status == StatusTerminal {
-				result.Solution = child
-				result.BestPath = child.Path()
+			if child.Status == StatusTerminal && t.acceptSolution(ctx, child, result) {
 				result.Duration = time.Since(start)
 				result.MaxDepthReached = maxDepth
 				result.TerminatedBy = TerminatedSolution
@@ -155,11 +187,7 @@ func (t *ThoughtTree) ExploreWithBFS(ctx context.Context, goal string) (*Explora
 	}
 
 	// No solution found
-	result.Duration = time.Since(start)
-	result.MaxDepthReached = maxDepth
-	result.TerminatedBy = TerminatedExhausted
-	result.BestPath = t.findBestLeafPath()
-	return result, nil
+	return t.finishExhausted(ctx, result, start, maxDepth)
 }
 
 // ExploreWithDFS explores the tree depth-first.
@@ -168,6 +196,7 @@ func (t *ThoughtTree) ExploreWithBFS(ctx context.Context, goal string) (*Explora
 func (t *ThoughtTree) ExploreWithDFS(ctx context.Context, goal string) (*ExplorationResult, error) {
 	start := time.Now()
 	result := &ExplorationResult{}
+	defer t.beginExploration(ctx, result)()
 
 	if t.root == nil {
 		t.Initialize(goal)
@@ -179,9 +208,7 @@ func (t *ThoughtTree) ExploreWithDFS(ctx context.Context, goal string) (*Explora
 	}
 	result.NodesExplored++
 
-	if t.root.Status == StatusTerminal {
-		result.Solution = t.root
-		result.BestPath = t.root.Path()
+	if t.root.Status == StatusTerminal && t.acceptSolution(ctx, t.root, result) {
 		result.Duration = time.Since(start)
 		result.TerminatedBy = TerminatedSolution
 		return result, nil
@@ -198,7 +225,7 @@ func (t *ThoughtTree) ExploreWithDFS(ctx context.Context, goal string) (*Explora
 			result.Duration = time.Since(start)
 			result.MaxDepthReached = maxDepth
 			result.TerminatedBy = TerminatedCancelled
-			result.BestPath = t.findBestLeafPath()
+			result.BestPath = t.fallbackPath(result)
 			return result, ctx.Err()
 		default:
 		}
@@ -208,7 +235,7 @@ func (t *ThoughtTree) ExploreWithDFS(ctx context.Context, goal string) (*Explora
 			result.Duration = time.Since(start)
 			result.MaxDepthReached = maxDepth
 			result.TerminatedBy = TerminatedTimeout
-			result.BestPath = t.findBestLeafPath()
+			result.BestPath = t.fallbackPath(result)
 			return result, nil
 		}
 
@@ -246,9 +273,7 @@ func (t *ThoughtTree) ExploreWithDFS(ctx context.Context, goal string) (*Explora
 			result.NodesExplored++
 
 			// Check for solution
-			if child.Status == StatusTerminal {
-				result.Solution = child
-				result.BestPath = child.Path()
+			if child.Status == StatusTerminal && t.acceptSolution(ctx, child, result) {
 				result.Duration = time.Since(start)
 				result.MaxDepthReached = maxDepth
 				result.TerminatedBy = TerminatedSolution
@@ -263,11 +288,7 @@ func (t *ThoughtTree) ExploreWithDFS(ctx context.Context, goal string) (*Explora
 	}
 
 	// No solution found
-	result.Duration = time.Since(start)
-	result.MaxDepthReached = maxDepth
-	result.TerminatedBy = TerminatedExhausted
-	result.BestPath = t.findBestLeafPath()
-	return result, nil
+	return t.finishExhausted(ctx, result, start, maxDepth)
 }
 
 // ExploreWithBestFirst explores highest-value nodes first.
@@ -276,6 +297,7 @@ func (t *ThoughtTree) ExploreWithDFS(ctx context.Context, goal string) (*Explora
 func (t *ThoughtTree) ExploreWithBestFirst(ctx context.Context, goal string) (*ExplorationResult, error) {
 	start := time.Now()
 	result := &ExplorationResult{}
+	defer t.beginExploration(ctx, result)()
 
 	if t.root == nil {
 		t.Initialize(goal)
@@ -287,9 +309,7 @@ func (t *ThoughtTree) ExploreWithBestFirst(ctx context.Context, goal string) (*E
 	}
 	result.NodesExplored++
 
-	if t.root.Status == StatusTerminal {
-		result.Solution = t.root
-		result.BestPath = t.root.Path()
+	if t.root.Status == StatusTerminal && t.acceptSolution(ctx, t.root, result) {
 		result.Duration = time.Since(start)
 		result.TerminatedBy = TerminatedSolution
 		return result, nil
@@ -309,7 +329,7 @@ func (t *ThoughtTree) ExploreWithBestFirst(ctx context.Context, goal string) (*E
 			result.Duration = time.Since(start)
 			result.MaxDepthReached = maxDepth
 			result.TerminatedBy = TerminatedCancelled
-			result.BestPath = t.findBestLeafPath()
+			result.BestPath = t.fallbackPath(result)
 			return result, ctx.Err()
 		default:
 		}
@@ -319,12 +339,12 @@ func (t *ThoughtTree) ExploreWithBestFirst(ctx context.Context, goal string) (*E
 			result.Duration = time.Since(start)
 			result.MaxDepthReached = maxDepth
 			result.TerminatedBy = TerminatedTimeout
-			result.BestPath = t.findBestLeafPath()
+			result.BestPath = t.fallbackPath(result)
 			return result, nil
 		}
 
-		// Pop highest value node
-		node := heap.Pop(pq).(*ThoughtNode)
+		// Pop highest value node, or sample one when stochastic
+		node := t.popNext(pq)
 
 		// Track depth
 		if node.Depth > maxDepth {
@@ -355,9 +375,7 @@ func (t *ThoughtTree) ExploreWithBestFirst(ctx context.Context, goal string) (*E
 			result.NodesExplored++
 
 			// Check for solution
-			if child.Status == StatusTerminal {
-				result.Solution = child
-				result.BestPath = child.Path()
+			if child.Status == StatusTerminal && t.acceptSolution(ctx, child, result) {
 				result.Duration = time.Since(start)
 				result.MaxDepthReached = maxDepth
 				result.TerminatedBy = TerminatedSolution
@@ -372,11 +390,7 @@ func (t *ThoughtTree) ExploreWithBestFirst(ctx context.Context, goal string) (*E
 	}
 
 	// No solution found
-	result.Duration = time.Since(start)
-	result.MaxDepthReached = maxDepth
-	result.TerminatedBy = TerminatedExhausted
-	result.BestPath = t.findBestLeafPath()
-	return result, nil
+	return t.finishExhausted(ctx, result, start, maxDepth)
 }
 
 // ExploreWithBeam uses beam search - keep top-k nodes at each level.
@@ -384,6 +398,7 @@ func (t *ThoughtTree) ExploreWithBestFirst(ctx context.Context, goal string) (*E
 func (t *ThoughtTree) ExploreWithBeam(ctx context.Context, goal string, beamWidth int) (*ExplorationResult, error) {
 	start := time.Now()
 	result := &ExplorationResult{}
+	defer t.beginExploration(ctx, result)()
 
 	if t.root == nil {
 		t.Initialize(goal)
@@ -395,9 +410,7 @@ func (t *ThoughtTree) ExploreWithBeam(ctx context.Context, goal string, beamWidt
 	}
 	result.NodesExplored++
 
-	if t.root.Status == StatusTerminal {
-		result.Solution = t.root
-		result.BestPath = t.root.Path()
+	if t.root.Status == StatusTerminal && t.acceptSolution(ctx, t.root, result) {
 		result.Duration = time.Since(start)
 		result.TerminatedBy = TerminatedSolution
 		return result, nil
@@ -414,7 +427,7 @@ func (t *ThoughtTree) ExploreWithBeam(ctx context.Context, goal string, beamWidt
 			result.Duration = time.Since(start)
 			result.MaxDepthReached = maxDepth
 			result.TerminatedBy = TerminatedCancelled
-			result.BestPath = t.findBestLeafPath()
+			result.BestPath = t.fallbackPath(result)
 			return result, ctx.Err()
 		default:
 		}
@@ -424,7 +437,7 @@ func (t *ThoughtTree) ExploreWithBeam(ctx context.Context, goal string, beamWidt
 			result.Duration = time.Since(start)
 			result.MaxDepthReached = maxDepth
 			result.TerminatedBy = TerminatedTimeout
-			result.BestPath = t.findBestLeafPath()
+			result.BestPath = t.fallbackPath(result)
 			return result, nil
 		}
 
@@ -454,9 +467,7 @@ func (t *ThoughtTree) ExploreWithBeam(ctx context.Context, goal string, beamWidt
 				result.NodesExplored++
 
 				// Check for solution
-				if child.Status == StatusTerminal {
-					result.Solution = child
-					result.BestPath = child.Path()
+				if child.Status == StatusTerminal && t.acceptSolution(ctx, child, result) {
 					result.Duration = time.Since(start)
 					result.MaxDepthReached = maxDepth
 					result.TerminatedBy = TerminatedSolution
@@ -469,18 +480,99 @@ func (t *ThoughtTree) ExploreWithBeam(ctx context.Context, goal string, beamWidt
 			}
 		}
 
-		// Select top-k candidates for next beam
-		beam = selectTopK(candidates, beamWidth)
+		// Select top-k candidates for next beam, or sample k when stochastic
+		beam = sampleK(candidates, beamWidth, t.config.SamplingTemperature, t.sampler)
 	}
 
 	// No solution found
+	return t.finishExhausted(ctx, result, start, maxDepth)
+}
+
+// beginExploration snapshots the tree's cache counters and returns a
+// function, to be deferred, that records the lookups made since into result
+// and emits the Complete progress event. Nested explorations (IDDFS runs
+// DFS repeatedly) only emit Complete from the outermost call.
+func (t *ThoughtTree) beginExploration(ctx context.Context, result *ExplorationResult) func() {
+	hits := atomic.LoadInt64(&t.cacheHits)
+	misses := atomic.LoadInt64(&t.cacheMisses)
+	atomic.AddInt32(&t.active, 1)
+	return func() {
+		result.CacheHits = atomic.LoadInt64(&t.cacheHits) - hits
+		result.CacheMisses = atomic.LoadInt64(&t.cacheMisses) - misses
+		if atomic.AddInt32(&t.active, -1) > 0 {
+			return
+		}
+
+		reason := result.TerminatedBy
+		if reason == "" && ctx.Err() != nil {
+			// Cancelled before the search loop could record it.
+			reason = TerminatedCancelled
+		}
+		t.emit(ProgressEvent{Type: ProgressComplete, Depth: result.MaxDepthReached, TerminatedBy: reason})
+	}
+}
+
+// finishExhausted completes a search whose frontier ran out. Cancelled
+// evaluations are skipped rather than queued, so cancellation can also empty
+// the frontier; that case is reported as cancelled.
+func (t *ThoughtTree) finishExhausted(ctx context.Context, result *ExplorationResult, start time.Time, maxDepth int) (*ExplorationResult, error) {
 	result.Duration = time.Since(start)
 	result.MaxDepthReached = maxDepth
+	result.BestPath = t.fallbackPath(result)
+	if err := ctx.Err(); err != nil {
+		result.TerminatedBy = TerminatedCancelled
+		return result, err
+	}
 	result.TerminatedBy = TerminatedExhausted
-	result.BestPath = t.findBestLeafPath()
 	return result, nil
 }
 
+// acceptSolution decides whether a terminal node ends the search. Without a
+// PathVerifier every terminal node is accepted. Otherwise the full path is
+// verified and accepted only if it scores at least MinPathScore. A rejected
+// node is recorded and pruned so the search continues elsewhere. A
+// verification error counts as a rejection with score zero.
+func (t *ThoughtTree) acceptSolution(ctx context.Context, node *ThoughtNode, result *ExplorationResult) bool {
+	path := node.Path()
+	if t.verifier == nil {
+		result.Solution = node
+		result.BestPath = path
+		t.emit(ProgressEvent{Type: ProgressSolution, NodeID: node.ID, Depth: node.Depth})
+		return true
+	}
+
+	var score float64
+	if v, err := t.verifier.VerifyPath(ctx, path); err == nil && v != nil {
+		score = v.Score
+	}
+
+	if score >= t.config.MinPathScore {
+		result.Solution = node
+		result.BestPath = path
+		result.SolutionPathScore = score
+		t.emit(ProgressEvent{Type: ProgressSolution, NodeID: node.ID, Depth: node.Depth})
+		return true
+	}
+
+	node.MarkPruned()
+	result.RejectedSolutions++
+	if result.rejectedPath == nil || score > result.rejectedScore {
+		result.rejectedPath = path
+		result.rejectedScore = score
+	}
+	return false
+}
+
+// fallbackPath returns the path reported when no solution was accepted:
+// the best rejected candidate if any, otherwise the best leaf.
+func (t *ThoughtTree) fallbackPath(result *ExplorationResult) []*ThoughtNode {
+	if result.rejectedPath != nil {
+		result.SolutionPathScore = result.rejectedScore
+		return result.rejectedPath
+	}
+	return t.findBestLeafPath()
+}
+
 // findBestLeafPath finds the path to the highest-value leaf node.
 func (t *ThoughtTree) findBestLeafPath() []*ThoughtNode {
 	if t.root == nil {
@@ -529,6 +621,16 @@ func selectTopK(nodes []*ThoughtNode, k int) []*ThoughtNode {
 	return result
 }
 
+// popNext removes the next node to expand from pq: the highest-valued one,
+// or a softmax sample over the whole frontier when sampling is enabled.
+func (t *ThoughtTree) popNext(pq *nodeHeap) *ThoughtNode {
+	if t.sampler == nil {
+		return heap.Pop(pq).(*ThoughtNode)
+	}
+	i := sampleIndex(*pq, t.config.SamplingTemperature, t.sampler)
+	return heap.Remove(pq, i).(*ThoughtNode)
+}
+
 // nodeHeap implements a max-heap for nodes ordered by value.
 type nodeHeap []*ThoughtNode
 
@@ -553,6 +655,7 @@ func (h *nodeHeap) Pop() any {
 func (t *ThoughtTree) ExploreWithIDDFS(ctx context.Context, goal string) (*ExplorationResult, error) {
 	start := time.Now()
 	finalResult := &ExplorationResult{}
+	defer t.beginExploration(ctx, finalResult)()
 
 	for depth := 1; depth <= t.config.MaxDepth; depth++ {
 		// Create a config with current depth limit
@@ -569,10 +672,16 @@ func (t *ThoughtTree) ExploreWithIDDFS(ctx context.Context, goal string) (*Explo
 
 		finalResult.NodesExplored += result.NodesExplored
 		finalResult.MaxDepthReached = result.MaxDepthReached
+		finalResult.RejectedSolutions += result.RejectedSolutions
+		if result.rejectedPath != nil && (finalResult.rejectedPath == nil || result.rejectedScore > finalResult.rejectedScore) {
+			finalResult.rejectedPath = result.rejectedPath
+			finalResult.rejectedScore = result.rejectedScore
+		}
 
 		if result.Solution != nil {
 			finalResult.Solution = result.Solution
 			finalResult.BestPath = result.BestPath
+			finalResult.SolutionPathScore = result.SolutionPathScore
 			finalResult.Duration = time.Since(start)
 			finalResult.TerminatedBy = TerminatedSolution
 			return finalResult, nil
@@ -583,13 +692,14 @@ func (t *ThoughtTree) ExploreWithIDDFS(ctx context.Context, goal string) (*Explo
 			finalResult.Duration = time.Since(start)
 			finalResult.TerminatedBy = TerminatedTimeout
 			finalResult.BestPath = result.BestPath
+			finalResult.SolutionPathScore = result.SolutionPathScore
 			return finalResult, nil
 		}
 	}
 
 	finalResult.Duration = time.Since(start)
 	finalResult.TerminatedBy = TerminatedExhausted
-	finalResult.BestPath = t.findBestLeafPath()
+	finalResult.BestPath = t.fallbackPath(finalResult)
 	return finalResult, nil
 }
 
@@ -598,6 +708,7 @@ func (t *ThoughtTree) ExploreWithIDDFS(ctx context.Context, goal string) (*Explo
 func (t *ThoughtTree) ExploreWithMCTS(ctx context.Context, goal string, iterations int) (*ExplorationResult, error) {
 	start := time.Now()
 	result := &ExplorationResult{}
+	defer t.beginExploration(ctx, result)()
 
 	if t.root == nil {
 		t.Initialize(goal)
@@ -624,7 +735,7 @@ func (t *ThoughtTree) ExploreWithMCTS(ctx context.Context, goal string, iteratio
 			result.Duration = time.Since(start)
 			result.MaxDepthReached = maxDepth
 			result.TerminatedBy = TerminatedCancelled
-			result.BestPath = t.findBestLeafPath()
+			result.BestPath = t.fallbackPath(result)
 			return result, ctx.Err()
 		default:
 		}
@@ -634,7 +745,7 @@ func (t *ThoughtTree) ExploreWithMCTS(ctx context.Context, goal string, iteratio
 			result.Duration = time.Since(start)
 			result.MaxDepthReached = maxDepth
 			result.TerminatedBy = TerminatedTimeout
-			result.BestPath = t.findBestLeafPath()
+			result.BestPath = t.fallbackPath(result)
 			return result, nil
 		}
 
@@ -664,9 +775,7 @@ func (t *ThoughtTree) ExploreWithMCTS(ctx context.Context, goal string, iteratio
 		result.NodesExplored++
 
 		// Check for solution
-		if node.Status == StatusTerminal {
-			result.Solution = node
-			result.BestPath = node.Path()
+		if node.Status == StatusTerminal && t.acceptSolution(ctx, node, result) {
 			result.Duration = time.Since(start)
 			result.MaxDepthReached = maxDepth
 			result.TerminatedBy = TerminatedSolution
@@ -681,11 +790,7 @@ func (t *ThoughtTree) ExploreWithMCTS(ctx context.Context, goal string, iteratio
 		}
 	}
 
-	result.Duration = time.Since(start)
-	result.MaxDepthReached = maxDepth
-	result.TerminatedBy = TerminatedExhausted
-	result.BestPath = t.findBestLeafPath()
-	return result, nil
+	return t.finishExhausted(ctx, result, start, maxDepth)
 }
 
 // selectUCB1 selects the child with highest UCB1 score.
@@ -42,7 +42,7 @@ type testGenerator struct {
 	solutionBranch  int // Which branch gets the solution
 }
 
-func (g *testGenerator) GenerateThoughts(_ context.Context, node *ThoughtNode, n int) ([]string, error) {
+func (g *testGenerator) GenerateThoughts(_ context.Context, node *ThoughtNode, n int, _ float64) ([]string, error) {
 	count := n
 	if g.childrenPerNode > 0 && g.childrenPerNode < n {
 		count = g.childrenPerNode
@@ -556,6 +556,8 @@ func TestExplore_UsesConfiguredStrategy(t *testing.T) {
 		{"BFS", StrategyBFS},
 		{"DFS", StrategyDFS},
 		{"BestFirst", StrategyBestFirst},
+		{"Beam", StrategyBeam},
+		{"MCTS", StrategyMCTS},
 	}
 
 	for _, tt := range tests {
@@ -994,3 +996,166 @@ func TestExplorationResult_Fields(t *testing.T) {
 		t.Error("BestPath should be set")
 	}
 }
+
+// keywordVerifier scores a path low if any thought contains a keyword.
+type keywordVerifier struct {
+	reject string
+	calls  int
+}
+
+func (v *keywordVerifier) VerifyPath(_ context.Context, path []*ThoughtNode) (*PathVerification, error) {
+	v.calls++
+	for _, node := range path {
+		if strings.Contains(node.Thought, v.reject) {
+			return &PathVerification{Score: 0.1, Reasoning: "contradiction"}, nil
+		}
+	}
+	return &PathVerification{Score: 0.9, Reasoning: "consistent"}, nil
+}
+
+func newVerifiedTree(strategy Strategy, verifier PathVerifier) *ThoughtTree {
+	config := ToTConfig{
+		MaxBranches:    2,
+		MaxDepth:       3,
+		ValueThreshold: 0.1,
+		Strategy:       strategy,
+		MaxNodes:       100,
+		Timeout:        10 * time.Second,
+		MinPathScore:   0.5,
+	}
+
+	// The root yields an incoherent answer (valued highest so best-first
+	// reaches it first) and an intermediate step that leads to a sound one.
+	evaluator := &testEvaluator{
+		solutionKeyword: "answer",
+		valueFunc: func(s string) float64 {
+			if strings.Contains(s, "incoherent") {
+				return 0.9
+			}
+			return 0.6
+		},
+	}
+	generator := &MockGenerator{
+		ThoughtsFunc: func(node *ThoughtNode, _ int) []string {
+			if node.Depth == 0 {
+				return []string{"incoherent answer", "step"}
+			}
+			return []string{"coherent answer"}
+		},
+	}
+
+	tree := NewThoughtTree(config, evaluator, generator)
+	if verifier != nil {
+		tree.SetPathVerifier(verifier)
+	}
+	return tree
+}
+
+func TestExplore_PathVerifierRejectsIncoherentSolution(t *testing.T) {
+	strategies := []struct {
+		name    string
+		explore func(*ThoughtTree) (*ExplorationResult, error)
+	}{
+		{"bfs", func(tr *ThoughtTree) (*ExplorationResult, error) {
+			return tr.ExploreWithBFS(context.Background(), "goal")
+		}},
+		{"dfs", func(tr *ThoughtTree) (*ExplorationResult, error) {
+			return tr.ExploreWithDFS(context.Background(), "goal")
+		}},
+		{"best-first", func(tr *ThoughtTree) (*ExplorationResult, error) {
+			return tr.ExploreWithBestFirst(context.Background(), "goal")
+		}},
+		{"beam", func(tr *ThoughtTree) (*ExplorationResult, error) {
+			return tr.ExploreWithBeam(context.Background(), "goal", 2)
+		}},
+		{"iddfs", func(tr *ThoughtTree) (*ExplorationResult, error) {
+			return tr.ExploreWithIDDFS(context.Background(), "goal")
+		}},
+	}
+
+	for _, s := range strategies {
+		t.Run(s.name, func(t *testing.T) {
+			verifier := &keywordVerifier{reject: "incoherent"}
+			tree := newVerifiedTree(StrategyBFS, verifier)
+
+			result, err := s.explore(tree)
+			if err != nil {
+				t.Fatalf("explore failed: %v", err)
+			}
+
+			if result.Solution == nil {
+				t.Fatal("expected search to continue to the coherent solution")
+			}
+			if result.Solution.Thought != "coherent answer" {
+				t.Errorf("Solution = %q, want %q", result.Solution.Thought, "coherent answer")
+			}
+			if result.SolutionPathScore != 0.9 {
+				t.Errorf("SolutionPathScore = %v, want 0.9", result.SolutionPathScore)
+			}
+			if result.RejectedSolutions == 0 {
+				t.Error("expected the incoherent solution to be rejected")
+			}
+			if result.TerminatedBy != TerminatedSolution {
+				t.Errorf("TerminatedBy = %s, want %s", result.TerminatedBy, TerminatedSolution)
+			}
+		})
+	}
+}
+
+func TestExplore_WithoutPathVerifierAcceptsFirstSolution(t *testing.T) {
+	tree := newVerifiedTree(StrategyBFS, nil)
+
+	result, err := tree.ExploreWithBFS(context.Background(), "goal")
+	if err != nil {
+		t.Fatalf("ExploreWithBFS failed: %v", err)
+	}
+
+	if result.Solution == nil || result.Solution.Thought != "incoherent answer" {
+		t.Fatalf("expected the first terminal node, got %+v", result.Solution)
+	}
+	if result.SolutionPathScore != 0 {
+		t.Errorf("SolutionPathScore = %v, want 0 without a verifier", result.SolutionPathScore)
+	}
+}
+
+func TestExplore_AllSolutionsRejected(t *testing.T) {
+	verifier := &keywordVerifier{reject: "answer"}
+	tree := newVerifiedTree(StrategyBFS, verifier)
+
+	result, err := tree.ExploreWithBFS(context.Background(), "goal")
+	if err != nil {
+		t.Fatalf("ExploreWithBFS failed: %v", err)
+	}
+
+	if result.Solution != nil {
+		t.Errorf("expected no accepted solution, got %q", result.Solution.Thought)
+	}
+	if result.RejectedSolutions != 2 {
+		t.Errorf("RejectedSolutions = %d, want 2", result.RejectedSolutions)
+	}
+	if result.TerminatedBy != TerminatedExhausted {
+		t.Errorf("TerminatedBy = %s, want %s", result.TerminatedBy, TerminatedExhausted)
+	}
+
+	// The best rejected candidate is still reported for inspection.
+	if len(result.BestPath) == 0 || !strings.Contains(result.BestPath[len(result.BestPath)-1].Thought, "answer") {
+		t.Errorf("BestPath should end at a rejected candidate, got %v", result.BestPath)
+	}
+	if result.SolutionPathScore != 0.1 {
+		t.Errorf("SolutionPathScore = %v, want 0.1", result.SolutionPathScore)
+	}
+}
+
+func TestParseVerificationResponse(t *testing.T) {
+	v := parseVerificationResponse("SCORE: 0.35\nREASONING: step 2 contradicts step 1")
+	if v.Score != 0.35 {
+		t.Errorf("Score = %v, want 0.35", v.Score)
+	}
+	if v.Reasoning != "step 2 contradicts step 1" {
+		t.Errorf("Reasoning = %q", v.Reasoning)
+	}
+
+	if v := parseVerificationResponse("no idea"); v.Score != 0 {
+		t.Errorf("unparseable response should score 0, got %v", v.Score)
+	}
+}
@@ -9,6 +9,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math"
+	"math/rand/v2"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -26,6 +28,12 @@ const (
 
 	// StrategyBestFirst explores highest-value nodes first.
 	StrategyBestFirst Strategy = "best_first"
+
+	// StrategyBeam keeps the MaxBranches highest-value nodes at each depth.
+	StrategyBeam Strategy = "beam"
+
+	// StrategyMCTS runs Monte Carlo tree search for up to MaxNodes iterations.
+	StrategyMCTS Strategy = "mcts"
 )
 
 // NodeStatus represents the status of a thought node.
@@ -227,6 +235,29 @@ type ToTConfig struct {
 
 	// EvaluateBeforeExpand requires evaluation before branching.
 	EvaluateBeforeExpand bool
+
+	// Temperature schedules the generator's sampling temperature by depth.
+	// The zero value uses DefaultTemperature at every depth.
+	Temperature TemperatureSchedule
+
+	// MinPathScore is the lowest path score a PathVerifier may assign for a
+	// solution to be accepted. It has no effect without a verifier.
+	MinPathScore float64
+
+	// SamplingTemperature, when positive, makes beam and best-first search
+	// sample the nodes they keep or expand next with probability
+	// proportional to softmax(value/SamplingTemperature), instead of always
+	// taking the highest-valued ones. Higher values explore more diverse
+	// thoughts. Zero keeps selection deterministic.
+	SamplingTemperature float64
+
+	// SamplingSeed seeds stochastic selection so runs are reproducible.
+	// Zero picks a random seed.
+	SamplingSeed int64
+
+	// ProgressCallback, if set, receives expansion, evaluation, pruning,
+	// solution and completion events as exploration runs.
+	ProgressCallback ProgressCallback
 }
 
 // DefaultToTConfig returns default configuration.
@@ -239,6 +270,87 @@ func DefaultToTConfig() ToTConfig {
 		MaxNodes:             100,
 		Timeout:              5 * time.Minute,
 		EvaluateBeforeExpand: true,
+		Temperature:          ConstantTemperature(DefaultTemperature),
+		MinPathScore:         0.5,
+	}
+}
+
+// DefaultTemperature is the generation temperature used when no schedule
+// is configured.
+const DefaultTemperature = 0.7
+
+// ScheduleKind selects how a TemperatureSchedule varies with depth.
+type ScheduleKind string
+
+const (
+	// ScheduleConstant uses Start at every depth.
+	ScheduleConstant ScheduleKind = "constant"
+
+	// ScheduleLinear interpolates from Start at the root to End at the
+	// deepest expandable depth.
+	ScheduleLinear ScheduleKind = "linear"
+
+	// ScheduleExponential multiplies Start by Decay per level, never
+	// dropping below End.
+	ScheduleExponential ScheduleKind = "exponential"
+)
+
+// TemperatureSchedule maps node depth to a generation temperature, so that
+// exploration near the root is diverse and refinement deeper is focused.
+type TemperatureSchedule struct {
+	// Kind selects the schedule shape.
+	Kind ScheduleKind
+
+	// Start is the temperature at the root.
+	Start float64
+
+	// End is the final temperature (linear) or the floor (exponential).
+	End float64
+
+	// Decay is the per-level multiplier for exponential schedules (0-1).
+	Decay float64
+}
+
+// ConstantTemperature returns a schedule that uses t at every depth.
+func ConstantTemperature(t float64) TemperatureSchedule {
+	return TemperatureSchedule{Kind: ScheduleConstant, Start: t}
+}
+
+// LinearTemperature returns a schedule that decays linearly from start to end.
+func LinearTemperature(start, end float64) TemperatureSchedule {
+	return TemperatureSchedule{Kind: ScheduleLinear, Start: start, End: end}
+}
+
+// ExponentialTemperature returns a schedule that multiplies start by decay
+// per level, never dropping below floor.
+func ExponentialTemperature(start, decay, floor float64) TemperatureSchedule {
+	return TemperatureSchedule{Kind: ScheduleExponential, Start: start, Decay: decay, End: floor}
+}
+
+// At returns the temperature for generating children of a node at depth,
+// in a tree whose nodes are expanded up to maxDepth-1.
+func (s TemperatureSchedule) At(depth, maxDepth int) float64 {
+	switch s.Kind {
+	case ScheduleLinear:
+		last := maxDepth - 1
+		if last <= 0 || depth <= 0 {
+			return s.Start
+		}
+		if depth >= last {
+			return s.End
+		}
+		frac := float64(depth) / float64(last)
+		return s.Start + (s.End-s.Start)*frac
+	case ScheduleExponential:
+		t := s.Start * math.Pow(s.Decay, float64(depth))
+		if t < s.End {
+			return s.End
+		}
+		return t
+	case ScheduleConstant:
+		return s.Start
+	default:
+		return DefaultTemperature
 	}
 }
 
@@ -249,11 +361,26 @@ type ThoughtTree struct {
 	nodeCount int64
 	evaluator Evaluator
 	generator Generator
+	verifier  PathVerifier
+	cache     *EvaluationCache
 
 	// Metrics
 	expansions  int64
 	evaluations int64
 	backtracks  int64
+	cacheHits   int64
+	cacheMisses int64
+
+	// Progress reporting
+	best   float64
+	active int32
+
+	// Stochastic selection; nil when SamplingTemperature is zero
+	sampler *rand.Rand
+
+	// Node limit shared with concurrently exploring trees; nil when the
+	// tree explores alone
+	budget *nodeBudget
 
 	mu sync.RWMutex
 }
@@ -264,9 +391,23 @@ func NewThoughtTree(config ToTConfig, evaluator Evaluator, generator Generator)
 		config:    config,
 		evaluator: evaluator,
 		generator: generator,
+		sampler:   newSampler(config),
 	}
 }
 
+// SetPathVerifier enables verification of solution paths. With a verifier,
+// a terminal node only ends exploration if its full path scores at least
+// MinPathScore; otherwise the search continues.
+func (t *ThoughtTree) SetPathVerifier(v PathVerifier) {
+	t.verifier = v
+}
+
+// SetEvaluationCache makes Evaluate reuse results from cache. The same
+// cache may be shared by several trees and survives across Explore calls.
+func (t *ThoughtTree) SetEvaluationCache(cache *EvaluationCache) {
+	t.cache = cache
+}
+
 // Initialize sets the root thought.
 func (t *ThoughtTree) Initialize(problem string) *ThoughtNode {
 	t.mu.Lock()
@@ -290,6 +431,10 @@ func (t *ThoughtTree) Branch(ctx context.Context, node *ThoughtNode) ([]*Thought
 		return nil, errors.New("cannot branch from nil node")
 	}
 
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	if node.Depth >= t.config.MaxDepth {
 		return nil, nil // Max depth reached
 	}
@@ -299,12 +444,13 @@ func (t *ThoughtTree) Branch(ctx context.Context, node *ThoughtNode) ([]*Thought
 	}
 
 	// Check node limit
-	if atomic.LoadInt64(&t.nodeCount) >= int64(t.config.MaxNodes) {
+	if atomic.LoadInt64(&t.nodeCount) >= int64(t.config.MaxNodes) || t.budget.exhausted() {
 		return nil, errors.New("max nodes reached")
 	}
 
 	// Generate thoughts using the generator
-	thoughts, err := t.generator.GenerateThoughts(ctx, node, t.config.MaxBranches)
+	temperature := t.config.Temperature.At(node.Depth, t.config.MaxDepth)
+	thoughts, err := t.generator.GenerateThoughts(ctx, node, t.config.MaxBranches, temperature)
 	if err != nil {
 		return nil, fmt.Errorf("generate thoughts: %w", err)
 	}
@@ -312,6 +458,9 @@ func (t *ThoughtTree) Branch(ctx context.Context, node *ThoughtNode) ([]*Thought
 	// Create child nodes
 	children := make([]*ThoughtNode, 0, len(thoughts))
 	for _, thought := range thoughts {
+		if !t.budget.take() {
+			break
+		}
 		child := NewThoughtNode(t.generateID(), thought, node)
 		node.AddChild(child)
 		children = append(children, child)
@@ -323,6 +472,7 @@ func (t *ThoughtTree) Branch(ctx context.Context, node *ThoughtNode) ([]*Thought
 	node.mu.Unlock()
 
 	atomic.AddInt64(&t.expansions, 1)
+	t.emit(ProgressEvent{Type: ProgressExpand, NodeID: node.ID, Depth: node.Depth, Children: len(children)})
 
 	return children, nil
 }
@@ -333,8 +483,12 @@ func (t *ThoughtTree) Evaluate(ctx context.Context, node *ThoughtNode) error {
 		return errors.New("cannot evaluate nil node")
 	}
 
-	// Get evaluation from evaluator
-	result, err := t.evaluator.EvaluateThought(ctx, node)
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	// Get evaluation from the cache or the evaluator
+	result, err := t.evaluateCached(ctx, node)
 	if err != nil {
 		return fmt.Errorf("evaluate thought: %w", err)
 	}
@@ -347,15 +501,42 @@ func (t *ThoughtTree) Evaluate(ctx context.Context, node *ThoughtNode) error {
 	}
 
 	// Check for pruning
-	if result.Value < t.config.ValueThreshold && !result.IsTerminal {
+	pruned := result.Value < t.config.ValueThreshold && !result.IsTerminal
+	if pruned {
 		node.MarkPruned()
 	}
 
 	atomic.AddInt64(&t.evaluations, 1)
+	t.recordValue(result.Value)
+	t.emit(ProgressEvent{Type: ProgressEvaluate, NodeID: node.ID, Depth: node.Depth})
+	if pruned {
+		t.emit(ProgressEvent{Type: ProgressPrune, NodeID: node.ID, Depth: node.Depth})
+	}
 
 	return nil
 }
 
+// evaluateCached returns a cached evaluation for node if one exists,
+// otherwise calls the evaluator and caches the result.
+func (t *ThoughtTree) evaluateCached(ctx context.Context, node *ThoughtNode) (*EvaluationResult, error) {
+	if t.cache == nil {
+		return t.evaluator.EvaluateThought(ctx, node)
+	}
+
+	if result, ok := t.cache.Get(node); ok {
+		atomic.AddInt64(&t.cacheHits, 1)
+		return result, nil
+	}
+	atomic.AddInt64(&t.cacheMisses, 1)
+
+	result, err := t.evaluator.EvaluateThought(ctx, node)
+	if err != nil {
+		return nil, err
+	}
+	t.cache.Put(node, result)
+	return result, nil
+}
+
 // Backtrack returns to the parent node for alternative exploration.
 func (t *ThoughtTree) Backtrack(node *ThoughtNode) *ThoughtNode {
 	if node == nil || node.Parent == nil {
@@ -435,6 +616,8 @@ func (t *ThoughtTree) Metrics() TreeMetrics {
 		Expansions:  atomic.LoadInt64(&t.expansions),
 		Evaluations: atomic.LoadInt64(&t.evaluations),
 		Backtracks:  atomic.LoadInt64(&t.backtracks),
+		CacheHits:   atomic.LoadInt64(&t.cacheHits),
+		CacheMisses: atomic.LoadInt64(&t.cacheMisses),
 	}
 }
 
@@ -444,6 +627,8 @@ type TreeMetrics struct {
 	Expansions  int64
 	Evaluations int64
 	Backtracks  int64
+	CacheHits   int64
+	CacheMisses int64
 }
 
 // generateID generates a unique node ID.
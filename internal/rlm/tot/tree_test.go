@@ -485,7 +485,7 @@ func TestMockGenerator(t *testing.T) {
 	}
 
 	node := NewThoughtNode("test", "Test", nil)
-	thoughts, err := generator.GenerateThoughts(context.Background(), node, 3)
+	thoughts, err := generator.GenerateThoughts(context.Background(), node, 3, DefaultTemperature)
 	require.NoError(t, err)
 
 	assert.Len(t, thoughts, 3)
@@ -497,7 +497,7 @@ func TestMockGenerator_Default(t *testing.T) {
 	node := NewThoughtNode("test", "Test", nil)
 	node.Depth = 2
 
-	thoughts, err := generator.GenerateThoughts(context.Background(), node, 3)
+	thoughts, err := generator.GenerateThoughts(context.Background(), node, 3, DefaultTemperature)
 	require.NoError(t, err)
 
 	assert.Len(t, thoughts, 3)
@@ -639,4 +639,102 @@ func TestDefaultToTConfig(t *testing.T) {
 	assert.Equal(t, 100, config.MaxNodes)
 	assert.Equal(t, 5*time.Minute, config.Timeout)
 	assert.True(t, config.EvaluateBeforeExpand)
+	assert.Equal(t, ConstantTemperature(DefaultTemperature), config.Temperature)
+}
+
+// temperatureRecorder records the temperature requested at each depth.
+type temperatureRecorder struct {
+	mu      sync.Mutex
+	byDepth map[int][]float64
+}
+
+func (g *temperatureRecorder) GenerateThoughts(_ context.Context, node *ThoughtNode, n int, temperature float64) ([]string, error) {
+	g.mu.Lock()
+	g.byDepth[node.Depth] = append(g.byDepth[node.Depth], temperature)
+	g.mu.Unlock()
+
+	thoughts := make([]string, n)
+	for i := range thoughts {
+		thoughts[i] = fmt.Sprintf("d%d-%d", node.Depth+1, i)
+	}
+	return thoughts, nil
+}
+
+func TestThoughtTree_TemperatureSchedule(t *testing.T) {
+	tests := []struct {
+		name     string
+		schedule TemperatureSchedule
+		expected map[int]float64
+	}{
+		{
+			name:     "zero value uses default",
+			expected: map[int]float64{0: DefaultTemperature, 1: DefaultTemperature, 2: DefaultTemperature},
+		},
+		{
+			name:     "linear decay by depth",
+			schedule: LinearTemperature(1.0, 0.2),
+			expected: map[int]float64{0: 1.0, 1: 0.6, 2: 0.2},
+		},
+		{
+			name:     "exponential decay with floor",
+			schedule: ExponentialTemperature(1.0, 0.5, 0.3),
+			expected: map[int]float64{0: 1.0, 1: 0.5, 2: 0.3},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultToTConfig()
+			config.MaxDepth = 3
+			config.MaxBranches = 2
+			config.EvaluateBeforeExpand = false
+			config.Temperature = tt.schedule
+
+			gen := &temperatureRecorder{byDepth: make(map[int][]float64)}
+			tree := NewThoughtTree(config, &MockEvaluator{}, gen)
+			root := tree.Initialize("Problem")
+
+			// Expand the full tree so every depth is visited.
+			frontier := []*ThoughtNode{root}
+			for len(frontier) > 0 {
+				node := frontier[0]
+				frontier = frontier[1:]
+				children, err := tree.Branch(context.Background(), node)
+				require.NoError(t, err)
+				frontier = append(frontier, children...)
+			}
+
+			require.Len(t, gen.byDepth, len(tt.expected))
+			for depth, want := range tt.expected {
+				for _, got := range gen.byDepth[depth] {
+					assert.InDelta(t, want, got, 1e-9, "depth %d", depth)
+				}
+			}
+		})
+	}
+}
+
+func TestLLMGenerator_PassesTemperature(t *testing.T) {
+	client := &temperatureClient{response: "1. first\n2. second"}
+	gen := NewLLMGenerator(client)
+
+	thoughts, err := gen.GenerateThoughts(context.Background(), NewThoughtNode("root", "Problem", nil), 2, 0.9)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"first", "second"}, thoughts)
+	assert.Equal(t, []float64{0.9}, client.temperatures)
+}
+
+// temperatureClient is an LLM client that records requested temperatures.
+type temperatureClient struct {
+	response     string
+	temperatures []float64
+}
+
+func (c *temperatureClient) Complete(ctx context.Context, prompt string, maxTokens int) (string, error) {
+	return c.response, nil
+}
+
+func (c *temperatureClient) CompleteWithTemperature(ctx context.Context, prompt string, maxTokens int, temperature float64) (string, error) {
+	c.temperatures = append(c.temperatures, temperature)
+	return c.response, nil
 }
@@ -0,0 +1,368 @@
+package rlm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/rand/recurse/internal/memory/hypergraph"
+	"github.com/rand/recurse/internal/tui/components/dialogs/rlmtrace"
+)
+
+// TraceBackend stores trace events for the RLM controller and the trace
+// view. Implementations share query semantics: results are in
+// chronological order (oldest first) and a limit keeps the most recent
+// matches. Timestamps are stored with millisecond precision.
+type TraceBackend interface {
+	rlmtrace.TraceProvider
+
+	// RecordEvent stores an event. Recording an ID twice is an error.
+	RecordEvent(event TraceEvent) error
+
+	// Query returns the events matching q.
+	Query(q TraceQuery) ([]rlmtrace.TraceEvent, error)
+}
+
+// TraceQuery filters trace events. Zero fields match everything.
+type TraceQuery struct {
+	// SessionID matches events recorded under this session.
+	SessionID string
+
+	// ParentID matches direct children of this event.
+	ParentID string
+
+	// Types matches any of these event types.
+	Types []rlmtrace.TraceEventType
+
+	// Status matches events with this status.
+	Status string
+
+	// Since and Until bound the event timestamp: Since is inclusive and
+	// Until exclusive.
+	Since time.Time
+	Until time.Time
+
+	// Limit keeps the most recent matches (default: 100).
+	Limit int
+}
+
+// limit returns the effective result limit.
+func (q TraceQuery) limit() int {
+	if q.Limit <= 0 {
+		return 100
+	}
+	return q.Limit
+}
+
+// hypergraphTraceSubtype marks decision nodes that hold trace events.
+const hypergraphTraceSubtype = "trace_event"
+
+// hypergraphTraceIDPrefix keeps trace node IDs apart from memory node IDs.
+const hypergraphTraceIDPrefix = "trace:"
+
+// DefaultTraceScanLimit is how many of the most recently recorded events a
+// HypergraphTraceBackend reads to answer a query or compute stats.
+const DefaultTraceScanLimit = 10000
+
+// hypergraphTracePageSize is how many trace nodes are read per store query.
+const hypergraphTracePageSize = 500
+
+// hypergraphTraceRecord is the node metadata for one trace event.
+type hypergraphTraceRecord struct {
+	SessionID  string `json:"session_id,omitempty"`
+	Type       string `json:"type"`
+	Details    string `json:"details,omitempty"`
+	Tokens     int    `json:"tokens"`
+	DurationNs int64  `json:"duration_ns"`
+	Depth      int    `json:"depth"`
+	ParentID   string `json:"parent_id,omitempty"`
+	Status     string `json:"status"`
+	CreatedAt  int64  `json:"created_at"` // Unix milliseconds
+}
+
+// HypergraphTraceBackend stores trace events as nodes in a hypergraph
+// store, so instances sharing a store share their traces. Events are
+// archive-tier decision nodes, which keeps them out of memory retrieval.
+// Queries and stats read the store a page at a time and only look at the
+// most recently recorded events, up to the scan limit.
+type HypergraphTraceBackend struct {
+	store *hypergraph.Store
+
+	mu        sync.RWMutex
+	sessionID string
+	scanLimit int
+}
+
+// NewHypergraphTraceBackend creates a trace backend on store.
+func NewHypergraphTraceBackend(store *hypergraph.Store) *HypergraphTraceBackend {
+	return &HypergraphTraceBackend{store: store, scanLimit: DefaultTraceScanLimit}
+}
+
+// SetScanLimit sets how many of the most recently recorded events queries
+// and stats read. Zero or less restores DefaultTraceScanLimit.
+func (b *HypergraphTraceBackend) SetScanLimit(limit int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if limit <= 0 {
+		limit = DefaultTraceScanLimit
+	}
+	b.scanLimit = limit
+}
+
+// SetSessionID sets the current session ID for new events.
+func (b *HypergraphTraceBackend) SetSessionID(sessionID string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.sessionID = sessionID
+}
+
+// RecordEvent implements TraceBackend.
+func (b *HypergraphTraceBackend) RecordEvent(event TraceEvent) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ctx := context.Background()
+	nodeID := hypergraphTraceIDPrefix + event.ID
+	if existing, err := b.store.GetNode(ctx, nodeID); err == nil && existing != nil {
+		return fmt.Errorf("insert trace event: duplicate id %q", event.ID)
+	}
+
+	metadata, err := json.Marshal(hypergraphTraceRecord{
+		SessionID:  b.sessionID,
+		Type:       string(mapEventType(event.Type)),
+		Details:    event.Details,
+		Tokens:     event.Tokens,
+		DurationNs: event.Duration.Nanoseconds(),
+		Depth:      event.Depth,
+		ParentID:   event.ParentID,
+		Status:     event.Status,
+		CreatedAt:  event.Timestamp.UnixMilli(),
+	})
+	if err != nil {
+		return fmt.Errorf("marshal trace event: %w", err)
+	}
+
+	node := hypergraph.NewNode(hypergraph.NodeTypeDecision, event.Action)
+	node.ID = nodeID
+	node.Subtype = hypergraphTraceSubtype
+	node.Tier = hypergraph.TierArchive
+	node.Metadata = metadata
+	if err := b.store.CreateNode(ctx, node); err != nil {
+		return fmt.Errorf("insert trace event: %w", err)
+	}
+	return nil
+}
+
+// GetEvents implements rlmtrace.TraceProvider.
+func (b *HypergraphTraceBackend) GetEvents(limit int) ([]rlmtrace.TraceEvent, error) {
+	return b.Query(TraceQuery{Limit: limit})
+}
+
+// GetEvent implements rlmtrace.TraceProvider.
+func (b *HypergraphTraceBackend) GetEvent(id string) (*rlmtrace.TraceEvent, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	node, err := b.store.GetNode(context.Background(), hypergraphTraceIDPrefix+id)
+	if err != nil || node == nil {
+		// A missing event is not an error, matching PersistentTraceProvider.
+		return nil, nil
+	}
+	event, _, err := hypergraphTraceEvent(node)
+	if err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+// Query implements TraceBackend.
+func (b *HypergraphTraceBackend) Query(q TraceQuery) ([]rlmtrace.TraceEvent, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	type match struct {
+		event   rlmtrace.TraceEvent
+		created time.Time
+	}
+	var matches []match
+	err := b.scanNodes(b.scanLimit, func(node *hypergraph.Node) error {
+		event, sessionID, err := hypergraphTraceEvent(node)
+		if err != nil {
+			return err
+		}
+		if traceQueryMatches(q, event, sessionID) {
+			matches = append(matches, match{event: event, created: node.CreatedAt})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Order by event time; insertion time breaks ties.
+	sort.SliceStable(matches, func(i, j int) bool {
+		ti, tj := matches[i].event.Timestamp, matches[j].event.Timestamp
+		if !ti.Equal(tj) {
+			return ti.Before(tj)
+		}
+		return matches[i].created.Before(matches[j].created)
+	})
+	if limit := q.limit(); len(matches) > limit {
+		matches = matches[len(matches)-limit:]
+	}
+
+	var events []rlmtrace.TraceEvent
+	for _, m := range matches {
+		events = append(events, m.event)
+	}
+	return events, nil
+}
+
+// ClearEvents implements rlmtrace.TraceProvider.
+func (b *HypergraphTraceBackend) ClearEvents() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// Collect first: deleting while paging would shift the offsets.
+	var ids []string
+	err := b.scanNodes(0, func(node *hypergraph.Node) error {
+		ids = append(ids, node.ID)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	for _, id := range ids {
+		if err := b.store.DeleteNode(ctx, id); err != nil {
+			return fmt.Errorf("delete trace event: %w", err)
+		}
+	}
+	return nil
+}
+
+// Stats implements rlmtrace.TraceProvider. Stats are computed from the
+// stored events, so they cover every instance sharing the store, up to the
+// scan limit of most recent events.
+func (b *HypergraphTraceBackend) Stats() rlmtrace.TraceStats {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	stats := rlmtrace.TraceStats{
+		EventsByType: make(map[rlmtrace.TraceEventType]int),
+	}
+	err := b.scanNodes(b.scanLimit, func(node *hypergraph.Node) error {
+		event, _, err := hypergraphTraceEvent(node)
+		if err != nil {
+			return nil
+		}
+		stats.TotalEvents++
+		stats.TotalTokens += event.Tokens
+		stats.TotalDuration += event.Duration
+		stats.MaxDepth = max(stats.MaxDepth, event.Depth)
+		stats.EventsByType[event.Type]++
+		return nil
+	})
+	if err != nil {
+		// Return empty stats on error
+		return rlmtrace.TraceStats{EventsByType: make(map[rlmtrace.TraceEventType]int)}
+	}
+	return stats
+}
+
+// scanNodes calls fn on the trace nodes in the store, most recently
+// recorded first, reading a page at a time. A positive limit stops the
+// scan after that many nodes.
+func (b *HypergraphTraceBackend) scanNodes(limit int, fn func(*hypergraph.Node) error) error {
+	ctx := context.Background()
+	for offset := 0; limit <= 0 || offset < limit; {
+		pageSize := hypergraphTracePageSize
+		if limit > 0 {
+			pageSize = min(pageSize, limit-offset)
+		}
+		nodes, err := b.store.ListNodes(ctx, hypergraph.NodeFilter{
+			Types:    []hypergraph.NodeType{hypergraph.NodeTypeDecision},
+			Subtypes: []string{hypergraphTraceSubtype},
+			Tiers:    []hypergraph.Tier{hypergraph.TierArchive},
+			Limit:    pageSize,
+			Offset:   offset,
+		})
+		if err != nil {
+			return fmt.Errorf("query trace events: %w", err)
+		}
+		for _, node := range nodes {
+			if err := fn(node); err != nil {
+				return err
+			}
+		}
+		if len(nodes) < pageSize {
+			return nil
+		}
+		offset += len(nodes)
+	}
+	return nil
+}
+
+// hypergraphTraceEvent decodes a trace node, returning the event and the
+// session it was recorded under.
+func hypergraphTraceEvent(node *hypergraph.Node) (rlmtrace.TraceEvent, string, error) {
+	var rec hypergraphTraceRecord
+	if err := json.Unmarshal(node.Metadata, &rec); err != nil {
+		return rlmtrace.TraceEvent{}, "", fmt.Errorf("decode trace event %s: %w", node.ID, err)
+	}
+	return rlmtrace.TraceEvent{
+		ID:        node.ID[len(hypergraphTraceIDPrefix):],
+		Type:      rlmtrace.TraceEventType(rec.Type),
+		Action:    node.Content,
+		Details:   rec.Details,
+		Tokens:    rec.Tokens,
+		Duration:  time.Duration(rec.DurationNs),
+		Timestamp: time.UnixMilli(rec.CreatedAt),
+		Depth:     rec.Depth,
+		ParentID:  rec.ParentID,
+		Status:    rec.Status,
+	}, rec.SessionID, nil
+}
+
+// traceQueryMatches reports whether an event recorded under sessionID
+// matches q, ignoring the limit.
+func traceQueryMatches(q TraceQuery, event rlmtrace.TraceEvent, sessionID string) bool {
+	if q.SessionID != "" && sessionID != q.SessionID {
+		return false
+	}
+	if q.ParentID != "" && event.ParentID != q.ParentID {
+		return false
+	}
+	if q.Status != "" && event.Status != q.Status {
+		return false
+	}
+	if len(q.Types) > 0 {
+		found := false
+		for _, t := range q.Types {
+			if event.Type == t {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	ms := event.Timestamp.UnixMilli()
+	if !q.Since.IsZero() && ms < q.Since.UnixMilli() {
+		return false
+	}
+	if !q.Until.IsZero() && ms >= q.Until.UnixMilli() {
+		return false
+	}
+	return true
+}
+
+// Ensure both trace stores implement TraceBackend
+var (
+	_ TraceBackend = (*PersistentTraceProvider)(nil)
+	_ TraceBackend = (*HypergraphTraceBackend)(nil)
+)
@@ -0,0 +1,281 @@
+package rlm
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rand/recurse/internal/tui/components/dialogs/rlmtrace"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sessionTraceBackend is a TraceBackend that tags events with a session.
+type sessionTraceBackend interface {
+	TraceBackend
+	SetSessionID(sessionID string)
+}
+
+// traceBackendFactories builds each TraceBackend implementation fresh.
+var traceBackendFactories = map[string]func(t *testing.T) sessionTraceBackend{
+	"persistent": func(t *testing.T) sessionTraceBackend {
+		// A file keeps each test apart from the shared in-memory database.
+		p, err := NewPersistentTraceProvider(PersistentTraceConfig{
+			Path: filepath.Join(t.TempDir(), "traces.db"),
+		})
+		require.NoError(t, err)
+		t.Cleanup(func() { p.Close() })
+		return p
+	},
+	"hypergraph": func(t *testing.T) sessionTraceBackend {
+		return NewHypergraphTraceBackend(createTestStore(t))
+	},
+}
+
+// traceFixtureBase is the timestamp of the first fixture event.
+var traceFixtureBase = time.UnixMilli(1_700_000_000_000)
+
+// traceFixture is a small decomposition trace over two sessions, one
+// millisecond apart.
+func traceFixture() []struct {
+	session string
+	event   TraceEvent
+} {
+	at := func(i int) time.Time { return traceFixtureBase.Add(time.Duration(i) * time.Millisecond) }
+	return []struct {
+		session string
+		event   TraceEvent
+	}{
+		{"s1", TraceEvent{ID: "root", Type: "DECOMPOSE", Action: "split", Tokens: 10, Duration: time.Second, Timestamp: at(0), Depth: 0, Status: "completed"}},
+		{"s1", TraceEvent{ID: "c1", Type: "SUBCALL", Action: "part 1", Details: "d1", Tokens: 20, Duration: 2 * time.Second, Timestamp: at(1), Depth: 1, ParentID: "root", Status: "completed"}},
+		{"s1", TraceEvent{ID: "c2", Type: "SUBCALL", Action: "part 2", Tokens: 30, Duration: time.Second, Timestamp: at(2), Depth: 1, ParentID: "root", Status: "failed"}},
+		{"s1", TraceEvent{ID: "syn", Type: "SYNTHESIZE", Action: "merge", Tokens: 40, Timestamp: at(3), Depth: 0, ParentID: "root", Status: "completed"}},
+		{"s2", TraceEvent{ID: "d1", Type: "DIRECT", Action: "answer", Tokens: 5, Timestamp: at(4), Depth: 0, Status: "completed"}},
+		{"s2", TraceEvent{ID: "g1", Type: "SUBCALL", Action: "nested", Tokens: 15, Timestamp: at(5), Depth: 2, ParentID: "c1", Status: "running"}},
+	}
+}
+
+func recordTraceFixture(t *testing.T, b sessionTraceBackend) {
+	t.Helper()
+	for _, f := range traceFixture() {
+		b.SetSessionID(f.session)
+		require.NoError(t, b.RecordEvent(f.event))
+	}
+}
+
+func traceEventIDs(events []rlmtrace.TraceEvent) []string {
+	ids := make([]string, len(events))
+	for i, e := range events {
+		ids[i] = e.ID
+	}
+	return ids
+}
+
+// traceConformanceQueries are the queries every backend must answer
+// identically, with the expected event IDs for the fixture.
+var traceConformanceQueries = []struct {
+	name  string
+	query TraceQuery
+	want  []string
+}{
+	{"all", TraceQuery{}, []string{"root", "c1", "c2", "syn", "d1", "g1"}},
+	{"limit keeps most recent", TraceQuery{Limit: 2}, []string{"d1", "g1"}},
+	{"session", TraceQuery{SessionID: "s2"}, []string{"d1", "g1"}},
+	{"parent", TraceQuery{ParentID: "root"}, []string{"c1", "c2", "syn"}},
+	{"status", TraceQuery{Status: "completed"}, []string{"root", "c1", "syn", "d1"}},
+	{"single type", TraceQuery{Types: []rlmtrace.TraceEventType{rlmtrace.EventSubcall}}, []string{"c1", "c2", "g1"}},
+	{"several types", TraceQuery{Types: []rlmtrace.TraceEventType{rlmtrace.EventDecompose, rlmtrace.EventSynthesize}}, []string{"root", "syn"}},
+	{"since inclusive", TraceQuery{Since: traceFixtureBase.Add(4 * time.Millisecond)}, []string{"d1", "g1"}},
+	{"until exclusive", TraceQuery{Until: traceFixtureBase.Add(2 * time.Millisecond)}, []string{"root", "c1"}},
+	{"combined", TraceQuery{
+		SessionID: "s1",
+		Types:     []rlmtrace.TraceEventType{rlmtrace.EventSubcall},
+		Status:    "completed",
+		Since:     traceFixtureBase,
+	}, []string{"c1"}},
+	{"combined with limit", TraceQuery{ParentID: "root", Limit: 1}, []string{"syn"}},
+	{"no match", TraceQuery{SessionID: "missing"}, []string{}},
+}
+
+func TestTraceBackend_Conformance(t *testing.T) {
+	for name, newBackend := range traceBackendFactories {
+		t.Run(name, func(t *testing.T) {
+			t.Run("Query", func(t *testing.T) {
+				b := newBackend(t)
+				recordTraceFixture(t, b)
+
+				for _, tc := range traceConformanceQueries {
+					events, err := b.Query(tc.query)
+					require.NoError(t, err, tc.name)
+					assert.Equal(t, tc.want, traceEventIDs(events), tc.name)
+				}
+			})
+
+			t.Run("GetEvents", func(t *testing.T) {
+				b := newBackend(t)
+				recordTraceFixture(t, b)
+
+				events, err := b.GetEvents(3)
+				require.NoError(t, err)
+				assert.Equal(t, []string{"syn", "d1", "g1"}, traceEventIDs(events))
+
+				events, err = b.GetEvents(0)
+				require.NoError(t, err)
+				assert.Len(t, events, 6)
+			})
+
+			t.Run("GetEvent", func(t *testing.T) {
+				b := newBackend(t)
+				recordTraceFixture(t, b)
+
+				event, err := b.GetEvent("c1")
+				require.NoError(t, err)
+				require.NotNil(t, event)
+				assert.Equal(t, rlmtrace.TraceEvent{
+					ID:        "c1",
+					Type:      rlmtrace.EventSubcall,
+					Action:    "part 1",
+					Details:   "d1",
+					Tokens:    20,
+					Duration:  2 * time.Second,
+					Timestamp: traceFixtureBase.Add(time.Millisecond),
+					Depth:     1,
+					ParentID:  "root",
+					Status:    "completed",
+				}, *event)
+
+				missing, err := b.GetEvent("missing")
+				require.NoError(t, err)
+				assert.Nil(t, missing)
+			})
+
+			t.Run("DuplicateID", func(t *testing.T) {
+				b := newBackend(t)
+				event := TraceEvent{ID: "dup", Type: "DIRECT", Action: "a", Timestamp: traceFixtureBase, Status: "completed"}
+				require.NoError(t, b.RecordEvent(event))
+				assert.Error(t, b.RecordEvent(event))
+
+				events, err := b.GetEvents(10)
+				require.NoError(t, err)
+				assert.Len(t, events, 1)
+			})
+
+			t.Run("StatsAndClear", func(t *testing.T) {
+				b := newBackend(t)
+				recordTraceFixture(t, b)
+
+				stats := b.Stats()
+				assert.Equal(t, 6, stats.TotalEvents)
+				assert.Equal(t, 120, stats.TotalTokens)
+				assert.Equal(t, 4*time.Second, stats.TotalDuration)
+				assert.Equal(t, 2, stats.MaxDepth)
+				assert.Equal(t, map[rlmtrace.TraceEventType]int{
+					rlmtrace.EventDecompose:  1,
+					rlmtrace.EventSubcall:    3,
+					rlmtrace.EventSynthesize: 1,
+					rlmtrace.EventDecision:   1,
+				}, stats.EventsByType)
+
+				require.NoError(t, b.ClearEvents())
+				events, err := b.GetEvents(10)
+				require.NoError(t, err)
+				assert.Empty(t, events)
+				stats = b.Stats()
+				assert.Equal(t, 0, stats.TotalEvents)
+				assert.Empty(t, stats.EventsByType)
+			})
+		})
+	}
+}
+
+func TestTraceBackend_BackendsAgree(t *testing.T) {
+	backends := make(map[string]sessionTraceBackend)
+	for name, newBackend := range traceBackendFactories {
+		backends[name] = newBackend(t)
+		recordTraceFixture(t, backends[name])
+	}
+
+	for _, tc := range traceConformanceQueries {
+		want, err := backends["persistent"].Query(tc.query)
+		require.NoError(t, err, tc.name)
+		got, err := backends["hypergraph"].Query(tc.query)
+		require.NoError(t, err, tc.name)
+		assert.Equal(t, want, got, tc.name)
+	}
+	assert.Equal(t, backends["persistent"].Stats(), backends["hypergraph"].Stats())
+}
+
+func TestHypergraphTraceBackend_SharedStore(t *testing.T) {
+	store := createTestStore(t)
+	a := NewHypergraphTraceBackend(store)
+	b := NewHypergraphTraceBackend(store)
+
+	require.NoError(t, a.RecordEvent(TraceEvent{ID: "e1", Type: "DIRECT", Action: "a", Timestamp: traceFixtureBase, Status: "completed"}))
+
+	events, err := b.GetEvents(10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"e1"}, traceEventIDs(events))
+	assert.Equal(t, 1, b.Stats().TotalEvents)
+}
+
+func TestHypergraphTraceBackend_ScanLimit(t *testing.T) {
+	b := NewHypergraphTraceBackend(createTestStore(t))
+	total := hypergraphTracePageSize + 10
+	for i := range total {
+		require.NoError(t, b.RecordEvent(TraceEvent{
+			ID:        fmt.Sprintf("e%d", i),
+			Type:      "DIRECT",
+			Action:    "a",
+			Timestamp: traceFixtureBase.Add(time.Duration(i) * time.Millisecond),
+			Status:    "completed",
+		}))
+	}
+
+	// The default limit pages past the first page.
+	assert.Equal(t, total, b.Stats().TotalEvents)
+	events, err := b.Query(TraceQuery{Limit: total})
+	require.NoError(t, err)
+	assert.Len(t, events, total)
+
+	b.SetScanLimit(3)
+	assert.Equal(t, 3, b.Stats().TotalEvents)
+	events, err = b.Query(TraceQuery{Limit: total})
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		fmt.Sprintf("e%d", total-3), fmt.Sprintf("e%d", total-2), fmt.Sprintf("e%d", total-1),
+	}, traceEventIDs(events), "only the most recent events are read")
+
+	require.NoError(t, b.ClearEvents())
+	b.SetScanLimit(0)
+	assert.Zero(t, b.Stats().TotalEvents, "clearing is not bound by the scan limit")
+}
+
+func TestService_TraceInStore(t *testing.T) {
+	cfg := DefaultServiceConfig()
+	cfg.TraceInStore = true
+	svc, err := NewService(&mockLLMClient{}, cfg)
+	require.NoError(t, err)
+	defer svc.Stop()
+
+	_, ok := svc.TraceProvider().(*HypergraphTraceBackend)
+	assert.True(t, ok)
+
+	backend := NewHypergraphTraceBackend(svc.Store())
+	require.NoError(t, backend.RecordEvent(TraceEvent{ID: "e1", Type: "DIRECT", Action: "a", Timestamp: traceFixtureBase, Status: "completed"}))
+	events, err := svc.TraceProvider().GetEvents(10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"e1"}, traceEventIDs(events))
+}
+
+func TestService_TraceBackendTakesPrecedence(t *testing.T) {
+	backend := NewHypergraphTraceBackend(createTestStore(t))
+	cfg := DefaultServiceConfig()
+	cfg.TraceBackend = backend
+	cfg.TraceInStore = true
+	svc, err := NewService(&mockLLMClient{}, cfg)
+	require.NoError(t, err)
+	defer svc.Stop()
+
+	assert.Same(t, backend, svc.TraceProvider())
+}
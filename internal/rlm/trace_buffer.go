@@ -0,0 +1,302 @@
+package rlm
+
+import (
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/rand/recurse/internal/tui/components/dialogs/rlmtrace"
+)
+
+// TraceOverflowPolicy decides what a full trace buffer does with a new
+// routine event.
+type TraceOverflowPolicy string
+
+const (
+	// TraceOverflowBlock waits up to BlockTimeout for room, then drops.
+	TraceOverflowBlock TraceOverflowPolicy = "block"
+
+	// TraceOverflowDrop drops the event at once.
+	TraceOverflowDrop TraceOverflowPolicy = "drop"
+)
+
+// TraceBufferConfig configures write-behind buffering of persistent trace
+// events. Critical events (decisions, including the final answer, and
+// failures) are never dropped: when the buffer is full they displace the
+// oldest queued routine event, or wait for the flusher if there is none.
+type TraceBufferConfig struct {
+	// Size is the most events held in memory awaiting a write, including
+	// the batch being written. Zero writes each event synchronously.
+	Size int
+
+	// Overflow is what happens to a routine event when the buffer is full
+	// (default: TraceOverflowBlock).
+	Overflow TraceOverflowPolicy
+
+	// BlockTimeout bounds how long TraceOverflowBlock waits for room
+	// (default: 50ms).
+	BlockTimeout time.Duration
+
+	// BatchSize is the most events written per transaction (default: 64).
+	BatchSize int
+
+	// FlushInterval is how often a partial batch is written (default: 100ms).
+	FlushInterval time.Duration
+}
+
+// Enabled reports whether events are buffered.
+func (c TraceBufferConfig) Enabled() bool {
+	return c.Size > 0
+}
+
+func (c TraceBufferConfig) withDefaults() TraceBufferConfig {
+	if c.Overflow == "" {
+		c.Overflow = TraceOverflowBlock
+	}
+	if c.BlockTimeout <= 0 {
+		c.BlockTimeout = 50 * time.Millisecond
+	}
+	if c.BatchSize <= 0 {
+		c.BatchSize = 64
+	}
+	if c.BatchSize > c.Size {
+		c.BatchSize = c.Size
+	}
+	if c.FlushInterval <= 0 {
+		c.FlushInterval = 100 * time.Millisecond
+	}
+	return c
+}
+
+// TraceBufferMetrics contains trace buffer statistics.
+type TraceBufferMetrics struct {
+	Capacity int
+	Pending  int   // events buffered now
+	Peak     int   // most events buffered at once
+	Written  int64 // events written to storage
+	Dropped  int64 // routine events dropped on overflow
+	Failed   int64 // events lost to write errors
+}
+
+// errTraceBufferClosed is returned for events recorded after Close.
+var errTraceBufferClosed = errors.New("trace buffer closed")
+
+// criticalTraceEvent reports whether event must never be dropped: it is a
+// decision, which includes the final answer, or it reports a failure.
+func criticalTraceEvent(event TraceEvent) bool {
+	return mapEventType(event.Type) == rlmtrace.EventDecision || event.Status == "failed" || event.Status == "error"
+}
+
+// pendingTraceEvent is an event awaiting a write, with the session it was
+// recorded in.
+type pendingTraceEvent struct {
+	event     TraceEvent
+	sessionID string
+}
+
+// traceBuffer queues trace events and writes them in batches on a
+// background goroutine. Events stay queued until written, so the queue
+// length is the whole memory bound.
+type traceBuffer struct {
+	config TraceBufferConfig
+	write  func([]pendingTraceEvent) error
+
+	mu       sync.Mutex
+	queue    []pendingTraceEvent
+	inflight int           // leading queue entries being written
+	freed    chan struct{} // closed and replaced whenever events leave the queue
+	closed   bool
+	peak     int
+	written  int64
+	dropped  int64
+	failed   int64
+
+	flushMu   sync.Mutex // serializes batch writes so events land in order
+	wake      chan struct{}
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// newTraceBuffer starts a buffer that hands batches to write.
+func newTraceBuffer(config TraceBufferConfig, write func([]pendingTraceEvent) error) *traceBuffer {
+	b := &traceBuffer{
+		config: config.withDefaults(),
+		write:  write,
+		freed:  make(chan struct{}),
+		wake:   make(chan struct{}, 1),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	go b.run()
+	return b
+}
+
+// add queues item, applying the overflow policy when the buffer is full.
+func (b *traceBuffer) add(item pendingTraceEvent) error {
+	critical := criticalTraceEvent(item.event)
+	deadline := time.Now().Add(b.config.BlockTimeout)
+
+	b.mu.Lock()
+	for len(b.queue) >= b.config.Size && !b.closed {
+		if critical && b.evictRoutine() {
+			break
+		}
+		wait := time.Until(deadline)
+		if !critical && (b.config.Overflow == TraceOverflowDrop || wait <= 0) {
+			b.dropped++
+			b.mu.Unlock()
+			return nil
+		}
+
+		freed := b.freed
+		b.mu.Unlock()
+		b.signal()
+		if critical {
+			<-freed
+		} else {
+			timer := time.NewTimer(wait)
+			select {
+			case <-freed:
+			case <-timer.C:
+			}
+			timer.Stop()
+		}
+		b.mu.Lock()
+	}
+	if b.closed {
+		b.mu.Unlock()
+		return errTraceBufferClosed
+	}
+
+	b.queue = append(b.queue, item)
+	if len(b.queue) > b.peak {
+		b.peak = len(b.queue)
+	}
+	full := len(b.queue)-b.inflight >= b.config.BatchSize
+	b.mu.Unlock()
+
+	if full {
+		b.signal()
+	}
+	return nil
+}
+
+// evictRoutine drops the oldest queued routine event that is not being
+// written, reporting whether there was one. Callers hold b.mu.
+func (b *traceBuffer) evictRoutine() bool {
+	for i := b.inflight; i < len(b.queue); i++ {
+		if !criticalTraceEvent(b.queue[i].event) {
+			b.queue = append(b.queue[:i], b.queue[i+1:]...)
+			b.dropped++
+			return true
+		}
+	}
+	return false
+}
+
+// signal wakes the flusher without blocking.
+func (b *traceBuffer) signal() {
+	select {
+	case b.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (b *traceBuffer) run() {
+	defer close(b.done)
+	ticker := time.NewTicker(b.config.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-b.stop:
+			return
+		case <-b.wake:
+		case <-ticker.C:
+		}
+		if err := b.flush(); err != nil {
+			slog.Warn("Failed to write trace events", "error", err)
+		}
+	}
+}
+
+// flush writes everything queued, returning the last write error.
+func (b *traceBuffer) flush() error {
+	var lastErr error
+	for {
+		wrote, err := b.flushBatch()
+		if err != nil {
+			lastErr = err
+		}
+		if !wrote {
+			return lastErr
+		}
+	}
+}
+
+// flushBatch writes up to one batch from the head of the queue, reporting
+// whether there was anything to write. A failed batch is discarded.
+func (b *traceBuffer) flushBatch() (bool, error) {
+	b.flushMu.Lock()
+	defer b.flushMu.Unlock()
+
+	b.mu.Lock()
+	n := min(len(b.queue), b.config.BatchSize)
+	if n == 0 {
+		b.mu.Unlock()
+		return false, nil
+	}
+	batch := append([]pendingTraceEvent(nil), b.queue[:n]...)
+	b.inflight = n
+	b.mu.Unlock()
+
+	err := b.write(batch)
+
+	b.mu.Lock()
+	b.queue = b.queue[n:]
+	b.inflight = 0
+	if err != nil {
+		b.failed += int64(n)
+	} else {
+		b.written += int64(n)
+	}
+	close(b.freed)
+	b.freed = make(chan struct{})
+	b.mu.Unlock()
+
+	return true, err
+}
+
+// close stops the flusher and writes what remains. Events recorded
+// afterwards are rejected.
+func (b *traceBuffer) close() error {
+	var err error
+	b.closeOnce.Do(func() {
+		b.mu.Lock()
+		b.closed = true
+		close(b.freed)
+		b.freed = make(chan struct{})
+		b.mu.Unlock()
+
+		close(b.stop)
+		<-b.done
+		err = b.flush()
+	})
+	return err
+}
+
+// metrics returns buffer statistics.
+func (b *traceBuffer) metrics() TraceBufferMetrics {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return TraceBufferMetrics{
+		Capacity: b.config.Size,
+		Pending:  len(b.queue),
+		Peak:     b.peak,
+		Written:  b.written,
+		Dropped:  b.dropped,
+		Failed:   b.failed,
+	}
+}
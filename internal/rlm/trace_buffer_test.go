@@ -0,0 +1,180 @@
+package rlm
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// slowTraceSink records batches after a fixed delay, standing in for a
+// slow disk, and tracks the most events the buffer held during a write.
+type slowTraceSink struct {
+	delay time.Duration
+
+	mu      sync.Mutex
+	buffer  *traceBuffer
+	ids     []string
+	batches int
+	maxHeld int
+}
+
+func (s *slowTraceSink) write(batch []pendingTraceEvent) error {
+	time.Sleep(s.delay)
+	held := s.buffer.metrics().Pending
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.batches++
+	s.maxHeld = max(s.maxHeld, held)
+	for _, item := range batch {
+		s.ids = append(s.ids, item.event.ID)
+	}
+	return nil
+}
+
+func (s *slowTraceSink) written() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.ids...)
+}
+
+// burstEvent returns the ith event of a burst: every tenth is a decision
+// and every fifteenth a failure, the rest routine.
+func burstEvent(i int) TraceEvent {
+	event := TraceEvent{ID: fmt.Sprintf("evt-%03d", i), Type: "execute", Status: "completed"}
+	if i%10 == 0 {
+		event.Type = "decision"
+	}
+	if i%15 == 0 {
+		event.Status = "failed"
+	}
+	return event
+}
+
+func TestTraceBuffer_DropPolicyKeepsBoundAndCriticalEvents(t *testing.T) {
+	const size, burst = 8, 200
+	sink := &slowTraceSink{delay: 10 * time.Millisecond}
+	b := newTraceBuffer(TraceBufferConfig{
+		Size:      size,
+		Overflow:  TraceOverflowDrop,
+		BatchSize: 4,
+	}, sink.write)
+	sink.buffer = b
+
+	for i := 0; i < burst; i++ {
+		require.NoError(t, b.add(pendingTraceEvent{event: burstEvent(i)}))
+		assert.LessOrEqual(t, b.metrics().Pending, size)
+	}
+	require.NoError(t, b.close())
+
+	m := b.metrics()
+	assert.LessOrEqual(t, m.Peak, size)
+	assert.LessOrEqual(t, sink.maxHeld, size)
+	assert.Zero(t, m.Pending)
+	assert.Positive(t, m.Dropped, "a burst against a slow disk overflows")
+	assert.Equal(t, int64(burst), m.Written+m.Dropped)
+	assert.Less(t, sink.batches, burst, "events are written in batches")
+
+	written := make(map[string]bool)
+	for _, id := range sink.written() {
+		written[id] = true
+	}
+	for i := 0; i < burst; i++ {
+		if event := burstEvent(i); criticalTraceEvent(event) {
+			assert.True(t, written[event.ID], "critical event %s dropped", event.ID)
+		}
+	}
+}
+
+func TestTraceBuffer_BlockPolicyWaitsForRoom(t *testing.T) {
+	sink := &slowTraceSink{delay: 2 * time.Millisecond}
+	b := newTraceBuffer(TraceBufferConfig{
+		Size:         4,
+		BatchSize:    2,
+		BlockTimeout: time.Second,
+	}, sink.write)
+	sink.buffer = b
+
+	var want []string
+	for i := 1; i <= 40; i++ {
+		event := TraceEvent{ID: fmt.Sprintf("evt-%02d", i), Type: "execute", Status: "completed"}
+		require.NoError(t, b.add(pendingTraceEvent{event: event}))
+		want = append(want, event.ID)
+	}
+	require.NoError(t, b.close())
+
+	m := b.metrics()
+	assert.Zero(t, m.Dropped)
+	assert.LessOrEqual(t, m.Peak, 4)
+	assert.Equal(t, want, sink.written(), "events are written in order")
+
+	assert.ErrorIs(t, b.add(pendingTraceEvent{event: TraceEvent{ID: "late"}}), errTraceBufferClosed)
+}
+
+func TestTraceBuffer_CriticalEventWaitsWhenBufferIsAllCritical(t *testing.T) {
+	release := make(chan struct{})
+	var mu sync.Mutex
+	var ids []string
+	b := newTraceBuffer(TraceBufferConfig{Size: 2, BatchSize: 1, Overflow: TraceOverflowDrop}, func(batch []pendingTraceEvent) error {
+		<-release
+		mu.Lock()
+		defer mu.Unlock()
+		for _, item := range batch {
+			ids = append(ids, item.event.ID)
+		}
+		return nil
+	})
+
+	require.NoError(t, b.add(pendingTraceEvent{event: TraceEvent{ID: "d1", Type: "decision"}}))
+	require.NoError(t, b.add(pendingTraceEvent{event: TraceEvent{ID: "d2", Type: "decision"}}))
+	require.NoError(t, b.add(pendingTraceEvent{event: TraceEvent{ID: "routine", Type: "execute"}}))
+
+	added := make(chan error, 1)
+	go func() {
+		added <- b.add(pendingTraceEvent{event: TraceEvent{ID: "final", Type: "decision"}})
+	}()
+	select {
+	case <-added:
+		t.Fatal("critical event should wait for room, not be dropped")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	require.NoError(t, <-added)
+	require.NoError(t, b.close())
+
+	assert.Equal(t, []string{"d1", "d2", "final"}, ids)
+	assert.Equal(t, int64(1), b.metrics().Dropped)
+}
+
+func TestPersistentTraceProvider_Buffered(t *testing.T) {
+	provider, err := NewPersistentTraceProvider(PersistentTraceConfig{
+		Path:   filepath.Join(t.TempDir(), "trace.db"),
+		Buffer: TraceBufferConfig{Size: 16, BatchSize: 8, FlushInterval: time.Hour},
+	})
+	require.NoError(t, err)
+	defer provider.Close()
+
+	for i := 0; i < 50; i++ {
+		event := burstEvent(i)
+		event.Timestamp = time.Now()
+		require.NoError(t, provider.RecordEvent(event))
+	}
+
+	// Reads see buffered events
+	events, err := provider.GetEvents(100)
+	require.NoError(t, err)
+	assert.Len(t, events, 50)
+	assert.Equal(t, 50, provider.Stats().TotalEvents)
+
+	m := provider.BufferMetrics()
+	assert.Equal(t, 16, m.Capacity)
+	assert.Equal(t, int64(50), m.Written)
+	assert.Zero(t, m.Dropped)
+	assert.LessOrEqual(t, m.Peak, 16)
+}
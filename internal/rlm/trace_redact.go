@@ -0,0 +1,22 @@
+package rlm
+
+import "github.com/rand/recurse/internal/rlm/redact"
+
+// TraceRedactor redacts secrets from trace events before recording them to
+// another recorder, so no trace backend stores them.
+type TraceRedactor struct {
+	next     TraceRecorder
+	redactor *redact.Redactor
+}
+
+// NewTraceRedactor creates a redactor that records to next.
+func NewTraceRedactor(next TraceRecorder, redactor *redact.Redactor) *TraceRedactor {
+	return &TraceRedactor{next: next, redactor: redactor}
+}
+
+// RecordEvent implements TraceRecorder.
+func (r *TraceRedactor) RecordEvent(event TraceEvent) error {
+	event.Action = r.redactor.Redact(event.Action)
+	event.Details = r.redactor.Redact(event.Details)
+	return r.next.RecordEvent(event)
+}
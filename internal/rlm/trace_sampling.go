@@ -0,0 +1,244 @@
+package rlm
+
+import (
+	"hash/fnv"
+	"math"
+	"sync"
+	"time"
+)
+
+// TraceSamplingConfig configures trace event sampling.
+type TraceSamplingConfig struct {
+	// Rate is the fraction of executions whose routine events are kept.
+	// Zero or 1 keeps every event.
+	Rate float64
+
+	// HighLatency marks an execution interesting once any of its events
+	// takes at least this long (default: 30s).
+	HighLatency time.Duration
+}
+
+// Enabled reports whether the config drops anything.
+func (c TraceSamplingConfig) Enabled() bool {
+	return c.Rate > 0 && c.Rate < 1
+}
+
+const (
+	// maxSampledExecutions bounds the executions a TraceSampler tracks.
+	maxSampledExecutions = 1024
+
+	// maxPendingTraceEvents bounds the dropped events held per execution
+	// for replay if it turns interesting.
+	maxPendingTraceEvents = 256
+)
+
+// interestingTraceStatuses mark an execution for full detail.
+var interestingTraceStatuses = map[string]bool{
+	"failed":   true,
+	"error":    true,
+	"degraded": true,
+	"warning":  true,
+}
+
+// sampledExecution is the sampling state of one execution tree.
+type sampledExecution struct {
+	keep        bool // routine events are sampled in
+	interesting bool // full detail from now on
+	kept        map[string]bool
+	pending     []TraceEvent // dropped routine events, oldest first
+	eventIDs    []string
+}
+
+// TraceSampler records a sample of trace events to another recorder.
+//
+// Decision, error and final events are always kept. Routine events (sub-calls,
+// REPL executions, decomposition steps) are kept for a Rate fraction of
+// executions. An execution is the tree of events under one root event, and
+// the decision is a hash of the root ID, so a sampled tree is kept whole.
+// Executions that fail, degrade, warn or run slowly switch to full detail:
+// their dropped events are replayed, so a kept event never lacks its parent.
+type TraceSampler struct {
+	next   TraceRecorder
+	config TraceSamplingConfig
+
+	mu         sync.Mutex
+	executions map[string]*sampledExecution
+	order      []string          // execution IDs, oldest first
+	eventExec  map[string]string // event ID -> execution ID
+
+	// Metrics
+	recorded int64
+	dropped  int64
+	replayed int64
+}
+
+// NewTraceSampler creates a sampler that records to next.
+func NewTraceSampler(next TraceRecorder, config TraceSamplingConfig) *TraceSampler {
+	if config.HighLatency <= 0 {
+		config.HighLatency = 30 * time.Second
+	}
+	return &TraceSampler{
+		next:       next,
+		config:     config,
+		executions: make(map[string]*sampledExecution),
+		eventExec:  make(map[string]string),
+	}
+}
+
+// RecordEvent implements TraceRecorder.
+func (s *TraceSampler) RecordEvent(event TraceEvent) error {
+	if !s.config.Enabled() {
+		return s.next.RecordEvent(event)
+	}
+
+	s.mu.Lock()
+	execID, exec := s.execution(event)
+	if s.isInteresting(event) {
+		exec.interesting = true
+	}
+
+	keep := exec.keep || exec.interesting || exec.kept[event.ID] || s.isCritical(event)
+	if !keep {
+		if len(exec.pending) >= maxPendingTraceEvents {
+			exec.pending = exec.pending[1:]
+		}
+		exec.pending = append(exec.pending, event)
+		s.dropped++
+		s.finish(execID, event)
+		s.mu.Unlock()
+		return nil
+	}
+
+	// Replay dropped events once the execution needs them: it turned
+	// interesting, or this event hangs off a dropped parent.
+	var replay []TraceEvent
+	if exec.interesting || (event.ParentID != "" && !exec.kept[event.ParentID] && s.eventExec[event.ParentID] == execID) {
+		replay = exec.pending
+		exec.pending = nil
+		s.replayed += int64(len(replay))
+		s.dropped -= int64(len(replay))
+	}
+	for _, e := range replay {
+		exec.kept[e.ID] = true
+	}
+	exec.kept[event.ID] = true
+	s.recorded += int64(len(replay)) + 1
+	s.finish(execID, event)
+	s.mu.Unlock()
+
+	for _, e := range replay {
+		if err := s.next.RecordEvent(e); err != nil {
+			return err
+		}
+	}
+	return s.next.RecordEvent(event)
+}
+
+var _ InterestingMarker = (*TraceSampler)(nil)
+
+// MarkInteresting keeps full detail for the rest of the execution that
+// eventID belongs to, for signals the events themselves do not carry, such
+// as budget warnings. The orchestrator calls it when an execution nears its
+// cost ceiling.
+func (s *TraceSampler) MarkInteresting(eventID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if execID, ok := s.eventExec[eventID]; ok {
+		s.executions[execID].interesting = true
+	}
+}
+
+// execution returns the execution event belongs to, creating it for a new
+// root. Callers hold s.mu.
+func (s *TraceSampler) execution(event TraceEvent) (string, *sampledExecution) {
+	execID, ok := s.eventExec[event.ID]
+	if !ok {
+		if parentExec, found := s.eventExec[event.ParentID]; found {
+			execID = parentExec
+		} else if event.ParentID != "" {
+			execID = event.ParentID
+		} else {
+			execID = event.ID
+		}
+	}
+
+	exec, ok := s.executions[execID]
+	if !ok {
+		if len(s.order) >= maxSampledExecutions {
+			s.forget(s.order[0])
+		}
+		exec = &sampledExecution{
+			keep: sampleExecution(execID, s.config.Rate),
+			kept: make(map[string]bool),
+		}
+		s.executions[execID] = exec
+		s.order = append(s.order, execID)
+	}
+	if _, ok := s.eventExec[event.ID]; !ok && event.ID != "" {
+		s.eventExec[event.ID] = execID
+		exec.eventIDs = append(exec.eventIDs, event.ID)
+	}
+	return execID, exec
+}
+
+// finish forgets an execution once its root event completes. Callers hold
+// s.mu.
+func (s *TraceSampler) finish(execID string, event TraceEvent) {
+	if event.ID == execID && event.ParentID == "" && event.Status != "running" {
+		s.forget(execID)
+	}
+}
+
+// forget drops the state of an execution. Callers hold s.mu.
+func (s *TraceSampler) forget(execID string) {
+	exec, ok := s.executions[execID]
+	if !ok {
+		return
+	}
+	for _, id := range exec.eventIDs {
+		delete(s.eventExec, id)
+	}
+	delete(s.executions, execID)
+	for i, id := range s.order {
+		if id == execID {
+			s.order = append(s.order[:i], s.order[i+1:]...)
+			break
+		}
+	}
+}
+
+// isCritical reports whether event is always kept.
+func (s *TraceSampler) isCritical(event TraceEvent) bool {
+	return criticalTraceEvent(event) || interestingTraceStatuses[event.Status]
+}
+
+// isInteresting reports whether event switches its execution to full detail.
+func (s *TraceSampler) isInteresting(event TraceEvent) bool {
+	return interestingTraceStatuses[event.Status] || event.Duration >= s.config.HighLatency
+}
+
+// sampleExecution reports whether execID falls within rate. The decision
+// depends only on the ID, so it is stable for the whole execution.
+func sampleExecution(execID string, rate float64) bool {
+	h := fnv.New32a()
+	h.Write([]byte(execID))
+	return float64(h.Sum32()) < rate*math.MaxUint32
+}
+
+// Metrics returns sampler statistics.
+func (s *TraceSampler) Metrics() TraceSamplerMetrics {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return TraceSamplerMetrics{
+		Recorded: s.recorded,
+		Dropped:  s.dropped,
+		Replayed: s.replayed,
+	}
+}
+
+// TraceSamplerMetrics contains trace sampler statistics.
+type TraceSamplerMetrics struct {
+	Recorded int64
+	Dropped  int64
+	Replayed int64 // dropped events later recorded for an interesting execution
+}
@@ -0,0 +1,240 @@
+package rlm
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// unsampledExecutionID returns an execution ID whose routine events rate
+// drops.
+func unsampledExecutionID(t *testing.T, rate float64) string {
+	t.Helper()
+	for i := 0; i < 1000; i++ {
+		id := fmt.Sprintf("exec-%d", i)
+		if !sampleExecution(id, rate) {
+			return id
+		}
+	}
+	t.Fatal("no unsampled execution ID")
+	return ""
+}
+
+func recordedIDs(events []TraceEvent) []string {
+	ids := make([]string, len(events))
+	for i, e := range events {
+		ids[i] = e.ID
+	}
+	return ids
+}
+
+func TestTraceSamplingConfig_Enabled(t *testing.T) {
+	assert.False(t, TraceSamplingConfig{}.Enabled())
+	assert.False(t, TraceSamplingConfig{Rate: 1}.Enabled())
+	assert.True(t, TraceSamplingConfig{Rate: 0.1}.Enabled())
+}
+
+func TestTraceSampler_DisabledKeepsEverything(t *testing.T) {
+	next := &mockTraceRecorder{}
+	s := NewTraceSampler(next, TraceSamplingConfig{})
+
+	for i := 0; i < 50; i++ {
+		require.NoError(t, s.RecordEvent(TraceEvent{ID: fmt.Sprintf("e%d", i), Type: "execute", Status: "completed"}))
+	}
+	assert.Len(t, next.events, 50)
+}
+
+func TestTraceSampler_CriticalEventsNeverDropped(t *testing.T) {
+	next := &mockTraceRecorder{}
+	s := NewTraceSampler(next, TraceSamplingConfig{Rate: 0.01})
+
+	var critical []string
+	for i := 0; i < 200; i++ {
+		root := fmt.Sprintf("root-%d", i)
+		events := []TraceEvent{
+			{ID: root, Type: "decision", Status: "running"},
+			{ID: root + "-sub", Type: "SUBCALL", ParentID: root, Status: "completed"},
+			{ID: root + "-rec", Type: "recovery", ParentID: root, Status: "attempting"},
+			{ID: root + "-err", Type: "execute", ParentID: root, Status: "failed"},
+			{ID: root, Type: "DIRECT", Status: "completed"},
+		}
+		for _, e := range events {
+			require.NoError(t, s.RecordEvent(e))
+		}
+		critical = append(critical, root, root+"-rec", root+"-err")
+	}
+
+	kept := make(map[string]bool)
+	for _, e := range next.events {
+		kept[e.ID] = true
+	}
+	for _, id := range critical {
+		assert.True(t, kept[id], "critical event %s dropped", id)
+	}
+
+	// The final event of each root is recorded as well as its start.
+	finals := 0
+	for _, e := range next.events {
+		if e.Type == "DIRECT" {
+			finals++
+		}
+	}
+	assert.Equal(t, 200, finals)
+}
+
+func TestTraceSampler_RoutineEventsSampledAtRate(t *testing.T) {
+	for _, rate := range []float64{0.1, 0.25, 0.5} {
+		t.Run(fmt.Sprint(rate), func(t *testing.T) {
+			next := &mockTraceRecorder{}
+			s := NewTraceSampler(next, TraceSamplingConfig{Rate: rate})
+
+			const n = 4000
+			for i := 0; i < n; i++ {
+				require.NoError(t, s.RecordEvent(TraceEvent{
+					ID:     fmt.Sprintf("exec-%d", i),
+					Type:   "execute",
+					Status: "completed",
+				}))
+			}
+
+			got := float64(len(next.events)) / n
+			assert.InDelta(t, rate, got, 0.03)
+
+			m := s.Metrics()
+			assert.Equal(t, int64(len(next.events)), m.Recorded)
+			assert.Equal(t, int64(n), m.Recorded+m.Dropped)
+		})
+	}
+}
+
+func TestTraceSampler_StableWithinExecution(t *testing.T) {
+	next := &mockTraceRecorder{}
+	s := NewTraceSampler(next, TraceSamplingConfig{Rate: 0.5})
+
+	for i := 0; i < 200; i++ {
+		root := fmt.Sprintf("exec-%d", i)
+		require.NoError(t, s.RecordEvent(TraceEvent{ID: root, Type: "execute", Status: "running"}))
+		for j := 0; j < 3; j++ {
+			child := fmt.Sprintf("%s-%d", root, j)
+			require.NoError(t, s.RecordEvent(TraceEvent{ID: child, Type: "SUBCALL", ParentID: root, Status: "completed"}))
+			require.NoError(t, s.RecordEvent(TraceEvent{ID: child + "-x", Type: "execute", ParentID: child, Status: "completed"}))
+		}
+		require.NoError(t, s.RecordEvent(TraceEvent{ID: root, Type: "execute", Status: "completed"}))
+	}
+
+	perExecution := make(map[int]int)
+	kept := make(map[string]bool)
+	for _, e := range next.events {
+		if e.ParentID != "" {
+			assert.True(t, kept[e.ParentID], "event %s kept without parent %s", e.ID, e.ParentID)
+		}
+		kept[e.ID] = true
+		perExecution[execIndex(e.ID)]++
+	}
+
+	// Each execution is kept whole (8 events) or not at all.
+	for exec, count := range perExecution {
+		assert.Equal(t, 8, count, exec)
+	}
+	assert.NotEmpty(t, perExecution)
+	assert.Less(t, len(perExecution), 200)
+}
+
+// execIndex parses the execution number from an "exec-N[-...]" event ID.
+func execIndex(id string) int {
+	var n int
+	fmt.Sscanf(id, "exec-%d", &n)
+	return n
+}
+
+func TestTraceSampler_InterestingExecutionReplaysDropped(t *testing.T) {
+	next := &mockTraceRecorder{}
+	s := NewTraceSampler(next, TraceSamplingConfig{Rate: 0.1})
+	root := unsampledExecutionID(t, 0.1)
+
+	require.NoError(t, s.RecordEvent(TraceEvent{ID: root, Type: "execute", Status: "running"}))
+	require.NoError(t, s.RecordEvent(TraceEvent{ID: "a", Type: "SUBCALL", ParentID: root, Status: "completed"}))
+	require.NoError(t, s.RecordEvent(TraceEvent{ID: "b", Type: "execute", ParentID: "a", Status: "completed"}))
+	assert.Empty(t, next.events)
+
+	// A failure switches the execution to full detail.
+	require.NoError(t, s.RecordEvent(TraceEvent{ID: "c", Type: "execute", ParentID: "a", Status: "failed"}))
+	assert.Equal(t, []string{root, "a", "b", "c"}, recordedIDs(next.events))
+
+	require.NoError(t, s.RecordEvent(TraceEvent{ID: "d", Type: "execute", ParentID: root, Status: "completed"}))
+	assert.Equal(t, []string{root, "a", "b", "c", "d"}, recordedIDs(next.events))
+	assert.Equal(t, int64(3), s.Metrics().Replayed)
+}
+
+func TestTraceSampler_HighLatencyIsInteresting(t *testing.T) {
+	next := &mockTraceRecorder{}
+	s := NewTraceSampler(next, TraceSamplingConfig{Rate: 0.1, HighLatency: time.Second})
+	root := unsampledExecutionID(t, 0.1)
+
+	require.NoError(t, s.RecordEvent(TraceEvent{ID: root, Type: "execute", Status: "running"}))
+	require.NoError(t, s.RecordEvent(TraceEvent{ID: "fast", Type: "SUBCALL", ParentID: root, Duration: time.Millisecond, Status: "completed"}))
+	assert.Empty(t, next.events)
+
+	require.NoError(t, s.RecordEvent(TraceEvent{ID: "slow", Type: "SUBCALL", ParentID: root, Duration: 2 * time.Second, Status: "completed"}))
+	assert.Equal(t, []string{root, "fast", "slow"}, recordedIDs(next.events))
+}
+
+func TestTraceSampler_CriticalChildKeepsDroppedParent(t *testing.T) {
+	next := &mockTraceRecorder{}
+	s := NewTraceSampler(next, TraceSamplingConfig{Rate: 0.1})
+	root := unsampledExecutionID(t, 0.1)
+
+	require.NoError(t, s.RecordEvent(TraceEvent{ID: root, Type: "decision", Status: "running"}))
+	require.NoError(t, s.RecordEvent(TraceEvent{ID: "sub", Type: "SUBCALL", ParentID: root, Status: "completed"}))
+	assert.Equal(t, []string{root}, recordedIDs(next.events))
+
+	// A nested decision is kept, and brings its dropped parent with it.
+	require.NoError(t, s.RecordEvent(TraceEvent{ID: "nested", Type: "decision", ParentID: "sub", Status: "running"}))
+	assert.Equal(t, []string{root, "sub", "nested"}, recordedIDs(next.events))
+}
+
+func TestTraceSampler_MarkInteresting(t *testing.T) {
+	next := &mockTraceRecorder{}
+	s := NewTraceSampler(next, TraceSamplingConfig{Rate: 0.1})
+	root := unsampledExecutionID(t, 0.1)
+
+	require.NoError(t, s.RecordEvent(TraceEvent{ID: root, Type: "execute", Status: "running"}))
+	require.NoError(t, s.RecordEvent(TraceEvent{ID: "a", Type: "SUBCALL", ParentID: root, Status: "completed"}))
+	assert.Empty(t, next.events)
+
+	// e.g. a budget warning during the execution
+	s.MarkInteresting("a")
+	require.NoError(t, s.RecordEvent(TraceEvent{ID: "b", Type: "SUBCALL", ParentID: root, Status: "completed"}))
+	assert.Equal(t, []string{root, "a", "b"}, recordedIDs(next.events))
+}
+
+func TestTraceSampler_ForgetsFinishedExecutions(t *testing.T) {
+	s := NewTraceSampler(&mockTraceRecorder{}, TraceSamplingConfig{Rate: 0.5})
+
+	for i := 0; i < 10; i++ {
+		root := fmt.Sprintf("exec-%d", i)
+		require.NoError(t, s.RecordEvent(TraceEvent{ID: root, Type: "decision", Status: "running"}))
+		require.NoError(t, s.RecordEvent(TraceEvent{ID: root + "-a", Type: "SUBCALL", ParentID: root, Status: "completed"}))
+		require.NoError(t, s.RecordEvent(TraceEvent{ID: root, Type: "DIRECT", Status: "completed"}))
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	assert.Empty(t, s.executions)
+	assert.Empty(t, s.eventExec)
+	assert.Empty(t, s.order)
+}
+
+func TestService_TraceSampling(t *testing.T) {
+	cfg := DefaultServiceConfig()
+	cfg.TraceSampling = TraceSamplingConfig{Rate: 0.5}
+	svc, err := NewService(&mockLLMClient{}, cfg)
+	require.NoError(t, err)
+	defer svc.Stop()
+
+	_, ok := svc.Controller().Tracer().(*TraceSampler)
+	assert.True(t, ok)
+}
@@ -3,7 +3,6 @@ package verify
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"regexp"
 	"strings"
@@ -54,6 +53,14 @@ type Constraint struct {
 
 	// Variables lists the variables involved in this constraint.
 	Variables []string
+
+	// Function is the function the constraint was extracted from, when
+	// extracted per function (see Reverify); empty for top-level code.
+	Function string
+
+	// Changed is set by ExtractChangeConstraints on constraints of code the
+	// change added or modified.
+	Changed bool
 }
 
 // VerificationResult contains the outcome of constraint verification.
@@ -75,6 +82,23 @@ type VerificationResult struct {
 
 	// SolverOutput contains raw solver output for debugging.
 	SolverOutput string
+
+	// Granular is set when each constraint result is its own verdict, as
+	// from VerifyGroups, rather than whether it loaded into a shared model.
+	Granular bool
+
+	// Confidence is how far to trust the verdict, in [0, 1], aggregated
+	// from the confidence of each constraint and whether it was satisfied
+	// (see ConfidenceAggregator). A result satisfied only by inferred,
+	// low-confidence constraints scores lower than one backed by type
+	// checks; one that checked no constraints scores zero.
+	Confidence float64
+
+	// RemovedGuarantees are constraints the code held before the change
+	// that it no longer states, e.g. a deleted assert, set when the change
+	// has a Before. They are not checked, since the code no longer
+	// expresses them, but a caller may treat them as possible regressions.
+	RemovedGuarantees []Constraint
 }
 
 // VerificationStatus represents the outcome of verification.
@@ -93,6 +117,10 @@ type ConstraintResult struct {
 	Constraint *Constraint
 	Satisfied  bool
 	Message    string
+
+	// Cached is set when the result was reused from an earlier
+	// verification instead of solved again.
+	Cached bool
 }
 
 // CounterExample provides values that violate constraints.
@@ -119,18 +147,32 @@ type CodeChange struct {
 	Context string
 }
 
+// codeExecutor runs Python code; *repl.Manager implements it.
+type codeExecutor interface {
+	Execute(ctx context.Context, code string) (*repl.ExecuteResult, error)
+}
+
 // VerificationChain orchestrates constraint extraction and verification via REPL.
 type VerificationChain struct {
-	repl    *repl.Manager
-	timeout time.Duration
+	repl        codeExecutor
+	solver      SolverBackend
+	aggregator  ConfidenceAggregator
+	timeout     time.Duration
+	parallelism int
+
+	// Extra REPLs VerifyGroups solves on alongside repl
+	workers []codeExecutor
 }
 
 // NewVerificationChain creates a new verification chain with the given REPL manager.
 func NewVerificationChain(replMgr *repl.Manager) *VerificationChain {
-	return &VerificationChain{
-		repl:    replMgr,
+	c := &VerificationChain{
 		timeout: 30 * time.Second,
 	}
+	if replMgr != nil {
+		c.repl = replMgr
+	}
+	return c
 }
 
 // SetTimeout configures the verification timeout.
@@ -138,6 +180,39 @@ func (c *VerificationChain) SetTimeout(d time.Duration) {
 	c.timeout = d
 }
 
+// SetSolver selects the solver backend; nil restores the default,
+// CPMpyBackend.
+func (c *VerificationChain) SetSolver(solver SolverBackend) {
+	c.solver = solver
+}
+
+// Solver returns the solver backend in use.
+func (c *VerificationChain) Solver() SolverBackend {
+	if c.solver == nil {
+		return CPMpyBackend{}
+	}
+	return c.solver
+}
+
+// SetParallelism makes VerifyChange solve independent constraint groups
+// separately, so each constraint gets its own verdict, up to n at a time
+// on the REPLs given to SetWorkers. Zero, the default, solves all
+// constraints together in one model.
+func (c *VerificationChain) SetParallelism(n int) {
+	c.parallelism = max(n, 0)
+}
+
+// SetWorkers adds REPLs for VerifyGroups to solve on alongside the chain's
+// own, one group at a time each. A REPL runs one execution at a time, so
+// without workers the groups are solved one after another. The caller
+// starts and stops the workers.
+func (c *VerificationChain) SetWorkers(replMgrs ...*repl.Manager) {
+	c.workers = c.workers[:0]
+	for _, mgr := range replMgrs {
+		c.workers = append(c.workers, mgr)
+	}
+}
+
 // GeneratePreconditions extracts preconditions from code documentation.
 func (c *VerificationChain) GeneratePreconditions(ctx context.Context, change CodeChange) ([]Constraint, error) {
 	var constraints []Constraint
@@ -187,25 +262,31 @@ func (c *VerificationChain) GenerateInvariants(ctx context.Context, change CodeC
 	return constraints, nil
 }
 
-// Verify checks that code satisfies the given constraints using CPMpy.
+// Verify checks that code satisfies the given constraints using the solver
+// backend, CPMpy by default.
 func (c *VerificationChain) Verify(ctx context.Context, constraints []Constraint, code string) (*VerificationResult, error) {
 	if c.repl == nil {
 		return nil, fmt.Errorf("REPL manager not configured")
 	}
+	return c.verifyOn(ctx, c.repl, constraints, code)
+}
 
+// verifyOn is Verify on the REPL exec.
+func (c *VerificationChain) verifyOn(ctx context.Context, exec codeExecutor, constraints []Constraint, code string) (*VerificationResult, error) {
 	start := time.Now()
 	result := &VerificationResult{
 		CheckedConstraints: make([]ConstraintResult, 0, len(constraints)),
 	}
 
-	// Build CPMpy verification code
-	verifyCode := c.buildVerificationCode(constraints, code)
+	// Build the backend's verification code
+	solver := c.Solver()
+	verifyCode := solver.BuildCode(constraints, code)
 
 	// Execute via REPL with timeout
 	ctx, cancel := context.WithTimeout(ctx, c.timeout)
 	defer cancel()
 
-	execResult, err := c.repl.Execute(ctx, verifyCode)
+	execResult, err := exec.Execute(ctx, verifyCode)
 	if err != nil {
 		if ctx.Err() == context.DeadlineExceeded {
 			result.Status = StatusTimeout
@@ -227,198 +308,82 @@ func (c *VerificationChain) Verify(ctx context.Context, constraints []Constraint
 	}
 
 	// Parse the JSON result from REPL
-	return c.parseVerificationResult(execResult.ReturnVal, constraints, result)
+	result, err = solver.ParseResult(execResult.ReturnVal, constraints, result)
+	if result != nil {
+		c.aggregateConfidence(result)
+	}
+	return result, err
 }
 
 // VerifyChange is a convenience method that generates constraints and verifies.
+// With a Before, only the constraints of the regions the change added or
+// modified are checked, and the guarantees it lost are reported.
 func (c *VerificationChain) VerifyChange(ctx context.Context, change CodeChange) (*VerificationResult, error) {
-	// Collect all constraints
-	var allConstraints []Constraint
-
-	pre, err := c.GeneratePreconditions(ctx, change)
-	if err != nil {
-		return nil, fmt.Errorf("generate preconditions: %w", err)
-	}
-	allConstraints = append(allConstraints, pre...)
-
-	post, err := c.GeneratePostconditions(ctx, change)
-	if err != nil {
-		return nil, fmt.Errorf("generate postconditions: %w", err)
-	}
-	allConstraints = append(allConstraints, post...)
-
-	inv, err := c.GenerateInvariants(ctx, change)
+	allConstraints, removed, err := c.changeConstraints(ctx, change)
 	if err != nil {
-		return nil, fmt.Errorf("generate invariants: %w", err)
+		return nil, err
 	}
-	allConstraints = append(allConstraints, inv...)
 
 	if len(allConstraints) == 0 {
 		// No constraints to verify
 		return &VerificationResult{
-			Satisfied: true,
-			Status:    StatusSatisfied,
+			Satisfied:         true,
+			Status:            StatusSatisfied,
+			RemovedGuarantees: removed,
 		}, nil
 	}
 
-	return c.Verify(ctx, allConstraints, change.After)
+	var result *VerificationResult
+	if c.parallelism > 0 {
+		result, err = c.VerifyGroups(ctx, allConstraints, change.After)
+	} else {
+		result, err = c.Verify(ctx, allConstraints, change.After)
+	}
+	if result != nil {
+		result.RemovedGuarantees = removed
+	}
+	return result, err
 }
 
-// buildVerificationCode generates Python code for CPMpy constraint solving.
-func (c *VerificationChain) buildVerificationCode(constraints []Constraint, code string) string {
-	var sb strings.Builder
-
-	// Import CPMpy
-	sb.WriteString(`
-import json
-try:
-    from cpmpy import *
-except ImportError:
-    # Fallback to simple constraint checking without CPMpy
-    class Model:
-        def __init__(self):
-            self.constraints = []
-        def __iadd__(self, constraint):
-            self.constraints.append(constraint)
-            return self
-        def solve(self):
-            return all(c for c in self.constraints if isinstance(c, bool))
-    def intvar(lb, ub, name=None):
-        return (lb + ub) // 2  # Return midpoint as placeholder
-    def boolvar(name=None):
-        return True
-
-# Create model
-model = Model()
-
-# Define variables
-variables = {}
-`)
-
-	// Extract and declare variables
-	varSet := make(map[string]bool)
-	for _, constraint := range constraints {
-		for _, v := range constraint.Variables {
-			if !varSet[v] {
-				varSet[v] = true
-				sb.WriteString(fmt.Sprintf("variables['%s'] = intvar(-1000000, 1000000, name='%s')\n", v, v))
-			}
-		}
+// changeConstraints returns the constraints VerifyChange checks: all of
+// After's without a Before, otherwise those of the regions the change
+// touched, along with the guarantees it lost.
+func (c *VerificationChain) changeConstraints(ctx context.Context, change CodeChange) ([]Constraint, []Constraint, error) {
+	if change.Before == "" {
+		constraints, err := c.extractConstraints(ctx, change)
+		return constraints, nil, err
 	}
-
-	// Add constraints
-	sb.WriteString("\n# Add constraints\nconstraint_results = []\n")
-	for i, constraint := range constraints {
-		sb.WriteString(fmt.Sprintf(`
-# Constraint %d: %s
-try:
-    constraint_%d = %s
-    model += constraint_%d
-    constraint_results.append({
-        'index': %d,
-        'name': %q,
-        'type': %q,
-        'added': True,
-        'error': None
-    })
-except Exception as e:
-    constraint_results.append({
-        'index': %d,
-        'name': %q,
-        'type': %q,
-        'added': False,
-        'error': str(e)
-    })
-`, i, constraint.Name, i, constraint.Expression, i, i, constraint.Name, constraint.Type, i, constraint.Name, constraint.Type))
-	}
-
-	// Solve and return result
-	sb.WriteString(`
-# Solve the model
-result = {
-    'satisfied': False,
-    'status': 'unknown',
-    'constraints': constraint_results,
-    'counter_example': None
+	extracted, err := c.ExtractChangeConstraints(ctx, change)
+	if err != nil {
+		return nil, nil, err
+	}
+	return extracted.ChangedConstraints(), extracted.Removed, nil
 }
 
-try:
-    if model.solve():
-        result['satisfied'] = True
-        result['status'] = 'satisfied'
-    else:
-        result['status'] = 'violated'
-        # Try to extract counter-example
-        result['counter_example'] = {
-            'variables': {k: str(v.value()) if hasattr(v, 'value') else str(v) for k, v in variables.items()},
-            'explanation': 'Constraints could not be satisfied'
-        }
-except Exception as e:
-    result['status'] = 'error'
-    result['error'] = str(e)
-
-json.dumps(result)
-`)
-
-	return sb.String()
-}
+// extractConstraints collects a change's preconditions, postconditions and
+// invariants, in that order.
+func (c *VerificationChain) extractConstraints(ctx context.Context, change CodeChange) ([]Constraint, error) {
+	var allConstraints []Constraint
 
-// parseVerificationResult parses the JSON result from CPMpy execution.
-func (c *VerificationChain) parseVerificationResult(jsonStr string, constraints []Constraint, result *VerificationResult) (*VerificationResult, error) {
-	// Clean up the JSON string
-	jsonStr = strings.TrimSpace(jsonStr)
-	jsonStr = strings.Trim(jsonStr, "'\"")
-
-	var parsed struct {
-		Satisfied      bool   `json:"satisfied"`
-		Status         string `json:"status"`
-		Constraints    []struct {
-			Index int    `json:"index"`
-			Name  string `json:"name"`
-			Type  string `json:"type"`
-			Added bool   `json:"added"`
-			Error string `json:"error"`
-		} `json:"constraints"`
-		CounterExample *struct {
-			Variables   map[string]string `json:"variables"`
-			Explanation string            `json:"explanation"`
-		} `json:"counter_example"`
-		Error string `json:"error"`
-	}
-
-	if err := json.Unmarshal([]byte(jsonStr), &parsed); err != nil {
-		// If JSON parsing fails, try to infer result from raw output
-		result.Status = StatusUnknown
-		return result, nil
+	pre, err := c.GeneratePreconditions(ctx, change)
+	if err != nil {
+		return nil, fmt.Errorf("generate preconditions: %w", err)
 	}
+	allConstraints = append(allConstraints, pre...)
 
-	result.Satisfied = parsed.Satisfied
-	result.Status = VerificationStatus(parsed.Status)
-
-	// Map constraint results back
-	for _, cr := range parsed.Constraints {
-		if cr.Index < len(constraints) {
-			result.CheckedConstraints = append(result.CheckedConstraints, ConstraintResult{
-				Constraint: &constraints[cr.Index],
-				Satisfied:  cr.Added && cr.Error == "",
-				Message:    cr.Error,
-			})
-		}
+	post, err := c.GeneratePostconditions(ctx, change)
+	if err != nil {
+		return nil, fmt.Errorf("generate postconditions: %w", err)
 	}
+	allConstraints = append(allConstraints, post...)
 
-	// Extract counter-example
-	if parsed.CounterExample != nil {
-		vars := make(map[string]any)
-		for k, v := range parsed.CounterExample.Variables {
-			vars[k] = v
-		}
-		result.CounterExample = &CounterExample{
-			Variables:   vars,
-			Explanation: parsed.CounterExample.Explanation,
-		}
+	inv, err := c.GenerateInvariants(ctx, change)
+	if err != nil {
+		return nil, fmt.Errorf("generate invariants: %w", err)
 	}
+	allConstraints = append(allConstraints, inv...)
 
-	return result, nil
+	return allConstraints, nil
 }
 
 // extractFromDocstrings extracts constraints from docstrings and comments.
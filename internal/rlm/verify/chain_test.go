@@ -262,7 +262,7 @@ func TestVerificationChain_BuildVerificationCode(t *testing.T) {
 		},
 	}
 
-	code := chain.buildVerificationCode(constraints, "")
+	code := chain.Solver().BuildCode(constraints, "")
 
 	// Check that the generated code has expected components
 	assert.Contains(t, code, "from cpmpy import")
@@ -732,7 +732,7 @@ func TestVerificationChain_ParseVerificationResult_Success(t *testing.T) {
 	}`
 
 	result := &VerificationResult{}
-	parsed, err := chain.parseVerificationResult(jsonResult, constraints, result)
+	parsed, err := chain.Solver().ParseResult(jsonResult, constraints, result)
 	require.NoError(t, err)
 
 	assert.True(t, parsed.Satisfied)
@@ -761,7 +761,7 @@ func TestVerificationChain_ParseVerificationResult_Failure(t *testing.T) {
 	}`
 
 	result := &VerificationResult{}
-	parsed, err := chain.parseVerificationResult(jsonResult, constraints, result)
+	parsed, err := chain.Solver().ParseResult(jsonResult, constraints, result)
 	require.NoError(t, err)
 
 	assert.False(t, parsed.Satisfied)
@@ -777,7 +777,7 @@ func TestVerificationChain_ParseVerificationResult_InvalidJSON(t *testing.T) {
 	jsonResult := `not valid json`
 
 	result := &VerificationResult{}
-	parsed, err := chain.parseVerificationResult(jsonResult, constraints, result)
+	parsed, err := chain.Solver().ParseResult(jsonResult, constraints, result)
 	require.NoError(t, err) // Should not error, just return unknown status
 
 	assert.Equal(t, StatusUnknown, parsed.Status)
@@ -807,7 +807,7 @@ func TestVerificationChain_BuildVerificationCode_MultipleConstraints(t *testing.
 		},
 	}
 
-	code := chain.buildVerificationCode(constraints, "")
+	code := chain.Solver().BuildCode(constraints, "")
 
 	// Check all variables are declared
 	assert.Contains(t, code, "variables['x']")
@@ -828,7 +828,7 @@ func TestVerificationChain_BuildVerificationCode_MultipleConstraints(t *testing.
 func TestVerificationChain_BuildVerificationCode_EmptyConstraints(t *testing.T) {
 	chain := NewVerificationChain(nil)
 
-	code := chain.buildVerificationCode([]Constraint{}, "")
+	code := chain.Solver().BuildCode([]Constraint{}, "")
 
 	// Should still have basic structure
 	assert.Contains(t, code, "from cpmpy import")
@@ -1024,6 +1024,6 @@ func BenchmarkVerificationChain_BuildVerificationCode(b *testing.B) {
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		_ = chain.buildVerificationCode(constraints, "")
+		_ = chain.Solver().BuildCode(constraints, "")
 	}
 }
@@ -0,0 +1,78 @@
+package verify
+
+import "math"
+
+// ConfidenceAggregator combines the constraint results of a verification
+// into one trust score in [0, 1]. Each result counts its constraint's
+// Confidence when satisfied and zero when not; no results score zero,
+// since nothing was verified.
+type ConfidenceAggregator func(results []ConstraintResult) float64
+
+// MinConfidence scores a verification by its weakest constraint: it is no
+// more trustworthy than its least certain check.
+func MinConfidence(results []ConstraintResult) float64 {
+	if len(results) == 0 {
+		return 0
+	}
+	lowest := math.Inf(1)
+	for _, cr := range results {
+		lowest = min(lowest, constraintScore(cr))
+	}
+	return lowest
+}
+
+// WeightedMeanConfidence averages constraint scores weighted by their
+// confidence, so high-confidence checks such as type constraints count
+// for more than constraints inferred from prose. It is the default.
+func WeightedMeanConfidence(results []ConstraintResult) float64 {
+	var weighted, total float64
+	for _, cr := range results {
+		if cr.Constraint == nil {
+			continue
+		}
+		weight := clampConfidence(cr.Constraint.Confidence)
+		weighted += weight * constraintScore(cr)
+		total += weight
+	}
+	if total == 0 {
+		return 0
+	}
+	return weighted / total
+}
+
+// constraintScore is a result's contribution to the aggregate.
+func constraintScore(cr ConstraintResult) float64 {
+	if !cr.Satisfied || cr.Constraint == nil {
+		return 0
+	}
+	return clampConfidence(cr.Constraint.Confidence)
+}
+
+func clampConfidence(c float64) float64 {
+	return max(0, min(c, 1))
+}
+
+// SetConfidenceAggregator selects how VerificationResult.Confidence is
+// computed; nil restores the default, WeightedMeanConfidence.
+func (c *VerificationChain) SetConfidenceAggregator(agg ConfidenceAggregator) {
+	c.aggregator = agg
+}
+
+// aggregateConfidence sets result.Confidence. Outside granular results a
+// constraint only counts as satisfied if the shared model was.
+func (c *VerificationChain) aggregateConfidence(result *VerificationResult) {
+	agg := c.aggregator
+	if agg == nil {
+		agg = WeightedMeanConfidence
+	}
+
+	results := result.CheckedConstraints
+	if !result.Granular && !result.Satisfied {
+		results = make([]ConstraintResult, len(result.CheckedConstraints))
+		for i, cr := range result.CheckedConstraints {
+			cr.Satisfied = false
+			results[i] = cr
+		}
+	}
+	result.Confidence = agg(results)
+}
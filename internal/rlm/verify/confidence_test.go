@@ -0,0 +1,104 @@
+package verify
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func confidenceResult(confidence float64, satisfied bool) ConstraintResult {
+	return ConstraintResult{Constraint: &Constraint{Confidence: confidence}, Satisfied: satisfied}
+}
+
+func TestMinConfidence(t *testing.T) {
+	assert.Zero(t, MinConfidence(nil))
+	assert.InDelta(t, 0.8, MinConfidence([]ConstraintResult{
+		confidenceResult(0.95, true),
+		confidenceResult(0.8, true),
+		confidenceResult(0.9, true),
+	}), 1e-9)
+	assert.Zero(t, MinConfidence([]ConstraintResult{
+		confidenceResult(0.95, true),
+		confidenceResult(0.8, false),
+	}), "a failed constraint")
+}
+
+func TestWeightedMeanConfidence(t *testing.T) {
+	assert.Zero(t, WeightedMeanConfidence(nil))
+
+	typeChecks := []ConstraintResult{confidenceResult(0.95, true), confidenceResult(0.95, true)}
+	inferred := []ConstraintResult{confidenceResult(0.5, true), confidenceResult(0.5, true)}
+	assert.InDelta(t, 0.95, WeightedMeanConfidence(typeChecks), 1e-9)
+	assert.InDelta(t, 0.5, WeightedMeanConfidence(inferred), 1e-9)
+
+	// (0.95*0.95 + 0.5*0.5) / 1.45: type checks dominate
+	mixed := []ConstraintResult{confidenceResult(0.95, true), confidenceResult(0.5, true)}
+	assert.InDelta(t, 0.7948, WeightedMeanConfidence(mixed), 1e-4)
+
+	// Failing the high-confidence check costs more than failing the low one
+	failHigh := []ConstraintResult{confidenceResult(0.95, false), confidenceResult(0.5, true)}
+	failLow := []ConstraintResult{confidenceResult(0.95, true), confidenceResult(0.5, false)}
+	assert.Less(t, WeightedMeanConfidence(failHigh), WeightedMeanConfidence(failLow))
+
+	// Out-of-range confidences are clamped
+	assert.InDelta(t, 1.0, WeightedMeanConfidence([]ConstraintResult{confidenceResult(1.7, true)}), 1e-9)
+}
+
+func TestVerificationChain_Confidence(t *testing.T) {
+	typeChecks := []Constraint{
+		{Name: "type_x", Expression: "True", Confidence: 0.95, Variables: []string{"x"}},
+		{Name: "type_y", Expression: "True", Confidence: 0.95, Variables: []string{"y"}},
+	}
+	inferred := []Constraint{
+		{Name: "doc_x", Expression: "True", Confidence: 0.6, Variables: []string{"x"}},
+		{Name: "doc_y", Expression: "True", Confidence: 0.6, Variables: []string{"y"}},
+	}
+
+	chain := &VerificationChain{repl: &fakeSolver{}, timeout: time.Second}
+	strong, err := chain.Verify(context.Background(), typeChecks, "")
+	require.NoError(t, err)
+	weak, err := chain.Verify(context.Background(), inferred, "")
+	require.NoError(t, err)
+
+	assert.True(t, strong.Satisfied)
+	assert.True(t, weak.Satisfied)
+	assert.InDelta(t, 0.95, strong.Confidence, 1e-9)
+	assert.InDelta(t, 0.6, weak.Confidence, 1e-9)
+
+	t.Run("min", func(t *testing.T) {
+		chain := &VerificationChain{repl: &fakeSolver{}, timeout: time.Second}
+		chain.SetConfidenceAggregator(MinConfidence)
+		result, err := chain.Verify(context.Background(), append(typeChecks, inferred[0]), "")
+		require.NoError(t, err)
+		assert.InDelta(t, 0.6, result.Confidence, 1e-9)
+	})
+
+	t.Run("violated model", func(t *testing.T) {
+		// In one model, a violation leaves no constraint satisfied
+		chain := &VerificationChain{repl: &fakeSolver{infeasible: map[string]bool{"doc_y": true}}, timeout: time.Second}
+		result, err := chain.Verify(context.Background(), append(typeChecks, inferred...), "")
+		require.NoError(t, err)
+		assert.False(t, result.Satisfied)
+		assert.Zero(t, result.Confidence)
+	})
+
+	t.Run("grouped", func(t *testing.T) {
+		// Solved per variable, only y's group fails
+		chain := &VerificationChain{repl: &fakeSolver{infeasible: map[string]bool{"doc_y": true}}, timeout: time.Second}
+		result, err := chain.VerifyGroups(context.Background(), append(typeChecks, inferred...), "")
+		require.NoError(t, err)
+		assert.False(t, result.Satisfied)
+		// x's constraints: (0.95*0.95 + 0.6*0.6) / 3.1
+		assert.InDelta(t, (0.95*0.95+0.6*0.6)/3.1, result.Confidence, 1e-9)
+	})
+
+	t.Run("nothing to verify", func(t *testing.T) {
+		result, err := NewVerificationChain(nil).VerifyChange(context.Background(), CodeChange{After: "x = 1"})
+		require.NoError(t, err)
+		assert.True(t, result.Satisfied)
+		assert.Zero(t, result.Confidence)
+	})
+}
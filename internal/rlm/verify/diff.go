@@ -0,0 +1,219 @@
+package verify
+
+import (
+	"context"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// ChangeKind says how a symbol differs between Before and After.
+type ChangeKind string
+
+const (
+	ChangeAdded    ChangeKind = "added"
+	ChangeRemoved  ChangeKind = "removed"
+	ChangeModified ChangeKind = "modified"
+)
+
+// SymbolChange is a function or top-level variable that differs between a
+// change's Before and After.
+type SymbolChange struct {
+	// Name is the function's name, qualified by its receiver for Go
+	// methods, or the variable's.
+	Name string
+
+	Kind ChangeKind
+}
+
+// CodeDiff is the structured difference between a change's Before and
+// After, by function and top-level variable.
+type CodeDiff struct {
+	// Functions lists the added, removed and modified functions, by name.
+	Functions []SymbolChange
+
+	// Variables lists the added, removed and reassigned top-level
+	// variables and constants, by name.
+	Variables []SymbolChange
+
+	// TopLevelChanged is set when code outside any function changed.
+	TopLevelChanged bool
+}
+
+// DiffChange computes the structured difference between change.Before and
+// change.After. Functions are split as for Reverify, so code in languages
+// other than Go and Python only reports whether it changed at all. With an
+// empty Before everything in After is added.
+func DiffChange(change CodeChange) *CodeDiff {
+	beforeSegments := splitFunctions(change.Before, change.Language)
+	afterSegments := splitFunctions(change.After, change.Language)
+	before := functionTexts(beforeSegments)
+	after := functionTexts(afterSegments)
+
+	diff := &CodeDiff{
+		Functions:       diffSymbols(withoutTopLevel(before), withoutTopLevel(after)),
+		Variables:       diffSymbols(topLevelVariables(before[""], change.Language), topLevelVariables(after[""], change.Language)),
+		TopLevelChanged: strings.TrimSpace(before[""]) != strings.TrimSpace(after[""]),
+	}
+	return diff
+}
+
+// Empty reports whether Before and After do not differ.
+func (d *CodeDiff) Empty() bool {
+	return len(d.Functions) == 0 && len(d.Variables) == 0 && !d.TopLevelChanged
+}
+
+// Changed reports whether the change added or modified function, or, for
+// the empty name, changed top-level code.
+func (d *CodeDiff) Changed(function string) bool {
+	if function == "" {
+		return d.TopLevelChanged
+	}
+	for _, fc := range d.Functions {
+		if fc.Name == function {
+			return fc.Kind != ChangeRemoved
+		}
+	}
+	return false
+}
+
+// diffSymbols compares two name-to-source maps, in name order.
+func diffSymbols(before, after map[string]string) []SymbolChange {
+	var changes []SymbolChange
+	for name, text := range after {
+		prev, ok := before[name]
+		switch {
+		case !ok:
+			changes = append(changes, SymbolChange{Name: name, Kind: ChangeAdded})
+		case strings.TrimSpace(prev) != strings.TrimSpace(text):
+			changes = append(changes, SymbolChange{Name: name, Kind: ChangeModified})
+		}
+	}
+	for name := range before {
+		if _, ok := after[name]; !ok {
+			changes = append(changes, SymbolChange{Name: name, Kind: ChangeRemoved})
+		}
+	}
+	slices.SortFunc(changes, func(a, b SymbolChange) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return changes
+}
+
+// withoutTopLevel returns texts without the top-level code.
+func withoutTopLevel(texts map[string]string) map[string]string {
+	funcs := make(map[string]string, len(texts))
+	for name, text := range texts {
+		if name != "" {
+			funcs[name] = text
+		}
+	}
+	return funcs
+}
+
+var (
+	pythonAssignPattern = regexp.MustCompile(`^([A-Za-z_]\w*)\s*(?::[^=]+)?=\s*(.*)$`)
+	goDeclPattern       = regexp.MustCompile(`^(?:var|const)\s+(\w+)\b(.*)$`)
+	goGroupSpecPattern  = regexp.MustCompile(`^\s+(\w+)\b(.*)$`)
+)
+
+// topLevelVariables maps the variables and constants assigned in
+// top-level code to their definitions. Python assignments count only at
+// column 0; Go declarations include those in var and const blocks.
+func topLevelVariables(code, language string) map[string]string {
+	vars := make(map[string]string)
+	inGroup := false
+	for line := range strings.SplitSeq(code, "\n") {
+		switch language {
+		case "python":
+			if m := pythonAssignPattern.FindStringSubmatch(line); m != nil && !strings.HasPrefix(m[2], "=") {
+				vars[m[1]] = strings.TrimSpace(m[2])
+			}
+		case "go":
+			trimmed := strings.TrimSpace(line)
+			switch {
+			case trimmed == "var (" || trimmed == "const (":
+				inGroup = true
+			case inGroup && trimmed == ")":
+				inGroup = false
+			case inGroup:
+				if m := goGroupSpecPattern.FindStringSubmatch(line); m != nil {
+					vars[m[1]] = strings.TrimSpace(m[2])
+				}
+			default:
+				if m := goDeclPattern.FindStringSubmatch(line); m != nil {
+					vars[m[1]] = strings.TrimSpace(m[2])
+				}
+			}
+		}
+	}
+	return vars
+}
+
+// ChangeConstraints is the constraint extraction of a change, scoped by
+// its diff.
+type ChangeConstraints struct {
+	Diff *CodeDiff
+
+	// Constraints are After's constraints, extracted per function as for
+	// Reverify; those of code the change added or modified are marked
+	// Changed.
+	Constraints []Constraint
+
+	// Removed are the guarantees Before had that After no longer does,
+	// e.g. the precondition of a deleted assert or of a removed function.
+	Removed []Constraint
+}
+
+// ChangedConstraints returns the constraints of code the change added or
+// modified.
+func (cc *ChangeConstraints) ChangedConstraints() []Constraint {
+	var changed []Constraint
+	for _, c := range cc.Constraints {
+		if c.Changed {
+			changed = append(changed, c)
+		}
+	}
+	return changed
+}
+
+// ExtractChangeConstraints extracts the constraints of change.After,
+// marking those in the regions the change touched, and the constraints of
+// change.Before it lost. A constraint is lost when no constraint of After
+// in the same function has its type and expression, so renumbering and
+// moving code within a function does not count as a loss.
+func (c *VerificationChain) ExtractChangeConstraints(ctx context.Context, change CodeChange) (*ChangeConstraints, error) {
+	diff := DiffChange(change)
+	after, err := c.extractFunctionConstraints(ctx, splitFunctions(change.After, change.Language), change.Language)
+	if err != nil {
+		return nil, err
+	}
+	for i := range after {
+		after[i].Changed = diff.Changed(after[i].Function)
+	}
+
+	result := &ChangeConstraints{Diff: diff, Constraints: after}
+	if change.Before == "" {
+		return result, nil
+	}
+	before, err := c.extractFunctionConstraints(ctx, splitFunctions(change.Before, change.Language), change.Language)
+	if err != nil {
+		return nil, err
+	}
+	kept := make(map[string]bool, len(after))
+	for i := range after {
+		kept[guaranteeKey(&after[i])] = true
+	}
+	for i := range before {
+		if !kept[guaranteeKey(&before[i])] {
+			result.Removed = append(result.Removed, before[i])
+		}
+	}
+	return result, nil
+}
+
+// guaranteeKey identifies what a constraint guarantees, independent of its
+// position-based name.
+func guaranteeKey(c *Constraint) string {
+	return c.Function + "\x00" + string(c.Type) + "\x00" + c.Expression
+}
@@ -0,0 +1,119 @@
+package verify
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffChange_Python(t *testing.T) {
+	before := "RATE = 0.1\nLIMIT = 100\n\n" + reverifyBefore
+	after := strings.Replace(before, "    assert amount <= balance\n", "", 1)
+	after = strings.Replace(after, "RATE = 0.1", "RATE = 0.2", 1)
+	after = strings.Replace(after, "LIMIT = 100\n", "", 1)
+	after += "\ndef close(balance: int) -> int:\n    return 0\n"
+
+	diff := DiffChange(CodeChange{Before: before, After: after, Language: "python"})
+	assert.Equal(t, []SymbolChange{
+		{Name: "close", Kind: ChangeAdded},
+		{Name: "withdraw", Kind: ChangeModified},
+	}, diff.Functions)
+	assert.Equal(t, []SymbolChange{
+		{Name: "LIMIT", Kind: ChangeRemoved},
+		{Name: "RATE", Kind: ChangeModified},
+	}, diff.Variables)
+	assert.True(t, diff.TopLevelChanged)
+	assert.False(t, diff.Empty())
+
+	assert.True(t, diff.Changed("withdraw"))
+	assert.True(t, diff.Changed("close"))
+	assert.False(t, diff.Changed("deposit"))
+	assert.True(t, diff.Changed(""))
+
+	assert.True(t, DiffChange(CodeChange{Before: before, After: before, Language: "python"}).Empty())
+}
+
+func TestDiffChange_Go(t *testing.T) {
+	before := `package bank
+
+const limit = 100
+
+var (
+	rate  = 0.1
+	owner = "bank"
+)
+
+func Deposit(balance, amount int) int {
+	return balance + amount
+}
+
+func (a *Account) Close() {}
+`
+	after := strings.Replace(before, `	owner = "bank"`+"\n", "", 1)
+	after = strings.Replace(after, "func (a *Account) Close() {}\n", "", 1)
+	after = strings.Replace(after, "return balance + amount", "return balance + amount + 1", 1)
+
+	diff := DiffChange(CodeChange{Before: before, After: after, Language: "go"})
+	assert.Equal(t, []SymbolChange{
+		{Name: "Account.Close", Kind: ChangeRemoved},
+		{Name: "Deposit", Kind: ChangeModified},
+	}, diff.Functions)
+	assert.Equal(t, []SymbolChange{{Name: "owner", Kind: ChangeRemoved}}, diff.Variables)
+	assert.False(t, diff.Changed("Account.Close"), "a removed function has no constraints to focus on")
+}
+
+func TestExtractChangeConstraints_RemovedAssert(t *testing.T) {
+	chain := NewVerificationChain(nil)
+	after := strings.Replace(reverifyBefore, "    assert amount <= balance\n", "", 1)
+	change := CodeChange{Before: reverifyBefore, After: after, Language: "python"}
+
+	extracted, err := chain.ExtractChangeConstraints(context.Background(), change)
+	require.NoError(t, err)
+	assert.Equal(t, []SymbolChange{{Name: "withdraw", Kind: ChangeModified}}, extracted.Diff.Functions)
+
+	// The deleted assert is noted as a lost precondition
+	require.Len(t, extracted.Removed, 1)
+	lost := extracted.Removed[0]
+	assert.Equal(t, ConstraintTypePrecondition, lost.Type)
+	assert.Equal(t, "assert", lost.Source)
+	assert.Equal(t, "withdraw", lost.Function)
+	assert.Equal(t, "amount <= balance", lost.Expression)
+
+	// Only withdraw's remaining constraints are in the changed region
+	changed := extracted.ChangedConstraints()
+	require.NotEmpty(t, changed)
+	for _, c := range changed {
+		assert.Equal(t, "withdraw", c.Function, c.Name)
+	}
+	assert.Greater(t, len(extracted.Constraints), len(changed))
+
+	// Renumbered constraints are not losses
+	moved := strings.Replace(reverifyBefore, "import math\n", "import math\nimport os\n", 1)
+	extracted, err = chain.ExtractChangeConstraints(context.Background(), CodeChange{Before: reverifyBefore, After: moved, Language: "python"})
+	require.NoError(t, err)
+	assert.Empty(t, extracted.Removed)
+	assert.Empty(t, extracted.ChangedConstraints())
+}
+
+func TestVerifyChange_ReportsRemovedGuarantees(t *testing.T) {
+	chain := &VerificationChain{repl: &fakeSolver{}, timeout: time.Second}
+	after := strings.Replace(reverifyBefore, "    assert amount <= balance\n", "", 1)
+
+	result, err := chain.VerifyChange(context.Background(), CodeChange{Before: reverifyBefore, After: after, Language: "python"})
+	require.NoError(t, err)
+	assert.True(t, result.Satisfied)
+	require.Len(t, result.RemovedGuarantees, 1)
+	assert.Equal(t, "amount <= balance", result.RemovedGuarantees[0].Expression)
+	require.NotEmpty(t, result.CheckedConstraints)
+	for _, r := range result.CheckedConstraints {
+		assert.True(t, strings.HasPrefix(r.Constraint.Name, "withdraw."), "only the changed function is verified: %s", r.Constraint.Name)
+	}
+
+	result, err = chain.VerifyChange(context.Background(), CodeChange{After: after, Language: "python"})
+	require.NoError(t, err)
+	assert.Empty(t, result.RemovedGuarantees, "nothing can be lost without a Before")
+}
@@ -0,0 +1,281 @@
+package verify
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Reverify verifies change incrementally against previous, the result of
+// verifying an earlier version of the code: constraints are extracted per
+// function, and only those of functions the change touched (or added), and
+// of top-level code if it changed, are solved again, together with every
+// constraint sharing a group with them (see GroupConstraints), since their
+// joint model changed. The others reuse their previous result, marked
+// Cached.
+//
+// Reuse needs per-constraint verdicts extracted per function, so previous
+// should come from Reverify; with a nil or other previous result every
+// constraint is solved. Solving uses VerifyGroups, so the result is
+// Granular and can feed the next Reverify.
+func (c *VerificationChain) Reverify(ctx context.Context, previous *VerificationResult, change CodeChange) (*VerificationResult, error) {
+	start := time.Now()
+
+	before := functionTexts(splitFunctions(change.Before, change.Language))
+	segments := splitFunctions(change.After, change.Language)
+	after := functionTexts(segments)
+
+	// With a Before, the change's extraction also finds the lost guarantees
+	var constraints, removed []Constraint
+	if change.Before == "" {
+		extracted, err := c.extractFunctionConstraints(ctx, segments, change.Language)
+		if err != nil {
+			return nil, err
+		}
+		constraints = extracted
+	} else {
+		extracted, err := c.ExtractChangeConstraints(ctx, change)
+		if err != nil {
+			return nil, err
+		}
+		constraints, removed = extracted.Constraints, extracted.Removed
+	}
+
+	// Previous verdicts by function, name and expression
+	cached := make(map[string]ConstraintResult)
+	if previous != nil && previous.Granular {
+		for _, cr := range previous.CheckedConstraints {
+			if cr.Constraint != nil {
+				cached[constraintKey(cr.Constraint)] = cr
+			}
+		}
+	}
+
+	// A constraint is stale if it has no reusable verdict, or shares a
+	// group with one that has none
+	reusable := make([]bool, len(constraints))
+	for i := range constraints {
+		fn := constraints[i].Function
+		_, ok := cached[constraintKey(&constraints[i])]
+		reusable[i] = ok && change.Before != "" && strings.TrimSpace(before[fn]) == strings.TrimSpace(after[fn])
+	}
+	for _, group := range GroupConstraints(constraints) {
+		if slices.ContainsFunc(group, func(i int) bool { return !reusable[i] }) {
+			for _, i := range group {
+				reusable[i] = false
+			}
+		}
+	}
+
+	results := make([]ConstraintResult, len(constraints))
+	var stale []Constraint
+	var staleIdx []int
+	for i := range constraints {
+		constraint := &constraints[i]
+		if reusable[i] {
+			prev := cached[constraintKey(constraint)]
+			prev.Constraint = constraint
+			prev.Cached = true
+			results[i] = prev
+			continue
+		}
+		stale = append(stale, *constraint)
+		staleIdx = append(staleIdx, i)
+	}
+
+	result := &VerificationResult{
+		Satisfied: true,
+		Status:    StatusSatisfied,
+		Granular:  true,
+
+		RemovedGuarantees: removed,
+	}
+	var solveErr error
+	if len(stale) > 0 {
+		solved, err := c.VerifyGroups(ctx, stale, change.After)
+		if solved == nil {
+			return nil, err
+		}
+		solveErr = err
+		for j, idx := range staleIdx {
+			cr := solved.CheckedConstraints[j]
+			cr.Constraint = &constraints[idx]
+			results[idx] = cr
+		}
+		result.Status = solved.Status
+		result.CounterExample = solved.CounterExample
+		result.SolverOutput = solved.SolverOutput
+	}
+
+	for _, cr := range results {
+		if cr.Satisfied {
+			continue
+		}
+		result.Satisfied = false
+		if cr.Cached {
+			// A constraint that still fails carries its previous verdict
+			if statusSeverity(previous.Status) > statusSeverity(result.Status) {
+				result.Status = previous.Status
+			}
+			if result.CounterExample == nil {
+				result.CounterExample = previous.CounterExample
+			}
+		}
+	}
+
+	result.CheckedConstraints = results
+	c.aggregateConfidence(result)
+	result.Duration = time.Since(start)
+	return result, solveErr
+}
+
+// constraintKey identifies a constraint across versions of the code.
+func constraintKey(c *Constraint) string {
+	return c.Function + "\x00" + c.Name + "\x00" + c.Expression
+}
+
+// extractFunctionConstraints extracts constraints from each segment,
+// tagging each with its function and qualifying its name by it.
+func (c *VerificationChain) extractFunctionConstraints(ctx context.Context, segments []codeSegment, language string) ([]Constraint, error) {
+	var constraints []Constraint
+	for _, seg := range segments {
+		extracted, err := c.extractConstraints(ctx, CodeChange{After: seg.text, Language: language})
+		if err != nil {
+			return nil, fmt.Errorf("function %q: %w", seg.function, err)
+		}
+		for _, constraint := range extracted {
+			constraint.Function = seg.function
+			if seg.function != "" {
+				constraint.Name = seg.function + "." + constraint.Name
+			}
+			constraints = append(constraints, constraint)
+		}
+	}
+	return constraints, nil
+}
+
+// codeSegment is a function's source, or top-level code when function is
+// empty.
+type codeSegment struct {
+	function string
+	text     string
+}
+
+var (
+	pythonDefPattern = regexp.MustCompile(`^(\s*)(?:async\s+)?def\s+(\w+)`)
+	goFuncPattern    = regexp.MustCompile(`^func\s+(?:\(\s*\w*\s*\*?(\w+)(?:\[[^\]]*\])?\s*\)\s*)?(\w+)`)
+)
+
+// splitFunctions splits code into its functions, each with the comments or
+// decorators right above it, and the top-level code between them, which
+// comes first. Python functions end where the indentation returns to the
+// def's level; Go functions at the closing brace in column 0. Code in
+// other languages is one top-level segment.
+func splitFunctions(code, language string) []codeSegment {
+	lines := strings.Split(code, "\n")
+	var top []string
+	var funcs []codeSegment
+
+	for i := 0; i < len(lines); i++ {
+		name, indent, ok := functionStart(lines[i], language)
+		if !ok {
+			top = append(top, lines[i])
+			continue
+		}
+
+		// Take the comments and decorators directly above
+		lead := len(top)
+		for lead > 0 && isLeadingLine(top[lead-1], language) {
+			lead--
+		}
+		body := append([]string(nil), top[lead:]...)
+		top = top[:lead]
+
+		body = append(body, lines[i])
+		if !isOneLiner(lines[i], language) {
+			for i+1 < len(lines) && !functionEnded(lines, i+1, indent, language) {
+				i++
+				body = append(body, lines[i])
+			}
+			if language == "go" && i+1 < len(lines) {
+				i++ // the closing brace
+				body = append(body, lines[i])
+			}
+		}
+		funcs = append(funcs, codeSegment{function: name, text: strings.Join(body, "\n")})
+	}
+
+	return append([]codeSegment{{text: strings.Join(top, "\n")}}, funcs...)
+}
+
+// functionStart reports whether line starts a function, and its name and
+// indentation.
+func functionStart(line, language string) (string, int, bool) {
+	switch language {
+	case "python":
+		if m := pythonDefPattern.FindStringSubmatch(line); m != nil {
+			return m[2], len(m[1]), true
+		}
+	case "go":
+		if m := goFuncPattern.FindStringSubmatch(line); m != nil {
+			if m[1] != "" {
+				return m[1] + "." + m[2], 0, true
+			}
+			return m[2], 0, true
+		}
+	}
+	return "", 0, false
+}
+
+// functionEnded reports whether lines[i] is past the body of a function
+// whose definition is at indent.
+func functionEnded(lines []string, i, indent int, language string) bool {
+	line := lines[i]
+	switch language {
+	case "python":
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			return false
+		}
+		return len(line)-len(strings.TrimLeft(line, " \t")) <= indent
+	case "go":
+		return line == "}"
+	}
+	return true
+}
+
+// isOneLiner reports whether a Go function's whole body is on the line
+// that starts it.
+func isOneLiner(line, language string) bool {
+	opens := strings.Count(line, "{")
+	return language == "go" && opens > 0 && opens == strings.Count(line, "}")
+}
+
+// isLeadingLine reports whether a line belongs with the function below it.
+func isLeadingLine(line, language string) bool {
+	trimmed := strings.TrimSpace(line)
+	switch language {
+	case "python":
+		return strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "@")
+	case "go":
+		return strings.HasPrefix(trimmed, "//")
+	}
+	return false
+}
+
+// functionTexts maps each function to its source; repeated names are
+// joined.
+func functionTexts(segments []codeSegment) map[string]string {
+	texts := make(map[string]string, len(segments))
+	for _, seg := range segments {
+		if prev, ok := texts[seg.function]; ok {
+			texts[seg.function] = prev + "\n" + seg.text
+		} else {
+			texts[seg.function] = seg.text
+		}
+	}
+	return texts
+}
@@ -0,0 +1,208 @@
+package verify
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const reverifyBefore = `import math
+
+def deposit(balance: int, amount: int) -> int:
+    """
+    @requires amount must be positive
+    """
+    return balance + amount
+
+# Withdrawals never overdraw.
+def withdraw(balance: int, amount: int) -> int:
+    assert amount <= balance
+    return balance - amount
+
+def interest(principal: float, rate: float) -> float:
+    """
+    @requires rate must be non-negative
+    """
+    return principal * rate
+`
+
+func solvedNames(solver *fakeSolver) []string {
+	var names []string
+	for _, model := range solver.models {
+		names = append(names, model...)
+	}
+	slices.Sort(names)
+	return names
+}
+
+func TestSplitFunctions_Python(t *testing.T) {
+	segments := splitFunctions(reverifyBefore, "python")
+	require.Len(t, segments, 4)
+
+	assert.Equal(t, "", segments[0].function)
+	assert.Contains(t, segments[0].text, "import math")
+	assert.Equal(t, "deposit", segments[1].function)
+	assert.Contains(t, segments[1].text, "@requires amount must be positive")
+	assert.Equal(t, "withdraw", segments[2].function)
+	assert.True(t, strings.HasPrefix(segments[2].text, "# Withdrawals never overdraw."), "leading comments go with the function")
+	assert.NotContains(t, segments[2].text, "def interest")
+	assert.Equal(t, "interest", segments[3].function)
+}
+
+func TestSplitFunctions_Go(t *testing.T) {
+	code := `package bank
+
+// Deposit adds to a balance.
+// @requires amount must be positive
+func Deposit(balance, amount int) int {
+	if amount <= 0 {
+		panic("bad amount")
+	}
+	return balance + amount
+}
+
+func (a *Account) Close() error { return nil }
+
+var ErrClosed = errors.New("closed")
+`
+	segments := splitFunctions(code, "go")
+	require.Len(t, segments, 3)
+
+	assert.Contains(t, segments[0].text, "package bank")
+	assert.Contains(t, segments[0].text, "var ErrClosed")
+	assert.Equal(t, "Deposit", segments[1].function)
+	assert.True(t, strings.HasPrefix(segments[1].text, "// Deposit adds"))
+	assert.True(t, strings.HasSuffix(segments[1].text, "}"))
+	assert.Equal(t, "Account.Close", segments[2].function)
+}
+
+func TestReverify_OnlyChangedFunctionIsResolved(t *testing.T) {
+	solver := &fakeSolver{}
+	chain := &VerificationChain{repl: solver, timeout: time.Second, parallelism: 2}
+	ctx := context.Background()
+
+	first, err := chain.Reverify(ctx, nil, CodeChange{After: reverifyBefore, Language: "python"})
+	require.NoError(t, err)
+	assert.True(t, first.Satisfied)
+	for _, cr := range first.CheckedConstraints {
+		assert.False(t, cr.Cached, cr.Constraint.Name)
+	}
+	initial := solvedNames(solver)
+	assert.Contains(t, initial, "deposit.precondition_0")
+	assert.Contains(t, initial, "withdraw.assert_0")
+	assert.Contains(t, initial, "interest.precondition_0")
+
+	// Tighten withdraw only
+	after := strings.Replace(reverifyBefore, "assert amount <= balance", "assert amount <= balance - 10", 1)
+	solver.models = nil
+	second, err := chain.Reverify(ctx, first, CodeChange{Before: reverifyBefore, After: after, Language: "python"})
+	require.NoError(t, err)
+	assert.True(t, second.Satisfied)
+	require.Len(t, second.CheckedConstraints, len(first.CheckedConstraints))
+
+	// deposit shares balance and amount with withdraw, so it is solved
+	// again with it; interest shares only the return value
+	var resolved []string
+	for _, cr := range second.CheckedConstraints {
+		assert.True(t, cr.Satisfied, cr.Constraint.Name)
+		if cr.Constraint.Function == "withdraw" || cr.Constraint.Function == "deposit" {
+			assert.False(t, cr.Cached, cr.Constraint.Name)
+		}
+		if !cr.Cached {
+			resolved = append(resolved, cr.Constraint.Name)
+		}
+	}
+	slices.Sort(resolved)
+	assert.Equal(t, resolved, solvedNames(solver), "only the re-solved constraints reach the solver")
+	assert.Contains(t, resolved, "withdraw.assert_0")
+	assert.Contains(t, resolved, "deposit.precondition_0")
+	assert.NotContains(t, resolved, "interest.precondition_0")
+
+	// Nothing changed: nothing is solved
+	solver.models = nil
+	third, err := chain.Reverify(ctx, second, CodeChange{Before: after, After: after, Language: "python"})
+	require.NoError(t, err)
+	assert.Empty(t, solver.models)
+	assert.True(t, third.Satisfied)
+}
+
+func TestReverify_CachedViolationPersists(t *testing.T) {
+	solver := &fakeSolver{infeasible: map[string]bool{"interest.precondition_0": true}}
+	chain := &VerificationChain{repl: solver, timeout: time.Second, parallelism: 2}
+	ctx := context.Background()
+
+	first, err := chain.Reverify(ctx, nil, CodeChange{After: reverifyBefore, Language: "python"})
+	require.NoError(t, err)
+	assert.False(t, first.Satisfied)
+	assert.Equal(t, StatusViolated, first.Status)
+
+	// Changing deposit leaves interest's violation in place
+	after := strings.Replace(reverifyBefore, "return balance + amount", "return amount + balance", 1)
+	second, err := chain.Reverify(ctx, first, CodeChange{Before: reverifyBefore, After: after, Language: "python"})
+	require.NoError(t, err)
+	assert.False(t, second.Satisfied)
+	assert.Equal(t, StatusViolated, second.Status)
+	assert.NotNil(t, second.CounterExample)
+
+	for _, cr := range second.CheckedConstraints {
+		if cr.Constraint.Name == "interest.precondition_0" {
+			assert.True(t, cr.Cached)
+			assert.False(t, cr.Satisfied)
+		}
+	}
+}
+
+func TestReverify_ResolvesCachedConstraintsSharingVariables(t *testing.T) {
+	before := `
+def f(x: int) -> int:
+    assert x > 0
+    return x
+
+def g(x: int) -> int:
+    return x
+`
+	after := strings.Replace(before, "def g(x: int) -> int:\n", "def g(x: int) -> int:\n    assert x < 0\n", 1)
+	// f's x > 0 and g's new x < 0 each hold alone, but not together
+	solver := &fakeSolver{conflicts: [][]string{{"f.assert_0", "g.assert_0"}}}
+	chain := &VerificationChain{repl: solver, timeout: time.Second, parallelism: 2}
+	ctx := context.Background()
+
+	first, err := chain.Reverify(ctx, nil, CodeChange{After: before, Language: "python"})
+	require.NoError(t, err)
+	assert.True(t, first.Satisfied)
+
+	second, err := chain.Reverify(ctx, first, CodeChange{Before: before, After: after, Language: "python"})
+	require.NoError(t, err)
+	assert.False(t, second.Satisfied)
+	assert.Equal(t, StatusViolated, second.Status)
+	for _, cr := range second.CheckedConstraints {
+		if cr.Constraint.Name == "f.assert_0" {
+			assert.False(t, cr.Cached, "solved again with g's constraint")
+			assert.False(t, cr.Satisfied)
+		}
+	}
+}
+
+func TestReverify_UngranularPreviousResolvesEverything(t *testing.T) {
+	solver := &fakeSolver{}
+	chain := &VerificationChain{repl: solver, timeout: time.Second}
+	ctx := context.Background()
+
+	// A single-model result only says which constraints loaded
+	previous, err := chain.VerifyChange(ctx, CodeChange{After: reverifyBefore, Language: "python"})
+	require.NoError(t, err)
+	assert.False(t, previous.Granular)
+
+	solver.models = nil
+	result, err := chain.Reverify(ctx, previous, CodeChange{Before: reverifyBefore, After: reverifyBefore, Language: "python"})
+	require.NoError(t, err)
+	for _, cr := range result.CheckedConstraints {
+		assert.False(t, cr.Cached, cr.Constraint.Name)
+	}
+	assert.Len(t, solvedNames(solver), len(result.CheckedConstraints))
+}
@@ -0,0 +1,191 @@
+package verify
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// GroupConstraints partitions constraints into independent groups: two
+// constraints share a group when they share a variable, directly or
+// through other constraints, since those must be solved together. A
+// constraint without variables is a group of its own. Groups hold indices
+// into constraints and are ordered by their first constraint.
+func GroupConstraints(constraints []Constraint) [][]int {
+	parent := make([]int, len(constraints))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	owner := make(map[string]int) // variable → first constraint using it
+	for i, constraint := range constraints {
+		for _, v := range constraint.Variables {
+			j, ok := owner[v]
+			if !ok {
+				owner[v] = i
+				continue
+			}
+			if ri, rj := find(i), find(j); ri != rj {
+				parent[max(ri, rj)] = min(ri, rj)
+			}
+		}
+	}
+
+	var groups [][]int
+	index := make(map[int]int) // root → position in groups
+	for i := range constraints {
+		root := find(i)
+		g, ok := index[root]
+		if !ok {
+			g = len(groups)
+			index[root] = g
+			groups = append(groups, nil)
+		}
+		groups[g] = append(groups[g], i)
+	}
+	return groups
+}
+
+// VerifyGroups verifies each independent group of constraints (see
+// GroupConstraints) in a separate solve, running up to the configured
+// parallelism at once, each on its own REPL (see SetWorkers). A group's
+// timeout starts when its solve does. Unlike Verify, each constraint's result says
+// whether its group was satisfied, so one violated or slow group does not
+// hide the verdicts of the others. The overall status is the worst of the
+// groups', and the counter-example the first violated group's.
+func (c *VerificationChain) VerifyGroups(ctx context.Context, constraints []Constraint, code string) (*VerificationResult, error) {
+	if c.repl == nil {
+		return nil, fmt.Errorf("REPL manager not configured")
+	}
+
+	start := time.Now()
+	groups := GroupConstraints(constraints)
+	batches := make([][]Constraint, len(groups))
+	for g, members := range groups {
+		batches[g] = make([]Constraint, len(members))
+		for i, idx := range members {
+			batches[g][i] = constraints[idx]
+		}
+	}
+	results := make([]*VerificationResult, len(groups))
+	errs := make([]error, len(groups))
+
+	// Each worker solves on its own REPL, so a solve never queues behind
+	// another and its timeout measures only the solve
+	executors := append([]codeExecutor{c.repl}, c.workers...)
+	workers := min(max(c.parallelism, 1), len(executors), len(groups))
+	pending := make(chan int, len(groups))
+	for g := range groups {
+		pending <- g
+	}
+	close(pending)
+
+	var wg sync.WaitGroup
+	for w := range workers {
+		wg.Add(1)
+		go func(exec codeExecutor) {
+			defer wg.Done()
+			for g := range pending {
+				if ctx.Err() != nil {
+					errs[g] = ctx.Err()
+					continue
+				}
+				results[g], errs[g] = c.verifyOn(ctx, exec, batches[g], code)
+			}
+		}(executors[w])
+	}
+	wg.Wait()
+
+	result := &VerificationResult{
+		Satisfied:          true,
+		Status:             StatusSatisfied,
+		CheckedConstraints: make([]ConstraintResult, len(constraints)),
+		Granular:           true,
+	}
+	var firstErr error
+	var output strings.Builder
+	for g, members := range groups {
+		groupResult := results[g]
+		if groupResult == nil {
+			groupResult = &VerificationResult{Status: StatusError}
+		}
+		if errs[g] != nil && firstErr == nil {
+			firstErr = fmt.Errorf("verify group %d: %w", g, errs[g])
+		}
+
+		if !groupResult.Satisfied {
+			result.Satisfied = false
+		}
+		if statusSeverity(groupResult.Status) > statusSeverity(result.Status) {
+			result.Status = groupResult.Status
+		}
+		if groupResult.CounterExample != nil && result.CounterExample == nil {
+			result.CounterExample = groupResult.CounterExample
+		}
+		if groupResult.SolverOutput != "" {
+			fmt.Fprintf(&output, "# group %d\n%s\n", g, groupResult.SolverOutput)
+		}
+
+		for i, idx := range members {
+			cr := groupConstraintResult(&batches[g][i], groupResult, errs[g])
+			cr.Constraint = &constraints[idx]
+			result.CheckedConstraints[idx] = cr
+		}
+	}
+
+	result.SolverOutput = output.String()
+	c.aggregateConfidence(result)
+	result.Duration = time.Since(start)
+	return result, firstErr
+}
+
+// groupConstraintResult is the result of a constraint of a group:
+// satisfied if it loaded into the model and the group's solve succeeded.
+func groupConstraintResult(constraint *Constraint, group *VerificationResult, err error) ConstraintResult {
+	cr := ConstraintResult{Constraint: constraint}
+	for _, checked := range group.CheckedConstraints {
+		if checked.Constraint == constraint {
+			cr.Message = checked.Message
+		}
+	}
+
+	switch {
+	case cr.Message != "":
+		// Failed to load into the model
+	case err != nil:
+		cr.Message = err.Error()
+	case group.Satisfied:
+		cr.Satisfied = true
+	default:
+		cr.Message = fmt.Sprintf("constraint group %s", group.Status)
+	}
+	return cr
+}
+
+// statusSeverity orders statuses for merging group results: a definite
+// violation outranks a failure to decide.
+func statusSeverity(status VerificationStatus) int {
+	switch status {
+	case StatusSatisfied:
+		return 0
+	case StatusUnknown:
+		return 1
+	case StatusTimeout:
+		return 2
+	case StatusError:
+		return 3
+	case StatusViolated:
+		return 4
+	default:
+		return 1
+	}
+}
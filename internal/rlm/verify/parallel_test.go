@@ -0,0 +1,270 @@
+package verify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"regexp"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rand/recurse/internal/rlm/repl"
+)
+
+var constraintLine = regexp.MustCompile(`# Constraint (\d+): ([\w.]+)`)
+
+// fakeSolver answers verification code without Python: a model is
+// violated if it holds a constraint named in infeasible.
+type fakeSolver struct {
+	infeasible map[string]bool
+	broken     map[string]bool // fail to load into the model
+	failing    map[string]bool // the REPL call fails
+	conflicts  [][]string      // violated when all in one model
+	delay      time.Duration
+
+	mu          sync.Mutex
+	models      [][]string
+	running     int
+	maxParallel int
+}
+
+func (s *fakeSolver) Execute(ctx context.Context, code string) (*repl.ExecuteResult, error) {
+	s.mu.Lock()
+	s.running++
+	s.maxParallel = max(s.maxParallel, s.running)
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		s.running--
+		s.mu.Unlock()
+	}()
+	select {
+	case <-time.After(s.delay):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	type loaded struct {
+		Index int    `json:"index"`
+		Name  string `json:"name"`
+		Added bool   `json:"added"`
+		Error string `json:"error,omitempty"`
+	}
+	var names []string
+	var constraints []loaded
+	status := "satisfied"
+	for _, m := range constraintLine.FindAllStringSubmatch(code, -1) {
+		name := m[2]
+		names = append(names, name)
+		if s.failing[name] {
+			return nil, errors.New("REPL crashed")
+		}
+		c := loaded{Index: len(constraints), Name: name, Added: !s.broken[name]}
+		if s.broken[name] {
+			c.Error = "name 'y' is not defined"
+		}
+		constraints = append(constraints, c)
+		if s.infeasible[name] {
+			status = "violated"
+		}
+	}
+	for _, conflict := range s.conflicts {
+		if !slices.ContainsFunc(conflict, func(name string) bool { return !slices.Contains(names, name) }) {
+			status = "violated"
+		}
+	}
+
+	s.mu.Lock()
+	s.models = append(s.models, names)
+	s.mu.Unlock()
+
+	result := map[string]any{
+		"satisfied":   status == "satisfied",
+		"status":      status,
+		"constraints": constraints,
+	}
+	if status == "violated" {
+		result["counter_example"] = map[string]any{
+			"variables":   map[string]string{"x": "0"},
+			"explanation": "Constraints could not be satisfied",
+		}
+	}
+	out, err := json.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+	return &repl.ExecuteResult{ReturnVal: string(out)}, nil
+}
+
+func constraintOn(name string, vars ...string) Constraint {
+	return Constraint{Name: name, Expression: "True", Variables: vars}
+}
+
+func TestGroupConstraints(t *testing.T) {
+	constraints := []Constraint{
+		constraintOn("a", "x"),
+		constraintOn("b", "y"),
+		constraintOn("c", "z", "x"),
+		constraintOn("d"),
+		constraintOn("e", "w", "z"),
+		constraintOn("f", "y"),
+		constraintOn("g"),
+	}
+
+	assert.Equal(t, [][]int{{0, 2, 4}, {1, 5}, {3}, {6}}, GroupConstraints(constraints))
+	assert.Empty(t, GroupConstraints(nil))
+}
+
+func TestGroupConstraints_JoinsThroughLaterConstraint(t *testing.T) {
+	// a and b are independent until c links them
+	constraints := []Constraint{
+		constraintOn("a", "x"),
+		constraintOn("b", "y"),
+		constraintOn("c", "y", "x"),
+	}
+	assert.Equal(t, [][]int{{0, 1, 2}}, GroupConstraints(constraints))
+}
+
+func TestVerifyGroups_ViolationDoesNotMaskOthers(t *testing.T) {
+	solver := &fakeSolver{infeasible: map[string]bool{"hi_bound": true}}
+	chain := &VerificationChain{repl: solver, timeout: time.Second, parallelism: 4}
+
+	constraints := []Constraint{
+		constraintOn("x_positive", "x"),
+		constraintOn("hi_bound", "hi"),
+		constraintOn("y_small", "y"),
+		constraintOn("x_even", "x"),
+	}
+
+	result, err := chain.VerifyGroups(context.Background(), constraints, "")
+	require.NoError(t, err)
+	assert.False(t, result.Satisfied)
+	assert.Equal(t, StatusViolated, result.Status)
+	require.NotNil(t, result.CounterExample)
+
+	require.Len(t, result.CheckedConstraints, len(constraints))
+	for i, cr := range result.CheckedConstraints {
+		assert.Same(t, &constraints[i], cr.Constraint, "results follow the input order")
+	}
+	assert.True(t, result.CheckedConstraints[0].Satisfied)
+	assert.False(t, result.CheckedConstraints[1].Satisfied)
+	assert.Equal(t, "constraint group violated", result.CheckedConstraints[1].Message)
+	assert.True(t, result.CheckedConstraints[2].Satisfied)
+	assert.True(t, result.CheckedConstraints[3].Satisfied)
+
+	// Constraints sharing x were solved together
+	assert.ElementsMatch(t, [][]string{{"x_positive", "x_even"}, {"hi_bound"}, {"y_small"}}, solver.models)
+}
+
+func TestVerifyGroups_BoundsConcurrency(t *testing.T) {
+	solver := &fakeSolver{delay: 20 * time.Millisecond}
+	chain := &VerificationChain{repl: solver, timeout: time.Second, parallelism: 2}
+	chain.workers = []codeExecutor{solver, solver, solver}
+
+	var constraints []Constraint
+	for _, v := range []string{"a", "b", "c", "d", "e", "f"} {
+		constraints = append(constraints, constraintOn(v+"_ok", v))
+	}
+
+	result, err := chain.VerifyGroups(context.Background(), constraints, "")
+	require.NoError(t, err)
+	assert.True(t, result.Satisfied)
+	assert.Equal(t, StatusSatisfied, result.Status)
+	assert.Len(t, solver.models, 6)
+	assert.Equal(t, 2, solver.maxParallel)
+}
+
+func TestVerifyGroups_OneSolveAtATimePerREPL(t *testing.T) {
+	// Without workers the groups queue for the chain's REPL; each group's
+	// timeout covers only its own solve
+	solver := &fakeSolver{delay: 30 * time.Millisecond}
+	chain := &VerificationChain{repl: solver, timeout: 50 * time.Millisecond, parallelism: 4}
+
+	var constraints []Constraint
+	for _, v := range []string{"a", "b", "c", "d"} {
+		constraints = append(constraints, constraintOn(v+"_ok", v))
+	}
+
+	result, err := chain.VerifyGroups(context.Background(), constraints, "")
+	require.NoError(t, err)
+	assert.True(t, result.Satisfied)
+	assert.Equal(t, StatusSatisfied, result.Status, "queued groups do not time out")
+	assert.Len(t, solver.models, 4)
+	assert.Equal(t, 1, solver.maxParallel)
+}
+
+func TestVerifyGroups_ErrorsStayInTheirGroup(t *testing.T) {
+	solver := &fakeSolver{
+		broken:  map[string]bool{"uses_y": true},
+		failing: map[string]bool{"crashes": true},
+	}
+	chain := &VerificationChain{repl: solver, timeout: time.Second, parallelism: 3}
+
+	constraints := []Constraint{
+		constraintOn("ok", "x"),
+		constraintOn("uses_y", "y"),
+		constraintOn("crashes", "z"),
+	}
+
+	result, err := chain.VerifyGroups(context.Background(), constraints, "")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "verify group 2")
+	assert.False(t, result.Satisfied)
+	assert.Equal(t, StatusError, result.Status)
+
+	assert.True(t, result.CheckedConstraints[0].Satisfied)
+	assert.False(t, result.CheckedConstraints[1].Satisfied)
+	assert.Equal(t, "name 'y' is not defined", result.CheckedConstraints[1].Message)
+	assert.False(t, result.CheckedConstraints[2].Satisfied)
+	assert.Contains(t, result.CheckedConstraints[2].Message, "REPL crashed")
+}
+
+func TestVerifyChange_Parallelism(t *testing.T) {
+	change := CodeChange{
+		After: `
+def f(a: int, b: int) -> int:
+    assert a > 0
+    assert b > 100
+    return a + b
+`,
+		Language: "python",
+	}
+
+	t.Run("single model by default", func(t *testing.T) {
+		solver := &fakeSolver{}
+		chain := &VerificationChain{repl: solver, timeout: time.Second}
+
+		_, err := chain.VerifyChange(context.Background(), change)
+		require.NoError(t, err)
+		assert.Len(t, solver.models, 1)
+	})
+
+	t.Run("grouped", func(t *testing.T) {
+		solver := &fakeSolver{infeasible: map[string]bool{"assert_1": true}}
+		chain := &VerificationChain{repl: solver, timeout: time.Second}
+		chain.SetParallelism(2)
+
+		result, err := chain.VerifyChange(context.Background(), change)
+		require.NoError(t, err)
+		assert.Greater(t, len(solver.models), 1)
+		assert.Equal(t, StatusViolated, result.Status)
+
+		// b's constraints fail together; a's and the return type's pass
+		satisfied := make(map[string]bool)
+		for _, cr := range result.CheckedConstraints {
+			satisfied[cr.Constraint.Name] = cr.Satisfied
+		}
+		assert.Equal(t, map[string]bool{
+			"type_a":      true,
+			"type_b":      false,
+			"assert_0":    true,
+			"assert_1":    false,
+			"return_type": true,
+		}, satisfied)
+	})
+}
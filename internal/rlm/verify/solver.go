@@ -0,0 +1,305 @@
+package verify
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// SolverBackend generates the code that checks constraints in the REPL and
+// interprets what it returns. Backends differ in the Python library they
+// solve with and so in the theories they support.
+type SolverBackend interface {
+	// Name identifies the backend, e.g. "cpmpy".
+	Name() string
+
+	// BuildCode generates Python code that adds the constraints to a model,
+	// solves it and evaluates to the JSON verdict ParseResult reads.
+	BuildCode(constraints []Constraint, code string) string
+
+	// ParseResult fills result from the REPL's return value.
+	ParseResult(output string, constraints []Constraint, result *VerificationResult) (*VerificationResult, error)
+}
+
+// CPMpyBackend solves with CPMpy, falling back to plain evaluation of
+// boolean constraints when CPMpy is not installed. It is the default.
+type CPMpyBackend struct{}
+
+// Name returns "cpmpy".
+func (CPMpyBackend) Name() string { return "cpmpy" }
+
+// BuildCode generates Python code for CPMpy constraint solving.
+func (CPMpyBackend) BuildCode(constraints []Constraint, code string) string {
+	var sb strings.Builder
+
+	// Import CPMpy
+	sb.WriteString(`
+import json
+try:
+    from cpmpy import *
+except ImportError:
+    # Fallback to simple constraint checking without CPMpy
+    class Model:
+        def __init__(self):
+            self.constraints = []
+        def __iadd__(self, constraint):
+            self.constraints.append(constraint)
+            return self
+        def solve(self):
+            return all(c for c in self.constraints if isinstance(c, bool))
+    def intvar(lb, ub, name=None):
+        return (lb + ub) // 2  # Return midpoint as placeholder
+    def boolvar(name=None):
+        return True
+
+# Create model
+model = Model()
+
+# Define variables
+variables = {}
+`)
+
+	// Extract and declare variables
+	varSet := make(map[string]bool)
+	for _, constraint := range constraints {
+		for _, v := range constraint.Variables {
+			if !varSet[v] {
+				varSet[v] = true
+				sb.WriteString(fmt.Sprintf("variables['%s'] = intvar(-1000000, 1000000, name='%s')\n", v, v))
+			}
+		}
+	}
+
+	// Add constraints
+	sb.WriteString("\n# Add constraints\nconstraint_results = []\n")
+	for i, constraint := range constraints {
+		sb.WriteString(fmt.Sprintf(`
+# Constraint %d: %s
+try:
+    constraint_%d = %s
+    model += constraint_%d
+    constraint_results.append({
+        'index': %d,
+        'name': %q,
+        'type': %q,
+        'added': True,
+        'error': None
+    })
+except Exception as e:
+    constraint_results.append({
+        'index': %d,
+        'name': %q,
+        'type': %q,
+        'added': False,
+        'error': str(e)
+    })
+`, i, constraint.Name, i, constraint.Expression, i, i, constraint.Name, constraint.Type, i, constraint.Name, constraint.Type))
+	}
+
+	// Solve and return result
+	sb.WriteString(`
+# Solve the model
+result = {
+    'satisfied': False,
+    'status': 'unknown',
+    'constraints': constraint_results,
+    'counter_example': None
+}
+
+try:
+    if model.solve():
+        result['satisfied'] = True
+        result['status'] = 'satisfied'
+    else:
+        result['status'] = 'violated'
+        # Try to extract counter-example
+        result['counter_example'] = {
+            'variables': {k: str(v.value()) if hasattr(v, 'value') else str(v) for k, v in variables.items()},
+            'explanation': 'Constraints could not be satisfied'
+        }
+except Exception as e:
+    result['status'] = 'error'
+    result['error'] = str(e)
+
+json.dumps(result)
+`)
+
+	return sb.String()
+}
+
+// ParseResult parses the JSON result from CPMpy execution.
+func (CPMpyBackend) ParseResult(jsonStr string, constraints []Constraint, result *VerificationResult) (*VerificationResult, error) {
+	return parseSolverResult(jsonStr, constraints, result)
+}
+
+// parseSolverResult parses the JSON result the generated code returns,
+// which both backends share.
+func parseSolverResult(jsonStr string, constraints []Constraint, result *VerificationResult) (*VerificationResult, error) {
+	// Clean up the JSON string
+	jsonStr = strings.TrimSpace(jsonStr)
+	jsonStr = strings.Trim(jsonStr, "'\"")
+
+	var parsed struct {
+		Satisfied   bool   `json:"satisfied"`
+		Status      string `json:"status"`
+		Constraints []struct {
+			Index int    `json:"index"`
+			Name  string `json:"name"`
+			Type  string `json:"type"`
+			Added bool   `json:"added"`
+			Error string `json:"error"`
+		} `json:"constraints"`
+		CounterExample *struct {
+			Variables   map[string]string `json:"variables"`
+			Explanation string            `json:"explanation"`
+		} `json:"counter_example"`
+		Error string `json:"error"`
+	}
+
+	if err := json.Unmarshal([]byte(jsonStr), &parsed); err != nil {
+		// If JSON parsing fails, try to infer result from raw output
+		result.Status = StatusUnknown
+		return result, nil
+	}
+
+	result.Satisfied = parsed.Satisfied
+	result.Status = VerificationStatus(parsed.Status)
+	if parsed.Error != "" {
+		// Keep the solver's own error, e.g. a missing library, visible
+		result.SolverOutput = strings.TrimSpace(result.SolverOutput + "\n" + parsed.Error)
+	}
+
+	// Map constraint results back
+	for _, cr := range parsed.Constraints {
+		if cr.Index < len(constraints) {
+			result.CheckedConstraints = append(result.CheckedConstraints, ConstraintResult{
+				Constraint: &constraints[cr.Index],
+				Satisfied:  cr.Added && cr.Error == "",
+				Message:    cr.Error,
+			})
+		}
+	}
+
+	// Extract counter-example
+	if parsed.CounterExample != nil {
+		vars := make(map[string]any)
+		for k, v := range parsed.CounterExample.Variables {
+			vars[k] = v
+		}
+		result.CounterExample = &CounterExample{
+			Variables:   vars,
+			Explanation: parsed.CounterExample.Explanation,
+		}
+	}
+
+	return result, nil
+}
+
+// Z3Backend solves with the Z3 SMT solver, whose theories (strings,
+// arrays, reals) go beyond CPMpy's. Variables are Z3 integers, as with
+// CPMpy. When the constraints conflict, the counter-example names the
+// conflicting ones (Z3's unsat core) instead of giving variable values.
+type Z3Backend struct{}
+
+// Name returns "z3".
+func (Z3Backend) Name() string { return "z3" }
+
+// BuildCode generates Python code for Z3 constraint solving.
+func (Z3Backend) BuildCode(constraints []Constraint, code string) string {
+	var sb strings.Builder
+
+	sb.WriteString(`
+import json
+try:
+    from z3 import *
+    _z3_error = None
+except ImportError as e:
+    _z3_error = str(e)
+
+def _verify_z3():
+    if _z3_error is not None:
+        return {
+            'satisfied': False,
+            'status': 'error',
+            'constraints': [],
+            'counter_example': None,
+            'error': 'z3 is not available: ' + _z3_error
+        }
+
+    solver = Solver()
+    variables = {}
+    tracked = {}
+`)
+
+	// Declare variables
+	varSet := make(map[string]bool)
+	for _, constraint := range constraints {
+		for _, v := range constraint.Variables {
+			if !varSet[v] {
+				varSet[v] = true
+				sb.WriteString(fmt.Sprintf("    variables['%s'] = Int('%s')\n", v, v))
+			}
+		}
+	}
+
+	// Add constraints, each tracked so an unsat core can name it
+	sb.WriteString("\n    constraint_results = []\n")
+	for i, constraint := range constraints {
+		sb.WriteString(fmt.Sprintf(`
+    # Constraint %d: %s
+    try:
+        constraint_%d = %s
+        if isinstance(constraint_%d, bool):
+            constraint_%d = BoolVal(constraint_%d)
+        solver.assert_and_track(constraint_%d, Bool('constraint_%d'))
+        tracked['constraint_%d'] = %q
+        constraint_results.append({'index': %d, 'name': %q, 'type': %q, 'added': True, 'error': None})
+    except Exception as e:
+        constraint_results.append({'index': %d, 'name': %q, 'type': %q, 'added': False, 'error': str(e)})
+`, i, constraint.Name, i, constraint.Expression, i, i, i, i, i, i, constraint.Name,
+			i, constraint.Name, constraint.Type, i, constraint.Name, constraint.Type))
+	}
+
+	sb.WriteString(`
+    result = {
+        'satisfied': False,
+        'status': 'unknown',
+        'constraints': constraint_results,
+        'counter_example': None
+    }
+
+    try:
+        outcome = solver.check()
+        if outcome == sat:
+            result['satisfied'] = True
+            result['status'] = 'satisfied'
+        elif outcome == unsat:
+            result['status'] = 'violated'
+            core = [tracked.get(str(c), str(c)) for c in solver.unsat_core()]
+            result['counter_example'] = {
+                'variables': {},
+                'explanation': 'Conflicting constraints: ' + ', '.join(core)
+            }
+        else:
+            result['error'] = solver.reason_unknown()
+    except Exception as e:
+        result['status'] = 'error'
+        result['error'] = str(e)
+
+    return result
+
+json.dumps(_verify_z3())
+`)
+
+	return sb.String()
+}
+
+// ParseResult parses the JSON result from Z3 execution.
+func (Z3Backend) ParseResult(jsonStr string, constraints []Constraint, result *VerificationResult) (*VerificationResult, error) {
+	return parseSolverResult(jsonStr, constraints, result)
+}
+
+var (
+	_ SolverBackend = CPMpyBackend{}
+	_ SolverBackend = Z3Backend{}
+)
@@ -0,0 +1,126 @@
+package verify
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rand/recurse/internal/rlm/repl"
+)
+
+// mockBackend records what the chain asks of it and parses with the shared
+// JSON reader.
+type mockBackend struct {
+	built  [][]Constraint
+	parsed []string
+}
+
+func (b *mockBackend) Name() string { return "mock" }
+
+func (b *mockBackend) BuildCode(constraints []Constraint, code string) string {
+	b.built = append(b.built, constraints)
+	return "# mock solver\n" + code
+}
+
+func (b *mockBackend) ParseResult(output string, constraints []Constraint, result *VerificationResult) (*VerificationResult, error) {
+	b.parsed = append(b.parsed, output)
+	return parseSolverResult(output, constraints, result)
+}
+
+// cannedExecutor returns the same REPL result for any code.
+type cannedExecutor struct {
+	returnVal string
+	code      []string
+}
+
+func (e *cannedExecutor) Execute(ctx context.Context, code string) (*repl.ExecuteResult, error) {
+	e.code = append(e.code, code)
+	return &repl.ExecuteResult{ReturnVal: e.returnVal}, nil
+}
+
+func solverTestConstraints() []Constraint {
+	return []Constraint{
+		{Type: ConstraintTypePrecondition, Name: "x_positive", Expression: "variables['x'] > 0", Variables: []string{"x"}},
+		{Type: ConstraintTypePostcondition, Name: "y_bounded", Expression: "variables['y'] <= 10", Variables: []string{"y"}},
+	}
+}
+
+const violatedJSON = `{"satisfied": false, "status": "violated", "constraints": [
+	{"index": 0, "name": "x_positive", "type": "precondition", "added": true, "error": null},
+	{"index": 1, "name": "y_bounded", "type": "postcondition", "added": false, "error": "bad operand"}
+], "counter_example": {"variables": {"x": "0"}, "explanation": "Constraints could not be satisfied"}}`
+
+func TestVerificationChain_SolverDefault(t *testing.T) {
+	chain := NewVerificationChain(nil)
+	assert.Equal(t, "cpmpy", chain.Solver().Name())
+
+	chain.SetSolver(Z3Backend{})
+	assert.Equal(t, "z3", chain.Solver().Name())
+
+	chain.SetSolver(nil)
+	assert.Equal(t, "cpmpy", chain.Solver().Name())
+}
+
+func TestVerificationChain_VerifyUsesSolverBackend(t *testing.T) {
+	backend := &mockBackend{}
+	exec := &cannedExecutor{returnVal: "'" + violatedJSON + "'"}
+	chain := &VerificationChain{repl: exec, timeout: time.Second}
+	chain.SetSolver(backend)
+
+	constraints := solverTestConstraints()
+	result, err := chain.Verify(context.Background(), constraints, "def f(x): return x")
+	require.NoError(t, err)
+
+	require.Len(t, backend.built, 1)
+	assert.Equal(t, constraints, backend.built[0])
+	require.Len(t, exec.code, 1)
+	assert.Equal(t, "# mock solver\ndef f(x): return x", exec.code[0], "the REPL runs the backend's code")
+	require.Len(t, backend.parsed, 1)
+
+	assert.False(t, result.Satisfied)
+	assert.Equal(t, StatusViolated, result.Status)
+	require.Len(t, result.CheckedConstraints, 2)
+	assert.True(t, result.CheckedConstraints[0].Satisfied)
+	assert.Equal(t, "bad operand", result.CheckedConstraints[1].Message)
+}
+
+func TestSolverBackends_ParseConsistently(t *testing.T) {
+	constraints := solverTestConstraints()
+	backends := []SolverBackend{CPMpyBackend{}, Z3Backend{}, &mockBackend{}}
+
+	var results []*VerificationResult
+	for _, backend := range backends {
+		result, err := backend.ParseResult(violatedJSON, constraints, &VerificationResult{})
+		require.NoError(t, err, backend.Name())
+		results = append(results, result)
+	}
+	for i := 1; i < len(results); i++ {
+		assert.Equal(t, results[0], results[i], backends[i].Name())
+	}
+
+	// A solver error is kept in the output
+	result, err := Z3Backend{}.ParseResult(
+		`{"satisfied": false, "status": "error", "constraints": [], "error": "z3 is not available: No module named 'z3'"}`,
+		constraints, &VerificationResult{})
+	require.NoError(t, err)
+	assert.Equal(t, StatusError, result.Status)
+	assert.Contains(t, result.SolverOutput, "z3 is not available")
+}
+
+func TestZ3Backend_BuildCode(t *testing.T) {
+	code := Z3Backend{}.BuildCode(solverTestConstraints(), "")
+
+	assert.Contains(t, code, "from z3 import *")
+	assert.Contains(t, code, "variables['x'] = Int('x')")
+	assert.Contains(t, code, "variables['y'] = Int('y')")
+	assert.Contains(t, code, "constraint_0 = variables['x'] > 0")
+	assert.Contains(t, code, "solver.assert_and_track(constraint_1, Bool('constraint_1'))")
+	assert.Contains(t, code, `tracked['constraint_0'] = "x_positive"`)
+	assert.Contains(t, code, "solver.unsat_core()")
+	assert.True(t, strings.HasSuffix(strings.TrimSpace(code), "json.dumps(_verify_z3())"))
+	assert.NotContains(t, code, "cpmpy")
+}
@@ -0,0 +1,130 @@
+package verify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ValidationReport is the outcome of a dry run: the constraints extracted
+// from a change and which of them would fail to load into the model,
+// without solving anything.
+type ValidationReport struct {
+	// Constraints lists every extracted constraint, in verification order.
+	Constraints []Constraint
+
+	// Malformed lists the constraints whose expressions do not parse.
+	Malformed []MalformedConstraint
+
+	// Duration is how long extraction and validation took.
+	Duration time.Duration
+}
+
+// Valid reports whether every extracted constraint is well-formed.
+func (r *ValidationReport) Valid() bool {
+	return len(r.Malformed) == 0
+}
+
+// MalformedConstraint is a constraint whose expression is not a valid
+// CPMpy (Python) expression.
+type MalformedConstraint struct {
+	// Index is the constraint's position in ValidationReport.Constraints.
+	Index int
+
+	// Constraint is the malformed constraint.
+	Constraint *Constraint
+
+	// Message explains what is wrong with the expression.
+	Message string
+}
+
+// Validate extracts the constraints VerifyChange would check and has the
+// REPL parse each expression with ast.parse, reporting the malformed ones.
+// Nothing is evaluated and the solver is not invoked, so it is a cheap
+// pre-check before a solve and a way to debug extraction.
+func (c *VerificationChain) Validate(ctx context.Context, change CodeChange) (*ValidationReport, error) {
+	start := time.Now()
+
+	constraints, _, err := c.changeConstraints(ctx, change)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ValidationReport{Constraints: constraints}
+	if len(constraints) > 0 {
+		if c.repl == nil {
+			return nil, fmt.Errorf("REPL manager not configured")
+		}
+		malformed, err := c.parseExpressions(ctx, constraints)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range malformed {
+			if m.Index < 0 || m.Index >= len(constraints) {
+				return nil, fmt.Errorf("validation reported unknown constraint %d", m.Index)
+			}
+			report.Malformed = append(report.Malformed, MalformedConstraint{
+				Index:      m.Index,
+				Constraint: &report.Constraints[m.Index],
+				Message:    m.Message,
+			})
+		}
+	}
+
+	report.Duration = time.Since(start)
+	return report, nil
+}
+
+// parseExpressionsCode parses each expression of the JSON list in the
+// format verb as a Python expression, printing the failures as JSON. It
+// ends in a statement, as the REPL re-evaluates a final expression.
+const parseExpressionsCode = `
+import ast as _ast, json as _json
+_malformed = []
+for _i, _expr in enumerate(_json.loads(%q)):
+    try:
+        _ast.parse(_expr, mode="eval")
+    except SyntaxError as _e:
+        _malformed.append({"index": _i, "message": f"{_e.msg} (column {_e.offset})"})
+    except ValueError as _e:
+        _malformed.append({"index": _i, "message": str(_e)})
+print(_json.dumps(_malformed))
+del _malformed
+`
+
+// malformedExpression is a parse failure reported by parseExpressionsCode.
+type malformedExpression struct {
+	Index   int    `json:"index"`
+	Message string `json:"message"`
+}
+
+// parseExpressions has the REPL parse the constraints' expressions,
+// returning those that are not valid Python expressions.
+func (c *VerificationChain) parseExpressions(ctx context.Context, constraints []Constraint) ([]malformedExpression, error) {
+	exprs := make([]string, len(constraints))
+	for i, constraint := range constraints {
+		exprs[i] = constraint.Expression
+	}
+	list, err := json.Marshal(exprs)
+	if err != nil {
+		return nil, fmt.Errorf("encode expressions: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	result, err := c.repl.Execute(ctx, fmt.Sprintf(parseExpressionsCode, string(list)))
+	if err != nil {
+		return nil, fmt.Errorf("REPL execution failed: %w", err)
+	}
+	if result.Error != "" {
+		return nil, fmt.Errorf("validate expressions: %s", result.Error)
+	}
+
+	var malformed []malformedExpression
+	if err := json.Unmarshal([]byte(strings.TrimSpace(result.Output)), &malformed); err != nil {
+		return nil, fmt.Errorf("decode validation result: %w", err)
+	}
+	return malformed, nil
+}
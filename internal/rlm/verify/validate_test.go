@@ -0,0 +1,147 @@
+package verify
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rand/recurse/internal/rlm/repl"
+)
+
+// newREPLChain returns a verification chain backed by a running REPL.
+func newREPLChain(t *testing.T) *VerificationChain {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	replMgr, err := repl.NewManager(repl.Options{})
+	require.NoError(t, err)
+	require.NoError(t, replMgr.Start(ctx))
+	t.Cleanup(func() { replMgr.Stop() })
+	return NewVerificationChain(replMgr)
+}
+
+func expressionConstraints(exprs ...string) []Constraint {
+	constraints := make([]Constraint, len(exprs))
+	for i, expr := range exprs {
+		constraints[i] = Constraint{Name: "c", Expression: expr}
+	}
+	return constraints
+}
+
+func TestVerificationChain_ParseExpressions(t *testing.T) {
+	chain := newREPLChain(t)
+
+	valid := []string{
+		"variables['x'] > 0",
+		"(variables['lo'] >= 0) & (variables['lo'] <= 10)",
+		"True  # x must be sorted",
+		"len(items) > 0 and items[0] is not None",
+		"sum(v for v in xs if v > 0) <= limit",
+		"AllDifferent(cells[1:, ::2])",
+		"{'a': 1, **extra} == {k: v for k, v in pairs}",
+		"a if cond else b",
+		"all(map(lambda v: v > 0, xs))",
+		"(n := len(xs)) > 0",
+		"größe > 0",
+		`name != """it's "quoted" """`,
+	}
+	malformed, err := chain.parseExpressions(context.Background(), expressionConstraints(valid...))
+	require.NoError(t, err)
+	assert.Empty(t, malformed)
+
+	invalid := []string{
+		"",
+		"# only a comment",
+		"(variables['x'] > 0",
+		"x > > 1",
+		"total = 5",
+		"x == 'open",
+		"import os",
+		"x > 0; y < 1",
+		"a if b",
+		"cost > $5",
+	}
+	malformed, err = chain.parseExpressions(context.Background(), expressionConstraints(invalid...))
+	require.NoError(t, err)
+	require.Len(t, malformed, len(invalid))
+	for i, m := range malformed {
+		assert.Equal(t, i, m.Index)
+		assert.NotEmpty(t, m.Message, invalid[i])
+	}
+}
+
+func TestVerificationChain_Validate_WellFormed(t *testing.T) {
+	chain := newREPLChain(t)
+
+	change := CodeChange{
+		After: `
+def clamp(x: int, lo: int, hi: int) -> int:
+    """
+    @requires lo must be less than hi
+    @ensures result is non-negative
+    """
+    assert lo < hi
+    return max(lo, min(x, hi))
+`,
+		Language: "python",
+	}
+
+	report, err := chain.Validate(context.Background(), change)
+	require.NoError(t, err)
+	assert.True(t, report.Valid())
+	assert.Empty(t, report.Malformed)
+	assert.NotEmpty(t, report.Constraints)
+
+	// The report lists what VerifyChange would check, in the same order
+	want, err := chain.extractConstraints(context.Background(), change)
+	require.NoError(t, err)
+	assert.Equal(t, want, report.Constraints)
+}
+
+func TestVerificationChain_Validate_FlagsMalformed(t *testing.T) {
+	chain := newREPLChain(t)
+
+	change := CodeChange{
+		After: `
+def withdraw(balance, amount):
+    assert amount > 0
+    assert (balance >= amount
+    assert balance = amount
+    return balance - amount
+`,
+		Language: "python",
+	}
+
+	report, err := chain.Validate(context.Background(), change)
+	require.NoError(t, err)
+	require.Len(t, report.Constraints, 3)
+	assert.False(t, report.Valid())
+
+	require.Len(t, report.Malformed, 2)
+	assert.Equal(t, 1, report.Malformed[0].Index)
+	assert.Equal(t, "(balance >= amount", report.Malformed[0].Constraint.Expression)
+	assert.Contains(t, report.Malformed[0].Message, "column")
+	assert.Equal(t, 2, report.Malformed[1].Index)
+	assert.Same(t, &report.Constraints[2], report.Malformed[1].Constraint)
+	assert.NotEmpty(t, report.Malformed[1].Message)
+}
+
+func TestVerificationChain_Validate_NoREPL(t *testing.T) {
+	chain := NewVerificationChain(nil)
+
+	_, err := chain.Validate(context.Background(), CodeChange{After: "assert x > 0", Language: "python"})
+	assert.ErrorContains(t, err, "REPL manager not configured")
+}
+
+func TestVerificationChain_Validate_NoConstraints(t *testing.T) {
+	chain := NewVerificationChain(nil)
+
+	report, err := chain.Validate(context.Background(), CodeChange{After: "x = 1", Language: "python"})
+	require.NoError(t, err)
+	assert.Empty(t, report.Constraints)
+	assert.True(t, report.Valid())
+}
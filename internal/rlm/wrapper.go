@@ -3,13 +3,17 @@ package rlm
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"slices"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/rand/recurse/internal/rlm/compress"
 	"github.com/rand/recurse/internal/rlm/meta"
+	"github.com/rand/recurse/internal/rlm/redact"
 	"github.com/rand/recurse/internal/rlm/repl"
 )
 
@@ -24,6 +28,9 @@ type Wrapper struct {
 	classifier    *TaskClassifier
 	llmClassifier *LLMClassifier
 
+	// Final classifications of earlier prompts, possibly shared (nil disables)
+	classificationCache *ClassificationCache
+
 	// Proactive computation advisor
 	computationAdvisor *ComputationAdvisor
 
@@ -32,6 +39,10 @@ type Wrapper struct {
 	compressionEnabled   bool
 	compressionThreshold int // Compress when total tokens exceed this
 
+	// Escalates compression and evicts contexts under memory pressure (nil
+	// disables)
+	memoryPressure *memoryPressureMonitor
+
 	// Thresholds for when to use RLM mode
 	minContextTokensForRLM            int
 	minContextTokensForComputational  int // Lower threshold for computational tasks
@@ -42,6 +53,49 @@ type Wrapper struct {
 
 	// LLM fallback settings
 	llmFallbackMinConfidence float64 // Minimum rule-based confidence to try LLM fallback
+
+	// Prompts this small with no context skip mode selection (0 disables)
+	fastPathMaxTokens int
+
+	// Relevance ranking of contexts before externalization
+	contextRanking RankConfig
+
+	// Reloading of externalized files that change on disk
+	contextRefresh RefreshConfig
+
+	// Graded outcomes that can override the static mode rules
+	modeOutcomes *ModeOutcomeTracker
+
+	// Redacts secrets from contexts before externalization
+	redactor *redact.Redactor
+
+	// Records each RLM loop step for replay (nil disables)
+	tracer TraceRecorder
+
+	// Fetches context the model asks for with request_context() (nil disables)
+	contextProvider ContextProvider
+
+	// Token budget the RLM system prompt is compacted to fit (0 disables)
+	systemPromptBudget int
+
+	// Calls RLM code made to each REPL builtin, across executions
+	builtinUsage *builtinUsageTracker
+
+	// Inputs and model responses of recent traced executions, for export
+	captures executionCaptures
+
+	// Persists each execution's complete conversation (nil disables)
+	conversationStore ConversationStore
+
+	// Cleanup of FINAL() output before it is returned
+	outputSanitize OutputSanitizeConfig
+
+	// Makes text surrogates of images for text-only clients (nil uses a
+	// placeholder)
+	imageDescriber ImageDescriber
+
+	// User-supplied Python helpers loaded next to the builtins
+	helpers []REPLHelper
 }
 
 // WrapperConfig configures the RLM wrapper.
@@ -71,6 +125,15 @@ type WrapperConfig struct {
 	// DisableLLMFallback disables LLM-based classification fallback.
 	DisableLLMFallback bool
 
+	// ClassifierClient is the LLM client of the classification fallback.
+	// Nil uses the client given to SetLLMClient.
+	ClassifierClient meta.LLMClient
+
+	// ClassificationCache reuses the classifications of prompts seen
+	// before, by this or another Wrapper sharing the cache, skipping the
+	// rules and the LLM fallback. Nil classifies every prompt.
+	ClassificationCache *ClassificationCache
+
 	// CompressionEnabled enables context compression before mode selection.
 	CompressionEnabled bool
 
@@ -81,6 +144,65 @@ type WrapperConfig struct {
 	// CompressionConfig configures the compression manager (optional).
 	// If nil, default compression config is used when CompressionEnabled is true.
 	CompressionConfig *compress.ManagerConfig
+
+	// MemoryPressure lowers the compression threshold and evicts the least
+	// used externalized contexts while the process's RSS is above a
+	// threshold. Disabled by default.
+	MemoryPressure MemoryPressureConfig
+
+	// FastPathMaxTokens is the prompt size at or below which a prompt with
+	// no contexts goes straight to Direct mode, skipping classification.
+	// Zero disables the fast path.
+	FastPathMaxTokens int
+
+	// ContextRanking ranks contexts by relevance to the prompt in RLM mode
+	// and externalizes only the top ones. Nothing is dropped unless TopK or
+	// MinRelevance is set.
+	ContextRanking RankConfig
+
+	// ContextRefresh reloads file-backed contexts whose files change on
+	// disk during an RLM execution, telling the model which variables
+	// were refreshed. A zero Interval disables it.
+	ContextRefresh RefreshConfig
+
+	// Redactor redacts secrets from the prompts of the client set with
+	// SetLLMClient and from saved conversations. Contexts are externalized
+	// into the REPL as they are. Nil redacts nothing.
+	Redactor *redact.Redactor
+
+	// Tracer records the model responses, code, outputs and final answer
+	// of each RLM execution, so ReplayExecution can reconstruct it. Nil
+	// records nothing.
+	Tracer TraceRecorder
+
+	// ContextProvider fetches the context an RLM execution asks for with
+	// request_context(), which is loaded as new variables before the next
+	// iteration. Nil leaves such requests unfulfilled.
+	ContextProvider ContextProvider
+
+	// SystemPromptBudget is the token budget of the RLM system prompt,
+	// leaving more of a small model's window for context. Over budget,
+	// the examples are trimmed first, then the computation suggestion and
+	// task-type guidance, and finally the instructions are shortened; the
+	// variables, function signatures and FINAL() contract are always kept.
+	// Zero leaves the prompt whole.
+	SystemPromptBudget int
+
+	// OutputSanitize strips terminal escapes and control characters from
+	// FINAL() output, collapses pathological repetition and caps its size
+	// before it is returned. JSON and code output is left alone unless
+	// IncludeStructured is set. Off by default.
+	OutputSanitize OutputSanitizeConfig
+
+	// ImageDescriber makes the text surrogate of image contexts when the
+	// LLM client cannot see images, e.g. NewVisionDescriber with a separate
+	// vision model. Nil replaces each image with a placeholder naming it.
+	ImageDescriber ImageDescriber
+
+	// ConversationStore persists the complete conversation of each RLM
+	// execution, redacted, under its RLMExecutionResult.ExecutionID. Nil
+	// persists nothing.
+	ConversationStore ConversationStore
 }
 
 // DefaultWrapperConfig returns sensible defaults.
@@ -95,6 +217,9 @@ func DefaultWrapperConfig() WrapperConfig {
 		DisableLLMFallback:                false,
 		CompressionEnabled:                false, // Disabled by default
 		CompressionThreshold:              8000,  // Compress when context exceeds 8K tokens
+		FastPathMaxTokens:                 DefaultFastPathMaxTokens,
+		ContextRanking:                    DefaultRankConfig(),
+		ContextRefresh:                    DefaultRefreshConfig(),
 	}
 }
 
@@ -128,6 +253,19 @@ func NewWrapper(svc *Service, cfg WrapperConfig) *Wrapper {
 		llmFallbackMinConfidence:          cfg.LLMFallbackMinConfidence,
 		compressionEnabled:                cfg.CompressionEnabled,
 		compressionThreshold:              cfg.CompressionThreshold,
+		fastPathMaxTokens:                 cfg.FastPathMaxTokens,
+		contextRanking:                    cfg.ContextRanking,
+		contextRefresh:                    cfg.ContextRefresh,
+		modeOutcomes:                      NewModeOutcomeTracker(DefaultModeOutcomeConfig()),
+		redactor:                          cfg.Redactor,
+		tracer:                            cfg.Tracer,
+		classificationCache:               cfg.ClassificationCache,
+		contextProvider:                   cfg.ContextProvider,
+		systemPromptBudget:                cfg.SystemPromptBudget,
+		builtinUsage:                      newBuiltinUsageTracker(),
+		outputSanitize:                    cfg.OutputSanitize,
+		imageDescriber:                    cfg.ImageDescriber,
+		conversationStore:                 cfg.ConversationStore,
 	}
 
 	// Initialize compression manager if enabled
@@ -141,10 +279,21 @@ func NewWrapper(svc *Service, cfg WrapperConfig) *Wrapper {
 		w.compressionMgr = compress.NewManager(compressCfg)
 	}
 
+	if cfg.MemoryPressure.Enabled() {
+		pressure := cfg.MemoryPressure
+		if pressure.Signal == nil {
+			pressure.Signal = w.replRSS
+		}
+		w.memoryPressure = newMemoryPressureMonitor(pressure)
+	}
+
 	// Initialize classifier unless disabled
 	if !cfg.DisableClassifier {
 		w.classifier = NewTaskClassifier()
 	}
+	if cfg.ClassifierClient != nil {
+		w.llmClassifier = NewLLMClassifier(GuardCalls(cfg.ClassifierClient))
+	}
 
 	// Initialize computation advisor for proactive REPL suggestions
 	w.computationAdvisor = NewComputationAdvisor()
@@ -157,18 +306,57 @@ func (w *Wrapper) SetREPLManager(replMgr *repl.Manager) {
 	w.replMgr = replMgr
 	if replMgr != nil {
 		w.contextLoader = NewContextLoader(replMgr)
+		w.contextLoader.SetRankConfig(w.contextRanking)
+		w.contextLoader.SetRefreshConfig(w.contextRefresh)
 	}
 }
 
-// SetLLMClient sets the LLM client for sub-calls and LLM classification fallback.
+// SetLLMClient sets the LLM client for sub-calls and, unless
+// WrapperConfig.ClassifierClient is set, the LLM classification fallback.
 func (w *Wrapper) SetLLMClient(client meta.LLMClient) {
+	client = redactPrompts(client, w.redactor)
 	w.client = client
 	// Initialize LLM classifier if client is available
 	if client != nil && w.llmClassifier == nil {
-		w.llmClassifier = NewLLMClassifier(client)
+		w.llmClassifier = NewLLMClassifier(GuardCalls(client))
 	}
 }
 
+// SetContextProvider sets the provider that fetches the context an RLM
+// execution asks for with request_context(). Nil disables fetching.
+func (w *Wrapper) SetContextProvider(provider ContextProvider) {
+	w.contextProvider = provider
+}
+
+// SetSystemPromptBudget sets the token budget the RLM system prompt is
+// compacted to fit. Zero leaves the prompt whole.
+func (w *Wrapper) SetSystemPromptBudget(tokens int) {
+	w.systemPromptBudget = tokens
+}
+
+// SetOutputSanitize sets how FINAL() output is cleaned up before it is
+// returned.
+func (w *Wrapper) SetOutputSanitize(cfg OutputSanitizeConfig) {
+	w.outputSanitize = cfg
+}
+
+// SetImageDescriber sets what makes the text surrogate of images for a
+// client that cannot see them. Nil uses a placeholder.
+func (w *Wrapper) SetImageDescriber(describer ImageDescriber) {
+	w.imageDescriber = describer
+}
+
+// SetClassificationCache sets the cache of prompt classifications, which
+// may be shared with other Wrappers. Nil disables caching.
+func (w *Wrapper) SetClassificationCache(cache *ClassificationCache) {
+	w.classificationCache = cache
+}
+
+// ClassificationCache returns the cache of prompt classifications, or nil.
+func (w *Wrapper) ClassificationCache() *ClassificationCache {
+	return w.classificationCache
+}
+
 // PrepareContext prepares context for a prompt, potentially externalizing it.
 // Returns the modified prompt and any loaded context info.
 // Uses automatic mode selection. For explicit mode control, use PrepareContextWithOptions.
@@ -179,40 +367,98 @@ func (w *Wrapper) PrepareContext(ctx context.Context, prompt string, contexts []
 // PrepareContextWithOptions prepares context with explicit options.
 // Allows forcing RLM or Direct mode via ModeOverride.
 func (w *Wrapper) PrepareContextWithOptions(ctx context.Context, prompt string, contexts []ContextSource, opts PrepareOptions) (*PreparedPrompt, error) {
+	if opts.RecursionDepth == 0 {
+		opts.RecursionDepth = RecursionDepthFrom(ctx)
+	}
+
+	// Prior turns are loaded like any other context
+	if len(opts.History) > 0 {
+		contexts = append(contexts[:len(contexts):len(contexts)], ConversationContext(opts.History))
+	}
+
+	// Images go to a client that can see them as is; for one that cannot,
+	// they become text before anything else reads the contexts
+	contexts, images := w.resolveImages(ctx, contexts)
+	pressure := w.relieveMemoryPressure(ctx, contexts)
+	prepared, err := w.prepareContext(ctx, prompt, contexts, opts, pressure)
+	if err != nil {
+		return nil, err
+	}
+	prepared.Images = images
+	prepared.MemoryPressure = pressure
+	return prepared, nil
+}
+
+// prepareContext selects the mode for prompt and its text contexts and
+// prepares them for it, compressing them sooner under memory pressure.
+func (w *Wrapper) prepareContext(ctx context.Context, prompt string, contexts []ContextSource, opts PrepareOptions, pressure *MemoryPressureActions) (*PreparedPrompt, error) {
 	// Calculate total context size
 	totalTokens := estimateTokens(prompt)
 	for _, c := range contexts {
 		totalTokens += estimateTokens(c.Content)
 	}
 
+	// Trivial prompts need no classification to know Direct mode suffices
+	if (opts.ModeOverride == "" || opts.ModeOverride == ModeOverrideAuto) &&
+		isFastPath(totalTokens, len(contexts), w.fastPathMaxTokens) {
+		slog.Debug("Mode selection: Direct (fast path)",
+			"total_tokens", totalTokens)
+		prepared := w.prepareDirectMode(prompt, contexts)
+		prepared.ModeReason = fastPathReason
+		prepared.ModeInfo = buildModeSelectionInfo(
+			ModeDirecte,
+			fastPathReason,
+			opts.ModeOverride,
+			nil,
+			totalTokens,
+			0,
+			w.fastPathMaxTokens,
+			w.replMgr != nil,
+			false,
+			0,
+		)
+		return prepared, nil
+	}
+
 	// Apply compression if enabled and context exceeds threshold
-	var compressionResult *compress.PreparedContext
-	if w.compressionEnabled && w.compressionMgr != nil && totalTokens > w.compressionThreshold {
-		compressed, err := w.compressContexts(ctx, contexts, prompt, totalTokens)
+	var compression *CompressionStats
+	compressionThreshold := pressure.compressionThreshold(w.compressionThreshold)
+	if w.compressionEnabled && w.compressionMgr != nil &&
+		totalTokens > w.compressionMgr.EffectiveThreshold(compressionThreshold, opts.RecursionDepth) {
+		compressed, err := w.compressContexts(ctx, contexts, prompt, totalTokens, compressionThreshold, opts.RecursionDepth)
 		if err != nil {
 			slog.Warn("Context compression failed, using original contexts", "error", err)
 		} else {
-			compressionResult = compressed
 			// Update contexts with compressed versions
 			contexts = w.applyCompressionResults(contexts, compressed)
-			// Recalculate total tokens after compression
+			// Recalculate total tokens after compression. Contexts are
+			// re-compressed one by one, so measure what will be sent rather
+			// than trusting the manager's combined figures.
+			compression = &CompressionStats{OriginalTokens: totalTokens}
 			totalTokens = estimateTokens(prompt)
 			for _, c := range contexts {
 				totalTokens += estimateTokens(c.Content)
 			}
+			compression.CompressedTokens = totalTokens
 			slog.Info("Context compressed",
-				"original_tokens", compressed.OriginalTokens,
-				"compressed_tokens", compressed.CompressedTokens,
-				"ratio", fmt.Sprintf("%.2f", compressed.Ratio))
+				"original_tokens", compression.OriginalTokens,
+				"compressed_tokens", compression.CompressedTokens,
+				"saved_tokens", compression.SavedTokens(),
+				"ratio", fmt.Sprintf("%.2f", compression.Ratio()),
+				"depth", opts.RecursionDepth)
 		}
 	}
-	_ = compressionResult // Used for future metrics/logging
 
 	// Classify the task if classifier is available and not skipped
 	var classification *Classification
+	cachedClassification := false
 	if w.classifier != nil && !opts.SkipClassification {
-		c := w.classifier.Classify(prompt, contexts)
+		c, ok := w.classificationCache.Get(prompt, contexts)
+		if !ok {
+			c = w.classifier.Classify(prompt, contexts)
+		}
 		classification = &c
+		cachedClassification = ok
 	}
 
 	// Check for mode override
@@ -253,6 +499,12 @@ func (w *Wrapper) PrepareContextWithOptions(ctx context.Context, prompt string,
 		classification = selectionResult.classification
 	}
 
+	// Remember the final classification, so the prompt's next appearance
+	// also skips any LLM fallback it needed
+	if classification != nil && (!cachedClassification || selectionResult.usedLLMFallback) {
+		w.classificationCache.Put(prompt, contexts, *classification)
+	}
+
 	// Build mode selection info for transparency
 	modeInfo := buildModeSelectionInfo(
 		mode,
@@ -266,12 +518,13 @@ func (w *Wrapper) PrepareContextWithOptions(ctx context.Context, prompt string,
 		selectionResult.usedLLMFallback,
 		selectionResult.ruleBasedConfidence,
 	)
+	modeInfo.Evidence = selectionResult.evidence
 
 	// Check if RLM is actually possible when forced
 	if mode == ModeRLM {
 		if w.contextLoader == nil {
 			if opts.ModeOverride == ModeOverrideRLM {
-				return nil, fmt.Errorf("RLM mode requested but context loader not available")
+				return nil, fmt.Errorf("RLM mode requested but context loader not available: %w", ErrNotConfigured)
 			}
 			mode = ModeDirecte
 			reason = "RLM not available, falling back to Direct"
@@ -279,7 +532,7 @@ func (w *Wrapper) PrepareContextWithOptions(ctx context.Context, prompt string,
 			modeInfo.Reason = reason
 		} else if w.replMgr == nil {
 			if opts.ModeOverride == ModeOverrideRLM {
-				return nil, fmt.Errorf("RLM mode requested but REPL not available")
+				return nil, fmt.Errorf("RLM mode requested: %w", ErrREPLUnavailable)
 			}
 			mode = ModeDirecte
 			reason = "REPL not available, falling back to Direct"
@@ -295,6 +548,11 @@ func (w *Wrapper) PrepareContextWithOptions(ctx context.Context, prompt string,
 		}
 		prepared.ModeReason = reason
 		prepared.ModeInfo = modeInfo
+		prepared.Compression = compression
+		if opts.OutputSchema != nil && prepared.Mode == ModeRLM {
+			prepared.OutputSchema = opts.OutputSchema
+			prepared.SystemPrompt += opts.OutputSchema.promptSection()
+		}
 		return prepared, nil
 	}
 
@@ -303,6 +561,7 @@ func (w *Wrapper) PrepareContextWithOptions(ctx context.Context, prompt string,
 	prepared.Classification = classification
 	prepared.ModeReason = reason
 	prepared.ModeInfo = modeInfo
+	prepared.Compression = compression
 	return prepared, nil
 }
 
@@ -332,8 +591,30 @@ type PreparedPrompt struct {
 	// LoadedContext contains info about externalized context (RLM mode only).
 	LoadedContext *LoadedContext
 
-	// TotalTokens is the estimated total tokens.
+	// TotalTokens is the estimated total tokens, after any compression.
 	TotalTokens int
+
+	// Compression compares the context's size before and after
+	// compression; nil when it was not compressed.
+	Compression *CompressionStats
+
+	// MemoryPressure reports what was done to relieve memory pressure
+	// before the prompt was prepared; nil when there was none.
+	MemoryPressure *MemoryPressureActions
+
+	// OutputSchema is the schema the answer must satisfy, already
+	// described in SystemPrompt (RLM mode only).
+	OutputSchema *OutputSchema
+
+	// Images are the image contexts the client can see, to be sent with
+	// FinalPrompt through its CompleteWithImages; ExecuteRLM sends them
+	// with its first call only and asks the model to note what it needs
+	// from them. Images the client cannot see were turned into text
+	// contexts instead.
+	Images []meta.Image
+
+	// sources are the contexts given for externalization (RLM mode only).
+	sources []ContextSource
 }
 
 // ExecutionMode indicates how the prompt should be executed.
@@ -366,6 +647,34 @@ type PrepareOptions struct {
 
 	// SkipClassification disables task classification even if classifier is available.
 	SkipClassification bool
+
+	// RecursionDepth is the depth of the call being prepared. Compression
+	// becomes more aggressive at deeper levels. Zero takes the depth of the
+	// orchestration carried by the context, if any.
+	RecursionDepth int
+
+	// History is the conversation before the prompt, oldest first. It is
+	// added to the contexts as the `conversation` variable, so a long
+	// history is externalized in RLM mode rather than resent inline.
+	History []ConversationTurn
+
+	// OutputSchema, if set, is the JSON Schema a structured answer must
+	// satisfy. In RLM mode it is added to the system prompt and enforced
+	// on FINAL_JSON.
+	OutputSchema *OutputSchema
+}
+
+// DefaultFastPathMaxTokens is the default prompt size, in estimated tokens,
+// below which a prompt with no context skips analysis (about 128 characters).
+const DefaultFastPathMaxTokens = 32
+
+// fastPathReason is the mode reason recorded for fast-path prompts.
+const fastPathReason = "fast-path direct"
+
+// isFastPath reports whether a prompt of promptTokens with contextCount
+// contexts is small enough to go straight to Direct mode.
+func isFastPath(promptTokens, contextCount, maxTokens int) bool {
+	return maxTokens > 0 && contextCount == 0 && promptTokens <= maxTokens
 }
 
 // modeSelectionResult contains the full result of mode selection for transparency.
@@ -376,6 +685,7 @@ type modeSelectionResult struct {
 	usedLLMFallback     bool
 	ruleBasedConfidence float64
 	thresholdUsed       int
+	evidence            *ModeEvidence // set when outcomes overrode the rules
 }
 
 // selectMode determines which execution mode to use based on task classification and context size.
@@ -386,7 +696,15 @@ func (w *Wrapper) selectMode(ctx context.Context, query string, totalTokens int,
 }
 
 // selectModeDetailed performs mode selection with full detail tracking for transparency.
+// The static rules choose first; graded outcomes may then override them.
 func (w *Wrapper) selectModeDetailed(ctx context.Context, query string, totalTokens int, contexts []ContextSource, classification *Classification) modeSelectionResult {
+	result := w.selectModeByRules(ctx, query, totalTokens, contexts, classification)
+	w.applyModeEvidence(&result, len(contexts), totalTokens)
+	return result
+}
+
+// selectModeByRules performs rule-based mode selection.
+func (w *Wrapper) selectModeByRules(ctx context.Context, query string, totalTokens int, contexts []ContextSource, classification *Classification) modeSelectionResult {
 	result := modeSelectionResult{
 		classification:      classification,
 		thresholdUsed:       w.minContextTokensForRLM,
@@ -548,13 +866,15 @@ func (w *Wrapper) prepareRLMMode(ctx context.Context, prompt string, contexts []
 		Classification: classification,
 	}
 
-	// Load contexts into REPL
-	loaded, err := w.contextLoader.Load(ctx, contexts)
+	// Load the contexts most relevant to the prompt into the REPL
+	loaded, err := w.contextLoader.LoadForTask(ctx, prompt, contexts)
 	if err != nil {
 		slog.Warn("Failed to externalize context, falling back to direct mode", "error", err)
 		return w.prepareDirectMode(prompt, contexts), nil
 	}
 	result.LoadedContext = loaded
+	result.sources = contexts
+	w.memoryPressure.loaded(loaded)
 
 	// Store the original prompt as a REPL variable too
 	if err := w.replMgr.SetVar(ctx, "user_query", prompt); err != nil {
@@ -618,11 +938,32 @@ func (w *Wrapper) prepareDirectMode(prompt string, contexts []ContextSource) *Pr
 	return result
 }
 
-// generateRLMSystemPrompt generates the system prompt for RLM mode.
+// generateRLMSystemPrompt generates the system prompt for RLM mode,
+// compacted as far as needed to fit the system-prompt budget.
 func (w *Wrapper) generateRLMSystemPrompt(loaded *LoadedContext, classification *Classification, suggestion *REPLSuggestion) string {
+	prompt := w.buildRLMSystemPrompt(loaded, classification, suggestion, compactNone)
+	if w.systemPromptBudget <= 0 {
+		return prompt
+	}
+	for level := compactNone + 1; level <= compactTerse && estimateTokens(prompt) > w.systemPromptBudget; level++ {
+		prompt = w.buildRLMSystemPrompt(loaded, classification, suggestion, level)
+	}
+	if tokens := estimateTokens(prompt); tokens > w.systemPromptBudget {
+		slog.Debug("System prompt exceeds budget after compaction",
+			"tokens", tokens, "budget", w.systemPromptBudget)
+	}
+	return prompt
+}
+
+// buildRLMSystemPrompt builds the system prompt for RLM mode, leaving out
+// the optional sections the compaction level drops.
+func (w *Wrapper) buildRLMSystemPrompt(loaded *LoadedContext, classification *Classification, suggestion *REPLSuggestion, level systemPromptCompaction) string {
 	var sb strings.Builder
 
-	sb.WriteString(`You are operating in RLM (Recursive Language Model) mode.
+	if level >= compactTerse {
+		sb.WriteString(terseRLMPreamble)
+	} else {
+		sb.WriteString(`You are operating in RLM (Recursive Language Model) mode.
 
 Context has been externalized to Python variables. Use code execution to process it.
 
@@ -633,18 +974,21 @@ Context has been externalized to Python variables. Use code execution to process
 - Only use llm_call() when you need reasoning about content, not mechanical operations
 
 `)
+	}
 
 	// Add proactive computation suggestion if available
-	if suggestion != nil && suggestion.Confidence >= 0.7 {
+	if suggestion != nil && suggestion.Confidence >= 0.7 && level < compactNoSuggestion {
 		sb.WriteString(suggestion.FormatForPrompt())
 		sb.WriteString("\n")
 	}
 
 	// Add task-type-specific guidance
-	if classification != nil && classification.Confidence >= 0.5 {
+	if classification != nil && classification.Confidence >= 0.5 && level < compactNoGuidance {
 		sb.WriteString(w.getTaskTypeGuidance(classification.Type))
 	}
 
+	sb.WriteString(conversationGuidance(loaded))
+
 	sb.WriteString(`## Available Variables
 `)
 
@@ -654,27 +998,56 @@ Context has been externalized to Python variables. Use code execution to process
 		}
 	}
 
+	if level >= compactTerse {
+		sb.WriteString(terseRLMFunctions)
+		sb.WriteString(w.helpersPromptSection(true))
+		if w.contextProvider != nil {
+			sb.WriteString("- request_context(description) - ask for missing context, loaded as new variables next turn\n")
+		}
+		return sb.String()
+	}
+
 	sb.WriteString(`
 ## Core Functions
 
 ### Direct Operations (use these first - no LLM needed)
 - grep(ctx, pattern, context_lines=0) - Search for patterns, returns matches
+- grep(ctx, text, fuzzy=True, max_distance=2) - Near-matches (typos, variants); fall back to it when exact grep finds nothing
 - peek(ctx, start, end, by_lines=False) - View slice of context
 - partition(ctx, n=4, overlap=0) - Split into n chunks
 - count_tokens_approx(text) - Estimate token count
 
 ### LLM Operations (use only when reasoning is needed)
-- llm_call(prompt, context, model) - Single sub-LLM call for analysis
+- llm_call(prompt, context, model) - Single sub-LLM call for analysis; pass verify=True to have code in the response checked, synthesis=True when the result feeds your final answer
 - map_reduce(ctx, map_prompt, reduce_prompt, n_chunks=4) - For very large contexts only
 
 ### Output (call immediately when you have the answer)
 - FINAL(response) - Return your answer (string)
 - FINAL_JSON(obj) - Return structured data
+- Printed output and the value of a last bare expression are only shown back to you; FINAL() alone submits the answer
 
 `)
+	sb.WriteString(w.helpersPromptSection(false))
+
+	if w.contextProvider != nil {
+		sb.WriteString(`### Missing Context
+- request_context(description) - Ask for context that was not loaded, e.g. a file the task depends on; it is loaded as new variables before your next turn
 
-	// Add efficient examples based on task type
-	sb.WriteString(w.getTaskTypeExamples(classification))
+`)
+	}
+
+	// Add efficient examples based on task type, most relevant first
+	examples := w.getTaskTypeExamples(classification)
+	switch {
+	case level >= compactNoExamples:
+		examples = nil
+	case level >= compactOneExample:
+		examples = examples[:1]
+	}
+	if len(examples) > 0 {
+		sb.WriteString("## Efficient Patterns\n\n")
+		sb.WriteString(strings.Join(examples, "\n"))
+	}
 
 	return sb.String()
 }
@@ -714,35 +1087,30 @@ This requires reasoning about relationships:
 	}
 }
 
-// getTaskTypeExamples returns efficient examples based on task type.
-func (w *Wrapper) getTaskTypeExamples(classification *Classification) string {
-	var sb strings.Builder
-	sb.WriteString("## Efficient Patterns\n\n")
-
-	// Always show the most relevant example first based on task type
+// getTaskTypeExamples returns efficient examples based on task type, the
+// most relevant first.
+func (w *Wrapper) getTaskTypeExamples(classification *Classification) []string {
 	if classification != nil && classification.Confidence >= 0.5 {
 		switch classification.Type {
 		case TaskTypeComputational:
-			sb.WriteString(`### Counting (ONE iteration)
+			return []string{`### Counting (ONE iteration)
 ` + "```python" + `
 # Count occurrences - direct Python, no LLM needed
 import re
 count = len(re.findall(r'\bword\b', context, re.IGNORECASE))
 FINAL(str(count))
 ` + "```" + `
-
-### Summing (ONE iteration)
+`, `### Summing (ONE iteration)
 ` + "```python" + `
 # Extract and sum numbers - direct Python
 import re
 numbers = [float(x.replace(',', '')) for x in re.findall(r'\$?([\d,]+\.?\d*)', context)]
 FINAL(str(sum(numbers)))
 ` + "```" + `
-`)
-			return sb.String()
+`}
 
 		case TaskTypeRetrieval:
-			sb.WriteString(`### Finding Specific Value (ONE iteration)
+			return []string{`### Finding Specific Value (ONE iteration)
 ` + "```python" + `
 # Find the code/password/ID directly
 matches = grep(context, r'(code|password|key|id)[:\s]+(\S+)', context_lines=0)
@@ -753,11 +1121,10 @@ if matches:
 else:
     FINAL("Not found")
 ` + "```" + `
-`)
-			return sb.String()
+`}
 
 		case TaskTypeAnalytical:
-			sb.WriteString(`### Relationship Analysis (ONE-TWO iterations)
+			return []string{`### Relationship Analysis (ONE-TWO iterations)
 ` + "```python" + `
 # Find mentions and analyze relationship
 mentions_a = grep(context, r'Alice|Bob', context_lines=2)
@@ -769,33 +1136,28 @@ if mentions_a:
 else:
     FINAL("no")
 ` + "```" + `
-`)
-			return sb.String()
+`}
 		}
 	}
 
 	// Default examples for unknown task type
-	sb.WriteString(`### Quick Count
+	return []string{`### Quick Count
 ` + "```python" + `
 import re
 count = len(re.findall(r'pattern', context))
 FINAL(str(count))
 ` + "```" + `
-
-### Quick Search
+`, `### Quick Search
 ` + "```python" + `
 matches = grep(context, r'what_you_need')
 FINAL(matches[0] if matches else "Not found")
 ` + "```" + `
-
-### Analysis (only if reasoning needed)
+`, `### Analysis (only if reasoning needed)
 ` + "```python" + `
 result = llm_call("Analyze this", context, "fast")
 FINAL(result)
 ` + "```" + `
-`)
-
-	return sb.String()
+`}
 }
 
 // generateRLMPrompt generates the user prompt for RLM mode.
@@ -825,12 +1187,49 @@ type RLMConfig struct {
 	// MaxIterations is the maximum number of code execution rounds.
 	MaxIterations int
 
-	// MaxTokensPerCall is the maximum tokens per LLM call.
+	// MinIterations is the fewest rounds an analytical or transformational
+	// task runs before FINAL() is accepted. An earlier FINAL() is held as
+	// provisional and the model is asked to verify it against the context
+	// first. Other task types always accept FINAL() on the first round.
+	// Zero, the default, or one accepts it immediately.
+	MinIterations int
+
+	// MaxTokensPerCall caps the tokens per LLM call. Each call's limit is
+	// sized to the task type and the room left in the context window, up
+	// to this cap. Zero leaves only the adaptive limit.
 	MaxTokensPerCall int
 
+	// ContextWindow is the model's context window in tokens
+	// (default: 200000). A smaller window the client reports in its
+	// meta.Capabilities takes precedence, as does a smaller output limit
+	// over MaxTokensPerCall.
+	ContextWindow int
+
+	// HistoryTokenBudget bounds the conversation sent on each iteration.
+	// Past it, the oldest iteration exchanges are elided; the system
+	// prompt, the original request and the last RecentTurns exchanges are
+	// always sent. Zero sends the whole conversation.
+	HistoryTokenBudget int
+
+	// RecentTurns is how many of the latest exchanges are never elided
+	// (default: 2).
+	RecentTurns int
+
 	// Timeout is the maximum total execution time.
 	Timeout time.Duration
 
+	// MaxTimeout lets the deadline move past Timeout, up to this total,
+	// while the execution makes progress: each iteration that calls
+	// FINAL() or prints new output without error pushes the deadline to
+	// TimeoutExtension past its end. An execution that stops progressing
+	// is still cancelled at its current deadline. Zero, or no more than
+	// Timeout, keeps Timeout a hard limit.
+	MaxTimeout time.Duration
+
+	// TimeoutExtension is how far past a progressing iteration the
+	// deadline moves (default: a quarter of Timeout).
+	TimeoutExtension time.Duration
+
 	// EnableProfiling enables detailed performance profiling.
 	EnableProfiling bool
 
@@ -843,15 +1242,113 @@ type RLMConfig struct {
 	// Use this for streaming progress updates to the UI.
 	// The callback should be fast and non-blocking.
 	OnProgress ProgressCallback
+
+	// VerifyComputation re-derives numeric answers to computational tasks
+	// with a second, independently written Python approach and records any
+	// discrepancy in the result metadata.
+	VerifyComputation bool
+
+	// CostCeiling aborts the execution with ErrBudgetExceeded before any
+	// LLM call or sub-call that would exceed CostCeiling.MaxCost. Zero
+	// disables it; a ceiling already carried by ctx is shared instead.
+	CostCeiling CostCeiling
+
+	// MaxLLMCalls aborts the execution with ErrBudgetExceeded before it
+	// makes more LLM calls than this, iterations and sub-calls alike,
+	// returning the last REPL output as a partial answer. Zero leaves calls
+	// unbounded.
+	MaxLLMCalls int
+
+	// OutputSchema, if set, replaces the prepared prompt's output schema.
+	// A FINAL answer that fails it is sent back to the model once, with
+	// the validation error, before it is accepted; SchemaError on the
+	// result then says why it still fails.
+	OutputSchema *OutputSchema
+
+	// ContextLeak checks for code that prints or returns whole
+	// externalized variables, flagging or truncating the output and telling
+	// the model to use grep() or peek() instead. Off by default.
+	ContextLeak ContextLeakConfig
+
+	// OutputPolicy decides whether printed output or the last
+	// expression's value is the answer when the loop terminates early
+	// without FINAL(). The zero value prefers printed output.
+	OutputPolicy OutputPolicy
 }
 
 // DefaultRLMConfig returns sensible defaults for RLM execution.
 func DefaultRLMConfig() RLMConfig {
 	return RLMConfig{
-		MaxIterations:    10,
-		MaxTokensPerCall: 4096,
-		Timeout:          5 * time.Minute,
+		MaxIterations:      10,
+		MaxTokensPerCall:   8192,
+		ContextWindow:      defaultContextWindow,
+		HistoryTokenBudget: 32000,
+		RecentTurns:        2,
+		Timeout:            5 * time.Minute,
+	}
+}
+
+// defaultContextWindow is the context window assumed when RLMConfig does
+// not set one.
+const defaultContextWindow = 200000
+
+// taskTypeMaxTokens is the per-call output budget for each task type. It
+// applies to every iteration, so each budget leaves room for a full code
+// cell as well as the FINAL answer. Computational and retrieval steps emit
+// short code and a short answer; analytical steps synthesize across the
+// context and need more.
+var taskTypeMaxTokens = map[TaskType]int{
+	TaskTypeComputational:    2048,
+	TaskTypeRetrieval:        2048,
+	TaskTypeTransformational: 4096,
+	TaskTypeAnalytical:       8192,
+	TaskTypeUnknown:          4096,
+}
+
+// maxTokensFor returns the output limit for a call with a prompt of
+// promptTokens: the task type's budget, capped by MaxTokensPerCall and by
+// the room left in the context window after the prompt.
+func (cfg RLMConfig) maxTokensFor(taskType TaskType, promptTokens int) int {
+	limit, ok := taskTypeMaxTokens[taskType]
+	if !ok {
+		limit = taskTypeMaxTokens[TaskTypeUnknown]
+	}
+	if cfg.MaxTokensPerCall > 0 {
+		limit = min(limit, cfg.MaxTokensPerCall)
+	}
+
+	window := cfg.ContextWindow
+	if window <= 0 {
+		window = defaultContextWindow
+	}
+	return max(1, min(limit, window-promptTokens))
+}
+
+// withinLimits returns cfg with its context window and output limit
+// lowered to the ones caps reports, where known.
+func (cfg RLMConfig) withinLimits(caps meta.Capabilities) RLMConfig {
+	if caps.ContextWindow > 0 && (cfg.ContextWindow <= 0 || caps.ContextWindow < cfg.ContextWindow) {
+		cfg.ContextWindow = caps.ContextWindow
+	}
+	if caps.MaxOutputTokens > 0 && (cfg.MaxTokensPerCall <= 0 || caps.MaxOutputTokens < cfg.MaxTokensPerCall) {
+		cfg.MaxTokensPerCall = caps.MaxOutputTokens
 	}
+	return cfg
+}
+
+// minIterationsFor returns the round FINAL() is first accepted on for a
+// task type, never past MaxIterations.
+func (cfg RLMConfig) minIterationsFor(taskType TaskType) int {
+	if taskType != TaskTypeAnalytical && taskType != TaskTypeTransformational {
+		// Lookups and calculations have nothing further to explore, and
+		// unclassified tasks are not held back
+		return 1
+	}
+	floor := max(1, cfg.MinIterations)
+	if cfg.MaxIterations > 0 {
+		floor = min(floor, cfg.MaxIterations)
+	}
+	return floor
 }
 
 // ExecuteRLM executes a prompt in RLM mode with code execution loop.
@@ -864,26 +1361,72 @@ func (w *Wrapper) ExecuteRLM(ctx context.Context, prepared *PreparedPrompt) (*RL
 // ExecuteRLMWithConfig executes RLM with custom configuration.
 func (w *Wrapper) ExecuteRLMWithConfig(ctx context.Context, prepared *PreparedPrompt, cfg RLMConfig) (*RLMExecutionResult, error) {
 	if prepared.Mode != ModeRLM {
-		return nil, fmt.Errorf("not in RLM mode")
+		return nil, fmt.Errorf("%w: not in RLM mode", ErrInvalidMode)
 	}
 
 	if w.replMgr == nil {
-		return nil, fmt.Errorf("REPL manager not configured")
+		return nil, fmt.Errorf("REPL manager %w", ErrNotConfigured)
 	}
 
 	if w.client == nil {
-		return nil, fmt.Errorf("LLM client not configured")
+		return nil, fmt.Errorf("LLM client %w", ErrNotConfigured)
+	}
+
+	return w.runRLM(ctx, prepared, cfg, nil)
+}
+
+// ContinueRLM resumes an execution that ran out of iterations, granting it
+// additionalIterations more. The loop picks up the preserved conversation
+// and the REPL variables left by the earlier run, so exploration is not
+// redone. A handle can be continued once; a continuation that runs out
+// again returns a new handle. Token and cost totals include the earlier
+// runs, and the cost ceiling applies afresh to the continuation.
+func (w *Wrapper) ContinueRLM(ctx context.Context, handle *RLMResumeHandle, additionalIterations int) (*RLMExecutionResult, error) {
+	if handle == nil {
+		return nil, errors.New("continue RLM: no resume handle")
+	}
+	if additionalIterations <= 0 {
+		return nil, fmt.Errorf("continue RLM: additional iterations must be positive, got %d", additionalIterations)
+	}
+	if w.client == nil {
+		return nil, fmt.Errorf("LLM client %w", ErrNotConfigured)
+	}
+	if w.replMgr == nil || w.replMgr != handle.replMgr {
+		return nil, fmt.Errorf("%w: the REPL holding the execution state was replaced", ErrREPLUnavailable)
 	}
+	if !handle.used.CompareAndSwap(false, true) {
+		return nil, errors.New("continue RLM: resume handle already continued")
+	}
+
+	cfg := handle.cfg
+	cfg.MaxIterations = handle.iterations + additionalIterations
+	return w.runRLM(ctx, handle.prepared, cfg, handle)
+}
 
-	// Apply timeout
-	if cfg.Timeout > 0 {
+// runRLM runs the code execution loop, from the start or from where
+// resume left off.
+func (w *Wrapper) runRLM(ctx context.Context, prepared *PreparedPrompt, cfg RLMConfig, resume *RLMResumeHandle) (*RLMExecutionResult, error) {
+	// Apply timeout, extended while the execution makes progress if
+	// allowed
+	var deadline *adaptiveDeadline
+	switch {
+	case cfg.Timeout > 0 && cfg.MaxTimeout > cfg.Timeout:
+		extension := cfg.TimeoutExtension
+		if extension <= 0 {
+			extension = cfg.Timeout / 4
+		}
+		var cancel context.CancelFunc
+		ctx, deadline, cancel = withAdaptiveDeadline(ctx, cfg.Timeout, cfg.MaxTimeout, extension)
+		defer cancel()
+	case cfg.Timeout > 0:
 		var cancel context.CancelFunc
 		ctx, cancel = context.WithTimeout(ctx, cfg.Timeout)
 		defer cancel()
 	}
 
 	result := &RLMExecutionResult{
-		StartTime: time.Now(),
+		StartTime:   time.Now(),
+		Compression: prepared.Compression,
 	}
 
 	// Initialize profiling if enabled
@@ -893,32 +1436,150 @@ func (w *Wrapper) ExecuteRLMWithConfig(ctx context.Context, prepared *PreparedPr
 		result.Profile = profile
 	}
 
+	// Size calls to what the client's models accept, and apply the
+	// execution profile
+	cfg = cfg.withinLimits(meta.CapabilitiesOf(w.client))
+	settings := ProfileSettingsFrom(ctx)
+	cfg = cfg.withProfile(settings)
+
+	// Task type drives early termination and each call's output limit
+	taskType := TaskTypeUnknown
+	if prepared.Classification != nil {
+		taskType = prepared.Classification.Type
+	}
+
 	// Initialize termination tracker if enabled
 	var termTracker *TerminationTracker
 	if cfg.EnableEarlyTermination {
-		taskType := TaskTypeUnknown
-		if prepared.Classification != nil {
-			taskType = prepared.Classification.Type
-		}
 		termTracker = NewTerminationTracker(taskType)
+		if resume != nil && resume.termTracker != nil {
+			termTracker = resume.termTracker
+		}
+		termTracker.SetOutputPolicy(cfg.OutputPolicy)
 	}
 
 	// Initialize progress emitter if callback provided
 	progress := NewProgressEmitter(cfg.OnProgress, cfg.MaxIterations)
 
-	// Clear any previous FINAL output
-	if _, err := w.replMgr.Execute(ctx, "clear_final_output()"); err != nil {
-		slog.Warn("Failed to clear FINAL output", "error", err)
-	}
+	// Record each step for replay, and a fresh run's inputs and model
+	// responses for export as a bundle
+	trace := startExecutionTrace(w.tracer, prepared.FinalPrompt)
+	client := w.client
+	mm, sendImages := meta.AcceptsImages(w.client)
+	sendImages = sendImages && len(prepared.Images) > 0
+	if sendImages {
+		client = meta.WithImagesOnce(mm, prepared.Images)
+	}
+	var capture *executionCapture
+	if trace != nil && resume == nil {
+		result.ExecutionID = trace.rootID
+		capture = w.captures.start(trace.rootID, prepared, cfg)
+		client = &recordingClient{client: client, capture: capture}
+		ctx = withExecutionCapture(ctx, capture)
+	}
+
+	// The conversation is persisted under the execution's ID, a
+	// continuation's under the execution it continues
+	conversationID := result.ExecutionID
+	switch {
+	case resume != nil:
+		conversationID = resume.conversationID
+	case conversationID == "" && w.conversationStore != nil:
+		conversationID = newExecutionID()
+		result.ExecutionID = conversationID
+	}
+
+	// Enforce the cost ceiling and call limit on this loop and on sub-calls
+	// made from the REPL
+	guard := CostGuardFrom(ctx)
+	ceiling := cfg.CostCeiling
+	if cfg.MaxLLMCalls > 0 {
+		ceiling.MaxCalls = cfg.MaxLLMCalls
+	}
+	if ceiling.Enabled() {
+		guard = guard.Child(ceiling)
+		ctx = WithCostGuard(ctx, guard)
+	}
+	startSpent, startCalls := guard.Spent(), guard.Calls()
+
+	// Account every call made for this run, including sub-calls; a resumed
+	// run carries on from the earlier run's usage
+	ctx, usage := meta.TrackUsage(ctx)
+	var priorUsage meta.Usage
+	var priorEstimated int
+
+	// Latest REPL output, returned as a partial answer if the ceiling is hit
+	var partialOutput string
+
+	// Last executed code, shown to the verifier so it can pick a different approach
+	var lastCode string
+
+	// Catches code dumping externalized variables into the conversation
+	leakGuard := newContextLeakGuard(cfg.ContextLeak, prepared)
+
+	// A FINAL() called before the task's minimum iterations, held until the
+	// model has verified it and accepted if it never calls FINAL() again
+	minIterations := cfg.minIterationsFor(taskType)
+	var provisional *FinalOutputResult
+
+	// The schema structured answers are validated against
+	schema := cfg.OutputSchema
+	if schema == nil {
+		schema = prepared.OutputSchema
+	}
+	schemaCorrected := false
+
+	var conversation []conversationMessage
+	startIteration := 0
+
+	// The model's latest turn, and the conversation's length when it
+	// came: the loop may stop before recording it
+	var pendingTurn *conversationMessage
+	pendingAt := 0
+	if resume != nil {
+		// Pick up where the earlier run stopped; the REPL still holds its
+		// variables.
+		conversation = slices.Clone(resume.conversation)
+		startIteration = resume.iterations
+		partialOutput = resume.partialOutput
+		lastCode = resume.lastCode
+		result.TotalTokens = resume.totalTokens
+		startSpent -= resume.totalCost
+		startCalls -= resume.llmCalls
+		priorUsage = resume.usage
+		priorEstimated = resume.estimated
+	} else {
+		// Clear any previous FINAL output
+		if _, err := w.replMgr.Execute(ctx, "clear_final_output()"); err != nil {
+			slog.Warn("Failed to clear FINAL output", "error", err)
+		}
+		if w.contextProvider != nil {
+			if _, err := w.replMgr.Execute(ctx, "clear_context_requests()"); err != nil {
+				slog.Warn("Failed to clear context requests", "error", err)
+			}
+		}
+		if _, err := w.replMgr.Execute(ctx, "clear_builtin_usage()"); err != nil {
+			slog.Warn("Failed to clear builtin usage", "error", err)
+		}
+		w.loadHelpers(ctx)
 
-	// Build initial conversation
-	conversation := []conversationMessage{
-		{Role: "system", Content: prepared.SystemPrompt},
-		{Role: "user", Content: prepared.FinalPrompt},
+		// Build initial conversation, describing a schema the prepared
+		// prompt does not
+		systemPrompt := prepared.SystemPrompt
+		if schema != nil && schema != prepared.OutputSchema {
+			systemPrompt += schema.promptSection()
+		}
+		conversation = []conversationMessage{
+			{Role: "system", Content: systemPrompt},
+			{Role: "user", Content: prepared.FinalPrompt},
+		}
+		if sendImages {
+			conversation[1].Content += "\n\n" + imagesOnceNotice
+		}
 	}
 
 	// Main execution loop
-	for iteration := 0; iteration < cfg.MaxIterations; iteration++ {
+	for iteration := startIteration; iteration < cfg.MaxIterations; iteration++ {
 		result.Iterations = iteration + 1
 
 		// Emit iteration start
@@ -931,25 +1592,52 @@ func (w *Wrapper) ExecuteRLMWithConfig(ctx context.Context, prepared *PreparedPr
 		}
 
 		// Check context cancellation
-		if err := ctx.Err(); err != nil {
-			result.Error = fmt.Sprintf("context cancelled: %v", err)
+		if ctx.Err() != nil {
+			result.Error = fmt.Sprintf("context cancelled: %v", context.Cause(ctx))
 			if iterProfile != nil {
 				profile.EndIteration(iterProfile)
 			}
 			break
 		}
 
-		// Send conversation to LLM (timed)
-		prompt := w.formatConversation(conversation)
+		// Reload externalized files that changed on disk, and say so in the
+		// pending user turn so exchanges stay paired for trimHistory
+		if refreshed := w.refreshContext(ctx, prepared); len(refreshed) > 0 {
+			result.ContextRefreshes = append(result.ContextRefreshes, refreshed...)
+			notice := RefreshNotice(refreshed)
+			if last := len(conversation) - 1; conversation[last].Role == "user" {
+				conversation[last].Content += "\n\n" + notice
+			} else {
+				conversation = append(conversation, conversationMessage{Role: "user", Content: notice})
+			}
+		}
+
+		// Send conversation to LLM (timed), eliding old exchanges past the
+		// history budget
+		history, elided := trimHistory(conversation, cfg.HistoryTokenBudget, cfg.RecentTurns)
+		prompt := w.formatConversation(history)
+		promptTokens := estimateTokens(prompt)
+		if elided > 0 {
+			result.HistoryElidedTurns = elided
+			result.HistoryElidedTokens += estimateTokens(w.formatConversation(conversation)) - promptTokens
+		}
+		if err := guard.Allow(guard.Ceiling().Cost(promptTokens, 0)); err != nil {
+			result.abortAtCeiling(err, partialOutput)
+			progress.EmitError(iteration+1, err.Error())
+			if iterProfile != nil {
+				profile.EndIteration(iterProfile)
+			}
+			break
+		}
 		progress.EmitLLMStart(iteration + 1)
 		llmStart := time.Now()
-		response, err := w.client.Complete(ctx, prompt, cfg.MaxTokensPerCall)
+		response, callUsage, err := meta.CompleteWithUsage(ctx, client, prompt, cfg.maxTokensFor(taskType, promptTokens), estimateTokens)
 		llmDur := time.Since(llmStart)
 		if iterProfile != nil {
 			iterProfile.LLMCallDur = llmDur
 		}
 		if err != nil {
-			result.Error = fmt.Sprintf("LLM call failed: %v", err)
+			result.setError(fmt.Errorf("%w: %w", ErrLLMCall, cancellationCause(ctx, err)))
 			progress.EmitError(iteration+1, err.Error())
 			if iterProfile != nil {
 				profile.EndIteration(iterProfile)
@@ -957,14 +1645,15 @@ func (w *Wrapper) ExecuteRLMWithConfig(ctx context.Context, prepared *PreparedPr
 			break
 		}
 
-		// Estimate tokens used
-		promptTokens := estimateTokens(prompt)
-		completionTokens := estimateTokens(response)
+		// Account what the provider reported, else the estimate
+		promptTokens, completionTokens := callUsage.PromptTokens, callUsage.CompletionTokens
 		result.TotalTokens += promptTokens + completionTokens
+		guard.Charge(guard.Ceiling().Cost(promptTokens, completionTokens))
 		if iterProfile != nil {
 			iterProfile.PromptTokens = promptTokens
 			iterProfile.CompletionTokens = completionTokens
 		}
+		trace.response(iteration+1, response, promptTokens+completionTokens, llmDur)
 
 		// Extract Python code from response (timed as parsing)
 		parseStart := time.Now()
@@ -972,6 +1661,10 @@ func (w *Wrapper) ExecuteRLMWithConfig(ctx context.Context, prepared *PreparedPr
 		if iterProfile != nil {
 			iterProfile.ParseDur = time.Since(parseStart)
 		}
+		pendingTurn, pendingAt = &conversationMessage{Role: "assistant", Content: response}, len(conversation)
+		if code != "" {
+			pendingTurn.Content = "```python\n" + code + "\n```"
+		}
 
 		// Emit LLM end with code detection
 		progress.EmitLLMEnd(iteration+1, llmDur, promptTokens+completionTokens, code != "")
@@ -1006,10 +1699,15 @@ func (w *Wrapper) ExecuteRLMWithConfig(ctx context.Context, prepared *PreparedPr
 		}
 
 		// Execute the code in REPL (timed)
+		lastCode = code
 		progress.EmitREPLStart(iteration+1, code)
 		replStart := time.Now()
 		execResult, err := w.replMgr.Execute(ctx, code)
 		replDur := time.Since(replStart)
+		w.memoryPressure.used(code)
+		execResult, leaks := leakGuard.check(iteration+1, execResult)
+		result.ContextLeaks = append(result.ContextLeaks, leaks...)
+		trace.code(iteration+1, code, execResult, err, replDur)
 		if iterProfile != nil {
 			iterProfile.REPLExecDur = replDur
 			if execResult != nil {
@@ -1020,7 +1718,7 @@ func (w *Wrapper) ExecuteRLMWithConfig(ctx context.Context, prepared *PreparedPr
 			}
 		}
 		if err != nil {
-			result.Error = fmt.Sprintf("REPL execution failed: %v", err)
+			result.setError(fmt.Errorf("REPL execution failed: %w", cancellationCause(ctx, err)))
 			progress.EmitError(iteration+1, err.Error())
 			if iterProfile != nil {
 				profile.EndIteration(iterProfile)
@@ -1040,22 +1738,76 @@ func (w *Wrapper) ExecuteRLMWithConfig(ctx context.Context, prepared *PreparedPr
 		if err != nil {
 			slog.Warn("Failed to check FINAL output", "error", err)
 		}
+		deadline.observe(hasFinal, execResult.Output, execResult.Error)
 
 		if hasFinal {
 			// Get the final output
 			finalOutput, err := w.GetFinalOutputWithMetadata(ctx)
 			if err != nil {
-				result.Error = fmt.Sprintf("failed to get FINAL output: %v", err)
+				result.setError(fmt.Errorf("failed to get FINAL output: %w", err))
 				progress.EmitError(iteration+1, err.Error())
 				if iterProfile != nil {
 					profile.EndIteration(iterProfile)
 				}
 				break
 			}
+
+			// Too early to accept: have the model check it against the context
+			if iteration+1 < minIterations && finalOutput != nil {
+				provisional = finalOutput
+				partialOutput = finalOutput.Content
+				result.ProvisionalFinals++
+				if _, err := w.replMgr.Execute(ctx, "clear_final_output()"); err != nil {
+					slog.Warn("Failed to clear FINAL output", "error", err)
+				}
+				conversation = append(conversation,
+					conversationMessage{Role: "assistant", Content: "```python\n" + code + "\n```"},
+					conversationMessage{Role: "user", Content: buildVerifyFinalPrompt(finalOutput.Content)},
+				)
+				var iterDur time.Duration
+				if iterProfile != nil {
+					iterDur = time.Since(iterProfile.StartTime)
+					profile.EndIteration(iterProfile)
+				}
+				progress.EmitIterationEnd(iteration+1, iterDur, true)
+				continue
+			}
+
+			// Send an answer that fails the output schema back for one
+			// correction, holding it in case the model never resubmits
+			if finalOutput != nil && schema != nil {
+				result.SchemaError = ""
+				if err := schema.Validate(finalOutput.Content); err != nil {
+					result.SchemaError = err.Error()
+					if !schemaCorrected && iteration+1 < cfg.MaxIterations {
+						schemaCorrected = true
+						provisional = finalOutput
+						partialOutput = finalOutput.Content
+						result.SchemaCorrections++
+						if _, err := w.replMgr.Execute(ctx, "clear_final_output()"); err != nil {
+							slog.Warn("Failed to clear FINAL output", "error", err)
+						}
+						conversation = append(conversation,
+							conversationMessage{Role: "assistant", Content: "```python\n" + code + "\n```"},
+							conversationMessage{Role: "user", Content: buildSchemaCorrectionPrompt(finalOutput.Content, err, schema)},
+						)
+						var iterDur time.Duration
+						if iterProfile != nil {
+							iterDur = time.Since(iterProfile.StartTime)
+							profile.EndIteration(iterProfile)
+						}
+						progress.EmitIterationEnd(iteration+1, iterDur, true)
+						continue
+					}
+				}
+			}
+
 			if finalOutput != nil {
 				result.FinalOutput = finalOutput.Content
 				result.FinalType = finalOutput.Type
 				result.FinalMetadata = finalOutput.Metadata
+				result.Provenance = finalOutput.Provenance
+				result.Sanitization = finalOutput.Sanitization
 				progress.EmitFinal(iteration+1, finalOutput.Content)
 			}
 			if iterProfile != nil {
@@ -1067,24 +1819,36 @@ func (w *Wrapper) ExecuteRLMWithConfig(ctx context.Context, prepared *PreparedPr
 			break
 		}
 
+		if output := strings.TrimSpace(execResult.Output); output != "" {
+			partialOutput = output
+		}
+
+		// A sub-call refused by the cost ceiling ends the execution
+		if err := guard.Err(); err != nil {
+			result.abortAtCeiling(err, partialOutput)
+			progress.EmitError(iteration+1, err.Error())
+			if iterProfile != nil {
+				profile.EndIteration(iterProfile)
+			}
+			break
+		}
+
 		// Check for early termination (if enabled and FINAL not called)
 		if termTracker != nil {
 			iterResult := &IterationResult{
 				HasFinal:     false,
 				CodeExecuted: code,
 				REPLOutput:   execResult.Output,
+				ReturnValue:  execResult.ReturnVal,
 				REPLError:    execResult.Error,
 				Iteration:    iteration + 1,
 			}
 
 			termCheck := termTracker.CheckTermination(iterResult)
 			if termCheck.ShouldTerminate {
-				// Use REPL output as final answer if we're terminating early
-				if execResult.Output != "" {
-					result.FinalOutput = strings.TrimSpace(execResult.Output)
-				} else if execResult.ReturnVal != "" && execResult.ReturnVal != "None" {
-					result.FinalOutput = strings.TrimSpace(execResult.ReturnVal)
-				}
+				// Take the answer from the source the output policy
+				// makes authoritative
+				result.FinalOutput = cfg.OutputPolicy.resultAnswer(execResult)
 				result.EarlyTerminated = true
 				result.TerminationReason = termCheck.Reason
 
@@ -1100,8 +1864,16 @@ func (w *Wrapper) ExecuteRLMWithConfig(ctx context.Context, prepared *PreparedPr
 			}
 		}
 
-		// Build execution feedback for next iteration
+		// Build execution feedback for next iteration, loading any context
+		// the code asked for
 		feedback := w.buildExecutionFeedback(execResult)
+		if len(leaks) > 0 {
+			feedback += "\n\n" + ContextLeakNotice(leaks)
+		}
+		if requests := w.fulfillContextRequests(ctx, iteration+1, prepared); len(requests) > 0 {
+			result.ContextRequests = append(result.ContextRequests, requests...)
+			feedback += "\n\n" + ContextRequestNotice(requests)
+		}
 
 		conversation = append(conversation,
 			conversationMessage{Role: "assistant", Content: "```python\n" + code + "\n```"},
@@ -1125,7 +1897,59 @@ func (w *Wrapper) ExecuteRLMWithConfig(ctx context.Context, prepared *PreparedPr
 			"return_val", truncate(execResult.ReturnVal, 100))
 	}
 
+	// Count the builtins this run's code called, on top of the earlier run's
+	runUsage := w.readBuiltinUsage(ctx)
+	w.builtinUsage.record(taskType, runUsage, resume == nil)
+	result.BuiltinUsage = make(BuiltinUsage)
+	if resume != nil {
+		result.BuiltinUsage.add(resume.builtinUsage)
+	}
+	result.BuiltinUsage.add(runUsage)
+
+	// A provisional answer the model never revised stands
+	if provisional != nil && result.FinalOutput == "" && result.Error == "" {
+		result.FinalOutput = provisional.Content
+		result.FinalType = provisional.Type
+		result.FinalMetadata = provisional.Metadata
+		result.Provenance = provisional.Provenance
+		result.Sanitization = provisional.Sanitization
+		result.TerminationReason = "provisional FINAL() accepted"
+		if schema != nil {
+			result.SchemaError = ""
+			if err := schema.Validate(provisional.Content); err != nil {
+				result.SchemaError = err.Error()
+			}
+		}
+		progress.EmitFinal(result.Iterations, provisional.Content)
+	}
+
+	// Independently re-derive computational answers
+	if cfg.VerifyComputation && result.FinalOutput != "" && !result.Partial &&
+		prepared.Classification != nil && prepared.Classification.Type == TaskTypeComputational {
+		verification, tokens := w.verifyNumericAnswer(ctx, prepared.FinalPrompt, lastCode, result.FinalOutput, cfg.MaxTokensPerCall)
+		if verification != nil {
+			result.TotalTokens += tokens
+			result.Verification = verification
+			if result.FinalMetadata == nil {
+				result.FinalMetadata = make(map[string]string)
+			}
+			result.FinalMetadata["verification"] = verification.Status()
+			if verification.Discrepancy {
+				slog.Warn("Computational answer failed independent verification",
+					"original", verification.Original,
+					"independent", verification.Independent)
+			}
+		}
+	}
+
+	result.TotalCost = guard.Spent() - startSpent
+	result.TimeoutExtended = deadline.extended(cfg.Timeout)
+	result.LLMCalls = guard.Calls() - startCalls
 	result.Duration = time.Since(result.StartTime)
+	total := priorUsage.Add(usage.Total())
+	result.InputTokens = total.PromptTokens
+	result.OutputTokens = total.CompletionTokens
+	result.EstimatedTokens = priorEstimated + usage.Estimated().Total()
 
 	// Finalize profiling
 	if profile != nil {
@@ -1133,10 +1957,45 @@ func (w *Wrapper) ExecuteRLMWithConfig(ctx context.Context, prepared *PreparedPr
 		profile.Finalize()
 	}
 
-	// If we exhausted iterations without FINAL, note it
-	if result.FinalOutput == "" && result.Error == "" && result.Iterations >= cfg.MaxIterations {
-		result.Error = fmt.Sprintf("max iterations (%d) reached without FINAL() call", cfg.MaxIterations)
+	// If we exhausted iterations without FINAL, answer with the last output
+	// if the profile accepts partial answers, else note it and keep what a
+	// continuation needs
+	exhausted := result.FinalOutput == "" && result.Error == "" && result.Iterations >= cfg.MaxIterations
+	if exhausted && settings.AcceptPartial && partialOutput != "" {
+		result.FinalOutput = partialOutput
+		result.Partial = true
+		result.TerminationReason = "max iterations reached, partial answer accepted"
+		progress.EmitFinal(result.Iterations, partialOutput)
+	} else if exhausted {
+		result.setError(&MaxIterationsError{Iterations: cfg.MaxIterations})
+		result.Resume = &RLMResumeHandle{
+			prepared:       prepared,
+			cfg:            cfg,
+			replMgr:        w.replMgr,
+			conversation:   conversation,
+			iterations:     result.Iterations,
+			termTracker:    termTracker,
+			partialOutput:  partialOutput,
+			lastCode:       lastCode,
+			totalTokens:    result.TotalTokens,
+			totalCost:      result.TotalCost,
+			llmCalls:       result.LLMCalls,
+			usage:          total,
+			estimated:      result.EstimatedTokens,
+			builtinUsage:   result.BuiltinUsage,
+			conversationID: conversationID,
+		}
+	}
+
+	trace.final(result)
+	if capture != nil {
+		capture.finish(result)
+	}
+	transcript := conversation
+	if pendingTurn != nil && len(conversation) == pendingAt {
+		transcript = append(slices.Clip(conversation), *pendingTurn)
 	}
+	w.saveConversation(ctx, conversationID, prepared, transcript, result)
 
 	// Emit completion
 	progress.EmitComplete(result.Iterations, result.Duration, result.FinalOutput, result.EarlyTerminated, result.TerminationReason)
@@ -1144,6 +2003,19 @@ func (w *Wrapper) ExecuteRLMWithConfig(ctx context.Context, prepared *PreparedPr
 	return result, nil
 }
 
+// refreshContext reloads the prepared context's file-backed variables if
+// their files changed and a check is due.
+func (w *Wrapper) refreshContext(ctx context.Context, prepared *PreparedPrompt) []ContextRefresh {
+	if w.contextLoader == nil || prepared.LoadedContext == nil {
+		return nil
+	}
+	refreshed, err := w.contextLoader.RefreshIfDue(ctx, prepared.LoadedContext)
+	if err != nil {
+		slog.Warn("Failed to refresh context", "error", err)
+	}
+	return refreshed
+}
+
 // conversationMessage represents a message in the RLM conversation.
 type conversationMessage struct {
 	Role    string
@@ -1175,6 +2047,56 @@ func (w *Wrapper) formatConversation(messages []conversationMessage) string {
 	return sb.String()
 }
 
+// trimHistory returns the messages to send when the conversation must fit
+// in budget tokens, and how many exchanges it elided. The system prompt and
+// original request (the first two messages) and the last recent exchanges
+// are kept; older exchanges (assistant/user pairs) are dropped oldest first
+// and replaced by a note. The budget is exceeded only when the kept
+// messages alone do not fit.
+func trimHistory(messages []conversationMessage, budget, recent int) ([]conversationMessage, int) {
+	const head = 2
+	if budget <= 0 || len(messages) <= head {
+		return messages, 0
+	}
+	if recent <= 0 {
+		recent = 2
+	}
+
+	tokens := make([]int, len(messages))
+	total := 0
+	for i, msg := range messages {
+		tokens[i] = estimateTokens(msg.Content) + 4 // role framing
+		total += tokens[i]
+	}
+	if total <= budget {
+		return messages, 0
+	}
+
+	// Leave room for the elision note.
+	budget -= 50
+
+	turns := (len(messages) - head) / 2
+	elided := 0
+	for elided < turns-recent && total > budget {
+		first := head + 2*elided
+		total -= tokens[first] + tokens[first+1]
+		elided++
+	}
+	if elided == 0 {
+		return messages, 0
+	}
+
+	trimmed := make([]conversationMessage, 0, len(messages)-2*elided+1)
+	trimmed = append(trimmed, messages[:head]...)
+	trimmed = append(trimmed, conversationMessage{
+		Role: "user",
+		Content: fmt.Sprintf("[%d earlier iteration(s) omitted to save context. "+
+			"Variables they defined are still available in the REPL.]", elided),
+	})
+	trimmed = append(trimmed, messages[head+2*elided:]...)
+	return trimmed, elided
+}
+
 // buildExecutionFeedback creates feedback message from REPL execution.
 func (w *Wrapper) buildExecutionFeedback(result *repl.ExecuteResult) string {
 	var sb strings.Builder
@@ -1190,22 +2112,44 @@ func (w *Wrapper) buildExecutionFeedback(result *repl.ExecuteResult) string {
 	}
 
 	if result.Output != "" {
-		sb.WriteString("Output:\n```\n")
-		sb.WriteString(truncate(result.Output, 2000))
+		output, marker := truncateFeedbackOutput(result.Output, maxFeedbackOutput)
+		sb.WriteString("Printed output:\n```\n")
+		sb.WriteString(output)
 		sb.WriteString("\n```\n")
+		if marker != "" {
+			sb.WriteString(marker)
+			sb.WriteString("\n")
+		}
 	}
 
 	if result.ReturnVal != "" && result.ReturnVal != "None" {
-		sb.WriteString("Return value: ")
+		sb.WriteString("Value of the last expression: ")
 		sb.WriteString(truncate(result.ReturnVal, 500))
 		sb.WriteString("\n")
 	}
 
-	sb.WriteString("\nContinue exploring the context or call FINAL(response) when you have your answer.")
+	if result.Output == "" && (result.ReturnVal == "" || result.ReturnVal == "None") {
+		sb.WriteString("No output.\n")
+	}
+
+	sb.WriteString("\nPrinted output and expression values are not answers: continue exploring the context or call FINAL(response) when you have your answer.")
 
 	return sb.String()
 }
 
+// buildVerifyFinalPrompt asks the model to check a FINAL() answer given
+// before the minimum iterations against the context before resubmitting it.
+func buildVerifyFinalPrompt(answer string) string {
+	var sb strings.Builder
+	sb.WriteString("You called FINAL() with:\n```\n")
+	sb.WriteString(truncate(answer, 2000))
+	sb.WriteString("\n```\n")
+	sb.WriteString("This answer is provisional. Before it is accepted, verify it against the context: ")
+	sb.WriteString("use peek() and grep() to find the evidence it rests on and check nothing contradicts it. ")
+	sb.WriteString("Then call FINAL() again with the confirmed or corrected answer.")
+	return sb.String()
+}
+
 // extractPythonCode extracts Python code from an LLM response.
 // Looks for ```python blocks or bare code that looks like Python.
 func extractPythonCode(response string) string {
@@ -1299,24 +2243,53 @@ type RLMExecutionResult struct {
 	// FinalMetadata contains additional metadata from the final output.
 	FinalMetadata map[string]string
 
+	// Provenance lists the context regions read via peek() and grep() that
+	// the final output was derived from.
+	Provenance []ContextRef
+
 	// Iterations is how many code execution rounds occurred.
 	Iterations int
 
 	// TotalTokens is the total tokens used across all calls.
 	TotalTokens int
 
-	// TotalCost is the estimated total cost.
+	// InputTokens and OutputTokens split the usage of every call, as
+	// reported by the provider or estimated where it reported none;
+	// EstimatedTokens is how much of it is estimated.
+	InputTokens     int
+	OutputTokens    int
+	EstimatedTokens int
+
+	// TotalCost is the estimated total cost, including sub-calls. It is only
+	// tracked when a cost ceiling is set, since pricing comes from it.
 	TotalCost float64
 
+	// LLMCalls counts the LLM calls made, including sub-calls. It is only
+	// tracked under a cost ceiling or call limit.
+	LLMCalls int
+
+	// Partial is true when the cost ceiling or call limit aborted the
+	// execution, or its iterations ran out under a profile accepting
+	// partial answers; FinalOutput then holds the last REPL output instead
+	// of a FINAL() answer.
+	Partial bool
+
 	// StartTime is when execution started.
 	StartTime time.Time
 
+	// TimeoutExtended is how far progress moved the deadline past
+	// RLMConfig.Timeout, zero without RLMConfig.MaxTimeout.
+	TimeoutExtended time.Duration
+
 	// Duration is total execution time.
 	Duration time.Duration
 
 	// Error is set if execution failed.
 	Error string
 
+	// Err is the error behind Error, for matching with errors.Is.
+	Err error
+
 	// Note contains any additional information.
 	Note string
 
@@ -1328,6 +2301,105 @@ type RLMExecutionResult struct {
 
 	// TerminationReason explains why the loop terminated.
 	TerminationReason string
+
+	// Verification is the independent check of a numeric answer, set when
+	// RLMConfig.VerifyComputation is enabled for a computational task.
+	Verification *NumericVerification
+
+	// Compression is the prepared prompt's context compression, nil when
+	// none was applied. Token counts are of what was sent, so they already
+	// reflect it.
+	Compression *CompressionStats
+
+	// ProvisionalFinals is how many FINAL() calls came before
+	// RLMConfig.MinIterations and were sent back for verification.
+	ProvisionalFinals int
+
+	// HistoryElidedTurns is how many iteration exchanges were left out of
+	// the last prompt to stay within RLMConfig.HistoryTokenBudget, and
+	// HistoryElidedTokens the estimated tokens this saved across all calls.
+	HistoryElidedTurns  int
+	HistoryElidedTokens int
+
+	// ContextRefreshes lists the variables reloaded during execution
+	// because their files changed on disk.
+	ContextRefreshes []ContextRefresh
+
+	// ContextRequests lists the context the model asked for with
+	// request_context() and what was loaded for it.
+	ContextRequests []ContextRequest
+
+	// BuiltinUsage counts the calls the execution's code made to each REPL
+	// builtin, e.g. how often it used grep() versus llm_call().
+	BuiltinUsage BuiltinUsage
+
+	// ContextLeaks lists the outputs that reproduced an externalized
+	// variable, when RLMConfig.ContextLeak is on.
+	ContextLeaks []ContextLeak
+
+	// SchemaCorrections is how many FINAL answers failed the output schema
+	// and were sent back for correction, and SchemaError why the accepted
+	// answer still fails it, empty if it passes or there is no schema.
+	SchemaCorrections int
+	SchemaError       string
+
+	// Sanitization says what OutputSanitize changed in FINAL() output,
+	// nil if nothing.
+	Sanitization *OutputSanitizeReport
+
+	// Resume is set when the loop ran out of iterations without FINAL();
+	// pass it to Wrapper.ContinueRLM to grant more.
+	Resume *RLMResumeHandle
+
+	// ExecutionID is the ID of the execution's trace, to pass to
+	// Service.ExportExecutionBundle, and of its persisted conversation.
+	// Empty when tracing and conversation persistence are off, or the run
+	// was a continuation.
+	ExecutionID string
+}
+
+// RLMResumeHandle holds the state of an RLM execution that ran out of
+// iterations: the conversation so far, with the REPL it ran against still
+// holding its variables and externalized context.
+type RLMResumeHandle struct {
+	prepared      *PreparedPrompt
+	cfg           RLMConfig
+	replMgr       *repl.Manager
+	conversation  []conversationMessage
+	iterations    int
+	termTracker   *TerminationTracker
+	partialOutput string
+	lastCode      string
+	totalTokens   int
+	totalCost     float64
+	llmCalls      int
+	usage         meta.Usage
+	estimated     int
+	builtinUsage  BuiltinUsage
+
+	// Execution the conversation is persisted under
+	conversationID string
+
+	used atomic.Bool
+}
+
+// Iterations returns how many iterations the execution has run so far.
+func (h *RLMResumeHandle) Iterations() int {
+	return h.iterations
+}
+
+// setError records a failure in both Err and Error.
+func (r *RLMExecutionResult) setError(err error) {
+	r.Err = err
+	r.Error = err.Error()
+}
+
+// abortAtCeiling records a cost ceiling abort with the best partial answer.
+func (r *RLMExecutionResult) abortAtCeiling(err error, partial string) {
+	r.setError(err)
+	r.FinalOutput = partial
+	r.Partial = true
+	r.TerminationReason = "cost ceiling reached"
 }
 
 // FinalOutputResult contains the result from FINAL() including metadata.
@@ -1335,12 +2407,30 @@ type FinalOutputResult struct {
 	Content  string            `json:"content"`
 	Type     string            `json:"type"` // "text", "json", "code", "markdown"
 	Metadata map[string]string `json:"metadata,omitempty"`
+
+	// Provenance lists the context regions the output was derived from.
+	Provenance []ContextRef `json:"provenance,omitempty"`
+
+	// Sanitization says what sanitizing changed in Content, nil if nothing.
+	Sanitization *OutputSanitizeReport `json:"sanitization,omitempty"`
 }
 
 // GetFinalOutput retrieves the FINAL() output from the REPL.
 func (w *Wrapper) GetFinalOutput(ctx context.Context) (string, error) {
+	output, err := w.rawFinalOutput(ctx)
+	if err != nil || output == "" {
+		return output, err
+	}
+	if w.outputSanitize.appliesTo("text") {
+		output, _ = SanitizeOutput(output, w.outputSanitize)
+	}
+	return output, nil
+}
+
+// rawFinalOutput retrieves the FINAL() output as a string, unsanitized.
+func (w *Wrapper) rawFinalOutput(ctx context.Context) (string, error) {
 	if w.replMgr == nil {
-		return "", fmt.Errorf("REPL not available")
+		return "", ErrREPLUnavailable
 	}
 
 	result, err := w.replMgr.Execute(ctx, "get_final_output()")
@@ -1355,49 +2445,90 @@ func (w *Wrapper) GetFinalOutput(ctx context.Context) (string, error) {
 	}
 
 	// Strip quotes from string representation
-	output = strings.Trim(output, "'\"")
-	return output, nil
+	return strings.Trim(output, "'\""), nil
 }
 
 // GetFinalOutputWithMetadata retrieves FINAL() output with type and metadata.
 func (w *Wrapper) GetFinalOutputWithMetadata(ctx context.Context) (*FinalOutputResult, error) {
 	if w.replMgr == nil {
-		return nil, fmt.Errorf("REPL not available")
+		return nil, ErrREPLUnavailable
 	}
 
-	// Get the full metadata dict
-	result, err := w.replMgr.Execute(ctx, "get_final_metadata()")
+	// The REPL serializes the metadata itself, so content survives intact
+	result, err := w.replMgr.Execute(ctx, "print(get_final_metadata_json())")
 	if err != nil {
 		return nil, err
 	}
 
-	if result.ReturnVal == "None" || result.ReturnVal == "" {
+	jsonStr := strings.TrimSpace(result.Output)
+	if result.Error != "" {
+		// Older bootstraps lack get_final_metadata_json; repair the dict repr
+		jsonStr, err = w.legacyFinalMetadata(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if jsonStr == "null" || jsonStr == "" {
 		return nil, nil
 	}
 
-	// Parse the JSON dict representation
-	// Python returns: {'content': '...', 'type': '...', 'metadata': {...}}
-	// We need to convert Python dict syntax to JSON
-	jsonStr := result.ReturnVal
-	jsonStr = strings.ReplaceAll(jsonStr, "'", "\"")
-	jsonStr = strings.ReplaceAll(jsonStr, "None", "null")
-	jsonStr = strings.ReplaceAll(jsonStr, "True", "true")
-	jsonStr = strings.ReplaceAll(jsonStr, "False", "false")
-
 	var output FinalOutputResult
 	if err := json.Unmarshal([]byte(jsonStr), &output); err != nil {
 		// Fallback to simple string extraction
-		content, _ := w.GetFinalOutput(ctx)
-		return &FinalOutputResult{Content: content, Type: "text"}, nil
+		slog.Warn("Failed to parse FINAL metadata", "error", err)
+		content, _ := w.rawFinalOutput(ctx)
+		output = FinalOutputResult{Content: content, Type: "text"}
+	}
+	if w.outputSanitize.appliesTo(output.Type) {
+		w.sanitizeFinal(&output)
+	}
+
+	provenance, err := w.GetFinalProvenance(ctx)
+	if err != nil {
+		slog.Warn("Failed to get FINAL provenance", "error", err)
 	}
+	output.Provenance = provenance
 
 	return &output, nil
 }
 
+// sanitizeFinal sanitizes the content of output, recording what changed.
+func (w *Wrapper) sanitizeFinal(output *FinalOutputResult) {
+	content, report := SanitizeOutput(output.Content, w.outputSanitize)
+	if !report.Changed() {
+		return
+	}
+	slog.Info("Sanitized FINAL output",
+		"escapes_removed", report.EscapesRemoved,
+		"controls_escaped", report.ControlsEscaped,
+		"repetitions_elided", report.RepetitionsElided,
+		"truncated", report.Truncated)
+	output.Content = content
+	output.Sanitization = &report
+}
+
+// legacyFinalMetadata reads the FINAL metadata dict repr and repairs it into
+// JSON. It returns "null" if FINAL() has not been called.
+func (w *Wrapper) legacyFinalMetadata(ctx context.Context) (string, error) {
+	result, err := w.replMgr.Execute(ctx, "get_final_metadata()")
+	if err != nil {
+		return "", err
+	}
+	if result.ReturnVal == "None" || result.ReturnVal == "" {
+		return "null", nil
+	}
+	repaired, err := repairPythonLiteral(result.ReturnVal)
+	if err != nil {
+		// Leave it to the caller's plain-text fallback
+		return result.ReturnVal, nil
+	}
+	return repaired, nil
+}
+
 // HasFinalOutput checks if FINAL() has been called.
 func (w *Wrapper) HasFinalOutput(ctx context.Context) (bool, error) {
 	if w.replMgr == nil {
-		return false, fmt.Errorf("REPL not available")
+		return false, ErrREPLUnavailable
 	}
 
 	result, err := w.replMgr.Execute(ctx, "has_final_output()")
@@ -1408,10 +2539,19 @@ func (w *Wrapper) HasFinalOutput(ctx context.Context) (bool, error) {
 	return result.ReturnVal == "True", nil
 }
 
+// LoadedContext returns the contexts externalized in the REPL and not yet
+// cleared or evicted, nil if there are none.
+func (w *Wrapper) LoadedContext() *LoadedContext {
+	if w == nil || w.contextLoader == nil {
+		return nil
+	}
+	return w.contextLoader.Loaded()
+}
+
 // ClearContext clears all externalized context from the REPL.
 func (w *Wrapper) ClearContext(ctx context.Context) error {
 	if w.replMgr == nil {
-		return fmt.Errorf("REPL not available")
+		return ErrREPLUnavailable
 	}
 
 	// Get list of variables and clear them
@@ -1427,33 +2567,53 @@ func (w *Wrapper) ClearContext(ctx context.Context) error {
 	// Build list of variable names to delete
 	var names []string
 	for _, v := range vars.Variables {
-		// Don't delete built-in RLM functions
-		if !isBuiltinRLMVar(v.Name) {
+		// Don't delete built-in RLM functions or custom helpers
+		if !isBuiltinRLMVar(v.Name) && !w.isHelper(v.Name) {
 			names = append(names, v.Name)
 		}
 	}
 
 	if len(names) > 0 {
+		w.memoryPressure.forget(names)
 		return w.contextLoader.ClearContext(ctx, names)
 	}
 	return nil
 }
 
+// builtinRLMVars are the names the REPL bootstrap defines for RLM code.
+var builtinRLMVars = map[string]bool{
+	"peek": true, "grep": true, "partition": true,
+	"partition_by_lines": true, "extract_functions": true,
+	"count_tokens_approx": true, "llm_call": true,
+	"llm_batch": true, "FINAL": true, "RLMContext": true,
+	"get_final_output": true, "clear_final_output": true,
+	"summarize": true, "map_reduce": true, "find_relevant": true,
+	"FINAL_VAR": true, "FINAL_JSON": true, "FINAL_CODE": true,
+	"FinalOutput": true, "get_final_metadata": true,
+	"get_final_metadata_json": true, "get_final_provenance": true,
+	"has_final_output": true, "request_context": true,
+	"get_context_requests_json": true, "clear_context_requests": true,
+	"get_builtin_usage_json": true, "clear_builtin_usage": true,
+	"disable_callbacks": true, "enable_callbacks": true,
+	"MemoryNode": true, "memory_query": true, "memory_add_fact": true,
+	"memory_add_experience": true, "memory_get_context": true,
+	"memory_relate": true, "disable_memory": true, "enable_memory": true,
+	"verify_claim": true, "verify_claims": true, "audit_trace": true,
+	"disable_hallucination_detection": true, "enable_hallucination_detection": true,
+	"uv": true, "ruff": true, "ty": true, "lint": true, "fmt": true, "typecheck": true,
+	"re": true, "json": true, "ast": true, "pathlib": true, "itertools": true,
+	"collections": true, "Path": true,
+}
+
 func isBuiltinRLMVar(name string) bool {
-	builtins := map[string]bool{
-		"peek": true, "grep": true, "partition": true,
-		"partition_by_lines": true, "extract_functions": true,
-		"count_tokens_approx": true, "llm_call": true,
-		"llm_batch": true, "FINAL": true, "RLMContext": true,
-		"get_final_output": true, "clear_final_output": true,
-	}
-	return builtins[name]
+	return builtinRLMVars[name]
 }
 
-// compressContexts compresses multiple context sources using the compression manager.
-func (w *Wrapper) compressContexts(ctx context.Context, contexts []ContextSource, query string, totalTokens int) (*compress.PreparedContext, error) {
+// compressContexts compresses multiple context sources using the compression manager
+// to fit within threshold. The manager tightens the budget further when depth is above zero.
+func (w *Wrapper) compressContexts(ctx context.Context, contexts []ContextSource, query string, totalTokens, threshold, depth int) (*compress.PreparedContext, error) {
 	if w.compressionMgr == nil {
-		return nil, fmt.Errorf("compression manager not initialized")
+		return nil, fmt.Errorf("compression manager %w", ErrNotConfigured)
 	}
 
 	// Convert ContextSource to compress.ContextChunk
@@ -1474,12 +2634,12 @@ func (w *Wrapper) compressContexts(ctx context.Context, contexts []ContextSource
 	}
 
 	// Target: compress to fit within threshold, aiming for 30% of original
-	targetBudget := w.compressionThreshold
+	targetBudget := threshold
 	if targetBudget > totalTokens/3 {
 		targetBudget = totalTokens / 3
 	}
 
-	return w.compressionMgr.PrepareContext(ctx, chunks, query, targetBudget)
+	return w.compressionMgr.PrepareContextAtDepth(ctx, chunks, query, targetBudget, depth)
 }
 
 // applyCompressionResults updates context sources with compressed content.
@@ -2,16 +2,38 @@ package rlm
 
 import (
 	"context"
+	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/rand/recurse/internal/rlm/meta"
 	"github.com/rand/recurse/internal/rlm/repl"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"pgregory.net/rapid"
 )
 
+// newREPLWrapper returns a wrapper configured by cfg, with a started REPL
+// stopped when the test ends and a client answering with responses.
+func newREPLWrapper(t *testing.T, cfg WrapperConfig, responses ...string) *Wrapper {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	replMgr, err := repl.NewManager(repl.Options{})
+	require.NoError(t, err)
+	require.NoError(t, replMgr.Start(ctx))
+	t.Cleanup(func() { replMgr.Stop() })
+
+	w := NewWrapper(&Service{}, cfg)
+	w.SetREPLManager(replMgr)
+	w.SetLLMClient(&wrapperMockLLMClient{responses: responses})
+	return w
+}
+
 // TestExtractPythonCode tests the Python code extraction function.
 func TestExtractPythonCode(t *testing.T) {
 	tests := []struct {
@@ -144,10 +166,66 @@ func TestLooksLikeFinalAnswer(t *testing.T) {
 func TestDefaultRLMConfig(t *testing.T) {
 	cfg := DefaultRLMConfig()
 	assert.Equal(t, 10, cfg.MaxIterations)
-	assert.Equal(t, 4096, cfg.MaxTokensPerCall)
+	assert.Zero(t, cfg.MinIterations, "early FINAL is accepted unless configured")
+	assert.Equal(t, 8192, cfg.MaxTokensPerCall)
+	assert.Equal(t, 200000, cfg.ContextWindow)
+	assert.Equal(t, 32000, cfg.HistoryTokenBudget)
+	assert.Equal(t, 2, cfg.RecentTurns)
 	assert.Equal(t, 5*time.Minute, cfg.Timeout)
 }
 
+func TestRLMConfig_MaxTokensFor(t *testing.T) {
+	cfg := DefaultRLMConfig()
+
+	// Short-answer task types get a smaller budget than synthesis.
+	computational := cfg.maxTokensFor(TaskTypeComputational, 1000)
+	retrieval := cfg.maxTokensFor(TaskTypeRetrieval, 1000)
+	analytical := cfg.maxTokensFor(TaskTypeAnalytical, 1000)
+	assert.Less(t, computational, analytical)
+	assert.Less(t, retrieval, analytical)
+	assert.Less(t, computational, cfg.maxTokensFor(TaskTypeUnknown, 1000))
+	assert.Equal(t, cfg.maxTokensFor(TaskTypeUnknown, 1000), cfg.maxTokensFor("other", 1000))
+
+	// The explicit setting caps every task type.
+	cfg.MaxTokensPerCall = 3000
+	assert.Equal(t, 3000, cfg.maxTokensFor(TaskTypeAnalytical, 1000))
+	assert.Equal(t, computational, cfg.maxTokensFor(TaskTypeComputational, 1000))
+
+	// Without one, only the adaptive limit applies.
+	cfg.MaxTokensPerCall = 0
+	assert.Equal(t, analytical, cfg.maxTokensFor(TaskTypeAnalytical, 1000))
+}
+
+func TestRLMConfig_MaxTokensForNeverExceedsWindow(t *testing.T) {
+	cfg := RLMConfig{ContextWindow: 10000}
+	for _, taskType := range []TaskType{TaskTypeComputational, TaskTypeRetrieval, TaskTypeAnalytical, TaskTypeTransformational, TaskTypeUnknown} {
+		for prompt := 0; prompt < 10000; prompt += 250 {
+			limit := cfg.maxTokensFor(taskType, prompt)
+			assert.LessOrEqual(t, limit, 10000-prompt, "%s with %d prompt tokens", taskType, prompt)
+			assert.Positive(t, limit)
+		}
+	}
+	assert.Equal(t, 500, cfg.maxTokensFor(TaskTypeAnalytical, 9500))
+
+	// A prompt that overflows the window still asks for a token.
+	assert.Equal(t, 1, cfg.maxTokensFor(TaskTypeAnalytical, 12000))
+}
+
+func TestRLMConfig_WithinLimits(t *testing.T) {
+	cfg := DefaultRLMConfig()
+	assert.Equal(t, cfg, cfg.withinLimits(meta.Capabilities{}), "unknown limits leave the config")
+
+	limited := cfg.withinLimits(meta.Capabilities{ContextWindow: 16000, MaxOutputTokens: 1024})
+	assert.Equal(t, 16000, limited.ContextWindow)
+	assert.Equal(t, 1024, limited.MaxTokensPerCall)
+
+	// Limits are only lowered, and apply to unset settings
+	assert.Equal(t, cfg, cfg.withinLimits(meta.Capabilities{ContextWindow: 1000000, MaxOutputTokens: 65536}))
+	unset := RLMConfig{}.withinLimits(meta.Capabilities{ContextWindow: 16000, MaxOutputTokens: 1024})
+	assert.Equal(t, 16000, unset.ContextWindow)
+	assert.Equal(t, 1024, unset.MaxTokensPerCall)
+}
+
 // TestDefaultWrapperConfig tests default wrapper configuration.
 func TestDefaultWrapperConfig(t *testing.T) {
 	cfg := DefaultWrapperConfig()
@@ -193,10 +271,18 @@ type wrapperMockLLMClient struct {
 	responses []string
 	callIndex int
 	calls     []string
+	maxTokens []int
+
+	// usage, if set, is reported for the calls it covers.
+	usage []meta.Usage
 }
 
 func (m *wrapperMockLLMClient) Complete(ctx context.Context, prompt string, maxTokens int) (string, error) {
+	if len(m.calls) < len(m.usage) {
+		meta.RecordUsage(ctx, m.usage[len(m.calls)])
+	}
 	m.calls = append(m.calls, prompt)
+	m.maxTokens = append(m.maxTokens, maxTokens)
 	if m.callIndex < len(m.responses) {
 		resp := m.responses[m.callIndex]
 		m.callIndex++
@@ -246,6 +332,41 @@ func TestExecuteRLM_SingleIteration(t *testing.T) {
 	assert.Empty(t, result.Error)
 }
 
+// TestExecuteRLM_AdaptiveMaxTokens tests that each call's limit follows the task type.
+func TestExecuteRLM_AdaptiveMaxTokens(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	replMgr, err := repl.NewManager(repl.Options{})
+	require.NoError(t, err)
+	require.NoError(t, replMgr.Start(ctx))
+	defer replMgr.Stop()
+
+	limits := make(map[TaskType]int)
+	for _, taskType := range []TaskType{TaskTypeComputational, TaskTypeAnalytical} {
+		mockClient := &wrapperMockLLMClient{
+			responses: []string{"```python\nFINAL('done')\n```"},
+		}
+		w := &Wrapper{replMgr: replMgr, client: mockClient}
+
+		prepared := &PreparedPrompt{
+			Mode:           ModeRLM,
+			SystemPrompt:   "You are an RLM assistant.",
+			FinalPrompt:    "Answer the question",
+			Classification: &Classification{Type: taskType},
+		}
+		cfg := DefaultRLMConfig()
+		cfg.MaxIterations = 1
+		_, err := w.ExecuteRLMWithConfig(ctx, prepared, cfg)
+		require.NoError(t, err)
+		require.Len(t, mockClient.maxTokens, 1)
+		limits[taskType] = mockClient.maxTokens[0]
+	}
+
+	assert.Equal(t, 2048, limits[TaskTypeComputational], "room for a code cell and its FINAL")
+	assert.Equal(t, 8192, limits[TaskTypeAnalytical])
+}
+
 // TestExecuteRLM_MultipleIterations tests execution with multiple rounds.
 func TestExecuteRLM_MultipleIterations(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
@@ -287,6 +408,106 @@ func TestExecuteRLM_MultipleIterations(t *testing.T) {
 	assert.Empty(t, result.Error)
 }
 
+// TestExecuteRLM_MinIterations tests that an analytical task's early FINAL
+// is sent back for verification before it is accepted.
+func TestExecuteRLM_MinIterations(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	replMgr, err := repl.NewManager(repl.Options{})
+	require.NoError(t, err)
+	require.NoError(t, replMgr.Start(ctx))
+	defer replMgr.Stop()
+
+	newPrepared := func(taskType TaskType) *PreparedPrompt {
+		return &PreparedPrompt{
+			Mode:           ModeRLM,
+			SystemPrompt:   "You are an RLM assistant.",
+			FinalPrompt:    "Why did the deploy fail?",
+			Classification: &Classification{Type: taskType},
+		}
+	}
+	cfg := RLMConfig{
+		MaxIterations:    5,
+		MinIterations:    2,
+		MaxTokensPerCall: 1024,
+		Timeout:          10 * time.Second,
+	}
+
+	t.Run("analytical verifies before accepting", func(t *testing.T) {
+		mockClient := &wrapperMockLLMClient{
+			responses: []string{
+				"```python\nFINAL('a guess')\n```",
+				"```python\nevidence = 'checked'\nFINAL('a verified answer')\n```",
+			},
+		}
+		w := &Wrapper{replMgr: replMgr, client: mockClient}
+
+		result, err := w.ExecuteRLMWithConfig(ctx, newPrepared(TaskTypeAnalytical), cfg)
+		require.NoError(t, err)
+		assert.Equal(t, 2, result.Iterations)
+		assert.Equal(t, "a verified answer", result.FinalOutput)
+		assert.Equal(t, 1, result.ProvisionalFinals)
+		assert.Equal(t, "FINAL() called", result.TerminationReason)
+
+		require.Len(t, mockClient.calls, 2)
+		assert.Contains(t, mockClient.calls[1], "You called FINAL() with:")
+		assert.Contains(t, mockClient.calls[1], "a guess")
+		assert.Contains(t, mockClient.calls[1], "verify it against the context")
+	})
+
+	t.Run("provisional answer stands without another FINAL", func(t *testing.T) {
+		mockClient := &wrapperMockLLMClient{
+			responses: []string{
+				"```python\nFINAL('a guess')\n```",
+				"```python\nprint('looked around')\n```",
+			},
+		}
+		w := &Wrapper{replMgr: replMgr, client: mockClient}
+
+		short := cfg
+		short.MaxIterations = 2
+		result, err := w.ExecuteRLMWithConfig(ctx, newPrepared(TaskTypeAnalytical), short)
+		require.NoError(t, err)
+		assert.Equal(t, 2, result.Iterations)
+		assert.Equal(t, "a guess", result.FinalOutput)
+		assert.Empty(t, result.Error)
+		assert.Nil(t, result.Resume)
+	})
+
+	t.Run("computational accepts immediately", func(t *testing.T) {
+		mockClient := &wrapperMockLLMClient{
+			responses: []string{"```python\nFINAL(str(6 * 7))\n```"},
+		}
+		w := &Wrapper{replMgr: replMgr, client: mockClient}
+
+		result, err := w.ExecuteRLMWithConfig(ctx, newPrepared(TaskTypeComputational), cfg)
+		require.NoError(t, err)
+		assert.Equal(t, 1, result.Iterations)
+		assert.Equal(t, "42", result.FinalOutput)
+		assert.Zero(t, result.ProvisionalFinals)
+	})
+}
+
+func TestRLMConfig_MinIterationsFor(t *testing.T) {
+	cfg := DefaultRLMConfig()
+	assert.Equal(t, 1, cfg.minIterationsFor(TaskTypeAnalytical), "no floor by default")
+
+	cfg.MinIterations = 2
+	assert.Equal(t, 2, cfg.minIterationsFor(TaskTypeAnalytical))
+	assert.Equal(t, 2, cfg.minIterationsFor(TaskTypeTransformational))
+	assert.Equal(t, 1, cfg.minIterationsFor(TaskTypeComputational))
+	assert.Equal(t, 1, cfg.minIterationsFor(TaskTypeRetrieval))
+	assert.Equal(t, 1, cfg.minIterationsFor(TaskTypeUnknown))
+
+	// Never past the iteration limit
+	cfg.MinIterations = 20
+	assert.Equal(t, cfg.MaxIterations, cfg.minIterationsFor(TaskTypeAnalytical))
+
+	cfg.MinIterations = 0
+	assert.Equal(t, 1, cfg.minIterationsFor(TaskTypeAnalytical))
+}
+
 // TestExecuteRLM_MaxIterationsReached tests iteration limit.
 func TestExecuteRLM_MaxIterationsReached(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
@@ -327,6 +548,113 @@ func TestExecuteRLM_MaxIterationsReached(t *testing.T) {
 	assert.Equal(t, 3, result.Iterations)
 	assert.Empty(t, result.FinalOutput)
 	assert.Contains(t, result.Error, "max iterations")
+	require.NotNil(t, result.Resume)
+	assert.Equal(t, 3, result.Resume.Iterations())
+}
+
+// TestContinueRLM tests resuming an execution that ran out of iterations.
+func TestContinueRLM(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	replMgr, err := repl.NewManager(repl.Options{})
+	require.NoError(t, err)
+	require.NoError(t, replMgr.Start(ctx))
+	defer replMgr.Stop()
+
+	mockClient := &wrapperMockLLMClient{
+		responses: []string{
+			// Exploration that runs out of iterations
+			"```python\nfound = [n * n for n in range(5)]\nprint(len(found))\n```",
+			"```python\ntotal = sum(found)\nprint(total)\n```",
+			// Continuation: uses the variables from the first run
+			"```python\nFINAL(f'total={total} from {len(found)} items')\n```",
+		},
+	}
+	w := &Wrapper{replMgr: replMgr, client: mockClient}
+
+	prepared := &PreparedPrompt{
+		Mode:         ModeRLM,
+		SystemPrompt: "You are an RLM assistant.",
+		FinalPrompt:  "Sum the squares",
+	}
+	first, err := w.ExecuteRLMWithConfig(ctx, prepared, RLMConfig{
+		MaxIterations:    2,
+		MaxTokensPerCall: 1024,
+		Timeout:          10 * time.Second,
+	})
+	require.NoError(t, err)
+	assert.ErrorIs(t, first.Err, ErrMaxIterations)
+	require.NotNil(t, first.Resume)
+
+	result, err := w.ContinueRLM(ctx, first.Resume, 3)
+	require.NoError(t, err)
+	assert.Equal(t, "total=30 from 5 items", result.FinalOutput)
+	assert.Empty(t, result.Error)
+	assert.Nil(t, result.Resume)
+	assert.Equal(t, 3, result.Iterations)
+	assert.Greater(t, result.TotalTokens, first.TotalTokens)
+
+	// The continuation saw the whole earlier conversation.
+	require.Len(t, mockClient.calls, 3)
+	assert.Contains(t, mockClient.calls[2], "total = sum(found)")
+	assert.Contains(t, mockClient.calls[2], "Sum the squares")
+
+	// A handle resumes once.
+	_, err = w.ContinueRLM(ctx, first.Resume, 1)
+	assert.Error(t, err)
+}
+
+// TestContinueRLM_RunsOutAgain tests that a continuation that runs out returns a new handle.
+func TestContinueRLM_RunsOutAgain(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	replMgr, err := repl.NewManager(repl.Options{})
+	require.NoError(t, err)
+	require.NoError(t, replMgr.Start(ctx))
+	defer replMgr.Stop()
+
+	mockClient := &wrapperMockLLMClient{
+		responses: []string{
+			"```python\nsteps = 1\n```",
+			"```python\nsteps += 1\n```",
+			"```python\nFINAL(str(steps + 1))\n```",
+		},
+	}
+	w := &Wrapper{replMgr: replMgr, client: mockClient}
+
+	prepared := &PreparedPrompt{Mode: ModeRLM, SystemPrompt: "sys", FinalPrompt: "Count steps"}
+	result, err := w.ExecuteRLMWithConfig(ctx, prepared, RLMConfig{MaxIterations: 1, Timeout: 10 * time.Second})
+	require.NoError(t, err)
+	require.NotNil(t, result.Resume)
+
+	result, err = w.ContinueRLM(ctx, result.Resume, 1)
+	require.NoError(t, err)
+	assert.ErrorIs(t, result.Err, ErrMaxIterations)
+	require.NotNil(t, result.Resume)
+	assert.Equal(t, 2, result.Resume.Iterations())
+
+	result, err = w.ContinueRLM(ctx, result.Resume, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "3", result.FinalOutput)
+}
+
+// TestContinueRLM_Invalid tests continuation argument checks.
+func TestContinueRLM_Invalid(t *testing.T) {
+	w := &Wrapper{client: &wrapperMockLLMClient{}}
+
+	_, err := w.ContinueRLM(context.Background(), nil, 1)
+	assert.Error(t, err)
+
+	_, err = w.ContinueRLM(context.Background(), &RLMResumeHandle{}, 0)
+	assert.Error(t, err)
+
+	// The REPL holding the state is gone.
+	replMgr, err := repl.NewManager(repl.Options{})
+	require.NoError(t, err)
+	_, err = w.ContinueRLM(context.Background(), &RLMResumeHandle{replMgr: replMgr}, 1)
+	assert.ErrorIs(t, err, ErrREPLUnavailable)
 }
 
 // TestFormatConversation tests conversation formatting.
@@ -349,6 +677,149 @@ func TestFormatConversation(t *testing.T) {
 	assert.True(t, strings.HasSuffix(result, "Assistant: "))
 }
 
+// historyConversation builds a conversation with n iteration exchanges of
+// roughly size characters each.
+func historyConversation(n, size int) []conversationMessage {
+	messages := []conversationMessage{
+		{Role: "system", Content: "SYSTEM PROMPT"},
+		{Role: "user", Content: "ORIGINAL REQUEST"},
+	}
+	for i := 1; i <= n; i++ {
+		messages = append(messages,
+			conversationMessage{Role: "assistant", Content: fmt.Sprintf("code %d: %s", i, strings.Repeat("x", size))},
+			conversationMessage{Role: "user", Content: fmt.Sprintf("feedback %d: %s", i, strings.Repeat("y", size))},
+		)
+	}
+	return messages
+}
+
+// TestTrimHistory tests that long conversations are cut to the budget.
+func TestTrimHistory(t *testing.T) {
+	w := &Wrapper{}
+	messages := historyConversation(20, 400)
+	require.Greater(t, estimateTokens(w.formatConversation(messages)), 2000)
+
+	trimmed, elided := trimHistory(messages, 1000, 3)
+	prompt := w.formatConversation(trimmed)
+
+	assert.LessOrEqual(t, estimateTokens(prompt), 1000)
+	assert.Positive(t, elided)
+	assert.Equal(t, len(messages)-2*elided+1, len(trimmed))
+
+	// The system prompt, request and latest turns survive; old turns do not.
+	assert.Equal(t, messages[:2], trimmed[:2])
+	assert.Contains(t, trimmed[2].Content, fmt.Sprintf("%d earlier iteration(s) omitted", elided))
+	assert.Equal(t, messages[len(messages)-6:], trimmed[len(trimmed)-6:])
+	assert.NotContains(t, prompt, "code 1:")
+	assert.Contains(t, prompt, "code 20:")
+	assert.Contains(t, prompt, "feedback 18:")
+
+	// The full conversation is left untouched.
+	assert.Len(t, messages, 42)
+}
+
+// TestTrimHistory_Bounds tests the cases where nothing or only part can be elided.
+func TestTrimHistory_Bounds(t *testing.T) {
+	messages := historyConversation(3, 40)
+
+	// Under budget or disabled: unchanged
+	trimmed, elided := trimHistory(messages, 10000, 2)
+	assert.Equal(t, messages, trimmed)
+	assert.Zero(t, elided)
+	trimmed, elided = trimHistory(messages, 0, 2)
+	assert.Equal(t, messages, trimmed)
+	assert.Zero(t, elided)
+
+	// Recent turns are kept even when they alone exceed the budget
+	messages = historyConversation(4, 4000)
+	trimmed, elided = trimHistory(messages, 100, 2)
+	assert.Equal(t, 2, elided)
+	assert.Equal(t, messages[len(messages)-4:], trimmed[3:])
+}
+
+// TestExecuteRLM_HistoryBudget tests that prompts stay bounded over many iterations.
+func TestExecuteRLM_HistoryBudget(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	replMgr, err := repl.NewManager(repl.Options{})
+	require.NoError(t, err)
+	require.NoError(t, replMgr.Start(ctx))
+	defer replMgr.Stop()
+
+	var responses []string
+	for i := 1; i <= 11; i++ {
+		responses = append(responses, fmt.Sprintf("```python\nstep%d = %d\nprint('step %d ' + 'z' * 1500)\n```", i, i, i))
+	}
+	responses = append(responses, "```python\nFINAL(str(step1 + step11))\n```")
+	mockClient := &wrapperMockLLMClient{responses: responses}
+	w := &Wrapper{replMgr: replMgr, client: mockClient}
+
+	prepared := &PreparedPrompt{
+		Mode:         ModeRLM,
+		SystemPrompt: "You are an RLM assistant.",
+		FinalPrompt:  "Walk through the steps",
+	}
+	cfg := DefaultRLMConfig()
+	cfg.MaxIterations = 12
+	cfg.HistoryTokenBudget = 2000
+	cfg.RecentTurns = 2
+	result, err := w.ExecuteRLMWithConfig(ctx, prepared, cfg)
+	require.NoError(t, err)
+	assert.Equal(t, "12", result.FinalOutput)
+
+	require.Len(t, mockClient.calls, 12)
+	for i, prompt := range mockClient.calls {
+		assert.LessOrEqual(t, estimateTokens(prompt), 2000, "call %d", i+1)
+		assert.Contains(t, prompt, "You are an RLM assistant.", "call %d", i+1)
+		assert.Contains(t, prompt, "Walk through the steps", "call %d", i+1)
+	}
+	last := mockClient.calls[11]
+	assert.Contains(t, last, "step11 = 11")
+	assert.Contains(t, last, "step10 = 10")
+	assert.NotContains(t, last, "step1 = 1\n")
+
+	assert.Positive(t, result.HistoryElidedTurns)
+	assert.Positive(t, result.HistoryElidedTokens)
+}
+
+func TestExecuteRLM_ProviderUsage(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	replMgr, err := repl.NewManager(repl.Options{})
+	require.NoError(t, err)
+	require.NoError(t, replMgr.Start(ctx))
+	defer replMgr.Stop()
+
+	second := "```python\nFINAL(str(x))\n```"
+	mockClient := &wrapperMockLLMClient{
+		responses: []string{"```python\nx = 6 * 7\n```", second},
+		// The second call reports nothing and is estimated
+		usage: []meta.Usage{{PromptTokens: 900, CompletionTokens: 35}},
+	}
+	w := &Wrapper{replMgr: replMgr, client: mockClient}
+
+	prepared := &PreparedPrompt{
+		Mode:         ModeRLM,
+		SystemPrompt: "You are an RLM assistant.",
+		FinalPrompt:  "Compute 6 * 7",
+	}
+	result, err := w.ExecuteRLMWithConfig(ctx, prepared, DefaultRLMConfig())
+	require.NoError(t, err)
+	assert.Equal(t, "42", result.FinalOutput)
+	require.Len(t, mockClient.calls, 2)
+
+	estimated := meta.Usage{
+		PromptTokens:     estimateTokens(mockClient.calls[1]),
+		CompletionTokens: estimateTokens(second),
+	}
+	assert.Equal(t, 900+estimated.PromptTokens, result.InputTokens)
+	assert.Equal(t, 35+estimated.CompletionTokens, result.OutputTokens)
+	assert.Equal(t, estimated.Total(), result.EstimatedTokens)
+	assert.Equal(t, result.InputTokens+result.OutputTokens, result.TotalTokens)
+}
+
 // TestBuildExecutionFeedback tests feedback generation.
 func TestBuildExecutionFeedback(t *testing.T) {
 	w := &Wrapper{}
@@ -361,8 +832,8 @@ func TestBuildExecutionFeedback(t *testing.T) {
 		feedback := w.buildExecutionFeedback(result)
 
 		assert.Contains(t, feedback, "Code executed")
-		assert.Contains(t, feedback, "Hello, world!")
-		assert.Contains(t, feedback, "42")
+		assert.Contains(t, feedback, "Printed output:\n```\nHello, world!")
+		assert.Contains(t, feedback, "Value of the last expression: 42")
 		assert.Contains(t, feedback, "FINAL(response)")
 	})
 
@@ -383,7 +854,19 @@ func TestBuildExecutionFeedback(t *testing.T) {
 		}
 		feedback := w.buildExecutionFeedback(result)
 
-		assert.NotContains(t, feedback, "Return value: None")
+		assert.NotContains(t, feedback, "None")
+		assert.Contains(t, feedback, "No output.")
+	})
+
+	t.Run("long output truncated outside the code block", func(t *testing.T) {
+		result := &repl.ExecuteResult{
+			Output: strings.Repeat("match found on this line\n", 500),
+		}
+		feedback := w.buildExecutionFeedback(result)
+
+		assert.Contains(t, feedback, "line\n```\n[output truncated: ")
+		assert.Contains(t, feedback, "/12500 chars shown")
+		assert.Contains(t, feedback, "FINAL(response)")
 	})
 }
 
@@ -571,6 +1054,36 @@ func TestPrepareContext_RLMMode(t *testing.T) {
 	assert.NotNil(t, prepared.LoadedContext)
 }
 
+// TestPrepareContext_RLMMode_RanksContexts tests that irrelevant contexts are not externalized.
+func TestPrepareContext_RLMMode_RanksContexts(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	replMgr, err := repl.NewManager(repl.Options{})
+	require.NoError(t, err)
+	require.NoError(t, replMgr.Start(ctx))
+	defer replMgr.Stop()
+
+	w := NewWrapper(&Service{}, WrapperConfig{
+		MinContextTokensForRLM: 100,
+		ContextRanking:         RankConfig{TopK: 1},
+	})
+	w.SetREPLManager(replMgr)
+
+	contexts := []ContextSource{
+		{Name: "recipes", Type: ContextTypeFile, Content: strings.Repeat("Whisk eggs with sugar. ", 100)},
+		{Name: "server", Type: ContextTypeFile, Content: strings.Repeat("func retryRequest() { backoff.Retry() } ", 100)},
+	}
+	prepared, err := w.PrepareContext(ctx, "Explain the retryRequest backoff logic", contexts)
+	require.NoError(t, err)
+	require.Equal(t, ModeRLM, prepared.Mode)
+	require.NotNil(t, prepared.LoadedContext)
+	assert.Contains(t, prepared.LoadedContext.Variables, "server")
+	assert.NotContains(t, prepared.LoadedContext.Variables, "recipes")
+	require.Len(t, prepared.LoadedContext.Dropped, 1)
+	assert.Equal(t, "recipes", prepared.LoadedContext.Dropped[0].Name)
+}
+
 // TestRLMExecutionResult_Fields tests result struct fields.
 func TestRLMExecutionResult_Fields(t *testing.T) {
 	result := RLMExecutionResult{
@@ -1037,9 +1550,194 @@ func TestPrepareContextWithOptions_SkipClassification(t *testing.T) {
 	})
 }
 
+// TestPrepareContextWithOptions_Compression tests that compression is measured
+// and the prepared size is the compressed one.
+func TestPrepareContextWithOptions_Compression(t *testing.T) {
+	ctx := context.Background()
+
+	cfg := DefaultWrapperConfig()
+	cfg.CompressionEnabled = true
+	cfg.CompressionThreshold = 1000
+	w := NewWrapper(&Service{}, cfg)
+
+	var sb strings.Builder
+	for i := 0; i < 400; i++ {
+		fmt.Fprintf(&sb, "Entry %d: the service restarted after a routine health check and resumed normal operation.\n", i)
+	}
+	contexts := []ContextSource{{Type: ContextTypeFile, Content: sb.String()}}
+
+	prepared, err := w.PrepareContextWithOptions(ctx, "Summarize the log", contexts, PrepareOptions{
+		ModeOverride: ModeOverrideDirect,
+	})
+	require.NoError(t, err)
+	require.NotNil(t, prepared.Compression)
+
+	c := prepared.Compression
+	assert.Equal(t, estimateTokens("Summarize the log")+estimateTokens(sb.String()), c.OriginalTokens)
+	assert.Less(t, c.Ratio(), 0.5, "repetitive context compresses significantly")
+	assert.Equal(t, c.OriginalTokens-c.CompressedTokens, c.SavedTokens())
+
+	// The prepared size is what is sent, not the original
+	assert.Less(t, prepared.TotalTokens, c.OriginalTokens)
+	assert.InDelta(t, c.CompressedTokens, estimateTokens(prepared.FinalPrompt), float64(c.CompressedTokens)/10)
+
+	// Contexts under the threshold are left alone
+	small := []ContextSource{{Type: ContextTypeFile, Content: "short note"}}
+	prepared, err = w.PrepareContextWithOptions(ctx, "Summarize the log", small, PrepareOptions{
+		ModeOverride: ModeOverrideDirect,
+	})
+	require.NoError(t, err)
+	assert.Nil(t, prepared.Compression)
+}
+
+// TestPrepareContext_FastPath tests that tiny context-free prompts skip classification.
+func TestPrepareContext_FastPath(t *testing.T) {
+	ctx := context.Background()
+	w := NewWrapper(&Service{}, DefaultWrapperConfig())
+	task := "How many times does 'x' appear?"
+
+	t.Run("tiny prompt skips classification", func(t *testing.T) {
+		prepared, err := w.PrepareContext(ctx, task, nil)
+		require.NoError(t, err)
+		assert.Equal(t, ModeDirecte, prepared.Mode)
+		assert.Equal(t, "fast-path direct", prepared.ModeReason)
+		assert.Nil(t, prepared.Classification)
+		require.NotNil(t, prepared.ModeInfo)
+		assert.Nil(t, prepared.ModeInfo.Classification)
+		assert.Equal(t, DefaultFastPathMaxTokens, prepared.ModeInfo.ContextInfo.ThresholdUsed)
+	})
+
+	t.Run("larger prompt is classified", func(t *testing.T) {
+		prepared, err := w.PrepareContext(ctx, task+strings.Repeat(" Count carefully.", 20), nil)
+		require.NoError(t, err)
+		assert.Equal(t, ModeDirecte, prepared.Mode)
+		assert.NotEqual(t, "fast-path direct", prepared.ModeReason)
+		assert.NotNil(t, prepared.Classification)
+	})
+
+	t.Run("contexts disable the fast path", func(t *testing.T) {
+		contexts := []ContextSource{{Type: ContextTypeFile, Content: "x"}}
+		prepared, err := w.PrepareContext(ctx, task, contexts)
+		require.NoError(t, err)
+		assert.NotEqual(t, "fast-path direct", prepared.ModeReason)
+		assert.NotNil(t, prepared.Classification)
+	})
+
+	t.Run("zero threshold disables the fast path", func(t *testing.T) {
+		cfg := DefaultWrapperConfig()
+		cfg.FastPathMaxTokens = 0
+		prepared, err := NewWrapper(&Service{}, cfg).PrepareContext(ctx, task, nil)
+		require.NoError(t, err)
+		assert.NotNil(t, prepared.Classification)
+	})
+}
+
 // TestModeOverrideConstants tests mode override constant values.
 func TestModeOverrideConstants(t *testing.T) {
 	assert.Equal(t, ModeOverride("auto"), ModeOverrideAuto)
 	assert.Equal(t, ModeOverride("rlm"), ModeOverrideRLM)
 	assert.Equal(t, ModeOverride("direct"), ModeOverrideDirect)
 }
+
+// changingFileClient rewrites a file during its first call, as an editor
+// might while the model is working.
+type changingFileClient struct {
+	wrapperMockLLMClient
+	path    string
+	content string
+}
+
+func (c *changingFileClient) Complete(ctx context.Context, prompt string, maxTokens int) (string, error) {
+	if len(c.calls) == 0 {
+		if err := os.WriteFile(c.path, []byte(c.content), 0o644); err != nil {
+			return "", err
+		}
+		later := time.Now().Add(time.Minute)
+		if err := os.Chtimes(c.path, later, later); err != nil {
+			return "", err
+		}
+	}
+	return c.wrapperMockLLMClient.Complete(ctx, prompt, maxTokens)
+}
+
+// TestExecuteRLM_RefreshesChangedContext tests that a file changed on disk
+// mid-execution is reloaded and the model told.
+func TestExecuteRLM_RefreshesChangedContext(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	replMgr, err := repl.NewManager(repl.Options{})
+	require.NoError(t, err)
+	require.NoError(t, replMgr.Start(ctx))
+	defer replMgr.Stop()
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	original := "region: us-east-1\n"
+	require.NoError(t, os.WriteFile(path, []byte(original), 0o644))
+
+	cfg := DefaultWrapperConfig()
+	cfg.ContextRefresh = RefreshConfig{Interval: time.Nanosecond}
+	w := NewWrapper(&Service{}, cfg)
+	w.SetREPLManager(replMgr)
+
+	prepared, err := w.PrepareContextWithOptions(ctx, "Which region is configured?",
+		[]ContextSource{{Name: "config", Type: ContextTypeFile, Content: original, Metadata: map[string]any{"source": path}}},
+		PrepareOptions{ModeOverride: ModeOverrideRLM})
+	require.NoError(t, err)
+
+	updated := "region: eu-west-1\n"
+	client := &changingFileClient{
+		wrapperMockLLMClient: wrapperMockLLMClient{responses: []string{
+			"```python\nprint(config)\n```",
+			"```python\nFINAL(config.strip())\n```",
+		}},
+		path:    path,
+		content: updated,
+	}
+	w.client = client
+
+	result, err := w.ExecuteRLMWithConfig(ctx, prepared, RLMConfig{
+		MaxIterations:    5,
+		MaxTokensPerCall: 1024,
+		Timeout:          10 * time.Second,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "region: eu-west-1", result.FinalOutput)
+
+	require.Len(t, result.ContextRefreshes, 1)
+	assert.Equal(t, "config", result.ContextRefreshes[0].Name)
+	assert.False(t, prepared.LoadedContext.Variables["config"].RefreshedAt.IsZero())
+
+	require.Len(t, client.calls, 2)
+	assert.NotContains(t, client.calls[0], "Context refreshed")
+	assert.Contains(t, client.calls[1], "Context refreshed")
+	assert.Contains(t, client.calls[1], "`config`: reloaded from "+path)
+	assert.Equal(t, 2, strings.Count(client.calls[1], "User: "), "the notice joins the pending user turn")
+}
+
+// TestPrepareContext_DepthFromContext tests that preparation under an
+// orchestration compresses at the orchestration's depth.
+func TestPrepareContext_DepthFromContext(t *testing.T) {
+	cfg := DefaultWrapperConfig()
+	cfg.CompressionEnabled = true
+	cfg.CompressionThreshold = 1000
+	w := NewWrapper(&Service{}, cfg)
+
+	// Over the threshold at depth 3, whose default factor is about 0.42,
+	// but under it at depth 0
+	var sb strings.Builder
+	for i := 0; sb.Len() < 3200; i++ {
+		fmt.Fprintf(&sb, "Entry %d: the service restarted after a routine health check and resumed normal operation.\n", i)
+	}
+	contexts := []ContextSource{{Type: ContextTypeFile, Content: sb.String()}}
+	opts := PrepareOptions{ModeOverride: ModeOverrideDirect}
+
+	prepared, err := w.PrepareContextWithOptions(context.Background(), "Summarize the log", contexts, opts)
+	require.NoError(t, err)
+	assert.Nil(t, prepared.Compression)
+
+	ctx := WithRecursionDepth(context.Background(), 3)
+	prepared, err = w.PrepareContextWithOptions(ctx, "Summarize the log", contexts, opts)
+	require.NoError(t, err)
+	assert.NotNil(t, prepared.Compression, "compressed at the orchestration's depth")
+}